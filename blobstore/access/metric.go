@@ -40,15 +40,30 @@ var downloadMetric = prometheus.NewCounterVec(
 	[]string{"cluster", "way", "reason"},
 )
 
+var asyncWriteMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "blobstore",
+		Subsystem: "access",
+		Name:      "async_write",
+		Help:      "shards completed in background after put returned",
+	},
+	[]string{"cluster", "result"},
+)
+
 func init() {
 	prometheus.MustRegister(unhealthMetric)
 	prometheus.MustRegister(downloadMetric)
+	prometheus.MustRegister(asyncWriteMetric)
 }
 
 func reportUnhealth(cid proto.ClusterID, action, module, host, reason string) {
 	unhealthMetric.WithLabelValues(cid.ToString(), action, module, host, reason).Inc()
 }
 
+func reportAsyncWrite(cid proto.ClusterID, result string) {
+	asyncWriteMetric.WithLabelValues(cid.ToString(), result).Inc()
+}
+
 func reportDownload(cid proto.ClusterID, way, reason string) {
 	downloadMetric.WithLabelValues(cid.ToString(), way, reason).Inc()
 }
@@ -128,6 +128,12 @@ type StreamConfig struct {
 	// CodeModesPutQuorums
 	// just for one AZ is down, cant write quorum in all AZs
 	CodeModesPutQuorums map[codemode.CodeMode]int `json:"code_mode_put_quorums"`
+	// CodeModesParityQuorums
+	// put returns once all data shards and the number of parity shards are written,
+	// the remaining parity shards are completed in background and repaired if failed.
+	// if less shards than the put quorum are written, the remaining shards are enqueued
+	// to repair before put returns
+	CodeModesParityQuorums map[codemode.CodeMode]int `json:"code_mode_parity_quorums"`
 
 	ClusterConfig  controller.ClusterConfig `json:"cluster_config"`
 	BlobnodeConfig blobnode.Config          `json:"blobnode_config"`
@@ -192,6 +198,12 @@ func confCheck(cfg *StreamConfig) {
 			log.Fatalf("invalid put quorum(%d) in codemode(%d): %+v", quorum, mode, tactic)
 		}
 	}
+	for mode, quorum := range cfg.CodeModesParityQuorums {
+		tactic := mode.Tactic()
+		if quorum < 1 || quorum > tactic.M {
+			log.Fatalf("invalid parity quorum(%d) in codemode(%d): %+v", quorum, mode, tactic)
+		}
+	}
 
 	defaulter.Equal(&cfg.MaxBlobSize, defaultMaxBlobSize)
 	defaulter.LessOrEqual(&cfg.DiskPunishIntervalS, defaultDiskPunishIntervalS)
@@ -318,7 +330,7 @@ func (h *Handler) sendRepairMsgBg(ctx context.Context, blob blobIdent, badIdxes
 	}()
 }
 
-func (h *Handler) sendRepairMsg(ctx context.Context, blob blobIdent, badIdxes []uint8) {
+func (h *Handler) sendRepairMsg(ctx context.Context, blob blobIdent, badIdxes []uint8) (err error) {
 	span := trace.SpanFromContextSafe(ctx)
 	span.Infof("to repair %s indexes(%+v)", blob.String(), badIdxes)
 
@@ -338,7 +350,7 @@ func (h *Handler) sendRepairMsg(ctx context.Context, blob blobIdent, badIdxes []
 		Reason:    "access-repair",
 	}
 
-	if err := retry.Timed(3, 200).On(func() error {
+	if err = retry.Timed(3, 200).On(func() error {
 		host, err := serviceController.GetServiceHost(ctx, serviceProxy)
 		if err != nil {
 			span.Warn(err)
@@ -361,6 +373,7 @@ func (h *Handler) sendRepairMsg(ctx context.Context, blob blobIdent, badIdxes []
 	}
 
 	span.Infof("send repair message(%+v)", repairArgs)
+	return
 }
 
 func (h *Handler) clearGarbage(ctx context.Context, location *access.Location) error {
@@ -220,12 +220,26 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 	if num, ok := h.CodeModesPutQuorums[volume.CodeMode]; ok && num <= tactic.N+tactic.M {
 		putQuorum = uint32(num)
 	}
+	// parityQuorum is enabled if configured, all data shards and parityQuorum parity shards
+	// must be written, then the slow parity shards are completed in background.
+	parityQuorum := uint32(0)
+	if num, ok := h.CodeModesParityQuorums[volume.CodeMode]; ok && num <= tactic.M {
+		parityQuorum = uint32(num)
+	}
 
 	// writtenNum ONLY apply on data and partiy shards
 	// TODO: count N and M in each AZ,
 	//    decision ec data is recoverable or not.
 	maxWrittenIndex := tactic.N + tactic.M
 	writtenNum := uint32(0)
+	dataWrittenNum, parityWrittenNum := uint32(0), uint32(0)
+	hasQuorum := func() bool {
+		if parityQuorum > 0 {
+			return atomic.LoadUint32(&dataWrittenNum) == uint32(tactic.N) &&
+				atomic.LoadUint32(&parityWrittenNum) >= parityQuorum
+		}
+		return atomic.LoadUint32(&writtenNum) >= putQuorum
+	}
 
 	wg.Add(len(volume.Units))
 	for i, unitI := range volume.Units {
@@ -245,6 +259,7 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 				Bid:    bid,
 				Size:   int64(len(shards[index])),
 				Type:   blobnode.NormalIO,
+				Epoch:  unit.WriteEpoch,
 			}
 
 			crcDisabled := h.ShardCrcDisabled
@@ -293,7 +308,7 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 				code := rpc.DetectStatusCode(err)
 				switch code {
 				case errcode.CodeDiskBroken, errcode.CodeDiskNotFound,
-					errcode.CodeChunkNoSpace, errcode.CodeVUIDReadonly:
+					errcode.CodeChunkNoSpace, errcode.CodeVUIDReadonly, errcode.CodeChunkFenced:
 					h.discardVidChan <- discardVid{
 						cid:      clusterID,
 						codeMode: volume.CodeMode,
@@ -316,6 +331,13 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 					span.Warnf("punish volume:%d cos:blobnode/%d", vid, code)
 					return true, err
 
+				// chunk fenced means the volume has been allocated to others or the unit reallocated,
+				// the lease of this holder is stale, we should punish this volume
+				case errcode.CodeChunkFenced:
+					h.punishVolume(ctx, clusterID, vid, host, "Fenced")
+					span.Warnf("punish volume:%d cos:blobnode/%d", vid, code)
+					return true, err
+
 				// vuid not found means the reflection between vuid and diskID has change, we should refresh the volume
 				// disk not found means disk has been repaired or offline
 				case errcode.CodeDiskNotFound, errcode.CodeVuidNotFound:
@@ -330,6 +352,7 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 						unit = newUnit
 						args.DiskID = newUnit.DiskID
 						args.Vuid = newUnit.Vuid
+						args.Epoch = newUnit.WriteEpoch
 
 						needRetry = true
 						return true, err
@@ -343,7 +366,7 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 				}
 
 				// in timeout case and writtenNum is not satisfied with putQuorum, then should retry
-				if errorTimeout(err) && atomic.LoadUint32(&writtenNum) < putQuorum {
+				if errorTimeout(err) && !hasQuorum() {
 					h.punishDiskWith(ctx, clusterID, diskID, host, "Timeout")
 					span.Warn("connect timeout, need to punish threshold disk", diskID, host)
 					return false, err
@@ -362,6 +385,11 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 				return
 			}
 
+			if index < tactic.N {
+				atomic.AddUint32(&dataWrittenNum, 1)
+			} else if index < maxWrittenIndex {
+				atomic.AddUint32(&parityWrittenNum, 1)
+			}
 			if index < maxWrittenIndex {
 				atomic.AddUint32(&writtenNum, 1)
 			}
@@ -370,22 +398,53 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 	}
 
 	received := make(map[int]shardPutStatus, len(volume.Units))
-	for len(received) < len(volume.Units) && atomic.LoadUint32(&writtenNum) < putQuorum {
+	for len(received) < len(volume.Units) && !hasQuorum() {
 		st := <-statusCh
 		received[st.index] = st
 	}
 
+	// the parity quorum acknowledges less shards than putQuorum, the blob is under replicated until
+	// the background writes complete. the shards not written yet are enqueued to repair before return
+	// in case the background writes never complete, or waits for all shards if failed to enqueue.
+	underReplicated := false
+	if parityQuorum > 0 && hasQuorum() && atomic.LoadUint32(&writtenNum) < putQuorum {
+		pending := make([]uint8, 0, len(volume.Units)-len(received))
+		for idx := range volume.Units {
+			if st, ok := received[idx]; !ok || !st.status {
+				pending = append(pending, uint8(idx))
+			}
+		}
+		if errRepair := h.sendRepairMsg(ctx, blob, pending); errRepair == nil {
+			reportAsyncWrite(clusterID, "under_replicated")
+			underReplicated = true
+		} else {
+			for len(received) < len(volume.Units) {
+				st := <-statusCh
+				received[st.index] = st
+			}
+		}
+	}
+
 	writeDone := make(chan struct{}, 1)
 	// write unaccomplished shard to repair queue
 	go func(writeDone <-chan struct{}) {
+		background := make([]shardPutStatus, 0, len(volume.Units)-len(received))
 		for len(received) < len(volume.Units) {
 			st := <-statusCh
 			received[st.index] = st
+			background = append(background, st)
 		}
 
 		if _, ok := <-writeDone; !ok {
 			return
 		}
+		for _, st := range background {
+			if st.status {
+				reportAsyncWrite(clusterID, "success")
+			} else {
+				reportAsyncWrite(clusterID, "failed")
+			}
+		}
 
 		badIdxes := make([]uint8, 0)
 		for idx := range volume.Units {
@@ -394,13 +453,18 @@ func (h *Handler) writeToBlobnodes(ctx context.Context,
 			}
 			badIdxes = append(badIdxes, uint8(idx))
 		}
-		if len(badIdxes) > 0 {
+		// the shards failed in background are enqueued already if under replicated
+		if len(badIdxes) > 0 && !underReplicated {
 			h.sendRepairMsgBg(ctx, blob, badIdxes)
 		}
 	}(writeDone)
 
 	// return if had quorum successful shards
-	if atomic.LoadUint32(&writtenNum) >= putQuorum {
+	if hasQuorum() && (underReplicated || atomic.LoadUint32(&writtenNum) >= putQuorum) {
+		if parityQuorum > 0 {
+			span.Debugf("parity quorum write (data:%d parity:%d under_replicated:%v) of %s",
+				tactic.N, parityQuorum, underReplicated, blob.String())
+		}
 		writeDone <- struct{}{}
 		return
 	}
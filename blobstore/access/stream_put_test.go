@@ -16,18 +16,23 @@ package access
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"hash/crc32"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/proxy"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
@@ -313,6 +318,108 @@ func TestAccessStreamPutQuorum(t *testing.T) {
 	}
 }
 
+// repairRecordClient records the shard repair messages sent to proxy
+type repairRecordClient struct {
+	proxy.Client
+
+	mu      sync.Mutex
+	repairs []*proxy.ShardRepairArgs
+	err     error
+}
+
+func (c *repairRecordClient) SendShardRepairMsg(ctx context.Context, host string, args *proxy.ShardRepairArgs) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.repairs = append(c.repairs, args)
+	}
+	return c.err
+}
+
+func (c *repairRecordClient) getRepairs() []*proxy.ShardRepairArgs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.repairs
+}
+
+func (c *repairRecordClient) setErr(err error) {
+	c.mu.Lock()
+	c.err, c.repairs = err, nil
+	c.mu.Unlock()
+}
+
+func TestAccessStreamPutParityQuorum(t *testing.T) {
+	ctx := ctxWithName("TestAccessStreamPutParityQuorum")
+	dataShards.clean()
+	vuidController.Unbreak(1005)
+	// indexes 7 and 8 are parity shards in different az
+	vuidController.Block(1008)
+	vuidController.Block(1009)
+	defer func() {
+		streamer.CodeModesPutQuorums = nil
+		streamer.CodeModesParityQuorums = nil
+		vuidController.Unblock(1008)
+		vuidController.Unblock(1009)
+		vuidController.Break(1005)
+		dataShards.clean()
+	}()
+
+	size := 1 << 22
+	buff := make([]byte, size)
+	rand.Read(buff)
+	_, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.Error(t, err)
+
+	// the blob acknowledged less shards than the put quorum is enqueued to repair before put returns
+	repairCli := &repairRecordClient{Client: streamer.proxyClient}
+	originProxyClient := streamer.proxyClient
+	streamer.proxyClient = repairCli
+	defer func() { streamer.proxyClient = originProxyClient }()
+
+	streamer.CodeModesParityQuorums = map[codemode.CodeMode]int{codemode.EC6P6: 4}
+	startTime := time.Now()
+	_, err = streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.NoError(t, err)
+	duration := time.Since(startTime)
+	require.GreaterOrEqual(t, time.Second/2, duration, "greater duration: ", duration)
+	require.Len(t, repairCli.getRepairs(), 1)
+	require.Len(t, repairCli.getRepairs()[0].BadIdxes, 2)
+	for _, idx := range repairCli.getRepairs()[0].BadIdxes {
+		require.GreaterOrEqual(t, idx, uint8(6))
+	}
+	// the shards failed in background are not enqueued again
+	time.Sleep(time.Second)
+	require.Len(t, repairCli.getRepairs(), 1)
+
+	// waits for all shards if failed to enqueue the repair
+	repairCli.setErr(errors.New("proxy unavailable"))
+	_, err = streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.Error(t, err)
+	repairCli.setErr(nil)
+
+	// all data shards and 4 parity shards reach the put quorum
+	streamer.CodeModesPutQuorums = map[codemode.CodeMode]int{codemode.EC6P6: 10}
+	startTime = time.Now()
+	loc, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.NoError(t, err)
+	duration = time.Since(startTime)
+	require.GreaterOrEqual(t, time.Second/2, duration, "greater duration: ", duration)
+
+	transfer, err := streamer.Get(ctx(), bytes.NewBuffer(nil), *loc, uint64(size), 0)
+	require.NoError(t, err)
+	require.NoError(t, transfer())
+
+	// missing data shard is not tolerated by parity quorum, even if the put quorum is reached
+	vuidController.Unblock(1009)
+	vuidController.Block(1001)
+	defer vuidController.Unblock(1001)
+	_, err = streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.Error(t, err)
+	streamer.CodeModesParityQuorums = nil
+	_, err = streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.NoError(t, err)
+}
+
 func BenchmarkAccessStreamPut(b *testing.B) {
 	ctx := ctxWithName("BenchmarkAccessStreamPut")()
 	vuidController.Unbreak(1005)
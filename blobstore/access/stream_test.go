@@ -49,6 +49,9 @@ func TestAccessStreamConfig(t *testing.T) {
 	require.Equal(t, idcOther, cfg.IDC)
 	require.Equal(t, map[int]int{1024: 1}, cfg.MemPoolSizeClasses)
 	require.Equal(t, defaultDiskPunishIntervalS, cfg.DiskPunishIntervalS)
+
+	cfg.CodeModesParityQuorums = map[codemode.CodeMode]int{codemode.EC15P12: 1, codemode.EC6P6: 5}
+	confCheck(&cfg)
 }
 
 func TestAccessStreamNew(t *testing.T) {
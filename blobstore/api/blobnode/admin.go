@@ -0,0 +1,96 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/resourcepool"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+)
+
+// adminHost is the host of the urls of the admin socket, it is not resolved.
+const adminHost = "http://blobnode.admin"
+
+// AdminTasks is the migrate tasks running on the blobnode.
+type AdminTasks struct {
+	Tasks   map[proto.TaskType][]string `json:"tasks"`
+	Running map[proto.TaskType]int      `json:"running"`
+	Stats   WorkerStats                 `json:"stats"`
+}
+
+type AdminStopTaskArgs struct {
+	TaskID string `json:"task_id"`
+}
+
+// AdminQos is the data qos of the disks of the blobnode.
+type AdminQos struct {
+	MaxWaitCount   int   `json:"max_wait_count"`
+	NormalMBPS     int64 `json:"normal_mbps"`
+	BackgroundMBPS int64 `json:"background_mbps"`
+}
+
+// AdminAPI is the admin operations served on the local unix socket of the blobnode.
+type AdminAPI interface {
+	ListTasks(ctx context.Context) (AdminTasks, error)
+	StopTask(ctx context.Context, args *AdminStopTaskArgs) error
+	DiskStat(ctx context.Context) ([]*DiskInfo, error)
+	GetQos(ctx context.Context) (AdminQos, error)
+	SetQos(ctx context.Context, args *ConfigReloadArgs) error
+	BufPoolStat(ctx context.Context) (resourcepool.Status, error)
+}
+
+type adminClient struct {
+	rpc.Client
+}
+
+// NewAdminClient returns the client of the admin socket of the blobnode.
+func NewAdminClient(socket string) AdminAPI {
+	return &adminClient{rpc.NewClient(&rpc.Config{
+		Tc: rpc.TransportConfig{Network: rpc.NetworkConfig{UnixSocket: socket}},
+	})}
+}
+
+func (c *adminClient) ListTasks(ctx context.Context) (ret AdminTasks, err error) {
+	err = c.GetWith(ctx, adminHost+"/tasks", &ret)
+	return
+}
+
+func (c *adminClient) StopTask(ctx context.Context, args *AdminStopTaskArgs) error {
+	return c.PostWith(ctx, fmt.Sprintf("%v/task/stop/%v", adminHost, url.PathEscape(args.TaskID)), nil, rpc.NoneBody)
+}
+
+func (c *adminClient) DiskStat(ctx context.Context) (dis []*DiskInfo, err error) {
+	dis = make([]*DiskInfo, 0)
+	err = c.GetWith(ctx, adminHost+"/disks", &dis)
+	return
+}
+
+func (c *adminClient) GetQos(ctx context.Context) (ret AdminQos, err error) {
+	err = c.GetWith(ctx, adminHost+"/qos", &ret)
+	return
+}
+
+func (c *adminClient) SetQos(ctx context.Context, args *ConfigReloadArgs) error {
+	return c.PostWith(ctx, fmt.Sprintf("%v/qos?key=%v&value=%v", adminHost, url.QueryEscape(args.Key), url.QueryEscape(args.Value)), nil, rpc.NoneBody)
+}
+
+func (c *adminClient) BufPoolStat(ctx context.Context) (ret resourcepool.Status, err error) {
+	err = c.GetWith(ctx, adminHost+"/bufpool", &ret)
+	return
+}
@@ -211,6 +211,14 @@ type ChangeChunkStatusArgs struct {
 	Force  bool         `json:"force,omitempty"`
 }
 
+// FenceChunkArgs raises the fence epoch of the chunk, the following writes
+// carrying a lower volume epoch are rejected with ErrChunkFenced.
+type FenceChunkArgs struct {
+	DiskID proto.DiskID `json:"diskid"`
+	Vuid   proto.Vuid   `json:"vuid"`
+	Epoch  uint32       `json:"epoch"`
+}
+
 type ChunkInspectArgs struct {
 	DiskID proto.DiskID `json:"diskid"`
 	Vuid   proto.Vuid   `json:"vuid"`
@@ -250,6 +258,17 @@ func (c *client) SetChunkReadwrite(ctx context.Context, host string, args *Chang
 	return
 }
 
+func (c *client) FenceChunk(ctx context.Context, host string, args *FenceChunkArgs) (err error) {
+	if !IsValidDiskID(args.DiskID) {
+		err = bloberr.ErrInvalidDiskId
+		return
+	}
+
+	urlStr := fmt.Sprintf("%v/chunk/fence/diskid/%v/vuid/%v/epoch/%v", host, args.DiskID, args.Vuid, args.Epoch)
+	err = c.PostWith(ctx, urlStr, nil, rpc.NoneBody)
+	return
+}
+
 type ListChunkArgs struct {
 	DiskID proto.DiskID `json:"diskid"`
 }
@@ -92,6 +92,7 @@ type StorageAPI interface {
 	ReleaseChunk(ctx context.Context, host string, args *ChangeChunkStatusArgs) (err error)
 	SetChunkReadonly(ctx context.Context, host string, args *ChangeChunkStatusArgs) (err error)
 	SetChunkReadwrite(ctx context.Context, host string, args *ChangeChunkStatusArgs) (err error)
+	FenceChunk(ctx context.Context, host string, args *FenceChunkArgs) (err error)
 	ListChunks(ctx context.Context, host string, args *ListChunkArgs) (cis []*ChunkInfo, err error)
 
 	// shard
@@ -99,6 +100,7 @@ type StorageAPI interface {
 	RangeGetShard(ctx context.Context, host string, args *RangeGetShardArgs) (body io.ReadCloser, shardCrc uint32, err error)
 	PutShard(ctx context.Context, host string, args *PutShardArgs) (crc uint32, err error)
 	StatShard(ctx context.Context, host string, args *StatShardArgs) (si *ShardInfo, err error)
+	StatShards(ctx context.Context, host string, args *StatShardsArgs) (stats []*ShardStat, err error)
 	MarkDeleteShard(ctx context.Context, host string, args *DeleteShardArgs) (err error)
 	DeleteShard(ctx context.Context, host string, args *DeleteShardArgs) (err error)
 	ListShards(ctx context.Context, host string, args *ListShardsArgs) (sis []*ShardInfo, next proto.BlobID, err error)
@@ -53,6 +53,7 @@ type ChunkInfo struct {
 	Size       uint64       `json:"size"`   // Chunk File Size (logic size)
 	Status     ChunkStatus  `json:"status"` // normal、readOnly
 	Compacting bool         `json:"compacting"`
+	Epoch      uint32       `json:"epoch,omitempty"` // fence epoch, writes with lower volume epoch are rejected
 }
 
 type ShardInfo struct {
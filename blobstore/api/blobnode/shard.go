@@ -44,12 +44,16 @@ const (
 	ShardDataInline = 0x80 // 1000 0000
 )
 
+// FeatureStatShards the blobnode stats the shards of many bids in one request
+const FeatureStatShards = "blobnode_stat_shards"
+
 type PutShardArgs struct {
 	DiskID proto.DiskID `json:"diskid"`
 	Vuid   proto.Vuid   `json:"vuid"`
 	Bid    proto.BlobID `json:"bid"`
 	Size   int64        `json:"size"`
 	Type   IOType       `json:"iotype,omitempty"`
+	Epoch  uint32       `json:"epoch,omitempty"` // volume epoch held by the writer
 	Body   io.Reader    `json:"-"`
 }
 
@@ -77,6 +81,9 @@ func (c *client) PutShard(ctx context.Context, host string, args *PutShardArgs)
 	}
 	urlStr := fmt.Sprintf("%v/shard/put/diskid/%v/vuid/%v/bid/%v/size/%v?iotype=%d",
 		host, args.DiskID, args.Vuid, args.Bid, args.Size, args.Type)
+	if args.Epoch > 0 {
+		urlStr += fmt.Sprintf("&epoch=%d", args.Epoch)
+	}
 	req, err := http.NewRequest(http.MethodPost, urlStr, args.Body)
 	if err != nil {
 		return
@@ -240,6 +247,49 @@ func (c *client) StatShard(ctx context.Context, host string, args *StatShardArgs
 	return
 }
 
+// StatShardsArgs stats the shards of many bids in one request, the data of the
+// normal shards are read to verify the crcs if Verify is set.
+type StatShardsArgs struct {
+	DiskID proto.DiskID   `json:"diskid"`
+	Vuid   proto.Vuid     `json:"vuid"`
+	Bids   []proto.BlobID `json:"bids"`
+	Verify bool           `json:"verify"`
+}
+
+// ShardStat is the stat of one shard in StatShardsRet. Code is the error code of stating
+// or verifying the shard, such as CodeBidNotFound and CodeShardCrcMismatch, and the
+// shard info is valid if Code is 0 or CodeShardCrcMismatch.
+type ShardStat struct {
+	ShardInfo
+	Code int `json:"code,omitempty"`
+}
+
+type StatShardsRet struct {
+	Shards []*ShardStat `json:"shards"`
+}
+
+func (c *client) StatShards(ctx context.Context, host string, args *StatShardsArgs) (stats []*ShardStat, err error) {
+	if !IsValidDiskID(args.DiskID) {
+		err = bloberr.ErrInvalidDiskId
+		return
+	}
+
+	// the legacy blobnode during rolling upgrade has no such endpoint
+	supported, err := rpc.PeerSupportsWith(ctx, c.Client, host, FeatureStatShards)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, rpc.ErrPeerUnsupported
+	}
+
+	ret := StatShardsRet{}
+	if err = c.PostWith(ctx, host+"/shard/stats", &ret, args); err != nil {
+		return nil, err
+	}
+	return ret.Shards, nil
+}
+
 type ListShardsArgs struct {
 	DiskID   proto.DiskID `json:"diskid"`
 	Vuid     proto.Vuid   `json:"vuid" `
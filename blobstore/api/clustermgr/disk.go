@@ -176,6 +176,12 @@ func (c *Client) DroppedDisk(ctx context.Context, id proto.DiskID) (err error) {
 	return
 }
 
+// CancelDropDisk cancel dropping disk, and the disk is set writable again
+func (c *Client) CancelDropDisk(ctx context.Context, id proto.DiskID) (err error) {
+	err = c.PostWith(ctx, "/disk/canceldrop", nil, &DiskInfoArgs{DiskID: id})
+	return
+}
+
 func (c *Client) ListDroppingDisk(ctx context.Context) (ret []*blobnode.DiskInfo, err error) {
 	result := &ListDiskRet{}
 	err = c.GetWith(ctx, "/disk/droppinglist", result)
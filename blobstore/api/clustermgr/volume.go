@@ -38,6 +38,9 @@ type Unit struct {
 	Vuid   proto.Vuid   `json:"vuid"`
 	DiskID proto.DiskID `json:"disk_id"`
 	Host   string       `json:"host"`
+	// WriteEpoch is carried by the writes of the holder, it's raised on every allocation of the
+	// volume and reallocation of the unit, so the writes of the stale holders are fenced by blobnode
+	WriteEpoch uint32 `json:"write_epoch,omitempty"`
 }
 
 type VolumeInfo struct {
@@ -176,6 +179,8 @@ func (c *Client) UnlockVolume(ctx context.Context, args *UnlockVolumeArgs) (err
 
 type AllocVolumeUnitArgs struct {
 	Vuid proto.Vuid `json:"vuid"`
+	// Host alloc the new chunk on the disks of the host, which must be the host of vuid
+	Host string `json:"host,omitempty"`
 }
 
 type AllocVolumeUnit struct {
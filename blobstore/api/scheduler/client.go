@@ -29,6 +29,7 @@ const (
 	PathStats              = "/stats"
 	PathStatsLeader        = "/stats/leader"
 	PathStatsDiskMigrating = "/stats/disk/migrating"
+	PathRecoveryReport     = "/recovery/report"
 
 	PathTaskAcquire          = "/task/acquire"
 	PathTaskReclaim          = "/task/reclaim"
@@ -43,6 +44,20 @@ const (
 	PathTaskDetail    = "/task/detail"
 	PathTaskDetailURI = PathTaskDetail + "/:type/:id" // "/task/detail/:type/:id"
 	PathUpdateVolume  = "/update/vol"
+
+	PathDiskDropCancel       = "/disk/drop/cancel"
+	PathDiskDropCancelReport = "/disk/drop/cancel/report"
+
+	PathHostDrain       = "/host/drain"
+	PathHostDrainReport = "/host/drain/report"
+
+	PathHostMaintenance       = "/host/maintenance"
+	PathHostMaintenanceCancel = "/host/maintenance/cancel"
+	PathHostMaintenanceList   = "/host/maintenance/list"
+
+	PathVolumeFreeze       = "/volume/freeze"
+	PathVolumeFreezeCancel = "/volume/freeze/cancel"
+	PathVolumeFreezeList   = "/volume/freeze/list"
 )
 
 const defaultHostSyncIntervalMs = 3600000 // 1 hour
@@ -71,6 +86,7 @@ type ISchedulerStatus interface {
 	DiskMigratingStats(ctx context.Context, args *DiskMigratingStatsArgs) (ret *DiskMigratingStats, err error)
 	Stats(ctx context.Context, host string) (ret TasksStat, err error)
 	LeaderStats(ctx context.Context) (ret TasksStat, err error)
+	RecoveryReport(ctx context.Context) (ret *RecoveryReport, err error)
 }
 
 // IManualMigrator add manual migrate task.
@@ -78,6 +94,28 @@ type IManualMigrator interface {
 	AddManualMigrateTask(ctx context.Context, args *AddManualMigrateArgs) (err error)
 }
 
+// IDiskDropper cancel disk drop and drain host.
+type IDiskDropper interface {
+	CancelDiskDrop(ctx context.Context, args *DiskDropCancelArgs) (ret *DiskDropCancelReport, err error)
+	DiskDropCancelReport(ctx context.Context, args *DiskDropCancelArgs) (ret *DiskDropCancelReport, err error)
+	DrainHost(ctx context.Context, args *HostDrainArgs) (ret *HostDrainReport, err error)
+	HostDrainReport(ctx context.Context, args *HostDrainArgs) (ret *HostDrainReport, err error)
+}
+
+// IHostMaintainer hosts in maintenance window, such as rolling upgrade.
+type IHostMaintainer interface {
+	SetHostMaintenance(ctx context.Context, args *HostMaintenanceArgs) (ret *HostMaintenance, err error)
+	CancelHostMaintenance(ctx context.Context, args *HostMaintenanceArgs) (err error)
+	ListHostMaintenance(ctx context.Context) (ret *HostMaintenanceList, err error)
+}
+
+// IVolumeFreezer volumes frozen from repair and migration, such as investigation of corruption.
+type IVolumeFreezer interface {
+	FreezeVolume(ctx context.Context, args *VolumeFreezeArgs) (ret *VolumeFreeze, err error)
+	UnfreezeVolume(ctx context.Context, args *VolumeFreezeArgs) (err error)
+	ListFrozenVolumes(ctx context.Context) (ret *VolumeFreezeList, err error)
+}
+
 // IVolumeUpdater volume updater.
 type IVolumeUpdater interface {
 	UpdateVolume(ctx context.Context, host string, vid proto.Vid) (err error)
@@ -89,6 +127,9 @@ type IScheduler interface {
 	IInspector
 	ISchedulerStatus
 	IManualMigrator
+	IDiskDropper
+	IHostMaintainer
+	IVolumeFreezer
 	IVolumeUpdater
 }
 
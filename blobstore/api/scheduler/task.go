@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
@@ -25,11 +26,18 @@ import (
 
 type AcquireArgs struct {
 	IDC string `json:"idc"`
+	// host and running tasks of the worker, the tasks of the worker are sized by the scheduler
+	Worker  string `json:"worker,omitempty"`
+	Running int    `json:"running,omitempty"`
 }
 
 func (c *client) AcquireTask(ctx context.Context, args *AcquireArgs) (ret *proto.MigrateTask, err error) {
 	err = c.request(func(host string) error {
-		return c.GetWith(ctx, host+PathTaskAcquire+"?idc="+args.IDC, &ret)
+		path := host + PathTaskAcquire + "?idc=" + args.IDC
+		if args.Worker != "" {
+			path += "&worker=" + url.QueryEscape(args.Worker) + "&running=" + strconv.Itoa(args.Running)
+		}
+		return c.GetWith(ctx, path, &ret)
 	})
 	return
 }
@@ -73,6 +81,10 @@ type OperateTaskArgs struct {
 	Src      []proto.VunitLocation `json:"src"`
 	Dest     proto.VunitLocation   `json:"dest"`
 	Reason   string                `json:"reason"`
+	// bids skipped by the worker since too slow to recover from the sources
+	SkippedBids []proto.BlobID `json:"skipped_bids,omitempty"`
+	// host of the worker running the task
+	Worker string `json:"worker,omitempty"`
 }
 
 func (c *client) ReclaimTask(ctx context.Context, args *OperateTaskArgs) (err error) {
@@ -152,6 +164,16 @@ type MigrateTasksStat struct {
 	WorkerDoingCnt int         `json:"worker_doing_cnt"`
 	FinishingCnt   int         `json:"finishing_cnt"`
 	StatsPerMin    PerMinStats `json:"stats_per_min"`
+	Totals         TasksTotals `json:"totals"`
+}
+
+// TasksTotals is the cumulative stats of the tasks since the scheduler started.
+type TasksTotals struct {
+	FinishedCnt    uint64 `json:"finished_cnt"`
+	ReclaimedCnt   uint64 `json:"reclaimed_cnt"`
+	CanceledCnt    uint64 `json:"canceled_cnt"`
+	ShardCnt       uint64 `json:"shard_cnt"`
+	DataAmountByte uint64 `json:"data_amount_byte"`
 }
 
 type DiskDropTasksStat struct {
@@ -187,13 +209,23 @@ type RunnerStat struct {
 }
 
 type TasksStat struct {
-	DiskRepair    *DiskRepairTasksStat    `json:"disk_repair,omitempty"`
-	DiskDrop      *DiskDropTasksStat      `json:"disk_drop,omitempty"`
-	Balance       *BalanceTasksStat       `json:"balance,omitempty"`
-	ManualMigrate *ManualMigrateTasksStat `json:"manual_migrate,omitempty"`
-	VolumeInspect *VolumeInspectTasksStat `json:"volume_inspect,omitempty"`
-	ShardRepair   *RunnerStat             `json:"shard_repair"`
-	BlobDelete    *RunnerStat             `json:"blob_delete"`
+	DiskRepair       *DiskRepairTasksStat    `json:"disk_repair,omitempty"`
+	DiskDrop         *DiskDropTasksStat      `json:"disk_drop,omitempty"`
+	Balance          *BalanceTasksStat       `json:"balance,omitempty"`
+	IntraNodeBalance *BalanceTasksStat       `json:"intra_node_balance,omitempty"`
+	ManualMigrate    *ManualMigrateTasksStat `json:"manual_migrate,omitempty"`
+	VolumeInspect    *VolumeInspectTasksStat `json:"volume_inspect,omitempty"`
+	ShardRepair      *RunnerStat             `json:"shard_repair"`
+	BlobDelete       *RunnerStat             `json:"blob_delete"`
+
+	WorkerTaskWindows map[string]WorkerTaskWindowStat `json:"worker_task_windows,omitempty"`
+}
+
+// WorkerTaskWindowStat is the migrate tasks window of the worker.
+type WorkerTaskWindowStat struct {
+	Window    int    `json:"window"`
+	Completed uint64 `json:"completed"`
+	Failed    uint64 `json:"failed"`
 }
 
 func (c *client) DetailMigrateTask(ctx context.Context, args *MigrateTaskDetailArgs) (detail MigrateTaskDetail, err error) {
@@ -220,6 +252,27 @@ func (c *client) LeaderStats(ctx context.Context) (ret TasksStat, err error) {
 	return
 }
 
+// RecoveryReport report of the cold start recovery of the leader, the migrating disks
+// rebuilt from the disk status in clustermgr, the alive tasks reported by the workers
+// but not known by the scheduler, and the volumes unlocked for the lost tasks.
+type RecoveryReport struct {
+	Enable          bool                        `json:"enable"`
+	Done            bool                        `json:"done"`
+	StartTime       int64                       `json:"start_time"`
+	EndTime         int64                       `json:"end_time,omitempty"`
+	RepairingDisks  []proto.DiskID              `json:"repairing_disks"`
+	DroppingDisks   []proto.DiskID              `json:"dropping_disks"`
+	OrphanTasks     map[proto.TaskType][]string `json:"orphan_tasks"`
+	UnlockedVolumes []proto.Vid                 `json:"unlocked_volumes"`
+}
+
+func (c *client) RecoveryReport(ctx context.Context) (ret *RecoveryReport, err error) {
+	err = c.request(func(host string) error {
+		return c.GetWith(ctx, host+PathRecoveryReport, &ret)
+	})
+	return
+}
+
 type DiskMigratingStatsArgs struct {
 	TaskType proto.TaskType `json:"task_type"`
 	DiskID   proto.DiskID   `json:"disk_id"`
@@ -242,6 +295,234 @@ func (c *client) DiskMigratingStats(ctx context.Context, args *DiskMigratingStat
 	return
 }
 
+// disk drop cancel status
+const (
+	DiskDropCancelling = "cancelling"
+	DiskDropCancelled  = "cancelled"
+)
+
+type DiskDropCancelArgs struct {
+	DiskID proto.DiskID `json:"disk_id"`
+}
+
+// DiskDropCancelReport report of the cancelled disk drop.
+// The volume units migrated before cancelling are kept in their new locations,
+// the pending tasks are stopped and the volume units are kept in the disk,
+// the running tasks are kept running and the disk is restored after they are done.
+type DiskDropCancelReport struct {
+	DiskID        proto.DiskID `json:"disk_id"`
+	Status        string       `json:"status"`
+	TotalCnt      int          `json:"total_cnt"`
+	MigratedCnt   int          `json:"migrated_cnt"`
+	RemainCnt     int          `json:"remain_cnt"`
+	StoppedVuids  []proto.Vuid `json:"stopped_vuids"`
+	InFlightVuids []proto.Vuid `json:"in_flight_vuids"`
+	CancelTime    int64        `json:"cancel_time"`
+	FinishTime    int64        `json:"finish_time,omitempty"`
+}
+
+// SetRemainCnt sets the count of volume units remained in the disk.
+func (r *DiskDropCancelReport) SetRemainCnt(remain int) {
+	r.RemainCnt = remain
+	r.MigratedCnt = r.TotalCnt - remain
+	if r.MigratedCnt < 0 {
+		r.MigratedCnt = 0
+	}
+}
+
+// Copy returns a copy of the report.
+func (r *DiskDropCancelReport) Copy() *DiskDropCancelReport {
+	report := *r
+	report.StoppedVuids = append([]proto.Vuid(nil), r.StoppedVuids...)
+	report.InFlightVuids = append([]proto.Vuid(nil), r.InFlightVuids...)
+	return &report
+}
+
+func (c *client) CancelDiskDrop(ctx context.Context, args *DiskDropCancelArgs) (ret *DiskDropCancelReport, err error) {
+	if args == nil || args.DiskID == proto.InvalidDiskID {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathDiskDropCancel, &ret, args)
+	})
+	return
+}
+
+func (c *client) DiskDropCancelReport(ctx context.Context, args *DiskDropCancelArgs) (ret *DiskDropCancelReport, err error) {
+	if args == nil || args.DiskID == proto.InvalidDiskID {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		path := host + PathDiskDropCancelReport + fmt.Sprintf("?disk_id=%d", args.DiskID)
+		return c.GetWith(ctx, path, &ret)
+	})
+	return
+}
+
+// host drain status
+const (
+	HostDraining = "draining"
+	HostDrained  = "drained"
+)
+
+// status of the disk in host drain
+const (
+	HostDrainDiskPending  = "pending"
+	HostDrainDiskDropping = "dropping"
+	HostDrainDiskDropped  = "dropped"
+)
+
+type HostDrainArgs struct {
+	Host string `json:"host"`
+}
+
+type HostDrainDisk struct {
+	DiskID      proto.DiskID `json:"disk_id"`
+	Status      string       `json:"status"`
+	TotalCnt    int          `json:"total_cnt"`
+	MigratedCnt int          `json:"migrated_cnt"`
+}
+
+// HostDrainReport report of the host drain.
+// The disks of the host are dropped in sequence, and the host is safe to remove
+// after all of its disks are dropped.
+type HostDrainReport struct {
+	Host         string          `json:"host"`
+	Status       string          `json:"status"`
+	SafeToRemove bool            `json:"safe_to_remove"`
+	TotalCnt     int             `json:"total_cnt"`
+	MigratedCnt  int             `json:"migrated_cnt"`
+	EtaS         int64           `json:"eta_s"` // estimated seconds to finish, -1 means unknown
+	StartTime    int64           `json:"start_time"`
+	FinishTime   int64           `json:"finish_time,omitempty"`
+	Disks        []HostDrainDisk `json:"disks"`
+}
+
+func (c *client) DrainHost(ctx context.Context, args *HostDrainArgs) (ret *HostDrainReport, err error) {
+	if args == nil || args.Host == "" {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathHostDrain, &ret, args)
+	})
+	return
+}
+
+func (c *client) HostDrainReport(ctx context.Context, args *HostDrainArgs) (ret *HostDrainReport, err error) {
+	if args == nil || args.Host == "" {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		path := host + PathHostDrainReport + "?host=" + url.QueryEscape(args.Host)
+		return c.GetWith(ctx, path, &ret)
+	})
+	return
+}
+
+// HostMaintenanceArgs args of the host maintenance window, the window lasts DurationS
+// seconds from now, it is extended if the host is in maintenance already.
+type HostMaintenanceArgs struct {
+	Host      string `json:"host"`
+	DurationS int64  `json:"duration_s,omitempty"`
+}
+
+// HostMaintenance maintenance window of the host, the migrate tasks with the source or
+// destination on the host are not assigned to workers until the window ends.
+type HostMaintenance struct {
+	Host      string `json:"host"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+}
+
+type HostMaintenanceList struct {
+	Hosts []*HostMaintenance `json:"hosts"`
+}
+
+func (c *client) SetHostMaintenance(ctx context.Context, args *HostMaintenanceArgs) (ret *HostMaintenance, err error) {
+	if args == nil || args.Host == "" || args.DurationS <= 0 {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathHostMaintenance, &ret, args)
+	})
+	return
+}
+
+func (c *client) CancelHostMaintenance(ctx context.Context, args *HostMaintenanceArgs) (err error) {
+	if args == nil || args.Host == "" {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathHostMaintenanceCancel, nil, args)
+	})
+	return
+}
+
+func (c *client) ListHostMaintenance(ctx context.Context) (ret *HostMaintenanceList, err error) {
+	err = c.request(func(host string) error {
+		return c.GetWith(ctx, host+PathHostMaintenanceList, &ret)
+	})
+	return
+}
+
+// VolumeFreezeArgs args of freezing the volume, the freeze lasts DurationS seconds from now,
+// it is extended if the volume is frozen already. Operator is who sets or cancels the freeze.
+type VolumeFreezeArgs struct {
+	Vid       proto.Vid `json:"vid"`
+	DurationS int64     `json:"duration_s,omitempty"`
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// VolumeFreeze freeze of the volume, the repair and migrate tasks of the volume are not
+// assigned to workers, and the shards of the volume are not repaired until the freeze ends.
+type VolumeFreeze struct {
+	Vid       proto.Vid `json:"vid"`
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason,omitempty"`
+	StartTime int64     `json:"start_time"`
+	EndTime   int64     `json:"end_time"`
+}
+
+type VolumeFreezeList struct {
+	Volumes []*VolumeFreeze `json:"volumes"`
+}
+
+func (c *client) FreezeVolume(ctx context.Context, args *VolumeFreezeArgs) (ret *VolumeFreeze, err error) {
+	if args == nil || args.Vid == proto.InvalidVid || args.DurationS <= 0 || args.Operator == "" {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathVolumeFreeze, &ret, args)
+	})
+	return
+}
+
+func (c *client) UnfreezeVolume(ctx context.Context, args *VolumeFreezeArgs) (err error) {
+	if args == nil || args.Vid == proto.InvalidVid || args.Operator == "" {
+		err = errcode.ErrIllegalArguments
+		return
+	}
+	err = c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathVolumeFreezeCancel, nil, args)
+	})
+	return
+}
+
+func (c *client) ListFrozenVolumes(ctx context.Context) (ret *VolumeFreezeList, err error) {
+	err = c.request(func(host string) error {
+		return c.GetWith(ctx, host+PathVolumeFreezeList, &ret)
+	})
+	return
+}
+
 func (c *client) selectHost() ([]string, error) {
 	hosts := c.selector.GetRandomN(c.hostRetry)
 	if len(hosts) == 0 {
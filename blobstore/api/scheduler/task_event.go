@@ -0,0 +1,54 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+// TaskEventSchemaVersion is the version of the schema of the task events, which is increased once
+// the fields are changed incompatibly, the consumers check it before decoding the events.
+const TaskEventSchemaVersion = 1
+
+// TaskEventType is the lifecycle event of the migrate tasks.
+type TaskEventType string
+
+const (
+	TaskEventCreated   = TaskEventType("created")
+	TaskEventAssigned  = TaskEventType("assigned")
+	TaskEventCompleted = TaskEventType("completed")
+	TaskEventFailed    = TaskEventType("failed")
+)
+
+// TaskEvent is published to the external systems on the lifecycle of the migrate tasks.
+type TaskEvent struct {
+	SchemaVersion int             `json:"schema_version"`
+	Event         TaskEventType   `json:"event"`
+	Time          time.Time       `json:"time"`
+	ClusterID     proto.ClusterID `json:"cluster_id"`
+	TaskID        string          `json:"task_id"`
+	TaskType      proto.TaskType  `json:"task_type"`
+	IDC           string          `json:"idc,omitempty"`
+	Vid           proto.Vid       `json:"vid,omitempty"`
+	SourceDiskID  proto.DiskID    `json:"source_disk_id,omitempty"`
+	// the destination of the task, changed by the workers on the failures
+	Destination *proto.VunitLocation `json:"destination,omitempty"`
+	// host of the worker the task is assigned to
+	Worker string `json:"worker,omitempty"`
+	// reason of the failure
+	Reason string `json:"reason,omitempty"`
+}
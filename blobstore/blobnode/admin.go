@@ -0,0 +1,173 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
+	base "github.com/cubefs/cubefs/blobstore/blobnode/base/workutils"
+	"github.com/cubefs/cubefs/blobstore/common/resourcepool"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/errors"
+	"github.com/cubefs/cubefs/blobstore/util/log"
+)
+
+const DefaultAdminSocket = "./blobnode.admin.sock"
+
+// adminServer serves the admin operations on the local unix socket, so the blobnode is debugged
+// on the host without its http port. The socket is accessible to the owner only, and the peers
+// not running as root or the user of the blobnode are rejected where the credentials are known.
+type adminServer struct {
+	socket string
+	server *http.Server
+}
+
+// adminListener rejects the connections of the peers not allowed.
+type adminListener struct {
+	net.Listener
+}
+
+func (l *adminListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if err = checkAdminPeer(conn); err != nil {
+			log.Warnf("reject admin connection: %v", err)
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+// NewAdminHandler returns the router of the admin operations.
+func NewAdminHandler(service *Service) *rpc.Router {
+	r := rpc.New()
+
+	rpc.RegisterArgsParser(&bnapi.AdminStopTaskArgs{}, "json")
+
+	r.Handle(http.MethodGet, "/tasks", service.AdminListTasks)
+	r.Handle(http.MethodPost, "/task/stop/:task_id", service.AdminStopTask, rpc.OptArgsURI())
+	r.Handle(http.MethodGet, "/disks", service.Stat)
+	r.Handle(http.MethodGet, "/qos", service.AdminGetQos)
+	r.Handle(http.MethodPost, "/qos", service.ConfigReload, rpc.OptArgsQuery())
+	r.Handle(http.MethodGet, "/bufpool", service.AdminBufPoolStat)
+	return r
+}
+
+// StartAdmin serves the admin operations on the unix socket, the stale socket left by the last
+// process is removed, since the flock of the blobnode ensures there is no other one.
+func (s *Service) StartAdmin(socket string) (err error) {
+	if info, statErr := os.Lstat(socket); statErr == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return errors.Newf("admin socket %s is not a socket", socket)
+		}
+		if err = os.Remove(socket); err != nil {
+			return
+		}
+	}
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return
+	}
+	if err = os.Chmod(socket, 0o600); err != nil {
+		ln.Close()
+		return
+	}
+
+	s.admin = &adminServer{socket: socket, server: &http.Server{Handler: NewAdminHandler(s)}}
+	go func() {
+		if err := s.admin.server.Serve(&adminListener{Listener: ln}); err != nil && err != http.ErrServerClosed {
+			log.Errorf("admin server exits: %v", err)
+		}
+	}()
+	log.Infof("admin server listens on %s", socket)
+	return nil
+}
+
+func (s *Service) closeAdmin() {
+	if s.admin == nil {
+		return
+	}
+	s.admin.server.Close()
+	os.Remove(s.admin.socket)
+}
+
+/*
+ *  method:         GET
+ *  url:            /tasks
+ *  response body:  json.Marshal(AdminTasks)
+ */
+func (s *Service) AdminListTasks(c *rpc.Context) {
+	mgr := s.WorkerService.taskRunnerMgr
+	c.RespondJSON(bnapi.AdminTasks{
+		Tasks:   mgr.GetAliveTasks(),
+		Running: mgr.RunningTaskCnt(),
+		Stats:   mgr.TaskStats(),
+	})
+}
+
+/*
+ *  method:         POST
+ *  url:            /task/stop/{task_id}
+ */
+func (s *Service) AdminStopTask(c *rpc.Context) {
+	args := new(bnapi.AdminStopTaskArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	span.Infof("admin stop task: %s", args.TaskID)
+
+	if err := s.WorkerService.taskRunnerMgr.StopTask(args.TaskID); err != nil {
+		c.RespondWith(http.StatusNotFound, "", []byte(err.Error()))
+		return
+	}
+	c.Respond()
+}
+
+/*
+ *  method:         GET
+ *  url:            /qos
+ *  response body:  json.Marshal(AdminQos)
+ */
+func (s *Service) AdminGetQos(c *rpc.Context) {
+	qosConf := s.Conf.DiskConfig.DataQos
+	c.RespondJSON(bnapi.AdminQos{
+		MaxWaitCount:   qosConf.MaxWaitCount,
+		NormalMBPS:     qosConf.NormalMBPS,
+		BackgroundMBPS: qosConf.BackgroundMBPS,
+	})
+}
+
+/*
+ *  method:         GET
+ *  url:            /bufpool
+ *  response body:  json.Marshal(resourcepool.Status)
+ */
+func (s *Service) AdminBufPoolStat(c *rpc.Context) {
+	if base.TaskBufPool == nil {
+		c.RespondJSON(resourcepool.Status{})
+		return
+	}
+	c.RespondJSON(base.TaskBufPool.Status())
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+)
+
+func TestAdminServer(t *testing.T) {
+	service, schedulerCli := newMockWorkService(t)
+	defer service.WorkerService.Close()
+	service.Conf = &Config{}
+	service.Conf.DiskConfig.DataQos.NormalMBPS = 100
+	service.WorkerService.taskRunnerMgr = initTestTaskRunnerMgr(t, schedulerCli, 2, proto.TaskTypeBalance)
+	defer service.WorkerService.taskRunnerMgr.StopAllAliveRunner()
+
+	socket := filepath.Join(t.TempDir(), "blobnode.admin.sock")
+	// the stale socket is replaced, other files are not
+	require.NoError(t, os.WriteFile(socket, nil, 0o600))
+	require.Error(t, service.StartAdmin(socket))
+	require.NoError(t, os.Remove(socket))
+	require.NoError(t, service.StartAdmin(socket))
+	service.closeAdmin()
+	require.NoError(t, service.StartAdmin(socket))
+	defer service.closeAdmin()
+	info, err := os.Stat(socket)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	ctx := context.Background()
+	cli := bnapi.NewAdminClient(socket)
+	tasks, err := cli.ListTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks.Tasks[proto.TaskTypeBalance], 2)
+
+	require.NoError(t, cli.StopTask(ctx, &bnapi.AdminStopTaskArgs{TaskID: tasks.Tasks[proto.TaskTypeBalance][0]}))
+	err = cli.StopTask(ctx, &bnapi.AdminStopTaskArgs{TaskID: "no_such_task"})
+	require.Equal(t, 404, rpc.DetectStatusCode(err))
+
+	disks, err := cli.DiskStat(ctx)
+	require.NoError(t, err)
+	require.Len(t, disks, 0)
+
+	qosConf, err := cli.GetQos(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), qosConf.NormalMBPS)
+	require.NoError(t, cli.SetQos(ctx, &bnapi.ConfigReloadArgs{Key: "normal_mbps", Value: "200"}))
+	qosConf, err = cli.GetQos(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(200), qosConf.NormalMBPS)
+	err = cli.SetQos(ctx, &bnapi.ConfigReloadArgs{Key: "no_such_key", Value: "1"})
+	require.Equal(t, 400, rpc.DetectStatusCode(err))
+
+	_, err = cli.BufPoolStat(ctx)
+	require.NoError(t, err)
+}
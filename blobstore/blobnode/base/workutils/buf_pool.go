@@ -77,3 +77,8 @@ func (b *BufPool) GetRepairBuf() ([]byte, error) {
 func (b *BufPool) Put(buf []byte) error {
 	return b.bufPool.Put(buf)
 }
+
+// Status returns the status of the size classes of the pool.
+func (b *BufPool) Status() resourcepool.Status {
+	return b.bufPool.Status()
+}
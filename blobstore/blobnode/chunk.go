@@ -333,6 +333,60 @@ func (s *Service) ChunkReadwrite(c *rpc.Context) {
 	span.Infof("update disk:%v vuid:%v normal success", args.DiskID, args.Vuid)
 }
 
+/*
+ *  method:         POST
+ *  url:            /chunk/fence/diskid/{diskid}/vuid/{vuid}/epoch/{epoch}
+ */
+func (s *Service) ChunkFence(c *rpc.Context) {
+	args := new(bnapi.FenceChunkArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+
+	span.Debugf("chunk fence args: %v", args)
+
+	if !bnapi.IsValidDiskID(args.DiskID) {
+		c.RespondError(bloberr.ErrInvalidDiskId)
+		return
+	}
+
+	if args.Epoch == 0 {
+		c.RespondError(bloberr.ErrInvalidParam)
+		return
+	}
+
+	limitKey := args.Vuid
+	err := s.ChunkLimitPerVuid.Acquire(limitKey)
+	if err != nil {
+		span.Errorf("fence vuid(%v) concurry conflict", args.Vuid)
+		c.RespondError(bloberr.ErrOverload)
+		return
+	}
+	defer s.ChunkLimitPerVuid.Release(limitKey)
+
+	s.lock.RLock()
+	ds, exist := s.Disks[args.DiskID]
+	s.lock.RUnlock()
+	if !exist {
+		span.Errorf("fence disk:%v not found", args.DiskID)
+		c.RespondError(bloberr.ErrNoSuchDisk)
+		return
+	}
+
+	err = ds.FenceChunk(ctx, args.Vuid, args.Epoch)
+	if err != nil {
+		span.Errorf("fence args:(%v) failed: %v", args, err)
+		c.RespondError(err)
+		return
+	}
+
+	span.Infof("fence disk:%v vuid:%v epoch:%v success", args.DiskID, args.Vuid, args.Epoch)
+}
+
 /*
  *  method:         GET
  *  url:            /chunk/list/diskid/{diskid}
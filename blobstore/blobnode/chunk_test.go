@@ -15,6 +15,7 @@
 package blobnode
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"testing"
@@ -22,7 +23,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
+	bloberr "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 )
 
@@ -157,6 +160,93 @@ func TestSetChunkStatus(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestFenceChunk(t *testing.T) {
+	service, _ := newTestBlobNodeService(t, "FenceChunk")
+	defer cleanTestBlobNodeService(service)
+
+	host := runTestServer(service)
+	client := bnapi.New(&bnapi.Config{})
+
+	ctx := context.TODO()
+
+	diskID := proto.DiskID(101)
+	vuid := proto.Vuid(2001)
+
+	fenceChunkArg := &bnapi.FenceChunkArgs{
+		DiskID: diskID,
+		Vuid:   vuid,
+		Epoch:  2,
+	}
+	err := client.FenceChunk(ctx, host, fenceChunkArg)
+	require.Error(t, err)
+
+	err = client.CreateChunk(ctx, host, &bnapi.CreateChunkArgs{DiskID: diskID, Vuid: vuid})
+	require.NoError(t, err)
+
+	err = client.FenceChunk(ctx, host, &bnapi.FenceChunkArgs{DiskID: diskID, Vuid: vuid})
+	require.Error(t, err)
+
+	err = client.FenceChunk(ctx, host, fenceChunkArg)
+	require.NoError(t, err)
+
+	chunkStat, err := client.StatChunk(ctx, host, &bnapi.StatChunkArgs{DiskID: diskID, Vuid: vuid})
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), chunkStat.Epoch)
+
+	// lower epoch is ignored
+	fenceChunkArg.Epoch = 1
+	err = client.FenceChunk(ctx, host, fenceChunkArg)
+	require.NoError(t, err)
+
+	// the stale holder is fenced before the first write of the new holder
+
+	shardData := []byte("test")
+	putShardArg := &bnapi.PutShardArgs{
+		DiskID: diskID,
+		Vuid:   vuid,
+		Bid:    proto.BlobID(1),
+		Size:   int64(len(shardData)),
+		Epoch:  1,
+		Body:   bytes.NewReader(shardData),
+	}
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.Error(t, err)
+	require.Equal(t, bloberr.CodeChunkFenced, rpc.DetectStatusCode(err))
+
+	putShardArg.Epoch = 2
+	putShardArg.Body = bytes.NewReader(shardData)
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.NoError(t, err)
+
+	// the normal writes without epoch are fenced, the background writes are not
+	putShardArg.Epoch = 0
+	putShardArg.Bid = proto.BlobID(2)
+	putShardArg.Body = bytes.NewReader(shardData)
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.Equal(t, bloberr.CodeChunkFenced, rpc.DetectStatusCode(err))
+	putShardArg.Type = bnapi.BackgroundIO
+	putShardArg.Body = bytes.NewReader(shardData)
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.NoError(t, err)
+	putShardArg.Type = bnapi.NormalIO
+
+	// the higher epoch of the write raises the epoch of the chunk
+	putShardArg.Epoch = 3
+	putShardArg.Bid = proto.BlobID(3)
+	putShardArg.Body = bytes.NewReader(shardData)
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.NoError(t, err)
+	chunkStat, err = client.StatChunk(ctx, host, &bnapi.StatChunkArgs{DiskID: diskID, Vuid: vuid})
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), chunkStat.Epoch)
+
+	putShardArg.Epoch = 2
+	putShardArg.Bid = proto.BlobID(4)
+	putShardArg.Body = bytes.NewReader(shardData)
+	_, err = client.PutShard(ctx, host, putShardArg)
+	require.Equal(t, bloberr.CodeChunkFenced, rpc.DetectStatusCode(err))
+}
+
 func TestReleaseChunk(t *testing.T) {
 	service, _ := newTestBlobNodeService(t, "ReleaseChunk")
 	defer cleanTestBlobNodeService(service)
@@ -32,6 +32,7 @@ type IBlobNode interface {
 	StatChunk(ctx context.Context, location proto.VunitLocation) (ci *ChunkInfo, err error)
 	StatShard(ctx context.Context, location proto.VunitLocation, bid proto.BlobID) (si *ShardInfo, err error)
 	ListShards(ctx context.Context, location proto.VunitLocation) (shards []*ShardInfo, err error)
+	StatShards(ctx context.Context, location proto.VunitLocation, bids []proto.BlobID, verify bool) (stats []*ShardStat, err error)
 	GetShard(ctx context.Context, location proto.VunitLocation, bid proto.BlobID, ioType api.IOType) (body io.ReadCloser, crc32 uint32, err error)
 	PutShard(ctx context.Context, location proto.VunitLocation, bid proto.BlobID, size int64, body io.Reader, ioType api.IOType) (err error)
 }
@@ -76,6 +77,16 @@ func (si *ShardInfo) NotExist() bool {
 	return si.Flag == ShardStatusNotExist
 }
 
+// ShardStat shard stat with the code of stating or verifying the shard
+type ShardStat struct {
+	api.ShardStat
+}
+
+// Intact returns true if the shard is normal and verified if required
+func (ss *ShardStat) Intact() bool {
+	return ss.Code == 0 && ss.Flag == api.ShardStatusNormal
+}
+
 // NewBlobNodeClient returns blobnode client
 func NewBlobNodeClient(conf *api.Config) IBlobNode {
 	return &BlobNodeClient{
@@ -149,6 +160,24 @@ func (c *BlobNodeClient) ListShards(ctx context.Context, location proto.VunitLoc
 	return sis, nil
 }
 
+// StatShards returns the stats of the shards of bids,
+// the data of the normal shards are read to verify the crcs if verify
+func (c *BlobNodeClient) StatShards(ctx context.Context, location proto.VunitLocation, bids []proto.BlobID, verify bool) (stats []*ShardStat, err error) {
+	pSpan := trace.SpanFromContextSafe(ctx)
+	span, ctx := trace.StartSpanFromContextWithTraceID(context.Background(), "StatShards", pSpan.TraceID())
+
+	infos, err := c.cli.StatShards(ctx, location.Host, &api.StatShardsArgs{DiskID: location.DiskID, Vuid: location.Vuid, Bids: bids, Verify: verify})
+	if err != nil {
+		span.Errorf("StatShards failed: location[%+v], bids len[%d], code[%d], err[%+v]", location, len(bids), rpc.DetectStatusCode(err), err)
+		return nil, err
+	}
+	for _, info := range infos {
+		stats = append(stats, &ShardStat{*info})
+	}
+	span.Debugf("StatShards success: location[%+v], bids len[%d], verify[%v]", location, len(bids), verify)
+	return stats, nil
+}
+
 // PutShard put data to shard
 func (c *BlobNodeClient) PutShard(ctx context.Context, location proto.VunitLocation, bid proto.BlobID, size int64, body io.Reader, ioType api.IOType) (err error) {
 	pSpan := trace.SpanFromContextSafe(ctx)
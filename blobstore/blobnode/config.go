@@ -60,6 +60,7 @@ type Config struct {
 	DiskConfig    core.RuntimeConfig `json:"disk_config"`
 	MetaConfig    db.MetaConfig      `json:"meta_config"`
 	FlockFilename string             `json:"flock_filename"`
+	AdminSocket   string             `json:"admin_socket"`
 
 	Clustermgr *cmapi.Config `json:"clustermgr"`
 
@@ -82,6 +82,10 @@ type chunk struct {
 	closed         bool
 	lastModifyTime int64
 
+	// fence, the writes carrying a lower volume epoch are rejected
+	fenceLock sync.RWMutex
+	epoch     uint32
+
 	// io schedulers
 	readPool  taskpool.IoPool
 	writePool taskpool.IoPool
@@ -129,6 +133,7 @@ func newChunkStorage(ctx context.Context, dataPath string, vm core.VuidMeta, rea
 		conf:           opt.Conf,
 		status:         vm.Status,
 		compacting:     vm.Compacting,
+		epoch:          vm.Epoch,
 		bidlimiter:     keycount.NewBlockingKeyCountLimit(1),
 		consistent:     core.NewConsistencyController(),
 		lastModifyTime: vm.Mtime,
@@ -265,6 +270,15 @@ func (cs *chunk) Write(ctx context.Context, b *core.Shard) (err error) {
 		return bloberr.ErrVuidNotMatch
 	}
 
+	// hold the fence until the write done, so no stale write lands after fenced
+	cs.fenceLock.RLock()
+	defer cs.fenceLock.RUnlock()
+	// the background writes without epoch are from the repair or migrate tasks, not the holders
+	// of the volume. the normal writes without epoch are fenced once the chunk has an epoch
+	if b.Epoch < cs.Epoch() && (b.Epoch > 0 || bnapi.GetIoType(ctx) == bnapi.NormalIO) {
+		return bloberr.ErrChunkFenced
+	}
+
 	elem := cs.consistent.Begin(b.Bid)
 	defer cs.consistent.End(elem)
 
@@ -332,6 +346,7 @@ func (cs *chunk) ChunkInfo(ctx context.Context) (info bnapi.ChunkInfo) {
 
 	info.Status = cs.status
 	info.Compacting = cs.compacting
+	info.Epoch = cs.Epoch()
 
 	return info
 }
@@ -352,6 +367,7 @@ func (cs *chunk) vuidMeta() (vm *core.VuidMeta) {
 		Mtime:       cs.lastModifyTime,
 		Status:      cs.status,
 		Compacting:  cs.compacting,
+		Epoch:       cs.Epoch(),
 	}
 	return vm
 }
@@ -791,6 +807,21 @@ func (cs *chunk) Status() (status bnapi.ChunkStatus) {
 	return cs.status
 }
 
+func (cs *chunk) Epoch() uint32 {
+	return atomic.LoadUint32(&cs.epoch)
+}
+
+// SetEpoch raises the fence epoch, it waits for the writes in flight done,
+// so that all the writes return after it are checked with the new epoch.
+func (cs *chunk) SetEpoch(epoch uint32) {
+	cs.fenceLock.Lock()
+	defer cs.fenceLock.Unlock()
+
+	if epoch > cs.epoch {
+		atomic.StoreUint32(&cs.epoch, epoch)
+	}
+}
+
 func (cs *chunk) CasDirty(old, new uint32) (swapped bool) {
 	return atomic.CompareAndSwapUint32(&cs.dirty, old, new)
 }
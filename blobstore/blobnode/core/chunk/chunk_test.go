@@ -32,6 +32,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/blobnode/base/qos"
 	"github.com/cubefs/cubefs/blobstore/blobnode/core"
 	"github.com/cubefs/cubefs/blobstore/blobnode/db"
+	bloberr "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	_ "github.com/cubefs/cubefs/blobstore/testing/nolog"
@@ -233,6 +234,24 @@ func TestChunkStorage_ReadWrite(t *testing.T) {
 	shard.Body = bytes.NewReader(shardData)
 	err = cs.Write(ctx, shard)
 	require.NoError(t, err)
+
+	// fenced, the stale writer is rejected
+	cs.SetEpoch(3)
+	require.Equal(t, uint32(3), cs.Epoch())
+	require.Equal(t, uint32(3), cs.VuidMeta().Epoch)
+	shard.Body = bytes.NewReader(shardData)
+	shard.Epoch = 2
+	err = cs.Write(ctx, shard)
+	require.ErrorIs(t, err, bloberr.ErrChunkFenced)
+
+	// fence never goes down
+	cs.SetEpoch(1)
+	require.Equal(t, uint32(3), cs.Epoch())
+
+	shard.Body = bytes.NewReader(shardData)
+	shard.Epoch = 3
+	err = cs.Write(ctx, shard)
+	require.NoError(t, err)
 }
 
 func TestChunkStorage_ReadWriteInline(t *testing.T) {
@@ -113,6 +113,10 @@ func (mock *diskMock) UpdateChunkCompactState(ctx context.Context, vuid proto.Vu
 	return
 }
 
+func (mock *diskMock) FenceChunk(ctx context.Context, vuid proto.Vuid, epoch uint32) (err error) {
+	return
+}
+
 func (mock *diskMock) ListChunks(ctx context.Context) (chunks []core.VuidMeta, err error) {
 	return
 }
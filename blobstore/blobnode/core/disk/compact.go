@@ -124,6 +124,7 @@ func (ds *DiskStorage) CompactChunkInternal(ctx context.Context, vuid proto.Vuid
 
 	ncsMeta := ncs.VuidMeta()
 	ncsMeta.Status = cs.Status()
+	ncsMeta.Epoch = cs.Epoch()
 	ncsMeta.Mtime = time.Now().UnixNano()
 
 	// insert new chunkmeta. no side effect.
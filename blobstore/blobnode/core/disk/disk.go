@@ -809,6 +809,45 @@ func (ds *DiskStorage) UpdateChunkStatus(ctx context.Context, vuid proto.Vuid, s
 	return nil
 }
 
+// FenceChunk raises the fence epoch of the chunk, the writes carrying a lower volume epoch
+// are rejected once it returns. The fence only goes up, lower epoch is ignored.
+func (ds *DiskStorage) FenceChunk(ctx context.Context, vuid proto.Vuid, epoch uint32) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	// The following logic, for the same vuid, only allows serial execution
+	if ds.ChunkLimitPerKey.Acquire(vuid) != nil {
+		return bloberr.ErrOverload
+	}
+	defer ds.ChunkLimitPerKey.Release(vuid)
+
+	ds.Lock.RLock()
+	cs, exist := ds.Chunks[vuid]
+	ds.Lock.RUnlock()
+	if !exist {
+		span.Errorf("disk(%v) no such vuid(%v)", ds.DiskID, vuid)
+		return bloberr.ErrNoSuchVuid
+	}
+
+	if cs.Epoch() >= epoch {
+		span.Debugf("chunk(%s) epoch:%d not lower than %d", cs.ID(), cs.Epoch(), epoch)
+		return nil
+	}
+
+	// fence in memory first, the persistence failure is retried by caller
+	cs.SetEpoch(epoch)
+
+	vm := cs.VuidMeta()
+	vm.Mtime = time.Now().UnixNano()
+
+	err = ds.SuperBlock.UpsertChunk(ctx, cs.ID(), *vm)
+	if err != nil {
+		span.Errorf("update chunk(%s) epoch to %d failed: %v", vm.ChunkId, epoch, err)
+		return err
+	}
+
+	return nil
+}
+
 func (ds *DiskStorage) UpdateChunkCompactState(ctx context.Context, vuid proto.Vuid, compacting bool) (
 	err error,
 ) {
@@ -41,6 +41,7 @@ type VuidMeta struct {
 	Compacting  bool              `json:"compacting"`
 	Status      bnapi.ChunkStatus `json:"status"` // normal、release
 	Reason      string            `json:"reason"`
+	Epoch       uint32            `json:"epoch,omitempty"` // fence epoch
 }
 
 // disk meta data for rocksdb
@@ -147,6 +148,8 @@ type ChunkAPI interface {
 	HasPendingRequest() bool
 	SetStatus(status bnapi.ChunkStatus) (err error)
 	SetDirty(dirty bool)
+	Epoch() uint32
+	SetEpoch(epoch uint32)
 }
 
 type DiskAPI interface {
@@ -166,6 +169,7 @@ type DiskAPI interface {
 	ReleaseChunk(ctx context.Context, vuid proto.Vuid, force bool) (err error)
 	UpdateChunkStatus(ctx context.Context, vuid proto.Vuid, status bnapi.ChunkStatus) (err error)
 	UpdateChunkCompactState(ctx context.Context, vuid proto.Vuid, compacting bool) (err error)
+	FenceChunk(ctx context.Context, vuid proto.Vuid, epoch uint32) (err error)
 	ListChunks(ctx context.Context) (chunks []VuidMeta, err error)
 	EnqueueCompact(ctx context.Context, vuid proto.Vuid)
 	GcRubbishChunk(ctx context.Context) (mayBeLost []bnapi.ChunkId, err error)
@@ -150,6 +150,7 @@ type Shard struct {
 	Buffer []byte // inline data
 
 	Body     io.Reader // for put: shard body
+	Epoch    uint32    // for put: volume epoch held by the writer
 	From, To int64     // for get: range (note: may fix in cs)
 	Writer   io.Writer // for get: transmission to network
 
@@ -92,6 +92,20 @@ func (mr *MockDiskAPIMockRecorder) EnqueueCompact(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueCompact", reflect.TypeOf((*MockDiskAPI)(nil).EnqueueCompact), arg0, arg1)
 }
 
+// FenceChunk mocks base method.
+func (m *MockDiskAPI) FenceChunk(arg0 context.Context, arg1 proto.Vuid, arg2 uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FenceChunk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FenceChunk indicates an expected call of FenceChunk.
+func (mr *MockDiskAPIMockRecorder) FenceChunk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FenceChunk", reflect.TypeOf((*MockDiskAPI)(nil).FenceChunk), arg0, arg1, arg2)
+}
+
 // GcRubbishChunk mocks base method.
 func (m *MockDiskAPI) GcRubbishChunk(arg0 context.Context) ([]blobnode.ChunkId, error) {
 	m.ctrl.T.Helper()
@@ -734,6 +748,20 @@ func (mr *MockChunkAPIMockRecorder) Disk() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disk", reflect.TypeOf((*MockChunkAPI)(nil).Disk))
 }
 
+// Epoch mocks base method.
+func (m *MockChunkAPI) Epoch() uint32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Epoch")
+	ret0, _ := ret[0].(uint32)
+	return ret0
+}
+
+// Epoch indicates an expected call of Epoch.
+func (mr *MockChunkAPIMockRecorder) Epoch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Epoch", reflect.TypeOf((*MockChunkAPI)(nil).Epoch))
+}
+
 // HasEnoughSpace mocks base method.
 func (m *MockChunkAPI) HasEnoughSpace(arg0 int64) bool {
 	m.ctrl.T.Helper()
@@ -905,6 +933,18 @@ func (mr *MockChunkAPIMockRecorder) SetDirty(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDirty", reflect.TypeOf((*MockChunkAPI)(nil).SetDirty), arg0)
 }
 
+// SetEpoch mocks base method.
+func (m *MockChunkAPI) SetEpoch(arg0 uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetEpoch", arg0)
+}
+
+// SetEpoch indicates an expected call of SetEpoch.
+func (mr *MockChunkAPIMockRecorder) SetEpoch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEpoch", reflect.TypeOf((*MockChunkAPI)(nil).SetEpoch), arg0)
+}
+
 // SetStatus mocks base method.
 func (m *MockChunkAPI) SetStatus(arg0 blobnode.ChunkStatus) error {
 	m.ctrl.T.Helper()
@@ -53,6 +53,9 @@ func initConfig(args []string) (cfg *cmd.Config, err error) {
 	if conf.FlockFilename == "" {
 		conf.FlockFilename = "./blobnode.flock"
 	}
+	if conf.AdminSocket == "" {
+		conf.AdminSocket = DefaultAdminSocket
+	}
 
 	if _, err = fileutil.TryLockFile(conf.FlockFilename); err != nil {
 		log.Errorf("Failed to flock, err: %v", err)
@@ -88,6 +91,10 @@ func setUp() (*rpc.Router, []rpc.ProgressHandler) {
 	if err != nil {
 		log.Fatalf("Failed to new blobnode service, err: %v", err)
 	}
+	// the blobnode serves without the admin socket
+	if err = gService.StartAdmin(conf.AdminSocket); err != nil {
+		log.Errorf("Failed to start admin server, socket: %s, err: %v", conf.AdminSocket, err)
+	}
 	// register all self functions of service
 	return NewHandler(gService), nil
 }
@@ -99,6 +106,7 @@ func tearDown() {
 
 func NewHandler(service *Service) *rpc.Router {
 	r := rpc.New()
+	rpc.RegisterFeatures(bnapi.FeatureStatShards)
 
 	rpc.RegisterArgsParser(&bnapi.DiskStatArgs{}, "json")
 	rpc.RegisterArgsParser(&bnapi.DiskProbeArgs{}, "json")
@@ -130,6 +138,7 @@ func NewHandler(service *Service) *rpc.Router {
 	r.Handle(http.MethodPost, "/chunk/release/diskid/:diskid/vuid/:vuid", service.ChunkRelease, rpc.OptArgsURI(), rpc.OptArgsQuery())
 	r.Handle(http.MethodPost, "/chunk/readonly/diskid/:diskid/vuid/:vuid", service.ChunkReadonly, rpc.OptArgsURI())
 	r.Handle(http.MethodPost, "/chunk/readwrite/diskid/:diskid/vuid/:vuid", service.ChunkReadwrite, rpc.OptArgsURI())
+	r.Handle(http.MethodPost, "/chunk/fence/diskid/:diskid/vuid/:vuid/epoch/:epoch", service.ChunkFence, rpc.OptArgsURI())
 	r.Handle(http.MethodGet, "/chunk/list/diskid/:diskid", service.ChunkList, rpc.OptArgsURI())
 	r.Handle(http.MethodGet, "/chunk/stat/diskid/:diskid/vuid/:vuid", service.ChunkStat, rpc.OptArgsURI())
 	r.Handle(http.MethodPost, "/chunk/compact/diskid/:diskid/vuid/:vuid", service.ChunkCompact, rpc.OptArgsURI())
@@ -137,6 +146,7 @@ func NewHandler(service *Service) *rpc.Router {
 	r.Handle(http.MethodGet, "/shard/get/diskid/:diskid/vuid/:vuid/bid/:bid", service.ShardGet, rpc.OptArgsURI(), rpc.OptArgsQuery())
 	r.Handle(http.MethodGet, "/shard/list/diskid/:diskid/vuid/:vuid/startbid/:startbid/status/:status/count/:count", service.ShardList, rpc.OptArgsURI())
 	r.Handle(http.MethodGet, "/shard/stat/diskid/:diskid/vuid/:vuid/bid/:bid", service.ShardStat, rpc.OptArgsURI())
+	r.Handle(http.MethodPost, "/shard/stats", service.ShardStats, rpc.OptArgsBody())
 	r.Handle(http.MethodPost, "/shard/markdelete/diskid/:diskid/vuid/:vuid/bid/:bid", service.ShardMarkdelete, rpc.OptArgsURI())
 	r.Handle(http.MethodPost, "/shard/delete/diskid/:diskid/vuid/:vuid/bid/:bid", service.ShardDelete, rpc.OptArgsURI())
 	r.Handle(http.MethodPost, "/shard/put/diskid/:diskid/vuid/:vuid/bid/:bid/size/:size", service.ShardPut, rpc.OptArgsURI(), rpc.OptArgsQuery())
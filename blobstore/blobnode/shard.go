@@ -15,6 +15,7 @@
 package blobnode
 
 import (
+	"io"
 	"math"
 	"net/http"
 	"os"
@@ -35,6 +36,8 @@ import (
 
 const (
 	ShardListPageLimit = 65536
+	// max bids of one stat shards request
+	ShardStatsLimit = 1024
 )
 
 /*
@@ -260,6 +263,83 @@ func (s *Service) ShardStat(c *rpc.Context) {
 	c.RespondJSON(stat)
 }
 
+/*
+ *  method:         POST
+ *  url:            /shard/stats
+ *  request body:   json.Marshal(bnapi.StatShardsArgs)
+ *  response body:  json.Marshal(bnapi.StatShardsRet)
+ */
+func (s *Service) ShardStats(c *rpc.Context) {
+	args := new(bnapi.StatShardsArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+
+	if !bnapi.IsValidDiskID(args.DiskID) {
+		c.RespondError(bloberr.ErrInvalidDiskId)
+		return
+	}
+	if len(args.Bids) > ShardStatsLimit {
+		c.RespondError(bloberr.ErrShardListExceedLimit)
+		return
+	}
+
+	s.lock.RLock()
+	ds, exist := s.Disks[args.DiskID]
+	s.lock.RUnlock()
+	if !exist {
+		span.Errorf("diskid:%v not exist", args.DiskID)
+		c.RespondError(bloberr.ErrNoSuchDisk)
+		return
+	}
+
+	cs, exist := ds.GetChunkStorage(args.Vuid)
+	if !exist {
+		span.Errorf("vuid<%d> not exist. diskid:%v", args.Vuid, args.DiskID)
+		c.RespondError(bloberr.ErrNoSuchVuid)
+		return
+	}
+
+	// the verification reads are background io
+	ctx = bnapi.SetIoType(ctx, bnapi.BackgroundIO)
+	ctx = limitio.SetLimitTrack(ctx)
+
+	ret := bnapi.StatShardsRet{Shards: make([]*bnapi.ShardStat, 0, len(args.Bids))}
+	for _, bid := range args.Bids {
+		if err := ctx.Err(); err != nil {
+			c.RespondError(err)
+			return
+		}
+		stat := &bnapi.ShardStat{ShardInfo: bnapi.ShardInfo{Vuid: args.Vuid, Bid: bid}}
+		ret.Shards = append(ret.Shards, stat)
+
+		sm, err := cs.ReadShardMeta(ctx, bid)
+		if err != nil {
+			stat.Code = rpc.DetectStatusCode(handlerBidNotFoundErr(err))
+			continue
+		}
+		stat.Size, stat.Crc, stat.Flag, stat.Inline = int64(sm.Size), sm.Crc, sm.Flag, sm.Inline
+		if !args.Verify || sm.Flag != bnapi.ShardStatusNormal || sm.Size == 0 {
+			continue
+		}
+
+		if _, err = cs.Read(ctx, core.NewShardReader(bid, args.Vuid, 0, 0, io.Discard)); err != nil {
+			span.Errorf("Failed verify shard. vuid:%v bid:%v err:%v", args.Vuid, bid, err)
+			if isShardErr(err) {
+				s.inspectMgr.reportBadShard(cs, bid, err)
+				err = bloberr.ErrShardCrcMismatch
+			}
+			stat.Code = rpc.DetectStatusCode(handlerBidNotFoundErr(err))
+		}
+	}
+	span.Debugf("stat shards. diskid:%v vuid:%v bids:%d verify:%v", args.DiskID, args.Vuid, len(args.Bids), args.Verify)
+	c.RespondJSON(ret)
+}
+
 /*
  *  method:         POST
  *  url:            /shard/markdelete/diskid/{diskid}/vuid/{vuid}/bid/{bid}
@@ -419,7 +499,7 @@ func (s *Service) ShardDelete(c *rpc.Context) {
 
 /*
  *  method:         POST
- *  url:            /shard/put/diskid/{diskid}/vuid/{vuid}/bid/{bid}/size/{size}?iotype={iotype}
+ *  url:            /shard/put/diskid/{diskid}/vuid/{vuid}/bid/{bid}/size/{size}?iotype={iotype}&epoch={epoch}
  *  request body:   bidData
  */
 func (s *Service) ShardPut(c *rpc.Context) {
@@ -482,6 +562,15 @@ func (s *Service) ShardPut(c *rpc.Context) {
 		return
 	}
 
+	// the first write of the new holder of the volume fences the writes of the stale holders
+	if args.Epoch > cs.Epoch() {
+		if err = ds.FenceChunk(ctx, args.Vuid, args.Epoch); err != nil && cs.Epoch() < args.Epoch {
+			span.Errorf("Failed to raise chunk epoch, args: %+v, err: %v", args, err)
+			c.RespondError(err)
+			return
+		}
+	}
+
 	if !cs.HasEnoughSpace(args.Size) {
 		span.Errorf("cs has no enougn space. args:%v, chunk info:%v, disk:%v",
 			args, cs.ChunkInfo(ctx), cs.Disk().Stats())
@@ -490,6 +579,7 @@ func (s *Service) ShardPut(c *rpc.Context) {
 	}
 
 	shard := core.NewShardWriter(args.Bid, args.Vuid, uint32(args.Size), c.Request.Body)
+	shard.Epoch = args.Epoch
 
 	start := time.Now()
 
@@ -31,6 +31,7 @@ import (
 	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
 	bloberr "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
 )
 
 func noLimitClient() bnapi.StorageAPI {
@@ -704,6 +705,63 @@ func TestShardDelete(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestShardStats(t *testing.T) {
+	service, _ := newTestBlobNodeService(t, "ShardStats")
+	defer cleanTestBlobNodeService(service)
+
+	host := runTestServer(service)
+	client := bnapi.New(&bnapi.Config{})
+	ctx := context.TODO()
+
+	diskID := proto.DiskID(101)
+	vuid := proto.Vuid(2001)
+	shardData := []byte("testData")
+	dataCrc := crc32.ChecksumIEEE(shardData)
+
+	statShardsArg := &bnapi.StatShardsArgs{
+		DiskID: proto.DiskID(0),
+		Vuid:   vuid,
+		Bids:   []proto.BlobID{30001, 30002, 30003},
+		Verify: true,
+	}
+	_, err := client.StatShards(ctx, host, statShardsArg)
+	require.Error(t, err)
+	statShardsArg.DiskID = diskID
+	_, err = client.StatShards(ctx, host, statShardsArg)
+	require.Error(t, err)
+
+	err = client.CreateChunk(ctx, host, &bnapi.CreateChunkArgs{DiskID: diskID, Vuid: vuid})
+	require.NoError(t, err)
+	for _, bid := range []proto.BlobID{30001, 30002} {
+		_, err = client.PutShard(ctx, host, &bnapi.PutShardArgs{
+			DiskID: diskID,
+			Vuid:   vuid,
+			Bid:    bid,
+			Size:   int64(len(shardData)),
+			Body:   bytes.NewReader(shardData),
+		})
+		require.NoError(t, err)
+	}
+	err = client.MarkDeleteShard(ctx, host, &bnapi.DeleteShardArgs{DiskID: diskID, Vuid: vuid, Bid: 30002})
+	require.NoError(t, err)
+
+	stats, err := client.StatShards(ctx, host, statShardsArg)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(stats))
+	require.Equal(t, 0, stats[0].Code)
+	require.Equal(t, proto.BlobID(30001), stats[0].Bid)
+	require.Equal(t, int64(len(shardData)), stats[0].Size)
+	require.Equal(t, dataCrc, stats[0].Crc)
+	require.Equal(t, bnapi.ShardStatusNormal, stats[0].Flag)
+	require.Equal(t, 0, stats[1].Code)
+	require.Equal(t, bnapi.ShardStatusMarkDelete, stats[1].Flag)
+	require.Equal(t, bloberr.CodeBidNotFound, stats[2].Code)
+
+	statShardsArg.Bids = make([]proto.BlobID, ShardStatsLimit+1)
+	_, err = client.StatShards(ctx, host, statShardsArg)
+	require.Equal(t, bloberr.CodeShardListExceedLimit, rpc.DetectStatusCode(err))
+}
+
 func TestShardDeleteConcurrency(t *testing.T) {
 	service, _ := newTestBlobNodeService(t, "ShardDeleteCon")
 	defer cleanTestBlobNodeService(service)
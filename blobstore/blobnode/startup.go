@@ -360,7 +360,7 @@ func NewService(conf Config) (svr *Service, err error) {
 	}
 	config.Register(callBackFn)
 
-	svr.WorkerService, err = NewWorkerService(&conf.WorkerConfig, clusterMgrCli, conf.ClusterID, conf.IDC)
+	svr.WorkerService, err = NewWorkerService(&conf.WorkerConfig, clusterMgrCli, conf.ClusterID, conf.IDC, conf.Host)
 	if err != nil {
 		span.Errorf("Failed to new worker service, err: %v", err)
 		return
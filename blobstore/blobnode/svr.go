@@ -65,6 +65,8 @@ type Service struct {
 
 	closed  bool
 	closeCh chan struct{}
+
+	admin *adminServer // admin operations on the local unix socket
 }
 
 func (s *Service) requestCounter(c *rpc.Context) {
@@ -100,6 +102,8 @@ func (s *Service) Close() {
 	close(s.closeCh)
 	span.Warnf("close closeCh done")
 
+	s.closeAdmin()
+
 	s.cancel()
 
 	// wait all requests done.
@@ -16,6 +16,7 @@ package blobnode
 
 import (
 	"context"
+	"sync"
 
 	"github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/blobnode/base/workutils"
@@ -28,6 +29,7 @@ import (
 
 // InspectTaskMgr inspect task manager
 type InspectTaskMgr struct {
+	mu        sync.Mutex
 	taskLimit limit.Limiter
 	bidGetter client.IBlobNode
 	reporter  scheduler.IInspector
@@ -45,12 +47,13 @@ func NewInspectTaskMgr(concurrency int, bidGetter client.IBlobNode, reporter sch
 // AddTask adds inspect task
 func (mgr *InspectTaskMgr) AddTask(ctx context.Context, task *proto.VolumeInspectTask) error {
 	span := trace.SpanFromContextSafe(ctx)
-	if err := mgr.taskLimit.Acquire(); err != nil {
+	taskLimit := mgr.getTaskLimit()
+	if err := taskLimit.Acquire(); err != nil {
 		return err
 	}
 
 	go func() {
-		defer mgr.taskLimit.Release()
+		defer taskLimit.Release()
 		ret := mgr.doInspect(ctx, task)
 		if err := mgr.reporter.CompleteInspectTask(ctx, ret); err != nil {
 			span.Errorf("report inspect result failed: result[%+v], err[%+v]", ret, err)
@@ -62,7 +65,21 @@ func (mgr *InspectTaskMgr) AddTask(ctx context.Context, task *proto.VolumeInspec
 
 // RunningTaskSize returns running inspect task size
 func (mgr *InspectTaskMgr) RunningTaskSize() int {
-	return mgr.taskLimit.Running()
+	return mgr.getTaskLimit().Running()
+}
+
+// SetConcurrency resets concurrency of inspect task,
+// running tasks are still released to the previous limiter.
+func (mgr *InspectTaskMgr) SetConcurrency(concurrency int) {
+	mgr.mu.Lock()
+	mgr.taskLimit = count.New(concurrency)
+	mgr.mu.Unlock()
+}
+
+func (mgr *InspectTaskMgr) getTaskLimit() limit.Limiter {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.taskLimit
 }
 
 func (mgr *InspectTaskMgr) doInspect(ctx context.Context, task *proto.VolumeInspectTask) *proto.VolumeInspectRet {
@@ -172,6 +172,7 @@ type TaskRunner struct {
 	taskID string
 	w      ITaskWorker
 	idc    string
+	worker string
 
 	taskletRunConcurrency int
 	state                 taskState
@@ -189,7 +190,7 @@ type TaskRunner struct {
 }
 
 // NewTaskRunner return task runner
-func NewTaskRunner(ctx context.Context, taskID string, w ITaskWorker, idc string,
+func NewTaskRunner(ctx context.Context, taskID string, w ITaskWorker, idc, worker string,
 	taskletRunConcurrency int, taskCounter *taskCounter, schedulerCli scheduler.IMigrator) *TaskRunner {
 	span, ctx := trace.StartSpanFromContext(ctx, "taskRunner")
 	ctx, cancel := context.WithCancel(ctx)
@@ -198,6 +199,7 @@ func NewTaskRunner(ctx context.Context, taskID string, w ITaskWorker, idc string
 		taskID:                taskID,
 		w:                     w,
 		idc:                   idc,
+		worker:                worker,
 		taskletRunConcurrency: taskletRunConcurrency,
 		ctx:                   ctx,
 		cancel:                cancel,
@@ -315,6 +317,7 @@ func (r *TaskRunner) cancelOrReclaim(retErr *WorkError) {
 	if ShouldReclaim(retErr) {
 		args := r.w.OperateArgs()
 		args.IDC = r.idc
+		args.Worker = r.worker
 		args.Reason = retErr.Error()
 		span.Infof("reclaim task: taskID[%s], err[%s]", r.taskID, retErr.String())
 		if err := r.schedulerCli.ReclaimTask(r.newCtx(), &args); err != nil {
@@ -329,6 +332,7 @@ func (r *TaskRunner) cancelOrReclaim(retErr *WorkError) {
 
 	args := r.w.OperateArgs()
 	args.IDC = r.idc
+	args.Worker = r.worker
 	args.Reason = retErr.Error()
 	if err := r.schedulerCli.CancelTask(r.newCtx(), &args); err != nil {
 		span.Errorf("cancel failed: taskID[%s], args[%+v], code[%d], err[%+v]",
@@ -343,6 +347,7 @@ func (r *TaskRunner) completeTask() {
 	r.span.Infof("complete task: taskID[%s]", r.taskID)
 	args := r.w.OperateArgs()
 	args.IDC = r.idc
+	args.Worker = r.worker
 	if err := r.schedulerCli.CompleteTask(r.newCtx(), &args); err != nil {
 		r.span.Errorf("complete failed: taskID[%s], args[%+v], code[%d], err[%+v]",
 			r.taskID, args, rpc.DetectStatusCode(err), err)
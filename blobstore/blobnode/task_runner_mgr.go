@@ -29,7 +29,10 @@ import (
 	"github.com/cubefs/cubefs/blobstore/util/log"
 )
 
-var errAddRunningTaskAgain = errors.New("running task add again")
+var (
+	errAddRunningTaskAgain = errors.New("running task add again")
+	errTaskTypeDisabled    = errors.New("task type is disabled")
+)
 
 // WorkerGenerator generates task worker.
 type WorkerGenerator = func(task MigrateTaskEx) ITaskWorker
@@ -45,7 +48,9 @@ type TaskRunnerMgr struct {
 	typeMgr map[proto.TaskType]mapTaskRunner
 
 	idc          string
+	host         string
 	meter        WorkerConfigMeter
+	switches     map[proto.TaskType]bool
 	genWorker    WorkerGenerator
 	renewalCli   scheduler.IMigrator // TODO: must be timeout in proto.RenewalTimeoutS
 	schedulerCli scheduler.IMigrator
@@ -53,18 +58,21 @@ type TaskRunnerMgr struct {
 }
 
 // NewTaskRunnerMgr returns task runner manager
-func NewTaskRunnerMgr(idc string, meter WorkerConfigMeter, genWorker WorkerGenerator,
+func NewTaskRunnerMgr(idc, host string, meter WorkerConfigMeter, genWorker WorkerGenerator,
 	renewalCli, schedulerCli scheduler.IMigrator) *TaskRunnerMgr {
 	return &TaskRunnerMgr{
 		typeMgr: map[proto.TaskType]mapTaskRunner{
-			proto.TaskTypeBalance:       make(mapTaskRunner),
-			proto.TaskTypeDiskDrop:      make(mapTaskRunner),
-			proto.TaskTypeDiskRepair:    make(mapTaskRunner),
-			proto.TaskTypeManualMigrate: make(mapTaskRunner),
+			proto.TaskTypeBalance:          make(mapTaskRunner),
+			proto.TaskTypeIntraNodeBalance: make(mapTaskRunner),
+			proto.TaskTypeDiskDrop:         make(mapTaskRunner),
+			proto.TaskTypeDiskRepair:       make(mapTaskRunner),
+			proto.TaskTypeManualMigrate:    make(mapTaskRunner),
 		},
 
 		idc:          idc,
+		host:         host,
 		meter:        meter,
+		switches:     make(map[proto.TaskType]bool),
 		genWorker:    genWorker,
 		renewalCli:   renewalCli,
 		schedulerCli: schedulerCli,
@@ -130,10 +138,13 @@ func (tm *TaskRunnerMgr) AddTask(ctx context.Context, task MigrateTaskEx) error
 	if !ok {
 		return fmt.Errorf("invalid task type: %s", t.TaskType)
 	}
+	if !tm.taskEnabled(t.TaskType) {
+		return errTaskTypeDisabled
+	}
 
 	w := tm.genWorker(task)
 	concurrency := tm.meter.concurrencyByType(t.TaskType)
-	runner := NewTaskRunner(ctx, t.TaskID, w, t.SourceIDC, concurrency, &tm.taskCounter, tm.schedulerCli)
+	runner := NewTaskRunner(ctx, t.TaskID, w, t.SourceIDC, tm.host, concurrency, &tm.taskCounter, tm.schedulerCli)
 	if err := mgr.addTask(t.TaskID, runner); err != nil {
 		return err
 	}
@@ -142,6 +153,32 @@ func (tm *TaskRunnerMgr) AddTask(ctx context.Context, task MigrateTaskEx) error
 	return nil
 }
 
+// SetMeter updates worker config meter, it takes effect on new added tasks.
+func (tm *TaskRunnerMgr) SetMeter(meter WorkerConfigMeter) {
+	tm.mu.Lock()
+	tm.meter = meter
+	tm.mu.Unlock()
+}
+
+// SetTaskSwitches updates enable flags of task types, task type not in switches is enabled.
+func (tm *TaskRunnerMgr) SetTaskSwitches(switches map[proto.TaskType]bool) {
+	tm.mu.Lock()
+	tm.switches = switches
+	tm.mu.Unlock()
+}
+
+// TaskEnabled returns true if the task type is enabled on this worker.
+func (tm *TaskRunnerMgr) TaskEnabled(taskType proto.TaskType) bool {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	return tm.taskEnabled(taskType)
+}
+
+func (tm *TaskRunnerMgr) taskEnabled(taskType proto.TaskType) bool {
+	enabled, ok := tm.switches[taskType]
+	return !ok || enabled
+}
+
 // GetAliveTasks returns all alive migrate task.
 func (tm *TaskRunnerMgr) GetAliveTasks() map[proto.TaskType][]string {
 	tm.mu.Lock()
@@ -155,6 +192,18 @@ func (tm *TaskRunnerMgr) GetAliveTasks() map[proto.TaskType][]string {
 	return all
 }
 
+// StopTask stops the runner of the task whatever its type is.
+func (tm *TaskRunnerMgr) StopTask(taskID string) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	for _, mgr := range tm.typeMgr {
+		if _, ok := mgr[taskID]; ok {
+			return mgr.stopTask(taskID)
+		}
+	}
+	return fmt.Errorf("no such task: %s", taskID)
+}
+
 // StopAllAliveRunner stops all alive runner
 func (tm *TaskRunnerMgr) StopAllAliveRunner() {
 	tm.mu.Lock()
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/blobnode/base/workutils"
@@ -44,6 +45,11 @@ type MigrateWorker struct {
 	benchmarkBids            []*ShardInfoSimple
 	downloadShardConcurrency int
 	forbiddenDirectDownload  bool
+
+	slowShardTimeout   time.Duration
+	slowShardSkipLimit int
+	skippedMu          sync.Mutex
+	skippedBids        []proto.BlobID // bids skipped since too slow to recover, repaired later by the record
 }
 
 // MigrateTaskEx migrate task execution machine
@@ -52,6 +58,11 @@ type MigrateTaskEx struct {
 
 	downloadShardConcurrency int
 	blobNodeCli              client.IBlobNode
+
+	// download of a shard exceeding the timeout is taken as stalled, and at most
+	// slowShardSkipLimit bids stalled on all the replicas are skipped in the task
+	slowShardTimeout   time.Duration
+	slowShardSkipLimit int
 }
 
 // NewMigrateWorker returns migrate worker
@@ -61,6 +72,8 @@ func NewMigrateWorker(task MigrateTaskEx) ITaskWorker {
 		bolbNodeCli:              task.blobNodeCli,
 		downloadShardConcurrency: task.downloadShardConcurrency,
 		forbiddenDirectDownload:  task.taskInfo.ForbiddenDirectDownload,
+		slowShardTimeout:         task.slowShardTimeout,
+		slowShardSkipLimit:       task.slowShardSkipLimit,
 	}
 }
 
@@ -108,6 +121,9 @@ func (w *MigrateWorker) ExecTasklet(ctx context.Context, tasklet Tasklet) *WorkE
 	mode := w.t.CodeMode
 	shardRecover := NewShardRecover(replicas, mode, tasklet.bids, w.bolbNodeCli, w.downloadShardConcurrency, w.t.TaskType)
 	defer shardRecover.ReleaseBuf()
+	if w.slowShardTimeout > 0 {
+		shardRecover.SetSlowShardPolicy(w.slowShardTimeout, w.skipSlowBids)
+	}
 
 	return MigrateBids(ctx,
 		shardRecover,
@@ -118,9 +134,46 @@ func (w *MigrateWorker) ExecTasklet(ctx context.Context, tasklet Tasklet) *WorkE
 		w.bolbNodeCli)
 }
 
+// skipSlowBids records the bids to skip if the task does not exceed the skip limit
+func (w *MigrateWorker) skipSlowBids(bids []proto.BlobID) bool {
+	w.skippedMu.Lock()
+	defer w.skippedMu.Unlock()
+	if len(w.skippedBids)+len(bids) > w.slowShardSkipLimit {
+		return false
+	}
+	w.skippedBids = append(w.skippedBids, bids...)
+	return true
+}
+
+func (w *MigrateWorker) getSkippedBids() []proto.BlobID {
+	w.skippedMu.Lock()
+	defer w.skippedMu.Unlock()
+	if len(w.skippedBids) == 0 {
+		return nil
+	}
+	return append([]proto.BlobID(nil), w.skippedBids...)
+}
+
 // Check checks migrate task execute result
 func (w *MigrateWorker) Check(ctx context.Context) *WorkError {
-	return CheckVunit(ctx, w.benchmarkBids, w.t.Destination, w.bolbNodeCli)
+	skippedBids := w.getSkippedBids()
+	if len(skippedBids) == 0 {
+		return CheckVunit(ctx, w.benchmarkBids, w.t.Destination, w.bolbNodeCli)
+	}
+
+	span := trace.SpanFromContextSafe(ctx)
+	span.Warnf("check without skipped bids: taskID[%s], skipped bids[%v]", w.t.TaskID, skippedBids)
+	skipped := make(map[proto.BlobID]struct{}, len(skippedBids))
+	for _, bid := range skippedBids {
+		skipped[bid] = struct{}{}
+	}
+	expectBids := make([]*ShardInfoSimple, 0, len(w.benchmarkBids))
+	for _, bid := range w.benchmarkBids {
+		if _, ok := skipped[bid.Bid]; !ok {
+			expectBids = append(expectBids, bid)
+		}
+	}
+	return CheckVunit(ctx, expectBids, w.t.Destination, w.bolbNodeCli)
 }
 
 // GetBenchmarkBids returns benchmark bids
@@ -131,10 +184,11 @@ func (w *MigrateWorker) GetBenchmarkBids() []*ShardInfoSimple {
 // OperateArgs args for cancel, complete, reclaim.
 func (w *MigrateWorker) OperateArgs() scheduler.OperateTaskArgs {
 	return scheduler.OperateTaskArgs{
-		TaskID:   w.t.TaskID,
-		TaskType: w.t.TaskType,
-		Src:      w.t.Sources,
-		Dest:     w.t.Destination,
+		TaskID:      w.t.TaskID,
+		TaskType:    w.t.TaskType,
+		Src:         w.t.Sources,
+		Dest:        w.t.Destination,
+		SkippedBids: w.getSkippedBids(),
 	}
 }
 
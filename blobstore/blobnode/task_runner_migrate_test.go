@@ -16,7 +16,9 @@ package blobnode
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -324,3 +326,86 @@ func TestMigrateArgs(t *testing.T) {
 	require.Equal(t, diskDropTask.Sources, args.Src)
 	require.Equal(t, diskDropTask.Destination, args.Dest)
 }
+
+func TestMigrateSkipSlowBids(t *testing.T) {
+	mode := codemode.EC6P6
+	replicas := genMockVol(100, mode)
+	task := &proto.MigrateTask{
+		TaskID:      "mock_balance_task_id",
+		TaskType:    proto.TaskTypeBalance,
+		CodeMode:    codemode.CodeMode(mode),
+		Sources:     replicas,
+		Destination: replicas[0],
+		SourceVuid:  replicas[0].Vuid,
+	}
+	bids := []proto.BlobID{1, 2, 3, 4, 5}
+	sizes := []int64{1024, 2048, 0, 512, 23}
+	getter := NewMockGetterWithBids(replicas, codemode.CodeMode(mode), bids, sizes)
+	w := NewMigrateWorker(MigrateTaskEx{
+		taskInfo: task, blobNodeCli: getter, downloadShardConcurrency: 1,
+		slowShardTimeout: time.Second, slowShardSkipLimit: 2,
+	}).(*MigrateWorker)
+
+	require.True(t, w.skipSlowBids([]proto.BlobID{1}))
+	require.False(t, w.skipSlowBids([]proto.BlobID{2, 3}))
+	require.True(t, w.skipSlowBids([]proto.BlobID{2}))
+	require.Equal(t, []proto.BlobID{1, 2}, w.OperateArgs().SkippedBids)
+
+	ctx := context.Background()
+	sr := NewShardRecover(replicas, codemode.CodeMode(mode), nil, getter, 1, proto.TaskTypeBalance)
+	sr.SetSlowShardPolicy(time.Second, func(bids []proto.BlobID) bool { return len(bids) == 1 })
+	sr.markSlow(1)
+	require.False(t, sr.trySkipSlowBids(ctx, []proto.BlobID{1, 2}))
+	require.True(t, sr.trySkipSlowBids(ctx, []proto.BlobID{1}))
+	require.True(t, sr.Skipped(1))
+	require.False(t, sr.Skipped(2))
+}
+
+func TestMigrateDiffDestShards(t *testing.T) {
+	ctx := context.Background()
+	mode := codemode.EC6P6
+	replicas := genMockVol(100, mode)
+	idx := 3
+	bids := []proto.BlobID{1, 2, 3, 4}
+	sizes := []int64{1024, 2048, 512, 23}
+	getter := NewMockGetterWithBids(replicas, mode, bids, sizes)
+
+	// the destination with the shards put by the previous attempt
+	src := replicas[idx]
+	dst := src
+	dst.Vuid, _ = proto.NewVuid(100, uint8(idx), 2)
+	getter.vunits[dst.Vuid] = newMockVunit(dst.Vuid, api.ChunkStatusNormal)
+	var shards []*ShardInfoSimple
+	for i, bid := range bids {
+		data, _, err := getter.vunits[src.Vuid].getShard(bid)
+		require.NoError(t, err)
+		buf := make([]byte, sizes[i])
+		_, err = io.ReadFull(data, buf)
+		require.NoError(t, err)
+		getter.vunits[dst.Vuid].putShard(bid, buf)
+		shards = append(shards, &ShardInfoSimple{Bid: bid, Size: sizes[i]})
+	}
+	require.Len(t, DiffDestShards(ctx, getter, &src, dst, shards), 0)
+
+	// the corrupted shard and the shard differs from the source
+	getter.CorruptShard(dst.Vuid, bids[0])
+	getter.vunits[dst.Vuid].putShard(bids[1], genMockBytes('x', sizes[1]))
+	diff := DiffDestShards(ctx, getter, &src, dst, shards)
+	require.Equal(t, []proto.BlobID{bids[0], bids[1]}, GetBids(diff))
+	diff = DiffDestShards(ctx, getter, nil, dst, shards)
+	require.Equal(t, []proto.BlobID{bids[0]}, GetBids(diff))
+
+	// the shard missed in the destination
+	getter.Delete(ctx, dst.Vuid, bids[2])
+	diff = DiffDestShards(ctx, getter, nil, dst, shards)
+	require.Equal(t, []proto.BlobID{bids[0], bids[2]}, GetBids(diff))
+
+	// the source failed to stat
+	getter.setFail(src.Vuid, errors.New("fake error"))
+	diff = DiffDestShards(ctx, getter, &src, dst, shards)
+	require.Equal(t, []proto.BlobID{bids[0], bids[2]}, GetBids(diff))
+
+	// the destination failed to verify is compared by size only
+	getter.setFail(dst.Vuid, errors.New("fake error"))
+	require.Len(t, DiffDestShards(ctx, getter, &src, dst, shards), 0)
+}
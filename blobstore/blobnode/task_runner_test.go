@@ -121,7 +121,7 @@ func TestTaskRunner(t *testing.T) {
 	stats := &mockStats{}
 	cli := newMockSchedulerCli(t, stats)
 	run := func(worker ITaskWorker) {
-		runner := NewTaskRunner(context.Background(), taskID, worker, idc, 3, &taskCounter{}, cli)
+		runner := NewTaskRunner(context.Background(), taskID, worker, idc, "", 3, &taskCounter{}, cli)
 		stats.step = ""
 		stats.wg.Add(1)
 		go runner.Run()
@@ -132,7 +132,7 @@ func TestTaskRunner(t *testing.T) {
 		log.Info("start test tasklet stop")
 		blocking := make(chan struct{})
 		worker := &mockWorker{blocking: blocking}
-		runner := NewTaskRunner(context.Background(), taskID, worker, idc, 2, &taskCounter{}, cli)
+		runner := NewTaskRunner(context.Background(), taskID, worker, idc, "", 2, &taskCounter{}, cli)
 		stats.step = ""
 		stats.wg.Add(1)
 		go runner.Run()
@@ -150,7 +150,7 @@ func TestTaskRunner(t *testing.T) {
 		log.Info("start test tasklet fail")
 		blocking := make(chan struct{})
 		worker := &mockWorker{blocking: blocking, taskRetErr: errors.New("mock fail")}
-		runner := NewTaskRunner(context.Background(), taskID, worker, idc, 3, &taskCounter{}, cli)
+		runner := NewTaskRunner(context.Background(), taskID, worker, idc, "", 3, &taskCounter{}, cli)
 		stats.step = ""
 		stats.wg.Add(1)
 		go runner.Run()
@@ -21,6 +21,7 @@ import (
 	"io"
 	"math/rand"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/cubefs/cubefs/blobstore/api/blobnode"
@@ -46,6 +47,7 @@ var (
 	errShardSizeNotMatch    = errors.New("shard data size not match")
 	errBufNotEnough         = errors.New("buf space not enough")
 	errInvalidReplicas      = errors.New("invalid volume replicas")
+	errShardDownloadSlow    = errors.New("shard download stalled")
 )
 
 const defaultGetConcurrency = 100
@@ -394,6 +396,14 @@ type ShardRecover struct {
 	ioType                   blobnode.IOType
 	taskType                 proto.TaskType
 	ds                       *downloadStatus
+
+	// the download of a shard exceeding shardTimeout is taken as stalled, and the bids
+	// can not recover only due to the stalls are skipped if skipSlow allows
+	shardTimeout time.Duration
+	skipSlow     func(bids []proto.BlobID) bool
+	slowMu       sync.Mutex
+	slowBids     map[proto.BlobID]struct{}
+	skippedBids  map[proto.BlobID]struct{}
 }
 
 // NewShardRecover returns shard recover
@@ -415,10 +425,57 @@ func NewShardRecover(replicas Vunits, mode codemode.CodeMode, bidInfos []*ShardI
 		taskType:                 taskType,
 		vunitShardGetConcurrency: vunitShardGetConcurrency,
 		ds:                       newDownloadStatus(),
+		slowBids:                 make(map[proto.BlobID]struct{}),
+		skippedBids:              make(map[proto.BlobID]struct{}),
 	}
 	return &repair
 }
 
+// SetSlowShardPolicy limits the download of a shard in timeout, skip decides whether
+// the bids stalled on all the replicas can be skipped.
+func (r *ShardRecover) SetSlowShardPolicy(timeout time.Duration, skip func(bids []proto.BlobID) bool) {
+	r.shardTimeout = timeout
+	r.skipSlow = skip
+}
+
+// Skipped returns true if the bid is skipped since it is too slow to recover
+func (r *ShardRecover) Skipped(bid proto.BlobID) bool {
+	r.slowMu.Lock()
+	defer r.slowMu.Unlock()
+	_, ok := r.skippedBids[bid]
+	return ok
+}
+
+func (r *ShardRecover) markSlow(bid proto.BlobID) {
+	r.slowMu.Lock()
+	r.slowBids[bid] = struct{}{}
+	r.slowMu.Unlock()
+}
+
+// trySkipSlowBids skips the fail bids if all of them are stalled.
+func (r *ShardRecover) trySkipSlowBids(ctx context.Context, failBids []proto.BlobID) bool {
+	if r.skipSlow == nil {
+		return false
+	}
+	span := trace.SpanFromContextSafe(ctx)
+	r.slowMu.Lock()
+	defer r.slowMu.Unlock()
+	for _, bid := range failBids {
+		if _, ok := r.slowBids[bid]; !ok {
+			return false
+		}
+	}
+	if !r.skipSlow(failBids) {
+		span.Warnf("slow bids exceed the skip limit: bids[%v]", failBids)
+		return false
+	}
+	for _, bid := range failBids {
+		r.skippedBids[bid] = struct{}{}
+	}
+	span.Warnf("skip slow bids: bids[%v]", failBids)
+	return true
+}
+
 // RecoverShards recover shards
 func (r *ShardRecover) RecoverShards(ctx context.Context, repairIdxs []uint8, direct bool) error {
 	span := trace.SpanFromContextSafe(ctx)
@@ -489,6 +546,9 @@ func (r *ShardRecover) recoverReplicaShards(ctx context.Context, repairIdxs []ui
 
 	failBids = r.collectFailBids(failBids, repairIdxs)
 	if len(failBids) != 0 {
+		if r.trySkipSlowBids(ctx, failBids) {
+			return nil
+		}
 		span.Errorf("recoverReplicaShards failed: failBids len[%d]", len(failBids))
 		return errBidCanNotRecover
 	}
@@ -633,7 +693,8 @@ func (r *ShardRecover) downloadReplShards(ctx context.Context, replica proto.Vun
 			}
 
 			span.Errorf("download shard: replica[%+v],index[%d], bid[%d], err[%+v]", replica, replica.Vuid.Index(), downloadBid, err)
-			if AllShardsCanNotDownload(err) {
+			// a stalled shard does not mean the others of the replica can not download
+			if err != errShardDownloadSlow && AllShardsCanNotDownload(err) {
 				span.Infof("all shards can not download, so cancel download: replica[%+v]", replica)
 				cancel()
 			}
@@ -652,15 +713,36 @@ func (r *ShardRecover) downloadShard(ctx context.Context, replica proto.VunitLoc
 		span.Infof("download cancel: replica[%+v],  bid[%d]", replica, bid)
 		return nil
 	default:
-		data, crc1, err := r.shardGetter.GetShard(ctx, replica, bid, r.ioType)
+		getCtx := ctx
+		if r.shardTimeout > 0 {
+			var cancel context.CancelFunc
+			getCtx, cancel = context.WithTimeout(ctx, r.shardTimeout)
+			defer cancel()
+		}
+		stalled := func() bool {
+			if ctx.Err() == nil && getCtx.Err() == context.DeadlineExceeded {
+				r.markSlow(bid)
+				return true
+			}
+			return false
+		}
+
+		data, crc1, err := r.shardGetter.GetShard(getCtx, replica, bid, r.ioType)
 		r.ds.downloaded(replica.Vuid)
 		if err != nil {
 			span.Errorf("download failed: replica[%+v], bid[%d], err[%+v]", replica, bid, err)
+			if stalled() {
+				return errShardDownloadSlow
+			}
 			return err
 		}
 
 		err = r.chunksShardsBuf[replica.Vuid.Index()].PutShard(bid, data)
 		data.Close()
+		if err != nil && stalled() {
+			span.Errorf("download stalled: replica[%+v], bid[%d], err[%+v]", replica, bid, err)
+			return errShardDownloadSlow
+		}
 		if err == errBidNotFoundInBuf {
 			span.Errorf("unexpect put shard failed: err[%+v]", err)
 			return err
@@ -840,7 +922,8 @@ func (r *ShardRecover) allocBuf(ctx context.Context, vunitIdxs []uint8) error {
 			switch r.taskType {
 			case proto.TaskTypeShardRepair:
 				buf, err = workutils.TaskBufPool.GetRepairBuf()
-			case proto.TaskTypeDiskRepair, proto.TaskTypeBalance, proto.TaskTypeManualMigrate, proto.TaskTypeDiskDrop:
+			case proto.TaskTypeDiskRepair, proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance,
+				proto.TaskTypeManualMigrate, proto.TaskTypeDiskDrop:
 				buf, err = workutils.TaskBufPool.GetMigrateBuf()
 			default:
 				err = errors.New("unknown type")
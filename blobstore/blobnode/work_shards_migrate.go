@@ -60,19 +60,98 @@ func GenMigrateBids(ctx context.Context, blobnodeCli client.IBlobNode, srcReplic
 		existInDest[bid.Bid] = bid.Size
 	}
 
+	var existBids []*ShardInfoSimple
 	for _, bid := range benchmarkBids {
 		if size, ok := existInDest[bid.Bid]; ok && size == bid.Size {
 			span.Debugf("benchmarkBids bid exist in dest: bid[%d]", bid.Bid)
+			existBids = append(existBids, bid)
 			continue
 		}
 		span.Debugf("benchmarkBids append: bid[%d], size[%d]", bid.Bid, bid.Size)
 		migBids = append(migBids, bid)
 	}
 
-	span.Infof("benchmarkBids: len[%d]", len(migBids))
+	// the shards put to the destination by the previous attempts are not transferred again if identical,
+	// the source of the same index is compared if it is not bad
+	var src *proto.VunitLocation
+	if !isBadIdx(badIdxs, dst.Vuid.Index()) {
+		for idx := range srcReplicas {
+			if srcReplicas[idx].Vuid.Index() == dst.Vuid.Index() && srcReplicas[idx].Vuid != dst.Vuid {
+				src = &srcReplicas[idx]
+				break
+			}
+		}
+	}
+	diffBids := DiffDestShards(ctx, blobnodeCli, src, dst, existBids)
+	migBids = append(migBids, diffBids...)
+
+	span.Infof("benchmarkBids: len[%d], identical in dest[%d]", len(migBids), len(existBids)-len(diffBids))
 	return migBids, benchmarkBids, nil
 }
 
+// DiffDestShards returns the bids whose shards on the destination are not identical, the shards are
+// verified by the crcs on the destination, and compared with the crcs of the source if src is not nil.
+// The shards are taken as identical if the destination fails to verify, such as the old blobnode.
+func DiffDestShards(ctx context.Context, blobnodeCli client.IBlobNode, src *proto.VunitLocation,
+	dst proto.VunitLocation, bids []*ShardInfoSimple,
+) (diffBids []*ShardInfoSimple) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	for start := 0; start < len(bids); start += ShardStatsLimit {
+		end := start + ShardStatsLimit
+		if end > len(bids) {
+			end = len(bids)
+		}
+		batch := bids[start:end]
+		ids := GetBids(batch)
+		destStats, err := blobnodeCli.StatShards(ctx, dst, ids, true)
+		if err != nil {
+			span.Warnf("verify shards in dest failed and compare by size: dest[%+v], bids len[%d], err[%+v]", dst, len(ids), err)
+			continue
+		}
+		destStatsMap := shardStatsMap(destStats)
+		var srcStatsMap map[proto.BlobID]*client.ShardStat
+		if src != nil {
+			srcStats, err := blobnodeCli.StatShards(ctx, *src, ids, false)
+			if err != nil {
+				span.Warnf("stat shards in source failed: src[%+v], bids len[%d], err[%+v]", *src, len(ids), err)
+			}
+			srcStatsMap = shardStatsMap(srcStats)
+		}
+
+		for _, bid := range batch {
+			destStat, ok := destStatsMap[bid.Bid]
+			if !ok || !destStat.Intact() || destStat.Size != bid.Size {
+				span.Warnf("shard in dest is not intact: bid[%d], stat[%+v]", bid.Bid, destStat)
+				diffBids = append(diffBids, bid)
+				continue
+			}
+			if srcStat, ok := srcStatsMap[bid.Bid]; ok && srcStat.Intact() && srcStat.Crc != destStat.Crc {
+				span.Warnf("shard in dest differs from source: bid[%d], src crc[%d], dest crc[%d]", bid.Bid, srcStat.Crc, destStat.Crc)
+				diffBids = append(diffBids, bid)
+			}
+		}
+	}
+	return diffBids
+}
+
+func shardStatsMap(stats []*client.ShardStat) map[proto.BlobID]*client.ShardStat {
+	m := make(map[proto.BlobID]*client.ShardStat, len(stats))
+	for _, stat := range stats {
+		m[stat.Bid] = stat
+	}
+	return m
+}
+
+func isBadIdx(badIdxs []uint8, idx uint8) bool {
+	for _, badIdx := range badIdxs {
+		if badIdx == idx {
+			return true
+		}
+	}
+	return false
+}
+
 // MigrateBids migrate the bids data to destination
 func MigrateBids(ctx context.Context, shardRecover *ShardRecover, badIdx uint8, destLocation proto.VunitLocation,
 	direct bool, bids []*ShardInfoSimple, blobnodeCli client.IBlobNode) *WorkError {
@@ -90,6 +169,10 @@ func MigrateBids(ctx context.Context, shardRecover *ShardRecover, badIdx uint8,
 	span.Infof("put data to destination: dest[%+v]", destLocation)
 	destIdx := destLocation.Vuid.Index()
 	for _, bid := range bids {
+		if shardRecover.Skipped(bid.Bid) {
+			span.Warnf("skip put slow bid to destination: bid[%d]", bid.Bid)
+			continue
+		}
 		data, err := shardRecover.GetShard(destIdx, bid.Bid)
 		if err != nil {
 			return OtherError(err)
@@ -0,0 +1,124 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/taskswitch"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/limit"
+	"github.com/cubefs/cubefs/blobstore/util/limit/count"
+)
+
+// workerSwitchTaskTypes task types which can be enabled or disabled on worker
+var workerSwitchTaskTypes = []proto.TaskType{
+	proto.TaskTypeDiskRepair,
+	proto.TaskTypeBalance,
+	proto.TaskTypeIntraNodeBalance,
+	proto.TaskTypeDiskDrop,
+	proto.TaskTypeManualMigrate,
+	proto.TaskTypeVolumeInspect,
+	proto.TaskTypeShardRepair,
+}
+
+type workerConfigGetter interface {
+	GetConfig(ctx context.Context, key string) (string, error)
+}
+
+// reloadConfig reloads worker config meter and task switches from config center.
+// config meter in config center overwrites on the meter of config file,
+// and falls back to config file if the key is deleted.
+func (s *WorkerService) reloadConfig() {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "reloadWorkerConfig")
+
+	meter := s.baseMeter
+	val, err := s.configGetter.GetConfig(ctx, proto.WorkerConfigMeterKey)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal([]byte(val), &meter); err != nil {
+			span.Errorf("unmarshal worker config meter failed: val[%s], err[%+v]", val, err)
+			meter = s.WorkerConfigMeter
+		}
+		meter.checkAndFix()
+	case rpc.DetectStatusCode(err) == http.StatusNotFound:
+	default:
+		span.Errorf("get worker config meter failed: err[%+v]", err)
+		meter = s.WorkerConfigMeter
+	}
+	s.applyMeter(ctx, meter)
+
+	switches := make(map[proto.TaskType]bool, len(workerSwitchTaskTypes))
+	for _, taskType := range workerSwitchTaskTypes {
+		val, err := s.configGetter.GetConfig(ctx, proto.WorkerTaskSwitchKey(taskType))
+		if err != nil {
+			if rpc.DetectStatusCode(err) != http.StatusNotFound {
+				span.Errorf("get worker task switch failed: task_type[%s], err[%+v]", taskType, err)
+				switches[taskType] = s.taskRunnerMgr.TaskEnabled(taskType)
+			}
+			continue
+		}
+		switches[taskType] = val == taskswitch.SwitchOpen
+	}
+	s.taskRunnerMgr.SetTaskSwitches(switches)
+}
+
+func (s *WorkerService) applyMeter(ctx context.Context, meter WorkerConfigMeter) {
+	if meter == s.WorkerConfigMeter {
+		return
+	}
+
+	span := trace.SpanFromContextSafe(ctx)
+	span.Infof("worker config meter changed: old[%+v], new[%+v]", s.WorkerConfigMeter, meter)
+	old := s.WorkerConfigMeter
+	s.WorkerConfigMeter = meter
+
+	s.taskRunnerMgr.SetMeter(meter)
+	if meter.InspectConcurrency != old.InspectConcurrency {
+		s.inspectTaskMgr.SetConcurrency(meter.InspectConcurrency)
+	}
+	if meter.ShardRepairConcurrency != old.ShardRepairConcurrency {
+		s.mu.Lock()
+		s.shardRepairLimit = count.New(meter.ShardRepairConcurrency)
+		s.mu.Unlock()
+	}
+}
+
+func (s *WorkerService) getShardRepairLimit() limit.Limiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shardRepairLimit
+}
+
+// cancelTask gives back the acquired task to scheduler
+func (s *WorkerService) cancelTask(ctx context.Context, t *proto.MigrateTask, reason string) {
+	span := trace.SpanFromContextSafe(ctx)
+	args := scheduler.OperateTaskArgs{
+		IDC:      s.taskRunnerMgr.idc,
+		TaskID:   t.TaskID,
+		TaskType: t.TaskType,
+		Src:      t.Sources,
+		Dest:     t.Destination,
+		Reason:   reason,
+	}
+	if err := s.schedulerCli.CancelTask(ctx, &args); err != nil {
+		span.Errorf("cancel task failed: taskID[%s], code[%d], err[%+v]", t.TaskID, rpc.DetectStatusCode(err), err)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/taskswitch"
+)
+
+type mockConfigGetter map[string]string
+
+func (m mockConfigGetter) GetConfig(ctx context.Context, key string) (string, error) {
+	if val, ok := m[key]; ok {
+		return val, nil
+	}
+	return "", errcode.ErrNotFound
+}
+
+func TestWorkerReloadConfig(t *testing.T) {
+	svr, schedulerCli := newMockWorkService(t)
+	ws := svr.WorkerService
+	ws.WorkerConfigMeter.checkAndFix()
+	ws.baseMeter = ws.WorkerConfigMeter
+
+	getter := mockConfigGetter{
+		proto.WorkerConfigMeterKey:                           `{"max_task_runner_cnt":3,"inspect_concurrency":2,"shard_repair_concurrency":4,"balance_concurrency":5}`,
+		proto.WorkerTaskSwitchKey(proto.TaskTypeBalance):     taskswitch.SwitchClose,
+		proto.WorkerTaskSwitchKey(proto.TaskTypeDiskRepair):  taskswitch.SwitchOpen,
+		proto.WorkerTaskSwitchKey(proto.TaskTypeShardRepair): taskswitch.SwitchClose,
+	}
+	ws.configGetter = getter
+	ws.reloadConfig()
+
+	require.Equal(t, 3, ws.MaxTaskRunnerCnt)
+	require.Equal(t, 4, ws.ShardRepairConcurrency)
+	require.Equal(t, 5, ws.taskRunnerMgr.meter.BalanceConcurrency)
+	require.Equal(t, ws.baseMeter.DiskDropConcurrency, ws.DiskDropConcurrency)
+	require.False(t, ws.taskRunnerMgr.TaskEnabled(proto.TaskTypeBalance))
+	require.False(t, ws.taskRunnerMgr.TaskEnabled(proto.TaskTypeShardRepair))
+	require.True(t, ws.taskRunnerMgr.TaskEnabled(proto.TaskTypeDiskRepair))
+	require.True(t, ws.taskRunnerMgr.TaskEnabled(proto.TaskTypeDiskDrop))
+
+	// disabled task is given back to scheduler
+	schedulerCli.EXPECT().CancelTask(A, A).Return(nil)
+	err := ws.taskRunnerMgr.AddTask(context.Background(), MigrateTaskEx{
+		taskInfo: &proto.MigrateTask{TaskID: "balance_1", TaskType: proto.TaskTypeBalance},
+	})
+	require.ErrorIs(t, err, errTaskTypeDisabled)
+	ws.cancelTask(context.Background(), &proto.MigrateTask{TaskID: "balance_1", TaskType: proto.TaskTypeBalance}, err.Error())
+
+	// invalid meter keeps the current meter
+	getter[proto.WorkerConfigMeterKey] = "{"
+	ws.reloadConfig()
+	require.Equal(t, 3, ws.MaxTaskRunnerCnt)
+
+	// fall back to config file after keys deleted
+	delete(getter, proto.WorkerConfigMeterKey)
+	delete(getter, proto.WorkerTaskSwitchKey(proto.TaskTypeBalance))
+	ws.reloadConfig()
+	require.Equal(t, ws.baseMeter, ws.WorkerConfigMeter)
+	require.Equal(t, ws.baseMeter, ws.taskRunnerMgr.meter)
+	require.True(t, ws.taskRunnerMgr.TaskEnabled(proto.TaskTypeBalance))
+}
@@ -29,6 +29,7 @@ import (
 	api "github.com/cubefs/cubefs/blobstore/api/blobnode"
 	"github.com/cubefs/cubefs/blobstore/blobnode/client"
 	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	_ "github.com/cubefs/cubefs/blobstore/testing/nolog"
 	"github.com/cubefs/cubefs/blobstore/util/errors"
@@ -238,6 +239,22 @@ func (getter *MockGetter) ListShards(ctx context.Context, location proto.VunitLo
 	return getter.vunits[vuid].listShards()
 }
 
+func (getter *MockGetter) StatShards(ctx context.Context, location proto.VunitLocation, bids []proto.BlobID, verify bool) (stats []*client.ShardStat, err error) {
+	getter.mu.Lock()
+	defer getter.mu.Unlock()
+	vuid := location.Vuid
+	if err, ok := getter.failVuid[vuid]; ok {
+		return nil, err
+	}
+	return getter.vunits[vuid].statShards(bids, verify), nil
+}
+
+func (getter *MockGetter) CorruptShard(vuid proto.Vuid, bid proto.BlobID) {
+	getter.mu.Lock()
+	defer getter.mu.Unlock()
+	getter.vunits[vuid].corrupt(bid)
+}
+
 func (getter *MockGetter) StatChunk(ctx context.Context, location proto.VunitLocation) (ci *client.ChunkInfo, err error) {
 	vuid := location.Vuid
 	if _, ok := getter.vunits[vuid]; ok {
@@ -353,6 +370,35 @@ func (m *mockVunit) statShard(bid proto.BlobID) (si *client.ShardInfo, err error
 	return m.bidInfos[bid], nil
 }
 
+func (m *mockVunit) statShards(bids []proto.BlobID, verify bool) (stats []*client.ShardStat) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, bid := range bids {
+		stat := &client.ShardStat{}
+		stat.Vuid, stat.Bid = m.vuid, bid
+		stats = append(stats, stat)
+		info, ok := m.bidInfos[bid]
+		if !ok {
+			stat.Code = errcode.CodeBidNotFound
+			continue
+		}
+		stat.ShardInfo = info.ShardInfo
+		if verify && info.Flag == api.ShardStatusNormal && crc32.ChecksumIEEE(m.shards[bid]) != info.Crc {
+			stat.Code = errcode.CodeShardCrcMismatch
+		}
+	}
+	return
+}
+
+// corrupt changes the data of the shard but keeps the crc
+func (m *mockVunit) corrupt(bid proto.BlobID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data := append([]byte(nil), m.shards[bid]...)
+	data[0]++
+	m.shards[bid] = data
+}
+
 func (m *mockVunit) listShards() (shards []*client.ShardInfo, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -16,6 +16,8 @@ package blobnode
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
@@ -53,13 +55,19 @@ type WorkerConfigMeter struct {
 
 	// batch download concurrency of single tasklet
 	DownloadShardConcurrency int `json:"download_shard_concurrency"`
+
+	// download of a shard exceeding the timeout is taken as stalled, 0 means no timeout
+	SlowShardTimeoutMs int `json:"slow_shard_timeout_ms"`
+	// max bids stalled on all the replicas skipped by a migrate task, the skipped bids
+	// are recorded in the task result and left to repair, 0 means never skip
+	SlowShardSkipLimit int `json:"slow_shard_skip_limit"`
 }
 
 func (meter *WorkerConfigMeter) concurrencyByType(taskType proto.TaskType) int {
 	switch taskType {
 	case proto.TaskTypeDiskRepair:
 		return meter.RepairConcurrency
-	case proto.TaskTypeBalance:
+	case proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance:
 		return meter.BalanceConcurrency
 	case proto.TaskTypeDiskDrop:
 		return meter.DiskDropConcurrency
@@ -84,8 +92,14 @@ type WorkerConfig struct {
 	Scheduler scheduler.Config `json:"scheduler"`
 	// blbonode client config
 	BlobNode bnapi.Config `json:"blobnode"`
+	// network of the blobnode client of the task types, overwrites on the network of the blobnode
+	// client config, so that the background traffic is steered and shaped apart from the foreground
+	TaskNetworks map[proto.TaskType]rpc.NetworkConfig `json:"task_networks"`
 
 	DroppedBidRecord *recordlog.Config `json:"dropped_bid_record"`
+
+	// reload config meter and task switches from clustermgr period
+	ReloadConfigIntervalS int `json:"reload_config_interval_s"`
 }
 
 // WorkerService worker worker_service
@@ -96,23 +110,35 @@ type WorkerService struct {
 	taskRunnerMgr  *TaskRunnerMgr
 	inspectTaskMgr *InspectTaskMgr
 
+	mu               sync.RWMutex
 	shardRepairLimit limit.Limiter
 	shardRepairer    *ShardRepairer
 
+	// meter loaded from config file, config center overwrites on it
+	baseMeter    WorkerConfigMeter
+	configGetter workerConfigGetter
+
 	schedulerCli scheduler.IScheduler
 	blobNodeCli  client.IBlobNode
+	// blobnode clients of the task types by the task networks
+	taskBlobNodeClis map[proto.TaskType]client.IBlobNode
+}
+
+func (meter *WorkerConfigMeter) checkAndFix() {
+	fixConfigItemInt(&meter.MaxTaskRunnerCnt, 1)
+	fixConfigItemInt(&meter.RepairConcurrency, 1)
+	fixConfigItemInt(&meter.BalanceConcurrency, 1)
+	fixConfigItemInt(&meter.DiskDropConcurrency, 1)
+	fixConfigItemInt(&meter.ManualMigrateConcurrency, 10)
+	fixConfigItemInt(&meter.ShardRepairConcurrency, 1)
+	fixConfigItemInt(&meter.InspectConcurrency, 1)
+	fixConfigItemInt(&meter.DownloadShardConcurrency, 10)
 }
 
 func (cfg *WorkerConfig) checkAndFix() {
 	fixConfigItemInt(&cfg.AcquireIntervalMs, 500)
-	fixConfigItemInt(&cfg.MaxTaskRunnerCnt, 1)
-	fixConfigItemInt(&cfg.RepairConcurrency, 1)
-	fixConfigItemInt(&cfg.BalanceConcurrency, 1)
-	fixConfigItemInt(&cfg.DiskDropConcurrency, 1)
-	fixConfigItemInt(&cfg.ManualMigrateConcurrency, 10)
-	fixConfigItemInt(&cfg.ShardRepairConcurrency, 1)
-	fixConfigItemInt(&cfg.InspectConcurrency, 1)
-	fixConfigItemInt(&cfg.DownloadShardConcurrency, 10)
+	fixConfigItemInt(&cfg.ReloadConfigIntervalS, 60)
+	cfg.WorkerConfigMeter.checkAndFix()
 	fixConfigItemInt64(&cfg.Scheduler.ClientTimeoutMs, 1000)
 	fixConfigItemInt64(&cfg.Scheduler.HostSyncIntervalMs, 1000)
 	fixConfigItemInt64(&cfg.BlobNode.ClientTimeoutMs, 1000)
@@ -130,23 +156,38 @@ func fixConfigItemInt64(actual *int64, defaultVal int64) {
 	}
 }
 
+// WorkerClusterMgrAPI clustermgr api used by worker service.
+type WorkerClusterMgrAPI interface {
+	cmapi.APIService
+	workerConfigGetter
+}
+
 // NewWorkerService returns rpc worker_service
-func NewWorkerService(cfg *WorkerConfig, service cmapi.APIService, clusterID proto.ClusterID, idc string) (*WorkerService, error) {
+func NewWorkerService(cfg *WorkerConfig, service WorkerClusterMgrAPI, clusterID proto.ClusterID, idc, host string) (*WorkerService, error) {
 	cfg.checkAndFix()
 
 	base.TaskBufPool = base.NewBufPool(&cfg.BufPoolConf)
 
 	schedulerCli := scheduler.New(&cfg.Scheduler, service, clusterID)
 	blobNodeCli := client.NewBlobNodeClient(&cfg.BlobNode)
+	taskBlobNodeClis := make(map[proto.TaskType]client.IBlobNode, len(cfg.TaskNetworks))
+	for taskType, network := range cfg.TaskNetworks {
+		if err := network.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid network of task type %s: %v", taskType, err)
+		}
+		taskCfg := cfg.BlobNode
+		taskCfg.Tc.Network = network
+		taskBlobNodeClis[taskType] = client.NewBlobNodeClient(&taskCfg)
+	}
 
 	renewalConfig := cfg.Scheduler
 	renewalConfig.ClientTimeoutMs = 1000 * proto.RenewalTimeoutS
 	renewalCli := scheduler.New(&renewalConfig, service, clusterID)
-	taskRunnerMgr := NewTaskRunnerMgr(idc, cfg.WorkerConfigMeter, NewMigrateWorker, renewalCli, schedulerCli)
-	inspectTaskMgr := NewInspectTaskMgr(cfg.InspectConcurrency, blobNodeCli, schedulerCli)
+	taskRunnerMgr := NewTaskRunnerMgr(idc, host, cfg.WorkerConfigMeter, NewMigrateWorker, renewalCli, schedulerCli)
+	inspectTaskMgr := NewInspectTaskMgr(cfg.InspectConcurrency, taskBlobNodeCli(taskBlobNodeClis, blobNodeCli, proto.TaskTypeVolumeInspect), schedulerCli)
 
 	shardRepairLimit := count.New(cfg.ShardRepairConcurrency)
-	shardRepairer := NewShardRepairer(blobNodeCli)
+	shardRepairer := NewShardRepairer(taskBlobNodeCli(taskBlobNodeClis, blobNodeCli, proto.TaskTypeShardRepair))
 
 	// init dropped bid record
 	bidRecord := base.DroppedBidRecorderInst()
@@ -159,13 +200,17 @@ func NewWorkerService(cfg *WorkerConfig, service cmapi.APIService, clusterID pro
 		Closer:       closer.New(),
 		WorkerConfig: *cfg,
 
-		schedulerCli:   schedulerCli,
-		blobNodeCli:    blobNodeCli,
-		taskRunnerMgr:  taskRunnerMgr,
-		inspectTaskMgr: inspectTaskMgr,
+		schedulerCli:     schedulerCli,
+		blobNodeCli:      blobNodeCli,
+		taskBlobNodeClis: taskBlobNodeClis,
+		taskRunnerMgr:    taskRunnerMgr,
+		inspectTaskMgr:   inspectTaskMgr,
 
 		shardRepairLimit: shardRepairLimit,
 		shardRepairer:    shardRepairer,
+
+		baseMeter:    cfg.WorkerConfigMeter,
+		configGetter: service,
 	}
 
 	go svr.Run()
@@ -183,13 +228,20 @@ func (s *WorkerService) ShardRepair(c *rpc.Context) {
 	span := trace.SpanFromContextSafe(c.Request.Context())
 	ctx := trace.ContextWithSpan(c.Request.Context(), span)
 
-	err := s.shardRepairLimit.Acquire()
+	if !s.taskRunnerMgr.TaskEnabled(proto.TaskTypeShardRepair) {
+		span.Warnf("shard repair is disabled on this worker")
+		c.RespondError(errcode.ErrRequestLimited)
+		return
+	}
+
+	shardRepairLimit := s.getShardRepairLimit()
+	err := shardRepairLimit.Acquire()
 	if err != nil {
 		span.Errorf("the shard repair request is too much: err[%+v]", err)
 		c.RespondError(errcode.ErrRequestLimited)
 		return
 	}
-	defer s.shardRepairLimit.Release()
+	defer shardRepairLimit.Release()
 
 	err = s.shardRepairer.RepairShard(ctx, args)
 	c.RespondError(err)
@@ -210,10 +262,21 @@ func (s *WorkerService) Run() {
 func (s *WorkerService) loopAcquireTask() {
 	ticker := time.NewTicker(time.Duration(s.AcquireIntervalMs) * time.Millisecond)
 	defer ticker.Stop()
+
+	// config meter is only changed in this loop, so that acquiring reads it without lock
+	var reloadC <-chan time.Time
+	if s.configGetter != nil {
+		s.reloadConfig()
+		reloadTicker := time.NewTicker(time.Duration(s.ReloadConfigIntervalS) * time.Second)
+		defer reloadTicker.Stop()
+		reloadC = reloadTicker.C
+	}
 	for {
 		select {
 		case <-ticker.C:
 			s.tryAcquireTask()
+		case <-reloadC:
+			s.reloadConfig()
 		case <-s.Done():
 			return
 		}
@@ -221,23 +284,23 @@ func (s *WorkerService) loopAcquireTask() {
 }
 
 func (s *WorkerService) tryAcquireTask() {
-	if s.hasTaskRunnerResource() {
-		s.acquireTask()
+	if running, ok := s.hasTaskRunnerResource(); ok {
+		s.acquireTask(running)
 	}
 
-	if s.hasInspectTaskResource() {
+	if s.taskRunnerMgr.TaskEnabled(proto.TaskTypeVolumeInspect) && s.hasInspectTaskResource() {
 		s.acquireInspectTask()
 	}
 }
 
-func (s *WorkerService) hasTaskRunnerResource() bool {
+func (s *WorkerService) hasTaskRunnerResource() (int, bool) {
 	running := s.taskRunnerMgr.RunningTaskCnt()
 	all := 0
 	for _, cnt := range running {
 		all += cnt
 	}
 	log.Infof("task running %d / %d, %+v", all, s.MaxTaskRunnerCnt, running)
-	return all < s.MaxTaskRunnerCnt
+	return all, all < s.MaxTaskRunnerCnt
 }
 
 func (s *WorkerService) hasInspectTaskResource() bool {
@@ -246,10 +309,15 @@ func (s *WorkerService) hasInspectTaskResource() bool {
 	return inspectCnt < s.InspectConcurrency
 }
 
-// acquire:disk repair & balance & disk drop task
-func (s *WorkerService) acquireTask() {
+// acquire:disk repair & balance & disk drop task, the running tasks are reported to
+// the scheduler which sizes the tasks of the worker
+func (s *WorkerService) acquireTask(running int) {
 	span, ctx := trace.StartSpanFromContext(context.Background(), "acquireTask")
-	t, err := s.schedulerCli.AcquireTask(ctx, &scheduler.AcquireArgs{IDC: s.taskRunnerMgr.idc})
+	t, err := s.schedulerCli.AcquireTask(ctx, &scheduler.AcquireArgs{
+		IDC:     s.taskRunnerMgr.idc,
+		Worker:  s.taskRunnerMgr.host,
+		Running: running,
+	})
 	if err != nil {
 		code := rpc.DetectStatusCode(err)
 		if code != errcode.CodeNotingTodo {
@@ -265,8 +333,15 @@ func (s *WorkerService) acquireTask() {
 	err = s.taskRunnerMgr.AddTask(ctx, MigrateTaskEx{
 		taskInfo:                 t,
 		downloadShardConcurrency: s.DownloadShardConcurrency,
-		blobNodeCli:              s.blobNodeCli,
+		blobNodeCli:              s.getBlobNodeCli(t.TaskType),
+		slowShardTimeout:         time.Duration(s.SlowShardTimeoutMs) * time.Millisecond,
+		slowShardSkipLimit:       s.SlowShardSkipLimit,
 	})
+	if err == errTaskTypeDisabled {
+		span.Warnf("task type is disabled and cancel task: task_type[%s], taskID[%s]", t.TaskType, t.TaskID)
+		s.cancelTask(ctx, t, err.Error())
+		return
+	}
 	if err != nil {
 		span.Errorf("add task failed: taskID[%s], err[%v]", t.TaskID, err)
 		return
@@ -274,6 +349,18 @@ func (s *WorkerService) acquireTask() {
 	span.Infof("acquire task success: task_type[%s], taskID[%s]", t.TaskType, t.TaskID)
 }
 
+// getBlobNodeCli returns the blobnode client of the task type
+func (s *WorkerService) getBlobNodeCli(taskType proto.TaskType) client.IBlobNode {
+	return taskBlobNodeCli(s.taskBlobNodeClis, s.blobNodeCli, taskType)
+}
+
+func taskBlobNodeCli(clis map[proto.TaskType]client.IBlobNode, cli client.IBlobNode, taskType proto.TaskType) client.IBlobNode {
+	if taskCli, ok := clis[taskType]; ok {
+		return taskCli
+	}
+	return cli
+}
+
 // acquire inspect task
 func (s *WorkerService) acquireInspectTask() {
 	span, ctx := trace.StartSpanFromContext(context.Background(), "acquireInspectTask")
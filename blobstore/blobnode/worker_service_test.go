@@ -56,6 +56,10 @@ func (m *mBlobNodeCli) ListShards(ctx context.Context, location proto.VunitLocat
 	return nil, nil
 }
 
+func (m *mBlobNodeCli) StatShards(ctx context.Context, location proto.VunitLocation, bids []proto.BlobID, verify bool) ([]*client.ShardStat, error) {
+	return nil, nil
+}
+
 func (m *mBlobNodeCli) GetShard(ctx context.Context, location proto.VunitLocation, bid proto.BlobID, ioType bnapi.IOType) (io.ReadCloser, uint32, error) {
 	return nil, 0, nil
 }
@@ -143,7 +147,7 @@ func newMockWorkService(t *testing.T) (*Service, *mockScheCli) {
 		schedulerCli:     schedulerCli,
 		blobNodeCli:      blobnodeCli,
 
-		taskRunnerMgr:  NewTaskRunnerMgr("z0", getDefaultConfig().WorkerConfigMeter, NewMockMigrateWorker, schedulerCli, schedulerCli),
+		taskRunnerMgr:  NewTaskRunnerMgr("z0", "", getDefaultConfig().WorkerConfigMeter, NewMockMigrateWorker, schedulerCli, schedulerCli),
 		inspectTaskMgr: NewInspectTaskMgr(1, blobnodeCli, schedulerCli),
 	}
 	return &Service{WorkerService: workSvr}, schedulerCli
@@ -189,7 +193,7 @@ func TestWorkerService(t *testing.T) {
 
 func TestNewWorkService(t *testing.T) {
 	clusterMgr := cmapi.New(&cmapi.Config{})
-	svr, err := NewWorkerService(&WorkerConfig{}, clusterMgr, 1, "z0")
+	svr, err := NewWorkerService(&WorkerConfig{}, clusterMgr, 1, "z0", "")
 	require.NoError(t, err)
 	svr.Close()
 }
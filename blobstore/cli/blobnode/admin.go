@@ -0,0 +1,128 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"github.com/desertbit/grumble"
+
+	"github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/cli/common"
+	"github.com/cubefs/cubefs/blobstore/cli/common/flags"
+	"github.com/cubefs/cubefs/blobstore/cli/common/fmt"
+)
+
+func adminFlags(f *grumble.Flags) {
+	flags.VerboseRegister(f)
+	f.StringL("socket", "./blobnode.admin.sock", "admin unix socket of the blobnode")
+}
+
+func adminClient(c *grumble.Context) blobnode.AdminAPI {
+	return blobnode.NewAdminClient(c.Flags.String("socket"))
+}
+
+func addCmdAdmin(cmd *grumble.Command) {
+	adminCommand := &grumble.Command{
+		Name:     "admin",
+		Help:     "admin tools on the host",
+		LongHelp: "admin tools over the local unix socket of blobnode, run as root or the user of blobnode",
+	}
+	cmd.AddCommand(adminCommand)
+
+	adminCommand.AddCommand(&grumble.Command{
+		Name:  "tasks",
+		Help:  "show running migrate tasks",
+		Flags: adminFlags,
+		Run: func(c *grumble.Context) error {
+			tasks, err := adminClient(c).ListTasks(common.CmdContext())
+			if err != nil {
+				return err
+			}
+			fmt.Println(common.Readable(tasks))
+			return nil
+		},
+	})
+
+	adminCommand.AddCommand(&grumble.Command{
+		Name:  "stop",
+		Help:  "stop running migrate task",
+		Flags: adminFlags,
+		Args: func(a *grumble.Args) {
+			a.String("task_id", "task id")
+		},
+		Run: func(c *grumble.Context) error {
+			taskID := c.Args.String("task_id")
+			if !common.Confirm(fmt.Sprintf("confirm stop task %s?", taskID)) {
+				return nil
+			}
+			return adminClient(c).StopTask(common.CmdContext(), &blobnode.AdminStopTaskArgs{TaskID: taskID})
+		},
+	})
+
+	adminCommand.AddCommand(&grumble.Command{
+		Name:  "disks",
+		Help:  "show status of disks",
+		Flags: adminFlags,
+		Run: func(c *grumble.Context) error {
+			disks, err := adminClient(c).DiskStat(common.CmdContext())
+			if err != nil {
+				return err
+			}
+			fmt.Println(common.Readable(disks))
+			return nil
+		},
+	})
+
+	adminCommand.AddCommand(&grumble.Command{
+		Name:  "qos",
+		Help:  "show or set data qos, key is normal_mbps or background_mbps",
+		Flags: adminFlags,
+		Args: func(a *grumble.Args) {
+			a.String("key", "qos key", grumble.Default(""))
+			a.String("value", "qos value", grumble.Default(""))
+		},
+		Run: func(c *grumble.Context) error {
+			cli := adminClient(c)
+			if key := c.Args.String("key"); key != "" {
+				value := c.Args.String("value")
+				if !common.Confirm(fmt.Sprintf("confirm set %s to %s?", key, value)) {
+					return nil
+				}
+				if err := cli.SetQos(common.CmdContext(), &blobnode.ConfigReloadArgs{Key: key, Value: value}); err != nil {
+					return err
+				}
+			}
+			qos, err := cli.GetQos(common.CmdContext())
+			if err != nil {
+				return err
+			}
+			fmt.Println(common.Readable(qos))
+			return nil
+		},
+	})
+
+	adminCommand.AddCommand(&grumble.Command{
+		Name:  "bufpool",
+		Help:  "show stats of task buffer pool",
+		Flags: adminFlags,
+		Run: func(c *grumble.Context) error {
+			stat, err := adminClient(c).BufPoolStat(common.CmdContext())
+			if err != nil {
+				return err
+			}
+			fmt.Println(common.Readable(stat))
+			return nil
+		},
+	})
+}
@@ -32,6 +32,7 @@ func Register(app *grumble.App) {
 	addCmdChunk(blobnodeCommand)
 	addCmdShard(blobnodeCommand)
 	addCmdIOStat(blobnodeCommand)
+	addCmdAdmin(blobnodeCommand)
 }
 
 func blobnodeFlags(f *grumble.Flags) {
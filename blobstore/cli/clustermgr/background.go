@@ -34,6 +34,7 @@ var (
 	BackgroundTaskTypes = []string{
 		string(proto.TaskTypeDiskRepair),
 		string(proto.TaskTypeBalance),
+		string(proto.TaskTypeIntraNodeBalance),
 		string(proto.TaskTypeDiskDrop),
 		string(proto.TaskTypeManualMigrate),
 		string(proto.TaskTypeVolumeInspect),
@@ -30,6 +30,11 @@ const (
 	_count          = "count"
 	_diskID         = "disk_id"
 	_directDownload = "direct_download"
+	_host           = "host"
+	_durationS      = "duration_s"
+	_vid            = "vid"
+	_operator       = "operator"
+	_reason         = "reason"
 )
 
 func addCmdMigrateTask(cmd *grumble.Command) {
@@ -87,6 +92,99 @@ func addCmdMigrateTask(cmd *grumble.Command) {
 			f.Uint64L(_diskID, 0, "disk id for which disk of progress")
 		},
 	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "cancel_drop",
+		Help: "cancel dropping disk",
+		Run:  cmdCancelDiskDrop,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.Uint64L(_diskID, 0, "disk id for which disk to cancel drop")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "cancel_drop_report",
+		Help: "show report of cancelled disk drop",
+		Run:  cmdDiskDropCancelReport,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.Uint64L(_diskID, 0, "disk id for which disk of report")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "drain_host",
+		Help: "drain all disks of the host in sequence",
+		Run:  cmdDrainHost,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.StringL(_host, "", "blobnode host to drain, such as http://127.0.0.1:8889")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "drain_host_report",
+		Help: "show report of host drain",
+		Run:  cmdHostDrainReport,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.StringL(_host, "", "blobnode host of report")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "maintain_host",
+		Help: "hold the migrate tasks on the host during the maintenance window",
+		Run:  cmdMaintainHost,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.StringL(_host, "", "blobnode host to maintain, such as http://127.0.0.1:8889")
+			f.Int64L(_durationS, 3600, "seconds of the maintenance window")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "maintain_host_cancel",
+		Help: "end the maintenance window of the host",
+		Run:  cmdMaintainHostCancel,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.StringL(_host, "", "blobnode host in maintenance")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "maintain_host_list",
+		Help: "list the hosts in maintenance window",
+		Run:  cmdMaintainHostList,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "freeze_volume",
+		Help: "freeze the repair and migration of the volume for investigation",
+		Run:  cmdFreezeVolume,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.Uint64L(_vid, 0, "volume id to freeze")
+			f.Int64L(_durationS, 86400, "seconds of the freeze")
+			f.StringL(_operator, "", "who freezes the volume")
+			f.StringL(_reason, "", "reason of the freeze")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "freeze_volume_cancel",
+		Help: "end the freeze of the volume",
+		Run:  cmdFreezeVolumeCancel,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.Uint64L(_vid, 0, "frozen volume id")
+			f.StringL(_operator, "", "who unfreezes the volume")
+		},
+	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "freeze_volume_list",
+		Help: "list the frozen volumes",
+		Run:  cmdFreezeVolumeList,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+		},
+	})
 }
 
 func migrateFlags(f *grumble.Flags) {
@@ -240,6 +338,179 @@ func cmdGetMigratingProgress(c *grumble.Context) error {
 	return nil
 }
 
+func cmdCancelDiskDrop(c *grumble.Context) error {
+	diskID := proto.DiskID(c.Flags.Uint64(_diskID))
+	if diskID == proto.InvalidDiskID {
+		return errcode.ErrIllegalDiskID
+	}
+	if !common.Confirm(fmt.Sprintf("confirm cancel drop disk %d?", diskID)) {
+		return nil
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	report, err := cli.CancelDiskDrop(common.CmdContext(), &scheduler.DiskDropCancelArgs{DiskID: diskID})
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(report))
+	return nil
+}
+
+func cmdDiskDropCancelReport(c *grumble.Context) error {
+	diskID := proto.DiskID(c.Flags.Uint64(_diskID))
+	if diskID == proto.InvalidDiskID {
+		return errcode.ErrIllegalDiskID
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	report, err := cli.DiskDropCancelReport(common.CmdContext(), &scheduler.DiskDropCancelArgs{DiskID: diskID})
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(report))
+	return nil
+}
+
+func cmdDrainHost(c *grumble.Context) error {
+	host := c.Flags.String(_host)
+	if host == "" {
+		return errcode.ErrIllegalArguments
+	}
+	if !common.Confirm(fmt.Sprintf("confirm drain all disks of host %s?", host)) {
+		return nil
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	report, err := cli.DrainHost(common.CmdContext(), &scheduler.HostDrainArgs{Host: host})
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(report))
+	return nil
+}
+
+func cmdHostDrainReport(c *grumble.Context) error {
+	host := c.Flags.String(_host)
+	if host == "" {
+		return errcode.ErrIllegalArguments
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	report, err := cli.HostDrainReport(common.CmdContext(), &scheduler.HostDrainArgs{Host: host})
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(report))
+	return nil
+}
+
+func cmdMaintainHost(c *grumble.Context) error {
+	host := c.Flags.String(_host)
+	durationS := c.Flags.Int64(_durationS)
+	if host == "" || durationS <= 0 {
+		return errcode.ErrIllegalArguments
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	ret, err := cli.SetHostMaintenance(common.CmdContext(), &scheduler.HostMaintenanceArgs{Host: host, DurationS: durationS})
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(ret))
+	return nil
+}
+
+func cmdMaintainHostCancel(c *grumble.Context) error {
+	host := c.Flags.String(_host)
+	if host == "" {
+		return errcode.ErrIllegalArguments
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+	return cli.CancelHostMaintenance(common.CmdContext(), &scheduler.HostMaintenanceArgs{Host: host})
+}
+
+func cmdMaintainHostList(c *grumble.Context) error {
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	ret, err := cli.ListHostMaintenance(common.CmdContext())
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(ret))
+	return nil
+}
+
+func cmdFreezeVolume(c *grumble.Context) error {
+	args := &scheduler.VolumeFreezeArgs{
+		Vid:       proto.Vid(c.Flags.Uint64(_vid)),
+		DurationS: c.Flags.Int64(_durationS),
+		Operator:  c.Flags.String(_operator),
+		Reason:    c.Flags.String(_reason),
+	}
+	if args.Vid == proto.InvalidVid || args.DurationS <= 0 || args.Operator == "" {
+		return errcode.ErrIllegalArguments
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	ret, err := cli.FreezeVolume(common.CmdContext(), args)
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(ret))
+	return nil
+}
+
+func cmdFreezeVolumeCancel(c *grumble.Context) error {
+	args := &scheduler.VolumeFreezeArgs{
+		Vid:      proto.Vid(c.Flags.Uint64(_vid)),
+		Operator: c.Flags.String(_operator),
+	}
+	if args.Vid == proto.InvalidVid || args.Operator == "" {
+		return errcode.ErrIllegalArguments
+	}
+
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+	return cli.UnfreezeVolume(common.CmdContext(), args)
+}
+
+func cmdFreezeVolumeList(c *grumble.Context) error {
+	clusterID := getClusterID(c.Flags)
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+
+	ret, err := cli.ListFrozenVolumes(common.CmdContext())
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.Readable(ret))
+	return nil
+}
+
 func printMigrateTask(task *proto.MigrateTask) {
 	type MigrateTaskSimple struct {
 		ID       string             `json:"id"`
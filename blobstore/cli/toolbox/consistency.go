@@ -0,0 +1,426 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package toolbox
+
+import (
+	"bufio"
+	"context"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/desertbit/grumble"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/cli/common"
+	"github.com/cubefs/cubefs/blobstore/cli/common/cfmt"
+	"github.com/cubefs/cubefs/blobstore/cli/common/fmt"
+	"github.com/cubefs/cubefs/blobstore/cli/config"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	"github.com/cubefs/cubefs/blobstore/common/ec"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+)
+
+func addCmdConsistency(cmd *grumble.Command) {
+	cmd.AddCommand(&grumble.Command{
+		Name: "consistency",
+		Help: "check consistency of locations",
+		LongHelp: "resolve the sampled locations through clustermgr, stat their shards in blobnodes in batches,\n" +
+			"verify the shard sizes and crcs, then show the consistency score and the suspect blobs",
+		Args: func(a *grumble.Args) {
+			a.String("filepath", "location file, one location [json|hex|base64] per line")
+		},
+		Flags: func(f *grumble.Flags) {
+			f.IntL("sample", 0, "check randomly sampled locations, 0 means all of the file")
+			f.IntL("concurrency", 8, "number of volumes checked concurrently")
+			f.BoolL("crc", false, "read shards data to verify crcs")
+			f.StringL("output", "", "save suspect blobs to file path, must not exist file")
+		},
+		Run: checkConsistency,
+	})
+}
+
+type suspectShard struct {
+	Index  int          `json:"index"`
+	Vuid   proto.Vuid   `json:"vuid"`
+	DiskID proto.DiskID `json:"disk_id"`
+	Host   string       `json:"host"`
+	Reason string       `json:"reason"`
+}
+
+// suspectBlob is a blob need to be repaired, it is unrecoverable if more than
+// M shards of the blob are bad.
+type suspectBlob struct {
+	ClusterID  proto.ClusterID   `json:"cluster_id"`
+	CodeMode   codemode.CodeMode `json:"code_mode"`
+	Vid        proto.Vid         `json:"vid"`
+	Bid        proto.BlobID      `json:"bid"`
+	Size       uint32            `json:"size"`
+	Repairable bool              `json:"repairable"`
+	Reason     string            `json:"reason,omitempty"`
+	Shards     []suspectShard    `json:"shards,omitempty"`
+}
+
+// max bids stated in one request to blobnode
+const statShardsBatch = 1024
+
+type consistencyChecker struct {
+	crc     bool
+	bnCli   blobnode.StorageAPI
+	volumes sync.Map // mapping: cluster id and vid -> *clustermgr.VolumeInfo
+
+	mu         sync.Mutex
+	blobs      int
+	healthy    int
+	repairable int
+	suspects   []*suspectBlob
+}
+
+type volumeKey struct {
+	clusterID proto.ClusterID
+	vid       proto.Vid
+}
+
+func (c *consistencyChecker) getVolume(ctx context.Context, clusterID proto.ClusterID, vid proto.Vid) (
+	*clustermgr.VolumeInfo, error,
+) {
+	key := volumeKey{clusterID: clusterID, vid: vid}
+	if volume, ok := c.volumes.Load(key); ok {
+		return volume.(*clustermgr.VolumeInfo), nil
+	}
+	cmCli := config.NewCluster(clusterID.ToString(), nil, "")
+	volume, err := cmCli.GetVolumeInfo(ctx, &clustermgr.GetVolumeArgs{Vid: vid})
+	if err != nil {
+		return nil, err
+	}
+	c.volumes.Store(key, volume)
+	return volume, nil
+}
+
+func (c *consistencyChecker) checkShard(ctx context.Context, unit clustermgr.Unit, bid proto.BlobID, shardSize int) string {
+	shard, err := c.bnCli.StatShard(ctx, unit.Host, &blobnode.StatShardArgs{
+		DiskID: unit.DiskID,
+		Vuid:   unit.Vuid,
+		Bid:    bid,
+	})
+	if err != nil {
+		if rpc.DetectStatusCode(err) == errcode.CodeBidNotFound {
+			return "missing"
+		}
+		return fmt.Sprintf("stat shard: %s", err.Error())
+	}
+	if shard.Flag != blobnode.ShardStatusNormal {
+		return fmt.Sprintf("shard status %d", shard.Flag)
+	}
+	if shard.Size != int64(shardSize) {
+		return fmt.Sprintf("size %d, expected %d", shard.Size, shardSize)
+	}
+	if !c.crc {
+		return ""
+	}
+
+	body, _, err := c.bnCli.GetShard(ctx, unit.Host, &blobnode.GetShardArgs{
+		DiskID: unit.DiskID,
+		Vuid:   unit.Vuid,
+		Bid:    bid,
+		Type:   blobnode.BackgroundIO,
+	})
+	if err != nil {
+		return fmt.Sprintf("get shard: %s", err.Error())
+	}
+	defer body.Close()
+	hasher := crc32.NewIEEE()
+	n, err := io.Copy(hasher, body)
+	if err != nil {
+		return fmt.Sprintf("read shard: %s", err.Error())
+	}
+	if n != shard.Size {
+		return fmt.Sprintf("read %d bytes, expected %d", n, shard.Size)
+	}
+	if crc := hasher.Sum32(); crc != shard.Crc {
+		return fmt.Sprintf("crc %d, expected %d", crc, shard.Crc)
+	}
+	return ""
+}
+
+type blobTask struct {
+	loc  *access.Location
+	blob access.Blob
+}
+
+// checkBlobs checks the blobs of one volume, the shards of the blobs on each unit are
+// stated in batches instead of one request per shard. The suspect of the blob is nil if
+// all shards of the blob are consistent.
+func (c *consistencyChecker) checkBlobs(ctx context.Context, key volumeKey, tasks []blobTask) []*suspectBlob {
+	suspects := make([]*suspectBlob, len(tasks))
+	for i, t := range tasks {
+		suspects[i] = &suspectBlob{
+			ClusterID: t.loc.ClusterID,
+			CodeMode:  t.loc.CodeMode,
+			Vid:       t.blob.Vid,
+			Bid:       t.blob.Bid,
+			Size:      t.blob.Size,
+		}
+	}
+
+	volume, err := c.getVolume(ctx, key.clusterID, key.vid)
+	if err != nil {
+		for _, suspect := range suspects {
+			suspect.Reason = fmt.Sprintf("get volume: %s", err.Error())
+		}
+		return suspects
+	}
+
+	// the blobs having reasons are not checked on the units
+	var (
+		checked    []int
+		bids       []proto.BlobID
+		shardSizes []int
+	)
+	for i, t := range tasks {
+		suspect := suspects[i]
+		if volume.CodeMode != t.loc.CodeMode {
+			suspect.Reason = fmt.Sprintf("volume codemode %s, expected %s", volume.CodeMode, t.loc.CodeMode)
+			continue
+		}
+		tactic := t.loc.CodeMode.Tactic()
+		if len(volume.Units) != tactic.N+tactic.M+tactic.L {
+			suspect.Reason = fmt.Sprintf("volume has %d units, expected %d", len(volume.Units), tactic.N+tactic.M+tactic.L)
+			continue
+		}
+		sizes, err := ec.GetBufferSizes(int(t.blob.Size), tactic)
+		if err != nil {
+			suspect.Reason = fmt.Sprintf("get shard size: %s", err.Error())
+			continue
+		}
+		checked = append(checked, i)
+		bids = append(bids, t.blob.Bid)
+		shardSizes = append(shardSizes, sizes.ShardSize)
+	}
+
+	if len(checked) > 0 {
+		for idx, unit := range volume.Units {
+			reasons := c.checkShards(ctx, unit, bids, shardSizes)
+			for j, reason := range reasons {
+				if reason == "" {
+					continue
+				}
+				suspect := suspects[checked[j]]
+				suspect.Shards = append(suspect.Shards, suspectShard{
+					Index:  idx,
+					Vuid:   unit.Vuid,
+					DiskID: unit.DiskID,
+					Host:   unit.Host,
+					Reason: reason,
+				})
+			}
+		}
+	}
+
+	for _, i := range checked {
+		if len(suspects[i].Shards) == 0 {
+			suspects[i] = nil
+			continue
+		}
+		suspects[i].Repairable = len(suspects[i].Shards) <= tasks[i].loc.CodeMode.Tactic().M
+	}
+	return suspects
+}
+
+// checkShards returns the reasons of the inconsistent shards of the bids on the unit, the
+// shards are checked one by one if the blobnode does not support stating in batches.
+func (c *consistencyChecker) checkShards(ctx context.Context, unit clustermgr.Unit, bids []proto.BlobID, shardSizes []int) []string {
+	reasons := make([]string, len(bids))
+	for start := 0; start < len(bids); start += statShardsBatch {
+		end := start + statShardsBatch
+		if end > len(bids) {
+			end = len(bids)
+		}
+		stats, err := c.bnCli.StatShards(ctx, unit.Host, &blobnode.StatShardsArgs{
+			DiskID: unit.DiskID,
+			Vuid:   unit.Vuid,
+			Bids:   bids[start:end],
+			Verify: c.crc,
+		})
+		if err == nil && len(stats) != end-start {
+			err = fmt.Errorf("got %d shard stats, expected %d", len(stats), end-start)
+		}
+		if rpc.DetectStatusCode(err) == http.StatusNotImplemented {
+			for i := start; i < end; i++ {
+				reasons[i] = c.checkShard(ctx, unit, bids[i], shardSizes[i])
+			}
+			continue
+		}
+		if err != nil {
+			for i := start; i < end; i++ {
+				reasons[i] = fmt.Sprintf("stat shards: %s", err.Error())
+			}
+			continue
+		}
+		for i, stat := range stats {
+			reasons[start+i] = shardStatReason(stat, shardSizes[start+i])
+		}
+	}
+	return reasons
+}
+
+func shardStatReason(stat *blobnode.ShardStat, shardSize int) string {
+	switch stat.Code {
+	case 0:
+	case errcode.CodeBidNotFound:
+		return "missing"
+	case errcode.CodeShardCrcMismatch:
+		return "crc mismatch"
+	default:
+		return fmt.Sprintf("stat shard: code %d", stat.Code)
+	}
+	if stat.Flag != blobnode.ShardStatusNormal {
+		return fmt.Sprintf("shard status %d", stat.Flag)
+	}
+	if stat.Size != int64(shardSize) {
+		return fmt.Sprintf("size %d, expected %d", stat.Size, shardSize)
+	}
+	return ""
+}
+
+func (c *consistencyChecker) record(suspect *suspectBlob) {
+	c.mu.Lock()
+	c.blobs++
+	switch {
+	case suspect == nil:
+		c.healthy++
+	case suspect.Repairable:
+		c.repairable++
+		c.suspects = append(c.suspects, suspect)
+	default:
+		c.suspects = append(c.suspects, suspect)
+	}
+	c.mu.Unlock()
+}
+
+func readSampleLocations(filepath string, sample int) ([]access.Location, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1<<16), 1<<24)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if sample > 0 && sample < len(lines) {
+		rand.Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
+		lines = lines[:sample]
+	}
+	locs := make([]access.Location, 0, len(lines))
+	for _, line := range lines {
+		loc, err := cfmt.ParseLocation(line)
+		if err != nil {
+			return nil, fmt.Errorf("parse location %s: %s", line, err.Error())
+		}
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+func checkConsistency(c *grumble.Context) error {
+	ctx := common.CmdContext()
+	locs, err := readSampleLocations(c.Args.String("filepath"), c.Flags.Int("sample"))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if file := c.Flags.String("output"); file != "" {
+		f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	checker := &consistencyChecker{
+		crc:   c.Flags.Bool("crc"),
+		bnCli: blobnode.New(&blobnode.Config{}),
+	}
+	concurrency := c.Flags.Int("concurrency")
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// check the blobs by volumes, so that the shards on one unit are stated in batches
+	var keys []volumeKey
+	volumeTasks := make(map[volumeKey][]blobTask)
+	for idx := range locs {
+		loc := &locs[idx]
+		for _, blob := range loc.Spread() {
+			key := volumeKey{clusterID: loc.ClusterID, vid: blob.Vid}
+			if _, ok := volumeTasks[key]; !ok {
+				keys = append(keys, key)
+			}
+			volumeTasks[key] = append(volumeTasks[key], blobTask{loc: loc, blob: blob})
+		}
+	}
+
+	keyC := make(chan volumeKey, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keyC {
+				for _, suspect := range checker.checkBlobs(ctx, key, volumeTasks[key]) {
+					checker.record(suspect)
+				}
+			}
+		}()
+	}
+	for _, key := range keys {
+		keyC <- key
+	}
+	close(keyC)
+	wg.Wait()
+
+	for _, suspect := range checker.suspects {
+		fmt.Fprintln(w, common.RawString(suspect))
+	}
+
+	score := 100.0
+	if checker.blobs > 0 {
+		score = float64(checker.healthy) * 100 / float64(checker.blobs)
+	}
+	fmt.Printf("checked %d locations %d blobs: healthy %d, repairable %d, unrecoverable %d\n",
+		len(locs), checker.blobs, checker.healthy, checker.repairable, len(checker.suspects)-checker.repairable)
+	fmt.Printf("consistency score: %.4f%%\n", score)
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package toolbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	"github.com/cubefs/cubefs/blobstore/common/ec"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/testing/mocks"
+)
+
+func TestConsistencyShardStatReason(t *testing.T) {
+	normal := blobnode.ShardInfo{Size: 1024, Flag: blobnode.ShardStatusNormal}
+	for _, cs := range []struct {
+		stat   blobnode.ShardStat
+		reason string
+	}{
+		{blobnode.ShardStat{ShardInfo: normal}, ""},
+		{blobnode.ShardStat{Code: errcode.CodeBidNotFound}, "missing"},
+		{blobnode.ShardStat{ShardInfo: normal, Code: errcode.CodeShardCrcMismatch}, "crc mismatch"},
+		{blobnode.ShardStat{Code: errcode.CodeDiskBroken}, fmt.Sprintf("stat shard: code %d", errcode.CodeDiskBroken)},
+		{
+			blobnode.ShardStat{ShardInfo: blobnode.ShardInfo{Size: 1024, Flag: blobnode.ShardStatusMarkDelete}},
+			fmt.Sprintf("shard status %d", blobnode.ShardStatusMarkDelete),
+		},
+		{blobnode.ShardStat{ShardInfo: blobnode.ShardInfo{Size: 10, Flag: blobnode.ShardStatusNormal}}, "size 10, expected 1024"},
+	} {
+		stat := cs.stat
+		require.Equal(t, cs.reason, shardStatReason(&stat, 1024))
+	}
+}
+
+func newTestConsistencyChecker(t *testing.T, volume *clustermgr.VolumeInfo) (*consistencyChecker, *mocks.MockStorageAPI) {
+	bnCli := mocks.NewMockStorageAPI(gomock.NewController(t))
+	checker := &consistencyChecker{bnCli: bnCli}
+	checker.volumes.Store(volumeKey{clusterID: 1, vid: volume.Vid}, volume)
+	return checker, bnCli
+}
+
+func newTestConsistencyVolume(vid proto.Vid, mode codemode.CodeMode) *clustermgr.VolumeInfo {
+	volume := &clustermgr.VolumeInfo{}
+	volume.Vid = vid
+	volume.CodeMode = mode
+	for i := 0; i < mode.GetShardNum(); i++ {
+		volume.Units = append(volume.Units, clustermgr.Unit{
+			Vuid:   proto.EncodeVuid(proto.EncodeVuidPrefix(vid, uint8(i)), 1),
+			DiskID: proto.DiskID(i + 1),
+			Host:   fmt.Sprintf("http://blobnode-%d", i),
+		})
+	}
+	return volume
+}
+
+func TestConsistencyCheckBlobs(t *testing.T) {
+	ctx := context.Background()
+	mode := codemode.EC3P3
+	volume := newTestConsistencyVolume(10, mode)
+	checker, bnCli := newTestConsistencyChecker(t, volume)
+
+	loc := &access.Location{ClusterID: 1, CodeMode: mode, Size: 5 << 10, BlobSize: 4 << 10}
+	var tasks []blobTask
+	for bid := proto.BlobID(100); bid < 102; bid++ {
+		tasks = append(tasks, blobTask{loc: loc, blob: access.Blob{Bid: bid, Vid: 10, Size: uint32(4 << 10)}})
+	}
+	tasks[1].blob.Size = 1 << 10
+	// the blob of the other codemode is not checked on the units
+	tasks = append(tasks, blobTask{
+		loc:  &access.Location{ClusterID: 1, CodeMode: codemode.EC6P6},
+		blob: access.Blob{Bid: 102, Vid: 10, Size: 1 << 10},
+	})
+	shardSize := func(size uint32) int64 {
+		sizes, err := ec.GetBufferSizes(int(size), mode.Tactic())
+		require.NoError(t, err)
+		return int64(sizes.ShardSize)
+	}
+	stats := func(sizes ...int64) []*blobnode.ShardStat {
+		ret := make([]*blobnode.ShardStat, 0, len(sizes))
+		for _, size := range sizes {
+			ret = append(ret, &blobnode.ShardStat{ShardInfo: blobnode.ShardInfo{Size: size, Flag: blobnode.ShardStatusNormal}})
+		}
+		return ret
+	}
+	size0, size1 := shardSize(tasks[0].blob.Size), shardSize(tasks[1].blob.Size)
+
+	bnCli.EXPECT().StatShards(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, host string, args *blobnode.StatShardsArgs) ([]*blobnode.ShardStat, error) {
+			require.Equal(t, []proto.BlobID{100, 101}, args.Bids)
+			switch host {
+			case volume.Units[1].Host:
+				ret := stats(size0, size1)
+				ret[1].Code = errcode.CodeBidNotFound
+				return ret, nil
+			case volume.Units[2].Host:
+				return nil, rpc.NewError(http.StatusNotImplemented, "", errors.New("not implemented"))
+			case volume.Units[3].Host:
+				return nil, errors.New("connection refused")
+			case volume.Units[4].Host:
+				return stats(size0), nil
+			}
+			return stats(size0, size1), nil
+		}).Times(len(volume.Units))
+	// the shards are stated one by one on the blobnode not stating in batches
+	bnCli.EXPECT().StatShard(gomock.Any(), volume.Units[2].Host, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, args *blobnode.StatShardArgs) (*blobnode.ShardInfo, error) {
+			if args.Bid == 100 {
+				return &blobnode.ShardInfo{Size: size0, Flag: blobnode.ShardStatusNormal}, nil
+			}
+			return nil, errcode.ErrNoSuchBid
+		}).Times(2)
+
+	suspects := checker.checkBlobs(ctx, volumeKey{clusterID: 1, vid: 10}, tasks)
+	require.Len(t, suspects, 3)
+
+	require.NotNil(t, suspects[0])
+	require.True(t, suspects[0].Repairable)
+	require.Len(t, suspects[0].Shards, 2)
+	require.Equal(t, 3, suspects[0].Shards[0].Index)
+	require.Equal(t, "stat shards: connection refused", suspects[0].Shards[0].Reason)
+	require.Equal(t, 4, suspects[0].Shards[1].Index)
+	require.Equal(t, "stat shards: got 1 shard stats, expected 2", suspects[0].Shards[1].Reason)
+
+	// more than M shards are bad
+	require.NotNil(t, suspects[1])
+	require.False(t, suspects[1].Repairable)
+	require.Len(t, suspects[1].Shards, 4)
+	require.Equal(t, "missing", suspects[1].Shards[0].Reason)
+	require.Equal(t, "missing", suspects[1].Shards[1].Reason)
+	require.Equal(t, volume.Units[1].Vuid, suspects[1].Shards[0].Vuid)
+
+	require.NotNil(t, suspects[2])
+	require.Nil(t, suspects[2].Shards)
+	require.Equal(t, "volume codemode EC3P3, expected EC6P6", suspects[2].Reason)
+
+	for _, suspect := range suspects {
+		checker.record(suspect)
+	}
+	checker.record(nil)
+	require.Equal(t, 4, checker.blobs)
+	require.Equal(t, 1, checker.healthy)
+	require.Equal(t, 1, checker.repairable)
+	require.Len(t, checker.suspects, 3)
+}
+
+func TestConsistencyCheckBlobsHealthy(t *testing.T) {
+	ctx := context.Background()
+	mode := codemode.EC3P3
+	volume := newTestConsistencyVolume(11, mode)
+	checker, bnCli := newTestConsistencyChecker(t, volume)
+	checker.crc = true
+
+	loc := &access.Location{ClusterID: 1, CodeMode: mode}
+	tasks := make([]blobTask, statShardsBatch+1)
+	for i := range tasks {
+		tasks[i] = blobTask{loc: loc, blob: access.Blob{Bid: proto.BlobID(i + 1), Vid: 11, Size: 1 << 10}}
+	}
+	sizes, err := ec.GetBufferSizes(1<<10, mode.Tactic())
+	require.NoError(t, err)
+
+	// the bids are stated in batches with the crcs verified by the blobnodes
+	bnCli.EXPECT().StatShards(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, args *blobnode.StatShardsArgs) ([]*blobnode.ShardStat, error) {
+			require.True(t, args.Verify)
+			require.LessOrEqual(t, len(args.Bids), statShardsBatch)
+			ret := make([]*blobnode.ShardStat, len(args.Bids))
+			for i := range ret {
+				ret[i] = &blobnode.ShardStat{ShardInfo: blobnode.ShardInfo{
+					Size: int64(sizes.ShardSize),
+					Flag: blobnode.ShardStatusNormal,
+				}}
+			}
+			return ret, nil
+		}).Times(2 * len(volume.Units))
+
+	for _, suspect := range checker.checkBlobs(ctx, volumeKey{clusterID: 1, vid: 11}, tasks) {
+		require.Nil(t, suspect)
+	}
+}
+
+func TestConsistencyReadSampleLocations(t *testing.T) {
+	var lines string
+	for i := 0; i < 10; i++ {
+		loc := access.Location{
+			ClusterID: 1,
+			CodeMode:  codemode.EC3P3,
+			Size:      1 << 10,
+			BlobSize:  4 << 10,
+			Blobs:     []access.SliceInfo{{MinBid: proto.BlobID(i + 1), Vid: 1, Count: 1}},
+		}
+		lines += loc.HexString() + "\n\n"
+	}
+	file := filepath.Join(t.TempDir(), "locations")
+	require.NoError(t, os.WriteFile(file, []byte(lines), 0o644))
+
+	locs, err := readSampleLocations(file, 0)
+	require.NoError(t, err)
+	require.Len(t, locs, 10)
+	require.Equal(t, proto.BlobID(1), locs[0].Blobs[0].MinBid)
+
+	locs, err = readSampleLocations(file, 3)
+	require.NoError(t, err)
+	require.Len(t, locs, 3)
+	bids := make(map[proto.BlobID]struct{})
+	for _, loc := range locs {
+		bids[loc.Blobs[0].MinBid] = struct{}{}
+	}
+	require.Len(t, bids, 3)
+
+	require.NoError(t, os.WriteFile(file, []byte("not a location\n"), 0o644))
+	_, err = readSampleLocations(file, 0)
+	require.Error(t, err)
+	_, err = readSampleLocations(filepath.Join(t.TempDir(), "none"), 0)
+	require.Error(t, err)
+}
@@ -27,4 +27,5 @@ func Register(app *grumble.App) {
 	app.AddCommand(toolboxCommand)
 
 	addCmdShard(toolboxCommand)
+	addCmdConsistency(toolboxCommand)
 }
@@ -335,6 +335,76 @@ func (s *Service) DiskDropped(c *rpc.Context) {
 	}
 }
 
+// DiskCancelDrop remove the disk from dropping list and set it writable again,
+// the volume units migrated already keep in their new locations
+func (s *Service) DiskCancelDrop(c *rpc.Context) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	args := new(clustermgr.DiskInfoArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	span.Infof("accept DiskCancelDrop request, args: %v", args)
+
+	diskInfo, err := s.DiskMgr.GetDiskInfo(ctx, args.DiskID)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	if diskInfo.Status != proto.DiskStatusNormal {
+		c.RespondError(apierrors.ErrChangeDiskStatusNotAllow)
+		return
+	}
+
+	isDropping, err := s.DiskMgr.IsDroppingDisk(ctx, args.DiskID)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	if isDropping {
+		data, err := json.Marshal(args)
+		if err != nil {
+			span.Errorf("cancel drop args: %v, error: %v", args, err)
+			c.RespondError(errors.Info(apierrors.ErrUnexpected).Detail(err))
+			return
+		}
+		proposeInfo := base.EncodeProposeInfo(s.DiskMgr.GetModuleName(), diskmgr.OperTypeCancelDroppingDisk, data, base.ProposeContext{ReqID: span.TraceID()})
+		err = s.raftNode.Propose(ctx, proposeInfo)
+		if err != nil {
+			span.Error(err)
+			c.RespondError(apierrors.ErrRaftPropose)
+			return
+		}
+	}
+	// cancelled already
+	if !diskInfo.Readonly {
+		return
+	}
+
+	data, err := json.Marshal(&clustermgr.DiskAccessArgs{DiskID: args.DiskID, Readonly: false})
+	if err != nil {
+		span.Errorf("access args: %v, error: %v", args, err)
+		c.RespondError(errors.Info(apierrors.ErrUnexpected).Detail(err))
+		return
+	}
+	proposeInfo := base.EncodeProposeInfo(s.DiskMgr.GetModuleName(), diskmgr.OperTypeSwitchReadonly, data, base.ProposeContext{ReqID: span.TraceID()})
+	err = s.raftNode.Propose(ctx, proposeInfo)
+	if err != nil {
+		span.Error(err)
+		c.RespondError(apierrors.ErrRaftPropose)
+		return
+	}
+
+	// adjust volume health when setting disk writable
+	err = s.VolumeMgr.DiskWritableChange(ctx, args.DiskID)
+	if err != nil {
+		span.Error("adjust volume health failed", errors.Detail(err))
+		err = errors.Info(apierrors.ErrUnexpected).Detail(err)
+		c.RespondError(err)
+	}
+}
+
 func (s *Service) DiskDroppingList(c *rpc.Context) {
 	ctx := c.Request.Context()
 	span := trace.SpanFromContextSafe(ctx)
@@ -197,6 +197,32 @@ func TestDisk(t *testing.T) {
 		require.Error(t, err)
 	}
 
+	// test cancel drop
+	{
+		err := testClusterClient.SetReadonlyDisk(ctx, 3, true)
+		require.NoError(t, err)
+		err = testClusterClient.DropDisk(ctx, 3)
+		require.NoError(t, err)
+
+		err = testClusterClient.CancelDropDisk(ctx, 3)
+		require.NoError(t, err)
+		ret, err := testClusterClient.ListDroppingDisk(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(ret))
+		disk, err := testClusterClient.DiskInfo(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, proto.DiskStatusNormal, disk.Status)
+		require.False(t, disk.Readonly)
+
+		// cancel repeatably
+		err = testClusterClient.CancelDropDisk(ctx, 3)
+		require.NoError(t, err)
+
+		// dropped disk can not be cancelled
+		err = testClusterClient.CancelDropDisk(ctx, 2)
+		require.Error(t, err)
+	}
+
 	// test heartbeat
 	{
 		heartbeatInfos := make([]*blobnode.DiskHeartBeatInfo, 0)
@@ -144,6 +144,53 @@ func (s *idcStorage) alloc(ctx context.Context, count int, excludes map[proto.Di
 	return ret, nil
 }
 
+// allocFromHost alloc chunks from the disks of the host only, the chunks of one
+// blobnode are moved between its disks without crossing the network.
+func (s *idcStorage) allocFromHost(ctx context.Context, host string, count int, excludes map[proto.DiskID]*diskItem) ([]proto.DiskID, error) {
+	span := trace.SpanFromContextSafe(ctx)
+	var stg *blobNodeStorage
+	for _, blobNodeStg := range s.blobNodeStorages {
+		if blobNodeStg.host == host {
+			stg = blobNodeStg
+			break
+		}
+	}
+	if stg == nil || atomic.LoadInt64(&stg.freeChunk) < int64(count) {
+		span.Warnf("alloc from host failed, host: %s, blobNodeStorage: %+v", host, stg)
+		return nil, ErrNoEnoughSpace
+	}
+
+	chosenExcludes := make(map[proto.DiskID]*diskItem, len(excludes)+count)
+	for id, disk := range excludes {
+		chosenExcludes[id] = disk
+	}
+	chosenDisks := make([]*diskItem, 0, count)
+	for len(chosenDisks) < count {
+		disk := stg.allocDisk(ctx, chosenExcludes)
+		if disk == nil {
+			span.Warnf("alloc from host failed, host: %s, chosenDisks: %d, count: %d", host, len(chosenDisks), count)
+			return nil, ErrNoEnoughSpace
+		}
+		chosenExcludes[disk.diskID] = disk
+		chosenDisks = append(chosenDisks, disk)
+	}
+
+	ret := make([]proto.DiskID, 0, count)
+	atomic.AddInt64(&s.freeChunk, int64(-count))
+	atomic.AddInt64(&stg.freeChunk, int64(-count))
+	for _, disk := range chosenDisks {
+		disk.lock.Lock()
+		disk.info.FreeChunkCnt -= 1
+		rack := disk.info.Rack
+		disk.lock.Unlock()
+		if rackStg, ok := s.rackStorages[rack]; ok {
+			atomic.AddInt64(&rackStg.freeChunk, -1)
+		}
+		ret = append(ret, disk.diskID)
+	}
+	return ret, nil
+}
+
 // 1. alloc rack with free chunk weight
 // 2. alloc from rack's data node storage
 // 3. if can't meet the alloc count request, then retry with enable same rack
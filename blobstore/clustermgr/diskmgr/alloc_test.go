@@ -20,6 +20,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -439,6 +440,33 @@ func TestAllocWithDiffHost(t *testing.T) {
 	}
 }
 
+func TestAllocFromHost(t *testing.T) {
+	testDiskMgr, closeTestDiskMgr := initTestDiskMgr(t)
+	defer closeTestDiskMgr()
+	// disk never expire
+	testDiskMgr.HeartbeatExpireIntervalS = 6000
+
+	_, ctx := trace.StartSpanFromContext(context.Background(), "alloc-from-host")
+	// disks 1-10 are all on the host 0
+	initTestDiskMgrDisks(t, testDiskMgr, 1, 10, testIdcs[0])
+	testDiskMgr.refresh(ctx)
+	host := testIdcs[0] + hostPrefix + "0"
+	excludes := map[proto.DiskID]*diskItem{proto.DiskID(1): testDiskMgr.allDisks[proto.DiskID(1)]}
+
+	allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+	_, err := allocator.allocFromHost(ctx, host, 10, excludes)
+	require.Equal(t, ErrNoEnoughSpace, err)
+	_, err = allocator.allocFromHost(ctx, testIdcs[0]+hostPrefix+"1", 1, nil)
+	require.Equal(t, ErrNoEnoughSpace, err)
+
+	freeChunk := atomic.LoadInt64(&allocator.freeChunk)
+	diskIDs, err := allocator.allocFromHost(ctx, host, 9, excludes)
+	require.NoError(t, err)
+	require.Equal(t, 9, len(diskIDs))
+	require.NotContains(t, diskIDs, proto.DiskID(1))
+	require.Equal(t, freeChunk-9, atomic.LoadInt64(&allocator.freeChunk))
+}
+
 func TestAllocWithDiffRackAndSameHost(t *testing.T) {
 	testDiskMgr, closeTestDiskMgr := initTestDiskMgr(t)
 	defer closeTestDiskMgr()
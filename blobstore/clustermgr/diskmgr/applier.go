@@ -38,6 +38,7 @@ const (
 	OperTypeHeartbeatDiskInfo
 	OperTypeSwitchReadonly
 	OperTypeAdminUpdateDisk
+	OperTypeCancelDroppingDisk
 )
 
 func (d *DiskMgr) LoadData(ctx context.Context) error {
@@ -161,6 +162,18 @@ func (d *DiskMgr) Apply(ctx context.Context, operTypes []int32, datas [][]byte,
 				errs[idx] = d.droppedDisk(taskCtx, args.DiskID)
 				wg.Done()
 			})
+		case OperTypeCancelDroppingDisk:
+			args := &clustermgr.DiskInfoArgs{}
+			err := json.Unmarshal(datas[idx], args)
+			if err != nil {
+				errs[idx] = errors.Info(err, t, datas[idx]).Detail(err)
+				wg.Done()
+				continue
+			}
+			d.taskPool.Run(d.getTaskIdx(args.DiskID), func() {
+				errs[idx] = d.cancelDroppingDisk(taskCtx, args.DiskID)
+				wg.Done()
+			})
 		case OperTypeHeartbeatDiskInfo:
 			args := &clustermgr.DisksHeartbeatArgs{}
 			err := json.Unmarshal(datas[idx], args)
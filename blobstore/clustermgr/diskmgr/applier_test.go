@@ -142,6 +142,14 @@ func TestApplier_Apply(t *testing.T) {
 		datas = append(datas, data)
 	}
 
+	// OperTypeCancelDroppingDisk
+	{
+		data, err := json.Marshal(&clustermgr.DiskInfoArgs{DiskID: proto.DiskID(1)})
+		require.NoError(t, err)
+		operTypes = append(operTypes, OperTypeCancelDroppingDisk)
+		datas = append(datas, data)
+	}
+
 	// OperTypeSwitchReadonly
 	{
 		data, err := json.Marshal(&clustermgr.DiskAccessArgs{DiskID: proto.DiskID(3), Readonly: true})
@@ -89,6 +89,8 @@ type AllocPolicy struct {
 	Idc      string
 	Vuids    []proto.Vuid
 	Excludes []proto.DiskID
+	// Host alloc chunks from the disks of the host only if not empty
+	Host string
 }
 
 type HeartbeatEvent struct {
@@ -434,13 +436,17 @@ func (d *DiskMgr) AllocChunks(ctx context.Context, policy *AllocPolicy) (ret []p
 		}
 	}
 
-	ret, err = allocator.alloc(ctx, len(policy.Vuids), excludes)
+	if policy.Host != "" {
+		ret, err = allocator.allocFromHost(ctx, policy.Host, len(policy.Vuids), excludes)
+	} else {
+		ret, err = allocator.alloc(ctx, len(policy.Vuids), excludes)
+	}
 	if err != nil {
 		return
 	}
 
 	// check if allocated result is host aware or disk aware
-	if d.HostAware {
+	if d.HostAware && policy.Host == "" {
 		selectedHost := make(map[string]bool)
 		for i := range ret {
 			disk, ok := d.getDisk(ret[i])
@@ -681,6 +687,27 @@ func (d *DiskMgr) droppedDisk(ctx context.Context, id proto.DiskID) error {
 	return err
 }
 
+// cancelDroppingDisk remove the disk from dropping list, the disk keeps its status
+func (d *DiskMgr) cancelDroppingDisk(ctx context.Context, id proto.DiskID) error {
+	disk, ok := d.getDisk(id)
+	if !ok {
+		return apierrors.ErrCMDiskNotFound
+	}
+
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	if !disk.dropping {
+		return nil
+	}
+	err := d.droppedDiskTbl.CancelDroppingDisk(id)
+	if err != nil {
+		return errors.Info(err, "diskMgr.cancelDroppingDisk cancel dropping disk failed").Detail(err)
+	}
+	disk.dropping = false
+
+	return nil
+}
+
 // heartBeatDiskInfo process disk's heartbeat
 func (d *DiskMgr) heartBeatDiskInfo(ctx context.Context, infos []*blobnode.DiskHeartBeatInfo) error {
 	span := trace.SpanFromContextSafe(ctx)
@@ -52,6 +52,8 @@ func NewHandler(service *Service) *rpc.Router {
 
 	rpc.GET("/disk/droppinglist", service.DiskDroppingList)
 
+	rpc.POST("/disk/canceldrop", service.DiskCancelDrop, rpc.OptArgsBody())
+
 	rpc.POST("/disk/access", service.DiskAccess, rpc.OptArgsBody())
 
 	rpc.POST("/admin/disk/update", service.AdminDiskUpdate, rpc.OptArgsBody())
@@ -65,6 +65,12 @@ func (d *DroppedDiskTable) DroppedDisk(diskId proto.DiskID) error {
 	return d.tbl.Delete(key)
 }
 
+// CancelDroppingDisk remove a dropping disk when the dropping is cancelled
+func (d *DroppedDiskTable) CancelDroppingDisk(diskId proto.DiskID) error {
+	key := diskId.Encode()
+	return d.tbl.Delete(key)
+}
+
 // GetDroppingDisk find a dropping disk if exist
 func (d *DroppedDiskTable) IsDroppingDisk(diskId proto.DiskID) (exist bool, err error) {
 	key := diskId.Encode()
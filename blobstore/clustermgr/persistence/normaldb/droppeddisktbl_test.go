@@ -88,4 +88,18 @@ func TestDiskDropTbl(t *testing.T) {
 		require.Equal(t, 1, len(droppingList))
 		require.Equal(t, []proto.DiskID{diskID2}, droppingList)
 	}
+
+	// cancel dropping disk
+	{
+		err = diskDropTbl.CancelDroppingDisk(diskID2)
+		require.NoError(t, err)
+
+		exist, err := diskDropTbl.IsDroppingDisk(diskID2)
+		require.NoError(t, err)
+		require.Equal(t, false, exist)
+
+		droppingList, err := diskDropTbl.GetAllDroppingDisk()
+		require.NoError(t, err)
+		require.Equal(t, 0, len(droppingList))
+	}
 }
@@ -69,6 +69,7 @@ type VolumeUnitRecord struct {
 	Total      uint64
 	Used       uint64
 	Compacting bool
+	WriteEpoch uint32
 }
 
 type TokenRecord struct {
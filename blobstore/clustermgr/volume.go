@@ -179,6 +179,12 @@ func (s *Service) VolumeUpdate(c *rpc.Context) {
 		c.RespondError(err)
 		return
 	}
+	// fence the old chunk before updated, so no writes land on it once the volume unit is updated
+	if err = s.VolumeMgr.FenceVolumeUnit(ctx, args.OldVuid); err != nil {
+		span.Errorf("fence old volume unit failed, args: %v, error: %v", args, err)
+		c.RespondError(err)
+		return
+	}
 	data, err := json.Marshal(args)
 	if err != nil {
 		span.Errorf("update json marshal failed, args: %v, error: %v", args, err)
@@ -266,7 +272,7 @@ func (s *Service) VolumeUnitAlloc(c *rpc.Context) {
 	}
 	span.Debugf("accept VolumeUnitAlloc request, args: %v", args)
 
-	ret, err := s.VolumeMgr.AllocVolumeUnit(ctx, args.Vuid)
+	ret, err := s.VolumeMgr.AllocVolumeUnit(ctx, args.Vuid, args.Host)
 	if err != nil {
 		span.Error("alloc volumeUnit failed, err: ", errors.Detail(err))
 		c.RespondError(err)
@@ -17,8 +17,13 @@ package clustermgr
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -468,6 +473,33 @@ func TestService_AdminUpdateVolumeUnit(t *testing.T) {
 	require.Error(t, err)
 }
 
+var (
+	fenceBlobNodeOnce sync.Once
+	fenceBlobNodeAddr string
+)
+
+// fenceBlobNodePort returns the port of the blobnode serving the chunk fence only, it listens on
+// all the addresses, so the disks of the different hosts on loopback share it
+func fenceBlobNodePort() string {
+	fenceBlobNodeOnce.Do(func() {
+		ln, err := net.Listen("tcp", ":0")
+		if err != nil {
+			panic("listen fence blobnode error: " + err.Error())
+		}
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/chunk/fence/") {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		server.Listener.Close()
+		server.Listener = ln
+		server.Start()
+		fenceBlobNodeAddr = ln.Addr().String()
+	})
+	_, port, _ := net.SplitHostPort(fenceBlobNodeAddr)
+	return port
+}
+
 func generateVolume(volumeDBPath, NormalDBPath string) error {
 	var (
 		unitCount = 27
@@ -541,7 +573,7 @@ func generateVolume(volumeDBPath, NormalDBPath string) error {
 			ClusterID:    proto.ClusterID(1),
 			Idc:          "z0",
 			Rack:         "rack1",
-			Host:         "http://127.0.0." + strconv.Itoa(i) + ":80800",
+			Host:         "http://127.0.0." + strconv.Itoa(i) + ":" + fenceBlobNodePort(),
 			Path:         "",
 			Status:       proto.DiskStatusNormal,
 			Readonly:     false,
@@ -61,9 +61,10 @@ func (vol *volume) ToVolumeInfo() cm.VolumeInfo {
 	units := make([]cm.Unit, len(vol.vUnits))
 	for i, vUnit := range vol.vUnits {
 		units[i] = cm.Unit{
-			Vuid:   vUnit.vuInfo.Vuid,
-			DiskID: vUnit.vuInfo.DiskID,
-			Host:   vUnit.vuInfo.Host,
+			Vuid:       vUnit.vuInfo.Vuid,
+			DiskID:     vUnit.vuInfo.DiskID,
+			Host:       vUnit.vuInfo.Host,
+			WriteEpoch: vUnit.writeEpoch,
 		}
 	}
 	return cm.VolumeInfo{
@@ -150,6 +151,7 @@ type volumeUnit struct {
 	vuidPrefix proto.VuidPrefix
 	epoch      uint32
 	nextEpoch  uint32
+	writeEpoch uint32 // fence epoch of the writes, see cm.Unit
 	vuInfo     *cm.VolumeUnitInfo
 }
 
@@ -163,6 +165,7 @@ func (vUnit *volumeUnit) ToVolumeUnitRecord() (ret *volumedb.VolumeUnitRecord) {
 		Used:       vUnit.vuInfo.Used,
 		Total:      vUnit.vuInfo.Total,
 		Compacting: vUnit.vuInfo.Compacting,
+		WriteEpoch: vUnit.writeEpoch,
 	}
 }
 
@@ -316,6 +319,7 @@ func volumeUnitRecordToVolumeUnit(record *volumedb.VolumeUnitRecord) (ret *volum
 		vuidPrefix: record.VuidPrefix,
 		epoch:      record.Epoch,
 		nextEpoch:  record.NextEpoch,
+		writeEpoch: record.WriteEpoch,
 		vuInfo: &cm.VolumeUnitInfo{
 			Vuid:       proto.EncodeVuid(record.VuidPrefix, record.Epoch),
 			DiskID:     record.DiskID,
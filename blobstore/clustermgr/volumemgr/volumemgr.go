@@ -55,6 +55,10 @@ const (
 	defaultListVolumeMaxCount          = 2000
 	defaultAllocFactor                 = 5
 	defaultAllocatableSize             = 1 << 30
+
+	// retry of fencing the chunks of volume units
+	fenceChunkRetryTimes      = 3
+	fenceChunkRetryIntervalMS = 200
 )
 
 // notify queue key definition
@@ -102,8 +106,9 @@ type VolumeMgrAPI interface {
 	// DiskWritableChange call when disk broken or heartbeat timeout or switch readonly, it'll refresh volume's health
 	DiskWritableChange(ctx context.Context, diskID proto.DiskID) (err error)
 
-	// AllocVolumeUnit alloc a new chunk to volume unit, it will increase volumeUnit's nextEpoch in memory
-	AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*cm.AllocVolumeUnit, error)
+	// AllocVolumeUnit alloc a new chunk to volume unit, it will increase volumeUnit's nextEpoch in memory,
+	// the new chunk is allocated on the disks of the same host with vuid if host is not empty
+	AllocVolumeUnit(ctx context.Context, vuid proto.Vuid, host string) (*cm.AllocVolumeUnit, error)
 
 	// ReleaseVolumeUnit release old volume unit's chunk
 	ReleaseVolumeUnit(ctx context.Context, vuid proto.Vuid, diskID proto.DiskID, force bool) (err error)
@@ -371,6 +376,23 @@ func (v *VolumeMgr) AllocVolume(ctx context.Context, mode codemode.CodeMode, cou
 		return nil, apierrors.ErrNoAvailableVolume
 	}
 
+	// fence the chunks by the raised write epochs before returned, so the writes of the previous holders
+	// are rejected before the first write of the new holder. the volumes failed to fence are not returned,
+	// they are left allocated until expired
+	fenced := make([]cm.AllocVolumeInfo, 0, len(ret.AllocVolumeInfos))
+	for i := range ret.AllocVolumeInfos {
+		if err = v.fenceVolume(ctx, &ret.AllocVolumeInfos[i].VolumeInfo); err != nil {
+			span.Warnf("fence volume(%d) failed: %v", ret.AllocVolumeInfos[i].Vid, err)
+			continue
+		}
+		fenced = append(fenced, ret.AllocVolumeInfos[i])
+	}
+	if len(fenced) == 0 {
+		span.Errorf("no fenced volume, alloc args:%+v, alloc volume is:%d", allocArgs, len(ret.AllocVolumeInfos))
+		return nil, apierrors.ErrNoAvailableVolume
+	}
+	ret = &cm.AllocatedVolumeInfos{AllocVolumeInfos: fenced}
+
 	return ret, nil
 }
 
@@ -591,11 +613,17 @@ func (v *VolumeMgr) applyAllocVolume(ctx context.Context, vid proto.Vid, host st
 		expireTime: expireTime,
 	}
 	volume.token = token
+	// the new holder takes over the units with the raised write epochs, the writes of the
+	// previous holders are fenced once the chunks see the new epochs
+	for _, unit := range volume.vUnits {
+		unit.writeEpoch++
+	}
 	// set volume status into active, it'll call change status event function
 	volume.setStatus(ctx, proto.VolumeStatusActive)
 	volRecord := volume.ToRecord()
 	tokenRecord := token.ToTokenRecord()
-	err = v.volumeTbl.PutVolumeAndToken([]*volumedb.VolumeRecord{volRecord}, []*volumedb.TokenRecord{tokenRecord})
+	err = v.volumeTbl.PutVolumes([]*volumedb.VolumeRecord{volRecord},
+		[][]*volumedb.VolumeUnitRecord{volumeUnitsToVolumeUnitRecords(volume.vUnits)}, []*volumedb.TokenRecord{tokenRecord})
 	if err != nil {
 		volume.lock.Unlock()
 		err = errors.Info(err, "put volume and tokenID in db error").Detail(err)
@@ -502,6 +502,50 @@ func TestVolumeMgr_AllocVolume(t *testing.T) {
 		require.Nil(t, ret)
 	}
 
+	// the chunks of the allocated volumes are fenced before returned, the volumes failed to fence are not returned
+	{
+		dnClient := mocks.NewMockStorageAPI(gomock.NewController(t))
+		blobNodeClient := mockVolumeMgr.blobNodeClient
+		mockVolumeMgr.blobNodeClient = dnClient
+		defer func() { mockVolumeMgr.blobNodeClient = blobNodeClient }()
+
+		fencedInfos := make([]clustermgr.AllocVolumeInfo, len(allocVolumeInfos))
+		copy(fencedInfos, allocVolumeInfos)
+		for i := range fencedInfos {
+			vid := fencedInfos[i].Vid
+			fencedInfos[i].Units = []clustermgr.Unit{
+				{Vuid: proto.EncodeVuid(proto.EncodeVuidPrefix(vid, 0), 1), DiskID: 1, WriteEpoch: 2},
+				{Vuid: proto.EncodeVuid(proto.EncodeVuidPrefix(vid, 1), 1), DiskID: 2, WriteEpoch: 2},
+			}
+		}
+		dnClient.EXPECT().FenceChunk(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+			func(_ context.Context, _ string, args *blobnode.FenceChunkArgs) error {
+				require.Equal(t, uint32(2), args.Epoch)
+				if args.Vuid.Vid() == 3 && args.DiskID == 2 {
+					return errors.New("timeout")
+				}
+				return nil
+			})
+		proposeFenced := func(ctx context.Context, data []byte) error {
+			mockVolumeMgr.pendingEntries.Range(func(key, value interface{}) bool {
+				mockVolumeMgr.pendingEntries.Store(key, &clustermgr.AllocatedVolumeInfos{AllocVolumeInfos: fencedInfos})
+				return true
+			})
+			return nil
+		}
+		mockRaftServer.EXPECT().Propose(gomock.Any(), gomock.Any()).DoAndReturn(proposeFenced)
+		ret, err := mockVolumeMgr.AllocVolume(ctx, mode, len(args.Vids), args.Host)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(ret.AllocVolumeInfos))
+		require.Equal(t, proto.Vid(1), ret.AllocVolumeInfos[0].Vid)
+
+		// none of the volumes fenced
+		fencedInfos = fencedInfos[1:]
+		mockRaftServer.EXPECT().Propose(gomock.Any(), gomock.Any()).DoAndReturn(proposeFenced)
+		_, err = mockVolumeMgr.AllocVolume(ctx, mode, len(args.Vids), args.Host)
+		require.Error(t, err)
+	}
+
 	// failed case , no pending entries
 	{
 		mockRaftServer.EXPECT().Propose(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, data []byte) error {
@@ -565,6 +609,10 @@ func TestVolumeMgr_applyAllocVolume(t *testing.T) {
 			_, err := mockVolumeMgr.applyAllocVolume(ctx, vid, args.Host, args.ExpireTime)
 			require.NoError(t, err)
 		}
+		// the write epochs of the units are raised for the new holder
+		for _, unit := range mockVolumeMgr.all.getVol(args.Vids[0]).ToVolumeInfo().Units {
+			require.Equal(t, uint32(1), unit.WriteEpoch)
+		}
 
 		allocVolLenMap = mockVolumeMgr.allocator.StatAllocatable()
 		afterLength := allocVolLenMap[mode]
@@ -17,6 +17,7 @@ package volumemgr
 import (
 	"context"
 	"encoding/json"
+	"sync"
 
 	"github.com/google/uuid"
 
@@ -26,8 +27,10 @@ import (
 	"github.com/cubefs/cubefs/blobstore/clustermgr/diskmgr"
 	apierrors "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	"github.com/cubefs/cubefs/blobstore/util/errors"
+	"github.com/cubefs/cubefs/blobstore/util/retry"
 )
 
 // ListVolumeUnitInfo return disk's volume unit infos, it use index-table disk-vuid as index
@@ -49,7 +52,7 @@ func (v *VolumeMgr) ListVolumeUnitInfo(ctx context.Context, args *cmapi.ListVolu
 	return ret, nil
 }
 
-func (v *VolumeMgr) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*cmapi.AllocVolumeUnit, error) {
+func (v *VolumeMgr) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid, host string) (*cmapi.AllocVolumeUnit, error) {
 	span := trace.SpanFromContextSafe(ctx)
 	vid := vuid.Vid()
 	vol := v.all.getVol(vid)
@@ -98,8 +101,12 @@ func (v *VolumeMgr) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*cmap
 	if err != nil {
 		return nil, errors.Info(err, "get disk info failed").Detail(err)
 	}
+	if host != "" && host != diskInfo.Host {
+		span.Errorf("alloc volume unit host not match, vuid: %d, host: %s, disk host: %s", vuid, host, diskInfo.Host)
+		return nil, apierrors.ErrIllegalArguments
+	}
 
-	policy := &diskmgr.AllocPolicy{Idc: diskInfo.Idc, Vuids: []proto.Vuid{newVuid.(proto.Vuid)}, Excludes: excludes}
+	policy := &diskmgr.AllocPolicy{Idc: diskInfo.Idc, Vuids: []proto.Vuid{newVuid.(proto.Vuid)}, Excludes: excludes, Host: host}
 	allocDiskID, err := v.diskMgr.AllocChunks(ctx, policy)
 	if err != nil {
 		return nil, errors.Info(err, "alloc chunk failed").Detail(err)
@@ -168,6 +175,81 @@ func (v *VolumeMgr) ReleaseVolumeUnit(ctx context.Context, vuid proto.Vuid, disk
 	return
 }
 
+// FenceVolumeUnit fences the writes to the chunk of the volume unit to be reallocated with the write
+// epoch raised by the update, so no writes land on the old chunk once the volume unit is updated.
+func (v *VolumeMgr) FenceVolumeUnit(ctx context.Context, oldVuid proto.Vuid) (err error) {
+	vol := v.all.getVol(oldVuid.Vid())
+	if vol == nil {
+		return ErrVolumeNotExist
+	}
+	index := oldVuid.Index()
+	vol.lock.RLock()
+	if int(index) >= len(vol.vUnits) || vol.vUnits[index].vuInfo.Vuid != oldVuid {
+		vol.lock.RUnlock()
+		return ErrOldVuidNotMatch
+	}
+	diskID, epoch := vol.vUnits[index].vuInfo.DiskID, vol.vUnits[index].writeEpoch+1
+	vol.lock.RUnlock()
+
+	return v.fenceChunk(ctx, diskID, oldVuid, epoch)
+}
+
+// fenceVolume fences the writes to the chunks of all units of the allocated volume carrying
+// the write epochs lower than the units, so the previous holders of the volume can not write to it.
+func (v *VolumeMgr) fenceVolume(ctx context.Context, volInfo *cmapi.VolumeInfo) (err error) {
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+	)
+	for i := range volInfo.Units {
+		unit := volInfo.Units[i]
+		if unit.WriteEpoch == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if e := v.fenceChunk(ctx, unit.DiskID, unit.Vuid, unit.WriteEpoch); e != nil {
+				once.Do(func() {
+					err = errors.Info(e, "fence volume unit", unit.Vuid).Detail(e)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+// fenceChunk raises the fence epoch of the chunk with retry. the chunks on the broken or dropped
+// disks and the chunks not found are skipped, as no writes can land on them.
+func (v *VolumeMgr) fenceChunk(ctx context.Context, diskID proto.DiskID, vuid proto.Vuid, epoch uint32) error {
+	span := trace.SpanFromContextSafe(ctx)
+	diskInfo, err := v.diskMgr.GetDiskInfo(ctx, diskID)
+	if err != nil {
+		return err
+	}
+	if diskInfo.Status >= proto.DiskStatusBroken {
+		span.Infof("skip fence vuid(%d) on disk(%d) status %s", vuid, diskID, diskInfo.Status)
+		return nil
+	}
+
+	return retry.Timed(fenceChunkRetryTimes, fenceChunkRetryIntervalMS).On(func() error {
+		err := v.blobNodeClient.FenceChunk(ctx, diskInfo.Host, &blobnode.FenceChunkArgs{
+			DiskID: diskID,
+			Vuid:   vuid,
+			Epoch:  epoch,
+		})
+		if rpc.DetectStatusCode(err) == apierrors.CodeVuidNotFound {
+			span.Warnf("fence vuid(%d) on disk(%d) not found", vuid, diskID)
+			return nil
+		}
+		if err != nil {
+			span.Warnf("fence vuid(%d) on disk(%d) to epoch %d failed: %v", vuid, diskID, epoch, err)
+		}
+		return err
+	})
+}
+
 func (v *VolumeMgr) applyUpdateVolumeUnit(ctx context.Context, newVuid proto.Vuid, newDiskID proto.DiskID) (err error) {
 	span := trace.SpanFromContextSafe(ctx)
 	span.Debugf("start apply update volume unit, newVuid is %d, newDiskID is %d", newVuid, newDiskID)
@@ -208,6 +290,8 @@ func (v *VolumeMgr) applyUpdateVolumeUnit(ctx context.Context, newVuid proto.Vui
 	vol.vUnits[index].vuInfo.Host = diskInfo.Host
 	vol.vUnits[index].vuInfo.Compacting = false
 	vol.vUnits[index].vuInfo.Vuid = newVuid
+	// the old chunk is fenced by the raised write epoch
+	vol.vUnits[index].writeEpoch++
 
 	unitRecord := vol.vUnits[index].ToVolumeUnitRecord()
 	err = v.volumeTbl.UpdateVolumeUnit(unitRecord.VuidPrefix, unitRecord)
@@ -24,6 +24,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/api/blobnode"
 	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
 	"github.com/cubefs/cubefs/blobstore/clustermgr/diskmgr"
+	apierrors "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	"github.com/cubefs/cubefs/blobstore/testing/mocks"
@@ -75,19 +76,19 @@ func TestVolumeMgr_AllocVolumeUnit(t *testing.T) {
 		return nil
 	})
 	mockVolumeMgr.raftServer = mockRaftServer
-	ret, err := mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1))
+	ret, err := mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1), "")
 	require.NoError(t, err)
 	require.Equal(t, ret.Vuid, proto.EncodeVuid(vuidPrefix, 3))
 	require.NotEqual(t, ret.DiskID, 0)
 
 	// failed case,raft propose error
 	mockRaftServer.EXPECT().Propose(gomock.Any(), gomock.Any()).Return(errors.New("error"))
-	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1))
+	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1), "")
 	require.Error(t, err)
 	require.Nil(t, ret)
 
 	// failed case:vid not exist
-	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(44, 1), 1))
+	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(44, 1), 1), "")
 	require.Error(t, err)
 	require.Nil(t, ret)
 
@@ -100,12 +101,12 @@ func TestVolumeMgr_AllocVolumeUnit(t *testing.T) {
 		})
 		return nil
 	})
-	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1))
+	ret, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(vuidPrefix, 1), "")
 	require.Error(t, err)
 	require.Nil(t, ret)
 
 	// failed case , index over
-	_, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(1, 30), 1))
+	_, err = mockVolumeMgr.AllocVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(1, 30), 1), "")
 	require.Error(t, err)
 }
 
@@ -205,6 +206,23 @@ func TestVolumeMgr_updateVolumeUnit(t *testing.T) {
 		volInfo.vUnits[0].nextEpoch = 2
 		volInfo.lock.Unlock()
 
+		oldUnit := mockVolumeMgr.all.getVol(3).ToVolumeInfo().Units[0]
+		require.Equal(t, uint32(0), oldUnit.WriteEpoch)
+
+		// the old chunk is fenced by the write epoch to be raised before updated
+		fenceArgs := &blobnode.FenceChunkArgs{DiskID: oldUnit.DiskID, Vuid: oldUnit.Vuid, Epoch: 1}
+		dnClient.EXPECT().FenceChunk(gomock.Any(), "127.0.0.1", fenceArgs).Return(errors.New("timeout"))
+		dnClient.EXPECT().FenceChunk(gomock.Any(), "127.0.0.1", fenceArgs).Return(nil)
+		err = mockVolumeMgr.FenceVolumeUnit(ctx, oldUnit.Vuid)
+		require.NoError(t, err)
+		dnClient.EXPECT().FenceChunk(gomock.Any(), "127.0.0.1", fenceArgs).Times(fenceChunkRetryTimes).Return(errors.New("timeout"))
+		err = mockVolumeMgr.FenceVolumeUnit(ctx, oldUnit.Vuid)
+		require.Error(t, err)
+		// the chunk not found is not writable
+		dnClient.EXPECT().FenceChunk(gomock.Any(), "127.0.0.1", fenceArgs).Return(apierrors.ErrNoSuchVuid)
+		err = mockVolumeMgr.FenceVolumeUnit(ctx, oldUnit.Vuid)
+		require.NoError(t, err)
+
 		// success case, vid=1 volume status=active
 		err = mockVolumeMgr.applyUpdateVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(3, 0), 2), 2)
 		require.NoError(t, err)
@@ -212,6 +230,11 @@ func TestVolumeMgr_updateVolumeUnit(t *testing.T) {
 		// repeat update
 		err = mockVolumeMgr.applyUpdateVolumeUnit(ctx, proto.EncodeVuid(proto.EncodeVuidPrefix(3, 0), 2), 2)
 		require.NoError(t, err)
+		require.Equal(t, uint32(1), volInfo.vUnits[0].writeEpoch)
+
+		// reallocated already
+		err = mockVolumeMgr.FenceVolumeUnit(ctx, oldUnit.Vuid)
+		require.Equal(t, ErrOldVuidNotMatch, err)
 
 		units, err := mockVolumeMgr.ListVolumeUnitInfo(ctx, &clustermgr.ListVolumeUnitArgs{DiskID: 2})
 		require.NoError(t, err)
@@ -43,6 +43,7 @@ const (
 	CodeTooManyChunks    = 632
 	CodeChunkInuse       = 633
 	CodeSizeOverBurst    = 634
+	CodeChunkFenced      = 635
 
 	CodeBidNotFound          = 651
 	CodeShardSizeTooLarge    = 652
@@ -51,6 +52,7 @@ const (
 	CodeShardInvalidOffset   = 655
 	CodeShardListExceedLimit = 656
 	CodeShardInvalidBid      = 657
+	CodeShardCrcMismatch     = 658
 
 	CodeDestReplicaBad = 670
 	CodeOrphanShard    = 671
@@ -84,6 +86,7 @@ var (
 	ErrTooManyChunks    = Error(CodeTooManyChunks)
 	ErrChunkInuse       = Error(CodeChunkInuse)
 	ErrSizeOverBurst    = Error(CodeSizeOverBurst)
+	ErrChunkFenced      = Error(CodeChunkFenced)
 
 	ErrNoSuchBid            = Error(CodeBidNotFound)
 	ErrShardSizeTooLarge    = Error(CodeShardSizeTooLarge)
@@ -92,6 +95,7 @@ var (
 	ErrShardInvalidOffset   = Error(CodeShardInvalidOffset)
 	ErrShardListExceedLimit = Error(CodeShardListExceedLimit)
 	ErrShardInvalidBid      = Error(CodeShardInvalidBid)
+	ErrShardCrcMismatch     = Error(CodeShardCrcMismatch)
 
 	ErrOrphanShard    = Error(CodeOrphanShard)
 	ErrIllegalTask    = Error(CodeIllegalTask)
@@ -121,6 +121,7 @@ var errCodeMap = map[int]string{
 	CodeTooManyChunks:    "too many chunks",
 	CodeChunkInuse:       "chunk in use",
 	CodeSizeOverBurst:    "request size over limit burst",
+	CodeChunkFenced:      "chunk fenced by newer volume epoch",
 
 	CodeBidNotFound:          "bid not found",
 	CodeShardSizeTooLarge:    "shard size too large",
@@ -129,6 +130,7 @@ var errCodeMap = map[int]string{
 	CodeShardInvalidOffset:   "shard offset is invalid",
 	CodeShardInvalidBid:      "shard key bid is invalid",
 	CodeShardListExceedLimit: "shard list exceed the limit",
+	CodeShardCrcMismatch:     "shard crc mismatch",
 
 	CodeDestReplicaBad: "dest replica is bad can not repair",
 	CodeOrphanShard:    "shard is an orphan",
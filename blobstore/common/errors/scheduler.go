@@ -27,6 +27,13 @@ var (
 	ErrCanNotDropped         = errors.New("disk can not dropped")
 	ErrUnexpectMigrationTask = errors.New("unexpect migration task")
 	ErrIllegalDiskID         = errors.New("illegal disk id")
+	ErrNotDroppingDisk       = errors.New("disk is not dropping")
+	ErrNotDrainingHost       = errors.New("host is not draining")
+	ErrNoDisksInHost         = errors.New("no disks in host")
+	ErrHostInMaintenance     = errors.New("host is in maintenance")
+	ErrNotMaintenanceHost    = errors.New("host is not in maintenance")
+	ErrVolumeFrozen          = errors.New("volume is frozen")
+	ErrNotFrozenVolume       = errors.New("volume is not frozen")
 
 	// error code
 	ErrNothingTodo = Error(CodeNotingTodo)
@@ -135,3 +135,15 @@ func (t TaskSwitch) Valid() bool {
 func (t TaskSwitch) String() string {
 	return string(t)
 }
+
+// blobnode worker config keys in config center
+const (
+	// WorkerConfigMeterKey value is json of blobnode worker config meter
+	WorkerConfigMeterKey      = "worker_config_meter"
+	workerTaskSwitchKeyPrefix = "worker_switch_"
+)
+
+// WorkerTaskSwitchKey returns the config key of worker task type switch
+func WorkerTaskSwitchKey(taskType TaskType) string {
+	return workerTaskSwitchKeyPrefix + taskType.String()
+}
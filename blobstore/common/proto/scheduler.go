@@ -43,12 +43,15 @@ const (
 	TaskTypeVolumeInspect TaskType = "volume_inspect"
 	TaskTypeShardRepair   TaskType = "shard_repair"
 	TaskTypeBlobDelete    TaskType = "blob_delete"
+
+	// TaskTypeIntraNodeBalance moves the chunks between the disks of one blobnode
+	TaskTypeIntraNodeBalance TaskType = "intra_node_balance"
 )
 
 func (t TaskType) Valid() bool {
 	switch t {
 	case TaskTypeDiskRepair, TaskTypeBalance, TaskTypeDiskDrop, TaskTypeManualMigrate,
-		TaskTypeVolumeInspect, TaskTypeShardRepair, TaskTypeBlobDelete:
+		TaskTypeVolumeInspect, TaskTypeShardRepair, TaskTypeBlobDelete, TaskTypeIntraNodeBalance:
 		return true
 	default:
 		return false
@@ -111,6 +114,9 @@ type MigrateTask struct {
 	ForbiddenDirectDownload bool `json:"forbidden_direct_download"`
 
 	WorkerRedoCnt uint8 `json:"worker_redo_cnt"` // worker redo task count
+
+	// bids skipped by the worker since too slow to recover, they are missing in the destination
+	SkippedBids []BlobID `json:"skipped_bids,omitempty"`
 }
 
 func (t *MigrateTask) Vid() Vid {
@@ -147,6 +153,9 @@ func (t *MigrateTask) Copy() *MigrateTask {
 	dst := make([]VunitLocation, len(t.Sources))
 	copy(dst, t.Sources)
 	task.Sources = dst
+	if t.SkippedBids != nil {
+		task.SkippedBids = append([]BlobID(nil), t.SkippedBids...)
+	}
 	return task
 }
 
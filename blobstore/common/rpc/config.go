@@ -15,6 +15,7 @@
 package rpc
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"time"
@@ -40,6 +41,9 @@ type TransportConfig struct {
 	// requesting compression with an "Accept-Encoding: gzip"
 	DisableCompression bool `json:"disable_compression"`
 
+	// network of the connections
+	Network NetworkConfig `json:"network"`
+
 	// auth config
 	Auth auth.Config `json:"auth"`
 }
@@ -49,6 +53,7 @@ type TransportConfig struct {
 func (tc TransportConfig) Default() TransportConfig {
 	noAuth := tc
 	noAuth.Auth = auth.Config{}
+	noAuth.Network = NetworkConfig{}
 	none := TransportConfig{}
 	if noAuth == none {
 		return TransportConfig{
@@ -57,7 +62,8 @@ func (tc TransportConfig) Default() TransportConfig {
 			MaxIdleConnsPerHost: 10,
 			IdleConnTimeoutMs:   10 * 1000,
 
-			Auth: tc.Auth,
+			Network: tc.Network,
+			Auth:    tc.Auth,
 		}
 	}
 	return tc
@@ -76,10 +82,16 @@ func NewTransport(cfg *TransportConfig) http.RoundTripper {
 		WriteBufferSize:       1 << 16,
 		ReadBufferSize:        1 << 16,
 	}
-	tr.DialContext = (&net.Dialer{
+	tr.DialContext = cfg.Network.dialer(&net.Dialer{
 		Timeout:   time.Duration(cfg.DialTimeoutMs) * time.Millisecond,
 		KeepAlive: 30 * time.Second,
 	}).DialContext
+	if socket := cfg.Network.UnixSocket; socket != "" {
+		dialer := &net.Dialer{Timeout: time.Duration(cfg.DialTimeoutMs) * time.Millisecond}
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
 
 	if cfg.Auth.EnableAuth {
 		authTr := auth.NewAuthTransport(tr, &cfg.Auth)
@@ -0,0 +1,260 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version handshake between components.
+//
+//   - the client carries its version and features in every request headers,
+//   - the server acks with its version and features in response headers,
+//   - the client caches the version and features of the peer host.
+//
+// A peer without ack is a legacy peer which supports none of features,
+// so that new fields and endpoints can be gated by features during rolling upgrade.
+
+// headers of handshake
+const (
+	HeaderVersion     = "X-Blobstore-Version"
+	HeaderFeatures    = "X-Blobstore-Features"
+	HeaderAckVersion  = "X-Ack-Blobstore-Version"
+	HeaderAckFeatures = "X-Ack-Blobstore-Features"
+)
+
+// builtin features of rpc
+const (
+	FeatureCrcEncoded = "crc_encoded"
+)
+
+const (
+	featureSeparator = ","
+	// peer info expires to detect the upgrade or downgrade of peer
+	peerInfoExpiration = 10 * time.Minute
+	// unchanged peer info is refreshed after half of expiration
+	peerInfoRefresh = peerInfoExpiration / 2
+)
+
+// ErrPeerUnsupported the peer does not support the feature
+var ErrPeerUnsupported = NewError(http.StatusNotImplemented, "PeerUnsupported", errors.New("peer does not support the feature"))
+
+var (
+	localVersion     string
+	localVersionOnce sync.Once
+
+	localFeatures = struct {
+		sync.RWMutex
+		features map[string]struct{}
+		encoded  string
+	}{
+		features: map[string]struct{}{FeatureCrcEncoded: {}},
+		encoded:  FeatureCrcEncoded,
+	}
+
+	peers sync.Map // host -> *PeerInfo
+)
+
+// PeerInfo version and features of the peer.
+type PeerInfo struct {
+	Host       string
+	Version    string
+	Features   map[string]struct{}
+	Legacy     bool // peer not supports handshake
+	UpdateTime time.Time
+
+	encodedFeatures string
+}
+
+// Supports returns true if the peer supports the feature.
+func (p *PeerInfo) Supports(feature string) bool {
+	_, ok := p.Features[feature]
+	return ok
+}
+
+// RegisterFeatures registers features supported by this program,
+// should be called at startup before serving.
+func RegisterFeatures(features ...string) {
+	localFeatures.Lock()
+	for _, feature := range features {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			localFeatures.features[feature] = struct{}{}
+		}
+	}
+	fs := make([]string, 0, len(localFeatures.features))
+	for feature := range localFeatures.features {
+		fs = append(fs, feature)
+	}
+	sort.Strings(fs)
+	localFeatures.encoded = strings.Join(fs, featureSeparator)
+	localFeatures.Unlock()
+}
+
+// LocalFeatures returns sorted features supported by this program.
+func LocalFeatures() []string {
+	return decodeFeatureList(encodedLocalFeatures())
+}
+
+// LocalVersion returns version of this program.
+func LocalVersion() string {
+	localVersionOnce.Do(func() {
+		localVersion = programVersion()
+	})
+	return localVersion
+}
+
+func encodedLocalFeatures() string {
+	localFeatures.RLock()
+	defer localFeatures.RUnlock()
+	return localFeatures.encoded
+}
+
+func decodeFeatureList(encoded string) []string {
+	features := make([]string, 0)
+	for _, feature := range strings.Split(encoded, featureSeparator) {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+func decodeFeatures(encoded string) map[string]struct{} {
+	features := make(map[string]struct{})
+	for _, feature := range decodeFeatureList(encoded) {
+		features[feature] = struct{}{}
+	}
+	return features
+}
+
+// peerKey returns host:port of the host, which may be an url with scheme.
+func peerKey(host string) string {
+	if strings.Contains(host, "://") {
+		if u, err := url.Parse(host); err == nil {
+			return u.Host
+		}
+	}
+	return host
+}
+
+// GetPeerInfo returns the handshake info of the host, return false if never handshake.
+func GetPeerInfo(host string) (*PeerInfo, bool) {
+	val, ok := peers.Load(peerKey(host))
+	if !ok {
+		return nil, false
+	}
+	peer := val.(*PeerInfo)
+	if time.Since(peer.UpdateTime) > peerInfoExpiration {
+		return peer, false
+	}
+	return peer, true
+}
+
+// PeerSupports returns true if the host has handshake and supports the feature.
+func PeerSupports(host, feature string) bool {
+	peer, ok := GetPeerInfo(host)
+	return ok && peer.Supports(feature)
+}
+
+// Handshake negotiates with the host explicitly, any path on the server
+// acks the handshake, so that it works with all components.
+// The returned peer is nil if the client does not handshake.
+func Handshake(ctx context.Context, cli Client, host string) (*PeerInfo, error) {
+	resp, err := cli.Head(ctx, strings.TrimSuffix(host, "/")+"/")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	peer, _ := GetPeerInfo(resp.Request.URL.Host)
+	return peer, nil
+}
+
+// PeerSupportsWith returns true if the host supports the feature,
+// negotiates with the host if never handshake or expired.
+func PeerSupportsWith(ctx context.Context, cli Client, host, feature string) (bool, error) {
+	if peer, ok := GetPeerInfo(host); ok {
+		return peer.Supports(feature), nil
+	}
+	peer, err := Handshake(ctx, cli, host)
+	if err != nil {
+		return false, err
+	}
+	return peer != nil && peer.Supports(feature), nil
+}
+
+func setHandshakeHeader(req *http.Request) {
+	if req.Header.Get(HeaderVersion) == "" {
+		req.Header.Set(HeaderVersion, LocalVersion())
+		req.Header.Set(HeaderFeatures, encodedLocalFeatures())
+	}
+}
+
+// recordPeerInfo records the ack of the peer, the unchanged peer info
+// is not rebuilt in every request until refresh.
+func recordPeerInfo(req *http.Request, resp *http.Response) {
+	if req.URL == nil || req.URL.Host == "" {
+		return
+	}
+	version := resp.Header.Get(HeaderAckVersion)
+	encoded := resp.Header.Get(HeaderAckFeatures)
+	if val, ok := peers.Load(req.URL.Host); ok {
+		old := val.(*PeerInfo)
+		if old.Version == version && old.encodedFeatures == encoded &&
+			time.Since(old.UpdateTime) < peerInfoRefresh {
+			return
+		}
+	}
+
+	peer := &PeerInfo{Host: req.URL.Host, UpdateTime: time.Now()}
+	if version != "" {
+		peer.Version = version
+		peer.Features = decodeFeatures(encoded)
+		peer.encodedFeatures = encoded
+	} else {
+		peer.Legacy = true
+		peer.Features = make(map[string]struct{})
+	}
+	peers.Store(peer.Host, peer)
+}
+
+type handshaker struct{}
+
+var _ ProgressHandler = (*handshaker)(nil)
+
+func (*handshaker) Handler(w http.ResponseWriter, req *http.Request, f func(http.ResponseWriter, *http.Request)) {
+	if req.Header.Get(HeaderVersion) != "" {
+		w.Header().Set(HeaderAckVersion, LocalVersion())
+		w.Header().Set(HeaderAckFeatures, encodedLocalFeatures())
+	}
+	f(w, req)
+}
+
+// PeerVersion returns version of the client, empty if the client is legacy.
+func (c *Context) PeerVersion() string {
+	return c.Request.Header.Get(HeaderVersion)
+}
+
+// PeerSupports returns true if the client supports the feature.
+func (c *Context) PeerSupports(feature string) bool {
+	_, ok := decodeFeatures(c.Request.Header.Get(HeaderFeatures))[feature]
+	return ok
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakeFeatures(t *testing.T) {
+	RegisterFeatures(" feature_b ", "feature_a", "")
+	features := LocalFeatures()
+	require.Equal(t, []string{"crc_encoded", "feature_a", "feature_b"}, features)
+	require.NotEmpty(t, LocalVersion())
+	require.Equal(t, map[string]struct{}{"a": {}, "b": {}}, decodeFeatures("a, b,,"))
+}
+
+func TestHandshakeServerAndClient(t *testing.T) {
+	RegisterFeatures("feature_handshake")
+
+	router := New()
+	router.Handle(http.MethodGet, "/feature", func(c *Context) {
+		c.RespondJSON(map[string]interface{}{
+			"version":   c.PeerVersion(),
+			"supported": c.PeerSupports("feature_handshake"),
+		})
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+	host := mustHost(t, server.URL)
+
+	cli := NewClient(&Config{})
+	ret := make(map[string]interface{})
+	require.NoError(t, cli.GetWith(context.Background(), server.URL+"/feature", &ret))
+	require.Equal(t, LocalVersion(), ret["version"])
+	require.Equal(t, true, ret["supported"])
+
+	peer, ok := GetPeerInfo(host)
+	require.True(t, ok)
+	require.False(t, peer.Legacy)
+	require.Equal(t, LocalVersion(), peer.Version)
+	require.True(t, peer.Supports("feature_handshake"))
+	require.True(t, PeerSupports(host, FeatureCrcEncoded))
+	require.False(t, PeerSupports(host, "feature_not_exist"))
+
+	// handshake by any path
+	peer, err := Handshake(context.Background(), cli, server.URL)
+	require.NoError(t, err)
+	require.True(t, peer.Supports("feature_handshake"))
+}
+
+func TestHandshakeLegacyPeer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	host := mustHost(t, server.URL)
+
+	_, ok := GetPeerInfo(host)
+	require.False(t, ok)
+
+	peer, err := Handshake(context.Background(), NewClient(&Config{}), server.URL)
+	require.NoError(t, err)
+	require.True(t, peer.Legacy)
+	require.Empty(t, peer.Version)
+	require.False(t, PeerSupports(host, FeatureCrcEncoded))
+}
+
+func TestHandshakePeerSupportsWith(t *testing.T) {
+	RegisterFeatures("feature_supports_with")
+	server := httptest.NewServer(New())
+	defer server.Close()
+	host := mustHost(t, server.URL)
+	cli := NewClient(&Config{})
+
+	_, ok := GetPeerInfo(server.URL)
+	require.False(t, ok)
+	supported, err := PeerSupportsWith(context.Background(), cli, server.URL, "feature_supports_with")
+	require.NoError(t, err)
+	require.True(t, supported)
+	require.True(t, PeerSupports(server.URL, "feature_supports_with"))
+	supported, err = PeerSupportsWith(context.Background(), cli, server.URL, "feature_not_exist")
+	require.NoError(t, err)
+	require.False(t, supported)
+
+	// the unchanged peer info is not rebuilt
+	peer, ok := GetPeerInfo(host)
+	require.True(t, ok)
+	again, err := Handshake(context.Background(), cli, server.URL)
+	require.NoError(t, err)
+	require.True(t, peer == again)
+
+	_, err = PeerSupportsWith(context.Background(), cli, "http://127.0.0.1:1", "feature_supports_with")
+	require.Error(t, err)
+}
+
+type noHandshakeClient struct {
+	Client
+}
+
+func (noHandshakeClient) Head(ctx context.Context, url string) (*http.Response, error) {
+	return http.Head(url)
+}
+
+func TestHandshakePeerSupportsWithoutHandshake(t *testing.T) {
+	RegisterFeatures("feature_without_handshake")
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	// the peer info is not recorded by the client without handshake
+	cli := noHandshakeClient{Client: NewClient(&Config{})}
+	peer, err := Handshake(context.Background(), cli, server.URL)
+	require.NoError(t, err)
+	require.Nil(t, peer)
+	supported, err := PeerSupportsWith(context.Background(), cli, server.URL, "feature_without_handshake")
+	require.NoError(t, err)
+	require.False(t, supported)
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u.Host
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rpc
+
+import (
+	"fmt"
+	"net"
+)
+
+// NetworkConfig selects the network of the outgoing connections, so that the traffic is steered
+// out of the interface and shaped by the fabric apart from the others of the host.
+type NetworkConfig struct {
+	// LocalAddr is the source ip of the connections, the interface owning it is chosen by the
+	// source policy routing of the host
+	LocalAddr string `json:"local_addr"`
+	// Interface binds the connections to the interface, linux only and requires CAP_NET_RAW
+	Interface string `json:"interface"`
+	// DSCP marks the packets of the connections with the traffic class, 0-63, 0 means unmarked
+	DSCP int `json:"dscp"`
+	// UnixSocket dials the unix socket whatever the host of the url is, such as the local admin
+	// socket of the services
+	UnixSocket string `json:"unix_socket"`
+}
+
+// Validate returns error if the network config is invalid.
+func (nc NetworkConfig) Validate() error {
+	if nc.LocalAddr != "" && net.ParseIP(nc.LocalAddr) == nil {
+		return fmt.Errorf("invalid local addr %s", nc.LocalAddr)
+	}
+	if nc.DSCP < 0 || nc.DSCP > 63 {
+		return fmt.Errorf("invalid dscp %d, should be in [0, 63]", nc.DSCP)
+	}
+	return nil
+}
+
+func (nc NetworkConfig) dialer(dialer *net.Dialer) *net.Dialer {
+	if nc.LocalAddr != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(nc.LocalAddr)}
+	}
+	if nc.Interface != "" || nc.DSCP > 0 {
+		dialer.Control = nc.control
+	}
+	return dialer
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package rpc
+
+import (
+	"strings"
+	"syscall"
+)
+
+func (nc NetworkConfig) control(network, address string, c syscall.RawConn) error {
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		if nc.Interface != "" {
+			if err = syscall.BindToDevice(int(fd), nc.Interface); err != nil {
+				return
+			}
+		}
+		if nc.DSCP > 0 {
+			// the dscp is the upper 6 bits of the tos or the traffic class
+			if strings.HasSuffix(network, "6") {
+				err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, nc.DSCP<<2)
+			} else {
+				err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, nc.DSCP<<2)
+			}
+		}
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkConfigDial(t *testing.T) {
+	remotes := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remotes <- r.RemoteAddr
+	}))
+	defer server.Close()
+
+	var tos int
+	nc := NetworkConfig{LocalAddr: "127.0.0.1", DSCP: 10}
+	dialer := nc.dialer(&net.Dialer{})
+	control := dialer.Control
+	dialer.Control = func(network, address string, c syscall.RawConn) error {
+		if err := control(network, address, c); err != nil {
+			return err
+		}
+		return c.Control(func(fd uintptr) {
+			tos, _ = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS)
+		})
+	}
+	cli := &http.Client{Transport: &http.Transport{DialContext: dialer.DialContext}}
+	resp, err := cli.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 10<<2, tos)
+	host, _, _ := net.SplitHostPort(<-remotes)
+	require.Equal(t, "127.0.0.1", host)
+}
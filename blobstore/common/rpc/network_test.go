@@ -0,0 +1,56 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkConfig(t *testing.T) {
+	require.NoError(t, NetworkConfig{}.Validate())
+	require.NoError(t, NetworkConfig{LocalAddr: "127.0.0.1", DSCP: 63}.Validate())
+	require.Error(t, NetworkConfig{LocalAddr: "localhost"}.Validate())
+	require.Error(t, NetworkConfig{DSCP: 64}.Validate())
+	require.Error(t, NetworkConfig{DSCP: -1}.Validate())
+
+	// the network is kept by the default transport config
+	tc := TransportConfig{Network: NetworkConfig{DSCP: 10}}.Default()
+	require.Equal(t, NetworkConfig{DSCP: 10}, tc.Network)
+	require.Equal(t, 10, tc.MaxConnsPerHost)
+}
+
+func TestNetworkUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})}
+	go server.Serve(ln)
+	defer server.Close()
+
+	// the host of the url is ignored
+	cli := NewClient(&Config{Tc: TransportConfig{Network: NetworkConfig{UnixSocket: socket}}})
+	resp, err := cli.Get(context.Background(), "http://any.host:1234/admin")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -74,7 +74,7 @@ func New() *Router {
 	r := &Router{
 		Router:          httprouter.New(),
 		hasMiddleware:   false,
-		headMiddlewares: []ProgressHandler{&crcDecoder{}},
+		headMiddlewares: []ProgressHandler{&handshaker{}, &crcDecoder{}},
 	}
 	r.headHandler = buildHTTPHandler(r.Router.ServeHTTP, r.headMiddlewares...)
 	return r
@@ -254,6 +254,7 @@ func (c *client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	if req.Header.Get(HeaderUA) == "" {
 		req.Header.Set(HeaderUA, UserAgent)
 	}
+	setHandshakeHeader(req)
 	span := trace.SpanFromContextSafe(ctx)
 	err := trace.InjectWithHTTPHeader(ctx, req)
 	if err != nil {
@@ -263,6 +264,7 @@ func (c *client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	if err != nil {
 		return resp, err
 	}
+	recordPeerInfo(req, resp)
 
 	header := resp.Header
 	traceLog := header[HeaderTraceLog]
@@ -55,6 +55,8 @@ type BalanceMgr struct {
 
 	clusterTopology IClusterTopology
 	clusterMgrCli   client.ClusterMgrAPI
+	// the disks migrating by these managers are not balanced
+	conflictMgrs []diskMigratingChecker
 
 	cfg *BalanceMgrConfig
 }
@@ -73,6 +75,11 @@ func NewBalanceMgr(clusterMgrCli client.ClusterMgrAPI, volumeUpdater client.IVol
 	return mgr
 }
 
+// SetConflictMgrs sets the managers whose migrating disks are not balanced
+func (mgr *BalanceMgr) SetConflictMgrs(conflictMgrs ...diskMigratingChecker) {
+	mgr.conflictMgrs = conflictMgrs
+}
+
 // Run run balance task manager
 func (mgr *BalanceMgr) Run() {
 	go mgr.collectTaskLoop()
@@ -159,6 +166,9 @@ func (mgr *BalanceMgr) selectDisks(maxFreeChunkCnt, minFreeChunkCnt int64) []*cl
 		if ok := mgr.IMigrator.IsMigratingDisk(disk.DiskID); ok {
 			continue
 		}
+		if isMigratingByOthers(mgr.conflictMgrs, disk.DiskID) {
+			continue
+		}
 		if disk.FreeChunkCnt < minFreeChunkCnt {
 			selected = append(selected, disk)
 		}
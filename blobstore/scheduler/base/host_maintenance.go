@@ -0,0 +1,77 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+// HostMaintenance hosts in maintenance window such as upgrade, the migrate tasks
+// on them are held until the window ends
+type HostMaintenance struct {
+	hosts map[string]int64 // host -> end time of the window in unix seconds
+	mu    sync.RWMutex
+}
+
+// Update replaces all hosts in maintenance
+func (m *HostMaintenance) Update(hosts map[string]int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hosts = hosts
+}
+
+// InMaintenance returns true if the host is in maintenance window
+func (m *HostMaintenance) InMaintenance(host string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	end, ok := m.hosts[host]
+	return ok && end > time.Now().Unix()
+}
+
+// TaskInMaintenance returns true if the source or destination of the task is in maintenance window
+func (m *HostMaintenance) TaskInMaintenance(task *proto.MigrateTask) bool {
+	m.mu.RLock()
+	empty := len(m.hosts) == 0
+	m.mu.RUnlock()
+	if empty {
+		return false
+	}
+
+	return m.SourceInMaintenance(task) || (task.Destination.Host != "" && m.InMaintenance(task.Destination.Host))
+}
+
+// SourceInMaintenance returns true if the source unit of the task is in maintenance window
+func (m *HostMaintenance) SourceInMaintenance(task *proto.MigrateTask) bool {
+	idx := int(task.SourceVuid.Index())
+	return idx < len(task.Sources) && m.InMaintenance(task.Sources[idx].Host)
+}
+
+var hostMaintenance *HostMaintenance
+
+// NewHostMaintenanceOnce singleton mode:make sure only one instance in global
+var NewHostMaintenanceOnce sync.Once
+
+// HostMaintenanceInst returns the hosts in maintenance shared by all migrate managers
+func HostMaintenanceInst() *HostMaintenance {
+	NewHostMaintenanceOnce.Do(func() {
+		hostMaintenance = &HostMaintenance{
+			hosts: make(map[string]int64),
+		}
+	})
+	return hostMaintenance
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func TestHostMaintenance(t *testing.T) {
+	m := &HostMaintenance{hosts: make(map[string]int64)}
+	task := &proto.MigrateTask{
+		SourceVuid: proto.EncodeVuid(proto.EncodeVuidPrefix(1, 1), 1),
+		Sources: []proto.VunitLocation{
+			{Host: "http://127.0.0.1:8889"},
+			{Host: "http://127.0.0.2:8889"},
+		},
+		Destination: proto.VunitLocation{Host: "http://127.0.0.3:8889"},
+	}
+	require.False(t, m.TaskInMaintenance(task))
+
+	now := time.Now().Unix()
+	m.Update(map[string]int64{"http://127.0.0.1:8889": now + 60, "http://127.0.0.4:8889": now - 1})
+	require.True(t, m.InMaintenance("http://127.0.0.1:8889"))
+	require.False(t, m.InMaintenance("http://127.0.0.4:8889"))
+	// only the source unit and the destination are concerned
+	require.False(t, m.TaskInMaintenance(task))
+
+	m.Update(map[string]int64{"http://127.0.0.2:8889": now + 60})
+	require.True(t, m.TaskInMaintenance(task))
+	m.Update(map[string]int64{"http://127.0.0.3:8889": now + 60})
+	require.True(t, m.TaskInMaintenance(task))
+	m.Update(nil)
+	require.False(t, m.TaskInMaintenance(task))
+}
@@ -73,6 +73,9 @@ type msgEx struct {
 	state    int
 	deadline time.Time
 	msg      interface{}
+
+	reported bool // reported alive by worker since last zombie check
+	missed   int  // continuous zombie check cycles not reported alive
 }
 
 // Push push message to queue id is uniquely identifies。
@@ -97,28 +100,41 @@ func (q *Queue) Push(id string, msg interface{}) error {
 
 // Pop  fetch a msg from queue。
 func (q *Queue) Pop() (string, interface{}, bool) {
+	return q.PopAccepted(nil)
+}
+
+// PopAccepted fetch a msg accepted by the func from queue, the msgs not accepted are left in queue
+func (q *Queue) PopAccepted(accept func(msg interface{}) bool) (string, interface{}, bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	now := time.Now()
 	for ele := q.doing.Front(); ele != nil; ele = ele.Next() {
 		m := ele.Value.(*msgEx)
-		if m.deadline.Before(now) {
+		if m.deadline.Before(now) && (accept == nil || accept(m.msg)) {
 			m.deadline = now.Add(q.msgTimeout)
+			m.reported, m.missed = true, 0
 			return m.id, m.msg, true
 		}
 	}
 
 	// no timeout msg in doing ,fetch from todo
-	if q.todo.Len() == 0 {
+	var elem *list.Element
+	for ele := q.todo.Front(); ele != nil; ele = ele.Next() {
+		if accept == nil || accept(ele.Value.(*msgEx).msg) {
+			elem = ele
+			break
+		}
+	}
+	if elem == nil {
 		return "", nil, false
 	}
-	elem := q.todo.Front()
 	q.todo.Remove(elem)
 
 	m := elem.Value.(*msgEx)
 	m.state = msgStateDoing
 	m.deadline = now.Add(q.msgTimeout)
+	m.reported, m.missed = true, 0
 
 	elem = q.doing.PushFront(m)
 	q.msgs[m.id] = elem
@@ -162,6 +178,50 @@ func (q *Queue) Requeue(id string, delay time.Duration) error {
 	return nil
 }
 
+// Renewal extends the lease of msg in doing queue and marks it reported alive.
+func (q *Queue) Renewal(id string, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	elem, ok := q.msgs[id]
+	if !ok {
+		return ErrNoSuchMessageID
+	}
+	m := elem.Value.(*msgEx)
+	if m.state == msgStateTodo {
+		return nil
+	}
+	m.deadline = time.Now().Add(lease)
+	m.reported, m.missed = true, 0
+	return nil
+}
+
+// ReclaimZombies expires the msgs in doing queue which are still leased but not reported
+// alive for maxMissed continuous calls, they are reissued by the next Pop.
+func (q *Queue) ReclaimZombies(maxMissed int) (ids []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for ele := q.doing.Front(); ele != nil; ele = ele.Next() {
+		m := ele.Value.(*msgEx)
+		if m.deadline.Before(now) {
+			// lease expired, waiting to be reissued
+			continue
+		}
+		if m.reported {
+			m.reported, m.missed = false, 0
+			continue
+		}
+		if m.missed++; m.missed >= maxMissed {
+			m.deadline = now
+			m.missed = 0
+			ids = append(ids, m.id)
+		}
+	}
+	return ids
+}
+
 // Remove remove message by id
 func (q *Queue) Remove(id string) error {
 	q.mu.Lock()
@@ -310,6 +370,11 @@ func (q *WorkerTaskQueue) AddPreparedTask(idc, taskID string, wtask WorkerTask)
 
 // Acquire acquire task by idc
 func (q *WorkerTaskQueue) Acquire(idc string) (taskID string, wtask WorkerTask, exist bool) {
+	return q.AcquireAccepted(idc, nil)
+}
+
+// AcquireAccepted acquire task accepted by the func by idc
+func (q *WorkerTaskQueue) AcquireAccepted(idc string, accept func(task WorkerTask) bool) (taskID string, wtask WorkerTask, exist bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
@@ -318,7 +383,11 @@ func (q *WorkerTaskQueue) Acquire(idc string) (taskID string, wtask WorkerTask,
 		return "", nil, false
 	}
 
-	taskID, task, exist := idcQueue.Pop()
+	var acceptMsg func(msg interface{}) bool
+	if accept != nil {
+		acceptMsg = func(msg interface{}) bool { return accept(msg.(WorkerTask)) }
+	}
+	taskID, task, exist := idcQueue.PopAccepted(acceptMsg)
 	if exist {
 		return taskID, task.(WorkerTask), exist
 	}
@@ -378,7 +447,33 @@ func (q *WorkerTaskQueue) Renewal(idc, taskID string) error {
 	if !ok {
 		return errNoSuchIDCQueue
 	}
-	return idcQueue.Requeue(taskID, q.leaseExpiredS)
+	return idcQueue.Renewal(taskID, q.leaseExpiredS)
+}
+
+// Release expires the lease of task immediately without waiting for the lease timeout
+func (q *WorkerTaskQueue) Release(idc, taskID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	idcQueue, ok := q.idcQueues[idc]
+	if !ok {
+		return errNoSuchIDCQueue
+	}
+	return idcQueue.Requeue(taskID, 0)
+}
+
+// ReclaimZombies reclaims the tasks leased but not reported alive by any worker
+// for maxMissed continuous cycles, returns the reclaimed task ids by idc.
+func (q *WorkerTaskQueue) ReclaimZombies(maxMissed int) map[string][]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	zombies := make(map[string][]string)
+	for idc, idcQueue := range q.idcQueues {
+		if ids := idcQueue.ReclaimZombies(maxMissed); len(ids) > 0 {
+			zombies[idc] = ids
+		}
+	}
+	return zombies
 }
 
 // Complete complete task
@@ -260,3 +260,68 @@ func TestWorkerTaskQueue(t *testing.T) {
 	_, err = wq.Complete(idc, taskID2, vunits([]proto.Vuid{4, 5, 6}), vunit(4))
 	require.EqualError(t, err, ErrUnmatchedVuids.Error())
 }
+
+func TestWorkerTaskQueueReclaimZombies(t *testing.T) {
+	idc := "z0"
+	taskID1, taskID2 := "task_id1", "task_id2"
+	task1 := mockWorkerTask{src: vunits([]proto.Vuid{1, 2, 3}), dst: vunit(4)}
+	task2 := mockWorkerTask{src: vunits([]proto.Vuid{5, 6, 7}), dst: vunit(8)}
+
+	wq := newTestWorkerTaskQueue(100*time.Millisecond, time.Hour)
+	wq.AddPreparedTask(idc, taskID1, &task1)
+	wq.AddPreparedTask(idc, taskID2, &task2)
+	wq.AddPreparedTask("z1", "task_id3", &task2)
+
+	_, _, exist := wq.Acquire(idc)
+	require.True(t, exist)
+	_, _, exist = wq.Acquire(idc)
+	require.True(t, exist)
+	_, _, exist = wq.Acquire(idc)
+	require.False(t, exist)
+
+	// new leased tasks are treated as alive in the first cycle
+	require.Empty(t, wq.ReclaimZombies(2))
+
+	// task2 is reported alive, task1 is not
+	require.Empty(t, wq.ReclaimZombies(2))
+	require.NoError(t, wq.Renewal(idc, taskID2))
+	zombies := wq.ReclaimZombies(2)
+	require.Equal(t, map[string][]string{idc: {taskID1}}, zombies)
+
+	// zombie task is reissued and the todo task is not touched
+	id, _, exist := wq.Acquire(idc)
+	require.True(t, exist)
+	require.Equal(t, taskID1, id)
+	_, _, exist = wq.Acquire(idc)
+	require.False(t, exist)
+	todo, doing := wq.StatsTasks()
+	require.Equal(t, 1, todo)
+	require.Equal(t, 2, doing)
+}
+
+func TestWorkerTaskQueueAcquireAccepted(t *testing.T) {
+	idc := "z0"
+	task1 := mockWorkerTask{src: vunits([]proto.Vuid{1, 2, 3}), dst: vunit(4)}
+	task2 := mockWorkerTask{src: vunits([]proto.Vuid{1, 2, 3}), dst: vunit(5)}
+	wq := newTestWorkerTaskQueue(100*time.Millisecond, time.Minute)
+	wq.AddPreparedTask(idc, "task_id1", &task1)
+	wq.AddPreparedTask(idc, "task_id2", &task2)
+
+	skipDst4 := func(task WorkerTask) bool { return task.GetDestination().Vuid != 4 }
+	id, _, exist := wq.AcquireAccepted(idc, skipDst4)
+	require.True(t, exist)
+	require.Equal(t, "task_id2", id)
+	_, _, exist = wq.AcquireAccepted(idc, skipDst4)
+	require.False(t, exist)
+
+	// the released task is acquired again without waiting for the lease
+	require.NoError(t, wq.Release(idc, "task_id2"))
+	id, _, exist = wq.AcquireAccepted(idc, skipDst4)
+	require.True(t, exist)
+	require.Equal(t, "task_id2", id)
+	require.Error(t, wq.Release("z1", "task_id2"))
+
+	id, _, exist = wq.Acquire(idc)
+	require.True(t, exist)
+	require.Equal(t, "task_id1", id)
+}
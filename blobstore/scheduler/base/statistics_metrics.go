@@ -20,10 +20,12 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/common/counter"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	api "github.com/cubefs/cubefs/blobstore/scheduler/client"
@@ -107,6 +109,8 @@ type TaskStatsMgr struct {
 	reclaimCounter prometheus.Counter
 	cancelCounter  prometheus.Counter
 
+	totals scheduler.TasksTotals // updated atomically
+
 	taskCntStats TaskCntStats
 }
 
@@ -255,16 +259,36 @@ func (statsMgr *TaskStatsMgr) ReportWorkerTaskStats(taskID string, s proto.TaskS
 
 	statsMgr.dataSizeProCounter.Add(float64(increaseDataSize))
 	statsMgr.shardCntProCounter.Add(float64(increaseShardCnt))
+	atomic.AddUint64(&statsMgr.totals.DataAmountByte, uint64(increaseDataSize))
+	atomic.AddUint64(&statsMgr.totals.ShardCnt, uint64(increaseShardCnt))
+}
+
+// FinishTask finish task
+func (statsMgr *TaskStatsMgr) FinishTask() {
+	atomic.AddUint64(&statsMgr.totals.FinishedCnt, 1)
 }
 
 // ReclaimTask reclaim task
 func (statsMgr *TaskStatsMgr) ReclaimTask() {
 	statsMgr.reclaimCounter.Inc()
+	atomic.AddUint64(&statsMgr.totals.ReclaimedCnt, 1)
 }
 
 // CancelTask cancel task
 func (statsMgr *TaskStatsMgr) CancelTask() {
 	statsMgr.cancelCounter.Inc()
+	atomic.AddUint64(&statsMgr.totals.CanceledCnt, 1)
+}
+
+// Totals returns the cumulative stats of the tasks
+func (statsMgr *TaskStatsMgr) Totals() scheduler.TasksTotals {
+	return scheduler.TasksTotals{
+		FinishedCnt:    atomic.LoadUint64(&statsMgr.totals.FinishedCnt),
+		ReclaimedCnt:   atomic.LoadUint64(&statsMgr.totals.ReclaimedCnt),
+		CanceledCnt:    atomic.LoadUint64(&statsMgr.totals.CanceledCnt),
+		ShardCnt:       atomic.LoadUint64(&statsMgr.totals.ShardCnt),
+		DataAmountByte: atomic.LoadUint64(&statsMgr.totals.DataAmountByte),
+	}
 }
 
 // QueryTaskDetail find task detail info
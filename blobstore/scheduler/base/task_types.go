@@ -27,6 +27,7 @@ const (
 	defaultFinishQueueRetryDelayS  = 10
 	defaultCollectIntervalS        = 5
 	defaultCheckTaskIntervalS      = 5
+	defaultZombieTaskCheckCycles   = 3
 
 	defaultDiskConcurrency = 1
 	defaultWorkQueueSize   = 20
@@ -53,6 +54,8 @@ type TaskCommonConfig struct {
 	CollectTaskIntervalS    int `json:"collect_task_interval_s"`
 	CheckTaskIntervalS      int `json:"check_task_interval_s"`
 	DiskConcurrency         int `json:"disk_concurrency"`
+	// the task leased but not reported alive by workers for such renewal cycles is reclaimed
+	ZombieTaskCheckCycles int `json:"zombie_task_check_cycles"`
 }
 
 // CheckAndFix check and fix task common config
@@ -64,4 +67,5 @@ func (conf *TaskCommonConfig) CheckAndFix() {
 	defaulter.LessOrEqual(&conf.CollectTaskIntervalS, defaultCollectIntervalS)
 	defaulter.LessOrEqual(&conf.CheckTaskIntervalS, defaultCheckTaskIntervalS)
 	defaulter.LessOrEqual(&conf.DiskConcurrency, defaultDiskConcurrency)
+	defaulter.LessOrEqual(&conf.ZombieTaskCheckCycles, defaultZombieTaskCheckCycles)
 }
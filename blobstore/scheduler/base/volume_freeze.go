@@ -0,0 +1,64 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+// VolumeFreeze volumes frozen for investigation, the repair and migrate tasks of them are
+// held and the shards of them are not repaired until the freeze ends
+type VolumeFreeze struct {
+	vids map[proto.Vid]int64 // vid -> end time of the freeze in unix seconds
+	mu   sync.RWMutex
+}
+
+// Update replaces all frozen volumes
+func (f *VolumeFreeze) Update(vids map[proto.Vid]int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vids = vids
+}
+
+// Frozen returns true if the volume is frozen
+func (f *VolumeFreeze) Frozen(vid proto.Vid) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	end, ok := f.vids[vid]
+	return ok && end > time.Now().Unix()
+}
+
+// TaskFrozen returns true if the volume of the task is frozen
+func (f *VolumeFreeze) TaskFrozen(task *proto.MigrateTask) bool {
+	return f.Frozen(task.Vid())
+}
+
+var volumeFreeze *VolumeFreeze
+
+// NewVolumeFreezeOnce singleton mode:make sure only one instance in global
+var NewVolumeFreezeOnce sync.Once
+
+// VolumeFreezeInst returns the frozen volumes shared by all task managers
+func VolumeFreezeInst() *VolumeFreeze {
+	NewVolumeFreezeOnce.Do(func() {
+		volumeFreeze = &VolumeFreeze{
+			vids: make(map[proto.Vid]int64),
+		}
+	})
+	return volumeFreeze
+}
@@ -0,0 +1,40 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func TestVolumeFreeze(t *testing.T) {
+	f := &VolumeFreeze{vids: make(map[proto.Vid]int64)}
+	task := &proto.MigrateTask{SourceVuid: proto.EncodeVuid(proto.EncodeVuidPrefix(1, 1), 1)}
+	require.False(t, f.TaskFrozen(task))
+
+	now := time.Now().Unix()
+	f.Update(map[proto.Vid]int64{1: now + 60, 2: now - 1})
+	require.True(t, f.Frozen(1))
+	require.False(t, f.Frozen(2))
+	require.False(t, f.Frozen(3))
+	require.True(t, f.TaskFrozen(task))
+
+	f.Update(nil)
+	require.False(t, f.TaskFrozen(task))
+}
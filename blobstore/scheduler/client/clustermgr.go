@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,7 @@ type ClusterMgrVolumeAPI interface {
 	UnlockVolume(ctx context.Context, Vid proto.Vid) (err error)
 	UpdateVolume(ctx context.Context, newVuid, oldVuid proto.Vuid, newDiskID proto.DiskID) (err error)
 	AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (ret *AllocVunitInfo, err error)
+	AllocVolumeUnitOnHost(ctx context.Context, vuid proto.Vuid, host string) (ret *AllocVunitInfo, err error)
 	ReleaseVolumeUnit(ctx context.Context, vuid proto.Vuid, diskID proto.DiskID) (err error)
 	ListDiskVolumeUnits(ctx context.Context, diskID proto.DiskID) (ret []*VunitInfoSimple, err error)
 	ListVolume(ctx context.Context, marker proto.Vid, count int) (volInfo []*VolumeInfoSimple, retVid proto.Vid, err error)
@@ -56,7 +58,10 @@ type ClusterMgrDiskAPI interface {
 	SetDiskRepairing(ctx context.Context, diskID proto.DiskID) (err error)
 	SetDiskRepaired(ctx context.Context, diskID proto.DiskID) (err error)
 	SetDiskDropped(ctx context.Context, diskID proto.DiskID) (err error)
+	CancelDiskDrop(ctx context.Context, diskID proto.DiskID) (err error)
 	GetDiskInfo(ctx context.Context, diskID proto.DiskID) (ret *DiskInfoSimple, err error)
+	ListHostDisks(ctx context.Context, host string) (disks []*DiskInfoSimple, err error)
+	DropDisk(ctx context.Context, diskID proto.DiskID) (err error)
 }
 
 type ClusterMgrServiceAPI interface {
@@ -76,6 +81,14 @@ type ClusterMgrTaskAPI interface {
 	DeleteMigratingDisk(ctx context.Context, taskType proto.TaskType, diskID proto.DiskID) (err error)
 	GetMigratingDisk(ctx context.Context, taskType proto.TaskType, diskID proto.DiskID) (meta *MigratingDiskMeta, err error)
 	ListMigratingDisks(ctx context.Context, taskType proto.TaskType) (disks []*MigratingDiskMeta, err error)
+	AddDrainingHost(ctx context.Context, value *DrainingHostMeta) (err error)
+	ListDrainingHosts(ctx context.Context) (hosts []*DrainingHostMeta, err error)
+	SetMaintenanceHost(ctx context.Context, value *MaintenanceHostMeta) (err error)
+	DeleteMaintenanceHost(ctx context.Context, host string) (err error)
+	ListMaintenanceHosts(ctx context.Context) (hosts []*MaintenanceHostMeta, err error)
+	SetFrozenVolume(ctx context.Context, value *FrozenVolumeMeta) (err error)
+	DeleteFrozenVolume(ctx context.Context, vid proto.Vid) (err error)
+	ListFrozenVolumes(ctx context.Context) (vols []*FrozenVolumeMeta, err error)
 	GetVolumeInspectCheckPoint(ctx context.Context) (ck *proto.VolumeInspectCheckPoint, err error)
 	SetVolumeInspectCheckPoint(ctx context.Context, startVid proto.Vid) (err error)
 	GetConsumeOffset(taskType proto.TaskType, topic string, partition int32) (offset int64, err error)
@@ -109,6 +122,27 @@ type ClusterMgrAPI interface {
 // 		migrating-disk_repair-1
 //		migrating-disk_drop-2
 //
+//	draining host key
+//  - - - - - - - - - - - - - - - - - - -
+//  | _drainingHostPrefix | host |
+//  - - - - - - - - - - - - - - - - - - -
+//  for example:
+//		draining_host-http://127.0.0.1:8889
+//
+//	maintenance host key
+//  - - - - - - - - - - - - - - - - - - - - -
+//  | _maintenanceHostPrefix | host |
+//  - - - - - - - - - - - - - - - - - - - - -
+//  for example:
+//		maintenance_host-http://127.0.0.1:8889
+//
+//	frozen volume key
+//  - - - - - - - - - - - - - - - - -
+//  | _frozenVolumePrefix | vid |
+//  - - - - - - - - - - - - - - - - -
+//  for example:
+//		frozen_volume-100
+//
 // volume inspect checkpoint key
 //  - - - - - - - - - - - - - -
 //  | {task_type} | _checkPoint |
@@ -125,10 +159,13 @@ type ClusterMgrAPI interface {
 //		shard_repair-consume_offset-shard_repair-2
 
 const (
-	_delimiter           = "-"
-	_migratingDiskPrefix = "migrating"
-	_checkPoint          = "checkpoint"
-	_consumeOffset       = "consume_offset"
+	_delimiter             = "-"
+	_migratingDiskPrefix   = "migrating"
+	_drainingHostPrefix    = "draining_host"
+	_maintenanceHostPrefix = "maintenance_host"
+	_frozenVolumePrefix    = "frozen_volume"
+	_checkPoint            = "checkpoint"
+	_consumeOffset         = "consume_offset"
 )
 
 var (
@@ -142,6 +179,11 @@ type MigratingDiskMeta struct {
 	Disk     *DiskInfoSimple `json:"disk"`
 	TaskType proto.TaskType  `json:"task_type"`
 	Ctime    string          `json:"ctime"`
+
+	// disk drop is cancelled, the pending tasks are stopped and the running tasks are kept
+	CancelTime    int64        `json:"cancel_time,omitempty"`
+	StoppedVuids  []proto.Vuid `json:"stopped_vuids,omitempty"`
+	InFlightVuids []proto.Vuid `json:"in_flight_vuids,omitempty"`
 }
 
 func (d *MigratingDiskMeta) ID() string {
@@ -156,6 +198,89 @@ func genMigratingDiskPrefix(taskType proto.TaskType) string {
 	return fmt.Sprintf("%s%s%s%s", _migratingDiskPrefix, _delimiter, taskType, _delimiter)
 }
 
+// DrainingHostMeta meta of the host draining, the disks of the host are dropped in sequence
+type DrainingHostMeta struct {
+	Host  string            `json:"host"`
+	Disks []*DiskInfoSimple `json:"disks"`
+	// disks have been added into the dropping list of clustermgr
+	DropDisks  []proto.DiskID `json:"drop_disks,omitempty"`
+	StartTime  int64          `json:"start_time"`
+	FinishTime int64          `json:"finish_time,omitempty"`
+}
+
+func (h *DrainingHostMeta) ID() string {
+	return genDrainingHostID(h.Host)
+}
+
+// IsDropDisk returns true if the disk has been added into the dropping list
+func (h *DrainingHostMeta) IsDropDisk(diskID proto.DiskID) bool {
+	for _, id := range h.DropDisks {
+		if id == diskID {
+			return true
+		}
+	}
+	return false
+}
+
+func genDrainingHostID(host string) string {
+	return genDrainingHostPrefix() + host
+}
+
+func genDrainingHostPrefix() string {
+	return _drainingHostPrefix + _delimiter
+}
+
+// MaintenanceHostMeta meta of the host in maintenance window such as upgrade
+type MaintenanceHostMeta struct {
+	Host      string `json:"host"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+}
+
+func (h *MaintenanceHostMeta) ID() string {
+	return genMaintenanceHostID(h.Host)
+}
+
+// Expired returns true if the maintenance window has ended
+func (h *MaintenanceHostMeta) Expired(now int64) bool {
+	return h.EndTime <= now
+}
+
+func genMaintenanceHostID(host string) string {
+	return genMaintenanceHostPrefix() + host
+}
+
+func genMaintenanceHostPrefix() string {
+	return _maintenanceHostPrefix + _delimiter
+}
+
+// FrozenVolumeMeta meta of the volume frozen for investigation, Operator and Reason
+// are of the last one who sets the freeze
+type FrozenVolumeMeta struct {
+	Vid       proto.Vid `json:"vid"`
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason"`
+	StartTime int64     `json:"start_time"`
+	EndTime   int64     `json:"end_time"`
+}
+
+func (v *FrozenVolumeMeta) ID() string {
+	return genFrozenVolumeID(v.Vid)
+}
+
+// Expired returns true if the freeze has ended
+func (v *FrozenVolumeMeta) Expired(now int64) bool {
+	return v.EndTime <= now
+}
+
+func genFrozenVolumeID(vid proto.Vid) string {
+	return genFrozenVolumePrefix() + vid.ToString()
+}
+
+func genFrozenVolumePrefix() string {
+	return _frozenVolumePrefix + _delimiter
+}
+
 // GenMigrateTaskID return uniq task id
 func GenMigrateTaskID(taskType proto.TaskType, diskID proto.DiskID, volumeID proto.Vid) string {
 	return fmt.Sprintf("%s%d%s%s", GenMigrateTaskPrefixByDiskID(taskType, diskID), volumeID, _delimiter, xid.New().String())
@@ -173,6 +298,26 @@ func ValidMigrateTask(taskType proto.TaskType, taskID string) bool {
 	return strings.HasPrefix(taskID, GenMigrateTaskPrefix(taskType))
 }
 
+// ParseMigrateTaskID returns the source disk and volume of the task id
+func ParseMigrateTaskID(taskType proto.TaskType, taskID string) (diskID proto.DiskID, vid proto.Vid, err error) {
+	if !ValidMigrateTask(taskType, taskID) {
+		return 0, 0, errcode.ErrIllegalArguments
+	}
+	fields := strings.Split(strings.TrimPrefix(taskID, GenMigrateTaskPrefix(taskType)), _delimiter)
+	if len(fields) != 3 {
+		return 0, 0, errcode.ErrIllegalArguments
+	}
+	disk, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return 0, 0, errcode.ErrIllegalArguments
+	}
+	volume, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return 0, 0, errcode.ErrIllegalArguments
+	}
+	return proto.DiskID(disk), proto.Vid(volume), nil
+}
+
 type ConsumeOffset struct {
 	Topic     string `json:"topic"`
 	Partition int32  `json:"partition"`
@@ -370,6 +515,9 @@ type IClusterManager interface {
 	SetDisk(ctx context.Context, id proto.DiskID, status proto.DiskStatus) (err error)
 	DiskInfo(ctx context.Context, id proto.DiskID) (ret *blobnode.DiskInfo, err error)
 	DroppedDisk(ctx context.Context, id proto.DiskID) (err error)
+	DropDisk(ctx context.Context, id proto.DiskID) (err error)
+	SetReadonlyDisk(ctx context.Context, id proto.DiskID, readonly bool) (err error)
+	CancelDropDisk(ctx context.Context, id proto.DiskID) (err error)
 	RegisterService(ctx context.Context, node cmapi.ServiceNode, tickInterval, heartbeatTicks, expiresTicks uint32) (err error)
 	GetService(ctx context.Context, args cmapi.GetServiceArgs) (info cmapi.ServiceInfo, err error)
 	GetKV(ctx context.Context, key string) (ret cmapi.GetKvRet, err error)
@@ -463,14 +611,19 @@ func (c *clustermgrClient) UpdateVolume(ctx context.Context, newVuid, oldVuid pr
 
 // AllocVolumeUnit alloc volume unit
 func (c *clustermgrClient) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*AllocVunitInfo, error) {
+	return c.AllocVolumeUnitOnHost(ctx, vuid, "")
+}
+
+// AllocVolumeUnitOnHost alloc volume unit on the disks of the host, alloc on any host if host is empty
+func (c *clustermgrClient) AllocVolumeUnitOnHost(ctx context.Context, vuid proto.Vuid, host string) (*AllocVunitInfo, error) {
 	c.rwLock.Lock()
 	defer c.rwLock.Unlock()
 
 	span := trace.SpanFromContextSafe(ctx)
 
-	span.Debugf("alloc volume unit: args vuid[%d]", vuid)
+	span.Debugf("alloc volume unit: args vuid[%d], host[%s]", vuid, host)
 	ret := &AllocVunitInfo{}
-	info, err := c.client.AllocVolumeUnit(ctx, &cmapi.AllocVolumeUnitArgs{Vuid: vuid})
+	info, err := c.client.AllocVolumeUnit(ctx, &cmapi.AllocVolumeUnitArgs{Vuid: vuid, Host: host})
 	if err != nil {
 		span.Errorf("alloc volume unit failed: err[%+v]", err)
 		return nil, err
@@ -589,6 +742,34 @@ func (c *clustermgrClient) listAllDisks(ctx context.Context, status proto.DiskSt
 	return
 }
 
+// ListHostDisks list all disks of the host
+func (c *clustermgrClient) ListHostDisks(ctx context.Context, host string) (disks []*DiskInfoSimple, err error) {
+	c.rwLock.RLock()
+	defer c.rwLock.RUnlock()
+
+	span := trace.SpanFromContextSafe(ctx)
+	marker := defaultListDiskMarker
+	for {
+		args := &cmapi.ListOptionArgs{
+			Host:   host,
+			Count:  defaultListDiskNum,
+			Marker: marker,
+		}
+		selectDisks, selectMarker, err := c.listDisk(ctx, args)
+		if err != nil {
+			span.Errorf("list host disks failed: host[%s], err[%+v]", host, err)
+			return nil, err
+		}
+
+		marker = selectMarker
+		disks = append(disks, selectDisks...)
+		if marker == defaultListDiskMarker {
+			break
+		}
+	}
+	return
+}
+
 func (c *clustermgrClient) listDisks(ctx context.Context, status proto.DiskStatus, count int) (disks []*DiskInfoSimple, err error) {
 	span := trace.SpanFromContextSafe(ctx)
 
@@ -713,6 +894,42 @@ func (c *clustermgrClient) SetDiskDropped(ctx context.Context, diskID proto.Disk
 	return
 }
 
+// DropDisk adds disk into the dropping list of clustermgr, the disk is set readonly firstly
+func (c *clustermgrClient) DropDisk(ctx context.Context, diskID proto.DiskID) (err error) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+	span := trace.SpanFromContextSafe(ctx)
+
+	info, err := c.client.DiskInfo(ctx, diskID)
+	if err != nil {
+		span.Errorf("drop disk, get disk info failed: disk_id[%d], err[%+v]", diskID, err)
+		return err
+	}
+	if !info.Readonly {
+		if err = c.client.SetReadonlyDisk(ctx, diskID, true); err != nil {
+			span.Errorf("drop disk, set disk readonly failed: disk_id[%d], err[%+v]", diskID, err)
+			return err
+		}
+	}
+
+	span.Debugf("drop disk: args disk_id[%d]", diskID)
+	err = c.client.DropDisk(ctx, diskID)
+	span.Debugf("drop disk ret: err[%+v]", err)
+	return
+}
+
+// CancelDiskDrop cancel disk drop and set the disk writable
+func (c *clustermgrClient) CancelDiskDrop(ctx context.Context, diskID proto.DiskID) (err error) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+	span := trace.SpanFromContextSafe(ctx)
+
+	span.Debugf("cancel disk drop: args disk_id[%d]", diskID)
+	err = c.client.CancelDropDisk(ctx, diskID)
+	span.Debugf("cancel disk drop ret: err[%+v]", err)
+	return
+}
+
 func (c *clustermgrClient) setDiskStatus(ctx context.Context, diskID proto.DiskID, status proto.DiskStatus) (err error) {
 	return c.client.SetDisk(ctx, diskID, status)
 }
@@ -863,7 +1080,9 @@ func (c *clustermgrClient) listAllMigrateTasks(ctx context.Context, prefix strin
 
 // AddMigratingDisk adds migrating disk meta
 func (c *clustermgrClient) AddMigratingDisk(ctx context.Context, value *MigratingDiskMeta) (err error) {
-	value.Ctime = time.Now().String()
+	if value.Ctime == "" {
+		value.Ctime = time.Now().String()
+	}
 	return c.setTask(ctx, value.ID(), value)
 }
 
@@ -932,6 +1151,130 @@ func (c *clustermgrClient) ListMigratingDisks(ctx context.Context, taskType prot
 	return
 }
 
+// AddDrainingHost adds or updates draining host meta
+func (c *clustermgrClient) AddDrainingHost(ctx context.Context, value *DrainingHostMeta) (err error) {
+	return c.setTask(ctx, value.ID(), value)
+}
+
+// ListDrainingHosts returns all draining hosts, include the drained hosts
+func (c *clustermgrClient) ListDrainingHosts(ctx context.Context) (hosts []*DrainingHostMeta, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	marker := defaultListTaskMarker
+	for {
+		args := &cmapi.ListKvOpts{
+			Prefix: genDrainingHostPrefix(),
+			Count:  defaultListTaskNum,
+			Marker: marker,
+		}
+		ret, err := c.client.ListKV(ctx, args)
+		if err != nil {
+			span.Errorf("list draining hosts failed: err[%+v]", err)
+			return nil, err
+		}
+
+		for _, v := range ret.Kvs {
+			var host *DrainingHostMeta
+			if err = json.Unmarshal(v.Value, &host); err != nil {
+				span.Errorf("unmarshal draining host failed: err[%+v]", err)
+				return nil, err
+			}
+			hosts = append(hosts, host)
+		}
+		marker = ret.Marker
+		if marker == defaultListTaskMarker {
+			break
+		}
+	}
+	return
+}
+
+// SetMaintenanceHost adds or updates maintenance host meta
+func (c *clustermgrClient) SetMaintenanceHost(ctx context.Context, value *MaintenanceHostMeta) (err error) {
+	return c.setTask(ctx, value.ID(), value)
+}
+
+// DeleteMaintenanceHost deletes maintenance host meta
+func (c *clustermgrClient) DeleteMaintenanceHost(ctx context.Context, host string) (err error) {
+	return c.client.DeleteKV(ctx, genMaintenanceHostID(host))
+}
+
+// ListMaintenanceHosts returns all maintenance hosts, include the expired hosts
+func (c *clustermgrClient) ListMaintenanceHosts(ctx context.Context) (hosts []*MaintenanceHostMeta, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	marker := defaultListTaskMarker
+	for {
+		args := &cmapi.ListKvOpts{
+			Prefix: genMaintenanceHostPrefix(),
+			Count:  defaultListTaskNum,
+			Marker: marker,
+		}
+		ret, err := c.client.ListKV(ctx, args)
+		if err != nil {
+			span.Errorf("list maintenance hosts failed: err[%+v]", err)
+			return nil, err
+		}
+
+		for _, v := range ret.Kvs {
+			var host *MaintenanceHostMeta
+			if err = json.Unmarshal(v.Value, &host); err != nil {
+				span.Errorf("unmarshal maintenance host failed: err[%+v]", err)
+				return nil, err
+			}
+			hosts = append(hosts, host)
+		}
+		marker = ret.Marker
+		if marker == defaultListTaskMarker {
+			break
+		}
+	}
+	return
+}
+
+// SetFrozenVolume adds or updates frozen volume meta
+func (c *clustermgrClient) SetFrozenVolume(ctx context.Context, value *FrozenVolumeMeta) (err error) {
+	return c.setTask(ctx, value.ID(), value)
+}
+
+// DeleteFrozenVolume deletes frozen volume meta
+func (c *clustermgrClient) DeleteFrozenVolume(ctx context.Context, vid proto.Vid) (err error) {
+	return c.client.DeleteKV(ctx, genFrozenVolumeID(vid))
+}
+
+// ListFrozenVolumes returns all frozen volumes, include the expired volumes
+func (c *clustermgrClient) ListFrozenVolumes(ctx context.Context) (vols []*FrozenVolumeMeta, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	marker := defaultListTaskMarker
+	for {
+		args := &cmapi.ListKvOpts{
+			Prefix: genFrozenVolumePrefix(),
+			Count:  defaultListTaskNum,
+			Marker: marker,
+		}
+		ret, err := c.client.ListKV(ctx, args)
+		if err != nil {
+			span.Errorf("list frozen volumes failed: err[%+v]", err)
+			return nil, err
+		}
+
+		for _, v := range ret.Kvs {
+			var vol *FrozenVolumeMeta
+			if err = json.Unmarshal(v.Value, &vol); err != nil {
+				span.Errorf("unmarshal frozen volume failed: err[%+v]", err)
+				return nil, err
+			}
+			vols = append(vols, vol)
+		}
+		marker = ret.Marker
+		if marker == defaultListTaskMarker {
+			break
+		}
+	}
+	return
+}
+
 func (c *clustermgrClient) GetVolumeInspectCheckPoint(ctx context.Context) (ck *proto.VolumeInspectCheckPoint, err error) {
 	ret, err := c.client.GetKV(ctx, genVolumeInspectCheckpointKey())
 	if err != nil {
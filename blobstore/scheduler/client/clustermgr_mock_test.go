@@ -52,6 +52,20 @@ func (mr *MockClusterManagerMockRecorder) AllocVolumeUnit(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocVolumeUnit", reflect.TypeOf((*MockClusterManager)(nil).AllocVolumeUnit), arg0, arg1)
 }
 
+// CancelDropDisk mocks base method.
+func (m *MockClusterManager) CancelDropDisk(arg0 context.Context, arg1 proto.DiskID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDropDisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDropDisk indicates an expected call of CancelDropDisk.
+func (mr *MockClusterManagerMockRecorder) CancelDropDisk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDropDisk", reflect.TypeOf((*MockClusterManager)(nil).CancelDropDisk), arg0, arg1)
+}
+
 // DeleteKV mocks base method.
 func (m *MockClusterManager) DeleteKV(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -81,6 +95,20 @@ func (mr *MockClusterManagerMockRecorder) DiskInfo(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskInfo", reflect.TypeOf((*MockClusterManager)(nil).DiskInfo), arg0, arg1)
 }
 
+// DropDisk mocks base method.
+func (m *MockClusterManager) DropDisk(arg0 context.Context, arg1 proto.DiskID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropDisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropDisk indicates an expected call of DropDisk.
+func (mr *MockClusterManagerMockRecorder) DropDisk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropDisk", reflect.TypeOf((*MockClusterManager)(nil).DropDisk), arg0, arg1)
+}
+
 // DroppedDisk mocks base method.
 func (m *MockClusterManager) DroppedDisk(arg0 context.Context, arg1 proto.DiskID) error {
 	m.ctrl.T.Helper()
@@ -300,6 +328,20 @@ func (mr *MockClusterManagerMockRecorder) SetKV(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKV", reflect.TypeOf((*MockClusterManager)(nil).SetKV), arg0, arg1, arg2)
 }
 
+// SetReadonlyDisk mocks base method.
+func (m *MockClusterManager) SetReadonlyDisk(arg0 context.Context, arg1 proto.DiskID, arg2 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadonlyDisk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReadonlyDisk indicates an expected call of SetReadonlyDisk.
+func (mr *MockClusterManagerMockRecorder) SetReadonlyDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadonlyDisk", reflect.TypeOf((*MockClusterManager)(nil).SetReadonlyDisk), arg0, arg1, arg2)
+}
+
 // UnlockVolume mocks base method.
 func (m *MockClusterManager) UnlockVolume(arg0 context.Context, arg1 *clustermgr.UnlockVolumeArgs) error {
 	m.ctrl.T.Helper()
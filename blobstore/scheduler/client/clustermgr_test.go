@@ -280,6 +280,125 @@ func TestClustermgrClient(t *testing.T) {
 		err = cli.SetDiskDropped(ctx, proto.DiskID(1))
 		require.NoError(t, err)
 	}
+	{
+		// drop disk
+		cli.client.(*MockClusterManager).EXPECT().DiskInfo(any, any).Return(nil, errMock)
+		err := cli.DropDisk(ctx, proto.DiskID(1))
+		require.True(t, errors.Is(err, errMock))
+
+		disk1 := &blobnode.DiskInfo{Host: "127.0.0.1:xxx", Status: proto.DiskStatusNormal}
+		cli.client.(*MockClusterManager).EXPECT().DiskInfo(any, any).Return(disk1, nil)
+		cli.client.(*MockClusterManager).EXPECT().SetReadonlyDisk(any, any, true).Return(errMock)
+		err = cli.DropDisk(ctx, proto.DiskID(1))
+		require.True(t, errors.Is(err, errMock))
+
+		cli.client.(*MockClusterManager).EXPECT().DiskInfo(any, any).Return(disk1, nil)
+		cli.client.(*MockClusterManager).EXPECT().SetReadonlyDisk(any, any, true).Return(nil)
+		cli.client.(*MockClusterManager).EXPECT().DropDisk(any, any).Return(nil)
+		err = cli.DropDisk(ctx, proto.DiskID(1))
+		require.NoError(t, err)
+
+		disk2 := &blobnode.DiskInfo{Host: "127.0.0.1:xxx", Status: proto.DiskStatusNormal, Readonly: true}
+		cli.client.(*MockClusterManager).EXPECT().DiskInfo(any, any).Return(disk2, nil)
+		cli.client.(*MockClusterManager).EXPECT().DropDisk(any, any).Return(errMock)
+		err = cli.DropDisk(ctx, proto.DiskID(1))
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		// list host disks
+		disk1 := &blobnode.DiskInfo{Host: "127.0.0.1:xxx", Status: proto.DiskStatusNormal}
+		disk1.DiskID = proto.DiskID(1)
+		disk2 := &blobnode.DiskInfo{Host: "127.0.0.1:xxx", Status: proto.DiskStatusDropped}
+		disk2.DiskID = proto.DiskID(2)
+		cli.client.(*MockClusterManager).EXPECT().ListDisk(any, any).Return(cmapi.ListDiskRet{Disks: []*blobnode.DiskInfo{disk1}, Marker: disk1.DiskID}, nil)
+		cli.client.(*MockClusterManager).EXPECT().ListDisk(any, any).Return(cmapi.ListDiskRet{Disks: []*blobnode.DiskInfo{disk2}, Marker: defaultDiskListMarker}, nil)
+		disks, err := cli.ListHostDisks(ctx, "127.0.0.1:xxx")
+		require.NoError(t, err)
+		require.Equal(t, 2, len(disks))
+		require.Equal(t, proto.DiskID(2), disks[1].DiskID)
+
+		cli.client.(*MockClusterManager).EXPECT().ListDisk(any, any).Return(cmapi.ListDiskRet{}, errMock)
+		_, err = cli.ListHostDisks(ctx, "127.0.0.1:xxx")
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		// draining host
+		host := &DrainingHostMeta{Host: "127.0.0.1:xxx", Disks: []*DiskInfoSimple{{DiskID: 1}}, DropDisks: []proto.DiskID{1}}
+		require.True(t, host.IsDropDisk(1))
+		require.False(t, host.IsDropDisk(2))
+		cli.client.(*MockClusterManager).EXPECT().SetKV(any, host.ID(), any).Return(nil)
+		err := cli.AddDrainingHost(ctx, host)
+		require.NoError(t, err)
+
+		hostBytes, _ := json.Marshal(host)
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{Kvs: []*cmapi.KeyValue{
+			{Key: host.ID(), Value: hostBytes},
+		}, Marker: defaultListTaskMarker}, nil)
+		hosts, err := cli.ListDrainingHosts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(hosts))
+		require.Equal(t, host.Host, hosts[0].Host)
+		require.Equal(t, host.DropDisks, hosts[0].DropDisks)
+
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{Kvs: []*cmapi.KeyValue{
+			{Key: host.ID(), Value: append(hostBytes, []byte("mock")...)},
+		}}, nil)
+		_, err = cli.ListDrainingHosts(ctx)
+		require.Error(t, err)
+
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{}, errMock)
+		_, err = cli.ListDrainingHosts(ctx)
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		// maintenance host
+		host := &MaintenanceHostMeta{Host: "127.0.0.1:xxx", StartTime: 100, EndTime: 200}
+		require.True(t, host.Expired(200))
+		require.False(t, host.Expired(199))
+		cli.client.(*MockClusterManager).EXPECT().SetKV(any, host.ID(), any).Return(nil)
+		err := cli.SetMaintenanceHost(ctx, host)
+		require.NoError(t, err)
+		cli.client.(*MockClusterManager).EXPECT().DeleteKV(any, host.ID()).Return(nil)
+		err = cli.DeleteMaintenanceHost(ctx, host.Host)
+		require.NoError(t, err)
+
+		hostBytes, _ := json.Marshal(host)
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{Kvs: []*cmapi.KeyValue{
+			{Key: host.ID(), Value: hostBytes},
+		}, Marker: defaultListTaskMarker}, nil)
+		hosts, err := cli.ListMaintenanceHosts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []*MaintenanceHostMeta{host}, hosts)
+
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{}, errMock)
+		_, err = cli.ListMaintenanceHosts(ctx)
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		// frozen volume
+		vol := &FrozenVolumeMeta{Vid: 100, Operator: "admin", Reason: "corruption", StartTime: 100, EndTime: 200}
+		require.Equal(t, "frozen_volume-100", vol.ID())
+		require.True(t, vol.Expired(200))
+		require.False(t, vol.Expired(199))
+		cli.client.(*MockClusterManager).EXPECT().SetKV(any, vol.ID(), any).Return(nil)
+		err := cli.SetFrozenVolume(ctx, vol)
+		require.NoError(t, err)
+		cli.client.(*MockClusterManager).EXPECT().DeleteKV(any, vol.ID()).Return(nil)
+		err = cli.DeleteFrozenVolume(ctx, vol.Vid)
+		require.NoError(t, err)
+
+		volBytes, _ := json.Marshal(vol)
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{Kvs: []*cmapi.KeyValue{
+			{Key: vol.ID(), Value: volBytes},
+		}, Marker: defaultListTaskMarker}, nil)
+		vols, err := cli.ListFrozenVolumes(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []*FrozenVolumeMeta{vol}, vols)
+
+		cli.client.(*MockClusterManager).EXPECT().ListKV(any, any).Return(cmapi.ListKvRet{}, errMock)
+		_, err = cli.ListFrozenVolumes(ctx)
+		require.True(t, errors.Is(err, errMock))
+	}
 	{
 		// get disk info
 		cli.client.(*MockClusterManager).EXPECT().DiskInfo(any, any).Return(nil, errMock)
@@ -488,3 +607,20 @@ func TestClustermgrClient(t *testing.T) {
 		require.Equal(t, offset, offset2)
 	}
 }
+
+func TestParseMigrateTaskID(t *testing.T) {
+	taskID := GenMigrateTaskID(proto.TaskTypeDiskDrop, proto.DiskID(6), proto.Vid(12))
+	diskID, vid, err := ParseMigrateTaskID(proto.TaskTypeDiskDrop, taskID)
+	require.NoError(t, err)
+	require.Equal(t, proto.DiskID(6), diskID)
+	require.Equal(t, proto.Vid(12), vid)
+
+	for _, id := range []string{taskID, "disk_drop-6-12", "disk_drop-x-12-y", "disk_drop-6-x-y"} {
+		_, _, err = ParseMigrateTaskID(proto.TaskTypeBalance, id)
+		require.Error(t, err)
+		if id != taskID {
+			_, _, err = ParseMigrateTaskID(proto.TaskTypeDiskDrop, id)
+			require.Error(t, err)
+		}
+	}
+}
@@ -37,6 +37,20 @@ func (m *MockClusterMgrAPI) EXPECT() *MockClusterMgrAPIMockRecorder {
 	return m.recorder
 }
 
+// AddDrainingHost mocks base method.
+func (m *MockClusterMgrAPI) AddDrainingHost(arg0 context.Context, arg1 *client.DrainingHostMeta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDrainingHost", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddDrainingHost indicates an expected call of AddDrainingHost.
+func (mr *MockClusterMgrAPIMockRecorder) AddDrainingHost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDrainingHost", reflect.TypeOf((*MockClusterMgrAPI)(nil).AddDrainingHost), arg0, arg1)
+}
+
 // AddMigrateTask mocks base method.
 func (m *MockClusterMgrAPI) AddMigrateTask(arg0 context.Context, arg1 *proto.MigrateTask) error {
 	m.ctrl.T.Helper()
@@ -80,6 +94,63 @@ func (mr *MockClusterMgrAPIMockRecorder) AllocVolumeUnit(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocVolumeUnit", reflect.TypeOf((*MockClusterMgrAPI)(nil).AllocVolumeUnit), arg0, arg1)
 }
 
+// AllocVolumeUnitOnHost mocks base method.
+func (m *MockClusterMgrAPI) AllocVolumeUnitOnHost(arg0 context.Context, arg1 proto.Vuid, arg2 string) (*client.AllocVunitInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocVolumeUnitOnHost", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*client.AllocVunitInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocVolumeUnitOnHost indicates an expected call of AllocVolumeUnitOnHost.
+func (mr *MockClusterMgrAPIMockRecorder) AllocVolumeUnitOnHost(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocVolumeUnitOnHost", reflect.TypeOf((*MockClusterMgrAPI)(nil).AllocVolumeUnitOnHost), arg0, arg1, arg2)
+}
+
+// CancelDiskDrop mocks base method.
+func (m *MockClusterMgrAPI) CancelDiskDrop(arg0 context.Context, arg1 proto.DiskID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDiskDrop", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDiskDrop indicates an expected call of CancelDiskDrop.
+func (mr *MockClusterMgrAPIMockRecorder) CancelDiskDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDiskDrop", reflect.TypeOf((*MockClusterMgrAPI)(nil).CancelDiskDrop), arg0, arg1)
+}
+
+// DeleteFrozenVolume mocks base method.
+func (m *MockClusterMgrAPI) DeleteFrozenVolume(arg0 context.Context, arg1 proto.Vid) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFrozenVolume", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFrozenVolume indicates an expected call of DeleteFrozenVolume.
+func (mr *MockClusterMgrAPIMockRecorder) DeleteFrozenVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFrozenVolume", reflect.TypeOf((*MockClusterMgrAPI)(nil).DeleteFrozenVolume), arg0, arg1)
+}
+
+// DeleteMaintenanceHost mocks base method.
+func (m *MockClusterMgrAPI) DeleteMaintenanceHost(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMaintenanceHost", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMaintenanceHost indicates an expected call of DeleteMaintenanceHost.
+func (mr *MockClusterMgrAPIMockRecorder) DeleteMaintenanceHost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMaintenanceHost", reflect.TypeOf((*MockClusterMgrAPI)(nil).DeleteMaintenanceHost), arg0, arg1)
+}
+
 // DeleteMigrateTask mocks base method.
 func (m *MockClusterMgrAPI) DeleteMigrateTask(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -108,6 +179,20 @@ func (mr *MockClusterMgrAPIMockRecorder) DeleteMigratingDisk(arg0, arg1, arg2 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMigratingDisk", reflect.TypeOf((*MockClusterMgrAPI)(nil).DeleteMigratingDisk), arg0, arg1, arg2)
 }
 
+// DropDisk mocks base method.
+func (m *MockClusterMgrAPI) DropDisk(arg0 context.Context, arg1 proto.DiskID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropDisk", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DropDisk indicates an expected call of DropDisk.
+func (mr *MockClusterMgrAPIMockRecorder) DropDisk(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropDisk", reflect.TypeOf((*MockClusterMgrAPI)(nil).DropDisk), arg0, arg1)
+}
+
 // GetConfig mocks base method.
 func (m *MockClusterMgrAPI) GetConfig(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -303,6 +388,21 @@ func (mr *MockClusterMgrAPIMockRecorder) ListDiskVolumeUnits(arg0, arg1 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDiskVolumeUnits", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListDiskVolumeUnits), arg0, arg1)
 }
 
+// ListDrainingHosts mocks base method.
+func (m *MockClusterMgrAPI) ListDrainingHosts(arg0 context.Context) ([]*client.DrainingHostMeta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDrainingHosts", arg0)
+	ret0, _ := ret[0].([]*client.DrainingHostMeta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDrainingHosts indicates an expected call of ListDrainingHosts.
+func (mr *MockClusterMgrAPIMockRecorder) ListDrainingHosts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDrainingHosts", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListDrainingHosts), arg0)
+}
+
 // ListDropDisks mocks base method.
 func (m *MockClusterMgrAPI) ListDropDisks(arg0 context.Context) ([]*client.DiskInfoSimple, error) {
 	m.ctrl.T.Helper()
@@ -318,6 +418,51 @@ func (mr *MockClusterMgrAPIMockRecorder) ListDropDisks(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDropDisks", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListDropDisks), arg0)
 }
 
+// ListFrozenVolumes mocks base method.
+func (m *MockClusterMgrAPI) ListFrozenVolumes(arg0 context.Context) ([]*client.FrozenVolumeMeta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFrozenVolumes", arg0)
+	ret0, _ := ret[0].([]*client.FrozenVolumeMeta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFrozenVolumes indicates an expected call of ListFrozenVolumes.
+func (mr *MockClusterMgrAPIMockRecorder) ListFrozenVolumes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFrozenVolumes", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListFrozenVolumes), arg0)
+}
+
+// ListHostDisks mocks base method.
+func (m *MockClusterMgrAPI) ListHostDisks(arg0 context.Context, arg1 string) ([]*client.DiskInfoSimple, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHostDisks", arg0, arg1)
+	ret0, _ := ret[0].([]*client.DiskInfoSimple)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHostDisks indicates an expected call of ListHostDisks.
+func (mr *MockClusterMgrAPIMockRecorder) ListHostDisks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHostDisks", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListHostDisks), arg0, arg1)
+}
+
+// ListMaintenanceHosts mocks base method.
+func (m *MockClusterMgrAPI) ListMaintenanceHosts(arg0 context.Context) ([]*client.MaintenanceHostMeta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMaintenanceHosts", arg0)
+	ret0, _ := ret[0].([]*client.MaintenanceHostMeta)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMaintenanceHosts indicates an expected call of ListMaintenanceHosts.
+func (mr *MockClusterMgrAPIMockRecorder) ListMaintenanceHosts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMaintenanceHosts", reflect.TypeOf((*MockClusterMgrAPI)(nil).ListMaintenanceHosts), arg0)
+}
+
 // ListMigrateTasks mocks base method.
 func (m *MockClusterMgrAPI) ListMigrateTasks(arg0 context.Context, arg1 proto.TaskType, arg2 *clustermgr.ListKvOpts) ([]*proto.MigrateTask, string, error) {
 	m.ctrl.T.Helper()
@@ -478,6 +623,34 @@ func (mr *MockClusterMgrAPIMockRecorder) SetDiskRepairing(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDiskRepairing", reflect.TypeOf((*MockClusterMgrAPI)(nil).SetDiskRepairing), arg0, arg1)
 }
 
+// SetFrozenVolume mocks base method.
+func (m *MockClusterMgrAPI) SetFrozenVolume(arg0 context.Context, arg1 *client.FrozenVolumeMeta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFrozenVolume", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFrozenVolume indicates an expected call of SetFrozenVolume.
+func (mr *MockClusterMgrAPIMockRecorder) SetFrozenVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFrozenVolume", reflect.TypeOf((*MockClusterMgrAPI)(nil).SetFrozenVolume), arg0, arg1)
+}
+
+// SetMaintenanceHost mocks base method.
+func (m *MockClusterMgrAPI) SetMaintenanceHost(arg0 context.Context, arg1 *client.MaintenanceHostMeta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMaintenanceHost", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMaintenanceHost indicates an expected call of SetMaintenanceHost.
+func (mr *MockClusterMgrAPIMockRecorder) SetMaintenanceHost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaintenanceHost", reflect.TypeOf((*MockClusterMgrAPI)(nil).SetMaintenanceHost), arg0, arg1)
+}
+
 // SetVolumeInspectCheckPoint mocks base method.
 func (m *MockClusterMgrAPI) SetVolumeInspectCheckPoint(arg0 context.Context, arg1 proto.Vid) error {
 	m.ctrl.T.Helper()
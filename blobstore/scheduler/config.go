@@ -35,9 +35,13 @@ const (
 	defaultClientTimeoutMs            = int64(1000)
 	defaultHostSyncIntervalMs         = int64(1000)
 
+	defaultHostDiskConcurrency = 1
+
 	defaultMaxDiskFreeChunkCnt = int64(1024)
 	defaultMinDiskFreeChunkCnt = int64(20)
 
+	defaultMinFreeChunkCntDiff = int64(100)
+
 	defaultInspectIntervalS  = 1
 	defaultListVolIntervalMs = 10
 	defaultListVolStep       = 100
@@ -55,6 +59,10 @@ const (
 	defaultMaxBatchSize           = 10
 	defaultBatchIntervalSec       = 2
 
+	defaultTaskWindowMinTasks  = 1
+	defaultTaskWindowMaxTasks  = 32
+	defaultTaskWindowInitTasks = 4
+
 	defaultTickInterval   = uint32(1)
 	defaultHeartbeatTicks = uint32(30)
 	defaultExpiresTicks   = uint32(60)
@@ -83,12 +91,17 @@ type Config struct {
 	Blobnode   blobnode.Config   `json:"blobnode"`
 	Scheduler  scheduler.Config  `json:"scheduler"`
 
-	Balance       BalanceMgrConfig    `json:"balance"`
-	DiskDrop      MigrateConfig       `json:"disk_drop"`
-	DiskRepair    MigrateConfig       `json:"disk_repair"`
-	ManualMigrate MigrateConfig       `json:"manual_migrate"`
-	VolumeInspect VolumeInspectMgrCfg `json:"volume_inspect"`
-	TaskLog       recordlog.Config    `json:"task_log"`
+	Balance          BalanceMgrConfig          `json:"balance"`
+	IntraNodeBalance IntraNodeBalanceMgrConfig `json:"intra_node_balance"`
+	DiskDrop         MigrateConfig             `json:"disk_drop"`
+	DiskRepair       MigrateConfig             `json:"disk_repair"`
+	ManualMigrate    MigrateConfig             `json:"manual_migrate"`
+	VolumeInspect    VolumeInspectMgrCfg       `json:"volume_inspect"`
+	TaskLog          recordlog.Config          `json:"task_log"`
+	TaskWindow       TaskWindowConfig          `json:"task_window"`
+	Digest           DigestConfig              `json:"digest"`
+	TaskNotify       TaskNotifyConfig          `json:"task_notify"`
+	Recovery         RecoveryConfig            `json:"recovery"`
 
 	Kafka       KafkaConfig       `json:"kafka"`
 	ShardRepair ShardRepairConfig `json:"shard_repair"`
@@ -189,10 +202,17 @@ func (c *Config) fixConfig() (err error) {
 		return errInvalidKafka
 	}
 	c.fixBalanceConfig()
+	c.fixIntraNodeBalanceConfig()
 	c.fixDiskDropConfig()
 	c.fixDiskRepairConfig()
 	c.fixManualMigrateConfig()
 	c.fixInspectConfig()
+	c.fixTaskWindowConfig()
+	if err := c.fixDigestConfig(); err != nil {
+		return err
+	}
+	c.fixTaskNotifyConfig()
+	c.fixRecoveryConfig()
 	c.fixShardRepairConfig()
 	if err := c.fixBlobDeleteConfig(); err != nil {
 		return err
@@ -235,8 +255,15 @@ func (c *Config) fixBalanceConfig() {
 	c.Balance.CheckAndFix()
 }
 
+func (c *Config) fixIntraNodeBalanceConfig() {
+	c.IntraNodeBalance.ClusterID = c.ClusterID
+	defaulter.LessOrEqual(&c.IntraNodeBalance.MinFreeChunkCntDiff, defaultMinFreeChunkCntDiff)
+	c.IntraNodeBalance.CheckAndFix()
+}
+
 func (c *Config) fixDiskDropConfig() {
 	c.DiskDrop.ClusterID = c.ClusterID
+	defaulter.LessOrEqual(&c.DiskDrop.HostDiskConcurrency, defaultHostDiskConcurrency)
 	c.DiskDrop.CheckAndFix()
 }
 
@@ -261,6 +288,40 @@ func (c *Config) fixInspectConfig() {
 	defaulter.LessOrEqual(&c.VolumeInspect.InspectIntervalS, defaultInspectIntervalS)
 }
 
+func (c *Config) fixDigestConfig() error {
+	if c.Digest.HourOfDay < 0 || c.Digest.HourOfDay > 23 {
+		return errInvalidHourRange
+	}
+	defaulter.LessOrEqual(&c.Digest.TimeoutMs, defaultDigestTimeoutMs)
+	return nil
+}
+
+func (c *Config) fixTaskNotifyConfig() {
+	defaulter.LessOrEqual(&c.TaskNotify.QueueSize, defaultTaskNotifyQueueSize)
+	defaulter.LessOrEqual(&c.TaskNotify.BatchSize, defaultTaskNotifyBatchSize)
+	defaulter.LessOrEqual(&c.TaskNotify.TimeoutMs, defaultTaskNotifyTimeoutMs)
+	defaulter.LessOrEqual(&c.TaskNotify.Kafka.TimeoutMs, defaultTaskNotifyTimeoutMs)
+}
+
+func (c *Config) fixRecoveryConfig() {
+	defaulter.LessOrEqual(&c.Recovery.ReportWindowS, defaultRecoveryReportWindowS)
+}
+
+func (c *Config) fixTaskWindowConfig() {
+	defaulter.LessOrEqual(&c.TaskWindow.MinTasks, defaultTaskWindowMinTasks)
+	defaulter.LessOrEqual(&c.TaskWindow.MaxTasks, defaultTaskWindowMaxTasks)
+	if c.TaskWindow.MaxTasks < c.TaskWindow.MinTasks {
+		c.TaskWindow.MaxTasks = c.TaskWindow.MinTasks
+	}
+	defaulter.LessOrEqual(&c.TaskWindow.InitTasks, defaultTaskWindowInitTasks)
+	if c.TaskWindow.InitTasks < c.TaskWindow.MinTasks {
+		c.TaskWindow.InitTasks = c.TaskWindow.MinTasks
+	}
+	if c.TaskWindow.InitTasks > c.TaskWindow.MaxTasks {
+		c.TaskWindow.InitTasks = c.TaskWindow.MaxTasks
+	}
+}
+
 func (c *Config) fixShardRepairConfig() {
 	c.ShardRepair.ClusterID = c.ClusterID
 	defaulter.LessOrEqual(&c.ShardRepair.TaskPoolSize, defaultTaskPoolSize)
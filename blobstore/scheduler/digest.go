@@ -0,0 +1,339 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const defaultDigestTimeoutMs = int64(10000)
+
+var (
+	digestMigrateTypes = []proto.TaskType{
+		proto.TaskTypeDiskRepair, proto.TaskTypeDiskDrop, proto.TaskTypeBalance,
+		proto.TaskTypeIntraNodeBalance, proto.TaskTypeManualMigrate,
+	}
+	digestRunnerTypes = []proto.TaskType{proto.TaskTypeShardRepair, proto.TaskTypeBlobDelete}
+)
+
+// DigestConfig is the config of the daily digest of the background tasks, which is posted to the
+// webhook in json and mailed in text. The digest is disabled without the webhook or the mail.
+type DigestConfig struct {
+	HourOfDay  int        `json:"hour_of_day"`
+	WebhookURL string     `json:"webhook_url"`
+	SMTP       SMTPConfig `json:"smtp"`
+	TimeoutMs  int64      `json:"timeout_ms"`
+}
+
+// SMTPConfig is the mail server to send the digest.
+type SMTPConfig struct {
+	Addr     string   `json:"addr"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+func (c *DigestConfig) enabled() bool {
+	return c.WebhookURL != "" || c.smtpEnabled()
+}
+
+func (c *DigestConfig) smtpEnabled() bool {
+	return c.SMTP.Addr != "" && len(c.SMTP.To) > 0
+}
+
+// MigrateDigest is the summary of the migrate tasks of the type in the period.
+type MigrateDigest struct {
+	TaskType       proto.TaskType `json:"task_type"`
+	Enable         bool           `json:"enable"`
+	FinishedCnt    uint64         `json:"finished_cnt"`
+	FailedCnt      uint64         `json:"failed_cnt"` // reclaimed or canceled by the workers
+	ShardCnt       uint64         `json:"shard_cnt"`
+	DataAmountByte uint64         `json:"data_amount_byte"`
+	Backlog        int            `json:"backlog"`       // the tasks not finished at the end
+	BacklogDelta   int            `json:"backlog_delta"` // the change of the backlog in the period
+}
+
+// RunnerDigest is the summary of the shard repair or blob delete in the period, the runners run
+// on all the nodes, so the failures are the sum of the nodes reporting.
+type RunnerDigest struct {
+	TaskType      proto.TaskType    `json:"task_type"`
+	Enable        bool              `json:"enable"`
+	FailedCnt     uint64            `json:"failed_cnt"`
+	NodeFailedCnt map[string]uint64 `json:"node_failed_cnt"`
+}
+
+// Digest is the summary of the background tasks of the cluster in the period.
+type Digest struct {
+	ClusterID      proto.ClusterID `json:"cluster_id"`
+	StartTime      time.Time       `json:"start_time"`
+	EndTime        time.Time       `json:"end_time"`
+	Migrates       []MigrateDigest `json:"migrates"`
+	Runners        []RunnerDigest  `json:"runners"`
+	RepairingDisks []proto.DiskID  `json:"repairing_disks"`
+	DroppingDisks  []proto.DiskID  `json:"dropping_disks"`
+	// the nodes whose runner failures are not counted, they are counted in the next digest
+	UnreachableNodes []string `json:"unreachable_nodes,omitempty"`
+}
+
+// Subject returns the subject of the digest mail.
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("[blobstore] scheduler digest of cluster %d on %s", d.ClusterID, d.EndTime.Format("2006-01-02"))
+}
+
+// Text returns the digest in text.
+func (d *Digest) Text() string {
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "background tasks of cluster %d from %s to %s\n\n", d.ClusterID,
+		d.StartTime.Format(time.RFC3339), d.EndTime.Format(time.RFC3339))
+	fmt.Fprintln(b, "migrate tasks:")
+	for _, m := range d.Migrates {
+		fmt.Fprintf(b, "  %-18s enable:%-5v finished:%d failed:%d shards:%d data:%s backlog:%d(%+d)\n",
+			m.TaskType, m.Enable, m.FinishedCnt, m.FailedCnt, m.ShardCnt, humanize.IBytes(m.DataAmountByte),
+			m.Backlog, m.BacklogDelta)
+	}
+	fmt.Fprintln(b, "runners:")
+	for _, r := range d.Runners {
+		fmt.Fprintf(b, "  %-18s enable:%-5v failed:%d nodes:%v\n", r.TaskType, r.Enable, r.FailedCnt, r.NodeFailedCnt)
+	}
+	if len(d.UnreachableNodes) > 0 {
+		fmt.Fprintf(b, "unreachable nodes: %v\n", d.UnreachableNodes)
+	}
+	fmt.Fprintf(b, "repairing disks: %v\n", d.RepairingDisks)
+	fmt.Fprintf(b, "dropping disks: %v\n", d.DroppingDisks)
+	return b.String()
+}
+
+// DigestMgr sends the digest of the background tasks daily, so that the owners of the cluster learn
+// the health of it without the dashboards. The digest covers the tasks since the last one, or since
+// the scheduler started for the first one, and is generated on the leader, which collects the
+// failures of the runners from the followers.
+type DigestMgr struct {
+	closer.Closer
+
+	clusterID     proto.ClusterID
+	conf          DigestConfig
+	localHost     string
+	followerHosts []string
+	migrators     map[proto.TaskType]Migrator
+	runners       map[proto.TaskType]ITaskRunner
+	diskRepairMgr IDisKMigrator
+	diskDropMgr   IDisKMigrator
+	webhookCli    rpc.Client
+	sendMail      func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	nodeStats     func(ctx context.Context, host string) (api.TasksStat, error)
+
+	last         time.Time
+	lastTotals   map[proto.TaskType]api.TasksTotals
+	lastBacklogs map[proto.TaskType]int
+	lastFailed   map[runnerNode]uint64
+}
+
+type runnerNode struct {
+	host     string
+	taskType proto.TaskType
+}
+
+// NewDigestMgr returns digest manager
+func NewDigestMgr(clusterID proto.ClusterID, conf DigestConfig, localHost string, followerHosts []string,
+	migrators map[proto.TaskType]Migrator, runners map[proto.TaskType]ITaskRunner, diskRepairMgr, diskDropMgr IDisKMigrator,
+) *DigestMgr {
+	cli := rpc.NewClient(&rpc.Config{ClientTimeoutMs: conf.TimeoutMs})
+	return &DigestMgr{
+		Closer:        closer.New(),
+		clusterID:     clusterID,
+		conf:          conf,
+		localHost:     localHost,
+		followerHosts: followerHosts,
+		migrators:     migrators,
+		runners:       runners,
+		diskRepairMgr: diskRepairMgr,
+		diskDropMgr:   diskDropMgr,
+		webhookCli:    cli,
+		sendMail:      smtp.SendMail,
+		nodeStats: func(ctx context.Context, host string) (ret api.TasksStat, err error) {
+			err = cli.GetWith(ctx, scheme+host+api.PathStats, &ret)
+			return
+		},
+		lastTotals:   make(map[proto.TaskType]api.TasksTotals),
+		lastBacklogs: make(map[proto.TaskType]int),
+		lastFailed:   make(map[runnerNode]uint64),
+	}
+}
+
+// Run sends the digest at the hour of every day
+func (mgr *DigestMgr) Run() {
+	if !mgr.conf.enabled() {
+		return
+	}
+	// the baseline of the first digest
+	mgr.collect(context.Background(), time.Now())
+	go func() {
+		for {
+			t := time.NewTimer(time.Until(nextDigestTime(time.Now(), mgr.conf.HourOfDay)))
+			select {
+			case now := <-t.C:
+				span, ctx := trace.StartSpanFromContext(context.Background(), "SendDigest")
+				if err := mgr.send(ctx, mgr.collect(ctx, now)); err != nil {
+					span.Errorf("send digest failed: err[%+v]", err)
+				}
+			case <-mgr.Done():
+				t.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func nextDigestTime(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// collect returns the digest of the tasks since the last collection.
+func (mgr *DigestMgr) collect(ctx context.Context, now time.Time) *Digest {
+	d := &Digest{ClusterID: mgr.clusterID, StartTime: mgr.last, EndTime: now}
+	for _, typ := range digestMigrateTypes {
+		migrator, ok := mgr.migrators[typ]
+		if !ok {
+			continue
+		}
+		stat := migrator.Stats()
+		totals, last := stat.Totals, mgr.lastTotals[typ]
+		backlog := stat.PreparingCnt + stat.WorkerDoingCnt + stat.FinishingCnt
+		d.Migrates = append(d.Migrates, MigrateDigest{
+			TaskType:       typ,
+			Enable:         migrator.Enabled(),
+			FinishedCnt:    totals.FinishedCnt - last.FinishedCnt,
+			FailedCnt:      totals.ReclaimedCnt + totals.CanceledCnt - last.ReclaimedCnt - last.CanceledCnt,
+			ShardCnt:       totals.ShardCnt - last.ShardCnt,
+			DataAmountByte: totals.DataAmountByte - last.DataAmountByte,
+			Backlog:        backlog,
+			BacklogDelta:   backlog - mgr.lastBacklogs[typ],
+		})
+		mgr.lastTotals[typ] = totals
+		mgr.lastBacklogs[typ] = backlog
+	}
+	var nodeFailed map[runnerNode]uint64
+	nodeFailed, d.UnreachableNodes = mgr.runnerFailures(ctx)
+	for _, typ := range digestRunnerTypes {
+		runner, ok := mgr.runners[typ]
+		if !ok {
+			continue
+		}
+		r := RunnerDigest{TaskType: typ, Enable: runner.Enabled(), NodeFailedCnt: make(map[string]uint64)}
+		for node, failed := range nodeFailed {
+			if node.taskType != typ {
+				continue
+			}
+			// the failures are counted since the node restarted if the total goes backwards
+			delta := failed
+			if last := mgr.lastFailed[node]; failed >= last {
+				delta = failed - last
+			}
+			r.FailedCnt += delta
+			r.NodeFailedCnt[node.host] = delta
+			mgr.lastFailed[node] = failed
+		}
+		d.Runners = append(d.Runners, r)
+	}
+	if mgr.diskRepairMgr != nil {
+		d.RepairingDisks, _, _ = mgr.diskRepairMgr.Progress(ctx)
+	}
+	if mgr.diskDropMgr != nil {
+		d.DroppingDisks, _, _ = mgr.diskDropMgr.Progress(ctx)
+	}
+	mgr.last = now
+	return d
+}
+
+// runnerFailures returns the cumulative failures of the runners of this node and the followers,
+// and the followers failed to report, whose failures are left to the next digest.
+func (mgr *DigestMgr) runnerFailures(ctx context.Context) (failures map[runnerNode]uint64, unreachable []string) {
+	failures = make(map[runnerNode]uint64)
+	for typ, runner := range mgr.runners {
+		_, failed := runner.GetErrorStats()
+		failures[runnerNode{host: mgr.localHost, taskType: typ}] = failed
+	}
+
+	span := trace.SpanFromContextSafe(ctx)
+	for _, host := range mgr.followerHosts {
+		stat, err := mgr.nodeStats(ctx, host)
+		if err != nil {
+			span.Warnf("get runner stats of follower failed: host[%s], err[%+v]", host, err)
+			unreachable = append(unreachable, host)
+			continue
+		}
+		if stat.ShardRepair != nil {
+			failures[runnerNode{host: host, taskType: proto.TaskTypeShardRepair}] = stat.ShardRepair.TotalErrCnt
+		}
+		if stat.BlobDelete != nil {
+			failures[runnerNode{host: host, taskType: proto.TaskTypeBlobDelete}] = stat.BlobDelete.TotalErrCnt
+		}
+	}
+	return
+}
+
+// send posts the digest to the webhook and mails it, both are tried.
+func (mgr *DigestMgr) send(ctx context.Context, d *Digest) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+	if mgr.conf.WebhookURL != "" {
+		if e := mgr.webhookCli.PostWith(ctx, mgr.conf.WebhookURL, nil, d); e != nil {
+			span.Errorf("post digest to webhook failed: url[%s], err[%+v]", mgr.conf.WebhookURL, e)
+			err = e
+		}
+	}
+	if mgr.conf.smtpEnabled() {
+		if e := mgr.mail(d); e != nil {
+			span.Errorf("mail digest failed: addr[%s], err[%+v]", mgr.conf.SMTP.Addr, e)
+			err = e
+		}
+	}
+	if err == nil {
+		span.Infof("digest sent: %+v", d)
+	}
+	return
+}
+
+func (mgr *DigestMgr) mail(d *Digest) error {
+	conf := mgr.conf.SMTP
+	var auth smtp.Auth
+	if conf.Username != "" {
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, strings.Split(conf.Addr, ":")[0])
+	}
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", conf.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(conf.To, ","))
+	fmt.Fprintf(msg, "Subject: %s\r\n", d.Subject())
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(d.Text(), "\n", "\r\n"))
+	return mgr.sendMail(conf.Addr, auth, conf.From, conf.To, msg.Bytes())
+}
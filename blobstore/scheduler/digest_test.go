@@ -0,0 +1,189 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func TestNextDigestTime(t *testing.T) {
+	now := time.Date(2023, 5, 1, 8, 30, 0, 0, time.Local)
+	require.Equal(t, time.Date(2023, 5, 1, 9, 0, 0, 0, time.Local), nextDigestTime(now, 9))
+	require.Equal(t, time.Date(2023, 5, 2, 8, 0, 0, 0, time.Local), nextDigestTime(now, 8))
+	require.Equal(t, time.Date(2023, 5, 2, 0, 0, 0, 0, time.Local), nextDigestTime(now, 0))
+}
+
+func TestDigestMgr(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+
+	stat := api.MigrateTasksStat{PreparingCnt: 3, WorkerDoingCnt: 2}
+	diskRepairMgr := NewMockMigrater(ctr)
+	diskRepairMgr.EXPECT().Stats().AnyTimes().DoAndReturn(func() api.MigrateTasksStat { return stat })
+	diskRepairMgr.EXPECT().Enabled().AnyTimes().Return(true)
+	diskRepairMgr.EXPECT().Progress(any).AnyTimes().Return([]proto.DiskID{1}, 0, 0)
+	diskDropMgr := NewMockMigrater(ctr)
+	diskDropMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
+	diskDropMgr.EXPECT().Enabled().AnyTimes().Return(false)
+	diskDropMgr.EXPECT().Progress(any).AnyTimes().Return([]proto.DiskID{2, 3}, 0, 0)
+	var failed uint64 = 5
+	shardRepairMgr := NewMockTaskRunner(ctr)
+	shardRepairMgr.EXPECT().Enabled().AnyTimes().Return(true)
+	shardRepairMgr.EXPECT().GetErrorStats().AnyTimes().DoAndReturn(func() ([]string, uint64) { return nil, failed })
+
+	var posted Digest
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+	}))
+	defer webhook.Close()
+
+	conf := DigestConfig{
+		WebhookURL: webhook.URL,
+		SMTP:       SMTPConfig{Addr: "127.0.0.1:25", From: "scheduler@cubefs.io", To: []string{"owner@cubefs.io"}},
+		TimeoutMs:  defaultDigestTimeoutMs,
+	}
+	mgr := NewDigestMgr(1, conf, "127.0.0.1:9800", nil,
+		map[proto.TaskType]Migrator{proto.TaskTypeDiskRepair: diskRepairMgr, proto.TaskTypeDiskDrop: diskDropMgr},
+		map[proto.TaskType]ITaskRunner{proto.TaskTypeShardRepair: shardRepairMgr}, diskRepairMgr, diskDropMgr)
+	defer mgr.Close()
+	var mailed []byte
+	mgr.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, conf.SMTP.Addr, addr)
+		require.Nil(t, a)
+		require.Equal(t, conf.SMTP.To, to)
+		mailed = msg
+		return nil
+	}
+
+	// the baseline
+	start := time.Now()
+	mgr.collect(ctx, start)
+
+	stat.Totals = api.TasksTotals{FinishedCnt: 10, ReclaimedCnt: 1, CanceledCnt: 2, ShardCnt: 100, DataAmountByte: 1 << 30}
+	stat.PreparingCnt = 1
+	failed = 8
+	d := mgr.collect(ctx, start.Add(24*time.Hour))
+	require.Equal(t, start, d.StartTime)
+	require.Len(t, d.Migrates, 2)
+	require.Equal(t, MigrateDigest{
+		TaskType:       proto.TaskTypeDiskRepair,
+		Enable:         true,
+		FinishedCnt:    10,
+		FailedCnt:      3,
+		ShardCnt:       100,
+		DataAmountByte: 1 << 30,
+		Backlog:        3,
+		BacklogDelta:   -2,
+	}, d.Migrates[0])
+	require.Equal(t, proto.TaskTypeDiskDrop, d.Migrates[1].TaskType)
+	require.Equal(t, []RunnerDigest{{
+		TaskType:      proto.TaskTypeShardRepair,
+		Enable:        true,
+		FailedCnt:     3,
+		NodeFailedCnt: map[string]uint64{"127.0.0.1:9800": 3},
+	}}, d.Runners)
+	require.Equal(t, []proto.DiskID{1}, d.RepairingDisks)
+	require.Equal(t, []proto.DiskID{2, 3}, d.DroppingDisks)
+
+	require.NoError(t, mgr.send(ctx, d))
+	require.Equal(t, d.Migrates, posted.Migrates)
+	require.Equal(t, d.DroppingDisks, posted.DroppingDisks)
+	require.Contains(t, string(mailed), "Subject: "+d.Subject())
+	require.Contains(t, string(mailed), "finished:10 failed:3 shards:100 data:1.0 GiB backlog:3(-2)")
+
+	// only the tasks since the last digest
+	d = mgr.collect(ctx, start.Add(48*time.Hour))
+	require.Equal(t, uint64(0), d.Migrates[0].FinishedCnt)
+	require.Equal(t, 0, d.Migrates[0].BacklogDelta)
+	require.Equal(t, uint64(0), d.Runners[0].FailedCnt)
+
+	// the webhook failed
+	mgr.conf.WebhookURL = webhook.URL + "/404"
+	webhook.Close()
+	require.Error(t, mgr.send(ctx, d))
+}
+
+func TestDigestMgrFollowers(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+
+	var leaderFailed uint64 = 1
+	shardRepairMgr := NewMockTaskRunner(ctr)
+	shardRepairMgr.EXPECT().Enabled().AnyTimes().Return(true)
+	shardRepairMgr.EXPECT().GetErrorStats().AnyTimes().DoAndReturn(func() ([]string, uint64) { return nil, leaderFailed })
+	blobDeleteMgr := NewMockTaskRunner(ctr)
+	blobDeleteMgr.EXPECT().Enabled().AnyTimes().Return(true)
+	blobDeleteMgr.EXPECT().GetErrorStats().AnyTimes().Return(nil, uint64(0))
+
+	followerFailed := map[string]uint64{"follower1": 10, "follower2": 20}
+	unreachable := map[string]bool{}
+	mgr := NewDigestMgr(1, DigestConfig{TimeoutMs: defaultDigestTimeoutMs}, "leader", []string{"follower1", "follower2"},
+		nil, map[proto.TaskType]ITaskRunner{
+			proto.TaskTypeShardRepair: shardRepairMgr,
+			proto.TaskTypeBlobDelete:  blobDeleteMgr,
+		}, nil, nil)
+	defer mgr.Close()
+	mgr.nodeStats = func(_ context.Context, host string) (api.TasksStat, error) {
+		if unreachable[host] {
+			return api.TasksStat{}, errors.New("connection refused")
+		}
+		return api.TasksStat{
+			ShardRepair: &api.RunnerStat{TotalErrCnt: followerFailed[host]},
+			BlobDelete:  &api.RunnerStat{TotalErrCnt: followerFailed[host] * 2},
+		}, nil
+	}
+
+	start := time.Now()
+	mgr.collect(ctx, start)
+
+	// the failures of all the nodes are summed
+	leaderFailed, followerFailed["follower1"], followerFailed["follower2"] = 2, 13, 25
+	d := mgr.collect(ctx, start.Add(24*time.Hour))
+	require.Empty(t, d.UnreachableNodes)
+	require.Len(t, d.Runners, 2)
+	require.Equal(t, proto.TaskTypeShardRepair, d.Runners[0].TaskType)
+	require.Equal(t, uint64(9), d.Runners[0].FailedCnt)
+	require.Equal(t, map[string]uint64{"leader": 1, "follower1": 3, "follower2": 5}, d.Runners[0].NodeFailedCnt)
+	require.Equal(t, proto.TaskTypeBlobDelete, d.Runners[1].TaskType)
+	require.Equal(t, uint64(16), d.Runners[1].FailedCnt)
+
+	// the unreachable follower is counted in the next digest, and the restarted one since its start
+	unreachable["follower1"] = true
+	followerFailed["follower1"], followerFailed["follower2"] = 15, 4
+	d = mgr.collect(ctx, start.Add(48*time.Hour))
+	require.Equal(t, []string{"follower1"}, d.UnreachableNodes)
+	require.Equal(t, uint64(4), d.Runners[0].FailedCnt)
+	require.Equal(t, map[string]uint64{"leader": 0, "follower2": 4}, d.Runners[0].NodeFailedCnt)
+	require.Contains(t, d.Text(), "unreachable nodes: [follower1]")
+
+	delete(unreachable, "follower1")
+	d = mgr.collect(ctx, start.Add(72*time.Hour))
+	require.Empty(t, d.UnreachableNodes)
+	require.Equal(t, uint64(2), d.Runners[0].FailedCnt)
+	require.Equal(t, map[string]uint64{"leader": 0, "follower1": 2, "follower2": 0}, d.Runners[0].NodeFailedCnt)
+}
@@ -17,6 +17,7 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
@@ -36,6 +37,12 @@ type DiskDropMgr struct {
 	droppingDisks *migratingDisks
 	droppedDisks  *migratedDisks
 
+	cancelLock    sync.RWMutex
+	cancelReports map[proto.DiskID]*api.DiskDropCancelReport
+
+	drainLock     sync.RWMutex
+	drainingHosts map[string]*client.DrainingHostMeta
+
 	clusterMgrCli client.ClusterMgrAPI
 	hasRevised    bool
 
@@ -50,6 +57,8 @@ func NewDiskDropMgr(clusterMgrCli client.ClusterMgrAPI, volumeUpdater client.IVo
 		cfg:           conf,
 		droppedDisks:  newMigratedDisks(),
 		droppingDisks: newMigratingDisks(),
+		cancelReports: make(map[proto.DiskID]*api.DiskDropCancelReport),
+		drainingHosts: make(map[string]*client.DrainingHostMeta),
 	}
 	mgr.IMigrator = NewMigrateMgr(clusterMgrCli, volumeUpdater, taskSwitch, taskLogger, conf, proto.TaskTypeDiskDrop)
 	mgr.SetClearJunkTasksWhenLoadingFunc(mgr.clearJunkTasksWhenLoading)
@@ -67,6 +76,18 @@ func (mgr *DiskDropMgr) Load() (err error) {
 
 	for _, disk := range droppingDisks {
 		mgr.droppingDisks.add(disk.Disk.DiskID, disk.Disk)
+		if disk.CancelTime != 0 {
+			mgr.cancelReports[disk.Disk.DiskID] = newDiskDropCancelReport(disk)
+			mgr.IMigrator.StopDiskTasks(disk.Disk.DiskID)
+		}
+	}
+
+	drainingHosts, err := mgr.clusterMgrCli.ListDrainingHosts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, host := range drainingHosts {
+		mgr.drainingHosts[host.Host] = host
 	}
 
 	return mgr.IMigrator.Load()
@@ -102,6 +123,7 @@ func (mgr *DiskDropMgr) Run() {
 	mgr.IMigrator.Run()
 	go mgr.checkDroppedAndClearLoop()
 	go mgr.checkAndClearJunkTasksLoop()
+	go mgr.drainHostLoop()
 }
 
 // collectTaskLoop collect disk drop task loop
@@ -158,6 +180,9 @@ func (mgr *DiskDropMgr) reviseDropDisks(ctx context.Context) error {
 	span := trace.SpanFromContextSafe(ctx)
 
 	for _, disk := range mgr.droppingDisks.list() {
+		if mgr.isCancellingDisk(disk.DiskID) {
+			continue
+		}
 		if err := mgr.reviseDropTask(ctx, disk.DiskID); err != nil {
 			span.Errorf("revise drop tasks failed: disk_id[%d]", disk.DiskID)
 			return err
@@ -285,6 +310,10 @@ func (mgr *DiskDropMgr) checkDroppedAndClear() {
 	span, ctx := trace.StartSpanFromContext(context.Background(), "disk_drop.checkDroppedAndClear")
 
 	for _, disk := range mgr.droppingDisks.list() {
+		if mgr.isCancellingDisk(disk.DiskID) {
+			mgr.checkCancelledAndRestore(ctx, disk.DiskID)
+			continue
+		}
 		if !mgr.checkDiskDropped(ctx, disk.DiskID) {
 			continue
 		}
@@ -350,6 +379,141 @@ func (mgr *DiskDropMgr) clearTasksByDiskID(ctx context.Context, diskID proto.Dis
 	mgr.droppingDisks.delete(diskID)
 }
 
+// CancelDrop cancels the dropping disk. The pending tasks are stopped, the running tasks are kept
+// and the volume units migrated keep in their new locations. The disk is restored in clustermgr
+// after all the running tasks are done.
+func (mgr *DiskDropMgr) CancelDrop(ctx context.Context, diskID proto.DiskID) (report *api.DiskDropCancelReport, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	mgr.cancelLock.Lock()
+	defer mgr.cancelLock.Unlock()
+	if report, ok := mgr.cancelReports[diskID]; ok {
+		return report.Copy(), nil
+	}
+	if _, ok := mgr.droppingDisks.get(diskID); !ok {
+		return nil, errcode.ErrNotDroppingDisk
+	}
+
+	meta, err := mgr.clusterMgrCli.GetMigratingDisk(ctx, proto.TaskTypeDiskDrop, diskID)
+	if err != nil {
+		span.Errorf("get migrating disk failed: disk_id[%d], err[%+v]", diskID, err)
+		return nil, err
+	}
+
+	// stop the pending tasks firstly, and the tasks prepared later than listing are stopped too
+	mgr.IMigrator.StopDiskTasks(diskID)
+	defer func() {
+		if err != nil {
+			mgr.IMigrator.ResumeDiskTasks(diskID)
+		}
+	}()
+	tasks, err := mgr.IMigrator.ListAllTaskByDiskID(ctx, diskID)
+	if err != nil {
+		span.Errorf("list disk tasks failed: disk_id[%d], err[%+v]", diskID, err)
+		return nil, err
+	}
+	meta.CancelTime = time.Now().Unix()
+	meta.StoppedVuids, meta.InFlightVuids = nil, nil
+	for _, task := range tasks {
+		if task.State == proto.MigrateStateInited {
+			meta.StoppedVuids = append(meta.StoppedVuids, task.SourceVuid)
+			continue
+		}
+		meta.InFlightVuids = append(meta.InFlightVuids, task.SourceVuid)
+	}
+	if err = mgr.clusterMgrCli.AddMigratingDisk(ctx, meta); err != nil {
+		span.Errorf("update migrating disk failed: disk_id[%d], err[%+v]", diskID, err)
+		return nil, err
+	}
+
+	report = newDiskDropCancelReport(meta)
+	mgr.cancelReports[diskID] = report
+	span.Warnf("cancel disk drop: disk_id[%d], stopped tasks[%d], in flight tasks[%d]",
+		diskID, len(meta.StoppedVuids), len(meta.InFlightVuids))
+	return report.Copy(), nil
+}
+
+// CancelDropReport returns the report of cancelled disk drop
+func (mgr *DiskDropMgr) CancelDropReport(ctx context.Context, diskID proto.DiskID) (report *api.DiskDropCancelReport, err error) {
+	mgr.cancelLock.RLock()
+	r, ok := mgr.cancelReports[diskID]
+	if ok {
+		report = r.Copy()
+	}
+	mgr.cancelLock.RUnlock()
+	if !ok {
+		return nil, errcode.ErrNotDroppingDisk
+	}
+	if report.Status == api.DiskDropCancelled {
+		return report, nil
+	}
+
+	vunits, err := mgr.clusterMgrCli.ListDiskVolumeUnits(ctx, diskID)
+	if err != nil {
+		return nil, err
+	}
+	report.SetRemainCnt(len(vunits))
+	return report, nil
+}
+
+func (mgr *DiskDropMgr) isCancellingDisk(diskID proto.DiskID) bool {
+	mgr.cancelLock.RLock()
+	defer mgr.cancelLock.RUnlock()
+	report, ok := mgr.cancelReports[diskID]
+	return ok && report.Status == api.DiskDropCancelling
+}
+
+// checkCancelledAndRestore restores the cancelled disk after the running tasks are done
+func (mgr *DiskDropMgr) checkCancelledAndRestore(ctx context.Context, diskID proto.DiskID) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	tasks, err := mgr.IMigrator.ListAllTaskByDiskID(ctx, diskID)
+	if err != nil {
+		span.Errorf("find all tasks failed: disk_id[%d], err[%+v]", diskID, err)
+		return
+	}
+	if len(tasks) != 0 {
+		span.Infof("cancelled disk has tasks not done: disk_id[%d], tasks len[%d]", diskID, len(tasks))
+		return
+	}
+	vunits, err := mgr.clusterMgrCli.ListDiskVolumeUnits(ctx, diskID)
+	if err != nil {
+		span.Errorf("list disk volume units failed: disk_id[%d], err[%+v]", diskID, err)
+		return
+	}
+	if err = mgr.clusterMgrCli.CancelDiskDrop(ctx, diskID); err != nil {
+		span.Errorf("cancel disk drop failed: disk_id[%d], err[%+v]", diskID, err)
+		return
+	}
+
+	base.InsistOn(ctx, "delete migrating disk fail", func() error {
+		return mgr.clusterMgrCli.DeleteMigratingDisk(ctx, proto.TaskTypeDiskDrop, diskID)
+	})
+	mgr.ClearDeletedTasks(diskID)
+	mgr.IMigrator.ResumeDiskTasks(diskID)
+	mgr.droppingDisks.delete(diskID)
+
+	mgr.cancelLock.Lock()
+	report := mgr.cancelReports[diskID]
+	report.Status = api.DiskDropCancelled
+	report.FinishTime = time.Now().Unix()
+	report.SetRemainCnt(len(vunits))
+	mgr.cancelLock.Unlock()
+	span.Warnf("disk drop cancelled and restored: disk_id[%d], migrated[%d], remain[%d]",
+		diskID, report.MigratedCnt, report.RemainCnt)
+}
+
+func newDiskDropCancelReport(meta *client.MigratingDiskMeta) *api.DiskDropCancelReport {
+	return &api.DiskDropCancelReport{
+		DiskID:        meta.Disk.DiskID,
+		Status:        api.DiskDropCancelling,
+		TotalCnt:      int(meta.Disk.UsedChunkCnt),
+		StoppedVuids:  meta.StoppedVuids,
+		InFlightVuids: meta.InFlightVuids,
+		CancelTime:    meta.CancelTime,
+	}
+}
+
 // checkAndClearJunkTasksLoop due to network timeout, the dropped disk may still have some junk migrate tasks in clustermgr,
 // and we need to clear those tasks later
 func (mgr *DiskDropMgr) checkAndClearJunkTasksLoop() {
@@ -60,13 +60,24 @@ func TestDiskDropLoad(t *testing.T) {
 	{
 		mgr := newDiskDroper(t)
 		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return(nil, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(nil, errMock)
+		err := mgr.Load()
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		mgr := newDiskDroper(t)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return(nil, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(
+			[]*client.DrainingHostMeta{{Host: testDisk1.Host, Disks: []*client.DiskInfoSimple{testDisk1}}}, nil)
 		mgr.IMigrator.(*MockMigrater).EXPECT().Load().Return(nil)
 		err := mgr.Load()
 		require.NoError(t, err)
+		require.Equal(t, 1, len(mgr.drainingHosts))
 	}
 	{
 		mgr := newDiskDroper(t)
 		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return([]*client.MigratingDiskMeta{{Disk: testDisk1}, {Disk: testDisk2}}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(nil, nil)
 		mgr.IMigrator.(*MockMigrater).EXPECT().Load().Return(errMock)
 		err := mgr.Load()
 		require.Equal(t, 2, mgr.droppingDisks.size())
@@ -76,6 +87,7 @@ func TestDiskDropLoad(t *testing.T) {
 		mgr := newDiskDroper(t)
 		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return(
 			[]*client.MigratingDiskMeta{{Disk: &client.DiskInfoSimple{DiskID: proto.DiskID(1)}}}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(nil, nil)
 		mgr.IMigrator.(*MockMigrater).EXPECT().Load().Return(errMock)
 		err := mgr.Load()
 		require.True(t, errors.Is(err, errMock))
@@ -84,6 +96,7 @@ func TestDiskDropLoad(t *testing.T) {
 		mgr := newDiskDroper(t)
 		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return(
 			[]*client.MigratingDiskMeta{{Disk: &client.DiskInfoSimple{DiskID: proto.DiskID(1)}}}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(nil, nil)
 		mgr.IMigrator.(*MockMigrater).EXPECT().Load().Return(nil)
 		err := mgr.Load()
 		require.NoError(t, err)
@@ -531,3 +544,201 @@ func TestDiskDropDiskProgress(t *testing.T) {
 		require.Equal(t, int(testDisk1.UsedChunkCnt)-3, stats.MigratedTasksCnt)
 	}
 }
+
+func TestDiskDropCancelDrop(t *testing.T) {
+	ctx := context.Background()
+	{
+		// not dropping disk
+		mgr := newDiskDroper(t)
+		_, err := mgr.CancelDrop(ctx, testDisk1.DiskID)
+		require.ErrorIs(t, err, errcode.ErrNotDroppingDisk)
+		_, err = mgr.CancelDropReport(ctx, testDisk1.DiskID)
+		require.ErrorIs(t, err, errcode.ErrNotDroppingDisk)
+	}
+	{
+		mgr := newDiskDroper(t)
+		mgr.droppingDisks.add(testDisk1.DiskID, testDisk1)
+		meta := &client.MigratingDiskMeta{Disk: testDisk1, TaskType: proto.TaskTypeDiskDrop}
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetMigratingDisk(any, any, any).Return(nil, errMock)
+		_, err := mgr.CancelDrop(ctx, testDisk1.DiskID)
+		require.ErrorIs(t, err, errMock)
+
+		// list tasks failed and resume
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetMigratingDisk(any, any, any).Return(meta, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().StopDiskTasks(any).Return()
+		mgr.IMigrator.(*MockMigrater).EXPECT().ResumeDiskTasks(any).Return()
+		mgr.IMigrator.(*MockMigrater).EXPECT().ListAllTaskByDiskID(any, any).Return(nil, errMock)
+		_, err = mgr.CancelDrop(ctx, testDisk1.DiskID)
+		require.ErrorIs(t, err, errMock)
+		require.False(t, mgr.isCancellingDisk(testDisk1.DiskID))
+
+		// cancel success
+		task1 := &proto.MigrateTask{State: proto.MigrateStateInited, SourceVuid: proto.Vuid(1)}
+		task2 := &proto.MigrateTask{State: proto.MigrateStatePrepared, SourceVuid: proto.Vuid(2)}
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetMigratingDisk(any, any, any).Return(meta, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().StopDiskTasks(any).Return()
+		mgr.IMigrator.(*MockMigrater).EXPECT().ListAllTaskByDiskID(any, any).Return([]*proto.MigrateTask{task1, task2}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddMigratingDisk(any, any).Return(nil)
+		report, err := mgr.CancelDrop(ctx, testDisk1.DiskID)
+		require.NoError(t, err)
+		require.Equal(t, api.DiskDropCancelling, report.Status)
+		require.Equal(t, []proto.Vuid{1}, report.StoppedVuids)
+		require.Equal(t, []proto.Vuid{2}, report.InFlightVuids)
+		require.NotZero(t, meta.CancelTime)
+		require.True(t, mgr.isCancellingDisk(testDisk1.DiskID))
+
+		// cancel again
+		report, err = mgr.CancelDrop(ctx, testDisk1.DiskID)
+		require.NoError(t, err)
+		require.Equal(t, api.DiskDropCancelling, report.Status)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDiskVolumeUnits(any, any).Return([]*client.VunitInfoSimple{{}, {}}, nil)
+		report, err = mgr.CancelDropReport(ctx, testDisk1.DiskID)
+		require.NoError(t, err)
+		require.Equal(t, 2, report.RemainCnt)
+		require.Equal(t, report.TotalCnt-2, report.MigratedCnt)
+
+		// wait running tasks
+		mgr.IMigrator.(*MockMigrater).EXPECT().ListAllTaskByDiskID(any, any).Return([]*proto.MigrateTask{task2}, nil)
+		mgr.checkDroppedAndClear()
+		require.Equal(t, 1, mgr.droppingDisks.size())
+
+		// cancel in clustermgr failed
+		mgr.IMigrator.(*MockMigrater).EXPECT().ListAllTaskByDiskID(any, any).Times(2).Return(nil, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDiskVolumeUnits(any, any).Times(2).Return([]*client.VunitInfoSimple{{}}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().CancelDiskDrop(any, any).Return(errMock)
+		mgr.checkDroppedAndClear()
+		require.Equal(t, 1, mgr.droppingDisks.size())
+
+		// restored
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().CancelDiskDrop(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigratingDisk(any, any, any).Return(nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().ClearDeletedTasks(any).Return()
+		mgr.IMigrator.(*MockMigrater).EXPECT().ResumeDiskTasks(any).Return()
+		mgr.checkDroppedAndClear()
+		require.Equal(t, 0, mgr.droppingDisks.size())
+		require.False(t, mgr.isCancellingDisk(testDisk1.DiskID))
+
+		report, err = mgr.CancelDropReport(ctx, testDisk1.DiskID)
+		require.NoError(t, err)
+		require.Equal(t, api.DiskDropCancelled, report.Status)
+		require.Equal(t, 1, report.RemainCnt)
+		require.NotZero(t, report.FinishTime)
+	}
+	{
+		// load cancelling disk
+		mgr := newDiskDroper(t)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListMigratingDisks(any, any).Return(
+			[]*client.MigratingDiskMeta{{Disk: testDisk1, CancelTime: time.Now().Unix()}, {Disk: testDisk2}}, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().StopDiskTasks(testDisk1.DiskID).Return()
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDrainingHosts(any).Return(nil, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().Load().Return(nil)
+		err := mgr.Load()
+		require.NoError(t, err)
+		require.True(t, mgr.isCancellingDisk(testDisk1.DiskID))
+		require.False(t, mgr.isCancellingDisk(testDisk2.DiskID))
+	}
+}
+
+func TestDiskDropDrainHost(t *testing.T) {
+	ctx := context.Background()
+	host := "127.0.0.1:8000"
+	newHostDisks := func() []*client.DiskInfoSimple {
+		return []*client.DiskInfoSimple{
+			{DiskID: 3, Host: host, Status: proto.DiskStatusNormal, UsedChunkCnt: 10},
+			{DiskID: 1, Host: host, Status: proto.DiskStatusNormal, UsedChunkCnt: 20},
+			{DiskID: 2, Host: host, Status: proto.DiskStatusNormal, UsedChunkCnt: 30},
+		}
+	}
+	{
+		mgr := newDiskDroper(t)
+		_, err := mgr.HostDrainReport(ctx, host)
+		require.ErrorIs(t, err, errcode.ErrNotDrainingHost)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListHostDisks(any, any).Return(nil, errMock)
+		_, err = mgr.DrainHost(ctx, host)
+		require.ErrorIs(t, err, errMock)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListHostDisks(any, any).Return(nil, nil)
+		_, err = mgr.DrainHost(ctx, host)
+		require.ErrorIs(t, err, errcode.ErrNoDisksInHost)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListHostDisks(any, any).Return(newHostDisks(), nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddDrainingHost(any, any).Return(errMock)
+		_, err = mgr.DrainHost(ctx, host)
+		require.ErrorIs(t, err, errMock)
+		require.Equal(t, 0, len(mgr.drainingHosts))
+	}
+	{
+		mgr := newDiskDroper(t)
+		mgr.cfg.DiskConcurrency = 2
+		mgr.cfg.HostDiskConcurrency = 1
+		getDisk := func(ctx context.Context, diskID proto.DiskID) (*client.DiskInfoSimple, error) {
+			for _, disk := range mgr.drainingHosts[host].Disks {
+				if disk.DiskID == diskID {
+					return &client.DiskInfoSimple{DiskID: diskID, Status: disk.Status}, nil
+				}
+			}
+			return nil, errMock
+		}
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetDiskInfo(any, any).AnyTimes().DoAndReturn(getDisk)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddDrainingHost(any, any).AnyTimes().Return(nil)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListHostDisks(any, any).Return(newHostDisks(), nil)
+		report, err := mgr.DrainHost(ctx, host)
+		require.NoError(t, err)
+		require.Equal(t, api.HostDraining, report.Status)
+		require.Equal(t, 60, report.TotalCnt)
+		require.Equal(t, int64(-1), report.EtaS)
+		require.Equal(t, proto.DiskID(1), report.Disks[0].DiskID)
+
+		// drain again returns the draining report
+		report, err = mgr.DrainHost(ctx, host)
+		require.NoError(t, err)
+		require.Equal(t, api.HostDraining, report.Status)
+
+		// drop disk failed
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DropDisk(any, proto.DiskID(1)).Return(errMock)
+		mgr.drainHosts()
+		require.Empty(t, mgr.drainingHosts[host].DropDisks)
+
+		// disks of the host are dropped one by one
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DropDisk(any, proto.DiskID(1)).Return(nil)
+		mgr.drainHosts()
+		mgr.drainHosts()
+		require.Equal(t, []proto.DiskID{1}, mgr.drainingHosts[host].DropDisks)
+
+		dropping := &client.DiskInfoSimple{DiskID: 1, UsedChunkCnt: 20}
+		mgr.droppingDisks.add(dropping.DiskID, dropping)
+		mgr.IMigrator.(*MockMigrater).EXPECT().ListAllTaskByDiskID(any, any).Return(make([]*proto.MigrateTask, 15), nil)
+		mgr.drainingHosts[host].StartTime -= 10
+		report, err = mgr.HostDrainReport(ctx, host)
+		require.NoError(t, err)
+		require.Equal(t, api.HostDrainDiskDropping, report.Disks[0].Status)
+		require.Equal(t, 5, report.MigratedCnt)
+		require.Equal(t, api.HostDrainDiskPending, report.Disks[1].Status)
+		require.Less(t, int64(0), report.EtaS)
+
+		mgr.drainingHosts[host].Disks[0].Status = proto.DiskStatusDropped
+		mgr.droppingDisks.delete(dropping.DiskID)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DropDisk(any, proto.DiskID(2)).Return(nil)
+		mgr.drainHosts()
+		require.Equal(t, []proto.DiskID{1, 2}, mgr.drainingHosts[host].DropDisks)
+
+		// the broken disk is repaired by disk repair, and the host is drained after all disks are empty
+		mgr.drainingHosts[host].Disks[1].Status = proto.DiskStatusDropped
+		mgr.drainingHosts[host].Disks[2].Status = proto.DiskStatusBroken
+		mgr.drainHosts()
+		require.Zero(t, mgr.drainingHosts[host].FinishTime)
+
+		mgr.drainingHosts[host].Disks[2].Status = proto.DiskStatusRepaired
+		mgr.drainHosts()
+		report, err = mgr.HostDrainReport(ctx, host)
+		require.NoError(t, err)
+		require.Equal(t, api.HostDrained, report.Status)
+		require.True(t, report.SafeToRemove)
+		require.Equal(t, report.TotalCnt, report.MigratedCnt)
+		require.Equal(t, int64(0), report.EtaS)
+	}
+}
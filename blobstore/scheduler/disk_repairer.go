@@ -163,6 +163,7 @@ func (mgr *DiskRepairMgr) Run() {
 	go mgr.finishTaskLoop()
 	go mgr.checkRepairedAndClearLoop()
 	go mgr.checkAndClearJunkTasksLoop()
+	go checkZombieTaskLoop(mgr.Closer, mgr.taskSwitch, proto.TaskTypeDiskRepair, mgr.workQueue, mgr.cfg.ZombieTaskCheckCycles)
 }
 
 func (mgr *DiskRepairMgr) Enabled() bool {
@@ -408,6 +409,12 @@ func (mgr *DiskRepairMgr) popTaskAndPrepare() error {
 		}
 	}()
 
+	if base.VolumeFreezeInst().TaskFrozen(t) {
+		span.Infof("the volume is frozen and retry later: task_id[%s]", t.TaskID)
+		err = errcode.ErrVolumeFrozen
+		return err
+	}
+
 	err = mgr.prepareTask(t)
 	if err != nil {
 		span.Errorf("prepare task failed: task_id[%s], err[%+v]", t.TaskID, err)
@@ -476,6 +483,7 @@ func (mgr *DiskRepairMgr) finishTaskInAdvance(ctx context.Context, task *proto.M
 	}
 
 	mgr.finishTaskCounter.Add()
+	mgr.taskStatsMgr.FinishTask()
 	mgr.prepareQueue.RemoveTask(task.TaskID)
 	mgr.deletedTasks.add(task.SourceDiskID, task.TaskID)
 	base.VolTaskLockerInst().Unlock(ctx, task.Vid())
@@ -549,6 +557,7 @@ func (mgr *DiskRepairMgr) finishTask(ctx context.Context, task *proto.MigrateTas
 	}
 
 	mgr.finishTaskCounter.Add()
+	mgr.taskStatsMgr.FinishTask()
 	// 1.remove task in memory
 	// 2.release lock of volume task
 	mgr.finishQueue.RemoveTask(task.TaskID)
@@ -733,7 +742,7 @@ func (mgr *DiskRepairMgr) AcquireTask(ctx context.Context, idc string) (task pro
 		return task, proto.ErrTaskPaused
 	}
 
-	_, repairTask, _ := mgr.workQueue.Acquire(idc)
+	_, repairTask, _ := mgr.workQueue.AcquireAccepted(idc, acceptNotHeld)
 	if repairTask != nil {
 		task = *repairTask.(*proto.MigrateTask)
 		return task, nil
@@ -812,6 +821,10 @@ func (mgr *DiskRepairMgr) RenewalTask(ctx context.Context, idc, taskID string) e
 		return proto.ErrTaskPaused
 	}
 
+	if err := releaseHeld(ctx, mgr.workQueue, idc, taskID); err != nil {
+		return err
+	}
+
 	span := trace.SpanFromContextSafe(ctx)
 	err := mgr.workQueue.Renewal(idc, taskID)
 	if err != nil {
@@ -870,6 +883,7 @@ func (mgr *DiskRepairMgr) Stats() api.MigrateTasksStat {
 			DataAmountByte: base.DataMountFormat(increaseDataSize),
 			ShardCnt:       fmt.Sprint(increaseShardCnt),
 		},
+		Totals: mgr.taskStatsMgr.Totals(),
 	}
 }
 
@@ -0,0 +1,251 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+)
+
+// DrainHost drains all the disks of the host. The disks are added into the dropping list
+// of clustermgr in sequence, at most HostDiskConcurrency disks of one host and DiskConcurrency
+// disks of all draining hosts at the same time, and the host is safe to remove after all of
+// its disks are dropped.
+func (mgr *DiskDropMgr) DrainHost(ctx context.Context, host string) (report *api.HostDrainReport, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	mgr.drainLock.Lock()
+	defer mgr.drainLock.Unlock()
+	if meta, ok := mgr.drainingHosts[host]; ok && meta.FinishTime == 0 {
+		return mgr.hostDrainReport(ctx, meta), nil
+	}
+
+	disks, err := mgr.clusterMgrCli.ListHostDisks(ctx, host)
+	if err != nil {
+		span.Errorf("list host disks failed: host[%s], err[%+v]", host, err)
+		return nil, err
+	}
+	if len(disks) == 0 {
+		return nil, errcode.ErrNoDisksInHost
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].DiskID < disks[j].DiskID })
+
+	meta := &client.DrainingHostMeta{
+		Host:      host,
+		Disks:     disks,
+		StartTime: time.Now().Unix(),
+	}
+	if err = mgr.clusterMgrCli.AddDrainingHost(ctx, meta); err != nil {
+		span.Errorf("add draining host failed: host[%s], err[%+v]", host, err)
+		return nil, err
+	}
+	mgr.drainingHosts[host] = meta
+	span.Warnf("start drain host: host[%s], disks len[%d]", host, len(disks))
+	return mgr.hostDrainReport(ctx, meta), nil
+}
+
+// HostDrainReport returns the progress of the host drain
+func (mgr *DiskDropMgr) HostDrainReport(ctx context.Context, host string) (report *api.HostDrainReport, err error) {
+	mgr.drainLock.RLock()
+	defer mgr.drainLock.RUnlock()
+	meta, ok := mgr.drainingHosts[host]
+	if !ok {
+		return nil, errcode.ErrNotDrainingHost
+	}
+	return mgr.hostDrainReport(ctx, meta), nil
+}
+
+func (mgr *DiskDropMgr) hostDrainReport(ctx context.Context, meta *client.DrainingHostMeta) *api.HostDrainReport {
+	span := trace.SpanFromContextSafe(ctx)
+
+	report := &api.HostDrainReport{
+		Host:       meta.Host,
+		Status:     api.HostDraining,
+		EtaS:       -1,
+		StartTime:  meta.StartTime,
+		FinishTime: meta.FinishTime,
+		Disks:      make([]api.HostDrainDisk, 0, len(meta.Disks)),
+	}
+	for _, disk := range meta.Disks {
+		stat := api.HostDrainDisk{
+			DiskID:   disk.DiskID,
+			Status:   api.HostDrainDiskPending,
+			TotalCnt: int(disk.UsedChunkCnt),
+		}
+		switch {
+		case isDrainedDisk(disk):
+			stat.Status = api.HostDrainDiskDropped
+			stat.MigratedCnt = stat.TotalCnt
+		case meta.IsDropDisk(disk.DiskID):
+			stat.Status = api.HostDrainDiskDropping
+			if dropping, ok := mgr.droppingDisks.get(disk.DiskID); ok {
+				stat.TotalCnt = int(dropping.UsedChunkCnt)
+				remainTasks, err := mgr.IMigrator.ListAllTaskByDiskID(ctx, disk.DiskID)
+				if err != nil {
+					span.Errorf("find remain task failed: disk_id[%d], err[%+v]", disk.DiskID, err)
+					break
+				}
+				if stat.MigratedCnt = stat.TotalCnt - len(remainTasks); stat.MigratedCnt < 0 {
+					stat.MigratedCnt = 0
+				}
+			}
+		}
+		report.TotalCnt += stat.TotalCnt
+		report.MigratedCnt += stat.MigratedCnt
+		report.Disks = append(report.Disks, stat)
+	}
+
+	if meta.FinishTime != 0 {
+		report.Status = api.HostDrained
+		report.SafeToRemove = true
+		report.EtaS = 0
+		return report
+	}
+	// estimate the remaining time by the migrated rate since the drain started
+	remain := report.TotalCnt - report.MigratedCnt
+	elapsed := time.Now().Unix() - meta.StartTime
+	switch {
+	case remain <= 0:
+		report.EtaS = 0
+	case report.MigratedCnt > 0 && elapsed > 0:
+		report.EtaS = elapsed * int64(remain) / int64(report.MigratedCnt)
+	}
+	return report
+}
+
+// isDrainedDisk returns true if the disk has no volume units any more
+func isDrainedDisk(disk *client.DiskInfoSimple) bool {
+	return disk.IsDropped() || disk.IsRepaired()
+}
+
+func (mgr *DiskDropMgr) drainHostLoop() {
+	t := time.NewTicker(time.Duration(mgr.cfg.CollectTaskIntervalS) * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			mgr.IMigrator.WaitEnable()
+			mgr.drainHosts()
+		case <-mgr.IMigrator.Done():
+			return
+		}
+	}
+}
+
+// drainHosts refreshes the disks of the draining hosts, drops the next disks within the limits,
+// and marks the hosts drained whose disks are all dropped
+func (mgr *DiskDropMgr) drainHosts() {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "disk_drop.drainHosts")
+	defer span.Finish()
+
+	mgr.drainLock.Lock()
+	defer mgr.drainLock.Unlock()
+
+	hosts := make([]*client.DrainingHostMeta, 0, len(mgr.drainingHosts))
+	for _, meta := range mgr.drainingHosts {
+		if meta.FinishTime != 0 {
+			continue
+		}
+		if err := mgr.refreshDrainingHost(ctx, meta); err != nil {
+			span.Errorf("refresh draining host failed: host[%s], err[%+v]", meta.Host, err)
+			return
+		}
+		hosts = append(hosts, meta)
+	}
+	// the hosts drained earlier are dropped first
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].StartTime < hosts[j].StartTime })
+
+	inflight := 0
+	for _, meta := range hosts {
+		inflight += len(droppingDisksOfHost(meta))
+	}
+	for _, meta := range hosts {
+		changed := false
+		hostInflight := len(droppingDisksOfHost(meta))
+		for _, disk := range meta.Disks {
+			if inflight >= mgr.cfg.DiskConcurrency || hostInflight >= mgr.cfg.HostDiskConcurrency {
+				break
+			}
+			if isDrainedDisk(disk) || meta.IsDropDisk(disk.DiskID) || !disk.IsHealth() {
+				continue
+			}
+			if err := mgr.clusterMgrCli.DropDisk(ctx, disk.DiskID); err != nil {
+				span.Errorf("drop disk of draining host failed: host[%s], disk_id[%d], err[%+v]", meta.Host, disk.DiskID, err)
+				break
+			}
+			span.Infof("drop disk of draining host: host[%s], disk_id[%d]", meta.Host, disk.DiskID)
+			meta.DropDisks = append(meta.DropDisks, disk.DiskID)
+			inflight++
+			hostInflight++
+			changed = true
+		}
+
+		if isDrainedHost(meta) {
+			meta.FinishTime = time.Now().Unix()
+			changed = true
+			span.Warnf("host is drained and safe to remove: host[%s], disks len[%d]", meta.Host, len(meta.Disks))
+		}
+		if !changed {
+			continue
+		}
+		if err := mgr.clusterMgrCli.AddDrainingHost(ctx, meta); err != nil {
+			span.Errorf("update draining host failed: host[%s], err[%+v]", meta.Host, err)
+		}
+	}
+}
+
+// refreshDrainingHost refreshes the status of the disks, the used chunk count is kept
+// as it was when the drain started
+func (mgr *DiskDropMgr) refreshDrainingHost(ctx context.Context, meta *client.DrainingHostMeta) error {
+	for _, disk := range meta.Disks {
+		if isDrainedDisk(disk) {
+			continue
+		}
+		info, err := mgr.clusterMgrCli.GetDiskInfo(ctx, disk.DiskID)
+		if err != nil {
+			return err
+		}
+		disk.Status = info.Status
+		disk.Readonly = info.Readonly
+	}
+	return nil
+}
+
+func isDrainedHost(meta *client.DrainingHostMeta) bool {
+	for _, disk := range meta.Disks {
+		if !isDrainedDisk(disk) {
+			return false
+		}
+	}
+	return true
+}
+
+// droppingDisksOfHost returns the disks added into the dropping list but not dropped yet
+func droppingDisksOfHost(meta *client.DrainingHostMeta) (disks []proto.DiskID) {
+	for _, disk := range meta.Disks {
+		if !isDrainedDisk(disk) && meta.IsDropDisk(disk.DiskID) {
+			disks = append(disks, disk.DiskID)
+		}
+	}
+	return
+}
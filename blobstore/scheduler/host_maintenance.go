@@ -0,0 +1,182 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const (
+	// the maintenance hosts written into clustermgr kv by others are applied in the interval
+	hostMaintenanceRefreshInterval = 10 * time.Second
+	maxHostMaintenanceDurationS    = int64(24 * 3600)
+)
+
+// HostMaintenanceMgr manages the hosts in maintenance window such as rolling upgrade.
+// The migrate tasks with the source or destination on these hosts are not assigned to
+// workers, and the running ones are released at the next renewal, so that the tasks
+// do not fail due to the restart of the hosts and are resumed after the window ends.
+type HostMaintenanceMgr struct {
+	closer.Closer
+
+	clusterMgrCli client.ClusterMgrAPI
+
+	mu    sync.Mutex
+	hosts map[string]*client.MaintenanceHostMeta
+}
+
+// NewHostMaintenanceMgr returns host maintenance manager
+func NewHostMaintenanceMgr(clusterMgrCli client.ClusterMgrAPI) *HostMaintenanceMgr {
+	return &HostMaintenanceMgr{
+		Closer:        closer.New(),
+		clusterMgrCli: clusterMgrCli,
+		hosts:         make(map[string]*client.MaintenanceHostMeta),
+	}
+}
+
+// Load loads maintenance hosts from clustermgr
+func (mgr *HostMaintenanceMgr) Load() error {
+	return mgr.refresh(context.Background())
+}
+
+// Run refreshes maintenance hosts in background
+func (mgr *HostMaintenanceMgr) Run() {
+	go func() {
+		t := time.NewTicker(hostMaintenanceRefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				span, ctx := trace.StartSpanFromContext(context.Background(), "refreshMaintenanceHosts")
+				if err := mgr.refresh(ctx); err != nil {
+					span.Errorf("refresh maintenance hosts failed: err[%+v]", err)
+				}
+			case <-mgr.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (mgr *HostMaintenanceMgr) refresh(ctx context.Context) error {
+	span := trace.SpanFromContextSafe(ctx)
+
+	metas, err := mgr.clusterMgrCli.ListMaintenanceHosts(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	now := time.Now().Unix()
+	hosts := make(map[string]*client.MaintenanceHostMeta, len(metas))
+	for _, meta := range metas {
+		if meta.Expired(now) {
+			span.Infof("maintenance window ended: host[%s]", meta.Host)
+			if err := mgr.clusterMgrCli.DeleteMaintenanceHost(ctx, meta.Host); err != nil {
+				span.Warnf("delete maintenance host failed: host[%s], err[%+v]", meta.Host, err)
+			}
+			continue
+		}
+		hosts[meta.Host] = meta
+	}
+	mgr.hosts = hosts
+	mgr.apply()
+	return nil
+}
+
+// apply shares the maintenance hosts with all migrate managers, must be called with lock
+func (mgr *HostMaintenanceMgr) apply() {
+	hosts := make(map[string]int64, len(mgr.hosts))
+	for host, meta := range mgr.hosts {
+		hosts[host] = meta.EndTime
+	}
+	base.HostMaintenanceInst().Update(hosts)
+}
+
+// SetHost starts or extends the maintenance window of the host
+func (mgr *HostMaintenanceMgr) SetHost(ctx context.Context, host string, durationS int64) (*api.HostMaintenance, error) {
+	span := trace.SpanFromContextSafe(ctx)
+	if host == "" || durationS <= 0 || durationS > maxHostMaintenanceDurationS {
+		return nil, errcode.ErrIllegalArguments
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	now := time.Now().Unix()
+	meta := &client.MaintenanceHostMeta{Host: host, StartTime: now, EndTime: now + durationS}
+	if old, ok := mgr.hosts[host]; ok && !old.Expired(now) {
+		meta.StartTime = old.StartTime
+	}
+	if err := mgr.clusterMgrCli.SetMaintenanceHost(ctx, meta); err != nil {
+		span.Errorf("set maintenance host failed: host[%s], err[%+v]", host, err)
+		return nil, err
+	}
+	mgr.hosts[host] = meta
+	mgr.apply()
+	span.Warnf("host in maintenance: host[%s], end_time[%d]", host, meta.EndTime)
+	return toHostMaintenance(meta), nil
+}
+
+// CancelHost ends the maintenance window of the host
+func (mgr *HostMaintenanceMgr) CancelHost(ctx context.Context, host string) error {
+	span := trace.SpanFromContextSafe(ctx)
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	if _, ok := mgr.hosts[host]; !ok {
+		return errcode.ErrNotMaintenanceHost
+	}
+	if err := mgr.clusterMgrCli.DeleteMaintenanceHost(ctx, host); err != nil {
+		span.Errorf("delete maintenance host failed: host[%s], err[%+v]", host, err)
+		return err
+	}
+	delete(mgr.hosts, host)
+	mgr.apply()
+	span.Warnf("host maintenance canceled: host[%s]", host)
+	return nil
+}
+
+// ListHosts returns the hosts in maintenance window
+func (mgr *HostMaintenanceMgr) ListHosts() *api.HostMaintenanceList {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	now := time.Now().Unix()
+	ret := &api.HostMaintenanceList{Hosts: make([]*api.HostMaintenance, 0, len(mgr.hosts))}
+	for _, meta := range mgr.hosts {
+		if !meta.Expired(now) {
+			ret.Hosts = append(ret.Hosts, toHostMaintenance(meta))
+		}
+	}
+	sort.Slice(ret.Hosts, func(i, j int) bool { return ret.Hosts[i].Host < ret.Hosts[j].Host })
+	return ret
+}
+
+func toHostMaintenance(meta *client.MaintenanceHostMeta) *api.HostMaintenance {
+	return &api.HostMaintenance{Host: meta.Host, StartTime: meta.StartTime, EndTime: meta.EndTime}
+}
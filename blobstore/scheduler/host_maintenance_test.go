@@ -0,0 +1,97 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+)
+
+func TestHostMaintenanceMgr(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+	mgr := NewHostMaintenanceMgr(clusterMgr)
+	defer mgr.Close()
+	defer base.HostMaintenanceInst().Update(nil)
+
+	now := time.Now().Unix()
+	host1, host2 := "http://127.0.0.1:8889", "http://127.0.0.2:8889"
+	{
+		// the expired hosts are removed when loading
+		clusterMgr.EXPECT().ListMaintenanceHosts(any).Return(nil, errMock)
+		require.True(t, errors.Is(mgr.Load(), errMock))
+
+		clusterMgr.EXPECT().ListMaintenanceHosts(any).Return([]*client.MaintenanceHostMeta{
+			{Host: host1, StartTime: now - 10, EndTime: now + 60},
+			{Host: host2, StartTime: now - 60, EndTime: now - 1},
+		}, nil)
+		clusterMgr.EXPECT().DeleteMaintenanceHost(any, host2).Return(errMock)
+		require.NoError(t, mgr.Load())
+		require.True(t, base.HostMaintenanceInst().InMaintenance(host1))
+		require.False(t, base.HostMaintenanceInst().InMaintenance(host2))
+		require.Len(t, mgr.ListHosts().Hosts, 1)
+	}
+	{
+		_, err := mgr.SetHost(ctx, host2, 0)
+		require.ErrorIs(t, err, errcode.ErrIllegalArguments)
+		_, err = mgr.SetHost(ctx, host2, maxHostMaintenanceDurationS+1)
+		require.ErrorIs(t, err, errcode.ErrIllegalArguments)
+
+		clusterMgr.EXPECT().SetMaintenanceHost(any, any).Return(errMock)
+		_, err = mgr.SetHost(ctx, host2, 60)
+		require.True(t, errors.Is(err, errMock))
+		require.False(t, base.HostMaintenanceInst().InMaintenance(host2))
+
+		clusterMgr.EXPECT().SetMaintenanceHost(any, any).Return(nil)
+		ret, err := mgr.SetHost(ctx, host2, 60)
+		require.NoError(t, err)
+		require.Equal(t, host2, ret.Host)
+		require.True(t, base.HostMaintenanceInst().InMaintenance(host2))
+
+		// extend the window of the host in maintenance
+		clusterMgr.EXPECT().SetMaintenanceHost(any, any).Return(nil)
+		ret, err = mgr.SetHost(ctx, host1, 120)
+		require.NoError(t, err)
+		require.Equal(t, now-10, ret.StartTime)
+		require.LessOrEqual(t, now+120, ret.EndTime)
+
+		hosts := mgr.ListHosts().Hosts
+		require.Len(t, hosts, 2)
+		require.Equal(t, host1, hosts[0].Host)
+		require.Equal(t, host2, hosts[1].Host)
+	}
+	{
+		require.ErrorIs(t, mgr.CancelHost(ctx, "http://127.0.0.3:8889"), errcode.ErrNotMaintenanceHost)
+
+		clusterMgr.EXPECT().DeleteMaintenanceHost(any, host1).Return(errMock)
+		require.True(t, errors.Is(mgr.CancelHost(ctx, host1), errMock))
+		require.True(t, base.HostMaintenanceInst().InMaintenance(host1))
+
+		clusterMgr.EXPECT().DeleteMaintenanceHost(any, host1).Return(nil)
+		require.NoError(t, mgr.CancelHost(ctx, host1))
+		require.False(t, base.HostMaintenanceInst().InMaintenance(host1))
+		require.True(t, base.HostMaintenanceInst().InMaintenance(host2))
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/recordlog"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/taskswitch"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/util/log"
+)
+
+// diskMigratingChecker checks whether the disk is migrating by the tasks of other manager
+type diskMigratingChecker interface {
+	IsMigratingDisk(diskID proto.DiskID) bool
+}
+
+// IntraNodeBalanceMgrConfig intra node balance task manager config
+type IntraNodeBalanceMgrConfig struct {
+	// the disk with the least free chunks is balanced when the free chunks
+	// of the disks on the same host differ more than this
+	MinFreeChunkCntDiff int64 `json:"min_free_chunk_cnt_diff"`
+	MigrateConfig
+}
+
+// IntraNodeBalanceMgr balances the chunks between the disks of one blobnode,
+// the chunk is migrated to the other disk of the same host which is much
+// cheaper than migrating across the nodes.
+type IntraNodeBalanceMgr struct {
+	IMigrator
+
+	clusterTopology IClusterTopology
+	clusterMgrCli   client.ClusterMgrAPI
+	// the disks migrating by these managers are not balanced
+	conflictMgrs []diskMigratingChecker
+
+	cfg *IntraNodeBalanceMgrConfig
+}
+
+// NewIntraNodeBalanceMgr returns intra node balance manager
+func NewIntraNodeBalanceMgr(clusterMgrCli client.ClusterMgrAPI, volumeUpdater client.IVolumeUpdater,
+	taskSwitch taskswitch.ISwitcher, clusterTopology IClusterTopology, taskLogger recordlog.Encoder,
+	conf *IntraNodeBalanceMgrConfig, conflictMgrs ...diskMigratingChecker) *IntraNodeBalanceMgr {
+	mgr := &IntraNodeBalanceMgr{
+		clusterTopology: clusterTopology,
+		clusterMgrCli:   clusterMgrCli,
+		conflictMgrs:    conflictMgrs,
+		cfg:             conf,
+	}
+	mgr.IMigrator = NewMigrateMgr(clusterMgrCli, volumeUpdater, taskSwitch, taskLogger,
+		&conf.MigrateConfig, proto.TaskTypeIntraNodeBalance)
+	mgr.IMigrator.SetLockFailHandleFunc(mgr.IMigrator.FinishTaskInAdvanceWhenLockFail)
+	return mgr
+}
+
+// Run run intra node balance task manager
+func (mgr *IntraNodeBalanceMgr) Run() {
+	go mgr.collectTaskLoop()
+	mgr.IMigrator.Run()
+	go mgr.checkAndClearJunkTasksLoop()
+}
+
+func (mgr *IntraNodeBalanceMgr) collectTaskLoop() {
+	t := time.NewTicker(time.Duration(mgr.cfg.CollectTaskIntervalS) * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			mgr.IMigrator.WaitEnable()
+			err := mgr.collectionTask()
+			if err == ErrTooManyBalancingTasks || err == ErrNoBalanceVunit {
+				log.Debugf("no task to collect and sleep: sleep second[%d], err[%+v]", collectBalanceTaskPauseS, err)
+				time.Sleep(time.Duration(collectBalanceTaskPauseS) * time.Second)
+			}
+		case <-mgr.IMigrator.Done():
+			return
+		}
+	}
+}
+
+func (mgr *IntraNodeBalanceMgr) collectionTask() (err error) {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "intra_node_balance_collectionTask")
+	defer span.Finish()
+
+	needBalanceDiskCnt := mgr.cfg.DiskConcurrency - mgr.IMigrator.GetMigratingDiskNum()
+	if needBalanceDiskCnt <= 0 {
+		span.Warnf("the number of balancing disk is greater than config: current[%d], conf[%d]",
+			mgr.IMigrator.GetMigratingDiskNum(), mgr.cfg.DiskConcurrency)
+		return ErrTooManyBalancingTasks
+	}
+
+	disks := mgr.selectDisks(mgr.cfg.MinFreeChunkCntDiff)
+	span.Debugf("select intra node balance disks: len[%d]", len(disks))
+
+	balanceDiskCnt := 0
+	for _, disk := range disks {
+		err = mgr.genOneBalanceTask(ctx, disk)
+		if err != nil {
+			continue
+		}
+
+		balanceDiskCnt++
+		if balanceDiskCnt >= needBalanceDiskCnt {
+			break
+		}
+	}
+	if balanceDiskCnt == 0 {
+		span.Infof("select disks has no balance volume unit on disk: len[%d]", len(disks))
+		return ErrNoBalanceVunit
+	}
+
+	return nil
+}
+
+// selectDisks returns the disk with the least free chunks of each host whose free chunks
+// differ from the disk with the most free chunks more than minFreeChunkCntDiff,
+// the hosts with balancing disk are skipped so that one host balances one disk at a time.
+func (mgr *IntraNodeBalanceMgr) selectDisks(minFreeChunkCntDiff int64) []*client.DiskInfoSimple {
+	type hostDisks struct {
+		min, max  *client.DiskInfoSimple
+		balancing bool
+	}
+	hosts := make(map[string]*hostDisks)
+	for idcName := range mgr.clusterTopology.GetIDCs() {
+		for _, disk := range mgr.clusterTopology.GetIDCDisks(idcName) {
+			h, ok := hosts[disk.Host]
+			if !ok {
+				h = &hostDisks{}
+				hosts[disk.Host] = h
+			}
+			if mgr.IMigrator.IsMigratingDisk(disk.DiskID) {
+				h.balancing = true
+			}
+			if !disk.IsHealth() || disk.Readonly {
+				continue
+			}
+			if h.min == nil || disk.FreeChunkCnt < h.min.FreeChunkCnt {
+				h.min = disk
+			}
+			if h.max == nil || disk.FreeChunkCnt > h.max.FreeChunkCnt {
+				h.max = disk
+			}
+		}
+	}
+
+	var selected []*client.DiskInfoSimple
+	for _, h := range hosts {
+		if h.balancing || h.min == nil || h.max.FreeChunkCnt-h.min.FreeChunkCnt < minFreeChunkCntDiff {
+			continue
+		}
+		if mgr.isConflictDisk(h.min.DiskID) {
+			continue
+		}
+		selected = append(selected, h.min)
+	}
+	sortDiskByFreeChunkCnt(selected)
+	return selected
+}
+
+func (mgr *IntraNodeBalanceMgr) isConflictDisk(diskID proto.DiskID) bool {
+	return isMigratingByOthers(mgr.conflictMgrs, diskID)
+}
+
+func isMigratingByOthers(conflictMgrs []diskMigratingChecker, diskID proto.DiskID) bool {
+	for _, conflict := range conflictMgrs {
+		if conflict.IsMigratingDisk(diskID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (mgr *IntraNodeBalanceMgr) genOneBalanceTask(ctx context.Context, diskInfo *client.DiskInfoSimple) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	vuid, err := mgr.selectBalanceVunit(ctx, diskInfo.DiskID)
+	if err != nil {
+		span.Errorf("generate task source failed: disk_id[%d], err[%+v]", diskInfo.DiskID, err)
+		return
+	}
+
+	span.Debugf("select intra node balance volume unit; vuid[%d], volume_id[%v]", vuid, vuid.Vid())
+	task := &proto.MigrateTask{
+		TaskID:       client.GenMigrateTaskID(proto.TaskTypeIntraNodeBalance, diskInfo.DiskID, vuid.Vid()),
+		TaskType:     proto.TaskTypeIntraNodeBalance,
+		State:        proto.MigrateStateInited,
+		SourceIDC:    diskInfo.Idc,
+		SourceDiskID: diskInfo.DiskID,
+		SourceVuid:   vuid,
+	}
+	mgr.IMigrator.AddTask(ctx, task)
+	return
+}
+
+func (mgr *IntraNodeBalanceMgr) selectBalanceVunit(ctx context.Context, diskID proto.DiskID) (vuid proto.Vuid, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	vunits, err := mgr.clusterMgrCli.ListDiskVolumeUnits(ctx, diskID)
+	if err != nil {
+		return
+	}
+
+	sort.Slice(vunits, func(i, j int) bool {
+		return vunits[i].Used < vunits[j].Used
+	})
+
+	for i := range vunits {
+		volInfo, err := mgr.clusterMgrCli.GetVolumeInfo(ctx, vunits[i].Vuid.Vid())
+		if err != nil {
+			span.Errorf("get volume info failed: vid[%d], err[%+v]", vunits[i].Vuid.Vid(), err)
+			continue
+		}
+		if volInfo.IsIdle() {
+			return vunits[i].Vuid, nil
+		}
+	}
+	return vuid, ErrNoBalanceVunit
+}
+
+// checkAndClearJunkTasksLoop clears the junk migrate tasks in clustermgr left by network timeout
+func (mgr *IntraNodeBalanceMgr) checkAndClearJunkTasksLoop() {
+	t := time.NewTicker(clearJunkMigrationTaskInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			mgr.checkAndClearJunkTasks()
+		case <-mgr.IMigrator.Done():
+			return
+		}
+	}
+}
+
+func (mgr *IntraNodeBalanceMgr) checkAndClearJunkTasks() {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "intra_node_balance.clearJunkTasks")
+
+	for _, task := range mgr.DeletedTasks() {
+		if time.Since(task.DeletedTime) < junkMigrationTaskProtectionWindow {
+			continue
+		}
+		_, err := mgr.clusterMgrCli.GetMigrateTask(ctx, proto.TaskTypeIntraNodeBalance, task.TaskID)
+		if err != nil {
+			if rpc.DetectStatusCode(err) != http.StatusNotFound {
+				span.Errorf("get intra node balance task from clustermanager failed: err[%+v]", err)
+				continue
+			}
+		} else {
+			span.Warnf("delete junk task: task_id[%s]", task.TaskID)
+			base.InsistOn(ctx, "delete junk task", func() error {
+				return mgr.clusterMgrCli.DeleteMigrateTask(ctx, task.TaskID)
+			})
+		}
+
+		mgr.ClearDeletedTaskByID(task.DiskID, task.TaskID)
+	}
+}
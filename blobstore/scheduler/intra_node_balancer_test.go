@@ -0,0 +1,205 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/testing/mocks"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+func newIntraNodeBalancer(t *testing.T, conflictMgrs ...diskMigratingChecker) *IntraNodeBalanceMgr {
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+	volumeUpdater := NewMockVolumeUpdater(ctr)
+	taskSwitch := mocks.NewMockSwitcher(ctr)
+	topologyMgr := NewMockClusterTopology(ctr)
+	taskLogger := mocks.NewMockRecordLogEncoder(ctr)
+	migrater := NewMockMigrater(ctr)
+	conf := &IntraNodeBalanceMgrConfig{}
+	c := closer.New()
+
+	migrater.EXPECT().Close().AnyTimes().DoAndReturn(c.Close)
+	migrater.EXPECT().Done().AnyTimes().Return(c.Done())
+	migrater.EXPECT().WaitEnable().AnyTimes().Return()
+	migrater.EXPECT().Enabled().AnyTimes().Return(true)
+
+	mgr := NewIntraNodeBalanceMgr(clusterMgr, volumeUpdater, taskSwitch, topologyMgr, taskLogger, conf, conflictMgrs...)
+	mgr.IMigrator = migrater
+	return mgr
+}
+
+func TestIntraNodeBalanceRun(t *testing.T) {
+	mgr := newIntraNodeBalancer(t)
+	defer mgr.Close()
+
+	mgr.IMigrator.(*MockMigrater).EXPECT().Run().Return()
+	mgr.IMigrator.(*MockMigrater).EXPECT().GetMigratingDiskNum().AnyTimes().Return(1)
+	mgr.cfg.CollectTaskIntervalS = 1
+	mgr.cfg.CheckTaskIntervalS = 1
+	require.True(t, mgr.Enabled())
+	mgr.Run()
+
+	time.Sleep(1 * time.Second)
+}
+
+func TestIntraNodeBalanceCollectionTask(t *testing.T) {
+	{
+		mgr := newIntraNodeBalancer(t)
+		mgr.IMigrator.(*MockMigrater).EXPECT().GetMigratingDiskNum().AnyTimes().Return(1)
+
+		err := mgr.collectionTask()
+		require.True(t, errors.Is(err, ErrTooManyBalancingTasks))
+		mgr.Close()
+	}
+	{
+		conflictMgr := NewMockMigrater(gomock.NewController(t))
+		mgr := newIntraNodeBalancer(t, conflictMgr)
+		mgr.cfg.DiskConcurrency = 3
+		mgr.cfg.MinFreeChunkCntDiff = 100
+		mgr.IMigrator.(*MockMigrater).EXPECT().GetMigratingDiskNum().AnyTimes().Return(0)
+
+		newDisk := func(diskID proto.DiskID, host string, freeChunkCnt int64, status proto.DiskStatus) *client.DiskInfoSimple {
+			return &client.DiskInfoSimple{
+				ClusterID:    1,
+				Idc:          "z0",
+				Rack:         "rack1",
+				Host:         host,
+				Status:       status,
+				DiskID:       diskID,
+				FreeChunkCnt: freeChunkCnt,
+				MaxChunkCnt:  700,
+			}
+		}
+		disks := []*client.DiskInfoSimple{
+			// the replaced disk 3 makes the disk 1 balanced
+			newDisk(1, "127.0.0.1:8000", 10, proto.DiskStatusNormal),
+			newDisk(2, "127.0.0.1:8000", 50, proto.DiskStatusNormal),
+			newDisk(3, "127.0.0.1:8000", 700, proto.DiskStatusNormal),
+			// the broken disk is not the destination
+			newDisk(4, "127.0.0.2:8000", 10, proto.DiskStatusNormal),
+			newDisk(5, "127.0.0.2:8000", 700, proto.DiskStatusBroken),
+			// the disk 7 is balancing and the host is skipped
+			newDisk(6, "127.0.0.3:8000", 10, proto.DiskStatusNormal),
+			newDisk(7, "127.0.0.3:8000", 300, proto.DiskStatusNormal),
+			newDisk(8, "127.0.0.3:8000", 700, proto.DiskStatusNormal),
+			// the disk 9 is migrating by other manager
+			newDisk(9, "127.0.0.4:8000", 10, proto.DiskStatusNormal),
+			newDisk(10, "127.0.0.4:8000", 700, proto.DiskStatusNormal),
+		}
+		clusterTopMgr := &ClusterTopologyMgr{
+			taskStatsMgr: base.NewClusterTopologyStatisticsMgr(1, []float64{}),
+		}
+		clusterTopMgr.buildClusterTopology(disks, 1)
+		mgr.clusterTopology = clusterTopMgr
+		mgr.IMigrator.(*MockMigrater).EXPECT().IsMigratingDisk(any).AnyTimes().DoAndReturn(func(diskID proto.DiskID) bool {
+			return diskID == 7
+		})
+		conflictMgr.EXPECT().IsMigratingDisk(any).AnyTimes().DoAndReturn(func(diskID proto.DiskID) bool {
+			return diskID == 9
+		})
+
+		selected := mgr.selectDisks(mgr.cfg.MinFreeChunkCntDiff)
+		require.Equal(t, 1, len(selected))
+		require.Equal(t, proto.DiskID(1), selected[0].DiskID)
+
+		volume := MockGenVolInfo(10000, codemode.EC6P6, proto.VolumeStatusIdle)
+		var units []*client.VunitInfoSimple
+		for _, unit := range volume.VunitLocations {
+			units = append(units, &client.VunitInfoSimple{Vuid: unit.Vuid, DiskID: unit.DiskID})
+		}
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ListDiskVolumeUnits(any, any).Return(units, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().AddTask(any, any).DoAndReturn(func(_ context.Context, task *proto.MigrateTask) {
+			require.Equal(t, proto.TaskTypeIntraNodeBalance, task.TaskType)
+			require.Equal(t, proto.DiskID(1), task.SourceDiskID)
+		})
+		err := mgr.collectionTask()
+		require.NoError(t, err)
+
+		// no disk to balance when free chunks are even
+		mgr.cfg.MinFreeChunkCntDiff = 1000
+		err = mgr.collectionTask()
+		require.True(t, errors.Is(err, ErrNoBalanceVunit))
+	}
+}
+
+func TestIntraNodeBalanceAllocVunit(t *testing.T) {
+	ctx := context.Background()
+	mgr := newMigrateMgr(t)
+	mgr.taskType = proto.TaskTypeIntraNodeBalance
+	volume := MockGenVolInfo(100, codemode.EC6P6, proto.VolumeStatusIdle)
+	vuid := volume.VunitLocations[0].Vuid
+	host := volume.VunitLocations[0].Host
+
+	newVunit := &client.AllocVunitInfo{VunitLocation: volume.VunitLocations[0]}
+	newVunit.DiskID = proto.DiskID(10000)
+	mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocVolumeUnitOnHost(any, vuid, host).Return(newVunit, nil)
+	ret, err := mgr.allocVunit(ctx, vuid, volume.VunitLocations, host)
+	require.NoError(t, err)
+	require.Equal(t, host, ret.Host)
+
+	mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocVolumeUnitOnHost(any, vuid, host).Return(nil, errMock)
+	_, err = mgr.allocVunit(ctx, vuid, volume.VunitLocations, host)
+	require.True(t, errors.Is(err, errMock))
+
+	otherVunit := &client.AllocVunitInfo{VunitLocation: newVunit.VunitLocation}
+	otherVunit.Host = "127.0.0.100:8000"
+	mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocVolumeUnitOnHost(any, vuid, host).Return(otherVunit, nil)
+	_, err = mgr.allocVunit(ctx, vuid, volume.VunitLocations, host)
+	require.Error(t, err)
+}
+
+func TestIntraNodeBalanceCheckAndClearJunkTasks(t *testing.T) {
+	{
+		mgr := newIntraNodeBalancer(t)
+		mgr.IMigrator.(*MockMigrater).EXPECT().DeletedTasks().Return([]DeletedTask{
+			{DiskID: proto.DiskID(1), TaskID: xid.New().String(), DeletedTime: time.Now()},
+		})
+		mgr.checkAndClearJunkTasks()
+	}
+	{
+		mgr := newIntraNodeBalancer(t)
+		mgr.IMigrator.(*MockMigrater).EXPECT().DeletedTasks().Return([]DeletedTask{
+			{DiskID: proto.DiskID(1), TaskID: xid.New().String(), DeletedTime: time.Now().Add(-junkMigrationTaskProtectionWindow)},
+		})
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetMigrateTask(any, proto.TaskTypeIntraNodeBalance, any).Return(nil, errcode.ErrNotFound)
+		mgr.IMigrator.(*MockMigrater).EXPECT().ClearDeletedTaskByID(any, any).Return()
+		mgr.checkAndClearJunkTasks()
+	}
+	{
+		mgr := newIntraNodeBalancer(t)
+		mgr.IMigrator.(*MockMigrater).EXPECT().DeletedTasks().Return([]DeletedTask{
+			{DiskID: proto.DiskID(1), TaskID: xid.New().String(), DeletedTime: time.Now().Add(-junkMigrationTaskProtectionWindow)},
+		})
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetMigrateTask(any, any, any).Return(&proto.MigrateTask{}, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().ClearDeletedTaskByID(any, any).Return()
+		mgr.checkAndClearJunkTasks()
+	}
+}
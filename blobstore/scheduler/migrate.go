@@ -45,6 +45,7 @@ const (
 type MMigrator interface {
 	IMigrator
 	IDisKMigrator
+	IDiskDropMigrator
 	IManualMigrator
 }
 
@@ -75,6 +76,15 @@ type IDisKMigrator interface {
 	DiskProgress(ctx context.Context, diskID proto.DiskID) (stats *api.DiskMigratingStats, err error)
 }
 
+// IDiskDropMigrator interface of disk drop migrator
+type IDiskDropMigrator interface {
+	IDisKMigrator
+	CancelDrop(ctx context.Context, diskID proto.DiskID) (report *api.DiskDropCancelReport, err error)
+	CancelDropReport(ctx context.Context, diskID proto.DiskID) (report *api.DiskDropCancelReport, err error)
+	DrainHost(ctx context.Context, host string) (report *api.HostDrainReport, err error)
+	HostDrainReport(ctx context.Context, host string) (report *api.HostDrainReport, err error)
+}
+
 // IManualMigrator interface of manual migrator
 type IManualMigrator interface {
 	Migrator
@@ -87,6 +97,7 @@ type IMigrator interface {
 	// inner interface
 	SetLockFailHandleFunc(lockFailHandleFunc lockFailFunc)
 	SetClearJunkTasksWhenLoadingFunc(clearJunkTasksWhenLoadingFunc clearJunkTasksFunc)
+	SetRepairShardSender(repairShardSender client.ProxyAPI)
 	GetMigratingDiskNum() int
 	IsMigratingDisk(diskID proto.DiskID) bool
 	ClearDeletedTasks(diskID proto.DiskID)
@@ -98,6 +109,8 @@ type IMigrator interface {
 	ListAllTask(ctx context.Context) (tasks []*proto.MigrateTask, err error)
 	ListAllTaskByDiskID(ctx context.Context, diskID proto.DiskID) (tasks []*proto.MigrateTask, err error)
 	FinishTaskInAdvanceWhenLockFail(ctx context.Context, task *proto.MigrateTask)
+	StopDiskTasks(diskID proto.DiskID)
+	ResumeDiskTasks(diskID proto.DiskID)
 }
 
 type migratingDisks struct {
@@ -304,10 +317,42 @@ func (m *migratedDisks) list() (disks []*migratedDisk) {
 	return disks
 }
 
+type stoppedDisks struct {
+	disks map[proto.DiskID]struct{}
+	sync.RWMutex
+}
+
+func newStoppedDisks() *stoppedDisks {
+	return &stoppedDisks{
+		disks: make(map[proto.DiskID]struct{}),
+	}
+}
+
+func (m *stoppedDisks) add(diskID proto.DiskID) {
+	m.Lock()
+	m.disks[diskID] = struct{}{}
+	m.Unlock()
+}
+
+func (m *stoppedDisks) delete(diskID proto.DiskID) {
+	m.Lock()
+	delete(m.disks, diskID)
+	m.Unlock()
+}
+
+func (m *stoppedDisks) exist(diskID proto.DiskID) (ok bool) {
+	m.RLock()
+	_, ok = m.disks[diskID]
+	m.RUnlock()
+	return
+}
+
 // MigrateConfig migrate config
 type MigrateConfig struct {
 	ClusterID proto.ClusterID `json:"-"` // fill in config.go
 	base.TaskCommonConfig
+	// the number of disks of one host dropped concurrently when draining the host, only used by disk drop
+	HostDiskConcurrency int `json:"host_disk_concurrency"`
 }
 
 type clearJunkTasksFunc func(ctx context.Context, tasks []*proto.MigrateTask) error
@@ -334,6 +379,7 @@ type MigrateMgr struct {
 	workQueue    *base.WorkerTaskQueue // store prepared task
 	finishQueue  *base.TaskQueue       // store completed task
 	deletedTasks *diskMigratedTasks
+	stoppedDisks *stoppedDisks // the pending tasks of stopped disk are finished in advance
 
 	finishTaskCounter counter.Counter
 	taskStatsMgr      *base.TaskStatsMgr
@@ -345,6 +391,8 @@ type MigrateMgr struct {
 	lockFailHandleFunc lockFailFunc
 	// clear junk tasks
 	clearJunkTasksWhenLoadingFunc clearJunkTasksFunc
+	// repair the bids skipped by the worker in the destination
+	repairShardSender client.ProxyAPI
 }
 
 // NewMigrateMgr returns migrate manager
@@ -369,6 +417,7 @@ func NewMigrateMgr(
 		workQueue:    base.NewWorkerTaskQueue(time.Duration(conf.CancelPunishDurationS) * time.Second),
 		finishQueue:  base.NewTaskQueue(time.Duration(conf.FinishQueueRetryDelayS) * time.Second),
 		deletedTasks: newDiskMigratedTasks(),
+		stoppedDisks: newStoppedDisks(),
 
 		cfg:        conf,
 		taskLogger: taskLogger,
@@ -387,6 +436,11 @@ func (mgr *MigrateMgr) SetLockFailHandleFunc(lockFailHandleFunc lockFailFunc) {
 	mgr.lockFailHandleFunc = lockFailHandleFunc
 }
 
+// SetRepairShardSender set the sender of the shard repair messages of the skipped bids
+func (mgr *MigrateMgr) SetRepairShardSender(repairShardSender client.ProxyAPI) {
+	mgr.repairShardSender = repairShardSender
+}
+
 // SetClearJunkTasksWhenLoadingFunc set clear junk task func
 func (mgr *MigrateMgr) SetClearJunkTasksWhenLoadingFunc(clearJunkTasksWhenLoadingFunc clearJunkTasksFunc) {
 	mgr.clearJunkTasksWhenLoadingFunc = clearJunkTasksWhenLoadingFunc
@@ -480,6 +534,33 @@ func (mgr *MigrateMgr) listMigratingDisks(ctx context.Context) (*migratingDisks,
 func (mgr *MigrateMgr) Run() {
 	go mgr.prepareTaskLoop()
 	go mgr.finishTaskLoop()
+	go checkZombieTaskLoop(mgr.Closer, mgr.taskSwitch, mgr.taskType, mgr.workQueue, mgr.cfg.ZombieTaskCheckCycles)
+}
+
+// checkZombieTaskLoop cross-checks the leased tasks with the alive tasks renewed by workers
+// in every renewal period, the zombie tasks are reclaimed and reissued to workers.
+func checkZombieTaskLoop(c closer.Closer, taskSwitch taskswitch.ISwitcher, taskType proto.TaskType,
+	workQueue *base.WorkerTaskQueue, maxMissed int,
+) {
+	t := time.NewTicker(proto.TaskRenewalPeriodS * time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			taskSwitch.WaitEnable()
+			zombies := workQueue.ReclaimZombies(maxMissed)
+			if len(zombies) == 0 {
+				continue
+			}
+			span, _ := trace.StartSpanFromContext(context.Background(), "checkZombieTask")
+			for idc, ids := range zombies {
+				span.Warnf("reclaim zombie tasks: task_type[%s], idc[%s], task_ids[%v]", taskType, idc, ids)
+			}
+		case <-c.Done():
+			return
+		}
+	}
 }
 
 func (mgr *MigrateMgr) prepareTaskLoop() {
@@ -527,6 +608,25 @@ func (mgr *MigrateMgr) prepareTask() (err error) {
 		}
 	}()
 
+	// the tasks of stopped disk are finished even if the volume is frozen or the host is in maintenance,
+	// or the stopping waits for them forever
+	if mgr.stoppedDisks.exist(migTask.SourceDiskID) {
+		span.Infof("the source disk has been stopped and finish task immediately: task_id[%s], disk_id[%d]",
+			migTask.TaskID, migTask.SourceDiskID)
+		mgr.finishTaskInAdvance(ctx, migTask, "disk migrate stopped")
+		return nil
+	}
+
+	if base.VolumeFreezeInst().TaskFrozen(migTask) {
+		span.Infof("the volume is frozen and retry later: task_id[%s]", migTask.TaskID)
+		return errcode.ErrVolumeFrozen
+	}
+
+	if base.HostMaintenanceInst().SourceInMaintenance(migTask) {
+		span.Infof("the source host is in maintenance and retry later: task_id[%s]", migTask.TaskID)
+		return errcode.ErrHostInMaintenance
+	}
+
 	volInfo, err := mgr.clusterMgrCli.GetVolumeInfo(ctx, migTask.SourceVuid.Vid())
 	if err != nil {
 		span.Errorf("prepare task failed: err[%v]", err)
@@ -568,7 +668,8 @@ func (mgr *MigrateMgr) prepareTask() (err error) {
 	}
 
 	// alloc volume unit
-	ret, err := base.AllocVunitSafe(ctx, mgr.clusterMgrCli, migTask.SourceVuid, migTask.Sources)
+	ret, err := mgr.allocVunit(ctx, migTask.SourceVuid, migTask.Sources,
+		volInfo.VunitLocations[migTask.SourceVuid.Index()].Host)
 	if err != nil {
 		span.Errorf("alloc volume unit failed: err[%+v]", err)
 		return
@@ -659,6 +760,12 @@ func (mgr *MigrateMgr) finishTask() (err error) {
 		err = nil
 	}
 
+	// the destination is in the volume mapping now, repair the bids missing in it before the task is finished
+	err = mgr.repairSkippedBids(ctx, migrateTask)
+	if err != nil {
+		return
+	}
+
 	err = mgr.clusterMgrCli.UnlockVolume(ctx, migrateTask.SourceVuid.Vid())
 	if err != nil {
 		span.Errorf("unlock volume failed: err[%+v]", err)
@@ -680,6 +787,7 @@ func (mgr *MigrateMgr) finishTask() (err error) {
 	mgr.deleteMigratingVuid(migrateTask.SourceDiskID, migrateTask.SourceVuid)
 
 	mgr.finishTaskCounter.Add()
+	mgr.taskStatsMgr.FinishTask()
 
 	// add delete task and check it again
 	mgr.addDeletedTask(migrateTask)
@@ -687,6 +795,27 @@ func (mgr *MigrateMgr) finishTask() (err error) {
 	return
 }
 
+// repairSkippedBids sends a shard repair message of the destination for each bid skipped by the worker,
+// the task is retried if any message fails to send, the messages sent before are repaired more than once.
+func (mgr *MigrateMgr) repairSkippedBids(ctx context.Context, task *proto.MigrateTask) error {
+	if len(task.SkippedBids) == 0 {
+		return nil
+	}
+	span := trace.SpanFromContextSafe(ctx)
+	vid := task.Destination.Vuid.Vid()
+	badIdxs := []uint8{task.Destination.Vuid.Index()}
+	for _, bid := range task.SkippedBids {
+		if err := mgr.repairShardSender.SendShardRepairMsg(ctx, vid, bid, badIdxs); err != nil {
+			span.Errorf("send shard repair msg of skipped bid failed: task_id[%s], vid[%d], bid[%d], err[%+v]",
+				task.TaskID, vid, bid, err)
+			return err
+		}
+	}
+	span.Infof("send shard repair msg of skipped bids success: task_id[%s], vid[%d], bids[%v]",
+		task.TaskID, vid, task.SkippedBids)
+	return nil
+}
+
 func (mgr *MigrateMgr) updateVolumeCache(ctx context.Context, task *proto.MigrateTask) (err error) {
 	span := trace.SpanFromContextSafe(ctx)
 	span.Infof("update volume cache: vid[%d], task_id[%s]", task.SourceVuid.Vid(), task.TaskID)
@@ -726,6 +855,7 @@ func (mgr *MigrateMgr) finishTaskInAdvance(ctx context.Context, task *proto.Migr
 	}
 
 	mgr.finishTaskCounter.Add()
+	mgr.taskStatsMgr.FinishTask()
 	mgr.prepareQueue.RemoveTask(task.TaskID)
 	mgr.addDeletedTask(task)
 	base.VolTaskLockerInst().Unlock(ctx, task.SourceVuid.Vid())
@@ -742,7 +872,7 @@ func (mgr *MigrateMgr) handleUpdateVolMappingFail(ctx context.Context, task *pro
 
 	if base.ShouldAllocAndRedo(code) {
 		span.Infof("realloc vunit and redo: task_id[%s]", task.TaskID)
-		newVunit, err := base.AllocVunitSafe(ctx, mgr.clusterMgrCli, task.SourceVuid, task.Sources)
+		newVunit, err := mgr.allocVunit(ctx, task.SourceVuid, task.Sources, task.Sources[task.SourceVuid.Index()].Host)
 		if err != nil {
 			span.Errorf("realloc failed: vuid[%d], err[%+v]", task.SourceVuid, err)
 			return err
@@ -765,6 +895,43 @@ func (mgr *MigrateMgr) handleUpdateVolMappingFail(ctx context.Context, task *pro
 	return err
 }
 
+// hostAllocVunit allocates volume unit on the disks of the host
+type hostAllocVunit struct {
+	cli  client.ClusterMgrAPI
+	host string
+}
+
+func (h *hostAllocVunit) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*client.AllocVunitInfo, error) {
+	return h.cli.AllocVolumeUnitOnHost(ctx, vuid, h.host)
+}
+
+// allocVunit allocates the destination of the task, the destination of intra node balance
+// task is on the other disk of the source host.
+func (mgr *MigrateMgr) allocVunit(ctx context.Context, vuid proto.Vuid, sources []proto.VunitLocation,
+	host string) (*client.AllocVunitInfo, error) {
+	if mgr.taskType != proto.TaskTypeIntraNodeBalance {
+		return base.AllocVunitSafe(ctx, mgr.clusterMgrCli, vuid, sources)
+	}
+	ret, err := base.AllocVunitSafe(ctx, &hostAllocVunit{cli: mgr.clusterMgrCli, host: host}, vuid, sources)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Host != host {
+		return nil, fmt.Errorf("alloc volume unit on other host: vuid[%d], host[%s], alloc host[%s]", vuid, host, ret.Host)
+	}
+	return ret, nil
+}
+
+// StopDiskTasks stops the pending tasks of disk, the prepared tasks keep running
+func (mgr *MigrateMgr) StopDiskTasks(diskID proto.DiskID) {
+	mgr.stoppedDisks.add(diskID)
+}
+
+// ResumeDiskTasks resumes the tasks of disk
+func (mgr *MigrateMgr) ResumeDiskTasks(diskID proto.DiskID) {
+	mgr.stoppedDisks.delete(diskID)
+}
+
 // ClearDeletedTasks clear tasks when disk is migrated
 func (mgr *MigrateMgr) ClearDeletedTasks(diskID proto.DiskID) {
 	switch mgr.taskType {
@@ -777,7 +944,7 @@ func (mgr *MigrateMgr) ClearDeletedTasks(diskID proto.DiskID) {
 // ClearDeletedTaskByID clear migrated task
 func (mgr *MigrateMgr) ClearDeletedTaskByID(diskID proto.DiskID, taskID string) {
 	switch mgr.taskType {
-	case proto.TaskTypeBalance: // only balance task need to clear by id
+	case proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance: // only balance task need to clear by id
 		mgr.deletedTasks.deleteByID(diskID, taskID)
 	default:
 	}
@@ -795,7 +962,7 @@ func (mgr *MigrateMgr) DeletedTasks() []DeletedTask {
 
 func (mgr *MigrateMgr) addMigratingVuid(diskID proto.DiskID, vuid proto.Vuid, taskID string) {
 	switch mgr.taskType {
-	case proto.TaskTypeBalance: // only balance task need to add
+	case proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance: // only balance task need to add
 		mgr.diskMigratingVuids.addMigratingVuid(diskID, vuid, taskID)
 	default:
 	}
@@ -803,7 +970,7 @@ func (mgr *MigrateMgr) addMigratingVuid(diskID proto.DiskID, vuid proto.Vuid, ta
 
 func (mgr *MigrateMgr) deleteMigratingVuid(diskID proto.DiskID, vuid proto.Vuid) {
 	switch mgr.taskType {
-	case proto.TaskTypeBalance: // only balance task need to add
+	case proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance: // only balance task need to add
 		mgr.diskMigratingVuids.deleteMigratingVuid(diskID, vuid)
 	default:
 	}
@@ -811,7 +978,7 @@ func (mgr *MigrateMgr) deleteMigratingVuid(diskID proto.DiskID, vuid proto.Vuid)
 
 func (mgr *MigrateMgr) addDeletedTask(task *proto.MigrateTask) {
 	switch mgr.taskType {
-	case proto.TaskTypeDiskDrop, proto.TaskTypeBalance: // only disk drop and balance task need to add
+	case proto.TaskTypeDiskDrop, proto.TaskTypeBalance, proto.TaskTypeIntraNodeBalance: // only disk drop and balance task need to add
 		mgr.deletedTasks.add(task.SourceDiskID, task.TaskID)
 	default:
 	}
@@ -844,6 +1011,7 @@ func (mgr *MigrateMgr) Stats() api.MigrateTasksStat {
 			DataAmountByte: base.DataMountFormat(increaseDataSize),
 			ShardCnt:       fmt.Sprint(increaseShardCnt),
 		},
+		Totals: mgr.taskStatsMgr.Totals(),
 	}
 }
 
@@ -855,7 +1023,7 @@ func (mgr *MigrateMgr) AcquireTask(ctx context.Context, idc string) (task proto.
 		return task, proto.ErrTaskPaused
 	}
 
-	_, migTask, _ := mgr.workQueue.Acquire(idc)
+	_, migTask, _ := mgr.workQueue.AcquireAccepted(idc, acceptNotHeld)
 	if migTask != nil {
 		task = *migTask.(*proto.MigrateTask)
 		span.Infof("acquire %s taskId: %s", mgr.taskType, task.TaskID)
@@ -913,6 +1081,12 @@ func (mgr *MigrateMgr) CompleteTask(ctx context.Context, args *api.OperateTaskAr
 
 	t := completeTask.(*proto.MigrateTask)
 	t.State = proto.MigrateStateWorkCompleted
+	if len(args.SkippedBids) > 0 {
+		// repaired by the shard repair messages sent in the finish phase
+		span.Warnf("migrate task completed with skipped bids: task_id[%s], vid[%d], bids[%v]",
+			t.TaskID, t.Vid(), args.SkippedBids)
+		t.SkippedBids = args.SkippedBids
+	}
 
 	err = mgr.clusterMgrCli.UpdateMigrateTask(ctx, t)
 	if err != nil {
@@ -931,6 +1105,10 @@ func (mgr *MigrateMgr) RenewalTask(ctx context.Context, idc, taskID string) (err
 		return proto.ErrTaskPaused
 	}
 
+	if err = releaseHeld(ctx, mgr.workQueue, idc, taskID); err != nil {
+		return
+	}
+
 	err = mgr.workQueue.Renewal(idc, taskID)
 	if err != nil {
 		span := trace.SpanFromContextSafe(ctx)
@@ -939,6 +1117,41 @@ func (mgr *MigrateMgr) RenewalTask(ctx context.Context, idc, taskID string) (err
 	return
 }
 
+func acceptNotHeld(task base.WorkerTask) bool {
+	return heldReason(task.(*proto.MigrateTask)) == nil
+}
+
+// heldReason returns the error why the task is held, the task on the hosts in maintenance
+// or of the frozen volume is held
+func heldReason(task *proto.MigrateTask) error {
+	if base.VolumeFreezeInst().TaskFrozen(task) {
+		return errcode.ErrVolumeFrozen
+	}
+	if base.HostMaintenanceInst().TaskInMaintenance(task) {
+		return errcode.ErrHostInMaintenance
+	}
+	return nil
+}
+
+// releaseHeld rejects the renewal of the held task, and releases the lease at once,
+// so that the worker stops it before the hosts restart or the volume is changed.
+func releaseHeld(ctx context.Context, workQueue *base.WorkerTaskQueue, idc, taskID string) error {
+	task, err := workQueue.Query(idc, taskID)
+	if err != nil {
+		return nil
+	}
+	reason := heldReason(task.(*proto.MigrateTask))
+	if reason == nil {
+		return nil
+	}
+	span := trace.SpanFromContextSafe(ctx)
+	span.Warnf("release held task: task_id[%s], reason[%v]", taskID, reason)
+	if err = workQueue.Release(idc, taskID); err != nil {
+		span.Warnf("release task failed: task_id[%s], err[%+v]", taskID, err)
+	}
+	return reason
+}
+
 // IsMigratingDisk returns true if disk is migrating
 func (mgr *MigrateMgr) IsMigratingDisk(diskID proto.DiskID) bool {
 	return mgr.diskMigratingVuids.isMigratingDisk(diskID)
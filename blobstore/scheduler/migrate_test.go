@@ -176,6 +176,45 @@ func TestPrepareMigrateTask(t *testing.T) {
 		err = mgr.prepareTask()
 		require.NoError(t, err)
 	}
+	{
+		// one task and finish in advance because the disk is stopped
+		mgr := newMigrateMgr(t)
+		t1 := mockGenMigrateTask(proto.TaskTypeManualMigrate, "z0", 4, 100, proto.MigrateStateInited, MockMigrateVolInfoMap)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddMigrateTask(any, any).Return(nil)
+		mgr.AddTask(ctx, t1)
+
+		mgr.StopDiskTasks(t1.SourceDiskID)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+		mgr.taskLogger.(*mocks.MockRecordLogEncoder).EXPECT().Encode(any).Return(nil)
+		err := mgr.prepareTask()
+		require.NoError(t, err)
+		inited, _, _ := mgr.StatQueueTaskCnt()
+		require.Equal(t, 0, inited)
+		mgr.ResumeDiskTasks(t1.SourceDiskID)
+		require.False(t, mgr.stoppedDisks.exist(t1.SourceDiskID))
+	}
+	{
+		// the task of the stopped disk is finished even if the volume is frozen and the host is in maintenance
+		mgr := newMigrateMgr(t)
+		t1 := mockGenMigrateTask(proto.TaskTypeDiskDrop, "z0", 4, 100, proto.MigrateStateInited, MockMigrateVolInfoMap)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddMigrateTask(any, any).Return(nil)
+		mgr.AddTask(ctx, t1)
+
+		base.VolumeFreezeInst().Update(map[proto.Vid]int64{t1.Vid(): time.Now().Unix() + 60})
+		base.HostMaintenanceInst().Update(map[string]int64{t1.Sources[t1.SourceVuid.Index()].Host: time.Now().Unix() + 60})
+		err := mgr.prepareTask()
+		require.True(t, errors.Is(err, errcode.ErrVolumeFrozen))
+
+		mgr.StopDiskTasks(t1.SourceDiskID)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+		mgr.taskLogger.(*mocks.MockRecordLogEncoder).EXPECT().Encode(any).Return(nil)
+		err = mgr.prepareTask()
+		require.NoError(t, err)
+		inited, _, _ := mgr.StatQueueTaskCnt()
+		require.Equal(t, 0, inited)
+		base.VolumeFreezeInst().Update(nil)
+		base.HostMaintenanceInst().Update(nil)
+	}
 	{
 		// one task and finish in advance because  other migrate task is doing on this volume
 		mgr := newMigrateMgr(t)
@@ -329,6 +368,35 @@ func TestFinishMigrateTask(t *testing.T) {
 			err = mgr.finishTask()
 			require.NoError(t, err)
 		}
+		{
+			// repair the skipped bids in the destination
+			mgr := newMigrateMgr(t)
+			sender := NewMockMqProxyAPI(gomock.NewController(t))
+			mgr.SetRepairShardSender(sender)
+			t1 := mockGenMigrateTask(proto.TaskTypeManualMigrate, "z0", 4, 100, proto.MigrateStateWorkCompleted, MockMigrateVolInfoMap)
+			t1.SkippedBids = []proto.BlobID{10, 11}
+			badIdxs := []uint8{t1.Destination.Vuid.Index()}
+			mgr.finishQueue.PushTask(t1.TaskID, t1)
+
+			// send failed and the task is retried
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateVolume(any, any, any, any).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ReleaseVolumeUnit(any, any, any).Return(nil)
+			sender.EXPECT().SendShardRepairMsg(any, t1.Destination.Vuid.Vid(), proto.BlobID(10), badIdxs).Return(nil)
+			sender.EXPECT().SendShardRepairMsg(any, t1.Destination.Vuid.Vid(), proto.BlobID(11), badIdxs).Return(errMock)
+			err := mgr.finishTask()
+			require.True(t, errors.Is(err, errMock))
+
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateVolume(any, any, any, any).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ReleaseVolumeUnit(any, any, any).Return(nil)
+			sender.EXPECT().SendShardRepairMsg(any, t1.Destination.Vuid.Vid(), any, badIdxs).Times(2).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UnlockVolume(any, any).Return(nil)
+			mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+			mgr.taskLogger.(*mocks.MockRecordLogEncoder).EXPECT().Encode(any).Return(nil)
+			err = mgr.finishTask()
+			require.NoError(t, err)
+		}
 	}
 }
 
@@ -468,6 +536,68 @@ func TestRenewalMigrateTask(t *testing.T) {
 	}
 }
 
+func TestMigrateTaskInMaintenance(t *testing.T) {
+	ctx := context.Background()
+	idc := "z0"
+	mgr := newMigrateMgr(t)
+	mgr.taskSwitch.(*mocks.MockSwitcher).EXPECT().Enabled().AnyTimes().Return(true)
+	t1 := mockGenMigrateTask(proto.TaskTypeManualMigrate, idc, 4, 100, proto.MigrateStatePrepared, MockMigrateVolInfoMap)
+	mgr.workQueue.AddPreparedTask(idc, t1.TaskID, t1)
+
+	base.HostMaintenanceInst().Update(map[string]int64{t1.Sources[0].Host: time.Now().Unix() + 60})
+	defer base.HostMaintenanceInst().Update(nil)
+	_, err := mgr.AcquireTask(ctx, idc)
+	require.True(t, errors.Is(err, proto.ErrTaskEmpty))
+
+	base.HostMaintenanceInst().Update(nil)
+	task, err := mgr.AcquireTask(ctx, idc)
+	require.NoError(t, err)
+	require.Equal(t, t1.TaskID, task.TaskID)
+
+	// the renewal is rejected and the lease is released at once
+	base.HostMaintenanceInst().Update(map[string]int64{t1.Sources[0].Host: time.Now().Unix() + 60})
+	err = mgr.RenewalTask(ctx, idc, t1.TaskID)
+	require.ErrorIs(t, err, errcode.ErrHostInMaintenance)
+	_, err = mgr.AcquireTask(ctx, idc)
+	require.True(t, errors.Is(err, proto.ErrTaskEmpty))
+
+	base.HostMaintenanceInst().Update(nil)
+	task, err = mgr.AcquireTask(ctx, idc)
+	require.NoError(t, err)
+	require.Equal(t, t1.TaskID, task.TaskID)
+}
+
+func TestMigrateTaskOfFrozenVolume(t *testing.T) {
+	ctx := context.Background()
+	idc := "z0"
+	mgr := newMigrateMgr(t)
+	mgr.taskSwitch.(*mocks.MockSwitcher).EXPECT().Enabled().AnyTimes().Return(true)
+	t1 := mockGenMigrateTask(proto.TaskTypeManualMigrate, idc, 4, 100, proto.MigrateStatePrepared, MockMigrateVolInfoMap)
+	mgr.workQueue.AddPreparedTask(idc, t1.TaskID, t1)
+
+	base.VolumeFreezeInst().Update(map[proto.Vid]int64{t1.Vid(): time.Now().Unix() + 60})
+	defer base.VolumeFreezeInst().Update(nil)
+	_, err := mgr.AcquireTask(ctx, idc)
+	require.True(t, errors.Is(err, proto.ErrTaskEmpty))
+
+	base.VolumeFreezeInst().Update(nil)
+	task, err := mgr.AcquireTask(ctx, idc)
+	require.NoError(t, err)
+	require.Equal(t, t1.TaskID, task.TaskID)
+
+	// the renewal is rejected and the lease is released at once
+	base.VolumeFreezeInst().Update(map[proto.Vid]int64{t1.Vid(): time.Now().Unix() + 60})
+	err = mgr.RenewalTask(ctx, idc, t1.TaskID)
+	require.ErrorIs(t, err, errcode.ErrVolumeFrozen)
+	_, err = mgr.AcquireTask(ctx, idc)
+	require.True(t, errors.Is(err, proto.ErrTaskEmpty))
+
+	base.VolumeFreezeInst().Update(nil)
+	task, err = mgr.AcquireTask(ctx, idc)
+	require.NoError(t, err)
+	require.Equal(t, t1.TaskID, task.TaskID)
+}
+
 func TestAddMigrateTask(t *testing.T) {
 	{
 		ctx := context.Background()
@@ -0,0 +1,292 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const defaultRecoveryReportWindowS = 60
+
+// RecoveryConfig is the config of the cold start recovery, which is enabled once the records of
+// the scheduler in clustermgr are lost, such as the migrate tasks and the migrating disks.
+type RecoveryConfig struct {
+	Enable bool `json:"enable"`
+	// the alive tasks reported by the workers in the window are reconciled, it should be longer than
+	// several renewal periods, so that the workers have reported and stopped the lost tasks
+	ReportWindowS int `json:"report_window_s"`
+	// scan all volumes for the ones locked by the lost tasks not reported by any worker
+	ScanLockedVolumes bool `json:"scan_locked_volumes"`
+}
+
+// RecoveryMgr rebuilds the state of the scheduler lost in clustermgr. Before loading, the disks
+// being repaired and dropped in clustermgr without the migrating records are recorded again, so
+// their tasks are regenerated by the disk repair and disk drop. After starting, the alive tasks
+// renewed by the workers but not known are stopped on the workers, and once the report window
+// ends, the volumes left locked by these lost tasks are unlocked.
+type RecoveryMgr struct {
+	closer.Closer
+
+	clusterMgrCli   client.ClusterMgrAPI
+	cfg             RecoveryConfig
+	dropConcurrency int
+
+	mu          sync.Mutex
+	windowEnded bool
+	orphans     map[proto.TaskType]map[string]struct{}
+	report      api.RecoveryReport
+}
+
+// NewRecoveryMgr returns the cold start recovery manager, dropConcurrency is the number of
+// the dropping disks recorded again at most.
+func NewRecoveryMgr(clusterMgrCli client.ClusterMgrAPI, cfg RecoveryConfig, dropConcurrency int) *RecoveryMgr {
+	return &RecoveryMgr{
+		Closer:          closer.New(),
+		clusterMgrCli:   clusterMgrCli,
+		cfg:             cfg,
+		dropConcurrency: dropConcurrency,
+		orphans:         make(map[proto.TaskType]map[string]struct{}),
+		report: api.RecoveryReport{
+			Enable:      cfg.Enable,
+			StartTime:   time.Now().Unix(),
+			OrphanTasks: make(map[proto.TaskType][]string),
+		},
+	}
+}
+
+// RebuildDisks records the disks being repaired and dropped again, it should be called before
+// the disk repair and disk drop are loaded.
+func (mgr *RecoveryMgr) RebuildDisks() error {
+	if !mgr.cfg.Enable {
+		return nil
+	}
+	span, ctx := trace.StartSpanFromContext(context.Background(), "recovery.RebuildDisks")
+
+	repairingDisks, err := mgr.clusterMgrCli.ListRepairingDisks(ctx)
+	if err != nil {
+		return err
+	}
+	rebuilt, err := mgr.rebuildDisks(ctx, proto.TaskTypeDiskRepair, repairingDisks, len(repairingDisks))
+	if err != nil {
+		return err
+	}
+	mgr.report.RepairingDisks = rebuilt
+
+	// the dropping disks are not all dropped concurrently, the rest are collected by the disk drop later
+	dropDisks, err := mgr.clusterMgrCli.ListDropDisks(ctx)
+	if err != nil {
+		return err
+	}
+	rebuilt, err = mgr.rebuildDisks(ctx, proto.TaskTypeDiskDrop, dropDisks, mgr.dropConcurrency)
+	if err != nil {
+		return err
+	}
+	mgr.report.DroppingDisks = rebuilt
+
+	span.Infof("rebuild migrating disks: repairing[%v], dropping[%v]", mgr.report.RepairingDisks, mgr.report.DroppingDisks)
+	return nil
+}
+
+func (mgr *RecoveryMgr) rebuildDisks(ctx context.Context, taskType proto.TaskType,
+	disks []*client.DiskInfoSimple, concurrency int,
+) (rebuilt []proto.DiskID, err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	metas, err := mgr.clusterMgrCli.ListMigratingDisks(ctx, taskType)
+	if err != nil {
+		return nil, err
+	}
+	recorded := make(map[proto.DiskID]struct{}, len(metas))
+	for _, meta := range metas {
+		recorded[meta.Disk.DiskID] = struct{}{}
+	}
+
+	for _, disk := range disks {
+		if len(recorded) >= concurrency {
+			break
+		}
+		if _, ok := recorded[disk.DiskID]; ok {
+			continue
+		}
+		meta := &client.MigratingDiskMeta{TaskType: taskType, Disk: disk}
+		if err = mgr.clusterMgrCli.AddMigratingDisk(ctx, meta); err != nil {
+			span.Errorf("rebuild migrating disk failed: task_type[%s], disk_id[%d], err[%+v]", taskType, disk.DiskID, err)
+			return nil, err
+		}
+		span.Warnf("rebuild migrating disk: task_type[%s], disk_id[%d], status[%s]", taskType, disk.DiskID, disk.Status)
+		recorded[disk.DiskID] = struct{}{}
+		rebuilt = append(rebuilt, disk.DiskID)
+	}
+	return rebuilt, nil
+}
+
+// ReportOrphans records the alive tasks of the worker failed to renew in the report window.
+func (mgr *RecoveryMgr) ReportOrphans(taskType proto.TaskType, taskIDs []string) {
+	if !mgr.cfg.Enable || len(taskIDs) == 0 {
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if mgr.windowEnded {
+		return
+	}
+	ids, ok := mgr.orphans[taskType]
+	if !ok {
+		ids = make(map[string]struct{})
+		mgr.orphans[taskType] = ids
+	}
+	for _, id := range taskIDs {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		ids[id] = struct{}{}
+		mgr.report.OrphanTasks[taskType] = append(mgr.report.OrphanTasks[taskType], id)
+	}
+}
+
+// Run reconciles the reported tasks once the report window ends.
+func (mgr *RecoveryMgr) Run() {
+	if !mgr.cfg.Enable {
+		return
+	}
+	go func() {
+		t := time.NewTimer(time.Duration(mgr.cfg.ReportWindowS) * time.Second)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			mgr.reconcile()
+		case <-mgr.Done():
+		}
+	}()
+}
+
+func (mgr *RecoveryMgr) reconcile() {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "recovery.reconcile")
+
+	mgr.mu.Lock()
+	mgr.windowEnded = true
+	vids := make(map[proto.Vid]struct{})
+	for taskType, ids := range mgr.orphans {
+		for id := range ids {
+			// the volume is not locked by the disk repair
+			if taskType == proto.TaskTypeDiskRepair {
+				continue
+			}
+			if _, vid, err := client.ParseMigrateTaskID(taskType, id); err == nil {
+				vids[vid] = struct{}{}
+			}
+		}
+		sort.Strings(mgr.report.OrphanTasks[taskType])
+	}
+	mgr.mu.Unlock()
+
+	if mgr.cfg.ScanLockedVolumes {
+		locked, err := mgr.listLockedVolumes(ctx)
+		if err != nil {
+			span.Errorf("list locked volumes failed: err[%+v]", err)
+		}
+		for _, vid := range locked {
+			vids[vid] = struct{}{}
+		}
+	}
+
+	sorted := make([]proto.Vid, 0, len(vids))
+	for vid := range vids {
+		sorted = append(sorted, vid)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var unlocked []proto.Vid
+	for _, vid := range sorted {
+		if mgr.unlockOrphanVolume(ctx, vid) {
+			unlocked = append(unlocked, vid)
+		}
+	}
+
+	mgr.mu.Lock()
+	mgr.report.UnlockedVolumes = unlocked
+	mgr.report.Done = true
+	mgr.report.EndTime = time.Now().Unix()
+	mgr.mu.Unlock()
+	span.Warnf("cold start recovery done: orphan_volumes[%d], unlocked_volumes[%v]", len(sorted), unlocked)
+}
+
+func (mgr *RecoveryMgr) listLockedVolumes(ctx context.Context) (vids []proto.Vid, err error) {
+	marker := proto.Vid(0)
+	for {
+		vols, next, err := mgr.clusterMgrCli.ListVolume(ctx, marker, defaultListVolStep)
+		if err != nil {
+			return vids, err
+		}
+		for _, vol := range vols {
+			if vol.Status == proto.VolumeStatusLock {
+				vids = append(vids, vol.Vid)
+			}
+		}
+		if len(vols) == 0 || next == proto.InvalidVid {
+			return vids, nil
+		}
+		marker = next
+	}
+}
+
+// unlockOrphanVolume unlocks the volume in clustermgr if there is no task of the volume in the
+// scheduler, the volume task locker is held meanwhile, so no task of the volume is prepared.
+func (mgr *RecoveryMgr) unlockOrphanVolume(ctx context.Context, vid proto.Vid) bool {
+	span := trace.SpanFromContextSafe(ctx)
+
+	if err := base.VolTaskLockerInst().TryLock(ctx, vid); err != nil {
+		span.Infof("volume has running task: vid[%d]", vid)
+		return false
+	}
+	defer base.VolTaskLockerInst().Unlock(ctx, vid)
+
+	vol, err := mgr.clusterMgrCli.GetVolumeInfo(ctx, vid)
+	if err != nil {
+		span.Errorf("get volume info failed: vid[%d], err[%+v]", vid, err)
+		return false
+	}
+	if vol.Status != proto.VolumeStatusLock {
+		return false
+	}
+	if err = mgr.clusterMgrCli.UnlockVolume(ctx, vid); err != nil {
+		span.Errorf("unlock orphan volume failed: vid[%d], err[%+v]", vid, err)
+		return false
+	}
+	span.Warnf("unlock orphan volume: vid[%d]", vid)
+	return true
+}
+
+// Report returns the report of the recovery.
+func (mgr *RecoveryMgr) Report() *api.RecoveryReport {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	report := mgr.report
+	report.OrphanTasks = make(map[proto.TaskType][]string, len(mgr.report.OrphanTasks))
+	for taskType, ids := range mgr.report.OrphanTasks {
+		report.OrphanTasks[taskType] = append([]string(nil), ids...)
+	}
+	return &report
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+)
+
+func TestRecoveryRebuildDisks(t *testing.T) {
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+
+	// do nothing if disabled
+	mgr := NewRecoveryMgr(clusterMgr, RecoveryConfig{}, 1)
+	require.NoError(t, mgr.RebuildDisks())
+
+	mgr = NewRecoveryMgr(clusterMgr, RecoveryConfig{Enable: true}, 2)
+	clusterMgr.EXPECT().ListRepairingDisks(any).Return(nil, errMock)
+	require.True(t, errors.Is(mgr.RebuildDisks(), errMock))
+
+	disk := func(id proto.DiskID) *client.DiskInfoSimple { return &client.DiskInfoSimple{DiskID: id} }
+	meta := func(id proto.DiskID) *client.MigratingDiskMeta { return &client.MigratingDiskMeta{Disk: disk(id)} }
+	clusterMgr.EXPECT().ListRepairingDisks(any).Return([]*client.DiskInfoSimple{disk(1), disk(2)}, nil)
+	clusterMgr.EXPECT().ListMigratingDisks(any, proto.TaskTypeDiskRepair).Return([]*client.MigratingDiskMeta{meta(1)}, nil)
+	clusterMgr.EXPECT().ListDropDisks(any).Return([]*client.DiskInfoSimple{disk(3), disk(4), disk(5), disk(6)}, nil)
+	clusterMgr.EXPECT().ListMigratingDisks(any, proto.TaskTypeDiskDrop).Return([]*client.MigratingDiskMeta{meta(4)}, nil)
+	var added []*client.MigratingDiskMeta
+	clusterMgr.EXPECT().AddMigratingDisk(any, any).Times(2).DoAndReturn(
+		func(_ context.Context, meta *client.MigratingDiskMeta) error {
+			added = append(added, meta)
+			return nil
+		})
+	require.NoError(t, mgr.RebuildDisks())
+	// the dropping disks are rebuilt at most the concurrency
+	require.Len(t, added, 2)
+	require.Equal(t, proto.TaskTypeDiskRepair, added[0].TaskType)
+	require.Equal(t, proto.DiskID(2), added[0].Disk.DiskID)
+	require.Equal(t, proto.TaskTypeDiskDrop, added[1].TaskType)
+	require.Equal(t, proto.DiskID(3), added[1].Disk.DiskID)
+
+	report := mgr.Report()
+	require.Equal(t, []proto.DiskID{2}, report.RepairingDisks)
+	require.Equal(t, []proto.DiskID{3}, report.DroppingDisks)
+	require.False(t, report.Done)
+}
+
+func TestRecoveryReconcile(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+	mgr := NewRecoveryMgr(clusterMgr, RecoveryConfig{Enable: true, ScanLockedVolumes: true}, 1)
+	defer mgr.Close()
+
+	repairTask := client.GenMigrateTaskID(proto.TaskTypeDiskRepair, 1, 9101)
+	balanceTask := client.GenMigrateTaskID(proto.TaskTypeBalance, 2, 9102)
+	dropTask := client.GenMigrateTaskID(proto.TaskTypeDiskDrop, 3, 9103)
+	runningTask := client.GenMigrateTaskID(proto.TaskTypeBalance, 4, 9104)
+	mgr.ReportOrphans(proto.TaskTypeDiskRepair, []string{repairTask})
+	mgr.ReportOrphans(proto.TaskTypeBalance, []string{balanceTask, runningTask})
+	mgr.ReportOrphans(proto.TaskTypeBalance, []string{balanceTask})
+	mgr.ReportOrphans(proto.TaskTypeDiskDrop, []string{dropTask})
+	require.Len(t, mgr.Report().OrphanTasks[proto.TaskTypeBalance], 2)
+
+	// the volume of the running task is held by the scheduler
+	require.NoError(t, base.VolTaskLockerInst().TryLock(ctx, 9104))
+	defer base.VolTaskLockerInst().Unlock(ctx, 9104)
+
+	clusterMgr.EXPECT().ListVolume(any, proto.Vid(0), any).Return([]*client.VolumeInfoSimple{
+		{Vid: 9102, Status: proto.VolumeStatusLock},
+		{Vid: 9105, Status: proto.VolumeStatusLock},
+	}, proto.Vid(9105), nil)
+	clusterMgr.EXPECT().ListVolume(any, proto.Vid(9105), any).Return([]*client.VolumeInfoSimple{
+		{Vid: 9106, Status: proto.VolumeStatusIdle},
+	}, proto.Vid(0), nil)
+	clusterMgr.EXPECT().GetVolumeInfo(any, proto.Vid(9102)).Return(&client.VolumeInfoSimple{Vid: 9102, Status: proto.VolumeStatusLock}, nil)
+	clusterMgr.EXPECT().GetVolumeInfo(any, proto.Vid(9103)).Return(&client.VolumeInfoSimple{Vid: 9103, Status: proto.VolumeStatusIdle}, nil)
+	clusterMgr.EXPECT().GetVolumeInfo(any, proto.Vid(9105)).Return(&client.VolumeInfoSimple{Vid: 9105, Status: proto.VolumeStatusLock}, nil)
+	clusterMgr.EXPECT().UnlockVolume(any, proto.Vid(9102)).Return(nil)
+	clusterMgr.EXPECT().UnlockVolume(any, proto.Vid(9105)).Return(errMock)
+	mgr.reconcile()
+
+	report := mgr.Report()
+	require.True(t, report.Done)
+	require.Equal(t, []proto.Vid{9102}, report.UnlockedVolumes)
+	require.Equal(t, []string{repairTask}, report.OrphanTasks[proto.TaskTypeDiskRepair])
+	require.Equal(t, []string{dropTask}, report.OrphanTasks[proto.TaskTypeDiskDrop])
+
+	// the volumes are not held after reconciling
+	require.NoError(t, base.VolTaskLockerInst().TryLock(ctx, 9102))
+	base.VolTaskLockerInst().Unlock(ctx, 9102)
+
+	// the tasks reported after the window are ignored
+	mgr.ReportOrphans(proto.TaskTypeBalance, []string{client.GenMigrateTaskID(proto.TaskTypeBalance, 5, 9107)})
+	require.Len(t, mgr.Report().OrphanTasks[proto.TaskTypeBalance], 2)
+}
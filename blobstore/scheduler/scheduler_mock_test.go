@@ -236,6 +236,36 @@ func (mr *MockMigraterMockRecorder) AddTask(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTask", reflect.TypeOf((*MockMigrater)(nil).AddTask), arg0, arg1)
 }
 
+// CancelDrop mocks base method.
+func (m *MockMigrater) CancelDrop(arg0 context.Context, arg1 proto.DiskID) (*scheduler.DiskDropCancelReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDrop", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.DiskDropCancelReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDrop indicates an expected call of CancelDrop.
+func (mr *MockMigraterMockRecorder) CancelDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDrop", reflect.TypeOf((*MockMigrater)(nil).CancelDrop), arg0, arg1)
+}
+
+// CancelDropReport mocks base method.
+func (m *MockMigrater) CancelDropReport(arg0 context.Context, arg1 proto.DiskID) (*scheduler.DiskDropCancelReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDropReport", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.DiskDropCancelReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDropReport indicates an expected call of CancelDropReport.
+func (mr *MockMigraterMockRecorder) CancelDropReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDropReport", reflect.TypeOf((*MockMigrater)(nil).CancelDropReport), arg0, arg1)
+}
+
 // CancelTask mocks base method.
 func (m *MockMigrater) CancelTask(arg0 context.Context, arg1 *scheduler.OperateTaskArgs) error {
 	m.ctrl.T.Helper()
@@ -343,6 +373,21 @@ func (mr *MockMigraterMockRecorder) Done() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Done", reflect.TypeOf((*MockMigrater)(nil).Done))
 }
 
+// DrainHost mocks base method.
+func (m *MockMigrater) DrainHost(arg0 context.Context, arg1 string) (*scheduler.HostDrainReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DrainHost", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.HostDrainReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DrainHost indicates an expected call of DrainHost.
+func (mr *MockMigraterMockRecorder) DrainHost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrainHost", reflect.TypeOf((*MockMigrater)(nil).DrainHost), arg0, arg1)
+}
+
 // Enabled mocks base method.
 func (m *MockMigrater) Enabled() bool {
 	m.ctrl.T.Helper()
@@ -398,6 +443,21 @@ func (mr *MockMigraterMockRecorder) GetTask(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockMigrater)(nil).GetTask), arg0, arg1)
 }
 
+// HostDrainReport mocks base method.
+func (m *MockMigrater) HostDrainReport(arg0 context.Context, arg1 string) (*scheduler.HostDrainReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostDrainReport", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.HostDrainReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HostDrainReport indicates an expected call of HostDrainReport.
+func (mr *MockMigraterMockRecorder) HostDrainReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostDrainReport", reflect.TypeOf((*MockMigrater)(nil).HostDrainReport), arg0, arg1)
+}
+
 // IsDeletedTask mocks base method.
 func (m *MockMigrater) IsDeletedTask(arg0 *proto.MigrateTask) bool {
 	m.ctrl.T.Helper()
@@ -541,6 +601,18 @@ func (mr *MockMigraterMockRecorder) ReportWorkerTaskStats(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportWorkerTaskStats", reflect.TypeOf((*MockMigrater)(nil).ReportWorkerTaskStats), arg0)
 }
 
+// ResumeDiskTasks mocks base method.
+func (m *MockMigrater) ResumeDiskTasks(arg0 proto.DiskID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResumeDiskTasks", arg0)
+}
+
+// ResumeDiskTasks indicates an expected call of ResumeDiskTasks.
+func (mr *MockMigraterMockRecorder) ResumeDiskTasks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeDiskTasks", reflect.TypeOf((*MockMigrater)(nil).ResumeDiskTasks), arg0)
+}
+
 // Run mocks base method.
 func (m *MockMigrater) Run() {
 	m.ctrl.T.Helper()
@@ -565,6 +637,18 @@ func (mr *MockMigraterMockRecorder) SetClearJunkTasksWhenLoadingFunc(arg0 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClearJunkTasksWhenLoadingFunc", reflect.TypeOf((*MockMigrater)(nil).SetClearJunkTasksWhenLoadingFunc), arg0)
 }
 
+// SetRepairShardSender mocks base method.
+func (m *MockMigrater) SetRepairShardSender(arg0 client.ProxyAPI) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRepairShardSender", arg0)
+}
+
+// SetRepairShardSender indicates an expected call of SetRepairShardSender.
+func (mr *MockMigraterMockRecorder) SetRepairShardSender(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRepairShardSender", reflect.TypeOf((*MockMigrater)(nil).SetRepairShardSender), arg0)
+}
+
 // SetLockFailHandleFunc mocks base method.
 func (m *MockMigrater) SetLockFailHandleFunc(arg0 lockFailFunc) {
 	m.ctrl.T.Helper()
@@ -607,6 +691,18 @@ func (mr *MockMigraterMockRecorder) Stats() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockMigrater)(nil).Stats))
 }
 
+// StopDiskTasks mocks base method.
+func (m *MockMigrater) StopDiskTasks(arg0 proto.DiskID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StopDiskTasks", arg0)
+}
+
+// StopDiskTasks indicates an expected call of StopDiskTasks.
+func (mr *MockMigraterMockRecorder) StopDiskTasks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopDiskTasks", reflect.TypeOf((*MockMigrater)(nil).StopDiskTasks), arg0)
+}
+
 // WaitEnable mocks base method.
 func (m *MockMigrater) WaitEnable() {
 	m.ctrl.T.Helper()
@@ -38,11 +38,19 @@ type Service struct {
 	leaderHost    string
 	followerHosts []string
 
-	balanceMgr    Migrator
-	diskDropMgr   IDisKMigrator
-	diskRepairMgr IDisKMigrator
-	manualMigMgr  IManualMigrator
-	inspectMgr    IVolumeInspector
+	balanceMgr          Migrator
+	intraNodeBalanceMgr Migrator
+	diskDropMgr         IDiskDropMigrator
+	diskRepairMgr       IDisKMigrator
+	manualMigMgr        IManualMigrator
+	inspectMgr          IVolumeInspector
+
+	hostMaintenanceMgr *HostMaintenanceMgr
+	volumeFreezeMgr    *VolumeFreezeMgr
+	recoveryMgr        *RecoveryMgr
+	taskWindows        *workerTaskWindows
+	digestMgr          *DigestMgr
+	taskNotifier       *TaskNotifier
 
 	shardRepairMgr  ITaskRunner
 	blobDeleteMgr   ITaskRunner
@@ -59,6 +67,8 @@ func (svr *Service) mgrByType(typ proto.TaskType) (Migrator, error) {
 		return svr.diskRepairMgr, nil
 	case proto.TaskTypeBalance:
 		return svr.balanceMgr, nil
+	case proto.TaskTypeIntraNodeBalance:
+		return svr.intraNodeBalanceMgr, nil
 	case proto.TaskTypeDiskDrop:
 		return svr.diskDropMgr, nil
 	case proto.TaskTypeManualMigrate:
@@ -87,15 +97,21 @@ func (svr *Service) HTTPTaskAcquire(c *rpc.Context) {
 		return
 	}
 
+	if !svr.taskWindows.Allow(args.Worker, args.Running) {
+		c.RespondError(errcode.ErrNothingTodo)
+		return
+	}
+
 	// acquire task ordered: returns disk repair task first and other random
 	ctx := c.Request.Context()
-	migrators := []Migrator{svr.diskRepairMgr, svr.manualMigMgr, svr.diskDropMgr, svr.balanceMgr}
+	migrators := []Migrator{svr.diskRepairMgr, svr.manualMigMgr, svr.diskDropMgr, svr.balanceMgr, svr.intraNodeBalanceMgr}
 	shuffledMigrators := migrators[1:]
 	rand.Shuffle(len(shuffledMigrators), func(i, j int) {
 		shuffledMigrators[i], shuffledMigrators[j] = shuffledMigrators[j], shuffledMigrators[i]
 	})
 	for _, acquire := range migrators {
 		if migrateTask, err := acquire.AcquireTask(ctx, args.IDC); err == nil {
+			svr.taskNotifier.Notify(ctx, api.TaskEventAssigned, &migrateTask, args.Worker, "")
 			c.RespondJSON(migrateTask)
 			return
 		}
@@ -120,13 +136,17 @@ func (svr *Service) HTTPTaskReclaim(c *rpc.Context) {
 		c.RespondError(err)
 		return
 	}
+	svr.taskWindows.OnFail(args.Worker)
 
 	newDst, err := base.AllocVunitSafe(ctx, svr.clusterMgrCli, args.Dest.Vuid, args.Src)
 	if err != nil {
 		c.RespondError(err)
 		return
 	}
-	c.RespondError(reclaimer.ReclaimTask(ctx, args.IDC, args.TaskID, args.Src, args.Dest, newDst))
+	if err = reclaimer.ReclaimTask(ctx, args.IDC, args.TaskID, args.Src, args.Dest, newDst); err == nil {
+		svr.taskNotifier.NotifyArgs(ctx, api.TaskEventFailed, args)
+	}
+	c.RespondError(err)
 }
 
 // HTTPTaskCancel cancel task
@@ -146,7 +166,11 @@ func (svr *Service) HTTPTaskCancel(c *rpc.Context) {
 		c.RespondError(err)
 		return
 	}
-	c.RespondError(canceler.CancelTask(ctx, args))
+	svr.taskWindows.OnFail(args.Worker)
+	if err = canceler.CancelTask(ctx, args); err == nil {
+		svr.taskNotifier.NotifyArgs(ctx, api.TaskEventFailed, args)
+	}
+	c.RespondError(err)
 }
 
 // HTTPTaskComplete complete task
@@ -166,7 +190,11 @@ func (svr *Service) HTTPTaskComplete(c *rpc.Context) {
 		c.RespondError(err)
 		return
 	}
-	c.RespondError(completer.CompleteTask(ctx, args))
+	if err = completer.CompleteTask(ctx, args); err == nil {
+		svr.taskWindows.OnComplete(args.Worker)
+		svr.taskNotifier.NotifyArgs(ctx, api.TaskEventCompleted, args)
+	}
+	c.RespondError(err)
 }
 
 // HTTPInspectAcquire acquire inspect task
@@ -212,6 +240,7 @@ func (svr *Service) HTTPTaskRenewal(c *rpc.Context) {
 		}
 
 		errors := make(map[string]string)
+		var failed []string
 		for _, id := range ids {
 			if !client.ValidMigrateTask(typ, id) {
 				errors[id] = errcode.ErrIllegalArguments.Error()
@@ -219,8 +248,11 @@ func (svr *Service) HTTPTaskRenewal(c *rpc.Context) {
 			}
 			if err := renewaler.RenewalTask(ctx, args.IDC, id); err != nil {
 				errors[id] = err.Error()
+				failed = append(failed, id)
 			}
 		}
+		// the tasks lost by the scheduler are stopped by the worker, and reconciled by the recovery
+		svr.recoveryMgr.ReportOrphans(typ, failed)
 
 		if len(errors) > 0 {
 			typeErrors[typ] = errors
@@ -294,6 +326,135 @@ func (svr *Service) HTTPDiskMigratingStats(c *rpc.Context) {
 	c.RespondJSON(stats)
 }
 
+// HTTPDiskDropCancel cancels disk drop
+func (svr *Service) HTTPDiskDropCancel(c *rpc.Context) {
+	args := new(api.DiskDropCancelArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	report, err := svr.diskDropMgr.CancelDrop(c.Request.Context(), args.DiskID)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(report)
+}
+
+// HTTPDiskDropCancelReport returns report of cancelled disk drop
+func (svr *Service) HTTPDiskDropCancelReport(c *rpc.Context) {
+	args := new(api.DiskDropCancelArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	report, err := svr.diskDropMgr.CancelDropReport(c.Request.Context(), args.DiskID)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(report)
+}
+
+// HTTPHostDrain drains all disks of the host
+func (svr *Service) HTTPHostDrain(c *rpc.Context) {
+	args := new(api.HostDrainArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if args.Host == "" {
+		c.RespondError(errcode.ErrIllegalArguments)
+		return
+	}
+	report, err := svr.diskDropMgr.DrainHost(c.Request.Context(), args.Host)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(report)
+}
+
+// HTTPHostDrainReport returns report of host drain
+func (svr *Service) HTTPHostDrainReport(c *rpc.Context) {
+	args := new(api.HostDrainArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	report, err := svr.diskDropMgr.HostDrainReport(c.Request.Context(), args.Host)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(report)
+}
+
+// HTTPHostMaintenance starts or extends the maintenance window of the host
+func (svr *Service) HTTPHostMaintenance(c *rpc.Context) {
+	args := new(api.HostMaintenanceArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	ret, err := svr.hostMaintenanceMgr.SetHost(c.Request.Context(), args.Host, args.DurationS)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(ret)
+}
+
+// HTTPHostMaintenanceCancel ends the maintenance window of the host
+func (svr *Service) HTTPHostMaintenanceCancel(c *rpc.Context) {
+	args := new(api.HostMaintenanceArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondError(svr.hostMaintenanceMgr.CancelHost(c.Request.Context(), args.Host))
+}
+
+// HTTPHostMaintenanceList returns the hosts in maintenance window
+func (svr *Service) HTTPHostMaintenanceList(c *rpc.Context) {
+	c.RespondJSON(svr.hostMaintenanceMgr.ListHosts())
+}
+
+// HTTPVolumeFreeze freezes or extends the freeze of the volume
+func (svr *Service) HTTPVolumeFreeze(c *rpc.Context) {
+	args := new(api.VolumeFreezeArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	ret, err := svr.volumeFreezeMgr.Freeze(c.Request.Context(), args)
+	if err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(ret)
+}
+
+// HTTPVolumeFreezeCancel ends the freeze of the volume
+func (svr *Service) HTTPVolumeFreezeCancel(c *rpc.Context) {
+	args := new(api.VolumeFreezeArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	c.RespondError(svr.volumeFreezeMgr.Unfreeze(c.Request.Context(), args))
+}
+
+// HTTPVolumeFreezeList returns the frozen volumes
+func (svr *Service) HTTPVolumeFreezeList(c *rpc.Context) {
+	c.RespondJSON(svr.volumeFreezeMgr.List())
+}
+
+// HTTPRecoveryReport returns the report of the cold start recovery
+func (svr *Service) HTTPRecoveryReport(c *rpc.Context) {
+	c.RespondJSON(svr.recoveryMgr.Report())
+}
+
 // HTTPStats returns service stats
 func (svr *Service) HTTPStats(c *rpc.Context) {
 	ctx := c.Request.Context()
@@ -351,6 +512,10 @@ func (svr *Service) HTTPStats(c *rpc.Context) {
 		Enable:           svr.balanceMgr.Enabled(),
 		MigrateTasksStat: svr.balanceMgr.Stats(),
 	}
+	taskStats.IntraNodeBalance = &api.BalanceTasksStat{
+		Enable:           svr.intraNodeBalanceMgr.Enabled(),
+		MigrateTasksStat: svr.intraNodeBalanceMgr.Stats(),
+	}
 
 	// stats manual migrate tasks
 	taskStats.ManualMigrate = &api.ManualMigrateTasksStat{
@@ -364,6 +529,7 @@ func (svr *Service) HTTPStats(c *rpc.Context) {
 		FinishedPerMin: fmt.Sprint(finished),
 		TimeOutPerMin:  fmt.Sprint(timeout),
 	}
+	taskStats.WorkerTaskWindows = svr.taskWindows.Stats()
 
 	c.RespondJSON(taskStats)
 }
@@ -26,6 +26,7 @@ import (
 	cmapi "github.com/cubefs/cubefs/blobstore/api/clustermgr"
 	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/common/counter"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/rpc"
 	"github.com/cubefs/cubefs/blobstore/scheduler/client"
@@ -54,6 +55,7 @@ func newMockService(t *testing.T) *Service {
 	diskRepairMgr := NewMockMigrater(ctr)
 	manualMgr := NewMockMigrater(ctr)
 	balanceMgr := NewMockMigrater(ctr)
+	intraNodeBalanceMgr := NewMockMigrater(ctr)
 	inspectorMgr := NewMockVolumeInspector(ctr)
 	clusterTopology := NewMockClusterTopology(ctr)
 
@@ -137,6 +139,8 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().Enabled().Return(true)
 	balanceMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
 	balanceMgr.EXPECT().Enabled().Return(true)
+	intraNodeBalanceMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
+	intraNodeBalanceMgr.EXPECT().Enabled().Return(true)
 	manualMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
 	inspectorMgr.EXPECT().GetTaskStats().Return([counter.SLOT]int{}, [counter.SLOT]int{})
 	inspectorMgr.EXPECT().Enabled().Return(true)
@@ -158,14 +162,17 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().DiskProgress(any, any).Return(&api.DiskMigratingStats{TotalTasksCnt: int(testDisk1.UsedChunkCnt), MigratedTasksCnt: 1}, nil)
 
 	service := &Service{
-		ClusterID:     1,
-		leader:        true,
-		leaderHost:    localHost + ":9800",
-		balanceMgr:    balanceMgr,
-		diskDropMgr:   diskDropMgr,
-		manualMigMgr:  manualMgr,
-		diskRepairMgr: diskRepairMgr,
-		inspectMgr:    inspectorMgr,
+		ClusterID:           1,
+		leader:              true,
+		leaderHost:          localHost + ":9800",
+		balanceMgr:          balanceMgr,
+		intraNodeBalanceMgr: intraNodeBalanceMgr,
+		diskDropMgr:         diskDropMgr,
+		manualMigMgr:        manualMgr,
+		diskRepairMgr:       diskRepairMgr,
+		inspectMgr:          inspectorMgr,
+		taskWindows:         newWorkerTaskWindows(TaskWindowConfig{Enable: true, MinTasks: 1, MaxTasks: 8, InitTasks: 2}),
+		recoveryMgr:         NewRecoveryMgr(clusterMgrCli, RecoveryConfig{}, 1),
 
 		shardRepairMgr:  shardRepairMgr,
 		blobDeleteMgr:   blobDeleteMgr,
@@ -196,6 +203,9 @@ func TestServiceAPI(t *testing.T) {
 	task, err := cli.AcquireTask(ctx, &api.AcquireArgs{IDC: idc})
 	require.NoError(t, err)
 	require.Equal(t, proto.TaskTypeDiskRepair, task.TaskType)
+	// the worker running the tasks of its window acquires nothing
+	_, err = cli.AcquireTask(ctx, &api.AcquireArgs{IDC: idc, Worker: "worker", Running: 2})
+	require.Equal(t, errcode.CodeNotingTodo, rpc.DetectStatusCode(err))
 
 	for _, taskType := range taskTypes {
 		require.NoError(t, cli.ReclaimTask(ctx, &api.OperateTaskArgs{IDC: idc, TaskType: taskType, TaskID: client.GenMigrateTaskID(taskType, diskID, volumeID)}))
@@ -256,6 +266,11 @@ func TestServiceAPI(t *testing.T) {
 	_, err = cli.Stats(ctx, schedulerServer.URL)
 	require.NoError(t, err)
 
+	// recovery report
+	report, err := cli.RecoveryReport(ctx)
+	require.NoError(t, err)
+	require.False(t, report.Enable)
+
 	// task detail
 	{
 		_, err = cli.DetailMigrateTask(ctx, nil)
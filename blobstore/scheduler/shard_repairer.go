@@ -338,6 +338,11 @@ func (mgr *ShardRepairMgr) consume(ctx context.Context, repairMsg *proto.ShardRe
 			return shardRepairRet{status: ShardRepairStatusUndo}
 		}
 	}
+	// the shards of the frozen volume are not changed and the message is retried by the fail queue
+	if base.VolumeFreezeInst().Frozen(repairMsg.Vid) {
+		return shardRepairRet{status: ShardRepairStatusFailed, err: errcode.ErrVolumeFrozen}
+	}
+
 	jobKey := fmt.Sprintf("%d:%d:%s", repairMsg.Vid, repairMsg.Bid, repairMsg.BadIdx)
 	_, err, _ := mgr.group.Do(jobKey, func() (ret interface{}, e error) {
 		e = mgr.repairWithCheckVolConsistency(ctx, repairMsg)
@@ -182,6 +182,14 @@ func TestConsumerShardRepairMsg(t *testing.T) {
 		ret := mgr.consume(ctx, msg, consuming)
 		require.Equal(t, ShardRepairStatusUndo, ret.status)
 	}
+	{
+		// the shards of the frozen volume are not repaired
+		base.VolumeFreezeInst().Update(map[proto.Vid]int64{msg.Vid: time.Now().Unix() + 60})
+		ret := mgr.consume(ctx, msg, commonCloser)
+		base.VolumeFreezeInst().Update(nil)
+		require.Equal(t, ShardRepairStatusFailed, ret.status)
+		require.ErrorIs(t, ret.err, errcode.ErrVolumeFrozen)
+	}
 	{
 		// message punished and consume success
 		msg := &proto.ShardRepairMsg{Bid: 1, Vid: 1, ReqId: "123456", BadIdx: []uint8{0, 1}, Retry: defaultMessagePunishThreshold}
@@ -104,7 +104,13 @@ func NewService(conf *Config) (svr *Service, err error) {
 		kafkaMonitors: make([]*base.KafkaTopicMonitor, 0),
 	}
 
-	clusterMgrCli := client.NewClusterMgrClient(&conf.ClusterMgr)
+	taskNotifier, err := NewTaskNotifier(conf.ClusterID, conf.TaskNotify)
+	if err != nil {
+		log.Errorf("new task notifier: cfg[%+v], err[%w]", conf.TaskNotify, err)
+		return nil, err
+	}
+	svr.taskNotifier = taskNotifier
+	clusterMgrCli := taskNotifier.WrapClusterMgr(client.NewClusterMgrClient(&conf.ClusterMgr))
 
 	blobnodeCli := client.NewBlobnodeClient(&conf.Blobnode)
 	switchMgr := taskswitch.NewSwitchMgr(clusterMgrCli)
@@ -182,20 +188,50 @@ func NewService(conf *Config) (svr *Service, err error) {
 
 	diskRepairMgr := NewDiskRepairMgr(clusterMgrCli, diskRepairTaskSwitch, taskLogger, &conf.DiskRepair)
 
+	// the disks of the cross node balance and disk drop are not balanced in the node
+	intraNodeBalanceTaskSwitch, err := switchMgr.AddSwitch(proto.TaskTypeIntraNodeBalance.String())
+	if err != nil {
+		return nil, err
+	}
+	intraNodeBalanceMgr := NewIntraNodeBalanceMgr(clusterMgrCli, volumeUpdater, intraNodeBalanceTaskSwitch,
+		topologyMgr, taskLogger, &conf.IntraNodeBalance, balanceMgr, diskDropMgr)
+	balanceMgr.SetConflictMgrs(intraNodeBalanceMgr)
+
 	manualMigMgr := NewManualMigrateMgr(clusterMgrCli, volumeUpdater, taskLogger, &conf.ManualMigrate)
 
 	mqProxy := client.NewProxyClient(&conf.Proxy, cmapi.New(&conf.ClusterMgr), conf.ClusterID)
+	balanceMgr.SetRepairShardSender(mqProxy)
+	diskDropMgr.SetRepairShardSender(mqProxy)
+	intraNodeBalanceMgr.SetRepairShardSender(mqProxy)
+	manualMigMgr.SetRepairShardSender(mqProxy)
 	inspectorTaskSwitch, err := switchMgr.AddSwitch(proto.TaskTypeVolumeInspect.String())
 	if err != nil {
 		return nil, err
 	}
 	inspectMgr := NewVolumeInspectMgr(clusterMgrCli, mqProxy, inspectorTaskSwitch, &conf.VolumeInspect)
 
+	svr.hostMaintenanceMgr = NewHostMaintenanceMgr(clusterMgrCli)
+	svr.volumeFreezeMgr = NewVolumeFreezeMgr(clusterMgrCli)
+	svr.recoveryMgr = NewRecoveryMgr(clusterMgrCli, conf.Recovery, conf.DiskDrop.DiskConcurrency)
+	svr.taskWindows = newWorkerTaskWindows(conf.TaskWindow)
 	svr.balanceMgr = balanceMgr
+	svr.intraNodeBalanceMgr = intraNodeBalanceMgr
 	svr.diskDropMgr = diskDropMgr
 	svr.manualMigMgr = manualMigMgr
 	svr.diskRepairMgr = diskRepairMgr
 	svr.inspectMgr = inspectMgr
+	svr.digestMgr = NewDigestMgr(conf.ClusterID, conf.Digest, conf.Leader(), conf.Follower(),
+		map[proto.TaskType]Migrator{
+			proto.TaskTypeDiskRepair:       diskRepairMgr,
+			proto.TaskTypeDiskDrop:         diskDropMgr,
+			proto.TaskTypeBalance:          balanceMgr,
+			proto.TaskTypeIntraNodeBalance: intraNodeBalanceMgr,
+			proto.TaskTypeManualMigrate:    manualMigMgr,
+		},
+		map[proto.TaskType]ITaskRunner{
+			proto.TaskTypeShardRepair: shardRepairMgr,
+			proto.TaskTypeBlobDelete:  deleteMgr,
+		}, diskRepairMgr, diskDropMgr)
 
 	err = svr.waitAndLoad()
 	if err != nil {
@@ -216,12 +252,26 @@ func (svr *Service) waitAndLoad() error {
 }
 
 func (svr *Service) load() (err error) {
+	// hold the tasks on the hosts in maintenance and of the frozen volumes before any task is loaded
+	if err = svr.hostMaintenanceMgr.Load(); err != nil {
+		return
+	}
+	if err = svr.volumeFreezeMgr.Load(); err != nil {
+		return
+	}
+	// record the lost migrating disks again before the disk repair and disk drop are loaded
+	if err = svr.recoveryMgr.RebuildDisks(); err != nil {
+		return
+	}
 	if err = svr.diskRepairMgr.Load(); err != nil {
 		return
 	}
 	if err = svr.balanceMgr.Load(); err != nil {
 		return
 	}
+	if err = svr.intraNodeBalanceMgr.Load(); err != nil {
+		return
+	}
 	if err = svr.diskDropMgr.Load(); err != nil {
 		return
 	}
@@ -247,11 +297,17 @@ func (svr *Service) register(cfg ServiceRegisterConfig) error {
 
 // Run run task
 func (svr *Service) Run() {
+	svr.hostMaintenanceMgr.Run()
+	svr.volumeFreezeMgr.Run()
+	svr.recoveryMgr.Run()
 	svr.diskRepairMgr.Run()
 	svr.balanceMgr.Run()
+	svr.intraNodeBalanceMgr.Run()
 	svr.diskDropMgr.Run()
 	svr.manualMigMgr.Run()
 	svr.inspectMgr.Run()
+	svr.digestMgr.Run()
+	svr.taskNotifier.Run()
 }
 
 // RunTask run shard repair and blob delete tasks
@@ -347,10 +403,18 @@ func (svr *Service) Close() {
 	}
 	svr.CloseKafkaMonitors()
 	svr.balanceMgr.Close()
+	svr.intraNodeBalanceMgr.Close()
 	svr.diskRepairMgr.Close()
 	svr.diskDropMgr.Close()
 	svr.manualMigMgr.Close()
 	svr.inspectMgr.Close()
+	svr.hostMaintenanceMgr.Close()
+	svr.volumeFreezeMgr.Close()
+	svr.recoveryMgr.Close()
+	svr.digestMgr.Close()
+	if svr.taskNotifier != nil {
+		svr.taskNotifier.Close()
+	}
 }
 
 // NewHandler returns app server handler
@@ -358,6 +422,10 @@ func NewHandler(service *Service) *rpc.Router {
 	rpc.RegisterArgsParser(&api.AcquireArgs{}, "json")
 	rpc.RegisterArgsParser(&api.DiskMigratingStatsArgs{}, "json")
 	rpc.RegisterArgsParser(&api.MigrateTaskDetailArgs{}, "json")
+	rpc.RegisterArgsParser(&api.DiskDropCancelArgs{}, "json")
+	rpc.RegisterArgsParser(&api.HostDrainArgs{}, "json")
+	rpc.RegisterArgsParser(&api.HostMaintenanceArgs{}, "json")
+	rpc.RegisterArgsParser(&api.VolumeFreezeArgs{}, "json")
 
 	// rpc http svr interface
 	rpc.GET(api.PathTaskAcquire, service.HTTPTaskAcquire, rpc.OptArgsQuery())
@@ -376,6 +444,17 @@ func NewHandler(service *Service) *rpc.Router {
 	rpc.GET(api.PathStats, service.HTTPStats, rpc.OptArgsQuery())
 	rpc.GET(api.PathStatsLeader, service.HTTPStats, rpc.OptArgsQuery())
 	rpc.GET(api.PathStatsDiskMigrating, service.HTTPDiskMigratingStats, rpc.OptArgsQuery())
+	rpc.POST(api.PathDiskDropCancel, service.HTTPDiskDropCancel, rpc.OptArgsBody())
+	rpc.GET(api.PathDiskDropCancelReport, service.HTTPDiskDropCancelReport, rpc.OptArgsQuery())
+	rpc.POST(api.PathHostDrain, service.HTTPHostDrain, rpc.OptArgsBody())
+	rpc.GET(api.PathHostDrainReport, service.HTTPHostDrainReport, rpc.OptArgsQuery())
+	rpc.POST(api.PathHostMaintenance, service.HTTPHostMaintenance, rpc.OptArgsBody())
+	rpc.POST(api.PathHostMaintenanceCancel, service.HTTPHostMaintenanceCancel, rpc.OptArgsBody())
+	rpc.GET(api.PathHostMaintenanceList, service.HTTPHostMaintenanceList)
+	rpc.POST(api.PathVolumeFreeze, service.HTTPVolumeFreeze, rpc.OptArgsBody())
+	rpc.POST(api.PathVolumeFreezeCancel, service.HTTPVolumeFreezeCancel, rpc.OptArgsBody())
+	rpc.GET(api.PathVolumeFreezeList, service.HTTPVolumeFreezeList)
+	rpc.GET(api.PathRecoveryReport, service.HTTPRecoveryReport)
 
 	rpc.POST(api.PathUpdateVolume, service.HTTPUpdateVolume, rpc.OptArgsBody())
 
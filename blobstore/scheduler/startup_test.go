@@ -100,17 +100,20 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr := NewMockMigrater(ctr)
 	manualMgr := NewMockMigrater(ctr)
 	balanceMgr := NewMockMigrater(ctr)
+	intraNodeBalanceMgr := NewMockMigrater(ctr)
 	inspecterMgr := NewMockVolumeInspector(ctr)
 	clusterTopology := NewMockClusterTopology(ctr)
 	volumeUpdater := NewMockVolumeUpdater(ctr)
 
 	balanceMgr.EXPECT().Close().AnyTimes().Return()
+	intraNodeBalanceMgr.EXPECT().Close().AnyTimes().Return()
 	diskRepairMgr.EXPECT().Close().AnyTimes().Return()
 	diskDropMgr.EXPECT().Close().AnyTimes().Return()
 	manualMgr.EXPECT().Close().AnyTimes().Return()
 	inspecterMgr.EXPECT().Close().AnyTimes().Return()
 
 	balanceMgr.EXPECT().Run().AnyTimes().Return()
+	intraNodeBalanceMgr.EXPECT().Run().AnyTimes().Return()
 	diskDropMgr.EXPECT().Run().AnyTimes().Return()
 	diskRepairMgr.EXPECT().Run().AnyTimes().Return()
 	inspecterMgr.EXPECT().Run().AnyTimes().Return()
@@ -123,6 +126,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	blobDeleteMgr.EXPECT().Close().AnyTimes().Return()
 
 	balanceMgr.EXPECT().Load().AnyTimes().Return(nil)
+	intraNodeBalanceMgr.EXPECT().Load().AnyTimes().Return(nil)
 	diskRepairMgr.EXPECT().Load().AnyTimes().Return(nil)
 	diskDropMgr.EXPECT().Load().AnyTimes().Return(nil)
 	manualMgr.EXPECT().Load().AnyTimes().Return(nil)
@@ -141,6 +145,8 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskDropMgr.EXPECT().Enabled().AnyTimes().Return(true)
 	balanceMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
 	balanceMgr.EXPECT().Enabled().AnyTimes().Return(true)
+	intraNodeBalanceMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
+	intraNodeBalanceMgr.EXPECT().Enabled().AnyTimes().Return(true)
 	manualMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
 	inspecterMgr.EXPECT().GetTaskStats().AnyTimes().Return([counter.SLOT]int{}, [counter.SLOT]int{})
 	inspecterMgr.EXPECT().Enabled().AnyTimes().Return(true)
@@ -152,23 +158,32 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 	diskDropMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 	balanceMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
+	intraNodeBalanceMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 
 	clusterTopology.EXPECT().UpdateVolume(any).AnyTimes().Return(&client.VolumeInfoSimple{}, nil)
 	clusterMgrCli.EXPECT().GetConfig(any, any).AnyTimes().Return("", errMock)
+	clusterMgrCli.EXPECT().ListMaintenanceHosts(any).AnyTimes().Return(nil, nil)
+	clusterMgrCli.EXPECT().ListFrozenVolumes(any).AnyTimes().Return(nil, nil)
 
 	service := &Service{
-		ClusterID:       1,
-		leader:          isLeader,
-		balanceMgr:      balanceMgr,
-		diskDropMgr:     diskDropMgr,
-		manualMigMgr:    manualMgr,
-		diskRepairMgr:   diskRepairMgr,
-		inspectMgr:      inspecterMgr,
-		shardRepairMgr:  shardRepairMgr,
-		blobDeleteMgr:   blobDeleteMgr,
-		clusterTopology: clusterTopology,
-		volumeUpdater:   volumeUpdater,
-		clusterMgrCli:   clusterMgrCli,
+		ClusterID:           1,
+		leader:              isLeader,
+		balanceMgr:          balanceMgr,
+		intraNodeBalanceMgr: intraNodeBalanceMgr,
+		diskDropMgr:         diskDropMgr,
+		manualMigMgr:        manualMgr,
+		diskRepairMgr:       diskRepairMgr,
+		inspectMgr:          inspecterMgr,
+		shardRepairMgr:      shardRepairMgr,
+		blobDeleteMgr:       blobDeleteMgr,
+		clusterTopology:     clusterTopology,
+		volumeUpdater:       volumeUpdater,
+		clusterMgrCli:       clusterMgrCli,
+
+		hostMaintenanceMgr: NewHostMaintenanceMgr(clusterMgrCli),
+		volumeFreezeMgr:    NewVolumeFreezeMgr(clusterMgrCli),
+		recoveryMgr:        NewRecoveryMgr(clusterMgrCli, RecoveryConfig{}, 1),
+		digestMgr:          NewDigestMgr(1, DigestConfig{}, "", nil, nil, nil, nil, nil),
 	}
 	return service
 }
@@ -0,0 +1,220 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/kafka"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const (
+	defaultTaskNotifyQueueSize = 10000
+	defaultTaskNotifyBatchSize = 100
+	defaultTaskNotifyTimeoutMs = int64(3000)
+)
+
+// TaskNotifyConfig is the config of the publishing of the task events, the events are published to
+// the kafka topic one message per event, and posted to the webhook in json array of the batch.
+type TaskNotifyConfig struct {
+	WebhookURL string            `json:"webhook_url"`
+	Kafka      kafka.ProducerCfg `json:"kafka"`
+	QueueSize  int               `json:"queue_size"`
+	BatchSize  int               `json:"batch_size"`
+	TimeoutMs  int64             `json:"timeout_ms"`
+}
+
+// TaskEventSink publishes the task events to an external system.
+type TaskEventSink interface {
+	Name() string
+	Publish(ctx context.Context, events []*api.TaskEvent) error
+}
+
+type webhookEventSink struct {
+	url string
+	cli rpc.Client
+}
+
+func (s *webhookEventSink) Name() string { return "webhook" }
+
+func (s *webhookEventSink) Publish(ctx context.Context, events []*api.TaskEvent) error {
+	return s.cli.PostWith(ctx, s.url, nil, events)
+}
+
+type kafkaEventSink struct {
+	producer base.IProducer
+}
+
+func (s *kafkaEventSink) Name() string { return "kafka" }
+
+func (s *kafkaEventSink) Publish(ctx context.Context, events []*api.TaskEvent) error {
+	msgs := make([][]byte, 0, len(events))
+	for _, event := range events {
+		msg, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return s.producer.SendMessages(msgs)
+}
+
+// TaskNotifier publishes the lifecycle events of the migrate tasks to the sinks asynchronously, so
+// that the external workflow systems coordinate with the background tasks. The events are dropped
+// once the queue is full, the scheduling of the tasks is never blocked by the sinks.
+type TaskNotifier struct {
+	closer.Closer
+
+	clusterID proto.ClusterID
+	batchSize int
+	sinks     []TaskEventSink
+	eventC    chan *api.TaskEvent
+}
+
+// NewTaskNotifier returns task notifier with the sinks of the config and the extra sinks.
+func NewTaskNotifier(clusterID proto.ClusterID, conf TaskNotifyConfig, extraSinks ...TaskEventSink) (*TaskNotifier, error) {
+	n := &TaskNotifier{
+		Closer:    closer.New(),
+		clusterID: clusterID,
+		batchSize: conf.BatchSize,
+		eventC:    make(chan *api.TaskEvent, conf.QueueSize),
+	}
+	if conf.WebhookURL != "" {
+		n.sinks = append(n.sinks, &webhookEventSink{
+			url: conf.WebhookURL,
+			cli: rpc.NewClient(&rpc.Config{ClientTimeoutMs: conf.TimeoutMs}),
+		})
+	}
+	if len(conf.Kafka.BrokerList) > 0 && conf.Kafka.Topic != "" {
+		producer, err := base.NewMsgSender(&conf.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		n.sinks = append(n.sinks, &kafkaEventSink{producer: producer})
+	}
+	n.sinks = append(n.sinks, extraSinks...)
+	return n, nil
+}
+
+// Run publishes the events until closed
+func (n *TaskNotifier) Run() {
+	if n == nil || len(n.sinks) == 0 {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case event := <-n.eventC:
+				n.publish(n.batch(event))
+			case <-n.Done():
+				return
+			}
+		}
+	}()
+}
+
+// batch returns the event and the events queued after it, at most the batch size.
+func (n *TaskNotifier) batch(event *api.TaskEvent) []*api.TaskEvent {
+	events := []*api.TaskEvent{event}
+	for len(events) < n.batchSize {
+		select {
+		case event = <-n.eventC:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+	return events
+}
+
+func (n *TaskNotifier) publish(events []*api.TaskEvent) {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "PublishTaskEvents")
+	for _, sink := range n.sinks {
+		if err := sink.Publish(ctx, events); err != nil {
+			span.Errorf("publish task events failed: sink[%s], events[%d], err[%+v]", sink.Name(), len(events), err)
+		}
+	}
+}
+
+// Notify queues the event of the task.
+func (n *TaskNotifier) Notify(ctx context.Context, event api.TaskEventType, task *proto.MigrateTask, worker, reason string) {
+	if n == nil || len(n.sinks) == 0 {
+		return
+	}
+	e := &api.TaskEvent{
+		SchemaVersion: api.TaskEventSchemaVersion,
+		Event:         event,
+		Time:          time.Now(),
+		ClusterID:     n.clusterID,
+		TaskID:        task.TaskID,
+		TaskType:      task.TaskType,
+		IDC:           task.SourceIDC,
+		Vid:           task.Vid(),
+		SourceDiskID:  task.SourceDiskID,
+		Worker:        worker,
+		Reason:        reason,
+	}
+	if task.Destination.Vuid != 0 {
+		dest := task.Destination
+		e.Destination = &dest
+	}
+	select {
+	case n.eventC <- e:
+	default:
+		span := trace.SpanFromContextSafe(ctx)
+		span.Warnf("task event dropped since queue is full: event[%s], task_id[%s]", event, task.TaskID)
+	}
+}
+
+// NotifyArgs queues the event of the task operated by the worker.
+func (n *TaskNotifier) NotifyArgs(ctx context.Context, event api.TaskEventType, args *api.OperateTaskArgs) {
+	n.Notify(ctx, event, &proto.MigrateTask{
+		TaskID:      args.TaskID,
+		TaskType:    args.TaskType,
+		SourceIDC:   args.IDC,
+		Destination: args.Dest,
+	}, args.Worker, args.Reason)
+}
+
+// notifyClusterMgr notifies the creation of the migrate tasks once they are added to clustermgr,
+// which covers the tasks generated by all the migrators.
+type notifyClusterMgr struct {
+	client.ClusterMgrAPI
+	notifier *TaskNotifier
+}
+
+func (c *notifyClusterMgr) AddMigrateTask(ctx context.Context, value *proto.MigrateTask) (err error) {
+	if err = c.ClusterMgrAPI.AddMigrateTask(ctx, value); err == nil {
+		c.notifier.Notify(ctx, api.TaskEventCreated, value, "", "")
+	}
+	return
+}
+
+// WrapClusterMgr returns the clustermgr client notifying the creation of the migrate tasks.
+func (n *TaskNotifier) WrapClusterMgr(cli client.ClusterMgrAPI) client.ClusterMgrAPI {
+	if n == nil || len(n.sinks) == 0 {
+		return cli
+	}
+	return &notifyClusterMgr{ClusterMgrAPI: cli, notifier: n}
+}
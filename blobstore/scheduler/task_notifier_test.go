@@ -0,0 +1,127 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+type testEventSink struct {
+	mu     sync.Mutex
+	events []*api.TaskEvent
+}
+
+func (s *testEventSink) Name() string { return "test" }
+
+func (s *testEventSink) Publish(ctx context.Context, events []*api.TaskEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *testEventSink) get() []*api.TaskEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*api.TaskEvent{}, s.events...)
+}
+
+func TestTaskNotifier(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+
+	// no sink
+	n, err := NewTaskNotifier(1, TaskNotifyConfig{QueueSize: 1, BatchSize: 1})
+	require.NoError(t, err)
+	cmCli := NewMockClusterMgrAPI(ctr)
+	require.Equal(t, cmCli, n.WrapClusterMgr(cmCli))
+	n.Notify(ctx, api.TaskEventAssigned, &proto.MigrateTask{TaskID: "t0"}, "", "")
+	require.Len(t, n.eventC, 0)
+	var nilNotifier *TaskNotifier
+	nilNotifier.Notify(ctx, api.TaskEventAssigned, &proto.MigrateTask{TaskID: "t0"}, "", "")
+
+	var posted []*api.TaskEvent
+	var postedMu sync.Mutex
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*api.TaskEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&events))
+		postedMu.Lock()
+		posted = append(posted, events...)
+		postedMu.Unlock()
+	}))
+	defer webhook.Close()
+
+	sink := &testEventSink{}
+	n, err = NewTaskNotifier(1, TaskNotifyConfig{WebhookURL: webhook.URL, QueueSize: 10, BatchSize: 2, TimeoutMs: 1000}, sink)
+	require.NoError(t, err)
+	defer n.Close()
+
+	// the created events are notified by the clustermgr client once added
+	cmCli.EXPECT().AddMigrateTask(any, any).Return(errors.New("fake error"))
+	cmCli.EXPECT().AddMigrateTask(any, any).Return(nil)
+	wrapped := n.WrapClusterMgr(cmCli)
+	task := &proto.MigrateTask{
+		TaskID: "t1", TaskType: proto.TaskTypeBalance, SourceIDC: "z0", SourceDiskID: 3,
+		SourceVuid: proto.EncodeVuid(proto.EncodeVuidPrefix(100, 1), 1),
+	}
+	require.Error(t, wrapped.AddMigrateTask(ctx, task))
+	require.NoError(t, wrapped.AddMigrateTask(ctx, task))
+	task.Destination = proto.VunitLocation{Vuid: 10, Host: "dst", DiskID: 5}
+	n.Notify(ctx, api.TaskEventAssigned, task, "worker1", "")
+	n.NotifyArgs(ctx, api.TaskEventFailed, &api.OperateTaskArgs{
+		TaskID: "t1", TaskType: proto.TaskTypeBalance, IDC: "z0", Worker: "worker1", Reason: "broken dst",
+	})
+	require.Len(t, n.eventC, 3)
+	n.Run()
+
+	require.Eventually(t, func() bool { return len(sink.get()) == 3 }, 3*time.Second, 10*time.Millisecond)
+	events := sink.get()
+	require.Equal(t, api.TaskEventCreated, events[0].Event)
+	require.Equal(t, api.TaskEventSchemaVersion, events[0].SchemaVersion)
+	require.Equal(t, proto.ClusterID(1), events[0].ClusterID)
+	require.Equal(t, proto.Vid(100), events[0].Vid)
+	require.Equal(t, proto.DiskID(3), events[0].SourceDiskID)
+	require.Nil(t, events[0].Destination)
+	require.Equal(t, api.TaskEventAssigned, events[1].Event)
+	require.Equal(t, "worker1", events[1].Worker)
+	require.Equal(t, proto.DiskID(5), events[1].Destination.DiskID)
+	require.Equal(t, api.TaskEventFailed, events[2].Event)
+	require.Equal(t, "broken dst", events[2].Reason)
+	require.Eventually(t, func() bool {
+		postedMu.Lock()
+		defer postedMu.Unlock()
+		return len(posted) == 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// the events are dropped once the queue is full
+	full, err := NewTaskNotifier(1, TaskNotifyConfig{QueueSize: 1, BatchSize: 1}, sink)
+	require.NoError(t, err)
+	full.Notify(ctx, api.TaskEventCompleted, task, "", "")
+	full.Notify(ctx, api.TaskEventCompleted, task, "", "")
+	require.Len(t, full.eventC, 1)
+}
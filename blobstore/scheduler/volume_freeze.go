@@ -0,0 +1,202 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const (
+	// the frozen volumes written into clustermgr kv by others are applied in the interval
+	volumeFreezeRefreshInterval = 10 * time.Second
+	maxVolumeFreezeDurationS    = int64(30 * 24 * 3600)
+)
+
+// VolumeFreezeMgr manages the volumes frozen for investigation such as corruption incidents.
+// The repair and migrate tasks of these volumes are not assigned to workers, the running ones
+// are released at the next renewal, and the shard repair messages of them are retried later,
+// so that the shard layout of the volumes is kept until the freeze ends or is canceled.
+type VolumeFreezeMgr struct {
+	closer.Closer
+
+	clusterMgrCli client.ClusterMgrAPI
+
+	mu   sync.Mutex
+	vols map[proto.Vid]*client.FrozenVolumeMeta
+}
+
+// NewVolumeFreezeMgr returns volume freeze manager
+func NewVolumeFreezeMgr(clusterMgrCli client.ClusterMgrAPI) *VolumeFreezeMgr {
+	return &VolumeFreezeMgr{
+		Closer:        closer.New(),
+		clusterMgrCli: clusterMgrCli,
+		vols:          make(map[proto.Vid]*client.FrozenVolumeMeta),
+	}
+}
+
+// Load loads frozen volumes from clustermgr
+func (mgr *VolumeFreezeMgr) Load() error {
+	return mgr.refresh(context.Background())
+}
+
+// Run refreshes frozen volumes in background
+func (mgr *VolumeFreezeMgr) Run() {
+	go func() {
+		t := time.NewTicker(volumeFreezeRefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				span, ctx := trace.StartSpanFromContext(context.Background(), "refreshFrozenVolumes")
+				if err := mgr.refresh(ctx); err != nil {
+					span.Errorf("refresh frozen volumes failed: err[%+v]", err)
+				}
+			case <-mgr.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (mgr *VolumeFreezeMgr) refresh(ctx context.Context) error {
+	span := trace.SpanFromContextSafe(ctx)
+
+	metas, err := mgr.clusterMgrCli.ListFrozenVolumes(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	now := time.Now().Unix()
+	vols := make(map[proto.Vid]*client.FrozenVolumeMeta, len(metas))
+	for _, meta := range metas {
+		if meta.Expired(now) {
+			span.Warnf("volume freeze expired: vid[%d], operator[%s], reason[%s]", meta.Vid, meta.Operator, meta.Reason)
+			if err := mgr.clusterMgrCli.DeleteFrozenVolume(ctx, meta.Vid); err != nil {
+				span.Warnf("delete frozen volume failed: vid[%d], err[%+v]", meta.Vid, err)
+			}
+			continue
+		}
+		vols[meta.Vid] = meta
+	}
+	mgr.vols = vols
+	mgr.apply()
+	return nil
+}
+
+// apply shares the frozen volumes with all task managers, must be called with lock
+func (mgr *VolumeFreezeMgr) apply() {
+	vids := make(map[proto.Vid]int64, len(mgr.vols))
+	for vid, meta := range mgr.vols {
+		vids[vid] = meta.EndTime
+	}
+	base.VolumeFreezeInst().Update(vids)
+}
+
+// Freeze freezes or extends the freeze of the volume
+func (mgr *VolumeFreezeMgr) Freeze(ctx context.Context, args *api.VolumeFreezeArgs) (*api.VolumeFreeze, error) {
+	span := trace.SpanFromContextSafe(ctx)
+	if args.Vid == proto.InvalidVid || args.Operator == "" ||
+		args.DurationS <= 0 || args.DurationS > maxVolumeFreezeDurationS {
+		return nil, errcode.ErrIllegalArguments
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	now := time.Now().Unix()
+	meta := &client.FrozenVolumeMeta{
+		Vid:       args.Vid,
+		Operator:  args.Operator,
+		Reason:    args.Reason,
+		StartTime: now,
+		EndTime:   now + args.DurationS,
+	}
+	if old, ok := mgr.vols[args.Vid]; ok && !old.Expired(now) {
+		meta.StartTime = old.StartTime
+	}
+	if err := mgr.clusterMgrCli.SetFrozenVolume(ctx, meta); err != nil {
+		span.Errorf("set frozen volume failed: vid[%d], err[%+v]", args.Vid, err)
+		return nil, err
+	}
+	mgr.vols[args.Vid] = meta
+	mgr.apply()
+	span.Warnf("volume frozen: vid[%d], operator[%s], reason[%s], end_time[%d]",
+		meta.Vid, meta.Operator, meta.Reason, meta.EndTime)
+	return toVolumeFreeze(meta), nil
+}
+
+// Unfreeze ends the freeze of the volume
+func (mgr *VolumeFreezeMgr) Unfreeze(ctx context.Context, args *api.VolumeFreezeArgs) error {
+	span := trace.SpanFromContextSafe(ctx)
+	if args.Operator == "" {
+		return errcode.ErrIllegalArguments
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	meta, ok := mgr.vols[args.Vid]
+	if !ok {
+		return errcode.ErrNotFrozenVolume
+	}
+	if err := mgr.clusterMgrCli.DeleteFrozenVolume(ctx, args.Vid); err != nil {
+		span.Errorf("delete frozen volume failed: vid[%d], err[%+v]", args.Vid, err)
+		return err
+	}
+	delete(mgr.vols, args.Vid)
+	mgr.apply()
+	span.Warnf("volume unfrozen: vid[%d], operator[%s], frozen by[%s], reason[%s]",
+		args.Vid, args.Operator, meta.Operator, meta.Reason)
+	return nil
+}
+
+// List returns the frozen volumes
+func (mgr *VolumeFreezeMgr) List() *api.VolumeFreezeList {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	now := time.Now().Unix()
+	ret := &api.VolumeFreezeList{Volumes: make([]*api.VolumeFreeze, 0, len(mgr.vols))}
+	for _, meta := range mgr.vols {
+		if !meta.Expired(now) {
+			ret.Volumes = append(ret.Volumes, toVolumeFreeze(meta))
+		}
+	}
+	sort.Slice(ret.Volumes, func(i, j int) bool { return ret.Volumes[i].Vid < ret.Volumes[j].Vid })
+	return ret
+}
+
+func toVolumeFreeze(meta *client.FrozenVolumeMeta) *api.VolumeFreeze {
+	return &api.VolumeFreeze{
+		Vid:       meta.Vid,
+		Operator:  meta.Operator,
+		Reason:    meta.Reason,
+		StartTime: meta.StartTime,
+		EndTime:   meta.EndTime,
+	}
+}
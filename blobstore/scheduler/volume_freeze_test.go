@@ -0,0 +1,108 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+)
+
+func TestVolumeFreezeMgr(t *testing.T) {
+	ctx := context.Background()
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+	mgr := NewVolumeFreezeMgr(clusterMgr)
+	defer mgr.Close()
+	defer base.VolumeFreezeInst().Update(nil)
+
+	now := time.Now().Unix()
+	vid1, vid2 := proto.Vid(1), proto.Vid(2)
+	{
+		// the expired volumes are removed when loading
+		clusterMgr.EXPECT().ListFrozenVolumes(any).Return(nil, errMock)
+		require.True(t, errors.Is(mgr.Load(), errMock))
+
+		clusterMgr.EXPECT().ListFrozenVolumes(any).Return([]*client.FrozenVolumeMeta{
+			{Vid: vid1, Operator: "alice", StartTime: now - 10, EndTime: now + 60},
+			{Vid: vid2, Operator: "bob", StartTime: now - 60, EndTime: now - 1},
+		}, nil)
+		clusterMgr.EXPECT().DeleteFrozenVolume(any, vid2).Return(errMock)
+		require.NoError(t, mgr.Load())
+		require.True(t, base.VolumeFreezeInst().Frozen(vid1))
+		require.False(t, base.VolumeFreezeInst().Frozen(vid2))
+		require.Len(t, mgr.List().Volumes, 1)
+	}
+	{
+		_, err := mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid2, DurationS: 60})
+		require.ErrorIs(t, err, errcode.ErrIllegalArguments)
+		_, err = mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid2, Operator: "bob"})
+		require.ErrorIs(t, err, errcode.ErrIllegalArguments)
+		_, err = mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid2, Operator: "bob", DurationS: maxVolumeFreezeDurationS + 1})
+		require.ErrorIs(t, err, errcode.ErrIllegalArguments)
+
+		clusterMgr.EXPECT().SetFrozenVolume(any, any).Return(errMock)
+		_, err = mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid2, Operator: "bob", DurationS: 60})
+		require.True(t, errors.Is(err, errMock))
+		require.False(t, base.VolumeFreezeInst().Frozen(vid2))
+
+		clusterMgr.EXPECT().SetFrozenVolume(any, any).Return(nil)
+		ret, err := mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid2, Operator: "bob", Reason: "corruption", DurationS: 60})
+		require.NoError(t, err)
+		require.Equal(t, vid2, ret.Vid)
+		require.Equal(t, "bob", ret.Operator)
+		require.Equal(t, "corruption", ret.Reason)
+		require.True(t, base.VolumeFreezeInst().Frozen(vid2))
+
+		// extend the freeze of the frozen volume
+		clusterMgr.EXPECT().SetFrozenVolume(any, any).DoAndReturn(
+			func(_ context.Context, meta *client.FrozenVolumeMeta) error {
+				require.Equal(t, "carol", meta.Operator)
+				return nil
+			})
+		ret, err = mgr.Freeze(ctx, &api.VolumeFreezeArgs{Vid: vid1, Operator: "carol", DurationS: 120})
+		require.NoError(t, err)
+		require.Equal(t, now-10, ret.StartTime)
+		require.LessOrEqual(t, now+120, ret.EndTime)
+
+		vols := mgr.List().Volumes
+		require.Len(t, vols, 2)
+		require.Equal(t, vid1, vols[0].Vid)
+		require.Equal(t, vid2, vols[1].Vid)
+	}
+	{
+		require.ErrorIs(t, mgr.Unfreeze(ctx, &api.VolumeFreezeArgs{Vid: vid1}), errcode.ErrIllegalArguments)
+		require.ErrorIs(t, mgr.Unfreeze(ctx, &api.VolumeFreezeArgs{Vid: 3, Operator: "alice"}), errcode.ErrNotFrozenVolume)
+
+		clusterMgr.EXPECT().DeleteFrozenVolume(any, vid1).Return(errMock)
+		require.True(t, errors.Is(mgr.Unfreeze(ctx, &api.VolumeFreezeArgs{Vid: vid1, Operator: "alice"}), errMock))
+		require.True(t, base.VolumeFreezeInst().Frozen(vid1))
+
+		clusterMgr.EXPECT().DeleteFrozenVolume(any, vid1).Return(nil)
+		require.NoError(t, mgr.Unfreeze(ctx, &api.VolumeFreezeArgs{Vid: vid1, Operator: "alice"}))
+		require.False(t, base.VolumeFreezeInst().Frozen(vid1))
+		require.True(t, base.VolumeFreezeInst().Frozen(vid2))
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"sync"
+
+	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+)
+
+// TaskWindowConfig is the config of sizing the migrate tasks running on each worker.
+type TaskWindowConfig struct {
+	Enable    bool `json:"enable"`
+	MinTasks  int  `json:"min_tasks"`
+	MaxTasks  int  `json:"max_tasks"`
+	InitTasks int  `json:"init_tasks"`
+}
+
+type workerTaskWindow struct {
+	window    float64
+	completed uint64
+	failed    uint64
+}
+
+// workerTaskWindows sizes the migrate tasks running on each worker by AIMD. The window of the
+// worker grows by one task after a window of tasks completed, and halves on the task canceled or
+// reclaimed by the worker, so the fast worker acquires more tasks and the failing worker backs off.
+// The worker acquires no task when its running tasks reach the window, the worker not reporting
+// its host and running tasks is not limited.
+type workerTaskWindows struct {
+	conf TaskWindowConfig
+
+	mu      sync.Mutex
+	workers map[string]*workerTaskWindow
+}
+
+func newWorkerTaskWindows(conf TaskWindowConfig) *workerTaskWindows {
+	return &workerTaskWindows{
+		conf:    conf,
+		workers: make(map[string]*workerTaskWindow),
+	}
+}
+
+func (w *workerTaskWindows) get(worker string) *workerTaskWindow {
+	win, ok := w.workers[worker]
+	if !ok {
+		win = &workerTaskWindow{window: float64(w.conf.InitTasks)}
+		w.workers[worker] = win
+	}
+	return win
+}
+
+func (w *workerTaskWindows) enabled(worker string) bool {
+	return w != nil && w.conf.Enable && worker != ""
+}
+
+// Allow returns true if the worker running the tasks can acquire one more task.
+func (w *workerTaskWindows) Allow(worker string, running int) bool {
+	if !w.enabled(worker) {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return running < int(w.get(worker).window)
+}
+
+// OnComplete increases the window of the worker additively.
+func (w *workerTaskWindows) OnComplete(worker string) {
+	if !w.enabled(worker) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	win := w.get(worker)
+	win.completed++
+	win.window += 1 / win.window
+	if max := float64(w.conf.MaxTasks); win.window > max {
+		win.window = max
+	}
+}
+
+// OnFail decreases the window of the worker multiplicatively.
+func (w *workerTaskWindows) OnFail(worker string) {
+	if !w.enabled(worker) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	win := w.get(worker)
+	win.failed++
+	win.window /= 2
+	if min := float64(w.conf.MinTasks); win.window < min {
+		win.window = min
+	}
+}
+
+// Stats returns the windows of the workers.
+func (w *workerTaskWindows) Stats() map[string]api.WorkerTaskWindowStat {
+	if w == nil || !w.conf.Enable {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := make(map[string]api.WorkerTaskWindowStat, len(w.workers))
+	for worker, win := range w.workers {
+		stats[worker] = api.WorkerTaskWindowStat{
+			Window:    int(win.window),
+			Completed: win.completed,
+			Failed:    win.failed,
+		}
+	}
+	return stats
+}
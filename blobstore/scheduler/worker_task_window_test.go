@@ -0,0 +1,62 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerTaskWindows(t *testing.T) {
+	// disabled or unknown workers are not limited
+	var nilWindows *workerTaskWindows
+	require.True(t, nilWindows.Allow("worker", 100))
+	nilWindows.OnComplete("worker")
+	require.Nil(t, nilWindows.Stats())
+	require.True(t, newWorkerTaskWindows(TaskWindowConfig{}).Allow("worker", 100))
+
+	w := newWorkerTaskWindows(TaskWindowConfig{Enable: true, MinTasks: 1, MaxTasks: 4, InitTasks: 2})
+	require.True(t, w.Allow("", 100))
+	require.True(t, w.Allow("worker", 1))
+	require.False(t, w.Allow("worker", 2))
+
+	// grows by one after a window completed
+	w.OnComplete("worker")
+	w.OnComplete("worker")
+	require.False(t, w.Allow("worker", 2))
+	w.OnComplete("worker")
+	require.True(t, w.Allow("worker", 2))
+	require.False(t, w.Allow("worker", 3))
+	for i := 0; i < 20; i++ {
+		w.OnComplete("worker")
+	}
+	require.Equal(t, 4, w.Stats()["worker"].Window)
+
+	// halves on failures
+	w.OnFail("worker")
+	require.Equal(t, 2, w.Stats()["worker"].Window)
+	w.OnFail("worker")
+	w.OnFail("worker")
+	stat := w.Stats()["worker"]
+	require.Equal(t, 1, stat.Window)
+	require.Equal(t, uint64(23), stat.Completed)
+	require.Equal(t, uint64(3), stat.Failed)
+	require.True(t, w.Allow("worker", 0))
+	require.False(t, w.Allow("worker", 1))
+
+	// the windows of the workers are independent
+	require.True(t, w.Allow("other", 1))
+}
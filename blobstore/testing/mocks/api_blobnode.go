@@ -94,6 +94,20 @@ func (mr *MockStorageAPIMockRecorder) DiskInfo(arg0, arg1, arg2 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskInfo", reflect.TypeOf((*MockStorageAPI)(nil).DiskInfo), arg0, arg1, arg2)
 }
 
+// FenceChunk mocks base method.
+func (m *MockStorageAPI) FenceChunk(arg0 context.Context, arg1 string, arg2 *blobnode.FenceChunkArgs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FenceChunk", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FenceChunk indicates an expected call of FenceChunk.
+func (mr *MockStorageAPIMockRecorder) FenceChunk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FenceChunk", reflect.TypeOf((*MockStorageAPI)(nil).FenceChunk), arg0, arg1, arg2)
+}
+
 // GetShard mocks base method.
 func (m *MockStorageAPI) GetShard(arg0 context.Context, arg1 string, arg2 *blobnode.GetShardArgs) (io.ReadCloser, uint32, error) {
 	m.ctrl.T.Helper()
@@ -301,6 +315,21 @@ func (mr *MockStorageAPIMockRecorder) StatShard(arg0, arg1, arg2 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatShard", reflect.TypeOf((*MockStorageAPI)(nil).StatShard), arg0, arg1, arg2)
 }
 
+// StatShards mocks base method.
+func (m *MockStorageAPI) StatShards(arg0 context.Context, arg1 string, arg2 *blobnode.StatShardsArgs) ([]*blobnode.ShardStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatShards", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*blobnode.ShardStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatShards indicates an expected call of StatShards.
+func (mr *MockStorageAPIMockRecorder) StatShards(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatShards", reflect.TypeOf((*MockStorageAPI)(nil).StatShards), arg0, arg1, arg2)
+}
+
 // String mocks base method.
 func (m *MockStorageAPI) String(arg0 context.Context, arg1 string) string {
 	m.ctrl.T.Helper()
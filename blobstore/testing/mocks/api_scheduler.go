@@ -80,6 +80,35 @@ func (mr *MockISchedulerMockRecorder) AddManualMigrateTask(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddManualMigrateTask", reflect.TypeOf((*MockIScheduler)(nil).AddManualMigrateTask), arg0, arg1)
 }
 
+// CancelDiskDrop mocks base method.
+func (m *MockIScheduler) CancelDiskDrop(arg0 context.Context, arg1 *scheduler.DiskDropCancelArgs) (*scheduler.DiskDropCancelReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDiskDrop", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.DiskDropCancelReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDiskDrop indicates an expected call of CancelDiskDrop.
+func (mr *MockISchedulerMockRecorder) CancelDiskDrop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDiskDrop", reflect.TypeOf((*MockIScheduler)(nil).CancelDiskDrop), arg0, arg1)
+}
+
+// CancelHostMaintenance mocks base method.
+func (m *MockIScheduler) CancelHostMaintenance(arg0 context.Context, arg1 *scheduler.HostMaintenanceArgs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelHostMaintenance", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelHostMaintenance indicates an expected call of CancelHostMaintenance.
+func (mr *MockISchedulerMockRecorder) CancelHostMaintenance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelHostMaintenance", reflect.TypeOf((*MockIScheduler)(nil).CancelHostMaintenance), arg0, arg1)
+}
+
 // CancelTask mocks base method.
 func (m *MockIScheduler) CancelTask(arg0 context.Context, arg1 *scheduler.OperateTaskArgs) error {
 	m.ctrl.T.Helper()
@@ -137,6 +166,21 @@ func (mr *MockISchedulerMockRecorder) DetailMigrateTask(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetailMigrateTask", reflect.TypeOf((*MockIScheduler)(nil).DetailMigrateTask), arg0, arg1)
 }
 
+// DiskDropCancelReport mocks base method.
+func (m *MockIScheduler) DiskDropCancelReport(arg0 context.Context, arg1 *scheduler.DiskDropCancelArgs) (*scheduler.DiskDropCancelReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiskDropCancelReport", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.DiskDropCancelReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskDropCancelReport indicates an expected call of DiskDropCancelReport.
+func (mr *MockISchedulerMockRecorder) DiskDropCancelReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskDropCancelReport", reflect.TypeOf((*MockIScheduler)(nil).DiskDropCancelReport), arg0, arg1)
+}
+
 // DiskMigratingStats mocks base method.
 func (m *MockIScheduler) DiskMigratingStats(arg0 context.Context, arg1 *scheduler.DiskMigratingStatsArgs) (*scheduler.DiskMigratingStats, error) {
 	m.ctrl.T.Helper()
@@ -152,6 +196,51 @@ func (mr *MockISchedulerMockRecorder) DiskMigratingStats(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskMigratingStats", reflect.TypeOf((*MockIScheduler)(nil).DiskMigratingStats), arg0, arg1)
 }
 
+// DrainHost mocks base method.
+func (m *MockIScheduler) DrainHost(arg0 context.Context, arg1 *scheduler.HostDrainArgs) (*scheduler.HostDrainReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DrainHost", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.HostDrainReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DrainHost indicates an expected call of DrainHost.
+func (mr *MockISchedulerMockRecorder) DrainHost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrainHost", reflect.TypeOf((*MockIScheduler)(nil).DrainHost), arg0, arg1)
+}
+
+// FreezeVolume mocks base method.
+func (m *MockIScheduler) FreezeVolume(arg0 context.Context, arg1 *scheduler.VolumeFreezeArgs) (*scheduler.VolumeFreeze, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FreezeVolume", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.VolumeFreeze)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FreezeVolume indicates an expected call of FreezeVolume.
+func (mr *MockISchedulerMockRecorder) FreezeVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeVolume", reflect.TypeOf((*MockIScheduler)(nil).FreezeVolume), arg0, arg1)
+}
+
+// HostDrainReport mocks base method.
+func (m *MockIScheduler) HostDrainReport(arg0 context.Context, arg1 *scheduler.HostDrainArgs) (*scheduler.HostDrainReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostDrainReport", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.HostDrainReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HostDrainReport indicates an expected call of HostDrainReport.
+func (mr *MockISchedulerMockRecorder) HostDrainReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostDrainReport", reflect.TypeOf((*MockIScheduler)(nil).HostDrainReport), arg0, arg1)
+}
+
 // LeaderStats mocks base method.
 func (m *MockIScheduler) LeaderStats(arg0 context.Context) (scheduler.TasksStat, error) {
 	m.ctrl.T.Helper()
@@ -167,6 +256,36 @@ func (mr *MockISchedulerMockRecorder) LeaderStats(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaderStats", reflect.TypeOf((*MockIScheduler)(nil).LeaderStats), arg0)
 }
 
+// ListFrozenVolumes mocks base method.
+func (m *MockIScheduler) ListFrozenVolumes(arg0 context.Context) (*scheduler.VolumeFreezeList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFrozenVolumes", arg0)
+	ret0, _ := ret[0].(*scheduler.VolumeFreezeList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFrozenVolumes indicates an expected call of ListFrozenVolumes.
+func (mr *MockISchedulerMockRecorder) ListFrozenVolumes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFrozenVolumes", reflect.TypeOf((*MockIScheduler)(nil).ListFrozenVolumes), arg0)
+}
+
+// ListHostMaintenance mocks base method.
+func (m *MockIScheduler) ListHostMaintenance(arg0 context.Context) (*scheduler.HostMaintenanceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHostMaintenance", arg0)
+	ret0, _ := ret[0].(*scheduler.HostMaintenanceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHostMaintenance indicates an expected call of ListHostMaintenance.
+func (mr *MockISchedulerMockRecorder) ListHostMaintenance(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHostMaintenance", reflect.TypeOf((*MockIScheduler)(nil).ListHostMaintenance), arg0)
+}
+
 // ReclaimTask mocks base method.
 func (m *MockIScheduler) ReclaimTask(arg0 context.Context, arg1 *scheduler.OperateTaskArgs) error {
 	m.ctrl.T.Helper()
@@ -181,6 +300,21 @@ func (mr *MockISchedulerMockRecorder) ReclaimTask(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReclaimTask", reflect.TypeOf((*MockIScheduler)(nil).ReclaimTask), arg0, arg1)
 }
 
+// RecoveryReport mocks base method.
+func (m *MockIScheduler) RecoveryReport(arg0 context.Context) (*scheduler.RecoveryReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecoveryReport", arg0)
+	ret0, _ := ret[0].(*scheduler.RecoveryReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecoveryReport indicates an expected call of RecoveryReport.
+func (mr *MockISchedulerMockRecorder) RecoveryReport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoveryReport", reflect.TypeOf((*MockIScheduler)(nil).RecoveryReport), arg0)
+}
+
 // RenewalTask mocks base method.
 func (m *MockIScheduler) RenewalTask(arg0 context.Context, arg1 *scheduler.TaskRenewalArgs) (*scheduler.TaskRenewalRet, error) {
 	m.ctrl.T.Helper()
@@ -210,6 +344,21 @@ func (mr *MockISchedulerMockRecorder) ReportTask(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportTask", reflect.TypeOf((*MockIScheduler)(nil).ReportTask), arg0, arg1)
 }
 
+// SetHostMaintenance mocks base method.
+func (m *MockIScheduler) SetHostMaintenance(arg0 context.Context, arg1 *scheduler.HostMaintenanceArgs) (*scheduler.HostMaintenance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHostMaintenance", arg0, arg1)
+	ret0, _ := ret[0].(*scheduler.HostMaintenance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetHostMaintenance indicates an expected call of SetHostMaintenance.
+func (mr *MockISchedulerMockRecorder) SetHostMaintenance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostMaintenance", reflect.TypeOf((*MockIScheduler)(nil).SetHostMaintenance), arg0, arg1)
+}
+
 // Stats mocks base method.
 func (m *MockIScheduler) Stats(arg0 context.Context, arg1 string) (scheduler.TasksStat, error) {
 	m.ctrl.T.Helper()
@@ -225,6 +374,20 @@ func (mr *MockISchedulerMockRecorder) Stats(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockIScheduler)(nil).Stats), arg0, arg1)
 }
 
+// UnfreezeVolume mocks base method.
+func (m *MockIScheduler) UnfreezeVolume(arg0 context.Context, arg1 *scheduler.VolumeFreezeArgs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfreezeVolume", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnfreezeVolume indicates an expected call of UnfreezeVolume.
+func (mr *MockISchedulerMockRecorder) UnfreezeVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeVolume", reflect.TypeOf((*MockIScheduler)(nil).UnfreezeVolume), arg0, arg1)
+}
+
 // UpdateVolume mocks base method.
 func (m *MockIScheduler) UpdateVolume(arg0 context.Context, arg1 string, arg2 proto.Vid) error {
 	m.ctrl.T.Helper()
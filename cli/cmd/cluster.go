@@ -40,6 +40,7 @@ func newClusterCmd(client *master.MasterClient) *cobra.Command {
 		newClusterSetThresholdCmd(client),
 		newClusterSetParasCmd(client),
 		newClusterDisableMpDecommissionCmd(client),
+		newClusterCapabilityCmd(client),
 	)
 	return clusterCmd
 }
@@ -56,6 +57,9 @@ const (
 	nodeAutoRepairRateKey         = "autoRepairRate"
 	nodeMaxDpCntLimit             = "maxDpCntLimit"
 	cmdForbidMpDecommission       = "forbid meta partition decommission"
+	cmdClusterCapabilityShort     = "Manage the capabilities negotiated by the clients at mount"
+	cmdCapabilityListShort        = "List the capabilities and the servers not supporting them"
+	cmdCapabilitySetShort         = "Enable or disable the capability by the feature flag"
 )
 
 func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
@@ -266,3 +270,67 @@ If 'forbid=true', MetaPartition decommission/migrate and MetaNode decommission i
 	}
 	return cmd
 }
+
+func newClusterCapabilityCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpCapability + " [COMMAND]",
+		Short: cmdClusterCapabilityShort,
+		Long: `A capability is enabled for the clients mounting afterwards only if all the active servers of
+its roles support it and it is not disabled by the feature flag. The mounted clients are not affected.`,
+	}
+	cmd.AddCommand(
+		newClusterCapabilityListCmd(client),
+		newClusterCapabilitySetCmd(client),
+	)
+	return cmd
+}
+
+func newClusterCapabilityListCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpList,
+		Short: cmdCapabilityListShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err   error
+				views []*proto.CapabilityView
+			)
+			defer func() {
+				errout(err)
+			}()
+			if views, err = client.AdminAPI().GetCapabilities(); err != nil {
+				return
+			}
+			stdout("%v\n", capabilityTableHeader)
+			for _, view := range views {
+				stdout("%v\n", formatCapabilityTableRow(view))
+			}
+		},
+	}
+	return cmd
+}
+
+func newClusterCapabilitySetCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpSet + " [NAME] [ENABLE]",
+		Short: cmdCapabilitySetShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err    error
+				enable bool
+			)
+			defer func() {
+				errout(err)
+			}()
+			if enable, err = strconv.ParseBool(args[1]); err != nil {
+				err = fmt.Errorf("Parse bool fail: %v\n", err)
+				return
+			}
+			if err = client.AdminAPI().SetCapability(args[0], enable); err != nil {
+				return
+			}
+			stdout("Set capability %v enable to %v successful!\n", args[0], enable)
+		},
+	}
+	return cmd
+}
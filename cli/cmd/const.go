@@ -43,6 +43,7 @@ const (
 	CliOpGetDiscard           = "get-discard"
 	CliOpSetDiscard           = "set-discard"
 	CliOpForbidMpDecommission = "forbid-mp-decommission"
+	CliOpCapability           = "capability"
 
 	// Shorthand format of operation name
 	CliOpDecommissionShortHand = "dec"
@@ -899,3 +899,13 @@ func formatDecommissionProgress(progress *proto.DecommissionProgress) string {
 	}
 	return sb.String()
 }
+
+var (
+	capabilityTablePattern = "%-16v    %-20v    %-10v    %-10v    %-8v    %v"
+	capabilityTableHeader  = fmt.Sprintf(capabilityTablePattern, "NAME", "ROLES", "SUPPORTED", "DISABLED", "ENABLED", "LACKING")
+)
+
+func formatCapabilityTableRow(view *proto.CapabilityView) string {
+	return fmt.Sprintf(capabilityTablePattern, view.Name, strings.Join(view.Roles, ","), view.Supported,
+		view.Disabled, view.Enabled, strings.Join(view.Lacking, ","))
+}
@@ -15,6 +15,10 @@
 package cmd
 
 import (
+	"encoding/json"
+	"os"
+	"strconv"
+
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/spf13/cobra"
@@ -35,14 +39,23 @@ func newNodeSetCmd(client *master.MasterClient) *cobra.Command {
 		newNodeSetListCmd(client),
 		newNodeSetInfoCmd(client),
 		newNodeSetUpdateCmd(client),
+		newNodeSetSimulateCmd(client),
 	)
 	return cmd
 }
 
 const (
-	cmdNodeSetListShort   = "List cluster nodeSets"
-	cmdGetNodeSetShort    = "Show nodeSet information"
-	cmdUpdateNodeSetShort = "Update nodeSet"
+	cmdNodeSetListShort     = "List cluster nodeSets"
+	cmdGetNodeSetShort      = "Show nodeSet information"
+	cmdUpdateNodeSetShort   = "Update nodeSet"
+	cmdSimulateNodeSetUse   = "simulate [NODESET ID]"
+	cmdSimulateNodeSetShort = "Compare the node select policies on the topology of nodeSet"
+)
+
+// the node types of the placement snapshot, same as the partition types in master
+const (
+	placementNodeTypeMeta uint32 = 0x01
+	placementNodeTypeData uint32 = 0x02
 )
 
 func newNodeSetListCmd(client *master.MasterClient) *cobra.Command {
@@ -119,3 +132,83 @@ func newNodeSetUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&metaNodeSelector, "metaNodeSelector", "", "Set the node select policy(metanode) for specify nodeset")
 	return cmd
 }
+
+func newNodeSetSimulateCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		metaNode     bool
+		policies     string
+		count        int
+		replicaNum   int
+		snapshotFile string
+		exportFile   string
+	)
+	cmd := &cobra.Command{
+		Use:   cmdSimulateNodeSetUse,
+		Short: cmdSimulateNodeSetShort,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				snapshot  *proto.PlacementSnapshot
+				report    *proto.PlacementSimulationReport
+				nodeSetId uint64
+				data      []byte
+				err       error
+			)
+			defer func() {
+				errout(err)
+			}()
+
+			if snapshotFile != "" {
+				if data, err = os.ReadFile(snapshotFile); err != nil {
+					return
+				}
+				snapshot = &proto.PlacementSnapshot{}
+				if err = json.Unmarshal(data, snapshot); err != nil {
+					return
+				}
+			} else {
+				if len(args) == 0 {
+					stdout("nodeset id or snapshot file is required\n")
+					return
+				}
+				if nodeSetId, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+					return
+				}
+			}
+			nodeType := placementNodeTypeData
+			if metaNode {
+				nodeType = placementNodeTypeMeta
+			}
+			if report, err = client.AdminAPI().SimulatePlacement(nodeSetId, nodeType, snapshot, policies, count, replicaNum); err != nil {
+				return
+			}
+			if exportFile != "" {
+				if data, err = json.MarshalIndent(report.Snapshot, "", "  "); err != nil {
+					return
+				}
+				if err = os.WriteFile(exportFile, data, 0o644); err != nil {
+					return
+				}
+			}
+			stdout("Nodes: %v  Partitions: %v  ReplicaNum: %v  PartitionSize: %v\n",
+				len(report.Snapshot.Nodes), report.Partitions, report.ReplicaNum, report.PartitionSize)
+			pattern := "%-22v %-8v %-8v %-10v %-10v %-10v %-10v %-10v %-10v\n"
+			stdout(pattern, "Policy", "Placed", "Failed", "MaxUsage", "MinUsage", "UsageSD", "MaxParts", "MinParts", "PartsSD")
+			for _, view := range report.Results {
+				stdout(pattern, view.Policy, view.Placed, view.Failed,
+					strconv.FormatFloat(view.MaxUsageRatio, 'f', 4, 64),
+					strconv.FormatFloat(view.MinUsageRatio, 'f', 4, 64),
+					strconv.FormatFloat(view.UsageStdDev, 'f', 4, 64),
+					view.MaxPartitions, view.MinPartitions,
+					strconv.FormatFloat(view.PartitionStdDev, 'f', 2, 64))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&metaNode, "metaNode", false, "Simulate on the metanodes instead of the datanodes")
+	cmd.Flags().StringVar(&policies, "policies", "", "The node select policies to compare, separated by comma, all policies by default")
+	cmd.Flags().IntVar(&count, "count", 0, "The count of partitions to place")
+	cmd.Flags().IntVar(&replicaNum, "replicaNum", 0, "The replica number of partitions")
+	cmd.Flags().StringVar(&snapshotFile, "snapshot", "", "Replay the topology snapshot exported before instead of the nodeset")
+	cmd.Flags().StringVar(&exportFile, "export", "", "Export the topology snapshot to the file to replay later")
+	return cmd
+}
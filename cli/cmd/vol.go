@@ -15,13 +15,16 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/sdk/meta"
 	"github.com/cubefs/cubefs/util"
 	"github.com/spf13/cobra"
 )
@@ -51,6 +54,8 @@ func newVolCmd(client *master.MasterClient) *cobra.Command {
 		newVolAddMPCmd(client),
 		newVolSetForbiddenCmd(client),
 		newVolSetAuditLogCmd(client),
+		newVolExportNamespaceCmd(client),
+		newVolImportNamespaceCmd(client),
 	)
 	return cmd
 }
@@ -1021,3 +1026,103 @@ func newVolSetAuditLogCmd(client *master.MasterClient) *cobra.Command {
 	}
 	return cmd
 }
+
+var (
+	cmdVolExportNamespaceUse   = "export-namespace [VOLUME] [PATH]"
+	cmdVolExportNamespaceShort = "Export the namespace metadata of the subtree at the path"
+	cmdVolImportNamespaceUse   = "import-namespace [VOLUME] [PATH]"
+	cmdVolImportNamespaceShort = "Import the exported namespace metadata under the path, the existing entries are skipped"
+)
+
+func newVolNamespaceWrapper(client *master.MasterClient, volName, path string) (mw *meta.MetaWrapper, ino uint64, err error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, 0, fmt.Errorf("path(%v) is not absolute", path)
+	}
+	metaConfig := &meta.MetaConfig{
+		Volume:  volName,
+		Masters: client.Nodes(),
+	}
+	if mw, err = meta.NewMetaWrapper(metaConfig); err != nil {
+		return
+	}
+	if ino, err = mw.LookupPath(path); err != nil {
+		mw.Close()
+		err = fmt.Errorf("lookup path(%v): %v", path, err)
+	}
+	return
+}
+
+func newVolExportNamespaceCmd(client *master.MasterClient) *cobra.Command {
+	var optFile string
+	cmd := &cobra.Command{
+		Use:   cmdVolExportNamespaceUse,
+		Short: cmdVolExportNamespaceShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			mw, root, err := newVolNamespaceWrapper(client, args[0], args[1])
+			if err != nil {
+				return
+			}
+			defer mw.Close()
+			out := os.Stdout
+			if optFile != "" {
+				if out, err = os.Create(optFile); err != nil {
+					return
+				}
+				defer out.Close()
+			}
+			w := bufio.NewWriter(out)
+			count, err := mw.ExportNamespace(root, w)
+			if err != nil {
+				return
+			}
+			if err = w.Flush(); err != nil {
+				return
+			}
+			if optFile != "" {
+				err = out.Sync()
+			}
+			fmt.Fprintf(os.Stderr, "%v records of the namespace are exported\n", count)
+		},
+	}
+	cmd.Flags().StringVar(&optFile, "file", "", "Specify the file to export to, stdout if empty")
+	return cmd
+}
+
+func newVolImportNamespaceCmd(client *master.MasterClient) *cobra.Command {
+	var optFile string
+	cmd := &cobra.Command{
+		Use:   cmdVolImportNamespaceUse,
+		Short: cmdVolImportNamespaceShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				errout(err)
+			}()
+			mw, parent, err := newVolNamespaceWrapper(client, args[0], args[1])
+			if err != nil {
+				return
+			}
+			defer mw.Close()
+			in := os.Stdin
+			if optFile != "" {
+				if in, err = os.Open(optFile); err != nil {
+					return
+				}
+				defer in.Close()
+			}
+			created, skipped, err := mw.ImportNamespace(bufio.NewReader(in), parent, nil)
+			stdout("%v entries are created, %v existing entries are skipped\n", created, skipped)
+			if err != nil {
+				err = fmt.Errorf("%v, import the same stream again to resume", err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optFile, "file", "", "Specify the file to import from, stdin if empty")
+	return cmd
+}
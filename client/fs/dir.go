@@ -16,6 +16,7 @@ package fs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -39,7 +40,8 @@ import (
 
 // used to locate the position in parent
 type DirContext struct {
-	Name string
+	Name   string
+	Cursor string
 }
 
 type DirContexts struct {
@@ -59,7 +61,7 @@ func (dctx *DirContexts) GetCopy(handle fuse.HandleID) DirContext {
 	dctx.RUnlock()
 
 	if found {
-		return DirContext{dirCtx.Name}
+		return DirContext{dirCtx.Name, dirCtx.Cursor}
 	} else {
 		return DirContext{}
 	}
@@ -72,6 +74,7 @@ func (dctx *DirContexts) Put(handle fuse.HandleID, dirCtx *DirContext) {
 	oldCtx, found := dctx.dirCtx[handle]
 	if found {
 		oldCtx.Name = dirCtx.Name
+		oldCtx.Cursor = dirCtx.Cursor
 		return
 	}
 
@@ -171,6 +174,7 @@ func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 	}
 
 	d.super.ic.Put(info)
+	d.super.nc.InvalidateDir(d.info.Inode)
 	child := NewFile(d.super, info, uint32(req.Flags&DefaultFlag), d.info.Inode, req.Name)
 	newInode = info.Inode
 
@@ -203,10 +207,17 @@ func (d *Dir) Forget() {
 	d.super.ic.Delete(ino)
 
 	d.super.fslock.Lock()
-	delete(d.super.nodeCache, ino)
+	if node, ok := d.super.nodeCache[ino]; ok && node == fs.Node(d) {
+		delete(d.super.nodeCache, ino)
+	}
 	d.super.fslock.Unlock()
 }
 
+// NodeGeneration returns the epoch of the inode as the generation of the fuse node.
+func (d *Dir) NodeGeneration() uint32 {
+	return d.info.Epoch
+}
+
 // Mkdir handles the mkdir request.
 func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
 	start := time.Now()
@@ -229,6 +240,7 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 	}
 
 	d.super.ic.Put(info)
+	d.super.nc.InvalidateDir(d.info.Inode)
 	child := NewDir(d.super, info, d.info.Inode, req.Name)
 	newInode = info.Inode
 	d.super.fslock.Lock()
@@ -301,6 +313,11 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	log.LogDebugf("TRACE Lookup: parent(%v) req(%v)", d.info.Inode, req)
 	log.LogDebugf("TRACE Lookup: parent(%v) path(%v) d.super.bcacheDir(%v)", d.info.Inode, d.getCwd(), d.super.bcacheDir)
 
+	parentMtime := d.modifyTime()
+	if errno := d.super.nc.Get(d.info.Inode, req.Name, req.Uid, req.Gid, parentMtime); errno != 0 {
+		return nil, ParseError(errno)
+	}
+
 	if d.needDentrycache() {
 		dcachev2 = true
 	}
@@ -317,6 +334,7 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 				if err != syscall.ENOENT {
 					log.LogErrorf("Lookup: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 				}
+				d.super.nc.Put(d.info.Inode, req.Name, req.Uid, req.Gid, err, parentMtime)
 				return nil, ParseError(err)
 			}
 			info := &proto.DentryInfo{
@@ -337,13 +355,14 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 				if err != syscall.ENOENT {
 					log.LogErrorf("Lookup: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 				}
+				d.super.nc.Put(d.info.Inode, req.Name, req.Uid, req.Gid, err, parentMtime)
 				return nil, ParseError(err)
 			}
 		}
 		ino = cino
 	}
 
-	info, err := d.super.InodeGet(ino)
+	info, err := d.super.InodeGetLatest(ino)
 	if err != nil {
 		log.LogErrorf("Lookup: parent(%v) name(%v) ino(%v) err(%v)", d.info.Inode, req.Name, ino, err)
 		dummyInodeInfo := &proto.InodeInfo{Inode: ino}
@@ -353,6 +372,10 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	mode := proto.OsMode(info.Mode)
 	d.super.fslock.Lock()
 	child, ok := d.super.nodeCache[ino]
+	// the id is reused by a new inode, the node of the former inode is kept by its handles only
+	if ok && nodeEpoch(child) != info.Epoch {
+		ok = false
+	}
 	if !ok {
 		if mode.IsDir() {
 			child = NewDir(d.super, info, d.info.Inode, req.Name)
@@ -369,6 +392,15 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	return child, nil
 }
 
+// modifyTime returns the modify time of the directory known by the client, the negative cache of the
+// directory is dropped once it changes.
+func (d *Dir) modifyTime() int64 {
+	if info := d.super.ic.Get(d.info.Inode); info != nil {
+		return info.ModifyTime.Unix()
+	}
+	return d.info.ModifyTime.Unix()
+}
+
 func (d *Dir) buildDcacheKey(inode uint64, name string) string {
 	return fmt.Sprintf("%v_%v", inode, name)
 }
@@ -388,10 +420,14 @@ func (d *Dir) ReadDir(ctx context.Context, req *fuse.ReadRequest, resp *fuse.Rea
 	var dirCtx DirContext
 	if req.Offset != 0 {
 		dirCtx = d.dctx.GetCopy(req.Handle)
+		if dirCtx.Cursor == "" {
+			// the session of the handle is not found, do not list the directory from the start again
+			return make([]fuse.Dirent, 0), io.EOF
+		}
 	} else {
 		dirCtx = DirContext{}
 	}
-	children, err := d.super.mw.ReadDirLimit_ll(d.info.Inode, dirCtx.Name, limit)
+	children, cursor, more, err := d.super.mw.ReadDirStream_ll(d.info.Inode, dirCtx.Cursor, limit)
 	if err != nil {
 		log.LogErrorf("readdirlimit: Readdir: ino(%v) err(%v) offset %v", d.info.Inode, err, req.Offset)
 		return make([]fuse.Dirent, 0), ParseError(err)
@@ -427,19 +463,16 @@ func (d *Dir) ReadDir(ctx context.Context, req *fuse.ReadRequest, resp *fuse.Rea
 		}}, children...)
 	}
 
-	// skip the first one, which is already accessed
-	childrenNr := uint64(len(children))
-	if childrenNr == 0 || (dirCtx.Name != "" && childrenNr == 1) {
+	// the cursor continues strictly after the entries returned, none of them is read again
+	if len(children) == 0 {
 		return make([]fuse.Dirent, 0), io.EOF
-	} else if childrenNr < limit {
+	} else if !more {
 		err = io.EOF
 	}
-	if dirCtx.Name != "" {
-		children = children[1:]
-	}
 
 	/* update dirCtx */
 	dirCtx.Name = children[len(children)-1].Name
+	dirCtx.Cursor = cursor
 	d.dctx.Put(req.Handle, &dirCtx)
 
 	inodes := make([]uint64, 0, len(children))
@@ -498,28 +531,20 @@ func (d *Dir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: d.super.volname})
 	}()
 
-	// transform ReadDirAll to ReadDirLimit_ll
-	noMore := false
-	from := ""
+	// transform ReadDirAll to ReadDirStream_ll
+	more := true
+	cursor := ""
 	var children []proto.Dentry
-	for !noMore {
-		batches, err := d.super.mw.ReadDirLimit_ll(d.info.Inode, from, DefaultReaddirLimit)
+	for more {
+		var batches []proto.Dentry
+		var next string
+		batches, next, more, err = d.super.mw.ReadDirStream_ll(d.info.Inode, cursor, DefaultReaddirLimit)
 		if err != nil {
-			log.LogErrorf("Readdir: ino(%v) err(%v) from(%v)", d.info.Inode, err, from)
+			log.LogErrorf("Readdir: ino(%v) err(%v) cursor(%v)", d.info.Inode, err, cursor)
 			return make([]fuse.Dirent, 0), ParseError(err)
 		}
-		batchNr := uint64(len(batches))
-		if batchNr == 0 || (from != "" && batchNr == 1) {
-			noMore = true
-			break
-		} else if batchNr < DefaultReaddirLimit {
-			noMore = true
-		}
-		if from != "" {
-			batches = batches[1:]
-		}
 		children = append(children, batches...)
-		from = batches[len(batches)-1].Name
+		cursor = next
 	}
 
 	inodes := make([]uint64, 0, len(children))
@@ -631,6 +656,8 @@ func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	// }
 	d.super.ic.Delete(d.info.Inode)
 	d.super.ic.Delete(dstDir.info.Inode)
+	d.super.nc.InvalidateDir(d.info.Inode)
+	d.super.nc.InvalidateDir(dstDir.info.Inode)
 
 	elapsed := time.Since(start)
 	log.LogDebugf("TRACE Rename: SrcParent(%v) OldName(%v) DstParent(%v) NewName(%v) (%v)ns", d.info.Inode, req.OldName, dstDir.info.Inode, req.NewName, elapsed.Nanoseconds())
@@ -660,6 +687,8 @@ func (d *Dir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.
 			d.super.ic.Delete(ino)
 			return ParseError(err)
 		}
+		// the denials depend on the mode and the owners
+		d.super.nc.InvalidateDir(ino)
 	}
 
 	fillAttr(info, &resp.Attr)
@@ -691,6 +720,7 @@ func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (fs.Node, error
 	}
 
 	d.super.ic.Put(info)
+	d.super.nc.InvalidateDir(d.info.Inode)
 	child := NewFile(d.super, info, DefaultFlag, d.info.Inode, req.Name)
 
 	d.super.fslock.Lock()
@@ -722,6 +752,7 @@ func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (fs.Node, e
 	}
 
 	d.super.ic.Put(info)
+	d.super.nc.InvalidateDir(parentIno)
 	child := NewFile(d.super, info, DefaultFlag, d.info.Inode, req.NewName)
 
 	d.super.fslock.Lock()
@@ -765,6 +796,7 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	}
 
 	d.super.ic.Put(info)
+	d.super.nc.InvalidateDir(d.info.Inode)
 
 	d.super.fslock.Lock()
 	newFile, ok := d.super.nodeCache[info.Inode]
@@ -823,6 +855,23 @@ func (d *Dir) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fus
 			"Bytes:" + strconv.FormatInt(int64(fbytes), 10)
 		value = []byte(summaryStr)
 
+	} else if name == meta.DirChangesKey || strings.HasPrefix(name, meta.DirChangesKey+".") {
+		var from uint64
+		if name != meta.DirChangesKey {
+			if from, err = strconv.ParseUint(strings.TrimPrefix(name, meta.DirChangesKey+"."), 10, 64); err != nil {
+				return ParseError(syscall.EINVAL)
+			}
+		}
+		changes := &proto.ReadDirChangesResponse{}
+		changes.Events, changes.Next, changes.Truncated, err = d.super.mw.ReadDirChanges_ll(ino, from, meta.DirChangesLimit)
+		if err != nil {
+			log.LogErrorf("GetXattr: ino(%v) name(%v) err(%v)", ino, name, err)
+			return ParseError(err)
+		}
+		if value, err = json.Marshal(changes); err != nil {
+			return ParseError(err)
+		}
+
 	} else {
 		info, err = d.super.mw.XAttrGet_ll(ino, name)
 		if err != nil {
@@ -895,6 +944,8 @@ func (d *Dir) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
 		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
 		return ParseError(err)
 	}
+	// the denials depend on the acls
+	d.super.nc.InvalidateDir(ino)
 	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v)", ino, name)
 	return nil
 }
@@ -921,6 +972,7 @@ func (d *Dir) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) err
 		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
 		return ParseError(err)
 	}
+	d.super.nc.InvalidateDir(ino)
 	log.LogDebugf("TRACE RemoveXattr: ino(%v) name(%v)", ino, name)
 	return nil
 }
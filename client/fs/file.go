@@ -29,6 +29,7 @@ import (
 	"github.com/cubefs/cubefs/depends/bazil.org/fuse/fs"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
@@ -184,7 +185,9 @@ func (f *File) Forget() {
 	if DisableMetaCache {
 		f.super.ic.Delete(ino)
 		f.super.fslock.Lock()
-		delete(f.super.nodeCache, ino)
+		if node, ok := f.super.nodeCache[ino]; ok && node == fs.Node(f) {
+			delete(f.super.nodeCache, ino)
+		}
 		f.super.fslock.Unlock()
 		if err := f.super.ec.EvictStream(ino); err != nil {
 			log.LogWarnf("Forget: stream not ready to evict, ino(%v) err(%v)", ino, err)
@@ -201,6 +204,11 @@ func (f *File) Forget() {
 	}
 }
 
+// NodeGeneration returns the epoch of the inode as the generation of the fuse node.
+func (f *File) NodeGeneration() uint32 {
+	return f.info.Epoch
+}
+
 // Open handles the open request.
 func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (handle fs.Handle, err error) {
 	bgTime := stat.BeginStat()
@@ -224,6 +232,8 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 			needBCache = true
 		}
 	}
+	// the requests of the handle are rejected if the inode id is reused by another inode
+	f.super.mw.HoldInodeEpoch(ino, f.info.Epoch)
 	if needBCache {
 		f.super.ec.OpenStreamWithCache(ino, needBCache)
 	} else {
@@ -286,6 +296,7 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 	bgTime := stat.BeginStat()
 
 	defer func() {
+		f.super.mw.ReleaseInodeEpoch(ino)
 		stat.EndStat("Release", err, bgTime, 1)
 		log.LogInfof("action[Release] %v", f.fWriter)
 		f.fWriter.FreeCache()
@@ -314,6 +325,15 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 	return nil
 }
 
+// dataContext returns the context of the data operation, the operation on a handle
+// opened with O_NONBLOCK fails with EAGAIN instead of retrying.
+func dataContext(ctx context.Context, flags fuse.OpenFlags) context.Context {
+	if flags&fuse.OpenNonblock != 0 {
+		return stream.WithNonblock(ctx)
+	}
+	return ctx
+}
+
 // Read handles the read request.
 func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) (err error) {
 	bgTime := stat.BeginStat()
@@ -332,7 +352,7 @@ func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	}()
 	var size int
 	if proto.IsHot(f.super.volType) {
-		size, err = f.super.ec.Read(f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
+		size, err = f.super.ec.ReadWithContext(dataContext(ctx, req.FileFlags), f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
 	} else {
 		size, err = f.fReader.Read(ctx, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
 	}
@@ -378,6 +398,12 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	log.LogDebugf("TRACE Write enter: ino(%v) offset(%v) len(%v)  flags(%v) fileflags(%v) quotaIds(%v) req(%v)",
 		ino, req.Offset, reqlen, req.Flags, req.FileFlags, f.info.QuotaInfos, req)
 	if proto.IsHot(f.super.volType) {
+		// fail fast on the full volume instead of the ENOSPC of the datanodes in the middle of the flushes
+		if f.super.ec.FullReadOnly() {
+			log.LogWarnf("Write: ino(%v) offset(%v) len(%v) volume(%v) is read-only as it is full",
+				ino, req.Offset, reqlen, f.super.volname)
+			return ParseError(syscall.EROFS)
+		}
 		filesize, _ := f.fileSize(ino)
 		if req.Offset > int64(filesize) && reqlen == 1 && req.Data[0] == 0 {
 
@@ -439,7 +465,7 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	var size int
 	if proto.IsHot(f.super.volType) {
 		f.super.ec.GetStreamer(ino).SetParentInode(f.parentIno)
-		if size, err = f.super.ec.Write(ino, int(req.Offset), req.Data, flags, checkFunc); err == ParseError(syscall.ENOSPC) {
+		if size, err = f.super.ec.WriteWithContext(dataContext(ctx, req.FileFlags), ino, int(req.Offset), req.Data, flags, checkFunc); err == ParseError(syscall.ENOSPC) {
 			return
 		}
 	} else {
@@ -451,8 +477,11 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 		f.super.handleError("Write", msg)
 		errMetric := exporter.NewCounter("fileWriteFailed")
 		errMetric.AddWithLabels(1, map[string]string{exporter.Vol: f.super.volname, exporter.Err: "EIO"})
-		if err == syscall.EOPNOTSUPP {
+		switch err {
+		case syscall.EOPNOTSUPP:
 			return fuse.ENOTSUP
+		case syscall.ETIMEDOUT, syscall.EINTR, syscall.EAGAIN:
+			return ParseError(err)
 		}
 		return fuse.EIO
 	}
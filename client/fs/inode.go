@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/depends/bazil.org/fuse"
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse/fs"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
@@ -28,12 +29,22 @@ const (
 )
 
 func (s *Super) InodeGet(ino uint64) (*proto.InodeInfo, error) {
+	return s.inodeGet(ino, s.mw.InodeGet_ll)
+}
+
+// InodeGetLatest gets the latest inode of the id regardless of the epoch held by the open handles,
+// it is used by lookup, since the id of the dentry may be reused by a new inode.
+func (s *Super) InodeGetLatest(ino uint64) (*proto.InodeInfo, error) {
+	return s.inodeGet(ino, s.mw.InodeGetLatest_ll)
+}
+
+func (s *Super) inodeGet(ino uint64, get func(uint64) (*proto.InodeInfo, error)) (*proto.InodeInfo, error) {
 	info := s.ic.Get(ino)
 	if info != nil {
 		return info, nil
 	}
 
-	info, err := s.mw.InodeGet_ll(ino)
+	info, err := get(ino)
 	if err != nil || info == nil {
 		log.LogErrorf("InodeGet: ino(%v) err(%v) info(%v)", ino, err, info)
 		if err != nil {
@@ -46,7 +57,8 @@ func (s *Super) InodeGet(ino uint64) (*proto.InodeInfo, error) {
 	s.fslock.Lock()
 	node, isFind := s.nodeCache[ino]
 	s.fslock.Unlock()
-	if isFind {
+	// the node of another epoch is replaced by lookup
+	if isFind && nodeEpoch(node) == info.Epoch {
 		s, ok := node.(*Dir)
 		if ok {
 			s.info = info
@@ -58,6 +70,13 @@ func (s *Super) InodeGet(ino uint64) (*proto.InodeInfo, error) {
 	return info, nil
 }
 
+func nodeEpoch(node fs.Node) uint32 {
+	if dir, ok := node.(*Dir); ok {
+		return dir.info.Epoch
+	}
+	return node.(*File).info.Epoch
+}
+
 func setattr(info *proto.InodeInfo, req *fuse.SetattrRequest) (valid uint32) {
 	if req.Valid.Mode() {
 		info.Mode = proto.Mode(req.Mode)
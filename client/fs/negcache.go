@@ -0,0 +1,214 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"container/list"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// the cached failures are never trusted longer than these
+	MaxNegativeLookupValid = 60 * time.Second
+	MaxPermDeniedValid     = 10 * time.Second
+
+	MaxNegativeCacheEntries = 1000000
+)
+
+type negativeName struct {
+	parent uint64
+	name   string
+}
+
+type negativeCaller struct {
+	uid, gid uint32
+}
+
+type deniedResult struct {
+	errno      syscall.Errno
+	expiration time.Time
+}
+
+type negativeEntry struct {
+	key         negativeName
+	parentMtime int64
+	noent       time.Time // expiration of ENOENT, zero if not cached
+	denied      map[negativeCaller]deniedResult
+}
+
+// NegativeCache caches the failed lookups, so the applications probing the paths repeatedly are answered by
+// the client. The names not existing are cached for all callers, while the denials are cached for the caller
+// denied only, so a cached result never grants what the metanode would deny. The entries of a directory are
+// dropped once the directory is changed by the client or its modify time known by the client changes.
+type NegativeCache struct {
+	sync.Mutex
+	cache     map[negativeName]*list.Element
+	dirs      map[uint64]map[string]struct{}
+	lruList   *list.List
+	noentTTL  time.Duration
+	deniedTTL time.Duration
+	maxSize   int
+}
+
+// NewNegativeCache returns a negative cache, the ttls are capped by the max ones and 0 disables the caching.
+func NewNegativeCache(noentTTL, deniedTTL time.Duration) *NegativeCache {
+	if noentTTL > MaxNegativeLookupValid {
+		noentTTL = MaxNegativeLookupValid
+	}
+	if deniedTTL > MaxPermDeniedValid {
+		deniedTTL = MaxPermDeniedValid
+	}
+	return &NegativeCache{
+		cache:     make(map[negativeName]*list.Element),
+		dirs:      make(map[uint64]map[string]struct{}),
+		lruList:   list.New(),
+		noentTTL:  noentTTL,
+		deniedTTL: deniedTTL,
+		maxSize:   MaxNegativeCacheEntries,
+	}
+}
+
+func (nc *NegativeCache) enabled() bool {
+	return nc != nil && (nc.noentTTL > 0 || nc.deniedTTL > 0)
+}
+
+func isPermDenied(errno syscall.Errno) bool {
+	return errno == syscall.EACCES || errno == syscall.EPERM
+}
+
+// Put caches the failure of the lookup of name in parent by the caller.
+func (nc *NegativeCache) Put(parent uint64, name string, uid, gid uint32, err error, parentMtime int64) {
+	errno, ok := err.(syscall.Errno)
+	if !nc.enabled() || !ok {
+		return
+	}
+	switch {
+	case errno == syscall.ENOENT && nc.noentTTL > 0:
+	case isPermDenied(errno) && nc.deniedTTL > 0:
+	default:
+		return
+	}
+
+	now := time.Now()
+	nc.Lock()
+	defer nc.Unlock()
+	key := negativeName{parent: parent, name: name}
+	var entry *negativeEntry
+	if element, ok := nc.cache[key]; ok {
+		entry = element.Value.(*negativeEntry)
+		nc.lruList.MoveToFront(element)
+		if entry.parentMtime != parentMtime {
+			entry.noent, entry.denied = time.Time{}, nil
+			entry.parentMtime = parentMtime
+		}
+	} else {
+		if nc.lruList.Len() >= nc.maxSize {
+			nc.remove(nc.lruList.Back())
+		}
+		entry = &negativeEntry{key: key, parentMtime: parentMtime}
+		nc.cache[key] = nc.lruList.PushFront(entry)
+		names, ok := nc.dirs[parent]
+		if !ok {
+			names = make(map[string]struct{})
+			nc.dirs[parent] = names
+		}
+		names[name] = struct{}{}
+	}
+	if errno == syscall.ENOENT {
+		entry.noent = now.Add(nc.noentTTL)
+		return
+	}
+	if entry.denied == nil {
+		entry.denied = make(map[negativeCaller]deniedResult)
+	}
+	entry.denied[negativeCaller{uid: uid, gid: gid}] = deniedResult{errno: errno, expiration: now.Add(nc.deniedTTL)}
+}
+
+// Get returns the cached failure of the lookup of name in parent by the caller, or 0 if none.
+func (nc *NegativeCache) Get(parent uint64, name string, uid, gid uint32, parentMtime int64) syscall.Errno {
+	if !nc.enabled() {
+		return 0
+	}
+	nc.Lock()
+	defer nc.Unlock()
+	element, ok := nc.cache[negativeName{parent: parent, name: name}]
+	if !ok {
+		return 0
+	}
+	entry := element.Value.(*negativeEntry)
+	if entry.parentMtime != parentMtime {
+		nc.remove(element)
+		return 0
+	}
+	now := time.Now()
+	if now.Before(entry.noent) {
+		return syscall.ENOENT
+	}
+	if result, ok := entry.denied[negativeCaller{uid: uid, gid: gid}]; ok {
+		if now.Before(result.expiration) {
+			return result.errno
+		}
+		delete(entry.denied, negativeCaller{uid: uid, gid: gid})
+	}
+	if len(entry.denied) == 0 && !now.Before(entry.noent) {
+		nc.remove(element)
+	}
+	return 0
+}
+
+// Delete drops the cached failures of name in parent.
+func (nc *NegativeCache) Delete(parent uint64, name string) {
+	if !nc.enabled() {
+		return
+	}
+	nc.Lock()
+	defer nc.Unlock()
+	if element, ok := nc.cache[negativeName{parent: parent, name: name}]; ok {
+		nc.remove(element)
+	}
+}
+
+// InvalidateDir drops the cached failures of the names in parent, such as on the changes of its entries,
+// its mode and owners or its acls.
+func (nc *NegativeCache) InvalidateDir(parent uint64) {
+	if !nc.enabled() {
+		return
+	}
+	nc.Lock()
+	defer nc.Unlock()
+	for name := range nc.dirs[parent] {
+		if element, ok := nc.cache[negativeName{parent: parent, name: name}]; ok {
+			nc.remove(element)
+		}
+	}
+	delete(nc.dirs, parent)
+}
+
+// remove removes the entry, the caller should grab the lock.
+func (nc *NegativeCache) remove(element *list.Element) {
+	if element == nil {
+		return
+	}
+	entry := nc.lruList.Remove(element).(*negativeEntry)
+	delete(nc.cache, entry.key)
+	if names, ok := nc.dirs[entry.key.parent]; ok {
+		delete(names, entry.key.name)
+		if len(names) == 0 {
+			delete(nc.dirs, entry.key.parent)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegativeCachePut(t *testing.T) {
+	nc := NewNegativeCache(time.Minute, time.Minute)
+	require.Equal(t, MaxNegativeLookupValid, nc.noentTTL)
+	require.Equal(t, MaxPermDeniedValid, nc.deniedTTL)
+
+	nc.Put(1, "a", 0, 0, syscall.ENOENT, 100)
+	require.Equal(t, syscall.ENOENT, nc.Get(1, "a", 0, 0, 100))
+	// the names not existing are cached for all callers
+	require.Equal(t, syscall.ENOENT, nc.Get(1, "a", 1000, 1000, 100))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 0, 0, 100))
+	require.Equal(t, syscall.Errno(0), nc.Get(2, "a", 0, 0, 100))
+
+	// the denials are cached for the caller denied only
+	nc.Put(1, "b", 1000, 1000, syscall.EACCES, 100)
+	require.Equal(t, syscall.EACCES, nc.Get(1, "b", 1000, 1000, 100))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 1000, 1001, 100))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 0, 0, 100))
+
+	// the other failures are not cached
+	nc.Put(1, "c", 0, 0, syscall.EIO, 100)
+	nc.Put(1, "d", 0, 0, errors.New("no errno"), 100)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "c", 0, 0, 100))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "d", 0, 0, 100))
+
+	// the entries are dropped once the modify time of the parent changes
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 101))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 100))
+	nc.Put(1, "b", 0, 0, syscall.ENOENT, 101)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 1000, 1000, 100))
+	require.Equal(t, 0, nc.lruList.Len())
+	require.Empty(t, nc.dirs)
+}
+
+func TestNegativeCacheDisabled(t *testing.T) {
+	var nilCache *NegativeCache
+	nilCache.Put(1, "a", 0, 0, syscall.ENOENT, 0)
+	require.Equal(t, syscall.Errno(0), nilCache.Get(1, "a", 0, 0, 0))
+	nilCache.InvalidateDir(1)
+
+	nc := NewNegativeCache(0, 0)
+	nc.Put(1, "a", 0, 0, syscall.ENOENT, 0)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, 0, nc.lruList.Len())
+
+	// only the kinds with a ttl are cached
+	nc = NewNegativeCache(time.Minute, 0)
+	nc.Put(1, "a", 0, 0, syscall.EPERM, 0)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, 0, nc.lruList.Len())
+}
+
+func TestNegativeCacheExpiration(t *testing.T) {
+	nc := NewNegativeCache(50*time.Millisecond, 100*time.Millisecond)
+	nc.Put(1, "a", 0, 0, syscall.ENOENT, 0)
+	nc.Put(1, "b", 1000, 1000, syscall.EPERM, 0)
+	require.Equal(t, syscall.ENOENT, nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, syscall.EPERM, nc.Get(1, "b", 1000, 1000, 0))
+
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, syscall.EPERM, nc.Get(1, "b", 1000, 1000, 0))
+
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 1000, 1000, 0))
+	// the expired entries are removed by the lookups
+	require.Equal(t, 0, nc.lruList.Len())
+	require.Empty(t, nc.cache)
+	require.Empty(t, nc.dirs)
+}
+
+func TestNegativeCacheInvalidation(t *testing.T) {
+	nc := NewNegativeCache(time.Minute, time.Minute)
+	nc.Put(1, "a", 0, 0, syscall.ENOENT, 0)
+	nc.Put(1, "b", 1000, 1000, syscall.EACCES, 0)
+	nc.Put(2, "a", 0, 0, syscall.ENOENT, 0)
+	nc.Put(3, "a", 0, 0, syscall.ENOENT, 0)
+
+	// a create in the directory drops the names cached in it only
+	nc.InvalidateDir(1)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "b", 1000, 1000, 0))
+	require.Equal(t, syscall.ENOENT, nc.Get(2, "a", 0, 0, 0))
+	require.Equal(t, syscall.ENOENT, nc.Get(3, "a", 0, 0, 0))
+
+	// a rename drops the names cached in both the source and the destination
+	nc.Put(1, "a", 0, 0, syscall.ENOENT, 0)
+	nc.InvalidateDir(1)
+	nc.InvalidateDir(2)
+	require.Equal(t, syscall.Errno(0), nc.Get(1, "a", 0, 0, 0))
+	require.Equal(t, syscall.Errno(0), nc.Get(2, "a", 0, 0, 0))
+	require.Equal(t, syscall.ENOENT, nc.Get(3, "a", 0, 0, 0))
+
+	nc.Delete(3, "a")
+	require.Equal(t, syscall.Errno(0), nc.Get(3, "a", 0, 0, 0))
+	require.Equal(t, 0, nc.lruList.Len())
+	require.Empty(t, nc.dirs)
+}
+
+func TestNegativeCacheCapacity(t *testing.T) {
+	nc := NewNegativeCache(time.Minute, time.Minute)
+	nc.maxSize = 10
+	for i := 0; i < 10; i++ {
+		nc.Put(uint64(i%3), fmt.Sprintf("n%v", i), 0, 0, syscall.ENOENT, 0)
+	}
+	// caching the name again moves it to the front of the lru list
+	nc.Put(0, "n0", 0, 0, syscall.ENOENT, 0)
+
+	for i := 10; i < 15; i++ {
+		nc.Put(uint64(i%3), fmt.Sprintf("n%v", i), 0, 0, syscall.ENOENT, 0)
+		require.Equal(t, 10, nc.lruList.Len())
+		require.Equal(t, 10, len(nc.cache))
+	}
+	require.Equal(t, syscall.ENOENT, nc.Get(0, "n0", 0, 0, 0))
+	for i := 1; i < 6; i++ {
+		require.Equal(t, syscall.Errno(0), nc.Get(uint64(i%3), fmt.Sprintf("n%v", i), 0, 0, 0))
+	}
+	for i := 6; i < 15; i++ {
+		require.Equal(t, syscall.ENOENT, nc.Get(uint64(i%3), fmt.Sprintf("n%v", i), 0, 0, 0))
+	}
+	names := 0
+	for _, dir := range nc.dirs {
+		names += len(dir)
+	}
+	require.Equal(t, 10, names)
+}
@@ -52,6 +52,7 @@ type Super struct {
 	owner       string
 	ic          *InodeCache
 	dc          *Dcache
+	nc          *NegativeCache
 	mw          *meta.MetaWrapper
 	ec          *stream.ExtentClient
 	orphan      *OrphanInodeList
@@ -91,6 +92,7 @@ type Super struct {
 	taskPool      []common.TaskPool
 	closeC        chan struct{}
 	enableVerRead bool
+	fuseQueues    int64
 }
 
 // Functions that Super needs to implement
@@ -117,6 +119,9 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		ValidateOwner:   opt.Authenticate || opt.AccessKey == "",
 		EnableSummary:   opt.EnableSummary && opt.EnableXattr,
 		MetaSendTimeout: opt.MetaSendTimeout,
+		MetaRetryLimit:  int(opt.MetaRetry),
+		ReaddirEncoding: opt.ReaddirEncoding,
+		MetaHedgeDelay:  time.Duration(opt.MetaHedgeDelayMs) * time.Millisecond,
 	}
 	s.mw, err = meta.NewMetaWrapper(metaConfig)
 	if err != nil {
@@ -154,6 +159,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		s.ic = NewInodeCache(inodeExpiration, DefaultMaxInodeCache)
 		s.dc = NewDcache(inodeExpiration, DefaultMaxInodeCache)
 	}
+	s.nc = NewNegativeCache(time.Duration(opt.NegativeLookupValid)*time.Second, time.Duration(opt.PermDeniedValid)*time.Second)
 	s.orphan = NewOrphanInodeList()
 	s.nodeCache = make(map[uint64]fs.Node)
 	s.disableDcache = opt.DisableDcache
@@ -206,6 +212,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.enableBcache = opt.EnableBcache
 
 	s.readThreads = int(opt.ReadThreads)
+	s.fuseQueues = opt.FuseQueues
 	s.writeThreads = int(opt.WriteThreads)
 
 	if s.enableBcache {
@@ -217,6 +224,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		Masters:           masters,
 		FollowerRead:      opt.FollowerRead,
 		NearRead:          opt.NearRead,
+		WireCompress:      opt.WireCompress,
 		ReadRate:          opt.ReadRate,
 		WriteRate:         opt.WriteRate,
 		VolumeType:        opt.VolType,
@@ -235,6 +243,16 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 
 		DisableMetaCache:             DisableMetaCache,
 		MinWriteAbleDataPartitionCnt: opt.MinWriteAbleDataPartitionCnt,
+		SmallReadSize:                int(opt.SmallReadSize),
+		RetryBudgets: [stream.OpClassCount]stream.RetryBudget{
+			stream.OpClassSmallRead: {Timeout: time.Duration(opt.SmallReadTimeoutS) * time.Second, MaxRetry: int(opt.SmallReadRetry)},
+			stream.OpClassLargeRead: {Timeout: time.Duration(opt.LargeReadTimeoutS) * time.Second, MaxRetry: int(opt.LargeReadRetry)},
+			stream.OpClassWrite:     {Timeout: time.Duration(opt.WriteTimeoutS) * time.Second, MaxRetry: int(opt.WriteRetry)},
+		},
+	}
+
+	if opt.EnablePlacementHint {
+		extentConfig.OnGetPlacementHint = s.getPlacementHint
 	}
 
 	s.ec, err = stream.NewExtentClient(extentConfig)
@@ -443,6 +461,12 @@ func (s *Super) SetSuspend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.fuseQueues != 1 {
+		err = fmt.Errorf("Suspend is not supported with fuseQueues %v", s.fuseQueues)
+		replyFail(w, r, err.Error())
+		return
+	}
+
 	s.fslock.Lock()
 	if s.sockaddr != "" ||
 		!atomic.CompareAndSwapUint32((*uint32)(&s.state), uint32(fs.FSStatResume), uint32(fs.FSStatSuspend)) {
@@ -747,3 +771,12 @@ func (s *Super) SetTransaction(txMaskStr string, timeout int64, retryNum int64,
 	log.LogDebugf("SetTransaction: mask[%v], op[%v], timeout[%v], retryNum[%v], retryInterval[%v ms]",
 		mask, txMaskStr, timeout, retryNum, retryInterval)
 }
+
+// getPlacementHint returns the placement hint xattr of the file, or empty if not set.
+func (s *Super) getPlacementHint(ino uint64) (string, error) {
+	info, err := s.mw.XAttrGet_ll(ino, proto.XAttrPlacementHint)
+	if err != nil {
+		return "", err
+	}
+	return string(info.Get(proto.XAttrPlacementHint)), nil
+}
@@ -429,6 +429,7 @@ func main() {
 
 	fsConn, super, err := mount(opt)
 	if err != nil {
+		reportMountFailure(opt, err)
 		err = errors.NewErrorf("mount failed: %v", err)
 		syslog.Println(err)
 		log.LogFlush()
@@ -472,6 +473,10 @@ func main() {
 		}
 	}
 
+	if opt.EnableSupervisor {
+		newSupervisor(opt).start()
+	}
+
 	if err = fs.Serve(fsConn, super, opt); err != nil {
 		log.LogFlush()
 		syslog.Printf("fs Serve returns err(%v)", err)
@@ -492,6 +497,26 @@ func getPushAddrFromMaster(masterAddr string) (addr string, err error) {
 	return
 }
 
+// reportMountFailure reports the reason of the mount failure to the master for the
+// diagnostics of the volume, the reason is truncated to bound the keys on the master.
+func reportMountFailure(opt *proto.MountOptions, mountErr error) {
+	const maxReasonLen = 128
+	reason := mountErr.Error()
+	if len(reason) > maxReasonLen {
+		reason = reason[:maxReasonLen]
+	}
+	localIP, _ := ump.GetLocalIpAddr()
+	report := &proto.ClientErrorReport{
+		VolName: opt.Volname,
+		Client:  localIP,
+		Items:   []*proto.ClientErrorItem{{Category: proto.ClientErrMountFailure, Key: reason, Count: 1}},
+	}
+	mc := master.NewMasterClientFromString(opt.Master, false)
+	if err := mc.ClientAPI().ReportClientErrors(report); err != nil {
+		syslog.Printf("report mount failure to master failed: %v\n", err)
+	}
+}
+
 func startDaemon() error {
 	cmdPath, err := os.Executable()
 	if err != nil {
@@ -810,6 +835,36 @@ func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
 	opt.RequestTimeout = GlobalMountOptions[proto.RequestTimeout].GetInt64()
 	opt.MinWriteAbleDataPartitionCnt = int(GlobalMountOptions[proto.MinWriteAbleDataPartitionCnt].GetInt64())
 	opt.FileSystemName = GlobalMountOptions[proto.FileSystemName].GetString()
+	opt.EnableSupervisor = GlobalMountOptions[proto.EnableSupervisor].GetBool()
+	opt.SupervisorProbeIntervalS = GlobalMountOptions[proto.SupervisorProbeIntervalS].GetInt64()
+	opt.SupervisorStuckTimeoutS = GlobalMountOptions[proto.SupervisorStuckTimeoutS].GetInt64()
+	opt.SupervisorMaxRemounts = GlobalMountOptions[proto.SupervisorMaxRemounts].GetInt64()
+	opt.SmallReadSize = GlobalMountOptions[proto.SmallReadSize].GetInt64()
+	opt.SmallReadTimeoutS = GlobalMountOptions[proto.SmallReadTimeoutS].GetInt64()
+	opt.SmallReadRetry = GlobalMountOptions[proto.SmallReadRetry].GetInt64()
+	opt.LargeReadTimeoutS = GlobalMountOptions[proto.LargeReadTimeoutS].GetInt64()
+	opt.LargeReadRetry = GlobalMountOptions[proto.LargeReadRetry].GetInt64()
+	opt.WriteTimeoutS = GlobalMountOptions[proto.WriteTimeoutS].GetInt64()
+	opt.WriteRetry = GlobalMountOptions[proto.WriteRetry].GetInt64()
+	opt.MetaRetry = GlobalMountOptions[proto.MetaRetry].GetInt64()
+	opt.FuseQueues = GlobalMountOptions[proto.FuseQueues].GetInt64()
+	opt.FuseQueueWorkers = GlobalMountOptions[proto.FuseQueueWorkers].GetInt64()
+	opt.NamespacePath = GlobalMountOptions[proto.NamespacePath].GetString()
+	opt.ReaddirEncoding = GlobalMountOptions[proto.ReaddirEncoding].GetString()
+	opt.WireCompress = GlobalMountOptions[proto.WireCompress].GetBool()
+	opt.EnablePlacementHint = GlobalMountOptions[proto.EnablePlacementHint].GetBool()
+	opt.MetaHedgeDelayMs = GlobalMountOptions[proto.MetaHedgeDelayMs].GetInt64()
+	opt.NegativeLookupValid = GlobalMountOptions[proto.NegativeLookupValid].GetInt64()
+	opt.PermDeniedValid = GlobalMountOptions[proto.PermDeniedValid].GetInt64()
+	if _, err = proto.ParseDentryBatchEncoding(opt.ReaddirEncoding); err != nil {
+		return nil, err
+	}
+
+	if opt.NamespacePath != "" && opt.Master != "" {
+		if err = resolveNamespacePath(opt); err != nil {
+			return nil, err
+		}
+	}
 
 	if opt.MountPoint == "" || opt.Volname == "" || opt.Owner == "" || opt.Master == "" {
 		return nil, errors.New(fmt.Sprintf("invalid config file: lack of mandatory fields, mountPoint(%v), volName(%v), owner(%v), masterAddr(%v)", opt.MountPoint, opt.Volname, opt.Owner, opt.Master))
@@ -826,6 +881,30 @@ func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
 	return opt, nil
 }
 
+// resolveNamespacePath resolves the volume and the sub directory mounted at the
+// namespace path by the mount map of the master.
+func resolveNamespacePath(opt *proto.MountOptions) error {
+	mc := master.NewMasterClientFromString(opt.Master, false)
+	view, err := mc.ClientAPI().GetMountMap()
+	if err != nil {
+		return errors.Trace(err, "get mount map failed")
+	}
+	entry, volPath, ok := view.Resolve(opt.NamespacePath)
+	if !ok {
+		return errors.New(fmt.Sprintf("namespace path(%v) is not mounted", opt.NamespacePath))
+	}
+	if opt.Volname != "" && opt.Volname != entry.VolName {
+		return errors.New(fmt.Sprintf("namespace path(%v) is mounted from volume(%v) rather than volName(%v)", opt.NamespacePath, entry.VolName, opt.Volname))
+	}
+	if opt.SubDir != "" {
+		return errors.New(fmt.Sprintf("subdir(%v) conflicts with namespace path(%v)", opt.SubDir, opt.NamespacePath))
+	}
+	opt.Volname = entry.VolName
+	opt.SubDir = volPath
+	syslog.Printf("resolve namespace path(%v) to volume(%v) subdir(%v), mount map version(%v)\n", opt.NamespacePath, opt.Volname, opt.SubDir, view.Version)
+	return nil
+}
+
 func checkPermission(opt *proto.MountOptions) (err error) {
 	mc := master.NewMasterClientFromString(opt.Master, false)
 	localIP, _ := ump.GetLocalIpAddr()
@@ -917,5 +996,24 @@ func loadConfFromMaster(opt *proto.MountOptions) (err error) {
 	}
 	opt.EbsEndpoint = clusterInfo.EbsAddr
 	opt.EbsServicePath = clusterInfo.ServicePath
+
+	negotiateCapabilities(mc, opt)
 	return
 }
+
+// negotiateCapabilities disables the features of the mount options whose capabilities are not
+// enabled by the master, all of them are disabled if the master does not support the negotiation.
+func negotiateCapabilities(mc *master.MasterClient, opt *proto.MountOptions) {
+	requested := opt.RequestedCapabilities()
+	if len(requested) == 0 {
+		return
+	}
+	n, err := mc.ClientAPI().NegotiateCapabilities(opt.Volname, requested)
+	if err != nil {
+		n = &proto.CapabilityNegotiation{}
+	}
+	if disabled := opt.ApplyCapabilities(n); len(disabled) > 0 {
+		syslog.Printf("capabilities%v are not enabled: disabled by the cluster%v unsupported%v err(%v)\n",
+			disabled, n.Disabled, n.Unsupported, err)
+	}
+}
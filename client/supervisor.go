@@ -0,0 +1,446 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/auditlog"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ControlCommandSupervisorStatus = "/supervisor/status"
+
+	// environment passed to the remounted client to keep the remount times for backoff
+	supervisorRemountEnv = "CFS_SUPERVISOR_REMOUNTS"
+	daemonizeStatusEnv   = "DAEMONIZE_STATUS_FD"
+
+	supervisorBaseBackoff = 5 * time.Second
+	supervisorMaxBackoff  = 5 * time.Minute
+	// the remount times are reset after the mount keeps healthy for a while
+	supervisorStableTime = 30 * time.Minute
+	// the interval to check whether the new client has mounted after remount
+	supervisorMountCheckInterval = time.Second
+	supervisorMountTimeout       = 2 * time.Minute
+
+	procMountInfo = "/proc/self/mountinfo"
+)
+
+// supervisor probes the health of the mount, and remounts the client when the mount is wedged.
+// A stuck probe on the mount point means that the fuse requests are not served any more,
+// while an unreachable master is only reported since remounting doesn't help.
+type supervisor struct {
+	sync.RWMutex
+	opt          *proto.MountOptions
+	mc           *master.MasterClient
+	interval     time.Duration
+	stuckTimeout time.Duration
+	baseBackoff  time.Duration
+	mountTimeout time.Duration
+	maxRemounts  int
+	remounts     int
+	healthySince time.Time
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	// the stat on a stuck mount point can not be cancelled, so the stat in flight is shared
+	// by the probes to block at most one goroutine on the stuck mount
+	statLock sync.Mutex
+	statCall *statCall
+
+	// replaced by the tests
+	stat        func(path string) error
+	isMounted   func(mountPoint string) (bool, error)
+	startClient func(remounts int) (exited <-chan error, kill func(), err error)
+	exit        func()
+
+	// status
+	mountStuck      bool
+	masterReachable bool
+	lastProbeTime   time.Time
+	lastErr         string
+}
+
+type supervisorStatus struct {
+	MountStuck      bool   `json:"mountStuck"`
+	MasterReachable bool   `json:"masterReachable"`
+	Remounts        int    `json:"remounts"`
+	MaxRemounts     int    `json:"maxRemounts"`
+	LastProbeTime   int64  `json:"lastProbeTime"`
+	HealthySince    int64  `json:"healthySince"`
+	LastErr         string `json:"lastErr"`
+}
+
+func newSupervisor(opt *proto.MountOptions) *supervisor {
+	s := &supervisor{
+		opt:             opt,
+		mc:              master.NewMasterClientFromString(opt.Master, false),
+		interval:        time.Duration(opt.SupervisorProbeIntervalS) * time.Second,
+		stuckTimeout:    time.Duration(opt.SupervisorStuckTimeoutS) * time.Second,
+		baseBackoff:     supervisorBaseBackoff,
+		mountTimeout:    supervisorMountTimeout,
+		maxRemounts:     int(opt.SupervisorMaxRemounts),
+		healthySince:    time.Now(),
+		masterReachable: true,
+		stat: func(path string) error {
+			_, err := os.Stat(path)
+			return err
+		},
+		isMounted: isFuseMounted,
+		exit: func() {
+			auditlog.StopAudit()
+			log.LogFlush()
+			os.Exit(1)
+		},
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.startClient = s.startNewClient
+	if s.interval <= 0 {
+		s.interval = 30 * time.Second
+	}
+	if s.stuckTimeout <= 0 {
+		s.stuckTimeout = 60 * time.Second
+	}
+	if val := os.Getenv(supervisorRemountEnv); val != "" {
+		s.remounts, _ = strconv.Atoi(val)
+	}
+	return s
+}
+
+func (s *supervisor) start() {
+	http.HandleFunc(ControlCommandSupervisorStatus, s.getStatus)
+	go s.loop()
+	log.LogInfof("supervisor: started, mountPoint(%v) interval(%v) stuckTimeout(%v) remounts(%v/%v)",
+		s.opt.MountPoint, s.interval, s.stuckTimeout, s.remounts, s.maxRemounts)
+}
+
+func (s *supervisor) stop() {
+	s.cancel()
+}
+
+func (s *supervisor) loop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s.probe(s.ctx) {
+			continue
+		}
+		s.remount(s.ctx)
+	}
+}
+
+// probe returns false if the mount is wedged and needs to be remounted.
+func (s *supervisor) probe(ctx context.Context) (healthy bool) {
+	mountErr := s.probeMount(ctx)
+	if ctx.Err() != nil {
+		return true
+	}
+	masterErr := s.probeMaster()
+
+	s.Lock()
+	defer s.Unlock()
+	s.lastProbeTime = time.Now()
+	s.mountStuck = mountErr != nil
+	if masterErr != nil && s.masterReachable {
+		s.event(fmt.Sprintf("master(%v) is unreachable: %v", s.opt.Master, masterErr))
+	} else if masterErr == nil && !s.masterReachable {
+		s.event(fmt.Sprintf("master(%v) is reachable again", s.opt.Master))
+	}
+	s.masterReachable = masterErr == nil
+
+	switch {
+	case mountErr != nil:
+		s.lastErr = mountErr.Error()
+		s.healthySince = time.Time{}
+		return false
+	case masterErr != nil:
+		s.lastErr = masterErr.Error()
+	default:
+		s.lastErr = ""
+	}
+	if s.healthySince.IsZero() {
+		s.healthySince = time.Now()
+	}
+	if s.remounts > 0 && time.Since(s.healthySince) > supervisorStableTime {
+		log.LogInfof("supervisor: mount keeps healthy since %v, reset remounts(%v)", s.healthySince, s.remounts)
+		s.remounts = 0
+	}
+	return true
+}
+
+// probeMount checks that the mount point is mounted by fuse and stats it, which walks through
+// the fuse request queue. It returns the error of ctx if cancelled.
+func (s *supervisor) probeMount(ctx context.Context) (err error) {
+	mounted, err := s.isMounted(s.opt.MountPoint)
+	if err != nil {
+		// the mount table is unavailable, judge by the stat only
+		log.LogWarnf("supervisor: check mount point(%v) failed: %v", s.opt.MountPoint, err)
+	} else if !mounted {
+		return fmt.Errorf("mount point(%v) is not mounted", s.opt.MountPoint)
+	}
+
+	call := s.statMountPoint()
+	timer := time.NewTimer(s.stuckTimeout)
+	defer timer.Stop()
+	select {
+	case <-call.done:
+		s.statLock.Lock()
+		if s.statCall == call {
+			s.statCall = nil
+		}
+		s.statLock.Unlock()
+		if call.err != nil && os.IsNotExist(call.err) {
+			return fmt.Errorf("mount point(%v) is gone: %v", s.opt.MountPoint, call.err)
+		}
+		// errors other than stuck are served by the client itself
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("mount point(%v) is stuck for %v", s.opt.MountPoint, s.stuckTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type statCall struct {
+	done chan struct{}
+	err  error
+}
+
+// statMountPoint returns the stat in flight on the mount point, or starts a new one.
+func (s *supervisor) statMountPoint() *statCall {
+	s.statLock.Lock()
+	defer s.statLock.Unlock()
+	if s.statCall == nil {
+		call := &statCall{done: make(chan struct{})}
+		s.statCall = call
+		go func() {
+			call.err = s.stat(s.opt.MountPoint)
+			close(call.done)
+		}()
+	}
+	return s.statCall
+}
+
+// resetStat abandons the stat in flight on the detached mount point, which may never return.
+func (s *supervisor) resetStat() {
+	s.statLock.Lock()
+	s.statCall = nil
+	s.statLock.Unlock()
+}
+
+func (s *supervisor) probeMaster() (err error) {
+	_, err = s.mc.AdminAPI().GetClusterInfo()
+	return
+}
+
+func (s *supervisor) event(msg string) {
+	detail := fmt.Sprintf("client supervisor vol(%v) mountPoint(%v): %v", s.opt.Volname, s.opt.MountPoint, msg)
+	log.LogWarn(detail)
+	exporter.Warning(detail)
+}
+
+func (s *supervisor) remount(ctx context.Context) {
+	s.Lock()
+	if s.maxRemounts > 0 && s.remounts >= s.maxRemounts {
+		s.event(fmt.Sprintf("mount is wedged (%v) but remounted %v times, give up", s.lastErr, s.remounts))
+		s.Unlock()
+		return
+	}
+	s.remounts++
+	remounts := s.remounts
+	backoff := s.baseBackoff << uint(remounts-1)
+	if backoff > supervisorMaxBackoff || backoff <= 0 {
+		backoff = supervisorMaxBackoff
+	}
+	s.event(fmt.Sprintf("mount is wedged (%v), remount after %v, remounts(%v)", s.lastErr, backoff, remounts))
+	s.Unlock()
+
+	timer := time.NewTimer(backoff)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+		return
+	}
+	// recovered during the backoff
+	if err := s.probeMount(ctx); err == nil {
+		s.event("mount recovered before remount")
+		return
+	} else if ctx.Err() != nil {
+		return
+	}
+
+	exited, kill, err := s.startClient(remounts)
+	if err != nil {
+		s.event(fmt.Sprintf("remount failed: %v", err))
+		return
+	}
+	// the new client is killed if it fails to mount, the wedged client keeps running then
+	if err = s.waitMounted(ctx, exited); err != nil {
+		kill()
+		s.event(fmt.Sprintf("remount failed: %v", err))
+		return
+	}
+	log.LogWarnf("supervisor: new client mounted on %v, exit", s.opt.MountPoint)
+	s.exit()
+}
+
+// waitMounted waits until the new client mounts the mount point and serves the probe.
+// The client exiting with nil error is the parent of the daemonized one, keep waiting then.
+func (s *supervisor) waitMounted(ctx context.Context, exited <-chan error) error {
+	deadline := time.NewTimer(s.mountTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(supervisorMountCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-exited:
+			if err != nil {
+				return fmt.Errorf("new client exited: %v", err)
+			}
+			exited = nil
+			continue
+		case <-deadline.C:
+			return fmt.Errorf("new client not mounted in %v", s.mountTimeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := s.probeMount(ctx); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// startNewClient lazily unmounts the wedged mount point and starts a new client with the same arguments.
+// The exit of the new client is sent to exited.
+func (s *supervisor) startNewClient(remounts int) (exited <-chan error, kill func(), err error) {
+	if mounted, e := s.isMounted(s.opt.MountPoint); e != nil || mounted {
+		if out, err := exec.Command("fusermount", "-uz", s.opt.MountPoint).CombinedOutput(); err != nil {
+			log.LogWarnf("supervisor: fusermount -uz %v failed: %v, %s", s.opt.MountPoint, err, out)
+			if out, err = exec.Command("umount", "-l", s.opt.MountPoint).CombinedOutput(); err != nil {
+				return nil, nil, fmt.Errorf("umount -l %v: %v, %s", s.opt.MountPoint, err, out)
+			}
+		}
+	}
+	s.resetStat()
+
+	cmdPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	env := make([]string, 0, len(os.Environ())+1)
+	for _, e := range os.Environ() {
+		if strings.HasPrefix(e, daemonizeStatusEnv+"=") || strings.HasPrefix(e, supervisorRemountEnv+"=") {
+			continue
+		}
+		env = append(env, e)
+	}
+	env = append(env, fmt.Sprintf("%v=%v", supervisorRemountEnv, remounts))
+
+	cmd := exec.Command(cmdPath, os.Args[1:]...)
+	cmd.Env = env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err = cmd.Start(); err != nil {
+		return
+	}
+	log.LogWarnf("supervisor: new client started, pid(%v) args(%v)", cmd.Process.Pid, os.Args[1:])
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	kill = func() {
+		if err := cmd.Process.Kill(); err != nil {
+			log.LogWarnf("supervisor: kill new client pid(%v) failed: %v", cmd.Process.Pid, err)
+		}
+	}
+	return done, kill, nil
+}
+
+// isFuseMounted returns true if the mount point is mounted by fuse.
+func isFuseMounted(mountPoint string) (bool, error) {
+	f, err := os.Open(procMountInfo)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	mountPoint = filepath.Clean(mountPoint)
+	unescape := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// 36 35 0:32 / /mnt/cfs rw,relatime shared:1 - fuse.cubefs cubefs rw,user_id=0
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || unescape.Replace(fields[4]) != mountPoint {
+			continue
+		}
+		for i := 5; i < len(fields)-1; i++ {
+			if fields[i] == "-" {
+				if strings.HasPrefix(fields[i+1], "fuse") {
+					return true, nil
+				}
+				break
+			}
+		}
+	}
+	return false, scanner.Err()
+}
+
+func (s *supervisor) getStatus(w http.ResponseWriter, r *http.Request) {
+	s.RLock()
+	status := &supervisorStatus{
+		MountStuck:      s.mountStuck,
+		MasterReachable: s.masterReachable,
+		Remounts:        s.remounts,
+		MaxRemounts:     s.maxRemounts,
+		LastProbeTime:   s.lastProbeTime.Unix(),
+		LastErr:         s.lastErr,
+	}
+	if !s.healthySince.IsZero() {
+		status.HealthySince = s.healthySince.Unix()
+	}
+	s.RUnlock()
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
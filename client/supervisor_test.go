@@ -0,0 +1,126 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSupervisor() *supervisor {
+	s := newSupervisor(&proto.MountOptions{MountPoint: "/mnt/cfs", Volname: "vol"})
+	s.stuckTimeout = 100 * time.Millisecond
+	s.baseBackoff = time.Millisecond
+	s.mountTimeout = 3 * supervisorMountCheckInterval
+	s.isMounted = func(string) (bool, error) { return true, nil }
+	s.stat = func(string) error { return nil }
+	return s
+}
+
+func TestSupervisorProbeMount(t *testing.T) {
+	s := newTestSupervisor()
+	require.NoError(t, s.probeMount(context.Background()))
+
+	s.isMounted = func(string) (bool, error) { return false, nil }
+	require.Error(t, s.probeMount(context.Background()))
+
+	// judge by the stat only if the mount table is unavailable
+	s.isMounted = func(string) (bool, error) { return false, errors.New("no mountinfo") }
+	require.NoError(t, s.probeMount(context.Background()))
+}
+
+func TestSupervisorProbeMountStuck(t *testing.T) {
+	s := newTestSupervisor()
+	var stats int32
+	release := make(chan struct{})
+	s.stat = func(string) error {
+		atomic.AddInt32(&stats, 1)
+		<-release
+		return nil
+	}
+
+	// the stuck stat is shared by the probes
+	require.Error(t, s.probeMount(context.Background()))
+	require.Error(t, s.probeMount(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(&stats))
+
+	// the probe returns once cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stuckTimeout = time.Hour
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	require.ErrorIs(t, s.probeMount(ctx), context.Canceled)
+	require.Less(t, time.Since(start), time.Second)
+
+	// a new stat is started after the stuck one returns
+	close(release)
+	require.NoError(t, s.probeMount(context.Background()))
+	require.NoError(t, s.probeMount(context.Background()))
+	require.Equal(t, int32(2), atomic.LoadInt32(&stats))
+}
+
+func TestSupervisorRemount(t *testing.T) {
+	s := newTestSupervisor()
+	var exits, kills int32
+	s.exit = func() { atomic.AddInt32(&exits, 1) }
+	s.isMounted = func(string) (bool, error) { return false, nil }
+
+	// the new client exits without mounting
+	s.startClient = func(int) (<-chan error, func(), error) {
+		exited := make(chan error, 1)
+		exited <- errors.New("exit status 1")
+		return exited, func() { atomic.AddInt32(&kills, 1) }, nil
+	}
+	s.remount(context.Background())
+	require.Equal(t, int32(0), atomic.LoadInt32(&exits))
+	require.Equal(t, int32(1), atomic.LoadInt32(&kills))
+
+	// the new client never mounts
+	s.startClient = func(int) (<-chan error, func(), error) {
+		return make(chan error), func() { atomic.AddInt32(&kills, 1) }, nil
+	}
+	s.remount(context.Background())
+	require.Equal(t, int32(0), atomic.LoadInt32(&exits))
+	require.Equal(t, int32(2), atomic.LoadInt32(&kills))
+
+	// the daemonized client mounts after its parent exits
+	s.startClient = func(int) (<-chan error, func(), error) {
+		exited := make(chan error, 1)
+		exited <- nil
+		s.isMounted = func(string) (bool, error) { return true, nil }
+		return exited, func() { atomic.AddInt32(&kills, 1) }, nil
+	}
+	s.remount(context.Background())
+	require.Equal(t, int32(1), atomic.LoadInt32(&exits))
+	require.Equal(t, int32(2), atomic.LoadInt32(&kills))
+	require.Equal(t, 3, s.remounts)
+
+	// cancelled during the backoff
+	s.isMounted = func(string) (bool, error) { return false, nil }
+	s.baseBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.remount(ctx)
+	require.Equal(t, int32(1), atomic.LoadInt32(&exits))
+}
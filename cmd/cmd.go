@@ -90,6 +90,11 @@ var (
 	redirectSTD      = flag.Bool("redirect-std", true, "redirect standard output to file")
 )
 
+// panicDumper is implemented by the servers which dump their states when the process panics.
+type panicDumper interface {
+	DumpOnPanic()
+}
+
 func interceptSignal(s common.Server) {
 	sigC := make(chan os.Signal, 1)
 	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
@@ -239,6 +244,9 @@ func main() {
 	defer log.LogFlush()
 	if errors.SupportPanicHook() {
 		err = errors.AtPanic(func() {
+			if dumper, ok := server.(panicDumper); ok {
+				dumper.DumpOnPanic()
+			}
 			log.LogFlush()
 		})
 		if err != nil {
@@ -30,8 +30,10 @@ type FileService struct {
 }
 
 func NewFileService(objectNode string, masters []string, mc *client.MasterGClient) *FileService {
+	// the router of the masters only never fails
+	router, _ := NewClusterRouter(masters, nil)
 	return &FileService{
-		manager:    NewVolumeManager(masters, true),
+		manager:    NewVolumeManager(router, true),
 		userClient: &user.UserClient{MasterGClient: mc},
 		objectNode: objectNode,
 	}
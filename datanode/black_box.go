@@ -0,0 +1,448 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	DefaultBlackBoxInterval = 10 * time.Second
+	DefaultBlackBoxSlots    = 60
+
+	blackBoxDirName     = "blackbox"
+	blackBoxRingFile    = "blackbox.json"
+	blackBoxDumpPrefix  = "blackbox-"
+	blackBoxMaxDumps    = 10
+	blackBoxTimeLayout  = "20060102150405"
+	blackBoxReasonPanic = "panic"
+	blackBoxReasonFatal = "fatal"
+	blackBoxReasonStop  = "shutdown"
+
+	blackBoxRequestSlots  = 4096 // the requests being served beyond the slots are not tracked
+	blackBoxRequestProbes = 8
+)
+
+// BlackBoxPartitionStat is the io of a partition in a sample window.
+type BlackBoxPartitionStat struct {
+	PartitionID uint64 `json:"pid"`
+	Reads       uint64 `json:"reads"`
+	ReadBytes   uint64 `json:"readBytes"`
+	Writes      uint64 `json:"writes"`
+	WriteBytes  uint64 `json:"writeBytes"`
+	Errors      uint64 `json:"errors"`
+	AvgCostUs   uint64 `json:"avgCostUs"`
+	MaxCostUs   uint64 `json:"maxCostUs"`
+}
+
+// BlackBoxSample is the io of the partitions in a sample window, the idle partitions are omitted.
+type BlackBoxSample struct {
+	Time       string                   `json:"time"`
+	InFlight   int                      `json:"inFlight"`
+	Partitions []*BlackBoxPartitionStat `json:"partitions"`
+}
+
+// BlackBoxRequest is the descriptor of a request being served.
+type BlackBoxRequest struct {
+	Op          string `json:"op"`
+	PartitionID uint64 `json:"pid"`
+	ExtentID    uint64 `json:"extentId"`
+	Offset      int64  `json:"offset"`
+	Size        uint32 `json:"size"`
+	ReqID       int64  `json:"reqId"`
+	Remote      string `json:"remote"`
+	Start       string `json:"start"`
+	ElapsedMs   int64  `json:"elapsedMs"`
+
+	start time.Time
+}
+
+// blackBoxRequestSlot holds a request being served, it is claimed by the goroutine serving the
+// request, and ver is odd while the goroutine writes the request, so the slots are read without
+// locks and the torn ones are skipped.
+type blackBoxRequestSlot struct {
+	busy        int32
+	ver         uint64
+	opcode      uint32
+	partitionID uint64
+	extentID    uint64
+	offset      int64
+	size        uint32
+	reqID       int64
+	startNs     int64
+	remote      atomic.Value // string
+}
+
+// BlackBoxDump is what the datanode was doing right before it is dumped.
+type BlackBoxDump struct {
+	Reason   string             `json:"reason"`
+	Detail   string             `json:"detail,omitempty"`
+	Time     string             `json:"time"`
+	Samples  []*BlackBoxSample  `json:"samples"`
+	InFlight []*BlackBoxRequest `json:"inFlight"`
+	// the requests not tracked as the slots were all busy
+	Untracked uint64 `json:"untracked"`
+}
+
+type blackBoxCounters struct {
+	reads      uint64
+	readBytes  uint64
+	writes     uint64
+	writeBytes uint64
+	errors     uint64
+	costUs     uint64
+	maxCostUs  uint64
+}
+
+func (c *blackBoxCounters) swap(pid uint64) *BlackBoxPartitionStat {
+	stat := &BlackBoxPartitionStat{
+		PartitionID: pid,
+		Reads:       atomic.SwapUint64(&c.reads, 0),
+		ReadBytes:   atomic.SwapUint64(&c.readBytes, 0),
+		Writes:      atomic.SwapUint64(&c.writes, 0),
+		WriteBytes:  atomic.SwapUint64(&c.writeBytes, 0),
+		Errors:      atomic.SwapUint64(&c.errors, 0),
+		MaxCostUs:   atomic.SwapUint64(&c.maxCostUs, 0),
+	}
+	costUs := atomic.SwapUint64(&c.costUs, 0)
+	if ops := stat.Reads + stat.Writes; ops > 0 {
+		stat.AvgCostUs = costUs / ops
+	}
+	return stat
+}
+
+// blackBox keeps a ring of the recent io of the partitions and the requests being served. The ring is
+// persisted once a sample window ends, so it survives even if the process is killed. On shutdown, on the
+// fatal events of the raft and on the panics of the process, the ring and the requests being served are
+// dumped to a new file, the latest dumps are kept. The panics are caught by the panic hook of the process
+// if supported, otherwise only the panics of the packets served by OperatePacket are dumped.
+type blackBox struct {
+	dir      string
+	interval time.Duration
+	slots    int
+
+	counters  sync.Map // partition id -> *blackBoxCounters
+	requests  []blackBoxRequestSlot
+	cursor    uint32
+	untracked uint64
+
+	mu      sync.Mutex
+	samples []*BlackBoxSample // the ring of the samples
+	next    int
+	dumped  bool
+	stopC   chan struct{}
+	stopped sync.Once
+}
+
+func newBlackBox(dir string, interval time.Duration, slots int) *blackBox {
+	if interval <= 0 {
+		interval = DefaultBlackBoxInterval
+	}
+	if slots <= 0 {
+		slots = DefaultBlackBoxSlots
+	}
+	return &blackBox{
+		dir:      dir,
+		interval: interval,
+		slots:    slots,
+		requests: make([]blackBoxRequestSlot, blackBoxRequestSlots),
+		stopC:    make(chan struct{}),
+	}
+}
+
+func (b *blackBox) start() {
+	if b == nil {
+		return
+	}
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		log.LogErrorf("action[blackBox] mkdir %v err %v", b.dir, err)
+	}
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.sample()
+				if err := b.persist(); err != nil {
+					log.LogWarnf("action[blackBox] persist ring err %v", err)
+				}
+			case <-b.stopC:
+				return
+			}
+		}
+	}()
+}
+
+func (b *blackBox) stop() {
+	if b == nil {
+		return
+	}
+	b.stopped.Do(func() { close(b.stopC) })
+}
+
+// begin records the request being served in a free slot, the returned slot is passed to end. The
+// request is not tracked and -1 is returned if the probed slots are all busy.
+func (b *blackBox) begin(p *repl.Packet, remote string) int {
+	if b == nil {
+		return -1
+	}
+	cursor := atomic.AddUint32(&b.cursor, 1)
+	for i := uint32(0); i < blackBoxRequestProbes; i++ {
+		slot := int((cursor + i) % uint32(len(b.requests)))
+		r := &b.requests[slot]
+		if !atomic.CompareAndSwapInt32(&r.busy, 0, 1) {
+			continue
+		}
+		atomic.AddUint64(&r.ver, 1)
+		atomic.StoreUint32(&r.opcode, uint32(p.Opcode))
+		atomic.StoreUint64(&r.partitionID, p.PartitionID)
+		atomic.StoreUint64(&r.extentID, p.ExtentID)
+		atomic.StoreInt64(&r.offset, p.ExtentOffset)
+		atomic.StoreUint32(&r.size, p.Size)
+		atomic.StoreInt64(&r.reqID, p.ReqID)
+		atomic.StoreInt64(&r.startNs, time.Now().UnixNano())
+		r.remote.Store(remote)
+		atomic.AddUint64(&r.ver, 1)
+		return slot
+	}
+	atomic.AddUint64(&b.untracked, 1)
+	return -1
+}
+
+// end releases the slot and counts the request if it is a client read or write, size is the size
+// of the request.
+func (b *blackBox) end(slot int, p *repl.Packet, size uint32, costUs uint64, failed bool) {
+	if b == nil {
+		return
+	}
+	if slot >= 0 {
+		atomic.StoreInt32(&b.requests[slot].busy, 0)
+	}
+	isRead, isWrite := isSloReadOp(p.Opcode), isSloWriteOp(p.Opcode)
+	if !isRead && !isWrite {
+		return
+	}
+	value, ok := b.counters.Load(p.PartitionID)
+	if !ok {
+		value, _ = b.counters.LoadOrStore(p.PartitionID, &blackBoxCounters{})
+	}
+	c := value.(*blackBoxCounters)
+	if isRead {
+		atomic.AddUint64(&c.reads, 1)
+		atomic.AddUint64(&c.readBytes, uint64(size))
+	} else {
+		atomic.AddUint64(&c.writes, 1)
+		atomic.AddUint64(&c.writeBytes, uint64(size))
+	}
+	if failed {
+		atomic.AddUint64(&c.errors, 1)
+	}
+	atomic.AddUint64(&c.costUs, costUs)
+	for {
+		cur := atomic.LoadUint64(&c.maxCostUs)
+		if costUs <= cur || atomic.CompareAndSwapUint64(&c.maxCostUs, cur, costUs) {
+			break
+		}
+	}
+}
+
+// load returns the request in the slot, or nil if the slot is free or rewritten while being read.
+func (r *blackBoxRequestSlot) load() *BlackBoxRequest {
+	ver := atomic.LoadUint64(&r.ver)
+	if ver%2 == 1 || atomic.LoadInt32(&r.busy) == 0 {
+		return nil
+	}
+	p := &proto.Packet{Opcode: uint8(atomic.LoadUint32(&r.opcode))}
+	req := &BlackBoxRequest{
+		Op:          p.GetOpMsg(),
+		PartitionID: atomic.LoadUint64(&r.partitionID),
+		ExtentID:    atomic.LoadUint64(&r.extentID),
+		Offset:      atomic.LoadInt64(&r.offset),
+		Size:        atomic.LoadUint32(&r.size),
+		ReqID:       atomic.LoadInt64(&r.reqID),
+		start:       time.Unix(0, atomic.LoadInt64(&r.startNs)),
+	}
+	req.Remote, _ = r.remote.Load().(string)
+	if atomic.LoadInt32(&r.busy) == 0 || atomic.LoadUint64(&r.ver) != ver {
+		return nil
+	}
+	return req
+}
+
+func (b *blackBox) inFlightCount() (count int) {
+	for i := range b.requests {
+		if atomic.LoadInt32(&b.requests[i].busy) == 1 {
+			count++
+		}
+	}
+	return
+}
+
+func (b *blackBox) inFlightRequests() []*BlackBoxRequest {
+	now := time.Now()
+	reqs := make([]*BlackBoxRequest, 0)
+	for i := range b.requests {
+		req := b.requests[i].load()
+		if req == nil {
+			continue
+		}
+		req.Start = req.start.Format(time.RFC3339Nano)
+		req.ElapsedMs = now.Sub(req.start).Milliseconds()
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].start.Before(reqs[j].start) })
+	return reqs
+}
+
+// sample ends the current window and pushes it into the ring.
+func (b *blackBox) sample() *BlackBoxSample {
+	s := &BlackBoxSample{Time: time.Now().Format(time.RFC3339), Partitions: make([]*BlackBoxPartitionStat, 0)}
+	b.counters.Range(func(key, value interface{}) bool {
+		stat := value.(*blackBoxCounters).swap(key.(uint64))
+		if stat.Reads+stat.Writes == 0 {
+			return true
+		}
+		s.Partitions = append(s.Partitions, stat)
+		return true
+	})
+	sort.Slice(s.Partitions, func(i, j int) bool { return s.Partitions[i].PartitionID < s.Partitions[j].PartitionID })
+	s.InFlight = b.inFlightCount()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.samples) < b.slots {
+		b.samples = append(b.samples, s)
+	} else {
+		b.samples[b.next] = s
+		b.next = (b.next + 1) % b.slots
+	}
+	return s
+}
+
+// recentSamples returns the samples in the ring, the oldest first.
+func (b *blackBox) recentSamples() []*BlackBoxSample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	samples := make([]*BlackBoxSample, 0, len(b.samples))
+	for i := 0; i < len(b.samples); i++ {
+		samples = append(samples, b.samples[(b.next+i)%len(b.samples)])
+	}
+	return samples
+}
+
+func (b *blackBox) snapshot(reason, detail string) *BlackBoxDump {
+	return &BlackBoxDump{
+		Reason:    reason,
+		Detail:    detail,
+		Time:      time.Now().Format(time.RFC3339Nano),
+		Samples:   b.recentSamples(),
+		InFlight:  b.inFlightRequests(),
+		Untracked: atomic.LoadUint64(&b.untracked),
+	}
+}
+
+func (b *blackBox) writeFile(name string, v interface{}) (err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	tmp := path.Join(b.dir, "."+name+".tmp")
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	return os.Rename(tmp, path.Join(b.dir, name))
+}
+
+// persist writes the ring to the disk, the requests being served are not included.
+func (b *blackBox) persist() error {
+	return b.writeFile(blackBoxRingFile, b.recentSamples())
+}
+
+// dump writes the ring with the current window and the requests being served to a new file, it is
+// done once, as the first panic or the shutdown tells the most.
+func (b *blackBox) dump(reason, detail string) (name string, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.dumped {
+		b.mu.Unlock()
+		return
+	}
+	b.dumped = true
+	b.mu.Unlock()
+
+	b.sample()
+	if err = os.MkdirAll(b.dir, 0o755); err != nil {
+		log.LogErrorf("action[blackBox] mkdir %v err %v", b.dir, err)
+		return
+	}
+	name = blackBoxDumpPrefix + reason + "-" + time.Now().Format(blackBoxTimeLayout) + ".json"
+	if err = b.writeFile(name, b.snapshot(reason, detail)); err != nil {
+		log.LogErrorf("action[blackBox] dump %v err %v", name, err)
+		return
+	}
+	log.LogWarnf("action[blackBox] dumped %v to %v", reason, path.Join(b.dir, name))
+	b.removeOldDumps()
+	return
+}
+
+func (b *blackBox) removeOldDumps() {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return
+	}
+	dumps := make([]string, 0)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), blackBoxDumpPrefix) && strings.HasSuffix(entry.Name(), ".json") {
+			dumps = append(dumps, entry.Name())
+		}
+	}
+	if len(dumps) <= blackBoxMaxDumps {
+		return
+	}
+	// ordered by the time of the dumps
+	sort.Slice(dumps, func(i, j int) bool {
+		return dumps[i][strings.LastIndex(dumps[i], "-"):] < dumps[j][strings.LastIndex(dumps[j], "-"):]
+	})
+	for _, name := range dumps[:len(dumps)-blackBoxMaxDumps] {
+		if err = os.Remove(path.Join(b.dir, name)); err != nil {
+			log.LogWarnf("action[blackBox] remove %v err %v", name, err)
+		}
+	}
+}
+
+func (s *DataNode) getBlackBox(w http.ResponseWriter, r *http.Request) {
+	if s.blackBox == nil {
+		s.buildFailureResp(w, http.StatusNotFound, "black box is disabled")
+		return
+	}
+	s.buildSuccessResp(w, s.blackBox.snapshot("query", fmt.Sprintf("ring of %v samples every %v",
+		s.blackBox.slots, s.blackBox.interval)))
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlackBoxTestPacket(opcode uint8, pid uint64, size uint32) *repl.Packet {
+	p := &repl.Packet{}
+	p.Opcode = opcode
+	p.PartitionID = pid
+	p.ExtentID = 1025
+	p.Size = size
+	p.ReqID = proto.GenerateRequestID()
+	return p
+}
+
+func TestBlackBoxRing(t *testing.T) {
+	box := newBlackBox(t.TempDir(), 0, 3)
+	require.Equal(t, DefaultBlackBoxInterval, box.interval)
+
+	read := newBlackBoxTestPacket(proto.OpStreamRead, 1, 4096)
+	write := newBlackBoxTestPacket(proto.OpWrite, 2, 128)
+	heartbeat := newBlackBoxTestPacket(proto.OpDataNodeHeartbeat, 0, 0)
+	box.end(box.begin(read, "client"), read, read.Size, 100, false)
+	box.end(box.begin(read, "client"), read, read.Size, 300, true)
+	box.end(box.begin(write, "client"), write, write.Size, 50, false)
+	box.end(box.begin(heartbeat, "master"), heartbeat, heartbeat.Size, 10, false)
+	box.begin(write, "client")
+
+	sample := box.sample()
+	require.Equal(t, 1, sample.InFlight)
+	require.Equal(t, []*BlackBoxPartitionStat{
+		{PartitionID: 1, Reads: 2, ReadBytes: 8192, Errors: 1, AvgCostUs: 200, MaxCostUs: 300},
+		{PartitionID: 2, Writes: 1, WriteBytes: 128, AvgCostUs: 50, MaxCostUs: 50},
+	}, sample.Partitions)
+
+	// the oldest samples are overwritten
+	for i := 0; i < 3; i++ {
+		box.end(box.begin(write, "client"), write, write.Size, 50, false)
+		box.sample()
+	}
+	samples := box.recentSamples()
+	require.Len(t, samples, 3)
+	for _, s := range samples {
+		require.Len(t, s.Partitions, 1)
+		require.Equal(t, uint64(2), s.Partitions[0].PartitionID)
+	}
+
+	require.NoError(t, box.persist())
+	data, err := os.ReadFile(path.Join(box.dir, blackBoxRingFile))
+	require.NoError(t, err)
+	persisted := make([]*BlackBoxSample, 0)
+	require.NoError(t, json.Unmarshal(data, &persisted))
+	require.Equal(t, samples, persisted)
+}
+
+func TestBlackBoxDump(t *testing.T) {
+	box := newBlackBox(path.Join(t.TempDir(), blackBoxDirName), 0, 0)
+	write := newBlackBoxTestPacket(proto.OpWrite, 2, 128)
+	box.end(box.begin(write, "client"), write, write.Size, 50, false)
+	box.begin(write, "client")
+
+	name, err := box.dump(blackBoxReasonPanic, "boom")
+	require.NoError(t, err)
+	data, err := os.ReadFile(path.Join(box.dir, name))
+	require.NoError(t, err)
+	dump := &BlackBoxDump{}
+	require.NoError(t, json.Unmarshal(data, dump))
+	require.Equal(t, blackBoxReasonPanic, dump.Reason)
+	require.Equal(t, "boom", dump.Detail)
+	require.Len(t, dump.Samples, 1)
+	require.Len(t, dump.InFlight, 1)
+	require.Equal(t, write.ReqID, dump.InFlight[0].ReqID)
+	require.Equal(t, "client", dump.InFlight[0].Remote)
+
+	// dumped once
+	name, err = box.dump(blackBoxReasonStop, "")
+	require.NoError(t, err)
+	require.Empty(t, name)
+
+	// the latest dumps are kept
+	for i := 0; i < blackBoxMaxDumps+2; i++ {
+		name = fmt.Sprintf("%v%v-%v.json", blackBoxDumpPrefix, blackBoxReasonStop, 20230101000000+i)
+		require.NoError(t, os.WriteFile(path.Join(box.dir, name), []byte("{}"), 0o644))
+	}
+	box.removeOldDumps()
+	entries, err := os.ReadDir(box.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, blackBoxMaxDumps)
+	_, err = os.Stat(path.Join(box.dir, name))
+	require.NoError(t, err)
+
+	// nothing is recorded if disabled
+	var disabled *blackBox
+	require.Equal(t, -1, disabled.begin(write, "client"))
+	disabled.end(-1, write, write.Size, 0, false)
+	_, err = disabled.dump(blackBoxReasonStop, "")
+	require.NoError(t, err)
+}
+
+func TestBlackBoxDumpOnPanic(t *testing.T) {
+	s := &DataNode{blackBox: newBlackBox(path.Join(t.TempDir(), blackBoxDirName), 0, 0)}
+	s.DumpOnPanic()
+	entries, err := os.ReadDir(s.blackBox.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.True(t, strings.HasPrefix(entries[0].Name(), blackBoxDumpPrefix+blackBoxReasonPanic))
+	data, err := os.ReadFile(path.Join(s.blackBox.dir, entries[0].Name()))
+	require.NoError(t, err)
+	dump := &BlackBoxDump{}
+	require.NoError(t, json.Unmarshal(data, dump))
+	require.Contains(t, dump.Detail, "DumpOnPanic")
+
+	// nothing is dumped if disabled
+	(&DataNode{}).DumpOnPanic()
+}
+
+func TestBlackBoxRequestSlots(t *testing.T) {
+	box := newBlackBox(t.TempDir(), 0, 0)
+	box.requests = box.requests[:blackBoxRequestProbes]
+	write := newBlackBoxTestPacket(proto.OpWrite, 2, 128)
+
+	// the requests beyond the slots are not tracked
+	slots := make([]int, 0)
+	for i := 0; i < blackBoxRequestProbes; i++ {
+		slot := box.begin(write, "client")
+		require.NotEqual(t, -1, slot)
+		slots = append(slots, slot)
+	}
+	require.Equal(t, -1, box.begin(write, "client"))
+	dump := box.snapshot("query", "")
+	require.Len(t, dump.InFlight, blackBoxRequestProbes)
+	require.Equal(t, uint64(1), dump.Untracked)
+	require.Equal(t, "OpWrite", dump.InFlight[0].Op)
+	for _, slot := range slots {
+		box.end(slot, write, write.Size, 50, false)
+	}
+	require.Empty(t, box.inFlightRequests())
+
+	// the slots are read while being rewritten
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := newBlackBoxTestPacket(proto.OpStreamRead, 1, 4096)
+			for j := 0; j < 1000; j++ {
+				box.end(box.begin(p, "client"), p, p.Size, 10, false)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		for _, req := range box.inFlightRequests() {
+			require.Equal(t, uint64(1), req.PartitionID)
+			require.Equal(t, "client", req.Remote)
+		}
+	}
+	wg.Wait()
+	require.Empty(t, box.inFlightRequests())
+}
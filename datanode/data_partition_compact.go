@@ -0,0 +1,630 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// ExtentOwnerFile records the inode each normal extent was created for, by the create packets of the clients.
+	ExtentOwnerFile = "EXTENT_OWNER"
+
+	DefaultCompactColdTime   = 7 * 24 * time.Hour // partitions not written within the time are compacted
+	IntervalToCompactExtents = time.Hour
+
+	extentOwnerRecordSize = 16
+	extentOwnerMinStale   = 1024
+	compactMaxExtentSize  = 64 * util.MB
+	compactMinExtents     = 16
+	compactPacketDeadline = 30 // seconds
+	compactHeaderSize     = util.PageSize
+)
+
+// extentOwners is the extent id to inode map persisted as an append only log,
+// the log is rewritten once the stale records outnumber the live ones.
+type extentOwners struct {
+	sync.Mutex
+	path   string
+	file   *os.File
+	owners map[uint64]uint64
+	stale  int
+	loaded bool
+	closed bool
+}
+
+func newExtentOwners(dir string) *extentOwners {
+	return &extentOwners{path: path.Join(dir, ExtentOwnerFile)}
+}
+
+func (eo *extentOwners) load() (err error) {
+	if eo.loaded {
+		return
+	}
+	eo.owners = make(map[uint64]uint64)
+	eo.stale = 0
+	fp, err := os.Open(eo.path)
+	if os.IsNotExist(err) {
+		eo.loaded = true
+		return nil
+	} else if err != nil {
+		return
+	}
+	defer fp.Close()
+	reader := bufio.NewReader(fp)
+	record := make([]byte, extentOwnerRecordSize)
+	for {
+		if _, err = io.ReadFull(reader, record); err == io.EOF || err == io.ErrUnexpectedEOF {
+			// the torn record of the crash is dropped by the next rewrite
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		extentID := binary.BigEndian.Uint64(record[0:8])
+		inode := binary.BigEndian.Uint64(record[8:16])
+		if _, ok := eo.owners[extentID]; ok {
+			eo.stale++
+		}
+		if inode == 0 {
+			delete(eo.owners, extentID)
+			eo.stale++
+			continue
+		}
+		eo.owners[extentID] = inode
+	}
+	eo.loaded = true
+	return
+}
+
+func (eo *extentOwners) append(extentID, inode uint64) (err error) {
+	if eo.file == nil {
+		if eo.file, err = os.OpenFile(eo.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+			return
+		}
+	}
+	record := make([]byte, extentOwnerRecordSize)
+	binary.BigEndian.PutUint64(record[0:8], extentID)
+	binary.BigEndian.PutUint64(record[8:16], inode)
+	_, err = eo.file.Write(record)
+	return
+}
+
+// record records the inode the extent is created for.
+func (eo *extentOwners) record(extentID, inode uint64) (err error) {
+	eo.Lock()
+	defer eo.Unlock()
+	if eo.closed {
+		return
+	}
+	if err = eo.load(); err != nil {
+		return
+	}
+	if _, ok := eo.owners[extentID]; ok {
+		eo.stale++
+	}
+	eo.owners[extentID] = inode
+	return eo.append(extentID, inode)
+}
+
+func (eo *extentOwners) owner(extentID uint64) (inode uint64, ok bool) {
+	eo.Lock()
+	defer eo.Unlock()
+	if eo.closed || eo.load() != nil {
+		return
+	}
+	inode, ok = eo.owners[extentID]
+	return
+}
+
+// forget removes the owners of the extents, the extents not in keep are removed if keep is not nil.
+func (eo *extentOwners) forget(extentIDs []uint64, keep map[uint64]struct{}) (err error) {
+	eo.Lock()
+	defer eo.Unlock()
+	if eo.closed {
+		return
+	}
+	if err = eo.load(); err != nil {
+		return
+	}
+	if keep != nil {
+		for extentID := range eo.owners {
+			if _, ok := keep[extentID]; !ok {
+				extentIDs = append(extentIDs, extentID)
+			}
+		}
+	}
+	for _, extentID := range extentIDs {
+		if _, ok := eo.owners[extentID]; !ok {
+			continue
+		}
+		delete(eo.owners, extentID)
+		// the record of the owner and the one of the removal
+		eo.stale += 2
+		if err = eo.append(extentID, 0); err != nil {
+			return
+		}
+	}
+	if eo.stale > extentOwnerMinStale && eo.stale > len(eo.owners) {
+		err = eo.rewrite()
+	}
+	return
+}
+
+func (eo *extentOwners) rewrite() (err error) {
+	tmpPath := eo.path + ".tmp"
+	fp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	writer := bufio.NewWriter(fp)
+	record := make([]byte, extentOwnerRecordSize)
+	for extentID, inode := range eo.owners {
+		binary.BigEndian.PutUint64(record[0:8], extentID)
+		binary.BigEndian.PutUint64(record[8:16], inode)
+		if _, err = writer.Write(record); err != nil {
+			fp.Close()
+			return
+		}
+	}
+	if err = writer.Flush(); err == nil {
+		err = fp.Sync()
+	}
+	fp.Close()
+	if err != nil {
+		return
+	}
+	if eo.file != nil {
+		eo.file.Close()
+		eo.file = nil
+	}
+	if err = os.Rename(tmpPath, eo.path); err != nil {
+		return
+	}
+	eo.stale = 0
+	return
+}
+
+func (eo *extentOwners) close() {
+	eo.Lock()
+	defer eo.Unlock()
+	eo.closed = true
+	if eo.file != nil {
+		eo.file.Close()
+		eo.file = nil
+	}
+}
+
+func (dp *DataPartition) isCompactEnabled() bool {
+	return dp.dataNode != nil && dp.dataNode.compactSmallExtentSize > 0 && dp.isNormalType()
+}
+
+// recordExtentOwner records the inode carried by the create packet of the client.
+func (dp *DataPartition) recordExtentOwner(p *repl.Packet) {
+	if !dp.isCompactEnabled() || len(p.Data) < 8 || p.Size < 8 {
+		return
+	}
+	inode := binary.BigEndian.Uint64(p.Data[:8])
+	if inode == 0 {
+		return
+	}
+	if err := dp.extentOwners.record(p.ExtentID, inode); err != nil {
+		log.LogWarnf("action[recordExtentOwner] dp %v extent %v inode %v err %v", dp.partitionID, p.ExtentID, inode, err)
+	}
+}
+
+// compactScheduler merges the small extents of the cold partition into the large ones on the leader.
+func (dp *DataPartition) compactScheduler() {
+	if !dp.isCompactEnabled() {
+		return
+	}
+	ticker := time.NewTicker(IntervalToCompactExtents)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			dp.compactSmallExtents()
+		case <-dp.stopC:
+			return
+		}
+	}
+}
+
+type compactMember struct {
+	inode      uint64
+	ek         proto.ExtentKey
+	mtime      int64
+	size       int64
+	modifyTime int64  // modify time of the small extent
+	crc        uint32 // crc of the small extent copied
+	offset     int64  // offset in the compacted extent
+}
+
+func (dp *DataPartition) compactStopped() bool {
+	return !dp.isLeader || dp.scrubStopped() || dp.Disk().Status != proto.ReadWrite || dp.Status() != proto.ReadWrite
+}
+
+func (dp *DataPartition) compactSmallExtents() {
+	if dp.IsDataPartitionLoading() || dp.compactStopped() || atomic.LoadUint64(&dp.verSeq) > 0 {
+		return
+	}
+	store := dp.ExtentStore()
+	extents, _, err := store.GetAllWatermarks(func(ei *storage.ExtentInfo) bool {
+		return !storage.IsTinyExtent(ei.FileID) && !ei.IsDeleted
+	})
+	if err != nil {
+		log.LogWarnf("action[compactSmallExtents] dp %v get watermarks err %v", dp.partitionID, err)
+		return
+	}
+	var (
+		lastModify int64
+		exists     = make(map[uint64]struct{}, len(extents))
+	)
+	for _, ei := range extents {
+		exists[ei.FileID] = struct{}{}
+		if ei.ModifyTime > lastModify {
+			lastModify = ei.ModifyTime
+		}
+	}
+	coldTime := int64(dp.dataNode.compactColdTime / time.Second)
+	if time.Now().Unix()-lastModify < coldTime {
+		return
+	}
+	// the owners of the extents deleted are useless
+	if err = dp.extentOwners.forget(nil, exists); err != nil {
+		log.LogWarnf("action[compactSmallExtents] dp %v prune extent owners err %v", dp.partitionID, err)
+	}
+
+	candidates := make([]*storage.ExtentInfo, 0)
+	for _, ei := range extents {
+		if ei.Size == 0 || ei.Size >= uint64(dp.dataNode.compactSmallExtentSize) || ei.SnapshotDataOff != util.ExtentSize {
+			continue
+		}
+		if _, ok := dp.extentOwners.owner(ei.FileID); ok {
+			candidates = append(candidates, ei)
+		}
+	}
+	if len(candidates) < compactMinExtents {
+		return
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].FileID < candidates[j].FileID })
+
+	mw, err := dp.dataNode.getCompactMetaWrapper(dp.volumeID)
+	if err != nil {
+		log.LogWarnf("action[compactSmallExtents] dp %v vol %v new meta wrapper err %v", dp.partitionID, dp.volumeID, err)
+		return
+	}
+	targetSize := dp.MaxExtentSize()
+	if targetSize > compactMaxExtentSize {
+		targetSize = compactMaxExtentSize
+	}
+	var (
+		members    = make([]*compactMember, 0)
+		size       = int64(compactHeaderSize)
+		compacted  int
+		extentsCnt int
+	)
+	for _, ei := range candidates {
+		if dp.compactStopped() {
+			return
+		}
+		member := dp.getCompactMember(mw, ei, coldTime)
+		if member == nil {
+			continue
+		}
+		alignedSize := (member.size + util.PageSize - 1) / util.PageSize * util.PageSize
+		if compactHeaderSize+alignedSize > targetSize {
+			continue
+		}
+		if size+alignedSize > targetSize {
+			if len(members) >= compactMinExtents {
+				compacted += dp.compactExtents(mw, members)
+				extentsCnt++
+			}
+			members, size = members[:0], int64(compactHeaderSize)
+		}
+		member.offset = size
+		size += alignedSize
+		members = append(members, member)
+	}
+	if len(members) >= compactMinExtents {
+		compacted += dp.compactExtents(mw, members)
+		extentsCnt++
+	}
+	log.LogInfof("action[compactSmallExtents] dp %v compacted %v small extents into %v extents", dp.partitionID, compacted, extentsCnt)
+}
+
+// getCompactMember returns the member if the small extent is referenced as a whole by the only extent key of its inode.
+func (dp *DataPartition) getCompactMember(mw *meta.MetaWrapper, ei *storage.ExtentInfo, coldTime int64) *compactMember {
+	inode, ok := dp.extentOwners.owner(ei.FileID)
+	if !ok {
+		return nil
+	}
+	info, err := mw.InodeGet_ll(inode)
+	if err == syscall.ENOENT {
+		dp.extentOwners.forget([]uint64{ei.FileID}, nil)
+		return nil
+	} else if err != nil || !proto.IsRegular(info.Mode) || time.Now().Unix()-info.ModifyTime.Unix() < coldTime {
+		return nil
+	}
+	_, _, eks, err := mw.GetExtents(inode)
+	if err != nil {
+		return nil
+	}
+	if len(eks) != 1 || eks[0].PartitionId != dp.partitionID || eks[0].ExtentId != ei.FileID ||
+		eks[0].FileOffset != 0 || eks[0].ExtentOffset != 0 || uint64(eks[0].Size) != ei.Size || eks[0].IsSplit() {
+		// the extent is not owned by the inode any more, or shared by the extent keys
+		dp.extentOwners.forget([]uint64{ei.FileID}, nil)
+		return nil
+	}
+	return &compactMember{
+		inode:      inode,
+		ek:         eks[0],
+		mtime:      info.ModifyTime.Unix(),
+		size:       int64(ei.Size),
+		modifyTime: ei.ModifyTime,
+	}
+}
+
+func (dp *DataPartition) newCompactPacket(opcode uint8, extentID uint64) *repl.Packet {
+	p := repl.NewPacket()
+	p.Opcode = opcode
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = dp.partitionID
+	p.ExtentID = extentID
+	p.ReqID = proto.GenerateRequestID()
+	p.VerSeq = atomic.LoadUint64(&dp.verSeq)
+	replicas := dp.getReplicaCopy()
+	p.Arg = []byte(strings.Join(replicas[1:], proto.AddrSplit) + proto.AddrSplit)
+	p.ArgLen = uint32(len(p.Arg))
+	p.RemainingFollowers = uint8(len(replicas) - 1)
+	if len(replicas) == 1 {
+		p.RemainingFollowers = 127
+	}
+	return p
+}
+
+// sendCompactPacket sends the packet to the local node as the leader, the packet is replicated as the ones of the clients.
+func (dp *DataPartition) sendCompactPacket(conn net.Conn, p *repl.Packet) (reply *repl.Packet, err error) {
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	reply = new(repl.Packet)
+	if err = reply.ReadFromConnWithVer(conn, compactPacketDeadline); err != nil {
+		return
+	}
+	if reply.ResultCode != proto.OpOk {
+		err = fmt.Errorf("op(%v) reply(%v)", p.GetOpMsg(), reply.GetResultMsg())
+	}
+	return
+}
+
+// compactExtents copies the members into a new extent and swaps the extent keys of the inodes to the new extent,
+// the members failed to swap, like the files modified meanwhile, are left as they are. It returns the count of
+// the members swapped.
+//
+// The members are aligned to the page and no member is at the offset 0 of the new extent, so the deletion of the
+// extent key of a member punches the hole of the member rather than deleting the whole new extent.
+func (dp *DataPartition) compactExtents(mw *meta.MetaWrapper, members []*compactMember) (compacted int) {
+	conn, err := gConnPool.GetConnect(dp.dataNode.localServerAddr)
+	if err != nil {
+		log.LogWarnf("action[compactExtents] dp %v connect err %v", dp.partitionID, err)
+		return
+	}
+	defer func() {
+		gConnPool.PutConnect(conn, err != nil)
+	}()
+
+	p := dp.newCompactPacket(proto.OpCreateExtent, 0)
+	p.Data = make([]byte, 8)
+	p.Size = uint32(len(p.Data))
+	reply, err := dp.sendCompactPacket(conn, p)
+	if err != nil {
+		log.LogWarnf("action[compactExtents] dp %v create extent err %v", dp.partitionID, err)
+		return
+	}
+	extentID := reply.ExtentID
+	if err = dp.writeCompactExtent(conn, extentID, members); err != nil {
+		log.LogWarnf("action[compactExtents] dp %v write extent %v err %v", dp.partitionID, extentID, err)
+	} else {
+		compacted = dp.swapCompactExtentKeys(mw, extentID, members)
+	}
+	if compacted > 0 {
+		log.LogInfof("action[compactExtents] dp %v compacted %v of %v small extents into extent %v",
+			dp.partitionID, compacted, len(members), extentID)
+		return
+	}
+	// nothing refers to the new extent
+	p = dp.newCompactPacket(proto.OpMarkDelete, extentID)
+	p.Data, _ = json.Marshal(&proto.ExtentKey{PartitionId: dp.partitionID, ExtentId: extentID})
+	p.Size = uint32(len(p.Data))
+	if _, err = dp.sendCompactPacket(conn, p); err != nil {
+		log.LogWarnf("action[compactExtents] dp %v delete extent %v err %v", dp.partitionID, extentID, err)
+	}
+	return
+}
+
+func (dp *DataPartition) writeCompactExtent(conn net.Conn, extentID uint64, members []*compactMember) (err error) {
+	var (
+		store  = dp.ExtentStore()
+		buf    = make([]byte, util.BlockSize)
+		bufLen int
+		offset int64
+	)
+	flush := func() error {
+		if bufLen == 0 {
+			return nil
+		}
+		p := dp.newCompactPacket(proto.OpWrite, extentID)
+		p.ExtentOffset = offset
+		p.Data = buf[:bufLen]
+		p.Size = uint32(bufLen)
+		p.CRC = crc32.ChecksumIEEE(p.Data)
+		if _, err := dp.sendCompactPacket(conn, p); err != nil {
+			return err
+		}
+		offset += int64(bufLen)
+		buf, bufLen = make([]byte, util.BlockSize), 0
+		return nil
+	}
+	// skip to the offset by the zeros, the writes of the extent are appended
+	pad := func(to int64) error {
+		for offset+int64(bufLen) < to {
+			n := int(to - offset - int64(bufLen))
+			if n > util.BlockSize-bufLen {
+				n = util.BlockSize - bufLen
+			}
+			bufLen += n
+			if bufLen == util.BlockSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, member := range members {
+		if err = pad(member.offset); err != nil {
+			return
+		}
+		member.crc = 0
+		for read := int64(0); read < member.size; {
+			if dp.compactStopped() {
+				return fmt.Errorf("partition is not writable or not the leader")
+			}
+			n := member.size - read
+			if n > int64(util.BlockSize-bufLen) {
+				n = int64(util.BlockSize - bufLen)
+			}
+			dp.disk.limitRead.Run(int(n), func() {
+				_, err = store.Read(member.ek.ExtentId, read, n, buf[bufLen:bufLen+int(n)], false)
+			})
+			if err != nil {
+				dp.checkIsDiskError(err, ReadFlag)
+				return
+			}
+			member.crc = crc32.Update(member.crc, crc32.IEEETable, buf[bufLen:bufLen+int(n)])
+			read += n
+			bufLen += int(n)
+			if bufLen == util.BlockSize {
+				if err = flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return flush()
+}
+
+// compactMemberUnchanged returns false if the small extent is written after it was copied. The modify time
+// is in seconds, so the data are read again and checked by the crc against the overwrites in the same second.
+func (dp *DataPartition) compactMemberUnchanged(member *compactMember) bool {
+	store := dp.ExtentStore()
+	ei, err := store.Watermark(member.ek.ExtentId)
+	if err != nil || ei.IsDeleted || int64(ei.Size) != member.size || ei.ModifyTime != member.modifyTime {
+		return false
+	}
+	var (
+		crc uint32
+		buf = make([]byte, util.BlockSize)
+	)
+	for read := int64(0); read < member.size; {
+		n := member.size - read
+		if n > util.BlockSize {
+			n = util.BlockSize
+		}
+		dp.disk.limitRead.Run(int(n), func() {
+			_, err = store.Read(member.ek.ExtentId, read, n, buf[:n], false)
+		})
+		if err != nil {
+			dp.checkIsDiskError(err, ReadFlag)
+			return false
+		}
+		crc = crc32.Update(crc, crc32.IEEETable, buf[:n])
+		read += n
+	}
+	return crc == member.crc
+}
+
+func (dp *DataPartition) swapCompactExtentKeys(mw *meta.MetaWrapper, extentID uint64, members []*compactMember) (compacted int) {
+	swapped := make([]uint64, 0, len(members))
+	for _, member := range members {
+		if !dp.compactMemberUnchanged(member) {
+			continue
+		}
+		ek := member.ek
+		ek.ExtentId = extentID
+		ek.ExtentOffset = uint64(member.offset)
+		ek.CRC = 0
+		// the metanode checks the discarded key and deletes the small extent
+		if _, err := mw.AppendExtentKey(0, member.inode, ek, []proto.ExtentKey{member.ek}); err != nil {
+			log.LogWarnf("action[swapCompactExtentKeys] dp %v inode %v swap %v to %v err %v",
+				dp.partitionID, member.inode, member.ek, ek, err)
+			continue
+		}
+		// keep the modify time of the file, the change time is updated by the swap though
+		if err := mw.Setattr(member.inode, proto.AttrModifyTime, 0, 0, 0, 0, member.mtime); err != nil {
+			log.LogWarnf("action[swapCompactExtentKeys] dp %v inode %v restore mtime err %v", dp.partitionID, member.inode, err)
+		}
+		swapped = append(swapped, member.ek.ExtentId)
+		compacted++
+	}
+	if err := dp.extentOwners.forget(swapped, nil); err != nil {
+		log.LogWarnf("action[swapCompactExtentKeys] dp %v forget extent owners err %v", dp.partitionID, err)
+	}
+	return
+}
+
+// getCompactMetaWrapper returns the meta wrapper of the volume to swap the extent keys of the compaction.
+func (s *DataNode) getCompactMetaWrapper(volName string) (mw *meta.MetaWrapper, err error) {
+	if val, ok := s.compactMetaWrappers.Load(volName); ok {
+		return val.(*meta.MetaWrapper), nil
+	}
+	metaConfig := &meta.MetaConfig{
+		Volume:        volName,
+		Masters:       MasterClient.Nodes(),
+		Authenticate:  false,
+		ValidateOwner: false,
+	}
+	if mw, err = meta.NewMetaWrapper(metaConfig); err != nil {
+		return
+	}
+	if val, loaded := s.compactMetaWrappers.LoadOrStore(volName, mw); loaded {
+		mw.Close()
+		mw = val.(*meta.MetaWrapper)
+	}
+	return
+}
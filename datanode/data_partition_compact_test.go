@@ -0,0 +1,113 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"hash/crc32"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtentOwners(t *testing.T) {
+	dir := t.TempDir()
+	eo := newExtentOwners(dir)
+	for extentID := uint64(1025); extentID < 1025+3*extentOwnerMinStale; extentID++ {
+		require.NoError(t, eo.record(extentID, extentID*10))
+	}
+	require.NoError(t, eo.record(1025, 1))
+	require.NoError(t, eo.forget([]uint64{1026, 1027}, nil))
+	eo.close()
+	// recording after the partition stopped is ignored
+	require.NoError(t, eo.record(1, 1))
+
+	eo = newExtentOwners(dir)
+	inode, ok := eo.owner(1025)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), inode)
+	_, ok = eo.owner(1026)
+	require.False(t, ok)
+	_, ok = eo.owner(1)
+	require.False(t, ok)
+	inode, ok = eo.owner(1028)
+	require.True(t, ok)
+	require.Equal(t, uint64(10280), inode)
+	require.Equal(t, 5, eo.stale)
+
+	// the extents deleted are pruned and the log is rewritten
+	keep := map[uint64]struct{}{1025: {}, 1028: {}}
+	require.NoError(t, eo.forget(nil, keep))
+	require.Equal(t, 0, eo.stale)
+	info, err := os.Stat(path.Join(dir, ExtentOwnerFile))
+	require.NoError(t, err)
+	require.Equal(t, int64(2*extentOwnerRecordSize), info.Size())
+	eo.close()
+
+	eo = newExtentOwners(dir)
+	require.NoError(t, eo.load())
+	require.Equal(t, map[uint64]uint64{1025: 1, 1028: 10280}, eo.owners)
+}
+
+func TestCompactMemberUnchanged(t *testing.T) {
+	store, err := storage.NewExtentStore(path.Join(t.TempDir(), "datapartition_1"), 1, util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer store.Close()
+	dp := &DataPartition{extentStore: store, disk: &Disk{limitRead: newIOLimiter(0, 0)}}
+
+	extentID, err := store.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, store.Create(extentID))
+	data := make([]byte, util.BlockSize+100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for offset := 0; offset < len(data); offset += util.BlockSize {
+		end := offset + util.BlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err = store.Write(extentID, int64(offset), int64(end-offset), data[offset:end],
+			crc32.ChecksumIEEE(data[offset:end]), storage.AppendWriteType, true)
+		require.NoError(t, err)
+	}
+	ei, err := store.Watermark(extentID)
+	require.NoError(t, err)
+	member := &compactMember{
+		ek:         proto.ExtentKey{PartitionId: 1, ExtentId: extentID, Size: uint32(len(data))},
+		size:       int64(len(data)),
+		modifyTime: ei.ModifyTime,
+		crc:        crc32.ChecksumIEEE(data),
+	}
+	require.True(t, dp.compactMemberUnchanged(member))
+
+	// the overwrite in the same second keeps the size and the modify time of the extent
+	data[0]++
+	_, err = store.Write(extentID, 0, 1, data[:1], crc32.ChecksumIEEE(data[:1]), storage.RandomWriteType, true)
+	require.NoError(t, err)
+	ei, err = store.Watermark(extentID)
+	require.NoError(t, err)
+	member.modifyTime = ei.ModifyTime
+	require.False(t, dp.compactMemberUnchanged(member))
+	member.crc = crc32.ChecksumIEEE(data)
+	require.True(t, dp.compactMemberUnchanged(member))
+
+	member.size++
+	require.False(t, dp.compactMemberUnchanged(member))
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// DiskRunningMark exists on the disk while the datanode is running, and is removed on the clean
+	// shutdown. The modify time of the mark is renewed periodically as the last time the disk was alive.
+	DiskRunningMark = ".diskRunning"
+
+	DefaultCrashVerifyWindow  = 10 * time.Minute // extents modified within the window before the crash are verified
+	crashVerifyRetryInterval  = time.Minute
+	crashVerifyMaxRounds      = 30
+	maxSuspectRegionsPerDisk  = 1024
+	suspectRegionReasonRepair = "repair failed: "
+	suspectRegionReasonRead   = "read failed: "
+)
+
+type suspectBlock struct {
+	partitionID uint64
+	extentID    uint64
+	blockNo     int
+}
+
+// checkUncleanShutdown finds the running mark left by the last run of the datanode, whose modify
+// time is the last time the disk was alive, and creates the mark for this run.
+func (d *Disk) checkUncleanShutdown() {
+	markPath := path.Join(d.Path, DiskRunningMark)
+	info, err := os.Stat(markPath)
+	if err == nil {
+		d.lastAliveTime = info.ModTime().Unix()
+		msg := fmt.Sprintf("disk %v on %v was not shut down cleanly, last alive at %v, verify the extents modified since %v",
+			d.Path, LocalIP, info.ModTime(), info.ModTime().Add(-d.dataNode.crashVerifyWindow))
+		exporter.Warning(msg)
+		log.LogWarn(msg)
+	} else if !os.IsNotExist(err) {
+		log.LogErrorf("action[checkUncleanShutdown] disk %v stat running mark err %v", d.Path, err)
+	}
+	d.touchRunningMark()
+}
+
+func (d *Disk) touchRunningMark() {
+	markPath := path.Join(d.Path, DiskRunningMark)
+	now := time.Now()
+	err := os.Chtimes(markPath, now, now)
+	if err == nil {
+		return
+	}
+	if !os.IsNotExist(err) {
+		log.LogErrorf("action[touchRunningMark] disk %v err %v", d.Path, err)
+		return
+	}
+	fp, err := os.Create(markPath)
+	if err != nil {
+		log.LogErrorf("action[touchRunningMark] disk %v create err %v", d.Path, err)
+		return
+	}
+	fp.Close()
+}
+
+// markCleanShutdown removes the running mark after the partitions on the disk are stopped.
+func (d *Disk) markCleanShutdown() {
+	if err := os.Remove(path.Join(d.Path, DiskRunningMark)); err != nil && !os.IsNotExist(err) {
+		log.LogErrorf("action[markCleanShutdown] disk %v err %v", d.Path, err)
+	}
+}
+
+func (d *Disk) crashVerifySince() int64 {
+	if d.lastAliveTime == 0 || d.dataNode.crashVerifyWindow <= 0 {
+		return 0
+	}
+	return d.lastAliveTime - int64(d.dataNode.crashVerifyWindow/time.Second)
+}
+
+// doCrashVerifyTask verifies the extents written shortly before the unclean shutdown ahead of the scrub.
+// The corrupt blocks are repaired from the replicas, and the blocks which cannot be verified or repaired
+// are reported to the master as the suspect regions. The verification is retried round by round, as the
+// replicas may not be ready to repair soon after the restart.
+func (d *Disk) doCrashVerifyTask() {
+	if d.crashVerifySince() == 0 {
+		return
+	}
+	for round := 0; round < crashVerifyMaxRounds; round++ {
+		if round > 0 {
+			time.Sleep(crashVerifyRetryInterval)
+		}
+		if d.Status == proto.Unavailable {
+			continue
+		}
+		partitions := make([]*DataPartition, 0)
+		d.RLock()
+		for _, dp := range d.partitionMap {
+			partitions = append(partitions, dp)
+		}
+		d.RUnlock()
+		var pending int
+		for _, dp := range partitions {
+			pending += dp.crashVerify()
+		}
+		log.LogInfof("action[doCrashVerifyTask] disk %v round %v pending extents %v", d.Path, round, pending)
+		if pending == 0 {
+			return
+		}
+	}
+	log.LogWarnf("action[doCrashVerifyTask] disk %v gives up with %v suspect regions", d.Path, len(d.getSuspectRegions()))
+}
+
+func (d *Disk) reportSuspectRegion(dp *DataPartition, extentID uint64, blockNo int, size int64, reason string) {
+	key := suspectBlock{partitionID: dp.partitionID, extentID: extentID, blockNo: blockNo}
+	d.suspectMutex.Lock()
+	defer d.suspectMutex.Unlock()
+	if _, ok := d.suspectRegions[key]; !ok && len(d.suspectRegions) >= maxSuspectRegionsPerDisk {
+		return
+	}
+	d.suspectRegions[key] = proto.SuspectExtentRegion{
+		DiskPath:    d.Path,
+		PartitionID: dp.partitionID,
+		ExtentID:    extentID,
+		Offset:      int64(blockNo) * util.BlockSize,
+		Size:        size,
+		Reason:      reason,
+		ReportTime:  time.Now().Unix(),
+	}
+}
+
+func (d *Disk) clearSuspectRegions(partitionID, extentID uint64) {
+	d.suspectMutex.Lock()
+	defer d.suspectMutex.Unlock()
+	for key := range d.suspectRegions {
+		if key.partitionID == partitionID && key.extentID == extentID {
+			delete(d.suspectRegions, key)
+		}
+	}
+}
+
+func (d *Disk) getSuspectRegions() (regions []proto.SuspectExtentRegion) {
+	d.suspectMutex.Lock()
+	defer d.suspectMutex.Unlock()
+	regions = make([]proto.SuspectExtentRegion, 0, len(d.suspectRegions))
+	for _, region := range d.suspectRegions {
+		regions = append(regions, region)
+	}
+	return
+}
+
+// markSuspectExtents marks the normal extents modified since the time, the reads of the clients on
+// them are verified until the extents are verified by the crash verification.
+func (dp *DataPartition) markSuspectExtents(since int64) {
+	if !dp.isNormalType() {
+		return
+	}
+	extents, _, err := dp.ExtentStore().GetAllWatermarks(func(ei *storage.ExtentInfo) bool {
+		return !storage.IsTinyExtent(ei.FileID) && ei.ModifyTime >= since
+	})
+	if err != nil {
+		log.LogWarnf("action[markSuspectExtents] dp %v get watermarks err %v", dp.partitionID, err)
+	}
+	for _, ei := range extents {
+		dp.suspectExtents.Store(ei.FileID, struct{}{})
+	}
+	if len(extents) > 0 {
+		log.LogWarnf("action[markSuspectExtents] dp %v marks %v extents modified since %v", dp.partitionID, len(extents), since)
+	}
+}
+
+func (dp *DataPartition) isSuspectExtent(extentID uint64) bool {
+	_, ok := dp.suspectExtents.Load(extentID)
+	return ok
+}
+
+// crashVerify verifies the suspect extents of the partition, and returns the count of the extents left.
+func (dp *DataPartition) crashVerify() (pending int) {
+	dp.suspectExtents.Range(func(key, _ interface{}) bool {
+		extentID := key.(uint64)
+		if dp.IsDataPartitionLoading() || dp.scrubStopped() || !dp.crashVerifyExtent(extentID) {
+			pending++
+			return true
+		}
+		dp.suspectExtents.Delete(extentID)
+		dp.disk.clearSuspectRegions(dp.partitionID, extentID)
+		return true
+	})
+	return
+}
+
+// crashVerifyExtent returns true if all the blocks of the extent are verified or repaired.
+func (dp *DataPartition) crashVerifyExtent(extentID uint64) (ok bool) {
+	store := dp.ExtentStore()
+	ei, err := store.Watermark(extentID)
+	if err != nil || ei.IsDeleted {
+		return true
+	}
+	ok = true
+	data := make([]byte, util.BlockSize)
+	blockCnt := int((ei.Size + util.BlockSize - 1) / util.BlockSize)
+	for blockNo := 0; blockNo < blockCnt; blockNo++ {
+		var (
+			crc     uint32
+			size    int64
+			corrupt bool
+		)
+		dp.disk.limitRead.Run(util.BlockSize, func() {
+			crc, size, corrupt, err = store.VerifyBlock(extentID, blockNo, data)
+		})
+		if err != nil {
+			// the extent deleted since
+			if ei, wErr := store.Watermark(extentID); wErr != nil || ei.IsDeleted {
+				return true
+			}
+			dp.checkIsDiskError(err, ReadFlag)
+			log.LogErrorf("action[crashVerify] dp %v extent %v block %v verify err %v", dp.partitionID, extentID, blockNo, err)
+			dp.disk.reportSuspectRegion(dp, extentID, blockNo, util.BlockSize, suspectRegionReasonRead+err.Error())
+			return false
+		}
+		if !corrupt {
+			continue
+		}
+		log.LogErrorf("action[crashVerify] dp %v extent %v block %v size %v crc %v is corrupt",
+			dp.partitionID, extentID, blockNo, size, crc)
+		if err = dp.repairBlockFromReplicas(extentID, blockNo, size, crc); err != nil {
+			log.LogErrorf("action[crashVerify] dp %v extent %v block %v repair err %v", dp.partitionID, extentID, blockNo, err)
+			dp.disk.reportSuspectRegion(dp, extentID, blockNo, size, suspectRegionReasonRepair+err.Error())
+			ok = false
+		}
+	}
+	return
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUncleanShutdown(t *testing.T) {
+	newDisk := func(dir string) *Disk {
+		d := &Disk{
+			Path:           dir,
+			dataNode:       &DataNode{crashVerifyWindow: DefaultCrashVerifyWindow},
+			suspectRegions: make(map[suspectBlock]proto.SuspectExtentRegion),
+		}
+		d.checkUncleanShutdown()
+		return d
+	}
+	dir := t.TempDir()
+
+	// the first run, and the clean shutdown
+	d := newDisk(dir)
+	require.Equal(t, int64(0), d.crashVerifySince())
+	_, err := os.Stat(path.Join(dir, DiskRunningMark))
+	require.NoError(t, err)
+	d.markCleanShutdown()
+	d = newDisk(dir)
+	require.Equal(t, int64(0), d.crashVerifySince())
+
+	// the mark left by the crash
+	alive := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path.Join(dir, DiskRunningMark), alive, alive))
+	d = newDisk(dir)
+	require.Equal(t, alive.Unix(), d.lastAliveTime)
+	require.Equal(t, alive.Add(-DefaultCrashVerifyWindow).Unix(), d.crashVerifySince())
+	d.dataNode.crashVerifyWindow = -1
+	require.Equal(t, int64(0), d.crashVerifySince())
+
+	// the regions which cannot be repaired are reported until the extent is verified
+	dp := &DataPartition{partitionID: 1}
+	d.reportSuspectRegion(dp, 1024, 1, 100, suspectRegionReasonRepair+"no healthy replica")
+	d.reportSuspectRegion(dp, 1024, 2, 100, suspectRegionReasonRepair+"no healthy replica")
+	d.reportSuspectRegion(dp, 1025, 0, 100, suspectRegionReasonRepair+"no healthy replica")
+	regions := d.getSuspectRegions()
+	require.Len(t, regions, 3)
+	for _, region := range regions {
+		if region.ExtentID == 1024 && region.Offset == 2*util.BlockSize {
+			require.Equal(t, dir, region.DiskPath)
+			require.Equal(t, int64(100), region.Size)
+		}
+	}
+	d.clearSuspectRegions(1, 1024)
+	regions = d.getSuspectRegions()
+	require.Len(t, regions, 1)
+	require.Equal(t, uint64(1025), regions[0].ExtentID)
+
+	dp.suspectExtents.Store(uint64(1024), struct{}{})
+	require.True(t, dp.isSuspectExtent(1024))
+	require.False(t, dp.isSuspectExtent(1025))
+}
@@ -40,6 +40,7 @@ type DataPartitionRepairTask struct {
 	extents                        map[uint64]*storage.ExtentInfo
 	ExtentsToBeCreated             []*storage.ExtentInfo
 	ExtentsToBeRepaired            []*storage.ExtentInfo
+	EnvelopesToBeRepaired          []*storage.ExtentEnvelope
 	LeaderTinyDeleteRecordFileSize int64
 	LeaderAddr                     string
 }
@@ -49,6 +50,7 @@ func NewDataPartitionRepairTask(extentFiles []*storage.ExtentInfo, tinyDeleteRec
 		extents:                        make(map[uint64]*storage.ExtentInfo),
 		ExtentsToBeCreated:             make([]*storage.ExtentInfo, 0),
 		ExtentsToBeRepaired:            make([]*storage.ExtentInfo, 0),
+		EnvelopesToBeRepaired:          make([]*storage.ExtentEnvelope, 0),
 		LeaderTinyDeleteRecordFileSize: tinyDeleteRecordFileSize,
 		LeaderAddr:                     leaderAddr,
 	}
@@ -68,6 +70,8 @@ func NewDataPartitionRepairTask(extentFiles []*storage.ExtentInfo, tinyDeleteRec
 //     - for each extent, we compare all the replicas to find the one with the largest size.
 //     - periodically check the size of the local extent, and if it is smaller than the largest size,
 //     add it to the tobeRepaired list, and generate the corresponding tasks.
+//     - the newest encryption envelope of each extent is repaired to the replicas missing it, since
+//     the envelopes replicated by raft are lost on the replicas rebuilt after the log is truncated.
 //  2. tiny extent repair:
 //     - when creating the new partition, add all tiny extents to the toBeRepaired list,
 //     and the repair task will create all the tiny extents first.
@@ -173,11 +177,7 @@ func (dp *DataPartition) getLocalExtentInfo(extentType uint8, tinyExtents []uint
 		extents = make([]*storage.ExtentInfo, 0)
 		return
 	}
-	extents = make([]*storage.ExtentInfo, 0, len(localExtents))
-	for _, et := range localExtents {
-		newEt := *et
-		extents = append(extents, &newEt)
-	}
+	extents = dp.extentStore.AttachExtentEnvelopes(localExtents)
 	return
 }
 
@@ -239,6 +239,7 @@ func (dp *DataPartition) DoRepair(repairTasks []*DataPartitionRepairTask) {
 
 		store.Create(extentInfo.FileID)
 	}
+	dp.repairExtentEnvelopes(repairTasks[0].EnvelopesToBeRepaired)
 	log.LogDebugf("action[DoRepair] leader to repair len[%v], {%v}", len(repairTasks[0].ExtentsToBeRepaired), repairTasks[0].ExtentsToBeRepaired)
 	for _, extentInfo := range repairTasks[0].ExtentsToBeRepaired {
 		log.LogDebugf("action[DoRepair] leader to repair len[%v], {%v}", len(repairTasks[0].ExtentsToBeRepaired), extentInfo)
@@ -328,9 +329,50 @@ func (dp *DataPartition) prepareRepairTasks(repairTasks []*DataPartitionRepairTa
 	}
 	dp.buildExtentCreationTasks(repairTasks, extentInfoMap)
 	availableTinyExtents, brokenTinyExtents = dp.buildExtentRepairTasks(repairTasks, extentInfoMap)
+	dp.buildEnvelopeRepairTasks(repairTasks, extentInfoMap)
 	return
 }
 
+// Repair the encryption envelope of an extent if the replicas do not have the newest one.
+func (dp *DataPartition) buildEnvelopeRepairTasks(repairTasks []*DataPartitionRepairTask, extentInfoMap map[uint64]*storage.ExtentInfo) {
+	newestEnvelopes := make(map[uint64]*storage.ExtentEnvelope)
+	for _, repairTask := range repairTasks {
+		if repairTask == nil {
+			continue
+		}
+		for extentID, extentInfo := range repairTask.extents {
+			if extentInfo.Envelope != nil && extentInfo.Envelope.NewerThan(newestEnvelopes[extentID]) {
+				newestEnvelopes[extentID] = extentInfo.Envelope
+			}
+		}
+	}
+	for extentID, env := range newestEnvelopes {
+		if extentInfo := extentInfoMap[extentID]; extentInfo == nil || extentInfo.IsDeleted {
+			continue
+		}
+		for index, repairTask := range repairTasks {
+			if repairTask == nil {
+				continue
+			}
+			if extentInfo, ok := repairTask.extents[extentID]; ok && !env.NewerThan(extentInfo.Envelope) {
+				continue
+			}
+			repairTask.EnvelopesToBeRepaired = append(repairTask.EnvelopesToBeRepaired, env)
+			log.LogInfof("action[buildEnvelopeRepairTasks] envelope(%v_%v) key(%v:%v) on Index(%v) on(%v).",
+				dp.partitionID, extentID, env.KeyID, env.KeyVersion, index, repairTask.addr)
+		}
+	}
+}
+
+// repairExtentEnvelopes stores the envelopes of the extents which have been created.
+func (dp *DataPartition) repairExtentEnvelopes(envelopes []*storage.ExtentEnvelope) {
+	for _, env := range envelopes {
+		if err := dp.extentStore.RepairExtentEnvelope(env); err != nil {
+			log.LogWarnf("action[repairExtentEnvelopes] partition(%v) extent(%v) err(%v)", dp.partitionID, env.ExtentID, err)
+		}
+	}
+}
+
 // Create a new extent if one of the replica is missing.
 func (dp *DataPartition) buildExtentCreationTasks(repairTasks []*DataPartitionRepairTask, extentInfoMap map[uint64]*storage.ExtentInfo) {
 	for extentID, extentInfo := range extentInfoMap {
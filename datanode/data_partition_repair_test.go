@@ -0,0 +1,63 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEnvelopeRepairTasks(t *testing.T) {
+	dp := &DataPartition{partitionID: 1}
+	oldEnv := &storage.ExtentEnvelope{ExtentID: 1025, KeyID: "k1", KeyVersion: 1, UpdateTime: 100}
+	newEnv := &storage.ExtentEnvelope{ExtentID: 1025, KeyID: "k2", KeyVersion: 2, UpdateTime: 200}
+	deletedEnv := &storage.ExtentEnvelope{ExtentID: 1027, KeyID: "k1", KeyVersion: 1, UpdateTime: 100}
+
+	leader := NewDataPartitionRepairTask([]*storage.ExtentInfo{
+		{FileID: 1025, Envelope: newEnv},
+		{FileID: 1026},
+		{FileID: 1027, Envelope: deletedEnv},
+	}, 0, "leader", "leader")
+	// the key is not rotated on the replica
+	stale := NewDataPartitionRepairTask([]*storage.ExtentInfo{
+		{FileID: 1025, Envelope: oldEnv},
+		{FileID: 1026},
+		{FileID: 1027, IsDeleted: true},
+	}, 0, "stale", "leader")
+	// the replica is rebuilt after the raft log is truncated
+	rebuilt := NewDataPartitionRepairTask(nil, 0, "rebuilt", "leader")
+	tasks := []*DataPartitionRepairTask{leader, stale, rebuilt, nil}
+
+	extentInfoMap := map[uint64]*storage.ExtentInfo{
+		1025: {FileID: 1025},
+		1026: {FileID: 1026},
+		1027: {FileID: 1027, IsDeleted: true},
+	}
+	dp.buildEnvelopeRepairTasks(tasks, extentInfoMap)
+	require.Empty(t, leader.EnvelopesToBeRepaired)
+	require.Equal(t, []*storage.ExtentEnvelope{newEnv}, stale.EnvelopesToBeRepaired)
+	require.Equal(t, []*storage.ExtentEnvelope{newEnv}, rebuilt.EnvelopesToBeRepaired)
+
+	// the newest envelope is taken whichever replica holds it
+	leader.extents[1025].Envelope = oldEnv
+	stale.extents[1025].Envelope = newEnv
+	leader.EnvelopesToBeRepaired, stale.EnvelopesToBeRepaired, rebuilt.EnvelopesToBeRepaired = nil, nil, nil
+	dp.buildEnvelopeRepairTasks(tasks, extentInfoMap)
+	require.Equal(t, []*storage.ExtentEnvelope{newEnv}, leader.EnvelopesToBeRepaired)
+	require.Empty(t, stale.EnvelopesToBeRepaired)
+	require.Equal(t, []*storage.ExtentEnvelope{newEnv}, rebuilt.EnvelopesToBeRepaired)
+}
@@ -0,0 +1,249 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ActionScrubRepairBlock = "ActionScrubRepairBlock"
+
+	IntervalToScrubDisk = time.Hour // interval between two rounds of verification on a disk
+)
+
+var errScrubRaftTermChanged = errors.New("raft term changed during block repair")
+
+// doScrubTask verifies the blocks of the normal extents on the disk round by round,
+// the corrupt blocks are repaired in place from the healthy replicas.
+func (d *Disk) doScrubTask() {
+	if d.dataNode.diskScrubFlow <= 0 {
+		return
+	}
+	for {
+		time.Sleep(IntervalToScrubDisk)
+		if d.Status == proto.Unavailable {
+			continue
+		}
+		partitions := make([]*DataPartition, 0)
+		d.RLock()
+		for _, dp := range d.partitionMap {
+			partitions = append(partitions, dp)
+		}
+		d.RUnlock()
+		for _, dp := range partitions {
+			dp.scrub()
+		}
+	}
+}
+
+func (dp *DataPartition) scrubStopped() bool {
+	select {
+	case <-dp.stopC:
+		return true
+	default:
+	}
+	return dp.raftStopped() || dp.partitionStatus == proto.Unavailable
+}
+
+func (dp *DataPartition) scrub() {
+	if !dp.isNormalType() || dp.IsDataPartitionLoading() || dp.scrubStopped() {
+		return
+	}
+	store := dp.ExtentStore()
+	// the extents modified recently are skipped as the normal repair does
+	extents, _, err := store.GetAllWatermarks(storage.NormalExtentFilter())
+	if err != nil {
+		log.LogWarnf("action[scrub] dp %v get watermarks err %v", dp.partitionID, err)
+		return
+	}
+	data := make([]byte, util.BlockSize)
+	var verified, corrupted int
+	for _, ei := range extents {
+		blockCnt := int((ei.Size + util.BlockSize - 1) / util.BlockSize)
+		for blockNo := 0; blockNo < blockCnt; blockNo++ {
+			if dp.scrubStopped() {
+				return
+			}
+			var (
+				crc     uint32
+				size    int64
+				corrupt bool
+			)
+			dp.disk.limitScrub.Run(util.BlockSize, func() {
+				crc, size, corrupt, err = store.VerifyBlock(ei.FileID, blockNo, data)
+			})
+			if err != nil {
+				dp.checkIsDiskError(err, ReadFlag)
+				log.LogWarnf("action[scrub] dp %v extent %v block %v verify err %v", dp.partitionID, ei.FileID, blockNo, err)
+				break
+			}
+			verified++
+			if !corrupt {
+				continue
+			}
+			corrupted++
+			log.LogErrorf("action[scrub] dp %v extent %v block %v size %v crc %v is corrupt",
+				dp.partitionID, ei.FileID, blockNo, size, crc)
+			if err = dp.repairCorruptBlock(ei.FileID, blockNo, size, crc); err != nil {
+				log.LogErrorf("action[scrub] dp %v extent %v block %v repair err %v", dp.partitionID, ei.FileID, blockNo, err)
+			}
+		}
+	}
+	log.LogInfof("action[scrub] dp %v verified %v blocks, found %v corrupt blocks", dp.partitionID, verified, corrupted)
+}
+
+// repairCorruptBlock repairs the corrupt block found by the scrub within the repair limit of the disk.
+func (dp *DataPartition) repairCorruptBlock(extentID uint64, blockNo int, size int64, crc uint32) (err error) {
+	if !dp.disk.scrubRepairLimiter.Allow() {
+		return fmt.Errorf("repair limit, try again in next round")
+	}
+	return dp.repairBlockFromReplicas(extentID, blockNo, size, crc)
+}
+
+// repairBlockFromReplicas fetches the block from the other replicas and rewrites the local one in place.
+// The data is accepted only if it matches the crc persisted on write, and the repair is given up
+// if the raft term changes while fetching, or the block has been rewritten in the meantime.
+func (dp *DataPartition) repairBlockFromReplicas(extentID uint64, blockNo int, size int64, crc uint32) (err error) {
+	if !AutoRepairStatus {
+		return fmt.Errorf("auto repair is disabled")
+	}
+	if dp.raftStopped() {
+		return fmt.Errorf(RaftNotStarted)
+	}
+	leaderID, term := dp.raftPartition.LeaderTerm()
+	if leaderID == 0 {
+		return storage.NoLeaderError
+	}
+	for _, addr := range dp.getReplicaCopy() {
+		if addr == dp.dataNode.localServerAddr {
+			continue
+		}
+		var data []byte
+		if data, err = dp.fetchBlock(addr, extentID, blockNo, size); err != nil {
+			log.LogWarnf("action[repairCorruptBlock] dp %v extent %v block %v fetch from %v err %v",
+				dp.partitionID, extentID, blockNo, addr, err)
+			continue
+		}
+		if actualCrc := crc32.ChecksumIEEE(data); actualCrc != crc {
+			err = fmt.Errorf("replica %v crc mismatch expectCrc(%v) actualCrc(%v)", addr, crc, actualCrc)
+			log.LogWarnf("action[repairCorruptBlock] dp %v extent %v block %v err %v", dp.partitionID, extentID, blockNo, err)
+			continue
+		}
+		if dp.raftStopped() {
+			return fmt.Errorf(RaftNotStarted)
+		}
+		if _, curTerm := dp.raftPartition.LeaderTerm(); curTerm != term {
+			return errScrubRaftTermChanged
+		}
+		dp.disk.limitWrite.Run(int(size), func() {
+			err = dp.ExtentStore().RepairBlock(extentID, blockNo, data, crc)
+		})
+		if err != nil {
+			dp.checkIsDiskError(err, WriteFlag)
+			return
+		}
+		log.LogWarnf("action[repairCorruptBlock] dp %v extent %v block %v repaired from %v", dp.partitionID, extentID, blockNo, addr)
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("no healthy replica")
+	}
+	return
+}
+
+// readVerified reads the extent and verifies the blocks read against the crcs persisted on write.
+// The corrupt block found is repaired from the healthy replicas before the data is read again,
+// and the read fails if the repair fails, so that the corrupt data is never returned.
+func (dp *DataPartition) readVerified(extentID uint64, offset, size int64, data []byte) (crc uint32, err error) {
+	store := dp.ExtentStore()
+	var blockNo int
+	if crc, blockNo, err = store.ReadVerified(extentID, offset, size, data); err != storage.BlockCorruptError {
+		return
+	}
+	log.LogErrorf("action[readVerified] dp %v extent %v block %v is corrupt, offset %v size %v",
+		dp.partitionID, extentID, blockNo, offset, size)
+	if rErr := dp.repairBlockOnRead(extentID, blockNo); rErr != nil {
+		log.LogErrorf("action[readVerified] dp %v extent %v block %v repair err %v", dp.partitionID, extentID, blockNo, rErr)
+		return
+	}
+	crc, _, err = store.ReadVerified(extentID, offset, size, data)
+	return
+}
+
+func (dp *DataPartition) repairBlockOnRead(extentID uint64, blockNo int) (err error) {
+	var (
+		crc     uint32
+		size    int64
+		corrupt bool
+	)
+	// the block may be rewritten after the read
+	data := make([]byte, util.BlockSize)
+	if crc, size, corrupt, err = dp.ExtentStore().VerifyBlock(extentID, blockNo, data); err != nil || !corrupt {
+		return
+	}
+	return dp.repairCorruptBlock(extentID, blockNo, size, crc)
+}
+
+func (dp *DataPartition) fetchBlock(addr string, extentID uint64, blockNo int, size int64) (data []byte, err error) {
+	var conn net.Conn
+	if conn, err = dp.getRepairConn(addr); err != nil {
+		return
+	}
+	defer func() {
+		dp.putRepairConn(conn, err != nil)
+	}()
+	offset := int64(blockNo) * util.BlockSize
+	request := repl.NewExtentRepairReadPacket(dp.partitionID, extentID, int(offset), int(size))
+	if err = request.WriteToConn(conn); err != nil {
+		return
+	}
+	data = make([]byte, 0, size)
+	for int64(len(data)) < size {
+		reply := repl.NewPacket()
+		if err = reply.ReadFromConnWithVer(conn, proto.ReadDeadlineTime); err != nil {
+			return
+		}
+		if reply.ResultCode != proto.OpOk {
+			err = fmt.Errorf("%v result code %v: %v", ActionScrubRepairBlock, reply.ResultCode,
+				string(reply.Data[:intMin(len(reply.Data), int(reply.Size))]))
+			return
+		}
+		if reply.ReqID != request.ReqID || reply.ExtentID != extentID ||
+			reply.Size == 0 || reply.ExtentOffset != offset+int64(len(data)) {
+			err = fmt.Errorf("%v unavali reply %v request %v", ActionScrubRepairBlock, reply.GetUniqueLogId(), request.GetUniqueLogId())
+			return
+		}
+		if reply.CRC != crc32.ChecksumIEEE(reply.Data[:reply.Size]) {
+			err = storage.CrcMismatchError
+			return
+		}
+		data = append(data, reply.Data[:reply.Size]...)
+	}
+	if int64(len(data)) != size {
+		err = fmt.Errorf("%v read size %v, expect %v", ActionScrubRepairBlock, len(data), size)
+	}
+	return
+}
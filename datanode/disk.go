@@ -82,6 +82,14 @@ type Disk struct {
 	limitRead   *ioLimiter
 	limitWrite  *ioLimiter
 
+	limitScrub         *ioLimiter
+	scrubRepairLimiter *rate.Limiter
+
+	// the last time the disk was alive before the unclean shutdown, 0 if shut down cleanly
+	lastAliveTime  int64
+	suspectMutex   sync.Mutex
+	suspectRegions map[suspectBlock]proto.SuspectExtentRegion
+
 	// diskPartition info
 	diskPartition       *disk.PartitionStat
 	DiskErrPartitionSet map[uint64]struct{}
@@ -129,8 +137,12 @@ func NewDisk(path string, reservedSpace, diskRdonlySpace uint64, maxErrCnt int,
 	d.limitFactor[proto.IopsWriteType] = rate.NewLimiter(rate.Limit(proto.QosDefaultDiskMaxIoLimit), defaultIOLimitBurst)
 	d.limitRead = newIOLimiter(space.dataNode.diskReadFlow, space.dataNode.diskReadIocc)
 	d.limitWrite = newIOLimiter(space.dataNode.diskWriteFlow, space.dataNode.diskWriteIocc)
+	d.limitScrub = newIOLimiter(space.dataNode.diskScrubFlow, 1)
+	d.scrubRepairLimiter = rate.NewLimiter(rate.Limit(float64(space.dataNode.diskScrubRepairLimit)/60), 1)
 
 	d.DiskErrPartitionSet = make(map[uint64]struct{}, 0)
+	d.suspectRegions = make(map[suspectBlock]proto.SuspectExtentRegion)
+	d.checkUncleanShutdown()
 
 	err = d.initDecommissionStatus()
 	if err != nil {
@@ -351,6 +363,7 @@ func (d *Disk) startScheduleToUpdateSpaceInfo() {
 				d.updateSpaceInfo()
 			case <-checkStatusTicker.C:
 				d.checkDiskStatus()
+				d.touchRunningMark()
 			}
 		}
 	}()
@@ -637,6 +650,10 @@ func (d *Disk) RestorePartition(visitor PartitionVisitor) (err error) {
 				syslog.Println(mesg)
 				return
 			}
+			// mark the extents before the partition serves the reads
+			if since := d.crashVerifySince(); since != 0 {
+				dp.markSuspectExtents(since)
+			}
 			if visitor != nil {
 				visitor(dp)
 			}
@@ -35,6 +35,7 @@ import (
 	"github.com/cubefs/cubefs/storage"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -138,6 +139,14 @@ type DataPartition struct {
 	recoverErrCnt              uint64 // donot reset, if reach max err cnt, delete this dp
 
 	diskErrCnt uint64 // number of disk io errors while reading or writing
+	ioStat     partitionIoStat
+
+	verifyReadCrc int32 // verify the block crcs on the reads of the clients, set by the volume
+	maxExtentSize int64 // max size of the normal extents appended by the clients, set by the volume
+
+	suspectExtents sync.Map // extents written shortly before the unclean shutdown and not verified yet
+
+	extentOwners *extentOwners // inodes the normal extents are created for, to compact the small extents
 }
 
 func (dp *DataPartition) IsForbidden() bool {
@@ -148,6 +157,30 @@ func (dp *DataPartition) SetForbidden(status bool) {
 	dp.config.Forbidden = status
 }
 
+func (dp *DataPartition) IsVerifyReadCrc() bool {
+	return atomic.LoadInt32(&dp.verifyReadCrc) == 1
+}
+
+func (dp *DataPartition) SetVerifyReadCrc(status bool) {
+	var val int32
+	if status {
+		val = 1
+	}
+	atomic.StoreInt32(&dp.verifyReadCrc, val)
+}
+
+// MaxExtentSize returns the size of the extent size class of the volume.
+func (dp *DataPartition) MaxExtentSize() int64 {
+	if size := atomic.LoadInt64(&dp.maxExtentSize); size > 0 {
+		return size
+	}
+	return util.ExtentSize
+}
+
+func (dp *DataPartition) SetMaxExtentSize(size int64) {
+	atomic.StoreInt64(&dp.maxExtentSize, size)
+}
+
 func CreateDataPartition(dpCfg *dataPartitionCfg, disk *Disk, request *proto.CreateDataPartitionRequest) (dp *DataPartition, err error) {
 	if dp, err = newDataPartition(dpCfg, disk, true); err != nil {
 		return
@@ -317,6 +350,7 @@ func newDataPartition(dpCfg *dataPartitionCfg, disk *Disk, isCreate bool) (dp *D
 		DataPartitionCreateType: dpCfg.CreateType,
 		volVersionInfoList:      &proto.VolVersionInfoList{},
 	}
+	partition.extentOwners = newExtentOwners(dataPath)
 	atomic.StoreUint64(&partition.recoverErrCnt, 0)
 	log.LogInfof("action[newDataPartition] dp %v replica num %v", partitionID, dpCfg.ReplicaNum)
 	partition.replicasInit()
@@ -326,6 +360,9 @@ func newDataPartition(dpCfg *dataPartitionCfg, disk *Disk, isCreate bool) (dp *D
 		log.LogWarnf("action[newDataPartition] dp %v NewExtentStore failed %v", partitionID, err.Error())
 		return
 	}
+	if partition.isSpareExtentEnabled() {
+		partition.extentStore.EnableSpareExtents(partition.dataNode.spareExtentCount)
+	}
 	// store applyid
 	if err = partition.storeAppliedID(partition.appliedID); err != nil {
 		log.LogErrorf("action[newDataPartition] dp %v initial Apply [%v] failed: %v",
@@ -336,6 +373,8 @@ func newDataPartition(dpCfg *dataPartitionCfg, disk *Disk, isCreate bool) (dp *D
 	dp = partition
 	go partition.statusUpdateScheduler()
 	go partition.startEvict()
+	go partition.spareExtentScheduler()
+	go partition.compactScheduler()
 	if isCreate {
 		if err = dp.getVerListFromMaster(); err != nil {
 			log.LogErrorf("action[newDataPartition] vol %v dp %v loadFromMaster verList failed err %v", dp.volumeID, dp.partitionID, err)
@@ -420,6 +459,49 @@ func (partition *DataPartition) fsmVersionOp(opItem *RaftCmdItem) (err error) {
 	return
 }
 
+// HandleExtentEnvelope replicates the encryption envelope of the extent to the replicas by raft,
+// so that all the replicas keep the same envelope. It is checked by the leader before submitting.
+func (partition *DataPartition) HandleExtentEnvelope(env *storage.ExtentEnvelope) (err error) {
+	if leader, ok := partition.IsRaftLeader(); !ok {
+		return fmt.Errorf("partition(%v) is not raft leader, leader(%v)", partition.partitionID, leader)
+	}
+	if err = partition.ExtentStore().CheckExtentEnvelope(env); err != nil {
+		return
+	}
+	newEnv := *env
+	newEnv.UpdateTime = time.Now().Unix()
+	envData, err := json.Marshal(&newEnv)
+	if err != nil {
+		return
+	}
+	pItem := &RaftCmdItem{
+		Op: uint32(proto.OpExtentEnvelope),
+		K:  []byte("envelope"),
+		V:  envData,
+	}
+	data, _ := MarshalRaftCmd(pItem)
+	_, err = partition.Submit(data)
+	return
+}
+
+func (partition *DataPartition) fsmExtentEnvelope(opItem *RaftCmdItem, index uint64) {
+	env := new(storage.ExtentEnvelope)
+	if err := json.Unmarshal(opItem.V, env); err != nil {
+		log.LogErrorf("action[fsmExtentEnvelope] dp[%v] index(%v) op item %v err %v", partition.partitionID, index, opItem, err)
+		return
+	}
+	err := partition.ExtentStore().SetExtentEnvelope(env)
+	if err == storage.BrokenDiskError {
+		err = fmt.Errorf("[fsmExtentEnvelope] ApplyID(%v) Partition(%v) Extent(%v) apply err(%v)",
+			index, partition.partitionID, env.ExtentID, err)
+		exporter.Warning(err.Error())
+		panic(newRaftApplyError(err))
+	}
+	if err != nil {
+		log.LogErrorf("action[fsmExtentEnvelope] dp[%v] index(%v) extent(%v) err %v", partition.partitionID, index, env.ExtentID, err)
+	}
+}
+
 func (dp *DataPartition) getVerListFromMaster() (err error) {
 	var verList *proto.VolVersionInfoList
 	verList, err = MasterClient.AdminAPI().GetVerList(dp.volumeID)
@@ -555,6 +637,7 @@ func (dp *DataPartition) Stop() {
 		// Close the store and raftstore.
 		dp.stopRaft()
 		dp.extentStore.Close()
+		dp.extentOwners.close()
 		err := dp.storeAppliedID(atomic.LoadUint64(&dp.appliedID))
 		if err != nil {
 			log.LogErrorf("action[Stop]: failed to store applied index")
@@ -852,6 +935,7 @@ func (dp *DataPartition) Load() (response *proto.LoadDataPartitionResponse) {
 // DoExtentStoreRepair performs the repairs of the extent store.
 // 1. when the extent size is smaller than the max size on the record, start to repair the missing part.
 // 2. if the extent does not even exist, create the extent first, and then repair.
+// 3. the encryption envelopes older than the ones of the other replicas are replaced.
 func (dp *DataPartition) DoExtentStoreRepair(repairTask *DataPartitionRepairTask) {
 	if dp.stopRecover && dp.isDecommissionRecovering() {
 		log.LogWarnf("DoExtentStoreRepair %v receive stop signal", dp.partitionID)
@@ -879,6 +963,7 @@ func (dp *DataPartition) DoExtentStoreRepair(repairTask *DataPartitionRepairTask
 			continue
 		}
 	}
+	dp.repairExtentEnvelopes(repairTask.EnvelopesToBeRepaired)
 
 	var (
 		wg           *sync.WaitGroup
@@ -1061,6 +1146,30 @@ func (dp *DataPartition) putRepairConn(conn net.Conn, forceClose bool) {
 	dp.dataNode.putRepairConnFunc(conn, forceClose)
 }
 
+// spareExtentScheduler refills the spare extents of the normal partitions on the writable disks.
+func (dp *DataPartition) spareExtentScheduler() {
+	if !dp.isSpareExtentEnabled() {
+		return
+	}
+	ticker := time.NewTicker(SpareExtentRefillInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if dp.Disk().Status != proto.ReadWrite || dp.Status() != proto.ReadWrite {
+				continue
+			}
+			dp.extentStore.RefillSpareExtents(SpareExtentRefillInterval)
+		case <-dp.stopC:
+			return
+		}
+	}
+}
+
+func (dp *DataPartition) isSpareExtentEnabled() bool {
+	return dp.dataNode != nil && dp.dataNode.spareExtentCount > 0 && dp.isNormalType()
+}
+
 func (dp *DataPartition) isNormalType() bool {
 	return proto.IsNormalDp(dp.partitionType)
 }
@@ -51,6 +51,10 @@ func (dp *DataPartition) Apply(command []byte, index uint64) (resp interface{},
 			dp.fsmVersionOp(opItem)
 			return
 		}
+		if opItem.Op == uint32(proto.OpExtentEnvelope) {
+			dp.fsmExtentEnvelope(opItem, index)
+			return
+		}
 		return
 	}
 	if index > dp.metaAppliedID {
@@ -161,6 +165,7 @@ func (dp *DataPartition) HandleFatalEvent(err *raft.FatalError) {
 		dp.checkIsDiskError(err.Err, 0)
 		log.LogCriticalf("action[HandleFatalEvent] raft apply err(%v), partitionId:%v", err, dp.partitionID)
 	} else {
+		dp.dataNode.blackBox.dump(blackBoxReasonFatal, fmt.Sprintf("partitionId(%v) err: %v", dp.partitionID, err))
 		log.LogFatalf("action[HandleFatalEvent] err(%v), partitionId:%v", err, dp.partitionID)
 	}
 }
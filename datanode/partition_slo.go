@@ -0,0 +1,86 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// partitionIoStat accumulates the io of the client requests served by the
+// partition between two heartbeats, the master tracks the partition slo by it.
+// Only the ops served by the local disk are counted, so that the latency blames
+// the replica itself rather than the followers it replicates to.
+type partitionIoStat struct {
+	readCnt      uint64
+	readCostUs   uint64
+	writeCnt     uint64
+	writeCostUs  uint64
+	diskErrCount uint64
+}
+
+func isSloReadOp(opcode uint8) bool {
+	switch opcode {
+	case proto.OpStreamRead, proto.OpRead, proto.OpStreamFollowerRead:
+		return true
+	}
+	return false
+}
+
+// isSloWriteOp returns true for the appends, which are written to the local extent store while
+// the repl protocol forwards them to the followers in parallel. The random writes are excluded
+// as they wait for the raft commit, whose latency includes the followers.
+func isSloWriteOp(opcode uint8) bool {
+	switch opcode {
+	case proto.OpWrite, proto.OpSyncWrite:
+		return true
+	}
+	return false
+}
+
+// record counts the op if it is a client read or write, diskErr means the op failed by disk error.
+func (s *partitionIoStat) record(opcode uint8, costUs uint64, diskErr bool) {
+	switch {
+	case isSloReadOp(opcode):
+		atomic.AddUint64(&s.readCnt, 1)
+		atomic.AddUint64(&s.readCostUs, costUs)
+	case isSloWriteOp(opcode):
+		atomic.AddUint64(&s.writeCnt, 1)
+		atomic.AddUint64(&s.writeCostUs, costUs)
+	default:
+		return
+	}
+	if diskErr {
+		atomic.AddUint64(&s.diskErrCount, 1)
+	}
+}
+
+// fillReport fills the io stats since the last report and resets them.
+func (s *partitionIoStat) fillReport(vr *proto.DataPartitionReport) {
+	readCnt := atomic.SwapUint64(&s.readCnt, 0)
+	readCostUs := atomic.SwapUint64(&s.readCostUs, 0)
+	writeCnt := atomic.SwapUint64(&s.writeCnt, 0)
+	writeCostUs := atomic.SwapUint64(&s.writeCostUs, 0)
+	vr.IoErrCnt = atomic.SwapUint64(&s.diskErrCount, 0)
+	vr.ReadOpCnt = readCnt
+	if readCnt > 0 {
+		vr.ReadLatencyUs = readCostUs / readCnt
+	}
+	vr.WriteOpCnt = writeCnt
+	if writeCnt > 0 {
+		vr.WriteLatencyUs = writeCostUs / writeCnt
+	}
+}
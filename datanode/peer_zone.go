@@ -0,0 +1,85 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	peerZoneExpiration    = 10 * time.Minute
+	peerZoneRetryInterval = 30 * time.Second
+)
+
+type peerZone struct {
+	zone   string
+	expire time.Time
+}
+
+// peerZones caches the zones of the peer datanodes queried from the master for ordering the
+// replication chains. The zone is loaded in the background, so that the write path never waits
+// for the master, the peer is of the unknown zone until its zone is loaded and retried later on
+// the error.
+type peerZones struct {
+	sync.RWMutex
+	zones   map[string]*peerZone
+	loading map[string]struct{}
+}
+
+func newPeerZones() *peerZones {
+	return &peerZones{
+		zones:   make(map[string]*peerZone),
+		loading: make(map[string]struct{}),
+	}
+}
+
+func (z *peerZones) zoneOf(addr string) (zone string, ok bool) {
+	z.RLock()
+	pz, ok := z.zones[addr]
+	z.RUnlock()
+	if !ok || time.Now().After(pz.expire) {
+		z.load(addr)
+	}
+	if !ok || pz.zone == "" {
+		return "", false
+	}
+	return pz.zone, true
+}
+
+func (z *peerZones) load(addr string) {
+	z.Lock()
+	if _, ok := z.loading[addr]; ok {
+		z.Unlock()
+		return
+	}
+	z.loading[addr] = struct{}{}
+	z.Unlock()
+
+	go func() {
+		dataNode, err := MasterClient.NodeAPI().GetDataNode(addr)
+		z.Lock()
+		defer z.Unlock()
+		delete(z.loading, addr)
+		if err != nil {
+			log.LogWarnf("action[peerZones.load] get datanode(%v) err(%v)", addr, err)
+			z.zones[addr] = &peerZone{expire: time.Now().Add(peerZoneRetryInterval)}
+			return
+		}
+		z.zones[addr] = &peerZone{zone: dataNode.ZoneName, expire: time.Now().Add(peerZoneExpiration)}
+	}()
+}
@@ -22,8 +22,10 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"regexp"
 	"runtime"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -68,6 +70,9 @@ const (
 
 	DefaultDiskUnavailableErrorCount          = 5
 	DefaultDiskUnavailablePartitionErrorCount = 3
+	DefaultDiskScrubRepairLimit               = 10 // repaired blocks per minute per disk
+	SpareExtentRefillInterval                 = 10 * time.Second
+	DefaultWireCompressCpuLimit               = 80 // cpu usage percent
 )
 
 const (
@@ -121,6 +126,34 @@ const (
 
 	// disk status becomes unavailable if disk error partition count reaches this value
 	ConfigKeyDiskUnavailablePartitionErrorCount = "diskUnavailablePartitionErrorCount"
+
+	// background verification of the extent blocks
+	ConfigDiskScrubFlow        = "diskScrubFlow"        // int
+	ConfigDiskScrubRepairLimit = "diskScrubRepairLimit" // int
+
+	// verify the extents modified within the window before the unclean shutdown, disabled if negative
+	ConfigCrashVerifyWindowSec = "crashVerifyWindowSec" // int
+
+	// max spare extents created and pre-allocated in the background per partition, disabled if 0
+	ConfigSpareExtentCount = "spareExtentCount" // int
+
+	// merge the small extents of the cold partitions into the large ones, disabled if 0
+	ConfigKeyCompactSmallExtentSize = "compactSmallExtentSize" // int, extents smaller than the size are merged
+	ConfigKeyCompactColdSec         = "compactColdSec"         // int, partitions not written within the time are cold
+
+	// forward the packets to the followers in the same remote zone by a chain, enable after all datanodes upgraded
+	ConfigKeyEnableReplChain = "enableReplChain" // bool
+
+	// compress the data on the wire for the clients negotiating the compression
+	ConfigKeyEnableWireCompress = "enableWireCompress" // bool
+	// stop compressing while the cpu usage percent is above the limit
+	ConfigKeyWireCompressCpuLimit = "wireCompressCpuLimit" // int
+
+	// keep the recent io of the partitions and dump them with the requests being served on panic and shutdown
+	ConfigKeyEnableBlackBox      = "enableBlackBox"      // bool
+	ConfigKeyBlackBoxDir         = "blackBoxDir"         // string, the blackbox directory under raftDir by default
+	ConfigKeyBlackBoxIntervalSec = "blackBoxIntervalSec" // int, seconds of a sample window
+	ConfigKeyBlackBoxSlots       = "blackBoxSlots"       // int, sample windows kept in the ring
 )
 
 const cpuSampleDuration = 1 * time.Second
@@ -165,6 +198,7 @@ type DataNode struct {
 
 	diskQosEnable           bool
 	diskQosEnableFromMaster bool
+	partitionReports        proto.DataPartitionReportTracker
 	diskReadIocc            int
 	diskReadIops            int
 	diskReadFlow            int
@@ -180,6 +214,25 @@ type DataNode struct {
 	cpuSamplerDone          chan struct{}
 
 	diskUnavailablePartitionErrorCount uint64 // disk status becomes unavailable when disk error partition count reaches this value
+
+	diskScrubFlow        int // read flow per disk to verify the blocks, disabled if less than or equal to 0
+	diskScrubRepairLimit int // corrupt blocks repaired per minute per disk
+	crashVerifyWindow    time.Duration
+
+	spareExtentCount int // max spare extents per partition
+
+	compactSmallExtentSize int64
+	compactColdTime        time.Duration
+	compactMetaWrappers    sync.Map // vol name -> *meta.MetaWrapper
+
+	enableReplChain bool
+	peerZones       *peerZones
+
+	enableWireCompress   bool
+	wireCompressCpuLimit float64
+	wireCompressor       proto.WireCompressor
+
+	blackBox *blackBox // nil if disabled
 }
 
 type verOp2Phase struct {
@@ -267,6 +320,7 @@ func doStart(server common.Server, cfg *config.Config) (err error) {
 
 	go s.registerHandler()
 
+	s.blackBox.start()
 	s.scheduleTask()
 
 	// start metrics (LackDpCount, etc.)
@@ -282,6 +336,8 @@ func doShutdown(server common.Server) {
 	if !ok {
 		return
 	}
+	s.blackBox.dump(blackBoxReasonStop, "")
+	s.blackBox.stop()
 	s.closeMetrics()
 	close(s.stopC)
 	s.space.Stop()
@@ -295,6 +351,12 @@ func doShutdown(server common.Server) {
 	close(s.cpuSamplerDone)
 }
 
+// DumpOnPanic dumps the black box when the process panics, it is called by the panic hook of the process
+// before the panic unwinds, so the first panic is dumped even if it is recovered later.
+func (s *DataNode) DumpOnPanic() {
+	s.blackBox.dump(blackBoxReasonPanic, string(debug.Stack()))
+}
+
 func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	var (
 		port       string
@@ -357,6 +419,54 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	s.diskUnavailablePartitionErrorCount = uint64(diskUnavailablePartitionErrorCount)
 	log.LogDebugf("action[parseConfig] load diskUnavailablePartitionErrorCount(%v)", s.diskUnavailablePartitionErrorCount)
 
+	s.diskScrubFlow = cfg.GetInt(ConfigDiskScrubFlow)
+	if s.diskScrubFlow > 0 && s.diskScrubFlow < util.BlockSize {
+		s.diskScrubFlow = util.BlockSize
+	}
+	s.diskScrubRepairLimit = cfg.GetInt(ConfigDiskScrubRepairLimit)
+	if s.diskScrubRepairLimit <= 0 {
+		s.diskScrubRepairLimit = DefaultDiskScrubRepairLimit
+	}
+
+	s.crashVerifyWindow = time.Duration(cfg.GetInt64(ConfigCrashVerifyWindowSec)) * time.Second
+	if s.crashVerifyWindow == 0 {
+		s.crashVerifyWindow = DefaultCrashVerifyWindow
+	}
+	log.LogDebugf("action[parseConfig] load crashVerifyWindow(%v)", s.crashVerifyWindow)
+
+	if s.spareExtentCount = cfg.GetInt(ConfigSpareExtentCount); s.spareExtentCount < 0 {
+		s.spareExtentCount = 0
+	}
+	log.LogDebugf("action[parseConfig] load spareExtentCount(%v)", s.spareExtentCount)
+	if s.compactSmallExtentSize = cfg.GetInt64(ConfigKeyCompactSmallExtentSize); s.compactSmallExtentSize < 0 {
+		s.compactSmallExtentSize = 0
+	}
+	if s.compactColdTime = time.Duration(cfg.GetInt64(ConfigKeyCompactColdSec)) * time.Second; s.compactColdTime <= 0 {
+		s.compactColdTime = DefaultCompactColdTime
+	}
+	log.LogDebugf("action[parseConfig] load compactSmallExtentSize(%v) compactColdTime(%v)",
+		s.compactSmallExtentSize, s.compactColdTime)
+	s.enableReplChain = cfg.GetBool(ConfigKeyEnableReplChain)
+	s.peerZones = newPeerZones()
+	log.LogDebugf("action[parseConfig] load enableReplChain(%v)", s.enableReplChain)
+	s.enableWireCompress = cfg.GetBool(ConfigKeyEnableWireCompress)
+	if s.wireCompressCpuLimit = float64(cfg.GetInt(ConfigKeyWireCompressCpuLimit)); s.wireCompressCpuLimit <= 0 {
+		s.wireCompressCpuLimit = DefaultWireCompressCpuLimit
+	}
+	log.LogDebugf("action[parseConfig] load enableWireCompress(%v) wireCompressCpuLimit(%v)",
+		s.enableWireCompress, s.wireCompressCpuLimit)
+	if cfg.GetBool(ConfigKeyEnableBlackBox) {
+		dir := cfg.GetString(ConfigKeyBlackBoxDir)
+		if dir == "" {
+			dir = path.Join(cfg.GetString(ConfigKeyRaftDir), blackBoxDirName)
+		}
+		s.blackBox = newBlackBox(dir, time.Duration(cfg.GetInt64(ConfigKeyBlackBoxIntervalSec))*time.Second,
+			cfg.GetInt(ConfigKeyBlackBoxSlots))
+		log.LogDebugf("action[parseConfig] load blackBox dir(%v) interval(%v) slots(%v)",
+			s.blackBox.dir, s.blackBox.interval, s.blackBox.slots)
+	}
+	log.LogDebugf("action[parseConfig] load diskScrubFlow(%v) diskScrubRepairLimit(%v)", s.diskScrubFlow, s.diskScrubRepairLimit)
+
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
@@ -622,6 +732,7 @@ func (s *DataNode) registerHandler() {
 	http.HandleFunc("/partition", s.getPartitionAPI)
 	http.HandleFunc("/extent", s.getExtentAPI)
 	http.HandleFunc("/block", s.getBlockCrcAPI)
+	http.HandleFunc("/extentEnvelope", s.getExtentEnvelopeAPI)
 	http.HandleFunc("/stats", s.getStatAPI)
 	http.HandleFunc("/raftStatus", s.getRaftStatus)
 	http.HandleFunc("/setAutoRepairStatus", s.setAutoRepairStatus)
@@ -635,6 +746,7 @@ func (s *DataNode) registerHandler() {
 	http.HandleFunc("/setDiskBad", s.setDiskBadAPI)
 	http.HandleFunc("/setDiskQos", s.setDiskQos)
 	http.HandleFunc("/getDiskQos", s.getDiskQos)
+	http.HandleFunc("/getBlackBox", s.getBlackBox)
 }
 
 func (s *DataNode) startTCPService() (err error) {
@@ -680,6 +792,9 @@ func (s *DataNode) serveConn(conn net.Conn) {
 	c.SetKeepAlive(true)
 	c.SetNoDelay(true)
 	packetProcessor := repl.NewReplProtocol(conn, s.Prepare, s.OperatePacket, s.Post)
+	if s.enableReplChain {
+		packetProcessor.SetReplChain(s.zoneName, s.peerZones.zoneOf)
+	}
 	packetProcessor.ServerConn()
 	space.Stats().RemoveConnection()
 }
@@ -759,6 +874,9 @@ func (s *DataNode) serveSmuxStream(stream *smux.Stream) {
 	if s.enableSmuxConnPool {
 		packetProcessor.SetSmux(s.getRepairConnFunc, s.putRepairConnFunc)
 	}
+	if s.enableReplChain {
+		packetProcessor.SetReplChain(s.zoneName, s.peerZones.zoneOf)
+	}
 	packetProcessor.ServerConn()
 }
 
@@ -283,6 +283,43 @@ func (s *DataNode) getBlockCrcAPI(w http.ResponseWriter, r *http.Request) {
 	s.buildSuccessResp(w, blocks)
 }
 
+func (s *DataNode) getExtentEnvelopeAPI(w http.ResponseWriter, r *http.Request) {
+	var (
+		partitionID uint64
+		extentID    uint64
+		err         error
+		env         *storage.ExtentEnvelope
+	)
+	if err = r.ParseForm(); err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if partitionID, err = strconv.ParseUint(r.FormValue("partitionID"), 10, 64); err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	partition := s.space.Partition(partitionID)
+	if partition == nil {
+		s.buildFailureResp(w, http.StatusNotFound, "partition not exist")
+		return
+	}
+	// list all the envelopes of the partition if extent is not given
+	if r.FormValue("extentID") == "" {
+		s.buildSuccessResp(w, partition.ExtentStore().GetAllExtentEnvelopes(r.FormValue("keyID")))
+		return
+	}
+	if extentID, err = strconv.ParseUint(r.FormValue("extentID"), 10, 64); err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if env, err = partition.ExtentStore().GetExtentEnvelope(extentID); err != nil {
+		s.buildFailureResp(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	s.buildSuccessResp(w, env)
+}
+
 func (s *DataNode) getTinyDeleted(w http.ResponseWriter, r *http.Request) {
 	var (
 		partitionID uint64
@@ -106,6 +106,9 @@ func (manager *SpaceManager) Stop() {
 		}(partitionC)
 	}
 	wg.Wait()
+	for _, disk := range manager.disks {
+		disk.markCleanShutdown()
+	}
 }
 
 func (manager *SpaceManager) GetAllDiskPartitions() []*disk.PartitionStat {
@@ -252,6 +255,8 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace, diskRdonlySpac
 		manager.putDisk(disk)
 		err = nil
 		go disk.doBackendTask()
+		go disk.doScrubTask()
+		go disk.doCrashVerifyTask()
 	}
 	return
 }
@@ -469,6 +474,7 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			NeedCompare:                true,
 			DecommissionRepairProgress: partition.decommissionRepairProgress,
 		}
+		partition.ioStat.fillReport(vr)
 		log.LogDebugf("action[Heartbeats] dpid(%v), status(%v) total(%v) used(%v) leader(%v) isLeader(%v).", vr.PartitionID, vr.PartitionStatus, vr.Total, vr.Used, leaderAddr, vr.IsLeader)
 		response.PartitionReports = append(response.PartitionReports, vr)
 		return true
@@ -486,6 +492,7 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			}
 			response.BadDiskStats = append(response.BadDiskStats, bds)
 		}
+		response.SuspectRegions = append(response.SuspectRegions, d.getSuspectRegions()...)
 	}
 }
 
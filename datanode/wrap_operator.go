@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"net"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,7 +38,10 @@ import (
 	"github.com/cubefs/cubefs/util/log"
 )
 
-var ErrForbiddenDataPartition = errors.New("the data partition is forbidden")
+var (
+	ErrForbiddenDataPartition = errors.New("the data partition is forbidden")
+	ErrExtentSizeExceeded     = errors.New("the extent exceeds the extent size class of the volume")
+)
 
 func (s *DataNode) getPacketTpLabels(p *repl.Packet) map[string]string {
 	labels := make(map[string]string)
@@ -92,6 +96,10 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		tpLabels = s.getPacketTpLabels(p)
 	}
 	start := time.Now().UnixNano()
+	slot := -1
+	if s.blackBox != nil {
+		slot = s.blackBox.begin(p, c.RemoteAddr().String())
+	}
 	defer func() {
 		resultSize := p.Size
 		p.Size = sz
@@ -122,15 +130,30 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 			}
 		}
 		p.Size = resultSize
+		costUs := uint64(time.Now().UnixNano()-start) / 1e3
+		if partition, ok := p.Object.(*DataPartition); ok {
+			partition.ioStat.record(p.Opcode, costUs, p.ResultCode == proto.OpDiskErr)
+		}
+		s.blackBox.end(slot, p, sz, costUs, p.IsErrPacket())
 		if !shallDegrade {
 			tpObject.SetWithLabels(err, tpLabels)
 		}
 	}()
+	defer func() {
+		// dump what the datanode was doing before the panic crashes it, if the panic hook of the
+		// process is not supported
+		if r := recover(); r != nil {
+			s.blackBox.dump(blackBoxReasonPanic, fmt.Sprintf("op(%v) reqId(%v) panic: %v\n%s", p.GetOpMsg(), p.ReqID, r, debug.Stack()))
+			panic(r)
+		}
+	}()
 	switch p.Opcode {
 	case proto.OpCreateExtent:
 		s.handlePacketToCreateExtent(p)
 	case proto.OpWrite, proto.OpSyncWrite:
 		s.handleWritePacket(p)
+	case proto.OpNegotiateWireCompress:
+		s.handleNegotiateWireCompress(p)
 	case proto.OpStreamRead:
 		s.handleStreamReadPacket(p, c, StreamRead)
 	case proto.OpStreamFollowerRead:
@@ -152,6 +175,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		s.handlePacketToNotifyExtentRepair(p)
 	case proto.OpGetAllWatermarks:
 		s.handlePacketToGetAllWatermarks(p)
+	case proto.OpSetExtentEnvelope:
+		s.handlePacketToSetExtentEnvelope(p)
 	case proto.OpCreateDataPartition:
 		s.handlePacketToCreateDataPartition(p)
 	case proto.OpLoadDataPartition:
@@ -218,6 +243,9 @@ func (s *DataNode) handlePacketToCreateExtent(p *repl.Packet) {
 	partition.disk.limitWrite.Run(0, func() {
 		err = partition.ExtentStore().Create(p.ExtentID)
 	})
+	if err == nil {
+		partition.recordExtentOwner(p)
+	}
 }
 
 // Handle OpCreateDataPartition packet.
@@ -476,6 +504,38 @@ func (s *DataNode) checkVolumeForbidden(volNames []string) {
 	})
 }
 
+func (s *DataNode) checkVolumeVerifyReadCrc(volNames []string) {
+	vols := util.NewSet()
+	for _, volName := range volNames {
+		vols.Add(volName)
+	}
+	s.space.RangePartitions(func(partition *DataPartition) bool {
+		verify := vols.Has(partition.volumeID)
+		if verify != partition.IsVerifyReadCrc() {
+			log.LogInfof("action[checkVolumeVerifyReadCrc] dp %v vol %v verifyReadCrc %v", partition.partitionID, partition.volumeID, verify)
+			partition.SetVerifyReadCrc(verify)
+		}
+		return true
+	})
+}
+
+func (s *DataNode) checkVolumeExtentSizeClasses(classes map[string]string) {
+	s.space.RangePartitions(func(partition *DataPartition) bool {
+		class := classes[partition.volumeID]
+		size, ok := proto.ExtentSizeOfClass(class)
+		if !ok {
+			log.LogWarnf("action[checkVolumeExtentSizeClasses] vol %v unknown extent size class %v", partition.volumeID, class)
+			return true
+		}
+		if int64(size) != partition.MaxExtentSize() {
+			log.LogInfof("action[checkVolumeExtentSizeClasses] dp %v vol %v extent size class %v size %v",
+				partition.partitionID, partition.volumeID, class, size)
+			partition.SetMaxExtentSize(int64(size))
+		}
+		return true
+	})
+}
+
 func (s *DataNode) checkDecommissionDisks(decommissionDisks []string) {
 	decommissionDiskSet := util.NewSet()
 	for _, disk := range decommissionDisks {
@@ -529,6 +589,10 @@ func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
 
 			// set volume forbidden
 			s.checkVolumeForbidden(request.ForbiddenVols)
+			// set read crc verification of volume
+			s.checkVolumeVerifyReadCrc(request.VerifyReadCrcVols)
+			// set extent size classes of volume
+			s.checkVolumeExtentSizeClasses(request.VolExtentSizeClasses)
 			// set decommission disks
 			s.checkDecommissionDisks(request.DecommissionDisks)
 			s.diskQosEnableFromMaster = request.EnableDiskQos
@@ -552,6 +616,12 @@ func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
 			// set cpu util and io used in here
 			response.CpuUtil = s.cpuUtil.Load()
 			response.IoUtils = s.space.GetDiskUtils()
+			// the clients negotiate the wire compression at mount only if all the datanodes accept it
+			if s.enableWireCompress {
+				response.Capabilities = []string{proto.CapabilityWireCompress}
+			}
+			// report the changed partitions only if the master has applied the last reports
+			s.partitionReports.Track(request, response)
 
 			if needUpdate {
 				log.LogWarnf("action[handleHeartbeatPacket] master change disk qos limit to [flowWrite %v, flowRead %v, iopsWrite %v, iopsRead %v]",
@@ -683,11 +753,18 @@ func (s *DataNode) handleMarkDeletePacket(p *repl.Packet, c net.Conn) {
 			})
 		}
 	} else {
-		log.LogInfof("handleMarkDeletePacket Delete PartitionID(%v)_Extent(%v)",
-			p.PartitionID, p.ExtentID)
+		// the extent keys not at the offset 0, like the ones of the compacted extents, punch the holes
+		// as the batch deletion does rather than deleting the whole extent shared by the other keys
+		var offset, size int64
+		ext := new(proto.TinyExtentDeleteRecord)
+		if p.Size > 0 && json.Unmarshal(p.Data[:p.Size], ext) == nil && ext.ExtentOffset != 0 {
+			offset, size = int64(ext.ExtentOffset), int64(ext.Size)
+		}
+		log.LogInfof("handleMarkDeletePacket Delete PartitionID(%v)_Extent(%v)_Offset(%v)_Size(%v)",
+			p.PartitionID, p.ExtentID, offset, size)
 		partition.disk.allocCheckLimit(proto.IopsWriteType, 1)
 		partition.disk.limitWrite.Run(0, func() {
-			err = partition.ExtentStore().MarkDelete(p.ExtentID, 0, 0)
+			err = partition.ExtentStore().MarkDelete(p.ExtentID, offset, size)
 			if err != nil {
 				log.LogErrorf("action[handleMarkDeletePacket]: failed to mark delete extent(%v), %v", p.ExtentID, err)
 			}
@@ -766,6 +843,13 @@ func (s *DataNode) handleWritePacket(p *repl.Packet) {
 		err = storage.BrokenDiskError
 		return
 	}
+	// the clients not aware of the extent size class yet roll to a new extent on the failure,
+	// the followers follow the leader since the class might be applied at different heartbeats
+	if !proto.IsTinyExtentType(p.ExtentType) && p.IsLeaderPacket() &&
+		p.ExtentOffset+int64(p.Size) > partition.MaxExtentSize() {
+		err = ErrExtentSizeExceeded
+		return
+	}
 	store := partition.ExtentStore()
 	if proto.IsTinyExtentType(p.ExtentType) {
 		if !shallDegrade {
@@ -985,6 +1069,9 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 	if !shallDegrade {
 		metricPartitionIOLabels = GetIoMetricLabels(partition, "read")
 	}
+	// the repair reads are verified by the receivers
+	verifyCrc := !isRepairRead && (partition.IsVerifyReadCrc() || partition.isSuspectExtent(p.ExtentID))
+	compress := !isRepairRead && p.AcceptCompressedReply() && s.wireCompressAllowed()
 	log.LogDebugf("extentRepairReadPacket dp %v offset %v needSize %v", partition.partitionID, offset, needReplySize)
 	for {
 		if needReplySize <= 0 {
@@ -1011,6 +1098,10 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 		partition.Disk().allocCheckLimit(proto.FlowReadType, currReadSize)
 
 		partition.disk.limitRead.Run(int(currReadSize), func() {
+			if verifyCrc {
+				reply.CRC, err = partition.readVerified(reply.ExtentID, offset, int64(currReadSize), reply.Data)
+				return
+			}
 			reply.CRC, err = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, isRepairRead)
 		})
 		if !shallDegrade {
@@ -1028,7 +1119,15 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 		reply.ResultCode = proto.OpOk
 		reply.Opcode = p.Opcode
 		p.ResultCode = proto.OpOk
-		if err = reply.WriteToConn(connect); err != nil {
+		// the reply keeps the raw data to be put back to the buffers
+		wire := &reply.Packet
+		if compress {
+			compressed := reply.Packet
+			if s.wireCompressor.Compress(&compressed, proto.WireCompressMinSize) {
+				wire = &compressed
+			}
+		}
+		if err = wire.WriteToConn(connect); err != nil {
 			return
 		}
 		needReplySize -= currReadSize
@@ -1043,6 +1142,20 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 	p.PacketOkReply()
 }
 
+// handleNegotiateWireCompress accepts the wire compression requested by the client if it is enabled
+// and the cpu is not busy, the empty reply declines it and the connection is not compressed.
+func (s *DataNode) handleNegotiateWireCompress(p *repl.Packet) {
+	if string(p.Data[:p.Size]) == proto.WireCompressLz4 && s.wireCompressAllowed() {
+		p.PacketOkWithBody([]byte(proto.WireCompressLz4))
+		return
+	}
+	p.PacketOkReply()
+}
+
+func (s *DataNode) wireCompressAllowed() bool {
+	return s.enableWireCompress && s.cpuUtil.Load() <= s.wireCompressCpuLimit
+}
+
 func (s *DataNode) handlePacketToGetAllWatermarks(p *repl.Packet) {
 	var (
 		buf       []byte
@@ -1053,6 +1166,9 @@ func (s *DataNode) handlePacketToGetAllWatermarks(p *repl.Packet) {
 	store := partition.ExtentStore()
 	if proto.IsNormalExtentType(p.ExtentType) {
 		fInfoList, _, err = store.GetAllWatermarks(storage.NormalExtentFilter())
+		if err == nil {
+			fInfoList = store.AttachExtentEnvelopes(fInfoList)
+		}
 	} else {
 		extents := make([]uint64, 0)
 		err = json.Unmarshal(p.Data, &extents)
@@ -1072,6 +1188,25 @@ func (s *DataNode) handlePacketToGetAllWatermarks(p *repl.Packet) {
 	}
 }
 
+// Handle OpSetExtentEnvelope packet.
+func (s *DataNode) handlePacketToSetExtentEnvelope(p *repl.Packet) {
+	var err error
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionSetExtentEnvelope, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+	env := new(storage.ExtentEnvelope)
+	if err = json.Unmarshal(p.Data[:p.Size], env); err != nil {
+		return
+	}
+	env.ExtentID = p.ExtentID
+	partition := p.Object.(*DataPartition)
+	err = partition.HandleExtentEnvelope(env)
+}
+
 func (s *DataNode) writeEmptyPacketOnTinyExtentRepairRead(reply *repl.Packet, newOffset, currentOffset int64, connect net.Conn) (replySize int64, err error) {
 	replySize = newOffset - currentOffset
 	reply.Data = make([]byte, 0)
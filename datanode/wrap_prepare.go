@@ -35,7 +35,7 @@ func (s *DataNode) Prepare(p *repl.Packet) (err error) {
 			p.AfterPre = true
 		}
 	}()
-	if p.IsMasterCommand() {
+	if p.IsMasterCommand() || p.Opcode == proto.OpNegotiateWireCompress {
 		return
 	}
 	atomic.AddUint64(&s.metricsCnt, 1)
@@ -0,0 +1,17 @@
+package fs
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// pinToCPU locks the calling goroutine to its thread and binds the
+// thread to the cpu. The thread is never unlocked, so it exits with
+// the goroutine instead of running other goroutines on the cpu.
+func pinToCPU(cpu int) error {
+	runtime.LockOSThread()
+	var set unix.CPUSet
+	set.Set(cpu)
+	return unix.SchedSetaffinity(0, &set)
+}
@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestPinToCPU(t *testing.T) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		t.Fatal(err)
+	}
+	cpu := -1
+	for i := 0; i < len(set)*64; i++ {
+		if set.IsSet(i) {
+			cpu = i
+		}
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		if err := pinToCPU(cpu); err != nil {
+			errc <- err
+			return
+		}
+		var pinned unix.CPUSet
+		if err := unix.SchedGetaffinity(0, &pinned); err != nil {
+			errc <- err
+			return
+		}
+		if pinned.Count() != 1 || !pinned.IsSet(cpu) {
+			t.Errorf("expected the thread pinned to cpu %v", cpu)
+		}
+		errc <- nil
+	}()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+// pinToCPU is only supported on linux, the goroutines are scheduled
+// on any cpu on other platforms.
+func pinToCPU(cpu int) error {
+	return nil
+}
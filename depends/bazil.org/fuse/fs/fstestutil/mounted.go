@@ -63,7 +63,7 @@ func MountedFunc(fn func(*Mount) fs.FS, conf *fs.Config, options ...fuse.MountOp
 	if err != nil {
 		return nil, err
 	}
-	c, err := fuse.Mount(dir, options...)
+	c, err := fuse.Mount(dir, false, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -10,15 +10,25 @@ import (
 
 // SimpleFS is a trivial FS that just implements the Root method.
 type SimpleFS struct {
-	Node fs.Node
+	RootNode fs.Node
 }
 
 var _ = fs.FS(SimpleFS{})
 
 func (f SimpleFS) Root() (fs.Node, error) {
-	return f.Node, nil
+	return f.RootNode, nil
 }
 
+func (f SimpleFS) Node(ino, pino uint64, mode uint32) (fs.Node, error) {
+	return nil, fuse.ENOENT
+}
+
+func (f SimpleFS) State() (fs.FSStatType, string) {
+	return fs.FSStatResume, ""
+}
+
+func (f SimpleFS) Notify(stat fs.FSStatType, msg interface{}) {}
+
 // File can be embedded in a struct to make it look like a file.
 type File struct{}
 
@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse"
+)
+
+const (
+	handleChunkShift = 10
+	handleChunkSize  = 1 << handleChunkShift
+	handleChunkMask  = handleChunkSize - 1
+)
+
+type handleChunk [handleChunkSize]unsafe.Pointer // *serveHandle
+
+// handleTable maps handle ids to the served handles. Lookups are lock
+// free since each read and write request resolves its handle; updates
+// must be serialized by the caller, which holds Server.meta.
+//
+// The handles are kept in fixed size chunks, so growing the table only
+// copies the chunk directory and never moves a stored handle.
+type handleTable struct {
+	chunks atomic.Value // []*handleChunk
+	n      uint64
+}
+
+func (t *handleTable) loadChunks() []*handleChunk {
+	chunks, _ := t.chunks.Load().([]*handleChunk)
+	return chunks
+}
+
+// len returns the number of handle ids allocated in the table.
+func (t *handleTable) len() uint64 {
+	return atomic.LoadUint64(&t.n)
+}
+
+// get returns nil for the ids not in the table.
+func (t *handleTable) get(id fuse.HandleID) *serveHandle {
+	if uint64(id) >= t.len() {
+		return nil
+	}
+	chunks := t.loadChunks()
+	idx := uint64(id) >> handleChunkShift
+	if idx >= uint64(len(chunks)) {
+		return nil
+	}
+	return (*serveHandle)(atomic.LoadPointer(&chunks[idx][uint64(id)&handleChunkMask]))
+}
+
+// set stores the handle of an id that is already in the table.
+func (t *handleTable) set(id fuse.HandleID, sh *serveHandle) {
+	chunks := t.loadChunks()
+	atomic.StorePointer(&chunks[uint64(id)>>handleChunkShift][uint64(id)&handleChunkMask], unsafe.Pointer(sh))
+}
+
+// append stores the handle with the next id of the table.
+func (t *handleTable) append(sh *serveHandle) (id fuse.HandleID) {
+	id = fuse.HandleID(t.len())
+	chunks := t.loadChunks()
+	if idx := uint64(id) >> handleChunkShift; idx >= uint64(len(chunks)) {
+		grown := make([]*handleChunk, len(chunks), len(chunks)+1)
+		copy(grown, chunks)
+		grown = append(grown, new(handleChunk))
+		t.chunks.Store(grown)
+	}
+	t.set(id, sh)
+	atomic.AddUint64(&t.n, 1)
+	return
+}
@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse"
+)
+
+func TestHandleTable(t *testing.T) {
+	var table handleTable
+	if sh := table.get(0); sh != nil {
+		t.Fatalf("expected no handle in the empty table: %v", sh)
+	}
+
+	handles := make([]*serveHandle, 2*handleChunkSize+1)
+	for i := range handles {
+		handles[i] = &serveHandle{}
+		if id := table.append(handles[i]); id != fuse.HandleID(i) {
+			t.Fatalf("expected handle id %v: %v", i, id)
+		}
+	}
+	if n := table.len(); n != uint64(len(handles)) {
+		t.Fatalf("expected %v handles: %v", len(handles), n)
+	}
+	if n := len(table.loadChunks()); n != 3 {
+		t.Fatalf("expected 3 chunks: %v", n)
+	}
+	for i, sh := range handles {
+		if got := table.get(fuse.HandleID(i)); got != sh {
+			t.Fatalf("handle %v: expected %p: %p", i, sh, got)
+		}
+	}
+	if sh := table.get(fuse.HandleID(len(handles))); sh != nil {
+		t.Fatalf("expected no handle out of the table: %v", sh)
+	}
+
+	// the released ids are reused by set
+	table.set(handleChunkSize, nil)
+	if sh := table.get(handleChunkSize); sh != nil {
+		t.Fatalf("expected the handle released: %v", sh)
+	}
+	sh := &serveHandle{}
+	table.set(handleChunkSize, sh)
+	if got := table.get(handleChunkSize); got != sh {
+		t.Fatalf("expected %p: %p", sh, got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"hash/fnv"
 	"io"
 	"log"
+	"math"
 	"net"
 	"os"
 	"reflect"
@@ -233,6 +234,14 @@ type NodeForgetter interface {
 	Forget()
 }
 
+type NodeGenerationer interface {
+	// NodeGeneration returns the generation of the inode number of the
+	// node, which tells apart the inodes taking the same inode number
+	// over time. It is placed in the high 32 bits of the generation
+	// replied to the kernel.
+	NodeGeneration() uint32
+}
+
 type NodeRenamer interface {
 	Rename(ctx context.Context, req *fuse.RenameRequest, newDir Node) error
 }
@@ -403,7 +412,7 @@ type Server struct {
 	req        map[fuse.RequestID]*serveRequest
 	node       []*serveNode
 	nodeRef    map[Node]fuse.NodeID
-	handle     []*serveHandle
+	handle     handleTable // lock free to lookup
 	freeNode   []fuse.NodeID
 	freeHandle []fuse.HandleID
 	nodeGen    uint64
@@ -618,12 +627,13 @@ func (s *Server) SaveFuseContext(fs FS) (msg string, err error) {
 	}
 
 	skip = 1
-	for i, sh := range s.handle[skip:] {
+	for id := skip; id < s.handle.len(); id++ {
 		var (
-			handleid uint64 = skip + uint64(i)
+			handleid uint64 = id
 			n        int
 		)
 
+		sh := s.handle.get(fuse.HandleID(id))
 		if sh == nil {
 			continue
 		}
@@ -834,12 +844,11 @@ func (s *Server) LoadFuseContext(fs FS, sockaddr string) error {
 			}
 
 			sh := &serveHandle{handle: hdl, nodeID: fuse.NodeID(ch.NodeID)}
-			for uint64(len(s.handle)) < ch.HandleID {
-				freeHandleID := fuse.HandleID(len(s.handle))
+			for s.handle.len() < ch.HandleID {
+				freeHandleID := s.handle.append(nil)
 				s.freeHandle = append(s.freeHandle, freeHandleID)
-				s.handle = append(s.handle, nil)
 			}
-			s.handle = append(s.handle, sh)
+			s.handle.append(sh)
 		} else {
 			err = fmt.Errorf("LoadFuseContext: unrecognize handles file version %v\n", chVersion)
 			return err
@@ -915,12 +924,14 @@ func (s *Server) Serve(fs FS, opt *proto.MountOptions) error {
 		node:       root,
 		refs:       1,
 	})
-	s.handle = append(s.handle, nil)
+	s.handle.append(nil)
 
 	if err = s.TryRestore(fs); err != nil {
 		return fmt.Errorf("restore fail: %v", err)
 	}
 
+	s.startQueues(opt)
+
 	for {
 		if s.TrySuspend(fs) {
 			break
@@ -944,16 +955,118 @@ func (s *Server) Serve(fs FS, opt *proto.MountOptions) error {
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			if opt != nil && opt.RequestTimeout > 0 {
-				s.serveWithTimeOut(req, opt.RequestTimeout)
-			} else {
-				s.serve(req)
-			}
+			s.dispatch(req, opt)
 		}()
 	}
 	return nil
 }
 
+func (s *Server) dispatch(req fuse.Request, opt *proto.MountOptions) {
+	if opt != nil && opt.RequestTimeout > 0 {
+		s.serveWithTimeOut(req, opt.RequestTimeout)
+	} else {
+		s.serve(req)
+	}
+}
+
+// startQueues clones the extra device queues of the connection, each of
+// them is read by its own goroutine and served by its own handler pool,
+// so that requests from different CPUs are not funneled through a single
+// device file. The reader and the handlers of the i-th queue are pinned
+// to the i-th CPU. The connection falls back to the queues already
+// started if the kernel refuses to clone one.
+func (s *Server) startQueues(opt *proto.MountOptions) {
+	if opt == nil {
+		return
+	}
+	queues := int(opt.FuseQueues)
+	if queues <= 0 {
+		queues = runtime.NumCPU()
+	}
+	for i := 1; i < queues; i++ {
+		queue, err := s.conn.CloneQueue()
+		if err != nil {
+			log.Printf("fuse: clone queue %v of %v: %v", i, queues, err)
+			return
+		}
+		s.wg.Add(1)
+		go func(cpu int) {
+			defer s.wg.Done()
+			defer queue.Close()
+			s.serveQueue(queue, cpu, int(opt.FuseQueueWorkers), func(req fuse.Request) {
+				s.dispatch(req, opt)
+			})
+		}(i % runtime.NumCPU())
+	}
+}
+
+type requestReader interface {
+	ReadRequest() (fuse.Request, error)
+}
+
+// serveQueue reads the requests of a queue and hands them to the pool of
+// the workers. The reader never blocks on the busy workers: the
+// interrupts and the forgets are served inline, since the requests they
+// release may be the ones holding the workers, and the other requests
+// are served by new goroutines if all the workers are busy.
+func (s *Server) serveQueue(queue requestReader, cpu, workers int, dispatch func(fuse.Request)) {
+	if err := pinToCPU(cpu); err != nil {
+		log.Printf("fuse: pin queue to cpu %v: %v", cpu, err)
+	}
+
+	var reqC chan fuse.Request
+	if workers > 0 {
+		reqC = make(chan fuse.Request, workers)
+		for i := 0; i < workers; i++ {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if err := pinToCPU(cpu); err != nil {
+					log.Printf("fuse: pin queue worker to cpu %v: %v", cpu, err)
+				}
+				for req := range reqC {
+					dispatch(req)
+				}
+			}()
+		}
+		defer close(reqC)
+	}
+
+	for {
+		req, err := queue.ReadRequest()
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("fuse: read queue request: %v", err)
+			}
+			return
+		}
+
+		switch req.(type) {
+		case *fuse.InterruptRequest:
+			dispatch(req)
+			continue
+		case *fuse.ForgetRequest:
+			ForgetServeLimit.Wait(context.Background())
+			dispatch(req)
+			continue
+		default:
+		}
+
+		if reqC != nil {
+			select {
+			case reqC <- req:
+				continue
+			default:
+			}
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			dispatch(req)
+		}()
+	}
+}
+
 // Serve serves a FUSE connection with the default settings. See
 // Server.Serve.
 func Serve(c *fuse.Conn, fs FS, opt *proto.MountOptions) error {
@@ -1026,6 +1139,9 @@ func (c *Server) saveNode(inode uint64, node Node) (id fuse.NodeID, gen uint64)
 		c.node = append(c.node, sn)
 	}
 	sn.generation = c.nodeGen
+	if g, ok := node.(NodeGenerationer); ok {
+		sn.generation = c.nodeGen&math.MaxUint32 | uint64(g.NodeGeneration())<<32
+	}
 	c.nodeRef[node] = id
 	return id, sn.generation
 }
@@ -1036,10 +1152,9 @@ func (c *Server) saveHandle(handle Handle, nodeID fuse.NodeID) (id fuse.HandleID
 	if n := len(c.freeHandle); n > 0 {
 		id = c.freeHandle[n-1]
 		c.freeHandle = c.freeHandle[:n-1]
-		c.handle[id] = shandle
+		c.handle.set(id, shandle)
 	} else {
-		id = fuse.HandleID(len(c.handle))
-		c.handle = append(c.handle, shandle)
+		id = c.handle.append(shandle)
 	}
 	c.meta.Unlock()
 	return
@@ -1089,7 +1204,7 @@ func (c *Server) dropNode(id fuse.NodeID, n uint64) (forget bool) {
 
 func (c *Server) dropHandle(id fuse.HandleID) {
 	c.meta.Lock()
-	c.handle[id] = nil
+	c.handle.set(id, nil)
 	c.freeHandle = append(c.freeHandle, id)
 	c.meta.Unlock()
 }
@@ -1105,15 +1220,10 @@ func (m missingHandle) String() string {
 
 // Returns nil for invalid handles.
 func (c *Server) getHandle(id fuse.HandleID) (shandle *serveHandle) {
-	c.meta.Lock()
-	defer c.meta.Unlock()
-	if id < fuse.HandleID(len(c.handle)) {
-		shandle = c.handle[uint(id)]
-	}
-	if shandle == nil {
+	if shandle = c.handle.get(id); shandle == nil {
 		c.debug(missingHandle{
 			Handle:    id,
-			MaxHandle: fuse.HandleID(len(c.handle)),
+			MaxHandle: fuse.HandleID(c.handle.len()),
 		})
 	}
 	return
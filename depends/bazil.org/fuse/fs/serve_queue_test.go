@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/depends/bazil.org/fuse"
+)
+
+type chanReader chan fuse.Request
+
+func (r chanReader) ReadRequest() (fuse.Request, error) {
+	req, ok := <-r
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func TestServeQueueBusyWorkers(t *testing.T) {
+	var (
+		s       Server
+		mu      sync.Mutex
+		served  []fuse.Request
+		release = make(chan struct{})
+		inline  = make(chan fuse.Request, 2)
+	)
+	dispatch := func(req fuse.Request) {
+		switch req.(type) {
+		case *fuse.InterruptRequest, *fuse.ForgetRequest:
+			inline <- req
+		default:
+			<-release
+		}
+		mu.Lock()
+		served = append(served, req)
+		mu.Unlock()
+	}
+
+	queue := make(chanReader)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.serveQueue(queue, 0, 1, dispatch)
+	}()
+
+	// the worker and the queue of the workers are full, the reader
+	// never blocks on them
+	for i := 0; i < 3; i++ {
+		select {
+		case queue <- &fuse.ReadRequest{}:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the reader is blocked by the busy workers")
+		}
+	}
+
+	// the interrupts and the forgets are served while the workers are busy
+	for _, req := range []fuse.Request{&fuse.InterruptRequest{}, &fuse.ForgetRequest{}} {
+		queue <- req
+		select {
+		case got := <-inline:
+			if got != req {
+				t.Fatalf("expected %v served: %v", req, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%v is not served while the workers are busy", req)
+		}
+	}
+
+	close(release)
+	close(queue)
+	<-done
+	s.wg.Wait()
+	if len(served) != 5 {
+		t.Fatalf("expected 5 requests served: %v", len(served))
+	}
+}
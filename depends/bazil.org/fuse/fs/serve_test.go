@@ -66,7 +66,7 @@ func TestMountpointDoesNotExist(t *testing.T) {
 	defer os.Remove(tmp)
 
 	mountpoint := path.Join(tmp, "does-not-exist")
-	conn, err := fuse.Mount(mountpoint)
+	conn, err := fuse.Mount(mountpoint, false)
 	if err == nil {
 		conn.Close()
 		t.Fatalf("expected error with non-existent mountpoint")
@@ -76,7 +76,9 @@ func TestMountpointDoesNotExist(t *testing.T) {
 	}
 }
 
-type badRootFS struct{}
+type badRootFS struct {
+	fstestutil.SimpleFS
+}
 
 func (badRootFS) Root() (fs.Node, error) {
 	// pick a really distinct error, to identify it later
@@ -106,7 +108,9 @@ func TestRootErr(t *testing.T) {
 	}
 }
 
-type testPanic struct{}
+type testPanic struct {
+	fstestutil.SimpleFS
+}
 
 type panicSentinel struct{}
 
@@ -148,7 +152,9 @@ func TestPanic(t *testing.T) {
 	}
 }
 
-type testStatFS struct{}
+type testStatFS struct {
+	fstestutil.SimpleFS
+}
 
 func (f testStatFS) Root() (fs.Node, error) {
 	return f, nil
@@ -245,7 +251,9 @@ func TestStatfs(t *testing.T) {
 
 // Test Stat of root.
 
-type root struct{}
+type root struct {
+	fstestutil.SimpleFS
+}
 
 func (f root) Root() (fs.Node, error) {
 	return f, nil
@@ -0,0 +1,36 @@
+package fuse
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// FUSE_DEV_IOC_CLONE = _IOR(229, 0, uint32_t)
+const fuseDevIocClone = 0x8004e500
+
+// CloneQueue opens a new device queue of the mounted FUSE connection.
+// The kernel dispatches requests to all the queues of a connection, and
+// the requests read from a queue are answered on the same queue, so that
+// the queues can be served in parallel without sharing a device file.
+//
+// The returned Conn must be closed separately.
+func (c *Conn) CloneQueue() (*Conn, error) {
+	dev, err := os.OpenFile("/dev/fuse", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.rio.RLock()
+	fd := uint32(c.fd())
+	c.rio.RUnlock()
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dev.Fd(), fuseDevIocClone, uintptr(unsafe.Pointer(&fd)))
+	if errno != 0 {
+		dev.Close()
+		return nil, errno
+	}
+	return &Conn{
+		Ready: c.Ready,
+		dev:   dev,
+		proto: c.proto,
+	}, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package fuse
+
+import "syscall"
+
+// CloneQueue is only supported on linux, the connection is served by a
+// single queue on other platforms.
+func (c *Conn) CloneQueue() (*Conn, error) {
+	return nil, syscall.ENOTSUP
+}
@@ -54,6 +54,9 @@ const (
 
 	PeerNormal  PeerType = 0
 	PeerArbiter PeerType = 1
+	// PeerLearner receives the logs but neither votes nor counts in the quorum, it is
+	// promoted to PeerNormal by ConfUpdateNode
+	PeerLearner PeerType = 2
 )
 
 // The Snapshot interface is supplied by the application to access the snapshot data of application.
@@ -195,6 +198,8 @@ func (t PeerType) String() string {
 		return "PeerNormal"
 	case 1:
 		return "PeerArbiter"
+	case 2:
+		return "PeerLearner"
 	}
 	return "unknown"
 }
@@ -491,8 +491,23 @@ func (r *raftFsm) updatePeer(peer proto.Peer) {
 	}
 }
 
+// quorum returns the majority of the voters, the learners are not counted.
 func (r *raftFsm) quorum() int {
-	return len(r.replicas)/2 + 1
+	return r.voters()/2 + 1
+}
+
+func (r *raftFsm) voters() (n int) {
+	for _, pr := range r.replicas {
+		if pr.peer.Type != proto.PeerLearner {
+			n++
+		}
+	}
+	return
+}
+
+func (r *raftFsm) isLearner(id uint64) bool {
+	pr, ok := r.replicas[id]
+	return ok && pr.peer.Type == proto.PeerLearner
 }
 
 func (r *raftFsm) send(m *proto.Message) {
@@ -116,7 +116,7 @@ func (r *raftFsm) campaign(force bool, t CampaignType) {
 	}
 
 	for id := range r.replicas {
-		if id == r.config.NodeID {
+		if id == r.config.NodeID || r.isLearner(id) {
 			continue
 		}
 		li, lt := r.raftLog.lastIndexAndTerm()
@@ -144,7 +144,7 @@ func (r *raftFsm) poll(id uint64, v bool) (granted int) {
 			logger.Debug("raft[%v,%v] received vote rejection from %v at term %d.", r.id, r.config.ReplicateAddr, id, r.term)
 		}
 	}
-	if _, ok := r.votes[id]; !ok {
+	if _, ok := r.votes[id]; !ok && !r.isLearner(id) {
 		r.votes[id] = v
 	}
 	for _, vv := range r.votes {
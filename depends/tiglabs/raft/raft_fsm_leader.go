@@ -307,7 +307,9 @@ func stepLeader(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		if !r.isLearner(m.From) {
+			r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		}
 		proto.ReturnMessage(m)
 		return
 	}
@@ -363,7 +365,9 @@ func stepPreCandidate(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		if !r.isLearner(m.From) {
+			r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		}
 		proto.ReturnMessage(m)
 		return
 
@@ -438,6 +442,9 @@ func (r *raftFsm) tickElectionAck() {
 func (r *raftFsm) checkLeaderLease() bool {
 	var act int
 	for id, peer := range r.replicas {
+		if peer.peer.Type == proto.PeerLearner {
+			continue
+		}
 		if id == r.config.NodeID || peer.state == replicaStateSnapshot {
 			act++
 			continue
@@ -457,7 +464,13 @@ func (r *raftFsm) checkLeaderLease() bool {
 func (r *raftFsm) maybeCommit() bool {
 	mis := make(util.Uint64Slice, 0, len(r.replicas))
 	for _, rp := range r.replicas {
-		mis = append(mis, rp.match)
+		// the logs are committed by the voters, the learners catch up without slowing the commit
+		if rp.peer.Type != proto.PeerLearner {
+			mis = append(mis, rp.match)
+		}
+	}
+	if len(mis) == 0 {
+		return false
 	}
 	sort.Sort(sort.Reverse(mis))
 	mci := mis[r.quorum()-1]
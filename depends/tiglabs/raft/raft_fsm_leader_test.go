@@ -818,6 +818,67 @@ func TestPromotable(t *testing.T) {
 	}
 }
 
+// TestLearner tests that the learner neither campaigns nor counts in the quorum
+// until it is promoted by ConfUpdateNode.
+func TestLearner(t *testing.T) {
+	s := stor.DefaultMemoryStorage()
+	cfg := newTestRaftConfig(1, withStorage(s), withPeers(1, 2))
+	r := newTestRaftFsm(5, 1, cfg)
+	r.applyConfChange(&proto.ConfChange{Peer: proto.Peer{Type: proto.PeerLearner, ID: 3, PeerID: 3}, Type: proto.ConfAddNode})
+	if g := r.quorum(); g != 2 {
+		t.Errorf("quorum = %d, want 2", g)
+	}
+	r.becomeCandidate()
+	r.becomeLeader()
+	for _, m := range r.readMessages() {
+		if m.To == 3 && m.Type == proto.ReqMsgVote {
+			t.Errorf("learner is requested to vote")
+		}
+	}
+
+	// the learner catching up does not commit the entries
+	lasti := r.raftLog.lastIndex()
+	r.replicas[3].match = lasti
+	r.replicas[2].match = 0
+	r.raftLog.committed = 0
+	if r.maybeCommit() {
+		t.Errorf("committed by the learner")
+	}
+	r.replicas[2].match = lasti
+	if !r.maybeCommit() || r.raftLog.committed != lasti {
+		t.Errorf("committed = %d, want %d", r.raftLog.committed, lasti)
+	}
+
+	// the learner does not campaign
+	lcfg := newTestRaftConfig(3, withStorage(stor.DefaultMemoryStorage()), withPeers(1, 2))
+	learner := newTestRaftFsm(5, 1, lcfg)
+	learner.applyConfChange(&proto.ConfChange{Peer: proto.Peer{Type: proto.PeerLearner, ID: 3, PeerID: 3}, Type: proto.ConfAddNode})
+	if learner.promotable() {
+		t.Errorf("learner is promotable")
+	}
+	learner.Step(&proto.Message{From: 3, To: 3, Type: proto.LocalMsgHup})
+	if learner.state != stateFollower {
+		t.Errorf("learner state = %v, want %v", learner.state, stateFollower)
+	}
+
+	// the promoted learner votes and counts in the quorum
+	promote := &proto.ConfChange{Peer: proto.Peer{Type: proto.PeerNormal, ID: 3, PeerID: 3}, Type: proto.ConfUpdateNode}
+	r.applyConfChange(promote)
+	learner.applyConfChange(promote)
+	if g := r.quorum(); g != 2 {
+		t.Errorf("quorum = %d, want 2", g)
+	}
+	if !learner.promotable() {
+		t.Errorf("promoted learner is not promotable")
+	}
+	r.replicas[2].match = 0
+	r.replicas[3].match = lasti
+	r.raftLog.committed = 0
+	if !r.maybeCommit() || r.raftLog.committed != lasti {
+		t.Errorf("committed = %d, want %d", r.raftLog.committed, lasti)
+	}
+}
+
 func TestCampaignWhileLeader(t *testing.T) {
 	testCampaignWhileLeader(t, false)
 }
@@ -14,7 +14,11 @@
 
 package wal
 
-import "github.com/cubefs/cubefs/depends/tiglabs/raft/util"
+import (
+	"time"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/util"
+)
 
 const (
 	DefaultFileCacheCapacity = 2
@@ -34,6 +38,9 @@ type Config struct {
 
 	Sync bool
 
+	// SyncDelay 同步写时两次sync的最小间隔，期间写入的日志不等待sync，由timer合并到下一次sync（group commit）
+	SyncDelay time.Duration
+
 	// TruncateFirstDummy  初始化时添加一条日志然后截断
 	TruncateFirstDummy bool
 }
@@ -60,6 +67,13 @@ func (c *Config) GetSync() bool {
 	return c.Sync
 }
 
+func (c *Config) GetSyncDelay() time.Duration {
+	if c == nil || c.SyncDelay < 0 {
+		return 0
+	}
+	return c.SyncDelay
+}
+
 func (c *Config) GetTruncateFirstDummy() bool {
 	if c == nil {
 		return false
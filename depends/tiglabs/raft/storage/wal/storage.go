@@ -17,6 +17,8 @@ package wal
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/logger"
@@ -37,6 +39,15 @@ type Storage struct {
 	metafile   *metaFile
 	prevCommit uint64 // 有commit变化时sync一下
 
+	sync      int32 // atomic, 1表示同步写
+	syncDelay int64 // atomic, 两次sync的最小间隔（纳秒）
+
+	// syncMu 保护日志文件的写入和sync，延迟的sync在timer中执行
+	syncMu    sync.Mutex
+	lastSync  time.Time
+	dirty     bool // 有写入的日志等待延迟的sync
+	syncTimer *time.Timer
+
 	closed bool
 }
 
@@ -60,6 +71,7 @@ func NewStorage(dir string, c *Config) (*Storage, error) {
 		metafile:   mf,
 		prevCommit: hardState.Commit,
 	}
+	s.SetSync(c.GetSync(), c.GetSyncDelay())
 
 	// 加载日志文件
 	ls, err := openLogStorage(dir, s)
@@ -160,12 +172,73 @@ func (s *Storage) LastIndex() (index uint64, err error) {
 // If first index of entries > LastIndex,then append all entries,
 // Else write entries at first index and truncate the redundant log entries.
 func (s *Storage) StoreEntries(entries []*proto.Entry) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
 	if err := s.ls.SaveEntries(entries); err != nil {
 		return err
 	}
+	return s.syncEntries()
+}
+
+// SetSync changes whether the log entries are synced before they are acknowledged.
+// With a positive delay, the syncs are at least the delay apart, the entries stored
+// in the meantime are acknowledged at once and grouped into the next sync run by a
+// timer, so that the raft loop never waits for the delay.
+func (s *Storage) SetSync(sync bool, delay time.Duration) {
+	if sync {
+		atomic.StoreInt32(&s.sync, 1)
+	} else {
+		atomic.StoreInt32(&s.sync, 0)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	atomic.StoreInt64(&s.syncDelay, int64(delay))
+}
+
+func (s *Storage) isSync() bool {
+	return atomic.LoadInt32(&s.sync) == 1
+}
+
+// syncEntries syncs the log entries, or defers the sync to the timer if the last sync is
+// within the delay. The caller should hold the syncMu.
+func (s *Storage) syncEntries() error {
+	if !s.isSync() {
+		return nil
+	}
+	if delay := time.Duration(atomic.LoadInt64(&s.syncDelay)); delay > 0 {
+		if wait := delay - time.Since(s.lastSync); wait > 0 {
+			s.dirty = true
+			if s.syncTimer == nil {
+				s.syncTimer = time.AfterFunc(wait, s.delayedSync)
+			}
+			return nil
+		}
+	}
+	return s.syncLog()
+}
+
+func (s *Storage) syncLog() error {
+	if err := s.ls.Sync(); err != nil {
+		return err
+	}
+	s.lastSync = time.Now()
+	s.dirty = false
 	return nil
 }
 
+func (s *Storage) delayedSync() {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	s.syncTimer = nil
+	if s.closed || !s.dirty {
+		return
+	}
+	if err := s.syncLog(); err != nil {
+		logger.Error("Storage delayed sync failed: %v", err)
+	}
+}
+
 // StoreHardState store the raft state to the repository.
 func (s *Storage) StoreHardState(st proto.HardState) error {
 	if err := s.metafile.SaveHardState(st); err != nil {
@@ -173,7 +246,7 @@ func (s *Storage) StoreHardState(st proto.HardState) error {
 	}
 	s.hardState = st
 
-	if s.c.GetSync() {
+	if s.isSync() {
 		sync := false
 		if st.Commit != s.prevCommit {
 			sync = true
@@ -183,7 +256,10 @@ func (s *Storage) StoreHardState(st proto.HardState) error {
 			if err := s.metafile.Sync(); err != nil {
 				return err
 			}
-			if err := s.ls.Sync(); err != nil {
+			s.syncMu.Lock()
+			err := s.syncEntries()
+			s.syncMu.Unlock()
+			if err != nil {
 				return err
 			}
 		}
@@ -223,7 +299,10 @@ func (s *Storage) Truncate(index uint64) error {
 	}
 
 	// 截断日志文件
-	if err = s.ls.TruncateFront(index); err != nil {
+	s.syncMu.Lock()
+	err = s.ls.TruncateFront(index)
+	s.syncMu.Unlock()
+	if err != nil {
 		return err
 	}
 
@@ -255,7 +334,10 @@ func (s *Storage) ApplySnapshot(meta proto.SnapshotMeta) error {
 		return err
 	}
 
-	if err = s.ls.TruncateAll(); err != nil {
+	s.syncMu.Lock()
+	err = s.ls.TruncateAll()
+	s.syncMu.Unlock()
+	if err != nil {
 		return err
 	}
 
@@ -267,7 +349,18 @@ func (s *Storage) ApplySnapshot(meta proto.SnapshotMeta) error {
 
 // Close the storage.
 func (s *Storage) Close() {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
 	if !s.closed {
+		if s.syncTimer != nil {
+			s.syncTimer.Stop()
+			s.syncTimer = nil
+		}
+		if s.dirty {
+			if err := s.syncLog(); err != nil {
+				logger.Error("Storage sync on close failed: %v", err)
+			}
+		}
 		s.ls.Close()
 		s.metafile.Close()
 		s.closed = true
@@ -0,0 +1,83 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wal
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+)
+
+func TestStorageSyncDelay(t *testing.T) {
+	testPath, err := os.MkdirTemp("", "test_storage_sync_delay")
+	if err != nil {
+		t.Fatalf("prepare test path fail: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(testPath)
+	}()
+
+	s, err := NewStorage(testPath, &Config{Sync: true})
+	if err != nil {
+		t.Fatalf("new storage fail: %v", err)
+	}
+	defer s.Close()
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	index := uint64(0)
+	store := func() time.Duration {
+		index++
+		start := time.Now()
+		if err := s.StoreEntries([]*proto.Entry{genLogEntry(rnd, index)}); err != nil {
+			t.Fatalf("store entries fail: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	isDirty := func() bool {
+		s.syncMu.Lock()
+		defer s.syncMu.Unlock()
+		return s.dirty
+	}
+
+	delay := 50 * time.Millisecond
+	store()
+	s.SetSync(true, delay)
+	store()
+	// the entries within the delay are grouped into the sync of the timer without waiting
+	if elapsed := store(); elapsed >= delay/2 {
+		t.Fatalf("expect no wait for the delayed sync, but is %v", elapsed)
+	}
+	if !isDirty() {
+		t.Fatalf("expect the entries wait for the delayed sync")
+	}
+	time.Sleep(2 * delay)
+	if isDirty() {
+		t.Fatalf("expect the entries synced by the timer")
+	}
+
+	s.SetSync(false, delay)
+	if elapsed := store(); elapsed >= delay/2 {
+		t.Fatalf("expect no wait without sync, but is %v", elapsed)
+	}
+	ents, _, err := s.Entries(1, index+1, math.MaxUint32)
+	if err != nil || len(ents) != int(index) {
+		t.Fatalf("expect %v entries, but is %v err %v", index, len(ents), err)
+	}
+}
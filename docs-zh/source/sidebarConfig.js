@@ -80,6 +80,9 @@ module.exports = [
                     'maintenance/admin-api/metanode/partition.md',
                     'maintenance/admin-api/metanode/inode.md',
                     'maintenance/admin-api/metanode/dentry.md',
+                    'maintenance/admin-api/metanode/trace.md',
+                    'maintenance/admin-api/metanode/namespace.md',
+                    'maintenance/admin-api/metanode/limit.md',
                     'maintenance/admin-api/blobstore/base.md',
                     'maintenance/admin-api/blobstore/cm.md',
                     'maintenance/admin-api/blobstore/blobnode.md',
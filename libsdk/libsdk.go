@@ -54,6 +54,15 @@ struct cfs_dirent {
     uint32_t     nameLen;
 };
 
+struct cfs_dir_change {
+    uint64_t seq;
+    uint64_t ino;
+    char     op;
+    char     d_type;
+    char     name[256];
+    uint32_t     nameLen;
+};
+
 struct cfs_hdfs_stat_info {
     uint64_t size;
     uint64_t atime;
@@ -241,6 +250,7 @@ type client struct {
 	cluster             string
 	dirChildrenNumLimit uint32
 	enableAudit         bool
+	enableStatAhead     bool
 
 	// runtime context
 	cwd    string // current working directory
@@ -324,6 +334,12 @@ func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
 		} else {
 			c.enableAudit = false
 		}
+	case "enableStatAhead":
+		if v == "true" {
+			c.enableStatAhead = true
+		} else {
+			c.enableStatAhead = false
+		}
 	default:
 		return statusEINVAL
 	}
@@ -785,6 +801,7 @@ func cfs_readdir(id C.int64_t, fd C.int, dirents []C.struct_cfs_dirent, count C.
 	}
 
 	dirp := f.dirp
+	start := dirp.pos
 	for dirp.pos < len(dirp.dirents) && n < count {
 		// fill up ino
 		dirents[n].ino = C.uint64_t(dirp.dirents[dirp.pos].Inode)
@@ -814,6 +831,65 @@ func cfs_readdir(id C.int64_t, fd C.int, dirents []C.struct_cfs_dirent, count C.
 		n++
 	}
 
+	if c.enableStatAhead && n > 0 {
+		c.statAhead(f.path, dirp.dirents[start:dirp.pos])
+	}
+	return n
+}
+
+/*
+ * cfs_read_dir_changes reads the changes of the opened directory after the sequence *from,
+ * and *from is advanced to read the following changes. The changes are not complete if
+ * EOVERFLOW is returned, then the directory should be listed again and the changes are
+ * read from the advanced *from.
+ */
+
+//export cfs_read_dir_changes
+func cfs_read_dir_changes(id C.int64_t, fd C.int, from *C.uint64_t, changes []C.struct_cfs_dir_change, count C.int) (n C.int) {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return statusEBADFD
+	}
+
+	events, next, truncated, err := c.mw.ReadDirChanges_ll(f.ino, uint64(*from), uint64(count))
+	if err != nil {
+		return errorToStatus(err)
+	}
+	*from = C.uint64_t(next)
+	if truncated {
+		return errorToStatus(syscall.EOVERFLOW)
+	}
+
+	for ; n < count && int(n) < len(events); n++ {
+		event := &events[n]
+		changes[n].seq = C.uint64_t(event.Seq)
+		changes[n].ino = C.uint64_t(event.Inode)
+		changes[n].op = C.char(event.Op)
+
+		if proto.IsRegular(event.Type) {
+			changes[n].d_type = C.DT_REG
+		} else if proto.IsDir(event.Type) {
+			changes[n].d_type = C.DT_DIR
+		} else if proto.IsSymlink(event.Type) {
+			changes[n].d_type = C.DT_LNK
+		} else {
+			changes[n].d_type = C.DT_UNKNOWN
+		}
+
+		nameLen := len(event.Name)
+		if nameLen >= 256 {
+			nameLen = 255
+		}
+		hdr := (*reflect.StringHeader)(unsafe.Pointer(&event.Name))
+		C.memcpy(unsafe.Pointer(&changes[n].name[0]), unsafe.Pointer(hdr.Data), C.size_t(nameLen))
+		changes[n].name[nameLen] = 0
+		changes[n].nameLen = C.uint32_t(nameLen)
+	}
 	return n
 }
 
@@ -1358,6 +1434,27 @@ func (c *client) lookupPath(path string) (*proto.InodeInfo, error) {
 	return info, nil
 }
 
+// statAhead warms the dentry and inode caches with the entries returned by readdir, the
+// attributes are fetched in one batch, so that the getattr of the entries needs no round trip.
+func (c *client) statAhead(dirPath string, dentries []proto.Dentry) {
+	inodes := make([]uint64, 0, len(dentries))
+	for _, dentry := range dentries {
+		inodes = append(inodes, dentry.Inode)
+	}
+	infos := c.mw.BatchInodeGet(inodes)
+	c.putStatAhead(dirPath, dentries, infos)
+	log.LogDebugf("statAhead: dir(%v) dentries(%v) inodes(%v)", dirPath, len(dentries), len(infos))
+}
+
+func (c *client) putStatAhead(dirPath string, dentries []proto.Dentry, infos []*proto.InodeInfo) {
+	for _, info := range infos {
+		c.ic.Put(info)
+	}
+	for _, dentry := range dentries {
+		c.dc.Put(gopath.Join(dirPath, dentry.Name), dentry.Inode)
+	}
+}
+
 func (c *client) setattr(info *proto.InodeInfo, valid uint32, mode, uid, gid uint32, atime, mtime int64) error {
 	// Only rwx mode bit can be set
 	if valid&proto.AttrMode != 0 {
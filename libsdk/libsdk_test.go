@@ -0,0 +1,49 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatAheadCaches(t *testing.T) {
+	c := newClient()
+	defer removeClient(c.id)
+
+	dentries := []proto.Dentry{
+		{Name: "a", Inode: 10},
+		{Name: "b", Inode: 11},
+	}
+	infos := []*proto.InodeInfo{{Inode: 10, Size: 100}, {Inode: 11, Size: 200}}
+	c.putStatAhead("/dir", dentries, infos)
+
+	// the getattr of the entries needs no round trip to the metanodes, the meta wrapper is nil
+	info, err := c.lookupPath("/dir/a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), info.Size)
+	info, err = c.lookupPath("/dir//b/")
+	require.NoError(t, err)
+	require.Equal(t, uint64(200), info.Size)
+
+	// the dentries are cached even if their inodes are not returned by the batch
+	c.putStatAhead("/", []proto.Dentry{{Name: "c", Inode: 12}}, nil)
+	ino, ok := c.dc.Get("/c")
+	require.True(t, ok)
+	require.Equal(t, uint64(12), ino)
+	require.Nil(t, c.ic.Get(12))
+}
@@ -576,7 +576,7 @@ func parseBoolFieldToUpdateVol(r *http.Request, vol *Vol) (followerRead, authent
 	return
 }
 
-func parseRequestToSetApiQpsLimit(r *http.Request) (name string, limit uint32, timeout uint32, err error) {
+func parseRequestToSetApiQpsLimit(r *http.Request) (name string, limit uint32, timeout uint32, callerLimit uint32, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -589,6 +589,10 @@ func parseRequestToSetApiQpsLimit(r *http.Request) (name string, limit uint32, t
 		return
 	}
 
+	if callerLimit, err = extractUint32(r, CallerLimit); err != nil {
+		return
+	}
+
 	if timeout, err = extractUint32(r, TimeOut); err != nil {
 		return
 	}
@@ -600,6 +604,24 @@ func parseRequestToSetApiQpsLimit(r *http.Request) (name string, limit uint32, t
 	return
 }
 
+func parseRequestToSetApiCallerQuota(r *http.Request) (name string, caller string, limit uint32, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if name, err = extractName(r); err != nil {
+		return
+	}
+
+	if caller = r.FormValue(CallerKey); caller == "" {
+		err = keyNotFound(CallerKey)
+		return
+	}
+
+	limit, err = extractUint32(r, Limit)
+	return
+}
+
 func parseRequestToSetVolCapacity(r *http.Request) (name, authKey string, capacity int, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -673,6 +695,49 @@ type createVolReq struct {
 	clientReqPeriod, clientHitTriggerCnt uint32
 	// cold vol args
 	coldArgs coldVolArgs
+	// meta partitions are forked from the clone source at the snapshot version cloneSrcVerSeq,
+	// and the versions of the clone start from cloneVerSeq
+	cloneSrc       *Vol
+	cloneSrcVerSeq uint64
+	cloneVerSeq    uint64
+}
+
+type cloneVolReq struct {
+	name        string
+	cloneName   string
+	owner       string
+	authKey     string
+	materialize bool
+}
+
+func parseRequestToCloneVol(r *http.Request, req *cloneVolReq) (err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if req.name, err = extractName(r); err != nil {
+		return
+	}
+
+	if req.cloneName = r.FormValue(cloneNameKey); req.cloneName == "" {
+		return keyNotFound(cloneNameKey)
+	}
+	if !volNameRegexp.MatchString(req.cloneName) {
+		return errors.New("cloneName can only be number and letters")
+	}
+
+	if req.authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+
+	if req.owner = r.FormValue(volOwnerKey); req.owner != "" && !ownerRegexp.MatchString(req.owner) {
+		return errors.New("owner can only be number and letters")
+	}
+
+	if req.materialize, err = extractBoolWithDefault(r, materializeKey, false); err != nil {
+		return
+	}
+	return
 }
 
 func checkCacheAction(action int) error {
@@ -844,6 +909,46 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 	return
 }
 
+// parseRequestToAdviseCreateVol parses the args of the volume to create which affect the placement.
+func parseRequestToAdviseCreateVol(r *http.Request, req *createVolReq) (err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	if req.coldArgs, err = parseColdArgs(r); err != nil {
+		return
+	}
+
+	var parsedDpReplicaNum int
+	if parsedDpReplicaNum, err = extractUint(r, replicaNumKey); err != nil {
+		return
+	}
+	if parsedDpReplicaNum < 0 || parsedDpReplicaNum > math.MaxUint8 {
+		return fmt.Errorf("invalid arg dpReplicaNum: %v", parsedDpReplicaNum)
+	}
+	req.dpReplicaNum = uint8(parsedDpReplicaNum)
+
+	if req.capacity, err = extractUint(r, volCapacityKey); err != nil {
+		return
+	}
+
+	if req.volType, err = extractUint(r, volTypeKey); err != nil {
+		return
+	}
+
+	if req.crossZone, err = extractBoolWithDefault(r, crossZoneKey, false); err != nil {
+		return
+	}
+
+	if req.normalZonesFirst, err = extractBoolWithDefault(r, normalZonesFirstKey, false); err != nil {
+		return
+	}
+
+	req.zoneName = extractStr(r, zoneNameKey)
+	req.domainId, err = extractUint64WithDefault(r, domainIdKey, 0)
+	return
+}
+
 func parseRequestToCreateDataPartition(r *http.Request) (count int, name string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1022,6 +1127,28 @@ func parseAndExtractStatus(r *http.Request) (status bool, err error) {
 	return extractStatus(r)
 }
 
+func parseRequestToSetMetaDurability(r *http.Request, vol *Vol) (durability string, delayMs uint32, err error) {
+	durability = extractStrWithDefault(r, durabilityKey, vol.MetaDurability)
+	if durability == "default" {
+		// fall back to the durability of the metanode config
+		durability = proto.MetaDurabilityDefault
+	}
+	if !proto.IsValidMetaDurability(durability) {
+		err = fmt.Errorf("invalid %v %v", durabilityKey, durability)
+		return
+	}
+	var delay uint64
+	if delay, err = extractUint64WithDefault(r, groupCommitDelayMsKey, uint64(vol.MetaGroupCommitDelayMs)); err != nil {
+		return
+	}
+	if delay > maxMetaGroupCommitDelayMs {
+		err = fmt.Errorf("%v should not be larger than %v", groupCommitDelayMsKey, maxMetaGroupCommitDelayMs)
+		return
+	}
+	delayMs = uint32(delay)
+	return
+}
+
 func parseAndExtractForbidden(r *http.Request) (forbidden bool, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1397,6 +1524,71 @@ func parseQosInfo(r *http.Request) (info *proto.ClientReportLimitInfo, err error
 	return
 }
 
+func parseClientErrorReport(r *http.Request) (report *proto.ClientErrorReport, err error) {
+	var body []byte
+	if body, err = io.ReadAll(r.Body); err != nil {
+		return
+	}
+	report = &proto.ClientErrorReport{}
+	if err = json.Unmarshal(body, report); err != nil {
+		return
+	}
+	if report.VolName == "" {
+		err = keyNotFound(nameKey)
+	}
+	return
+}
+
+func parseRequestToSetMount(r *http.Request) (prefix, volName, subDir string, err error) {
+	if prefix, err = parseRequestToRemoveMount(r); err != nil {
+		return
+	}
+	if volName = r.FormValue(nameKey); volName == "" {
+		err = keyNotFound(nameKey)
+		return
+	}
+	subDir = r.FormValue(subDirKey)
+	return
+}
+
+func parseRequestToSetCapability(r *http.Request) (name string, enable bool, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name = r.FormValue(nameKey); name == "" {
+		err = keyNotFound(nameKey)
+		return
+	}
+	enable, err = extractStatus(r)
+	return
+}
+
+func parseRequestToNegotiateCapabilities(r *http.Request) (volName string, requested []string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName = r.FormValue(nameKey); volName == "" {
+		err = keyNotFound(nameKey)
+		return
+	}
+	for _, name := range strings.Split(r.FormValue(capabilitiesKey), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			requested = append(requested, name)
+		}
+	}
+	return
+}
+
+func parseRequestToRemoveMount(r *http.Request) (prefix string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if prefix = r.FormValue(mountPrefixKey); prefix == "" {
+		err = keyNotFound(mountPrefixKey)
+	}
+	return
+}
+
 func parseAndExtractName(r *http.Request) (name string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
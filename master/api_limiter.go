@@ -4,29 +4,98 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
+	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/time/rate"
 )
 
 const (
 	defaultApiLimitBurst = 1
+	// the least recently used caller limiters are evicted when the callers of an api exceed it
+	maxApiCallerLimiters = 10000
 )
 
 type ApiLimitInfo struct {
-	ApiName        string        `json:"api_name"`
-	QueryPath      string        `json:"query_path"`
-	Limit          uint32        `json:"limit"` // qps
-	LimiterTimeout uint32        `json:"limiter_timeout"`
-	Limiter        *rate.Limiter `json:"-"`
+	ApiName        string            `json:"api_name"`
+	QueryPath      string            `json:"query_path"`
+	Limit          uint32            `json:"limit"` // qps, 0 means no limit on the api
+	LimiterTimeout uint32            `json:"limiter_timeout"`
+	CallerLimit    uint32            `json:"caller_limit"`            // qps of each caller, 0 means no limit
+	CallerQuotas   map[string]uint32 `json:"caller_quotas,omitempty"` // caller --> qps, overrides the caller limit
+	Limiter        *rate.Limiter     `json:"-"`
+
+	callerLimiters *lru.Cache // caller -> *rate.Limiter
 }
 
 func (li *ApiLimitInfo) InitLimiter() {
-	li.Limiter = rate.NewLimiter(rate.Limit(li.Limit), defaultApiLimitBurst)
+	li.Limiter = nil
+	if li.Limit > 0 {
+		li.Limiter = rate.NewLimiter(rate.Limit(li.Limit), defaultApiLimitBurst)
+	}
+	li.callerLimiters, _ = lru.New(maxApiCallerLimiters)
+}
+
+func (li *ApiLimitInfo) callerQps(caller string) uint32 {
+	if qps, ok := li.CallerQuotas[caller]; ok {
+		return qps
+	}
+	return li.CallerLimit
+}
+
+// getCallerLimiter returns the limiter of the caller, nil if the caller is not limited.
+func (li *ApiLimitInfo) getCallerLimiter(caller string) *rate.Limiter {
+	qps := li.callerQps(caller)
+	if qps == 0 || caller == "" {
+		return nil
+	}
+
+	if limiter, ok := li.callerLimiters.Get(caller); ok {
+		return limiter.(*rate.Limiter)
+	}
+	limiter := rate.NewLimiter(rate.Limit(qps), defaultApiLimitBurst)
+	// the callers racing to add keep the earlier one
+	if exist, _ := li.callerLimiters.ContainsOrAdd(caller, limiter); exist {
+		if cur, ok := li.callerLimiters.Get(caller); ok {
+			return cur.(*rate.Limiter)
+		}
+	}
+	return limiter
+}
+
+// isHeartbeatApi returns true if the api is used by nodes to report heartbeats and tasks,
+// a limit shared by all the nodes on it is not allowed, or one node may starve the others.
+func isHeartbeatApi(qPath string) bool {
+	return qPath == proto.GetDataNodeTaskResponse || qPath == proto.GetMetaNodeTaskResponse ||
+		qPath == proto.GetLcNodeTaskResponse
+}
+
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// apiCaller returns the ip of the caller. The headers given by the clients can be spoofed, so
+// the caller is identified by the remote address, except that the requests proxied by the
+// master followers are identified by the last hop of X-Forwarded-For added by the follower.
+func apiCaller(r *http.Request, isMasterPeer func(ip string) bool) string {
+	ip := remoteIP(r)
+	if !isMasterPeer(ip) {
+		return ip
+	}
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	if hop := strings.TrimSpace(forwarded[len(forwarded)-1]); net.ParseIP(hop) != nil {
+		return hop
+	}
+	return ip
 }
 
 type ApiLimiter struct {
@@ -61,27 +130,79 @@ func (l *ApiLimiter) Replace(limiterInfos map[string]*ApiLimitInfo) {
 	}
 }
 
-func (l *ApiLimiter) SetLimiter(apiName string, Limit uint32, LimiterTimeout uint32) (err error) {
+func (l *ApiLimiter) SetLimiter(apiName string, Limit uint32, LimiterTimeout uint32, CallerLimit uint32) (err error) {
 	var normalizedName string
 	var qPath string
 	if err, normalizedName, qPath = l.IsApiNameValid(apiName); err != nil {
 		return err
 	}
+	if Limit == 0 && CallerLimit == 0 {
+		return fmt.Errorf("limit or caller limit of api [%v] must be larger than 0", apiName)
+	}
+	if Limit > 0 && isHeartbeatApi(qPath) {
+		return fmt.Errorf("api [%v] is used by heartbeat, only caller limit is allowed", apiName)
+	}
 
 	lInfo := &ApiLimitInfo{
 		ApiName:        normalizedName,
 		QueryPath:      qPath,
 		Limit:          Limit,
 		LimiterTimeout: LimiterTimeout,
+		CallerLimit:    CallerLimit,
 	}
 	lInfo.InitLimiter()
 
 	l.m.Lock()
+	// keep quotas of the callers
+	if old, ok := l.limiterInfos[qPath]; ok {
+		lInfo.CallerQuotas = old.CallerQuotas
+	}
 	l.limiterInfos[qPath] = lInfo
 	l.m.Unlock()
 	return nil
 }
 
+// SetCallerQuota sets the qps of the caller on the api, which overrides the caller limit of the api,
+// the quota of the caller is removed if the limit is 0.
+func (l *ApiLimiter) SetCallerQuota(apiName string, caller string, Limit uint32) (err error) {
+	var qPath string
+	if err, _, qPath = l.IsApiNameValid(apiName); err != nil {
+		return err
+	}
+	if caller == "" {
+		return fmt.Errorf("caller of api [%v] is empty", apiName)
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	old, ok := l.limiterInfos[qPath]
+	if !ok {
+		return fmt.Errorf("api [%v] has no limiter, set api qps limit first", apiName)
+	}
+	quotas := make(map[string]uint32, len(old.CallerQuotas)+1)
+	for k, v := range old.CallerQuotas {
+		quotas[k] = v
+	}
+	if Limit == 0 {
+		delete(quotas, caller)
+	} else {
+		quotas[caller] = Limit
+	}
+
+	lInfo := &ApiLimitInfo{
+		ApiName:        old.ApiName,
+		QueryPath:      old.QueryPath,
+		Limit:          old.Limit,
+		LimiterTimeout: old.LimiterTimeout,
+		CallerLimit:    old.CallerLimit,
+		CallerQuotas:   quotas,
+		Limiter:        old.Limiter,
+	}
+	lInfo.callerLimiters, _ = lru.New(maxApiCallerLimiters)
+	l.limiterInfos[qPath] = lInfo
+	return nil
+}
+
 func (l *ApiLimiter) RmLimiter(apiName string) (err error) {
 	var qPath string
 	if err, _, qPath = l.IsApiNameValid(apiName); err != nil {
@@ -94,7 +215,8 @@ func (l *ApiLimiter) RmLimiter(apiName string) (err error) {
 	return nil
 }
 
-func (l *ApiLimiter) Wait(qPath string) (err error) {
+// Wait waits for the limiter of the caller and then the limiter of the api.
+func (l *ApiLimiter) Wait(qPath string, caller string) (err error) {
 	var lInfo *ApiLimitInfo
 	var ok bool
 	l.m.RLock()
@@ -106,12 +228,19 @@ func (l *ApiLimiter) Wait(qPath string) (err error) {
 	l.m.RUnlock()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(lInfo.LimiterTimeout))
 	defer cancel()
-	err = lInfo.Limiter.Wait(ctx)
-	if err != nil {
-		log.LogErrorf("wait api limiter for api[%v] failed: %v", qPath, err)
-		return err
+	if limiter := lInfo.getCallerLimiter(caller); limiter != nil {
+		if err = limiter.Wait(ctx); err != nil {
+			log.LogErrorf("wait api limiter for api[%v] caller[%v] failed: %v", qPath, caller, err)
+			return err
+		}
+	}
+	if lInfo.Limiter != nil {
+		if err = lInfo.Limiter.Wait(ctx); err != nil {
+			log.LogErrorf("wait api limiter for api[%v] failed: %v", qPath, err)
+			return err
+		}
 	}
-	log.LogDebugf("wait api limiter for api[%v]", qPath)
+	log.LogDebugf("wait api limiter for api[%v] caller[%v]", qPath, caller)
 	return nil
 }
 
@@ -0,0 +1,60 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiCaller(t *testing.T) {
+	isMasterPeer := func(ip string) bool { return ip == "192.168.0.11" }
+	newRequest := func(remoteAddr, forwarded string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, proto.AdminGetCluster, nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set(proto.UserKey, "spoofed")
+		r.Header.Set("X-Real-Ip", "1.1.1.1")
+		if forwarded != "" {
+			r.Header.Set("X-Forwarded-For", forwarded)
+		}
+		return r
+	}
+
+	// the headers of the clients are ignored
+	assert.Equal(t, "192.168.0.100", apiCaller(newRequest("192.168.0.100:1234", "1.1.1.1"), isMasterPeer))
+	// the requests proxied by the followers take the client added by the follower
+	assert.Equal(t, "192.168.0.100", apiCaller(newRequest("192.168.0.11:1234", "1.1.1.1, 192.168.0.100"), isMasterPeer))
+	assert.Equal(t, "192.168.0.11", apiCaller(newRequest("192.168.0.11:1234", ""), isMasterPeer))
+}
+
+func TestApiCallerLimiters(t *testing.T) {
+	li := &ApiLimitInfo{CallerLimit: 1, CallerQuotas: map[string]uint32{"192.168.0.100": 10}}
+	li.InitLimiter()
+	assert.Nil(t, li.getCallerLimiter(""))
+	limiter := li.getCallerLimiter("192.168.0.100")
+	assert.EqualValues(t, 10, limiter.Limit())
+	assert.Equal(t, limiter, li.getCallerLimiter("192.168.0.100"))
+
+	// the limiters of the callers are bounded
+	for i := 0; i < 2*maxApiCallerLimiters; i++ {
+		assert.NotNil(t, li.getCallerLimiter(fmt.Sprintf("10.0.%v.%v", i/256, i%256)))
+	}
+	assert.Equal(t, maxApiCallerLimiters, li.callerLimiters.Len())
+}
@@ -290,6 +290,275 @@ func (m *Server) setEnableAuditLogForVolume(w http.ResponseWriter, r *http.Reque
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set volume audit log to (%v) success", status)))
 }
 
+func (m *Server) setVolMetaDurability(w http.ResponseWriter, r *http.Request) {
+	var (
+		name       string
+		durability string
+		delayMs    uint32
+		vol        *Vol
+		err        error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetMetaDurability))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetMetaDurability, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if durability, delayMs, err = parseRequestToSetMetaDurability(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	oldDurability, oldDelayMs := vol.MetaDurability, vol.MetaGroupCommitDelayMs
+	vol.MetaDurability, vol.MetaGroupCommitDelayMs = durability, delayMs
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.MetaDurability, vol.MetaGroupCommitDelayMs = oldDurability, oldDelayMs
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolMetaDurability] vol[%v] durability[%v] groupCommitDelayMs[%v]", name, durability, delayMs)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] meta durability[%v] groupCommitDelayMs[%v] successfully", name, durability, delayMs)))
+}
+
+func (m *Server) setVolVerifyReadCrc(w http.ResponseWriter, r *http.Request) {
+	var (
+		status bool
+		name   string
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetVerifyReadCrc))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetVerifyReadCrc, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if status, err = parseAndExtractStatus(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	vol, err := m.cluster.getVol(name)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldStatus := vol.VerifyReadCrc
+	vol.VerifyReadCrc = status
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.VerifyReadCrc = oldStatus
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolVerifyReadCrc] vol[%v] verifyReadCrc[%v]", name, status)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] verifyReadCrc to (%v) successfully", name, status)))
+}
+
+func (m *Server) setVolWriteBurstCredit(w http.ResponseWriter, r *http.Request) {
+	var (
+		name        string
+		burstCredit uint64
+		vol         *Vol
+		err         error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetWriteBurstCredit))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetWriteBurstCredit, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if burstCredit, err = extractUint64(r, burstCreditKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldBurstCredit := vol.WriteBurstCredit
+	vol.WriteBurstCredit = burstCredit
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.WriteBurstCredit = oldBurstCredit
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolWriteBurstCredit] vol[%v] burstCredit[%v]", name, burstCredit)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] write burstCredit[%v] successfully", name, burstCredit)))
+}
+
+func (m *Server) setVolMetaOpLimit(w http.ResponseWriter, r *http.Request) {
+	var (
+		name        string
+		metaOpLimit uint64
+		metaOpBurst uint64
+		vol         *Vol
+		err         error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetMetaOpLimit))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetMetaOpLimit, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if metaOpLimit, err = extractUint64(r, metaOpLimitKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if metaOpBurst, err = extractUint64(r, metaOpBurstKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if metaOpBurst == 0 {
+		metaOpBurst = metaOpLimit
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldLimit, oldBurst := vol.MetaOpLimit, vol.MetaOpBurst
+	vol.MetaOpLimit, vol.MetaOpBurst = metaOpLimit, metaOpBurst
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.MetaOpLimit, vol.MetaOpBurst = oldLimit, oldBurst
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolMetaOpLimit] vol[%v] metaOpLimit[%v] metaOpBurst[%v]", name, metaOpLimit, metaOpBurst)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] metaOpLimit[%v] metaOpBurst[%v] successfully",
+		name, metaOpLimit, metaOpBurst)))
+}
+
+func (m *Server) setVolExtentSizeClass(w http.ResponseWriter, r *http.Request) {
+	var (
+		name  string
+		class string
+		vol   *Vol
+		err   error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetExtentSizeClass))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetExtentSizeClass, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if class = r.FormValue(extentSizeClassKey); class == "default" {
+		class = proto.ExtentSizeClassDefault
+	}
+	if _, ok := proto.ExtentSizeOfClass(class); !ok {
+		err = fmt.Errorf("invalid %v %v", extentSizeClassKey, class)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldClass := vol.ExtentSizeClass
+	vol.ExtentSizeClass = class
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.ExtentSizeClass = oldClass
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolExtentSizeClass] vol[%v] extentSizeClass[%v]", name, class)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] extentSizeClass[%v] successfully", name, class)))
+}
+
+func (m *Server) setVolFullReadOnlyRatio(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		value    string
+		ratioVal float64
+		vol      *Vol
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetFullReadOnlyRatio))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetFullReadOnlyRatio, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value = r.FormValue(ratio); value == "" {
+		err = keyNotFound(ratio)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ratioVal, err = strconv.ParseFloat(value, 64); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	// 0 disables the transitions, a ratio too low leaves no room to recover
+	if ratioVal != 0 && (ratioVal <= volFullReadOnlyRecoverGap || ratioVal > 1) {
+		err = fmt.Errorf("invalid %v %v, must be 0 or in (%v, 1]", ratio, value, volFullReadOnlyRecoverGap)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	vol.volLock.Lock()
+	oldRatio := vol.FullReadOnlyRatio
+	vol.FullReadOnlyRatio = ratioVal
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.FullReadOnlyRatio = oldRatio
+		vol.volLock.Unlock()
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	vol.volLock.Unlock()
+	// apply the new ratio at once rather than at the next check of the volume status
+	vol.checkFullReadOnly(m.cluster)
+	log.LogInfof("action[setVolFullReadOnlyRatio] vol[%v] ratio[%v]", name, ratioVal)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] fullReadOnlyRatio[%v] successfully", name, ratioVal)))
+}
+
+func (m *Server) setVolLabelSelector(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		selector proto.LabelSelector
+		vol      *Vol
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolSetLabelSelector))
+	defer func() {
+		doStatAndMetric(proto.AdminVolSetLabelSelector, metric, err, map[string]string{exporter.Vol: name})
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if selector, err = proto.ParseLabelSelector(r.FormValue(labelSelectorKey)); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldSelector := vol.LabelSelector
+	vol.LabelSelector = selector.String()
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		vol.LabelSelector = oldSelector
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	log.LogInfof("action[setVolLabelSelector] vol[%v] labelSelector[%v->%v]", name, oldSelector, vol.LabelSelector)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set vol[%v] labelSelector to (%v) successfully", name, vol.LabelSelector)))
+}
+
 func (m *Server) setupForbidMetaPartitionDecommission(w http.ResponseWriter, r *http.Request) {
 	var (
 		status bool
@@ -795,22 +1064,23 @@ func (m *Server) getApiList(w http.ResponseWriter, r *http.Request) {
 
 func (m *Server) setApiQpsLimit(w http.ResponseWriter, r *http.Request) {
 	var (
-		name    string
-		limit   uint32
-		timeout uint32
-		err     error
+		name        string
+		limit       uint32
+		timeout     uint32
+		callerLimit uint32
+		err         error
 	)
 	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetApiQpsLimit))
 	defer func() {
 		doStatAndMetric(proto.AdminSetApiQpsLimit, metric, err, nil)
 	}()
 
-	if name, limit, timeout, err = parseRequestToSetApiQpsLimit(r); err != nil {
+	if name, limit, timeout, callerLimit, err = parseRequestToSetApiQpsLimit(r); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
 
-	if err = m.cluster.apiLimiter.SetLimiter(name, limit, timeout); err != nil {
+	if err = m.cluster.apiLimiter.SetLimiter(name, limit, timeout, callerLimit); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -825,11 +1095,47 @@ func (m *Server) setApiQpsLimit(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(fmt.Errorf("set api qps limit failed: %v", err)))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set api qps limit success: name: %v, limit: %v, timeout: %v",
-		name, limit, timeout)))
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set api qps limit success: name: %v, limit: %v, timeout: %v, callerLimit: %v",
+		name, limit, timeout, callerLimit)))
 	return
 }
 
+func (m *Server) setApiCallerQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		caller string
+		limit  uint32
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetApiCallerQuota))
+	defer func() {
+		doStatAndMetric(proto.AdminSetApiCallerQuota, metric, err, nil)
+	}()
+
+	if name, caller, limit, err = parseRequestToSetApiCallerQuota(r); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	if err = m.cluster.apiLimiter.SetCallerQuota(name, caller, limit); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	// persist to rocksdb
+	var qPath string
+	if err, _, qPath = m.cluster.apiLimiter.IsApiNameValid(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.cluster.syncPutApiLimiterInfo(m.cluster.apiLimiter.IsFollowerLimiter(qPath)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(fmt.Errorf("set api caller quota failed: %v", err)))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set api caller quota success: name: %v, caller: %v, limit: %v",
+		name, caller, limit)))
+}
+
 func (m *Server) getApiQpsLimit(w http.ResponseWriter, r *http.Request) {
 	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetMasterApiList))
 	defer func() {
@@ -1675,7 +1981,7 @@ func (m *Server) addMetaReplica(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = m.cluster.addMetaReplica(mp, addr); err != nil {
+	if err = m.cluster.addMetaReplica(mp, addr, false); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -2032,6 +2338,7 @@ func (m *Server) markDeleteVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventVolume, name, "")
 	msg = fmt.Sprintf("delete vol[%v] successfully,from[%v]", name, r.RemoteAddr)
 	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
@@ -2054,8 +2361,12 @@ func (m *Server) checkReplicaNum(r *http.Request, vol *Vol, req *updateVolReq) (
 	}
 	req.replicaNum = replicaNum
 	if replicaNum != 0 && replicaNum != int(vol.dpReplicaNum) {
-		if replicaNum != int(vol.dpReplicaNum)-1 {
-			err = fmt.Errorf("replicaNum only need be reduced one replica one time")
+		if replicaNum != int(vol.dpReplicaNum)-1 && replicaNum != int(vol.dpReplicaNum)+1 {
+			err = fmt.Errorf("replicaNum only need be changed one replica one time")
+			return
+		}
+		if replicaNum > int(vol.dpReplicaNum) && replicaNum != defaultReplicaNum {
+			err = fmt.Errorf("replicaNum can only be raised to %v", defaultReplicaNum)
 			return
 		}
 		if !proto.IsHot(vol.VolType) {
@@ -2149,6 +2460,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventVolume, req.name, "")
 
 	var response string
 	if hasTxParams(r) {
@@ -2366,17 +2678,104 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.createVol(req); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	if vol, err = m.cluster.createVol(req); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	if err = m.associateVolWithUser(req.owner, req.name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.bucketEvents.record(proto.BucketEventVolume, req.name, req.owner)
+
+	msg := fmt.Sprintf("create vol[%v] successfully, has allocate [%v] data partitions", req.name, len(vol.dataPartitions.partitions))
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// adviseCreateVol reports whether the cluster can host the volume and where it would be placed.
+func (m *Server) adviseCreateVol(w http.ResponseWriter, r *http.Request) {
+	req := &createVolReq{}
+
+	var err error
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminAdviseCreateVol))
+	defer func() {
+		doStatAndMetric(proto.AdminAdviseCreateVol, metric, err, nil)
+	}()
+
+	if err = parseRequestToAdviseCreateVol(r, req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if err = m.checkCreateReq(req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.adviseVolCreation(req)))
+}
+
+func (m *Server) cloneVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		req = &cloneVolReq{}
+		vol *Vol
+		err error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminCloneVol))
+	defer func() {
+		doStatAndMetric(proto.AdminCloneVol, metric, err, map[string]string{exporter.Vol: req.name})
+	}()
+
+	if err = parseRequestToCloneVol(r, req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.cloneVol(req); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	if err = m.associateVolWithUser(vol.Owner, vol.Name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.bucketEvents.record(proto.BucketEventVolume, vol.Name, vol.Owner)
+
+	msg := fmt.Sprintf("clone vol[%v] to [%v] successfully, source verSeq[%v]", req.name, req.cloneName, vol.getCloneInfo().SrcVerSeq)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) detachVolClone(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		err     error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminDetachVolClone))
+	defer func() {
+		doStatAndMetric(proto.AdminDetachVolClone, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
 
-	if err = m.associateVolWithUser(req.owner, req.name); err != nil {
+	if err = m.cluster.detachVolClone(name, authKey); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
 
-	msg := fmt.Sprintf("create vol[%v] successfully, has allocate [%v] data partitions", req.name, len(vol.dataPartitions.partitions))
+	msg := fmt.Sprintf("detach clone vol[%v] from source successfully", name)
+	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
@@ -2521,6 +2920,17 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		LatestVer:               vol.VersionMgr.getLatestVer(),
 		Forbidden:               vol.Forbidden,
 		EnableAuditLog:          vol.EnableAuditLog,
+		CloneInfo:               vol.getCloneInfo(),
+		MetaDurability:          vol.MetaDurability,
+		MetaGroupCommitDelayMs:  vol.MetaGroupCommitDelayMs,
+		VerifyReadCrc:           vol.VerifyReadCrc,
+		LabelSelector:           vol.LabelSelector,
+		WriteBurstCredit:        vol.WriteBurstCredit,
+		MetaOpLimit:             vol.MetaOpLimit,
+		MetaOpBurst:             vol.MetaOpBurst,
+		ExtentSizeClass:         vol.ExtentSizeClass,
+		FullReadOnlyRatio:       vol.FullReadOnlyRatio,
+		FullReadOnly:            vol.FullReadOnly,
 	}
 
 	vol.uidSpaceManager.RLock()
@@ -2642,6 +3052,8 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		MaxDpCntLimit:             dataNode.GetDpCntLimit(),
 		CpuUtil:                   dataNode.CpuUtil.Load(),
 		IoUtils:                   dataNode.GetIoUtils(),
+		Labels:                    dataNode.Labels,
+		SuspectRegions:            dataNode.SuspectRegions,
 	}
 
 	sendOkReply(w, r, newSuccessHTTPReply(dataNodeInfo))
@@ -3359,6 +3771,52 @@ func (m *Server) setNodeRdOnlyHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func (m *Server) setNodeLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		addr     string
+		nodeType uint32
+		labels   map[string]string
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetNodeLabels))
+	defer func() {
+		doStatAndMetric(proto.AdminSetNodeLabels, metric, err, nil)
+	}()
+
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if addr = r.FormValue(addrKey); addr == "" {
+		err = keyNotFound(addrKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if nodeType, err = parseNodeType(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	// the empty labels clear the labels of the node
+	if labels, err = proto.ParseNodeLabels(r.FormValue(labelsKey)); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if err = m.cluster.setNodeLabels(addr, nodeType, labels); err != nil {
+		log.LogErrorf("[setNodeLabelsHandler] set node %s labels %v, err (%s)", addr, labels, err.Error())
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	log.LogInfof("[setNodeLabelsHandler] set node %s labels(%v)", addr, proto.FormatNodeLabels(labels))
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("[setNodeLabelsHandler] set node %s labels(%v) success",
+		addr, proto.FormatNodeLabels(labels))))
+}
+
+func (m *Server) getPlacementViolations(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getPlacementViolations()))
+}
+
 func (m *Server) setDpRdOnlyHandler(w http.ResponseWriter, r *http.Request) {
 	var (
 		dpId   uint64
@@ -3554,6 +4012,87 @@ func (m *Server) updateNodeSetNodeSelector(w http.ResponseWriter, r *http.Reques
 	sendOkReply(w, r, newSuccessHTTPReply("update nodeset selector successfully"))
 }
 
+// simulatePlacement replays the placement of partitions with the node selectors on the topology of
+// the node set, or on the snapshot in the body exported before, so the policies could be compared.
+func (m *Server) simulatePlacement(w http.ResponseWriter, r *http.Request) {
+	var (
+		snapshot   *proto.PlacementSnapshot
+		body       []byte
+		partitions int
+		replicaNum int
+		err        error
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSimulatePlacement))
+	defer func() {
+		doStatAndMetric(proto.AdminSimulatePlacement, metric, err, nil)
+
+		if err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		}
+	}()
+
+	if body, err = io.ReadAll(r.Body); err != nil {
+		return
+	}
+	if len(body) > 0 {
+		snapshot = &proto.PlacementSnapshot{}
+		if err = json.Unmarshal(body, snapshot); err != nil {
+			return
+		}
+		if snapshot.NodeType != TypeDataPartition && snapshot.NodeType != TypeMetaPartition {
+			err = fmt.Errorf("snapshot nodeType %d is not legal, must be %d or %d", snapshot.NodeType, TypeDataPartition, TypeMetaPartition)
+			return
+		}
+	} else {
+		var (
+			id       uint64
+			nodeType uint32
+			ns       *nodeSet
+		)
+		value := r.FormValue(nodesetIdKey)
+		if value == "" {
+			err = keyNotFound(nodesetIdKey)
+			return
+		}
+		if id, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(nodesetIdKey)
+			return
+		}
+		if nodeType, err = parseNodeType(r); err != nil {
+			return
+		}
+		if ns, err = m.cluster.t.getNodeSetByNodeSetId(id); err != nil {
+			err = nodeSetNotFound(id)
+			return
+		}
+		snapshot = ns.placementSnapshot(nodeType)
+	}
+
+	if value := r.FormValue(countKey); value != "" {
+		if partitions, err = strconv.Atoi(value); err != nil {
+			err = unmatchedKey(countKey)
+			return
+		}
+	}
+	if value := r.FormValue(replicaNumKey); value != "" {
+		if replicaNum, err = strconv.Atoi(value); err != nil {
+			err = unmatchedKey(replicaNumKey)
+			return
+		}
+	}
+	policies := placementPolicies
+	if value := r.FormValue(placementPoliciesKey); value != "" {
+		policies = strings.Split(value, ",")
+	}
+
+	report, err := newPlacementSimulator(snapshot, partitions, replicaNum, 0).run(policies)
+	if err != nil {
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(report))
+}
+
 // get metanode some interval params
 func (m *Server) getNodeSetGrpInfoHandler(w http.ResponseWriter, r *http.Request) {
 	var err error
@@ -4187,6 +4726,7 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		NodeSetID:                 metaNode.NodeSetID,
 		PersistenceMetaPartitions: metaNode.PersistenceMetaPartitions,
 		CpuUtil:                   metaNode.CpuUtil.Load(),
+		Labels:                    metaNode.Labels,
 	}
 	sendOkReply(w, r, newSuccessHTTPReply(metaNodeInfo))
 }
@@ -4756,6 +5296,31 @@ func (m *Server) getVolStatInfo(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(volStat(vol, byMeta)))
 }
 
+func (m *Server) getVolClientState(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		name string
+		vol  *Vol
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.ClientVolState))
+	defer func() {
+		doStatAndMetric(proto.ClientVolState, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	vol.volLock.RLock()
+	state := &proto.VolClientState{Name: vol.Name, FullReadOnly: vol.FullReadOnly}
+	vol.volLock.RUnlock()
+	sendOkReply(w, r, newSuccessHTTPReply(state))
+}
+
 func volStat(vol *Vol, countByMeta bool) (stat *proto.VolStatInfo) {
 	stat = new(proto.VolStatInfo)
 	stat.Name = vol.Name
@@ -5503,6 +6068,206 @@ func (m *Server) setCheckDataReplicasEnable(w http.ResponseWriter, r *http.Reque
 		"set checkDataReplicasEnable to [%v] successfully", enable)))
 }
 
+func (m *Server) setDataPartitionSlo(w http.ResponseWriter, r *http.Request) {
+	var (
+		err            error
+		enable         bool
+		latencyMs      uint64
+		violationLimit int
+	)
+
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if enable, err = extractBoolWithDefault(r, enableKey, m.cluster.dpSloEnable); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if latencyMs, err = extractUint64WithDefault(r, latencyMsKey, m.cluster.dpSloLatencyMs); err != nil || latencyMs == 0 {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: fmt.Sprintf("invalid %v, err %v", latencyMsKey, err)})
+		return
+	}
+	if violationLimit, err = extractUintWithDefault(r, violationLimitKey, m.cluster.dpSloViolationLimit); err != nil || violationLimit == 0 {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: fmt.Sprintf("invalid %v, err %v", violationLimitKey, err)})
+		return
+	}
+
+	oldEnable := m.cluster.dpSloEnable
+	oldLatencyMs := m.cluster.dpSloLatencyMs
+	oldViolationLimit := m.cluster.dpSloViolationLimit
+	m.cluster.dpSloEnable = enable
+	m.cluster.dpSloLatencyMs = latencyMs
+	m.cluster.dpSloViolationLimit = violationLimit
+	if err = m.cluster.syncPutCluster(); err != nil {
+		m.cluster.dpSloEnable = oldEnable
+		m.cluster.dpSloLatencyMs = oldLatencyMs
+		m.cluster.dpSloViolationLimit = oldViolationLimit
+		log.LogErrorf("action[setDataPartitionSlo] syncPutCluster failed %v", err)
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrPersistenceByRaft))
+		return
+	}
+	// the windows judged by the old slo are discarded
+	if !enable || latencyMs != oldLatencyMs {
+		m.cluster.dpSloTracker.reset()
+	}
+
+	log.LogInfof("action[setDataPartitionSlo] enable[%v] latencyMs[%v] violationLimit[%v]", enable, latencyMs, violationLimit)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf(
+		"set data partition slo enable[%v] latencyMs[%v] violationLimit[%v] successfully", enable, latencyMs, violationLimit)))
+}
+
+func (m *Server) getDataPartitionSlo(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getDataPartitionSloInfo()))
+}
+
+func (m *Server) reportClientErrors(w http.ResponseWriter, r *http.Request) {
+	var (
+		report *proto.ClientErrorReport
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.ClientReportErrors))
+	defer func() {
+		doStatAndMetric(proto.ClientReportErrors, metric, err, nil)
+	}()
+
+	if report, err = parseClientErrorReport(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(report.VolName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	if report.Client == "" {
+		report.Client = iputil.RealIP(r)
+	}
+	m.cluster.reportClientErrors(report)
+	sendOkReply(w, r, newSuccessHTTPReply(nil))
+}
+
+func (m *Server) setMount(w http.ResponseWriter, r *http.Request) {
+	var (
+		prefix  string
+		volName string
+		subDir  string
+		err     error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetMount))
+	defer func() {
+		doStatAndMetric(proto.AdminSetMount, metric, err, map[string]string{exporter.Vol: volName})
+	}()
+
+	if prefix, volName, subDir, err = parseRequestToSetMount(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setMount(prefix, volName, subDir); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("mount vol[%v] subDir[%v] at prefix[%v] successfully", volName, subDir, prefix)))
+}
+
+func (m *Server) removeMount(w http.ResponseWriter, r *http.Request) {
+	var (
+		prefix string
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminRemoveMount))
+	defer func() {
+		doStatAndMetric(proto.AdminRemoveMount, metric, err, nil)
+	}()
+
+	if prefix, err = parseRequestToRemoveMount(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.removeMount(prefix); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("remove mount prefix[%v] successfully", prefix)))
+}
+
+func (m *Server) getMountMap(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.mountMap.view()))
+}
+
+func (m *Server) setCapability(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		enable bool
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetCapability))
+	defer func() {
+		doStatAndMetric(proto.AdminSetCapability, metric, err, nil)
+	}()
+
+	if name, enable, err = parseRequestToSetCapability(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setCapability(name, enable); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set capability[%v] enable[%v] successfully", name, enable)))
+}
+
+func (m *Server) getCapabilities(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getCapabilities()))
+}
+
+// negotiateCapabilities returns the capabilities requested by the client at mount which are
+// enabled for it.
+func (m *Server) negotiateCapabilities(w http.ResponseWriter, r *http.Request) {
+	var (
+		volName   string
+		requested []string
+		err       error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.ClientCapabilities))
+	defer func() {
+		doStatAndMetric(proto.ClientCapabilities, metric, err, map[string]string{exporter.Vol: volName})
+	}()
+
+	if volName, requested, err = parseRequestToNegotiateCapabilities(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(volName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	n := m.cluster.negotiateCapabilities(requested)
+	log.LogInfof("action[negotiateCapabilities] vol[%v] client[%v] requested[%v] enabled[%v] disabled[%v] unsupported[%v]",
+		volName, iputil.RealIP(r), requested, n.Enabled, n.Disabled, n.Unsupported)
+	sendOkReply(w, r, newSuccessHTTPReply(n))
+}
+
+func (m *Server) getVolClientHealth(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		err  error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetVolClientHealth))
+	defer func() {
+		doStatAndMetric(proto.AdminGetVolClientHealth, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getVolClientHealth(name)))
+}
+
 func (m *Server) setFileStats(w http.ResponseWriter, r *http.Request) {
 	var (
 		err    error
@@ -6117,6 +6882,48 @@ func (m *Server) DelBucketLifecycle(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+// listBucketEvents returns the bucket events after the polled epoch and seq,
+// objectnodes invalidate their bucket and user caches by them.
+func (m *Server) listBucketEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		epoch int64
+		seq   uint64
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if epoch, err = extractInt64WithDefault(r, epochKey, 0); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if seq, err = extractUint64WithDefault(r, seqKey, 0); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.bucketEvents.since(epoch, seq)))
+}
+
+// reportBucketEvent records the change of the bucket configs stored by an objectnode,
+// so that the other objectnodes reload them.
+func (m *Server) reportBucketEvent(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		name string
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	m.bucketEvents.record(proto.BucketEventMeta, name, "")
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("report vol[%v] bucket event successfully", name)))
+}
+
 func (m *Server) lcnodeInfo(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
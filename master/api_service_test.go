@@ -30,6 +30,7 @@ import (
 
 	"github.com/cubefs/cubefs/master/mocktest"
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/log"
@@ -652,6 +653,29 @@ func TestCreateVol(t *testing.T) {
 	}
 }
 
+func TestAdviseCreateVol(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?capacity=100&replicaNum=3&zoneName=%v", hostAddr, proto.AdminAdviseCreateVol, testZone2)
+	process(reqURL, t)
+
+	req := &createVolReq{capacity: 100, dpReplicaNum: 3, zoneName: testZone2}
+	advice := server.cluster.adviseVolCreation(req)
+	require.True(t, advice.Feasible, advice.Reasons)
+	require.Equal(t, uint64(100*util.GB*3), advice.RequiredSpace)
+	require.Len(t, advice.Zones, 1)
+	require.Equal(t, testZone2, advice.Zones[0].Name)
+	require.NotEmpty(t, advice.Zones[0].NodeSets)
+	require.True(t, advice.PostUsedRatio > advice.UsedRatio)
+
+	req.capacity = int(advice.DataAvailable/util.GB) + 1
+	advice = server.cluster.adviseVolCreation(req)
+	require.False(t, advice.Feasible)
+	require.Len(t, advice.Reasons, 1)
+
+	req.zoneName = "not_exist_zone"
+	advice = server.cluster.adviseVolCreation(req)
+	require.False(t, advice.Feasible)
+}
+
 func TestCreateMetaPartition(t *testing.T) {
 	server.cluster.checkMetaNodeHeartbeat()
 	time.Sleep(5 * time.Second)
@@ -722,6 +746,33 @@ func TestGetDataPartitions(t *testing.T) {
 	process(reqURL, t)
 }
 
+func TestMountMap(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?prefix=%v&name=%v&subDir=%v", hostAddr, proto.AdminSetMount, "/datasets/", commonVolName, "/train")
+	process(reqURL, t)
+	view := server.cluster.mountMap.view()
+	entry, volPath, ok := view.Resolve("/datasets/n01/a.jpg")
+	if !ok || entry.VolName != commonVolName || volPath != "/train/n01/a.jpg" {
+		t.Errorf("expect /datasets mounted from %v/train, but is %v %v", commonVolName, entry, volPath)
+		return
+	}
+	if err := server.cluster.setMount("/datasets", "notExistVol", ""); err != proto.ErrVolNotExists {
+		t.Errorf("expect err %v, but is %v", proto.ErrVolNotExists, err)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.ClientMountMap)
+	process(reqURL, t)
+
+	reqURL = fmt.Sprintf("%v%v?prefix=%v", hostAddr, proto.AdminRemoveMount, "/datasets")
+	process(reqURL, t)
+	if view = server.cluster.mountMap.view(); len(view.Entries) != 0 || view.Version != 2 {
+		t.Errorf("expect empty mount map of version 2, but is %v", view)
+		return
+	}
+	if err := server.cluster.removeMount("/datasets"); err == nil {
+		t.Errorf("expect err of removing not exists prefix")
+	}
+}
+
 func TestGetDataPartitionsNotCompress(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?name=%v", hostAddr, proto.ClientDataPartitions, commonVolName)
 	processCompression(reqURL, false, t)
@@ -1127,6 +1178,41 @@ func TestRemovePolicy(t *testing.T) {
 	}
 }
 
+func TestUserPosixIdentity(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserSetPosix)
+	param := &proto.UserPosixIdentityParam{UserID: testUserID, Posix: proto.PosixIdentity{Uid: 1001, Gid: 1001, Groups: []uint32{2001}}}
+	data, err := json.Marshal(param)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	userInfo, err := server.user.getUserInfoByUid(1001)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if userInfo.UserID != testUserID || !userInfo.Posix.InGroup(2001) {
+		t.Errorf("expect uid 1001 mapped to %v with group 2001, but is %v %v", testUserID, userInfo.UserID, userInfo.Posix)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?uid=%v", hostAddr, proto.UserGetUidInfo, 1001)
+	process(reqURL, t)
+
+	// the uid is owned by testUserID and cannot be mapped to another user
+	if _, err = server.user.setPosixIdentity(&proto.UserPosixIdentityParam{UserID: "cfs", Posix: param.Posix}); err != proto.ErrDuplicatePosixUid {
+		t.Errorf("expect err %v, but is %v", proto.ErrDuplicatePosixUid, err)
+		return
+	}
+
+	reqURL = fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserRemovePosix, testUserID)
+	process(reqURL, t)
+	if _, err = server.user.getUserInfoByUid(1001); err != proto.ErrPosixIdentityNotExists {
+		t.Errorf("expect err %v, but is %v", proto.ErrPosixIdentityNotExists, err)
+		return
+	}
+}
+
 func TestTransferVol(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserTransferVol)
 	param := &proto.UserTransferVolParam{Volume: commonVolName, UserSrc: "cfs", UserDst: testUserID, Force: false}
@@ -1479,3 +1565,191 @@ func TestVolumeEnableAuditLog(t *testing.T) {
 	require.True(t, vol.EnableAuditLog)
 	require.True(t, checkVolAuditLog(name, true))
 }
+
+func TestVolumeVerifyReadCrc(t *testing.T) {
+	name := "verifyReadCrcVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetVerifyReadCrc)
+	process(fmt.Sprintf("%v?name=%v&%v=true", reqUrl, vol.Name, enableKey), t)
+	require.True(t, vol.VerifyReadCrc)
+	process(fmt.Sprintf("%v?name=%v&%v=false", reqUrl, vol.Name, enableKey), t)
+	require.False(t, vol.VerifyReadCrc)
+}
+
+func TestVolumeWriteBurstCredit(t *testing.T) {
+	name := "writeBurstCreditVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetWriteBurstCredit)
+	process(fmt.Sprintf("%v?name=%v&%v=4096", reqUrl, vol.Name, burstCreditKey), t)
+	require.Equal(t, uint64(4096), vol.WriteBurstCredit)
+	process(fmt.Sprintf("%v?name=%v", reqUrl, vol.Name), t)
+	require.Equal(t, uint64(0), vol.WriteBurstCredit)
+
+	// the credits are assigned to the clients by their shares of the write flow limit
+	qosManager := &QosCtrlManager{
+		qosEnable: true,
+		vol:       &Vol{WriteBurstCredit: 4096},
+		serverFactorLimitMap: map[uint32]*ServerFactorLimit{
+			proto.FlowWriteType: {Total: 1024},
+		},
+	}
+	limitRsp := proto.NewLimitRsp2Client()
+	limitRsp.FactorMap[proto.FlowWriteType] = &proto.ClientLimitInfo{UsedLimit: 256}
+	qosManager.assignWriteBurstCredit(limitRsp)
+	require.Equal(t, uint64(1024), limitRsp.FactorMap[proto.FlowWriteType].BurstCredit)
+	limitRsp.FactorMap[proto.FlowWriteType] = &proto.ClientLimitInfo{UsedLimit: 2048}
+	qosManager.assignWriteBurstCredit(limitRsp)
+	require.Equal(t, uint64(4096), limitRsp.FactorMap[proto.FlowWriteType].BurstCredit)
+	qosManager.qosEnable = false
+	limitRsp.FactorMap[proto.FlowWriteType] = &proto.ClientLimitInfo{UsedLimit: 256}
+	qosManager.assignWriteBurstCredit(limitRsp)
+	require.Equal(t, uint64(0), limitRsp.FactorMap[proto.FlowWriteType].BurstCredit)
+}
+
+func TestVolumeMetaOpLimit(t *testing.T) {
+	name := "metaOpLimitVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetMetaOpLimit)
+	process(fmt.Sprintf("%v?name=%v&%v=3000", reqUrl, vol.Name, metaOpLimitKey), t)
+	require.Equal(t, uint64(3000), vol.MetaOpLimit)
+	require.Equal(t, uint64(3000), vol.MetaOpBurst)
+	process(fmt.Sprintf("%v?name=%v", reqUrl, vol.Name), t)
+	require.Equal(t, uint64(0), vol.MetaOpLimit)
+	require.Nil(t, vol.metaOpLimitShares())
+
+	// the limit is split among the metanodes by their shares of the partition replicas
+	shareVol := newVol(volValue{ID: 1, Name: "shareVol", MetaOpLimit: 3000, MetaOpBurst: 600})
+	mp1 := newMetaPartition(1, 1, 100, 3, shareVol.Name, shareVol.ID, 0)
+	mp1.Hosts = []string{"a", "b", "c"}
+	mp2 := newMetaPartition(2, 101, 200, 3, shareVol.Name, shareVol.ID, 0)
+	mp2.Hosts = []string{"a", "b", "d"}
+	shareVol.MetaPartitions[mp1.PartitionID] = mp1
+	shareVol.MetaPartitions[mp2.PartitionID] = mp2
+	shares := shareVol.metaOpLimitShares()
+	require.Len(t, shares, 4)
+	require.Equal(t, &proto.VolMetaOpLimit{Vol: shareVol.Name, Ops: 1000, Burst: 200}, shares["a"])
+	require.Equal(t, &proto.VolMetaOpLimit{Vol: shareVol.Name, Ops: 500, Burst: 100}, shares["c"])
+	var total float64
+	for _, share := range shares {
+		total += share.Ops
+	}
+	require.InDelta(t, 3000, total, 0.001)
+}
+
+func TestVolumeLabelSelector(t *testing.T) {
+	name := "labelSelectorVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetLabelSelector)
+	process(fmt.Sprintf("%v?name=%v&%v=rack=gpu,!deprecated", reqUrl, vol.Name, labelSelectorKey), t)
+	require.Equal(t, "rack=gpu,!deprecated", vol.LabelSelector)
+
+	// only the labeled data nodes are selected, the others are excluded
+	dataNode, err := server.cluster.dataNode(mds1Addr)
+	require.NoError(t, err)
+	nodeUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminSetNodeLabels)
+	process(fmt.Sprintf("%v?addr=%v&nodeType=%v&%v=rack=gpu", nodeUrl, mds1Addr, TypeDataPartition, labelsKey), t)
+	require.Equal(t, map[string]string{"rack": "gpu"}, dataNode.Labels)
+	excludeHosts := server.cluster.labelExcludeHosts(name, TypeDataPartition, nil)
+	require.NotContains(t, excludeHosts, mds1Addr)
+	require.Contains(t, excludeHosts, mds2Addr)
+
+	process(fmt.Sprintf("%v?addr=%v&nodeType=%v&%v=", nodeUrl, mds1Addr, TypeDataPartition, labelsKey), t)
+	require.Empty(t, dataNode.Labels)
+	process(fmt.Sprintf("%v?name=%v&%v=", reqUrl, vol.Name, labelSelectorKey), t)
+	require.Equal(t, "", vol.LabelSelector)
+	require.Empty(t, server.cluster.labelExcludeHosts(name, TypeDataPartition, nil))
+}
+
+func TestVolumeExtentSizeClass(t *testing.T) {
+	name := "extentSizeClassVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetExtentSizeClass)
+	process(fmt.Sprintf("%v?name=%v&%v=%v", reqUrl, vol.Name, extentSizeClassKey, proto.ExtentSizeClassSmall), t)
+	require.Equal(t, proto.ExtentSizeClassSmall, vol.ExtentSizeClass)
+	require.Equal(t, proto.ExtentSizeClassSmall, newSimpleView(vol).ExtentSizeClass)
+	processWithFatalV2(proto.AdminVolSetExtentSizeClass, false, map[string]interface{}{nameKey: vol.Name, extentSizeClassKey: "huge"}, t)
+	require.Equal(t, proto.ExtentSizeClassSmall, vol.ExtentSizeClass)
+	process(fmt.Sprintf("%v?name=%v&%v=default", reqUrl, vol.Name, extentSizeClassKey), t)
+	require.Equal(t, proto.ExtentSizeClassDefault, vol.ExtentSizeClass)
+}
+
+func TestVolumeFullReadOnly(t *testing.T) {
+	name := "fullReadOnlyVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	require.NoError(t, err)
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolSetFullReadOnlyRatio)
+	process(fmt.Sprintf("%v?name=%v&%v=0.9", reqUrl, vol.Name, ratio), t)
+	require.Equal(t, 0.9, vol.FullReadOnlyRatio)
+	require.False(t, vol.FullReadOnly)
+	processWithFatalV2(proto.AdminVolSetFullReadOnlyRatio, false, map[string]interface{}{nameKey: vol.Name, ratio: 1.5}, t)
+	processWithFatalV2(proto.AdminVolSetFullReadOnlyRatio, false, map[string]interface{}{nameKey: vol.Name, ratio: 0.01}, t)
+	require.Equal(t, 0.9, vol.FullReadOnlyRatio)
+
+	dps := vol.dataPartitions.clonePartitions()
+	require.NotEmpty(t, dps)
+	setUsed := func(usedRatio float64) {
+		for _, dp := range dps {
+			dp.used = 0
+		}
+		dps[0].used = uint64(usedRatio * float64(vol.Capacity*util.GB))
+	}
+	defer setUsed(0)
+
+	setUsed(0.92)
+	vol.checkFullReadOnly(server.cluster)
+	require.True(t, vol.FullReadOnly)
+	require.True(t, newSimpleView(vol).FullReadOnly)
+	ok, err := vol.needCreateDataPartition()
+	require.False(t, ok)
+	require.Equal(t, proto.ErrVolNoAvailableSpace, err)
+
+	reply := process(fmt.Sprintf("%v%v?name=%v", hostAddr, proto.ClientVolState, vol.Name), t)
+	require.Equal(t, true, reply.Data.(map[string]interface{})["FullReadOnly"])
+
+	// stays read-only until the used ratio drops below the gap
+	setUsed(0.87)
+	vol.checkFullReadOnly(server.cluster)
+	require.True(t, vol.FullReadOnly)
+	setUsed(0.8)
+	vol.checkFullReadOnly(server.cluster)
+	require.False(t, vol.FullReadOnly)
+
+	setUsed(0.95)
+	process(fmt.Sprintf("%v?name=%v&%v=0", reqUrl, vol.Name, ratio), t)
+	require.False(t, vol.FullReadOnly)
+}
@@ -65,6 +65,7 @@ func (m *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userID)
 	msg := fmt.Sprintf("delete user[%v] successfully", userID)
 	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
@@ -98,6 +99,7 @@ func (m *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
 	_ = sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -123,6 +125,28 @@ func (m *Server) getUserAKInfo(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
+func (m *Server) getUserUidInfo(w http.ResponseWriter, r *http.Request) {
+	var (
+		uid      uint32
+		userInfo *proto.UserInfo
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserGetUidInfo))
+	defer func() {
+		doStatAndMetric(proto.UserGetUidInfo, metric, err, nil)
+	}()
+
+	if uid, err = parsePosixUid(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.getUserInfoByUid(uid); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+}
+
 func (m *Server) getUserInfo(w http.ResponseWriter, r *http.Request) {
 	var (
 		userID   string
@@ -173,6 +197,7 @@ func (m *Server) updateUserPolicy(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -204,6 +229,58 @@ func (m *Server) removeUserPolicy(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
+	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+}
+
+func (m *Server) setUserPosix(w http.ResponseWriter, r *http.Request) {
+	var (
+		userInfo *proto.UserInfo
+		bytes    []byte
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserSetPosix))
+	defer func() {
+		doStatAndMetric(proto.UserSetPosix, metric, err, nil)
+	}()
+
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	param := proto.UserPosixIdentityParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.setPosixIdentity(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
+	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+}
+
+func (m *Server) removeUserPosix(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID   string
+		userInfo *proto.UserInfo
+		err      error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.UserRemovePosix))
+	defer func() {
+		doStatAndMetric(proto.UserRemovePosix, metric, err, nil)
+	}()
+
+	if userID, err = parseUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.removePosixIdentity(userID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -225,6 +302,7 @@ func (m *Server) deleteUserVolPolicy(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventVolume, vol, "")
 	msg := fmt.Sprintf("delete vol[%v] policy successfully", vol)
 	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
@@ -273,6 +351,8 @@ func (m *Server) transferUserVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
+	m.bucketEvents.record(proto.BucketEventVolume, volName, param.UserSrc)
+	m.bucketEvents.record(proto.BucketEventUser, "", userInfo.UserID)
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
@@ -345,6 +425,17 @@ func parseAccessKey(r *http.Request) (ak string, err error) {
 	return
 }
 
+func parsePosixUid(r *http.Request) (uid uint32, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if r.FormValue(UIDKey) == "" {
+		err = keyNotFound(UIDKey)
+		return
+	}
+	return extractUint32(r, UIDKey)
+}
+
 func parseKeywords(r *http.Request) (keywords string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
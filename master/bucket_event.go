@@ -0,0 +1,87 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	maxBucketEvents        = 4096
+	maxBucketEventsOfReply = 1024
+)
+
+// bucketEventLog keeps the latest changes of the buckets and users in memory,
+// objectnodes poll it to invalidate their caches of bucket infos, bucket
+// configs and users.
+//
+// The events are not persisted, a new epoch is started when the leader changes,
+// and the pollers of an older epoch are asked to reset their caches.
+type bucketEventLog struct {
+	sync.RWMutex
+	epoch  int64
+	seq    uint64
+	events []*proto.BucketEvent // ring of the latest maxBucketEvents events
+}
+
+func newBucketEventLog() *bucketEventLog {
+	return &bucketEventLog{
+		epoch:  time.Now().UnixNano(),
+		events: make([]*proto.BucketEvent, 0, maxBucketEvents),
+	}
+}
+
+func (l *bucketEventLog) reset() {
+	l.Lock()
+	l.epoch = time.Now().UnixNano()
+	l.seq = 0
+	l.events = l.events[:0]
+	l.Unlock()
+}
+
+func (l *bucketEventLog) record(eventType, bucket, userID string) {
+	l.Lock()
+	l.seq++
+	event := &proto.BucketEvent{Seq: l.seq, Type: eventType, Bucket: bucket, UserID: userID}
+	if len(l.events) < maxBucketEvents {
+		l.events = append(l.events, event)
+	} else {
+		l.events[(l.seq-1)%maxBucketEvents] = event
+	}
+	l.Unlock()
+}
+
+// since returns the events after seq of the epoch, at most maxBucketEventsOfReply
+// events are returned at once.
+func (l *bucketEventLog) since(epoch int64, seq uint64) (view *proto.BucketEventsView) {
+	l.RLock()
+	defer l.RUnlock()
+	view = &proto.BucketEventsView{Epoch: l.epoch, Seq: l.seq}
+	oldest := l.seq - uint64(len(l.events))
+	if epoch != l.epoch || seq > l.seq || seq < oldest {
+		view.Reset = true
+		return
+	}
+	for s := seq + 1; s <= l.seq && len(view.Events) < maxBucketEventsOfReply; s++ {
+		view.Events = append(view.Events, l.events[(s-1)%maxBucketEvents])
+	}
+	if len(view.Events) > 0 {
+		view.Seq = view.Events[len(view.Events)-1].Seq
+	}
+	return
+}
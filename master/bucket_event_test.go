@@ -0,0 +1,54 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketEventLog(t *testing.T) {
+	l := newBucketEventLog()
+	view := l.since(0, 0)
+	require.True(t, view.Reset)
+	epoch := view.Epoch
+
+	l.record(proto.BucketEventVolume, "vol1", "user1")
+	l.record(proto.BucketEventMeta, "vol1", "")
+	view = l.since(epoch, 0)
+	require.False(t, view.Reset)
+	require.Equal(t, uint64(2), view.Seq)
+	require.Len(t, view.Events, 2)
+	require.Equal(t, proto.BucketEventMeta, view.Events[1].Type)
+
+	view = l.since(epoch, 2)
+	require.False(t, view.Reset)
+	require.Empty(t, view.Events)
+
+	// the events older than the ring are lost
+	for i := 0; i < maxBucketEvents; i++ {
+		l.record(proto.BucketEventUser, "", "user1")
+	}
+	require.True(t, l.since(epoch, 1).Reset)
+	view = l.since(epoch, 2)
+	require.False(t, view.Reset)
+	require.Len(t, view.Events, maxBucketEventsOfReply)
+	require.Equal(t, uint64(3), view.Events[0].Seq)
+
+	l.reset()
+	require.True(t, l.since(epoch, 2).Reset)
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// capabilityFlags are the feature flags of the cluster, the capabilities disabled are not enabled
+// for the clients mounting afterwards even if all the servers support them, so that a feature is
+// rolled out or back without upgrading the clients. The flags are persisted in one key.
+type capabilityFlags struct {
+	sync.RWMutex
+	disabled map[string]bool
+}
+
+func newCapabilityFlags() *capabilityFlags {
+	return &capabilityFlags{disabled: make(map[string]bool)}
+}
+
+func (f *capabilityFlags) isDisabled(name string) bool {
+	f.RLock()
+	defer f.RUnlock()
+	return f.disabled[name]
+}
+
+func (f *capabilityFlags) disabledList() (names []string) {
+	for name := range f.disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func (f *capabilityFlags) reset(names []string) {
+	f.Lock()
+	defer f.Unlock()
+	f.disabled = make(map[string]bool, len(names))
+	for _, name := range names {
+		f.disabled[name] = true
+	}
+}
+
+// setCapability enables or disables the capability by the feature flag of the cluster.
+func (c *Cluster) setCapability(name string, enable bool) (err error) {
+	if !proto.IsCapability(name) {
+		return fmt.Errorf("unknown capability %v", name)
+	}
+	c.capabilityFlags.Lock()
+	defer c.capabilityFlags.Unlock()
+	if c.capabilityFlags.disabled[name] == !enable {
+		return
+	}
+	flags := &capabilityFlags{disabled: make(map[string]bool, len(c.capabilityFlags.disabled)+1)}
+	for n := range c.capabilityFlags.disabled {
+		flags.disabled[n] = true
+	}
+	if enable {
+		delete(flags.disabled, name)
+	} else {
+		flags.disabled[name] = true
+	}
+	if err = c.syncPutCapabilityFlags(flags.disabledList()); err != nil {
+		return
+	}
+	c.capabilityFlags.disabled = flags.disabled
+	log.LogInfof("action[setCapability] capability[%v] enable[%v]", name, enable)
+	return
+}
+
+// lackingServers returns the active servers of the role not supporting the capability, and false
+// if there is no active server of the role.
+func (c *Cluster) lackingServers(role, name string) (lacking []string, active bool) {
+	supports := func(capabilities []string) bool {
+		for _, capability := range capabilities {
+			if capability == name {
+				return true
+			}
+		}
+		return false
+	}
+	switch role {
+	case proto.MetaNode:
+		c.metaNodes.Range(func(addr, node interface{}) bool {
+			metaNode := node.(*MetaNode)
+			metaNode.RLock()
+			defer metaNode.RUnlock()
+			if !metaNode.IsActive {
+				return true
+			}
+			active = true
+			if !supports(metaNode.Capabilities) {
+				lacking = append(lacking, metaNode.Addr)
+			}
+			return true
+		})
+	case proto.DataNode:
+		c.dataNodes.Range(func(addr, node interface{}) bool {
+			dataNode := node.(*DataNode)
+			dataNode.RLock()
+			defer dataNode.RUnlock()
+			if !dataNode.isActive {
+				return true
+			}
+			active = true
+			if !supports(dataNode.Capabilities) {
+				lacking = append(lacking, dataNode.Addr)
+			}
+			return true
+		})
+	}
+	sort.Strings(lacking)
+	return
+}
+
+// capabilityView returns the state of the capability, which is supported only if there are active
+// servers of its roles and all of them support it. The servers of the versions before the
+// negotiation report no capability, so the capabilities are not enabled in the mixed-version
+// clusters until all the servers are upgraded.
+func (c *Cluster) capabilityView(name string) (view *proto.CapabilityView) {
+	view = &proto.CapabilityView{
+		Name:     name,
+		Roles:    proto.CapabilityRoles[name],
+		Disabled: c.capabilityFlags.isDisabled(name),
+	}
+	view.Supported = true
+	for _, role := range view.Roles {
+		lacking, active := c.lackingServers(role, name)
+		if !active || len(lacking) > 0 {
+			view.Supported = false
+		}
+		view.Lacking = append(view.Lacking, lacking...)
+	}
+	view.Enabled = view.Supported && !view.Disabled
+	return
+}
+
+func (c *Cluster) getCapabilities() (views []*proto.CapabilityView) {
+	for _, name := range proto.Capabilities() {
+		views = append(views, c.capabilityView(name))
+	}
+	return
+}
+
+// negotiateCapabilities returns the capabilities requested by the client which are enabled, the
+// ones unknown to the master are not supported.
+func (c *Cluster) negotiateCapabilities(requested []string) (n *proto.CapabilityNegotiation) {
+	n = &proto.CapabilityNegotiation{}
+	for _, name := range requested {
+		if !proto.IsCapability(name) {
+			n.Unsupported = append(n.Unsupported, name)
+			continue
+		}
+		view := c.capabilityView(name)
+		switch {
+		case view.Disabled:
+			n.Disabled = append(n.Disabled, name)
+		case !view.Supported:
+			n.Unsupported = append(n.Unsupported, name)
+		default:
+			n.Enabled = append(n.Enabled, name)
+		}
+	}
+	return
+}
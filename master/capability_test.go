@@ -0,0 +1,88 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func TestNegotiateCapabilities(t *testing.T) {
+	c := &Cluster{capabilityFlags: newCapabilityFlags()}
+	requested := []string{proto.CapabilityMetaHedgeRead, proto.CapabilityWireCompress, "unknown"}
+
+	// no active servers of the roles
+	n := c.negotiateCapabilities(requested)
+	require.Empty(t, n.Enabled)
+	require.Equal(t, requested, n.Unsupported)
+
+	// the metanodes of the versions before the negotiation report no capability
+	for i := 0; i < 3; i++ {
+		metaNode := newMetaNode(fmt.Sprintf("192.168.0.%v:17210", i+1), testZone1, "test")
+		metaNode.IsActive = true
+		metaNode.Capabilities = proto.MetaNodeCapabilities
+		c.metaNodes.Store(metaNode.Addr, metaNode)
+		dataNode := newDataNode(fmt.Sprintf("192.168.0.%v:17310", i+1), testZone1, "test")
+		dataNode.isActive = true
+		c.dataNodes.Store(dataNode.Addr, dataNode)
+	}
+	old := newMetaNode("192.168.0.4:17210", testZone1, "test")
+	old.IsActive = true
+	c.metaNodes.Store(old.Addr, old)
+	view := c.capabilityView(proto.CapabilityMetaHedgeRead)
+	require.False(t, view.Supported)
+	require.Equal(t, []string{old.Addr}, view.Lacking)
+	require.Len(t, c.capabilityView(proto.CapabilityWireCompress).Lacking, 3)
+
+	// the inactive servers are not counted
+	old.IsActive = false
+	n = c.negotiateCapabilities(requested)
+	require.Equal(t, []string{proto.CapabilityMetaHedgeRead}, n.Enabled)
+	require.Equal(t, []string{proto.CapabilityWireCompress, "unknown"}, n.Unsupported)
+
+	// disabled by the feature flag
+	c.capabilityFlags.reset([]string{proto.CapabilityMetaHedgeRead})
+	n = c.negotiateCapabilities(requested)
+	require.Empty(t, n.Enabled)
+	require.Equal(t, []string{proto.CapabilityMetaHedgeRead}, n.Disabled)
+
+	views := c.getCapabilities()
+	require.Len(t, views, len(proto.CapabilityRoles))
+	for _, view := range views {
+		require.False(t, view.Enabled)
+	}
+}
+
+func TestCapabilityApi(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&enable=false", hostAddr, proto.AdminSetCapability, proto.CapabilityWireCompress)
+	process(reqURL, t)
+	require.True(t, server.cluster.capabilityFlags.isDisabled(proto.CapabilityWireCompress))
+	require.Error(t, server.cluster.setCapability("unknown", false))
+
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminGetCapabilities)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?name=%v&capabilities=%v", hostAddr, proto.ClientCapabilities, commonVolName, proto.CapabilityWireCompress)
+	process(reqURL, t)
+	n := server.cluster.negotiateCapabilities([]string{proto.CapabilityWireCompress})
+	require.Equal(t, []string{proto.CapabilityWireCompress}, n.Disabled)
+
+	reqURL = fmt.Sprintf("%v%v?name=%v&enable=true", hostAddr, proto.AdminSetCapability, proto.CapabilityWireCompress)
+	process(reqURL, t)
+	require.False(t, server.cluster.capabilityFlags.isDisabled(proto.CapabilityWireCompress))
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+const (
+	clientHealthWindowSec = 10 * 60
+	// bound the memory used by the noisy clients
+	clientErrorMaxItemsPerReport = 64
+	clientErrorMaxKeysPerVol     = 4096
+)
+
+type clientErrorKey struct {
+	category string
+	key      string
+}
+
+type clientErrorSample struct {
+	reportTime int64
+	count      uint64
+}
+
+// clientErrors is the samples of an error reported by each client.
+type clientErrors map[string][]clientErrorSample
+
+// clientHealthTracker aggregates the errors reported by the clients of the volumes,
+// only the reports in the last window are kept.
+type clientHealthTracker struct {
+	sync.RWMutex
+	window int64
+	vols   map[string]map[clientErrorKey]clientErrors
+}
+
+func newClientHealthTracker() *clientHealthTracker {
+	return &clientHealthTracker{
+		window: clientHealthWindowSec,
+		vols:   make(map[string]map[clientErrorKey]clientErrors),
+	}
+}
+
+func (t *clientHealthTracker) record(report *proto.ClientErrorReport, now int64) {
+	if report.VolName == "" || report.Client == "" {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	errs, ok := t.vols[report.VolName]
+	if !ok {
+		errs = make(map[clientErrorKey]clientErrors)
+		t.vols[report.VolName] = errs
+	}
+	for i, item := range report.Items {
+		if i >= clientErrorMaxItemsPerReport {
+			break
+		}
+		if item == nil || item.Count == 0 {
+			continue
+		}
+		key := clientErrorKey{category: item.Category, key: item.Key}
+		clients, ok := errs[key]
+		if !ok {
+			if len(errs) >= clientErrorMaxKeysPerVol {
+				continue
+			}
+			clients = make(clientErrors)
+			errs[key] = clients
+		}
+		clients[report.Client] = append(clients[report.Client], clientErrorSample{reportTime: now, count: item.Count})
+	}
+	if len(errs) == 0 {
+		delete(t.vols, report.VolName)
+	}
+}
+
+// expire drops the samples reported before the window.
+func (t *clientHealthTracker) expire(now int64) {
+	t.Lock()
+	defer t.Unlock()
+	expireTime := now - t.window
+	for volName, errs := range t.vols {
+		for key, clients := range errs {
+			for client, samples := range clients {
+				idx := sort.Search(len(samples), func(i int) bool {
+					return samples[i].reportTime >= expireTime
+				})
+				if idx == len(samples) {
+					delete(clients, client)
+				} else if idx > 0 {
+					clients[client] = append(samples[:0:0], samples[idx:]...)
+				}
+			}
+			if len(clients) == 0 {
+				delete(errs, key)
+			}
+		}
+		if len(errs) == 0 {
+			delete(t.vols, volName)
+		}
+	}
+}
+
+func (t *clientHealthTracker) removeVol(volName string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.vols, volName)
+}
+
+// health returns the errors of the volume reported in the window, the most frequent ones first.
+func (t *clientHealthTracker) health(volName string, now int64) *proto.VolClientHealth {
+	t.RLock()
+	defer t.RUnlock()
+	expireTime := now - t.window
+	view := &proto.VolClientHealth{
+		VolName:   volName,
+		WindowSec: t.window,
+		Errors:    make([]*proto.ClientErrorStat, 0),
+	}
+	reported := make(map[string]struct{})
+	for key, clients := range t.vols[volName] {
+		stat := &proto.ClientErrorStat{Category: key.category, Key: key.key}
+		for client, samples := range clients {
+			var count uint64
+			for _, sample := range samples {
+				if sample.reportTime < expireTime {
+					continue
+				}
+				count += sample.count
+				if sample.reportTime > stat.LastReport {
+					stat.LastReport = sample.reportTime
+				}
+			}
+			if count == 0 {
+				continue
+			}
+			stat.Count += count
+			stat.Clients++
+			reported[client] = struct{}{}
+		}
+		if stat.Count > 0 {
+			view.Errors = append(view.Errors, stat)
+		}
+	}
+	view.Clients = len(reported)
+	sort.Slice(view.Errors, func(i, j int) bool {
+		if view.Errors[i].Count != view.Errors[j].Count {
+			return view.Errors[i].Count > view.Errors[j].Count
+		}
+		if view.Errors[i].Category != view.Errors[j].Category {
+			return view.Errors[i].Category < view.Errors[j].Category
+		}
+		return view.Errors[i].Key < view.Errors[j].Key
+	})
+	return view
+}
+
+// reportClientErrors records the errors observed by the client of the volume.
+func (c *Cluster) reportClientErrors(report *proto.ClientErrorReport) {
+	c.clientHealth.record(report, time.Now().Unix())
+}
+
+func (c *Cluster) getVolClientHealth(volName string) *proto.VolClientHealth {
+	return c.clientHealth.health(volName, time.Now().Unix())
+}
+
+func (c *Cluster) scheduleToExpireClientHealth() {
+	go func() {
+		for {
+			c.clientHealth.expire(time.Now().Unix())
+			time.Sleep(time.Minute)
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientHealthTracker(t *testing.T) {
+	tracker := newClientHealthTracker()
+	now := int64(100000)
+
+	tracker.record(&proto.ClientErrorReport{VolName: "vol", Client: "10.0.0.1", Items: []*proto.ClientErrorItem{
+		{Category: proto.ClientErrDataNodeTimeout, Key: "192.168.0.1:17310", Count: 3},
+		{Category: proto.ClientErrPartitionEIO, Key: "10", Count: 1},
+	}}, now-tracker.window-1)
+	tracker.record(&proto.ClientErrorReport{VolName: "vol", Client: "10.0.0.1", Items: []*proto.ClientErrorItem{
+		{Category: proto.ClientErrDataNodeTimeout, Key: "192.168.0.1:17310", Count: 2},
+	}}, now)
+	tracker.record(&proto.ClientErrorReport{VolName: "vol", Client: "10.0.0.2", Items: []*proto.ClientErrorItem{
+		{Category: proto.ClientErrDataNodeTimeout, Key: "192.168.0.1:17310", Count: 4},
+		{Category: proto.ClientErrMountFailure, Key: "timeout", Count: 1},
+		{Category: proto.ClientErrPartitionEIO, Key: "11", Count: 0},
+	}}, now)
+	// the reports without the volume or client are ignored
+	tracker.record(&proto.ClientErrorReport{VolName: "vol", Items: []*proto.ClientErrorItem{
+		{Category: proto.ClientErrPartitionEIO, Key: "12", Count: 1},
+	}}, now)
+
+	health := tracker.health("vol", now)
+	require.Equal(t, 2, health.Clients)
+	require.Len(t, health.Errors, 2)
+	require.Equal(t, proto.ClientErrDataNodeTimeout, health.Errors[0].Category)
+	require.Equal(t, uint64(6), health.Errors[0].Count)
+	require.Equal(t, 2, health.Errors[0].Clients)
+	require.Equal(t, now, health.Errors[0].LastReport)
+	require.Equal(t, proto.ClientErrMountFailure, health.Errors[1].Category)
+
+	tracker.expire(now)
+	require.Len(t, tracker.vols["vol"], 2)
+	require.Len(t, tracker.vols["vol"][clientErrorKey{proto.ClientErrDataNodeTimeout, "192.168.0.1:17310"}]["10.0.0.1"], 1)
+
+	tracker.expire(now + tracker.window + 1)
+	require.Len(t, tracker.vols, 0)
+	require.Len(t, tracker.health("vol", now).Errors, 0)
+
+	tracker.record(&proto.ClientErrorReport{VolName: "vol", Client: "10.0.0.1", Items: []*proto.ClientErrorItem{
+		{Category: proto.ClientErrPartitionEIO, Key: "10", Count: 1},
+	}}, now)
+	tracker.removeVol("vol")
+	require.Len(t, tracker.vols, 0)
+}
@@ -87,6 +87,13 @@ type Cluster struct {
 	checkAutoCreateDataPartition bool
 	masterClient                 *masterSDK.MasterClient
 	checkDataReplicasEnable      bool
+	dpSloEnable                  bool
+	dpSloLatencyMs               uint64
+	dpSloViolationLimit          int
+	dpSloTracker                 *dpSloTracker
+	mountMap                     *mountMap
+	capabilityFlags              *capabilityFlags
+	clientHealth                 *clientHealthTracker
 	fileStatsEnable              bool
 	clusterUuid                  string
 	clusterUuidEnable            bool
@@ -352,6 +359,12 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.snapshotMgr = newSnapshotManager()
 	c.snapshotMgr.cluster = c
 	c.S3ApiQosQuota = new(sync.Map)
+	c.dpSloLatencyMs = defaultDpSloLatencyMs
+	c.dpSloViolationLimit = defaultDpSloViolationLimit
+	c.dpSloTracker = newDpSloTracker()
+	c.mountMap = newMountMap()
+	c.capabilityFlags = newCapabilityFlags()
+	c.clientHealth = newClientHealthTracker()
 	return
 }
 
@@ -374,6 +387,10 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckDecommissionDataNode()
 	c.scheduleToCheckDecommissionDisk()
 	c.scheduleToCheckDataReplicas()
+	c.scheduleToCheckDataPartitionSlo()
+	c.scheduleToCheckPlacementViolations()
+	c.scheduleToDetachVolClones()
+	c.scheduleToExpireClientHealth()
 	c.scheduleToLcScan()
 	c.scheduleToSnapshotDelVerScan()
 	c.scheduleToBadDisk()
@@ -414,8 +431,8 @@ const (
 	TypeDataPartition uint32 = 0x02
 )
 
-func (c *Cluster) getHostFromDomainZone(domainId uint64, createType uint32, replicaNum uint8) (hosts []string, peers []proto.Peer, err error) {
-	hosts, peers, err = c.domainManager.getHostFromNodeSetGrp(domainId, replicaNum, createType)
+func (c *Cluster) getHostFromDomainZone(domainId uint64, createType uint32, replicaNum uint8, excludeHosts []string) (hosts []string, peers []proto.Peer, err error) {
+	hosts, peers, err = c.domainManager.getHostFromNodeSetGrp(domainId, replicaNum, createType, excludeHosts)
 	return
 }
 
@@ -478,6 +495,7 @@ func (c *Cluster) scheduleToCheckVolStatus() {
 				for _, vol := range vols {
 					vol.checkStatus(c)
 					vol.CheckStrategy(c)
+					vol.checkFullReadOnly(c)
 				}
 			}
 			time.Sleep(time.Second * time.Duration(c.cfg.IntervalToCheckDataPartition))
@@ -665,6 +683,15 @@ func (c *Cluster) checkDataNodeHeartbeat() {
 			if vol.Forbidden {
 				hbReq.ForbiddenVols = append(hbReq.ForbiddenVols, vol.Name)
 			}
+			if vol.VerifyReadCrc {
+				hbReq.VerifyReadCrcVols = append(hbReq.VerifyReadCrcVols, vol.Name)
+			}
+			if vol.ExtentSizeClass != proto.ExtentSizeClassDefault {
+				if hbReq.VolExtentSizeClasses == nil {
+					hbReq.VolExtentSizeClasses = make(map[string]string)
+				}
+				hbReq.VolExtentSizeClasses[vol.Name] = vol.ExtentSizeClass
+			}
 		}
 		tasks = append(tasks, task)
 		return true
@@ -677,6 +704,13 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 	c.volMutex.RLock()
 	defer c.volMutex.RUnlock()
 
+	metaOpLimitShares := make(map[string]map[string]*proto.VolMetaOpLimit)
+	for _, vol := range c.vols {
+		if shares := vol.metaOpLimitShares(); shares != nil {
+			metaOpLimitShares[vol.Name] = shares
+		}
+	}
+
 	c.metaNodes.Range(func(addr, metaNode interface{}) bool {
 		node := metaNode.(*MetaNode)
 		node.checkHeartbeat()
@@ -709,6 +743,10 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 				Mask:       vol.enableTransaction,
 				OpLimitVal: vol.txOpLimit,
 			})
+
+			if share, ok := metaOpLimitShares[vol.Name][node.Addr]; ok {
+				hbReq.VolMetaOpLimits = append(hbReq.VolMetaOpLimits, share)
+			}
 		}
 		log.LogDebugf("checkMetaNodeHeartbeat start")
 		for _, info := range hbReq.QuotaHbInfos {
@@ -1313,6 +1351,7 @@ func (c *Cluster) deleteVol(name string) {
 	c.volMutex.Lock()
 	defer c.volMutex.Unlock()
 	delete(c.vols, name)
+	c.clientHealth.removeVol(name)
 	return
 }
 
@@ -1352,6 +1391,10 @@ func (c *Cluster) markDeleteVol(name, authKey string, force bool) (err error) {
 		return fmt.Errorf("ec-vol can't be deleted if ec used size not equal 0, now(%d)", vol.totalUsedSpace())
 	}
 
+	if clones := c.getSharedVolClones(vol.Name); len(clones) > 0 {
+		return fmt.Errorf("vol %s shares data with clones %v, deletion not permitted", vol.Name, clones)
+	}
+
 	serverAuthKey = vol.Owner
 	if !matchKey(serverAuthKey, authKey) {
 		return proto.ErrVolAuthKeyNotMatch
@@ -1490,13 +1533,14 @@ func (c *Cluster) createDataPartition(volName string, preload *DataPartitionPreL
 	errChannel := make(chan error, dpReplicaNum)
 
 	if c.isFaultDomain(vol) {
-		if targetHosts, targetPeers, err = c.getHostFromDomainZone(vol.domainId, TypeDataPartition, dpReplicaNum); err != nil {
+		if targetHosts, targetPeers, err = c.getHostFromDomainZone(vol.domainId, TypeDataPartition, dpReplicaNum,
+			c.labelExcludeHosts(volName, TypeDataPartition, nil)); err != nil {
 			goto errHandler
 		}
 	} else {
 		zoneNum := c.decideZoneNum(vol.crossZone)
-		if targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil, nil,
-			int(dpReplicaNum), zoneNum, zoneName); err != nil {
+		if targetHosts, targetPeers, err = c.getHostFromNormalZone(TypeDataPartition, nil, nil,
+			c.labelExcludeHosts(volName, TypeDataPartition, nil), int(dpReplicaNum), zoneNum, zoneName); err != nil {
 			goto errHandler
 		}
 	}
@@ -2184,7 +2228,7 @@ func (c *Cluster) autoAddDataReplica(dp *DataPartition) (success bool, err error
 
 	if vol.crossZone {
 		zones := dp.getZones()
-		if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, nil, dp.Hosts, 1, 1, ""); err != nil {
+		if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, zones, nil, c.labelExcludeHosts(dp.VolName, TypeDataPartition, dp.Hosts), 1, 1, ""); err != nil {
 			goto errHandler
 		}
 	} else {
@@ -2202,7 +2246,7 @@ func (c *Cluster) autoAddDataReplica(dp *DataPartition) (success bool, err error
 		if ns, err = zone.getNodeSet(nodeSets[0]); err != nil {
 			goto errHandler
 		}
-		if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+		if targetHosts, _, err = ns.getAvailDataNodeHosts(c.labelExcludeHosts(dp.VolName, TypeDataPartition, dp.Hosts), 1); err != nil {
 			goto errHandler
 		}
 	}
@@ -2303,7 +2347,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 
 	if targetAddr != "" {
 		targetHosts = []string{targetAddr}
-	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(c.labelExcludeHosts(dp.VolName, TypeDataPartition, dp.Hosts), 1); err != nil {
 		if _, ok := c.vols[dp.VolName]; !ok {
 			log.LogWarnf("clusterID[%v] partitionID:%v  on node:%v offline failed,PersistenceHosts:[%v]",
 				c.Name, dp.PartitionID, srcAddr, dp.Hosts)
@@ -2316,7 +2360,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 		}
 		// select data nodes from the other node set in same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, dp.Hosts, 1); err != nil {
+		if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, c.labelExcludeHosts(dp.VolName, TypeDataPartition, dp.Hosts), 1); err != nil {
 			// select data nodes from the other zone
 			zones = dp.getLiveZones(srcAddr)
 			var excludeZone []string
@@ -2325,7 +2369,8 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 			} else {
 				excludeZone = append(excludeZone, zones[0])
 			}
-			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, dp.Hosts, 1, 1, ""); err != nil {
+			if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets,
+				c.labelExcludeHosts(dp.VolName, TypeDataPartition, dp.Hosts), 1, 1, ""); err != nil {
 				goto errHandler
 			}
 		}
@@ -3179,7 +3224,12 @@ func (c *Cluster) createVol(req *createVolReq) (vol *Vol, err error) {
 		log.LogError("init dataPartition error in verMgr init", err.Error())
 	}
 
-	if err = vol.initMetaPartitions(c, req.mpCount); err != nil {
+	if req.cloneSrc != nil {
+		err = vol.initClonedMetaPartitions(c, req.cloneSrc, req.cloneSrcVerSeq, req.cloneVerSeq)
+	} else {
+		err = vol.initMetaPartitions(c, req.mpCount)
+	}
+	if err != nil {
 
 		vol.Status = proto.VolStatusMarkDelete
 		if e := vol.deleteVolFromStore(c); e != nil {
@@ -139,7 +139,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		newPeers = []proto.Peer{{
 			Addr: targetAddr,
 		}}
-	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1); err != nil {
+	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(c.labelExcludeHosts(mp.volName, TypeMetaPartition, oldHosts), 1); err != nil {
 		if _, ok := c.vols[mp.volName]; !ok {
 			log.LogWarnf("[migrateMetaPartition] clusterID[%v] partitionID:%v  on node:[%v]",
 				c.Name, mp.PartitionID, mp.Hosts)
@@ -152,7 +152,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		}
 		// choose a meta node in other node set in the same zone
 		excludeNodeSets = append(excludeNodeSets, ns.ID)
-		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets, oldHosts, 1); err != nil {
+		if _, newPeers, err = zone.getAvailNodeHosts(TypeMetaPartition, excludeNodeSets, c.labelExcludeHosts(mp.volName, TypeMetaPartition, oldHosts), 1); err != nil {
 			zones = mp.getLiveZones(srcAddr)
 			var excludeZone []string
 			if len(zones) == 0 {
@@ -161,22 +161,36 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 				excludeZone = append(excludeZone, zones[0])
 			}
 			// choose a meta node in other zone
-			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets, oldHosts, 1, 1, ""); err != nil {
+			if _, newPeers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, excludeNodeSets,
+				c.labelExcludeHosts(mp.volName, TypeMetaPartition, oldHosts), 1, 1, ""); err != nil {
 				goto errHandler
 			}
 		}
 	}
 
-	if err = c.deleteMetaReplica(mp, srcAddr, false, false); err != nil {
+	// the new replica joins as a learner, so that the quorum of the src and the other replicas keeps
+	// committing while it catches up. It is promoted after catching up, then the partition is cut over
+	// by moving the leader off the src and removing the src
+	if err = c.addMetaReplica(mp, newPeers[0].Addr, true); err != nil {
 		goto errHandler
 	}
 
-	if err = c.addMetaReplica(mp, newPeers[0].Addr); err != nil {
+	if err = c.waitMetaReplicaCatchUp(mp, srcAddr, newPeers[0].Addr); err == nil {
+		err = c.promoteMetaLearner(mp, newPeers[0].Addr)
+	}
+	if err != nil {
+		if errDel := c.deleteMetaReplica(mp, newPeers[0].Addr, false, false); errDel != nil {
+			log.LogErrorf("action[migrateMetaPartition] vol[%v],partition[%v] rollback new replica[%v] err[%v]",
+				mp.volName, mp.PartitionID, newPeers[0].Addr, errDel)
+		}
 		goto errHandler
 	}
 
-	mp.IsRecover = true
-	c.putBadMetaPartitions(srcAddr, mp.PartitionID)
+	c.transferMetaLeaderFrom(mp, srcAddr, newPeers[0].Addr)
+
+	if err = c.deleteMetaReplica(mp, srcAddr, false, false); err != nil {
+		goto errHandler
+	}
 
 	mp.RLock()
 	c.syncUpdateMetaPartition(mp)
@@ -197,15 +211,110 @@ errHandler:
 	return
 }
 
+// loadMetaReplica loads the apply id and the leader of the meta partition on the replica.
+func (c *Cluster) loadMetaReplica(mp *MetaPartition, addr string) (resp *proto.MetaPartitionLoadResponse, err error) {
+	mr, err := mp.getMetaReplica(addr)
+	if err != nil {
+		return
+	}
+	task := mr.createTaskToLoadMetaPartition(mp.PartitionID)
+	packet, err := mr.metaNode.Sender.syncSendAdminTask(task)
+	if err != nil {
+		return
+	}
+	resp = &proto.MetaPartitionLoadResponse{}
+	if err = json.Unmarshal(packet.Data, resp); err != nil {
+		return
+	}
+	resp.Addr = addr
+	return
+}
+
+// waitMetaReplicaCatchUp waits until the apply id of the new replica lags behind the leader by no more
+// than metaPartitionMigrateMaxApplyLag, the replica receives the snapshot and the logs while the
+// partition keeps serving.
+func (c *Cluster) waitMetaReplicaCatchUp(mp *MetaPartition, srcAddr, addr string) (err error) {
+	var leader, replica *proto.MetaPartitionLoadResponse
+	deadline := time.Now().Add(metaPartitionMigrateCatchUpTimeout)
+	for {
+		leaderAddr := srcAddr
+		mp.RLock()
+		if mr, errLeader := mp.getMetaReplicaLeader(); errLeader == nil {
+			leaderAddr = mr.Addr
+		}
+		mp.RUnlock()
+		if leader, err = c.loadMetaReplica(mp, leaderAddr); err == nil {
+			if replica, err = c.loadMetaReplica(mp, addr); err == nil {
+				if replica.ApplyID+metaPartitionMigrateMaxApplyLag >= leader.ApplyID {
+					log.LogInfof("action[waitMetaReplicaCatchUp] vol[%v],partition[%v] replica[%v] applyID[%v] caught up leader[%v] applyID[%v]",
+						mp.volName, mp.PartitionID, addr, replica.ApplyID, leaderAddr, leader.ApplyID)
+					return nil
+				}
+				err = fmt.Errorf("replica[%v] applyID[%v] lags behind leader[%v] applyID[%v]",
+					addr, replica.ApplyID, leaderAddr, leader.ApplyID)
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("vol[%v],mp[%v] wait for replica[%v] to catch up timeout, err[%v]",
+				mp.volName, mp.PartitionID, addr, err)
+		}
+		time.Sleep(metaPartitionMigrateCatchUpInterval)
+	}
+}
+
+// transferMetaLeaderFrom moves the leader off the src before the src is removed, so that the
+// partition stops serving only for the leader transfer instead of electing the leader after the
+// leader is removed. The src is removed with the leader changed afterwards if the transfer fails.
+func (c *Cluster) transferMetaLeaderFrom(mp *MetaPartition, srcAddr, newAddr string) {
+	mp.RLock()
+	mr, err := mp.getMetaReplicaLeader()
+	candidate := newAddr
+	for _, host := range mp.Hosts {
+		if host != srcAddr && host != newAddr {
+			candidate = host
+			break
+		}
+	}
+	mp.RUnlock()
+	if err != nil || mr.Addr != srcAddr {
+		return
+	}
+
+	if err = mp.tryToChangeLeaderByHost(candidate); err != nil {
+		log.LogWarnf("action[transferMetaLeaderFrom] vol[%v],partition[%v] change leader to[%v] err[%v]",
+			mp.volName, mp.PartitionID, candidate, err)
+		return
+	}
+	deadline := time.Now().Add(metaPartitionLeaderTransferTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(metaPartitionLeaderTransferInterval)
+		resp, err := c.loadMetaReplica(mp, candidate)
+		if err != nil || resp.LeaderAddr == "" || resp.LeaderAddr == srcAddr {
+			continue
+		}
+		mp.Lock()
+		for _, r := range mp.Replicas {
+			r.IsLeader = r.Addr == resp.LeaderAddr
+		}
+		mp.Unlock()
+		log.LogInfof("action[transferMetaLeaderFrom] vol[%v],partition[%v] leader changed from[%v] to[%v]",
+			mp.volName, mp.PartitionID, srcAddr, resp.LeaderAddr)
+		return
+	}
+	log.LogWarnf("action[transferMetaLeaderFrom] vol[%v],partition[%v] leader not changed from[%v] in %v",
+		mp.volName, mp.PartitionID, srcAddr, metaPartitionLeaderTransferTimeout)
+}
+
 // taking the given mata partition offline.
 // 1. checking if the meta partition can be offline.
 // There are two cases where the partition is not allowed to be offline:
 // (1) the replica is not in the latest host list
 // (2) there are too few replicas
 // 2. choosing a new available meta node
-// 3. synchronized decommission meta partition
-// 4. synchronized create a new meta partition
-// 5. persistent the new host list
+// 3. synchronized create a new meta partition and wait for it to catch up
+// 4. moving the leader off the decommissioned replica
+// 5. synchronized decommission meta partition
+// 6. persistent the new host list
 func (c *Cluster) decommissionMetaPartition(nodeAddr string, mp *MetaPartition) (err error) {
 	if c.ForbidMpDecommission {
 		err = fmt.Errorf("cluster mataPartition decommission switch is disabled")
@@ -481,7 +590,9 @@ func (c *Cluster) updateMetaPartitionOfflinePeerIDWithLock(mp *MetaPartition, pe
 	return
 }
 
-func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err error) {
+// addMetaReplica adds the replica on the meta node, the replica added as a learner replicates
+// the logs without voting until it is promoted by promoteMetaLearner.
+func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string, asLearner bool) (err error) {
 	defer func() {
 		if err != nil {
 			log.LogErrorf("action[addMetaReplica],vol[%v],data partition[%v],err[%v]", partition.volName, partition.PartitionID, err)
@@ -498,14 +609,19 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 		return
 	}
 	addPeer := proto.Peer{ID: metaNode.ID, Addr: addr}
-	if err = c.addMetaPartitionRaftMember(partition, addPeer); err != nil {
+	if err = c.addMetaPartitionRaftMember(partition, addPeer, asLearner); err != nil {
 		return
 	}
 	newHosts := make([]string, 0, len(partition.Hosts)+1)
 	newPeers := make([]proto.Peer, 0, len(partition.Hosts)+1)
 	newHosts = append(partition.Hosts, addPeer.Addr)
 	newPeers = append(partition.Peers, addPeer)
+	oldLearners := partition.Learners
+	if asLearner {
+		partition.Learners = append(append([]proto.Peer{}, oldLearners...), addPeer)
+	}
 	if err = partition.persistToRocksDB("addMetaReplica", partition.volName, newHosts, newPeers, c); err != nil {
+		partition.Learners = oldLearners
 		return
 	}
 	if err = c.createMetaReplica(partition, addPeer); err != nil {
@@ -517,6 +633,37 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 	return
 }
 
+// promoteMetaLearner promotes the learner to a voter by adding it to the raft group again as a voter.
+func (c *Cluster) promoteMetaLearner(partition *MetaPartition, addr string) (err error) {
+	partition.Lock()
+	defer partition.Unlock()
+	var (
+		learner  proto.Peer
+		learners = make([]proto.Peer, 0, len(partition.Learners))
+	)
+	for _, peer := range partition.Learners {
+		if peer.Addr == addr {
+			learner = peer
+			continue
+		}
+		learners = append(learners, peer)
+	}
+	if learner.Addr == "" {
+		return fmt.Errorf("vol[%v],mp[%v] has no learner[%v]", partition.volName, partition.PartitionID, addr)
+	}
+	if err = c.addMetaPartitionRaftMember(partition, learner, false); err != nil {
+		return
+	}
+	oldLearners := partition.Learners
+	partition.Learners = learners
+	if err = c.syncUpdateMetaPartition(partition); err != nil {
+		partition.Learners = oldLearners
+		return
+	}
+	log.LogWarnf("action[promoteMetaLearner] vol[%v],mp[%v] promote learner[%v]", partition.volName, partition.PartitionID, addr)
+	return
+}
+
 func (c *Cluster) createMetaReplica(partition *MetaPartition, addPeer proto.Peer) (err error) {
 	task, err := partition.createTaskToCreateReplica(addPeer.Addr)
 	if err != nil {
@@ -532,7 +679,7 @@ func (c *Cluster) createMetaReplica(partition *MetaPartition, addPeer proto.Peer
 	return
 }
 
-func (c *Cluster) buildAddMetaPartitionRaftMemberTaskAndSyncSend(mp *MetaPartition, addPeer proto.Peer, leaderAddr string) (resp *proto.Packet, err error) {
+func (c *Cluster) buildAddMetaPartitionRaftMemberTaskAndSyncSend(mp *MetaPartition, addPeer proto.Peer, leaderAddr string, asLearner bool) (resp *proto.Packet, err error) {
 	defer func() {
 		var resultCode uint8
 		if resp != nil {
@@ -548,7 +695,7 @@ func (c *Cluster) buildAddMetaPartitionRaftMemberTaskAndSyncSend(mp *MetaPartiti
 		}
 	}()
 
-	t, err := mp.createTaskToAddRaftMember(addPeer, leaderAddr)
+	t, err := mp.createTaskToAddRaftMember(addPeer, leaderAddr, asLearner)
 	if err != nil {
 		return
 	}
@@ -562,7 +709,7 @@ func (c *Cluster) buildAddMetaPartitionRaftMemberTaskAndSyncSend(mp *MetaPartiti
 	return
 }
 
-func (c *Cluster) addMetaPartitionRaftMember(partition *MetaPartition, addPeer proto.Peer) (err error) {
+func (c *Cluster) addMetaPartitionRaftMember(partition *MetaPartition, addPeer proto.Peer, asLearner bool) (err error) {
 	var (
 		candidateAddrs []string
 		leaderAddr     string
@@ -589,7 +736,7 @@ func (c *Cluster) addMetaPartitionRaftMember(partition *MetaPartition, addPeer p
 		if leaderAddr == "" && len(candidateAddrs) < int(partition.ReplicaNum) {
 			time.Sleep(retrySendSyncTaskInternal)
 		}
-		_, err = c.buildAddMetaPartitionRaftMemberTaskAndSyncSend(partition, addPeer, host)
+		_, err = c.buildAddMetaPartitionRaftMemberTaskAndSyncSend(partition, addPeer, host, asLearner)
 		if err == nil {
 			break
 		}
@@ -829,6 +976,10 @@ func (c *Cluster) dealMetaNodeHeartbeatResp(nodeAddr string, resp *proto.MetaNod
 		log.LogWarnf("metaNode zone changed from [%v] to [%v]", oldZoneName, resp.ZoneName)
 	}
 
+	if !metaNode.partitionReports.Apply(resp) {
+		log.LogWarnf("action[dealMetaNodeHeartbeatResp] metaNode[%v] reports delta based on seq[%v] mismatched, resync by full reports",
+			nodeAddr, resp.BaseReportSeq)
+	}
 	// change cpu util and io used
 	metaNode.CpuUtil.Store(resp.CpuUtil)
 	metaNode.updateMetric(resp, c.cfg.MetaNodeThreshold)
@@ -1013,6 +1164,10 @@ func (c *Cluster) handleDataNodeHeartbeatResp(nodeAddr string, resp *proto.DataN
 		c.adjustDataNode(dataNode)
 		log.LogWarnf("dataNode [%v] zone changed from [%v] to [%v]", dataNode.Addr, oldZoneName, resp.ZoneName)
 	}
+	if !dataNode.partitionReports.Apply(resp) {
+		log.LogWarnf("action[handleDataNodeHeartbeatResp] dataNode[%v] reports delta based on seq[%v] mismatched, resync by full reports",
+			nodeAddr, resp.BaseReportSeq)
+	}
 	// change cpu util and io used
 	dataNode.CpuUtil.Store(resp.CpuUtil)
 	dataNode.SetIoUtils(resp.IoUtils)
@@ -52,6 +52,9 @@ const (
 
 	cfgVolForceDeletion           = "volForceDeletion"
 	cfgVolDeletionDentryThreshold = "volDeletionDentryThreshold"
+
+	// the followers serve the read-only admin APIs from the responses of the leader cached in the seconds
+	cfgFollowerCacheSec = "followerCacheSec"
 )
 
 // default value
@@ -61,6 +64,7 @@ const (
 	defaultIntervalToFreeDataPartition         = 10     // in terms of seconds
 	defaultIntervalToCheck                     = 60
 	defaultIntervalToCheckHeartbeat            = 6
+	defaultIntervalToFullHeartbeatReport       = 300 // the nodes report the changed partitions only in between
 	defaultIntervalToCheckDataPartition        = 5
 	defaultIntervalToCheckQos                  = 1
 	defaultIntervalToCheckCrc                  = 20 * defaultIntervalToCheck // in terms of seconds
@@ -83,6 +87,7 @@ const (
 	defaultOverSoldFactor                      float32 = 0       // 0 means no oversold limit
 	defaultMaxMetaPartitionCountOnEachNode             = 10000
 	defaultReplicaNum                                  = 3
+	maxRaisingReplicaDataPartitions                    = 10 // max data partitions of a vol recovering a raised replica at once
 	defaultDiffSpaceUsage                              = 1024 * 1024 * 1024
 	defaultDiffReplicaFileCount                        = 20
 	defaultNodeSetGrpStep                              = 1
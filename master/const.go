@@ -30,6 +30,13 @@ const (
 	countKey              = "count"
 	startKey              = "start"
 	enableKey             = "enable"
+	latencyMsKey          = "latencyMs"
+	mountPrefixKey        = "prefix"
+	durabilityKey         = "durability"
+	groupCommitDelayMsKey = "groupCommitDelayMs"
+	subDirKey             = "subDir"
+	capabilitiesKey       = "capabilities"
+	violationLimitKey     = "violationLimit"
 	thresholdKey          = "threshold"
 	dirQuotaKey           = "dirQuota"
 	dirLimitKey           = "dirSizeLimit"
@@ -87,6 +94,12 @@ const (
 	nodeTypeKey                = "nodeType"
 	ratio                      = "ratio"
 	rdOnlyKey                  = "rdOnly"
+	labelsKey                  = "labels"
+	labelSelectorKey           = "labelSelector"
+	burstCreditKey             = "burstCredit"
+	metaOpLimitKey             = "metaOpLimit"
+	metaOpBurstKey             = "metaOpBurst"
+	extentSizeClassKey         = "extentSizeClass"
 	srcAddrKey                 = "srcAddr"
 	targetAddrKey              = "targetAddr"
 	forceKey                   = "force"
@@ -111,6 +124,8 @@ const (
 	DiskDisableKey             = "diskDisable"
 	Limit                      = "limit"
 	TimeOut                    = "timeout"
+	CallerLimit                = "callerLimit"
+	CallerKey                  = "caller"
 	CountByMeta                = "countByMeta"
 	dpReadOnlyWhenVolFull      = "dpReadOnlyWhenVolFull"
 	PeriodicKey                = "periodic"
@@ -132,6 +147,10 @@ const (
 	Periodic                   = "periodic"
 	DecommissionType           = "decommissionType"
 	decommissionDiskFactor     = "decommissionDiskFactor"
+	cloneNameKey               = "cloneName"
+	materializeKey             = "materialize"
+	epochKey                   = "epoch"
+	seqKey                     = "seq"
 )
 
 const (
@@ -185,6 +204,7 @@ const (
 	defaultInitDataPartitionCnt                  = 10
 	maxInitDataPartitionCnt                      = 200
 	volExpansionRatio                            = 0.1
+	volFullReadOnlyRecoverGap                    = 0.05 // the full read-only volumes turn writable below ratio minus the gap
 	maxNumberOfDataPartitionsForExpansion        = 100
 	EmptyCrcValue                         uint32 = 4045511210
 	DefaultZoneName                              = proto.DefaultZoneName
@@ -201,10 +221,20 @@ const (
 	defaultFlowRLimit                     uint64 = 1 << 35
 	defaultLimitTypeCnt                          = 4
 	defaultClientTriggerHitCnt                   = 1
+	maxMetaGroupCommitDelayMs             uint64 = 100
 	defaultClientReqPeriodSeconds                = 1
 	defaultMaxQuotaNumPerVol                     = 100
 )
 
+const (
+	// the new replica of the migrated meta partition is caught up if its apply id lags behind the leader by no more
+	metaPartitionMigrateMaxApplyLag     uint64 = 1000
+	metaPartitionMigrateCatchUpInterval        = time.Second
+	metaPartitionMigrateCatchUpTimeout         = 30 * time.Minute
+	metaPartitionLeaderTransferInterval        = 100 * time.Millisecond
+	metaPartitionLeaderTransferTimeout         = 5 * time.Second
+)
+
 const (
 	normal uint8 = 0
 
@@ -286,6 +316,9 @@ const (
 
 	opSyncS3QosSet    uint32 = 0x60
 	opSyncS3QosDelete uint32 = 0x61
+
+	opSyncPutMountMap        uint32 = 0x62
+	opSyncPutCapabilityFlags uint32 = 0x63
 )
 
 const (
@@ -339,6 +372,9 @@ const (
 	lcNodePrefix     = keySeparator + lcNodeAcronym + keySeparator
 	lcConfPrefix     = keySeparator + lcConfigurationAcronym + keySeparator
 	S3QoSPrefix      = keySeparator + S3QoS + keySeparator
+	mountMapKey      = keySeparator + "mountMap"
+
+	capabilityFlagsKey = keySeparator + "capabilityFlags"
 )
 
 // selector enum
@@ -50,9 +50,11 @@ type DataNode struct {
 	PersistenceDataPartitions []uint64
 	BadDisks                  []string            // Keep this old field for compatibility
 	BadDiskStats              []proto.BadDiskStat // key: disk path
+	SuspectRegions            []proto.SuspectExtentRegion
 	DecommissionedDisks       sync.Map
 	ToBeOffline               bool
 	RdOnly                    bool
+	Labels                    map[string]string `graphql:"-"` // replaced as a whole on update
 	MigrateLock               sync.RWMutex
 	QosIopsRLimit             uint64
 	QosIopsWLimit             uint64
@@ -69,6 +71,8 @@ type DataNode struct {
 	ioUtils                   atomic.Value       `json:"-"`
 	DecommissionDiskList      []string
 	DecommissionDpTotal       int
+	partitionReports          proto.DataPartitionReportCache
+	Capabilities              []string
 }
 
 func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
@@ -155,6 +159,8 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 
 	dataNode.BadDisks = resp.BadDisks
 	dataNode.BadDiskStats = resp.BadDiskStats
+	dataNode.SuspectRegions = resp.SuspectRegions
+	dataNode.Capabilities = resp.Capabilities
 
 	dataNode.StartTime = resp.StartTime
 	if dataNode.Total == 0 {
@@ -266,6 +272,7 @@ func (dataNode *DataNode) createHeartbeatTask(masterAddr string, enableDiskQos b
 	request.QosFlowReadLimit = dataNode.QosFlowRLimit
 	request.QosFlowWriteLimit = dataNode.QosFlowWLimit
 	request.DecommissionDisks = dataNode.getDecommissionedDisks()
+	dataNode.partitionReports.Request(request, defaultIntervalToFullHeartbeatReport*time.Second)
 
 	task = proto.NewAdminTask(proto.OpDataNodeHeartbeat, dataNode.Addr, request)
 	return
@@ -451,10 +451,36 @@ func (partition *DataPartition) convertToDataPartitionResponse() (dpr *proto.Dat
 	dpr.LeaderAddr = partition.getLeaderAddr()
 	dpr.IsRecover = partition.isRecover
 	dpr.IsDiscard = partition.IsDiscard
+	dpr.Labels = partition.placementLabels()
 
 	return
 }
 
+// placementLabels returns the placement labels of the replicas for the clients to honor the placement
+// hints of the files, the caller should hold the lock of the partition.
+func (partition *DataPartition) placementLabels() map[string]string {
+	var (
+		nodeLabels []map[string]string
+		firstZone  string
+	)
+	for i, host := range partition.Hosts {
+		for _, replica := range partition.Replicas {
+			if replica.Addr != host || replica.dataNode == nil {
+				continue
+			}
+			if i == 0 {
+				firstZone = replica.dataNode.ZoneName
+			}
+			nodeLabels = append(nodeLabels, replica.dataNode.Labels)
+			break
+		}
+	}
+	if len(nodeLabels) == 0 {
+		return nil
+	}
+	return proto.PlacementLabels(nodeLabels, firstZone)
+}
+
 func (partition *DataPartition) getLeaderAddr() (leaderAddr string) {
 	for _, replica := range partition.Replicas {
 		if replica.IsLeader {
@@ -741,6 +767,7 @@ func (partition *DataPartition) updateMetric(vr *proto.DataPartitionReport, data
 		}
 	}
 	partition.checkAndRemoveMissReplica(dataNode.Addr)
+	c.recordDataPartitionSlo(vr, dataNode.Addr)
 
 	if replica.Status == proto.ReadWrite && (partition.RdOnly || replica.dataNode.RdOnly) {
 		replica.Status = int8(proto.ReadOnly)
@@ -893,6 +920,39 @@ func (partition *DataPartition) removeOneReplicaByHost(c *Cluster, host string,
 	return
 }
 
+// canChangeReplicaNum returns true if all the replicas of the partition are alive and none is
+// recovering, so that a replica can be added safely.
+func (partition *DataPartition) canChangeReplicaNum(timeOutSec int64) bool {
+	if !partition.IsDecommissionInitial() {
+		return false
+	}
+	partition.RLock()
+	defer partition.RUnlock()
+	if partition.isRecover || len(partition.Replicas) != len(partition.Hosts) {
+		return false
+	}
+	for _, replica := range partition.Replicas {
+		if replica.isRepairing() {
+			return false
+		}
+	}
+	return len(partition.liveReplicas(timeOutSec)) == len(partition.Hosts)
+}
+
+func (partition *DataPartition) updateReplicaNum(c *Cluster, replicaNum uint8) (oldReplicaNum uint8, err error) {
+	partition.Lock()
+	defer partition.Unlock()
+	oldReplicaNum = partition.ReplicaNum
+	if oldReplicaNum == replicaNum {
+		return
+	}
+	partition.ReplicaNum = replicaNum
+	if err = c.syncUpdateDataPartition(partition); err != nil {
+		partition.ReplicaNum = oldReplicaNum
+	}
+	return
+}
+
 func (partition *DataPartition) getNodeSets() (nodeSets []uint64) {
 	partition.RLock()
 	defer partition.RUnlock()
@@ -1524,7 +1584,7 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				partition.PartitionID, err.Error())
 			goto errHandler
 		}
-		targetHosts, _, err = ns.getAvailDataNodeHosts(partition.Hosts, 1)
+		targetHosts, _, err = ns.getAvailDataNodeHosts(c.labelExcludeHosts(partition.VolName, TypeDataPartition, partition.Hosts), 1)
 		if err != nil {
 			log.LogWarnf("action[TryAcquireDecommissionToken] dp %v choose from src nodeset failed:%v",
 				partition.PartitionID, err.Error())
@@ -1540,7 +1600,7 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				goto errHandler
 			}
 			excludeNodeSets = append(excludeNodeSets, ns.ID)
-			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, partition.Hosts, 1); err != nil {
+			if targetHosts, _, err = zone.getAvailNodeHosts(TypeDataPartition, excludeNodeSets, c.labelExcludeHosts(partition.VolName, TypeDataPartition, partition.Hosts), 1); err != nil {
 				// select data nodes from the other zone
 				zones = partition.getLiveZones(partition.DecommissionSrcAddr)
 				var excludeZone []string
@@ -1549,7 +1609,8 @@ func (partition *DataPartition) TryAcquireDecommissionToken(c *Cluster) bool {
 				} else {
 					excludeZone = append(excludeZone, zones[0])
 				}
-				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets, partition.Hosts, 1, 1, ""); err != nil {
+				if targetHosts, _, err = c.getHostFromNormalZone(TypeDataPartition, excludeZone, excludeNodeSets,
+					c.labelExcludeHosts(partition.VolName, TypeDataPartition, partition.Hosts), 1, 1, ""); err != nil {
 					log.LogWarnf("action[TryAcquireDecommissionToken] dp %v getHostFromNormalZone failed:%v",
 						partition.PartitionID, err.Error())
 					goto errHandler
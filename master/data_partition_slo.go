@@ -0,0 +1,249 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultDpSloLatencyMs      uint64 = 200
+	defaultDpSloViolationLimit        = 10
+	// the windows with less ops are too noisy to judge the replica
+	dpSloMinOpCnt = 16
+	// at most rebuild the replicas of dpSloRebuildBatch partitions in one round
+	dpSloRebuildBatch = 2
+	// the stat of a replica is dropped if it has not violated the slo for a while
+	dpSloStatExpireSec = 30 * 60
+)
+
+type replicaSloStat struct {
+	latencyUs     uint64
+	errCnt        uint64
+	violations    int
+	lastViolation int64
+}
+
+// dpSloTracker tracks the replicas violating the latency or availability slo of the
+// partitions. Each heartbeat report of a replica is a window, a violated window increases the
+// violations of the replica and a healthy one decreases it, so that only the replicas
+// repeatedly violating the slo, such as the ones on a slow disk, reach the limit.
+type dpSloTracker struct {
+	sync.RWMutex
+	volNames   map[uint64]string
+	partitions map[uint64]map[string]*replicaSloStat // partition id -> replica addr -> stat
+}
+
+func newDpSloTracker() *dpSloTracker {
+	return &dpSloTracker{
+		volNames:   make(map[uint64]string),
+		partitions: make(map[uint64]map[string]*replicaSloStat),
+	}
+}
+
+// record judges a window of the replica by the latency limit, the window is ignored
+// if it has too few ops and no error.
+func (t *dpSloTracker) record(volName string, partitionID uint64, addr string, opCnt, latencyUs, errCnt uint64,
+	latencyLimitUs uint64,
+) {
+	if opCnt < dpSloMinOpCnt && errCnt == 0 {
+		return
+	}
+	violated := errCnt > 0 || latencyUs > latencyLimitUs
+
+	t.Lock()
+	defer t.Unlock()
+	replicas, ok := t.partitions[partitionID]
+	if !ok {
+		if !violated {
+			return
+		}
+		replicas = make(map[string]*replicaSloStat)
+		t.partitions[partitionID] = replicas
+		t.volNames[partitionID] = volName
+	}
+	stat, ok := replicas[addr]
+	if !ok {
+		if !violated {
+			return
+		}
+		stat = &replicaSloStat{}
+		replicas[addr] = stat
+	}
+	if !violated {
+		if stat.violations > 0 {
+			stat.violations--
+		}
+		return
+	}
+	stat.violations++
+	stat.latencyUs = latencyUs
+	stat.errCnt = errCnt
+	stat.lastViolation = time.Now().Unix()
+}
+
+func (t *dpSloTracker) removePartition(partitionID uint64) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.partitions, partitionID)
+	delete(t.volNames, partitionID)
+}
+
+// expire drops the stats that have not violated the slo since expireTime.
+func (t *dpSloTracker) expire(expireTime int64) {
+	t.Lock()
+	defer t.Unlock()
+	for pid, replicas := range t.partitions {
+		for addr, stat := range replicas {
+			if stat.lastViolation < expireTime {
+				delete(replicas, addr)
+			}
+		}
+		if len(replicas) == 0 {
+			delete(t.partitions, pid)
+			delete(t.volNames, pid)
+		}
+	}
+}
+
+func (t *dpSloTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.volNames = make(map[uint64]string)
+	t.partitions = make(map[uint64]map[string]*replicaSloStat)
+}
+
+// violators returns the replicas with at least limit violations, the worse ones first.
+func (t *dpSloTracker) violators(limit int) (views []*proto.DataPartitionSloView) {
+	t.RLock()
+	defer t.RUnlock()
+	views = make([]*proto.DataPartitionSloView, 0)
+	for pid, replicas := range t.partitions {
+		for addr, stat := range replicas {
+			if stat.violations < limit {
+				continue
+			}
+			views = append(views, &proto.DataPartitionSloView{
+				PartitionID:   pid,
+				VolName:       t.volNames[pid],
+				Addr:          addr,
+				LatencyUs:     stat.latencyUs,
+				ErrCnt:        stat.errCnt,
+				Violations:    stat.violations,
+				LastViolation: stat.lastViolation,
+			})
+		}
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Violations != views[j].Violations {
+			return views[i].Violations > views[j].Violations
+		}
+		return views[i].PartitionID < views[j].PartitionID
+	})
+	return
+}
+
+func (c *Cluster) dpSloLatencyLimitUs() uint64 {
+	return c.dpSloLatencyMs * 1000
+}
+
+// recordDataPartitionSlo records the io stats of the replica reported by the data node. Only the
+// data nodes judge the replicas, the clients can't tell which replica of the chain slows a request.
+func (c *Cluster) recordDataPartitionSlo(vr *proto.DataPartitionReport, addr string) {
+	if !c.dpSloEnable {
+		return
+	}
+	opCnt := vr.ReadOpCnt + vr.WriteOpCnt
+	if opCnt == 0 {
+		return
+	}
+	latencyUs := (vr.ReadLatencyUs*vr.ReadOpCnt + vr.WriteLatencyUs*vr.WriteOpCnt) / opCnt
+	c.dpSloTracker.record(vr.VolName, vr.PartitionID, addr, opCnt, latencyUs, vr.IoErrCnt,
+		c.dpSloLatencyLimitUs())
+}
+
+func (c *Cluster) getDataPartitionSloInfo() *proto.DataPartitionSloInfo {
+	return &proto.DataPartitionSloInfo{
+		Enable:         c.dpSloEnable,
+		LatencyMs:      c.dpSloLatencyMs,
+		ViolationLimit: c.dpSloViolationLimit,
+		Violators:      c.dpSloTracker.violators(c.dpSloViolationLimit),
+	}
+}
+
+func (c *Cluster) scheduleToCheckDataPartitionSlo() {
+	go func() {
+		for {
+			if c.dpSloEnable && c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkDataPartitionSlo()
+			}
+			time.Sleep(time.Minute)
+		}
+	}()
+}
+
+// checkDataPartitionSlo rebuilds the replicas repeatedly violating the slo on other data nodes.
+func (c *Cluster) checkDataPartitionSlo() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkDataPartitionSlo occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkDataPartitionSlo occurred panic")
+		}
+	}()
+	c.dpSloTracker.expire(time.Now().Unix() - dpSloStatExpireSec)
+
+	violators := c.dpSloTracker.violators(c.dpSloViolationLimit)
+	violatorCnt := make(map[uint64]int)
+	for _, view := range violators {
+		violatorCnt[view.PartitionID]++
+	}
+
+	rebuilt := 0
+	for _, view := range violators {
+		if rebuilt >= dpSloRebuildBatch {
+			return
+		}
+		dp, err := c.getDataPartitionByID(view.PartitionID)
+		if err != nil || !dp.hasHost(view.Addr) {
+			c.dpSloTracker.removePartition(view.PartitionID)
+			continue
+		}
+		if dp.isRecover || dp.isSpecialReplicaCnt() || dp.IsDecommissionRunning() {
+			continue
+		}
+		// the slow path is likely not the replica if most of the replicas violate the slo
+		if violatorCnt[view.PartitionID]*2 > int(dp.ReplicaNum) {
+			log.LogWarnf("action[checkDataPartitionSlo] dp[%v] has %v replicas violating slo, skip rebuild",
+				dp.PartitionID, violatorCnt[view.PartitionID])
+			continue
+		}
+		msg := fmt.Sprintf("replica[%v] of vol[%v] dp[%v] violates slo %v times, latency[%vus] errCnt[%v]",
+			view.Addr, view.VolName, view.PartitionID, view.Violations, view.LatencyUs, view.ErrCnt)
+		if err = c.decommissionDataPartition(view.Addr, dp, false, msg); err != nil {
+			log.LogErrorf("action[checkDataPartitionSlo] rebuild %v failed, err[%v]", msg, err)
+			continue
+		}
+		c.dpSloTracker.removePartition(view.PartitionID)
+		rebuilt++
+		Warn(c.Name, fmt.Sprintf("action[checkDataPartitionSlo] rebuild %v", msg))
+	}
+}
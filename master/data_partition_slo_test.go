@@ -0,0 +1,63 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDpSloTracker(t *testing.T) {
+	tracker := newDpSloTracker()
+	limitUs := uint64(1000)
+	slowAddr, fastAddr := "192.168.0.1:17310", "192.168.0.2:17310"
+
+	for i := 0; i < 3; i++ {
+		tracker.record("vol", 1, slowAddr, 100, 2000, 0, limitUs)
+		tracker.record("vol", 1, fastAddr, 100, 500, 0, limitUs)
+	}
+	// windows with too few ops are ignored
+	tracker.record("vol", 1, fastAddr, dpSloMinOpCnt-1, 5000, 0, limitUs)
+	// errors are violations whatever the latency
+	tracker.record("vol", 2, fastAddr, 1, 10, 1, limitUs)
+
+	views := tracker.violators(3)
+	require.Len(t, views, 1)
+	require.Equal(t, uint64(1), views[0].PartitionID)
+	require.Equal(t, slowAddr, views[0].Addr)
+	require.Equal(t, "vol", views[0].VolName)
+	require.Equal(t, 3, views[0].Violations)
+
+	views = tracker.violators(1)
+	require.Len(t, views, 2)
+	require.Equal(t, slowAddr, views[0].Addr)
+	require.Equal(t, uint64(2), views[1].PartitionID)
+	require.Equal(t, uint64(1), views[1].ErrCnt)
+
+	// a healthy window decreases the violations
+	tracker.record("vol", 1, slowAddr, 100, 500, 0, limitUs)
+	require.Len(t, tracker.violators(3), 0)
+
+	tracker.removePartition(1)
+	views = tracker.violators(1)
+	require.Len(t, views, 1)
+	require.Equal(t, uint64(2), views[0].PartitionID)
+
+	tracker.expire(time.Now().Unix() + 1)
+	require.Len(t, tracker.violators(1), 0)
+	require.Len(t, tracker.partitions, 0)
+}
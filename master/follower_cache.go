@@ -0,0 +1,221 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// FollowerCacheAgeHeader is the seconds since the response served by the follower was fetched from the leader
+	FollowerCacheAgeHeader = "X-Cfs-Follower-Cache-Age"
+
+	maxFollowerCacheEntries = 1024
+	followerFetchTimeout    = 30 * time.Second
+)
+
+// the read-only admin APIs of the topology, the volumes and the statistics, which are queried
+// heavily by the dashboards and the automations
+var followerCacheAPIs = map[string]struct{}{
+	proto.AdminGetCluster:       {},
+	proto.AdminClusterStat:      {},
+	proto.AdminGetNodeInfo:      {},
+	proto.AdminListVols:         {},
+	proto.AdminGetDataPartition: {},
+	proto.GetDataNode:           {},
+	proto.GetMetaNode:           {},
+	proto.GetTopologyView:       {},
+	proto.GetAllZones:           {},
+	proto.GetNodeSet:            {},
+}
+
+// the request headers varying the responses of the leader, the responses are cached by them as well as the uri
+var followerCacheVaryHeaders = []string{proto.HeaderAcceptEncoding, proto.UserKey, proto.SkipOwnerValidation}
+
+// the hop-by-hop headers are not forwarded
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length",
+}
+
+type followerCacheEntry struct {
+	done      chan struct{} // closed once fetched
+	fetching  bool
+	fetchTime time.Time
+	status    int
+	header    http.Header
+	body      []byte
+	err       error
+}
+
+// followerCache is a TTL cache of the responses of the leader on the followers, it is not a
+// replica read of the follower's own raft state. The read-only admin APIs proxied by the followers
+// are served from the cached responses, which may be stale by up to the ttl, so the leader serves
+// each distinct query once in the ttl however many the followers receive. The concurrent queries
+// missing the cache share one query to the leader.
+type followerCache struct {
+	ttl        time.Duration
+	leaderAddr func() string
+	client     *http.Client
+
+	mu      sync.Mutex
+	entries map[string]*followerCacheEntry
+}
+
+func newFollowerCache(ttl time.Duration, leaderAddr func() string) *followerCache {
+	return &followerCache{
+		ttl:        ttl,
+		leaderAddr: leaderAddr,
+		client:     &http.Client{Timeout: followerFetchTimeout},
+		entries:    make(map[string]*followerCacheEntry),
+	}
+}
+
+func isFollowerCacheAPI(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	_, ok := followerCacheAPIs[r.URL.Path]
+	return ok
+}
+
+func followerCacheKey(r *http.Request) string {
+	key := r.URL.RequestURI()
+	for _, header := range followerCacheVaryHeaders {
+		key += "\n" + r.Header.Get(header)
+	}
+	return key
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+	for _, key := range hopHeaders {
+		dst.Del(key)
+	}
+}
+
+// serve returns false if the request is not served by the cache.
+func (oc *followerCache) serve(w http.ResponseWriter, r *http.Request) bool {
+	if oc == nil || !isFollowerCacheAPI(r) {
+		return false
+	}
+	entry, hit := oc.get(r)
+	if entry.err != nil {
+		log.LogWarnf("action[followerCache] path[%v] query[%v] fetch from leader err[%v]", r.URL.Path, r.URL.RawQuery, entry.err)
+		return false
+	}
+	if hit {
+		exporter.NewCounter("follower_cache_hit").Add(1)
+	}
+	copyHeader(w.Header(), entry.header)
+	w.Header().Set(FollowerCacheAgeHeader, strconv.Itoa(int(time.Since(entry.fetchTime)/time.Second)))
+	w.WriteHeader(entry.status)
+	if r.Method != http.MethodHead {
+		w.Write(entry.body)
+	}
+	return true
+}
+
+// get returns the entry of the request, fetches it from the leader if not cached or expired.
+func (oc *followerCache) get(r *http.Request) (entry *followerCacheEntry, hit bool) {
+	key := followerCacheKey(r)
+	oc.mu.Lock()
+	entry, ok := oc.entries[key]
+	// the entry being fetched is shared
+	if ok && (entry.fetching || time.Since(entry.fetchTime) < oc.ttl) {
+		oc.mu.Unlock()
+		<-entry.done
+		return entry, true
+	}
+	if len(oc.entries) >= maxFollowerCacheEntries {
+		oc.evict()
+	}
+	entry = &followerCacheEntry{done: make(chan struct{}), fetching: true}
+	oc.entries[key] = entry
+	oc.mu.Unlock()
+
+	oc.fetch(r, entry)
+	oc.mu.Lock()
+	entry.fetching = false
+	entry.fetchTime = time.Now()
+	if entry.err != nil && oc.entries[key] == entry {
+		delete(oc.entries, key)
+	}
+	oc.mu.Unlock()
+	close(entry.done)
+	return entry, false
+}
+
+// evict removes the expired entries, or all the entries if none expired, must be called with lock.
+func (oc *followerCache) evict() {
+	for key, entry := range oc.entries {
+		if !entry.fetching && time.Since(entry.fetchTime) >= oc.ttl {
+			delete(oc.entries, key)
+		}
+	}
+	if len(oc.entries) >= maxFollowerCacheEntries {
+		for key, entry := range oc.entries {
+			if !entry.fetching {
+				delete(oc.entries, key)
+			}
+		}
+	}
+}
+
+// fetch forwards the request with its headers to the leader.
+func (oc *followerCache) fetch(r *http.Request, entry *followerCacheEntry) {
+	leaderAddr := oc.leaderAddr()
+	if leaderAddr == "" {
+		entry.err = fmt.Errorf("no leader")
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%v%v", leaderAddr, r.URL.RequestURI()), nil)
+	if err != nil {
+		entry.err = err
+		return
+	}
+	copyHeader(req.Header, r.Header)
+	resp, err := oc.client.Do(req)
+	if err != nil {
+		entry.err = err
+		return
+	}
+	defer resp.Body.Close()
+	if entry.body, err = ioutil.ReadAll(resp.Body); err != nil {
+		entry.err = err
+		return
+	}
+	// the failures of the leader such as the meta not ready are not cached
+	if resp.StatusCode != http.StatusOK {
+		entry.err = fmt.Errorf("leader responds status %v", resp.StatusCode)
+		return
+	}
+	entry.status = resp.StatusCode
+	entry.header = make(http.Header)
+	copyHeader(entry.header, resp.Header)
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowerCache(t *testing.T) {
+	var queries int32
+	status := http.StatusOK
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-User", r.Header.Get(proto.UserKey))
+		w.WriteHeader(status)
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer leader.Close()
+	leaderAddr := strings.TrimPrefix(leader.URL, "http://")
+	oc := newFollowerCache(time.Hour, func() string { return leaderAddr })
+
+	serveAs := func(method, uri, user string) (*httptest.ResponseRecorder, bool) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, uri, nil)
+		if user != "" {
+			r.Header.Set(proto.UserKey, user)
+		}
+		return w, oc.serve(w, r)
+	}
+	serve := func(method, uri string) (*httptest.ResponseRecorder, bool) {
+		return serveAs(method, uri, "")
+	}
+
+	// not the read-only admin APIs
+	_, ok := serve(http.MethodGet, proto.AdminCreateVol)
+	require.False(t, ok)
+	_, ok = serve(http.MethodPost, proto.AdminListVols)
+	require.False(t, ok)
+	require.Equal(t, int32(0), queries)
+
+	// the concurrent queries share one query to the leader
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, ok := serve(http.MethodGet, proto.AdminListVols+"?keywords=a")
+			require.True(t, ok)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, proto.AdminListVols+"?keywords=a", w.Body.String())
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			require.NotEmpty(t, w.Header().Get(FollowerCacheAgeHeader))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), queries)
+
+	// the queries are cached by the query strings
+	w, ok := serve(http.MethodGet, proto.AdminListVols+"?keywords=b")
+	require.True(t, ok)
+	require.Equal(t, proto.AdminListVols+"?keywords=b", w.Body.String())
+	require.Equal(t, int32(2), queries)
+
+	// the headers are forwarded to the leader and the responses are cached by them
+	w, ok = serveAs(http.MethodGet, proto.AdminListVols+"?keywords=b", "u1")
+	require.True(t, ok)
+	require.Equal(t, "u1", w.Header().Get("X-User"))
+	require.Equal(t, int32(3), queries)
+	w, ok = serveAs(http.MethodGet, proto.AdminListVols+"?keywords=b", "u1")
+	require.True(t, ok)
+	require.Equal(t, "u1", w.Header().Get("X-User"))
+	require.Equal(t, int32(3), queries)
+
+	// the expired are fetched again
+	oc.ttl = 0
+	_, ok = serve(http.MethodGet, proto.AdminListVols+"?keywords=a")
+	require.True(t, ok)
+	require.Equal(t, int32(4), queries)
+
+	// the failures of the leader are proxied and not cached
+	status = http.StatusBadRequest
+	_, ok = serve(http.MethodGet, proto.GetTopologyView)
+	require.False(t, ok)
+	oc.ttl = time.Hour
+	status = http.StatusOK
+	_, ok = serve(http.MethodGet, proto.GetTopologyView)
+	require.True(t, ok)
+	require.Equal(t, int32(6), queries)
+
+	leaderAddr = ""
+	_, ok = serve(http.MethodGet, proto.GetAllZones)
+	require.False(t, ok)
+}
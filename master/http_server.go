@@ -69,6 +69,16 @@ func (m *Server) isClientPartitionsReq(r *http.Request) bool {
 	return r.URL.Path == proto.ClientDataPartitions
 }
 
+// isMasterPeer returns true if the ip is of a master in the cluster.
+func (m *Server) isMasterPeer(ip string) bool {
+	for _, peer := range m.config.peers {
+		if peer.Address == ip {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Server) isFollowerRead(r *http.Request) (followerRead bool) {
 	followerRead = false
 
@@ -96,17 +106,18 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 			func(w http.ResponseWriter, r *http.Request) {
 				log.LogDebugf("action[interceptor] request, method[%v] path[%v] query[%v]", r.Method, r.URL.Path, r.URL.Query())
 
+				caller := apiCaller(r, m.isMasterPeer)
 				if m.partition.IsRaftLeader() {
-					if err := m.cluster.apiLimiter.Wait(r.URL.Path); err != nil {
-						log.LogWarnf("action[interceptor] too many requests, path[%v]", r.URL.Path)
+					if err := m.cluster.apiLimiter.Wait(r.URL.Path, caller); err != nil {
+						log.LogWarnf("action[interceptor] too many requests, path[%v] caller[%v]", r.URL.Path, caller)
 						errMsg := fmt.Sprintf("too many requests for api: %s", html.EscapeString(r.URL.Path))
 						http.Error(w, errMsg, http.StatusTooManyRequests)
 						return
 					}
 				} else {
 					if m.cluster.apiLimiter.IsFollowerLimiter(r.URL.Path) {
-						if err := m.cluster.apiLimiter.Wait(r.URL.Path); err != nil {
-							log.LogWarnf("action[interceptor] too many requests, path[%v]", r.URL.Path)
+						if err := m.cluster.apiLimiter.Wait(r.URL.Path, caller); err != nil {
+							log.LogWarnf("action[interceptor] too many requests, path[%v] caller[%v]", r.URL.Path, caller)
 							errMsg := fmt.Sprintf("too many requests for api: %s", html.EscapeString(r.URL.Path))
 							http.Error(w, errMsg, http.StatusTooManyRequests)
 							return
@@ -137,6 +148,9 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 						http.Error(w, m.leaderInfo.addr, http.StatusBadRequest)
 						return
 					}
+					if m.followerCache.serve(w, r) {
+						return
+					}
 					m.proxy(w, r)
 				} else {
 					log.LogErrorf("action[interceptor] no leader,request[%v]", r.URL)
@@ -156,6 +170,7 @@ var AuthenticationUri2MsgTypeMap = map[string]proto.MsgType{
 	proto.RemoveRaftNode:     proto.MsgMasterRemoveRaftNodeReq,
 	proto.AdminSetNodeInfo:   proto.MsgMasterSetNodeInfoReq,
 	proto.AdminSetNodeRdOnly: proto.MsgMasterSetNodeRdOnlyReq,
+	proto.AdminSetNodeLabels: proto.MsgMasterSetNodeLabelsReq,
 
 	// Master API volume management
 	proto.AdminCreateVol: proto.MsgMasterCreateVolReq,
@@ -212,6 +227,8 @@ var AuthenticationUri2MsgTypeMap = map[string]proto.MsgType{
 	proto.UserRemovePolicy:    proto.MsgMasterUserRemovePolicyReq,
 	proto.UserDeleteVolPolicy: proto.MsgMasterUserDeleteVolPolicyReq,
 	proto.UserTransferVol:     proto.MsgMasterUserTransferVolReq,
+	proto.UserSetPosix:        proto.MsgMasterUserSetPosixReq,
+	proto.UserRemovePosix:     proto.MsgMasterUserRemovePosixReq,
 
 	// Master API zone management
 	proto.UpdateZone: proto.MsgMasterUpdateZoneReq,
@@ -264,6 +281,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminRemoveApiQpsLimit).
 		HandlerFunc(m.rmApiQpsLimit)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetApiCallerQuota).
+		HandlerFunc(m.setApiCallerQuota)
 	router.NewRoute().Name(proto.AdminGetIP).
 		Methods(http.MethodGet).
 		Path(proto.AdminGetIP).
@@ -294,6 +314,27 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolEnableAuditLog).
 		HandlerFunc(m.setEnableAuditLogForVolume)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetMetaDurability).
+		HandlerFunc(m.setVolMetaDurability)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetVerifyReadCrc).
+		HandlerFunc(m.setVolVerifyReadCrc)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetLabelSelector).
+		HandlerFunc(m.setVolLabelSelector)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetWriteBurstCredit).
+		HandlerFunc(m.setVolWriteBurstCredit)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetMetaOpLimit).
+		HandlerFunc(m.setVolMetaOpLimit)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetExtentSizeClass).
+		HandlerFunc(m.setVolExtentSizeClass)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolSetFullReadOnlyRatio).
+		HandlerFunc(m.setVolFullReadOnlyRatio)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminClusterForbidMpDecommission).
 		HandlerFunc(m.setupForbidMetaPartitionDecommission)
@@ -310,6 +351,27 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetCheckDataReplicasEnable).
 		HandlerFunc(m.setCheckDataReplicasEnable)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetDataPartitionSlo).
+		HandlerFunc(m.setDataPartitionSlo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetDataPartitionSlo).
+		HandlerFunc(m.getDataPartitionSlo)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetCapability).
+		HandlerFunc(m.setCapability)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetCapabilities).
+		HandlerFunc(m.getCapabilities)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolClientHealth).
+		HandlerFunc(m.getVolClientHealth)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetMount).
+		HandlerFunc(m.setMount)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRemoveMount).
+		HandlerFunc(m.removeMount)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetConfig).
 		HandlerFunc(m.setConfigHandler)
@@ -361,12 +423,21 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminCreateVol).
 		HandlerFunc(m.createVol)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminAdviseCreateVol).
+		HandlerFunc(m.adviseCreateVol)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.AdminGetVol).
 		HandlerFunc(m.getVolSimpleInfo)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDeleteVol).
 		HandlerFunc(m.markDeleteVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCloneVol).
+		HandlerFunc(m.cloneVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDetachVolClone).
+		HandlerFunc(m.detachVolClone)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateVol).
 		HandlerFunc(m.updateVol)
@@ -382,6 +453,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientVolStat).
 		HandlerFunc(m.getVolStatInfo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.ClientVolState).
+		HandlerFunc(m.getVolClientState)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetTopologyView).
 		HandlerFunc(m.getTopology)
@@ -425,6 +499,14 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DeleteBucketLifecycle).
 		HandlerFunc(m.DelBucketLifecycle)
+
+	// S3 bucket events APIS
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.ListBucketEvents).
+		HandlerFunc(m.listBucketEvents)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ReportBucketEvent).
+		HandlerFunc(m.reportBucketEvent)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AddLcNode).
 		HandlerFunc(m.addLcNode)
@@ -459,6 +541,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartitions).
 		HandlerFunc(m.getMetaPartitions)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.ClientReportErrors).
+		HandlerFunc(m.reportClientErrors)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.ClientMountMap).
+		HandlerFunc(m.getMountMap)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.ClientCapabilities).
+		HandlerFunc(m.negotiateCapabilities)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartition).
 		HandlerFunc(m.getMetaPartition)
@@ -648,6 +739,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateNodeSetNodeSelector).
 		HandlerFunc(m.updateNodeSetNodeSelector)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSimulatePlacement).
+		HandlerFunc(m.simulatePlacement)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateDomainDataUseRatio).
 		HandlerFunc(m.updateDataUseRatioHandler)
@@ -657,6 +751,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetNodeRdOnly).
 		HandlerFunc(m.setNodeRdOnlyHandler)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetNodeLabels).
+		HandlerFunc(m.setNodeLabelsHandler)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetPlacementViolations).
+		HandlerFunc(m.getPlacementViolations)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetDpRdOnly).
 		HandlerFunc(m.setDpRdOnlyHandler)
@@ -692,6 +792,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UserGetInfo).
 		HandlerFunc(m.getUserInfo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserGetUidInfo).
+		HandlerFunc(m.getUserUidInfo)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserSetPosix).
+		HandlerFunc(m.setUserPosix)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserRemovePosix).
+		HandlerFunc(m.removeUserPosix)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UserList).
 		HandlerFunc(m.getAllUsers)
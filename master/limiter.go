@@ -8,6 +8,7 @@ import (
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -380,6 +381,7 @@ func (qosManager *QosCtrlManager) initClientQosInfo(clientID uint64, host string
 		factorType++
 	}
 
+	qosManager.assignWriteBurstCredit(limitRsp2Client)
 	qosManager.cliInfoMgrMap[clientID] = &ClientInfoMgr{
 		Cli:    clientInitInfo,
 		Assign: limitRsp2Client,
@@ -533,6 +535,11 @@ func (qosManager *QosCtrlManager) HandleClientQosReq(reqClientInfo *proto.Client
 	}
 	qosManager.RUnlock()
 
+	if info, ok := reqClientInfo.FactorMap[proto.FlowWriteType]; ok && info.CreditConsumed > 0 {
+		exporter.NewCounter(MetricVolWriteCreditUsed).AddWithLabels(int64(info.CreditConsumed),
+			map[string]string{exporter.Vol: qosManager.vol.Name})
+	}
+
 	limitRsp = proto.NewLimitRsp2Client()
 	limitRsp.Enable = qosManager.qosEnable
 	limitRsp.ID = reqClientInfo.ID
@@ -577,6 +584,7 @@ func (qosManager *QosCtrlManager) HandleClientQosReq(reqClientInfo *proto.Client
 		index++
 	}
 	wg.Wait()
+	qosManager.assignWriteBurstCredit(limitRsp)
 
 	clientInfo.Cli = reqClientInfo
 	clientInfo.Assign = limitRsp
@@ -585,6 +593,24 @@ func (qosManager *QosCtrlManager) HandleClientQosReq(reqClientInfo *proto.Client
 	return
 }
 
+// assignWriteBurstCredit assigns the burst credits of the volume to the client in proportion to its
+// share of the write flow limit, so the credits of all the clients are up to those of the volume.
+func (qosManager *QosCtrlManager) assignWriteBurstCredit(limitRsp *proto.LimitRsp2Client) {
+	info, ok := limitRsp.FactorMap[proto.FlowWriteType]
+	if !ok || !qosManager.qosEnable {
+		return
+	}
+	total := qosManager.serverFactorLimitMap[proto.FlowWriteType].Total
+	if total == 0 {
+		return
+	}
+	credit := qosManager.vol.WriteBurstCredit
+	if info.UsedLimit < total {
+		credit = uint64(float64(credit) * float64(info.UsedLimit) / float64(total))
+	}
+	info.BurstCredit = credit
+}
+
 func (qosManager *QosCtrlManager) updateServerLimitByClientsInfo(factorType uint32) {
 	var (
 		cliSum                      proto.ClientLimitInfo
@@ -52,6 +52,7 @@ func (m *Server) handleLeaderChange(leader uint64) {
 			m.loadMetadata()
 			m.cluster.metaReady = true
 			m.metaReady = true
+			m.bucketEvents.reset()
 		}
 		m.cluster.checkDataNodeHeartbeat()
 		m.cluster.checkMetaNodeHeartbeat()
@@ -226,6 +227,18 @@ func (m *Server) loadMetadata() {
 	}
 	log.LogInfo("action[loadLcConfs] end")
 
+	log.LogInfo("action[loadMountMap] begin")
+	if err = m.cluster.loadMountMap(); err != nil {
+		panic(err)
+	}
+	log.LogInfo("action[loadMountMap] end")
+
+	log.LogInfo("action[loadCapabilityFlags] begin")
+	if err = m.cluster.loadCapabilityFlags(); err != nil {
+		panic(err)
+	}
+	log.LogInfo("action[loadCapabilityFlags] end")
+
 	log.LogInfo("action[loadLcNodes] begin")
 	if err = m.cluster.loadLcNodes(); err != nil {
 		panic(err)
@@ -46,8 +46,11 @@ type MetaNode struct {
 	ToBeOffline               bool
 	PersistenceMetaPartitions []uint64
 	RdOnly                    bool
+	Labels                    map[string]string `graphql:"-"` // replaced as a whole on update
 	MigrateLock               sync.RWMutex
 	CpuUtil                   atomicutil.Float64 `json:"-"`
+	partitionReports          proto.MetaPartitionReportCache
+	Capabilities              []string
 }
 
 func newMetaNode(addr, zoneName, clusterID string) (node *MetaNode) {
@@ -123,6 +126,7 @@ func (metaNode *MetaNode) updateMetric(resp *proto.MetaNodeHeartbeatResponse, th
 	}
 	metaNode.ZoneName = resp.ZoneName
 	metaNode.Threshold = threshold
+	metaNode.Capabilities = resp.Capabilities
 }
 
 func (metaNode *MetaNode) reachesThreshold() bool {
@@ -138,6 +142,7 @@ func (metaNode *MetaNode) createHeartbeatTask(masterAddr string, fileStatsEnable
 		MasterAddr: masterAddr,
 	}
 	request.FileStatsEnable = fileStatsEnable
+	metaNode.partitionReports.Request(request, defaultIntervalToFullHeartbeatReport*time.Second)
 	task = proto.NewAdminTask(proto.OpMetaNodeHeartbeat, metaNode.Addr, request)
 	return
 }
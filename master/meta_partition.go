@@ -44,6 +44,9 @@ type MetaReplica struct {
 	Status      int8 // unavailable, readOnly, readWrite
 	IsLeader    bool
 	metaNode    *MetaNode
+	// inodes of the replica of a cloned volume still referencing the data of the clone source
+	CloneVerSeq    uint64
+	ForkedInodeCnt uint64
 }
 
 // MetaPartition defines the structure of a meta partition
@@ -67,6 +70,7 @@ type MetaPartition struct {
 	volName          string
 	Hosts            []string
 	Peers            []proto.Peer
+	Learners         []proto.Peer // the peers replicating the logs without voting, promoted after catching up
 	OfflinePeerID    uint64
 	MissNodes        map[string]int64
 	LoadResponse     []*proto.MetaPartitionLoadResponse
@@ -75,6 +79,10 @@ type MetaPartition struct {
 	EqualCheckPass   bool
 	VerSeq           uint64
 	heartBeatDone    bool
+	// the extents of the versions up to CloneVerSeq are forked from the clone source, the
+	// replicas fork the metadata of the source partition cloneSrcID on the creation only
+	CloneVerSeq uint64
+	cloneSrcID  uint64
 
 	sync.RWMutex
 }
@@ -105,6 +113,15 @@ func (mp *MetaPartition) setPeers(peers []proto.Peer) {
 	mp.Peers = peers
 }
 
+func (mp *MetaPartition) isLearner(addr string) bool {
+	for _, learner := range mp.Learners {
+		if learner.Addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
 func (mp *MetaPartition) setHosts(hosts []string) {
 	mp.Hosts = hosts
 }
@@ -491,11 +508,24 @@ func (mp *MetaPartition) persistToRocksDB(action, volName string, newHosts []str
 	copy(oldHosts, mp.Hosts)
 	oldPeers := make([]proto.Peer, len(mp.Peers))
 	copy(oldPeers, mp.Peers)
+	oldLearners := mp.Learners
 	mp.Hosts = newHosts
 	mp.Peers = newPeers
+	// the removed peers are no longer learners
+	learners := make([]proto.Peer, 0, len(mp.Learners))
+	for _, learner := range mp.Learners {
+		for _, peer := range newPeers {
+			if peer.ID == learner.ID && peer.Addr == learner.Addr {
+				learners = append(learners, learner)
+				break
+			}
+		}
+	}
+	mp.Learners = learners
 	if err = c.syncUpdateMetaPartition(mp); err != nil {
 		mp.Hosts = oldHosts
 		mp.Peers = oldPeers
+		mp.Learners = oldLearners
 		log.LogWarnf("action[%v_persist] failed,vol[%v] partitionID:%v  old hosts:%v new hosts:%v oldPeers:%v  newPeers:%v",
 			action, volName, mp.PartitionID, mp.Hosts, newHosts, mp.Peers, newPeers)
 		return
@@ -607,6 +637,9 @@ func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers
 		Members:     peers,
 		VolName:     volName,
 		VerSeq:      mp.VerSeq,
+
+		CloneSrcPartitionID: mp.cloneSrcID,
+		CloneVerSeq:         mp.CloneVerSeq,
 	}
 	if specifyAddrs == nil {
 		hosts = mp.Hosts
@@ -666,16 +699,18 @@ func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminT
 		End:         mp.End,
 		PartitionID: mp.PartitionID,
 		Members:     mp.Peers,
+		Learners:    mp.Learners,
 		VolName:     mp.volName,
 		VerSeq:      mp.VerSeq,
+		CloneVerSeq: mp.CloneVerSeq,
 	}
 	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
 	resetMetaPartitionTaskID(t, mp.PartitionID)
 	return
 }
 
-func (mp *MetaPartition) createTaskToAddRaftMember(addPeer proto.Peer, leaderAddr string) (t *proto.AdminTask, err error) {
-	req := &proto.AddMetaPartitionRaftMemberRequest{PartitionId: mp.PartitionID, AddPeer: addPeer}
+func (mp *MetaPartition) createTaskToAddRaftMember(addPeer proto.Peer, leaderAddr string, asLearner bool) (t *proto.AdminTask, err error) {
+	req := &proto.AddMetaPartitionRaftMemberRequest{PartitionId: mp.PartitionID, AddPeer: addPeer, AsLearner: asLearner}
 	t = proto.NewAdminTask(proto.OpAddMetaPartitionRaftMember, leaderAddr, req)
 	resetMetaPartitionTaskID(t, mp.PartitionID)
 	return
@@ -759,6 +794,8 @@ func (mr *MetaReplica) updateMetric(mgr *proto.MetaPartitionReport) {
 	mr.TxRbInoCnt = mgr.TxRbInoCnt
 	mr.TxRbDenCnt = mgr.TxRbDenCnt
 	mr.FreeListLen = mgr.FreeListLen
+	mr.CloneVerSeq = mgr.CloneVerSeq
+	mr.ForkedInodeCnt = mgr.ForkedInodeCnt
 	mr.dataSize = mgr.Size
 	mr.setLastReportTime()
 
@@ -28,6 +28,37 @@ func TestMetaPartition(t *testing.T) {
 	decommissionMetaPartition(commonVol, maxPartitionID, t)
 }
 
+func TestMigrateMetaPartitionByLearner(t *testing.T) {
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	vol, err := server.cluster.getVol(commonVolName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	mp, err := vol.metaPartition(vol.maxPartitionID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	srcAddr := mp.Hosts[0]
+	if !assert.NoError(t, server.cluster.migrateMetaPartition(srcAddr, "", mp)) {
+		return
+	}
+	assert.NotContains(t, mp.Hosts, srcAddr)
+	assert.Empty(t, mp.Learners)
+
+	// the new replica is added as a learner and promoted after catching up
+	newAddr := mp.Hosts[len(mp.Hosts)-1]
+	asLearner := make([]bool, 0)
+	for _, mms := range mockMetaServers {
+		for _, req := range mms.AddRaftMemberRequests(mp.PartitionID) {
+			if req.AddPeer.Addr == newAddr {
+				asLearner = append(asLearner, req.AsLearner)
+			}
+		}
+	}
+	assert.Equal(t, []bool{true, false}, asLearner)
+}
+
 func createMetaPartition(vol *Vol, t *testing.T) {
 	count := 3
 	vol.mpsLock.RLock()
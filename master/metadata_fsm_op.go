@@ -61,6 +61,9 @@ type clusterValue struct {
 	DpRepairTimeOut             uint64
 	EnableAutoDecommissionDisk  bool
 	DecommissionDiskFactor      float64
+	DpSloEnable                 bool
+	DpSloLatencyMs              uint64
+	DpSloViolationLimit         int
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -91,6 +94,9 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		DpRepairTimeOut:             c.cfg.DpRepairTimeOut,
 		EnableAutoDecommissionDisk:  c.EnableAutoDecommissionDisk,
 		DecommissionDiskFactor:      c.DecommissionDiskFactor,
+		DpSloEnable:                 c.dpSloEnable,
+		DpSloLatencyMs:              c.dpSloLatencyMs,
+		DpSloViolationLimit:         c.dpSloViolationLimit,
 	}
 	return cv
 }
@@ -106,7 +112,9 @@ type metaPartitionValue struct {
 	Hosts         string
 	OfflinePeerID uint64
 	Peers         []bsProto.Peer
+	Learners      []bsProto.Peer
 	IsRecover     bool
+	CloneVerSeq   uint64
 }
 
 func newMetaPartitionValue(mp *MetaPartition) (mpv *metaPartitionValue) {
@@ -120,8 +128,10 @@ func newMetaPartitionValue(mp *MetaPartition) (mpv *metaPartitionValue) {
 		VolName:       mp.volName,
 		Hosts:         mp.hostsToString(),
 		Peers:         mp.Peers,
+		Learners:      mp.Learners,
 		OfflinePeerID: mp.OfflinePeerID,
 		IsRecover:     mp.IsRecover,
+		CloneVerSeq:   mp.CloneVerSeq,
 	}
 	return
 }
@@ -288,6 +298,17 @@ type volValue struct {
 	ClientReqPeriod, ClientHitTriggerCnt                   uint32
 	Forbidden                                              bool
 	EnableAuditLog                                         bool
+	CloneInfo                                              *bsProto.VolCloneInfo
+	MetaDurability                                         string
+	MetaGroupCommitDelayMs                                 uint32
+	VerifyReadCrc                                          bool
+	LabelSelector                                          string
+	WriteBurstCredit                                       uint64
+	MetaOpLimit                                            uint64
+	MetaOpBurst                                            uint64
+	ExtentSizeClass                                        string
+	FullReadOnlyRatio                                      float64
+	FullReadOnly                                           bool
 }
 
 func (v *volValue) Bytes() (raw []byte, err error) {
@@ -351,6 +372,18 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		DpReadOnlyWhenVolFull: vol.DpReadOnlyWhenVolFull,
 		Forbidden:             vol.Forbidden,
 		EnableAuditLog:        vol.EnableAuditLog,
+		CloneInfo:             vol.getCloneInfo(),
+
+		MetaDurability:         vol.MetaDurability,
+		MetaGroupCommitDelayMs: vol.MetaGroupCommitDelayMs,
+		VerifyReadCrc:          vol.VerifyReadCrc,
+		LabelSelector:          vol.LabelSelector,
+		WriteBurstCredit:       vol.WriteBurstCredit,
+		MetaOpLimit:            vol.MetaOpLimit,
+		MetaOpBurst:            vol.MetaOpBurst,
+		ExtentSizeClass:        vol.ExtentSizeClass,
+		FullReadOnlyRatio:      vol.FullReadOnlyRatio,
+		FullReadOnly:           vol.FullReadOnly,
 	}
 
 	return
@@ -380,6 +413,7 @@ type dataNodeValue struct {
 	ToBeOffline              bool
 	DecommissionDiskList     []string
 	DecommissionDpTotal      int
+	Labels                   map[string]string
 }
 
 func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
@@ -399,6 +433,7 @@ func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 		ToBeOffline:              dataNode.ToBeOffline,
 		DecommissionDiskList:     dataNode.DecommissionDiskList,
 		DecommissionDpTotal:      dataNode.DecommissionDpTotal,
+		Labels:                   dataNode.Labels,
 	}
 }
 
@@ -408,6 +443,7 @@ type metaNodeValue struct {
 	Addr      string
 	ZoneName  string
 	RdOnly    bool
+	Labels    map[string]string
 }
 
 func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
@@ -417,6 +453,7 @@ func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
 		Addr:      metaNode.Addr,
 		ZoneName:  metaNode.ZoneName,
 		RdOnly:    metaNode.RdOnly,
+		Labels:    metaNode.Labels,
 	}
 }
 
@@ -1144,6 +1181,14 @@ func (c *Cluster) loadClusterValue() (err error) {
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 
 		c.checkDataReplicasEnable = cv.CheckDataReplicasEnable
+
+		c.dpSloEnable = cv.DpSloEnable
+		if cv.DpSloLatencyMs != 0 {
+			c.dpSloLatencyMs = cv.DpSloLatencyMs
+		}
+		if cv.DpSloViolationLimit != 0 {
+			c.dpSloViolationLimit = cv.DpSloViolationLimit
+		}
 	}
 	return
 }
@@ -1358,6 +1403,7 @@ func (c *Cluster) loadDataNodes() (err error) {
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
 		dataNode.RdOnly = dnv.RdOnly
+		dataNode.Labels = dnv.Labels
 		for _, disk := range dnv.DecommissionedDisks {
 			dataNode.addDecommissionedDisk(disk)
 		}
@@ -1402,6 +1448,7 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
 		metaNode.RdOnly = mnv.RdOnly
+		metaNode.Labels = mnv.Labels
 
 		oldmn, ok := c.metaNodes.Load(metaNode.Addr)
 		if ok {
@@ -1501,8 +1548,10 @@ func (c *Cluster) loadMetaPartitions() (err error) {
 		mp := newMetaPartition(mpv.PartitionID, mpv.Start, mpv.End, vol.mpReplicaNum, vol.Name, mpv.VolID, 0)
 		mp.setHosts(strings.Split(mpv.Hosts, underlineSeparator))
 		mp.setPeers(mpv.Peers)
+		mp.Learners = mpv.Learners
 		mp.OfflinePeerID = mpv.OfflinePeerID
 		mp.IsRecover = mpv.IsRecover
+		mp.CloneVerSeq = mpv.CloneVerSeq
 		vol.addMetaPartition(mp)
 		c.addBadMetaParitionIdMap(mp)
 		log.LogInfof("action[loadMetaPartitions],vol[%v],mp[%v]", vol.Name, mp.PartitionID)
@@ -1797,3 +1846,59 @@ func (c *Cluster) loadLcConfs() (err error) {
 	}
 	return
 }
+
+func (c *Cluster) syncPutMountMap(view *bsProto.MountMapView) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncPutMountMap
+	metadata.K = mountMapKey
+	if metadata.V, err = json.Marshal(view); err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) loadMountMap() (err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(mountMapKey))
+	if err != nil {
+		err = fmt.Errorf("action[loadMountMap],err:%v", err.Error())
+		return err
+	}
+	view := &bsProto.MountMapView{}
+	for _, value := range result {
+		if err = json.Unmarshal(value, view); err != nil {
+			err = fmt.Errorf("action[loadMountMap],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+	}
+	c.mountMap.reset(view)
+	log.LogInfof("action[loadMountMap],version[%v] entries[%v]", view.Version, len(view.Entries))
+	return
+}
+
+func (c *Cluster) syncPutCapabilityFlags(disabled []string) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncPutCapabilityFlags
+	metadata.K = capabilityFlagsKey
+	if metadata.V, err = json.Marshal(disabled); err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) loadCapabilityFlags() (err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(capabilityFlagsKey))
+	if err != nil {
+		err = fmt.Errorf("action[loadCapabilityFlags],err:%v", err.Error())
+		return err
+	}
+	var disabled []string
+	for _, value := range result {
+		if err = json.Unmarshal(value, &disabled); err != nil {
+			err = fmt.Errorf("action[loadCapabilityFlags],value:%v,unmarshal err:%v", string(value), err)
+			return
+		}
+	}
+	c.capabilityFlags.reset(disabled)
+	log.LogInfof("action[loadCapabilityFlags],disabled[%v]", disabled)
+	return
+}
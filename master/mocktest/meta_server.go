@@ -34,14 +34,17 @@ type MockMetaServer struct {
 	ZoneName   string
 	mc         *master.MasterClient
 	partitions map[uint64]*MockMetaPartition // Key: metaRangeId, Val: metaPartition
+	// the requests to add the raft members received, key: partition id
+	addRaftMemberReqs map[uint64][]*proto.AddMetaPartitionRaftMemberRequest
 	sync.RWMutex
 }
 
 func NewMockMetaServer(addr string, zoneName string) *MockMetaServer {
 	mms := &MockMetaServer{
 		TcpAddr: addr, partitions: make(map[uint64]*MockMetaPartition, 0),
-		ZoneName: zoneName,
-		mc:       master.NewMasterClient([]string{hostAddr}, false),
+		addRaftMemberReqs: make(map[uint64][]*proto.AddMetaPartitionRaftMemberRequest),
+		ZoneName:          zoneName,
+		mc:                master.NewMasterClient([]string{hostAddr}, false),
 	}
 	return mms
 }
@@ -142,9 +145,27 @@ func (mms *MockMetaServer) serveConn(rc net.Conn) {
 
 func (mms *MockMetaServer) handleAddMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
+	reqData, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	req := &proto.AddMetaPartitionRaftMemberRequest{}
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	mms.Lock()
+	mms.addRaftMemberReqs[req.PartitionId] = append(mms.addRaftMemberReqs[req.PartitionId], req)
+	mms.Unlock()
 	return
 }
 
+// AddRaftMemberRequests returns the requests to add the raft members of the partition received.
+func (mms *MockMetaServer) AddRaftMemberRequests(partitionID uint64) []*proto.AddMetaPartitionRaftMemberRequest {
+	mms.RLock()
+	defer mms.RUnlock()
+	return append([]*proto.AddMetaPartitionRaftMemberRequest(nil), mms.addRaftMemberReqs[partitionID]...)
+}
+
 func (mms *MockMetaServer) handleRemoveMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
@@ -43,6 +43,7 @@ const (
 	MetricVolUsedGB            = "vol_used_GB"
 	MetricVolUsageGB           = "vol_usage_ratio"
 	MetricVolMetaCount         = "vol_meta_count"
+	MetricVolWriteCreditUsed   = "vol_write_burst_credit_consumed"
 	MetricBadMpCount           = "bad_mp_count"
 	MetricBadDpCount           = "bad_dp_count"
 	MetricDiskError            = "disk_error"
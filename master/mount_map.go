@@ -0,0 +1,132 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// mountMap stitches the volumes into one global namespace by mounting them at the
+// prefixes, the whole map is persisted in one key so that every change is atomic.
+type mountMap struct {
+	sync.RWMutex
+	version uint64
+	entries map[string]*proto.MountEntry // mapping: prefix -> entry
+}
+
+func newMountMap() *mountMap {
+	return &mountMap{entries: make(map[string]*proto.MountEntry)}
+}
+
+func (m *mountMap) view() *proto.MountMapView {
+	m.RLock()
+	defer m.RUnlock()
+	return m.viewLocked()
+}
+
+func (m *mountMap) viewLocked() *proto.MountMapView {
+	view := &proto.MountMapView{Version: m.version, Entries: make([]*proto.MountEntry, 0, len(m.entries))}
+	for _, entry := range m.entries {
+		view.Entries = append(view.Entries, entry)
+	}
+	sort.Slice(view.Entries, func(i, j int) bool { return view.Entries[i].Prefix < view.Entries[j].Prefix })
+	return view
+}
+
+func (m *mountMap) resetLocked(view *proto.MountMapView) {
+	m.version = view.Version
+	m.entries = make(map[string]*proto.MountEntry, len(view.Entries))
+	for _, entry := range view.Entries {
+		m.entries[entry.Prefix] = entry
+	}
+}
+
+func (m *mountMap) reset(view *proto.MountMapView) {
+	m.Lock()
+	defer m.Unlock()
+	m.resetLocked(view)
+}
+
+// setMount mounts the sub directory of the volume at the prefix, the entry of the
+// prefix is replaced if it exists.
+func (c *Cluster) setMount(prefix, volName, subDir string) (err error) {
+	if prefix, err = proto.CleanMountPrefix(prefix); err != nil {
+		return
+	}
+	if _, err = c.getVol(volName); err != nil {
+		return
+	}
+
+	c.mountMap.Lock()
+	defer c.mountMap.Unlock()
+	view := c.mountMap.viewLocked()
+	entry := &proto.MountEntry{
+		Prefix:     prefix,
+		VolName:    volName,
+		SubDir:     path.Clean("/" + subDir),
+		UpdateTime: time.Now().Unix(),
+	}
+	replaced := false
+	for i, e := range view.Entries {
+		if e.Prefix == prefix {
+			view.Entries[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		view.Entries = append(view.Entries, entry)
+	}
+	view.Version++
+	if err = c.syncPutMountMap(view); err != nil {
+		return
+	}
+	c.mountMap.resetLocked(view)
+	log.LogInfof("action[setMount] prefix[%v] vol[%v] subDir[%v] version[%v]", prefix, volName, entry.SubDir, view.Version)
+	return
+}
+
+func (c *Cluster) removeMount(prefix string) (err error) {
+	if prefix, err = proto.CleanMountPrefix(prefix); err != nil {
+		return
+	}
+
+	c.mountMap.Lock()
+	defer c.mountMap.Unlock()
+	if _, ok := c.mountMap.entries[prefix]; !ok {
+		return fmt.Errorf("mount prefix %v not exists", prefix)
+	}
+	view := c.mountMap.viewLocked()
+	entries := view.Entries[:0]
+	for _, e := range view.Entries {
+		if e.Prefix != prefix {
+			entries = append(entries, e)
+		}
+	}
+	view.Entries = entries
+	view.Version++
+	if err = c.syncPutMountMap(view); err != nil {
+		return
+	}
+	c.mountMap.resetLocked(view)
+	log.LogInfof("action[removeMount] prefix[%v] version[%v]", prefix, view.Version)
+	return
+}
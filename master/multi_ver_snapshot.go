@@ -579,6 +579,13 @@ func (verMgr *VolVersionManager) initVer2PhaseTask(verSeq uint64, op uint8) (ver
 }
 
 func (verMgr *VolVersionManager) createVer2PhaseTask(cluster *Cluster, verSeq uint64, op uint8, force bool) (verRsp *proto.VolVersionInfo, err error) {
+	if op == proto.DeleteVersion {
+		if clone := cluster.getVolClonePinnedVer(verMgr.vol.Name, verSeq); clone != "" {
+			err = fmt.Errorf("verseq %v is pinned by clone vol %v", verSeq, clone)
+			log.LogErrorf("vol %v createVer2PhaseTask. %v", verMgr.vol.Name, err)
+			return
+		}
+	}
 	if err = verMgr.startWork(); err != nil {
 		return
 	}
@@ -720,6 +727,23 @@ func (verMgr *VolVersionManager) init(cluster *Cluster) error {
 	return nil
 }
 
+// initCloneVer starts the versions of a cloned volume from verSeq, which is newer than the snapshot
+// version of the source the clone forks from, so that the forked extents are overwritten by appending.
+func (verMgr *VolVersionManager) initCloneVer(verSeq uint64) error {
+	verMgr.Lock()
+	verMgr.multiVersionList = []*proto.VolVersionInfo{{
+		Ver:    verSeq,
+		Status: proto.VersionNormal,
+	}}
+	verMgr.verSeq = verSeq
+	verMgr.Unlock()
+	log.LogWarnf("action[VolVersionManager.initCloneVer] vol %v verSeq %v", verMgr.vol.Name, verSeq)
+	if verMgr.c.partition.IsRaftLeader() {
+		return verMgr.Persist()
+	}
+	return nil
+}
+
 func (verMgr *VolVersionManager) getVersionInfo(verGet uint64) (verInfo *proto.VolVersionInfo, err error) {
 	verMgr.RLock()
 	defer verMgr.RUnlock()
@@ -0,0 +1,203 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	placementTypeData = "data"
+	placementTypeMeta = "meta"
+
+	placementViolationCheckInterval = 5 * time.Minute
+)
+
+func (c *Cluster) nodeLabels(addr string, nodeType uint32) map[string]string {
+	if nodeType == TypeDataPartition {
+		if dataNode, err := c.dataNode(addr); err == nil {
+			return dataNode.Labels
+		}
+		return nil
+	}
+	if metaNode, err := c.metaNode(addr); err == nil {
+		return metaNode.Labels
+	}
+	return nil
+}
+
+func (c *Cluster) volLabelSelector(volName string) proto.LabelSelector {
+	vol, err := c.getVol(volName)
+	if err != nil || vol.LabelSelector == "" {
+		return nil
+	}
+	selector, err := proto.ParseLabelSelector(vol.LabelSelector)
+	if err != nil {
+		log.LogErrorf("action[volLabelSelector] vol[%v] invalid label selector[%v], err[%v]", volName, vol.LabelSelector, err)
+		return nil
+	}
+	return selector
+}
+
+// labelExcludeHosts returns the exclude hosts appended with the nodes not selected by the
+// label selector of the volume, so that the replicas are placed on the selected nodes only.
+func (c *Cluster) labelExcludeHosts(volName string, nodeType uint32, excludeHosts []string) []string {
+	selector := c.volLabelSelector(volName)
+	if len(selector) == 0 {
+		return excludeHosts
+	}
+	hosts := make([]string, 0, len(excludeHosts))
+	hosts = append(hosts, excludeHosts...)
+	nodes := &c.metaNodes
+	if nodeType == TypeDataPartition {
+		nodes = &c.dataNodes
+	}
+	nodes.Range(func(key, value interface{}) bool {
+		var labels map[string]string
+		switch node := value.(type) {
+		case *DataNode:
+			labels = node.Labels
+		case *MetaNode:
+			labels = node.Labels
+		}
+		if !selector.Matches(labels) {
+			hosts = append(hosts, key.(string))
+		}
+		return true
+	})
+	return hosts
+}
+
+func (c *Cluster) setNodeLabels(addr string, nodeType uint32, labels map[string]string) (err error) {
+	if nodeType == TypeDataPartition {
+		c.dnMutex.Lock()
+		defer c.dnMutex.Unlock()
+		var dataNode *DataNode
+		if dataNode, err = c.dataNode(addr); err != nil {
+			return
+		}
+		oldLabels := dataNode.Labels
+		dataNode.Labels = labels
+		if err = c.syncUpdateDataNode(dataNode); err != nil {
+			dataNode.Labels = oldLabels
+			return fmt.Errorf("[setNodeLabels] syncUpdateDataNode err(%s)", err.Error())
+		}
+		return
+	}
+
+	c.mnMutex.Lock()
+	defer c.mnMutex.Unlock()
+	var metaNode *MetaNode
+	if metaNode, err = c.metaNode(addr); err != nil {
+		return
+	}
+	oldLabels := metaNode.Labels
+	metaNode.Labels = labels
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Labels = oldLabels
+		return fmt.Errorf("[setNodeLabels] syncUpdateMetaNode err(%s)", err.Error())
+	}
+	return
+}
+
+// getPlacementViolations returns the replicas on the nodes not selected by the label selectors
+// of the volumes any more, since the labels of the nodes or the selectors are changed.
+func (c *Cluster) getPlacementViolations() *proto.PlacementViolationsView {
+	view := &proto.PlacementViolationsView{Violations: make([]*proto.PlacementViolation, 0)}
+	for _, vol := range c.copyVols() {
+		if vol.LabelSelector == "" {
+			continue
+		}
+		selector, err := proto.ParseLabelSelector(vol.LabelSelector)
+		if err != nil || len(selector) == 0 {
+			continue
+		}
+		check := func(partitionID uint64, typ string, nodeType uint32, hosts []string) {
+			for _, addr := range hosts {
+				labels := c.nodeLabels(addr, nodeType)
+				if selector.Matches(labels) {
+					continue
+				}
+				view.Violations = append(view.Violations, &proto.PlacementViolation{
+					VolName:       vol.Name,
+					PartitionID:   partitionID,
+					PartitionType: typ,
+					Addr:          addr,
+					LabelSelector: vol.LabelSelector,
+					NodeLabels:    proto.FormatNodeLabels(labels),
+				})
+			}
+		}
+		for _, dp := range vol.dataPartitions.clonePartitions() {
+			dp.RLock()
+			hosts := append([]string{}, dp.Hosts...)
+			dp.RUnlock()
+			check(dp.PartitionID, placementTypeData, TypeDataPartition, hosts)
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			hosts := append([]string{}, mp.Hosts...)
+			mp.RUnlock()
+			check(mp.PartitionID, placementTypeMeta, TypeMetaPartition, hosts)
+		}
+	}
+	sort.Slice(view.Violations, func(i, j int) bool {
+		if view.Violations[i].VolName != view.Violations[j].VolName {
+			return view.Violations[i].VolName < view.Violations[j].VolName
+		}
+		return view.Violations[i].PartitionID < view.Violations[j].PartitionID
+	})
+	return view
+}
+
+func (c *Cluster) scheduleToCheckPlacementViolations() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkPlacementViolations()
+			}
+			time.Sleep(placementViolationCheckInterval)
+		}
+	}()
+}
+
+// checkPlacementViolations warns the replicas violating the label selectors, the replicas are
+// not moved automatically and are left to be decommissioned by the operators.
+func (c *Cluster) checkPlacementViolations() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkPlacementViolations occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkPlacementViolations occurred panic")
+		}
+	}()
+	violations := c.getPlacementViolations().Violations
+	if len(violations) == 0 {
+		return
+	}
+	vols := make(map[string]int)
+	for _, v := range violations {
+		vols[v.VolName]++
+	}
+	for volName, cnt := range vols {
+		Warn(c.Name, fmt.Sprintf("action[checkPlacementViolations] vol[%v] has %v replicas on the nodes not selected by label selector[%v]",
+			volName, cnt, c.volLabelSelector(volName)))
+	}
+}
@@ -35,6 +35,8 @@ const AvailableSpaceFirstNodeSelectorName = "AvailableSpaceFirst"
 
 const StrawNodeSelectorName = "Straw"
 
+const CapacityLoadBalancedNodeSelectorName = "CapacityLoadBalanced"
+
 const DefaultNodeSelectorName = CarryWeightNodeSelectorName
 
 func (ns *nodeSet) getNodes(nodeType NodeType) *sync.Map {
@@ -584,6 +586,120 @@ func NewStrawNodeSelector(nodeType NodeType) *StrawNodeSelector {
 	}
 }
 
+// NOTE: the score of a node adds up the usage ratio and the partition count relative to the most loaded node,
+// so the nodes with less used space and less partitions are selected first. The partitions selected since
+// the last heartbeat of the node are counted too, as the count is only refreshed by the heartbeat.
+type CapacityLoadBalancedNodeSelector struct {
+	nodeType NodeType
+
+	reported map[uint64]int
+	selected map[uint64]int
+}
+
+type scoredNode struct {
+	node  Node
+	usage float64
+	count int
+	score float64
+}
+
+func (s *CapacityLoadBalancedNodeSelector) GetName() string {
+	return CapacityLoadBalancedNodeSelectorName
+}
+
+func (s *CapacityLoadBalancedNodeSelector) getUsageAndCount(node interface{}) (usage float64, count int) {
+	var used, total uint64
+	switch s.nodeType {
+	case DataNodeType:
+		dataNode := node.(*DataNode)
+		used, total, count = dataNode.Used, dataNode.Total, int(dataNode.DataPartitionCount)
+	case MetaNodeType:
+		metaNode := node.(*MetaNode)
+		used, total, count = metaNode.Used, metaNode.Total, metaNode.MetaPartitionCount
+	default:
+		panic("unkown node type")
+	}
+	if total == 0 {
+		return 1, count
+	}
+	return float64(used) / float64(total), count
+}
+
+// getSelectedCount returns the times of the node selected since the partition count reported changed
+func (s *CapacityLoadBalancedNodeSelector) getSelectedCount(id uint64, reported int) int {
+	if last, ok := s.reported[id]; !ok || last != reported {
+		s.reported[id] = reported
+		s.selected[id] = 0
+	}
+	return s.selected[id]
+}
+
+func (s *CapacityLoadBalancedNodeSelector) Select(ns *nodeSet, excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+	newHosts = make([]string, 0)
+	peers = make([]proto.Peer, 0)
+	// if replica == 0, return
+	if replicaNum == 0 {
+		return
+	}
+	nodes := make([]*scoredNode, 0)
+	maxCount := 0
+	ns.getNodes(s.nodeType).Range(func(key, value interface{}) bool {
+		node := asNodeWrap(value, s.nodeType)
+		if contains(excludeHosts, node.GetAddr()) || !canAllocPartition(value, s.nodeType) {
+			return true
+		}
+		usage, count := s.getUsageAndCount(value)
+		count += s.getSelectedCount(node.GetID(), count)
+		if count > maxCount {
+			maxCount = count
+		}
+		nodes = append(nodes, &scoredNode{node: node, usage: usage, count: count})
+		return true
+	})
+	// if we cannot get enough writable nodes, return error
+	if len(nodes) < replicaNum {
+		err = fmt.Errorf("action[%vNodeSelector::Select] no enough writable hosts,replicaNum:%v  MatchNodeCount:%v  ",
+			s.GetName(), replicaNum, len(nodes))
+		return
+	}
+	for _, node := range nodes {
+		node.score = node.usage
+		if maxCount > 0 {
+			node.score += float64(node.count) / float64(maxCount)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].score == nodes[j].score {
+			return nodes[i].node.GetID() < nodes[j].node.GetID()
+		}
+		return nodes[i].score < nodes[j].score
+	})
+	orderHosts := make([]string, 0, replicaNum)
+	for _, scored := range nodes[:replicaNum] {
+		node := scored.node
+		node.SelectNodeForWrite()
+		s.selected[node.GetID()]++
+		orderHosts = append(orderHosts, node.GetAddr())
+		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
+		peers = append(peers, peer)
+	}
+	log.LogInfof("action[%vNodeSelector::Select] peers[%v]", s.GetName(), peers)
+	// reshuffle for primary-backup replication
+	if newHosts, err = reshuffleHosts(orderHosts); err != nil {
+		err = fmt.Errorf("action[%vNodeSelector::Select] err:%v  orderHosts is nil", s.GetName(), err.Error())
+		return
+	}
+	return
+}
+
+func NewCapacityLoadBalancedNodeSelector(nodeType NodeType) *CapacityLoadBalancedNodeSelector {
+	return &CapacityLoadBalancedNodeSelector{
+		nodeType: nodeType,
+		reported: make(map[uint64]int),
+		selected: make(map[uint64]int),
+	}
+}
+
 func NewNodeSelector(name string, nodeType NodeType) NodeSelector {
 	switch name {
 	case RoundRobinNodeSelectorName:
@@ -594,6 +710,8 @@ func NewNodeSelector(name string, nodeType NodeType) NodeSelector {
 		return NewAvailableSpaceFirstNodeSelector(nodeType)
 	case StrawNodeSelectorName:
 		return NewStrawNodeSelector(nodeType)
+	case CapacityLoadBalancedNodeSelectorName:
+		return NewCapacityLoadBalancedNodeSelector(nodeType)
 	default:
 		return NewCarryWeightNodeSelector(nodeType)
 	}
@@ -546,3 +546,44 @@ func TestBenchStrawNodeSelector(t *testing.T) {
 	selector = NewStrawNodeSelector(MetaNodeType)
 	metaNodeSelectorBench(t, selector)
 }
+
+func TestCapacityLoadBalancedNodeSelector(t *testing.T) {
+	nset := prepareDataNodesForBench(4, 100*util.GB, 0)
+	// the most used node is selected at last
+	val, _ := nset.dataNodes.Load("Datanode: 0")
+	node := val.(*DataNode)
+	node.Used = 80 * util.GB
+	node.AvailableSpace = 20 * util.GB
+	selector := NewCapacityLoadBalancedNodeSelector(DataNodeType)
+	_, peers, err := selector.Select(nset, nil, 3)
+	if err != nil {
+		t.Errorf("%v failed to select nodes %v", selector.GetName(), err)
+		return
+	}
+	for _, peer := range peers {
+		if peer.ID == node.ID {
+			t.Errorf("%v selected the most used node %v", selector.GetName(), node.ID)
+			return
+		}
+	}
+	// the nodes selected before the heartbeat are counted, so the partitions are spread
+	times, err := nodeSelectorBench(selector, nset, nil)
+	if err != nil {
+		t.Errorf("%v failed to Bench %v", selector.GetName(), err)
+		return
+	}
+	printNodeSelectTimes(t, times)
+	for id, count := range times {
+		if id != node.ID && count < loopNodeSelectorTestCount/4 {
+			t.Errorf("%v selected node %v only %v times", selector.GetName(), id, count)
+			return
+		}
+	}
+}
+
+func TestBenchCapacityLoadBalancedNodeSelector(t *testing.T) {
+	selector := NewCapacityLoadBalancedNodeSelector(DataNodeType)
+	dataNodeSelectorBench(t, selector)
+	selector = NewCapacityLoadBalancedNodeSelector(MetaNodeType)
+	metaNodeSelectorBench(t, selector)
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+const (
+	defaultSimulatePartitions        = 100
+	defaultSimulateReplicaNum        = 3
+	defaultSimulateMetaPartitionSize = 1 * util.GB
+
+	placementPoliciesKey = "policies"
+)
+
+// placementPolicies are the node selectors could be compared by the placement simulation
+var placementPolicies = []string{
+	CarryWeightNodeSelectorName,
+	AvailableSpaceFirstNodeSelectorName,
+	RoundRobinNodeSelectorName,
+	StrawNodeSelectorName,
+	CapacityLoadBalancedNodeSelectorName,
+}
+
+func isPlacementPolicy(name string) bool {
+	for _, policy := range placementPolicies {
+		if policy == name {
+			return true
+		}
+	}
+	return false
+}
+
+func toSelectorNodeType(nodeType uint32) NodeType {
+	if nodeType == TypeMetaPartition {
+		return MetaNodeType
+	}
+	return DataNodeType
+}
+
+// placementSnapshot exports the current topology of the node set for the placement simulation
+func (ns *nodeSet) placementSnapshot(nodeType uint32) *proto.PlacementSnapshot {
+	snapshot := &proto.PlacementSnapshot{
+		NodesetID: ns.ID,
+		NodeType:  nodeType,
+		Nodes:     make([]*proto.PlacementNodeSnapshot, 0),
+	}
+	if nodeType == TypeDataPartition {
+		ns.dataNodes.Range(func(key, value interface{}) bool {
+			dataNode := value.(*DataNode)
+			snapshot.Nodes = append(snapshot.Nodes, &proto.PlacementNodeSnapshot{
+				ID:             dataNode.ID,
+				Addr:           dataNode.Addr,
+				Total:          dataNode.Total,
+				Used:           dataNode.Used,
+				PartitionCount: int(dataNode.DataPartitionCount),
+				Writable:       dataNode.canAlloc() && dataNode.canAllocDp(),
+			})
+			return true
+		})
+	} else {
+		ns.metaNodes.Range(func(key, value interface{}) bool {
+			metaNode := value.(*MetaNode)
+			snapshot.Nodes = append(snapshot.Nodes, &proto.PlacementNodeSnapshot{
+				ID:             metaNode.ID,
+				Addr:           metaNode.Addr,
+				Total:          metaNode.Total,
+				Used:           metaNode.Used,
+				PartitionCount: metaNode.MetaPartitionCount,
+				Writable:       metaNode.isWritable(),
+			})
+			return true
+		})
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshot.Nodes[i].ID < snapshot.Nodes[j].ID
+	})
+	return snapshot
+}
+
+// placementSimulator replays the placement of partitions on a snapshot of the topology, the nodes
+// are rebuilt from the snapshot for each policy, and the space and the partition count of the nodes
+// selected are grown after each placement, as the heartbeats do in the cluster.
+type placementSimulator struct {
+	snapshot      *proto.PlacementSnapshot
+	partitions    int
+	replicaNum    int
+	partitionSize uint64
+}
+
+func newPlacementSimulator(snapshot *proto.PlacementSnapshot, partitions, replicaNum int, partitionSize uint64) *placementSimulator {
+	if partitions <= 0 {
+		partitions = defaultSimulatePartitions
+	}
+	if replicaNum <= 0 {
+		replicaNum = defaultSimulateReplicaNum
+	}
+	if partitionSize == 0 {
+		partitionSize = util.DefaultDataPartitionSize
+		if snapshot.NodeType == TypeMetaPartition {
+			partitionSize = defaultSimulateMetaPartitionSize
+		}
+	}
+	return &placementSimulator{
+		snapshot:      snapshot,
+		partitions:    partitions,
+		replicaNum:    replicaNum,
+		partitionSize: partitionSize,
+	}
+}
+
+func (sim *placementSimulator) buildNodeSet() *nodeSet {
+	ns := &nodeSet{
+		ID:        sim.snapshot.NodesetID,
+		metaNodes: new(sync.Map),
+		dataNodes: new(sync.Map),
+	}
+	for _, node := range sim.snapshot.Nodes {
+		avail := uint64(0)
+		if node.Total > node.Used {
+			avail = node.Total - node.Used
+		}
+		if sim.snapshot.NodeType == TypeMetaPartition {
+			ns.putMetaNode(&MetaNode{
+				ID:                 node.ID,
+				Addr:               node.Addr,
+				Total:              node.Total,
+				Used:               node.Used,
+				MaxMemAvailWeight:  avail,
+				MetaPartitionCount: node.PartitionCount,
+				IsActive:           node.Writable,
+			})
+			continue
+		}
+		ns.putDataNode(&DataNode{
+			ID:                 node.ID,
+			Addr:               node.Addr,
+			Total:              node.Total,
+			Used:               node.Used,
+			AvailableSpace:     avail,
+			DataPartitionCount: uint32(node.PartitionCount),
+			TotalPartitionSize: uint64(node.PartitionCount) * sim.partitionSize,
+			isActive:           node.Writable,
+		})
+	}
+	return ns
+}
+
+// place grows the node as the partition is created on it
+func (sim *placementSimulator) place(ns *nodeSet, addr string) {
+	if sim.snapshot.NodeType == TypeMetaPartition {
+		value, _ := ns.metaNodes.Load(addr)
+		metaNode := value.(*MetaNode)
+		metaNode.Used += sim.partitionSize
+		if metaNode.MaxMemAvailWeight > sim.partitionSize {
+			metaNode.MaxMemAvailWeight -= sim.partitionSize
+		} else {
+			metaNode.MaxMemAvailWeight = 0
+		}
+		metaNode.MetaPartitionCount++
+		return
+	}
+	value, _ := ns.dataNodes.Load(addr)
+	dataNode := value.(*DataNode)
+	dataNode.Used += sim.partitionSize
+	if dataNode.AvailableSpace > sim.partitionSize {
+		dataNode.AvailableSpace -= sim.partitionSize
+	} else {
+		dataNode.AvailableSpace = 0
+	}
+	dataNode.TotalPartitionSize += sim.partitionSize
+	dataNode.DataPartitionCount++
+}
+
+func (sim *placementSimulator) simulate(policy string) (view *proto.PlacementSimulationView, err error) {
+	if !isPlacementPolicy(policy) {
+		err = fmt.Errorf("unknown placement policy[%v]", policy)
+		return
+	}
+	ns := sim.buildNodeSet()
+	selector := NewNodeSelector(policy, toSelectorNodeType(sim.snapshot.NodeType))
+	view = &proto.PlacementSimulationView{Policy: policy}
+	for i := 0; i < sim.partitions; i++ {
+		hosts, _, err := selector.Select(ns, nil, sim.replicaNum)
+		if err != nil {
+			view.Failed++
+			continue
+		}
+		for _, host := range hosts {
+			sim.place(ns, host)
+		}
+		view.Placed++
+	}
+
+	usages := make([]float64, 0, len(sim.snapshot.Nodes))
+	counts := make([]float64, 0, len(sim.snapshot.Nodes))
+	for _, node := range sim.snapshot.Nodes {
+		var used, total uint64
+		var count int
+		if sim.snapshot.NodeType == TypeMetaPartition {
+			value, _ := ns.metaNodes.Load(node.Addr)
+			metaNode := value.(*MetaNode)
+			used, total, count = metaNode.Used, metaNode.Total, metaNode.MetaPartitionCount
+		} else {
+			value, _ := ns.dataNodes.Load(node.Addr)
+			dataNode := value.(*DataNode)
+			used, total, count = dataNode.Used, dataNode.Total, int(dataNode.DataPartitionCount)
+		}
+		usage := float64(1)
+		if total > 0 {
+			usage = float64(used) / float64(total)
+		}
+		if len(usages) == 0 || usage > view.MaxUsageRatio {
+			view.MaxUsageRatio = usage
+		}
+		if len(usages) == 0 || usage < view.MinUsageRatio {
+			view.MinUsageRatio = usage
+		}
+		if len(counts) == 0 || count > view.MaxPartitions {
+			view.MaxPartitions = count
+		}
+		if len(counts) == 0 || count < view.MinPartitions {
+			view.MinPartitions = count
+		}
+		usages = append(usages, usage)
+		counts = append(counts, float64(count))
+	}
+	view.UsageStdDev = stdDev(usages)
+	view.PartitionStdDev = stdDev(counts)
+	return view, nil
+}
+
+// run replays the placement with each policy and reports the balance of the nodes afterwards
+func (sim *placementSimulator) run(policies []string) (report *proto.PlacementSimulationReport, err error) {
+	report = &proto.PlacementSimulationReport{
+		Snapshot:      sim.snapshot,
+		Partitions:    sim.partitions,
+		ReplicaNum:    sim.replicaNum,
+		PartitionSize: sim.partitionSize,
+		Results:       make([]*proto.PlacementSimulationView, 0, len(policies)),
+	}
+	for _, policy := range policies {
+		view, err := sim.simulate(policy)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, view)
+	}
+	return report, nil
+}
+
+func stdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
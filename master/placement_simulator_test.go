@@ -0,0 +1,86 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/master/mocktest"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+func preparePlacementSnapshot(nodeType uint32) *proto.PlacementSnapshot {
+	snapshot := &proto.PlacementSnapshot{NodesetID: 1, NodeType: nodeType}
+	for i := 0; i < 6; i++ {
+		snapshot.Nodes = append(snapshot.Nodes, &proto.PlacementNodeSnapshot{
+			ID:             uint64(i + 1),
+			Addr:           fmt.Sprintf("node: %v", i+1),
+			Total:          10 * util.TB,
+			Used:           uint64(i) * util.TB,
+			PartitionCount: i * 10,
+			Writable:       true,
+		})
+	}
+	return snapshot
+}
+
+func TestPlacementSimulatorDataNodes(t *testing.T) {
+	snapshot := preparePlacementSnapshot(TypeDataPartition)
+	sim := newPlacementSimulator(snapshot, 50, 3, 0)
+	require.EqualValues(t, util.DefaultDataPartitionSize, sim.partitionSize)
+
+	report, err := sim.run(placementPolicies)
+	require.NoError(t, err)
+	require.Len(t, report.Results, len(placementPolicies))
+	for _, view := range report.Results {
+		mocktest.Log(t, fmt.Sprintf("%+v", view))
+		require.Equal(t, 50, view.Placed+view.Failed)
+	}
+
+	// the capacity and load balanced policy narrows the gap of the nodes
+	view := report.Results[len(report.Results)-1]
+	require.Equal(t, CapacityLoadBalancedNodeSelectorName, view.Policy)
+	require.Equal(t, 50, view.Placed)
+	require.Less(t, view.MaxPartitions-view.MinPartitions, 50)
+
+	// the snapshot is not changed by the simulation
+	require.Equal(t, 50, snapshot.Nodes[5].PartitionCount)
+	require.EqualValues(t, 5*util.TB, snapshot.Nodes[5].Used)
+}
+
+func TestPlacementSimulatorMetaNodes(t *testing.T) {
+	snapshot := preparePlacementSnapshot(TypeMetaPartition)
+	// the node not writable is never selected
+	snapshot.Nodes[0].Writable = false
+	report, err := newPlacementSimulator(snapshot, 20, 3, 0).run([]string{CapacityLoadBalancedNodeSelectorName})
+	require.NoError(t, err)
+	require.Equal(t, 20, report.Results[0].Placed)
+	require.Equal(t, 0, report.Results[0].MinPartitions)
+
+	// only 2 writable nodes left
+	snapshot.Nodes[1].Writable = false
+	snapshot.Nodes[2].Writable = false
+	snapshot.Nodes[3].Writable = false
+	report, err = newPlacementSimulator(snapshot, 20, 3, 0).run([]string{CapacityLoadBalancedNodeSelectorName})
+	require.NoError(t, err)
+	require.Equal(t, 20, report.Results[0].Failed)
+
+	_, err = newPlacementSimulator(snapshot, 20, 3, 0).run([]string{"unknown"})
+	require.Error(t, err)
+}
@@ -120,6 +120,7 @@ type Server struct {
 	config          *clusterConfig
 	cluster         *Cluster
 	user            *User
+	bucketEvents    *bucketEventLog
 	rocksDBStore    *raftstore_db.RocksDBStore
 	raftStore       raftstore.RaftStore
 	fsm             *MetadataFsm
@@ -128,6 +129,7 @@ type Server struct {
 	reverseProxy    *httputil.ReverseProxy
 	metaReady       bool
 	apiServer       *http.Server
+	followerCache   *followerCache
 }
 
 // NewServer creates a new server
@@ -140,6 +142,7 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	m.config = newClusterConfig()
 	gConfig = m.config
 	m.leaderInfo = &LeaderInfo{}
+	m.bucketEvents = newBucketEventLog()
 	m.reverseProxy = m.newReverseProxy()
 	if err = m.checkConfig(cfg); err != nil {
 		log.LogError(errors.Stack(err))
@@ -369,6 +372,14 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 	}
 	m.config.volDeletionDentryThreshold = uint64(threshold)
 
+	followerCacheSec := cfg.GetInt64WithDefault(cfgFollowerCacheSec, 0)
+	if followerCacheSec < 0 {
+		return fmt.Errorf("followerCacheSec can't be less than 0 ! ")
+	}
+	if followerCacheSec > 0 {
+		m.followerCache = newFollowerCache(time.Duration(followerCacheSec)*time.Second, func() string { return m.leaderInfo.addr })
+	}
+
 	return
 }
 
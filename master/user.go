@@ -28,6 +28,7 @@ type User struct {
 	userStore      sync.Map // K: userID, V: UserInfo
 	AKStore        sync.Map // K: ak, V: userID
 	volUser        sync.Map // K: vol, V: userIDs
+	uidStore       sync.Map // K: posix uid, V: userID
 	userStoreMutex sync.RWMutex
 	AKStoreMutex   sync.RWMutex
 	volUserMutex   sync.RWMutex
@@ -150,6 +151,9 @@ func (u *User) deleteKey(userID string) (err error) {
 	}
 	u.userStore.Delete(userID)
 	u.AKStore.Delete(akUser.AccessKey)
+	if userInfo.Posix != nil {
+		u.uidStore.Delete(userInfo.Posix.Uid)
+	}
 	// delete userID from related policy in volUserStore
 	u.removeUserFromAllVol(userID)
 	log.LogInfof("action[deleteUser], userID: %v, accesskey[%v]", userID, userInfo.AccessKey)
@@ -279,6 +283,72 @@ func (u *User) getUserInfo(userID string) (userInfo *proto.UserInfo, err error)
 	return
 }
 
+func (u *User) getUserInfoByUid(uid uint32) (userInfo *proto.UserInfo, err error) {
+	value, exist := u.uidStore.Load(uid)
+	if !exist {
+		err = proto.ErrPosixIdentityNotExists
+		return
+	}
+	if userInfo, err = u.getUserInfo(value.(string)); err != nil {
+		return
+	}
+	log.LogInfof("action[getUserInfoByUid], uid: %v, userID: %v", uid, userInfo.UserID)
+	return
+}
+
+func (u *User) setPosixIdentity(param *proto.UserPosixIdentityParam) (userInfo *proto.UserInfo, err error) {
+	u.userStoreMutex.Lock()
+	defer u.userStoreMutex.Unlock()
+	if userInfo, err = u.getUserInfo(param.UserID); err != nil {
+		return
+	}
+	if value, exist := u.uidStore.Load(param.Posix.Uid); exist && value.(string) != param.UserID {
+		err = proto.ErrDuplicatePosixUid
+		return
+	}
+	userInfo.Mu.Lock()
+	defer userInfo.Mu.Unlock()
+	formerPosix := userInfo.Posix
+	posix := param.Posix
+	userInfo.Posix = &posix
+	if err = u.syncUpdateUserInfo(userInfo); err != nil {
+		userInfo.Posix = formerPosix
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	if formerPosix != nil {
+		u.uidStore.Delete(formerPosix.Uid)
+	}
+	u.uidStore.Store(posix.Uid, userInfo.UserID)
+	log.LogInfof("action[setPosixIdentity], userID: %v, uid: %v, gid: %v, groups: %v",
+		userInfo.UserID, posix.Uid, posix.Gid, posix.Groups)
+	return
+}
+
+func (u *User) removePosixIdentity(userID string) (userInfo *proto.UserInfo, err error) {
+	u.userStoreMutex.Lock()
+	defer u.userStoreMutex.Unlock()
+	if userInfo, err = u.getUserInfo(userID); err != nil {
+		return
+	}
+	userInfo.Mu.Lock()
+	defer userInfo.Mu.Unlock()
+	formerPosix := userInfo.Posix
+	if formerPosix == nil {
+		err = proto.ErrPosixIdentityNotExists
+		return
+	}
+	userInfo.Posix = nil
+	if err = u.syncUpdateUserInfo(userInfo); err != nil {
+		userInfo.Posix = formerPosix
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	u.uidStore.Delete(formerPosix.Uid)
+	log.LogInfof("action[removePosixIdentity], userID: %v, uid: %v", userID, formerPosix.Uid)
+	return
+}
+
 func (u *User) updatePolicy(params *proto.UserPermUpdateParam) (userInfo *proto.UserInfo, err error) {
 	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
 		return
@@ -554,6 +624,10 @@ func (u *User) clearUserStore() {
 		u.userStore.Delete(key)
 		return true
 	})
+	u.uidStore.Range(func(key, value interface{}) bool {
+		u.uidStore.Delete(key)
+		return true
+	})
 }
 
 func (u *User) clearAKStore() {
@@ -116,6 +116,9 @@ func (u *User) loadUserStore() (err error) {
 			return err
 		}
 		u.userStore.Store(userInfo.UserID, userInfo)
+		if userInfo.Posix != nil {
+			u.uidStore.Store(userInfo.Posix.Uid, userInfo.UserID)
+		}
 		log.LogInfof("action[loadUserKeyInfo], userID[%v]", userInfo.UserID)
 	}
 	return
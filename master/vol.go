@@ -114,7 +114,19 @@ type Vol struct {
 	Forbidden               bool
 	mpsLock                 *mpsLockManager
 	EnableAuditLog          bool
+	MetaDurability          string
+	MetaGroupCommitDelayMs  uint32
+	VerifyReadCrc           bool
+	LabelSelector           string
+	WriteBurstCredit        uint64
+	MetaOpLimit             uint64
+	MetaOpBurst             uint64
+	ExtentSizeClass         string
+	FullReadOnlyRatio       float64
+	FullReadOnly            bool
 	preloadCapacity         uint64
+	cloneInfo               *proto.VolCloneInfo
+	cloneInfoLock           sync.RWMutex
 }
 
 func newVol(vv volValue) (vol *Vol) {
@@ -206,6 +218,17 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	}
 	vol.Forbidden = vv.Forbidden
 	vol.EnableAuditLog = vv.EnableAuditLog
+	vol.MetaDurability = vv.MetaDurability
+	vol.MetaGroupCommitDelayMs = vv.MetaGroupCommitDelayMs
+	vol.VerifyReadCrc = vv.VerifyReadCrc
+	vol.LabelSelector = vv.LabelSelector
+	vol.WriteBurstCredit = vv.WriteBurstCredit
+	vol.MetaOpLimit = vv.MetaOpLimit
+	vol.MetaOpBurst = vv.MetaOpBurst
+	vol.ExtentSizeClass = vv.ExtentSizeClass
+	vol.FullReadOnlyRatio = vv.FullReadOnlyRatio
+	vol.FullReadOnly = vv.FullReadOnly
+	vol.cloneInfo = vv.CloneInfo
 	return vol
 }
 
@@ -705,6 +728,9 @@ func (vol *Vol) checkReplicaNum(c *Cluster) {
 	}
 
 	dps := vol.cloneDataPartitionMap()
+	if !vol.raiseReplicaNum(c, dps) {
+		return
+	}
 	cnt := 0
 	for _, dp := range dps {
 		host := dp.getToBeDecommissionHost(int(vol.dpReplicaNum))
@@ -727,6 +753,58 @@ func (vol *Vol) checkReplicaNum(c *Cluster) {
 	vol.NeedToLowerReplica = false
 }
 
+// raiseReplicaNum adds one replica to each data partition that has fewer replicas than the volume,
+// partition by partition. A partition is changed only if all of its replicas are alive, and at most
+// maxRaisingReplicaDataPartitions partitions of the volume recover their new replicas at the same time.
+// It returns true if no partition lacks the replica any more.
+func (vol *Vol) raiseReplicaNum(c *Cluster, dps map[uint64]*DataPartition) (done bool) {
+	var (
+		lackReplicaDps []*DataPartition
+		recovering     int
+	)
+	for _, dp := range dps {
+		dp.RLock()
+		// select by the hosts, the partition may be raised already but not added the replica
+		// if the master stopped in between
+		if len(dp.Hosts) < int(vol.dpReplicaNum) {
+			lackReplicaDps = append(lackReplicaDps, dp)
+		}
+		if dp.isRecover {
+			recovering++
+		}
+		dp.RUnlock()
+	}
+	if len(lackReplicaDps) == 0 {
+		return true
+	}
+	for _, dp := range lackReplicaDps {
+		if recovering >= maxRaisingReplicaDataPartitions {
+			break
+		}
+		if !dp.canChangeReplicaNum(c.cfg.DataPartitionTimeOutSec) {
+			log.LogInfof("action[raiseReplicaNum] vol[%v] dp[%v] is not healthy, retry later", vol.Name, dp.PartitionID)
+			continue
+		}
+		oldReplicaNum, err := dp.updateReplicaNum(c, vol.dpReplicaNum)
+		if err != nil {
+			log.LogErrorf("action[raiseReplicaNum] vol[%v] dp[%v] err[%v]", vol.Name, dp.PartitionID, err)
+			continue
+		}
+		if success, err := c.autoAddDataReplica(dp); !success {
+			log.LogWarnf("action[raiseReplicaNum] vol[%v] dp[%v] add replica failed, err[%v]", vol.Name, dp.PartitionID, err)
+			// restore the replica num to retry the partition in the next check
+			if _, err = dp.updateReplicaNum(c, oldReplicaNum); err != nil {
+				log.LogErrorf("action[raiseReplicaNum] vol[%v] dp[%v] restore replicaNum err[%v]", vol.Name, dp.PartitionID, err)
+			}
+			continue
+		}
+		recovering++
+	}
+	log.LogInfof("action[raiseReplicaNum] vol[%v] replicaNum[%v] lack replica dps[%v] recovering[%v]",
+		vol.Name, vol.dpReplicaNum, len(lackReplicaDps), recovering)
+	return false
+}
+
 func (vol *Vol) checkMetaPartitions(c *Cluster) {
 	var tasks []*proto.AdminTask
 	metaPartitionInodeIdStep := gConfig.MetaPartitionInodeIdStep
@@ -813,6 +891,36 @@ func (mp *MetaPartition) memUsedReachThreshold(clusterName, volName string) bool
 	return true
 }
 
+// metaOpLimitShares splits the metadata operation limit of the volume among the metanodes by their
+// shares of the partition replicas of the volume, so the limit is kept however many nodes there are.
+func (vol *Vol) metaOpLimitShares() (shares map[string]*proto.VolMetaOpLimit) {
+	if vol.MetaOpLimit == 0 {
+		return
+	}
+	replicas := make(map[string]int)
+	total := 0
+	vol.mpsLock.RLock()
+	for _, mp := range vol.MetaPartitions {
+		mp.RLock()
+		for _, host := range mp.Hosts {
+			replicas[host]++
+			total++
+		}
+		mp.RUnlock()
+	}
+	vol.mpsLock.RUnlock()
+
+	shares = make(map[string]*proto.VolMetaOpLimit, len(replicas))
+	for host, cnt := range replicas {
+		shares[host] = &proto.VolMetaOpLimit{
+			Vol:   vol.Name,
+			Ops:   float64(vol.MetaOpLimit) * float64(cnt) / float64(total),
+			Burst: int((vol.MetaOpBurst*uint64(cnt) + uint64(total) - 1) / uint64(total)),
+		}
+	}
+	return
+}
+
 func (vol *Vol) cloneMetaPartitionMap() (mps map[uint64]*MetaPartition) {
 	mps = make(map[uint64]*MetaPartition, 0)
 	vol.mpsLock.RLock()
@@ -865,6 +973,12 @@ func (vol *Vol) status() uint8 {
 	return vol.Status
 }
 
+func (vol *Vol) isFullReadOnly() bool {
+	vol.volLock.RLock()
+	defer vol.volLock.RUnlock()
+	return vol.FullReadOnly
+}
+
 func (vol *Vol) capacity() uint64 {
 	vol.volLock.RLock()
 	defer vol.volLock.RUnlock()
@@ -940,6 +1054,43 @@ func (vol *Vol) shouldInhibitWriteBySpaceFull() bool {
 	return false
 }
 
+// checkFullReadOnly turns the volume read-only once its used ratio reaches FullReadOnlyRatio,
+// and writable again once the ratio drops volFullReadOnlyRecoverGap below it, by deleting the
+// data or expanding the capacity. The clients learn the state by their heartbeats.
+func (vol *Vol) checkFullReadOnly(c *Cluster) {
+	vol.volLock.Lock()
+	defer vol.volLock.Unlock()
+	if vol.Status == proto.VolStatusMarkDelete || !proto.IsHot(vol.VolType) {
+		return
+	}
+	fullReadOnly := false
+	usedRatio := float64(0)
+	if vol.FullReadOnlyRatio > 0 && vol.Capacity > 0 {
+		usedRatio = float64(vol.totalUsedSpace()) / float64(vol.Capacity*util.GB)
+		if vol.FullReadOnly {
+			fullReadOnly = usedRatio >= vol.FullReadOnlyRatio-volFullReadOnlyRecoverGap
+		} else {
+			fullReadOnly = usedRatio >= vol.FullReadOnlyRatio
+		}
+	}
+	if fullReadOnly == vol.FullReadOnly {
+		return
+	}
+	vol.FullReadOnly = fullReadOnly
+	if err := c.syncUpdateVol(vol); err != nil {
+		vol.FullReadOnly = !fullReadOnly
+		log.LogErrorf("action[checkFullReadOnly] vol[%v] fullReadOnly[%v] err[%v]", vol.Name, fullReadOnly, err)
+		return
+	}
+	msg := fmt.Sprintf("action[checkFullReadOnly] vol[%v] usedRatio[%.4f] ratio[%v] turns read-only[%v]",
+		vol.Name, usedRatio, vol.FullReadOnlyRatio, fullReadOnly)
+	if fullReadOnly {
+		WarnBySpecialKey(fmt.Sprintf("%v_%v_vol_full_read_only", c.Name, ModuleName), msg)
+		return
+	}
+	log.LogWarn(msg)
+}
+
 func (vol *Vol) needCreateDataPartition() (ok bool, err error) {
 	ok = false
 	if vol.status() == proto.VolStatusMarkDelete {
@@ -953,6 +1104,10 @@ func (vol *Vol) needCreateDataPartition() (ok bool, err error) {
 	}
 
 	if proto.IsHot(vol.VolType) {
+		if vol.isFullReadOnly() {
+			err = proto.ErrVolNoAvailableSpace
+			return
+		}
 		if vol.shouldInhibitWriteBySpaceFull() {
 			vol.setAllDataPartitionsToReadOnly()
 			err = proto.ErrVolNoAvailableSpace
@@ -1443,13 +1598,11 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 		hosts       []string
 		partitionID uint64
 		peers       []proto.Peer
-		wg          sync.WaitGroup
 	)
 
-	errChannel := make(chan error, vol.mpReplicaNum)
-
 	if c.isFaultDomain(vol) {
-		if hosts, peers, err = c.getHostFromDomainZone(vol.domainId, TypeMetaPartition, vol.mpReplicaNum); err != nil {
+		if hosts, peers, err = c.getHostFromDomainZone(vol.domainId, TypeMetaPartition, vol.mpReplicaNum,
+			c.labelExcludeHosts(vol.Name, TypeMetaPartition, nil)); err != nil {
 			log.LogErrorf("action[doCreateMetaPartition] getHostFromDomainZone err[%v]", err)
 			return nil, errors.NewError(err)
 		}
@@ -1457,7 +1610,8 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 		var excludeZone []string
 		zoneNum := c.decideZoneNum(vol.crossZone)
 
-		if hosts, peers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, nil, nil, int(vol.mpReplicaNum), zoneNum, vol.zoneName); err != nil {
+		if hosts, peers, err = c.getHostFromNormalZone(TypeMetaPartition, excludeZone, nil,
+			c.labelExcludeHosts(vol.Name, TypeMetaPartition, nil), int(vol.mpReplicaNum), zoneNum, vol.zoneName); err != nil {
 			log.LogErrorf("action[doCreateMetaPartition] getHostFromNormalZone err[%v]", err)
 			return nil, errors.NewError(err)
 		}
@@ -1473,6 +1627,20 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 	mp.setHosts(hosts)
 	mp.setPeers(peers)
 
+	if err = vol.createMetaPartitionOnHosts(c, mp); err != nil {
+		return nil, err
+	}
+	log.LogInfof("action[doCreateMetaPartition] success,volName[%v],partition[%v],start[%v],end[%v]", vol.Name, partitionID, start, end)
+	return
+}
+
+// createMetaPartitionOnHosts creates the replicas of the meta partition on its hosts,
+// the created replicas are deleted if any of them fails.
+func (vol *Vol) createMetaPartitionOnHosts(c *Cluster, mp *MetaPartition) (err error) {
+	var wg sync.WaitGroup
+	hosts := mp.Hosts
+	errChannel := make(chan error, len(hosts))
+
 	for _, host := range hosts {
 		wg.Add(1)
 		go func(host string) {
@@ -1512,11 +1680,10 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 			}(host)
 		}
 		wg.Wait()
-		return nil, errors.NewError(err)
+		return errors.NewError(err)
 	default:
 		mp.Status = proto.ReadWrite
 	}
-	log.LogInfof("action[doCreateMetaPartition] success,volName[%v],partition[%v],start[%v],end[%v]", vol.Name, partitionID, start, end)
 	return
 }
 
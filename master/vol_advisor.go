@@ -0,0 +1,176 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+// adviseVolCreation reports whether the volume of the request can be hosted by the cluster without
+// creating it. The data partitions of the volume would be created on the nodesets having enough
+// writable datanodes for the replicas in the zones of the volume, and the space required by all the
+// replicas when the volume is full is assumed to be spread over these nodesets by their available space.
+func (c *Cluster) adviseVolCreation(req *createVolReq) (advice *proto.VolCreationAdvice) {
+	advice = &proto.VolCreationAdvice{
+		VolType:    req.volType,
+		Capacity:   uint64(req.capacity),
+		ReplicaNum: req.dpReplicaNum,
+		CrossZone:  req.crossZone,
+	}
+	reject := func(format string, a ...interface{}) {
+		advice.Reasons = append(advice.Reasons, fmt.Sprintf(format, a...))
+	}
+
+	if c.DisableAutoAllocate {
+		reject("the cluster is frozen")
+	}
+	zoneName, err := c.checkZoneName(req.name, req.crossZone, req.normalZonesFirst, req.zoneName, req.domainId)
+	if err != nil {
+		reject("%v", err)
+		return
+	}
+	advice.ZoneName = zoneName
+
+	var zones []*Zone
+	if zoneName != "" {
+		for _, name := range strings.Split(zoneName, ",") {
+			zone, err := c.t.getZone(name)
+			if err != nil {
+				reject("zone %v not found", name)
+				return
+			}
+			zones = append(zones, zone)
+		}
+	} else if len(c.t.domainExcludeZones) > 0 {
+		zones = c.t.getDomainExcludeZones()
+	} else {
+		zones = c.t.getAllZones()
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].name < zones[j].name })
+
+	// the cold volume keeps the data in blobstore, only the cache is on the datanodes
+	capacity := uint64(req.capacity)
+	if proto.IsCold(req.volType) {
+		capacity = req.coldArgs.cacheCap
+	}
+	advice.RequiredSpace = capacity * util.GB * uint64(req.dpReplicaNum)
+
+	zoneNum := 1
+	if req.crossZone {
+		zoneNum = len(zones)
+	}
+	dataDemand := calculateDemandWriteNodes(zoneNum, int(req.dpReplicaNum))
+	metaDemand := calculateDemandWriteNodes(zoneNum, defaultReplicaNum)
+
+	var dataZones, metaZones int
+	for _, zone := range zones {
+		zoneAdvice := &proto.VolCreationZoneAdvice{Name: zone.name}
+		advice.Zones = append(advice.Zones, zoneAdvice)
+		if zone.getStatus() == unavailableZone {
+			continue
+		}
+		for _, ns := range zone.getAllNodeSet() {
+			if ns.canWriteForMetaNode(metaDemand) {
+				zoneAdvice.MetaNodeSets++
+			}
+			nsAdvice := ns.adviseDataSpace()
+			if nsAdvice.DataNodes < dataDemand {
+				continue
+			}
+			zoneAdvice.NodeSets = append(zoneAdvice.NodeSets, nsAdvice)
+			zoneAdvice.DataTotal += nsAdvice.DataTotal
+			zoneAdvice.DataUsed += nsAdvice.DataUsed
+			zoneAdvice.DataAvailable += nsAdvice.DataAvailable
+		}
+		sort.Slice(zoneAdvice.NodeSets, func(i, j int) bool { return zoneAdvice.NodeSets[i].ID < zoneAdvice.NodeSets[j].ID })
+		if zoneAdvice.MetaNodeSets > 0 {
+			metaZones++
+		}
+		if len(zoneAdvice.NodeSets) > 0 {
+			dataZones++
+		}
+		advice.DataTotal += zoneAdvice.DataTotal
+		advice.DataUsed += zoneAdvice.DataUsed
+		advice.DataAvailable += zoneAdvice.DataAvailable
+	}
+
+	// the space required by the replicas is shared by the nodesets by their available space
+	advice.UsedRatio, advice.PostUsedRatio = spaceRatios(advice.DataUsed, advice.DataTotal, advice.RequiredSpace)
+	for _, zoneAdvice := range advice.Zones {
+		zoneAdvice.UsedRatio, zoneAdvice.PostUsedRatio = spaceRatios(zoneAdvice.DataUsed, zoneAdvice.DataTotal,
+			shareSpace(advice.RequiredSpace, zoneAdvice.DataAvailable, advice.DataAvailable))
+		for _, nsAdvice := range zoneAdvice.NodeSets {
+			nsAdvice.UsedRatio, nsAdvice.PostUsedRatio = spaceRatios(nsAdvice.DataUsed, nsAdvice.DataTotal,
+				shareSpace(advice.RequiredSpace, nsAdvice.DataAvailable, advice.DataAvailable))
+		}
+	}
+
+	// if across zone, at least 2 zones are writable, the same as allocating zones for the partitions
+	needZones := 1
+	if zoneNum >= 2 {
+		needZones = 2
+	}
+	if metaZones < needZones {
+		reject("writable zones for meta partitions %v less than %v", metaZones, needZones)
+	}
+	if advice.RequiredSpace > 0 {
+		if dataZones < needZones {
+			reject("writable zones for data partitions %v less than %v", dataZones, needZones)
+		}
+		if advice.RequiredSpace > advice.DataAvailable {
+			reject("required space %v exceeds available space %v", advice.RequiredSpace, advice.DataAvailable)
+		}
+	}
+	advice.Feasible = len(advice.Reasons) == 0
+	return
+}
+
+// adviseDataSpace returns the space of the writable datanodes in the nodeset.
+func (ns *nodeSet) adviseDataSpace() (advice *proto.VolCreationNodeSetAdvice) {
+	advice = &proto.VolCreationNodeSetAdvice{ID: ns.ID, ZoneName: ns.zoneName}
+	ns.dataNodes.Range(func(key, value interface{}) bool {
+		node := value.(*DataNode)
+		if !node.isWriteAble() || !node.dpCntInLimit() {
+			return true
+		}
+		node.RLock()
+		advice.DataNodes++
+		advice.DataTotal += node.Total
+		advice.DataUsed += node.Used
+		advice.DataAvailable += node.AvailableSpace
+		node.RUnlock()
+		return true
+	})
+	return
+}
+
+func shareSpace(space, available, totalAvailable uint64) uint64 {
+	if totalAvailable == 0 {
+		return 0
+	}
+	return uint64(float64(space) * float64(available) / float64(totalAvailable))
+}
+
+func spaceRatios(used, total, required uint64) (usedRatio, postUsedRatio float64) {
+	if total == 0 {
+		return
+	}
+	return float64(used) / float64(total), float64(used+required) / float64(total)
+}
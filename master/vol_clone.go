@@ -0,0 +1,378 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	cloneVerReleaseRetry     = 10
+	cloneVerReleaseInterval  = 3 * time.Second
+	cloneDetachCheckInterval = 5 * time.Minute
+)
+
+// cloneVol forks a new volume from the source volume. A snapshot version is created on the source
+// to pin the shared data extents, and every meta partition of the clone is created on the hosts of
+// the source partition in the same inode range, whose replicas fork the inodes and dentries of the
+// local source replica visible at the snapshot version. The versions of the clone start from the
+// latest version of the source, so that the writes to the forked extents are copied on write.
+// The version is deleted if the clone fails to create.
+func (c *Cluster) cloneVol(req *cloneVolReq) (vol *Vol, err error) {
+	var (
+		src *Vol
+		ver *proto.VolVersionInfo
+	)
+
+	if src, err = c.getVol(req.name); err != nil {
+		return nil, proto.ErrVolNotExists
+	}
+	if !matchKey(src.Owner, req.authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	if src.Status != proto.VolStatusNormal {
+		return nil, fmt.Errorf("vol[%v] is not normal, status[%v]", src.Name, src.Status)
+	}
+	if !proto.IsHot(src.VolType) {
+		return nil, fmt.Errorf("vol[%v] is not hot vol, can't be cloned", src.Name)
+	}
+	if _, err = c.getVol(req.cloneName); err == nil {
+		return nil, proto.ErrDuplicateVol
+	}
+
+	if ver, err = src.VersionMgr.createVer2PhaseTask(c, uint64(time.Now().UnixMicro()), proto.CreateVersion, false); err != nil {
+		return nil, fmt.Errorf("action[cloneVol] vol[%v] create snapshot version failed, err[%v]", src.Name, err)
+	}
+	if ver == nil {
+		return nil, fmt.Errorf("action[cloneVol] vol[%v] create snapshot version failed, no version returned", src.Name)
+	}
+
+	createReq := newCreateVolReqFromClone(src, req)
+	createReq.cloneSrcVerSeq = ver.Ver
+	createReq.cloneVerSeq = src.VersionMgr.getLatestVer()
+	if vol, err = c.createVol(createReq); err != nil {
+		// the clone failed to create is deleted or marked to be deleted, which no longer pins the version
+		c.releaseCloneVer(src, ver.Ver)
+		return
+	}
+
+	vol.cloneInfoLock.Lock()
+	vol.cloneInfo.Status = proto.VolCloneStatusShared
+	vol.cloneInfo.CreateTime = time.Now().Unix()
+	vol.cloneInfo.Materialize = req.materialize
+	vol.cloneInfoLock.Unlock()
+
+	if err = c.syncUpdateVol(vol); err != nil {
+		log.LogErrorf("action[cloneVol] vol[%v] persist clone info failed, err[%v]", vol.Name, err)
+		vol.Status = proto.VolStatusMarkDelete
+		if errSync := c.syncUpdateVol(vol); errSync != nil {
+			log.LogErrorf("action[cloneVol] vol[%v] mark vol delete persist failed, err[%v]", vol.Name, errSync)
+			vol.Status = proto.VolStatusNormal
+		} else {
+			c.releaseCloneVer(src, ver.Ver)
+		}
+		return nil, proto.ErrPersistenceByRaft
+	}
+
+	log.LogInfof("action[cloneVol] vol[%v] cloned from vol[%v] verSeq[%v] materialize[%v]",
+		vol.Name, src.Name, ver.Ver, req.materialize)
+	return
+}
+
+// releaseCloneVer deletes the snapshot version of the source created for the clone once no clone pins
+// it. The version manager may still be committing the version just created, so it is retried for a while.
+func (c *Cluster) releaseCloneVer(src *Vol, verSeq uint64) (err error) {
+	for i := 0; i < cloneVerReleaseRetry; i++ {
+		if clone := c.getVolClonePinnedVer(src.Name, verSeq); clone != "" {
+			err = fmt.Errorf("verSeq[%v] is pinned by clone vol[%v]", verSeq, clone)
+			break
+		}
+		if info, errGet := src.VersionMgr.getVersionInfo(verSeq); errGet != nil ||
+			info.Status == proto.VersionDeleting || info.Status == proto.VersionDeleted {
+			return nil
+		}
+		if _, err = src.VersionMgr.createVer2PhaseTask(c, verSeq, proto.DeleteVersion, false); err == nil {
+			log.LogWarnf("action[releaseCloneVer] vol[%v] released clone verSeq[%v]", src.Name, verSeq)
+			return
+		}
+		time.Sleep(cloneVerReleaseInterval)
+	}
+	log.LogErrorf("action[releaseCloneVer] vol[%v] release clone verSeq[%v] failed, err[%v]", src.Name, verSeq, err)
+	return
+}
+
+func newCreateVolReqFromClone(src *Vol, req *cloneVolReq) *createVolReq {
+	owner := req.owner
+	if owner == "" {
+		owner = src.Owner
+	}
+
+	return &createVolReq{
+		name:                    req.cloneName,
+		owner:                   owner,
+		dpSize:                  int(src.dataPartitionSize / util.GB),
+		dpReplicaNum:            src.dpReplicaNum,
+		capacity:                int(src.Capacity),
+		deleteLockTime:          src.DeleteLockTime,
+		followerRead:            src.FollowerRead,
+		authenticate:            src.authenticate,
+		crossZone:               src.crossZone,
+		normalZonesFirst:        src.defaultPriority,
+		domainId:                src.domainId,
+		zoneName:                src.zoneName,
+		description:             src.description,
+		volType:                 src.VolType,
+		enablePosixAcl:          src.enablePosixAcl,
+		DpReadOnlyWhenVolFull:   src.DpReadOnlyWhenVolFull,
+		enableTransaction:       src.enableTransaction,
+		enableQuota:             src.enableQuota,
+		txTimeout:               src.txTimeout,
+		txConflictRetryNum:      src.txConflictRetryNum,
+		txConflictRetryInterval: src.txConflictRetryInterval,
+		qosLimitArgs:            &qosArgs{},
+		cloneSrc:                src,
+	}
+}
+
+// initClonedMetaPartitions creates the meta partitions forked from the meta partitions of the source volume.
+func (vol *Vol) initClonedMetaPartitions(c *Cluster, src *Vol, srcVerSeq, verSeq uint64) (err error) {
+	if err = vol.VersionMgr.initCloneVer(verSeq); err != nil {
+		return
+	}
+
+	srcMps := make([]*MetaPartition, 0)
+	for _, mp := range src.cloneMetaPartitionMap() {
+		srcMps = append(srcMps, mp)
+	}
+	sort.Slice(srcMps, func(i, j int) bool { return srcMps[i].Start < srcMps[j].Start })
+
+	cloneInfo := &proto.VolCloneInfo{
+		SrcVolName:     src.Name,
+		SrcVerSeq:      srcVerSeq,
+		MetaPartitions: make(map[uint64]uint64, len(srcMps)),
+	}
+
+	vol.createMpMutex.Lock()
+	for _, srcMp := range srcMps {
+		var mp *MetaPartition
+		if mp, err = vol.doCreateClonedMetaPartition(c, srcMp, srcVerSeq, verSeq); err != nil {
+			log.LogErrorf("action[initClonedMetaPartitions] vol[%v] clone meta partition[%v] err[%v]",
+				vol.Name, srcMp.PartitionID, err)
+			break
+		}
+		if err = c.syncAddMetaPartition(mp); err != nil {
+			err = errors.NewError(err)
+			break
+		}
+		vol.addMetaPartition(mp)
+		cloneInfo.MetaPartitions[mp.PartitionID] = srcMp.PartitionID
+	}
+	vol.createMpMutex.Unlock()
+
+	if err != nil {
+		return
+	}
+
+	vol.cloneInfoLock.Lock()
+	vol.cloneInfo = cloneInfo
+	vol.cloneInfoLock.Unlock()
+	return
+}
+
+// doCreateClonedMetaPartition creates the meta partition on the hosts of the source partition, so that
+// every replica forks the metadata from the local replica of the source partition.
+func (vol *Vol) doCreateClonedMetaPartition(c *Cluster, srcMp *MetaPartition, srcVerSeq, verSeq uint64) (mp *MetaPartition, err error) {
+	var partitionID uint64
+
+	srcMp.RLock()
+	hosts := make([]string, len(srcMp.Hosts))
+	copy(hosts, srcMp.Hosts)
+	peers := make([]proto.Peer, len(srcMp.Peers))
+	copy(peers, srcMp.Peers)
+	srcMp.RUnlock()
+	if len(hosts) != int(vol.mpReplicaNum) {
+		return nil, fmt.Errorf("source meta partition[%v] hosts[%v] mismatch replica num[%v]",
+			srcMp.PartitionID, hosts, vol.mpReplicaNum)
+	}
+
+	if partitionID, err = c.idAlloc.allocateMetaPartitionID(); err != nil {
+		return nil, errors.NewError(err)
+	}
+	mp = newMetaPartition(partitionID, srcMp.Start, srcMp.End, vol.mpReplicaNum, vol.Name, vol.ID, verSeq)
+	mp.setHosts(hosts)
+	mp.setPeers(peers)
+	mp.CloneVerSeq = srcVerSeq
+	mp.cloneSrcID = srcMp.PartitionID
+	// the replicas created later are synced by raft rather than forked
+	defer func() { mp.cloneSrcID = 0 }()
+
+	if err = vol.createMetaPartitionOnHosts(c, mp); err != nil {
+		return nil, err
+	}
+	log.LogInfof("action[doCreateClonedMetaPartition] success,volName[%v],partition[%v] forked from partition[%v] verSeq[%v]",
+		vol.Name, partitionID, srcMp.PartitionID, srcVerSeq)
+	return
+}
+
+// detachVolClone releases the source volume once no inode of the clone references the data
+// of the source any more, the replicas of the forked meta partitions report the inodes still
+// referencing the forked extents by the heartbeats.
+func (c *Cluster) detachVolClone(name, authKey string) (err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		return proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return proto.ErrVolAuthKeyNotMatch
+	}
+	if vol.getCloneInfo() == nil {
+		return fmt.Errorf("vol[%v] is not a clone", name)
+	}
+	return c.doDetachVolClone(vol)
+}
+
+func (c *Cluster) doDetachVolClone(vol *Vol) (err error) {
+	info := vol.getCloneInfo()
+	if info.Status == proto.VolCloneStatusDetached {
+		return nil
+	}
+	for mpID := range info.MetaPartitions {
+		var mp *MetaPartition
+		if mp, err = vol.metaPartition(mpID); err != nil {
+			return
+		}
+		if err = mp.checkForkedReleased(info.SrcVerSeq); err != nil {
+			return fmt.Errorf("vol[%v] can't be detached from vol[%v], %v", vol.Name, info.SrcVolName, err)
+		}
+	}
+
+	vol.cloneInfoLock.Lock()
+	oldStatus := vol.cloneInfo.Status
+	vol.cloneInfo.Status = proto.VolCloneStatusDetached
+	vol.cloneInfoLock.Unlock()
+
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.cloneInfoLock.Lock()
+		vol.cloneInfo.Status = oldStatus
+		vol.cloneInfoLock.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[detachVolClone] vol[%v] detached from source vol[%v]", vol.Name, info.SrcVolName)
+
+	// the snapshot version is useless once detached, the version is released in the background
+	// as the deletion waits for the version tasks of the source
+	if src, errGet := c.getVol(info.SrcVolName); errGet == nil {
+		go c.releaseCloneVer(src, info.SrcVerSeq)
+	}
+	return
+}
+
+func (c *Cluster) scheduleToDetachVolClones() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.detachMaterializedVolClones()
+			}
+			time.Sleep(cloneDetachCheckInterval)
+		}
+	}()
+}
+
+// detachMaterializedVolClones detaches the clones materialized, whose forked extents have all been
+// copied into the clone by the meta partitions.
+func (c *Cluster) detachMaterializedVolClones() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("detachMaterializedVolClones occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"detachMaterializedVolClones occurred panic")
+		}
+	}()
+	for _, vol := range c.copyVols() {
+		info := vol.getCloneInfo()
+		if info == nil || !info.Materialize || info.Status != proto.VolCloneStatusShared ||
+			vol.Status == proto.VolStatusMarkDelete {
+			continue
+		}
+		if err := c.doDetachVolClone(vol); err != nil {
+			log.LogInfof("action[detachMaterializedVolClones] vol[%v] is materializing, %v", vol.Name, err)
+		}
+	}
+}
+
+// checkForkedReleased checks that every replica of the forked meta partition has reported
+// that none of its inodes references the extents forked from the clone source.
+func (mp *MetaPartition) checkForkedReleased(cloneVerSeq uint64) error {
+	mp.RLock()
+	defer mp.RUnlock()
+	if len(mp.Replicas) < int(mp.ReplicaNum) {
+		return fmt.Errorf("meta partition[%v] has only %v replicas reported", mp.PartitionID, len(mp.Replicas))
+	}
+	for _, mr := range mp.Replicas {
+		if mr.CloneVerSeq != cloneVerSeq {
+			return fmt.Errorf("meta partition[%v] replica[%v] has not reported the forked inodes", mp.PartitionID, mr.Addr)
+		}
+		if mr.ForkedInodeCnt > 0 {
+			return fmt.Errorf("meta partition[%v] replica[%v] has %v inodes still referencing the data of the source",
+				mp.PartitionID, mr.Addr, mr.ForkedInodeCnt)
+		}
+	}
+	return nil
+}
+
+// getSharedVolClones returns the clones still sharing data with the source volume.
+func (c *Cluster) getSharedVolClones(srcName string) (clones []string) {
+	for name, vol := range c.copyVols() {
+		info := vol.getCloneInfo()
+		if info == nil || info.SrcVolName != srcName || vol.Status == proto.VolStatusMarkDelete {
+			continue
+		}
+		if info.Status != proto.VolCloneStatusDetached {
+			clones = append(clones, name)
+		}
+	}
+	return
+}
+
+// getVolClonePinnedVer returns a clone which pins the snapshot version of the source volume.
+func (c *Cluster) getVolClonePinnedVer(srcName string, verSeq uint64) string {
+	for name, vol := range c.copyVols() {
+		info := vol.getCloneInfo()
+		if info == nil || info.SrcVolName != srcName || vol.Status == proto.VolStatusMarkDelete {
+			continue
+		}
+		if info.SrcVerSeq == verSeq && info.Status != proto.VolCloneStatusDetached {
+			return name
+		}
+	}
+	return ""
+}
+
+func (vol *Vol) getCloneInfo() *proto.VolCloneInfo {
+	vol.cloneInfoLock.RLock()
+	defer vol.cloneInfoLock.RUnlock()
+	if vol.cloneInfo == nil {
+		return nil
+	}
+	info := *vol.cloneInfo
+	return &info
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolCloneRelation(t *testing.T) {
+	srcName := "cloneSrcVol"
+	cloneName := "cloneDstVol"
+	c := server.cluster
+
+	src := newVol(volValue{ID: 10001, Name: srcName, Owner: srcName})
+	clone := newVol(volValue{ID: 10002, Name: cloneName, Owner: srcName})
+	clone.cloneInfo = &proto.VolCloneInfo{
+		SrcVolName:     srcName,
+		SrcVerSeq:      100,
+		Status:         proto.VolCloneStatusShared,
+		MetaPartitions: map[uint64]uint64{2: 1},
+	}
+	c.putVol(src)
+	c.putVol(clone)
+	defer func() {
+		c.deleteVol(srcName)
+		c.deleteVol(cloneName)
+	}()
+
+	assert.Equal(t, []string{cloneName}, c.getSharedVolClones(srcName))
+	assert.Equal(t, cloneName, c.getVolClonePinnedVer(srcName, 100))
+	assert.Equal(t, "", c.getVolClonePinnedVer(srcName, 101))
+	assert.Nil(t, src.getCloneInfo())
+
+	// clone info is persisted with vol value
+	vv := newVolValue(clone)
+	loaded := newVolFromVolValue(vv)
+	assert.Equal(t, clone.getCloneInfo(), loaded.getCloneInfo())
+
+	// the verSeq is released after the clone is detached
+	clone.cloneInfo.Status = proto.VolCloneStatusDetached
+	assert.Empty(t, c.getSharedVolClones(srcName))
+	assert.Equal(t, "", c.getVolClonePinnedVer(srcName, 100))
+}
+
+func TestCloneVolParam(t *testing.T) {
+	req := map[string]interface{}{
+		nameKey:    commonVolName,
+		volAuthKey: buildAuthKey(testOwner),
+	}
+	reply := processWithFatalV2(proto.AdminCloneVol, false, req, t)
+	assert.Equal(t, proto.ErrCodeParamError, reply.Code)
+
+	req[nameKey] = "notExistVol"
+	req[cloneNameKey] = "cloneVol"
+	reply = processWithFatalV2(proto.AdminCloneVol, false, req, t)
+	assert.Equal(t, proto.ErrCodeVolNotExists, reply.Code)
+
+	// common vol is not a clone
+	delete(req, cloneNameKey)
+	req[nameKey] = commonVolName
+	reply = processWithFatalV2(proto.AdminDetachVolClone, false, req, t)
+	assert.NotEqual(t, proto.ErrCodeSuccess, reply.Code)
+}
+
+func TestCheckForkedReleased(t *testing.T) {
+	mp := newMetaPartition(1, 1, defaultMaxMetaPartitionInodeID, 2, "cloneVol", 1, 200)
+	mp.CloneVerSeq = 100
+
+	// the replicas not reported yet
+	mp.Replicas = []*MetaReplica{{Addr: "a"}}
+	assert.Error(t, mp.checkForkedReleased(100))
+
+	mp.Replicas = append(mp.Replicas, &MetaReplica{Addr: "b", CloneVerSeq: 100})
+	assert.Error(t, mp.checkForkedReleased(100))
+
+	mp.Replicas[0].CloneVerSeq = 100
+	mp.Replicas[1].ForkedInodeCnt = 3
+	assert.Error(t, mp.checkForkedReleased(100))
+
+	mp.Replicas[1].ForkedInodeCnt = 0
+	assert.NoError(t, mp.checkForkedReleased(100))
+}
+
+func TestDetachMaterializedVolClones(t *testing.T) {
+	c := server.cluster
+	newClone := func(id uint64, name string, materialize bool) *Vol {
+		vol := newVol(volValue{ID: id, Name: name, Owner: name})
+		mp := newMetaPartition(id, 1, defaultMaxMetaPartitionInodeID, 1, name, id, 200)
+		mp.CloneVerSeq = 100
+		mp.Replicas = []*MetaReplica{{Addr: "a", CloneVerSeq: 100}}
+		vol.addMetaPartition(mp)
+		vol.cloneInfo = &proto.VolCloneInfo{
+			SrcVolName:     "materializeSrcVol",
+			SrcVerSeq:      100,
+			Status:         proto.VolCloneStatusShared,
+			Materialize:    materialize,
+			MetaPartitions: map[uint64]uint64{id: 1},
+		}
+		c.putVol(vol)
+		return vol
+	}
+	materialized := newClone(10011, "materializedVol", true)
+	shared := newClone(10012, "sharedVol", false)
+	defer func() {
+		c.deleteVol(materialized.Name)
+		c.deleteVol(shared.Name)
+	}()
+
+	// the forked extents are not all copied yet
+	materialized.MetaPartitions[10011].Replicas[0].ForkedInodeCnt = 1
+	c.detachMaterializedVolClones()
+	assert.Equal(t, proto.VolCloneStatusShared, materialized.getCloneInfo().Status)
+
+	// only the clones to be materialized are detached automatically
+	materialized.MetaPartitions[10011].Replicas[0].ForkedInodeCnt = 0
+	c.detachMaterializedVolClones()
+	assert.Equal(t, proto.VolCloneStatusDetached, materialized.getCloneInfo().Status)
+	assert.Equal(t, proto.VolCloneStatusShared, shared.getCloneInfo().Status)
+}
+
+func TestReleaseCloneVer(t *testing.T) {
+	c := server.cluster
+	src := newVol(volValue{ID: 10021, Name: "releaseSrcVol", Owner: "releaseSrcVol"})
+	src.VersionMgr.multiVersionList = []*proto.VolVersionInfo{
+		{Ver: 0, Status: proto.VersionNormal},
+		{Ver: 100, Status: proto.VersionNormal},
+		{Ver: 200, Status: proto.VersionNormal},
+	}
+	clone := newVol(volValue{ID: 10022, Name: "releaseCloneVol", Owner: "releaseSrcVol"})
+	clone.cloneInfo = &proto.VolCloneInfo{SrcVolName: src.Name, SrcVerSeq: 100, Status: proto.VolCloneStatusShared}
+	c.putVol(clone)
+	defer c.deleteVol(clone.Name)
+
+	// the version pinned by the clone is not released
+	assert.Error(t, c.releaseCloneVer(src, 100))
+	_, err := src.VersionMgr.getVersionInfo(100)
+	assert.NoError(t, err)
+
+	// the version deleted already or deleting is taken as released
+	clone.Status = proto.VolStatusMarkDelete
+	assert.NoError(t, c.releaseCloneVer(src, 150))
+	src.VersionMgr.multiVersionList[1].Status = proto.VersionDeleting
+	assert.NoError(t, c.releaseCloneVer(src, 100))
+}
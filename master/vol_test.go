@@ -3,6 +3,8 @@ package master
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -417,3 +419,54 @@ func TestConcurrentReadWriteDataPartitionMap(t *testing.T) {
 		vol.updateViewCache(server.cluster)
 	}
 }
+
+func TestCheckRaiseReplicaNum(t *testing.T) {
+	check := func(dpReplicaNum uint8, replicaNum int) error {
+		vol := newVol(volValue{Name: "TestCheckRaiseReplicaNum", DpReplicaNum: dpReplicaNum, VolType: proto.VolumeTypeHot})
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?%v=%v", replicaNumKey, replicaNum), nil)
+		return server.checkReplicaNum(r, vol, &updateVolReq{followerRead: true})
+	}
+	assert.NoError(t, check(2, 3))
+	assert.NoError(t, check(3, 2))
+	// only one replica is added at a time, and only up to the default replica num
+	assert.Error(t, check(1, 2))
+	assert.Error(t, check(1, 3))
+	assert.Error(t, check(2, 4))
+	assert.Error(t, check(3, 4))
+}
+
+func TestRaiseReplicaNum(t *testing.T) {
+	name := "TestRaiseReplicaNum"
+	createVol(map[string]interface{}{nameKey: name, replicaNumKey: 2, followerReadKey: true}, t)
+	vol, err := server.cluster.getVol(name)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// report the replicas to master
+	server.cluster.checkDataNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+
+	dps := vol.dataPartitions.clonePartitions()
+	if !assert.True(t, len(dps) >= 2) {
+		return
+	}
+	raised, interrupted := dps[0], dps[1]
+	vol.dpReplicaNum = 3
+
+	assert.False(t, vol.raiseReplicaNum(server.cluster, map[uint64]*DataPartition{raised.PartitionID: raised}))
+	assert.Equal(t, uint8(3), raised.ReplicaNum)
+	assert.Len(t, raised.Hosts, 3)
+
+	// the master stopped after the replica num is raised but before the replica is added
+	_, err = interrupted.updateReplicaNum(server.cluster, 3)
+	assert.NoError(t, err)
+	assert.Len(t, interrupted.Hosts, 2)
+	assert.False(t, vol.raiseReplicaNum(server.cluster, map[uint64]*DataPartition{interrupted.PartitionID: interrupted}))
+	assert.Equal(t, uint8(3), interrupted.ReplicaNum)
+	assert.Len(t, interrupted.Hosts, 3)
+
+	assert.True(t, vol.raiseReplicaNum(server.cluster, map[uint64]*DataPartition{
+		raised.PartitionID:      raised,
+		interrupted.PartitionID: interrupted,
+	}))
+}
@@ -75,6 +75,15 @@ func (m *MetaNode) registerAPIHandler() (err error) {
 	http.HandleFunc("/getDentrySnapshot", m.getDentrySnapshotHandler)
 	// get tx information
 	http.HandleFunc("/getTx", m.getTxHandler)
+	// trace the operations under the paths
+	http.HandleFunc("/setPathTraceRule", m.setPathTraceRuleHandler)
+	http.HandleFunc("/delPathTraceRule", m.delPathTraceRuleHandler)
+	http.HandleFunc("/getPathTraceRules", m.getPathTraceRulesHandler)
+	http.HandleFunc("/getPathTraces", m.getPathTracesHandler)
+	http.HandleFunc("/getVolOpLimits", m.getVolOpLimitsHandler)
+	// export and import the namespace metadata of the subtrees
+	http.HandleFunc("/exportNamespace", m.exportNamespaceHandler)
+	http.HandleFunc("/importNamespace", m.importNamespaceHandler)
 	return
 }
 
@@ -0,0 +1,221 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultAttrBatchDelay = 500 * time.Microsecond
+	attrJournalOpHeadLen  = 8
+)
+
+var ErrAttrJournalStopped = errors.New("attr journal stopped")
+
+type attrJournalOp struct {
+	op   uint32
+	data []byte
+}
+
+type attrJournalResult struct {
+	resp interface{}
+	err  error
+}
+
+type attrJournalEntry struct {
+	attrJournalOp
+	done chan *attrJournalResult
+}
+
+// attrJournal groups the small and frequent inode attribute mutations, such as
+// the size and mtime updates of streaming writes, into one raft proposal.
+// An update waits at most maxDelay for others to join its proposal.
+type attrJournal struct {
+	entryC   chan *attrJournalEntry
+	stopC    chan bool
+	maxBatch int
+	maxDelay time.Duration
+	// submit proposes the raw command of a single op, or of a batch of ops
+	// with opFSMAttrJournalBatch, and waits for it to be applied.
+	submit func(op uint32, data []byte) (interface{}, error)
+}
+
+func newAttrJournal(maxBatch int, maxDelay time.Duration, stopC chan bool,
+	submit func(op uint32, data []byte) (interface{}, error),
+) *attrJournal {
+	if maxDelay <= 0 {
+		maxDelay = defaultAttrBatchDelay
+	}
+	return &attrJournal{
+		entryC:   make(chan *attrJournalEntry, maxBatch),
+		stopC:    stopC,
+		maxBatch: maxBatch,
+		maxDelay: maxDelay,
+		submit:   submit,
+	}
+}
+
+// propose queues the op to the next proposal and returns the apply result of the op.
+func (j *attrJournal) propose(op uint32, data []byte) (resp interface{}, err error) {
+	entry := &attrJournalEntry{
+		attrJournalOp: attrJournalOp{op: op, data: data},
+		done:          make(chan *attrJournalResult, 1),
+	}
+	select {
+	case j.entryC <- entry:
+	case <-j.stopC:
+		return nil, ErrAttrJournalStopped
+	}
+	select {
+	case result := <-entry.done:
+		return result.resp, result.err
+	case <-j.stopC:
+		return nil, ErrAttrJournalStopped
+	}
+}
+
+func (j *attrJournal) run() {
+	for {
+		select {
+		case entry := <-j.entryC:
+			batch := []*attrJournalEntry{entry}
+			timer := time.NewTimer(j.maxDelay)
+		collect:
+			for len(batch) < j.maxBatch {
+				select {
+				case entry = <-j.entryC:
+					batch = append(batch, entry)
+				case <-timer.C:
+					break collect
+				case <-j.stopC:
+					timer.Stop()
+					return
+				}
+			}
+			timer.Stop()
+			j.flush(batch)
+		case <-j.stopC:
+			return
+		}
+	}
+}
+
+func (j *attrJournal) flush(batch []*attrJournalEntry) {
+	if len(batch) == 1 {
+		resp, err := j.submit(batch[0].op, batch[0].data)
+		batch[0].done <- &attrJournalResult{resp: resp, err: err}
+		return
+	}
+	ops := make([]*attrJournalOp, 0, len(batch))
+	for _, entry := range batch {
+		ops = append(ops, &entry.attrJournalOp)
+	}
+	resp, err := j.submit(opFSMAttrJournalBatch, marshalAttrJournalOps(ops))
+	results, ok := resp.([]*attrJournalResult)
+	if err == nil && (!ok || len(results) != len(batch)) {
+		err = fmt.Errorf("attr journal batch got %v results of %v ops", len(results), len(batch))
+	}
+	for i, entry := range batch {
+		if err != nil {
+			entry.done <- &attrJournalResult{err: err}
+			continue
+		}
+		entry.done <- results[i]
+	}
+	log.LogDebugf("action[attrJournal.flush] proposed %v ops err(%v)", len(batch), err)
+}
+
+func marshalAttrJournalOps(ops []*attrJournalOp) []byte {
+	size := 0
+	for _, op := range ops {
+		size += attrJournalOpHeadLen + len(op.data)
+	}
+	buf := make([]byte, size)
+	off := 0
+	for _, op := range ops {
+		binary.BigEndian.PutUint32(buf[off:off+4], op.op)
+		binary.BigEndian.PutUint32(buf[off+4:off+8], uint32(len(op.data)))
+		off += attrJournalOpHeadLen
+		off += copy(buf[off:], op.data)
+	}
+	return buf
+}
+
+func unmarshalAttrJournalOps(raw []byte) (ops []*attrJournalOp, err error) {
+	for len(raw) > 0 {
+		if len(raw) < attrJournalOpHeadLen {
+			return nil, fmt.Errorf("attr journal op head is truncated, left %v bytes", len(raw))
+		}
+		op := binary.BigEndian.Uint32(raw[0:4])
+		dataLen := binary.BigEndian.Uint32(raw[4:8])
+		raw = raw[attrJournalOpHeadLen:]
+		if uint64(len(raw)) < uint64(dataLen) {
+			return nil, fmt.Errorf("attr journal op(%v) data is truncated, expect %v left %v bytes", op, dataLen, len(raw))
+		}
+		ops = append(ops, &attrJournalOp{op: op, data: raw[:dataLen]})
+		raw = raw[dataLen:]
+	}
+	return
+}
+
+// fsmAttrJournalBatch applies the ops of a batch in the order they were proposed.
+func (mp *metaPartition) fsmAttrJournalBatch(ops []*attrJournalOp) (results []*attrJournalResult) {
+	results = make([]*attrJournalResult, 0, len(ops))
+	for _, op := range ops {
+		result := &attrJournalResult{}
+		switch op.op {
+		case opFSMSetAttr:
+			req := &SetattrRequest{}
+			if result.err = json.Unmarshal(op.data, req); result.err == nil {
+				result.err = mp.fsmSetAttr(req)
+			}
+		case opFSMExtentsAddWithCheck:
+			ino := NewInode(0, 0)
+			if result.err = ino.Unmarshal(op.data); result.err == nil {
+				result.resp = mp.fsmAppendExtentsWithCheck(ino, false)
+			}
+		default:
+			result.err = fmt.Errorf("op(%v) is not supported by attr journal", op.op)
+		}
+		results = append(results, result)
+	}
+	return
+}
+
+// submitAttr proposes an attribute mutation through the attr journal if it is enabled.
+func (mp *metaPartition) submitAttr(op uint32, data []byte) (resp interface{}, err error) {
+	if mp.attrJournal == nil {
+		return mp.submit(op, data)
+	}
+	return mp.attrJournal.propose(op, data)
+}
+
+func (mp *metaPartition) startAttrJournal() {
+	if mp.manager == nil || mp.manager.metaNode == nil || mp.manager.metaNode.attrBatchCount <= 1 {
+		return
+	}
+	metaNode := mp.manager.metaNode
+	mp.attrJournal = newAttrJournal(metaNode.attrBatchCount, metaNode.attrBatchDelay, mp.stopC, mp.submit)
+	go mp.attrJournal.run()
+	log.LogInfof("action[startAttrJournal] mp[%v] batch count(%v) delay(%v)",
+		mp.config.PartitionId, mp.attrJournal.maxBatch, mp.attrJournal.maxDelay)
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttrJournalOpsMarshal(t *testing.T) {
+	ops := []*attrJournalOp{
+		{op: opFSMSetAttr, data: []byte(`{"ino":1}`)},
+		{op: opFSMExtentsAddWithCheck, data: []byte{}},
+		{op: opFSMSetAttr, data: []byte(`{"ino":2}`)},
+	}
+	raw := marshalAttrJournalOps(ops)
+	decoded, err := unmarshalAttrJournalOps(raw)
+	require.NoError(t, err)
+	require.Equal(t, len(ops), len(decoded))
+	for i := range ops {
+		require.Equal(t, ops[i].op, decoded[i].op)
+		require.Equal(t, ops[i].data, decoded[i].data)
+	}
+
+	_, err = unmarshalAttrJournalOps(raw[:len(raw)-1])
+	require.Error(t, err)
+	_, err = unmarshalAttrJournalOps(raw[:attrJournalOpHeadLen-1])
+	require.Error(t, err)
+}
+
+func TestAttrJournalPropose(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		proposals []uint32
+	)
+	submit := func(op uint32, data []byte) (interface{}, error) {
+		mu.Lock()
+		proposals = append(proposals, op)
+		mu.Unlock()
+		if op != opFSMAttrJournalBatch {
+			return uint8(len(data)), nil
+		}
+		ops, err := unmarshalAttrJournalOps(data)
+		require.NoError(t, err)
+		results := make([]*attrJournalResult, 0, len(ops))
+		for _, op := range ops {
+			results = append(results, &attrJournalResult{resp: uint8(len(op.data))})
+		}
+		return results, nil
+	}
+	stopC := make(chan bool)
+	j := newAttrJournal(4, 50*time.Millisecond, stopC, submit)
+	go j.run()
+
+	// a lone update is proposed as is after the delay
+	resp, err := j.propose(opFSMSetAttr, []byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), resp)
+	require.Equal(t, []uint32{opFSMSetAttr}, proposals)
+
+	// concurrent updates are grouped and each gets its own result
+	var wg sync.WaitGroup
+	for i := 1; i <= 4; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			resp, err := j.propose(opFSMSetAttr, make([]byte, n))
+			require.NoError(t, err)
+			require.Equal(t, uint8(n), resp)
+		}(i)
+	}
+	wg.Wait()
+	require.Less(t, len(proposals), 5)
+	require.Equal(t, uint32(opFSMAttrJournalBatch), proposals[len(proposals)-1])
+
+	close(stopC)
+	_, err = j.propose(opFSMSetAttr, nil)
+	require.ErrorIs(t, err, ErrAttrJournalStopped)
+}
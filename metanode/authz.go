@@ -0,0 +1,320 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the actions of the namespace operations consulted with the authorizer
+const (
+	AuthzActionCreate = "create"
+	AuthzActionDelete = "delete"
+	AuthzActionUpdate = "update"
+)
+
+const (
+	authzPluginHTTP         = "http"
+	defaultAuthzTimeout     = 500 * time.Millisecond
+	defaultAuthzCacheTTL    = 30 * time.Second
+	defaultAuthzCacheSize   = 1 << 16
+	authzDeniedReasonPrefix = "denied by authorizer"
+)
+
+var ErrAuthzDenied = errors.New(authzDeniedReasonPrefix)
+
+// AuthzRequest describes a namespace operation on the dentry Name of ParentID, or an inode
+// operation on Inode. Path is the full path of the dentry verified by the metanode, it is empty
+// for the inode operations or if the client does not report it. Client is the address of the
+// connection of the client.
+type AuthzRequest struct {
+	Volume   string `json:"volume"`
+	Action   string `json:"action"`
+	Op       string `json:"op"`
+	ParentID uint64 `json:"parentId"`
+	Name     string `json:"name"`
+	Inode    uint64 `json:"inode"`
+	Path     string `json:"path"`
+	Client   string `json:"client"`
+}
+
+func (r *AuthzRequest) cacheKey() string {
+	return fmt.Sprintf("%s|%s|%d|%s|%d|%s|%s", r.Volume, r.Action, r.ParentID, r.Name, r.Inode, r.Path, r.Client)
+}
+
+// Authorizer consults an external policy engine on the namespace operations.
+type Authorizer interface {
+	Authorize(req *AuthzRequest) (allowed bool, err error)
+}
+
+// AuthorizerConstructor creates the authorizer from the metanode config.
+type AuthorizerConstructor func(cfg *config.Config) (Authorizer, error)
+
+var (
+	authorizersMu sync.RWMutex
+	authorizers   = make(map[string]AuthorizerConstructor)
+)
+
+// RegisterAuthorizer registers an authorizer plugin, the plugin is chosen by cfgAuthzPlugin.
+func RegisterAuthorizer(name string, constructor AuthorizerConstructor) {
+	authorizersMu.Lock()
+	defer authorizersMu.Unlock()
+	authorizers[name] = constructor
+}
+
+func init() {
+	RegisterAuthorizer(authzPluginHTTP, newHTTPAuthorizer)
+}
+
+// httpAuthorizer asks the policy service by posting {"input": request} and expects
+// {"result": bool} in return, which is the data api of OPA.
+type httpAuthorizer struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPAuthorizer(cfg *config.Config) (Authorizer, error) {
+	url := cfg.GetString(cfgAuthzUrl)
+	if url == "" {
+		return nil, fmt.Errorf("%v is required by authorizer %v", cfgAuthzUrl, authzPluginHTTP)
+	}
+	timeout := time.Duration(cfg.GetInt64(cfgAuthzTimeoutMs)) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultAuthzTimeout
+	}
+	return &httpAuthorizer{url: url, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (a *httpAuthorizer) Authorize(req *AuthzRequest) (allowed bool, err error) {
+	body, err := json.Marshal(map[string]interface{}{"input": req})
+	if err != nil {
+		return
+	}
+	resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("authorizer responds status(%v) body(%s)", resp.StatusCode, data)
+	}
+	result := &struct {
+		Result *bool `json:"result"`
+	}{}
+	if err = json.Unmarshal(data, result); err != nil {
+		return
+	}
+	if result.Result == nil {
+		// OPA omits the result if the rule is undefined
+		return false, nil
+	}
+	return *result.Result, nil
+}
+
+type authzCacheEntry struct {
+	allowed  bool
+	expireAt time.Time
+}
+
+// authzChecker caches the decisions of the authorizer, and allows or denies the
+// operations by failOpen if the authorizer fails.
+type authzChecker struct {
+	authorizer Authorizer
+	failOpen   bool
+	cacheTTL   time.Duration
+	cacheSize  int
+
+	mu    sync.Mutex
+	cache map[string]*authzCacheEntry
+
+	paths *authzPathResolver
+}
+
+func newAuthzChecker(authorizer Authorizer, failOpen bool, cacheTTL time.Duration, cacheSize int) *authzChecker {
+	return &authzChecker{
+		authorizer: authorizer,
+		failOpen:   failOpen,
+		cacheTTL:   cacheTTL,
+		cacheSize:  cacheSize,
+		cache:      make(map[string]*authzCacheEntry),
+		paths:      newAuthzPathResolver(cacheTTL, cacheSize),
+	}
+}
+
+// newAuthzCheckerFromConfig returns nil if no authorizer plugin is configured.
+func newAuthzCheckerFromConfig(cfg *config.Config) (checker *authzChecker, err error) {
+	plugin := cfg.GetString(cfgAuthzPlugin)
+	if plugin == "" {
+		return nil, nil
+	}
+	authorizersMu.RLock()
+	constructor, ok := authorizers[plugin]
+	authorizersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("authorizer plugin %v is not registered", plugin)
+	}
+	authorizer, err := constructor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTL := defaultAuthzCacheTTL
+	if cfg.HasKey(cfgAuthzCacheTTLSec) {
+		cacheTTL = time.Duration(cfg.GetInt64(cfgAuthzCacheTTLSec)) * time.Second
+	}
+	checker = newAuthzChecker(authorizer, cfg.GetBool(cfgAuthzFailOpen), cacheTTL, defaultAuthzCacheSize)
+	log.LogInfof("[newAuthzCheckerFromConfig] plugin[%v] failOpen[%v] cacheTTL[%v]", plugin, checker.failOpen, cacheTTL)
+	return
+}
+
+func (c *authzChecker) getCache(key string) (allowed, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.expireAt) {
+		delete(c.cache, key)
+		return false, false
+	}
+	return entry.allowed, true
+}
+
+func (c *authzChecker) putCache(key string, allowed bool) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= c.cacheSize {
+		now := time.Now()
+		for k, entry := range c.cache {
+			if now.After(entry.expireAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= c.cacheSize {
+			c.cache = make(map[string]*authzCacheEntry)
+		}
+	}
+	c.cache[key] = &authzCacheEntry{allowed: allowed, expireAt: time.Now().Add(c.cacheTTL)}
+}
+
+// check returns ErrAuthzDenied if the operation is not allowed.
+func (c *authzChecker) check(req *AuthzRequest) error {
+	key := req.cacheKey()
+	allowed, ok := c.getCache(key)
+	if !ok {
+		var err error
+		if allowed, err = c.authorizer.Authorize(req); err != nil {
+			log.LogWarnf("[authzChecker] authorize req(%+v) failed, failOpen(%v) err(%v)", req, c.failOpen, err)
+			if c.failOpen {
+				return nil
+			}
+			return errors.NewErrorf("%v: %v", authzDeniedReasonPrefix, err)
+		}
+		c.putCache(key, allowed)
+	}
+	if !allowed {
+		return ErrAuthzDenied
+	}
+	return nil
+}
+
+// checkAuthz consults the authorizer on the namespace operation on the dentry, and responds
+// OpNotPerm to the client if it is denied. The full path reported by the client is verified
+// to refer to the dentry. It is checked before the request is proxied to the leader, so that
+// the authorizer is consulted with the address of the client.
+func (m *metadataManager) checkAuthz(conn net.Conn, p *Packet, mp MetaPartition, action string,
+	parentID uint64, name, fullPath, remoteAddr string,
+) bool {
+	if m.metaNode == nil || m.metaNode.authz == nil || isPartitionPeer(mp, remoteAddr) {
+		return true
+	}
+	req := &AuthzRequest{
+		Volume:   mp.GetVolName(),
+		Action:   action,
+		Op:       p.GetOpMsg(),
+		ParentID: parentID,
+		Name:     name,
+		Client:   authzClient(remoteAddr),
+	}
+	if fullPath != "" {
+		err := ErrAuthzPathMismatch
+		if path.Base(fullPath) == name {
+			err = m.metaNode.authz.paths.verify(req.Volume, parentID, path.Dir(fullPath), m.lookupDentry)
+		}
+		if err != nil {
+			return m.denyAuthz(conn, p, req, errors.NewErrorf("%v: path(%v): %v", authzDeniedReasonPrefix, fullPath, err), remoteAddr)
+		}
+		req.Path = fullPath
+	}
+	return m.authorize(conn, p, req, remoteAddr)
+}
+
+// checkInodeAuthz consults the authorizer on the operation on the inode.
+func (m *metadataManager) checkInodeAuthz(conn net.Conn, p *Packet, mp MetaPartition, action string,
+	ino uint64, remoteAddr string,
+) bool {
+	if m.metaNode == nil || m.metaNode.authz == nil || isPartitionPeer(mp, remoteAddr) {
+		return true
+	}
+	req := &AuthzRequest{
+		Volume: mp.GetVolName(),
+		Action: action,
+		Op:     p.GetOpMsg(),
+		Inode:  ino,
+		Client: authzClient(remoteAddr),
+	}
+	return m.authorize(conn, p, req, remoteAddr)
+}
+
+func (m *metadataManager) authorize(conn net.Conn, p *Packet, req *AuthzRequest, remoteAddr string) bool {
+	if err := m.metaNode.authz.check(req); err != nil {
+		return m.denyAuthz(conn, p, req, err, remoteAddr)
+	}
+	return true
+}
+
+func (m *metadataManager) denyAuthz(conn net.Conn, p *Packet, req *AuthzRequest, err error, remoteAddr string) bool {
+	log.LogWarnf("%s [checkAuthz] req(%+v) denied: %v", remoteAddr, req, err)
+	p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+	m.respondToClient(conn, p)
+	return false
+}
+
+func authzClient(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
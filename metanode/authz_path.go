@@ -0,0 +1,241 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+)
+
+const authzPartitionViewTTL = time.Minute
+
+var ErrAuthzPathMismatch = errors.New("the path does not refer to the inode")
+
+type authzDentryLookup func(vol string, parentID uint64, name string) (ino uint64, err error)
+
+type authzPartitionViews struct {
+	views    []*proto.MetaPartitionView
+	expireAt time.Time
+}
+
+// authzPathResolver verifies the paths reported by the clients by looking up the dentries from
+// the root, so that the authorizer is consulted with the paths resolved by the metanodes rather
+// than the ones the clients claim. The verified paths are cached for the ttl.
+type authzPathResolver struct {
+	cacheTTL  time.Duration
+	cacheSize int
+
+	mu       sync.Mutex
+	verified map[string]time.Time
+	views    map[string]*authzPartitionViews
+}
+
+func newAuthzPathResolver(cacheTTL time.Duration, cacheSize int) *authzPathResolver {
+	return &authzPathResolver{
+		cacheTTL:  cacheTTL,
+		cacheSize: cacheSize,
+		verified:  make(map[string]time.Time),
+		views:     make(map[string]*authzPartitionViews),
+	}
+}
+
+func (r *authzPathResolver) isVerified(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expireAt, ok := r.verified[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expireAt) {
+		delete(r.verified, key)
+		return false
+	}
+	return true
+}
+
+func (r *authzPathResolver) putVerified(key string) {
+	if r.cacheTTL <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.verified) >= r.cacheSize {
+		now := time.Now()
+		for k, expireAt := range r.verified {
+			if now.After(expireAt) {
+				delete(r.verified, k)
+			}
+		}
+		if len(r.verified) >= r.cacheSize {
+			r.verified = make(map[string]time.Time)
+		}
+	}
+	r.verified[key] = time.Now().Add(r.cacheTTL)
+}
+
+// verify returns ErrAuthzPathMismatch if the absolute path does not refer to the inode.
+func (r *authzPathResolver) verify(vol string, ino uint64, fullPath string, lookup authzDentryLookup) error {
+	key := fmt.Sprintf("%s|%d|%s", vol, ino, fullPath)
+	if r.isVerified(key) {
+		return nil
+	}
+	if !strings.HasPrefix(fullPath, "/") {
+		return ErrAuthzPathMismatch
+	}
+	cur := proto.RootIno
+	for _, name := range strings.Split(fullPath, "/") {
+		if name == "" {
+			continue
+		}
+		child, err := lookup(vol, cur, name)
+		if err != nil {
+			return err
+		}
+		cur = child
+	}
+	if cur != ino {
+		return ErrAuthzPathMismatch
+	}
+	r.putVerified(key)
+	return nil
+}
+
+// partitionView returns the view of the partition of the volume holding the inode.
+func (r *authzPathResolver) partitionView(vol string, ino uint64) (view *proto.MetaPartitionView, err error) {
+	find := func(views []*proto.MetaPartitionView) *proto.MetaPartitionView {
+		for _, v := range views {
+			if v.Start <= ino && ino <= v.End {
+				return v
+			}
+		}
+		return nil
+	}
+	r.mu.Lock()
+	cached, ok := r.views[vol]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		if view = find(cached.views); view != nil {
+			return
+		}
+	}
+	if masterClient == nil {
+		return nil, fmt.Errorf("no master client to get the partitions of vol(%v)", vol)
+	}
+	views, err := masterClient.ClientAPI().GetMetaPartitions(vol)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	r.views[vol] = &authzPartitionViews{views: views, expireAt: time.Now().Add(authzPartitionViewTTL)}
+	r.mu.Unlock()
+	if view = find(views); view == nil {
+		err = fmt.Errorf("no partition of vol(%v) holds inode(%v)", vol, ino)
+	}
+	return
+}
+
+// lookupDentry looks up the dentry from the local partition of the parent, or from the leader of
+// the partition if it is not on this metanode.
+func (m *metadataManager) lookupDentry(vol string, parentID uint64, name string) (ino uint64, err error) {
+	var p *Packet
+	req := &proto.LookupRequest{VolName: vol, ParentID: parentID, Name: name}
+	if mp := m.getVolPartitionByInode(vol, parentID); mp != nil {
+		req.PartitionID = mp.GetBaseConfig().PartitionId
+		if p, err = NewPacketToLookup(req); err != nil {
+			return
+		}
+		if err = mp.Lookup(req, p); err != nil {
+			return
+		}
+	} else {
+		var view *proto.MetaPartitionView
+		if view, err = m.metaNode.authz.paths.partitionView(vol, parentID); err != nil {
+			return
+		}
+		req.PartitionID = view.PartitionID
+		if p, err = NewPacketToLookup(req); err != nil {
+			return
+		}
+		if err = m.sendToPartitionLeader(view.LeaderAddr, p); err != nil {
+			return
+		}
+	}
+	if p.ResultCode != proto.OpOk {
+		return 0, errors.NewErrorf("lookup parent(%v) name(%v) failed: %v", parentID, name, p.GetResultMsg())
+	}
+	resp := &proto.LookupResponse{}
+	if err = json.Unmarshal(p.Data, resp); err != nil {
+		return
+	}
+	return resp.Inode, nil
+}
+
+func (m *metadataManager) getVolPartitionByInode(vol string, ino uint64) MetaPartition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, mp := range m.partitions {
+		conf := mp.GetBaseConfig()
+		if conf.VolName == vol && conf.Start <= ino && ino <= conf.End {
+			return mp
+		}
+	}
+	return nil
+}
+
+func (m *metadataManager) sendToPartitionLeader(addr string, p *Packet) (err error) {
+	if addr == "" {
+		return ErrNoLeader
+	}
+	reqID, reqOp := p.ReqID, p.Opcode
+	conn, err := m.connPool.GetConnect(addr)
+	if err != nil {
+		return
+	}
+	defer func() {
+		m.connPool.PutConnect(conn, err != nil)
+	}()
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConnWithVer(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	if reqID != p.ReqID || reqOp != p.Opcode {
+		err = fmt.Errorf("send and received packet mismatch: req(%v_%v) resp(%v_%v)", reqID, reqOp, p.ReqID, p.Opcode)
+	}
+	return
+}
+
+// isPartitionPeer returns true if the request is proxied by a peer of the partition, which has
+// consulted the authorizer with the address of the client.
+func isPartitionPeer(mp MetaPartition, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	for _, peer := range mp.GetBaseConfig().Peers {
+		if peerHost, _, err := net.SplitHostPort(peer.Addr); err == nil && peerHost == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthzHTTPAuthorizer(t *testing.T) {
+	var calls int32
+	// only 10.0.0.1 may delete under /prod
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		input := &struct {
+			Input AuthzRequest `json:"input"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(input))
+		req := input.Input
+		allowed := req.Action != AuthzActionDelete || !strings.HasPrefix(req.Path, "/prod") || req.Client == "10.0.0.1"
+		w.Write([]byte(`{"result":` + map[bool]string{true: "true", false: "false"}[allowed] + `}`))
+	}))
+	defer server.Close()
+
+	cfg := config.LoadConfigString(`{"authzPlugin":"http","authzUrl":"` + server.URL + `","authzCacheTTLSec":60}`)
+	checker, err := newAuthzCheckerFromConfig(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, checker)
+	require.False(t, checker.failOpen)
+
+	req := &AuthzRequest{Volume: "vol", Action: AuthzActionDelete, Path: "/prod/a", Client: "10.0.0.2"}
+	require.ErrorIs(t, checker.check(req), ErrAuthzDenied)
+	req.Client = "10.0.0.1"
+	require.NoError(t, checker.check(req))
+	require.NoError(t, checker.check(&AuthzRequest{Volume: "vol", Action: AuthzActionCreate, Path: "/prod/a"}))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// the decisions are cached
+	require.NoError(t, checker.check(req))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	_, err = newAuthzCheckerFromConfig(config.LoadConfigString(`{"authzPlugin":"http"}`))
+	require.Error(t, err)
+	_, err = newAuthzCheckerFromConfig(config.LoadConfigString(`{"authzPlugin":"unknown"}`))
+	require.Error(t, err)
+	checker, err = newAuthzCheckerFromConfig(config.LoadConfigString(`{}`))
+	require.NoError(t, err)
+	require.Nil(t, checker)
+}
+
+type authzFailedAuthorizer struct{}
+
+func (authzFailedAuthorizer) Authorize(req *AuthzRequest) (bool, error) {
+	return false, ErrAuthzDenied
+}
+
+func TestAuthzFailOpen(t *testing.T) {
+	req := &AuthzRequest{Volume: "vol", Action: AuthzActionDelete, Name: "a"}
+	require.NoError(t, newAuthzChecker(authzFailedAuthorizer{}, true, time.Minute, 8).check(req))
+	require.Error(t, newAuthzChecker(authzFailedAuthorizer{}, false, time.Minute, 8).check(req))
+}
+
+func TestAuthzCacheSize(t *testing.T) {
+	checker := newAuthzChecker(authzFailedAuthorizer{}, false, time.Minute, 2)
+	checker.putCache("a", true)
+	checker.putCache("b", true)
+	checker.putCache("c", false)
+	require.LessOrEqual(t, len(checker.cache), 2)
+	allowed, ok := checker.getCache("c")
+	require.True(t, ok)
+	require.False(t, allowed)
+
+	checker.cacheTTL = 0
+	checker.putCache("d", true)
+	_, ok = checker.getCache("d")
+	require.False(t, ok)
+}
+
+func TestAuthzPathResolver(t *testing.T) {
+	// /a/b is inode 3
+	dentries := map[uint64]map[string]uint64{1: {"a": 2}, 2: {"b": 3}}
+	var lookups int32
+	lookup := func(vol string, parentID uint64, name string) (uint64, error) {
+		atomic.AddInt32(&lookups, 1)
+		if ino, ok := dentries[parentID][name]; ok {
+			return ino, nil
+		}
+		return 0, ErrAuthzPathMismatch
+	}
+	resolver := newAuthzPathResolver(time.Minute, 8)
+	require.NoError(t, resolver.verify("vol", 3, "/a/b", lookup))
+	require.NoError(t, resolver.verify("vol", 1, "/", lookup))
+	require.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+
+	// the verified paths are cached
+	require.NoError(t, resolver.verify("vol", 3, "/a/b", lookup))
+	require.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+
+	// the forged paths are rejected
+	require.ErrorIs(t, resolver.verify("vol", 3, "/a", lookup), ErrAuthzPathMismatch)
+	require.Error(t, resolver.verify("vol", 3, "/c/b", lookup))
+	require.ErrorIs(t, resolver.verify("vol", 3, "a/b", lookup), ErrAuthzPathMismatch)
+}
+
+func TestAuthzPartitionPeer(t *testing.T) {
+	mp := &metaPartition{config: &MetaPartitionConfig{Peers: []proto.Peer{{ID: 1, Addr: "192.168.0.11:17210"}}}}
+	require.True(t, isPartitionPeer(mp, "192.168.0.11:40000"))
+	require.False(t, isPartitionPeer(mp, "192.168.0.100:40000"))
+	require.False(t, isPartitionPeer(mp, "invalid"))
+}
@@ -58,13 +58,19 @@ type (
 	// MetaNode -> Client updateDentry response
 	UpdateDentryResp = proto.UpdateDentryResponse
 	// Client -> MetaNode read dir request
-	ReadDirReq      = proto.ReadDirRequest
-	ReadDirOnlyReq  = proto.ReadDirOnlyRequest
-	ReadDirLimitReq = proto.ReadDirLimitRequest
+	ReadDirReq         = proto.ReadDirRequest
+	ReadDirOnlyReq     = proto.ReadDirOnlyRequest
+	ReadDirLimitReq    = proto.ReadDirLimitRequest
+	ReadDirStreamReq   = proto.ReadDirStreamRequest
+	ReadDirChangesReq  = proto.ReadDirChangesRequest
+	ReadDirFilteredReq = proto.ReadDirFilteredRequest
 	// MetaNode -> Client read dir response
-	ReadDirResp      = proto.ReadDirResponse
-	ReadDirOnlyResp  = proto.ReadDirOnlyResponse
-	ReadDirLimitResp = proto.ReadDirLimitResponse
+	ReadDirResp         = proto.ReadDirResponse
+	ReadDirOnlyResp     = proto.ReadDirOnlyResponse
+	ReadDirLimitResp    = proto.ReadDirLimitResponse
+	ReadDirStreamResp   = proto.ReadDirStreamResponse
+	ReadDirChangesResp  = proto.ReadDirChangesResponse
+	ReadDirFilteredResp = proto.ReadDirFilteredResponse
 
 	// MetaNode -> Client lookup
 	LookupReq = proto.LookupRequest
@@ -187,6 +193,12 @@ const (
 	opFSMStoreTickV1  = 72
 
 	opFSMVerListSnapShot = 73
+
+	opFSMInodeRecyclerSnap = 74
+
+	opFSMAttrJournalBatch = 75
+
+	opFSMExtentsMaterialize = 76
 )
 
 var exporterKey string
@@ -225,6 +237,17 @@ const (
 	cfgRetainLogs                = "retainLogs"                // string, raft RetainLogs
 	cfgRaftSyncSnapFormatVersion = "raftSyncSnapFormatVersion" // int, format version of snapshot that raft leader sent to follower
 	cfgServiceIDKey              = "serviceIDKey"
+	cfgInodeReuseDelaySec        = "inodeReuseDelaySec" // int, ids of the deleted inodes are reused after the seconds, 0 means never reuse
+	cfgAttrBatchCount            = "attrBatchCount"     // int, max attr updates grouped into one raft proposal, 0 or 1 means no grouping
+	cfgAttrBatchDelayUs          = "attrBatchDelayUs"   // int, max microseconds an attr update waits to be grouped
+	cfgAuthzPlugin               = "authzPlugin"        // string, authorizer plugin consulted on namespace operations, empty means disabled
+	cfgAuthzUrl                  = "authzUrl"           // string, url of the policy service of the http authorizer
+	cfgAuthzTimeoutMs            = "authzTimeoutMs"     // int, timeout of the http authorizer
+	cfgAuthzCacheTTLSec          = "authzCacheTTLSec"   // int, seconds the decisions are cached, 0 means no cache
+	cfgAuthzFailOpen             = "authzFailOpen"      // bool, allow the operations if the authorizer fails
+	cfgMetaDurability            = "metaDurability"     // string, durability of the raft log: async, group or sync
+	cfgGroupCommitDelayMs        = "groupCommitDelayMs" // int, min milliseconds between the fsyncs of the group durability
+	cfgVolOpQueueWaitMs          = "volOpQueueWaitMs"   // int, max milliseconds a limited operation waits in the queue
 
 	metaNodeDeleteBatchCountKey = "batchCount"
 	configNameResolveInterval   = "nameResolveInterval" // int
@@ -235,6 +258,18 @@ const (
 	intervalToPersistData = time.Minute * 5
 	intervalToSyncCursor  = time.Minute * 1
 
+	// lease of the continuation cursor of readdir stream, renewed by each batch
+	readDirStreamLease     = time.Minute * 10
+	readDirStreamMaxLimit  = 10000
+	readDirStreamMaxLeases = 100000
+
+	// the dentries scanned by a filtered readdir at most
+	readDirFilteredMaxScan = 10000
+
+	// the latest dentry changes kept in memory by each partition
+	dirChangeLogCapacity   = 4096
+	readDirChangesMaxLimit = 1000
+
 	defaultDelExtentsCnt         = 100000
 	defaultMaxQuotaGoroutine     = 5
 	defaultQuotaSwitch           = true
@@ -51,6 +51,7 @@ type Vol struct {
 	sync.RWMutex
 	dataPartitionView map[uint64]*DataPartition
 	volDeleteLockTime int64
+	cloneInfo         *proto.VolCloneInfo
 }
 
 // NewVol returns a new volume instance.
@@ -75,6 +76,31 @@ func (v *Vol) UpdatePartitions(partitions *DataPartitionsView) {
 	}
 }
 
+// GetWritablePartition returns a random writable data partition.
+func (v *Vol) GetWritablePartition() *DataPartition {
+	v.RLock()
+	defer v.RUnlock()
+	for _, dp := range v.dataPartitionView {
+		if dp.Status == proto.ReadWrite && !dp.IsDiscard && len(dp.Hosts) > 0 {
+			return dp
+		}
+	}
+	return nil
+}
+
+func (v *Vol) setCloneInfo(info *proto.VolCloneInfo) {
+	v.Lock()
+	defer v.Unlock()
+	v.cloneInfo = info
+}
+
+// getCloneInfo returns the clone info of the volume, nil if the volume is not a clone.
+func (v *Vol) getCloneInfo() *proto.VolCloneInfo {
+	v.RLock()
+	defer v.RUnlock()
+	return v.cloneInfo
+}
+
 func (v *Vol) replaceOrInsert(partition *DataPartition) {
 	v.Lock()
 	defer v.Unlock()
@@ -38,8 +38,9 @@ const (
 
 var (
 	// InodeV1Flag uint64 = 0x01
-	V2EnableColdInodeFlag uint64 = 0x02
-	V3EnableSnapInodeFlag uint64 = 0x04
+	V2EnableColdInodeFlag  uint64 = 0x02
+	V3EnableSnapInodeFlag  uint64 = 0x04
+	V4EnableEpochInodeFlag uint64 = 0x08
 )
 
 // Inode wraps necessary properties of `Inode` information in the file system.
@@ -83,6 +84,7 @@ type Inode struct {
 	NLink      uint32 // NodeLink counts
 	Flag       int32
 	Reserved   uint64 // reserved space
+	Epoch      uint32 // times the inode id is reused, the stale handles of the former inodes are detected by it
 	// Extents    *ExtentsTree
 	Extents    *SortedExtents
 	ObjExtents *SortedObjExtents
@@ -403,6 +405,7 @@ func (i *Inode) String() string {
 	buff.WriteString(fmt.Sprintf("NLink[%d]", i.NLink))
 	buff.WriteString(fmt.Sprintf("Flag[%d]", i.Flag))
 	buff.WriteString(fmt.Sprintf("Reserved[%d]", i.Reserved))
+	buff.WriteString(fmt.Sprintf("Epoch[%d]", i.Epoch))
 	buff.WriteString(fmt.Sprintf("Extents[%s]", i.Extents))
 	buff.WriteString(fmt.Sprintf("ObjExtents[%s]", i.ObjExtents))
 	buff.WriteString(fmt.Sprintf("verSeq[%v]", i.getVer()))
@@ -458,6 +461,7 @@ func (i *Inode) Copy() BtreeItem {
 	newIno.NLink = i.NLink
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
+	newIno.Epoch = i.Epoch
 	newIno.Extents = i.Extents.Clone()
 	newIno.ObjExtents = i.ObjExtents.Clone()
 	if i.multiSnap != nil {
@@ -496,6 +500,7 @@ func (i *Inode) CopyDirectly() BtreeItem {
 	newIno.NLink = i.NLink
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
+	newIno.Epoch = i.Epoch
 	newIno.Extents = i.Extents.Clone()
 	newIno.ObjExtents = i.ObjExtents.Clone()
 
@@ -669,6 +674,9 @@ func (i *Inode) MarshalInodeValue(buff *bytes.Buffer) {
 		i.Reserved |= V2EnableColdInodeFlag
 	}
 	i.Reserved |= V3EnableSnapInodeFlag
+	if i.Epoch > 0 {
+		i.Reserved |= V4EnableEpochInodeFlag
+	}
 
 	// log.LogInfof("action[MarshalInodeValue] inode[%v] Reserved %v", i.Inode, i.Reserved)
 	if err = binary.Write(buff, binary.BigEndian, &i.Reserved); err != nil {
@@ -701,6 +709,12 @@ func (i *Inode) MarshalInodeValue(buff *bytes.Buffer) {
 		}
 	}
 
+	if i.Reserved&V4EnableEpochInodeFlag > 0 {
+		if err = binary.Write(buff, binary.BigEndian, &i.Epoch); err != nil {
+			panic(err)
+		}
+	}
+
 	if err = binary.Write(buff, binary.BigEndian, i.getVer()); err != nil {
 		panic(err)
 	}
@@ -841,6 +855,12 @@ func (i *Inode) UnmarshalInodeValue(buff *bytes.Buffer) (err error) {
 		}
 	}
 
+	if i.Reserved&V4EnableEpochInodeFlag > 0 {
+		if err = binary.Read(buff, binary.BigEndian, &i.Epoch); err != nil {
+			return
+		}
+	}
+
 	if v3 {
 		var seq uint64
 		if err = binary.Read(buff, binary.BigEndian, &seq); err != nil {
@@ -0,0 +1,185 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"sync"
+
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/timeutil"
+)
+
+const (
+	recyclerVersion     = 1
+	recyclerRecordV1Len = 20
+	// the oldest recycled inodes are dropped and never reused if exceeded
+	maxRecycledInodes = 100000
+)
+
+type recycledInode struct {
+	ino      uint64
+	epoch    uint32 // epoch of the deleted inode
+	freeTime int64
+	reserved bool // reserved by the leader for a new inode, not persisted
+}
+
+// inodeRecycler keeps the ids of the deleted inodes in the order of deletion, so that they can be reused
+// by new inodes after a safety delay. The new inode takes the next epoch of the deleted one, the handles
+// holding the former epoch are detected as stale.
+type inodeRecycler struct {
+	sync.Mutex
+	items map[uint64]*list.Element
+	order *list.List
+}
+
+func newInodeRecycler() *inodeRecycler {
+	return &inodeRecycler{
+		items: make(map[uint64]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (r *inodeRecycler) clone() *inodeRecycler {
+	r.Lock()
+	defer r.Unlock()
+	nr := newInodeRecycler()
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		item := *e.Value.(*recycledInode)
+		item.reserved = false
+		nr.items[item.ino] = nr.order.PushBack(&item)
+	}
+	return nr
+}
+
+func (r *inodeRecycler) Len() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.order.Len()
+}
+
+func (r *inodeRecycler) add(ino uint64, epoch uint32, freeTime int64) {
+	// the id can not be reused any more
+	if epoch == math.MaxUint32 {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if e, ok := r.items[ino]; ok {
+		r.order.Remove(e)
+	}
+	r.items[ino] = r.order.PushBack(&recycledInode{ino: ino, epoch: epoch, freeTime: freeTime})
+	for r.order.Len() > maxRecycledInodes {
+		front := r.order.Front()
+		delete(r.items, front.Value.(*recycledInode).ino)
+		r.order.Remove(front)
+	}
+}
+
+func (r *inodeRecycler) remove(ino uint64) {
+	r.Lock()
+	defer r.Unlock()
+	if e, ok := r.items[ino]; ok {
+		delete(r.items, ino)
+		r.order.Remove(e)
+	}
+}
+
+// reserve takes the oldest recycled inode which has been deleted for the delay seconds and is not in use,
+// returns the id and the epoch of the new inode. The inode is only marked as reserved by the leader so that
+// it is not taken twice, it is removed from the recycler by all the replicas once the creation is applied
+// by raft, and it must be released if the creation fails.
+func (r *inodeRecycler) reserve(delay int64, skip func(ino uint64) bool) (ino uint64, epoch uint32, ok bool) {
+	r.Lock()
+	defer r.Unlock()
+	now := timeutil.GetCurrentTimeUnix()
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		item := e.Value.(*recycledInode)
+		if now-item.freeTime < delay {
+			return
+		}
+		if item.reserved || skip(item.ino) {
+			continue
+		}
+		item.reserved = true
+		return item.ino, item.epoch + 1, true
+	}
+	return
+}
+
+// release makes the reserved inode available again if its creation has not been applied.
+func (r *inodeRecycler) release(ino uint64) {
+	r.Lock()
+	defer r.Unlock()
+	if e, ok := r.items[ino]; ok {
+		e.Value.(*recycledInode).reserved = false
+	}
+}
+
+func (r *inodeRecycler) Marshal() (buf []byte, crc uint32, err error) {
+	r.Lock()
+	defer r.Unlock()
+	buffer := bytes.NewBuffer(make([]byte, 0, 4+r.order.Len()*recyclerRecordV1Len))
+	if err = binary.Write(buffer, binary.BigEndian, int32(recyclerVersion)); err != nil {
+		return
+	}
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		item := e.Value.(*recycledInode)
+		if err = binary.Write(buffer, binary.BigEndian, item.ino); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, item.epoch); err != nil {
+			return
+		}
+		if err = binary.Write(buffer, binary.BigEndian, item.freeTime); err != nil {
+			return
+		}
+	}
+	buf = buffer.Bytes()
+	crc = crc32.ChecksumIEEE(buf)
+	return
+}
+
+func (r *inodeRecycler) UnMarshal(data []byte) (err error) {
+	if len(data) < 4 || (len(data)-4)%recyclerRecordV1Len != 0 {
+		return errors.New("invalid inode recycler data length")
+	}
+	buff := bytes.NewBuffer(data)
+	var version int32
+	if err = binary.Read(buff, binary.BigEndian, &version); err != nil {
+		return
+	}
+	if version != recyclerVersion {
+		return errors.NewErrorf("invalid inode recycler version %v", version)
+	}
+	for buff.Len() != 0 {
+		item := &recycledInode{}
+		if err = binary.Read(buff, binary.BigEndian, &item.ino); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &item.epoch); err != nil {
+			return
+		}
+		if err = binary.Read(buff, binary.BigEndian, &item.freeTime); err != nil {
+			return
+		}
+		r.add(item.ino, item.epoch, item.freeTime)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInodeRecyclerReserve(t *testing.T) {
+	r := newInodeRecycler()
+	now := timeutil.GetCurrentTimeUnix()
+	r.add(10, 0, now-100)
+	r.add(11, 3, now-100)
+	r.add(12, 0, now)
+
+	noSkip := func(uint64) bool { return false }
+	ino, epoch, ok := r.reserve(50, noSkip)
+	require.True(t, ok)
+	require.Equal(t, uint64(10), ino)
+	require.Equal(t, uint32(1), epoch)
+	// the reserved inode is kept until the creation is applied
+	require.Equal(t, 3, r.Len())
+
+	// inode in use is skipped
+	_, _, ok = r.reserve(50, func(ino uint64) bool { return ino == 11 })
+	require.False(t, ok)
+
+	ino, epoch, ok = r.reserve(50, noSkip)
+	require.True(t, ok)
+	require.Equal(t, uint64(11), ino)
+	require.Equal(t, uint32(4), epoch)
+
+	// not deleted for the delay
+	_, _, ok = r.reserve(50, noSkip)
+	require.False(t, ok)
+
+	// the released inode can be reserved again
+	r.release(10)
+	ino, _, ok = r.reserve(50, noSkip)
+	require.True(t, ok)
+	require.Equal(t, uint64(10), ino)
+
+	// the reservations are not taken by the clone
+	nr := r.clone()
+	ino, _, ok = nr.reserve(50, noSkip)
+	require.True(t, ok)
+	require.Equal(t, uint64(10), ino)
+
+	r.remove(10)
+	r.remove(11)
+	r.remove(12)
+	require.Equal(t, 0, r.Len())
+}
+
+func TestInodeRecyclerMarshal(t *testing.T) {
+	r := newInodeRecycler()
+	for i := uint64(1); i <= 100; i++ {
+		r.add(i, uint32(i), int64(i))
+	}
+	data, crc, err := r.Marshal()
+	require.NoError(t, err)
+
+	nr := newInodeRecycler()
+	require.NoError(t, nr.UnMarshal(data))
+	ndata, ncrc, err := nr.clone().Marshal()
+	require.NoError(t, err)
+	require.Equal(t, data, ndata)
+	require.Equal(t, crc, ncrc)
+
+	require.Error(t, nr.UnMarshal(data[:len(data)-1]))
+}
+
+func TestInodeEpochMarshal(t *testing.T) {
+	ino := NewInode(100, proto.Mode(0o644))
+	val := ino.MarshalValue()
+	ino2 := NewInode(100, 0)
+	require.NoError(t, ino2.UnmarshalValue(val))
+	require.Equal(t, uint32(0), ino2.Epoch)
+	require.Equal(t, uint64(0), ino2.Reserved&V4EnableEpochInodeFlag)
+
+	ino.Epoch = 2
+	ino.Size = 1024
+	val = ino.MarshalValue()
+	ino2 = NewInode(100, 0)
+	require.NoError(t, ino2.UnmarshalValue(val))
+	require.Equal(t, uint32(2), ino2.Epoch)
+	require.Equal(t, uint64(1024), ino2.Size)
+	require.Equal(t, uint32(2), ino2.Copy().(*Inode).Epoch)
+}
@@ -88,6 +88,7 @@ type metadataManager struct {
 	curQuotaGoroutineNum int32
 	maxQuotaGoroutineNum int32
 	cpuUtil              atomicutil.Float64
+	partitionReports     proto.MetaPartitionReportTracker
 	stopC                chan struct{}
 	volUpdating          *sync.Map // map[string]*verOp2Phase
 	verUpdateChan        chan string
@@ -126,8 +127,12 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 
 	metric := exporter.NewTPCnt(p.GetOpMsg())
 	labels := m.getPacketLabels(p)
+	trace := m.samplePathTrace(p, remoteAddr)
 	defer func() {
 		metric.SetWithLabels(err, labels)
+		if trace != nil {
+			m.finishPathTrace(trace, p, start, err)
+		}
 		if err != nil {
 			log.LogWarnf("HandleMetadataOperation output (%s), remote %s, err %s", p.String(), remoteAddr, err.Error())
 			return
@@ -139,6 +144,10 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		}
 	}()
 
+	if !m.admitVolOp(conn, p, labels[exporter.Vol], remoteAddr) {
+		return
+	}
+
 	switch p.Opcode {
 	case proto.OpMetaCreateInode:
 		err = m.opCreateInode(conn, p, remoteAddr)
@@ -172,6 +181,12 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opReadDirOnly(conn, p, remoteAddr)
 	case proto.OpMetaReadDirLimit:
 		err = m.opReadDirLimit(conn, p, remoteAddr)
+	case proto.OpMetaReadDirStream:
+		err = m.opReadDirStream(conn, p, remoteAddr)
+	case proto.OpMetaReadDirChanges:
+		err = m.opReadDirChanges(conn, p, remoteAddr)
+	case proto.OpMetaReadDirFiltered:
+		err = m.opReadDirFiltered(conn, p, remoteAddr)
 	case proto.OpCreateMetaPartition:
 		err = m.opCreateMetaPartition(conn, p, remoteAddr)
 	case proto.OpMetaNodeHeartbeat:
@@ -551,11 +566,13 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		Cursor:      request.Start,
 		UniqId:      0,
 		Peers:       request.Members,
+		Learners:    request.Learners,
 		RaftStore:   m.raftStore,
 		NodeId:      m.nodeId,
 		RootDir:     path.Join(m.rootDir, partitionPrefix+partitionId),
 		ConnPool:    m.connPool,
 		VerSeq:      request.VerSeq,
+		CloneVerSeq: request.CloneVerSeq,
 	}
 	mpc.AfterStop = func() {
 		m.detachPartition(request.PartitionID)
@@ -567,6 +584,18 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		return
 	}
 
+	if request.CloneSrcPartitionID != 0 {
+		var src MetaPartition
+		if src, err = m.getPartition(request.CloneSrcPartitionID); err != nil {
+			err = errors.NewErrorf("[createPartition] clone source->%s", err.Error())
+			return
+		}
+		if err = partition.(*metaPartition).forkFrom(src.(*metaPartition), request.CloneVerSeq); err != nil {
+			err = errors.NewErrorf("[createPartition]->%s", err.Error())
+			return
+		}
+	}
+
 	if err = partition.RenameStaleMetadata(); err != nil {
 		err = errors.NewErrorf("[createPartition]->%s", err.Error())
 	}
@@ -96,6 +96,9 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			goto end
 		}
 		m.fileStatsEnable = req.FileStatsEnable
+		if m.metaNode != nil && m.metaNode.volOpLimiter != nil {
+			m.metaNode.volOpLimiter.applyLimits(req.VolMetaOpLimits)
+		}
 		// collect memory info
 		resp.Total = configTotalMem
 		resp.MemUsed, err = util.GetProcessMemory(os.Getpid())
@@ -105,6 +108,7 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 		}
 		// set cpu util and io used in here
 		resp.CpuUtil = m.cpuUtil.Load()
+		resp.Capabilities = proto.MetaNodeCapabilities
 
 		m.Range(true, func(id uint64, partition MetaPartition) bool {
 			m.checkFollowerRead(req.FLReadVols, partition)
@@ -128,6 +132,8 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 				FreeListLen:      uint64(partition.GetFreeListLen()),
 				UidInfo:          partition.GetUidInfo(),
 				QuotaReportInfos: partition.getQuotaReportInfos(),
+				CloneVerSeq:      mConf.CloneVerSeq,
+				ForkedInodeCnt:   partition.GetForkedInodeCnt(),
 			}
 			mpr.TxCnt, mpr.TxRbInoCnt, mpr.TxRbDenCnt = partition.TxGetCnt()
 
@@ -147,6 +153,8 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			resp.MetaPartitionReports = append(resp.MetaPartitionReports, mpr)
 			return true
 		})
+		// report the changed partitions only if the master has applied the last reports
+		m.partitionReports.Track(req, resp)
 		resp.ZoneName = m.zoneName
 		resp.Status = proto.TaskSucceeds
 	end:
@@ -282,6 +290,9 @@ func (m *metadataManager) opTxMetaLinkInode(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionCreate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -314,6 +325,9 @@ func (m *metadataManager) opMetaLinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionCreate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -366,6 +380,9 @@ func (m *metadataManager) opTxCreateDentry(conn net.Conn, p *Packet,
 		return
 	}
 
+	if !m.checkAuthz(conn, p, mp, AuthzActionCreate, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -582,6 +599,9 @@ func (m *metadataManager) opCreateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkAuthz(conn, p, mp, AuthzActionCreate, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -615,6 +635,9 @@ func (m *metadataManager) opQuotaCreateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkAuthz(conn, p, mp, AuthzActionCreate, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -651,6 +674,9 @@ func (m *metadataManager) opTxDeleteDentry(conn net.Conn, p *Packet,
 		return
 	}
 
+	if !m.checkAuthz(conn, p, mp, AuthzActionDelete, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -680,6 +706,9 @@ func (m *metadataManager) opDeleteDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkAuthz(conn, p, mp, AuthzActionDelete, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -715,6 +744,15 @@ func (m *metadataManager) opBatchDeleteDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	for i, den := range req.Dens {
+		var fullPath string
+		if len(req.FullPaths) == len(req.Dens) {
+			fullPath = req.FullPaths[i]
+		}
+		if !m.checkAuthz(conn, p, mp, AuthzActionDelete, req.ParentID, den.Name, fullPath, remoteAddr) {
+			return
+		}
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -748,6 +786,9 @@ func (m *metadataManager) opTxUpdateDentry(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkAuthz(conn, p, mp, AuthzActionUpdate, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -781,6 +822,9 @@ func (m *metadataManager) opUpdateDentry(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkAuthz(conn, p, mp, AuthzActionUpdate, req.ParentID, req.Name, req.GetFullPath(), remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -815,6 +859,9 @@ func (m *metadataManager) opTxMetaUnlinkInode(conn net.Conn, p *Packet, remoteAd
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionDelete, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -848,6 +895,9 @@ func (m *metadataManager) opMetaUnlinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionDelete, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -880,6 +930,11 @@ func (m *metadataManager) opMetaBatchUnlinkInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
+	for _, ino := range req.Inodes {
+		if !m.checkInodeAuthz(conn, p, mp, AuthzActionDelete, ino, remoteAddr) {
+			return
+		}
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -976,6 +1031,87 @@ func (m *metadataManager) opReadDirLimit(conn net.Conn, p *Packet,
 	return
 }
 
+// Handle OpReadDirStream
+func (m *metadataManager) opReadDirStream(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.ReadDirStreamRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ReadDirStream(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [%v]req: %v , resp: %v, body: %s", remoteAddr,
+		p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+// Handle OpReadDirChanges
+func (m *metadataManager) opReadDirChanges(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.ReadDirChangesRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ReadDirChanges(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [%v]req: %v , resp: %v, body: %s", remoteAddr,
+		p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+// Handle OpReadDirFiltered
+func (m *metadataManager) opReadDirFiltered(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.ReadDirFilteredRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		return
+	}
+	if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ReadDirFiltered(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [%v]req: %v , resp: %v, body: %s", remoteAddr,
+		p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) opMetaInodeGet(conn net.Conn, p *Packet,
 	remoteAddr string) (err error,
 ) {
@@ -994,7 +1130,11 @@ func (m *metadataManager) opMetaInodeGet(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("getPartition [%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
 		return
 	}
-	if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
+	if req.HedgeAppliedID > 0 {
+		if !m.serveHedgedRead(conn, mp, p, req.HedgeAppliedID) {
+			return
+		}
+	} else if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
 		return
 	}
 	if err = mp.InodeGet(req, p); err != nil {
@@ -1037,6 +1177,11 @@ func (m *metadataManager) opBatchMetaEvictInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	for _, ino := range req.Inodes {
+		if !m.checkInodeAuthz(conn, p, mp, AuthzActionDelete, ino, remoteAddr) {
+			return
+		}
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1071,6 +1216,9 @@ func (m *metadataManager) opMetaEvictInode(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionDelete, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1107,6 +1255,9 @@ func (m *metadataManager) opSetAttr(conn net.Conn, p *Packet,
 		return
 	}
 
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1142,7 +1293,11 @@ func (m *metadataManager) opMetaLookup(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
-	if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
+	if req.HedgeAppliedID > 0 {
+		if !m.serveHedgedRead(conn, mp, p, req.HedgeAppliedID) {
+			return
+		}
+	} else if !mp.IsFollowerRead() && !m.serveProxy(conn, mp, p) {
 		return
 	}
 	err = mp.Lookup(req, p)
@@ -1168,6 +1323,9 @@ func (m *metadataManager) opMetaExtentsAdd(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1205,6 +1363,9 @@ func (m *metadataManager) opMetaExtentAddWithCheck(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1326,6 +1487,9 @@ func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet,
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1568,10 +1732,15 @@ func (m *metadataManager) opAddMetaPartitionRaftMember(conn net.Conn,
 		return err
 	}
 
+	// adding an existing learner as a voter promotes it
+	changeType := raftProto.ConfAddNode
 	if mp.IsExsitPeer(req.AddPeer) {
-		p.PacketOkReply()
-		m.respondToClientWithVer(conn, p)
-		return
+		if req.AsLearner || !mp.IsLearner(req.AddPeer.ID) {
+			p.PacketOkReply()
+			m.respondToClientWithVer(conn, p)
+			return
+		}
+		changeType = raftProto.ConfUpdateNode
 	}
 
 	log.LogInfof("[%s], remote %s start add raft member, req %v", p.String(), remoteAddr, adminTask)
@@ -1594,8 +1763,12 @@ func (m *metadataManager) opAddMetaPartitionRaftMember(conn net.Conn,
 		m.respondToClientWithVer(conn, p)
 		return
 	}
-	_, err = mp.ChangeMember(raftProto.ConfAddNode,
-		raftProto.Peer{ID: req.AddPeer.ID}, reqData)
+	peerType := raftProto.PeerNormal
+	if req.AsLearner {
+		peerType = raftProto.PeerLearner
+	}
+	_, err = mp.ChangeMember(changeType,
+		raftProto.Peer{ID: req.AddPeer.ID, Type: peerType}, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClientWithVer(conn, p)
@@ -1799,6 +1972,9 @@ func (m *metadataManager) opMetaUpdateXAttr(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1830,6 +2006,9 @@ func (m *metadataManager) opMetaSetXAttr(conn net.Conn, p *Packet, remoteAddr st
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1861,6 +2040,9 @@ func (m *metadataManager) opMetaBatchSetXAttr(conn net.Conn, p *Packet, remoteAd
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -1967,6 +2149,9 @@ func (m *metadataManager) opMetaRemoveXAttr(conn net.Conn, p *Packet, remoteAddr
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -2023,6 +2208,9 @@ func (m *metadataManager) opMetaBatchExtentsAdd(conn net.Conn, p *Packet, remote
 		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
 		return
 	}
+	if !m.checkInodeAuthz(conn, p, mp, AuthzActionUpdate, req.Inode, remoteAddr) {
+		return
+	}
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
@@ -15,6 +15,7 @@
 package metanode
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/cubefs/cubefs/proto"
@@ -85,6 +86,19 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 	}
 }
 
+// serveHedgedRead returns true if the read hedged by the client is served locally, which requires
+// the partition to have applied the index the client has observed, so that the client never reads
+// older than it has read or written. The hedged read is not proxied to the leader, which has been
+// slow for the client already.
+func (m *metadataManager) serveHedgedRead(conn net.Conn, mp MetaPartition, p *Packet, appliedID uint64) bool {
+	if applied := mp.GetAppliedID(); applied < appliedID {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(fmt.Sprintf("applied index %v is behind %v", applied, appliedID)))
+		m.respondToClient(conn, p)
+		return false
+	}
+	return true
+}
+
 // The proxy is used during the leader change. When a leader of a partition changes, the proxy forwards the request to
 // the new leader.
 func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
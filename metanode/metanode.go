@@ -20,6 +20,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -63,6 +64,15 @@ type MetaNode struct {
 	raftReplicatePort         string
 	raftRetainLogs            uint64
 	raftSyncSnapFormatVersion uint32 // format version of snapshot that raft leader sent to follower
+	inodeReuseDelaySec        int64  // ids of the deleted inodes are reused after the delay, 0 means never reuse
+	attrBatchCount            int    // max attr updates grouped into one raft proposal
+	attrBatchDelay            time.Duration
+	metaDurability            string // default durability of the raft log, overridden by the volume
+	groupCommitDelay          time.Duration
+	authz                     *authzChecker // nil if no authorizer is configured
+	pathTracer                *pathTracer
+	volOpLimiter              *volOpLimiter
+	namespaceWrappers         sync.Map // vol -> *meta.MetaWrapper of the namespace export and import
 	zoneName                  string
 	httpStopC                 chan uint8
 	smuxStopC                 chan uint8
@@ -182,6 +192,7 @@ func doShutdown(s common.Server) {
 	m.stopStat()
 	m.stopServer()
 	m.stopSmuxServer()
+	m.volOpLimiter.stop()
 	m.stopMetaManager()
 	m.stopRaftServer()
 	masterClient.Stop()
@@ -285,6 +296,39 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	syslog.Println("conf raftSyncSnapFormatVersion=", m.raftSyncSnapFormatVersion)
 	log.LogInfof("[parseConfig] raftSyncSnapFormatVersion[%v]", m.raftSyncSnapFormatVersion)
 
+	if m.inodeReuseDelaySec = cfg.GetInt64(cfgInodeReuseDelaySec); m.inodeReuseDelaySec < 0 {
+		m.inodeReuseDelaySec = 0
+	}
+	log.LogInfof("[parseConfig] inodeReuseDelaySec[%v]", m.inodeReuseDelaySec)
+
+	if m.attrBatchCount = int(cfg.GetInt64(cfgAttrBatchCount)); m.attrBatchCount < 0 {
+		m.attrBatchCount = 0
+	}
+	m.attrBatchDelay = time.Duration(cfg.GetInt64(cfgAttrBatchDelayUs)) * time.Microsecond
+	if m.attrBatchDelay <= 0 {
+		m.attrBatchDelay = defaultAttrBatchDelay
+	}
+	log.LogInfof("[parseConfig] attrBatchCount[%v] attrBatchDelay[%v]", m.attrBatchCount, m.attrBatchDelay)
+
+	if m.metaDurability = cfg.GetString(cfgMetaDurability); !proto.IsValidMetaDurability(m.metaDurability) {
+		return fmt.Errorf("invalid %v %v", cfgMetaDurability, m.metaDurability)
+	}
+	if m.metaDurability == proto.MetaDurabilityDefault {
+		m.metaDurability = proto.MetaDurabilityAsync
+	}
+	m.groupCommitDelay = time.Duration(cfg.GetInt64(cfgGroupCommitDelayMs)) * time.Millisecond
+	if m.groupCommitDelay <= 0 {
+		m.groupCommitDelay = defaultGroupCommitDelay
+	}
+	log.LogInfof("[parseConfig] metaDurability[%v] groupCommitDelay[%v]", m.metaDurability, m.groupCommitDelay)
+
+	if m.authz, err = newAuthzCheckerFromConfig(cfg); err != nil {
+		return fmt.Errorf("%v, err:%v", proto.ErrInvalidCfg, err.Error())
+	}
+	m.pathTracer = newPathTracer()
+	m.volOpLimiter = newVolOpLimiter(time.Duration(cfg.GetInt64(cfgVolOpQueueWaitMs)) * time.Millisecond)
+	log.LogInfof("[parseConfig] volOpQueueWait[%v]", m.volOpLimiter.maxWait)
+
 	constCfg := config.ConstConfig{
 		Listen:           m.listen,
 		RaftHeartbetPort: m.raftHeartbeatPort,
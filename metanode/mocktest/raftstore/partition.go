@@ -6,6 +6,7 @@ package raftstoremock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	proto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
 	raftstore "github.com/cubefs/cubefs/raftstore"
@@ -149,6 +150,18 @@ func (mr *MockPartitionMockRecorder) LeaderTerm() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaderTerm", reflect.TypeOf((*MockPartition)(nil).LeaderTerm))
 }
 
+// SetWalSync mocks base method.
+func (m *MockPartition) SetWalSync(sync bool, delay time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetWalSync", sync, delay)
+}
+
+// SetWalSync indicates an expected call of SetWalSync.
+func (mr *MockPartitionMockRecorder) SetWalSync(sync, delay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWalSync", reflect.TypeOf((*MockPartition)(nil).SetWalSync), sync, delay)
+}
+
 // Status mocks base method.
 func (m *MockPartition) Status() *raftstore.PartitionStatus {
 	m.ctrl.T.Helper()
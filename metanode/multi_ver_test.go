@@ -83,6 +83,8 @@ func newPartition(conf *MetaPartitionConfig, manager *metadataManager) (mp *meta
 		vol:           NewVol(),
 		manager:       manager,
 		verSeq:        conf.VerSeq,
+		dirChanges:    newDirChangeLog(dirChangeLogCapacity),
+		readDirLeases: newReadDirLeaseTable(readDirStreamMaxLeases),
 	}
 	mp.config.Cursor = 0
 	mp.config.End = 100000
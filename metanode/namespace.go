@@ -0,0 +1,133 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// namespaceErrorTrailer is the trailer of the export stream carrying the error that ended it early.
+const namespaceErrorTrailer = "X-Namespace-Error"
+
+type namespaceImportResult struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
+// getNamespaceWrapper returns the meta wrapper of the volume to walk the namespace across the
+// partitions, the wrappers are kept for the later exports and imports of the volume.
+func (m *MetaNode) getNamespaceWrapper(vol string) (mw *meta.MetaWrapper, err error) {
+	if val, ok := m.namespaceWrappers.Load(vol); ok {
+		return val.(*meta.MetaWrapper), nil
+	}
+	if masterClient == nil {
+		return nil, fmt.Errorf("no master client to get the partitions of vol(%v)", vol)
+	}
+	metaConfig := &meta.MetaConfig{
+		Volume:        vol,
+		Masters:       masterClient.Nodes(),
+		Authenticate:  false,
+		ValidateOwner: false,
+	}
+	if mw, err = meta.NewMetaWrapper(metaConfig); err != nil {
+		return
+	}
+	if val, loaded := m.namespaceWrappers.LoadOrStore(vol, mw); loaded {
+		mw.Close()
+		mw = val.(*meta.MetaWrapper)
+	}
+	return
+}
+
+func (m *MetaNode) lookupNamespacePath(vol, path string) (mw *meta.MetaWrapper, ino uint64, err error) {
+	if vol == "" {
+		return nil, 0, fmt.Errorf("vol is empty")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, 0, fmt.Errorf("path(%v) is not absolute", path)
+	}
+	if mw, err = m.getNamespaceWrapper(vol); err != nil {
+		return
+	}
+	if ino, err = mw.LookupPath(path); err != nil {
+		err = fmt.Errorf("lookup path(%v) of vol(%v): %v", path, vol, err)
+	}
+	return
+}
+
+// exportNamespaceHandler streams the namespace metadata of the subtree at the path in json lines,
+// the error ending the stream early is set in the trailer.
+func (m *MetaNode) exportNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	vol, path := r.FormValue("vol"), r.FormValue("path")
+	mw, root, err := m.lookupNamespacePath(vol, path)
+	if err != nil {
+		resp := NewAPIResponse(http.StatusBadRequest, err.Error())
+		data, _ := resp.Marshal()
+		if _, err = w.Write(data); err != nil {
+			log.LogErrorf("[exportNamespaceHandler] response %s", err)
+		}
+		return
+	}
+	w.Header().Set("Trailer", namespaceErrorTrailer)
+	count, err := mw.ExportNamespace(root, w)
+	if err != nil {
+		w.Header().Set(namespaceErrorTrailer, err.Error())
+		log.LogErrorf("[exportNamespaceHandler] vol(%v) path(%v) exported(%v) err(%v)", vol, path, count, err)
+		return
+	}
+	log.LogInfof("[exportNamespaceHandler] vol(%v) path(%v) exported(%v)", vol, path, count)
+}
+
+// importNamespaceHandler imports the stream in the body under the path, the existing dentries
+// are skipped, so a failed import is resumed by posting the same stream again.
+func (m *MetaNode) importNamespaceHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[importNamespaceHandler] response %s", err)
+		}
+	}()
+	if r.Method != http.MethodPost {
+		resp.Code = http.StatusMethodNotAllowed
+		resp.Msg = "the stream must be posted"
+		return
+	}
+	vol, path := r.FormValue("vol"), r.FormValue("path")
+	mw, parent, err := m.lookupNamespacePath(vol, path)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	result := &namespaceImportResult{}
+	result.Created, result.Skipped, err = mw.ImportNamespace(r.Body, parent, nil)
+	resp.Data = result
+	if err != nil {
+		log.LogErrorf("[importNamespaceHandler] vol(%v) path(%v) result(%+v) err(%v)", vol, path, result, err)
+		resp.Code = http.StatusInternalServerError
+		resp.Msg = err.Error()
+		return
+	}
+	log.LogInfof("[importNamespaceHandler] vol(%v) path(%v) result(%+v)", vol, path, result)
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceHandlerArgs(t *testing.T) {
+	m := &MetaNode{}
+	call := func(handler http.HandlerFunc, method, url string) *APIResponse {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, url, strings.NewReader("")))
+		resp := &APIResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp))
+		return resp
+	}
+
+	require.Equal(t, http.StatusBadRequest, call(m.exportNamespaceHandler, http.MethodGet, "/exportNamespace?path=/dir").Code)
+	require.Equal(t, http.StatusBadRequest, call(m.exportNamespaceHandler, http.MethodGet, "/exportNamespace?vol=vol&path=dir").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, call(m.importNamespaceHandler, http.MethodGet, "/importNamespace?vol=vol&path=/dir").Code)
+	require.Equal(t, http.StatusBadRequest, call(m.importNamespaceHandler, http.MethodPost, "/importNamespace?vol=vol&path=dir").Code)
+}
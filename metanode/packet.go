@@ -15,7 +15,9 @@
 package metanode
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"hash/crc32"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/storage"
@@ -106,6 +108,65 @@ func NewPacketToBatchDeleteExtent(dp *DataPartition, exts []*proto.ExtentKey) *P
 	return p
 }
 
+// NewPacketToCreateExtent returns a new packet to create the extent of the inode.
+func NewPacketToCreateExtent(dp *DataPartition, inode uint64) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpCreateExtent
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = dp.PartitionID
+	p.Data = make([]byte, 8)
+	binary.BigEndian.PutUint64(p.Data, inode)
+	p.Size = uint32(len(p.Data))
+	p.ReqID = proto.GenerateRequestID()
+	p.RemainingFollowers = uint8(len(dp.Hosts) - 1)
+	if len(dp.Hosts) == 1 {
+		p.RemainingFollowers = 127
+	}
+	p.Arg = ([]byte)(dp.GetAllAddrs())
+	p.ArgLen = uint32(len(p.Arg))
+
+	return p
+}
+
+// NewPacketToWriteExtent returns a new packet to append the data to the extent.
+func NewPacketToWriteExtent(dp *DataPartition, extentID uint64, offset int64, data []byte) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpWrite
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = dp.PartitionID
+	p.ExtentID = extentID
+	p.ExtentOffset = offset
+	p.Data = data
+	p.Size = uint32(len(data))
+	p.CRC = crc32.ChecksumIEEE(data)
+	p.ReqID = proto.GenerateRequestID()
+	p.RemainingFollowers = uint8(len(dp.Hosts) - 1)
+	if len(dp.Hosts) == 1 {
+		p.RemainingFollowers = 127
+	}
+	p.Arg = ([]byte)(dp.GetAllAddrs())
+	p.ArgLen = uint32(len(p.Arg))
+
+	return p
+}
+
+// NewPacketToReadExtent returns a new packet to read the extent from any replica.
+func NewPacketToReadExtent(ext *proto.ExtentKey, offset int64, size uint32) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpStreamFollowerRead
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = ext.PartitionId
+	p.ExtentID = ext.ExtentId
+	p.ExtentOffset = offset
+	p.Size = size
+	p.ReqID = proto.GenerateRequestID()
+
+	return p
+}
+
 // NewPacketToDeleteExtent returns a new packet to delete the extent.
 func NewPacketToFreeInodeOnRaftFollower(partitionID uint64, freeInodes []byte) *Packet {
 	p := new(Packet)
@@ -120,3 +181,14 @@ func NewPacketToFreeInodeOnRaftFollower(partitionID uint64, freeInodes []byte) *
 
 	return p
 }
+
+// NewPacketToLookup returns a new packet to look up the dentry.
+func NewPacketToLookup(req *proto.LookupRequest) (p *Packet, err error) {
+	p = new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpMetaLookup
+	p.PartitionID = req.PartitionID
+	p.ReqID = proto.GenerateRequestID()
+	err = p.MarshalData(req)
+	return
+}
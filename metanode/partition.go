@@ -86,12 +86,14 @@ type MetaPartitionConfig struct {
 	Start         uint64              `json:"start"` // Minimal Inode ID of this range. (Required during initialization)
 	End           uint64              `json:"end"`   // Maximal Inode ID of this range. (Required during initialization)
 	PartitionType int                 `json:"partition_type"`
-	Peers         []proto.Peer        `json:"peers"` // Peers information of the raftStore
-	Cursor        uint64              `json:"-"`     // Cursor ID of the inode that have been assigned
+	Peers         []proto.Peer        `json:"peers"`    // Peers information of the raftStore
+	Learners      []proto.Peer        `json:"learners"` // the peers replicating the logs without voting
+	Cursor        uint64              `json:"-"`        // Cursor ID of the inode that have been assigned
 	UniqId        uint64              `json:"-"`
 	NodeId        uint64              `json:"-"`
 	RootDir       string              `json:"-"`
 	VerSeq        uint64              `json:"ver_seq"`
+	CloneVerSeq   uint64              `json:"clone_ver_seq"` // the extents of the versions before are forked from the clone source
 	BeforeStart   func()              `json:"-"`
 	AfterStart    func()              `json:"-"`
 	BeforeStop    func()              `json:"-"`
@@ -170,6 +172,9 @@ type OpDentry interface {
 	UpdateDentry(req *UpdateDentryReq, p *Packet, remoteAddr string) (err error)
 	ReadDir(req *ReadDirReq, p *Packet) (err error)
 	ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error)
+	ReadDirStream(req *ReadDirStreamReq, p *Packet) (err error)
+	ReadDirChanges(req *ReadDirChangesReq, p *Packet) (err error)
+	ReadDirFiltered(req *ReadDirFilteredReq, p *Packet) (err error)
 	ReadDirOnly(req *ReadDirOnlyReq, p *Packet) (err error)
 	Lookup(req *LookupReq, p *Packet) (err error)
 	GetDentryTree() *BTree
@@ -249,6 +254,7 @@ type OpPartition interface {
 	LeaderTerm() (leaderID, term uint64)
 	IsFollowerRead() bool
 	SetFollowerRead(bool)
+	GetAppliedID() uint64
 	GetCursor() uint64
 	GetUniqId() uint64
 	GetBaseConfig() MetaPartitionConfig
@@ -260,10 +266,12 @@ type OpPartition interface {
 	UpdatePartition(req *UpdatePartitionReq, resp *UpdatePartitionResp) (err error)
 	DeleteRaft() error
 	IsExsitPeer(peer proto.Peer) bool
+	IsLearner(peerID uint64) bool
 	TryToLeader(groupID uint64) error
 	CanRemoveRaftMember(peer proto.Peer) error
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
 	GetUniqID(p *Packet, num uint32) (err error)
+	GetForkedInodeCnt() uint64
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -493,6 +501,8 @@ type metaPartition struct {
 	multipartTree          *BTree                // collection for multipart management
 	txProcessor            *TransactionProcessor // transction processor
 	raftPartition          raftstore.Partition
+	walSync                bool // fsync the raft log before the entries are acked
+	walSyncDelay           time.Duration
 	stopC                  chan bool
 	storeChan              chan *storeMsg
 	state                  uint32
@@ -513,11 +523,18 @@ type metaPartition struct {
 	mqMgr                  *MetaQuotaManager
 	nonIdempotent          sync.Mutex
 	uniqChecker            *uniqChecker
+	inodeRecycler          *inodeRecycler
+	attrJournal            *attrJournal
 	verSeq                 uint64
 	multiVersionList       *proto.VolVersionInfoList
 	versionLock            sync.Mutex
 	verUpdateChan          chan []byte
 	enableAuditLog         bool
+	applyingIndex          uint64 // raft index of the log being applied, 0 out of applying
+	dirChanges             *dirChangeLog
+	readDirLeases          *readDirLeaseTable
+	forkedInodeCnt         uint64 // inodes referencing the forked extents of the clone source
+	forkedCheckTime        int64
 }
 
 func (mp *metaPartition) IsForbidden() bool {
@@ -708,6 +725,7 @@ func (mp *metaPartition) onStart(isCreate bool) (err error) {
 			mp.config.PartitionId, err.Error())
 		return
 	}
+	mp.dirChanges.reset(mp.applyID)
 	mp.startScheduleTask()
 	if err = mp.startFreeList(); err != nil {
 		err = errors.NewErrorf("[onStart] start free list id=%d: %s",
@@ -728,8 +746,10 @@ func (mp *metaPartition) onStart(isCreate bool) (err error) {
 	}
 
 	mp.vol.volDeleteLockTime = volumeInfo.DeleteLockTime
+	mp.walSync, mp.walSyncDelay = mp.walDurability(volumeInfo)
 
 	go mp.runVersionOp()
+	mp.startAttrJournal()
 
 	mp.volType = volumeInfo.VolType
 	var ebsClient *blobstore.BlobStoreClient
@@ -757,6 +777,7 @@ func (mp *metaPartition) onStart(isCreate bool) (err error) {
 	}
 
 	go mp.startCheckerEvict()
+	go mp.startCloneMaterializer()
 
 	log.LogDebugf("[before raft] get mp[%v] applied(%d),inodeCount(%d),dentryCount(%d)", mp.config.PartitionId, mp.applyID, mp.inodeTree.Len(), mp.dentryTree.Len())
 
@@ -811,7 +832,8 @@ func (mp *metaPartition) startRaft() (err error) {
 		addr := strings.Split(peer.Addr, ":")[0]
 		rp := raftstore.PeerAddress{
 			Peer: raftproto.Peer{
-				ID: peer.ID,
+				ID:   peer.ID,
+				Type: mp.raftPeerType(peer.ID),
 			},
 			Address:       addr,
 			HeartbeatPort: heartbeatPort,
@@ -826,6 +848,9 @@ func (mp *metaPartition) startRaft() (err error) {
 		Applied: mp.applyID,
 		Peers:   peers,
 		SM:      mp,
+
+		WalSync:      mp.walSync,
+		WalSyncDelay: mp.walSyncDelay,
 	}
 	mp.raftPartition, err = mp.config.RaftStore.CreatePartition(pc)
 	if err == nil {
@@ -881,11 +906,14 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		vol:           NewVol(),
 		manager:       manager,
 		uniqChecker:   newUniqChecker(),
+		inodeRecycler: newInodeRecycler(),
 		verSeq:        conf.VerSeq,
 		multiVersionList: &proto.VolVersionInfoList{
 			TemporaryVerMap: make(map[uint64]*proto.VolVersionInfo),
 		},
 		enableAuditLog: true,
+		dirChanges:     newDirChangeLog(dirChangeLogCapacity),
+		readDirLeases:  newReadDirLeaseTable(readDirStreamMaxLeases),
 	}
 	mp.txProcessor = NewTransactionProcessor(mp)
 	return mp
@@ -1010,6 +1038,7 @@ const (
 	CRC_COUNT_TX_STUFF   int = 7
 	CRC_COUNT_UINQ_STUFF int = 8
 	CRC_COUNT_MULTI_VER  int = 9
+	CRC_COUNT_RECYCLER   int = 10
 )
 
 func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
@@ -1026,7 +1055,8 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	}
 
 	crc_count := len(crcs)
-	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF && crc_count != CRC_COUNT_MULTI_VER {
+	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF &&
+		crc_count != CRC_COUNT_MULTI_VER && crc_count != CRC_COUNT_RECYCLER {
 		log.LogErrorf("action[LoadSnapshot] crc array length %d not match", len(crcs))
 		return ErrSnapshotCrcMismatch
 	}
@@ -1045,7 +1075,7 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		loadFuncs = append(loadFuncs, mp.loadUniqChecker)
 	}
 
-	if crc_count == CRC_COUNT_MULTI_VER {
+	if crc_count >= CRC_COUNT_MULTI_VER {
 		if err = mp.loadMultiVer(snapshotPath, crcs[CRC_COUNT_MULTI_VER-1]); err != nil {
 			return
 		}
@@ -1053,6 +1083,12 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		mp.storeMultiVersion(snapshotPath, &storeMsg{multiVerList: mp.multiVersionList.VerList})
 	}
 
+	if crc_count == CRC_COUNT_RECYCLER {
+		if err = mp.loadInodeRecycler(snapshotPath, crcs[CRC_COUNT_RECYCLER-1]); err != nil {
+			return
+		}
+	}
+
 	errs := make([]error, len(loadFuncs))
 	var wg sync.WaitGroup
 	wg.Add(len(loadFuncs))
@@ -1165,6 +1201,10 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeUniqChecker,
 		mp.storeMultiVersion,
 	}
+	// the recycler is only stored if inode reuse is used, keeps the snapshot compatible with the former versions
+	if sm.inodeRecycler != nil && sm.inodeRecycler.Len() > 0 {
+		storeFuncs = append(storeFuncs, mp.storeInodeRecycler)
+	}
 	for _, storeFunc := range storeFuncs {
 		var crc uint32
 		if crc, err = storeFunc(tmpDir, sm); err != nil {
@@ -1232,6 +1272,75 @@ func (mp *metaPartition) DeleteRaft() (err error) {
 	return
 }
 
+// allocInodeID returns the id and the epoch of a new inode, the id of a deleted inode is reused
+// if inode reuse is enabled and it has been deleted for longer than the safety delay. The reused
+// id is reserved until the creation is submitted, releaseInodeID must be called after that.
+func (mp *metaPartition) allocInodeID() (inodeId uint64, epoch uint32, err error) {
+	if delay := mp.inodeReuseDelaySec(); delay > 0 && mp.verSeq == 0 {
+		var ok bool
+		if inodeId, epoch, ok = mp.inodeRecycler.reserve(delay, mp.isInodeIDInUse); ok {
+			log.LogDebugf("allocInodeID: mp(%v) reuse inode(%v) epoch(%v)", mp.config.PartitionId, inodeId, epoch)
+			return
+		}
+	}
+	inodeId, err = mp.nextInodeID()
+	return
+}
+
+// releaseInodeID releases the reserved id, which is still recycled if the creation is not applied.
+func (mp *metaPartition) releaseInodeID(inodeId uint64, epoch uint32) {
+	if epoch > 0 {
+		mp.inodeRecycler.release(inodeId)
+	}
+}
+
+func (mp *metaPartition) inodeReuseDelaySec() int64 {
+	if mp.manager == nil || mp.manager.metaNode == nil {
+		return 0
+	}
+	return mp.manager.metaNode.inodeReuseDelaySec
+}
+
+// isInodeIDInUse returns true if the id is taken by an inode or referred by a transaction.
+func (mp *metaPartition) isInodeIDInUse(ino uint64) bool {
+	if ino < mp.config.Start || ino > mp.config.End {
+		return true
+	}
+	if mp.inodeTree.Has(NewInode(ino, 0)) {
+		return true
+	}
+	return mp.txProcessor.txResource.getTxRbInode(ino) != nil
+}
+
+// checkInodeEpoch replies not exist and returns false if the request refers to the inode of another
+// epoch, which means that the inode has been deleted and its id is reused by a new inode.
+func (mp *metaPartition) checkInodeEpoch(ino uint64, check *proto.InodeEpochCheck, p *Packet) bool {
+	if !check.CheckEpoch {
+		return true
+	}
+	var epoch uint32
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item != nil {
+		epoch = item.(*Inode).Epoch
+		if !check.IsStale(epoch) {
+			return true
+		}
+	}
+	err := fmt.Errorf("mp(%v) inode[%v] epoch[%v] is stale, current epoch[%v] exist[%v]",
+		mp.config.PartitionId, ino, check.Epoch, epoch, item != nil)
+	log.LogWarnf("action[checkInodeEpoch] %v", err)
+	p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
+	return false
+}
+
+// recycleInodeID records the id of the deleted inode for reusing.
+func (mp *metaPartition) recycleInodeID(ino *Inode) {
+	if mp.inodeReuseDelaySec() <= 0 || mp.verSeq > 0 {
+		return
+	}
+	mp.inodeRecycler.add(ino.Inode, ino.Epoch, timeutil.GetCurrentTimeUnix())
+}
+
 // Return a new inode ID and update the offset.
 func (mp *metaPartition) nextInodeID() (inodeId uint64, err error) {
 	for {
@@ -1299,6 +1408,23 @@ func (mp *metaPartition) IsExsitPeer(peer proto.Peer) bool {
 	return false
 }
 
+// IsLearner returns true if the peer replicates the logs without voting.
+func (mp *metaPartition) IsLearner(peerID uint64) bool {
+	for _, learner := range mp.config.Learners {
+		if learner.ID == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (mp *metaPartition) raftPeerType(peerID uint64) raftproto.PeerType {
+	if mp.IsLearner(peerID) {
+		return raftproto.PeerLearner
+	}
+	return raftproto.PeerNormal
+}
+
 func (mp *metaPartition) TryToLeader(groupID uint64) error {
 	return mp.raftPartition.TryToLeader(groupID)
 }
@@ -1314,6 +1440,7 @@ func (mp *metaPartition) ResponseLoadMetaPartition(p *Packet) (err error) {
 	resp.DentryCount = uint64(mp.GetDentryTreeLen())
 	resp.ApplyID = mp.getApplyID()
 	resp.CommittedID = mp.getCommittedID()
+	resp.LeaderAddr, _ = mp.IsLeader()
 	if err != nil {
 		err = errors.Trace(err,
 			"[ResponseLoadMetaPartition] check snapshot")
@@ -1697,13 +1824,15 @@ func (mp *metaPartition) initTxInfo(txInfo *proto.TransactionInfo) error {
 	return fmt.Errorf("tx create is limited")
 }
 
+// storeSnapshotFiles stores the snapshot of the new partition, the trees are empty unless
+// the partition is forked from the source of a cloned volume.
 func (mp *metaPartition) storeSnapshotFiles() (err error) {
 	msg := &storeMsg{
 		applyIndex:     mp.applyID,
 		txId:           mp.txProcessor.txManager.txIdAlloc.getTransactionID(),
-		inodeTree:      NewBtree(),
-		dentryTree:     NewBtree(),
-		extendTree:     NewBtree(),
+		inodeTree:      mp.inodeTree.GetTree(),
+		dentryTree:     mp.dentryTree.GetTree(),
+		extendTree:     mp.extendTree.GetTree(),
 		multipartTree:  NewBtree(),
 		txTree:         NewBtree(),
 		txRbInodeTree:  NewBtree(),
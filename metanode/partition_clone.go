@@ -0,0 +1,477 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the inode tree of a cloned partition is scanned for the forked extents at most once in the interval
+	forkedInodeCheckInterval = time.Minute
+	// the forked extents are materialized by batches in the interval
+	cloneMaterializeInterval = time.Minute
+	cloneMaterializeBatch    = 128
+)
+
+// forkFrom copies the inodes, dentries and xattrs of the source partition visible at the version
+// verSeq into the partition before it is started. The forked items take the version of the partition,
+// while the extent keys keep their versions, so that the clients overwrite the forked extents by
+// appending new extents to the clone, and the forked extents are never deleted by the clone.
+//
+// Every replica forks from the local replica of the source partition, which must have applied the
+// versions after verSeq to make sure that all the replicas see the same view of the source.
+func (mp *metaPartition) forkFrom(src *metaPartition, verSeq uint64) (err error) {
+	if srcVer := src.GetVerSeq(); srcVer <= verSeq {
+		return fmt.Errorf("source partition(%v) verSeq(%v) has not applied the version after clone verSeq(%v)",
+			src.config.PartitionId, srcVer, verSeq)
+	}
+	if src.config.Start != mp.config.Start || src.config.End != mp.config.End {
+		return fmt.Errorf("source partition(%v) range(%v-%v) mismatch clone range(%v-%v)", src.config.PartitionId,
+			src.config.Start, src.config.End, mp.config.Start, mp.config.End)
+	}
+
+	var (
+		cursor   = mp.config.Start
+		inodeCnt int
+	)
+	inodeTree := src.inodeTree.GetTree()
+	inodeTree.Ascend(func(item BtreeItem) bool {
+		ino := item.(*Inode)
+		if vIno, _ := ino.getInoByVer(verSeq, false); vIno == nil || vIno.ShouldDelete() {
+			return true
+		}
+		mp.inodeTree.ReplaceOrInsert(src.forkInode(ino, verSeq, mp.verSeq), true)
+		if ino.Inode > cursor {
+			cursor = ino.Inode
+		}
+		inodeCnt++
+		return true
+	})
+
+	dentryTree := src.dentryTree.GetTree()
+	dentryTree.Ascend(func(item BtreeItem) bool {
+		den, _ := item.(*Dentry).getDentryFromVerList(verSeq, false)
+		if den == nil || den.isDeleted() {
+			return true
+		}
+		forked := den.CopyDirectly().(*Dentry)
+		forked.setVerSeq(mp.verSeq)
+		mp.dentryTree.ReplaceOrInsert(forked, true)
+		return true
+	})
+
+	extendTree := src.extendTree.GetTree()
+	extendTree.Ascend(func(item BtreeItem) bool {
+		ext := item.(*Extend)
+		if !mp.inodeTree.Has(&Inode{Inode: ext.GetInode()}) {
+			return true
+		}
+		vExt := ext
+		if ext.verSeq > verSeq {
+			vExt = ext.GetExtentByVersion(verSeq)
+		}
+		if vExt == nil {
+			return true
+		}
+		forked := NewExtend(ext.GetInode())
+		forked.Merge(vExt, true)
+		forked.verSeq = mp.verSeq
+		mp.extendTree.ReplaceOrInsert(forked, true)
+		return true
+	})
+
+	mp.config.Cursor = cursor
+	log.LogInfof("action[forkFrom] partition(%v) forked inodes(%v) dentries(%v) from source partition(%v) at verSeq(%v), cursor(%v)",
+		mp.config.PartitionId, inodeCnt, mp.dentryTree.Len(), src.config.PartitionId, verSeq, cursor)
+	return
+}
+
+// forkInode returns the inode visible at the version verSeq with the extents of the version.
+func (mp *metaPartition) forkInode(ino *Inode, verSeq, forkVerSeq uint64) *Inode {
+	vIno, _ := ino.getInoByVer(verSeq, false)
+	forked := vIno.CopyDirectly().(*Inode)
+	if ino.getVer() > verSeq {
+		rsp := &proto.GetExtentsResponse{}
+		mp.GetExtentByVer(ino, &proto.GetExtentsRequest{Inode: ino.Inode, VerSeq: verSeq}, rsp)
+		forked.Extents = NewSortedExtentsFromEks(rsp.Extents)
+	}
+	forked.multiSnap = nil
+	forked.setVerNoCheck(forkVerSeq)
+	return forked
+}
+
+// isForkedExtent returns true if the extent belongs to the source of the cloned volume, which
+// is pinned by the snapshot version of the source and must not be deleted by the clone.
+func (mp *metaPartition) isForkedExtent(ek *proto.ExtentKey) bool {
+	return mp.config.CloneVerSeq > 0 && ek.GetSeq() <= mp.config.CloneVerSeq
+}
+
+func (mp *metaPartition) skipForkedExtents(eks []proto.ExtentKey) []proto.ExtentKey {
+	if mp.config.CloneVerSeq == 0 {
+		return eks
+	}
+	left := make([]proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		if mp.isForkedExtent(&ek) {
+			log.LogDebugf("action[skipForkedExtents] partition(%v) skip forked extent(%v)", mp.config.PartitionId, ek)
+			continue
+		}
+		left = append(left, ek)
+	}
+	return left
+}
+
+func (mp *metaPartition) skipForkedExtentKeys(eks []*proto.ExtentKey) []*proto.ExtentKey {
+	if mp.config.CloneVerSeq == 0 {
+		return eks
+	}
+	left := make([]*proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		if mp.isForkedExtent(ek) {
+			log.LogDebugf("action[skipForkedExtentKeys] partition(%v) skip forked extent(%v)", mp.config.PartitionId, ek)
+			continue
+		}
+		left = append(left, ek)
+	}
+	return left
+}
+
+// GetForkedInodeCnt returns the count of the inodes still referencing the forked extents, the clone
+// can be detached from the source once no inode of it references the forked extents any more.
+func (mp *metaPartition) GetForkedInodeCnt() uint64 {
+	if mp.config.CloneVerSeq == 0 {
+		return 0
+	}
+	cnt := atomic.LoadUint64(&mp.forkedInodeCnt)
+	// no extent is forked after the creation, the count never grows once it reaches zero
+	if cnt == 0 && atomic.LoadInt64(&mp.forkedCheckTime) > 0 {
+		return 0
+	}
+	now := time.Now().Unix()
+	if now-atomic.LoadInt64(&mp.forkedCheckTime) < int64(forkedInodeCheckInterval/time.Second) {
+		return cnt
+	}
+
+	cnt = 0
+	mp.inodeTree.GetTree().Ascend(func(item BtreeItem) bool {
+		ino := item.(*Inode)
+		forked := false
+		checkExtents := func(i *Inode) {
+			i.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+				forked = mp.isForkedExtent(&ek)
+				return !forked
+			})
+		}
+		ino.DoReadFunc(func() {
+			checkExtents(ino)
+			ino.RangeMultiVer(func(_ int, snapIno *Inode) bool {
+				if !forked {
+					checkExtents(snapIno)
+				}
+				return !forked
+			})
+		})
+		if forked {
+			cnt++
+		}
+		return true
+	})
+	atomic.StoreUint64(&mp.forkedInodeCnt, cnt)
+	atomic.StoreInt64(&mp.forkedCheckTime, now)
+	return cnt
+}
+
+// forkedExtent is an extent key forked from the clone source which the inode still references.
+type forkedExtent struct {
+	inode uint64
+	ek    proto.ExtentKey
+}
+
+// startCloneMaterializer copies the forked extents into the data partitions of the clone on the leader
+// if the clone is to be materialized, so that the clone can be detached from the source at last. The
+// forked extents kept by the snapshots of the clone are released once the snapshots are deleted.
+func (mp *metaPartition) startCloneMaterializer() {
+	if mp.config.CloneVerSeq == 0 {
+		return
+	}
+	timer := time.NewTimer(cloneMaterializeInterval)
+	for {
+		select {
+		case <-timer.C:
+			if _, ok := mp.IsLeader(); ok {
+				if info := mp.vol.getCloneInfo(); info != nil && info.Materialize && info.Status == proto.VolCloneStatusShared {
+					mp.materializeForkedExtents(info.SrcVolName)
+				}
+			}
+			timer.Reset(cloneMaterializeInterval)
+		case <-mp.stopC:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// collectForkedExtents returns at most limit forked extents referenced by the current version of the inodes.
+func (mp *metaPartition) collectForkedExtents(limit int) (forked []*forkedExtent) {
+	mp.inodeTree.GetTree().Ascend(func(item BtreeItem) bool {
+		ino := item.(*Inode)
+		if !proto.IsRegular(ino.Type) {
+			return true
+		}
+		ino.DoReadFunc(func() {
+			ino.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+				if mp.isForkedExtent(&ek) {
+					forked = append(forked, &forkedExtent{inode: ino.Inode, ek: ek})
+				}
+				return len(forked) < limit
+			})
+		})
+		return len(forked) < limit
+	})
+	return
+}
+
+func (mp *metaPartition) materializeForkedExtents(srcVolName string) {
+	forked := mp.collectForkedExtents(cloneMaterializeBatch)
+	if len(forked) == 0 {
+		return
+	}
+	view, err := masterClient.ClientAPI().GetDataPartitions(srcVolName)
+	if err != nil {
+		log.LogWarnf("action[materializeForkedExtents] mp(%v) get data partitions of source vol(%v) err(%v)",
+			mp.config.PartitionId, srcVolName, err)
+		return
+	}
+	srcHosts := make(map[uint64][]string, len(view.DataPartitions))
+	for _, dp := range view.DataPartitions {
+		srcHosts[dp.PartitionID] = dp.Hosts
+	}
+
+	var materialized int
+	for _, fe := range forked {
+		if _, ok := mp.IsLeader(); !ok {
+			return
+		}
+		hosts := srcHosts[fe.ek.PartitionId]
+		if len(hosts) == 0 {
+			log.LogWarnf("action[materializeForkedExtents] mp(%v) ino(%v) source dp(%v) of extent(%v) not found",
+				mp.config.PartitionId, fe.inode, fe.ek.PartitionId, fe.ek)
+			continue
+		}
+		dp := mp.vol.GetWritablePartition()
+		if dp == nil {
+			log.LogWarnf("action[materializeForkedExtents] mp(%v) no writable data partition", mp.config.PartitionId)
+			return
+		}
+		newEk, err := mp.copyForkedExtent(dp, hosts, fe)
+		if err != nil {
+			log.LogWarnf("action[materializeForkedExtents] mp(%v) ino(%v) copy extent(%v) err(%v)",
+				mp.config.PartitionId, fe.inode, fe.ek, err)
+			continue
+		}
+		status, err := mp.swapForkedExtent(fe, newEk)
+		if err != nil || status != proto.OpOk {
+			// the copy is deleted by the fsm if the inode is modified meanwhile
+			log.LogWarnf("action[materializeForkedExtents] mp(%v) ino(%v) swap extent(%v) to(%v) status(%v) err(%v)",
+				mp.config.PartitionId, fe.inode, fe.ek, newEk, status, err)
+			continue
+		}
+		materialized++
+	}
+	log.LogInfof("action[materializeForkedExtents] mp(%v) materialized %v of %v forked extents",
+		mp.config.PartitionId, materialized, len(forked))
+}
+
+// copyForkedExtent copies the data of the forked extent into a new extent of the data partition.
+func (mp *metaPartition) copyForkedExtent(dp *DataPartition, srcHosts []string, fe *forkedExtent) (newEk proto.ExtentKey, err error) {
+	conn, err := mp.config.ConnPool.GetConnect(dp.Hosts[0])
+	if err != nil {
+		return
+	}
+	defer func() {
+		mp.config.ConnPool.PutConnect(conn, err != nil)
+	}()
+
+	p := NewPacketToCreateExtent(dp, fe.inode)
+	if err = mp.sendToDataNode(conn, p); err != nil {
+		return
+	}
+	newEk = proto.ExtentKey{
+		FileOffset:  fe.ek.FileOffset,
+		PartitionId: dp.PartitionID,
+		ExtentId:    p.ExtentID,
+		Size:        fe.ek.Size,
+	}
+	for offset := uint32(0); offset < fe.ek.Size; {
+		size := fe.ek.Size - offset
+		if size > util.BlockSize {
+			size = util.BlockSize
+		}
+		var data []byte
+		if data, err = mp.readForkedExtent(srcHosts, &fe.ek, offset, size); err != nil {
+			break
+		}
+		if err = mp.sendToDataNode(conn, NewPacketToWriteExtent(dp, newEk.ExtentId, int64(offset), data)); err != nil {
+			break
+		}
+		offset += size
+	}
+	if err != nil {
+		if errDel := mp.doDeleteMarkedInodes(&proto.ExtentKey{PartitionId: dp.PartitionID, ExtentId: newEk.ExtentId}); errDel != nil {
+			log.LogWarnf("action[copyForkedExtent] mp(%v) delete extent(%v) err(%v)", mp.config.PartitionId, newEk, errDel)
+		}
+	}
+	return
+}
+
+// readForkedExtent reads the range of the forked extent from any replica of the source data partition.
+func (mp *metaPartition) readForkedExtent(hosts []string, ek *proto.ExtentKey, offset, size uint32) (data []byte, err error) {
+	for _, host := range hosts {
+		if data, err = mp.readForkedExtentFromHost(host, ek, offset, size); err == nil {
+			return
+		}
+		log.LogWarnf("action[readForkedExtent] mp(%v) read extent(%v) offset(%v) size(%v) from(%v) err(%v)",
+			mp.config.PartitionId, ek, offset, size, host, err)
+	}
+	return
+}
+
+func (mp *metaPartition) readForkedExtentFromHost(host string, ek *proto.ExtentKey, offset, size uint32) (data []byte, err error) {
+	conn, err := mp.config.ConnPool.GetConnect(host)
+	if err != nil {
+		return
+	}
+	defer func() {
+		mp.config.ConnPool.PutConnect(conn, err != nil)
+	}()
+
+	start := int64(ek.ExtentOffset) + int64(offset)
+	p := NewPacketToReadExtent(ek, start, size)
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	data = make([]byte, size)
+	for read := uint32(0); read < size; {
+		reply := new(Packet)
+		if err = reply.ReadFromConnWithVer(conn, proto.ReadDeadlineTime); err != nil {
+			return nil, err
+		}
+		if reply.ResultCode != proto.OpOk {
+			return nil, fmt.Errorf("read reply(%v)", reply.GetResultMsg())
+		}
+		if reply.Size == 0 || reply.ExtentOffset != start+int64(read) || read+reply.Size > size {
+			return nil, fmt.Errorf("read reply offset(%v) size(%v) mismatch read(%v)", reply.ExtentOffset, reply.Size, read)
+		}
+		if crc := crc32.ChecksumIEEE(reply.Data[:reply.Size]); crc != reply.CRC {
+			return nil, fmt.Errorf("read reply crc(%v) mismatch expect(%v)", crc, reply.CRC)
+		}
+		copy(data[read:], reply.Data[:reply.Size])
+		read += reply.Size
+	}
+	return
+}
+
+func (mp *metaPartition) sendToDataNode(conn net.Conn, p *Packet) (err error) {
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConnWithVer(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		err = fmt.Errorf("op(%v) reply(%v)", p.GetOpMsg(), p.GetResultMsg())
+	}
+	return
+}
+
+// newForkedExtentSwap returns the inode param to swap the forked extent to the copy. It returns nil if the
+// forked extent is no longer referenced by the inode, the generation of the inode is checked by the fsm to
+// make sure no write or truncate comes after.
+func (mp *metaPartition) newForkedExtentSwap(fe *forkedExtent, newEk proto.ExtentKey) *Inode {
+	item := mp.inodeTree.Get(NewInode(fe.inode, 0))
+	if item == nil {
+		return nil
+	}
+	ino := item.(*Inode)
+	inoParm := NewInode(fe.inode, 0)
+	found := false
+	ino.DoReadFunc(func() {
+		ino.Extents.Range(func(_ int, ek proto.ExtentKey) bool {
+			found = ek.FileOffset == fe.ek.FileOffset && ek.PartitionId == fe.ek.PartitionId &&
+				ek.ExtentId == fe.ek.ExtentId && ek.ExtentOffset == fe.ek.ExtentOffset && ek.Size == fe.ek.Size
+			return !found && ek.FileOffset <= fe.ek.FileOffset
+		})
+		inoParm.Generation = ino.Generation
+		inoParm.ModifyTime = ino.ModifyTime
+	})
+	if !found {
+		return nil
+	}
+	inoParm.setVer(mp.verSeq)
+	inoParm.Extents.Append(newEk)
+	inoParm.Extents.eks = append(inoParm.Extents.eks, fe.ek)
+	return inoParm
+}
+
+func (mp *metaPartition) swapForkedExtent(fe *forkedExtent, newEk proto.ExtentKey) (status uint8, err error) {
+	inoParm := mp.newForkedExtentSwap(fe, newEk)
+	if inoParm == nil {
+		if err = mp.doDeleteMarkedInodes(&newEk); err != nil {
+			log.LogWarnf("action[swapForkedExtent] mp(%v) delete extent(%v) err(%v)", mp.config.PartitionId, newEk, err)
+		}
+		return proto.OpConflictExtentsErr, nil
+	}
+	val, err := inoParm.Marshal()
+	if err != nil {
+		return
+	}
+	resp, err := mp.submit(opFSMExtentsMaterialize, val)
+	if err != nil {
+		return
+	}
+	return resp.(uint8), nil
+}
+
+// fsmMaterializeForkedExtent swaps the forked extent of the inode to the copy, with the modify time of
+// the inode kept. The swap is given up if the inode is modified after the swap is proposed, and the copy
+// is deleted.
+func (mp *metaPartition) fsmMaterializeForkedExtent(ino *Inode) (status uint8) {
+	item := mp.inodeTree.Get(ino)
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	fsmIno := item.(*Inode)
+	var gen uint64
+	fsmIno.DoReadFunc(func() { gen = fsmIno.Generation })
+	if gen != ino.Generation {
+		eks := ino.Extents.CopyExtents()
+		// the copy is never forked
+		eks[0].SetSeq(mp.verSeq)
+		mp.extDelCh <- eks[:1]
+		log.LogInfof("action[fsmMaterializeForkedExtent] mp(%v) ino(%v) generation(%v) changed from(%v), drop the copy(%v)",
+			mp.config.PartitionId, ino.Inode, gen, ino.Generation, eks[0])
+		return proto.OpConflictExtentsErr
+	}
+	return mp.fsmAppendExtentsWithCheck(ino, false)
+}
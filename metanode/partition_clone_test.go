@@ -0,0 +1,160 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionForkFromSource(t *testing.T) {
+	const (
+		snapVer  uint64 = 20
+		srcVer   uint64 = 30
+		cloneVer uint64 = 40
+	)
+	src := newPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "src", VerSeq: srcVer}, manager)
+	src.config.Start = 1
+
+	root := NewInode(proto.RootIno, DirModeType)
+	src.inodeTree.ReplaceOrInsert(root, true)
+
+	// the file of the snapshot keeps its extent
+	file := NewInode(2, 0)
+	file.Extents = NewSortedExtentsFromEks([]proto.ExtentKey{buildExtentKey(0, 0, 1, 0, 1000)})
+	src.inodeTree.ReplaceOrInsert(file, true)
+	src.dentryTree.ReplaceOrInsert(&Dentry{ParentId: proto.RootIno, Name: "a", Inode: 2}, true)
+
+	// the file created after the snapshot is not forked
+	newFile := NewInode(3, 0)
+	newFile.setVer(srcVer)
+	src.inodeTree.ReplaceOrInsert(newFile, true)
+	newDen := &Dentry{ParentId: proto.RootIno, Name: "b", Inode: 3}
+	newDen.setVerSeq(srcVer)
+	src.dentryTree.ReplaceOrInsert(newDen, true)
+
+	ext := NewExtend(2)
+	ext.Put([]byte("k"), []byte("v"), 0)
+	src.extendTree.ReplaceOrInsert(ext, true)
+
+	clone := newPartition(&MetaPartitionConfig{PartitionId: 2, VolName: "clone", VerSeq: cloneVer, CloneVerSeq: snapVer}, manager)
+	clone.config.Start = 1
+
+	// the source must have applied the version after the snapshot
+	src.verSeq = snapVer
+	require.Error(t, clone.forkFrom(src, snapVer))
+	src.verSeq = srcVer
+	require.NoError(t, clone.forkFrom(src, snapVer))
+
+	require.Equal(t, 2, clone.inodeTree.Len())
+	require.False(t, clone.inodeTree.Has(&Inode{Inode: 3}))
+	forked := clone.inodeTree.Get(&Inode{Inode: 2}).(*Inode)
+	require.Equal(t, cloneVer, forked.getVer())
+	require.Equal(t, 1, forked.Extents.Len())
+	require.Equal(t, uint64(2), clone.GetCursor())
+
+	require.Equal(t, 1, clone.dentryTree.Len())
+	den := clone.dentryTree.Get(&Dentry{ParentId: proto.RootIno, Name: "a"}).(*Dentry)
+	require.Equal(t, uint64(2), den.Inode)
+	require.Equal(t, cloneVer, den.getVerSeq())
+
+	require.Equal(t, 1, clone.extendTree.Len())
+	value, ok := clone.extendTree.Get(NewExtend(2)).(*Extend).Get([]byte("k"))
+	require.True(t, ok)
+	require.Equal(t, []byte("v"), value)
+
+	// the forked extents are never deleted by the clone
+	forkedEk := buildExtentKey(snapVer, 0, 1, 0, 1000)
+	cloneEk := buildExtentKey(cloneVer, 0, 2, 0, 1000)
+	require.True(t, clone.isForkedExtent(&forkedEk))
+	require.False(t, clone.isForkedExtent(&cloneEk))
+	require.Equal(t, []proto.ExtentKey{cloneEk}, clone.skipForkedExtents([]proto.ExtentKey{forkedEk, cloneEk}))
+	require.Equal(t, []*proto.ExtentKey{&cloneEk}, clone.skipForkedExtentKeys([]*proto.ExtentKey{&forkedEk, &cloneEk}))
+	require.False(t, src.isForkedExtent(&forkedEk))
+
+	require.Equal(t, uint64(1), clone.GetForkedInodeCnt())
+	forked.Extents = NewSortedExtentsFromEks([]proto.ExtentKey{cloneEk})
+	clone.forkedCheckTime = 0
+	require.Equal(t, uint64(0), clone.GetForkedInodeCnt())
+}
+
+func TestPartitionMaterializeForkedExtent(t *testing.T) {
+	const (
+		snapVer  uint64 = 20
+		cloneVer uint64 = 40
+	)
+	clone := newPartition(&MetaPartitionConfig{PartitionId: 2, VolName: "clone", VerSeq: cloneVer, CloneVerSeq: snapVer}, manager)
+	clone.config.Start = 1
+	clone.multiVersionList = &proto.VolVersionInfoList{VerList: []*proto.VolVersionInfo{{Ver: cloneVer}}}
+
+	root := NewInode(proto.RootIno, DirModeType)
+	clone.inodeTree.ReplaceOrInsert(root, true)
+	forkedEk := buildExtentKey(snapVer, 0, 1, 0, 1000)
+	cloneEk := buildExtentKey(cloneVer, 1000, 2, 0, 1000)
+	file := NewInode(2, 0)
+	file.Extents = NewSortedExtentsFromEks([]proto.ExtentKey{forkedEk, cloneEk})
+	file.Size = 2000
+	file.ModifyTime = 123
+	file.Generation = 5
+	file.setVer(cloneVer)
+	clone.inodeTree.ReplaceOrInsert(file, true)
+	other := NewInode(3, 0)
+	other.Extents = NewSortedExtentsFromEks([]proto.ExtentKey{buildExtentKey(snapVer, 0, 4, 0, 1000)})
+	other.setVer(cloneVer)
+	clone.inodeTree.ReplaceOrInsert(other, true)
+
+	forked := clone.collectForkedExtents(cloneMaterializeBatch)
+	require.Len(t, forked, 2)
+	require.Equal(t, uint64(2), forked[0].inode)
+	require.Equal(t, forkedEk, forked[0].ek)
+	require.Len(t, clone.collectForkedExtents(1), 1)
+
+	copyEk := proto.ExtentKey{FileOffset: 0, PartitionId: partitionId, ExtentId: 3, Size: 1000}
+	swap := clone.newForkedExtentSwap(forked[0], copyEk)
+	require.NotNil(t, swap)
+	require.Equal(t, uint64(5), swap.Generation)
+
+	// the copy is dropped if the inode is modified after the swap is proposed
+	file.Generation++
+	require.Equal(t, proto.OpConflictExtentsErr, clone.fsmMaterializeForkedExtent(swap))
+	dropped := <-clone.extDelCh
+	require.Len(t, dropped, 1)
+	require.Equal(t, uint64(3), dropped[0].ExtentId)
+	require.False(t, clone.isForkedExtent(&dropped[0]))
+
+	swap = clone.newForkedExtentSwap(forked[0], copyEk)
+	require.Equal(t, proto.OpOk, clone.fsmMaterializeForkedExtent(swap))
+	file = clone.inodeTree.Get(file).(*Inode)
+	eks := file.Extents.CopyExtents()
+	require.Len(t, eks, 2)
+	require.Equal(t, uint64(3), eks[0].ExtentId)
+	require.Equal(t, cloneEk.ExtentId, eks[1].ExtentId)
+	require.Equal(t, int64(123), file.ModifyTime)
+	require.Equal(t, uint64(2000), file.Size)
+	// the forked extent is left to the source
+	released := <-clone.extDelCh
+	require.Equal(t, forkedEk.ExtentId, released[0].ExtentId)
+	require.Empty(t, clone.skipForkedExtents(released))
+
+	forked = clone.collectForkedExtents(cloneMaterializeBatch)
+	require.Len(t, forked, 1)
+	require.Equal(t, uint64(3), forked[0].inode)
+	// the swap is given up once the forked extent is no longer referenced
+	other = clone.inodeTree.Get(other).(*Inode)
+	other.Extents = NewSortedExtentsFromEks([]proto.ExtentKey{buildExtentKey(cloneVer, 0, 5, 0, 500)})
+	require.Nil(t, clone.newForkedExtentSwap(forked[0], copyEk))
+}
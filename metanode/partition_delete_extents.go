@@ -134,6 +134,9 @@ LOOP:
 			var data []byte
 			buf = buf[:0]
 			log.LogDebugf("del eks [%v]", eks)
+			if eks = mp.skipForkedExtents(eks); len(eks) == 0 {
+				continue
+			}
 			for _, ek := range eks {
 				data, err = ek.MarshalBinaryWithCheckSum(true)
 				if err != nil {
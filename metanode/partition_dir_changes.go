@@ -0,0 +1,126 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// dirChangeLog keeps the latest dentry changes of the partition in memory. The changes are
+// recorded on applying the raft log and the raft index is the sequence of the change, so the
+// sequences are the same on all the replicas. The changes before the base are unknown, such as
+// the changes before the restart and the changes dropped from the full log.
+type dirChangeLog struct {
+	sync.RWMutex
+	capacity int
+	events   []proto.DirChangeEvent // ring buffer
+	start    int
+	base     uint64
+}
+
+func newDirChangeLog(capacity int) *dirChangeLog {
+	return &dirChangeLog{capacity: capacity}
+}
+
+// reset drops all the changes, the changes until the applied index are unknown.
+func (l *dirChangeLog) reset(applyID uint64) {
+	l.Lock()
+	defer l.Unlock()
+	l.events, l.start, l.base = nil, 0, applyID
+}
+
+func (l *dirChangeLog) append(event proto.DirChangeEvent) {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.events) < l.capacity {
+		l.events = append(l.events, event)
+		return
+	}
+	l.base = l.events[l.start].Seq
+	l.events[l.start] = event
+	l.start = (l.start + 1) % l.capacity
+}
+
+// read returns at most limit changes of the parent after the sequence from, truncated is true
+// if some changes after from are unknown.
+func (l *dirChangeLog) read(parentID, from uint64, limit int) (events []proto.DirChangeEvent, last uint64, truncated bool) {
+	l.RLock()
+	defer l.RUnlock()
+	truncated = from < l.base
+	for i := 0; i < len(l.events); i++ {
+		event := l.events[(l.start+i)%len(l.events)]
+		if event.Seq <= from || event.ParentID != parentID {
+			continue
+		}
+		events = append(events, event)
+		last = event.Seq
+		if len(events) >= limit {
+			break
+		}
+	}
+	return
+}
+
+// recordDirChange records the change of the dentry applied by the raft log, the changes
+// out of the raft log such as loading the snapshot are not recorded.
+func (mp *metaPartition) recordDirChange(op uint8, dentry *Dentry) {
+	if mp.applyingIndex == 0 || mp.dirChanges == nil {
+		return
+	}
+	mp.dirChanges.append(proto.DirChangeEvent{
+		Seq:      mp.applyingIndex,
+		Op:       op,
+		ParentID: dentry.ParentId,
+		Name:     dentry.Name,
+		Inode:    dentry.Inode,
+		Type:     dentry.Type,
+		Time:     time.Now().Unix(),
+	})
+}
+
+// ReadDirChanges reads the changes of the directory after the sequence of the request.
+func (mp *metaPartition) ReadDirChanges(req *ReadDirChangesReq, p *Packet) (err error) {
+	limit := int(req.Limit)
+	if limit <= 0 || limit > readDirChangesMaxLimit {
+		limit = readDirChangesMaxLimit
+	}
+	resp := &ReadDirChangesResp{}
+	// the changes are recorded before the index is applied, so all the changes until
+	// the applied index are returned if the limit is not reached
+	applied := mp.getApplyID()
+	var last uint64
+	resp.Events, last, resp.Truncated = mp.dirChanges.read(req.ParentID, req.From, limit)
+	if resp.Next = applied; len(resp.Events) >= limit || resp.Next < last {
+		resp.Next = last
+	}
+	if resp.Next < req.From {
+		resp.Next = req.From
+	}
+	log.LogDebugf("action[ReadDirChanges] mp[%v] parent[%v] from[%v] count[%v] next[%v] truncated[%v]",
+		mp.config.PartitionId, req.ParentID, req.From, len(resp.Events), resp.Next, resp.Truncated)
+
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
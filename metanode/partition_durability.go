@@ -0,0 +1,66 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const defaultGroupCommitDelay = 2 * time.Millisecond
+
+// walDurability returns how the raft log of the partition is synced, the durability
+// of the volume overrides the one of the metanode config:
+//   - async: the raft log is not synced, the entries may be lost if the nodes crash together.
+//   - group: the syncs of the raft log are at least the delay apart, the entries stored
+//     within the delay are acked at once and grouped into the next sync, so at most the
+//     entries of the last delay may be lost if the nodes crash together.
+//   - sync: the raft log is synced before every ack.
+func (mp *metaPartition) walDurability(view *proto.SimpleVolView) (sync bool, delay time.Duration) {
+	durability, delay := proto.MetaDurabilityAsync, defaultGroupCommitDelay
+	if mp.manager != nil && mp.manager.metaNode != nil {
+		durability, delay = mp.manager.metaNode.metaDurability, mp.manager.metaNode.groupCommitDelay
+	}
+	if view != nil && view.MetaDurability != proto.MetaDurabilityDefault {
+		durability = view.MetaDurability
+	}
+	if view != nil && view.MetaGroupCommitDelayMs > 0 {
+		delay = time.Duration(view.MetaGroupCommitDelayMs) * time.Millisecond
+	}
+
+	switch durability {
+	case proto.MetaDurabilityGroup:
+		return true, delay
+	case proto.MetaDurabilitySync:
+		return true, 0
+	default:
+		return false, 0
+	}
+}
+
+func (mp *metaPartition) updateWalDurability(view *proto.SimpleVolView) {
+	sync, delay := mp.walDurability(view)
+	if sync == mp.walSync && delay == mp.walSyncDelay {
+		return
+	}
+	log.LogInfof("updateWalDurability: mp(%v) vol(%v) durability(%v) sync(%v->%v) delay(%v->%v)",
+		mp.config.PartitionId, mp.config.VolName, view.MetaDurability, mp.walSync, sync, mp.walSyncDelay, delay)
+	mp.walSync, mp.walSyncDelay = sync, delay
+	if mp.raftPartition != nil {
+		mp.raftPartition.SetWalSync(sync, delay)
+	}
+}
@@ -80,6 +80,8 @@ func (mp *metaPartition) updateVolView(convert func(view *proto.DataPartitionsVi
 		return
 	}
 	mp.vol.volDeleteLockTime = volView.DeleteLockTime
+	mp.vol.setCloneInfo(volView.CloneInfo)
+	mp.updateWalDurability(volView)
 	return nil
 }
 
@@ -291,6 +293,9 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 
 		extInfo := inode.GetAllExtsOfflineInode(mp.config.PartitionId)
 		for dpID, inodeExts := range extInfo {
+			if inodeExts = mp.skipForkedExtentKeys(inodeExts); len(inodeExts) == 0 {
+				continue
+			}
 			exts, ok := deleteExtentsByPartition[dpID]
 			if !ok {
 				exts = make([]*proto.ExtentKey, 0)
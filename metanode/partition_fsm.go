@@ -48,6 +48,10 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 
 	mp.nonIdempotent.Lock()
 	defer mp.nonIdempotent.Unlock()
+	mp.applyingIndex = index
+	defer func() {
+		mp.applyingIndex = 0
+	}()
 
 	switch msg.Op {
 	case opFSMCreateInode:
@@ -214,6 +218,18 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp = mp.fsmAppendExtentsWithCheck(ino, false)
+	case opFSMExtentsMaterialize:
+		ino := NewInode(0, 0)
+		if err = ino.Unmarshal(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmMaterializeForkedExtent(ino)
+	case opFSMAttrJournalBatch:
+		var ops []*attrJournalOp
+		if ops, err = unmarshalAttrJournalOps(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmAttrJournalBatch(ops)
 	case opFSMExtentSplit:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -252,6 +268,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		quotaRebuild := mp.mqMgr.statisticRebuildStart()
 		uidRebuild := mp.acucumRebuildStart()
 		uniqChecker := mp.uniqChecker.clone()
+		inodeRecycler := mp.inodeRecycler.clone()
 		msg := &storeMsg{
 			command:        opFSMStoreTick,
 			applyIndex:     index,
@@ -266,6 +283,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			quotaRebuild:   quotaRebuild,
 			uidRebuild:     uidRebuild,
 			uniqChecker:    uniqChecker,
+			inodeRecycler:  inodeRecycler,
 			multiVerList:   mp.GetAllVerList(),
 		}
 		log.LogDebugf("opFSMStoreTick: quotaRebuild [%v] uidRebuild [%v]", quotaRebuild, uidRebuild)
@@ -582,7 +600,11 @@ func (mp *metaPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 		}
 		updated, err = mp.confRemoveNode(req, index)
 	case raftproto.ConfUpdateNode:
-		// updated, err = mp.confUpdateNode(req, index)
+		req := &proto.AddMetaPartitionRaftMemberRequest{}
+		if err = json.Unmarshal(confChange.Context, req); err != nil {
+			return
+		}
+		updated, err = mp.confPromoteLearner(req, index)
 	default:
 		// do nothing
 	}
@@ -621,6 +643,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		txRbInodeTree  = NewBtree()
 		txRbDentryTree = NewBtree()
 		uniqChecker    = newUniqChecker()
+		inodeRecycler  = newInodeRecycler()
 		verList        []*proto.VolVersionInfo
 	)
 
@@ -665,6 +688,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 	defer func() {
 		if err == io.EOF {
 			mp.applyID = appIndexID
+			mp.dirChanges.reset(appIndexID)
 			mp.config.UniqId = uniqID
 			mp.txProcessor.txManager.txIdAlloc.setTransactionID(txID)
 			mp.inodeTree = inodeTree
@@ -676,6 +700,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			mp.txProcessor.txResource.txRbInodeTree = txRbInodeTree
 			mp.txProcessor.txResource.txRbDentryTree = txRbDentryTree
 			mp.uniqChecker = uniqChecker
+			mp.inodeRecycler = inodeRecycler
 			mp.multiVersionList.VerList = make([]*proto.VolVersionInfo, len(verList))
 			copy(mp.multiVersionList.VerList, verList)
 			mp.verSeq = mp.multiVersionList.GetLastVer()
@@ -694,6 +719,7 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				txRbInodeTree:  mp.txProcessor.txResource.txRbInodeTree.GetTree(),
 				txRbDentryTree: mp.txProcessor.txResource.txRbDentryTree.GetTree(),
 				uniqChecker:    uniqChecker.clone(),
+				inodeRecycler:  inodeRecycler.clone(),
 				multiVerList:   mp.GetVerList(),
 			}
 			select {
@@ -842,6 +868,14 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				return
 			}
 			log.LogDebugf("ApplySnapshot: write snap uniqChecker")
+		case opFSMInodeRecyclerSnap:
+			if err = inodeRecycler.UnMarshal(snap.V); err != nil {
+				log.LogErrorf("ApplySnapshot: unmarshal snap inodeRecycler fail: partitionID(%v) err(%v)",
+					mp.config.PartitionId, err)
+				return
+			}
+			log.LogDebugf("ApplySnapshot: write snap inodeRecycler: partitionID(%v) count(%v)",
+				mp.config.PartitionId, inodeRecycler.Len())
 
 		default:
 			if leaderSnapFormatVer != math.MaxUint32 && leaderSnapFormatVer > mp.manager.metaNode.raftSyncSnapFormatVersion {
@@ -932,6 +966,11 @@ func (mp *metaPartition) uploadApplyID(applyId uint64) {
 	atomic.StoreUint64(&mp.applyID, applyId)
 }
 
+// GetAppliedID returns the applied index of the partition.
+func (mp *metaPartition) GetAppliedID() uint64 {
+	return mp.getApplyID()
+}
+
 func (mp *metaPartition) getApplyID() (applyId uint64) {
 	return atomic.LoadUint64(&mp.applyID)
 }
@@ -113,11 +113,32 @@ func (mp *metaPartition) confAddNode(req *proto.AddMetaPartitionRaftMemberReques
 		return
 	}
 	mp.config.Peers = append(mp.config.Peers, req.AddPeer)
+	if req.AsLearner {
+		mp.config.Learners = append(mp.config.Learners, req.AddPeer)
+	}
 	addr := strings.Split(req.AddPeer.Addr, ":")[0]
 	mp.config.RaftStore.AddNodeWithPort(req.AddPeer.ID, addr, heartbeatPort, replicaPort)
 	return
 }
 
+// confPromoteLearner makes the learner a voter, the learner is promoted after it catches up the logs.
+func (mp *metaPartition) confPromoteLearner(req *proto.AddMetaPartitionRaftMemberRequest, index uint64) (updated bool, err error) {
+	updated = mp.removeLearner(req.AddPeer.ID)
+	log.LogInfof("action[confPromoteLearner] partition(%v) promote learner(%v) updated(%v) index(%v)",
+		mp.config.PartitionId, req.AddPeer, updated, index)
+	return
+}
+
+func (mp *metaPartition) removeLearner(peerID uint64) bool {
+	for i, learner := range mp.config.Learners {
+		if learner.ID == peerID {
+			mp.config.Learners = append(mp.config.Learners[:i], mp.config.Learners[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func (mp *metaPartition) confRemoveNode(req *proto.RemoveMetaPartitionRaftMemberRequest, index uint64) (updated bool, err error) {
 	var canRemoveSelf bool
 	if canRemoveSelf, err = mp.canRemoveSelf(); err != nil {
@@ -140,6 +161,7 @@ func (mp *metaPartition) confRemoveNode(req *proto.RemoveMetaPartitionRaftMember
 		return
 	}
 	mp.config.Peers = append(mp.config.Peers[:peerIndex], mp.config.Peers[peerIndex+1:]...)
+	mp.removeLearner(req.RemovePeer.ID)
 	if mp.config.NodeId == req.RemovePeer.ID && !mp.isLoadingMetaPartition && canRemoveSelf {
 		mp.Stop()
 		mp.DeleteRaft()
@@ -116,6 +116,7 @@ func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 				parIno.IncNLink(mp.verSeq)
 				parIno.SetMtime()
 			}
+			mp.recordDirChange(proto.DirChangeCreate, dentry)
 			return
 		} else if proto.OsModeType(dentry.Type) != proto.OsModeType(d.Type) && !proto.IsSymlink(dentry.Type) && !proto.IsSymlink(d.Type) {
 			log.LogErrorf("action[fsmCreateDentry] ParentId [%v] get [%v] but should del, dentry name [%v], inode[%v], type[%v,%v],dir[%v,%v]",
@@ -135,6 +136,7 @@ func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 		parIno.IncNLink(mp.verSeq)
 		parIno.SetMtime()
 	}
+	mp.recordDirChange(proto.DirChangeCreate, dentry)
 	return
 }
 
@@ -297,6 +299,7 @@ func (mp *metaPartition) fsmDeleteDentry(denParm *Dentry, checkInode bool) (resp
 				}
 			})
 	}
+	mp.recordDirChange(proto.DirChangeDelete, denFound)
 	resp.Msg = denFound
 	return
 }
@@ -379,6 +382,7 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 			d.multiSnap.dentryList = append([]*Dentry{dn.(*Dentry)}, d.multiSnap.dentryList...)
 		}
 		d.Inode, dentry.Inode = dentry.Inode, d.Inode
+		mp.recordDirChange(proto.DirChangeUpdate, d)
 		resp.Msg = dentry
 	})
 	return
@@ -486,3 +490,92 @@ func (mp *metaPartition) readDirLimit(req *ReadDirLimitReq) (resp *ReadDirLimitR
 	log.LogDebugf("action[readDirLimit] mp[%v] resp %v", mp.config.PartitionId, resp)
 	return
 }
+
+// readDirFiltered scans at most req.Limit dentries of the parent strictly after the marker, the entries
+// whose inodes are in the partition are filtered and sorted here, and the others are left unresolved.
+func (mp *metaPartition) readDirFiltered(req *ReadDirFilteredReq) (resp *ReadDirFilteredResp) {
+	resp = &ReadDirFilteredResp{Entries: make([]proto.DirEntryInfo, 0)}
+	limit := req.Limit
+	if limit == 0 || limit > readDirFilteredMaxScan {
+		limit = readDirFilteredMaxScan
+	}
+	startDentry := &Dentry{
+		ParentId: req.ParentID,
+		Name:     req.Marker,
+	}
+	endDentry := &Dentry{
+		ParentId: req.ParentID + 1,
+	}
+	ino := NewInode(0, 0)
+	ino.setVer(req.VerSeq)
+	var last string
+	mp.dentryTree.AscendRange(startDentry, endDentry, func(i BtreeItem) bool {
+		den := i.(*Dentry)
+		if req.Marker != "" && den.Name == req.Marker {
+			return true
+		}
+		if resp.Scanned >= limit {
+			resp.Next = last
+			return false
+		}
+		resp.Scanned++
+		last = den.Name
+		d := mp.getDentryByVerSeq(den, req.VerSeq)
+		if d == nil {
+			return true
+		}
+		dentry := proto.Dentry{Inode: d.Inode, Type: d.Type, Name: d.Name}
+		if d.Inode < mp.config.Start || d.Inode > mp.config.End {
+			resp.Unresolved = append(resp.Unresolved, dentry)
+			return true
+		}
+		ino.Inode = d.Inode
+		inode := mp.getInodeByVer(ino)
+		if inode == nil || inode.ShouldDelete() {
+			return true
+		}
+		inode.RLock()
+		size, mtime := inode.Size, inode.ModifyTime
+		inode.RUnlock()
+		if req.Filter.Match(d.Type, size, mtime) {
+			resp.Entries = append(resp.Entries, proto.DirEntryInfo{Dentry: dentry, Size: size, Mtime: mtime})
+		}
+		return true
+	})
+	proto.SortDirEntries(resp.Entries, req.SortBy)
+	return
+}
+
+// readDirStream reads at most limit dentries of the parent strictly after the marker,
+// one more dentry is peeked to tell whether there are more dentries left.
+func (mp *metaPartition) readDirStream(parentID uint64, marker string, limit uint64, verSeq uint64) (children []proto.Dentry, hasMore bool) {
+	startDentry := &Dentry{
+		ParentId: parentID,
+		Name:     marker,
+	}
+	endDentry := &Dentry{
+		ParentId: parentID + 1,
+	}
+	children = make([]proto.Dentry, 0)
+	mp.dentryTree.AscendRange(startDentry, endDentry, func(i BtreeItem) bool {
+		den := i.(*Dentry)
+		if marker != "" && den.Name == marker {
+			return true
+		}
+		d := mp.getDentryByVerSeq(den, verSeq)
+		if d == nil {
+			return true
+		}
+		if uint64(len(children)) >= limit {
+			hasMore = true
+			return false
+		}
+		children = append(children, proto.Dentry{
+			Inode: d.Inode,
+			Type:  d.Type,
+			Name:  d.Name,
+		})
+		return true
+	})
+	return
+}
@@ -77,6 +77,10 @@ func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
+		return
+	}
+	if ino.Epoch > 0 {
+		mp.inodeRecycler.remove(ino.Inode)
 	}
 
 	return
@@ -413,7 +417,9 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
 	log.LogDebugf("action[internalDeleteInode] ino[%v] really be deleted", ino)
-	mp.inodeTree.Delete(ino)
+	if item := mp.inodeTree.Delete(ino); item != nil {
+		mp.recycleInodeID(item.(*Inode))
+	}
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
 	return
@@ -529,6 +529,93 @@ func (mp *metaPartition) ReadDir(req *ReadDirReq, p *Packet) (err error) {
 func (mp *metaPartition) ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err error) {
 	log.LogInfof("action[ReadDirLimit] read seq [%v], request[%v]", req.VerSeq, req)
 	resp := mp.readDirLimit(req)
+	if req.Encoding != nil {
+		// names of the huge directory share long prefixes, encode them to cut the bandwidth
+		if resp.Encoded, resp.Encoding, err = req.Encoding.Encode(resp.Children); err != nil {
+			p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+			return
+		}
+		resp.Children = nil
+	}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// ReadDirFiltered reads the entries of a directory selected by the attributes of their inodes, so that
+// the scanners need not fetch the whole directory and the inodes to filter them.
+func (mp *metaPartition) ReadDirFiltered(req *ReadDirFilteredReq, p *Packet) (err error) {
+	resp := mp.readDirFiltered(req)
+	log.LogDebugf("action[ReadDirFiltered] mp[%v] parent[%v] marker[%v] scanned[%v] matched[%v] unresolved[%v]",
+		mp.config.PartitionId, req.ParentID, req.Marker, resp.Scanned, len(resp.Entries), len(resp.Unresolved))
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// ReadDirStream reads the dentries of a directory batch by batch. The cursor returned in the response
+// carries the position and the lease of the session issued by the partition, the lease keeps the read
+// version of the session and is renewed by each batch. The cursor of the unknown or expired lease is rejected.
+func (mp *metaPartition) ReadDirStream(req *ReadDirStreamReq, p *Packet) (err error) {
+	var (
+		cursor *proto.ReadDirCursor
+		verSeq uint64
+	)
+	now := time.Now()
+	if req.Cursor == "" {
+		cursor = &proto.ReadDirCursor{
+			ParentID: req.ParentID,
+			Marker:   req.Marker,
+		}
+		verSeq = req.VerSeq
+		if cursor.Lease, err = mp.readDirLeases.issue(req.ParentID, verSeq, now); err != nil {
+			p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+			return
+		}
+	} else {
+		if cursor, err = proto.DecodeReadDirCursor(req.Cursor); err != nil {
+			p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(fmt.Sprintf("invalid cursor: %v", err)))
+			return
+		}
+		if cursor.ParentID != req.ParentID {
+			err = fmt.Errorf("cursor parent[%v] mismatch with request parent[%v]", cursor.ParentID, req.ParentID)
+			p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+			return
+		}
+		if verSeq, err = mp.readDirLeases.renew(cursor.Lease, cursor.ParentID, now); err != nil {
+			p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+			return
+		}
+	}
+
+	limit := req.Limit
+	if limit == 0 || limit > readDirStreamMaxLimit {
+		limit = readDirStreamMaxLimit
+	}
+
+	resp := &ReadDirStreamResp{}
+	resp.Children, resp.HasMore = mp.readDirStream(cursor.ParentID, cursor.Marker, limit, verSeq)
+	if len(resp.Children) > 0 {
+		cursor.Marker = resp.Children[len(resp.Children)-1].Name
+	}
+	if !resp.HasMore {
+		mp.readDirLeases.release(cursor.Lease)
+	}
+	if resp.Cursor, err = cursor.Encode(); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	log.LogDebugf("action[ReadDirStream] mp[%v] parent[%v] marker[%v] seq[%v] count[%v] more[%v]",
+		mp.config.PartitionId, cursor.ParentID, cursor.Marker, verSeq, len(resp.Children), resp.HasMore)
+
 	reply, err := json.Marshal(resp)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
@@ -540,6 +627,8 @@ func (mp *metaPartition) ReadDirLimit(req *ReadDirLimitReq, p *Packet) (err erro
 
 // Lookup looks up the given dentry from the request.
 func (mp *metaPartition) Lookup(req *LookupReq, p *Packet) (err error) {
+	// applied before the lookup, the state looked up is not older than it
+	appliedID := mp.getApplyID()
 	dentry := &Dentry{
 		ParentId: req.ParentID,
 		Name:     req.Name,
@@ -556,17 +645,19 @@ func (mp *metaPartition) Lookup(req *LookupReq, p *Packet) (err error) {
 		var resp *LookupResp
 		if status == proto.OpOk {
 			resp = &LookupResp{
-				Inode:  dentry.Inode,
-				Mode:   dentry.Type,
-				VerSeq: dentry.getSeqFiled(),
-				LayAll: denList,
+				Inode:     dentry.Inode,
+				Mode:      dentry.Type,
+				VerSeq:    dentry.getSeqFiled(),
+				LayAll:    denList,
+				AppliedID: appliedID,
 			}
 		} else {
 			resp = &LookupResp{
-				Inode:  0,
-				Mode:   0,
-				VerSeq: 0,
-				LayAll: denList,
+				Inode:     0,
+				Mode:      0,
+				VerSeq:    0,
+				LayAll:    denList,
+				AppliedID: appliedID,
 			}
 		}
 		reply, err = json.Marshal(resp)
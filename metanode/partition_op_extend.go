@@ -74,6 +74,9 @@ func (mp *metaPartition) UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (
 }
 
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	extend := NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value), mp.verSeq)
 	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
@@ -85,6 +88,9 @@ func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err er
 }
 
 func (mp *metaPartition) BatchSetXAttr(req *proto.BatchSetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	extend := NewExtend(req.Inode)
 	for key, val := range req.Attrs {
 		extend.Put([]byte(key), []byte(val), mp.verSeq)
@@ -99,6 +105,9 @@ func (mp *metaPartition) BatchSetXAttr(req *proto.BatchSetXAttrRequest, p *Packe
 }
 
 func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	response := &proto.GetXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
@@ -124,6 +133,9 @@ func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err er
 }
 
 func (mp *metaPartition) GetAllXAttr(req *proto.GetAllXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	response := &proto.GetAllXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
@@ -183,6 +195,9 @@ func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packe
 }
 
 func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	extend := NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), nil, req.VerSeq)
 	if _, err = mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
@@ -194,6 +209,9 @@ func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (
 }
 
 func (mp *metaPartition) ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	response := &proto.ListXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
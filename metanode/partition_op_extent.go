@@ -107,6 +107,11 @@ func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithChe
 		log.LogErrorf("ExtentAppendWithCheck CheckQuota fail err [%v]", err)
 		return
 	}
+	if req.IsStale(i.Epoch) {
+		err = fmt.Errorf("inode[%v] epoch[%v] is stale, current epoch[%v]", req.Inode, req.Epoch, i.Epoch)
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
+		return
+	}
 
 	// check volume's Type: if volume's type is cold, cbfs' extent can be modify/add only when objextent exist
 	if proto.IsCold(mp.volType) {
@@ -144,11 +149,12 @@ func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithChe
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
-	var opFlag uint32 = opFSMExtentsAddWithCheck
+	var resp interface{}
 	if req.IsSplit {
-		opFlag = opFSMExtentSplit
+		resp, err = mp.submit(opFSMExtentSplit, val)
+	} else {
+		resp, err = mp.submitAttr(opFSMExtentsAddWithCheck, val)
 	}
-	resp, err := mp.submit(opFlag, val)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
@@ -391,6 +397,10 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 		reply  []byte
 		status = retMsg.Status
 	)
+	if status == proto.OpOk && req.IsStale(ino.Epoch) {
+		log.LogWarnf("action[ExtentsList] inode[%v] epoch[%v] is stale, current epoch[%v]", req.Inode, req.Epoch, ino.Epoch)
+		status = proto.OpNotExistErr
+	}
 
 	if status == proto.OpOk {
 		resp := &proto.GetExtentsResponse{}
@@ -486,6 +496,11 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, rem
 		return
 	}
 	i := item.(*Inode)
+	if req.IsStale(i.Epoch) {
+		err = fmt.Errorf("inode[%v] epoch[%v] is stale, current epoch[%v]", req.Inode, req.Epoch, i.Epoch)
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
+		return
+	}
 	status := mp.isOverQuota(req.Inode, req.Size > i.Size, false)
 	if status != 0 {
 		log.LogErrorf("ExtentsTruncate fail status [%v]", status)
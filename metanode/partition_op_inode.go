@@ -37,6 +37,7 @@ func replyInfoNoCheck(info *proto.InodeInfo, ino *Inode) bool {
 	info.Uid = ino.Uid
 	info.Gid = ino.Gid
 	info.Generation = ino.Generation
+	info.Epoch = ino.Epoch
 	info.VerSeq = ino.getVer()
 	if length := len(ino.LinkTarget); length > 0 {
 		info.Target = make([]byte, length)
@@ -61,6 +62,7 @@ func replyInfo(info *proto.InodeInfo, ino *Inode, quotaInfos map[uint32]*proto.M
 	info.Uid = ino.Uid
 	info.Gid = ino.Gid
 	info.Generation = ino.Generation
+	info.Epoch = ino.Epoch
 	info.VerSeq = ino.getVer()
 	if length := len(ino.LinkTarget); length > 0 {
 		info.Target = make([]byte, length)
@@ -82,6 +84,7 @@ func txReplyInfo(inode *Inode, txInfo *proto.TransactionInfo, quotaInfos map[uin
 		Uid:        inode.Uid,
 		Gid:        inode.Gid,
 		Generation: inode.Generation,
+		Epoch:      inode.Epoch,
 		ModifyTime: time.Unix(inode.ModifyTime, 0),
 		CreateTime: time.Unix(inode.CreateTime, 0),
 		AccessTime: time.Unix(inode.AccessTime, 0),
@@ -115,12 +118,14 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet, remoteAddr st
 			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.GetFullPath(), err, time.Since(start).Milliseconds(), inoID, 0)
 		}()
 	}
-	inoID, err = mp.nextInodeID()
+	inoID, epoch, err := mp.allocInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
 		return
 	}
+	defer mp.releaseInodeID(inoID, epoch)
 	ino := NewInode(inoID, req.Mode)
+	ino.Epoch = epoch
 	ino.Uid = req.Uid
 	ino.Gid = req.Gid
 	ino.setVer(mp.verSeq)
@@ -169,12 +174,14 @@ func (mp *metaPartition) QuotaCreateInode(req *proto.QuotaCreateInodeRequest, p
 			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.GetFullPath(), err, time.Since(start).Milliseconds(), inoID, 0)
 		}()
 	}
-	inoID, err = mp.nextInodeID()
+	inoID, epoch, err := mp.allocInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
 		return
 	}
+	defer mp.releaseInodeID(inoID, epoch)
 	ino := NewInode(inoID, req.Mode)
+	ino.Epoch = epoch
 	ino.Uid = req.Uid
 	ino.Gid = req.Gid
 	ino.LinkTarget = req.Target
@@ -347,6 +354,9 @@ func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet, remoteAddr st
 		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
 		return
 	}
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 
 	if req.UniqID > 0 {
 		val = InodeOnceUnlinkMarshal(req)
@@ -490,6 +500,11 @@ func (mp *metaPartition) InodeGetSplitEk(req *InodeGetSplitReq, p *Packet) (err
 
 // InodeGet executes the inodeGet command from the client.
 func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
+	// applied before the inode get, the state got is not older than it
+	appliedID := mp.getApplyID()
 	ino := NewInode(req.Inode, 0)
 	ino.setVer(req.VerSeq)
 	getAllVerInfo := req.VerAll
@@ -518,7 +533,8 @@ func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
 	ino = retMsg.Msg
 	if retMsg.Status == proto.OpOk {
 		resp := &proto.InodeGetResponse{
-			Info: &proto.InodeInfo{},
+			Info:      &proto.InodeInfo{},
+			AppliedID: appliedID,
 		}
 		if getAllVerInfo {
 			replyInfoNoCheck(resp.Info, retMsg.Msg)
@@ -753,6 +769,9 @@ func (mp *metaPartition) EvictInodeBatch(req *BatchEvictInodeReq, p *Packet, rem
 
 // SetAttr set the inode attributes.
 func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error) {
+	if !mp.checkInodeEpoch(req.Inode, &req.InodeEpochCheck, p) {
+		return
+	}
 	if mp.verSeq != 0 {
 		req.VerSeq = mp.GetVerSeq()
 		reqData, err = json.Marshal(req)
@@ -761,7 +780,7 @@ func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet)
 			return
 		}
 	}
-	_, err = mp.submit(opFSMSetAttr, reqData)
+	_, err = mp.submitAttr(opFSMSetAttr, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
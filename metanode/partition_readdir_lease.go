@@ -0,0 +1,101 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// readDirLease is the lease of a readdir stream session. It is issued and held by the partition,
+// the read version of the session is kept in the lease, so the cursor only refers to the lease.
+type readDirLease struct {
+	parentID uint64
+	verSeq   uint64
+	expire   time.Time
+}
+
+// readDirLeaseTable keeps the leases of the readdir stream sessions of the partition in memory.
+// The leases are not replicated, a session continued on the other replica or after the restart
+// is rejected as the lease is not found.
+type readDirLeaseTable struct {
+	sync.Mutex
+	capacity int
+	leases   map[string]*readDirLease
+}
+
+func newReadDirLeaseTable(capacity int) *readDirLeaseTable {
+	return &readDirLeaseTable{
+		capacity: capacity,
+		leases:   make(map[string]*readDirLease),
+	}
+}
+
+// issue issues the lease of a new session, the expired leases are evicted if the table is full.
+func (t *readDirLeaseTable) issue(parentID, verSeq uint64, now time.Time) (id string, err error) {
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return
+	}
+	id = hex.EncodeToString(buf)
+
+	t.Lock()
+	defer t.Unlock()
+	if len(t.leases) >= t.capacity {
+		for leaseID, lease := range t.leases {
+			if now.After(lease.expire) {
+				delete(t.leases, leaseID)
+			}
+		}
+		if len(t.leases) >= t.capacity {
+			return "", fmt.Errorf("too many readdir sessions: %v", len(t.leases))
+		}
+	}
+	t.leases[id] = &readDirLease{
+		parentID: parentID,
+		verSeq:   verSeq,
+		expire:   now.Add(readDirStreamLease),
+	}
+	return
+}
+
+// renew validates the lease of the session and extends it, the expired lease is dropped.
+func (t *readDirLeaseTable) renew(id string, parentID uint64, now time.Time) (verSeq uint64, err error) {
+	t.Lock()
+	defer t.Unlock()
+	lease, ok := t.leases[id]
+	if !ok {
+		return 0, fmt.Errorf("lease[%v] not found", id)
+	}
+	if now.After(lease.expire) {
+		delete(t.leases, id)
+		return 0, fmt.Errorf("lease[%v] expired at %v", id, lease.expire)
+	}
+	if lease.parentID != parentID {
+		return 0, fmt.Errorf("lease[%v] of parent[%v] mismatch with parent[%v]", id, lease.parentID, parentID)
+	}
+	lease.expire = now.Add(readDirStreamLease)
+	return lease.verSeq, nil
+}
+
+// release drops the lease of the session read to the end.
+func (t *readDirLeaseTable) release(id string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.leases, id)
+}
@@ -51,6 +51,7 @@ const (
 	metadataFileTmp         = ".meta"
 	uniqIDFile              = "uniqID"
 	uniqCheckerFile         = "uniqChecker"
+	inodeRecyclerFile       = "inodeRecycler"
 	verdataFile             = "multiVer"
 	StaleMetadataSuffix     = ".old"
 	StaleMetadataTimeFormat = "20060102150405.000000000"
@@ -1304,6 +1305,51 @@ func (mp *metaPartition) storeUniqID(rootDir string, sm *storeMsg) (err error) {
 	return
 }
 
+func (mp *metaPartition) loadInodeRecycler(rootDir string, crc uint32) (err error) {
+	filename := path.Join(rootDir, inodeRecyclerFile)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		err = errors.NewErrorf("[loadInodeRecycler] ReadFile: %v", err.Error())
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadInodeRecycler]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return ErrSnapshotCrcMismatch
+	}
+	recycler := newInodeRecycler()
+	if err = recycler.UnMarshal(data); err != nil {
+		err = errors.NewErrorf("[loadInodeRecycler] Unmarshal: %v", err.Error())
+		return
+	}
+	mp.inodeRecycler = recycler
+	log.LogInfof("loadInodeRecycler: load complete: partitionID(%v) volume(%v) count(%v)",
+		mp.config.PartitionId, mp.config.VolName, recycler.Len())
+	return
+}
+
+func (mp *metaPartition) storeInodeRecycler(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	filename := path.Join(rootDir, inodeRecyclerFile)
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = fp.Sync()
+		fp.Close()
+	}()
+
+	var data []byte
+	if data, crc, err = sm.inodeRecycler.Marshal(); err != nil {
+		return
+	}
+	if _, err = fp.Write(data); err != nil {
+		return
+	}
+	log.LogInfof("storeInodeRecycler: store complete: partitionID(%v) volume(%v) count(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, sm.inodeRecycler.Len(), crc)
+	return
+}
+
 func (mp *metaPartition) storeUniqChecker(rootDir string, sm *storeMsg) (crc uint32, err error) {
 	filename := path.Join(rootDir, uniqCheckerFile)
 	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
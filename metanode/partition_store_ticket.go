@@ -41,6 +41,7 @@ type storeMsg struct {
 	uidRebuild     bool
 	uniqId         uint64
 	uniqChecker    *uniqChecker
+	inodeRecycler  *inodeRecycler
 	multiVerList   []*proto.VolVersionInfo
 }
 
@@ -15,6 +15,8 @@
 package metanode
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path"
 	"testing"
@@ -22,7 +24,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	raftproto "github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/compressor"
 )
 
 func TestMetaPartition_LoadSnapshot(t *testing.T) {
@@ -136,3 +140,311 @@ func TestMetaPartition_LoadSnapshot(t *testing.T) {
 	err = partition.LoadSnapshot(snapshotPath)
 	require.Equal(t, ErrSnapshotCrcMismatch, err)
 }
+
+func TestMetaPartition_ReadDirStream(t *testing.T) {
+	mp := newPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "test_vol"}, nil)
+	mp.multiVersionList = &proto.VolVersionInfoList{}
+	parentID := uint64(10)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID, Name: name, Inode: 100, Type: 1}, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID + 1, Name: "x", Inode: 101, Type: 1}, true)
+
+	readStream := func(cursor string) (resp *ReadDirStreamResp, status uint8) {
+		p := &Packet{}
+		req := &ReadDirStreamReq{ParentID: parentID, Cursor: cursor, Limit: 2}
+		mp.ReadDirStream(req, p)
+		if p.ResultCode != proto.OpOk {
+			return nil, p.ResultCode
+		}
+		resp = &ReadDirStreamResp{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp, p.ResultCode
+	}
+	names := func(dentries []proto.Dentry) (ret []string) {
+		for _, d := range dentries {
+			ret = append(ret, d.Name)
+		}
+		return
+	}
+
+	resp, _ := readStream("")
+	require.Equal(t, []string{"a", "b"}, names(resp.Children))
+	require.True(t, resp.HasMore)
+
+	// the last returned dentry is deleted and a dentry before the cursor is inserted
+	mp.dentryTree.Delete(&Dentry{ParentId: parentID, Name: "b"})
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID, Name: "aa", Inode: 102, Type: 1}, true)
+	resp, _ = readStream(resp.Cursor)
+	require.Equal(t, []string{"c", "d"}, names(resp.Children))
+	require.True(t, resp.HasMore)
+
+	last := resp.Cursor
+	resp, _ = readStream(resp.Cursor)
+	require.Equal(t, []string{"e"}, names(resp.Children))
+	require.False(t, resp.HasMore)
+	// the lease is released once the session is read to the end
+	require.Empty(t, mp.readDirLeases.leases)
+	_, status := readStream(last)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+
+	// invalid and forged cursors are rejected
+	_, status = readStream("invalid")
+	require.Equal(t, proto.OpArgMismatchErr, status)
+	forged := &proto.ReadDirCursor{ParentID: parentID, Marker: "a", Lease: "forged"}
+	cursor, err := forged.Encode()
+	require.NoError(t, err)
+	_, status = readStream(cursor)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+
+	// the expired lease is rejected and dropped
+	resp, _ = readStream("")
+	require.True(t, resp.HasMore)
+	require.Len(t, mp.readDirLeases.leases, 1)
+	for _, lease := range mp.readDirLeases.leases {
+		lease.expire = time.Now().Add(-time.Second)
+	}
+	_, status = readStream(resp.Cursor)
+	require.Equal(t, proto.OpArgMismatchErr, status)
+	require.Empty(t, mp.readDirLeases.leases)
+
+	// the lease of the other parent is rejected
+	resp, _ = readStream("")
+	c, err := proto.DecodeReadDirCursor(resp.Cursor)
+	require.NoError(t, err)
+	c.ParentID = parentID + 1
+	cursor, err = c.Encode()
+	require.NoError(t, err)
+	p := &Packet{}
+	mp.ReadDirStream(&ReadDirStreamReq{ParentID: parentID + 1, Cursor: cursor, Limit: 2}, p)
+	require.Equal(t, proto.OpArgMismatchErr, p.ResultCode)
+
+	// a new session starts after the marker
+	p = &Packet{}
+	mp.ReadDirStream(&ReadDirStreamReq{ParentID: parentID, Marker: "c", Limit: 2}, p)
+	require.Equal(t, proto.OpOk, p.ResultCode)
+	resp = &ReadDirStreamResp{}
+	require.NoError(t, json.Unmarshal(p.Data, resp))
+	require.Equal(t, []string{"d", "e"}, names(resp.Children))
+	require.False(t, resp.HasMore)
+
+	// the expired leases are evicted when the table is full
+	mp.readDirLeases = newReadDirLeaseTable(1)
+	resp, _ = readStream("")
+	_, status = readStream("")
+	require.Equal(t, proto.OpAgain, status)
+	for _, lease := range mp.readDirLeases.leases {
+		lease.expire = time.Now().Add(-time.Second)
+	}
+	_, status = readStream("")
+	require.Equal(t, proto.OpOk, status)
+}
+
+func TestMetaPartition_ReadDirFiltered(t *testing.T) {
+	mp := newPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "test_vol", Start: 1, End: 1000}, nil)
+	mp.multiVersionList = &proto.VolVersionInfoList{}
+	parentID := uint64(10)
+	addEntry := func(name string, ino uint64, mode uint32, size uint64, mtime int64) {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID, Name: name, Inode: ino, Type: mode}, true)
+		inode := NewInode(ino, mode)
+		inode.Size, inode.ModifyTime = size, mtime
+		mp.inodeTree.ReplaceOrInsert(inode, true)
+	}
+	addEntry("a", 100, 0o644, 10, 300)
+	addEntry("b", 101, 0o644, 2000, 100)
+	addEntry("c", 102, 0o644, 3000, 200)
+	addEntry("d", 103, uint32(os.ModeDir), 0, 100)
+	addEntry("e", 104, 0o644, 4000, 500)
+	// the inode in other partition
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID, Name: "f", Inode: 200000, Type: 0o644}, true)
+
+	readDir := func(req *ReadDirFilteredReq) *ReadDirFilteredResp {
+		p := &Packet{}
+		req.ParentID = parentID
+		require.NoError(t, mp.ReadDirFiltered(req, p))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+		resp := &ReadDirFilteredResp{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp
+	}
+	names := func(entries []proto.DirEntryInfo) (ret []string) {
+		for _, e := range entries {
+			ret = append(ret, e.Name)
+		}
+		return
+	}
+
+	resp := readDir(&ReadDirFilteredReq{Filter: proto.DirEntryFilter{MtimeBefore: 400, SizeAbove: 1000}, SortBy: proto.DirEntrySortByMtime})
+	require.Equal(t, []string{"b", "c"}, names(resp.Entries))
+	require.Equal(t, int64(100), resp.Entries[0].Mtime)
+	require.Equal(t, []proto.Dentry{{Name: "f", Inode: 200000, Type: 0o644}}, resp.Unresolved)
+	require.Equal(t, uint64(6), resp.Scanned)
+	require.Empty(t, resp.Next)
+
+	resp = readDir(&ReadDirFilteredReq{Filter: proto.DirEntryFilter{SizeAbove: 1000, WithDirs: true}, SortBy: proto.DirEntrySortBySize})
+	require.Equal(t, []string{"e", "c", "b", "d"}, names(resp.Entries))
+	resp = readDir(&ReadDirFilteredReq{Filter: proto.DirEntryFilter{FilesOnly: true}})
+	require.Equal(t, []string{"a", "b", "c", "e"}, names(resp.Entries))
+
+	// scan by pages
+	resp = readDir(&ReadDirFilteredReq{Limit: 2, Filter: proto.DirEntryFilter{SizeAbove: 1000}})
+	require.Equal(t, []string{"b"}, names(resp.Entries))
+	require.Equal(t, "b", resp.Next)
+	resp = readDir(&ReadDirFilteredReq{Marker: resp.Next, Limit: 2, Filter: proto.DirEntryFilter{SizeAbove: 1000}})
+	require.Equal(t, []string{"c"}, names(resp.Entries))
+	require.Equal(t, "d", resp.Next)
+	resp = readDir(&ReadDirFilteredReq{Marker: resp.Next, Limit: 2, Filter: proto.DirEntryFilter{SizeAbove: 1000}})
+	require.Equal(t, []string{"e"}, names(resp.Entries))
+	require.Len(t, resp.Unresolved, 1)
+	require.Empty(t, resp.Next)
+}
+
+func TestMetaPartition_ReadDirLimitEncoding(t *testing.T) {
+	mp := newPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "test_vol"}, nil)
+	mp.multiVersionList = &proto.VolVersionInfoList{}
+	parentID := uint64(10)
+	for i := 0; i < 1000; i++ {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: parentID, Name: fmt.Sprintf("job-20230101-part-%06d", i),
+			Inode: uint64(1000 + i), Type: 1}, true)
+	}
+
+	readDir := func(enc *proto.DentryBatchEncoding) (*ReadDirLimitResp, int) {
+		p := &Packet{}
+		require.NoError(t, mp.ReadDirLimit(&ReadDirLimitReq{ParentID: parentID, Encoding: enc}, p))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+		resp := &ReadDirLimitResp{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp, len(p.Data)
+	}
+
+	plain, plainSize := readDir(nil)
+	require.Nil(t, plain.Encoding)
+	require.Equal(t, 1000, len(plain.Children))
+
+	resp, size := readDir(&proto.DentryBatchEncoding{Delta: true, Compression: compressor.EncodingGzip})
+	require.Empty(t, resp.Children)
+	require.Equal(t, compressor.EncodingGzip, resp.Encoding.Compression)
+	children, err := resp.GetChildren()
+	require.NoError(t, err)
+	require.Equal(t, plain.Children, children)
+	require.Less(t, size*10, plainSize)
+}
+
+func TestDirChangeLog(t *testing.T) {
+	l := newDirChangeLog(4)
+	l.reset(10)
+	for seq := uint64(11); seq <= 16; seq++ {
+		l.append(proto.DirChangeEvent{Seq: seq, ParentID: seq % 2, Name: fmt.Sprintf("f%d", seq)})
+	}
+	// 11 and 12 are dropped
+	events, last, truncated := l.read(0, 12, 10)
+	require.False(t, truncated)
+	require.Equal(t, uint64(16), last)
+	require.Equal(t, []string{"f14", "f16"}, []string{events[0].Name, events[1].Name})
+
+	events, last, truncated = l.read(1, 10, 1)
+	require.True(t, truncated)
+	require.Equal(t, uint64(13), last)
+	require.Equal(t, 1, len(events))
+}
+
+func TestMetaPartition_ReadDirChanges(t *testing.T) {
+	mp := newPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "test_vol"}, nil)
+	mp.multiVersionList = &proto.VolVersionInfoList{}
+	parentID := uint64(10)
+	mp.inodeTree.ReplaceOrInsert(NewInode(parentID, proto.Mode(os.ModeDir)), true)
+
+	apply := func(index uint64, fn func()) {
+		mp.applyingIndex = index
+		fn()
+		mp.applyingIndex = 0
+		mp.applyID = index
+	}
+	apply(1, func() {
+		require.Equal(t, proto.OpOk, mp.fsmCreateDentry(&Dentry{ParentId: parentID, Name: "a", Inode: 100, Type: 420}, false))
+	})
+	apply(2, func() {
+		require.Equal(t, proto.OpOk, mp.fsmCreateDentry(&Dentry{ParentId: parentID, Name: "b", Inode: 101, Type: 420}, false))
+	})
+	apply(3, func() {
+		require.Equal(t, proto.OpOk, mp.fsmDeleteDentry(&Dentry{ParentId: parentID, Name: "a"}, false).Status)
+	})
+	apply(4, func() {})
+	// dentries out of the raft log are not recorded
+	require.Equal(t, proto.OpOk, mp.fsmCreateDentry(&Dentry{ParentId: parentID, Name: "c", Inode: 102, Type: 420}, false))
+
+	readChanges := func(from, limit uint64) *ReadDirChangesResp {
+		p := &Packet{}
+		require.NoError(t, mp.ReadDirChanges(&ReadDirChangesReq{ParentID: parentID, From: from, Limit: limit}, p))
+		require.Equal(t, proto.OpOk, p.ResultCode)
+		resp := &ReadDirChangesResp{}
+		require.NoError(t, json.Unmarshal(p.Data, resp))
+		return resp
+	}
+	resp := readChanges(0, 2)
+	require.Equal(t, 2, len(resp.Events))
+	require.Equal(t, proto.DirChangeCreate, resp.Events[0].Op)
+	require.Equal(t, "a", resp.Events[0].Name)
+	require.Equal(t, uint64(100), resp.Events[0].Inode)
+	require.Equal(t, uint64(2), resp.Next)
+
+	resp = readChanges(resp.Next, 0)
+	require.Equal(t, 1, len(resp.Events))
+	require.Equal(t, proto.DirChangeDelete, resp.Events[0].Op)
+	require.Equal(t, uint64(100), resp.Events[0].Inode)
+	require.Equal(t, uint64(4), resp.Next)
+	require.False(t, resp.Truncated)
+
+	mp.dirChanges.reset(mp.applyID)
+	resp = readChanges(2, 0)
+	require.Empty(t, resp.Events)
+	require.True(t, resp.Truncated)
+	require.Equal(t, uint64(4), resp.Next)
+}
+
+func TestMetaPartitionWalDurability(t *testing.T) {
+	mp := &metaPartition{config: &MetaPartitionConfig{PartitionId: 1, VolName: "vol"}}
+	sync, delay := mp.walDurability(&proto.SimpleVolView{})
+	require.False(t, sync)
+	require.Equal(t, time.Duration(0), delay)
+
+	mp.manager = &metadataManager{metaNode: &MetaNode{
+		metaDurability:   proto.MetaDurabilityGroup,
+		groupCommitDelay: 5 * time.Millisecond,
+	}}
+	sync, delay = mp.walDurability(&proto.SimpleVolView{})
+	require.True(t, sync)
+	require.Equal(t, 5*time.Millisecond, delay)
+
+	// the durability of the volume overrides the one of the metanode
+	sync, delay = mp.walDurability(&proto.SimpleVolView{MetaDurability: proto.MetaDurabilitySync})
+	require.True(t, sync)
+	require.Equal(t, time.Duration(0), delay)
+	mp.updateWalDurability(&proto.SimpleVolView{MetaDurability: proto.MetaDurabilityGroup, MetaGroupCommitDelayMs: 10})
+	require.True(t, mp.walSync)
+	require.Equal(t, 10*time.Millisecond, mp.walSyncDelay)
+	mp.updateWalDurability(&proto.SimpleVolView{MetaDurability: proto.MetaDurabilityAsync})
+	require.False(t, mp.walSync)
+}
+
+func TestMetaPartitionPromoteLearner(t *testing.T) {
+	mp := newPartition(&MetaPartitionConfig{
+		PartitionId: 1,
+		Peers:       []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}, {ID: 2, Addr: "127.0.0.2:17210"}},
+		Learners:    []proto.Peer{{ID: 2, Addr: "127.0.0.2:17210"}},
+	}, manager)
+	require.True(t, mp.IsLearner(2))
+	require.Equal(t, raftproto.PeerLearner, mp.raftPeerType(2))
+	require.Equal(t, raftproto.PeerNormal, mp.raftPeerType(1))
+
+	updated, err := mp.confPromoteLearner(&proto.AddMetaPartitionRaftMemberRequest{PartitionId: 1, AddPeer: mp.config.Peers[1]}, 1)
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.False(t, mp.IsLearner(2))
+	require.Len(t, mp.config.Peers, 2)
+
+	// promoting the voter changes nothing
+	updated, err = mp.confPromoteLearner(&proto.AddMetaPartitionRaftMemberRequest{PartitionId: 1, AddPeer: mp.config.Peers[1]}, 2)
+	require.NoError(t, err)
+	require.False(t, updated)
+}
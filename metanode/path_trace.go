@@ -0,0 +1,321 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	maxPathTraceRules      = 64
+	maxPathTraces          = 1024
+	maxPathTraceRequestLen = 4096
+)
+
+// PathTraceRule samples the operations on the paths under the prefix of the volume at the rate.
+type PathTraceRule struct {
+	Vol    string  `json:"vol"`
+	Prefix string  `json:"prefix"`
+	Rate   float64 `json:"rate"`
+}
+
+// PathTrace is the detail of a sampled operation.
+type PathTrace struct {
+	Time        string `json:"time"`
+	Vol         string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Op          string `json:"op"`
+	ReqID       int64  `json:"reqId"`
+	Path        string `json:"path"`
+	Remote      string `json:"remote"`
+	Request     string `json:"request"`
+	Result      string `json:"result"`
+	Err         string `json:"err,omitempty"`
+	CostUs      int64  `json:"costUs"`
+}
+
+// pathTracer traces the operations on the paths reported by the clients, so that the metadata
+// behavior of one application is debugged without tracing the whole volume. The operations not
+// carrying the paths, such as lookup and readdir, are not traced. The rules are not persisted.
+type pathTracer struct {
+	active int32 // 1 if any rule is set
+
+	mu     sync.RWMutex
+	rules  []*PathTraceRule // sorted by the length of the prefixes descending
+	traces []*PathTrace     // the ring of the recent traces
+	next   int
+	rand   func() float64
+}
+
+func newPathTracer() *pathTracer {
+	return &pathTracer{rand: rand.Float64}
+}
+
+func isPathUnder(p, prefix string) bool {
+	return prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+func (t *pathTracer) setRule(rule PathTraceRule) error {
+	if rule.Vol == "" {
+		return fmt.Errorf("vol is required")
+	}
+	if !strings.HasPrefix(rule.Prefix, "/") {
+		return fmt.Errorf("prefix(%v) is not an absolute path", rule.Prefix)
+	}
+	if rule.Rate <= 0 || rule.Rate > 1 {
+		return fmt.Errorf("rate(%v) is not in (0, 1]", rule.Rate)
+	}
+	rule.Prefix = path.Clean(rule.Prefix)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, r := range t.rules {
+		if r.Vol == rule.Vol && r.Prefix == rule.Prefix {
+			t.rules[i] = &rule
+			return nil
+		}
+	}
+	if len(t.rules) >= maxPathTraceRules {
+		return fmt.Errorf("rules exceed the limit %v", maxPathTraceRules)
+	}
+	t.rules = append(t.rules, &rule)
+	sort.SliceStable(t.rules, func(i, j int) bool { return len(t.rules[i].Prefix) > len(t.rules[j].Prefix) })
+	atomic.StoreInt32(&t.active, 1)
+	return nil
+}
+
+func (t *pathTracer) delRule(vol, prefix string) bool {
+	prefix = path.Clean(prefix)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, r := range t.rules {
+		if r.Vol == vol && r.Prefix == prefix {
+			t.rules = append(t.rules[:i], t.rules[i+1:]...)
+			if len(t.rules) == 0 {
+				atomic.StoreInt32(&t.active, 0)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (t *pathTracer) getRules() []PathTraceRule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rules := make([]PathTraceRule, 0, len(t.rules))
+	for _, r := range t.rules {
+		rules = append(rules, *r)
+	}
+	return rules
+}
+
+func (t *pathTracer) hasVolRule(vol string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, r := range t.rules {
+		if r.Vol == vol {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first of the paths under a rule of the volume, and the rule of the longest prefix.
+func (t *pathTracer) match(vol string, paths []string) (string, *PathTraceRule) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, r := range t.rules {
+		if r.Vol != vol {
+			continue
+		}
+		for _, p := range paths {
+			if isPathUnder(p, r.Prefix) {
+				return p, r
+			}
+		}
+	}
+	return "", nil
+}
+
+// sample returns the path of the request if the request is sampled.
+func (t *pathTracer) sample(vol string, data []byte) (string, bool) {
+	if atomic.LoadInt32(&t.active) == 0 || !t.hasVolRule(vol) {
+		return "", false
+	}
+	req := &struct {
+		FullPaths []string `json:"fullPaths"`
+	}{}
+	if err := json.Unmarshal(data, req); err != nil || len(req.FullPaths) == 0 {
+		return "", false
+	}
+	p, rule := t.match(vol, req.FullPaths)
+	if rule == nil || t.rand() >= rule.Rate {
+		return "", false
+	}
+	return p, true
+}
+
+func (t *pathTracer) record(trace *PathTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.traces) < maxPathTraces {
+		t.traces = append(t.traces, trace)
+		return
+	}
+	t.traces[t.next] = trace
+	t.next = (t.next + 1) % maxPathTraces
+}
+
+// recent returns the recent traces of the volume, the newest first, all volumes if vol is empty.
+func (t *pathTracer) recent(vol string, limit int) []*PathTrace {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	traces := make([]*PathTrace, 0)
+	for i := 0; i < len(t.traces) && (limit <= 0 || len(traces) < limit); i++ {
+		trace := t.traces[(t.next+len(t.traces)-1-i)%len(t.traces)]
+		if vol == "" || trace.Vol == vol {
+			traces = append(traces, trace)
+		}
+	}
+	return traces
+}
+
+// samplePathTrace returns the trace of the operation if it is sampled by the path trace rules.
+func (m *metadataManager) samplePathTrace(p *Packet, remoteAddr string) *PathTrace {
+	if m.metaNode == nil || m.metaNode.pathTracer == nil || atomic.LoadInt32(&m.metaNode.pathTracer.active) == 0 {
+		return nil
+	}
+	mp, err := m.getPartition(p.PartitionID)
+	if err != nil {
+		return nil
+	}
+	vol := mp.GetVolName()
+	tracePath, ok := m.metaNode.pathTracer.sample(vol, p.Data)
+	if !ok {
+		return nil
+	}
+	// the data of the packet is replaced by the response
+	req := p.Data
+	if len(req) > maxPathTraceRequestLen {
+		req = req[:maxPathTraceRequestLen]
+	}
+	return &PathTrace{
+		Time:        time.Now().Format(time.RFC3339Nano),
+		Vol:         vol,
+		PartitionID: p.PartitionID,
+		Op:          p.GetOpMsg(),
+		ReqID:       p.ReqID,
+		Path:        tracePath,
+		Remote:      remoteAddr,
+		Request:     string(req),
+	}
+}
+
+func (m *metadataManager) finishPathTrace(trace *PathTrace, p *Packet, start time.Time, err error) {
+	trace.Result = p.GetResultMsg()
+	trace.CostUs = time.Since(start).Microseconds()
+	if err != nil {
+		trace.Err = err.Error()
+	}
+	m.metaNode.pathTracer.record(trace)
+	log.LogWarnf("[pathTrace] vol(%v) pid(%v) op(%v) reqId(%v) path(%v) remote(%v) req(%v) result(%v) err(%v) cost(%vus)",
+		trace.Vol, trace.PartitionID, trace.Op, trace.ReqID, trace.Path, trace.Remote, trace.Request, trace.Result,
+		trace.Err, trace.CostUs)
+}
+
+func (m *MetaNode) setPathTraceRuleHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[setPathTraceRuleHandler] response %s", err)
+		}
+	}()
+	rate, err := strconv.ParseFloat(r.FormValue("rate"), 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	rule := PathTraceRule{Vol: r.FormValue("vol"), Prefix: r.FormValue("prefix"), Rate: rate}
+	if err = m.pathTracer.setRule(rule); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	log.LogInfof("[setPathTraceRuleHandler] rule(%+v) set", rule)
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+}
+
+func (m *MetaNode) delPathTraceRuleHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[delPathTraceRuleHandler] response %s", err)
+		}
+	}()
+	vol, prefix := r.FormValue("vol"), r.FormValue("prefix")
+	if !m.pathTracer.delRule(vol, prefix) {
+		resp.Code = http.StatusNotFound
+		resp.Msg = fmt.Sprintf("rule of vol(%v) prefix(%v) not found", vol, prefix)
+		return
+	}
+	log.LogInfof("[delPathTraceRuleHandler] rule of vol(%v) prefix(%v) deleted", vol, prefix)
+}
+
+func (m *MetaNode) getPathTraceRulesHandler(w http.ResponseWriter, r *http.Request) {
+	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
+	resp.Data = m.pathTracer.getRules()
+	data, _ := resp.Marshal()
+	if _, err := w.Write(data); err != nil {
+		log.LogErrorf("[getPathTraceRulesHandler] response %s", err)
+	}
+}
+
+func (m *MetaNode) getPathTracesHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getPathTracesHandler] response %s", err)
+		}
+	}()
+	limit := 0
+	if value := r.FormValue("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			resp.Code = http.StatusBadRequest
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	resp.Data = m.pathTracer.recent(r.FormValue("vol"), limit)
+}
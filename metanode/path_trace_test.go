@@ -0,0 +1,97 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathTracerRules(t *testing.T) {
+	tracer := newPathTracer()
+	require.Error(t, tracer.setRule(PathTraceRule{Prefix: "/a", Rate: 1}))
+	require.Error(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "a", Rate: 1}))
+	require.Error(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/a", Rate: 0}))
+	require.Error(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/a", Rate: 1.5}))
+
+	require.NoError(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/a/", Rate: 0.5}))
+	require.NoError(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/a/b", Rate: 1}))
+	require.NoError(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/a", Rate: 0.2}))
+	require.Equal(t, []PathTraceRule{{Vol: "vol", Prefix: "/a/b", Rate: 1}, {Vol: "vol", Prefix: "/a", Rate: 0.2}},
+		tracer.getRules())
+
+	// the longest prefix is matched on the boundaries of the path components
+	p, rule := tracer.match("vol", []string{"/a/b/c"})
+	require.Equal(t, "/a/b/c", p)
+	require.Equal(t, "/a/b", rule.Prefix)
+	_, rule = tracer.match("vol", []string{"/a/bc"})
+	require.Equal(t, "/a", rule.Prefix)
+	_, rule = tracer.match("vol", []string{"/ab"})
+	require.Nil(t, rule)
+	_, rule = tracer.match("other", []string{"/a/b"})
+	require.Nil(t, rule)
+
+	require.True(t, tracer.delRule("vol", "/a/b/"))
+	require.False(t, tracer.delRule("vol", "/a/b"))
+	require.True(t, tracer.delRule("vol", "/a"))
+	require.Equal(t, int32(0), tracer.active)
+}
+
+func TestPathTracerSample(t *testing.T) {
+	tracer := newPathTracer()
+	data, _ := json.Marshal(&proto.UpdateDentryRequest{RequestExtend: proto.RequestExtend{FullPaths: []string{"/app/x"}}})
+	_, ok := tracer.sample("vol", data)
+	require.False(t, ok)
+
+	require.NoError(t, tracer.setRule(PathTraceRule{Vol: "vol", Prefix: "/app", Rate: 0.5}))
+	tracer.rand = func() float64 { return 0.4 }
+	p, ok := tracer.sample("vol", data)
+	require.True(t, ok)
+	require.Equal(t, "/app/x", p)
+	tracer.rand = func() float64 { return 0.5 }
+	_, ok = tracer.sample("vol", data)
+	require.False(t, ok)
+
+	tracer.rand = func() float64 { return 0 }
+	_, ok = tracer.sample("other", data)
+	require.False(t, ok)
+	data, _ = json.Marshal(&proto.InodeGetRequest{Inode: 1})
+	_, ok = tracer.sample("vol", data)
+	require.False(t, ok)
+}
+
+func TestPathTracerRecent(t *testing.T) {
+	tracer := newPathTracer()
+	for i := 0; i < maxPathTraces+10; i++ {
+		vol := "a"
+		if i%2 == 1 {
+			vol = "b"
+		}
+		tracer.record(&PathTrace{Vol: vol, Path: fmt.Sprintf("/%d", i)})
+	}
+	traces := tracer.recent("", 0)
+	require.Len(t, traces, maxPathTraces)
+	require.Equal(t, fmt.Sprintf("/%d", maxPathTraces+9), traces[0].Path)
+	require.Equal(t, "/10", traces[maxPathTraces-1].Path)
+
+	traces = tracer.recent("a", 2)
+	require.Len(t, traces, 2)
+	require.Equal(t, fmt.Sprintf("/%d", maxPathTraces+8), traces[0].Path)
+	require.Equal(t, fmt.Sprintf("/%d", maxPathTraces+6), traces[1].Path)
+}
@@ -0,0 +1,400 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultVolOpQueueWait  = 200 * time.Millisecond
+	maxVolOpClientQueueLen = 1024
+)
+
+const (
+	volOpWaiting int32 = iota
+	volOpAdmitted
+	volOpCanceled
+)
+
+// the operations issued by the master and the other metanodes are never limited
+var unlimitedVolOps = map[uint8]struct{}{
+	proto.OpCreateMetaPartition:           {},
+	proto.OpMetaNodeHeartbeat:             {},
+	proto.OpDeleteMetaPartition:           {},
+	proto.OpUpdateMetaPartition:           {},
+	proto.OpLoadMetaPartition:             {},
+	proto.OpDecommissionMetaPartition:     {},
+	proto.OpAddMetaPartitionRaftMember:    {},
+	proto.OpRemoveMetaPartitionRaftMember: {},
+	proto.OpMetaPartitionTryToLeader:      {},
+	proto.OpMetaFreeInodesOnRaftFollower:  {},
+	proto.OpTxCommitRM:                    {},
+	proto.OpTxRollbackRM:                  {},
+	proto.OpVersionOperation:              {},
+}
+
+// VolOpLimitStat is the limit and the queues of a volume.
+type VolOpLimitStat struct {
+	proto.VolMetaOpLimit
+	Admitted uint64         `json:"admitted"`
+	Rejected uint64         `json:"rejected"`
+	Queued   map[string]int `json:"queued"` // the waiting operations of each client
+}
+
+type volOpWaiter struct {
+	state int32
+	ready chan struct{}
+}
+
+// volOpQueue admits the operations of a volume at the limit. Once the tokens run out, the operations
+// wait in the queues of their clients and the queues are served in turn, so a client sending lots of
+// operations only delays its own ones. The operations waiting longer than maxWait are rejected.
+type volOpQueue struct {
+	limit   proto.VolMetaOpLimit
+	limiter *rate.Limiter
+	maxWait time.Duration
+
+	mu      sync.Mutex
+	clients map[string][]*volOpWaiter
+	order   []string // the clients with waiting operations in turn
+	waiting int
+	kick    chan struct{}
+	stopC   chan struct{}
+
+	admitted uint64
+	rejected uint64
+}
+
+func newVolOpQueue(limit proto.VolMetaOpLimit, maxWait time.Duration) *volOpQueue {
+	q := &volOpQueue{
+		limit:   limit,
+		limiter: rate.NewLimiter(rate.Limit(limit.Ops), limit.Burst),
+		maxWait: maxWait,
+		clients: make(map[string][]*volOpWaiter),
+		kick:    make(chan struct{}, 1),
+		stopC:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *volOpQueue) setLimit(limit proto.VolMetaOpLimit) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limit == limit {
+		return
+	}
+	q.limit = limit
+	q.limiter.SetLimit(rate.Limit(limit.Ops))
+	q.limiter.SetBurst(limit.Burst)
+}
+
+// acquire returns false if the operation of the client is rejected.
+func (q *volOpQueue) acquire(client string) bool {
+	q.mu.Lock()
+	if q.waiting == 0 && q.limiter.Allow() {
+		q.mu.Unlock()
+		atomic.AddUint64(&q.admitted, 1)
+		return true
+	}
+	waiters := q.clients[client]
+	if len(waiters) >= maxVolOpClientQueueLen {
+		q.mu.Unlock()
+		atomic.AddUint64(&q.rejected, 1)
+		return false
+	}
+	w := &volOpWaiter{ready: make(chan struct{})}
+	if len(waiters) == 0 {
+		q.order = append(q.order, client)
+	}
+	q.clients[client] = append(waiters, w)
+	q.waiting++
+	q.mu.Unlock()
+
+	select {
+	case q.kick <- struct{}{}:
+	default:
+	}
+
+	timer := time.NewTimer(q.maxWait)
+	defer timer.Stop()
+	select {
+	case <-w.ready:
+	case <-timer.C:
+	case <-q.stopC:
+		// the limit is removed
+		return true
+	}
+	if atomic.CompareAndSwapInt32(&w.state, volOpWaiting, volOpCanceled) {
+		atomic.AddUint64(&q.rejected, 1)
+		return false
+	}
+	atomic.AddUint64(&q.admitted, 1)
+	return true
+}
+
+// pop returns the first waiting operation of the next client in turn.
+func (q *volOpQueue) pop() *volOpWaiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return nil
+	}
+	client := q.order[0]
+	q.order = q.order[1:]
+	waiters := q.clients[client]
+	w := waiters[0]
+	if len(waiters) > 1 {
+		q.clients[client] = waiters[1:]
+		q.order = append(q.order, client)
+	} else {
+		delete(q.clients, client)
+	}
+	q.waiting--
+	return w
+}
+
+// waitToken returns false if the queue is stopped.
+func (q *volOpQueue) waitToken() bool {
+	r := q.limiter.Reserve()
+	delay := r.Delay()
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-q.stopC:
+		r.Cancel()
+		return false
+	}
+}
+
+func (q *volOpQueue) run() {
+	// the token taken for a canceled operation is given to the next one
+	reserved := false
+	for {
+		w := q.pop()
+		if w == nil {
+			select {
+			case <-q.kick:
+				continue
+			case <-q.stopC:
+				return
+			}
+		}
+		if atomic.LoadInt32(&w.state) != volOpWaiting {
+			continue
+		}
+		if !reserved {
+			if !q.waitToken() {
+				return
+			}
+			reserved = true
+		}
+		if atomic.CompareAndSwapInt32(&w.state, volOpWaiting, volOpAdmitted) {
+			close(w.ready)
+			reserved = false
+		}
+	}
+}
+
+func (q *volOpQueue) stop() {
+	close(q.stopC)
+}
+
+func (q *volOpQueue) stat() *VolOpLimitStat {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stat := &VolOpLimitStat{
+		VolMetaOpLimit: q.limit,
+		Admitted:       atomic.LoadUint64(&q.admitted),
+		Rejected:       atomic.LoadUint64(&q.rejected),
+		Queued:         make(map[string]int, len(q.clients)),
+	}
+	for client, waiters := range q.clients {
+		stat.Queued[client] = len(waiters)
+	}
+	return stat
+}
+
+// volOpLimiter limits the metadata operations of the volumes on the metanode by the shares of the
+// limits of the volumes distributed by the master with the heartbeats. The operations are queued by
+// the clients, which are told apart by the host of the connections.
+type volOpLimiter struct {
+	maxWait time.Duration
+
+	mu     sync.RWMutex
+	queues map[string]*volOpQueue
+}
+
+func newVolOpLimiter(maxWait time.Duration) *volOpLimiter {
+	if maxWait <= 0 {
+		maxWait = defaultVolOpQueueWait
+	}
+	return &volOpLimiter{
+		maxWait: maxWait,
+		queues:  make(map[string]*volOpQueue),
+	}
+}
+
+func fixVolOpLimitBurst(limit *proto.VolMetaOpLimit) {
+	if limit.Burst <= 0 {
+		limit.Burst = int(limit.Ops)
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+}
+
+func (l *volOpLimiter) setLimit(limit proto.VolMetaOpLimit) error {
+	if limit.Vol == "" {
+		return fmt.Errorf("vol is required")
+	}
+	if limit.Ops <= 0 {
+		return fmt.Errorf("ops(%v) is not positive", limit.Ops)
+	}
+	fixVolOpLimitBurst(&limit)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if q, ok := l.queues[limit.Vol]; ok {
+		q.setLimit(limit)
+		return nil
+	}
+	l.queues[limit.Vol] = newVolOpQueue(limit, l.maxWait)
+	return nil
+}
+
+// applyLimits applies the limits from the master, the limits of the volumes not in them are removed.
+func (l *volOpLimiter) applyLimits(limits []*proto.VolMetaOpLimit) {
+	vols := make(map[string]struct{}, len(limits))
+	for _, limit := range limits {
+		if err := l.setLimit(*limit); err != nil {
+			log.LogWarnf("[applyLimits] limit(%+v) from master is invalid: %v", limit, err)
+			continue
+		}
+		vols[limit.Vol] = struct{}{}
+	}
+	l.mu.RLock()
+	removed := make([]string, 0)
+	for vol := range l.queues {
+		if _, ok := vols[vol]; !ok {
+			removed = append(removed, vol)
+		}
+	}
+	l.mu.RUnlock()
+	for _, vol := range removed {
+		l.delLimit(vol)
+	}
+}
+
+// delLimit removes the limit of the volume, the waiting operations are all admitted.
+func (l *volOpLimiter) delLimit(vol string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q, ok := l.queues[vol]
+	if !ok {
+		return false
+	}
+	delete(l.queues, vol)
+	q.stop()
+	return true
+}
+
+func (l *volOpLimiter) acquire(vol, client string) bool {
+	l.mu.RLock()
+	q, ok := l.queues[vol]
+	l.mu.RUnlock()
+	if !ok {
+		return true
+	}
+	return q.acquire(client)
+}
+
+// stats returns the stats of the volume, all volumes if vol is empty.
+func (l *volOpLimiter) stats(vol string) []*VolOpLimitStat {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	stats := make([]*VolOpLimitStat, 0, len(l.queues))
+	for name, q := range l.queues {
+		if vol == "" || name == vol {
+			stats = append(stats, q.stat())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Vol < stats[j].Vol })
+	return stats
+}
+
+func (l *volOpLimiter) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for vol, q := range l.queues {
+		delete(l.queues, vol)
+		q.stop()
+	}
+}
+
+func volOpClient(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// admitVolOp returns false if the operation is rejected by the limit of the volume, and the client
+// is told to try again. The operations proxied by the peers of the partition have been admitted by
+// the peers with the address of the client, so they are not charged to the peers again.
+func (m *metadataManager) admitVolOp(conn net.Conn, p *Packet, vol, remoteAddr string) bool {
+	if vol == "" || m.metaNode == nil || m.metaNode.volOpLimiter == nil {
+		return true
+	}
+	if _, ok := unlimitedVolOps[p.Opcode]; ok {
+		return true
+	}
+	if mp, err := m.getPartition(p.PartitionID); err == nil && isPartitionPeer(mp, remoteAddr) {
+		return true
+	}
+	if m.metaNode.volOpLimiter.acquire(vol, volOpClient(remoteAddr)) {
+		return true
+	}
+	p.PacketErrorWithBody(proto.OpAgain, []byte(fmt.Sprintf("operations of vol(%v) are limited", vol)))
+	m.respondToClient(conn, p)
+	return false
+}
+
+func (m *MetaNode) getVolOpLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
+	resp.Data = m.volOpLimiter.stats(r.FormValue("vol"))
+	data, _ := resp.Marshal()
+	if _, err := w.Write(data); err != nil {
+		log.LogErrorf("[getVolOpLimitsHandler] response %s", err)
+	}
+}
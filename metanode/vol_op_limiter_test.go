@@ -0,0 +1,130 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolOpLimiterLimits(t *testing.T) {
+	limiter := newVolOpLimiter(20 * time.Millisecond)
+	defer limiter.stop()
+	require.Error(t, limiter.setLimit(proto.VolMetaOpLimit{Ops: 1}))
+	require.Error(t, limiter.setLimit(proto.VolMetaOpLimit{Vol: "vol", Ops: 0}))
+
+	// no limit by default
+	for i := 0; i < 10; i++ {
+		require.True(t, limiter.acquire("vol", "a"))
+	}
+	require.Empty(t, limiter.stats(""))
+
+	require.NoError(t, limiter.setLimit(proto.VolMetaOpLimit{Vol: "vol", Ops: 1}))
+	require.True(t, limiter.acquire("vol", "a"))
+	// waits longer than the max wait
+	require.False(t, limiter.acquire("vol", "a"))
+	require.True(t, limiter.acquire("other", "a"))
+
+	stats := limiter.stats("vol")
+	require.Len(t, stats, 1)
+	require.Equal(t, proto.VolMetaOpLimit{Vol: "vol", Ops: 1, Burst: 1}, stats[0].VolMetaOpLimit)
+	require.Equal(t, uint64(1), stats[0].Admitted)
+	require.Equal(t, uint64(1), stats[0].Rejected)
+
+	require.True(t, limiter.delLimit("vol"))
+	require.False(t, limiter.delLimit("vol"))
+	require.True(t, limiter.acquire("vol", "a"))
+	require.Equal(t, defaultVolOpQueueWait, newVolOpLimiter(0).maxWait)
+}
+
+func TestVolOpLimiterApplyLimits(t *testing.T) {
+	limiter := newVolOpLimiter(20 * time.Millisecond)
+	defer limiter.stop()
+	limiter.applyLimits([]*proto.VolMetaOpLimit{
+		{Vol: "a", Ops: 10},
+		{Vol: "b", Ops: 20, Burst: 5},
+		{Vol: "c", Ops: 0},
+	})
+	stats := limiter.stats("")
+	require.Len(t, stats, 2)
+	require.Equal(t, proto.VolMetaOpLimit{Vol: "a", Ops: 10, Burst: 10}, stats[0].VolMetaOpLimit)
+	require.Equal(t, proto.VolMetaOpLimit{Vol: "b", Ops: 20, Burst: 5}, stats[1].VolMetaOpLimit)
+
+	// the limits of the volumes missing from the master are removed
+	limiter.applyLimits([]*proto.VolMetaOpLimit{{Vol: "b", Ops: 30}})
+	stats = limiter.stats("")
+	require.Len(t, stats, 1)
+	require.Equal(t, proto.VolMetaOpLimit{Vol: "b", Ops: 30, Burst: 30}, stats[0].VolMetaOpLimit)
+	limiter.applyLimits(nil)
+	require.Empty(t, limiter.stats(""))
+}
+
+func TestVolOpLimiterFairQueue(t *testing.T) {
+	limiter := newVolOpLimiter(5 * time.Second)
+	defer limiter.stop()
+	require.NoError(t, limiter.setLimit(proto.VolMetaOpLimit{Vol: "vol", Ops: 20, Burst: 1}))
+	require.True(t, limiter.acquire("vol", "a"))
+
+	// the client a crawls the volume
+	var admitted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.acquire("vol", "a") {
+				atomic.AddInt32(&admitted, 1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	require.Greater(t, limiter.stats("vol")[0].Queued["a"], 25)
+
+	// the operation of the client b is served in turn instead of waiting for all of the client a
+	start := time.Now()
+	require.True(t, limiter.acquire("vol", "b"))
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.Less(t, atomic.LoadInt32(&admitted), int32(15))
+
+	wg.Wait()
+	require.Equal(t, int32(30), atomic.LoadInt32(&admitted))
+	require.Empty(t, limiter.stats("vol")[0].Queued)
+}
+
+func TestVolOpLimiterDelLimit(t *testing.T) {
+	limiter := newVolOpLimiter(5 * time.Second)
+	defer limiter.stop()
+	require.NoError(t, limiter.setLimit(proto.VolMetaOpLimit{Vol: "vol", Ops: 0.1}))
+	require.True(t, limiter.acquire("vol", "a"))
+
+	done := make(chan bool)
+	go func() {
+		done <- limiter.acquire("vol", "a")
+	}()
+	time.Sleep(20 * time.Millisecond)
+	// the waiting operations are admitted once the limit is removed
+	require.True(t, limiter.delLimit("vol"))
+	require.True(t, <-done)
+}
+
+func TestVolOpClient(t *testing.T) {
+	require.Equal(t, "192.168.0.1", volOpClient("192.168.0.1:17010"))
+	require.Equal(t, "client", volOpClient("client"))
+}
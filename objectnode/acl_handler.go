@@ -104,6 +104,7 @@ func (o *ObjectNode) putBucketACLHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	vol.metaLoader.storeACL(acl)
+	o.reportBucketEvent(vol.Name())
 
 	return
 }
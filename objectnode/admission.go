@@ -0,0 +1,238 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	AdmissionPriorityLow    = "low"
+	AdmissionPriorityNormal = "normal"
+	AdmissionPriorityHigh   = "high"
+
+	defaultAdmissionRetryAfterSec = 1
+	maxAdmissionRetryAfterSec     = 60
+
+	// the requests of the priority are shed once the saturation reaches the ratio,
+	// and the requests of the high priority are never shed
+	admissionShedRatioLow    = 0.8
+	admissionShedRatioNormal = 1.0
+
+	// the weight of the latest request in the average latency
+	admissionLatencyWeight = 8
+	// the average latency halves every half life without requests, so that it recovers even
+	// if all the requests tracked are shed
+	admissionLatencyHalfLife = time.Second
+)
+
+// the requests releasing the resources or finishing the work done are not shed, and the
+// expensive listings are shed first
+var defaultAdmissionPriorities = map[string]string{
+	DELETE_OBJECT:              AdmissionPriorityHigh,
+	BATCH_DELETE:               AdmissionPriorityHigh,
+	ABORT_MULTIPART_UPLOAD:     AdmissionPriorityHigh,
+	COMPLETE_MULTIPART_UPLOAD:  AdmissionPriorityHigh,
+	List_BUCKETS:               AdmissionPriorityLow,
+	LIST_OBJECTS:               AdmissionPriorityLow,
+	LIST_OBJECTS_V2:            AdmissionPriorityLow,
+	LIST_PARTS:                 AdmissionPriorityLow,
+	LIST_MULTIPART_UPLOADS:     AdmissionPriorityLow,
+	GET_BUCKET_OBJECT_VERSIONS: AdmissionPriorityLow,
+}
+
+// AdmissionConfig is the config of the admission control. The saturation of the objectnode is the
+// highest ratio of the goroutines, the requests in flight, the uploads in flight and the average
+// latency of the requests not transferring the object data to their limits, the limits not set
+// are not tracked. The requests are shed by their priorities once the objectnode is saturated, and
+// the priorities of the apis not in ApiPriorities are the default ones.
+type AdmissionConfig struct {
+	MaxGoroutines int               `json:"maxGoroutines"`
+	MaxInflight   int64             `json:"maxInflight"`
+	MaxUploads    int64             `json:"maxUploads"`
+	MaxLatencyMs  int64             `json:"maxLatencyMs"`
+	RetryAfterSec int               `json:"retryAfterSec"`
+	ApiPriorities map[string]string `json:"apiPriorities"`
+}
+
+// AdmissionControl sheds the requests early with 503 and Retry-After when the objectnode is
+// saturated, rather than queueing them until the clients time out and retry.
+type AdmissionControl struct {
+	conf        AdmissionConfig
+	priorities  map[string]string
+	inflight    int64
+	uploads     int64
+	latencyNs   int64 // the average latency of the requests not transferring the object data
+	latencyTime int64 // the unix nano time latencyNs is updated
+	goroutines  func() int
+	now         func() time.Time
+}
+
+func NewAdmissionControl(conf AdmissionConfig) (ac *AdmissionControl, err error) {
+	if conf.MaxGoroutines <= 0 && conf.MaxInflight <= 0 && conf.MaxUploads <= 0 && conf.MaxLatencyMs <= 0 {
+		return nil, fmt.Errorf("no limit is set")
+	}
+	if conf.RetryAfterSec <= 0 {
+		conf.RetryAfterSec = defaultAdmissionRetryAfterSec
+	}
+	ac = &AdmissionControl{
+		conf:       conf,
+		priorities: make(map[string]string),
+		goroutines: runtime.NumGoroutine,
+		now:        time.Now,
+	}
+	for api, priority := range defaultAdmissionPriorities {
+		ac.priorities[api] = priority
+	}
+	for api, priority := range conf.ApiPriorities {
+		switch priority {
+		case AdmissionPriorityLow, AdmissionPriorityNormal, AdmissionPriorityHigh:
+			ac.priorities[api] = priority
+		default:
+			return nil, fmt.Errorf("invalid priority %v of api %v", priority, api)
+		}
+	}
+	return ac, nil
+}
+
+func (ac *AdmissionControl) priority(api string) string {
+	if priority, ok := ac.priorities[api]; ok {
+		return priority
+	}
+	return AdmissionPriorityNormal
+}
+
+// saturation returns the highest ratio of the load to the limits.
+func (ac *AdmissionControl) saturation() (ratio float64) {
+	check := func(load, limit int64) {
+		if limit > 0 {
+			ratio = math.Max(ratio, float64(load)/float64(limit))
+		}
+	}
+	check(int64(ac.goroutines()), int64(ac.conf.MaxGoroutines))
+	check(atomic.LoadInt64(&ac.inflight), ac.conf.MaxInflight)
+	check(atomic.LoadInt64(&ac.uploads), ac.conf.MaxUploads)
+	check(ac.latency(ac.now().UnixNano()), ac.conf.MaxLatencyMs*int64(time.Millisecond))
+	return
+}
+
+// latency returns the average latency decayed by the time since it is updated.
+func (ac *AdmissionControl) latency(now int64) int64 {
+	return decayLatency(atomic.LoadInt64(&ac.latencyNs), now-atomic.LoadInt64(&ac.latencyTime))
+}
+
+func decayLatency(latency, elapsed int64) int64 {
+	if latency == 0 || elapsed <= 0 {
+		return latency
+	}
+	return int64(float64(latency) * math.Exp2(-float64(elapsed)/float64(admissionLatencyHalfLife)))
+}
+
+// admit returns the seconds after which the shed request can be retried, or 0 if admitted.
+func (ac *AdmissionControl) admit(api string) (retryAfter int) {
+	var threshold float64
+	switch ac.priority(api) {
+	case AdmissionPriorityHigh:
+		return 0
+	case AdmissionPriorityLow:
+		threshold = admissionShedRatioLow
+	default:
+		threshold = admissionShedRatioNormal
+	}
+	ratio := ac.saturation()
+	if ratio < threshold {
+		return 0
+	}
+	// the more saturated, the later the clients retry
+	retryAfter = int(math.Ceil(float64(ac.conf.RetryAfterSec) * ratio / threshold))
+	if retryAfter > maxAdmissionRetryAfterSec {
+		retryAfter = maxAdmissionRetryAfterSec
+	}
+	return
+}
+
+func isDataTransferApi(api string) bool {
+	if _, ok := putApi[strings.ToLower(api)]; ok {
+		return true
+	}
+	return api == GET_OBJECT || api == GET_BUCKET_ARCHIVE
+}
+
+func (ac *AdmissionControl) begin(api string) {
+	atomic.AddInt64(&ac.inflight, 1)
+	if _, ok := putApi[strings.ToLower(api)]; ok {
+		atomic.AddInt64(&ac.uploads, 1)
+	}
+}
+
+func (ac *AdmissionControl) end(api string, cost time.Duration) {
+	atomic.AddInt64(&ac.inflight, -1)
+	if _, ok := putApi[strings.ToLower(api)]; ok {
+		atomic.AddInt64(&ac.uploads, -1)
+	}
+	// the latency of the data transfer depends on the object size and the client
+	if isDataTransferApi(api) {
+		return
+	}
+	now := ac.now().UnixNano()
+	for {
+		old := atomic.LoadInt64(&ac.latencyNs)
+		cur := decayLatency(old, now-atomic.LoadInt64(&ac.latencyTime))
+		latency := cur + (int64(cost)-cur)/admissionLatencyWeight
+		if atomic.CompareAndSwapInt64(&ac.latencyNs, old, latency) {
+			atomic.StoreInt64(&ac.latencyTime, now)
+			return
+		}
+	}
+}
+
+// admissionMiddleware returns a pre-handle middleware handler to shed the requests by their
+// priorities when the objectnode is saturated.
+func (o *ObjectNode) admissionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			ac := o.admission
+			if ac == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			api := ActionFromRouteName(mux.CurrentRoute(r).GetName()).Name()
+			if retryAfter := ac.admit(api); retryAfter > 0 {
+				log.LogWarnf("admissionMiddleware: shed request: requestID(%v) api(%v) priority(%v) retryAfter(%v)",
+					GetRequestID(r), api, ac.priority(api), retryAfter)
+				exporter.NewCounter(fmt.Sprintf("admission_shed_%v", ac.priority(api))).Add(1)
+				w.Header().Set(RetryAfter, strconv.Itoa(retryAfter))
+				SlowDown.ServeResponse(w, r)
+				return
+			}
+			ac.begin(api)
+			start := time.Now()
+			defer func() {
+				ac.end(api, time.Since(start))
+			}()
+			next.ServeHTTP(w, r)
+		})
+}
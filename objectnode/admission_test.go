@@ -0,0 +1,133 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmissionControl(t *testing.T) {
+	_, err := NewAdmissionControl(AdmissionConfig{})
+	require.Error(t, err)
+	_, err = NewAdmissionControl(AdmissionConfig{MaxInflight: 10, ApiPriorities: map[string]string{GET_OBJECT: "urgent"}})
+	require.Error(t, err)
+
+	ac, err := NewAdmissionControl(AdmissionConfig{
+		MaxGoroutines: 100,
+		MaxInflight:   10,
+		MaxUploads:    2,
+		MaxLatencyMs:  100,
+		ApiPriorities: map[string]string{HEAD_OBJECT: AdmissionPriorityHigh, LIST_OBJECTS: AdmissionPriorityNormal},
+	})
+	require.NoError(t, err)
+	goroutines := 0
+	ac.goroutines = func() int { return goroutines }
+	now := time.Now()
+	ac.now = func() time.Time { return now }
+	require.Equal(t, AdmissionPriorityHigh, ac.priority(HEAD_OBJECT))
+	require.Equal(t, AdmissionPriorityNormal, ac.priority(LIST_OBJECTS))
+	require.Equal(t, AdmissionPriorityLow, ac.priority(LIST_OBJECTS_V2))
+	require.Equal(t, AdmissionPriorityNormal, ac.priority(GET_OBJECT))
+
+	// the low priority requests are shed first
+	goroutines = 90
+	require.Equal(t, 0, ac.admit(GET_OBJECT))
+	require.Equal(t, 2, ac.admit(LIST_OBJECTS_V2))
+	goroutines = 150
+	require.Equal(t, 2, ac.admit(GET_OBJECT))
+	require.Equal(t, 0, ac.admit(HEAD_OBJECT))
+	goroutines = 100000
+	require.Equal(t, maxAdmissionRetryAfterSec, ac.admit(GET_OBJECT))
+	goroutines = 0
+
+	// the uploads in flight
+	ac.begin(PUT_OBJECT)
+	ac.begin(UPLOAD_PART)
+	require.Equal(t, 1, ac.admit(GET_OBJECT))
+	ac.end(PUT_OBJECT, time.Second)
+	ac.end(UPLOAD_PART, time.Second)
+	require.Equal(t, 0, ac.admit(GET_OBJECT))
+	require.Equal(t, int64(0), ac.latencyNs)
+
+	// the average latency of the requests not transferring the object data
+	for i := 0; i < 50; i++ {
+		ac.begin(HEAD_OBJECT)
+		ac.end(HEAD_OBJECT, time.Second)
+	}
+	require.Equal(t, int64(0), ac.inflight)
+	require.True(t, ac.admit(GET_OBJECT) > 0)
+	for i := 0; i < 50; i++ {
+		ac.begin(HEAD_OBJECT)
+		ac.end(HEAD_OBJECT, time.Millisecond)
+	}
+	require.Equal(t, 0, ac.admit(GET_OBJECT))
+
+	// the latency decays while all the requests are shed
+	for i := 0; i < 50; i++ {
+		ac.begin(HEAD_OBJECT)
+		ac.end(HEAD_OBJECT, time.Second)
+	}
+	require.True(t, ac.admit(GET_OBJECT) > 0)
+	now = now.Add(admissionLatencyHalfLife)
+	require.True(t, ac.admit(GET_OBJECT) > 0)
+	now = now.Add(3 * admissionLatencyHalfLife)
+	require.Equal(t, 0, ac.admit(GET_OBJECT))
+}
+
+func TestAdmissionMiddleware(t *testing.T) {
+	ac, err := NewAdmissionControl(AdmissionConfig{MaxInflight: 1, RetryAfterSec: 3})
+	require.NoError(t, err)
+	o := &ObjectNode{admission: ac}
+	block := make(chan struct{})
+	router := mux.NewRouter()
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectAction)).Methods(http.MethodPut).
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-block })
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteObjectAction)).Methods(http.MethodDelete).
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.Use(o.admissionMiddleware)
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/a", nil))
+		close(done)
+	}()
+	for i := 0; i < 100 && ac.saturation() < 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/b", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "3", w.Header().Get(RetryAfter))
+	require.Contains(t, w.Body.String(), SlowDown.ErrorCode)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/b", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	close(block)
+	<-done
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/b", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, int64(0), ac.inflight)
+}
@@ -0,0 +1,470 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+)
+
+// the verdicts of the request reputations, the most severe one of the hooks is taken
+const (
+	ReputationAllow    = "allow"
+	ReputationThrottle = "throttle"
+	ReputationDeny     = "deny"
+)
+
+const (
+	defaultAnonymousMaxIPs         = 100000
+	defaultAnonymousMaxBuckets     = 10000
+	defaultAnonymousThrottledQPS   = 1
+	defaultAnonymousRetryAfterSec  = 1
+	defaultReputationWebhookTimeMs = 200
+	defaultReputationCacheSec      = 60
+	defaultReputationCacheSize     = 100000
+
+	ReputationWebhookUserAgent = "Golang cubefs/objectnode reputation webhook"
+)
+
+// AnonymousRequest is the anonymous request judged by the request reputations.
+type AnonymousRequest struct {
+	IP        string `json:"ip"`
+	Method    string `json:"method"`
+	API       string `json:"api"`
+	Bucket    string `json:"bucket"`
+	Object    string `json:"object"`
+	UserAgent string `json:"userAgent"`
+	Referer   string `json:"referer"`
+}
+
+// RequestReputation is the hook to judge the anonymous requests, by which the bots scraping the
+// public buckets are throttled or denied. Judge is called for the anonymous requests within the
+// limits, so it should return fast and allow the request if the reputation is unknown.
+type RequestReputation interface {
+	Name() string
+	Judge(req *AnonymousRequest) string
+}
+
+// RequestReputationFunc creates the request reputation by its raw json config.
+type RequestReputationFunc func(conf json.RawMessage) (RequestReputation, error)
+
+var (
+	reputationMutex sync.RWMutex
+	reputationFuncs = map[string]RequestReputationFunc{
+		"userAgent": NewUserAgentReputation,
+		"webhook":   NewWebhookReputation,
+	}
+)
+
+// RegisterRequestReputation registers the request reputation to be configured by the name, it
+// should be called before the objectnode starts.
+func RegisterRequestReputation(name string, newFunc RequestReputationFunc) {
+	reputationMutex.Lock()
+	defer reputationMutex.Unlock()
+	reputationFuncs[name] = newFunc
+}
+
+func newRequestReputation(name string, conf json.RawMessage) (RequestReputation, error) {
+	reputationMutex.RLock()
+	newFunc, ok := reputationFuncs[name]
+	reputationMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown reputation %v", name)
+	}
+	return newFunc(conf)
+}
+
+type ReputationConfig struct {
+	Name   string          `json:"name"`
+	Config json.RawMessage `json:"config"`
+}
+
+// AnonymousLimitConfig is the config of the limits of the anonymous requests, which are separate
+// from the limits of the users and do not affect the authenticated requests. The limits not set
+// are not applied. The requests judged to be throttled by the reputations are limited by
+// ThrottledQPS of their ips instead of PerIPQPS, and the requests judged to be denied are rejected.
+type AnonymousLimitConfig struct {
+	QPS           float64            `json:"qps"`
+	Burst         int                `json:"burst"`
+	PerBucketQPS  float64            `json:"perBucketQps"`
+	PerIPQPS      float64            `json:"perIPQps"`
+	ThrottledQPS  float64            `json:"throttledQps"`
+	MaxConcurrent int64              `json:"maxConcurrent"`
+	MaxIPs        int                `json:"maxIPs"`
+	MaxBuckets    int                `json:"maxBuckets"`
+	RetryAfterSec int                `json:"retryAfterSec"`
+	Reputations   []ReputationConfig `json:"reputations"`
+}
+
+// AnonymousLimiter limits the anonymous requests by the total, the buckets and the ips of the
+// clients, and judges them by the request reputations.
+type AnonymousLimiter struct {
+	conf        AnonymousLimitConfig
+	global      *rate.Limiter
+	buckets     *lru.Cache // bucket -> *rate.Limiter
+	ips         *lru.Cache // ip -> *rate.Limiter, the throttled ips are keyed by ip/throttled
+	inflight    int64
+	reputations []RequestReputation
+}
+
+func NewAnonymousLimiter(conf AnonymousLimitConfig) (l *AnonymousLimiter, err error) {
+	if conf.QPS < 0 || conf.PerBucketQPS < 0 || conf.PerIPQPS < 0 || conf.ThrottledQPS < 0 {
+		return nil, fmt.Errorf("negative qps")
+	}
+	if conf.ThrottledQPS == 0 {
+		conf.ThrottledQPS = defaultAnonymousThrottledQPS
+	}
+	if conf.MaxIPs <= 0 {
+		conf.MaxIPs = defaultAnonymousMaxIPs
+	}
+	if conf.MaxBuckets <= 0 {
+		conf.MaxBuckets = defaultAnonymousMaxBuckets
+	}
+	if conf.RetryAfterSec <= 0 {
+		conf.RetryAfterSec = defaultAnonymousRetryAfterSec
+	}
+	l = &AnonymousLimiter{conf: conf}
+	if conf.QPS > 0 {
+		l.global = rate.NewLimiter(rate.Limit(conf.QPS), anonymousBurst(conf.QPS, conf.Burst))
+	}
+	if l.ips, err = lru.New(conf.MaxIPs); err != nil {
+		return nil, err
+	}
+	if l.buckets, err = lru.New(conf.MaxBuckets); err != nil {
+		return nil, err
+	}
+	for _, rc := range conf.Reputations {
+		var reputation RequestReputation
+		if reputation, err = newRequestReputation(rc.Name, rc.Config); err != nil {
+			return nil, fmt.Errorf("invalid reputation %v: %v", rc.Name, err)
+		}
+		l.reputations = append(l.reputations, reputation)
+	}
+	return l, nil
+}
+
+// anonymousBurst returns the burst of the limit, which is one second of the qps if not set.
+func anonymousBurst(qps float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	if qps < 1 {
+		return 1
+	}
+	return int(qps)
+}
+
+// judge returns the most severe verdict of the reputations.
+func (l *AnonymousLimiter) judge(req *AnonymousRequest) (verdict string) {
+	verdict = ReputationAllow
+	for _, reputation := range l.reputations {
+		switch reputation.Judge(req) {
+		case ReputationDeny:
+			log.LogDebugf("anonymous request denied by %v: %+v", reputation.Name(), req)
+			return ReputationDeny
+		case ReputationThrottle:
+			verdict = ReputationThrottle
+		}
+	}
+	return
+}
+
+// cachedLimiter returns the limiter of the key in the cache, the least recently used keys are
+// evicted so that the clients can not grow the cache without bound.
+func cachedLimiter(cache *lru.Cache, key string, qps float64) *rate.Limiter {
+	if limiter, ok := cache.Get(key); ok {
+		return limiter.(*rate.Limiter)
+	}
+	limiter := rate.NewLimiter(rate.Limit(qps), anonymousBurst(qps, 0))
+	// the keys racing to add keep the earlier one
+	if exist, _ := cache.ContainsOrAdd(key, limiter); exist {
+		if cur, ok := cache.Get(key); ok {
+			return cur.(*rate.Limiter)
+		}
+	}
+	return limiter
+}
+
+func (l *AnonymousLimiter) ipLimiter(ip string, throttled bool) *rate.Limiter {
+	if throttled {
+		return cachedLimiter(l.ips, ip+"/throttled", l.conf.ThrottledQPS)
+	}
+	return cachedLimiter(l.ips, ip, l.conf.PerIPQPS)
+}
+
+func (l *AnonymousLimiter) bucketLimiter(bucket string) *rate.Limiter {
+	return cachedLimiter(l.buckets, bucket, l.conf.PerBucketQPS)
+}
+
+// admit returns the error code and the reason if the request is rejected, the admitted request
+// must be ended. The cheap limits are checked before the reputations, so that the storms are
+// rejected without querying the reputations.
+func (l *AnonymousLimiter) admit(req *AnonymousRequest) (ec *ErrorCode, reason string) {
+	if l.conf.MaxConcurrent > 0 && atomic.AddInt64(&l.inflight, 1) > l.conf.MaxConcurrent {
+		atomic.AddInt64(&l.inflight, -1)
+		return SlowDown, "concurrent"
+	}
+	// the most specific limit is checked first, to save the tokens of the others from the bots
+	switch {
+	case l.conf.PerIPQPS > 0 && !l.ipLimiter(req.IP, false).Allow():
+		reason = "ip"
+	case l.conf.PerBucketQPS > 0 && req.Bucket != "" && !l.bucketLimiter(req.Bucket).Allow():
+		reason = "bucket"
+	case l.global != nil && !l.global.Allow():
+		reason = "qps"
+	default:
+		switch l.judge(req) {
+		case ReputationDeny:
+			l.end()
+			return AccessDenied, "reputation"
+		case ReputationThrottle:
+			if !l.ipLimiter(req.IP, true).Allow() {
+				reason = "throttled"
+			}
+		}
+		if reason == "" {
+			return nil, ""
+		}
+	}
+	l.end()
+	return SlowDown, reason
+}
+
+func (l *AnonymousLimiter) end() {
+	if l.conf.MaxConcurrent > 0 {
+		atomic.AddInt64(&l.inflight, -1)
+	}
+}
+
+// serveAnonymous serves the anonymous request within the anonymous limits.
+func (o *ObjectNode) serveAnonymous(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	l := o.anonymousLimit
+	if l == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+	vars := mux.Vars(r)
+	req := &AnonymousRequest{
+		IP:        o.trustedProxies.clientIP(r),
+		Method:    r.Method,
+		API:       ActionFromRouteName(mux.CurrentRoute(r).GetName()).Name(),
+		Bucket:    vars[ContextKeyBucket],
+		Object:    vars[ContextKeyObject],
+		UserAgent: r.UserAgent(),
+		Referer:   r.Referer(),
+	}
+	if ec, reason := l.admit(req); ec != nil {
+		log.LogWarnf("serveAnonymous: limit anonymous request: requestID(%v) ip(%v) api(%v) bucket(%v) reason(%v)",
+			GetRequestID(r), req.IP, req.API, req.Bucket, reason)
+		exporter.NewCounter(fmt.Sprintf("anonymous_limited_%v", reason)).Add(1)
+		if ec == SlowDown {
+			w.Header().Set(RetryAfter, strconv.Itoa(l.conf.RetryAfterSec))
+		}
+		ec.ServeResponse(w, r)
+		return
+	}
+	defer l.end()
+	next.ServeHTTP(w, r)
+}
+
+// UserAgentReputationConfig is the config of the reputation by the regular expressions of the
+// user agents, the empty user agent is matched by "^$".
+type UserAgentReputationConfig struct {
+	Deny     []string `json:"deny"`
+	Throttle []string `json:"throttle"`
+}
+
+// UserAgentReputation judges the anonymous requests by their user agents.
+type UserAgentReputation struct {
+	deny     []*regexp.Regexp
+	throttle []*regexp.Regexp
+}
+
+func NewUserAgentReputation(raw json.RawMessage) (RequestReputation, error) {
+	var conf UserAgentReputationConfig
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		return nil, err
+	}
+	compile := func(exprs []string) (res []*regexp.Regexp, err error) {
+		for _, expr := range exprs {
+			var re *regexp.Regexp
+			if re, err = regexp.Compile(expr); err != nil {
+				return
+			}
+			res = append(res, re)
+		}
+		return
+	}
+	var err error
+	reputation := new(UserAgentReputation)
+	if reputation.deny, err = compile(conf.Deny); err != nil {
+		return nil, err
+	}
+	if reputation.throttle, err = compile(conf.Throttle); err != nil {
+		return nil, err
+	}
+	return reputation, nil
+}
+
+func (u *UserAgentReputation) Name() string {
+	return "userAgent"
+}
+
+func (u *UserAgentReputation) Judge(req *AnonymousRequest) string {
+	for _, re := range u.deny {
+		if re.MatchString(req.UserAgent) {
+			return ReputationDeny
+		}
+	}
+	for _, re := range u.throttle {
+		if re.MatchString(req.UserAgent) {
+			return ReputationThrottle
+		}
+	}
+	return ReputationAllow
+}
+
+// WebhookReputationConfig is the config of the reputation by the webhook, which is posted with the
+// AnonymousRequest and responds the verdict of the ip in json, e.g. {"verdict": "deny"}.
+type WebhookReputationConfig struct {
+	TimeoutMs int `json:"timeoutMs"`
+	CacheSec  int `json:"cacheSec"`
+	CacheSize int `json:"cacheSize"`
+
+	WebhookConfig
+}
+
+type WebhookReputationResponse struct {
+	Verdict string `json:"verdict"`
+}
+
+type reputationVerdict struct {
+	verdict string
+	expire  time.Time
+}
+
+// WebhookReputation judges the anonymous requests by the verdicts of their ips from the webhook,
+// the verdicts are cached for CacheSec, and the requests are allowed if the webhook fails. The
+// concurrent requests of an ip not cached share one query.
+type WebhookReputation struct {
+	conf    WebhookReputationConfig
+	client  *http.Client
+	verdict *lru.Cache // ip -> *reputationVerdict
+	queries singleflight.Group
+}
+
+func NewWebhookReputation(raw json.RawMessage) (RequestReputation, error) {
+	var conf WebhookReputationConfig
+	if err := json.Unmarshal(raw, &conf); err != nil {
+		return nil, err
+	}
+	if err := conf.FixConfig(); err != nil {
+		return nil, err
+	}
+	if conf.TimeoutMs <= 0 {
+		conf.TimeoutMs = defaultReputationWebhookTimeMs
+	}
+	if conf.CacheSec <= 0 {
+		conf.CacheSec = defaultReputationCacheSec
+	}
+	if conf.CacheSize <= 0 {
+		conf.CacheSize = defaultReputationCacheSize
+	}
+	client, err := conf.BuildClient()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := lru.New(conf.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookReputation{conf: conf, client: client, verdict: cache}, nil
+}
+
+func (h *WebhookReputation) Name() string {
+	return "webhook"
+}
+
+func (h *WebhookReputation) Judge(req *AnonymousRequest) string {
+	if v, ok := h.verdict.Get(req.IP); ok && time.Now().Before(v.(*reputationVerdict).expire) {
+		return v.(*reputationVerdict).verdict
+	}
+	verdict, _, _ := h.queries.Do(req.IP, func() (interface{}, error) {
+		verdict, err := h.query(req)
+		if err != nil {
+			log.LogWarnf("WebhookReputation: query %v fail: ip(%v) err(%v)", h.conf.Endpoint, req.IP, err)
+			verdict = ReputationAllow
+		}
+		h.verdict.Add(req.IP, &reputationVerdict{
+			verdict: verdict,
+			expire:  time.Now().Add(time.Duration(h.conf.CacheSec) * time.Second),
+		})
+		return verdict, nil
+	})
+	return verdict.(string)
+}
+
+func (h *WebhookReputation) query(req *AnonymousRequest) (verdict string, err error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.conf.TimeoutMs)*time.Millisecond)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.conf.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	httpReq.Header.Set(ContentType, ValueContentTypeJSON)
+	httpReq.Header.Set(UserAgent, ReputationWebhookUserAgent)
+	if h.conf.Authorization != "" {
+		httpReq.Header.Set(Authorization, h.conf.Authorization)
+	}
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("statuscode %v", resp.StatusCode)
+	}
+	var result WebhookReputationResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&result); err != nil {
+		return
+	}
+	switch result.Verdict {
+	case ReputationAllow, ReputationThrottle, ReputationDeny:
+		return result.Verdict, nil
+	default:
+		return "", fmt.Errorf("unknown verdict %v", result.Verdict)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymousLimiter(t *testing.T) {
+	_, err := NewAnonymousLimiter(AnonymousLimitConfig{PerIPQPS: -1})
+	require.Error(t, err)
+	_, err = NewAnonymousLimiter(AnonymousLimitConfig{Reputations: []ReputationConfig{{Name: "unknown"}}})
+	require.Error(t, err)
+
+	l, err := NewAnonymousLimiter(AnonymousLimitConfig{QPS: 0.001, Burst: 4, PerBucketQPS: 0.001, PerIPQPS: 0.001})
+	require.NoError(t, err)
+	require.Equal(t, float64(defaultAnonymousThrottledQPS), l.conf.ThrottledQPS)
+
+	// the burst of the ip
+	req := &AnonymousRequest{IP: "10.0.0.1", Bucket: "a"}
+	ec, _ := l.admit(req)
+	require.Nil(t, ec)
+	ec, reason := l.admit(req)
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "ip", reason)
+
+	// the burst of the bucket
+	ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.2", Bucket: "b"})
+	require.Nil(t, ec)
+	ec, reason = l.admit(&AnonymousRequest{IP: "10.0.0.3", Bucket: "b"})
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "bucket", reason)
+
+	// the total burst
+	ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.4", Bucket: "c"})
+	require.Nil(t, ec)
+	ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.5", Bucket: "d"})
+	require.Nil(t, ec)
+	ec, reason = l.admit(&AnonymousRequest{IP: "10.0.0.6", Bucket: "e"})
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "qps", reason)
+
+	// the concurrent requests
+	l, err = NewAnonymousLimiter(AnonymousLimitConfig{MaxConcurrent: 1})
+	require.NoError(t, err)
+	ec, _ = l.admit(req)
+	require.Nil(t, ec)
+	ec, reason = l.admit(req)
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "concurrent", reason)
+	l.end()
+	ec, _ = l.admit(req)
+	require.Nil(t, ec)
+	l.end()
+	require.Equal(t, int64(0), l.inflight)
+
+	// the least recently used buckets are evicted
+	l, err = NewAnonymousLimiter(AnonymousLimitConfig{PerBucketQPS: 0.001, MaxBuckets: 2})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.1", Bucket: strconv.Itoa(i)})
+		require.Nil(t, ec)
+	}
+	require.Equal(t, 2, l.buckets.Len())
+}
+
+func TestAnonymousReputation(t *testing.T) {
+	var queried int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queried, 1)
+		req := new(AnonymousRequest)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		switch req.IP {
+		case "10.0.0.1":
+			json.NewEncoder(w).Encode(WebhookReputationResponse{Verdict: ReputationDeny})
+		case "10.0.0.2":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			json.NewEncoder(w).Encode(WebhookReputationResponse{Verdict: ReputationAllow})
+		}
+	}))
+	defer server.Close()
+
+	l, err := NewAnonymousLimiter(AnonymousLimitConfig{
+		ThrottledQPS: 0.001,
+		Reputations: []ReputationConfig{
+			{Name: "userAgent", Config: json.RawMessage(`{"deny": ["^$"], "throttle": ["(?i)bot"]}`)},
+			{Name: "webhook", Config: json.RawMessage(`{"endpoint": "` + server.URL + `"}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	// the empty user agent is denied
+	ec, reason := l.admit(&AnonymousRequest{IP: "10.0.0.3"})
+	require.Equal(t, AccessDenied, ec)
+	require.Equal(t, "reputation", reason)
+
+	// the bots are throttled by their ips, and the others are not limited
+	bot := &AnonymousRequest{IP: "10.0.0.3", UserAgent: "SomeBot/1.0"}
+	ec, _ = l.admit(bot)
+	require.Nil(t, ec)
+	ec, reason = l.admit(bot)
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "throttled", reason)
+	for i := 0; i < 10; i++ {
+		ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.3", UserAgent: "aws-sdk-go"})
+		require.Nil(t, ec)
+	}
+
+	// the verdicts of the webhook are cached, and the requests are allowed if it fails
+	for i := 0; i < 3; i++ {
+		ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.1", UserAgent: "curl"})
+		require.Equal(t, AccessDenied, ec)
+		ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.2", UserAgent: "curl"})
+		require.Nil(t, ec)
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&queried))
+
+	// the requests over the limits are rejected without querying the webhook
+	l, err = NewAnonymousLimiter(AnonymousLimitConfig{
+		PerIPQPS:    0.001,
+		Reputations: []ReputationConfig{{Name: "webhook", Config: json.RawMessage(`{"endpoint": "` + server.URL + `"}`)}},
+	})
+	require.NoError(t, err)
+	atomic.StoreInt32(&queried, 0)
+	ec, _ = l.admit(&AnonymousRequest{IP: "10.0.0.4"})
+	require.Nil(t, ec)
+	ec, reason = l.admit(&AnonymousRequest{IP: "10.0.0.4"})
+	require.Equal(t, SlowDown, ec)
+	require.Equal(t, "ip", reason)
+	require.Equal(t, int32(1), atomic.LoadInt32(&queried))
+
+	_, err = NewUserAgentReputation(json.RawMessage(`{"deny": ["("]}`))
+	require.Error(t, err)
+	_, err = NewWebhookReputation(json.RawMessage(`{"endpoint": "ftp://reputation"}`))
+	require.Error(t, err)
+}
+
+func TestWebhookReputationSingleflight(t *testing.T) {
+	var queried int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queried, 1)
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(w).Encode(WebhookReputationResponse{Verdict: ReputationThrottle})
+	}))
+	defer server.Close()
+
+	reputation, err := NewWebhookReputation(json.RawMessage(`{"endpoint": "` + server.URL + `", "timeoutMs": 1000}`))
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, ReputationThrottle, reputation.Judge(&AnonymousRequest{IP: "10.0.0.1"}))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&queried))
+}
+
+func TestAnonymousLimitMiddleware(t *testing.T) {
+	l, err := NewAnonymousLimiter(AnonymousLimitConfig{PerIPQPS: 0.001, RetryAfterSec: 3})
+	require.NoError(t, err)
+	o := &ObjectNode{anonymousLimit: l}
+	router := mux.NewRouter()
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectAction)).Methods(http.MethodGet).
+		HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.Use(o.authMiddleware)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/b/o", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/b/o", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "3", w.Header().Get(RetryAfter))
+	require.Contains(t, w.Body.String(), SlowDown.ErrorCode)
+
+	// the forwarding headers of the untrusted clients are ignored
+	r := httptest.NewRequest(http.MethodGet, "/b/o", nil)
+	r.Header.Set(XForwardedFor, "10.0.0.1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	o.trustedProxies, err = ParseTrustedProxies([]string{"192.0.2.0/24"})
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
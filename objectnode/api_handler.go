@@ -17,6 +17,7 @@ package objectnode
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -32,7 +33,6 @@ type RequestParam struct {
 	object    string
 	action    proto.Action
 	apiName   string
-	sourceIP  string
 	vars      map[string]string
 	accessKey string
 	r         *http.Request
@@ -77,6 +77,22 @@ func (p *RequestParam) RequestID() string {
 	return p.vars[ContextKeyRequestID]
 }
 
+// ConditionValues returns the request values to evaluate the conditions of policy, the
+// forwarding headers of the source ip and the transport are only trusted from the proxies.
+func (p *RequestParam) ConditionValues(proxies TrustedProxies) map[string]string {
+	values := map[string]string{
+		SOURCEIP:        proxies.clientIP(p.r),
+		REFERER:         p.r.Referer(),
+		HOST:            p.r.Host,
+		USERAGENT:       p.r.UserAgent(),
+		SECURETRANSPORT: strconv.FormatBool(proxies.secureTransport(p.r)),
+	}
+	if IsBucketApi(p.apiName) {
+		values[PREFIX] = p.r.URL.Query().Get(ParamPrefix)
+	}
+	return values
+}
+
 func ParseRequestParam(r *http.Request) *RequestParam {
 	p := new(RequestParam)
 	p.r = r
@@ -84,7 +100,6 @@ func ParseRequestParam(r *http.Request) *RequestParam {
 	p.bucket = p.vars[ContextKeyBucket]
 	p.object = p.vars[ContextKeyObject]
 	p.accessKey = p.vars[ContextKeyAccessKey]
-	p.sourceIP = getRequestIP(r)
 	if len(p.bucket) > 0 {
 		p.resource = p.bucket
 		if len(p.object) > 0 {
@@ -121,6 +136,15 @@ func (o *ObjectNode) getVol(bucket string) (vol *Volume, err error) {
 	return vol, nil
 }
 
+// reportBucketEvent notifies master that the bucket configs are changed, so that the
+// other objectnodes reload them. The failure is only logged since the configs are
+// reloaded periodically as well.
+func (o *ObjectNode) reportBucketEvent(bucket string) {
+	if err := o.router.Route(bucket).mc.AdminAPI().ReportBucketEvent(bucket); err != nil {
+		log.LogWarnf("reportBucketEvent: report bucket event fail: bucket(%v) err(%v)", bucket, err)
+	}
+}
+
 func (o *ObjectNode) errorResponse(w http.ResponseWriter, r *http.Request, err error, ec *ErrorCode) {
 	if err != nil || ec != nil {
 		log.LogErrorf("errorResponse: found error: requestID(%v) err(%v) errCode(%v)", GetRequestID(r), err, ec)
@@ -38,7 +38,8 @@ var regexBucketName = regexp.MustCompile(`^[0-9a-z][-0-9a-z]+[0-9a-z]$`)
 // Head bucket
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadBucket.html
 func (o *ObjectNode) headBucketHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(XAmzBucketRegion, o.region)
+	param := ParseRequestParam(r)
+	w.Header().Set(XAmzBucketRegion, o.router.Route(param.Bucket()).region)
 }
 
 // Create bucket
@@ -92,6 +93,7 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 	if errorCode != nil {
 		return
 	}
+	var location string
 	if length > 0 {
 		requestBytes, err := io.ReadAll(r.Body)
 		if err != nil && err != io.EOF {
@@ -106,12 +108,14 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 			errorCode = InvalidArgument
 			return
 		}
-		if createBucketRequest.LocationConstraint != o.region {
-			log.LogErrorf("createBucketHandler: location constraint not match the service: requestID(%v) LocationConstraint(%v) region(%v)",
-				GetRequestID(r), createBucketRequest.LocationConstraint, o.region)
-			errorCode = InvalidLocationConstraint
-			return
-		}
+		location = createBucketRequest.LocationConstraint
+	}
+	cluster, ok := o.router.RouteToCreate(bucket, location)
+	if !ok {
+		log.LogErrorf("createBucketHandler: location constraint not match the service: requestID(%v) LocationConstraint(%v) region(%v)",
+			GetRequestID(r), location, o.region)
+		errorCode = InvalidLocationConstraint
+		return
 	}
 
 	var acl *AccessControlPolicy
@@ -120,11 +124,12 @@ func (o *ObjectNode) createBucketHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err = o.mc.AdminAPI().CreateDefaultVolume(bucket, userInfo.UserID); err != nil {
-		log.LogErrorf("createBucketHandler: create bucket fail: requestID(%v) volume(%v) accessKey(%v) err(%v)",
-			GetRequestID(r), bucket, param.AccessKey(), err)
+	if err = cluster.mc.AdminAPI().CreateDefaultVolume(bucket, userInfo.UserID); err != nil {
+		log.LogErrorf("createBucketHandler: create bucket fail: requestID(%v) volume(%v) cluster(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), bucket, cluster, param.AccessKey(), err)
 		return
 	}
+	o.router.Pin(bucket, cluster)
 
 	w.Header().Set(Location, "/"+bucket)
 	w.Header().Set(Connection, "close")
@@ -196,7 +201,7 @@ func (o *ObjectNode) deleteBucketHandler(w http.ResponseWriter, r *http.Request)
 			GetRequestID(r), bucket, userInfo.UserID, err)
 		return
 	}
-	if err = o.mc.AdminAPI().DeleteVolume(bucket, authKey); err != nil {
+	if err = o.router.Route(bucket).mc.AdminAPI().DeleteVolume(bucket, authKey); err != nil {
 		log.LogErrorf("deleteBucketHandler: delete volume fail: requestID(%v) volume(%v) accessKey(%v) err(%v)",
 			GetRequestID(r), bucket, param.AccessKey(), err)
 		return
@@ -206,6 +211,7 @@ func (o *ObjectNode) deleteBucketHandler(w http.ResponseWriter, r *http.Request)
 
 	// release Volume from Volume manager
 	o.vm.Release(bucket)
+	o.router.Forget(bucket)
 	w.WriteHeader(http.StatusNoContent)
 	return
 }
@@ -255,15 +261,15 @@ func (o *ObjectNode) listBucketsHandler(w http.ResponseWriter, r *http.Request)
 		ownVols = append(ownVols, vol)
 	}
 	for _, ownVol := range ownVols {
-		var vol *Volume
-		if vol, err = o.getVol(ownVol); err != nil {
-			log.LogErrorf("listBucketsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+		var info *BucketInfo
+		if info, err = o.buckets.Get(ownVol); err != nil {
+			log.LogErrorf("listBucketsHandler: load bucket info fail: requestID(%v) volume(%v) err(%v)",
 				GetRequestID(r), ownVol, err)
 			continue
 		}
 		output.Buckets = append(output.Buckets, bucket{
 			Name:         ownVol,
-			CreationDate: formatTimeISO(vol.CreateTime()),
+			CreationDate: formatTimeISO(info.CreateTime),
 		})
 	}
 	output.Owner = Owner{DisplayName: userInfo.UserID, Id: userInfo.UserID}
@@ -309,7 +315,7 @@ func (o *ObjectNode) getBucketLocationHandler(w http.ResponseWriter, r *http.Req
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
-	location := LocationResponse{Location: o.region}
+	location := LocationResponse{Location: o.router.Route(param.Bucket()).region}
 	response, err := MarshalXMLEntity(location)
 	if err != nil {
 		log.LogErrorf("getBucketLocationHandler: xml marshal fail: requestID(%v) location(%v) err(%v)",
@@ -546,6 +552,7 @@ func (o *ObjectNode) putObjectLockConfigurationHandler(w http.ResponseWriter, r
 		return
 	}
 	vol.metaLoader.storeObjectLock(config)
+	o.reportBucketEvent(vol.Name())
 
 	w.WriteHeader(http.StatusNoContent)
 	return
@@ -604,6 +611,28 @@ func (o *ObjectNode) getUserInfoByAccessKeyV2(accessKey string) (userInfo *proto
 	return
 }
 
+// getPosixIdentity returns the POSIX identity the requester is mapped to, which is nil for the
+// anonymous requests and the users not mapped.
+func (o *ObjectNode) getPosixIdentity(param *RequestParam) (*proto.PosixIdentity, error) {
+	if param.AccessKey() == "" {
+		return nil, nil
+	}
+	userInfo, err := o.getUserInfoByAccessKeyV2(param.AccessKey())
+	if err != nil {
+		return nil, err
+	}
+	return userInfo.Posix, nil
+}
+
+// checkObjectPosixAccess checks that the requester is allowed to read the object by its POSIX identity.
+func (o *ObjectNode) checkObjectPosixAccess(param *RequestParam, vol *Volume, ino uint64) error {
+	identity, err := o.getPosixIdentity(param)
+	if err != nil {
+		return err
+	}
+	return vol.checkPosixAccess(identity, ino, posixPermRead)
+}
+
 func IsValidBucketName(bucketName string, minBucketLength, maxBucketLength int) bool {
 	if len(bucketName) < minBucketLength || len(bucketName) > maxBucketLength {
 		return false
@@ -123,6 +123,7 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		CacheControl: cacheControl,
 		Expires:      expires,
 		ACL:          acl,
+		Owner:        userInfo.Posix,
 	}
 
 	var uploadID string
@@ -742,9 +743,16 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	owner, err := o.getPosixIdentity(param)
+	if err != nil {
+		log.LogErrorf("completeMultipartUploadHandler: get posix identity fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
 	// complete multipart
 	start = time.Now()
-	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods)
+	fsFileInfo, err := vol.CompleteMultipart(param.Object(), uploadId, committedPartInfo, discardedInods, owner)
 	span.AppendTrackLog("part.c", start, err)
 	if err != nil {
 		log.LogErrorf("completeMultipartUploadHandler: complete multipart fail: requestID(%v) volume(%v) uploadID(%v) err(%v)",
@@ -165,6 +165,9 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if err = o.checkObjectPosixAccess(param, vol, fileInfo.Inode); err != nil {
+		return
+	}
 
 	// header condition check
 	errorCode = CheckConditionInHeader(r, fileInfo)
@@ -410,6 +413,9 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if err = o.checkObjectPosixAccess(param, vol, fileInfo.Inode); err != nil {
+		return
+	}
 
 	// parse request header
 	match := r.Header.Get(IfMatch)
@@ -634,12 +640,8 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 	for _, object := range deleteReq.Objects {
 		result := POLICY_UNKNOW
 		if policy != nil && !policy.IsEmpty() {
-			conditionCheck := map[string]string{
-				SOURCEIP: param.sourceIP,
-				REFERER:  param.r.Referer(),
-				KEYNAME:  object.Key,
-				HOST:     param.r.Host,
-			}
+			conditionCheck := param.ConditionValues(o.trustedProxies)
+			conditionCheck[KEYNAME] = object.Key
 			result = policy.IsAllowed(param, userInfo.UserID, vol.owner, conditionCheck)
 		}
 		if result == POLICY_DENY || (result == POLICY_UNKNOW && !allowByAcl) {
@@ -658,7 +660,7 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		if err = rateLimit.AcquireLimitResource(vol.owner, DELETE_OBJECT); err != nil {
 			return
 		}
-		if err1 := vol.DeletePath(object.Key); err1 != nil {
+		if err1 := vol.DeletePathBy(object.Key, userInfo.Posix); err1 != nil {
 			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
 				GetRequestID(r), vol.Name(), object.Key, err1)
 			if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
@@ -895,6 +897,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		Owner:        userInfo.Posix,
 	}
 	start = time.Now()
 	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
@@ -1006,7 +1009,12 @@ func (o *ObjectNode) getBucketV1Handler(w http.ResponseWriter, r *http.Request)
 		OnlyObject: true,
 	}
 	start := time.Now()
-	result, err := vol.ListFilesV1(option)
+	var result *ListFilesV1Result
+	if sources := o.namespaceSources(param.Bucket()); sources != nil {
+		result, err = o.listNamespace(r, sources, option)
+	} else {
+		result, err = vol.ListFilesV1(option)
+	}
 	span.AppendTrackLog("file.l", start, err)
 	if err != nil {
 		log.LogErrorf("getBucketV1Handler: list files fail: requestID(%v) volume(%v) option(%v) err(%v)",
@@ -1168,7 +1176,12 @@ func (o *ObjectNode) getBucketV2Handler(w http.ResponseWriter, r *http.Request)
 		StartAfter: startAfter,
 	}
 	start := time.Now()
-	result, err := vol.ListFilesV2(option)
+	var result *ListFilesV2Result
+	if sources := o.namespaceSources(param.Bucket()); sources != nil {
+		result, err = o.listNamespaceV2(r, sources, option)
+	} else {
+		result, err = vol.ListFilesV2(option)
+	}
 	span.AppendTrackLog("file.l", start, err)
 	if err != nil {
 		log.LogErrorf("getBucketV2Handler: list files fail, requestID(%v) volume(%v) option(%v) err(%v)",
@@ -1387,6 +1400,7 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		Owner:        userInfo.Posix,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(param.Object(), reader, opt)
@@ -1616,6 +1630,7 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          aclInfo,
 		ObjectLock:   objetLock,
+		Owner:        userInfo.Posix,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(key, reader, putOpt)
@@ -1719,9 +1734,16 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
 		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
 
+	owner, err := o.getPosixIdentity(param)
+	if err != nil {
+		log.LogErrorf("deleteObjectHandler: get posix identity fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), err)
+		return
+	}
+
 	// Delete file
 	start := time.Now()
-	err = vol.DeletePath(param.Object())
+	err = vol.DeletePathBy(param.Object(), owner)
 	span.AppendTrackLog("file.d", start, err)
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
@@ -167,7 +167,7 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 			auth, err := NewAuth(r)
 			if err != nil && err == MissingSecurityElement {
 				// anonymous request will be authed in policy and acl check step
-				next.ServeHTTP(w, r)
+				o.serveAnonymous(w, r, next)
 				return
 			}
 			if err != nil {
@@ -202,6 +202,36 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 		})
 }
 
+// NamespaceMiddleware returns a middleware handler to route the object to the volume
+// it is mounted from, the authentication and policy are checked again on the volume.
+func (o *ObjectNode) namespaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if o.mounts == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			vars := mux.Vars(r)
+			volName, key, ok, err := o.mounts.Resolve(vars[ContextKeyBucket], vars[ContextKeyObject])
+			if err == nil && ok {
+				err = o.authorizeNamespace(r, volName, key)
+			}
+			if err != nil {
+				log.LogErrorf("namespaceMiddleware: requestID(%v) bucket(%v) object(%v) vol(%v) key(%v) err(%v)",
+					GetRequestID(r), vars[ContextKeyBucket], vars[ContextKeyObject], volName, key, err)
+				o.errorResponse(w, r, err, nil)
+				return
+			}
+			if ok {
+				log.LogDebugf("namespaceMiddleware: requestID(%v) bucket(%v) object(%v) routed to vol(%v) key(%v)",
+					GetRequestID(r), vars[ContextKeyBucket], vars[ContextKeyObject], volName, key)
+				vars[ContextKeyBucket] = volName
+				vars[ContextKeyObject] = key
+			}
+			next.ServeHTTP(w, r)
+		})
+}
+
 // ContentMiddleware returns a middleware handler to process reader for content.
 // If the request contains the "X-amz-Decoded-Content-Length" header, it means that the data
 // in the request body is chunked. Use ChunkedReader to parse the data.
@@ -233,6 +263,10 @@ func (o *ObjectNode) expectMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			// panic recover and response specific status to client
 			p := recover()
+			if p == http.ErrAbortHandler {
+				// the handler aborts the response written partially, let the server close the connection
+				panic(p)
+			}
 			if p != nil {
 				log.LogErrorf("panic(%v): requestID(%v) stack(%v)", p, GetRequestID(r), string(debug.Stack()))
 				w.WriteHeader(StatusServerPanic)
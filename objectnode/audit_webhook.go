@@ -15,12 +15,8 @@
 package objectnode
 
 import (
-	"bytes"
-	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"time"
 
 	"github.com/cubefs/cubefs/blobstore/util/retry"
 )
@@ -67,28 +63,7 @@ func (w *WebhookAudit) sendTest() error {
 }
 
 func (w *WebhookAudit) send(data []byte) error {
-	req, err := http.NewRequest(http.MethodPost, w.Endpoint, bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-	req.Header.Set(ContentType, ValueContentTypeJSON)
-	req.Header.Set(UserAgent, AuditWebhookUserAgent)
-	if w.Authorization != "" {
-		req.Header.Set(Authorization, w.Authorization)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	resp, err := w.client.Do(req.WithContext(ctx))
-	if resp != nil && resp.Body != nil {
-		io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
-	}
-	if err != nil || resp.StatusCode/100 == 2 {
-		return err
-	}
-
-	return fmt.Errorf("%s returns '%s' statuscode", w.Endpoint, resp.Status)
+	return w.Post(w.client, AuditWebhookUserAgent, data)
 }
 
 func (w *WebhookAudit) Name() string {
@@ -0,0 +1,103 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type BucketInfo struct {
+	Name       string
+	Owner      string
+	CreateTime time.Time
+}
+
+// BucketCache caches the infos of buckets fetched from master, such that listing
+// the buckets of a user neither loads all the volumes nor looks up master for
+// each of them. The entries are invalidated by the bucket events of master.
+type BucketCache struct {
+	router  *ClusterRouter
+	strict  bool
+	buckets map[string]*BucketInfo // mapping: bucket name -> *BucketInfo
+	mu      sync.RWMutex
+	sf      singleflight.Group
+}
+
+func NewBucketCache(router *ClusterRouter, strict bool) *BucketCache {
+	return &BucketCache{
+		router:  router,
+		strict:  strict,
+		buckets: make(map[string]*BucketInfo),
+	}
+}
+
+func (c *BucketCache) Get(bucket string) (info *BucketInfo, err error) {
+	if c.strict {
+		return c.load(bucket)
+	}
+	c.mu.RLock()
+	info = c.buckets[bucket]
+	c.mu.RUnlock()
+	if info != nil {
+		return
+	}
+	ret, err, _ := c.sf.Do(bucket, func() (interface{}, error) {
+		return c.load(bucket)
+	})
+	if err != nil {
+		return nil, err
+	}
+	info = ret.(*BucketInfo)
+	c.mu.Lock()
+	c.buckets[bucket] = info
+	c.mu.Unlock()
+	return
+}
+
+func (c *BucketCache) load(bucket string) (info *BucketInfo, err error) {
+	var view *proto.SimpleVolView
+	if view, err = c.router.Route(bucket).mc.AdminAPI().GetVolumeSimpleInfo(bucket); err != nil {
+		log.LogErrorf("BucketCache: get volume info fail: volume(%v) err(%v)", bucket, err)
+		return
+	}
+	if view.Status == 1 {
+		return nil, proto.ErrVolNotExists
+	}
+	info = &BucketInfo{Name: view.Name, Owner: view.Owner}
+	if info.CreateTime, err = time.ParseInLocation(proto.TimeFormat, view.CreateTime, time.Local); err != nil {
+		log.LogErrorf("BucketCache: parse create time fail: volume(%v) createTime(%v) err(%v)",
+			bucket, view.CreateTime, err)
+		return nil, err
+	}
+	return
+}
+
+func (c *BucketCache) Invalidate(bucket string) {
+	c.mu.Lock()
+	delete(c.buckets, bucket)
+	c.mu.Unlock()
+}
+
+func (c *BucketCache) Reset() {
+	c.mu.Lock()
+	c.buckets = make(map[string]*BucketInfo)
+	c.mu.Unlock()
+}
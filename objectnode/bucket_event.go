@@ -0,0 +1,146 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// BucketEventWatcher polls the bucket events of master and invalidates the cached
+// bucket infos, bucket configs and users by them, so that the caches stay coherent
+// with the cluster without looking up master in the request path.
+type BucketEventWatcher struct {
+	mc        *master.MasterClient
+	vm        *VolumeManager
+	buckets   *BucketCache
+	users     UserInfoStore
+	interval  time.Duration
+	epoch     int64
+	seq       uint64
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewBucketEventWatcher(mc *master.MasterClient, vm *VolumeManager, buckets *BucketCache,
+	users UserInfoStore, interval time.Duration,
+) *BucketEventWatcher {
+	w := &BucketEventWatcher{
+		mc:       mc,
+		vm:       vm,
+		buckets:  buckets,
+		users:    users,
+		interval: interval,
+		closeCh:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *BucketEventWatcher) run() {
+	t := time.NewTimer(w.interval)
+	for {
+		select {
+		case <-t.C:
+		case <-w.closeCh:
+			t.Stop()
+			return
+		}
+		for w.poll() {
+		}
+		t.Reset(w.interval)
+	}
+}
+
+// poll applies the events after the last polled one, it returns true if some
+// events are applied and there may be more of them.
+func (w *BucketEventWatcher) poll() bool {
+	view, err := w.mc.AdminAPI().ListBucketEvents(w.epoch, w.seq)
+	if err != nil {
+		log.LogWarnf("BucketEventWatcher: list bucket events fail: epoch(%v) seq(%v) err(%v)", w.epoch, w.seq, err)
+		return false
+	}
+	if view.Reset {
+		log.LogInfof("BucketEventWatcher: reset caches: epoch(%v) seq(%v) newEpoch(%v) newSeq(%v)",
+			w.epoch, w.seq, view.Epoch, view.Seq)
+		w.reset()
+		w.epoch, w.seq = view.Epoch, view.Seq
+		return false
+	}
+	for _, event := range view.Events {
+		log.LogDebugf("BucketEventWatcher: apply event: %+v", event)
+		w.apply(event)
+	}
+	w.seq = view.Seq
+	return len(view.Events) > 0
+}
+
+func (w *BucketEventWatcher) apply(event *proto.BucketEvent) {
+	switch event.Type {
+	case proto.BucketEventVolume:
+		w.invalidateBucket(event.Bucket)
+	case proto.BucketEventMeta:
+		if vol, loaded := w.vm.Loaded(event.Bucket); loaded {
+			vol.reloadOSSMeta()
+		}
+	}
+	if cache, ok := w.users.(*CacheUserInfoStore); ok && event.UserID != "" {
+		cache.InvalidateUser(event.UserID)
+	}
+}
+
+func (w *BucketEventWatcher) invalidateBucket(bucket string) {
+	w.buckets.Invalidate(bucket)
+	if cache, ok := w.users.(*CacheUserInfoStore); ok {
+		cache.InvalidateBucket(bucket)
+	}
+	vol, loaded := w.vm.Loaded(bucket)
+	if !loaded {
+		return
+	}
+	info, err := w.buckets.Get(bucket)
+	switch {
+	case err == proto.ErrVolNotExists:
+		w.vm.Release(bucket)
+	case err != nil:
+		log.LogWarnf("BucketEventWatcher: get bucket info fail: bucket(%v) err(%v)", bucket, err)
+	case info.Owner != vol.GetOwner():
+		// the volume is transferred to another user, reload it with the new owner
+		w.vm.Release(bucket)
+	default:
+		vol.reloadOSSMeta()
+	}
+}
+
+// reset drops all the caches since some events may be lost.
+func (w *BucketEventWatcher) reset() {
+	w.buckets.Reset()
+	if cache, ok := w.users.(*CacheUserInfoStore); ok {
+		cache.Reset()
+	}
+	for _, vol := range w.vm.LoadedVolumes() {
+		vol.reloadOSSMeta()
+	}
+}
+
+func (w *BucketEventWatcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+}
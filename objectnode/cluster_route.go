@@ -0,0 +1,256 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultClusterName = "default"
+	// the discovered routes are looked up again after the ttl, the buckets may be migrated
+	clusterRouteDiscoveredTTL = time.Minute * 10
+	clusterRouteNotFoundTTL   = volumeBlacklistTTL
+)
+
+// ClusterConfig is a backing cluster of the buckets, the region is reported by the
+// master of the cluster if it is not configured.
+type ClusterConfig struct {
+	Name    string   `json:"name"`
+	Masters []string `json:"masterAddr"`
+	Region  string   `json:"region"`
+}
+
+// BucketRouteConfig routes the buckets matching the pattern to the cluster, the pattern
+// is a shell pattern such as "logs-*".
+type BucketRouteConfig struct {
+	Bucket  string `json:"bucket"`
+	Cluster string `json:"cluster"`
+}
+
+// ClusterRoutingConfig is the config of routing the buckets to the clusters.
+type ClusterRoutingConfig struct {
+	Clusters []*ClusterConfig     `json:"clusters"`
+	Buckets  []*BucketRouteConfig `json:"buckets"`
+	Discover bool                 `json:"discover"`
+}
+
+type BackendCluster struct {
+	name    string
+	region  string
+	masters []string
+	mc      *master.MasterClient
+}
+
+func (c *BackendCluster) String() string {
+	return c.name
+}
+
+type bucketRoute struct {
+	pattern string
+	cluster *BackendCluster
+}
+
+type routeCacheEntry struct {
+	cluster  *BackendCluster
+	expireAt time.Time
+}
+
+// ClusterRouter routes the buckets to the backing clusters, so that a single endpoint
+// fronts several clusters during the migrations or for the data placement. The bucket
+// is the volume of the same name in the cluster, it is routed by the first matching
+// rule, then by the cluster it was found in if discovery is enabled, otherwise it is
+// on the default cluster configured by masterAddr.
+type ClusterRouter struct {
+	clusters []*BackendCluster // the default cluster first
+	byName   map[string]*BackendCluster
+	routes   []*bucketRoute
+	discover bool
+	cache    sync.Map // mapping: bucket -> *routeCacheEntry
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func NewClusterRouter(masters []string, cfg *ClusterRoutingConfig) (*ClusterRouter, error) {
+	def := &BackendCluster{name: defaultClusterName, masters: masters, mc: master.NewMasterClient(masters, false)}
+	r := &ClusterRouter{
+		clusters: []*BackendCluster{def},
+		byName:   map[string]*BackendCluster{def.name: def},
+		closeCh:  make(chan struct{}),
+	}
+	if cfg == nil {
+		return r, nil
+	}
+	for _, cc := range cfg.Clusters {
+		if cc.Name == "" || len(cc.Masters) == 0 {
+			return nil, fmt.Errorf("cluster name and masterAddr are required: %+v", cc)
+		}
+		if _, ok := r.byName[cc.Name]; ok {
+			return nil, fmt.Errorf("duplicated cluster: %v", cc.Name)
+		}
+		c := &BackendCluster{name: cc.Name, region: cc.Region, masters: cc.Masters, mc: master.NewMasterClient(cc.Masters, false)}
+		r.clusters = append(r.clusters, c)
+		r.byName[c.name] = c
+	}
+	for _, rc := range cfg.Buckets {
+		c, ok := r.byName[rc.Cluster]
+		if !ok {
+			return nil, fmt.Errorf("bucket route %v to unknown cluster %v", rc.Bucket, rc.Cluster)
+		}
+		if _, err := path.Match(rc.Bucket, ""); err != nil {
+			return nil, fmt.Errorf("invalid bucket pattern %v: %v", rc.Bucket, err)
+		}
+		r.routes = append(r.routes, &bucketRoute{pattern: rc.Bucket, cluster: c})
+	}
+	r.discover = cfg.Discover && len(r.clusters) > 1
+	if r.discover {
+		go r.cacheCleanup()
+	}
+	return r, nil
+}
+
+func (r *ClusterRouter) cacheCleanup() {
+	t := time.NewTimer(volumeBlacklistCleanupInterval)
+	for {
+		select {
+		case <-t.C:
+		case <-r.closeCh:
+			t.Stop()
+			return
+		}
+		now := time.Now()
+		r.cache.Range(func(key, value interface{}) bool {
+			if entry, is := value.(*routeCacheEntry); !is || now.After(entry.expireAt) {
+				r.cache.Delete(key)
+			}
+			return true
+		})
+		t.Reset(volumeBlacklistCleanupInterval)
+	}
+}
+
+func (r *ClusterRouter) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closeCh)
+	})
+}
+
+// initRegions sets the regions not configured by the cluster names reported by the masters.
+func (r *ClusterRouter) initRegions(defaultRegion string) {
+	for _, c := range r.clusters {
+		if c.region != "" {
+			continue
+		}
+		if c.name == defaultClusterName {
+			c.region = defaultRegion
+			continue
+		}
+		ci, err := c.mc.AdminAPI().GetClusterInfo()
+		if err != nil {
+			log.LogWarnf("initRegions: get cluster info fail: cluster(%v) err(%v)", c.name, err)
+			continue
+		}
+		c.region = ci.Cluster
+		log.LogInfof("initRegions: cluster(%v) region(%v)", c.name, c.region)
+	}
+}
+
+func (r *ClusterRouter) Default() *BackendCluster {
+	return r.clusters[0]
+}
+
+func (r *ClusterRouter) Clusters() []*BackendCluster {
+	return r.clusters
+}
+
+func (r *ClusterRouter) matchRoute(bucket string) *BackendCluster {
+	for _, route := range r.routes {
+		if matched, _ := path.Match(route.pattern, bucket); matched {
+			return route.cluster
+		}
+	}
+	return nil
+}
+
+// Route returns the cluster of the bucket.
+func (r *ClusterRouter) Route(bucket string) *BackendCluster {
+	if c := r.matchRoute(bucket); c != nil {
+		return c
+	}
+	if !r.discover {
+		return r.Default()
+	}
+	if val, ok := r.cache.Load(bucket); ok {
+		entry := val.(*routeCacheEntry)
+		if time.Now().Before(entry.expireAt) {
+			return entry.cluster
+		}
+	}
+	c, ttl := r.lookup(bucket)
+	r.cache.Store(bucket, &routeCacheEntry{cluster: c, expireAt: time.Now().Add(ttl)})
+	return c
+}
+
+// lookup finds the cluster having the volume of the bucket, the default cluster first.
+func (r *ClusterRouter) lookup(bucket string) (*BackendCluster, time.Duration) {
+	for _, c := range r.clusters {
+		view, err := c.mc.AdminAPI().GetVolumeSimpleInfo(bucket)
+		if err == nil && view.Status != 1 {
+			log.LogDebugf("ClusterRouter: discover bucket(%v) on cluster(%v)", bucket, c.name)
+			return c, clusterRouteDiscoveredTTL
+		}
+		if err != nil && err != proto.ErrVolNotExists {
+			log.LogWarnf("ClusterRouter: lookup bucket fail: bucket(%v) cluster(%v) err(%v)", bucket, c.name, err)
+		}
+	}
+	return r.Default(), clusterRouteNotFoundTTL
+}
+
+// RouteToCreate returns the cluster to create the bucket in, a non-empty location
+// chooses the cluster of the region unless the bucket matches a rule.
+func (r *ClusterRouter) RouteToCreate(bucket, location string) (*BackendCluster, bool) {
+	if c := r.matchRoute(bucket); c != nil {
+		return c, location == "" || location == c.region
+	}
+	if location == "" {
+		return r.Default(), true
+	}
+	for _, c := range r.clusters {
+		if c.region == location {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Pin routes the bucket to the cluster it is just created in.
+func (r *ClusterRouter) Pin(bucket string, c *BackendCluster) {
+	if r.discover {
+		r.cache.Store(bucket, &routeCacheEntry{cluster: c, expireAt: time.Now().Add(clusterRouteDiscoveredTTL)})
+	}
+}
+
+// Forget drops the discovered route of the bucket.
+func (r *ClusterRouter) Forget(bucket string) {
+	r.cache.Delete(bucket)
+}
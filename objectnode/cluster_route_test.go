@@ -0,0 +1,105 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMaster(t *testing.T, volumes map[string]bool, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		reply := &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: proto.ErrVolNotExists.Error()}
+		if r.URL.Path == proto.AdminGetVol && volumes[r.URL.Query().Get("name")] {
+			reply = &proto.HTTPReply{Code: proto.ErrCodeSuccess, Data: &proto.SimpleVolView{Name: r.URL.Query().Get("name")}}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(reply))
+	}))
+}
+
+func TestClusterRouterRules(t *testing.T) {
+	_, err := NewClusterRouter([]string{"m0"}, &ClusterRoutingConfig{
+		Buckets: []*BucketRouteConfig{{Bucket: "logs-*", Cluster: "unknown"}},
+	})
+	require.Error(t, err)
+	_, err = NewClusterRouter([]string{"m0"}, &ClusterRoutingConfig{
+		Clusters: []*ClusterConfig{{Name: defaultClusterName, Masters: []string{"m1"}}},
+	})
+	require.Error(t, err)
+
+	router, err := NewClusterRouter([]string{"m0"}, &ClusterRoutingConfig{
+		Clusters: []*ClusterConfig{{Name: "hdd", Masters: []string{"m1"}, Region: "cn-hdd"}},
+		Buckets:  []*BucketRouteConfig{{Bucket: "logs-*", Cluster: "hdd"}},
+	})
+	require.NoError(t, err)
+	defer router.Close()
+	router.initRegions("cn-default")
+
+	require.Equal(t, "hdd", router.Route("logs-2023").name)
+	require.Equal(t, []string{"m1"}, router.Route("logs-2023").masters)
+	require.Equal(t, defaultClusterName, router.Route("images").name)
+	require.Equal(t, "cn-default", router.Default().region)
+
+	cluster, ok := router.RouteToCreate("images", "cn-hdd")
+	require.True(t, ok)
+	require.Equal(t, "hdd", cluster.name)
+	cluster, ok = router.RouteToCreate("images", "")
+	require.True(t, ok)
+	require.Equal(t, defaultClusterName, cluster.name)
+	_, ok = router.RouteToCreate("images", "cn-unknown")
+	require.False(t, ok)
+	// the rules win over the location
+	_, ok = router.RouteToCreate("logs-2023", "cn-default")
+	require.False(t, ok)
+}
+
+func TestClusterRouterDiscover(t *testing.T) {
+	var defaultCalls, hddCalls int32
+	defaultMaster := newTestMaster(t, map[string]bool{"images": true}, &defaultCalls)
+	defer defaultMaster.Close()
+	hddMaster := newTestMaster(t, map[string]bool{"archive": true}, &hddCalls)
+	defer hddMaster.Close()
+
+	router, err := NewClusterRouter([]string{strings.TrimPrefix(defaultMaster.URL, "http://")}, &ClusterRoutingConfig{
+		Clusters: []*ClusterConfig{{Name: "hdd", Masters: []string{strings.TrimPrefix(hddMaster.URL, "http://")}}},
+		Discover: true,
+	})
+	require.NoError(t, err)
+	defer router.Close()
+
+	require.Equal(t, defaultClusterName, router.Route("images").name)
+	require.Equal(t, "hdd", router.Route("archive").name)
+	require.Equal(t, defaultClusterName, router.Route("missing").name)
+	calls := atomic.LoadInt32(&defaultCalls) + atomic.LoadInt32(&hddCalls)
+
+	// the routes are cached
+	require.Equal(t, "hdd", router.Route("archive").name)
+	require.Equal(t, defaultClusterName, router.Route("missing").name)
+	require.Equal(t, calls, atomic.LoadInt32(&defaultCalls)+atomic.LoadInt32(&hddCalls))
+
+	hdd := router.byName["hdd"]
+	router.Pin("missing", hdd)
+	require.Equal(t, "hdd", router.Route("missing").name)
+	router.Forget("missing")
+	require.Equal(t, defaultClusterName, router.Route("missing").name)
+}
@@ -38,6 +38,7 @@ const (
 	Range              = "Range"
 	Expect             = "Expect"
 	XForwardedExpect   = "X-Forwarded-Expect"
+	XForwardedProto    = "X-Forwarded-Proto"
 	Location           = "Location"
 	CacheControl       = "Cache-Control"
 	Expires            = "Expires"
@@ -45,6 +46,7 @@ const (
 	Signature          = "Signature"
 	Origin             = "Origin"
 	UserAgent          = "User-Agent"
+	RetryAfter         = "Retry-After"
 
 	AccessControlRequestMethod    = "Access-Control-Request-Method"
 	AccessControlRequestHeaders   = "Access-Control-Request-Headers"
@@ -116,6 +118,8 @@ const (
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
 
+	ParamArchiveFormat = "format"
+
 	ParamMaxParts       = "max-parts"
 	ParamUploadIdMarker = "upload-id-marker"
 	ParamPartNoMarker   = "part-number-marker"
@@ -155,6 +159,11 @@ const (
 	XAttrKeyOSSCacheControl = "oss:cache"
 	XAttrKeyOSSExpires      = "oss:expires"
 
+	XAttrKeyOSSSoftDelete    = "oss:softdelete"
+	XAttrKeyOSSSoftDeleteKey = "oss:softdelete:key"
+
+	XAttrKeyOSSVerify = "oss:verify"
+
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
 )
@@ -123,6 +123,7 @@ func (o *ObjectNode) putBucketCorsHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 	vol.metaLoader.storeCORS(corsConfig)
+	o.reportBucketEvent(vol.Name())
 
 	return
 }
@@ -154,6 +155,7 @@ func (o *ObjectNode) deleteBucketCorsHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	vol.metaLoader.storeCORS(nil)
+	o.reportBucketEvent(vol.Name())
 
 	w.WriteHeader(http.StatusNoContent)
 	return
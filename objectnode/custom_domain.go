@@ -0,0 +1,241 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// the manifest of the domain bindings in the cert store
+	customDomainManifest = "domains.json"
+
+	defaultCustomDomainReloadIntervalSec = 60
+	// warn the certificates expiring in the period on reloading
+	customDomainCertExpiryWarning = 7 * 24 * time.Hour
+)
+
+// CustomDomainConfig is the config of serving the buckets by the custom domains.
+type CustomDomainConfig struct {
+	TLSListen         string `json:"tlsListen"`
+	CertStore         string `json:"certStore"`
+	ReloadIntervalSec int    `json:"reloadIntervalSec"`
+}
+
+// DomainBinding binds the custom domain to the bucket, the certificate and the key files
+// are relative to the cert store if not absolute.
+type DomainBinding struct {
+	Domain   string `json:"domain"`
+	Bucket   string `json:"bucket"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type customDomain struct {
+	bucket   string
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+// CustomDomains serves the buckets by the custom domains bound in the cert store. The manifest
+// and the certificates are reloaded periodically, so that the bindings are changed and the
+// certificates are rotated without restarting.
+type CustomDomains struct {
+	dir       string
+	interval  time.Duration
+	domains   atomic.Value // map[string]*customDomain
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewCustomDomains(dir string, interval time.Duration) (*CustomDomains, error) {
+	d := &CustomDomains{
+		dir:      dir,
+		interval: interval,
+		closeCh:  make(chan struct{}),
+	}
+	d.domains.Store(make(map[string]*customDomain))
+	if err := d.reload(); err != nil {
+		return nil, err
+	}
+	go d.run()
+	return d, nil
+}
+
+func (d *CustomDomains) run() {
+	t := time.NewTimer(d.interval)
+	for {
+		select {
+		case <-t.C:
+		case <-d.closeCh:
+			t.Stop()
+			return
+		}
+		if err := d.reload(); err != nil {
+			log.LogWarnf("CustomDomains: reload fail: dir(%v) err(%v)", d.dir, err)
+		}
+		t.Reset(d.interval)
+	}
+}
+
+func (d *CustomDomains) path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(d.dir, file)
+}
+
+func (d *CustomDomains) Close() {
+	d.closeOnce.Do(func() {
+		close(d.closeCh)
+	})
+}
+
+// reload loads the manifest and the certificates changed since the last reload. The domain
+// keeps the loaded certificate if the new one is invalid, and the error of the manifest
+// keeps all the bindings.
+func (d *CustomDomains) reload() error {
+	data, err := os.ReadFile(filepath.Join(d.dir, customDomainManifest))
+	if err != nil {
+		return err
+	}
+	var bindings []*DomainBinding
+	if err = json.Unmarshal(data, &bindings); err != nil {
+		return fmt.Errorf("invalid %v: %v", customDomainManifest, err)
+	}
+
+	old := d.domains.Load().(map[string]*customDomain)
+	domains := make(map[string]*customDomain, len(bindings))
+	for _, b := range bindings {
+		domain := strings.ToLower(b.Domain)
+		if domain == "" || b.Bucket == "" {
+			return fmt.Errorf("invalid %v: domain(%v) bucket(%v)", customDomainManifest, b.Domain, b.Bucket)
+		}
+		if _, ok := domains[domain]; ok {
+			return fmt.Errorf("invalid %v: duplicated domain(%v)", customDomainManifest, b.Domain)
+		}
+		entry := &customDomain{bucket: b.Bucket}
+		domains[domain] = entry
+		if b.CertFile == "" && b.KeyFile == "" {
+			// served by http only
+			continue
+		}
+		entry.certFile, entry.keyFile = d.path(b.CertFile), d.path(b.KeyFile)
+		prev := old[domain]
+		if prev != nil && prev.certFile == entry.certFile && prev.keyFile == entry.keyFile {
+			entry.cert, entry.modTime = prev.cert, prev.modTime
+		}
+		if err = entry.loadCert(); err != nil {
+			log.LogErrorf("CustomDomains: load certificate fail: domain(%v) cert(%v) key(%v) err(%v)",
+				domain, entry.certFile, entry.keyFile, err)
+		}
+	}
+
+	for domain, entry := range domains {
+		if prev, ok := old[domain]; !ok || prev.bucket != entry.bucket {
+			log.LogInfof("CustomDomains: bind domain(%v) to bucket(%v)", domain, entry.bucket)
+		}
+	}
+	for domain, prev := range old {
+		if _, ok := domains[domain]; !ok {
+			log.LogInfof("CustomDomains: unbind domain(%v) from bucket(%v)", domain, prev.bucket)
+		}
+	}
+	d.domains.Store(domains)
+	return nil
+}
+
+// loadCert loads the certificate if the files are modified, the loaded certificate is kept on error.
+func (c *customDomain) loadCert() error {
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if c.cert != nil && !modTime.After(c.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	}
+	if time.Until(cert.Leaf.NotAfter) < customDomainCertExpiryWarning {
+		log.LogWarnf("CustomDomains: certificate expires soon: cert(%v) notAfter(%v)", c.certFile, cert.Leaf.NotAfter)
+	}
+	log.LogInfof("CustomDomains: load certificate: cert(%v) notAfter(%v)", c.certFile, cert.Leaf.NotAfter)
+	c.cert, c.modTime = &cert, modTime
+	return nil
+}
+
+func (d *CustomDomains) lookup(host string) *customDomain {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return d.domains.Load().(map[string]*customDomain)[strings.ToLower(host)]
+}
+
+// Bucket returns the bucket bound to the domain of the host.
+func (d *CustomDomains) Bucket(host string) (bucket string, ok bool) {
+	if entry := d.lookup(host); entry != nil {
+		return entry.bucket, true
+	}
+	return "", false
+}
+
+// GetCertificate returns the certificate of the custom domain for the tls handshake.
+func (d *CustomDomains) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if entry := d.lookup(hello.ServerName); entry != nil && entry.cert != nil {
+		return entry.cert, nil
+	}
+	return nil, fmt.Errorf("no certificate for server name %v", hello.ServerName)
+}
+
+// customDomainMatcher matches the requests to the custom domains, and sets the bucket bound
+// to the domain as the bucket of the request.
+func (o *ObjectNode) customDomainMatcher(r *http.Request, match *mux.RouteMatch) bool {
+	bucket, ok := o.customDomains.Bucket(r.Host)
+	if !ok {
+		return false
+	}
+	if match.Vars == nil {
+		match.Vars = make(map[string]string)
+	}
+	match.Vars[ContextKeyBucket] = bucket
+	return true
+}
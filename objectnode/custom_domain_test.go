@@ -0,0 +1,150 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, dir, name, domain string, serial int64, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func writeTestBindings(t *testing.T, dir string, bindings []*DomainBinding) {
+	data, err := json.Marshal(bindings)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, customDomainManifest), data, 0o600))
+}
+
+func TestCustomDomains(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeTestCert(t, dir, "images", "images.example.com", 1, now)
+	writeTestBindings(t, dir, []*DomainBinding{
+		{Domain: "images.example.com", Bucket: "photos", CertFile: "images.crt", KeyFile: "images.key"},
+		{Domain: "static.example.com", Bucket: "assets"},
+	})
+
+	d, err := NewCustomDomains(dir, time.Hour)
+	require.NoError(t, err)
+	defer d.Close()
+
+	bucket, ok := d.Bucket("Images.Example.com:443")
+	require.True(t, ok)
+	require.Equal(t, "photos", bucket)
+	bucket, ok = d.Bucket("static.example.com")
+	require.True(t, ok)
+	require.Equal(t, "assets", bucket)
+	_, ok = d.Bucket("example.com")
+	require.False(t, ok)
+
+	cert, err := d.GetCertificate(&tls.ClientHelloInfo{ServerName: "images.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), cert.Leaf.SerialNumber.Int64())
+	_, err = d.GetCertificate(&tls.ClientHelloInfo{ServerName: "static.example.com"})
+	require.Error(t, err)
+
+	// rotate the certificate
+	writeTestCert(t, dir, "images", "images.example.com", 2, now.Add(time.Minute))
+	require.NoError(t, d.reload())
+	cert, err = d.GetCertificate(&tls.ClientHelloInfo{ServerName: "images.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+
+	// the invalid certificate keeps the loaded one
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images.crt"), []byte("invalid"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "images.crt"), now.Add(2*time.Minute), now.Add(2*time.Minute)))
+	require.NoError(t, d.reload())
+	cert, err = d.GetCertificate(&tls.ClientHelloInfo{ServerName: "images.example.com"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+
+	// the invalid manifest keeps the bindings
+	require.NoError(t, os.WriteFile(filepath.Join(dir, customDomainManifest), []byte("["), 0o600))
+	require.Error(t, d.reload())
+	_, ok = d.Bucket("static.example.com")
+	require.True(t, ok)
+
+	writeTestBindings(t, dir, []*DomainBinding{{Domain: "images.example.com", Bucket: "photos2"}})
+	require.NoError(t, d.reload())
+	bucket, _ = d.Bucket("images.example.com")
+	require.Equal(t, "photos2", bucket)
+	_, ok = d.Bucket("static.example.com")
+	require.False(t, ok)
+
+	writeTestBindings(t, dir, []*DomainBinding{{Domain: "a.example.com", Bucket: "a"}, {Domain: "A.example.com", Bucket: "b"}})
+	require.Error(t, d.reload())
+}
+
+func TestCustomDomainMatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeTestBindings(t, dir, []*DomainBinding{{Domain: "images.example.com", Bucket: "photos"}})
+	d, err := NewCustomDomains(dir, time.Hour)
+	require.NoError(t, err)
+	defer d.Close()
+	o := &ObjectNode{customDomains: d}
+
+	var vars map[string]string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		vars = mux.Vars(r)
+	}
+	router := mux.NewRouter()
+	bRouter := router.PathPrefix("/").Subrouter()
+	bRouter.MatcherFunc(o.customDomainMatcher).Subrouter().Path("/{object:.+}").HandlerFunc(handler)
+	bRouter.PathPrefix("/{bucket}").Subrouter().Path("/{object:.+}").HandlerFunc(handler)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://images.example.com/a/b.jpg", nil))
+	require.Equal(t, "photos", vars[ContextKeyBucket])
+	require.Equal(t, "a/b.jpg", vars[ContextKeyObject])
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://object.cube.io/a/b.jpg", nil))
+	require.Equal(t, "a", vars[ContextKeyBucket])
+	require.Equal(t, "b.jpg", vars[ContextKeyObject])
+}
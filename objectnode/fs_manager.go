@@ -20,7 +20,6 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
-	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -31,7 +30,7 @@ const (
 )
 
 type VolumeLoader struct {
-	masters    []string
+	router     *ClusterRouter
 	store      Store              // Storage for ACP management
 	volumes    map[string]*Volume // mapping: volume name -> *Volume
 	volMu      sync.RWMutex
@@ -77,6 +76,24 @@ func (loader *VolumeLoader) Release(volName string) {
 	}
 }
 
+// Loaded returns the volume only if it has been loaded.
+func (loader *VolumeLoader) Loaded(volName string) (*Volume, bool) {
+	loader.volMu.RLock()
+	defer loader.volMu.RUnlock()
+	vol, has := loader.volumes[volName]
+	return vol, has
+}
+
+func (loader *VolumeLoader) LoadedVolumes() []*Volume {
+	loader.volMu.RLock()
+	defer loader.volMu.RUnlock()
+	vols := make([]*Volume, 0, len(loader.volumes))
+	for _, vol := range loader.volumes {
+		vols = append(vols, vol)
+	}
+	return vols
+}
+
 func (loader *VolumeLoader) Volume(volName string) (*Volume, error) {
 	return loader.loadVolume(volName)
 }
@@ -124,7 +141,7 @@ func (loader *VolumeLoader) loadVolumeWithoutBlacklist(volName string) (*Volume,
 		}
 		config := &VolumeConfig{
 			Volume:           volName,
-			Masters:          loader.masters,
+			Masters:          loader.router.Route(volName).masters,
 			Store:            loader.store,
 			OnAsyncTaskError: onAsyncTaskError,
 			MetaStrict:       loader.metaStrict,
@@ -189,7 +206,7 @@ func (loader *VolumeLoader) loadVolume(volName string) (*Volume, error) {
 		}
 		config := &VolumeConfig{
 			Volume:           volName,
-			Masters:          loader.masters,
+			Masters:          loader.router.Route(volName).masters,
 			Store:            loader.store,
 			OnAsyncTaskError: onAsyncTaskError,
 			MetaStrict:       loader.metaStrict,
@@ -231,9 +248,9 @@ func (loader *VolumeLoader) Close() {
 	})
 }
 
-func NewVolumeLoader(masters []string, store Store, strict bool) *VolumeLoader {
+func NewVolumeLoader(router *ClusterRouter, store Store, strict bool) *VolumeLoader {
 	loader := &VolumeLoader{
-		masters:    masters,
+		router:     router,
 		store:      store,
 		volumes:    make(map[string]*Volume),
 		closeCh:    make(chan struct{}),
@@ -244,8 +261,7 @@ func NewVolumeLoader(masters []string, store Store, strict bool) *VolumeLoader {
 }
 
 type VolumeManager struct {
-	masters    []string
-	mc         *master.MasterClient
+	router     *ClusterRouter
 	loaders    [volumeLoaderNum]*VolumeLoader
 	store      Store
 	metaStrict bool
@@ -266,6 +282,18 @@ func (m *VolumeManager) VolumeWithoutBlacklist(volName string) (*Volume, error)
 	return m.selectLoader(volName).VolumeWithoutBlacklist(volName)
 }
 
+func (m *VolumeManager) Loaded(volName string) (*Volume, bool) {
+	return m.selectLoader(volName).Loaded(volName)
+}
+
+func (m *VolumeManager) LoadedVolumes() []*Volume {
+	vols := make([]*Volume, 0)
+	for _, loader := range m.loaders {
+		vols = append(vols, loader.LoadedVolumes()...)
+	}
+	return vols
+}
+
 // Release all
 func (m *VolumeManager) Close() {
 	m.closeOnce.Do(func() {
@@ -284,13 +312,13 @@ func (m *VolumeManager) init() {
 		vm: m,
 	}
 	for i := 0; i < len(m.loaders); i++ {
-		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict)
+		m.loaders[i] = NewVolumeLoader(m.router, m.store, m.metaStrict)
 	}
 }
 
-func NewVolumeManager(masters []string, strict bool) *VolumeManager {
+func NewVolumeManager(router *ClusterRouter, strict bool) *VolumeManager {
 	manager := &VolumeManager{
-		masters:    masters,
+		router:     router,
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
 	}
@@ -0,0 +1,177 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	completeMultipartWorkers = 32   // the parts whose extents are fetched concurrently
+	completeMultipartWindow  = 512  // the parts fetched but not linked yet, which bounds the memory
+	completeMultipartBatch   = 4096 // the extent keys appended to the complete inode in one request
+)
+
+// partExtents is the extents of a part, either the extent keys or the object extent keys of the
+// cold volumes.
+type partExtents struct {
+	size   uint64 // the size of the part inode
+	eks    []proto.ExtentKey
+	objEks []proto.ObjExtentKey
+}
+
+// verify checks the extents of the part cover the part as uploaded, so that the object is not
+// completed from a part being overwritten or truncated.
+func (pe *partExtents) verify(part *proto.MultipartPartInfo) bool {
+	var total uint64
+	for _, ek := range pe.eks {
+		total += uint64(ek.Size)
+	}
+	for _, ek := range pe.objEks {
+		total += ek.Size
+	}
+	return pe.size == part.Size && total == part.Size
+}
+
+// multipartLinker links the extents of the parts to the complete inode, the extents are fetched by
+// the workers in parallel and appended in the order of the parts with the offsets recomputed.
+type multipartLinker struct {
+	workers      int
+	window       int
+	batch        int
+	fetch        func(part *proto.MultipartPartInfo) (*partExtents, error)
+	appendEks    func(eks []proto.ExtentKey) error
+	appendObjEks func(eks []proto.ObjExtentKey) error
+}
+
+func (v *Volume) newMultipartLinker(inode uint64, cold bool) *multipartLinker {
+	return &multipartLinker{
+		workers: completeMultipartWorkers,
+		window:  completeMultipartWindow,
+		batch:   completeMultipartBatch,
+		fetch: func(part *proto.MultipartPartInfo) (pe *partExtents, err error) {
+			pe = new(partExtents)
+			if cold {
+				_, pe.size, _, pe.objEks, err = v.mw.GetObjExtents(part.Inode)
+			} else {
+				_, pe.size, pe.eks, err = v.mw.GetExtents(part.Inode)
+			}
+			return
+		},
+		appendEks: func(eks []proto.ExtentKey) error {
+			return v.mw.AppendExtentKeys(inode, eks)
+		},
+		appendObjEks: func(eks []proto.ObjExtentKey) error {
+			return v.mw.AppendObjExtentKeys(inode, eks)
+		},
+	}
+}
+
+// link returns the size of the complete inode, InvalidPart if any part fails the verification.
+func (l *multipartLinker) link(parts []*proto.MultipartPartInfo) (size uint64, err error) {
+	results := make([]*partExtents, len(parts))
+	errs := make([]error, len(parts))
+	doneCs := make([]chan struct{}, len(parts))
+	for i := range doneCs {
+		doneCs[i] = make(chan struct{})
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	stopC := make(chan struct{})
+	defer close(stopC)
+	// the window is released by the linker once a part is linked
+	windowC := make(chan struct{}, l.window)
+	taskC := make(chan int)
+	go func() {
+		defer close(taskC)
+		for i := range parts {
+			select {
+			case windowC <- struct{}{}:
+			case <-stopC:
+				return
+			}
+			select {
+			case taskC <- i:
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	for w := 0; w < l.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range taskC {
+				results[i], errs[i] = l.fetch(parts[i])
+				close(doneCs[i])
+			}
+		}()
+	}
+
+	eks := make([]proto.ExtentKey, 0)
+	objEks := make([]proto.ObjExtentKey, 0)
+	flush := func() error {
+		if len(eks) > 0 {
+			if err := l.appendEks(eks); err != nil {
+				return err
+			}
+			eks = eks[:0]
+		}
+		if len(objEks) > 0 {
+			if err := l.appendObjEks(objEks); err != nil {
+				return err
+			}
+			objEks = objEks[:0]
+		}
+		return nil
+	}
+	for i, part := range parts {
+		<-doneCs[i]
+		pe := results[i]
+		results[i] = nil
+		if errs[i] != nil {
+			log.LogErrorf("multipartLinker: get extents fail: partID(%v) inode(%v) err(%v)", part.ID, part.Inode, errs[i])
+			return 0, errs[i]
+		}
+		if !pe.verify(part) {
+			log.LogErrorf("multipartLinker: part verify fail: partID(%v) inode(%v) size(%v) inodeSize(%v)",
+				part.ID, part.Inode, part.Size, pe.size)
+			return 0, InvalidPart
+		}
+		for _, ek := range pe.eks {
+			ek.FileOffset = size
+			size += uint64(ek.Size)
+			eks = append(eks, ek)
+		}
+		for _, ek := range pe.objEks {
+			ek.FileOffset = size
+			size += ek.Size
+			objEks = append(objEks, ek)
+		}
+		<-windowC
+		if len(eks)+len(objEks) >= l.batch {
+			if err = flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err = flush(); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMultipartLinker(parts []*proto.MultipartPartInfo, appended *[]proto.ExtentKey) (*multipartLinker, *int32) {
+	var fetching, maxFetching int32
+	sizes := make(map[uint64]uint64)
+	for _, part := range parts {
+		sizes[part.Inode] = part.Size
+	}
+	l := &multipartLinker{
+		workers: 8,
+		window:  16,
+		batch:   10,
+		fetch: func(part *proto.MultipartPartInfo) (*partExtents, error) {
+			n := atomic.AddInt32(&fetching, 1)
+			defer atomic.AddInt32(&fetching, -1)
+			for {
+				m := atomic.LoadInt32(&maxFetching)
+				if n <= m || atomic.CompareAndSwapInt32(&maxFetching, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+			if part.Inode == 0 {
+				return nil, errors.New("get extents fail")
+			}
+			size := sizes[part.Inode]
+			// the part of two extents
+			return &partExtents{size: size, eks: []proto.ExtentKey{
+				{PartitionId: part.Inode, ExtentId: 1, Size: uint32(size / 2)},
+				{PartitionId: part.Inode, ExtentId: 2, Size: uint32(size - size/2)},
+			}}, nil
+		},
+		appendEks: func(eks []proto.ExtentKey) error {
+			*appended = append(*appended, eks...)
+			return nil
+		},
+	}
+	return l, &maxFetching
+}
+
+func TestMultipartLinker(t *testing.T) {
+	parts := make([]*proto.MultipartPartInfo, 0)
+	var total uint64
+	for i := 1; i <= 1000; i++ {
+		part := &proto.MultipartPartInfo{ID: uint16(i), Inode: uint64(i), Size: uint64(i * 3)}
+		total += part.Size
+		parts = append(parts, part)
+	}
+	var appended []proto.ExtentKey
+	l, maxFetching := newTestMultipartLinker(parts, &appended)
+	size, err := l.link(parts)
+	require.NoError(t, err)
+	require.Equal(t, total, size)
+	require.LessOrEqual(t, *maxFetching, int32(l.workers))
+
+	// linked in the order of the parts with the offsets recomputed
+	require.Len(t, appended, 2*len(parts))
+	var offset uint64
+	for i, ek := range appended {
+		require.Equal(t, uint64(i/2+1), ek.PartitionId)
+		require.Equal(t, offset, ek.FileOffset)
+		offset += uint64(ek.Size)
+	}
+
+	// the part not matching the uploaded size
+	appended = nil
+	l, _ = newTestMultipartLinker(parts, &appended)
+	invalid := *parts[500]
+	invalid.Size++
+	invalidParts := append(append(append([]*proto.MultipartPartInfo{}, parts[:500]...), &invalid), parts[501:]...)
+	_, err = l.link(invalidParts)
+	require.Equal(t, InvalidPart, err)
+	require.True(t, len(appended) <= 1000)
+
+	// the part failing to get the extents
+	appended = nil
+	l, _ = newTestMultipartLinker(parts, &appended)
+	invalid = *parts[10]
+	invalid.Inode = 0
+	invalidParts = append(append(append([]*proto.MultipartPartInfo{}, parts[:10]...), &invalid), parts[11:]...)
+	_, err = l.link(invalidParts)
+	require.Error(t, err)
+}
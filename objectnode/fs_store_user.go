@@ -71,6 +71,34 @@ func (s *CacheUserInfoStore) LoadUser(accessKey string) (*proto.UserInfo, error)
 	return s.selectLoader(accessKey).LoadUser(accessKey)
 }
 
+// InvalidateUser drops the cached user info of the user.
+func (s *CacheUserInfoStore) InvalidateUser(userID string) {
+	for _, loader := range s.loaders {
+		loader.invalidate(func(userInfo *proto.UserInfo) bool {
+			return userInfo.UserID == userID
+		})
+	}
+}
+
+// InvalidateBucket drops the cached user infos that own or are authorized to the bucket.
+func (s *CacheUserInfoStore) InvalidateBucket(bucket string) {
+	for _, loader := range s.loaders {
+		loader.invalidate(func(userInfo *proto.UserInfo) bool {
+			if userInfo.Policy == nil {
+				return false
+			}
+			_, authorized := userInfo.Policy.AuthorizedVols[bucket]
+			return authorized || userInfo.Policy.IsOwn(bucket)
+		})
+	}
+}
+
+func (s *CacheUserInfoStore) Reset() {
+	for _, loader := range s.loaders {
+		loader.invalidate(func(*proto.UserInfo) bool { return true })
+	}
+}
+
 func NewUserInfoStore(masters []string, strict bool) UserInfoStore {
 	mc := master.NewMasterClient(masters, false)
 	if strict {
@@ -234,6 +262,17 @@ func (us *CacheUserInfoLoader) LoadUser(accessKey string) (*proto.UserInfo, erro
 	return userInfo, nil
 }
 
+func (us *CacheUserInfoLoader) invalidate(match func(userInfo *proto.UserInfo) bool) {
+	us.akInfoMutex.Lock()
+	for ak, userInfo := range us.akInfoStore {
+		if match(userInfo) {
+			delete(us.akInfoStore, ak)
+			log.LogDebugf("invalidate: release user info: accessKey(%v) userID(%v)", ak, userInfo.UserID)
+		}
+	}
+	us.akInfoMutex.Unlock()
+}
+
 func (us *CacheUserInfoLoader) Close() {
 	us.akInfoMutex.Lock()
 	defer us.akInfoMutex.Unlock()
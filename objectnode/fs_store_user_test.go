@@ -0,0 +1,58 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheUserInfoStoreInvalidate(t *testing.T) {
+	store := &CacheUserInfoStore{}
+	for i := range store.loaders {
+		store.loaders[i] = &CacheUserInfoLoader{akInfoStore: make(map[string]*proto.UserInfo)}
+	}
+	cached := func(ak string) bool {
+		loader := store.selectLoader(ak)
+		_, has := loader.akInfoStore[ak]
+		return has
+	}
+	add := func(ak, userID string, own []string, authorized ...string) {
+		policy := proto.NewUserPolicy()
+		policy.OwnVols = own
+		for _, vol := range authorized {
+			policy.AuthorizedVols[vol] = []string{proto.BuiltinPermissionReadOnly.String()}
+		}
+		store.selectLoader(ak).akInfoStore[ak] = &proto.UserInfo{AccessKey: ak, UserID: userID, Policy: policy}
+	}
+
+	add("ak1", "user1", []string{"vol1"})
+	add("ak2", "user2", nil, "vol1")
+	add("ak3", "user3", []string{"vol2"})
+
+	store.InvalidateBucket("vol1")
+	require.False(t, cached("ak1"))
+	require.False(t, cached("ak2"))
+	require.True(t, cached("ak3"))
+
+	store.InvalidateUser("user3")
+	require.False(t, cached("ak3"))
+
+	add("ak1", "user1", []string{"vol1"})
+	store.Reset()
+	require.False(t, cached("ak1"))
+}
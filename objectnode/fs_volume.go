@@ -92,6 +92,64 @@ type PutFileOption struct {
 	CacheControl string
 	Expires      string
 	ObjectLock   *ObjectLockConfig
+	Owner        *proto.PosixIdentity
+}
+
+// posixOwner returns the uid and gid that the created file is owned by, which
+// is the POSIX identity the requester is mapped to in master, or root.
+func (opt *PutFileOption) posixOwner() (uid, gid uint32) {
+	if opt == nil || opt.Owner == nil {
+		return 0, 0
+	}
+	return opt.Owner.Uid, opt.Owner.Gid
+}
+
+func (opt *PutFileOption) posixIdentity() *proto.PosixIdentity {
+	if opt == nil {
+		return nil
+	}
+	return opt.Owner
+}
+
+// the permission bits checked against the POSIX identities
+const (
+	posixPermRead  uint32 = 4
+	posixPermWrite uint32 = 2
+	posixPermExec  uint32 = 1
+)
+
+// posixAllowed returns true if the POSIX identity is allowed to access the inode by the
+// permission bits of the mask, the requester without the identity acts as root.
+func posixAllowed(identity *proto.PosixIdentity, info *proto.InodeInfo, mask uint32) bool {
+	if identity == nil || identity.Uid == 0 {
+		return true
+	}
+	perm := info.Mode & uint32(os.ModePerm)
+	switch {
+	case info.Uid == identity.Uid:
+		perm >>= 6
+	case identity.InGroup(info.Gid):
+		perm >>= 3
+	}
+	return perm&mask == mask
+}
+
+// checkPosixAccess returns AccessDenied if the POSIX identity is not allowed to access the
+// inode by the permission bits of the mask.
+func (v *Volume) checkPosixAccess(identity *proto.PosixIdentity, ino uint64, mask uint32) (err error) {
+	if identity == nil || identity.Uid == 0 {
+		return
+	}
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(ino); err != nil {
+		return
+	}
+	if !posixAllowed(identity, info, mask) {
+		log.LogWarnf("checkPosixAccess: access denied: volume(%v) inode(%v) mode(%v) uid(%v) gid(%v) identity(%+v) mask(%v)",
+			v.name, ino, os.FileMode(info.Mode), info.Uid, info.Gid, identity, mask)
+		return AccessDenied
+	}
+	return
 }
 
 type ListFilesV1Option struct {
@@ -157,10 +215,14 @@ func (v *Volume) GetOwner() string {
 func (v *Volume) syncOSSMeta() {
 	v.ticker = time.NewTicker(OSSMetaUpdateDuration)
 	defer v.ticker.Stop()
+	purgeTicker := time.NewTicker(SoftDeletePurgeInterval)
+	defer purgeTicker.Stop()
 	for {
 		select {
 		case <-v.ticker.C:
 			v.loadOSSMeta()
+		case <-purgeTicker.C:
+			v.purgeSoftDeleted()
 		case <-v.closeCh:
 			return
 		}
@@ -198,9 +260,23 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeObjectLock(objectlock)
+
+	var softDelete *SoftDeleteConfiguration
+	if softDelete, err = v.loadSoftDelete(); err != nil {
+		return
+	}
+	v.metaLoader.storeSoftDelete(softDelete)
 	v.metaLoader.setSynced()
 }
 
+// reloadOSSMeta reloads the cached bucket configs, it does nothing if the
+// configs are not cached.
+func (v *Volume) reloadOSSMeta() {
+	if _, cached := v.metaLoader.(*cacheMetaLoader); cached {
+		v.loadOSSMeta()
+	}
+}
+
 func (v *Volume) Name() string {
 	return v.name
 }
@@ -277,6 +353,21 @@ func (v *Volume) loadObjectLock() (configuration *ObjectLockConfig, err error) {
 	return configuration, nil
 }
 
+func (v *Volume) loadSoftDelete() (configuration *SoftDeleteConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSSoftDelete); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &SoftDeleteConfiguration{}
+	if err = json.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -629,7 +720,7 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		return fsInfo, nil
 	}
 	var parentId uint64
-	if parentId, err = v.recursiveMakeDirectory(fixedPath); err != nil {
+	if parentId, err = v.recursiveMakeDirectoryBy(fixedPath, opt.posixIdentity()); err != nil {
 		log.LogErrorf("PutObject: recursive make directory fail: volume(%v) path(%v) err(%v)",
 			v.name, path, err)
 		return
@@ -678,12 +769,16 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 			return
 		}
 	}
+	if err = v.checkPosixAccess(opt.posixIdentity(), parentId, posixPermWrite|posixPermExec); err != nil {
+		return
+	}
 
 	// Intermediate data during the writing of new versions is managed through invisible files.
 	// This file has only inode but no dentry. In this way, this temporary file can be made invisible
 	// in the true sense. In order to avoid the adverse impact of other user operations on temporary data.
 	var invisibleTempDataInode *proto.InodeInfo
-	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), fixedPath); err != nil {
+	uid, gid := opt.posixOwner()
+	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, uid, gid, nil, make([]uint64, 0), fixedPath); err != nil {
 		log.LogErrorf("PutObject: inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
 		return
 	}
@@ -861,6 +956,11 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 // This method will only returns internal system errors.
 // This method will not return syscall.ENOENT error
 func (v *Volume) DeletePath(path string) (err error) {
+	return v.DeletePathBy(path, nil)
+}
+
+// DeletePathBy deletes the path by the POSIX identity, which must be allowed to write the parent.
+func (v *Volume) DeletePathBy(path string, owner *proto.PosixIdentity) (err error) {
 	defer func() {
 		// Audit behavior
 		log.LogInfof("Audit: DeletePath: volume(%v) path(%v), err(%v)", v.name, path, err)
@@ -872,6 +972,10 @@ func (v *Volume) DeletePath(path string) (err error) {
 			err = nil
 		}
 	}()
+	if isSoftDeleteReservedKey(path) {
+		err = syscall.ENOENT
+		return
+	}
 	var parent uint64
 	var ino uint64
 	var name string
@@ -883,6 +987,9 @@ func (v *Volume) DeletePath(path string) (err error) {
 	}
 	log.LogDebugf("DeletePath: lookup target: path(%v) parentID(%v) inode(%v) name(%v) mode(%v)",
 		path, parent, ino, name, mode)
+	if err = v.checkPosixAccess(owner, parent, posixPermWrite|posixPermExec); err != nil {
+		return
+	}
 	if mode.IsDir() {
 		// Check if the directory is empty and cannot delete non-empty directories.
 		var dentries []proto.Dentry
@@ -897,6 +1004,25 @@ func (v *Volume) DeletePath(path string) (err error) {
 		log.LogErrorf("DeletePath: load volume objetLock: volume(%v) err(%v)", v.name, err)
 		return
 	}
+	// move the object into the soft delete dir if soft delete is enabled
+	softDelete, err := v.metaLoader.loadSoftDelete()
+	if err != nil {
+		log.LogErrorf("DeletePath: load volume soft delete: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if softDelete.IsEnabled() && !mode.IsDir() {
+		if objetLock != nil {
+			if err = isObjectLocked(v, ino, name, path); err != nil {
+				return
+			}
+		}
+		if err = v.softDeletePath(parent, ino, name, path); err != nil {
+			return
+		}
+		deleteDentryCache(parent, name, v.name)
+		deleteAttrCache(parent, v.name)
+		return
+	}
 	log.LogInfof("DeletePath: delete: volume(%v) path(%v) inode(%v)", v.name, path, ino)
 
 	// delete dentry with condition when objectlock is open
@@ -966,7 +1092,7 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 
 	if v.mw.EnableQuota {
 		var parentId uint64
-		if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		if parentId, err = v.recursiveMakeDirectoryBy(path, opt.posixIdentity()); err != nil {
 			log.LogErrorf("InitMultipart: recursive make dir fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 				v.name, path, multipartID, err)
 			return
@@ -1127,7 +1253,8 @@ func (v *Volume) AbortMultipart(path string, multipartID string) (err error) {
 	return nil
 }
 
-func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo, discardedPartInodes map[uint64]uint16) (fsFileInfo *FSFileInfo, err error) {
+func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *proto.MultipartInfo,
+	discardedPartInodes map[uint64]uint16, owner *proto.PosixIdentity) (fsFileInfo *FSFileInfo, err error) {
 	defer func() {
 		log.LogInfof("Audit: CompleteMultipart: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
@@ -1138,7 +1265,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		filename  = pathItems[len(pathItems)-1].Name
 		parentId  uint64
 	)
-	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+	if parentId, err = v.recursiveMakeDirectoryBy(path, owner); err != nil {
 		log.LogErrorf("CompleteMultipart: recursive make dir fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
 		return
@@ -1168,12 +1295,19 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 			return
 		}
 	}
+	if err = v.checkPosixAccess(owner, parentId, posixPermWrite|posixPermExec); err != nil {
+		return
+	}
 	parts := multipartInfo.Parts
 	sort.SliceStable(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
 
 	// create inode for complete data
 	var completeInodeInfo *proto.InodeInfo
-	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+	var uid, gid uint32
+	if owner != nil {
+		uid, gid = owner.Uid, owner.Gid
+	}
+	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, uid, gid, nil, make([]uint64, 0), path); err != nil {
 		log.LogErrorf("CompleteMultipart: meta inode create fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
 		return
@@ -1191,52 +1325,12 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		}
 	}()
 
-	// merge complete extent keys
+	// link the extents of the parts to the complete inode
 	var size uint64
-	var fileOffset uint64
-	if proto.IsCold(v.volType) {
-		completeObjExtentKeys := make([]proto.ObjExtentKey, 0)
-		for _, part := range parts {
-			var objExtents []proto.ObjExtentKey
-			if _, _, _, objExtents, err = v.mw.GetObjExtents(part.Inode); err != nil {
-				log.LogErrorf("CompleteMultipart: meta get objextents fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
-					v.name, path, multipartID, part.ID, part.Inode, err)
-				return
-			}
-			for _, ek := range objExtents {
-				ek.FileOffset = fileOffset
-				fileOffset += ek.Size
-				completeObjExtentKeys = append(completeObjExtentKeys, ek)
-			}
-			size += part.Size
-		}
-		if err = v.mw.AppendObjExtentKeys(completeInodeInfo.Inode, completeObjExtentKeys); err != nil {
-			log.LogErrorf("CompleteMultipart: meta append extent keys fail: volume(%v) path(%v) multipartID(%v) inode(%v) err(%v)",
-				v.name, path, multipartID, completeInodeInfo.Inode, err)
-			return
-		}
-	} else {
-		completeExtentKeys := make([]proto.ExtentKey, 0)
-		for _, part := range parts {
-			var eks []proto.ExtentKey
-			if _, _, eks, err = v.mw.GetExtents(part.Inode); err != nil {
-				log.LogErrorf("CompleteMultipart: meta get extents fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
-					v.name, path, multipartID, part.ID, part.Inode, err)
-				return
-			}
-			// recompute offsets of extent keys
-			for _, ek := range eks {
-				ek.FileOffset = fileOffset
-				fileOffset += uint64(ek.Size)
-				completeExtentKeys = append(completeExtentKeys, ek)
-			}
-			size += part.Size
-		}
-		if err = v.mw.AppendExtentKeys(completeInodeInfo.Inode, completeExtentKeys); err != nil {
-			log.LogErrorf("CompleteMultipart: meta append extent keys fail: volume(%v) path(%v) multipartID(%v) inode(%v) err(%v)",
-				v.name, path, multipartID, completeInodeInfo.Inode, err)
-			return
-		}
+	if size, err = v.newMultipartLinker(completeInodeInfo.Inode, proto.IsCold(v.volType)).link(parts); err != nil {
+		log.LogErrorf("CompleteMultipart: link parts fail: volume(%v) path(%v) multipartID(%v) inode(%v) err(%v)",
+			v.name, path, multipartID, completeInodeInfo.Inode, err)
+		return
 	}
 
 	// compute md5 hash
@@ -1974,6 +2068,12 @@ func deleteAttrCache(inode uint64, volName string) {
 }
 
 func (v *Volume) recursiveMakeDirectory(path string) (partentIno uint64, err error) {
+	return v.recursiveMakeDirectoryBy(path, nil)
+}
+
+// recursiveMakeDirectoryBy makes the directories of the path owned by the POSIX identity, which
+// must be allowed to write the parents of the directories created.
+func (v *Volume) recursiveMakeDirectoryBy(path string, owner *proto.PosixIdentity) (partentIno uint64, err error) {
 	// in case of any mv or rename operation within refresh interval of dentry item in cache,
 	// recursiveMakeDirectory don't look up cache, and will force update dentry item
 	partentIno = rootIno
@@ -1996,8 +2096,15 @@ func (v *Volume) recursiveMakeDirectory(path string) (partentIno uint64, err err
 			return
 		}
 		if err == syscall.ENOENT {
+			if err = v.checkPosixAccess(owner, partentIno, posixPermWrite|posixPermExec); err != nil {
+				return
+			}
+			var uid, gid uint32
+			if owner != nil {
+				uid, gid = owner.Uid, owner.Gid
+			}
 			var info *proto.InodeInfo
-			info, err = v.mw.Create_ll(partentIno, pathItem.Name, uint32(DefaultDirMode), 0, 0, nil, path[:pathIterator.cursor])
+			info, err = v.mw.Create_ll(partentIno, pathItem.Name, uint32(DefaultDirMode), uid, gid, nil, path[:pathIterator.cursor])
 			if err != nil && err == syscall.EEXIST {
 				existInode, mode, e := v.mw.Lookup_ll(partentIno, pathItem.Name)
 				if e != nil {
@@ -2193,6 +2300,10 @@ func (v *Volume) findParentId(prefix string) (inode uint64, prefixDirs []string,
 	if len(dirs) <= 1 {
 		return proto.RootIno, prefixDirs, nil
 	}
+	// the soft deleted objects are invisible
+	if dirs[0] == SoftDeleteDirName {
+		return 0, nil, syscall.ENOENT
+	}
 
 	parentId := proto.RootIno
 	for index, dir := range dirs {
@@ -2302,6 +2413,10 @@ readDir:
 		if child.Name == lastKey {
 			continue
 		}
+		// the soft deleted objects are invisible
+		if len(dirs) == 0 && child.Name == SoftDeleteDirName {
+			continue
+		}
 		path := strings.Join(append(dirs, child.Name), pathSep)
 		if os.FileMode(child.Type).IsDir() {
 			path += pathSep
@@ -2596,6 +2711,10 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		log.LogErrorf("CopyFile: get source path inode info fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
+	if !posixAllowed(opt.posixIdentity(), sInodeInfo, posixPermRead) {
+		log.LogWarnf("CopyFile: source access denied: source path(%v) identity(%+v)", sourcePath, opt.posixIdentity())
+		return nil, AccessDenied
+	}
 	if sInodeInfo.Size > MaxCopyObjectSize {
 		log.LogErrorf("CopyFile: copy source path file size greater than 5GB, source path(%v), target path(%v)", sourcePath, targetPath)
 		return nil, syscall.EFBIG
@@ -2703,7 +2822,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		if !strings.HasSuffix(targetPath, pathSep) {
 			targetPath += pathSep
 		}
-		if tParentId, err = v.recursiveMakeDirectory(targetPath); err != nil {
+		if tParentId, err = v.recursiveMakeDirectoryBy(targetPath, opt.posixIdentity()); err != nil {
 			log.LogErrorf("CopyFile: recursive make directory of target path fail: volume(%v) target path(%v) err(%v)",
 				v.name, targetPath, err)
 			return
@@ -2726,7 +2845,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		return info, nil
 	}
 	// recursive create target directory, and get parent id and last name
-	if tParentId, err = v.recursiveMakeDirectory(targetPath); err != nil {
+	if tParentId, err = v.recursiveMakeDirectoryBy(targetPath, opt.posixIdentity()); err != nil {
 		log.LogErrorf("CopyFile: recursive make target path directory fail: volume(%v) path(%v) err(%v)",
 			v.name, targetPath, err)
 		return
@@ -2746,9 +2865,13 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			return
 		}
 	}
+	if err = v.checkPosixAccess(opt.posixIdentity(), tParentId, posixPermWrite|posixPermExec); err != nil {
+		return
+	}
 
 	// create target file inode and set target inode to be source file inode
-	if tInodeInfo, err = v.mw.InodeCreate_ll(tParentId, uint32(sMode), 0, 0, nil, make([]uint64, 0), targetPath); err != nil {
+	uid, gid := opt.posixOwner()
+	if tInodeInfo, err = v.mw.InodeCreate_ll(tParentId, uint32(sMode), uid, gid, nil, make([]uint64, 0), targetPath); err != nil {
 		return
 	}
 	defer func() {
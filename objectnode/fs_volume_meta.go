@@ -26,10 +26,12 @@ type ossMetaLoader interface {
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCORS() (cors *CORSConfiguration, err error)
 	loadObjectLock() (config *ObjectLockConfig, err error)
+	loadSoftDelete() (config *SoftDeleteConfiguration, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCORS(cors *CORSConfiguration)
 	storeObjectLock(config *ObjectLockConfig)
+	storeSoftDelete(config *SoftDeleteConfiguration)
 	setSynced()
 }
 
@@ -50,10 +52,12 @@ type OSSMeta struct {
 	acl        *AccessControlPolicy
 	corsConfig *CORSConfiguration
 	lockConfig *ObjectLockConfig
+	softDelete *SoftDeleteConfiguration
 	policyLock sync.RWMutex
 	aclLock    sync.RWMutex
 	corsLock   sync.RWMutex
 	objectLock sync.RWMutex
+	deleteLock sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -156,6 +160,31 @@ func (c *cacheMetaLoader) storeObjectLock(config *ObjectLockConfig) {
 	return
 }
 
+func (c *cacheMetaLoader) loadSoftDelete() (config *SoftDeleteConfiguration, err error) {
+	c.om.deleteLock.RLock()
+	config = c.om.softDelete
+	c.om.deleteLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSSoftDelete, func() (interface{}, error) {
+			sd, err := c.sml.loadSoftDelete()
+			return sd, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*SoftDeleteConfiguration)
+		c.storeSoftDelete(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeSoftDelete(config *SoftDeleteConfiguration) {
+	c.om.deleteLock.Lock()
+	c.om.softDelete = config
+	c.om.deleteLock.Unlock()
+	return
+}
+
 func (c *cacheMetaLoader) setSynced() {
 	atomic.StoreInt32(c.synced, 1)
 }
@@ -192,6 +221,14 @@ func (s *strictMetaLoader) storeObjectLock(cors *ObjectLockConfig) {
 	// do nothing
 }
 
+func (s *strictMetaLoader) loadSoftDelete() (config *SoftDeleteConfiguration, err error) {
+	return s.v.loadSoftDelete()
+}
+
+func (s *strictMetaLoader) storeSoftDelete(config *SoftDeleteConfiguration) {
+	// do nothing
+}
+
 func (s *strictMetaLoader) setSynced() {
 	// do nothing
 }
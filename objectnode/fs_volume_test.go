@@ -13,3 +13,30 @@
 // permissions and limitations under the License.
 
 package objectnode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosixAllowed(t *testing.T) {
+	dir := &proto.InodeInfo{Mode: uint32(os.ModeDir | 0o750), Uid: 1000, Gid: 100}
+	rw := posixPermWrite | posixPermExec
+
+	// the requesters without the identity and the root act as root
+	require.True(t, posixAllowed(nil, dir, rw))
+	require.True(t, posixAllowed(&proto.PosixIdentity{Uid: 0, Gid: 1}, dir, rw))
+
+	require.True(t, posixAllowed(&proto.PosixIdentity{Uid: 1000, Gid: 1}, dir, rw))
+	require.False(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 100}, dir, rw))
+	require.True(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 100}, dir, posixPermRead|posixPermExec))
+	require.True(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 1, Groups: []uint32{100}}, dir, posixPermRead))
+	require.False(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 1}, dir, posixPermRead))
+
+	file := &proto.InodeInfo{Mode: 0o604, Uid: 1000, Gid: 100}
+	require.False(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 100}, file, posixPermRead))
+	require.True(t, posixAllowed(&proto.PosixIdentity{Uid: 1001, Gid: 1}, file, posixPermRead))
+}
@@ -43,7 +43,7 @@ func (o *ObjectNode) getBucketLifecycleConfigurationHandler(w http.ResponseWrite
 	}
 
 	var lcConf *proto.LcConfiguration
-	if lcConf, err = o.mc.AdminAPI().GetBucketLifecycle(param.Bucket()); err != nil {
+	if lcConf, err = o.router.Route(param.Bucket()).mc.AdminAPI().GetBucketLifecycle(param.Bucket()); err != nil {
 		log.LogErrorf("getBucketLifecycle failed: bucket[%v] err(%v)", param.Bucket(), err)
 		errorCode = NoSuchLifecycleConfiguration
 		return
@@ -159,7 +159,7 @@ func (o *ObjectNode) putBucketLifecycleConfigurationHandler(w http.ResponseWrite
 		req.Rules = append(req.Rules, rule)
 	}
 
-	if err = o.mc.AdminAPI().SetBucketLifecycle(&req); err != nil {
+	if err = o.router.Route(param.Bucket()).mc.AdminAPI().SetBucketLifecycle(&req); err != nil {
 		log.LogErrorf("putBucketLifecycle failed: SetBucketLifecycle err: bucket[%v] err(%v)", param.Bucket(), err)
 		return
 	}
@@ -187,7 +187,7 @@ func (o *ObjectNode) deleteBucketLifecycleConfigurationHandler(w http.ResponseWr
 		return
 	}
 
-	if err = o.mc.AdminAPI().DelBucketLifecycle(param.Bucket()); err != nil {
+	if err = o.router.Route(param.Bucket()).mc.AdminAPI().DelBucketLifecycle(param.Bucket()); err != nil {
 		log.LogErrorf("deleteBucketLifecycle failed: bucket[%v] err(%v)", param.Bucket(), err)
 		return
 	}
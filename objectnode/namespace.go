@@ -0,0 +1,402 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+)
+
+// NamespaceMounts routes the objects to the volumes by the mount map of master, the
+// object "<bucket>/<key>" under a prefix mounted from another volume is served by the
+// volume, so that a dataset spans several volumes behind one bucket.
+type NamespaceMounts struct {
+	mc        *master.MasterClient
+	interval  time.Duration
+	view      atomic.Value // *proto.MountMapView
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewNamespaceMounts(mc *master.MasterClient, interval time.Duration) *NamespaceMounts {
+	n := &NamespaceMounts{
+		mc:       mc,
+		interval: interval,
+		closeCh:  make(chan struct{}),
+	}
+	n.view.Store(&proto.MountMapView{})
+	n.refresh()
+	go n.run()
+	return n
+}
+
+func (n *NamespaceMounts) run() {
+	t := time.NewTimer(n.interval)
+	for {
+		select {
+		case <-t.C:
+		case <-n.closeCh:
+			t.Stop()
+			return
+		}
+		n.refresh()
+		t.Reset(n.interval)
+	}
+}
+
+func (n *NamespaceMounts) refresh() {
+	view, err := n.mc.ClientAPI().GetMountMap()
+	if err != nil {
+		log.LogWarnf("NamespaceMounts: get mount map fail: err(%v)", err)
+		return
+	}
+	if old := n.view.Load().(*proto.MountMapView); old.Version != view.Version {
+		log.LogInfof("NamespaceMounts: update mount map: version(%v) newVersion(%v) entries(%v)",
+			old.Version, view.Version, len(view.Entries))
+	}
+	n.view.Store(view)
+}
+
+func (n *NamespaceMounts) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closeCh)
+	})
+}
+
+// Resolve returns the volume and the key of the object if it is mounted from
+// another place than the bucket itself. The keys of the buckets in the mount map
+// with the empty, "." or ".." segments are rejected rather than cleaned, so that
+// they never cross the mount points.
+func (n *NamespaceMounts) Resolve(bucket, object string) (volName, key string, ok bool, err error) {
+	if bucket == "" || object == "" {
+		return
+	}
+	view := n.view.Load().(*proto.MountMapView)
+	if !isNamespaceBucket(view, bucket) {
+		return
+	}
+	if !validNamespaceKey(object) {
+		return "", "", false, InvalidKey
+	}
+	entry, volPath, ok := view.Resolve("/" + bucket + "/" + object)
+	if !ok {
+		return
+	}
+	if key = strings.TrimPrefix(volPath, "/"); key == "" {
+		// the root of the mounted directory is not an object
+		return "", "", false, nil
+	}
+	if strings.HasSuffix(object, "/") {
+		key += "/"
+	}
+	if entry.VolName == bucket && key == object {
+		return "", "", false, nil
+	}
+	return entry.VolName, key, true, nil
+}
+
+// namespaceSource is a volume listed under the bucket, the keys under dir of the
+// bucket are the ones under root of the volume.
+type namespaceSource struct {
+	volName string
+	dir     string // empty for the root of the bucket, else with the trailing slash
+	root    string // empty for the root of the volume, else with the trailing slash
+}
+
+func (s *namespaceSource) volKey(key string) string {
+	return s.root + strings.TrimPrefix(key, s.dir)
+}
+
+func (s *namespaceSource) bucketKey(volKey string) string {
+	return s.dir + strings.TrimPrefix(volKey, s.root)
+}
+
+// Sources returns the volumes listed under the bucket, the one serving the root of
+// the bucket first and then the ones mounted under it in order, or nil if the bucket
+// is not in the mount map.
+func (n *NamespaceMounts) Sources(bucket string) (sources []*namespaceSource) {
+	view := n.view.Load().(*proto.MountMapView)
+	if bucket == "" || !isNamespaceBucket(view, bucket) {
+		return nil
+	}
+	base := &namespaceSource{volName: bucket}
+	if entry, volPath, ok := view.Resolve("/" + bucket); ok {
+		base.volName, base.root = entry.VolName, namespaceDir(volPath)
+	}
+	sources = append(sources, base)
+	bucketPath := "/" + bucket + "/"
+	for _, e := range view.Entries {
+		if strings.HasPrefix(e.Prefix, bucketPath) {
+			sources = append(sources, &namespaceSource{
+				volName: e.VolName,
+				dir:     namespaceDir(strings.TrimPrefix(e.Prefix, bucketPath)),
+				root:    namespaceDir(e.SubDir),
+			})
+		}
+	}
+	sort.Slice(sources[1:], func(i, j int) bool {
+		return sources[i+1].dir < sources[j+1].dir
+	})
+	return
+}
+
+func isNamespaceBucket(view *proto.MountMapView, bucket string) bool {
+	if _, _, ok := view.Resolve("/" + bucket); ok {
+		return true
+	}
+	for _, e := range view.Entries {
+		if strings.HasPrefix(e.Prefix, "/"+bucket+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func validNamespaceKey(key string) bool {
+	for _, segment := range strings.Split(strings.TrimSuffix(key, "/"), "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func namespaceDir(p string) string {
+	if p = strings.Trim(p, "/"); p == "" {
+		return ""
+	}
+	return p + "/"
+}
+
+// authorizeNamespace authenticates the request and checks its policy again on the volume and
+// key it is routed to, the signature is still verified against the original request.
+func (o *ObjectNode) authorizeNamespace(r *http.Request, bucket, object string) (err error) {
+	vars := make(map[string]string, len(mux.Vars(r)))
+	for k, v := range mux.Vars(r) {
+		vars[k] = v
+	}
+	vars[ContextKeyBucket], vars[ContextKeyObject] = bucket, object
+	r = mux.SetURLVars(r, vars)
+
+	auth, err := NewAuth(r)
+	switch {
+	case err == MissingSecurityElement:
+		// anonymous request is checked by the policy
+	case err != nil:
+		return
+	default:
+		if err = o.validateAuthInfo(r, auth); err != nil {
+			return
+		}
+	}
+	action := ActionFromRouteName(mux.CurrentRoute(r).GetName())
+	if !action.IsNone() && o.signatureIgnoredActions.Contains(action) {
+		return nil
+	}
+	allowed, ec, err := o.checkPolicy(r)
+	switch {
+	case err != nil:
+		return err
+	case ec != nil:
+		return ec
+	case !allowed:
+		return AccessDenied
+	}
+	return nil
+}
+
+type namespaceEntry struct {
+	key  string
+	file *FSFileInfo // nil for the common prefix
+}
+
+// listNamespace lists the objects of the bucket merged with the ones of the volumes mounted
+// under it, the requester must be allowed to list all the volumes.
+func (o *ObjectNode) listNamespace(r *http.Request, sources []*namespaceSource, opt *ListFilesV1Option) (
+	result *ListFilesV1Result, err error) {
+	bucket := mux.Vars(r)[ContextKeyBucket]
+	return mergeNamespaceList(sources, opt, func(s *namespaceSource, volOpt *ListFilesV1Option) (*ListFilesV1Result, error) {
+		if s.volName != bucket {
+			if err := o.authorizeNamespace(r, s.volName, ""); err != nil {
+				log.LogWarnf("listNamespace: list mounted volume not allowed: requestID(%v) bucket(%v) volume(%v) err(%v)",
+					GetRequestID(r), bucket, s.volName, err)
+				return nil, err
+			}
+		}
+		vol, err := o.getVol(s.volName)
+		if err != nil {
+			log.LogErrorf("listNamespace: load volume fail: requestID(%v) bucket(%v) volume(%v) err(%v)",
+				GetRequestID(r), bucket, s.volName, err)
+			return nil, err
+		}
+		return vol.ListFilesV1(volOpt)
+	})
+}
+
+// mergeNamespaceList merges the listings of the sources. The keys under a mount point are listed
+// from the mounted volume only, and the mount points deeper than the level listed by the delimiter
+// are the common prefixes. A source listed partially limits the merged listing to its last key,
+// so that the keys of the other sources after it are listed in the next page in order.
+func mergeNamespaceList(sources []*namespaceSource, opt *ListFilesV1Option,
+	list func(s *namespaceSource, volOpt *ListFilesV1Option) (*ListFilesV1Result, error)) (
+	result *ListFilesV1Result, err error) {
+	owner := func(key string) *namespaceSource {
+		s := sources[0]
+		for _, src := range sources[1:] {
+			if strings.HasPrefix(key, src.dir) && len(src.dir) > len(s.dir) {
+				s = src
+			}
+		}
+		return s
+	}
+
+	var (
+		entries   []*namespaceEntry
+		truncated bool
+		limit     string // the last key listed from the truncated sources
+	)
+	for _, s := range sources {
+		if !strings.HasPrefix(opt.Prefix, s.dir) && !strings.HasPrefix(s.dir, opt.Prefix) {
+			continue
+		}
+		marker := ""
+		if opt.Marker != "" {
+			if strings.HasPrefix(opt.Marker, s.dir) {
+				marker = s.volKey(opt.Marker)
+			} else if opt.Marker > s.dir {
+				// all the keys of the source are listed
+				continue
+			}
+		}
+		volPrefix := s.root
+		if strings.HasPrefix(opt.Prefix, s.dir) {
+			volPrefix = s.volKey(opt.Prefix)
+		} else if opt.Delimiter != "" {
+			rest := strings.TrimPrefix(s.dir, opt.Prefix)
+			if i := strings.Index(rest, opt.Delimiter); i >= 0 {
+				entries = append(entries, &namespaceEntry{key: opt.Prefix + rest[:i+len(opt.Delimiter)]})
+				continue
+			}
+		}
+
+		var res *ListFilesV1Result
+		res, err = list(s, &ListFilesV1Option{
+			Prefix:     volPrefix,
+			Delimiter:  opt.Delimiter,
+			Marker:     marker,
+			MaxKeys:    opt.MaxKeys,
+			OnlyObject: opt.OnlyObject,
+		})
+		if err != nil {
+			return
+		}
+		last := ""
+		for _, file := range res.Files {
+			key := s.bucketKey(file.Path)
+			if key > last {
+				last = key
+			}
+			if owner(key) == s {
+				file.Path = key
+				entries = append(entries, &namespaceEntry{key: key, file: file})
+			}
+		}
+		for _, prefix := range res.CommonPrefixes {
+			key := s.bucketKey(prefix)
+			if key > last {
+				last = key
+			}
+			if owner(key) == s {
+				entries = append(entries, &namespaceEntry{key: key})
+			}
+		}
+		if res.Truncated {
+			truncated = true
+			if limit == "" || last < limit {
+				limit = last
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	result = &ListFilesV1Result{}
+	count := uint64(0)
+	for i, entry := range entries {
+		if i > 0 && entry.key == entries[i-1].key {
+			continue
+		}
+		if (opt.Marker != "" && entry.key <= opt.Marker) || (limit != "" && entry.key > limit) {
+			continue
+		}
+		if count == opt.MaxKeys {
+			truncated = true
+			break
+		}
+		count++
+		if entry.file != nil {
+			result.Files = append(result.Files, entry.file)
+		} else {
+			result.CommonPrefixes = append(result.CommonPrefixes, entry.key)
+		}
+		result.NextMarker = entry.key
+	}
+	if result.Truncated = truncated; !truncated {
+		result.NextMarker = ""
+	}
+	return
+}
+
+func (o *ObjectNode) listNamespaceV2(r *http.Request, sources []*namespaceSource, opt *ListFilesV2Option) (
+	result *ListFilesV2Result, err error) {
+	marker := opt.StartAfter
+	if opt.ContToken != "" {
+		marker = opt.ContToken
+	}
+	res, err := o.listNamespace(r, sources, &ListFilesV1Option{
+		Prefix:     opt.Prefix,
+		Delimiter:  opt.Delimiter,
+		Marker:     marker,
+		MaxKeys:    opt.MaxKeys,
+		OnlyObject: true,
+	})
+	if err != nil {
+		return
+	}
+	return &ListFilesV2Result{
+		Files:          res.Files,
+		KeyCount:       uint64(len(res.Files)),
+		NextToken:      res.NextMarker,
+		Truncated:      res.Truncated,
+		CommonPrefixes: res.CommonPrefixes,
+	}, nil
+}
+
+func (o *ObjectNode) namespaceSources(bucket string) []*namespaceSource {
+	if o.mounts == nil {
+		return nil
+	}
+	return o.mounts.Sources(bucket)
+}
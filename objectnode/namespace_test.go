@@ -0,0 +1,149 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceMountsResolve(t *testing.T) {
+	view := &proto.MountMapView{Version: 1, Entries: []*proto.MountEntry{
+		{Prefix: "/datasets", VolName: "datasets"},
+		{Prefix: "/datasets/imagenet", VolName: "imagenet", SubDir: "/train"},
+		{Prefix: "/datasets/coco", VolName: "coco"},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := &proto.HTTPReply{Code: proto.ErrCodeSuccess, Data: view}
+		if r.URL.Path != proto.ClientMountMap {
+			reply = &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: "unknown path"}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(reply))
+	}))
+	defer server.Close()
+
+	mc := master.NewMasterClient([]string{strings.TrimPrefix(server.URL, "http://")}, false)
+	mounts := NewNamespaceMounts(mc, time.Minute)
+	defer mounts.Close()
+
+	volName, key, ok, err := mounts.Resolve("datasets", "imagenet/n01/a.jpg")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "imagenet", volName)
+	require.Equal(t, "train/n01/a.jpg", key)
+	volName, key, ok, err = mounts.Resolve("datasets", "imagenet/n01/")
+	require.True(t, ok)
+	require.Equal(t, "imagenet", volName)
+	require.Equal(t, "train/n01/", key)
+
+	// the objects of the bucket itself and the root of the mount are not routed
+	_, _, ok, _ = mounts.Resolve("datasets", "mnist/b.jpg")
+	require.False(t, ok)
+	_, _, ok, _ = mounts.Resolve("datasets", "coco")
+	require.False(t, ok)
+	_, _, ok, _ = mounts.Resolve("logs", "2023/c.log")
+	require.False(t, ok)
+	_, _, ok, _ = mounts.Resolve("datasets", "")
+	require.False(t, ok)
+
+	// the keys are not cleaned across the mount points
+	for _, object := range []string{"coco/../imagenet/a.jpg", "imagenet/./a.jpg", "imagenet//a.jpg", "/imagenet/a.jpg", ".."} {
+		_, _, ok, err = mounts.Resolve("datasets", object)
+		require.False(t, ok)
+		require.Equal(t, InvalidKey, err)
+	}
+	_, _, ok, err = mounts.Resolve("logs", "a/../b.log")
+	require.False(t, ok)
+	require.NoError(t, err)
+
+	sources := mounts.Sources("datasets")
+	require.Len(t, sources, 3)
+	require.Equal(t, namespaceSource{volName: "datasets"}, *sources[0])
+	require.Equal(t, namespaceSource{volName: "coco", dir: "coco/"}, *sources[1])
+	require.Equal(t, namespaceSource{volName: "imagenet", dir: "imagenet/", root: "train/"}, *sources[2])
+	require.Nil(t, mounts.Sources("logs"))
+}
+
+func TestMergeNamespaceList(t *testing.T) {
+	sources := []*namespaceSource{
+		{volName: "datasets"},
+		{volName: "imagenet", dir: "imagenet/", root: "train/"},
+	}
+	volumes := map[string][]string{
+		"datasets": {"a.txt", "imagenet/shadowed.jpg", "mnist/b.jpg", "z.txt"},
+		"imagenet": {"train/n01/a.jpg", "train/n02/b.jpg", "val/c.jpg"},
+	}
+	list := func(s *namespaceSource, opt *ListFilesV1Option) (*ListFilesV1Result, error) {
+		res := &ListFilesV1Result{}
+		for _, key := range volumes[s.volName] {
+			if !strings.HasPrefix(key, opt.Prefix) || key <= opt.Marker {
+				continue
+			}
+			if opt.Delimiter != "" {
+				if i := strings.Index(key[len(opt.Prefix):], opt.Delimiter); i >= 0 {
+					prefix := key[:len(opt.Prefix)+i+1]
+					if n := len(res.CommonPrefixes); n == 0 || res.CommonPrefixes[n-1] != prefix {
+						res.CommonPrefixes = append(res.CommonPrefixes, prefix)
+					}
+					continue
+				}
+			}
+			if uint64(len(res.Files)+len(res.CommonPrefixes)) == opt.MaxKeys {
+				res.Truncated = true
+				break
+			}
+			res.Files = append(res.Files, &FSFileInfo{Path: key})
+		}
+		return res, nil
+	}
+	keys := func(res *ListFilesV1Result) (keys []string) {
+		for _, file := range res.Files {
+			keys = append(keys, file.Path)
+		}
+		return
+	}
+
+	// the mount point is a common prefix, and the keys under it in the bucket are shadowed
+	res, err := mergeNamespaceList(sources, &ListFilesV1Option{Delimiter: "/", MaxKeys: 100}, list)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt", "z.txt"}, keys(res))
+	require.Equal(t, []string{"imagenet/", "mnist/"}, res.CommonPrefixes)
+
+	res, err = mergeNamespaceList(sources, &ListFilesV1Option{Prefix: "imagenet/", MaxKeys: 100}, list)
+	require.NoError(t, err)
+	require.Equal(t, []string{"imagenet/n01/a.jpg", "imagenet/n02/b.jpg"}, keys(res))
+
+	// the recursive listing is merged in order page by page
+	var all []string
+	marker := ""
+	for {
+		res, err = mergeNamespaceList(sources, &ListFilesV1Option{Marker: marker, MaxKeys: 2}, list)
+		require.NoError(t, err)
+		all = append(all, keys(res)...)
+		if !res.Truncated {
+			break
+		}
+		marker = res.NextMarker
+	}
+	require.Equal(t, []string{"a.txt", "imagenet/n01/a.jpg", "imagenet/n02/b.jpg", "mnist/b.jpg", "z.txt"}, all)
+}
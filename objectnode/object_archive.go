@@ -0,0 +1,288 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ArchiveFormatTar = "tar"
+	ArchiveFormatZip = "zip"
+
+	// the central directory of the zip archive is kept in memory until all the entries are
+	// written, so the entries of the zip archive are limited
+	DefaultArchiveMaxZipEntries = 100000
+
+	archiveListLimit = 1000
+)
+
+var ErrTooManyArchiveEntries = errors.New("too many archive entries")
+
+// ArchiveConfig is the config of the archive downloads. MaxStreams limits the archives streamed
+// by the objectnode at the same time, and MaxZipEntries limits the objects of a zip archive.
+type ArchiveConfig struct {
+	MaxStreams    int `json:"maxStreams"`
+	MaxZipEntries int `json:"maxZipEntries"`
+}
+
+// archiveTarget is the bucket archived.
+type archiveTarget interface {
+	listArchiveObjects(prefix, marker string, maxKeys uint64) (objects []*FSFileInfo, nextMarker string, err error)
+	readArchiveObject(object *FSFileInfo, writer io.Writer) error
+}
+
+// ObjectArchiver streams the objects under a prefix as a tar or zip archive. The objects are listed
+// by pages and read into the archive one by one, so the memory used by a stream is bounded by a
+// page of the listing, except the central directory of the zip archive.
+type ObjectArchiver struct {
+	conf    ArchiveConfig
+	streams chan struct{} // nil means unlimited
+}
+
+func NewObjectArchiver(conf ArchiveConfig) *ObjectArchiver {
+	if conf.MaxZipEntries <= 0 {
+		conf.MaxZipEntries = DefaultArchiveMaxZipEntries
+	}
+	a := &ObjectArchiver{conf: conf}
+	if conf.MaxStreams > 0 {
+		a.streams = make(chan struct{}, conf.MaxStreams)
+	}
+	return a
+}
+
+func (a *ObjectArchiver) acquire() bool {
+	if a.streams == nil {
+		return true
+	}
+	select {
+	case a.streams <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *ObjectArchiver) release() {
+	if a.streams != nil {
+		<-a.streams
+	}
+}
+
+func isValidArchiveFormat(format string) bool {
+	return format == ArchiveFormatTar || format == ArchiveFormatZip
+}
+
+// archiveWriter writes the entries of the objects into the archive.
+type archiveWriter interface {
+	create(object *FSFileInfo) (io.Writer, error)
+	Close() error
+}
+
+type tarArchiveWriter struct {
+	tw *tar.Writer
+}
+
+func (w *tarArchiveWriter) create(object *FSFileInfo) (io.Writer, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     object.Path,
+		Size:     object.Size,
+		Mode:     int64(object.Mode.Perm()),
+		ModTime:  object.ModifyTime,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	return w.tw, nil
+}
+
+func (w *tarArchiveWriter) Close() error {
+	return w.tw.Close()
+}
+
+type zipArchiveWriter struct {
+	zw         *zip.Writer
+	entries    int
+	maxEntries int
+}
+
+func (w *zipArchiveWriter) create(object *FSFileInfo) (io.Writer, error) {
+	if w.entries >= w.maxEntries {
+		return nil, ErrTooManyArchiveEntries
+	}
+	w.entries++
+	// the objects are stored as they are, most of the datasets are compressed already
+	header := &zip.FileHeader{
+		Name:     object.Path,
+		Method:   zip.Store,
+		Modified: object.ModifyTime,
+	}
+	header.SetMode(object.Mode.Perm())
+	return w.zw.CreateHeader(header)
+}
+
+func (w *zipArchiveWriter) Close() error {
+	return w.zw.Close()
+}
+
+// writeArchive writes the objects with the prefix in the target into w in the format, and returns
+// the number and the bytes of the objects written. The directories are skipped.
+func (a *ObjectArchiver) writeArchive(target archiveTarget, prefix, format string, w io.Writer) (count int, size int64, err error) {
+	var aw archiveWriter
+	switch format {
+	case ArchiveFormatTar:
+		aw = &tarArchiveWriter{tw: tar.NewWriter(w)}
+	case ArchiveFormatZip:
+		aw = &zipArchiveWriter{zw: zip.NewWriter(w), maxEntries: a.conf.MaxZipEntries}
+	default:
+		return 0, 0, fmt.Errorf("unknown archive format %v", format)
+	}
+
+	marker := ""
+	for {
+		var objects []*FSFileInfo
+		if objects, marker, err = target.listArchiveObjects(prefix, marker, archiveListLimit); err != nil {
+			return
+		}
+		for _, object := range objects {
+			if object.Mode.IsDir() {
+				continue
+			}
+			var entry io.Writer
+			if entry, err = aw.create(object); err != nil {
+				return
+			}
+			if err = target.readArchiveObject(object, entry); err != nil {
+				log.LogErrorf("writeArchive: read object fail: key(%v) inode(%v) err(%v)", object.Path, object.Inode, err)
+				return
+			}
+			count++
+			size += object.Size
+		}
+		if marker == "" {
+			break
+		}
+	}
+	err = aw.Close()
+	return
+}
+
+// archiveResponseWriter writes the response header on the first write of the archive, so the
+// errors occurred before are responded as usual.
+type archiveResponseWriter struct {
+	w           http.ResponseWriter
+	writeHeader func()
+	written     bool
+}
+
+func (w *archiveResponseWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.writeHeader()
+		w.written = true
+	}
+	return w.w.Write(p)
+}
+
+// Get bucket archive
+// Notes: CubeFS owned API, GET /?archive[&prefix=<prefix>][&format=tar|zip]
+func (o *ObjectNode) getBucketArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	format := r.URL.Query().Get(ParamArchiveFormat)
+	if format == "" {
+		format = ArchiveFormatTar
+	}
+	if !isValidArchiveFormat(format) {
+		errorCode = InvalidArgument
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketArchiveHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	if !o.archiver.acquire() {
+		log.LogWarnf("getBucketArchiveHandler: too many streams: requestID(%v) volume(%v)",
+			GetRequestID(r), vol.Name())
+		errorCode = SlowDown
+		return
+	}
+	defer o.archiver.release()
+
+	prefix := r.URL.Query().Get(ParamPrefix)
+	name := vol.Name()
+	if trimmed := strings.Trim(prefix, "/"); trimmed != "" {
+		name = trimmed[strings.LastIndex(trimmed, "/")+1:]
+	}
+	writer := &archiveResponseWriter{w: w, writeHeader: func() {
+		contentType := "application/x-tar"
+		if format == ArchiveFormatZip {
+			contentType = "application/zip"
+		}
+		w.Header().Set(ContentType, contentType)
+		w.Header().Set(ContentDisposition, fmt.Sprintf("attachment; filename=\"%v.%v\"", name, format))
+		w.WriteHeader(http.StatusOK)
+	}}
+	count, size, err := o.archiver.writeArchive(vol, prefix, format, writer)
+	if err != nil {
+		log.LogErrorf("getBucketArchiveHandler: write archive fail: requestID(%v) volume(%v) prefix(%v) format(%v) objects(%v) err(%v)",
+			GetRequestID(r), vol.Name(), prefix, format, count, err)
+		if err == ErrTooManyArchiveEntries {
+			errorCode = InvalidArgument
+		}
+		if writer.written {
+			// the archive is truncated, abort the connection so the client does not take it as complete
+			err, errorCode = nil, nil
+			panic(http.ErrAbortHandler)
+		}
+		return
+	}
+	log.LogInfof("getBucketArchiveHandler: requestID(%v) volume(%v) prefix(%v) format(%v) objects(%v) bytes(%v)",
+		GetRequestID(r), vol.Name(), prefix, format, count, size)
+}
+
+func (v *Volume) listArchiveObjects(prefix, marker string, maxKeys uint64) (objects []*FSFileInfo, nextMarker string, err error) {
+	var result *ListFilesV1Result
+	if result, err = v.ListFilesV1(&ListFilesV1Option{Prefix: prefix, Marker: marker, MaxKeys: maxKeys, OnlyObject: true}); err != nil {
+		return
+	}
+	return result.Files, result.NextMarker, nil
+}
+
+func (v *Volume) readArchiveObject(object *FSFileInfo, writer io.Writer) error {
+	return v.readFile(object.Inode, uint64(object.Size), object.Path, writer, 0, uint64(object.Size))
+}
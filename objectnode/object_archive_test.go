@@ -0,0 +1,143 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testArchiveTarget struct {
+	objects map[string][]byte
+	dirs    map[string]bool
+	pages   int
+}
+
+func (t *testArchiveTarget) listArchiveObjects(prefix, marker string, maxKeys uint64) ([]*FSFileInfo, string, error) {
+	t.pages++
+	var keys []string
+	for key := range t.objects {
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var next string
+	if uint64(len(keys)) > maxKeys {
+		keys = keys[:maxKeys]
+		next = keys[len(keys)-1]
+	}
+	objects := make([]*FSFileInfo, 0, len(keys))
+	for _, key := range keys {
+		info := &FSFileInfo{Path: key, Size: int64(len(t.objects[key])), Mode: 0o644, ModifyTime: time.Unix(1600000000, 0)}
+		if t.dirs[key] {
+			info.Mode = os.ModeDir | 0o755
+		}
+		objects = append(objects, info)
+	}
+	return objects, next, nil
+}
+
+func (t *testArchiveTarget) readArchiveObject(object *FSFileInfo, writer io.Writer) error {
+	_, err := writer.Write(t.objects[object.Path])
+	return err
+}
+
+func newTestArchiveTarget(count int) *testArchiveTarget {
+	t := &testArchiveTarget{objects: make(map[string][]byte), dirs: map[string]bool{"data/dir/": true}}
+	for i := 0; i < count; i++ {
+		t.objects[fmt.Sprintf("data/%05d", i)] = []byte(fmt.Sprintf("object-%v", i))
+	}
+	t.objects["data/dir/"] = nil
+	t.objects["other/0"] = []byte("other")
+	return t
+}
+
+func TestObjectArchiveTar(t *testing.T) {
+	count := 2*archiveListLimit + 10
+	target := newTestArchiveTarget(count)
+	buf := new(bytes.Buffer)
+	written, size, err := NewObjectArchiver(ArchiveConfig{}).writeArchive(target, "data/", ArchiveFormatTar, buf)
+	require.NoError(t, err)
+	require.Equal(t, count, written)
+	require.Equal(t, 3, target.pages)
+
+	var total int64
+	tr := tar.NewReader(buf)
+	for i := 0; ; i++ {
+		header, err := tr.Next()
+		if err == io.EOF {
+			require.Equal(t, count, i)
+			break
+		}
+		require.NoError(t, err)
+		key := fmt.Sprintf("data/%05d", i)
+		require.Equal(t, key, header.Name)
+		require.Equal(t, time.Unix(1600000000, 0), header.ModTime)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		require.Equal(t, target.objects[key], data)
+		total += int64(len(data))
+	}
+	require.Equal(t, total, size)
+}
+
+func TestObjectArchiveZip(t *testing.T) {
+	target := newTestArchiveTarget(10)
+	buf := new(bytes.Buffer)
+	written, _, err := NewObjectArchiver(ArchiveConfig{}).writeArchive(target, "data/", ArchiveFormatZip, buf)
+	require.NoError(t, err)
+	require.Equal(t, 10, written)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 10)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		require.Equal(t, target.objects[file.Name], data)
+	}
+
+	// the zip archive beyond the entries limit fails
+	_, _, err = NewObjectArchiver(ArchiveConfig{MaxZipEntries: 5}).writeArchive(target, "data/", ArchiveFormatZip, ioutil.Discard)
+	require.Equal(t, ErrTooManyArchiveEntries, err)
+}
+
+func TestObjectArchiveStreams(t *testing.T) {
+	a := NewObjectArchiver(ArchiveConfig{MaxStreams: 1})
+	require.True(t, a.acquire())
+	require.False(t, a.acquire())
+	a.release()
+	require.True(t, a.acquire())
+
+	a = NewObjectArchiver(ArchiveConfig{})
+	for i := 0; i < 10; i++ {
+		require.True(t, a.acquire())
+	}
+}
@@ -0,0 +1,534 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/util/retry"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+const (
+	VerifyStatusRunning   = "Running"
+	VerifyStatusCompleted = "Completed"
+	VerifyStatusFailed    = "Failed"
+	VerifyStatusCanceled  = "Canceled"
+
+	VerifyReasonMismatch  = "Mismatch"
+	VerifyReasonReadError = "ReadError"
+
+	VerifySignAlgorithm = "HMAC-SHA256"
+
+	verifyListLimit = 1000
+	// the progress of the running job is saved in the interval, the running job not saved
+	// in the timeout is abandoned by the stopped objectnode
+	verifySaveInterval = time.Minute
+	verifyStaleTimeout = 10 * time.Minute
+	// the tickets beyond are filed but not kept in the report
+	maxVerifyReportTickets = 100
+)
+
+var VerifyTicketUserAgent = "Golang cubefs/objectnode verify ticket"
+
+var (
+	ErrVerifyJobRunning  = errors.New("verify job is running")
+	ErrTooManyVerifyJobs = errors.New("too many verify jobs")
+)
+
+// ObjectVerifyConfig is the config of the object verify jobs. The completion reports are signed
+// by the SignKey, the read flow of the jobs on the objectnode is limited by ReadFlowMB, and the
+// repair tickets are posted to the TicketWebhook.
+type ObjectVerifyConfig struct {
+	SignKey       string         `json:"signKey"`
+	ReadFlowMB    int            `json:"readFlowMB"`
+	MaxJobs       int            `json:"maxJobs"`
+	TicketWebhook *WebhookConfig `json:"ticketWebhook"`
+}
+
+type RepairExtent struct {
+	PartitionId uint64 `xml:"PartitionId" json:"partitionId"`
+	ExtentId    uint64 `xml:"ExtentId" json:"extentId"`
+	FileOffset  uint64 `xml:"FileOffset" json:"fileOffset"`
+	Size        uint32 `xml:"Size" json:"size"`
+}
+
+// RepairTicket is filed for the object whose data does not match its ETag or cannot be read,
+// the extents locate the data partitions to be repaired.
+type RepairTicket struct {
+	TicketId     string          `xml:"TicketId" json:"ticketId"`
+	JobId        string          `xml:"JobId" json:"jobId"`
+	Bucket       string          `xml:"Bucket" json:"bucket"`
+	Key          string          `xml:"Key" json:"key"`
+	Inode        uint64          `xml:"Inode" json:"inode"`
+	Size         int64           `xml:"Size" json:"size"`
+	ExpectedETag string          `xml:"ExpectedETag" json:"expectedETag"`
+	ActualETag   string          `xml:"ActualETag,omitempty" json:"actualETag,omitempty"`
+	Reason       string          `xml:"Reason" json:"reason"`
+	Error        string          `xml:"Error,omitempty" json:"error,omitempty"`
+	Extents      []*RepairExtent `xml:"Extents>Extent" json:"extents,omitempty"`
+	CreateTime   string          `xml:"CreateTime" json:"createTime"`
+}
+
+// VerifyReport is the progress and the result of the verify job of the bucket. The objects
+// uploaded by multipart are read but Unverifiable, since their ETags are not the MD5 of the data.
+// The objects changed or deleted during the job are Skipped. The report is signed on completion.
+type VerifyReport struct {
+	XMLName        xml.Name        `xml:"VerifyReport" json:"-"`
+	JobId          string          `xml:"JobId" json:"jobId"`
+	Bucket         string          `xml:"Bucket" json:"bucket"`
+	Prefix         string          `xml:"Prefix" json:"prefix"`
+	Status         string          `xml:"Status" json:"status"`
+	StartTime      string          `xml:"StartTime" json:"startTime"`
+	UpdateTime     string          `xml:"UpdateTime" json:"updateTime"`
+	EndTime        string          `xml:"EndTime,omitempty" json:"endTime,omitempty"`
+	Marker         string          `xml:"Marker,omitempty" json:"marker,omitempty"`
+	Scanned        uint64          `xml:"Scanned" json:"scanned"`
+	ScannedBytes   uint64          `xml:"ScannedBytes" json:"scannedBytes"`
+	Verified       uint64          `xml:"Verified" json:"verified"`
+	Unverifiable   uint64          `xml:"Unverifiable" json:"unverifiable"`
+	Skipped        uint64          `xml:"Skipped" json:"skipped"`
+	Mismatched     uint64          `xml:"Mismatched" json:"mismatched"`
+	ReadErrors     uint64          `xml:"ReadErrors" json:"readErrors"`
+	TicketsUnsent  uint64          `xml:"TicketsUnsent" json:"ticketsUnsent"`
+	TicketsOmitted uint64          `xml:"TicketsOmitted" json:"ticketsOmitted"`
+	Tickets        []*RepairTicket `xml:"Tickets>Ticket" json:"tickets,omitempty"`
+	Error          string          `xml:"Error,omitempty" json:"error,omitempty"`
+	SignAlgorithm  string          `xml:"SignAlgorithm,omitempty" json:"signAlgorithm,omitempty"`
+	Signature      string          `xml:"Signature,omitempty" json:"signature,omitempty"`
+}
+
+func (r *VerifyReport) signature(key string) (string, error) {
+	cp := *r
+	cp.Signature = ""
+	data, err := json.Marshal(&cp)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sign signs the report by HMAC-SHA256 of its JSON encoding without the signature.
+func (r *VerifyReport) Sign(key string) (err error) {
+	r.SignAlgorithm = VerifySignAlgorithm
+	r.Signature, err = r.signature(key)
+	return
+}
+
+// VerifySignature returns true if the report is signed by the key.
+func (r *VerifyReport) VerifySignature(key string) bool {
+	if r.SignAlgorithm != VerifySignAlgorithm || r.Signature == "" {
+		return false
+	}
+	signature, err := r.signature(key)
+	return err == nil && hmac.Equal([]byte(signature), []byte(r.Signature))
+}
+
+func (r *VerifyReport) isRunning(now time.Time) bool {
+	if r.Status != VerifyStatusRunning {
+		return false
+	}
+	updateTime, err := time.Parse(ISO8601Layout, r.UpdateTime)
+	return err == nil && now.Sub(updateTime) < verifyStaleTimeout
+}
+
+// verifyTarget is the bucket verified by the job.
+type verifyTarget interface {
+	Name() string
+	listVerifyObjects(prefix, marker string, maxKeys uint64) (objects []*FSFileInfo, nextMarker string, err error)
+	readVerifyObject(object *FSFileInfo, writer io.Writer) error
+	statVerifyObject(inode uint64) (*proto.InodeInfo, error)
+	verifyObjectExtents(inode uint64) ([]proto.ExtentKey, error)
+	loadVerifyReport() (*VerifyReport, error)
+	storeVerifyReport(report *VerifyReport) error
+}
+
+// ObjectVerifier runs the verify jobs of the buckets, at most one job runs for a bucket. A job
+// re-reads the objects of the bucket, compares the MD5 of the data with the ETags, and files the
+// repair tickets for the mismatched and unreadable objects.
+type ObjectVerifier struct {
+	conf    ObjectVerifyConfig
+	limiter *rate.Limiter // nil means unlimited
+	client  *http.Client  // client of the ticket webhook
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*verifyJob // bucket -> running job
+}
+
+func NewObjectVerifier(conf ObjectVerifyConfig) (v *ObjectVerifier, err error) {
+	if conf.SignKey == "" {
+		return nil, errors.New("signKey is empty")
+	}
+	v = &ObjectVerifier{
+		conf: conf,
+		jobs: make(map[string]*verifyJob),
+	}
+	if conf.ReadFlowMB > 0 {
+		flow := conf.ReadFlowMB * util.MB
+		v.limiter = rate.NewLimiter(rate.Limit(flow), flow)
+	}
+	if conf.TicketWebhook != nil {
+		if err = conf.TicketWebhook.FixConfig(); err != nil {
+			return nil, err
+		}
+		if v.client, err = conf.TicketWebhook.BuildClient(); err != nil {
+			return nil, err
+		}
+	}
+	v.ctx, v.cancel = context.WithCancel(context.Background())
+	return v, nil
+}
+
+// Close cancels the running jobs and waits for them to save the reports.
+func (v *ObjectVerifier) Close() {
+	v.cancel()
+	v.wg.Wait()
+}
+
+// Start starts the verify job of the objects with the prefix in the bucket, the job running on
+// any objectnode fails it with ErrVerifyJobRunning.
+func (v *ObjectVerifier) Start(target verifyTarget, prefix string) (report *VerifyReport, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.jobs[target.Name()]; ok {
+		return nil, ErrVerifyJobRunning
+	}
+	if v.conf.MaxJobs > 0 && len(v.jobs) >= v.conf.MaxJobs {
+		return nil, ErrTooManyVerifyJobs
+	}
+	now := time.Now()
+	var last *VerifyReport
+	if last, err = target.loadVerifyReport(); err != nil {
+		return
+	}
+	if last != nil && last.isRunning(now) {
+		return nil, ErrVerifyJobRunning
+	}
+
+	report = &VerifyReport{
+		JobId:      uuid.New().String(),
+		Bucket:     target.Name(),
+		Prefix:     prefix,
+		Status:     VerifyStatusRunning,
+		StartTime:  formatTimeISO(now),
+		UpdateTime: formatTimeISO(now),
+	}
+	if err = target.storeVerifyReport(report); err != nil {
+		return
+	}
+	job := &verifyJob{verifier: v, target: target, report: report}
+	v.jobs[target.Name()] = job
+	v.wg.Add(1)
+	go job.run()
+	log.LogInfof("ObjectVerifier: start job: bucket(%v) prefix(%v) job(%v)", target.Name(), prefix, report.JobId)
+	return job.snapshot(), nil
+}
+
+// Report returns the report of the running job of the bucket on the objectnode, or the last
+// report saved in the bucket.
+func (v *ObjectVerifier) Report(target verifyTarget) (*VerifyReport, error) {
+	v.mu.Lock()
+	job, ok := v.jobs[target.Name()]
+	v.mu.Unlock()
+	if ok {
+		return job.snapshot(), nil
+	}
+	return target.loadVerifyReport()
+}
+
+func (v *ObjectVerifier) sendTicket(ticket *RepairTicket) error {
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+	return retry.ExponentialBackoff(5, 100).On(func() error {
+		return v.conf.TicketWebhook.Post(v.client, VerifyTicketUserAgent, data)
+	})
+}
+
+type verifyJob struct {
+	verifier *ObjectVerifier
+	target   verifyTarget
+
+	mu     sync.Mutex
+	report *VerifyReport
+}
+
+func (j *verifyJob) snapshot() *VerifyReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cp := *j.report
+	cp.Tickets = append([]*RepairTicket(nil), j.report.Tickets...)
+	return &cp
+}
+
+func (j *verifyJob) save() {
+	j.mu.Lock()
+	j.report.UpdateTime = formatTimeISO(time.Now())
+	j.mu.Unlock()
+	if err := j.target.storeVerifyReport(j.snapshot()); err != nil {
+		log.LogWarnf("ObjectVerifier: save report fail: bucket(%v) job(%v) err(%v)",
+			j.target.Name(), j.report.JobId, err)
+	}
+}
+
+func (j *verifyJob) run() {
+	v := j.verifier
+	defer func() {
+		v.mu.Lock()
+		delete(v.jobs, j.target.Name())
+		v.mu.Unlock()
+		v.wg.Done()
+	}()
+
+	prefix, marker := j.report.Prefix, ""
+	lastSave := time.Now()
+	for {
+		objects, nextMarker, err := j.target.listVerifyObjects(prefix, marker, verifyListLimit)
+		if err != nil {
+			j.finish(VerifyStatusFailed, err)
+			return
+		}
+		for _, object := range objects {
+			if v.ctx.Err() != nil {
+				j.finish(VerifyStatusCanceled, errors.New("objectnode is closed"))
+				return
+			}
+			j.verifyObject(object)
+		}
+		j.mu.Lock()
+		j.report.Marker = nextMarker
+		j.mu.Unlock()
+		if nextMarker == "" {
+			break
+		}
+		marker = nextMarker
+		if time.Since(lastSave) > verifySaveInterval {
+			j.save()
+			lastSave = time.Now()
+		}
+	}
+	j.finish(VerifyStatusCompleted, nil)
+}
+
+func (j *verifyJob) finish(status string, err error) {
+	j.mu.Lock()
+	now := formatTimeISO(time.Now())
+	j.report.Status, j.report.UpdateTime, j.report.EndTime = status, now, now
+	if err != nil {
+		j.report.Error = err.Error()
+	}
+	if signErr := j.report.Sign(j.verifier.conf.SignKey); signErr != nil {
+		log.LogErrorf("ObjectVerifier: sign report fail: bucket(%v) job(%v) err(%v)",
+			j.target.Name(), j.report.JobId, signErr)
+	}
+	report := *j.report
+	j.mu.Unlock()
+	if storeErr := j.target.storeVerifyReport(&report); storeErr != nil {
+		log.LogErrorf("ObjectVerifier: store report fail: bucket(%v) job(%v) err(%v)",
+			j.target.Name(), report.JobId, storeErr)
+	}
+	log.LogInfof("ObjectVerifier: finish job: bucket(%v) job(%v) status(%v) scanned(%v) verified(%v) mismatched(%v) readErrors(%v) err(%v)",
+		j.target.Name(), report.JobId, status, report.Scanned, report.Verified, report.Mismatched, report.ReadErrors, err)
+}
+
+func (j *verifyJob) verifyObject(object *FSFileInfo) {
+	if object.Mode.IsDir() {
+		return
+	}
+	hash := md5.New()
+	var writer io.Writer = hash
+	if j.verifier.limiter != nil {
+		writer = &verifyFlowWriter{ctx: j.verifier.ctx, limiter: j.verifier.limiter, writer: hash}
+	}
+	readErr := j.target.readVerifyObject(object, writer)
+	if readErr != nil && j.verifier.ctx.Err() != nil {
+		return
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	etag := ParseETagValue(object.ETag)
+
+	j.mu.Lock()
+	j.report.Scanned++
+	j.report.ScannedBytes += uint64(object.Size)
+	switch {
+	case readErr == nil && (!etag.Valid() || etag.PartNum > 0):
+		j.report.Unverifiable++
+		j.mu.Unlock()
+		return
+	case readErr == nil && etag.Value == actual:
+		j.report.Verified++
+		j.mu.Unlock()
+		return
+	}
+	j.mu.Unlock()
+
+	// the object changed or deleted after listed is not corrupted
+	info, err := j.target.statVerifyObject(object.Inode)
+	if err == syscall.ENOENT || (err == nil && (info.Size != uint64(object.Size) || !info.ModifyTime.Equal(object.ModifyTime))) {
+		j.mu.Lock()
+		j.report.Skipped++
+		j.mu.Unlock()
+		return
+	}
+
+	ticket := &RepairTicket{
+		TicketId:     uuid.New().String(),
+		JobId:        j.report.JobId,
+		Bucket:       j.target.Name(),
+		Key:          object.Path,
+		Inode:        object.Inode,
+		Size:         object.Size,
+		ExpectedETag: object.ETag,
+		Reason:       VerifyReasonMismatch,
+		CreateTime:   formatTimeISO(time.Now()),
+	}
+	if readErr != nil {
+		ticket.Reason, ticket.Error = VerifyReasonReadError, readErr.Error()
+	} else {
+		ticket.ActualETag = actual
+	}
+	if eks, err := j.target.verifyObjectExtents(object.Inode); err != nil {
+		log.LogWarnf("ObjectVerifier: get extents fail: bucket(%v) key(%v) inode(%v) err(%v)",
+			ticket.Bucket, ticket.Key, ticket.Inode, err)
+	} else {
+		for _, ek := range eks {
+			ticket.Extents = append(ticket.Extents, &RepairExtent{
+				PartitionId: ek.PartitionId,
+				ExtentId:    ek.ExtentId,
+				FileOffset:  ek.FileOffset,
+				Size:        ek.Size,
+			})
+		}
+	}
+	log.LogWarnf("ObjectVerifier: file repair ticket: bucket(%v) key(%v) inode(%v) reason(%v) expected(%v) actual(%v) err(%v) ticket(%v)",
+		ticket.Bucket, ticket.Key, ticket.Inode, ticket.Reason, ticket.ExpectedETag, ticket.ActualETag, ticket.Error, ticket.TicketId)
+	var sendErr error
+	if j.verifier.client != nil {
+		if sendErr = j.verifier.sendTicket(ticket); sendErr != nil {
+			log.LogErrorf("ObjectVerifier: send repair ticket fail: bucket(%v) key(%v) ticket(%v) err(%v)",
+				ticket.Bucket, ticket.Key, ticket.TicketId, sendErr)
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if readErr != nil {
+		j.report.ReadErrors++
+	} else {
+		j.report.Mismatched++
+	}
+	if sendErr != nil {
+		j.report.TicketsUnsent++
+	}
+	if len(j.report.Tickets) < maxVerifyReportTickets {
+		j.report.Tickets = append(j.report.Tickets, ticket)
+	} else {
+		j.report.TicketsOmitted++
+	}
+}
+
+// verifyFlowWriter limits the flow of the data read by the verify jobs.
+type verifyFlowWriter struct {
+	ctx     context.Context
+	limiter *rate.Limiter
+	writer  io.Writer
+}
+
+func (w *verifyFlowWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		size := len(p)
+		if burst := w.limiter.Burst(); size > burst {
+			size = burst
+		}
+		if err = w.limiter.WaitN(w.ctx, size); err != nil {
+			return
+		}
+		var written int
+		written, err = w.writer.Write(p[:size])
+		n += written
+		if err != nil {
+			return
+		}
+		p = p[size:]
+	}
+	return
+}
+
+func (v *Volume) listVerifyObjects(prefix, marker string, maxKeys uint64) (objects []*FSFileInfo, nextMarker string, err error) {
+	var result *ListFilesV1Result
+	if result, err = v.ListFilesV1(&ListFilesV1Option{Prefix: prefix, Marker: marker, MaxKeys: maxKeys, OnlyObject: true}); err != nil {
+		return
+	}
+	return result.Files, result.NextMarker, nil
+}
+
+func (v *Volume) readVerifyObject(object *FSFileInfo, writer io.Writer) error {
+	return v.readFile(object.Inode, uint64(object.Size), object.Path, writer, 0, uint64(object.Size))
+}
+
+func (v *Volume) statVerifyObject(inode uint64) (*proto.InodeInfo, error) {
+	return v.mw.InodeGet_ll(inode)
+}
+
+func (v *Volume) verifyObjectExtents(inode uint64) (eks []proto.ExtentKey, err error) {
+	if !proto.IsHot(v.volType) {
+		return
+	}
+	_, _, eks, err = v.mw.GetExtents(inode)
+	return
+}
+
+func (v *Volume) loadVerifyReport() (report *VerifyReport, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVerify); err != nil || len(raw) == 0 {
+		return
+	}
+	report = &VerifyReport{}
+	if err = json.Unmarshal(raw, report); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (v *Volume) storeVerifyReport(report *VerifyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return v.store.Put(v.name, bucketRootPath, XAttrKeyOSSVerify, data)
+}
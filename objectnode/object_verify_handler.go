@@ -0,0 +1,122 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Start bucket verify job
+// Notes: CubeFS owned API, POST /?verify[&prefix=<prefix>]
+func (o *ObjectNode) startBucketVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	if o.verifier == nil {
+		errorCode = UnsupportedOperation
+		return
+	}
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("startBucketVerifyHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	prefix := r.URL.Query().Get(ParamPrefix)
+	log.LogInfof("Audit: start bucket verify: requestID(%v) remote(%v) volume(%v) prefix(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), prefix)
+	var report *VerifyReport
+	if report, err = o.verifier.Start(vol, prefix); err != nil {
+		log.LogErrorf("startBucketVerifyHandler: start job fail: requestID(%v) volume(%v) prefix(%v) err(%v)",
+			GetRequestID(r), vol.Name(), prefix, err)
+		switch err {
+		case ErrVerifyJobRunning:
+			errorCode = VerifyJobRunning
+		case ErrTooManyVerifyJobs:
+			errorCode = TooManyVerifyJobs
+		}
+		return
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(report); err != nil {
+		log.LogErrorf("startBucketVerifyHandler: xml marshal fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
+
+// Get bucket verify report
+// Notes: CubeFS owned API, GET /?verify
+func (o *ObjectNode) getBucketVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	if o.verifier == nil {
+		errorCode = UnsupportedOperation
+		return
+	}
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketVerifyHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var report *VerifyReport
+	if report, err = o.verifier.Report(vol); err != nil {
+		log.LogErrorf("getBucketVerifyHandler: load report fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if report == nil {
+		errorCode = NoSuchVerifyJob
+		return
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(report); err != nil {
+		log.LogErrorf("getBucketVerifyHandler: xml marshal fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
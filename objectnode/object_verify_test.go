@@ -0,0 +1,274 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+type testVerifyObject struct {
+	info    *FSFileInfo
+	data    []byte
+	readErr error
+	changed bool
+}
+
+type testVerifyTarget struct {
+	mu      sync.Mutex
+	objects map[string]*testVerifyObject
+	report  *VerifyReport
+	block   chan struct{}
+}
+
+func newTestVerifyTarget() *testVerifyTarget {
+	return &testVerifyTarget{objects: make(map[string]*testVerifyObject)}
+}
+
+func (t *testVerifyTarget) put(key string, data []byte, etag string) *testVerifyObject {
+	if etag == "" {
+		sum := md5.Sum(data)
+		etag = hex.EncodeToString(sum[:])
+	}
+	obj := &testVerifyObject{
+		info: &FSFileInfo{Path: key, Size: int64(len(data)), ETag: etag, Inode: uint64(len(t.objects) + 1), ModifyTime: time.Unix(1, 0)},
+		data: data,
+	}
+	t.objects[key] = obj
+	return obj
+}
+
+func (t *testVerifyTarget) Name() string { return "bucket" }
+
+func (t *testVerifyTarget) listVerifyObjects(prefix, marker string, maxKeys uint64) ([]*FSFileInfo, string, error) {
+	if t.block != nil {
+		<-t.block
+	}
+	var keys []string
+	for key := range t.objects {
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var next string
+	if uint64(len(keys)) > maxKeys {
+		keys, next = keys[:maxKeys], keys[maxKeys-1]
+	}
+	objects := make([]*FSFileInfo, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, t.objects[key].info)
+	}
+	return objects, next, nil
+}
+
+func (t *testVerifyTarget) find(inode uint64) *testVerifyObject {
+	for _, obj := range t.objects {
+		if obj.info.Inode == inode {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (t *testVerifyTarget) readVerifyObject(object *FSFileInfo, writer io.Writer) error {
+	obj := t.objects[object.Path]
+	if obj.readErr != nil {
+		return obj.readErr
+	}
+	_, err := writer.Write(obj.data)
+	return err
+}
+
+func (t *testVerifyTarget) statVerifyObject(inode uint64) (*proto.InodeInfo, error) {
+	obj := t.find(inode)
+	info := &proto.InodeInfo{Inode: inode, Size: uint64(obj.info.Size), ModifyTime: obj.info.ModifyTime}
+	if obj.changed {
+		info.ModifyTime = info.ModifyTime.Add(time.Second)
+	}
+	return info, nil
+}
+
+func (t *testVerifyTarget) verifyObjectExtents(inode uint64) ([]proto.ExtentKey, error) {
+	return []proto.ExtentKey{{PartitionId: 10, ExtentId: inode, Size: uint32(t.find(inode).info.Size)}}, nil
+}
+
+func (t *testVerifyTarget) loadVerifyReport() (*VerifyReport, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.report, nil
+}
+
+func (t *testVerifyTarget) storeVerifyReport(report *VerifyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.report = &VerifyReport{}
+	return json.Unmarshal(data, t.report)
+}
+
+func waitVerifyJob(t *testing.T, v *ObjectVerifier, target verifyTarget) *VerifyReport {
+	for i := 0; i < 100; i++ {
+		report, err := v.Report(target)
+		require.NoError(t, err)
+		if report.Status != VerifyStatusRunning {
+			return report
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.FailNow(t, "verify job is not finished")
+	return nil
+}
+
+func TestObjectVerifier(t *testing.T) {
+	var (
+		tickets []*RepairTicket
+		mu      sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ticket := &RepairTicket{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(ticket))
+		mu.Lock()
+		tickets = append(tickets, ticket)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	_, err := NewObjectVerifier(ObjectVerifyConfig{})
+	require.Error(t, err)
+	v, err := NewObjectVerifier(ObjectVerifyConfig{
+		SignKey:       "key",
+		ReadFlowMB:    1,
+		TicketWebhook: &WebhookConfig{Endpoint: server.URL},
+	})
+	require.NoError(t, err)
+	defer v.Close()
+
+	target := newTestVerifyTarget()
+	target.put("a/ok", []byte("hello"), "")
+	target.put("a/empty", nil, "")
+	target.put("a/multipart", []byte("parts"), "0123456789abcdef0123456789abcdef-2")
+	target.put("a/corrupted", []byte("hello"), "0123456789abcdef0123456789abcdef")
+	target.put("a/changed", []byte("hello"), "0123456789abcdef0123456789abcdef").changed = true
+	target.put("a/unreadable", []byte("hello"), "").readErr = errors.New("no replica")
+	target.put("b/ok", []byte("hello"), "0123456789abcdef0123456789abcdef")
+
+	report, err := v.Start(target, "a/")
+	require.NoError(t, err)
+	require.Equal(t, VerifyStatusRunning, report.Status)
+	report = waitVerifyJob(t, v, target)
+	require.Equal(t, VerifyStatusCompleted, report.Status)
+	require.Equal(t, uint64(6), report.Scanned)
+	require.Equal(t, uint64(2), report.Verified)
+	require.Equal(t, uint64(1), report.Unverifiable)
+	require.Equal(t, uint64(1), report.Skipped)
+	require.Equal(t, uint64(1), report.Mismatched)
+	require.Equal(t, uint64(1), report.ReadErrors)
+	require.Equal(t, uint64(0), report.TicketsUnsent)
+	require.Len(t, report.Tickets, 2)
+	require.Len(t, tickets, 2)
+	for _, ticket := range report.Tickets {
+		require.Equal(t, report.JobId, ticket.JobId)
+		require.Len(t, ticket.Extents, 1)
+		switch ticket.Key {
+		case "a/corrupted":
+			require.Equal(t, VerifyReasonMismatch, ticket.Reason)
+			require.Equal(t, "5d41402abc4b2a76b9719d911017c592", ticket.ActualETag)
+		case "a/unreadable":
+			require.Equal(t, VerifyReasonReadError, ticket.Reason)
+			require.Equal(t, "no replica", ticket.Error)
+		default:
+			require.Fail(t, "unexpected ticket", ticket.Key)
+		}
+	}
+
+	// the report saved in the bucket is signed
+	report, err = target.loadVerifyReport()
+	require.NoError(t, err)
+	require.True(t, report.VerifySignature("key"))
+	require.False(t, report.VerifySignature("other"))
+	report.Mismatched = 0
+	require.False(t, report.VerifySignature("key"))
+}
+
+func TestObjectVerifierRunning(t *testing.T) {
+	v, err := NewObjectVerifier(ObjectVerifyConfig{SignKey: "key", MaxJobs: 1})
+	require.NoError(t, err)
+	defer v.Close()
+
+	target := newTestVerifyTarget()
+	target.put("a", []byte("hello"), "")
+	target.block = make(chan struct{})
+	_, err = v.Start(target, "")
+	require.NoError(t, err)
+	_, err = v.Start(target, "")
+	require.Equal(t, ErrVerifyJobRunning, err)
+	other := &testVerifyTargetNamed{testVerifyTarget: newTestVerifyTarget(), name: "other"}
+	_, err = v.Start(other, "")
+	require.Equal(t, ErrTooManyVerifyJobs, err)
+	close(target.block)
+	require.Equal(t, VerifyStatusCompleted, waitVerifyJob(t, v, target).Status)
+
+	// the job running on another objectnode
+	now := time.Now()
+	other.report = &VerifyReport{Status: VerifyStatusRunning, UpdateTime: formatTimeISO(now)}
+	_, err = v.Start(other, "")
+	require.Equal(t, ErrVerifyJobRunning, err)
+	other.report.UpdateTime = formatTimeISO(now.Add(-verifyStaleTimeout))
+	_, err = v.Start(other, "")
+	require.NoError(t, err)
+	require.Equal(t, VerifyStatusCompleted, waitVerifyJob(t, v, other).Status)
+
+	// the objects deleted during the job
+	deleted := &testVerifyTargetDeleted{testVerifyTarget: newTestVerifyTarget()}
+	deleted.put("a", []byte("hello"), "0123456789abcdef0123456789abcdef")
+	_, err = v.Start(deleted, "")
+	require.NoError(t, err)
+	report := waitVerifyJob(t, v, deleted)
+	require.Equal(t, uint64(1), report.Skipped)
+	require.Len(t, report.Tickets, 0)
+}
+
+type testVerifyTargetNamed struct {
+	*testVerifyTarget
+	name string
+}
+
+func (t *testVerifyTargetNamed) Name() string { return t.name }
+
+type testVerifyTargetDeleted struct {
+	*testVerifyTarget
+}
+
+func (t *testVerifyTargetDeleted) statVerifyObject(inode uint64) (*proto.InodeInfo, error) {
+	return nil, syscall.ENOENT
+}
@@ -19,6 +19,7 @@ package objectnode
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"syscall"
 
@@ -114,202 +115,315 @@ func (p Policy) Validate(bucket string) (bool, error) {
 // check policy is allowed for request
 // https://docs.aws.amazon.com/zh_cn/IAM/latest/UserGuide/reference_policies_evaluation-logic.html
 func (p *Policy) IsAllowed(params *RequestParam, reqUid, ownerUid string, conditionCheck map[string]string) PolicyCheckResult {
-	result := POLICY_UNKNOW
-	apiName := params.apiName
+	result, matched := p.check(params.apiName, reqUid, ownerUid, conditionCheck)
+	if len(matched) > 0 {
+		log.LogDebugf("bucket policy check: requestID(%v) result(%v) matched statements(%v)", GetRequestID(params.r), result, len(matched))
+	}
+	return result
+}
+
+// check returns the result of the policy and the statements matching the request, an explicit deny
+// overrides the allows.
+func (p *Policy) check(apiName, reqUid, ownerUid string, conditionCheck map[string]string) (result PolicyCheckResult, matched []Statement) {
+	result = POLICY_UNKNOW
 	// only bucket owner is allowed to put/get/delete bucket policy
 	if isPolicyApi(apiName) {
 		if reqUid == ownerUid {
-			return POLICY_ALLOW
+			return POLICY_ALLOW, nil
 		}
-		return POLICY_DENY
+		return POLICY_DENY, nil
 	}
 	if !supportByPolicy(apiName) {
-		return POLICY_UNKNOW
+		return
 	}
 	for _, statement := range p.Statements {
-		if tmp := statement.CheckPolicy(apiName, reqUid, conditionCheck); tmp == POLICY_DENY {
-			log.LogDebugf("bucket policy check: statement denied: requestID(%v) statement(%v)", GetRequestID(params.r), statement)
-			return POLICY_DENY
-		} else if tmp == POLICY_ALLOW {
-			log.LogDebugf("bucket policy check: statement allowed: requestID(%v) statement(%v)", GetRequestID(params.r), statement)
+		tmp := statement.CheckPolicy(apiName, reqUid, conditionCheck)
+		if tmp == POLICY_UNKNOW {
+			continue
+		}
+		matched = append(matched, statement)
+		if tmp == POLICY_DENY {
+			result = POLICY_DENY
+		} else if result != POLICY_DENY {
 			result = POLICY_ALLOW
 		}
 	}
-	return result
+	return
 }
 
 func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var (
-			err error
-			ec  *ErrorCode
-		)
-		allowed := false
-		defer func() {
-			if allowed {
-				f(w, r)
-			} else {
-				if ec == nil && err == nil {
-					ec = AccessDenied
-				}
-				o.errorResponse(w, r, err, ec)
-			}
-		}()
-
-		param := ParseRequestParam(r)
-		if param.Bucket() == "" {
-			log.LogDebugf("policyCheck: no bucket specified: requestID(%v)", GetRequestID(r))
-			allowed = true
+		allowed, ec, err := o.checkPolicy(r)
+		if allowed {
+			f(w, r)
 			return
 		}
+		if ec == nil && err == nil {
+			ec = AccessDenied
+		}
+		o.errorResponse(w, r, err, ec)
+	}
+}
+
+// checkPolicy checks the user policy, bucket policy and acl of the bucket and object of the request.
+func (o *ObjectNode) checkPolicy(r *http.Request) (allowed bool, ec *ErrorCode, err error) {
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		log.LogDebugf("policyCheck: no bucket specified: requestID(%v)", GetRequestID(r))
+		allowed = true
+		return
+	}
+
+	d := o.newPolicyDecision(param.Bucket(), param.Object(), param.apiName, param.Action(), param.ConditionValues(o.trustedProxies))
+	d.anonymous = isAnonymous(param.accessKey)
+	d.checkBucket = func() (err error) {
+		if bucket := mux.Vars(r)[ContextKeyBucket]; len(bucket) > 0 {
+			_, err = o.getVol(bucket)
+		}
+		return
+	}
+	d.loadUser = func() (*proto.UserInfo, error) {
+		return o.getUserInfoByAccessKey(param.AccessKey())
+	}
+	if param.apiName == COPY_OBJECT || param.apiName == UPLOAD_PART_COPY {
+		_, d.copySrcKey, _, _ = extractSrcBucketKey(r)
+		d.checkCopySource = func(uid string) error {
+			return o.allowedBySrcBucketPolicy(param, uid)
+		}
+	}
+
+	allowed, ec, err = d.evaluate()
+	trace := d.trace
+	last := trace.Steps[len(trace.Steps)-1]
+	if err != nil {
+		log.LogErrorf("policyCheck: requestID(%v) accessKey(%v) volume(%v) action(%v) step(%v) err(%v)",
+			GetRequestID(r), param.AccessKey(), param.Bucket(), param.Action(), last.Name, err)
+	} else if !allowed {
+		log.LogWarnf("policyCheck: denied: requestID(%v) accessKey(%v) userID(%v) volume(%v) object(%v) action(%v) decidedBy(%v) reason(%v)",
+			GetRequestID(r), param.AccessKey(), trace.Principal, param.Bucket(), param.Object(), param.Action(), trace.DecidedBy, last.Reason)
+	} else if log.EnableDebug() {
+		log.LogDebugf("policyCheck: allowed: requestID(%v) accessKey(%v) userID(%v) volume(%v) object(%v) action(%v) decidedBy(%v) reason(%v)",
+			GetRequestID(r), param.AccessKey(), trace.Principal, param.Bucket(), param.Object(), param.Action(), trace.DecidedBy, last.Reason)
+	}
+	return
+}
+
+// policyDecision is the chain deciding the access of a request by the user policy, the bucket policy
+// and the acl. It is evaluated by policyCheck for the requests and by the policy simulator, and the
+// trace records why every step passes or decides the request.
+type policyDecision struct {
+	bucket     string
+	key        string
+	copySrcKey string // the source key of the copy apis
+	apiName    string
+	action     proto.Action
+	anonymous  bool
+	conditions map[string]string
 
-		// step1. The account level api does not need to check any user policy and volume policy.
-		if IsAccountLevelApi(param.apiName) {
-			if !isAnonymous(param.accessKey) {
-				allowed = true
+	// checkBucket checks the bucket exists before the user is loaded
+	checkBucket func() error
+	// loadUser loads the user of the request, it is not called for the anonymous user
+	loadUser func() (*proto.UserInfo, error)
+	// loadBucket loads the owner, the bucket acl and the bucket policy
+	loadBucket func() (owner string, acl *AccessControlPolicy, policy *Policy, err error)
+	// loadObjectACL loads the object acl for the apis supported by object acl
+	loadObjectACL func() (*AccessControlPolicy, error)
+	// checkCopySource checks the access of the user to the source of the copy apis
+	checkCopySource func(uid string) error
+
+	trace *PolicySimulationResult
+}
+
+func (o *ObjectNode) newPolicyDecision(bucket, key, apiName string, action proto.Action, conditions map[string]string) *policyDecision {
+	var vol *Volume
+	return &policyDecision{
+		bucket:     bucket,
+		key:        key,
+		apiName:    apiName,
+		action:     action,
+		conditions: conditions,
+		loadBucket: func() (owner string, acl *AccessControlPolicy, policy *Policy, err error) {
+			if vol, acl, policy, err = o.loadBucketMeta(bucket); err != nil {
 				return
 			}
-			log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
-				param.apiName, GetRequestID(r))
-			allowed = false
+			return vol.GetOwner(), acl, policy, nil
+		},
+		loadObjectACL: func() (acl *AccessControlPolicy, err error) {
+			if acl, err = getObjectACL(vol, key, true); err == syscall.ENOENT {
+				err = nil
+			}
 			return
+		},
+	}
+}
+
+func (d *policyDecision) step(name, result, format string, args ...interface{}) {
+	d.trace.Steps = append(d.trace.Steps, &SimulationStep{
+		Name:   name,
+		Result: result,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *policyDecision) decide(name string, allowed bool, format string, args ...interface{}) bool {
+	decision := SimulationDecisionDeny
+	if allowed {
+		decision = SimulationDecisionAllow
+	}
+	d.step(name, decision, format, args...)
+	d.trace.Decision = decision
+	d.trace.DecidedBy = name
+	return allowed
+}
+
+func (d *policyDecision) fail(name string, err error) error {
+	d.step(name, SimulationStepError, "%v", err)
+	return err
+}
+
+// evaluate returns whether the request is allowed, ec is the error code of the denied request if
+// it is not AccessDenied, and err is the error failing the evaluation.
+func (d *policyDecision) evaluate() (allowed bool, ec *ErrorCode, err error) {
+	d.trace = &PolicySimulationResult{
+		Bucket:   d.bucket,
+		Action:   d.apiName,
+		Key:      d.key,
+		Decision: SimulationDecisionDeny,
+	}
+
+	// the reserved directory of soft deleted objects cannot be accessed as objects
+	if isSoftDeleteReservedKey(d.key) {
+		d.decide(SimulationStepReservedKey, false, "key(%v) is reserved for soft deleted objects", d.key)
+		return false, InvalidKey, nil
+	}
+	if isSoftDeleteReservedKey(d.copySrcKey) {
+		d.decide(SimulationStepReservedKey, false, "copy source(%v) is reserved for soft deleted objects", d.copySrcKey)
+		return false, InvalidKey, nil
+	}
+
+	// step1. The account level api does not need to check any user policy and volume policy.
+	if IsAccountLevelApi(d.apiName) {
+		if d.anonymous {
+			return d.decide(SimulationStepAccountLevel, false, "anonymous user is not allowed by account level api"), nil, nil
 		}
-		if bucket := mux.Vars(r)[ContextKeyBucket]; len(bucket) > 0 {
-			if _, err = o.getVol(bucket); err != nil {
-				allowed = false
-				return
-			}
+		return d.decide(SimulationStepAccountLevel, true, "account level api is allowed for any user"), nil, nil
+	}
+	if d.checkBucket != nil {
+		if err = d.checkBucket(); err != nil {
+			return false, nil, d.fail(SimulationStepBucket, err)
 		}
+	}
 
-		// step2. Check user policy
-		userInfo := new(proto.UserInfo)
-		userPolicy := new(proto.UserPolicy)
-		isOwner := false
-		if isAnonymous(param.accessKey) && apiAllowAnonymous(param.apiName) {
-			log.LogDebugf("anonymous user: requestID(%v)", GetRequestID(r))
-			goto policycheck
+	// step2. Check user policy
+	var user *proto.UserInfo
+	uid, isOwner := "", false
+	if d.anonymous {
+		if !apiAllowAnonymous(d.apiName) {
+			return d.decide(SimulationStepPrincipal, false, "anonymous user is not allowed by api(%v)", d.apiName), nil, nil
 		}
-		if isAnonymous(param.accessKey) && !apiAllowAnonymous(param.apiName) {
-			log.LogErrorf("policyCheck: anonymous user is not allowed by api(%v) requestID(%v)",
-				param.apiName, GetRequestID(r))
-			allowed = false
-			return
-		}
-		userInfo, err = o.getUserInfoByAccessKey(param.AccessKey())
-		if err != nil {
-			log.LogErrorf("user policy check: load user policy from master fail: requestID(%v) accessKey(%v) err(%v)",
-				GetRequestID(r), param.AccessKey(), err)
-			allowed = false
-			return
+		d.step(SimulationStepPrincipal, SimulationStepPass, "anonymous user is allowed by api(%v), user policy is skipped", d.apiName)
+	} else {
+		if user, err = d.loadUser(); err != nil {
+			return false, nil, d.fail(SimulationStepPrincipal, err)
 		}
+		uid = user.UserID
+		d.trace.Principal = uid
 		// White list for admin and root user.
-		if userInfo.UserType == proto.UserTypeRoot || userInfo.UserType == proto.UserTypeAdmin {
-			log.LogDebugf("user policy check: user is admin: requestID(%v) userID(%v) accessKey(%v) volume(%v)",
-				GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket())
-			allowed = true
-			return
+		if user.UserType == proto.UserTypeRoot || user.UserType == proto.UserTypeAdmin {
+			return d.decide(SimulationStepUserPolicy, true, "user type(%v) is in the white list", user.UserType), nil, nil
+		}
+		userPolicy := user.Policy
+		if userPolicy == nil {
+			userPolicy = proto.NewUserPolicy()
 		}
-		userPolicy = userInfo.Policy
-		isOwner = userPolicy.IsOwn(param.Bucket())
+		isOwner = userPolicy.IsOwn(d.bucket)
 		// The bucket is not owned by request user who has not been authorized, so bucket policy should be checked.
-		if !isOwner && userPolicy.IsAuthorizedS3(param.Bucket(), param.apiName) {
-			log.LogInfof("user policy check:  permission url(%v) requestID(%v) userID(%v) accessKey(%v) volume(%v) object(%v) action(%v) authorizedVols(%v)",
-				r.URL, GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket(), param.Object(), param.Action(), userPolicy.AuthorizedVols)
-			allowed = true
-			return
+		if !isOwner && userPolicy.IsAuthorizedS3(d.bucket, d.apiName) {
+			return d.decide(SimulationStepUserPolicy, true, "api(%v) is authorized by the user policy", d.apiName), nil, nil
 		}
 		// copy api should check srcBucket policy additionally
-		if param.apiName == COPY_OBJECT || param.apiName == UPLOAD_PART_COPY {
-			err = o.allowedBySrcBucketPolicy(param, userInfo.UserID)
-			if err != nil {
-				return
+		if d.checkCopySource != nil {
+			if err = d.checkCopySource(uid); err != nil {
+				if err == AccessDenied {
+					return d.decide(SimulationStepCopySource, false, "copy source(%v) is not allowed", d.copySrcKey), nil, err
+				}
+				return false, nil, d.fail(SimulationStepCopySource, err)
 			}
+			d.step(SimulationStepCopySource, SimulationStepPass, "copy source(%v) is allowed", d.copySrcKey)
 		}
 		// batch delete will delay to check just before delete for each key
-		if param.apiName == BATCH_DELETE {
-			log.LogDebugf("user policy check: delete objects delay check: requestID(%v) userID(%v) volume(%v)",
-				GetRequestID(r), userInfo.UserID, param.Bucket())
-			allowed = true
-			return
+		if d.apiName == BATCH_DELETE {
+			return d.decide(SimulationStepUserPolicy, true, "api(%v) is checked for each key on deletion", d.apiName), nil, nil
 		}
+		if isOwner {
+			d.step(SimulationStepUserPolicy, SimulationStepPass, "user owns the bucket")
+		} else {
+			d.step(SimulationStepUserPolicy, SimulationStepPass, "bucket is neither owned nor authorized by the user policy")
+		}
+	}
 
-		// step3. Check bucket policy
-	policycheck:
-		vol, acl, policy, err := o.loadBucketMeta(param.Bucket())
-		if err != nil {
-			log.LogErrorf("bucket policy check: load bucket metadata fail: requestID(%v) err(%v)", GetRequestID(r), err)
-			allowed = false
-			return
+	// step3. Check bucket policy
+	owner, acl, policy, err := d.loadBucket()
+	if err != nil {
+		return false, nil, d.fail(SimulationStepBucketPolicy, err)
+	}
+	if policy == nil || policy.IsEmpty() {
+		d.step(SimulationStepBucketPolicy, SimulationStepPass, "no bucket policy")
+	} else {
+		conditionCheck := make(map[string]string, len(d.conditions)+1)
+		for k, v := range d.conditions {
+			conditionCheck[k] = v
 		}
-		log.LogDebugf("bucket policy check: load bucket metadata, requestID(%v) userPolicy(%v/%+v) vol(%v/%v) acl(%+v) policy(%+v)",
-			GetRequestID(r), userInfo.UserID, userInfo.Policy, vol.Name(), vol.GetOwner(), acl, policy)
-		if vol != nil && policy != nil && !policy.IsEmpty() {
-			log.LogDebugf("bucket policy check: requestID(%v) policy(%v)", GetRequestID(r), policy)
-			conditionCheck := map[string]string{
-				SOURCEIP: param.sourceIP,
-				REFERER:  param.r.Referer(),
-				HOST:     param.r.Host,
-			}
-			if !IsBucketApi(param.apiName) {
-				conditionCheck[KEYNAME] = param.object
-			}
-			pcr := policy.IsAllowed(param, userInfo.UserID, vol.owner, conditionCheck)
-			switch pcr {
-			case POLICY_ALLOW:
-				allowed = true
-				log.LogDebugf("bucket policy check: policy allowed: requestID(%v)", GetRequestID(r))
-				return
-			case POLICY_DENY:
-				allowed = false
-				log.LogWarnf("bucket policy check: policy not allowed: requestID(%v) ", GetRequestID(r))
-				return
-			case POLICY_UNKNOW:
-				// policy check result is unknown so that acl should be checked
-				log.LogWarnf("bucket policy check: policy unknown: requestID(%v) ", GetRequestID(r))
-			default:
-				// do nothing
-			}
+		if !IsBucketApi(d.apiName) {
+			conditionCheck[KEYNAME] = d.key
 		}
-
-		// step4. Check acl
-		if IsApiSupportByACL(param.Action()) {
-			if vol != nil && IsApiSupportByObjectAcl(param.Action()) {
-				if param.Object() == "" {
-					ec = InvalidKey
-					log.LogErrorf("acl check: no object key specified: requestID(%v) volume(%v) action(%v)",
-						GetRequestID(r), param.Bucket(), param.Action())
-					return
-				}
-				if acl, err = getObjectACL(vol, param.object, true); err != nil && err != syscall.ENOENT {
-					log.LogErrorf("acl check: get object acl fail: requestID(%v) volume(%v) action(%v) err(%v)",
-						GetRequestID(r), param.Bucket(), param.Action(), err)
-					return
-				}
-				err = nil
-			}
-			if acl == nil && !isOwner {
-				allowed = false
-				log.LogWarnf("acl check: empty acl disallows: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
-					GetRequestID(r), userInfo.UserID, vol.GetOwner(), param.Bucket(), param.Action())
-				return
-			}
-			if acl != nil && !acl.IsAllowed(userInfo.UserID, param.Action()) {
-				allowed = false
-				log.LogWarnf("acl check: acl not allowed: requestID(%v) reqUid(%v) acl(%+v) volume(%v) action(%v)",
-					GetRequestID(r), userInfo.UserID, acl, param.Bucket(), param.Action())
-				return
-			}
-		} else if !isOwner {
-			allowed = false
-			log.LogWarnf("acl check: action not support acl: requestID(%v) reqUid(%v) ownerUid(%v) volume(%v) action(%v)",
-				GetRequestID(r), userInfo.UserID, vol.GetOwner(), param.Bucket(), param.Action())
-			return
+		pcr, matched := policy.check(d.apiName, uid, owner, conditionCheck)
+		for _, statement := range matched {
+			d.trace.MatchedStatements = append(d.trace.MatchedStatements, &SimulationStatement{Sid: statement.Sid, Effect: statement.Effect})
+		}
+		switch {
+		case isPolicyApi(d.apiName) && pcr == POLICY_ALLOW:
+			return d.decide(SimulationStepBucketPolicy, true, "bucket owner is allowed by policy api(%v)", d.apiName), nil, nil
+		case isPolicyApi(d.apiName):
+			return d.decide(SimulationStepBucketPolicy, false, "only bucket owner(%v) is allowed by policy api(%v)", owner, d.apiName), nil, nil
+		case pcr == POLICY_DENY:
+			return d.decide(SimulationStepBucketPolicy, false, "denied by the matched statements, explicit deny overrides allow"), nil, nil
+		case pcr == POLICY_ALLOW:
+			return d.decide(SimulationStepBucketPolicy, true, "allowed by the matched statements"), nil, nil
+		case !supportByPolicy(d.apiName):
+			d.step(SimulationStepBucketPolicy, SimulationStepPass, "api(%v) is not supported by bucket policy", d.apiName)
+		default:
+			// policy check result is unknown so that acl should be checked
+			d.step(SimulationStepBucketPolicy, SimulationStepPass, "no statement matches, acl is checked")
 		}
+	}
 
-		allowed = true
-		log.LogDebugf("bucket acl check: action allowed: requestID(%v) reqUid(%v) accessKey(%v) volume(%v) action(%v)",
-			GetRequestID(r), userInfo, param.AccessKey(), param.Bucket(), param.Action())
+	// step4. Check acl
+	if !IsApiSupportByACL(d.action) {
+		if !isOwner {
+			return d.decide(SimulationStepACL, false, "api(%v) is not supported by acl and user is not bucket owner", d.apiName), nil, nil
+		}
+		return d.decide(SimulationStepACL, true, "api(%v) is not supported by acl and user is bucket owner", d.apiName), nil, nil
+	}
+	if IsApiSupportByObjectAcl(d.action) {
+		if d.key == "" {
+			d.decide(SimulationStepACL, false, "api(%v) checks object acl but no key specified", d.apiName)
+			return false, InvalidKey, nil
+		}
+		if acl, err = d.loadObjectACL(); err != nil {
+			return false, nil, d.fail(SimulationStepACL, err)
+		}
+	}
+	if acl == nil {
+		if !isOwner {
+			return d.decide(SimulationStepACL, false, "empty acl disallows user who is not bucket owner"), nil, nil
+		}
+		return d.decide(SimulationStepACL, true, "empty acl allows bucket owner"), nil, nil
+	}
+	if !acl.IsAllowed(uid, d.action) {
+		return d.decide(SimulationStepACL, false, "no grant of acl allows permission(%v)", apiToPermission[d.action]), nil, nil
 	}
+	return d.decide(SimulationStepACL, true, "acl grants permission(%v)", apiToPermission[d.action]), nil, nil
 }
 
 func (o *ObjectNode) loadBucketMeta(bucket string) (vol *Volume, acl *AccessControlPolicy, policy *Policy, err error) {
@@ -340,12 +454,8 @@ func (o *ObjectNode) allowedBySrcBucketPolicy(param *RequestParam, reqUid string
 	paramCopy.apiName = GET_OBJECT
 	paramCopy.action = proto.OSSGetObjectAction
 	if vol != nil && policy != nil && !policy.IsEmpty() {
-		conditionCheck := map[string]string{
-			SOURCEIP: paramCopy.sourceIP,
-			KEYNAME:  srcKey,
-			REFERER:  paramCopy.r.Referer(),
-			HOST:     paramCopy.r.Host,
-		}
+		conditionCheck := paramCopy.ConditionValues(o.trustedProxies)
+		conditionCheck[KEYNAME] = srcKey
 		pcr := policy.IsAllowed(&paramCopy, reqUid, vol.owner, conditionCheck)
 		switch pcr {
 		case POLICY_ALLOW:
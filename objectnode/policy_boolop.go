@@ -0,0 +1,102 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+var invalidBoolValue = "value must be a boolean for %v condition"
+
+// Bool operation. It checks whether value by Key in given values map
+// is equal to the boolean condition value, such as aws:SecureTransport.
+type boolOp struct {
+	m map[Key]bool
+}
+
+// evaluates to check whether value by Key in given values is equal to condition value.
+func (op boolOp) evaluate(values map[string]string) bool {
+	for k, v := range op.m {
+		requestValue, ok := values[http.CanonicalHeaderKey(k.Name())]
+		if !ok {
+			requestValue = values[k.Name()]
+		}
+		b, err := strconv.ParseBool(requestValue)
+		if err != nil || b != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// returns condition key which is used by this condition operation.
+func (op boolOp) keys() KeySet {
+	keys := make(KeySet)
+	for key := range op.m {
+		keys.Add(key)
+	}
+	return keys
+}
+
+// returns "Bool" operator.
+func (op boolOp) operator() operator {
+	return boolean
+}
+
+// returns map representation of this operation.
+func (op boolOp) toMap() map[Key]ValueSet {
+	resultMap := make(map[Key]ValueSet)
+	for k, v := range op.m {
+		if !k.IsValid() {
+			return nil
+		}
+		resultMap[k] = NewValueSet(NewBoolValue(v))
+	}
+
+	return resultMap
+}
+
+// returns new Bool operation, value of the condition can be a boolean or a boolean string.
+func newBoolOp(m map[Key]ValueSet) (Operation, error) {
+	newMap := make(map[Key]bool)
+	for k, v := range m {
+		if len(v) != 1 {
+			return nil, fmt.Errorf(invalidBoolValue, boolean)
+		}
+		for value := range v {
+			var (
+				b   bool
+				err error
+			)
+			if value.GetType() == reflect.Bool {
+				b, err = value.GetBool()
+			} else {
+				var s string
+				if s, err = value.GetString(); err == nil {
+					b, err = strconv.ParseBool(s)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf(invalidBoolValue, boolean)
+			}
+			newMap[k] = b
+		}
+	}
+	return &boolOp{m: newMap}, nil
+}
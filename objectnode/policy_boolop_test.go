@@ -0,0 +1,59 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoolOpEvaluate(t *testing.T) {
+	secureOp, err := newBoolOp(map[Key]ValueSet{AWSSecureTransport: NewValueSet(NewBoolValue(true))})
+	require.NoError(t, err)
+	insecureOp, err := newBoolOp(map[Key]ValueSet{AWSSecureTransport: NewValueSet(NewStringValue("false"))})
+	require.NoError(t, err)
+	_, err = newBoolOp(map[Key]ValueSet{AWSSecureTransport: NewValueSet(NewStringValue("yes"))})
+	require.Error(t, err)
+
+	require.True(t, secureOp.evaluate(map[string]string{SECURETRANSPORT: "true"}))
+	require.False(t, secureOp.evaluate(map[string]string{SECURETRANSPORT: "false"}))
+	require.False(t, secureOp.evaluate(map[string]string{}))
+	require.True(t, insecureOp.evaluate(map[string]string{SECURETRANSPORT: "false"}))
+	require.False(t, insecureOp.evaluate(map[string]string{SECURETRANSPORT: "true"}))
+}
+
+func TestConditionWithRequestKeys(t *testing.T) {
+	data := `{
+		"Bool": {"aws:SecureTransport": "true"},
+		"IpAddress": {"aws:SourceIp": "192.168.1.0/24"},
+		"StringEquals": {"s3:prefix": ["", "home/"]}
+	}`
+	var condition Condition
+	require.NoError(t, json.Unmarshal([]byte(data), &condition))
+	require.NoError(t, condition.CheckValid())
+
+	values := map[string]string{SOURCEIP: "192.168.1.10", SECURETRANSPORT: "true", PREFIX: "home/"}
+	require.True(t, condition.Evaluate(values))
+	values[SECURETRANSPORT] = "false"
+	require.False(t, condition.Evaluate(values))
+	values[SECURETRANSPORT] = "true"
+	values[PREFIX] = "private/"
+	require.False(t, condition.Evaluate(values))
+	values[PREFIX] = ""
+	values[SOURCEIP] = "10.0.0.1"
+	require.False(t, condition.Evaluate(values))
+}
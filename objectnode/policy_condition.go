@@ -42,17 +42,23 @@ var (
 type operator string
 
 const (
-	stringLike    = "StringLike"
-	stringNotLike = "StringNotLike"
-	ipAddress     = "IpAddress"
-	notIPAddress  = "NotIpAddress"
+	stringLike      = "StringLike"
+	stringNotLike   = "StringNotLike"
+	stringEquals    = "StringEquals"
+	stringNotEquals = "StringNotEquals"
+	ipAddress       = "IpAddress"
+	notIPAddress    = "NotIpAddress"
+	boolean         = "Bool"
 )
 
 var supportedOperators = []operator{
 	stringLike,
 	stringNotLike,
+	stringEquals,
+	stringNotEquals,
 	ipAddress,
 	notIPAddress,
+	boolean,
 	// Add new conditions here.
 }
 
@@ -166,10 +172,13 @@ func (operations Condition) String() string {
 }
 
 var conditionOpMap = map[operator]func(map[Key]ValueSet) (Operation, error){
-	stringLike:    newStringLikeOp,
-	stringNotLike: newStringNotLikeOp,
-	ipAddress:     newIPAddressOp,
-	notIPAddress:  newNotIPAddressOp,
+	stringLike:      newStringLikeOp,
+	stringNotLike:   newStringNotLikeOp,
+	stringEquals:    newStringEqualsOp,
+	stringNotEquals: newStringNotEqualsOp,
+	ipAddress:       newIPAddressOp,
+	notIPAddress:    newNotIPAddressOp,
+	boolean:         newBoolOp,
 	// Add new conditions here.
 }
 
@@ -254,6 +263,8 @@ func (operations *Condition) parseOperations(operatorMap map[string]map[string]i
 			switch values.(type) {
 			case string:
 				valueSet.Add(NewStringValue(values.(string)))
+			case bool:
+				valueSet.Add(NewBoolValue(values.(bool)))
 			case []interface{}:
 				for _, value := range values.([]interface{}) {
 					if valueString, ok := value.(string); ok {
@@ -25,10 +25,13 @@ const (
 type ConditionEnum int
 
 const (
-	KEYNAME  = "KeyName"
-	SOURCEIP = "SourceIp"
-	REFERER  = "Referer"
-	HOST     = "Host"
+	KEYNAME         = "KeyName"
+	SOURCEIP        = "SourceIp"
+	REFERER         = "Referer"
+	HOST            = "Host"
+	SECURETRANSPORT = "SecureTransport"
+	USERAGENT       = "UserAgent"
+	PREFIX          = "prefix"
 )
 
 const (
@@ -88,7 +91,8 @@ var allowAnonymousActions = SliceString{ACTION_GET_OBJECT}
 var validBucketActions = SliceString{ACTION_LIST_BUCKET, ACTION_DELETE_BUCKET, ACTION_LIST_BUCKET_MULTIPART_UPLOADS, ACTION_GET_BUCKET_LOCATION, ACTION_PUT_OBJECT_LOCK_CFG, ACTION_GET_OBJECT_LOCK_CFG}
 
 func isPolicyApi(apiName string) bool {
-	return apiName == PUT_BUCKET_POLICY || apiName == GET_BUCKET_POLICY || apiName == DELETE_BUCKET_POLICY
+	return apiName == PUT_BUCKET_POLICY || apiName == GET_BUCKET_POLICY || apiName == DELETE_BUCKET_POLICY ||
+		apiName == SIMULATE_BUCKET_POLICY
 }
 
 func (list SliceString) Contain(v string) bool {
@@ -118,6 +118,7 @@ func (o *ObjectNode) putBucketPolicyHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	vol.metaLoader.storePolicy(policy)
+	o.reportBucketEvent(vol.Name())
 
 	w.WriteHeader(http.StatusNoContent)
 	return
@@ -151,6 +152,7 @@ func (o *ObjectNode) deleteBucketPolicyHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 	vol.metaLoader.storePolicy(nil)
+	o.reportBucketEvent(vol.Name())
 
 	w.WriteHeader(http.StatusNoContent)
 	return
@@ -34,12 +34,24 @@ const (
 
 	// AWSHost - key representing client's request host of any API, this is not standard AWS key
 	AWSHost Key = "aws:Host"
+
+	// AWSSecureTransport - key representing whether the request is sent by TLS of any API.
+	AWSSecureTransport Key = "aws:SecureTransport"
+
+	// AWSUserAgent - key representing User-Agent header of any API.
+	AWSUserAgent Key = "aws:UserAgent"
+
+	// S3Prefix - key representing prefix query parameter of ListBucket API.
+	S3Prefix Key = "s3:prefix"
 )
 
 var AllSupportedKeys = []Key{
 	AWSReferer,
 	AWSSourceIP,
 	AWSHost,
+	AWSSecureTransport,
+	AWSUserAgent,
+	S3Prefix,
 	// Add new supported condition keys.
 }
 
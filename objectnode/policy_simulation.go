@@ -0,0 +1,163 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ParamSimulatePrincipal       = "principal"
+	ParamSimulateAction          = "action"
+	ParamSimulateResource        = "resource"
+	ParamSimulateSourceIp        = "sourceIp"
+	ParamSimulateReferer         = "referer"
+	ParamSimulateHost            = "host"
+	ParamSimulateUserAgent       = "userAgent"
+	ParamSimulateSecureTransport = "secureTransport"
+)
+
+const (
+	SimulationDecisionAllow = "Allow"
+	SimulationDecisionDeny  = "Deny"
+
+	SimulationStepPass  = "Pass"
+	SimulationStepError = "Error"
+)
+
+const (
+	SimulationStepReservedKey  = "ReservedKey"
+	SimulationStepAccountLevel = "AccountLevelApi"
+	SimulationStepBucket       = "Bucket"
+	SimulationStepPrincipal    = "Principal"
+	SimulationStepUserPolicy   = "UserPolicy"
+	SimulationStepCopySource   = "CopySource"
+	SimulationStepBucketPolicy = "BucketPolicy"
+	SimulationStepACL          = "ACL"
+)
+
+type SimulationStep struct {
+	Name   string `xml:"Name"`
+	Result string `xml:"Result"`
+	Reason string `xml:"Reason"`
+}
+
+type SimulationStatement struct {
+	Sid    string `xml:"Sid"`
+	Effect string `xml:"Effect"`
+}
+
+// PolicySimulationResult is the decision of a request and the trace of the steps deciding it.
+type PolicySimulationResult struct {
+	XMLName           xml.Name               `xml:"PolicySimulationResult"`
+	Bucket            string                 `xml:"Bucket"`
+	Principal         string                 `xml:"Principal"`
+	Action            string                 `xml:"Action"`
+	Key               string                 `xml:"Key,omitempty"`
+	Decision          string                 `xml:"Decision"`
+	DecidedBy         string                 `xml:"DecidedBy"`
+	Steps             []*SimulationStep      `xml:"Steps>Step"`
+	MatchedStatements []*SimulationStatement `xml:"MatchedStatements>Statement,omitempty"`
+}
+
+// Simulate bucket policy
+// Notes: CubeFS owned API, GET /?policySimulation&principal=<uid>&action=<api>&resource=<key>
+// It explains how the access of the principal is decided by the user policy, bucket
+// policy and acl without doing the access, the empty principal means anonymous user.
+func (o *ObjectNode) simulateBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	query := r.URL.Query()
+	apiName := strings.TrimPrefix(query.Get(ParamSimulateAction), S3_ACTION_PREFIX)
+	action := proto.ParseAction(proto.OSSActionPrefix + apiName)
+	if apiName == "" || action.IsNone() {
+		log.LogErrorf("simulateBucketPolicyHandler: invalid action: requestID(%v) volume(%v) action(%v)",
+			GetRequestID(r), param.Bucket(), query.Get(ParamSimulateAction))
+		errorCode = InvalidArgument
+		return
+	}
+	key := strings.TrimPrefix(query.Get(ParamSimulateResource), S3_RESOURCE_PREFIX)
+	if strings.HasPrefix(key, param.Bucket()+"/") || key == param.Bucket() {
+		key = strings.TrimPrefix(key, param.Bucket())
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	var user *proto.UserInfo
+	if principal := query.Get(ParamSimulatePrincipal); principal != "" {
+		if user, err = o.mc.UserAPI().GetUserInfo(principal); err != nil {
+			log.LogErrorf("simulateBucketPolicyHandler: get user info fail: requestID(%v) volume(%v) principal(%v) err(%v)",
+				GetRequestID(r), param.Bucket(), principal, err)
+			if err == proto.ErrUserNotExists {
+				err, errorCode = nil, InvalidArgument
+			}
+			return
+		}
+	}
+
+	conditions := map[string]string{
+		SOURCEIP:        query.Get(ParamSimulateSourceIp),
+		REFERER:         query.Get(ParamSimulateReferer),
+		HOST:            query.Get(ParamSimulateHost),
+		USERAGENT:       query.Get(ParamSimulateUserAgent),
+		SECURETRANSPORT: query.Get(ParamSimulateSecureTransport),
+	}
+	if IsBucketApi(apiName) {
+		conditions[PREFIX] = query.Get(ParamPrefix)
+	}
+	d := o.newPolicyDecision(param.Bucket(), key, apiName, action, conditions)
+	d.anonymous = user == nil
+	d.checkBucket = func() (err error) {
+		_, err = o.getVol(param.Bucket())
+		return
+	}
+	d.loadUser = func() (*proto.UserInfo, error) {
+		return user, nil
+	}
+	// the denied requests with the error codes are decided as well
+	if _, _, err = d.evaluate(); err != nil {
+		log.LogErrorf("simulateBucketPolicyHandler: evaluate fail: requestID(%v) volume(%v) action(%v) key(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), apiName, key, err)
+		return
+	}
+	result := d.trace
+	log.LogInfof("simulateBucketPolicyHandler: requestID(%v) volume(%v) principal(%v) action(%v) key(%v) decision(%v) decidedBy(%v)",
+		GetRequestID(r), param.Bucket(), result.Principal, apiName, key, result.Decision, result.DecidedBy)
+
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("simulateBucketPolicyHandler: xml marshal fail: requestID(%v) volume(%v) result(%+v) err(%v)",
+			GetRequestID(r), param.Bucket(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicySimulationEvaluate(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Sid": "AllowRead", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*"},
+			{"Sid": "DenySecret", "Effect": "Deny", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/secret/*"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	owner := &proto.UserInfo{UserID: "owner", UserType: proto.UserTypeNormal, Policy: proto.NewUserPolicy()}
+	owner.Policy.AddOwnVol("bucket")
+	guest := &proto.UserInfo{UserID: "guest", UserType: proto.UserTypeNormal, Policy: proto.NewUserPolicy()}
+	admin := &proto.UserInfo{UserID: "admin", UserType: proto.UserTypeAdmin}
+	acl := &AccessControlPolicy{Owner: Owner{Id: "owner"}}
+
+	newDecision := func(user *proto.UserInfo, action proto.Action, key string) *policyDecision {
+		return &policyDecision{
+			bucket:     "bucket",
+			key:        key,
+			apiName:    string(action[len(proto.OSSActionPrefix):]),
+			action:     action,
+			anonymous:  user == nil,
+			conditions: map[string]string{},
+			loadUser: func() (*proto.UserInfo, error) {
+				return user, nil
+			},
+			loadBucket: func() (string, *AccessControlPolicy, *Policy, error) {
+				return "owner", acl, policy, nil
+			},
+			loadObjectACL: func() (*AccessControlPolicy, error) {
+				return acl, nil
+			},
+		}
+	}
+	newSim := func(user *proto.UserInfo, action proto.Action, key string) *PolicySimulationResult {
+		d := newDecision(user, action, key)
+		allowed, ec, err := d.evaluate()
+		require.NoError(t, err)
+		require.Nil(t, ec)
+		require.Equal(t, allowed, d.trace.Decision == SimulationDecisionAllow)
+		return d.trace
+	}
+
+	result := newSim(guest, proto.OSSGetObjectAction, "public/a.txt")
+	require.Equal(t, SimulationDecisionAllow, result.Decision)
+	require.Equal(t, SimulationStepBucketPolicy, result.DecidedBy)
+	require.Len(t, result.MatchedStatements, 1)
+	require.Equal(t, "AllowRead", result.MatchedStatements[0].Sid)
+
+	// explicit deny overrides allow
+	result = newSim(guest, proto.OSSGetObjectAction, "secret/b.txt")
+	require.Equal(t, SimulationDecisionDeny, result.Decision)
+	require.Equal(t, SimulationStepBucketPolicy, result.DecidedBy)
+	require.Len(t, result.MatchedStatements, 2)
+
+	// no statement matches, the acl only allows the owner
+	result = newSim(guest, proto.OSSPutObjectAction, "public/a.txt")
+	require.Equal(t, SimulationDecisionDeny, result.Decision)
+	require.Equal(t, SimulationStepACL, result.DecidedBy)
+	result = newSim(owner, proto.OSSPutObjectAction, "public/a.txt")
+	require.Equal(t, SimulationDecisionAllow, result.Decision)
+	require.Equal(t, SimulationStepACL, result.DecidedBy)
+
+	// the admin is in the white list, the policy apis are only allowed for the owner
+	result = newSim(admin, proto.OSSPutObjectAction, "secret/b.txt")
+	require.Equal(t, SimulationDecisionAllow, result.Decision)
+	require.Equal(t, SimulationStepUserPolicy, result.DecidedBy)
+	result = newSim(guest, proto.OSSGetBucketPolicyAction, "")
+	require.Equal(t, SimulationDecisionDeny, result.Decision)
+	require.Equal(t, SimulationStepBucketPolicy, result.DecidedBy)
+
+	// anonymous user is only allowed by the anonymous apis
+	result = newSim(nil, proto.OSSPutObjectAction, "public/a.txt")
+	require.Equal(t, SimulationDecisionDeny, result.Decision)
+	require.Equal(t, SimulationStepPrincipal, result.DecidedBy)
+	result = newSim(nil, proto.OSSGetObjectAction, "public/a.txt")
+	require.Equal(t, SimulationDecisionAllow, result.Decision)
+	require.Equal(t, SimulationStepBucketPolicy, result.DecidedBy)
+
+	// the denied requests with the error codes
+	d := newDecision(guest, proto.OSSGetObjectAction, "")
+	d.loadBucket = func() (string, *AccessControlPolicy, *Policy, error) {
+		return "owner", acl, nil, nil
+	}
+	allowed, ec, err := d.evaluate()
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, InvalidKey, ec)
+	require.Equal(t, SimulationStepACL, d.trace.DecidedBy)
+	d = newDecision(guest, proto.OSSGetObjectAction, SoftDeleteDirName+"/a.txt")
+	allowed, ec, _ = d.evaluate()
+	require.False(t, allowed)
+	require.Equal(t, InvalidKey, ec)
+	require.Equal(t, SimulationStepReservedKey, d.trace.DecidedBy)
+
+	// the source of the copy apis is checked for the user
+	d = newDecision(owner, proto.OSSCopyObjectAction, "public/a.txt")
+	d.copySrcKey = "src.txt"
+	d.checkCopySource = func(uid string) error {
+		require.Equal(t, "owner", uid)
+		return AccessDenied
+	}
+	allowed, _, err = d.evaluate()
+	require.False(t, allowed)
+	require.Equal(t, AccessDenied, err)
+	require.Equal(t, SimulationStepCopySource, d.trace.DecidedBy)
+
+	// the errors fail the evaluation
+	d = newDecision(owner, proto.OSSPutObjectAction, "public/a.txt")
+	d.loadBucket = func() (string, *AccessControlPolicy, *Policy, error) {
+		return "", nil, nil, NoSuchBucket
+	}
+	allowed, _, err = d.evaluate()
+	require.False(t, allowed)
+	require.Equal(t, NoSuchBucket, err)
+	require.Equal(t, SimulationStepError, d.trace.Steps[len(d.trace.Steps)-1].Result)
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringEqualsOpEvaluate(t *testing.T) {
+	prefixOp, err := newStringEqualsOp(map[Key]ValueSet{S3Prefix: NewValueSet(NewStringValue(""), NewStringValue("home/"))})
+	require.NoError(t, err)
+	agentOp, err := newStringNotEqualsOp(map[Key]ValueSet{AWSUserAgent: NewValueSet(NewStringValue("bad-agent"))})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		operation      Operation
+		values         map[string]string
+		expectedResult bool
+	}{
+		{prefixOp, map[string]string{PREFIX: "home/"}, true},
+		{prefixOp, map[string]string{PREFIX: ""}, true},
+		{prefixOp, map[string]string{PREFIX: "home/user"}, false},
+		{prefixOp, map[string]string{}, true},
+
+		{agentOp, map[string]string{USERAGENT: "bad-agent"}, false},
+		{agentOp, map[string]string{USERAGENT: "aws-cli/2.0"}, true},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.operation.evaluate(testCase.values)
+		require.Equal(t, testCase.expectedResult, result, "case %v", i+1)
+	}
+}
+
+func TestStringEqualsOpToMap(t *testing.T) {
+	op, err := newStringEqualsOp(map[Key]ValueSet{S3Prefix: NewValueSet(NewStringValue("home/"))})
+	require.NoError(t, err)
+	require.Equal(t, operator(stringEquals), op.operator())
+	require.Equal(t, map[Key]ValueSet{S3Prefix: NewValueSet(NewStringValue("home/"))}, op.toMap())
+}
@@ -89,6 +89,13 @@ var (
 	NoContentMd5HeaderErr               = &ErrorCode{"NoContentMd5Header", "Content-MD5 HTTP header is required for Upload Object/Part requests with Object Lock parameters", http.StatusBadRequest}
 	ObjectLockConfigurationNotFound     = &ErrorCode{"ObjectLockConfigurationNotFoundError", "Object Lock configuration does not exist for this bucket", http.StatusNotFound}
 	TooManyRequests                     = &ErrorCode{"TooManyRequests", "too many requests, please retry later", http.StatusTooManyRequests}
+	NoSuchSoftDeleteConfiguration       = &ErrorCode{"NoSuchSoftDeleteConfiguration", "The soft delete configuration does not exist", http.StatusNotFound}
+	NoSuchSoftDeletedObject             = &ErrorCode{"NoSuchSoftDeletedObject", "The specified soft deleted object does not exist", http.StatusNotFound}
+	KeyAlreadyExists                    = &ErrorCode{"KeyAlreadyExists", "An object with the same key already exists", http.StatusConflict}
+	NoSuchVerifyJob                     = &ErrorCode{"NoSuchVerifyJob", "The bucket has not been verified", http.StatusNotFound}
+	VerifyJobRunning                    = &ErrorCode{"VerifyJobRunning", "A verify job of the bucket is running", http.StatusConflict}
+	TooManyVerifyJobs                   = &ErrorCode{"TooManyVerifyJobs", "Too many verify jobs are running, please retry later", http.StatusServiceUnavailable}
+	SlowDown                            = &ErrorCode{"SlowDown", "Please reduce your request rate.", http.StatusServiceUnavailable}
 	MalformedPOSTRequest                = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
 )
 
@@ -26,6 +26,9 @@ import (
 func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 	var bucketRouters []*mux.Router
 	bRouter := router.PathPrefix("/").Subrouter()
+	if o.customDomains != nil {
+		bucketRouters = append(bucketRouters, bRouter.MatcherFunc(o.customDomainMatcher).Subrouter())
+	}
 	for _, d := range o.domains {
 		bucketRouters = append(bucketRouters, bRouter.Host("{bucket:.+}."+d).Subrouter())
 		bucketRouters = append(bucketRouters, bRouter.Host("{bucket:.+}."+d+":{port:[0-9]+}").Subrouter())
@@ -55,6 +58,41 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("object-lock", "").
 			HandlerFunc(o.getObjectLockConfigurationHandler)
 
+		// Get bucket soft delete configuration
+		// Notes: CubeFS owned API for soft delete
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketSoftDeleteAction)).
+			Methods(http.MethodGet).
+			Queries("softdelete", "").
+			HandlerFunc(o.getBucketSoftDeleteHandler)
+
+		// List soft deleted objects
+		// Notes: CubeFS owned API for soft delete
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListSoftDeletedObjectsAction)).
+			Methods(http.MethodGet).
+			Queries("softdeleted", "").
+			HandlerFunc(o.listSoftDeletedObjectsHandler)
+
+		// Simulate bucket policy
+		// Notes: CubeFS owned API for explaining the access decision of bucket policy and acl
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSimulateBucketPolicyAction)).
+			Methods(http.MethodGet).
+			Queries("policySimulation", "").
+			HandlerFunc(o.simulateBucketPolicyHandler)
+
+		// Get bucket verify report
+		// Notes: CubeFS owned API for verifying the objects against their ETags
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketVerifyAction)).
+			Methods(http.MethodGet).
+			Queries("verify", "").
+			HandlerFunc(o.getBucketVerifyHandler)
+
+		// Get bucket archive
+		// Notes: CubeFS owned API for downloading the objects under a prefix as a tar or zip archive
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketArchiveAction)).
+			Methods(http.MethodGet).
+			Queries("archive", "").
+			HandlerFunc(o.getBucketArchiveHandler)
+
 		// List parts
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListParts.html
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListPartsAction)).
@@ -289,6 +327,14 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("restore", "").
 			HandlerFunc(o.unsupportedOperationHandler)
 
+		// Recover soft deleted object
+		// Notes: CubeFS owned API for soft delete
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSRecoverSoftDeletedObjectAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries("recover", "").
+			HandlerFunc(o.recoverSoftDeletedObjectHandler)
+
 		// Delete objects (multiple objects)
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjects.html
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteObjectsAction)).
@@ -296,6 +342,13 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("delete", "").
 			HandlerFunc(o.deleteObjectsHandler)
 
+		// Start bucket verify job
+		// Notes: CubeFS owned API for verifying the objects against their ETags
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSStartBucketVerifyAction)).
+			Methods(http.MethodPost).
+			Queries("verify", "").
+			HandlerFunc(o.startBucketVerifyHandler)
+
 		// Post object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPostObjectAction)).
@@ -311,6 +364,13 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("object-lock", "").
 			HandlerFunc(o.putObjectLockConfigurationHandler)
 
+		// Put bucket soft delete configuration
+		// Notes: CubeFS owned API for soft delete
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketSoftDeleteAction)).
+			Methods(http.MethodPut).
+			Queries("softdelete", "").
+			HandlerFunc(o.putBucketSoftDeleteHandler)
+
 		// Upload part copy
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_UploadPartCopy.html
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSUploadPartCopyAction)).
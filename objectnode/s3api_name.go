@@ -91,4 +91,12 @@ const (
 	LIST_PARTS                 = "ListParts"                  // api:  GET /<ObjectName>?uploadId=<Id> , host=<bucket>.domain
 	COMPLETE_MULTIPART_UPLOAD  = "CompleteMultipartUpload"    // api:  POST /<ObjectName>?uploadId=<Id> , host=<bucket>.domain
 	ABORT_MULTIPART_UPLOAD     = "AbortMultipartUpload"       // api:  DELETE /<ObjectName>?uploadId=<Id> , host=<bucket>.domain
+	PUT_BUCKET_SOFT_DELETE     = "PutBucketSoftDelete"        // api:  PUT /?softdelete , host=<bucket>.domain
+	GET_BUCKET_SOFT_DELETE     = "GetBucketSoftDelete"        // api:  GET /?softdelete , host=<bucket>.domain
+	LIST_SOFT_DELETED_OBJECTS  = "ListSoftDeletedObjects"     // api:  GET /?softdeleted , host=<bucket>.domain
+	RECOVER_SOFT_DELETED       = "RecoverSoftDeletedObject"   // api:  POST /<ObjectName>?recover , host=<bucket>.domain
+	SIMULATE_BUCKET_POLICY     = "SimulateBucketPolicy"       // api:  GET /?policySimulation , host=<bucket>.domain
+	START_BUCKET_VERIFY        = "StartBucketVerify"          // api:  POST /?verify , host=<bucket>.domain
+	GET_BUCKET_VERIFY          = "GetBucketVerify"            // api:  GET /?verify , host=<bucket>.domain
+	GET_BUCKET_ARCHIVE         = "GetBucketArchive"           // api:  GET /?archive , host=<bucket>.domain
 )
@@ -16,6 +16,7 @@ package objectnode
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
@@ -154,6 +155,134 @@ const (
 
 	// s3 QoS config refresh interval
 	s3QoSRefreshIntervalSec = "s3QoSRefreshIntervalSec"
+
+	// Int type configuration item, used to configure the interval in seconds to poll the bucket
+	// events of master, by which the cached bucket infos, bucket configs and users are invalidated.
+	// Example:
+	//		{
+	//			"bucketEventIntervalSec": 1
+	//		}
+	configBucketEventIntervalSec = "bucketEventIntervalSec"
+
+	// Map type configuration item, used to route the buckets to several backing clusters, the buckets
+	// not routed are on the cluster of masterAddr. For detailed parameters, see the ClusterRoutingConfig
+	// structure.
+	// Example:
+	//		{
+	//			"clusterRouting": {
+	//				"clusters": [
+	//					{
+	//						"name": "hdd",
+	//						"masterAddr": ["master1.hdd.cube.io", "master2.hdd.cube.io"],
+	//						"region": "cn-hdd"
+	//					}
+	//				],
+	//				"buckets": [
+	//					{"bucket": "logs-*", "cluster": "hdd"}
+	//				],
+	//				"discover": true
+	//			}
+	//		}
+	configClusterRouting = "clusterRouting"
+
+	// Int type configuration item, used to configure the interval in seconds to poll the mount map
+	// of master, by which the objects under the prefixes mounted from other volumes are served by
+	// the volumes. The mount map is not used if it is not configured.
+	// Example:
+	//		{
+	//			"namespaceMountIntervalSec": 10
+	//		}
+	configNamespaceMountIntervalSec = "namespaceMountIntervalSec"
+
+	// Map type configuration item, used to serve the buckets by the custom domains bound in the cert
+	// store, the domains are served by https on tlsListen with the certificates of the domains. The
+	// bindings and the certificates are reloaded every reloadIntervalSec. For detailed parameters, see
+	// the CustomDomainConfig structure.
+	// Example:
+	//		{
+	//			"customDomain": {
+	//				"tlsListen": "443",
+	//				"certStore": "/cfs/objectnode/certs",
+	//				"reloadIntervalSec": 60
+	//			}
+	//		}
+	configCustomDomain = "customDomain"
+
+	// Map type configuration item, used to verify the objects of the buckets against their ETags by
+	// the jobs started by the StartBucketVerify api. The completion reports are signed by signKey,
+	// and the repair tickets are posted to ticketWebhook. For detailed parameters, see the
+	// ObjectVerifyConfig structure.
+	// Example:
+	//		{
+	//			"objectVerify": {
+	//				"signKey": "xxx",
+	//				"readFlowMB": 100,
+	//				"maxJobs": 2,
+	//				"ticketWebhook": {
+	//					"endpoint": "http://repair.cube.io/tickets"
+	//				}
+	//			}
+	//		}
+	configObjectVerify = "objectVerify"
+
+	// Map type configuration item, used to shed the requests with 503 and Retry-After by their
+	// priorities when the objectnode is saturated, the admission control is disabled if it is not
+	// configured. For detailed parameters, see the AdmissionConfig structure.
+	// Example:
+	//		{
+	//			"admission": {
+	//				"maxGoroutines": 100000,
+	//				"maxInflight": 20000,
+	//				"maxUploads": 5000,
+	//				"maxLatencyMs": 500,
+	//				"retryAfterSec": 1,
+	//				"apiPriorities": {
+	//					"HeadObject": "high",
+	//					"GetObject": "low"
+	//				}
+	//			}
+	//		}
+	configAdmission = "admission"
+
+	// Map type configuration item, used to limit the archives of the objects streamed by the
+	// GetBucketArchive api. For detailed parameters, see the ArchiveConfig structure.
+	// Example:
+	//		{
+	//			"archive": {
+	//				"maxStreams": 8,
+	//				"maxZipEntries": 100000
+	//			}
+	//		}
+	configArchive = "archive"
+
+	// Map type configuration item, used to limit the anonymous requests separately from the
+	// authenticated ones, and to judge them by the request reputations to throttle or deny the
+	// bots scraping the public buckets. For detailed parameters, see the AnonymousLimitConfig
+	// structure.
+	// Example:
+	//		{
+	//			"anonymousLimit": {
+	//				"qps": 2000,
+	//				"perBucketQps": 500,
+	//				"perIPQps": 20,
+	//				"throttledQps": 1,
+	//				"maxConcurrent": 500,
+	//				"reputations": [
+	//					{"name": "userAgent", "config": {"deny": ["^$"], "throttle": ["(?i)(bot|crawler|spider)"]}},
+	//					{"name": "webhook", "config": {"endpoint": "http://reputation.cube.io/judge", "cacheSec": 60}}
+	//				]
+	//			}
+	//		}
+	configAnonymousLimit = "anonymousLimit"
+
+	// String slice type configuration item, used to configure the CIDRs or the ips of the reverse
+	// proxies in front of the ObjectNode, only the X-Real-Ip and X-Forwarded-For headers of the
+	// requests from which are trusted to limit the anonymous requests by the client ips.
+	// Example:
+	//		{
+	//			"trustedProxies": ["10.0.0.0/24", "192.168.1.1"]
+	//		}
+	configTrustedProxies = "trustedProxies"
 )
 
 // Default of configuration value
@@ -164,6 +293,7 @@ const (
 	defaultMaxInodeAttrCacheNum   = 1000000
 	defaultS3QoSReloadIntervalSec = 300
 	defaultS3QoSConfName          = "s3qosInfo.conf"
+	defaultBucketEventIntervalSec = 1
 	// ebs
 	MaxSizePutOnce = int64(1) << 23
 )
@@ -187,7 +317,10 @@ type ObjectNode struct {
 	region     string
 	httpServer *http.Server
 	vm         *VolumeManager
-	mc         *master.MasterClient
+	mc         *master.MasterClient // client of the default cluster
+	router     *ClusterRouter
+	buckets    *BucketCache
+	mounts     *NamespaceMounts
 	state      uint32
 	wg         sync.WaitGroup
 	userStore  UserInfoStore
@@ -197,6 +330,19 @@ type ObjectNode struct {
 
 	closes []func() // close other resources after http server closed
 
+	customDomains *CustomDomains // buckets served by the custom domains
+	tlsListen     string
+	tlsServer     *http.Server
+
+	verifier *ObjectVerifier // verify jobs of the buckets
+
+	admission *AdmissionControl // shed the requests when saturated
+
+	anonymousLimit *AnonymousLimiter // limit the anonymous requests
+	trustedProxies TrustedProxies    // proxies whose forwarding headers are trusted
+
+	archiver *ObjectArchiver // archive downloads of the buckets
+
 	signatureIgnoredActions proto.Actions // signature ignored actions
 	disabledActions         proto.Actions // disabled actions
 	stsNotAllowedActions    proto.Actions // actions that are not accessible to STS users
@@ -240,6 +386,69 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	}
 	log.LogInfof("loadConfig: setup config: %v(%v)", configDomains, domains)
 
+	// parse custom domain config
+	if rawCustomDomain := cfg.GetValue(configCustomDomain); rawCustomDomain != nil {
+		if err = o.setCustomDomain(rawCustomDomain); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configCustomDomain, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configCustomDomain, rawCustomDomain)
+	}
+
+	// parse object verify config
+	if rawObjectVerify := cfg.GetValue(configObjectVerify); rawObjectVerify != nil {
+		if err = o.setObjectVerify(rawObjectVerify); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configObjectVerify, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v", configObjectVerify)
+	}
+
+	// parse admission config
+	if rawAdmission := cfg.GetValue(configAdmission); rawAdmission != nil {
+		var conf AdmissionConfig
+		if err = ParseJSONEntity(rawAdmission, &conf); err == nil {
+			o.admission, err = NewAdmissionControl(conf)
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configAdmission, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configAdmission, rawAdmission)
+	}
+
+	// parse trusted proxies
+	trustedProxies := cfg.GetStringSlice(configTrustedProxies)
+	if o.trustedProxies, err = ParseTrustedProxies(trustedProxies); err != nil {
+		err = fmt.Errorf("invalid %v configuration: %v", configTrustedProxies, err)
+		return
+	}
+	log.LogInfof("loadConfig: setup config: %v(%v)", configTrustedProxies, trustedProxies)
+
+	// parse anonymous limit config
+	if rawAnonymousLimit := cfg.GetValue(configAnonymousLimit); rawAnonymousLimit != nil {
+		var conf AnonymousLimitConfig
+		if err = ParseJSONEntity(rawAnonymousLimit, &conf); err == nil {
+			o.anonymousLimit, err = NewAnonymousLimiter(conf)
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configAnonymousLimit, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configAnonymousLimit, rawAnonymousLimit)
+	}
+
+	// parse archive config
+	var archiveConf ArchiveConfig
+	if rawArchive := cfg.GetValue(configArchive); rawArchive != nil {
+		if err = ParseJSONEntity(rawArchive, &archiveConf); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configArchive, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configArchive, rawArchive)
+	}
+	o.archiver = NewObjectArchiver(archiveConf)
+
 	// parse master config
 	masters := cfg.GetStringSlice(configMasterAddr)
 	if len(masters) == 0 {
@@ -291,9 +500,26 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	log.LogInfof("loadConfig: strict: %v", strict)
 	o.disableCreateBucketByS3 = cfg.GetBool(disableCreateBucketByS3)
 
-	o.mc = master.NewMasterClient(masters, false)
-	o.vm = NewVolumeManager(masters, strict)
+	// parse cluster routing config
+	var routing *ClusterRoutingConfig
+	if rawRouting := cfg.GetValue(configClusterRouting); rawRouting != nil {
+		routing = new(ClusterRoutingConfig)
+		if err = ParseJSONEntity(rawRouting, routing); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configClusterRouting, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configClusterRouting, rawRouting)
+	}
+	if o.router, err = NewClusterRouter(masters, routing); err != nil {
+		err = fmt.Errorf("invalid %v configuration: %v", configClusterRouting, err)
+		return
+	}
+	o.closes = append(o.closes, o.router.Close)
+
+	o.mc = o.router.Default().mc
+	o.vm = NewVolumeManager(o.router, strict)
 	o.userStore = NewUserInfoStore(masters, strict)
+	o.buckets = NewBucketCache(o.router, strict)
 
 	// parse inode cache
 	cacheEnable := cfg.GetBool(configObjMetaCache)
@@ -370,6 +596,41 @@ func (o *ObjectNode) setAuditLog(raw interface{}) error {
 	return nil
 }
 
+func (o *ObjectNode) setCustomDomain(raw interface{}) (err error) {
+	var conf CustomDomainConfig
+	if err = ParseJSONEntity(raw, &conf); err != nil {
+		return
+	}
+	if conf.CertStore == "" {
+		return errors.New("certStore is empty")
+	}
+	if conf.TLSListen != "" && !regexpListen.MatchString(conf.TLSListen) {
+		return errors.New("invalid tlsListen")
+	}
+	interval := conf.ReloadIntervalSec
+	if interval <= 0 {
+		interval = defaultCustomDomainReloadIntervalSec
+	}
+	if o.customDomains, err = NewCustomDomains(conf.CertStore, time.Duration(interval)*time.Second); err != nil {
+		return
+	}
+	o.tlsListen = conf.TLSListen
+	o.closes = append(o.closes, o.customDomains.Close)
+	return
+}
+
+func (o *ObjectNode) setObjectVerify(raw interface{}) (err error) {
+	var conf ObjectVerifyConfig
+	if err = ParseJSONEntity(raw, &conf); err != nil {
+		return
+	}
+	if o.verifier, err = NewObjectVerifier(conf); err != nil {
+		return
+	}
+	o.closes = append(o.closes, o.verifier.Close)
+	return
+}
+
 func handleStart(s common.Server, cfg *config.Config) (err error) {
 	o, ok := s.(*ObjectNode)
 	if !ok {
@@ -385,6 +646,7 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 		return
 	}
 	o.updateRegion(ci.Cluster)
+	o.router.initRegions(ci.Cluster)
 	log.LogInfof("handleStart: get cluster information: region(%v)", o.region)
 	if ci.EbsAddr != "" {
 		err = newEbsClient(ci, cfg)
@@ -416,6 +678,24 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 		o.limitMutex.Unlock()
 	}
 
+	// invalidate the caches by the bucket events of master
+	interval := cfg.GetIntWithDefault(configBucketEventIntervalSec, defaultBucketEventIntervalSec)
+	if interval <= 0 {
+		interval = defaultBucketEventIntervalSec
+	}
+	for _, cluster := range o.router.Clusters() {
+		watcher := NewBucketEventWatcher(cluster.mc, o.vm, o.buckets, o.userStore, time.Duration(interval)*time.Second)
+		o.closes = append(o.closes, watcher.Close)
+		log.LogInfof("handleStart: watch bucket events: cluster(%v) interval(%vs)", cluster, interval)
+	}
+
+	// route the objects to the volumes by the mount map of master
+	if interval := cfg.GetInt64(configNamespaceMountIntervalSec); interval > 0 {
+		o.mounts = NewNamespaceMounts(o.mc, time.Duration(interval)*time.Second)
+		o.closes = append(o.closes, o.mounts.Close)
+		log.LogInfof("handleStart: route objects by mount map: interval(%vs)", interval)
+	}
+
 	// start rest api
 	if err = o.startMuxRestAPI(); err != nil {
 		log.LogInfof("handleStart: start rest api fail: err(%v)", err)
@@ -458,9 +738,11 @@ func (o *ObjectNode) startMuxRestAPI() (err error) {
 		o.auditMiddleware,
 		o.expectMiddleware,
 		o.traceMiddleware,
+		o.admissionMiddleware,
 		o.authMiddleware,
 		o.corsMiddleware,
 		o.policyCheckMiddleware,
+		o.namespaceMiddleware,
 		o.contentMiddleware,
 	)
 
@@ -478,6 +760,27 @@ func (o *ObjectNode) startMuxRestAPI() (err error) {
 		}
 	}()
 	o.httpServer = server
+
+	// serve the custom domains by https with the certificates in the cert store
+	if o.customDomains != nil && o.tlsListen != "" {
+		tlsServer := &http.Server{
+			Addr:         ":" + o.tlsListen,
+			Handler:      router,
+			ReadTimeout:  5 * time.Minute,
+			WriteTimeout: 5 * time.Minute,
+			TLSConfig: &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: o.customDomains.GetCertificate,
+			},
+		}
+		go func() {
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil {
+				log.LogErrorf("startMuxRestAPI: start https server fail, err(%v)", err)
+				return
+			}
+		}()
+		o.tlsServer = tlsServer
+	}
 	return
 }
 
@@ -486,6 +789,10 @@ func (o *ObjectNode) shutdown() {
 		_ = o.httpServer.Shutdown(context.Background())
 		o.httpServer = nil
 	}
+	if o.tlsServer != nil {
+		_ = o.tlsServer.Shutdown(context.Background())
+		o.tlsServer = nil
+	}
 	// close other resources after http server closed
 	for _, f := range o.closes {
 		f()
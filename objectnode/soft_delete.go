@@ -0,0 +1,361 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The soft deleted objects are moved into the hidden directory under the bucket root,
+// named by the deletion time and the inode, so that they are sorted by the deletion time.
+const (
+	SoftDeleteDirName   = ".softdelete"
+	SoftDeleteSuspended = "Suspended"
+
+	MaxSoftDeleteConfigSize = 1 << 12
+	SoftDeletePurgeInterval = 10 * time.Minute
+
+	softDeleteReadLimit = 1000
+)
+
+var (
+	InvalidSoftDeleteStatusErr = errors.New("Status must be Enabled or Suspended")
+	InvalidSoftDeleteDaysErr   = fmt.Errorf("Days must be a positive integer not larger than %v", maximumRetentionDays)
+)
+
+// SoftDeleteConfiguration keeps the deleted objects recoverable for Days before they are purged.
+// The objects deleted while the configuration is Suspended are deleted permanently, the soft
+// deleted ones are still purged after Days.
+type SoftDeleteConfiguration struct {
+	XMLNS   string    `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName *xml.Name `xml:"SoftDeleteConfiguration" json:"-"`
+	Status  string    `xml:"Status" json:"status"`
+	Days    int64     `xml:"Days" json:"days"`
+}
+
+func (c *SoftDeleteConfiguration) CheckValid() error {
+	if c.Status != Enabled && c.Status != SoftDeleteSuspended {
+		return InvalidSoftDeleteStatusErr
+	}
+	if c.Days <= 0 || c.Days > maximumRetentionDays {
+		return InvalidSoftDeleteDaysErr
+	}
+	return nil
+}
+
+func (c *SoftDeleteConfiguration) IsEnabled() bool {
+	return c != nil && c.Status == Enabled
+}
+
+func (c *SoftDeleteConfiguration) Retention() time.Duration {
+	return time.Duration(nanosecondsPerDay * c.Days)
+}
+
+// parse SoftDeleteConfiguration from xml
+func ParseSoftDeleteConfigFromXML(data []byte) (*SoftDeleteConfiguration, error) {
+	config := SoftDeleteConfiguration{}
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return nil, NewError("InvalidSoftDeleteConfiguration", err.Error(), 400)
+	}
+	if err := config.CheckValid(); err != nil {
+		return nil, NewError("InvalidSoftDeleteConfiguration", err.Error(), 400)
+	}
+	return &config, nil
+}
+
+func storeSoftDelete(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSSoftDelete, bytes)
+}
+
+// isSoftDeleteReservedKey checks whether the key is under the hidden directory,
+// which is only accessible by the soft delete apis.
+func isSoftDeleteReservedKey(key string) bool {
+	key = strings.TrimPrefix(key, pathSep)
+	return key == SoftDeleteDirName || strings.HasPrefix(key, SoftDeleteDirName+pathSep)
+}
+
+func softDeleteEntryName(deleteTime time.Time, inode uint64) string {
+	return fmt.Sprintf("%020d_%d", deleteTime.UnixNano(), inode)
+}
+
+func parseSoftDeleteEntryName(name string) (deleteTime time.Time, ok bool) {
+	items := strings.SplitN(name, "_", 2)
+	if len(items) != 2 {
+		return
+	}
+	ts, err := strconv.ParseInt(items[0], 10, 64)
+	if err != nil {
+		return
+	}
+	return time.Unix(0, ts), true
+}
+
+type SoftDeletedObject struct {
+	Key        string
+	DeleteId   string
+	DeleteTime time.Time
+	Inode      uint64
+	Size       uint64
+	ETag       string
+}
+
+func (v *Volume) softDeleteDir(autoCreate bool) (inode uint64, err error) {
+	if autoCreate {
+		return v.recursiveMakeDirectory(SoftDeleteDirName + pathSep)
+	}
+	var mode uint32
+	if inode, mode, err = v.mw.Lookup_ll(rootIno, SoftDeleteDirName); err != nil {
+		return
+	}
+	if !os.FileMode(mode).IsDir() {
+		err = syscall.ENOTDIR
+	}
+	return
+}
+
+// softDeletePath moves the object into the hidden directory instead of deleting it.
+func (v *Volume) softDeletePath(parent, ino uint64, name, path string) (err error) {
+	var dirIno uint64
+	if dirIno, err = v.softDeleteDir(true); err != nil {
+		log.LogErrorf("softDeletePath: make soft delete dir fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	key := strings.TrimPrefix(path, pathSep)
+	if err = v.mw.XAttrSet_ll(ino, []byte(XAttrKeyOSSSoftDeleteKey), []byte(key)); err != nil {
+		log.LogErrorf("softDeletePath: set key fail: volume(%v) path(%v) inode(%v) err(%v)", v.name, path, ino, err)
+		return
+	}
+	entry := softDeleteEntryName(time.Now(), ino)
+	if err = v.mw.Rename_ll(parent, name, dirIno, entry, path, SoftDeleteDirName+pathSep+entry, false); err != nil {
+		log.LogErrorf("softDeletePath: rename fail: volume(%v) path(%v) entry(%v) err(%v)", v.name, path, entry, err)
+		return
+	}
+	log.LogInfof("softDeletePath: volume(%v) path(%v) inode(%v) entry(%v)", v.name, path, ino, entry)
+	return
+}
+
+// ListSoftDeletedObjects lists the soft deleted objects whose key has the prefix, in the order of deletion.
+func (v *Volume) ListSoftDeletedObjects(prefix, marker string, maxKeys uint64) (objects []*SoftDeletedObject,
+	nextMarker string, truncated bool, err error) {
+	if maxKeys == 0 {
+		return
+	}
+	var dirIno uint64
+	if dirIno, err = v.softDeleteDir(false); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	from := marker
+	for {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDirLimit_ll(dirIno, from, softDeleteReadLimit); err != nil {
+			return
+		}
+		var found []*SoftDeletedObject
+		if found, err = v.loadSoftDeletedObjects(children, from); err != nil {
+			return
+		}
+		for _, obj := range found {
+			if !strings.HasPrefix(obj.Key, prefix) {
+				continue
+			}
+			if uint64(len(objects)) >= maxKeys {
+				truncated = true
+				nextMarker = objects[len(objects)-1].DeleteId
+				return
+			}
+			objects = append(objects, obj)
+		}
+		if len(children) < softDeleteReadLimit {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
+func (v *Volume) loadSoftDeletedObjects(children []proto.Dentry, skip string) (objects []*SoftDeletedObject, err error) {
+	inodes := make([]uint64, 0, len(children))
+	for _, child := range children {
+		if child.Name != skip && os.FileMode(child.Type).IsRegular() {
+			inodes = append(inodes, child.Inode)
+		}
+	}
+	if len(inodes) == 0 {
+		return
+	}
+	var xattrs []*proto.XAttrInfo
+	if xattrs, err = v.mw.BatchGetXAttr(inodes, []string{XAttrKeyOSSSoftDeleteKey, XAttrKeyOSSETag}); err != nil {
+		return
+	}
+	xattrMap := make(map[uint64]*proto.XAttrInfo, len(xattrs))
+	for _, xattr := range xattrs {
+		xattrMap[xattr.Inode] = xattr
+	}
+	infoMap := make(map[uint64]*proto.InodeInfo, len(inodes))
+	for _, info := range v.mw.BatchInodeGet(inodes) {
+		infoMap[info.Inode] = info
+	}
+	for _, child := range children {
+		deleteTime, ok := parseSoftDeleteEntryName(child.Name)
+		xattr := xattrMap[child.Inode]
+		info := infoMap[child.Inode]
+		if child.Name == skip || !ok || xattr == nil || info == nil {
+			continue
+		}
+		key := string(xattr.Get(XAttrKeyOSSSoftDeleteKey))
+		if key == "" {
+			continue
+		}
+		objects = append(objects, &SoftDeletedObject{
+			Key:        key,
+			DeleteId:   child.Name,
+			DeleteTime: deleteTime,
+			Inode:      child.Inode,
+			Size:       info.Size,
+			ETag:       ParseETagValue(string(xattr.Get(XAttrKeyOSSETag))).ETag(),
+		})
+	}
+	return
+}
+
+// RecoverObject moves the soft deleted object back to its key. The latest deleted one is
+// recovered if deleteId is not specified. It fails with syscall.EEXIST if the key exists.
+func (v *Volume) RecoverObject(key, deleteId string) (err error) {
+	defer func() {
+		log.LogInfof("Audit: RecoverObject: volume(%v) key(%v) deleteId(%v) err(%v)", v.name, key, deleteId, err)
+	}()
+	key = strings.TrimPrefix(key, pathSep)
+	if key == "" || strings.HasSuffix(key, pathSep) {
+		return syscall.ENOENT
+	}
+	var dirIno uint64
+	if dirIno, err = v.softDeleteDir(false); err != nil {
+		return
+	}
+	var target *SoftDeletedObject
+	if deleteId != "" {
+		if _, ok := parseSoftDeleteEntryName(deleteId); !ok {
+			return syscall.ENOENT
+		}
+		var ino uint64
+		if ino, _, err = v.mw.Lookup_ll(dirIno, deleteId); err != nil {
+			return
+		}
+		var objects []*SoftDeletedObject
+		if objects, err = v.loadSoftDeletedObjects([]proto.Dentry{{Name: deleteId, Inode: ino, Type: DefaultFileMode}}, ""); err != nil {
+			return
+		}
+		if len(objects) == 1 && objects[0].Key == key {
+			target = objects[0]
+		}
+	} else {
+		var found []*SoftDeletedObject
+		if found, _, _, err = v.ListSoftDeletedObjects(key, "", ^uint64(0)); err != nil {
+			return
+		}
+		for _, obj := range found {
+			if obj.Key == key {
+				target = obj
+			}
+		}
+	}
+	if target == nil {
+		return syscall.ENOENT
+	}
+
+	var parent uint64
+	if parent, err = v.recursiveMakeDirectory(key); err != nil {
+		return
+	}
+	name := key[strings.LastIndex(key, pathSep)+1:]
+	if err = v.mw.Rename_ll(dirIno, target.DeleteId, parent, name, SoftDeleteDirName+pathSep+target.DeleteId, key, false); err != nil {
+		return
+	}
+	if err = v.mw.XAttrDel_ll(target.Inode, XAttrKeyOSSSoftDeleteKey); err != nil {
+		log.LogWarnf("RecoverObject: delete key xattr fail: volume(%v) key(%v) inode(%v) err(%v)", v.name, key, target.Inode, err)
+		err = nil
+	}
+	deleteDentryCache(parent, name, v.name)
+	deleteAttrCache(target.Inode, v.name)
+	return
+}
+
+// purgeSoftDeleted deletes the soft deleted objects which have been kept longer than the retention.
+func (v *Volume) purgeSoftDeleted() {
+	config, err := v.metaLoader.loadSoftDelete()
+	if err != nil || config == nil {
+		return
+	}
+	dirIno, err := v.softDeleteDir(false)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogWarnf("purgeSoftDeleted: lookup soft delete dir fail: volume(%v) err(%v)", v.name, err)
+		}
+		return
+	}
+	expireBefore := time.Now().Add(-config.Retention())
+	var purged int
+	defer func() {
+		if purged > 0 {
+			log.LogInfof("purgeSoftDeleted: volume(%v) purged(%v)", v.name, purged)
+		}
+	}()
+	from := ""
+	for {
+		var children []proto.Dentry
+		if children, err = v.mw.ReadDirLimit_ll(dirIno, from, softDeleteReadLimit); err != nil {
+			log.LogWarnf("purgeSoftDeleted: readdir fail: volume(%v) err(%v)", v.name, err)
+			return
+		}
+		for _, child := range children {
+			if child.Name == from {
+				continue
+			}
+			deleteTime, ok := parseSoftDeleteEntryName(child.Name)
+			if !ok || !os.FileMode(child.Type).IsRegular() {
+				continue
+			}
+			// the entries are sorted by the deletion time
+			if deleteTime.After(expireBefore) {
+				return
+			}
+			fullPath := SoftDeleteDirName + pathSep + child.Name
+			if _, err = v.mw.Delete_ll(dirIno, child.Name, false, fullPath); err != nil && err != syscall.ENOENT {
+				log.LogWarnf("purgeSoftDeleted: delete fail: volume(%v) entry(%v) err(%v)", v.name, child.Name, err)
+				continue
+			}
+			if err = v.mw.Evict(child.Inode, fullPath); err != nil {
+				log.LogWarnf("purgeSoftDeleted: evict fail: volume(%v) entry(%v) inode(%v) err(%v)", v.name, child.Name, child.Inode, err)
+			}
+			purged++
+		}
+		if len(children) < softDeleteReadLimit {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
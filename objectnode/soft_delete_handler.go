@@ -0,0 +1,298 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"syscall"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const ParamDeleteId = "deleteId"
+
+type SoftDeletedContent struct {
+	Key          string `xml:"Key"`
+	DeleteId     string `xml:"DeleteId"`
+	DeleteTime   string `xml:"DeleteTime"`
+	ExpireTime   string `xml:"ExpireTime"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type ListSoftDeletedResult struct {
+	XMLName     xml.Name              `xml:"ListSoftDeletedResult"`
+	Bucket      string                `xml:"Name"`
+	Prefix      string                `xml:"Prefix"`
+	Marker      string                `xml:"Marker"`
+	MaxKeys     int                   `xml:"MaxKeys"`
+	IsTruncated bool                  `xml:"IsTruncated"`
+	NextMarker  string                `xml:"NextMarker,omitempty"`
+	Contents    []*SoftDeletedContent `xml:"Contents"`
+}
+
+// Put bucket soft delete configuration
+// Notes: CubeFS owned API, PUT /?softdelete
+func (o *ObjectNode) putBucketSoftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketSoftDeleteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxSoftDeleteConfigSize+1)); err != nil {
+		log.LogErrorf("putBucketSoftDeleteHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxSoftDeleteConfigSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *SoftDeleteConfiguration
+	if config, err = ParseSoftDeleteConfigFromXML(body); err != nil {
+		log.LogErrorf("putBucketSoftDeleteHandler: parse soft delete config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	if body, err = json.Marshal(config); err != nil {
+		log.LogErrorf("putBucketSoftDeleteHandler: json.Marshal soft delete config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeSoftDelete(body, vol); err != nil {
+		log.LogErrorf("putBucketSoftDeleteHandler: store soft delete config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeSoftDelete(config)
+	o.reportBucketEvent(vol.Name())
+	log.LogInfof("Audit: put bucket soft delete: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+
+	w.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// Get bucket soft delete configuration
+// Notes: CubeFS owned API, GET /?softdelete
+func (o *ObjectNode) getBucketSoftDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketSoftDeleteHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *SoftDeleteConfiguration
+	if config, err = vol.metaLoader.loadSoftDelete(); err != nil {
+		log.LogErrorf("getBucketSoftDeleteHandler: load soft delete fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchSoftDeleteConfiguration
+		return
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("getBucketSoftDeleteHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
+
+// List soft deleted objects
+// Notes: CubeFS owned API, GET /?softdeleted&prefix=<prefix>&marker=<deleteId>&max-keys=<n>
+func (o *ObjectNode) listSoftDeletedObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listSoftDeletedObjectsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	marker := r.URL.Query().Get(ParamMarker)
+	prefix := r.URL.Query().Get(ParamPrefix)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+	maxKeysInt := uint64(MaxKeys)
+	if maxKeys != "" {
+		if maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 64); err != nil {
+			log.LogErrorf("listSoftDeletedObjectsHandler: parse max key fail: requestID(%v) volume(%v) maxKeys(%v) err(%v)",
+				GetRequestID(r), vol.Name(), maxKeys, err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	}
+
+	var config *SoftDeleteConfiguration
+	if config, err = vol.metaLoader.loadSoftDelete(); err != nil {
+		log.LogErrorf("listSoftDeletedObjectsHandler: load soft delete fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	objects, nextMarker, truncated, err := vol.ListSoftDeletedObjects(prefix, marker, maxKeysInt)
+	if err != nil {
+		log.LogErrorf("listSoftDeletedObjectsHandler: list fail: requestID(%v) volume(%v) prefix(%v) marker(%v) err(%v)",
+			GetRequestID(r), vol.Name(), prefix, marker, err)
+		return
+	}
+	contents := make([]*SoftDeletedContent, 0, len(objects))
+	for _, obj := range objects {
+		content := &SoftDeletedContent{
+			Key:          obj.Key,
+			DeleteId:     obj.DeleteId,
+			DeleteTime:   formatTimeISO(obj.DeleteTime),
+			ETag:         wrapUnescapedQuot(obj.ETag),
+			Size:         int(obj.Size),
+			StorageClass: StorageClassStandard,
+		}
+		if config != nil {
+			content.ExpireTime = formatTimeISO(obj.DeleteTime.Add(config.Retention()))
+		}
+		contents = append(contents, content)
+	}
+	result := &ListSoftDeletedResult{
+		Bucket:      param.Bucket(),
+		Prefix:      prefix,
+		Marker:      marker,
+		MaxKeys:     int(maxKeysInt),
+		IsTruncated: truncated,
+		NextMarker:  nextMarker,
+		Contents:    contents,
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("listSoftDeletedObjectsHandler: xml marshal fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+	return
+}
+
+// Recover soft deleted object
+// Notes: CubeFS owned API, POST /<ObjectName>?recover[&deleteId=<deleteId>]
+func (o *ObjectNode) recoverSoftDeletedObjectHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("recoverSoftDeletedObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	deleteId := r.URL.Query().Get(ParamDeleteId)
+	log.LogInfof("Audit: recover soft deleted object: requestID(%v) remote(%v) volume(%v) path(%v) deleteId(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), deleteId)
+
+	if err = vol.RecoverObject(param.Object(), deleteId); err != nil {
+		log.LogErrorf("recoverSoftDeletedObjectHandler: recover fail: requestID(%v) volume(%v) path(%v) deleteId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), deleteId, err)
+		switch err {
+		case syscall.ENOENT:
+			errorCode = NoSuchSoftDeletedObject
+		case syscall.EEXIST:
+			errorCode = KeyAlreadyExists
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteConfigCheckValid(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr error
+	}{
+		{
+			value: `<SoftDeleteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+						<Days>7</Days>
+					</SoftDeleteConfiguration>`,
+			expectedErr: nil,
+		},
+		{
+			value: `<SoftDeleteConfiguration>
+						<Status>Suspended</Status>
+						<Days>1</Days>
+					</SoftDeleteConfiguration>`,
+			expectedErr: nil,
+		},
+		{
+			value: `<SoftDeleteConfiguration>
+						<Status>enable</Status>
+						<Days>7</Days>
+					</SoftDeleteConfiguration>`,
+			expectedErr: InvalidSoftDeleteStatusErr,
+		},
+		{
+			value: `<SoftDeleteConfiguration>
+						<Status>Enabled</Status>
+					</SoftDeleteConfiguration>`,
+			expectedErr: InvalidSoftDeleteDaysErr,
+		},
+		{
+			value: `<SoftDeleteConfiguration>
+						<Status>Enabled</Status>
+						<Days>300000</Days>
+					</SoftDeleteConfiguration>`,
+			expectedErr: InvalidSoftDeleteDaysErr,
+		},
+	}
+
+	for _, tt := range tests {
+		config := SoftDeleteConfiguration{}
+		require.NoError(t, xml.Unmarshal([]byte(tt.value), &config))
+		require.Equal(t, tt.expectedErr, config.CheckValid())
+	}
+
+	_, err := ParseSoftDeleteConfigFromXML([]byte(`<SoftDeleteConfiguration><Days>7</Days>`))
+	require.Error(t, err)
+	config, err := ParseSoftDeleteConfigFromXML([]byte(tests[0].value))
+	require.NoError(t, err)
+	require.True(t, config.IsEnabled())
+	require.Equal(t, 7*24*time.Hour, config.Retention())
+
+	var nilConfig *SoftDeleteConfiguration
+	require.False(t, nilConfig.IsEnabled())
+}
+
+func TestSoftDeleteEntryName(t *testing.T) {
+	deleteTime := time.Unix(1700000000, 123456789)
+	name := softDeleteEntryName(deleteTime, 1024)
+	parsed, ok := parseSoftDeleteEntryName(name)
+	require.True(t, ok)
+	require.True(t, deleteTime.Equal(parsed))
+
+	// names of the earlier deleted entries sort first
+	require.Less(t, softDeleteEntryName(time.Unix(9, 0), 2), softDeleteEntryName(time.Unix(10, 0), 1))
+
+	for _, name := range []string{"", "1024", "abc_1024"} {
+		_, ok = parseSoftDeleteEntryName(name)
+		require.False(t, ok)
+	}
+}
+
+func TestIsSoftDeleteReservedKey(t *testing.T) {
+	for _, key := range []string{".softdelete", "/.softdelete", ".softdelete/", ".softdelete/a_1"} {
+		require.True(t, isSoftDeleteReservedKey(key), key)
+	}
+	for _, key := range []string{"", "a/.softdelete", ".softdeleted", "softdelete/a"} {
+		require.False(t, isSoftDeleteReservedKey(key), key)
+	}
+}
+
+func TestListSoftDeletedObjectsZeroMaxKeys(t *testing.T) {
+	objects, nextMarker, truncated, err := new(Volume).ListSoftDeletedObjects("", "", 0)
+	require.NoError(t, err)
+	require.Empty(t, objects)
+	require.Empty(t, nextMarker)
+	require.False(t, truncated)
+}
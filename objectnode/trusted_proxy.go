@@ -0,0 +1,115 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	XRealIP       = "X-Real-Ip"
+	XForwardedFor = "X-Forwarded-For"
+)
+
+// TrustedProxies are the networks of the reverse proxies in front of the ObjectNode, only the
+// forwarding headers of the requests from which are trusted.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses the CIDRs or the ips of the trusted proxies.
+func ParseTrustedProxies(cidrs []string) (proxies TrustedProxies, err error) {
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %v", cidr)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %v: %v", cidr, err)
+		}
+		proxies = append(proxies, ipnet)
+	}
+	return
+}
+
+func (t TrustedProxies) contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipnet := range t {
+		if ipnet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrustedProxy returns true if the request is sent by a trusted proxy.
+func (t TrustedProxies) isTrustedProxy(r *http.Request) bool {
+	return len(t) > 0 && t.contains(remoteIP(r))
+}
+
+// clientIP returns the ip of the client sending the request. The forwarding headers are only
+// trusted if the request is from a trusted proxy, in which case the rightmost ip not of the
+// trusted proxies in X-Forwarded-For is taken, since the ones on its left may be forged.
+func (t TrustedProxies) clientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	if !t.isTrustedProxy(r) {
+		return ip
+	}
+	if realIP := strings.TrimSpace(r.Header.Get(XRealIP)); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	forwarded := strings.Split(r.Header.Get(XForwardedFor), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !t.contains(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// secureTransport returns true if the request is sent by tls, or forwarded by a trusted proxy
+// which has received it by https. X-Forwarded-Proto of the other clients is ignored.
+func (t TrustedProxies) secureTransport(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return t.isTrustedProxy(r) && strings.EqualFold(strings.TrimSpace(r.Header.Get(XForwardedProto)), "https")
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
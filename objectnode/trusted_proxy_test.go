@@ -0,0 +1,112 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy"})
+	require.Error(t, err)
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/24", "192.168.1.1", "fd00::1"})
+	require.NoError(t, err)
+
+	newRequest := func(remoteAddr, realIP, forwarded string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/b/o", nil)
+		r.RemoteAddr = remoteAddr
+		if realIP != "" {
+			r.Header.Set(XRealIP, realIP)
+		}
+		if forwarded != "" {
+			r.Header.Set(XForwardedFor, forwarded)
+		}
+		return r
+	}
+
+	// the headers of the untrusted clients are ignored
+	r := newRequest("1.1.1.1:1234", "2.2.2.2", "3.3.3.3")
+	require.False(t, proxies.isTrustedProxy(r))
+	require.Equal(t, "1.1.1.1", proxies.clientIP(r))
+	require.Equal(t, "1.1.1.1", TrustedProxies(nil).clientIP(r))
+
+	// the headers of the trusted proxies are taken
+	require.Equal(t, "2.2.2.2", proxies.clientIP(newRequest("10.0.0.1:1234", "2.2.2.2", "3.3.3.3")))
+	require.Equal(t, "3.3.3.3", proxies.clientIP(newRequest("192.168.1.1:1234", "", "3.3.3.3")))
+	require.Equal(t, "fd00::1", proxies.clientIP(newRequest("[fd00::1]:1234", "", "")))
+
+	// the forged hops on the left of the client are skipped
+	require.Equal(t, "3.3.3.3", proxies.clientIP(newRequest("10.0.0.1:1234", "", "4.4.4.4, 3.3.3.3, 10.0.0.2")))
+	require.Equal(t, "10.0.0.3", proxies.clientIP(newRequest("10.0.0.1:1234", "", "10.0.0.3, 10.0.0.2")))
+	require.Equal(t, "10.0.0.2", proxies.clientIP(newRequest("10.0.0.1:1234", "", "forged, 10.0.0.2")))
+}
+
+func TestTrustedProxiesSecureTransport(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/24"})
+	require.NoError(t, err)
+	newRequest := func(remoteAddr, proto string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/b/o", nil)
+		r.RemoteAddr = remoteAddr
+		if proto != "" {
+			r.Header.Set(XForwardedProto, proto)
+		}
+		return r
+	}
+
+	// X-Forwarded-Proto of the untrusted clients is ignored
+	require.False(t, proxies.secureTransport(newRequest("1.1.1.1:1234", "https")))
+	require.False(t, TrustedProxies(nil).secureTransport(newRequest("10.0.0.1:1234", "https")))
+	// the proxies received the requests by https
+	require.True(t, proxies.secureTransport(newRequest("10.0.0.1:1234", "HTTPS")))
+	require.False(t, proxies.secureTransport(newRequest("10.0.0.1:1234", "http")))
+	require.False(t, proxies.secureTransport(newRequest("10.0.0.1:1234", "")))
+
+	r := newRequest("1.1.1.1:1234", "")
+	r.TLS = &tls.ConnectionState{}
+	require.True(t, proxies.secureTransport(r))
+	param := &RequestParam{r: r}
+	require.Equal(t, "true", param.ConditionValues(nil)[SECURETRANSPORT])
+	param.r = newRequest("1.1.1.1:1234", "https")
+	require.Equal(t, "false", param.ConditionValues(proxies)[SECURETRANSPORT])
+}
+
+func TestTrustedProxiesSourceIPCondition(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/24"})
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, "/b/o", nil)
+	r.RemoteAddr = "1.1.1.1:1234"
+	r.Header.Set(XRealIP, "192.168.0.1")
+	r.Header.Set(XForwardedFor, "192.168.0.1")
+	param := &RequestParam{r: r}
+
+	// the forged headers of the untrusted clients can not pass the ip allow-list
+	require.Equal(t, "1.1.1.1", param.ConditionValues(proxies)[SOURCEIP])
+	require.Equal(t, "1.1.1.1", param.ConditionValues(nil)[SOURCEIP])
+	op, err := newIPAddressOp(map[Key]ValueSet{AWSSourceIP: NewValueSet(NewStringValue("192.168.0.0/16"))})
+	require.NoError(t, err)
+	cond := Condition{op}
+	require.False(t, cond.Evaluate(param.ConditionValues(proxies)))
+
+	r.RemoteAddr = "10.0.0.1:1234"
+	require.Equal(t, "192.168.0.1", param.ConditionValues(proxies)[SOURCEIP])
+	require.True(t, cond.Evaluate(param.ConditionValues(proxies)))
+}
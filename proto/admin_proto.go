@@ -30,6 +30,7 @@ const (
 	AdminSetApiQpsLimit                       = "/admin/setApiQpsLimit"
 	AdminGetApiQpsLimit                       = "/admin/getApiQpsLimit"
 	AdminRemoveApiQpsLimit                    = "/admin/rmApiQpsLimit"
+	AdminSetApiCallerQuota                    = "/admin/setApiCallerQuota"
 	AdminGetCluster                           = "/admin/getCluster"
 	AdminSetClusterInfo                       = "/admin/setClusterInfo"
 	AdminGetMonitorPushAddr                   = "/admin/getMonitorPushAddr"
@@ -49,12 +50,29 @@ const (
 	AdminVolExpand                            = "/vol/expand"
 	AdminVolForbidden                         = "/vol/forbidden"
 	AdminVolEnableAuditLog                    = "/vol/auditlog"
+	AdminVolSetMetaDurability                 = "/vol/setMetaDurability"
+	AdminVolSetLabelSelector                  = "/vol/setLabelSelector"
+	AdminVolSetVerifyReadCrc                  = "/vol/setVerifyReadCrc"
+	AdminVolSetWriteBurstCredit               = "/vol/setWriteBurstCredit"
+	AdminVolSetMetaOpLimit                    = "/vol/setMetaOpLimit"
+	AdminVolSetExtentSizeClass                = "/vol/setExtentSizeClass"
+	AdminVolSetFullReadOnlyRatio              = "/vol/setFullReadOnlyRatio"
+	AdminCloneVol                             = "/vol/clone"
+	AdminDetachVolClone                       = "/vol/clone/detach"
 	AdminCreateVol                            = "/admin/createVol"
+	AdminAdviseCreateVol                      = "/admin/adviseCreateVol"
 	AdminGetVol                               = "/admin/getVol"
 	AdminClusterFreeze                        = "/cluster/freeze"
 	AdminClusterForbidMpDecommission          = "/cluster/forbidMetaPartitionDecommission"
 	AdminClusterStat                          = "/cluster/stat"
 	AdminSetCheckDataReplicasEnable           = "/cluster/setCheckDataReplicasEnable"
+	AdminSetDataPartitionSlo                  = "/admin/setDataPartitionSlo"
+	AdminGetDataPartitionSlo                  = "/admin/getDataPartitionSlo"
+	AdminSetCapability                        = "/admin/setCapability"
+	AdminGetCapabilities                      = "/admin/getCapabilities"
+	AdminSetMount                             = "/namespace/mount/set"
+	AdminRemoveMount                          = "/namespace/mount/remove"
+	AdminGetVolClientHealth                   = "/vol/clientHealth"
 	AdminGetIP                                = "/admin/getIp"
 	AdminCreateMetaPartition                  = "/metaPartition/create"
 	AdminSetMetaNodeThreshold                 = "/threshold/set"
@@ -67,9 +85,12 @@ const (
 	AdminUpdateNodeSetCapcity                 = "/admin/updateNodeSetCapcity"
 	AdminUpdateNodeSetId                      = "/admin/updateNodeSetId"
 	AdminUpdateNodeSetNodeSelector            = "/admin/updateNodeSetNodeSelector"
+	AdminSimulatePlacement                    = "/admin/simulatePlacement"
 	AdminUpdateDomainDataUseRatio             = "/admin/updateDomainDataRatio"
 	AdminUpdateZoneExcludeRatio               = "/admin/updateZoneExcludeRatio"
 	AdminSetNodeRdOnly                        = "/admin/setNodeRdOnly"
+	AdminSetNodeLabels                        = "/admin/setNodeLabels"
+	AdminGetPlacementViolations               = "/admin/getPlacementViolations"
 	AdminSetDpRdOnly                          = "/admin/setDpRdOnly"
 	AdminSetConfig                            = "/admin/setConfig"
 	AdminGetConfig                            = "/admin/getConfig"
@@ -116,7 +137,11 @@ const (
 	ClientVol            = "/client/vol"
 	ClientMetaPartition  = "/metaPartition/get"
 	ClientVolStat        = "/client/volStat"
+	ClientVolState       = "/client/volState"
 	ClientMetaPartitions = "/client/metaPartitions"
+	ClientReportErrors   = "/client/reportErrors"
+	ClientMountMap       = "/client/mountMap"
+	ClientCapabilities   = "/client/capabilities"
 
 	// qos api
 	QosGetStatus           = "/qos/getStatus"
@@ -186,6 +211,10 @@ const (
 	GetBucketLifecycle    = "/s3/getLifecycle"
 	DeleteBucketLifecycle = "/s3/deleteLifecycle"
 
+	// S3 bucket events APIS
+	ListBucketEvents  = "/s3/bucketEvents"
+	ReportBucketEvent = "/s3/reportBucketEvent"
+
 	AddLcNode = "/lcNode/add"
 
 	QueryDisableDisk = "/dataNode/queryDisableDisk"
@@ -214,6 +243,9 @@ const (
 	UserDeleteVolPolicy = "/user/deleteVolPolicy"
 	UserGetInfo         = "/user/info"
 	UserGetAKInfo       = "/user/akInfo"
+	UserSetPosix        = "/user/setPosix"
+	UserRemovePosix     = "/user/removePosix"
+	UserGetUidInfo      = "/user/uidInfo"
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
 	UsersOfVol          = "/vol/users"
@@ -240,6 +272,7 @@ const (
 var GApiInfo map[string]string = map[string]string{
 	"admingetmasterapilist":            AdminGetMasterApiList,
 	"adminsetapiqpslimit":              AdminSetApiQpsLimit,
+	"adminsetapicallerquota":           AdminSetApiCallerQuota,
 	"admingetcluster":                  AdminGetCluster,
 	"adminsetclusterinfo":              AdminSetClusterInfo,
 	"admingetdatapartition":            AdminGetDataPartition,
@@ -254,7 +287,10 @@ var GApiInfo map[string]string = map[string]string{
 	"adminupdatevol":                   AdminUpdateVol,
 	"adminvolshrink":                   AdminVolShrink,
 	"adminvolexpand":                   AdminVolExpand,
+	"adminclonevol":                    AdminCloneVol,
+	"admindetachvolclone":              AdminDetachVolClone,
 	"admincreatevol":                   AdminCreateVol,
+	"adminadvisecreatevol":             AdminAdviseCreateVol,
 	"admingetvol":                      AdminGetVol,
 	"adminclusterfreeze":               AdminClusterFreeze,
 	"adminclusterforbidmpdecommission": AdminClusterForbidMpDecommission,
@@ -337,6 +373,9 @@ var GApiInfo map[string]string = map[string]string{
 	"userdeletevolpolicy":             UserDeleteVolPolicy,
 	"usergetinfo":                     UserGetInfo,
 	"usergetakinfo":                   UserGetAKInfo,
+	"usersetposix":                    UserSetPosix,
+	"userremoveposix":                 UserRemovePosix,
+	"usergetuidinfo":                  UserGetUidInfo,
 	"usertransfervol":                 UserTransferVol,
 	"userlist":                        UserList,
 	"usersofvol":                      UsersOfVol,
@@ -561,9 +600,12 @@ type RemoveDataPartitionRaftMemberRequest struct {
 }
 
 // AddMetaPartitionRaftMemberRequest defines the request of add raftMember a meta partition.
+// The peer added as a learner replicates the logs without voting, adding an existing
+// learner as a voter promotes it.
 type AddMetaPartitionRaftMemberRequest struct {
 	PartitionId uint64
 	AddPeer     Peer
+	AsLearner   bool
 }
 
 // RemoveMetaPartitionRaftMemberRequest defines the request of add raftMember a meta partition.
@@ -625,6 +667,13 @@ type LoadMetaPartitionMetricResponse struct {
 	Result   string
 }
 
+// VolMetaOpLimit is the cap of the metadata operations per second of a volume.
+type VolMetaOpLimit struct {
+	Vol   string  `json:"vol"`
+	Ops   float64 `json:"ops"`
+	Burst int     `json:"burst"`
+}
+
 type UidLimitToMetaNode struct {
 	UidLimitInfo []*UidSpaceInfo
 }
@@ -683,6 +732,15 @@ type HeartBeatRequest struct {
 	ForbiddenVols     []string
 	DisableAuditVols  []string
 	DecommissionDisks []string // NOTE: for datanode
+	VerifyReadCrcVols []string // NOTE: for datanode
+	// NOTE: for datanode, extent size classes of the volumes not of the default class
+	VolExtentSizeClasses map[string]string
+	// NOTE: for metanode, shares of the metadata operation limits of the volumes on the node
+	VolMetaOpLimits []*VolMetaOpLimit
+	// sequence of the partition reports applied by the master, the node reports the changed
+	// partitions only if it is the sequence of the last reports and FullReport is not set
+	ReportSeq  uint64
+	FullReport bool
 }
 
 // DataPartitionReport defines the partition report.
@@ -697,6 +755,112 @@ type DataPartitionReport struct {
 	ExtentCount                int
 	NeedCompare                bool
 	DecommissionRepairProgress float64
+	// io stats of the replica since the last report, used to track the partition slo
+	ReadOpCnt      uint64
+	ReadLatencyUs  uint64 // average latency of the reads
+	WriteOpCnt     uint64
+	WriteLatencyUs uint64 // average latency of the writes
+	IoErrCnt       uint64
+}
+
+// DataPartitionSloView is the replica that violates the partition slo.
+type DataPartitionSloView struct {
+	PartitionID   uint64
+	VolName       string
+	Addr          string
+	LatencyUs     uint64 // average latency of the last violated window
+	ErrCnt        uint64
+	Violations    int
+	LastViolation int64
+}
+
+// DataPartitionSloInfo is the slo config and the replicas violating the slo.
+type DataPartitionSloInfo struct {
+	Enable         bool
+	LatencyMs      uint64
+	ViolationLimit int
+	Violators      []*DataPartitionSloView
+}
+
+// the categories of the errors reported by the clients
+const (
+	ClientErrPartitionEIO    = "partition_eio"    // keyed by the partition id
+	ClientErrDataNodeTimeout = "datanode_timeout" // keyed by the data node addr
+	ClientErrMountFailure    = "mount_failure"    // keyed by the reason
+)
+
+// ClientErrorItem is the count of an error observed by the client since the last report.
+type ClientErrorItem struct {
+	Category string
+	Key      string
+	Count    uint64
+}
+
+// ClientErrorReport is reported by the client periodically.
+type ClientErrorReport struct {
+	VolName string
+	Client  string
+	Items   []*ClientErrorItem
+}
+
+// ClientErrorStat is an error aggregated from the reports of the clients of a volume.
+type ClientErrorStat struct {
+	Category   string
+	Key        string
+	Count      uint64
+	Clients    int
+	LastReport int64
+}
+
+// VolClientHealth is the errors reported by the clients of a volume in the window.
+type VolClientHealth struct {
+	VolName   string
+	WindowSec int64
+	Clients   int // number of the clients reporting errors
+	Errors    []*ClientErrorStat
+}
+
+// VolCreationNodeSetAdvice is a nodeset which the data partitions of the volume would be created on.
+type VolCreationNodeSetAdvice struct {
+	ID            uint64
+	ZoneName      string
+	DataNodes     int // writable datanodes
+	DataTotal     uint64
+	DataUsed      uint64
+	DataAvailable uint64
+	UsedRatio     float64
+	PostUsedRatio float64 // used ratio after the volume is full
+}
+
+// VolCreationZoneAdvice is a zone which the volume would be created in.
+type VolCreationZoneAdvice struct {
+	Name          string
+	DataTotal     uint64
+	DataUsed      uint64
+	DataAvailable uint64
+	UsedRatio     float64
+	PostUsedRatio float64
+	MetaNodeSets  int // nodesets writable for the meta partitions
+	NodeSets      []*VolCreationNodeSetAdvice
+}
+
+// VolCreationAdvice reports whether the cluster can host the volume to create, the space is of the
+// writable datanodes in the nodesets to use, and is required by all the replicas when the volume is full.
+type VolCreationAdvice struct {
+	Feasible      bool
+	Reasons       []string
+	VolType       int
+	Capacity      uint64 // GB
+	ReplicaNum    uint8
+	ZoneName      string
+	CrossZone     bool
+	RequiredSpace uint64
+	DataTotal     uint64
+	DataUsed      uint64
+	DataAvailable uint64
+	UsedRatio     float64
+	PostUsedRatio float64
+	Zones         []*VolCreationZoneAdvice
 }
 
 type DataNodeQosResponse struct {
@@ -728,10 +892,25 @@ type DataNodeHeartbeatResponse struct {
 	PartitionReports    []*DataPartitionReport
 	Status              uint8
 	Result              string
-	BadDisks            []string           // Keep this old field for compatibility
-	BadDiskStats        []BadDiskStat      // key: disk path
-	CpuUtil             float64            `json:"cpuUtil"`
-	IoUtils             map[string]float64 `json:"ioUtil"`
+	BadDisks            []string              // Keep this old field for compatibility
+	BadDiskStats        []BadDiskStat         // key: disk path
+	CpuUtil             float64               `json:"cpuUtil"`
+	IoUtils             map[string]float64    `json:"ioUtil"`
+	SuspectRegions      []SuspectExtentRegion `json:",omitempty"`
+	Capabilities        []string              `json:",omitempty"`
+	HeartbeatReportDelta
+}
+
+// SuspectExtentRegion is the region of the extent written shortly before the unclean shutdown of the
+// datanode, which fails the crc verification after the restart and cannot be repaired from the replicas.
+type SuspectExtentRegion struct {
+	DiskPath    string
+	PartitionID uint64
+	ExtentID    uint64
+	Offset      int64
+	Size        int64
+	Reason      string
+	ReportTime  int64
 }
 
 // MetaPartitionReport defines the meta partition report.
@@ -752,6 +931,9 @@ type MetaPartitionReport struct {
 	FreeListLen      uint64
 	UidInfo          []*UidReportSpaceInfo
 	QuotaReportInfos []*QuotaReportInfo
+	// the partition of a cloned volume reports the inodes still referencing the data of the clone source
+	CloneVerSeq    uint64
+	ForkedInodeCnt uint64
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
@@ -762,7 +944,9 @@ type MetaNodeHeartbeatResponse struct {
 	MetaPartitionReports []*MetaPartitionReport
 	Status               uint8
 	Result               string
-	CpuUtil              float64 `json:"cpuUtil"`
+	CpuUtil              float64  `json:"cpuUtil"`
+	Capabilities         []string `json:",omitempty"`
+	HeartbeatReportDelta
 }
 
 // LcNodeHeartbeatResponse defines the response to the lc node heartbeat.
@@ -848,6 +1032,7 @@ type MetaPartitionLoadResponse struct {
 	DentryCount uint64
 	InodeCount  uint64
 	Addr        string
+	LeaderAddr  string
 }
 
 // DataPartitionResponse defines the response from a data node to the master that is related to a data partition.
@@ -862,6 +1047,7 @@ type DataPartitionResponse struct {
 	IsRecover     bool
 	PartitionTTL  int64
 	IsDiscard     bool
+	Labels        map[string]string `json:",omitempty"` // the placement labels, see PlacementLabels
 }
 
 // DataPartitionsView defines the view of a data partition
@@ -992,6 +1178,10 @@ type ClientLimitInfo struct {
 	UsedBuffer uint64
 	Used       uint64
 	Need       uint64
+	// assigned max burst credits, the unused limit of the client is saved as the credits
+	BurstCredit uint64
+	// reported usage served by the burst credits since the last report
+	CreditConsumed uint64
 }
 
 type ClientReportLimitInfo struct {
@@ -1085,6 +1275,102 @@ type SimpleVolView struct {
 	LatestVer      uint64
 	Forbidden      bool
 	EnableAuditLog bool
+	CloneInfo      *VolCloneInfo
+	// durability of the metadata raft log
+	MetaDurability         string
+	MetaGroupCommitDelayMs uint32
+	// verify the block crcs of the data on every read of the clients
+	VerifyReadCrc bool
+	// the partitions are placed on the nodes selected by the label selector only
+	LabelSelector string
+	// max burst credits of the write flow qos of the volume
+	WriteBurstCredit uint64
+	// max metadata operations per second of the volume across the metanodes, 0 to disable
+	MetaOpLimit uint64
+	MetaOpBurst uint64
+	// the normal extents of the volume are rolled at the size of the class
+	ExtentSizeClass string
+	// the volume turns read-only once the used ratio reaches FullReadOnlyRatio, 0 to disable
+	FullReadOnlyRatio float64
+	FullReadOnly      bool
+}
+
+// VolClientState is the state of a volume the clients poll by the heartbeats, it is cheap
+// to serve and small enough to be polled at short intervals.
+type VolClientState struct {
+	Name string
+	// the volume is read-only as it is full, the writes of the clients fail fast with EROFS
+	FullReadOnly bool
+}
+
+// Durability classes of the metadata raft log of a volume.
+const (
+	// follow the config of the metanode
+	MetaDurabilityDefault = ""
+	// the log is written to the page cache and flushed by the os
+	MetaDurabilityAsync = "async"
+	// the log is synced before it is acknowledged, the syncs are at least the group commit
+	// delay apart and the log written in the meantime is grouped into one sync
+	MetaDurabilityGroup = "group"
+	// the log is synced before it is acknowledged
+	MetaDurabilitySync = "sync"
+)
+
+func IsValidMetaDurability(durability string) bool {
+	switch durability {
+	case MetaDurabilityDefault, MetaDurabilityAsync, MetaDurabilityGroup, MetaDurabilitySync:
+		return true
+	}
+	return false
+}
+
+// Extent size classes of a volume, the clients roll to a new normal extent once the extent
+// reaches the size of the class. The size is at most util.ExtentSize, where the snapshot data
+// of the extent starts.
+const (
+	// the same as the large class
+	ExtentSizeClassDefault = ""
+	// 16MB, for the random write heavy workloads, which punch the holes of the extents and
+	// leave the extents sparse
+	ExtentSizeClassSmall = "small"
+	// 64MB
+	ExtentSizeClassMedium = "medium"
+	// 128MB, for the streaming workloads
+	ExtentSizeClassLarge = "large"
+)
+
+// ExtentSizeOfClass returns the max size of the normal extents of the class.
+func ExtentSizeOfClass(class string) (size int, ok bool) {
+	switch class {
+	case ExtentSizeClassSmall:
+		return 16 * util.MB, true
+	case ExtentSizeClassMedium:
+		return 64 * util.MB, true
+	case ExtentSizeClassDefault, ExtentSizeClassLarge:
+		return util.ExtentSize, true
+	}
+	return 0, false
+}
+
+const (
+	// the clone shares data extents with the source volume
+	VolCloneStatusShared uint8 = iota + 1
+	// no inode of the clone references the data of the source volume any more, the source volume is released
+	VolCloneStatusDetached
+)
+
+// VolCloneInfo defines the source of a cloned volume, the clone forks the metadata
+// of the source volume and shares data extents pinned by the snapshot version SrcVerSeq.
+type VolCloneInfo struct {
+	SrcVolName string
+	SrcVerSeq  uint64
+	Status     uint8
+	CreateTime int64
+	// the forked extents are copied into the clone in the background, and the clone is
+	// detached from the source once all of them are copied
+	Materialize bool
+	// clone meta partition id -> source meta partition id
+	MetaPartitions map[uint64]uint64
 }
 
 type NodeSetInfo struct {
@@ -1223,3 +1509,29 @@ const (
 const (
 	LFClient = 1 // low frequency client
 )
+
+// types of bucket events
+const (
+	BucketEventVolume = "volume" // the volume is created, deleted or updated
+	BucketEventMeta   = "meta"   // the bucket configs, such as ACL, policy and CORS, are changed
+	BucketEventUser   = "user"   // the user, its keys or its permissions are changed
+)
+
+// BucketEvent records a change of a bucket or a user, objectnodes invalidate
+// their caches of the Bucket and of the UserID by the events, either may be empty.
+type BucketEvent struct {
+	Seq    uint64
+	Type   string
+	Bucket string
+	UserID string
+}
+
+// BucketEventsView is the events recorded after the polled sequence. Reset is set
+// if some of the events are lost, then the pollers must drop all their caches and
+// poll again from Epoch and Seq.
+type BucketEventsView struct {
+	Epoch  int64
+	Seq    uint64
+	Reset  bool
+	Events []*BucketEvent
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/cubefs/cubefs/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +52,19 @@ func TestHTTPReplyRaw(t *testing.T) {
 	require.Equal(t, int(177), aStruct.Outter)
 	require.Equal(t, "", aStruct.inner)
 }
+
+func TestExtentSizeOfClass(t *testing.T) {
+	for class, expected := range map[string]int{
+		ExtentSizeClassDefault: util.ExtentSize,
+		ExtentSizeClassSmall:   16 * util.MB,
+		ExtentSizeClassMedium:  64 * util.MB,
+		ExtentSizeClassLarge:   util.ExtentSize,
+	} {
+		size, ok := ExtentSizeOfClass(class)
+		require.True(t, ok)
+		require.Equal(t, expected, size)
+		require.True(t, size <= util.ExtentSize)
+	}
+	_, ok := ExtentSizeOfClass("huge")
+	require.False(t, ok)
+}
@@ -206,6 +206,7 @@ const (
 	MsgMasterSetNodeInfoReq      MsgType = MsgMasterAPIAccessReq + 0x20400
 	MsgMasterSetNodeRdOnlyReq    MsgType = MsgMasterAPIAccessReq + 0x20500
 	MsgMasterAutoDecommissionReq MsgType = MsgMasterAPIAccessReq + 0x20600
+	MsgMasterSetNodeLabelsReq    MsgType = MsgMasterAPIAccessReq + 0x20700
 
 	// Master API volume management
 	MsgMasterCreateVolReq MsgType = MsgMasterAPIAccessReq + 0x30100
@@ -263,6 +264,8 @@ const (
 	MsgMasterUserRemovePolicyReq    MsgType = MsgMasterAPIAccessReq + 0x80500
 	MsgMasterUserDeleteVolPolicyReq MsgType = MsgMasterAPIAccessReq + 0x80600
 	MsgMasterUserTransferVolReq     MsgType = MsgMasterAPIAccessReq + 0x80700
+	MsgMasterUserSetPosixReq        MsgType = MsgMasterAPIAccessReq + 0x80800
+	MsgMasterUserRemovePosixReq     MsgType = MsgMasterAPIAccessReq + 0x80900
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq MsgType = MsgMasterAPIAccessReq + 0x90100
@@ -294,6 +297,7 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgMasterSetNodeInfoReq:      "master:setnodeinfo",
 	MsgMasterSetNodeRdOnlyReq:    "master:sernoderdonly",
 	MsgMasterAutoDecommissionReq: "master:autodecommission",
+	MsgMasterSetNodeLabelsReq:    "master:setnodelabels",
 
 	// Master API volume management
 	MsgMasterCreateVolReq: "master:createvol",
@@ -351,6 +355,8 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgMasterUserRemovePolicyReq:    "master:userremotepolicy",
 	MsgMasterUserDeleteVolPolicyReq: "master:userdeletevolpolicy",
 	MsgMasterUserTransferVolReq:     "master:usertransfervol",
+	MsgMasterUserSetPosixReq:        "master:usersetposix",
+	MsgMasterUserRemovePosixReq:     "master:userremoveposix",
 
 	// Master API zone management
 	MsgMasterUpdateZoneReq: "master:updatezone",
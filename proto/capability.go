@@ -0,0 +1,103 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import "sort"
+
+// the capabilities negotiated by the clients with the master at mount, each of which is enabled
+// only if the master and all the active servers of its roles support it, and it is not disabled
+// by the feature flags of the cluster.
+const (
+	// the lookups and inode gets hedged to the metanode followers guarded by the applied indexes
+	CapabilityMetaHedgeRead = "metaHedgeRead"
+	// the lz4 compression of the data on the wire to the datanodes
+	CapabilityWireCompress = "wireCompress"
+)
+
+// CapabilityRoles are the roles of the servers which must all support the capabilities.
+var CapabilityRoles = map[string][]string{
+	CapabilityMetaHedgeRead: {MetaNode},
+	CapabilityWireCompress:  {DataNode},
+}
+
+// MetaNodeCapabilities are the capabilities supported by the metanodes of this version.
+var MetaNodeCapabilities = []string{CapabilityMetaHedgeRead}
+
+// IsCapability returns true if the capability is known by this version.
+func IsCapability(name string) bool {
+	_, ok := CapabilityRoles[name]
+	return ok
+}
+
+// Capabilities returns the names of the capabilities known by this version in order.
+func Capabilities() (names []string) {
+	for name := range CapabilityRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// CapabilityView is the state of the capability in the cluster.
+type CapabilityView struct {
+	Name      string
+	Roles     []string
+	Disabled  bool     // disabled by the feature flag
+	Supported bool     // supported by all the active servers of the roles
+	Lacking   []string // the active servers not supporting it
+	Enabled   bool
+}
+
+// CapabilityNegotiation is the result of the capabilities requested by the client at mount.
+type CapabilityNegotiation struct {
+	Enabled     []string
+	Disabled    []string // disabled by the feature flags
+	Unsupported []string // not supported by the master or the servers
+}
+
+// Has returns true if the capability is enabled.
+func (n *CapabilityNegotiation) Has(name string) bool {
+	for _, enabled := range n.Enabled {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestedCapabilities returns the capabilities required by the features of the mount options.
+func (opt *MountOptions) RequestedCapabilities() (names []string) {
+	if opt.MetaHedgeDelayMs > 0 {
+		names = append(names, CapabilityMetaHedgeRead)
+	}
+	if opt.WireCompress {
+		names = append(names, CapabilityWireCompress)
+	}
+	return
+}
+
+// ApplyCapabilities disables the features of the mount options whose capabilities are not enabled
+// by the negotiation, and returns the capabilities of the features disabled.
+func (opt *MountOptions) ApplyCapabilities(n *CapabilityNegotiation) (disabled []string) {
+	if opt.MetaHedgeDelayMs > 0 && !n.Has(CapabilityMetaHedgeRead) {
+		opt.MetaHedgeDelayMs = 0
+		disabled = append(disabled, CapabilityMetaHedgeRead)
+	}
+	if opt.WireCompress && !n.Has(CapabilityWireCompress) {
+		opt.WireCompress = false
+		disabled = append(disabled, CapabilityWireCompress)
+	}
+	return
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountOptionsCapabilities(t *testing.T) {
+	for _, name := range Capabilities() {
+		require.True(t, IsCapability(name))
+		require.NotEmpty(t, CapabilityRoles[name])
+	}
+	require.False(t, IsCapability("unknown"))
+
+	opt := &MountOptions{}
+	require.Empty(t, opt.RequestedCapabilities())
+
+	opt.MetaHedgeDelayMs = 10
+	opt.WireCompress = true
+	requested := opt.RequestedCapabilities()
+	require.Equal(t, []string{CapabilityMetaHedgeRead, CapabilityWireCompress}, requested)
+
+	// the features not enabled by the negotiation are disabled
+	disabled := opt.ApplyCapabilities(&CapabilityNegotiation{Enabled: []string{CapabilityWireCompress}})
+	require.Equal(t, []string{CapabilityMetaHedgeRead}, disabled)
+	require.Equal(t, int64(0), opt.MetaHedgeDelayMs)
+	require.True(t, opt.WireCompress)
+
+	// all of them are disabled if not negotiated
+	disabled = opt.ApplyCapabilities(&CapabilityNegotiation{})
+	require.Equal(t, []string{CapabilityWireCompress}, disabled)
+	require.False(t, opt.WireCompress)
+}
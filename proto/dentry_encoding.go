@@ -0,0 +1,207 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/cubefs/cubefs/util/compressor"
+)
+
+const (
+	DentryEncodingDelta = "delta"
+
+	// the encoded dentries smaller than this are not compressed
+	DentryCompressMinSize = 4 * 1024
+)
+
+// DentryBatchEncoding is the encoding of the dentries in the readdir response. The dentries are
+// encoded in binary, the names sharing the prefix with the previous name and the inode deltas
+// are written when Delta is set, and the encoded data is compressed by Compression if not empty.
+type DentryBatchEncoding struct {
+	Delta       bool   `json:"delta,omitempty"`
+	Compression string `json:"compress,omitempty"`
+}
+
+// ParseDentryBatchEncoding parses the encoding written as "delta,gzip", the empty string
+// returns nil which means the dentries are not encoded.
+func ParseDentryBatchEncoding(s string) (enc *DentryBatchEncoding, err error) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		if enc == nil {
+			enc = &DentryBatchEncoding{}
+		}
+		switch item {
+		case DentryEncodingDelta:
+			enc.Delta = true
+		case compressor.EncodingGzip:
+			enc.Compression = item
+		default:
+			return nil, fmt.Errorf("invalid dentry encoding %v", item)
+		}
+	}
+	return enc, nil
+}
+
+func (enc *DentryBatchEncoding) String() string {
+	if enc == nil {
+		return ""
+	}
+	items := make([]string, 0, 2)
+	if enc.Delta {
+		items = append(items, DentryEncodingDelta)
+	}
+	if enc.Compression != "" {
+		items = append(items, enc.Compression)
+	}
+	return strings.Join(items, ",")
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendVarint(data []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+func sharedPrefixLen(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// Encode encodes the dentries, the compression is skipped for the small batch and the returned
+// encoding tells how the data is encoded actually.
+func (enc *DentryBatchEncoding) Encode(children []Dentry) (data []byte, actual *DentryBatchEncoding, err error) {
+	actual = &DentryBatchEncoding{Delta: enc.Delta}
+	var (
+		prev      string
+		prevInode uint64
+	)
+	data = appendUvarint(make([]byte, 0, len(children)*16), uint64(len(children)))
+	for _, child := range children {
+		ino := child.Inode
+		if enc.Delta {
+			shared := sharedPrefixLen(prev, child.Name)
+			data = appendUvarint(data, uint64(shared))
+			data = appendUvarint(data, uint64(len(child.Name)-shared))
+			data = append(data, child.Name[shared:]...)
+			data = appendVarint(data, int64(ino-prevInode))
+		} else {
+			data = appendUvarint(data, uint64(len(child.Name)))
+			data = append(data, child.Name...)
+			data = appendUvarint(data, ino)
+		}
+		data = appendUvarint(data, uint64(child.Type))
+		prev, prevInode = child.Name, ino
+	}
+	if enc.Compression != "" && len(data) >= DentryCompressMinSize {
+		if data, err = compressor.New(enc.Compression).Compress(data); err != nil {
+			return nil, nil, err
+		}
+		actual.Compression = enc.Compression
+	}
+	return data, actual, nil
+}
+
+// DecodeDentries decodes the dentries encoded by the encoding.
+func (enc *DentryBatchEncoding) DecodeDentries(data []byte) (children []Dentry, err error) {
+	if enc.Compression != "" {
+		if data, err = compressor.New(enc.Compression).Decompress(data); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		off  int
+		prev string
+		ino  uint64
+	)
+	readUvarint := func() (v uint64) {
+		if err != nil {
+			return
+		}
+		var n int
+		if v, n = binary.Uvarint(data[off:]); n <= 0 {
+			err = fmt.Errorf("invalid dentries at offset %v", off)
+			return
+		}
+		off += n
+		return
+	}
+	readString := func(size uint64) (s string) {
+		if err != nil {
+			return
+		}
+		if uint64(len(data)-off) < size {
+			err = fmt.Errorf("invalid dentries at offset %v", off)
+			return
+		}
+		s = string(data[off : off+int(size)])
+		off += int(size)
+		return
+	}
+
+	count := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid dentries count %v", count)
+	}
+	children = make([]Dentry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var name string
+		if enc.Delta {
+			shared := readUvarint()
+			if err == nil && shared > uint64(len(prev)) {
+				err = fmt.Errorf("invalid dentries shared prefix %v at offset %v", shared, off)
+			}
+			suffix := readString(readUvarint())
+			if err != nil {
+				return nil, err
+			}
+			name = prev[:shared] + suffix
+			delta, n := binary.Varint(data[off:])
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid dentries at offset %v", off)
+			}
+			off += n
+			ino += uint64(delta)
+		} else {
+			name = readString(readUvarint())
+			ino = readUvarint()
+		}
+		typ := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, Dentry{Name: name, Inode: ino, Type: uint32(typ)})
+		prev = name
+	}
+	return children, nil
+}
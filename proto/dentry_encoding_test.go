@@ -0,0 +1,83 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/util/compressor"
+)
+
+func TestParseDentryBatchEncoding(t *testing.T) {
+	enc, err := ParseDentryBatchEncoding("")
+	require.NoError(t, err)
+	require.Nil(t, enc)
+
+	enc, err = ParseDentryBatchEncoding(" delta, gzip")
+	require.NoError(t, err)
+	require.Equal(t, &DentryBatchEncoding{Delta: true, Compression: compressor.EncodingGzip}, enc)
+	require.Equal(t, "delta,gzip", enc.String())
+
+	_, err = ParseDentryBatchEncoding("delta,lz4")
+	require.Error(t, err)
+}
+
+func TestDentryBatchEncoding(t *testing.T) {
+	children := []Dentry{{Name: "b", Inode: 100, Type: 1}, {Name: "ab", Inode: 5, Type: 2}}
+	for i := 0; i < 1000; i++ {
+		children = append(children, Dentry{Name: fmt.Sprintf("long-common-prefix-%06d", i), Inode: uint64(200 + i), Type: 0x800001a4})
+	}
+
+	for _, enc := range []*DentryBatchEncoding{
+		{},
+		{Delta: true},
+		{Compression: compressor.EncodingGzip},
+		{Delta: true, Compression: compressor.EncodingGzip},
+	} {
+		data, actual, err := enc.Encode(children)
+		require.NoError(t, err)
+		require.Equal(t, enc, actual)
+		decoded, err := actual.DecodeDentries(data)
+		require.NoError(t, err)
+		require.Equal(t, children, decoded)
+
+		// the small batch is not compressed
+		data, actual, err = enc.Encode(children[:2])
+		require.NoError(t, err)
+		require.Equal(t, "", actual.Compression)
+		decoded, err = actual.DecodeDentries(data)
+		require.NoError(t, err)
+		require.Equal(t, children[:2], decoded)
+	}
+
+	delta, _, err := (&DentryBatchEncoding{Delta: true}).Encode(children)
+	require.NoError(t, err)
+	plain, _, err := (&DentryBatchEncoding{}).Encode(children)
+	require.NoError(t, err)
+	require.Less(t, len(delta)*2, len(plain))
+
+	empty, actual, err := (&DentryBatchEncoding{Delta: true}).Encode(nil)
+	require.NoError(t, err)
+	decoded, err := actual.DecodeDentries(empty)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	// the truncated data is rejected
+	_, err = (&DentryBatchEncoding{Delta: true}).DecodeDentries(delta[:len(delta)/2])
+	require.Error(t, err)
+}
@@ -77,6 +77,8 @@ var (
 	ErrSuperAdminExists                        = errors.New("super administrator exists ")
 	ErrInvalidUserID                           = errors.New("invalid user ID")
 	ErrInvalidUserType                         = errors.New("invalid user type")
+	ErrDuplicatePosixUid                       = errors.New("duplicate posix uid")
+	ErrPosixIdentityNotExists                  = errors.New("posix identity not exists")
 	ErrNoPermission                            = errors.New("no permission")
 	ErrTokenNotFound                           = errors.New("token not found")
 	ErrInvalidAccessKey                        = errors.New("invalid access key")
@@ -164,6 +166,8 @@ const (
 	ErrCodeZoneNumError
 	ErrCodeVersionOpError
 	ErrCodeNodeSetNotExists
+	ErrCodeDuplicatePosixUid
+	ErrCodePosixIdentityNotExists
 )
 
 // Err2CodeMap error map to code
@@ -229,6 +233,8 @@ var Err2CodeMap = map[error]int32{
 	ErrZoneNum:                         ErrCodeZoneNumError,
 	ErrCodeVersionOp:                   ErrCodeVersionOpError,
 	ErrNodeSetNotExists:                ErrCodeNodeSetNotExists,
+	ErrDuplicatePosixUid:               ErrCodeDuplicatePosixUid,
+	ErrPosixIdentityNotExists:          ErrCodePosixIdentityNotExists,
 }
 
 func ParseErrorCode(code int32) error {
@@ -301,6 +307,8 @@ var code2ErrMap = map[int32]error{
 	ErrCodeZoneNumError:                    ErrZoneNum,
 	ErrCodeVersionOpError:                  ErrCodeVersionOp,
 	ErrCodeNodeSetNotExists:                ErrNodeSetNotExists,
+	ErrCodeDuplicatePosixUid:               ErrDuplicatePosixUid,
+	ErrCodePosixIdentityNotExists:          ErrPosixIdentityNotExists,
 }
 
 type GeneralResp struct {
@@ -15,9 +15,12 @@
 package proto
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -87,6 +90,7 @@ type InodeInfo struct {
 	Uid        uint32                    `json:"uid"`
 	Gid        uint32                    `json:"gid"`
 	Generation uint64                    `json:"gen"`
+	Epoch      uint32                    `json:"epoch,omitempty"` // reused times of the inode id
 	ModifyTime time.Time                 `json:"mt"`
 	CreateTime time.Time                 `json:"ct"`
 	AccessTime time.Time                 `json:"at"`
@@ -183,6 +187,25 @@ func (d Dentry) String() string {
 	return fmt.Sprintf("Dentry{Name(%v),Inode(%v),Type(%v)}", d.Name, d.Inode, d.Type)
 }
 
+// NamespaceRecord is a record of the portable stream of the namespace metadata of a subtree,
+// the stream is a record per dentry in json lines, the parents come before their children and
+// the first record is the root of the subtree. The ids are of the source volume and remapped
+// on the import, the data is copied by the mover by the size.
+type NamespaceRecord struct {
+	Ino        uint64            `json:"ino"`
+	Parent     uint64            `json:"pino,omitempty"` // 0 for the root of the subtree
+	Name       string            `json:"name,omitempty"`
+	Mode       uint32            `json:"mode"`
+	Nlink      uint32            `json:"nlink"`
+	Uid        uint32            `json:"uid"`
+	Gid        uint32            `json:"gid"`
+	Size       uint64            `json:"sz"`
+	ModifyTime int64             `json:"mt"`
+	AccessTime int64             `json:"at"`
+	Target     []byte            `json:"tgt,omitempty"`
+	XAttrs     map[string]string `json:"xattrs,omitempty"`
+}
+
 type RequestExtend struct {
 	FullPaths []string `json:"fullPaths"`
 }
@@ -196,6 +219,23 @@ func (r *RequestExtend) GetFullPath() string {
 	return r.FullPaths[0]
 }
 
+// InodeEpochCheck carries the epoch of the inode which the handle of the client refers to,
+// the request is rejected if the inode id has been reused by an inode of another epoch.
+type InodeEpochCheck struct {
+	CheckEpoch bool   `json:"ckEpoch,omitempty"`
+	Epoch      uint32 `json:"epoch,omitempty"`
+}
+
+func (c *InodeEpochCheck) SetEpoch(epoch uint32) {
+	c.CheckEpoch = true
+	c.Epoch = epoch
+}
+
+// IsStale returns true if the handle refers to an inode of another epoch.
+func (c *InodeEpochCheck) IsStale(epoch uint32) bool {
+	return c.CheckEpoch && c.Epoch != epoch
+}
+
 // CreateInodeRequest defines the request to create an inode.
 type QuotaCreateInodeRequest struct {
 	VolName     string   `json:"vol"`
@@ -368,6 +408,7 @@ type UnlinkInodeRequest struct {
 	VerSeq      uint64 `json:"ver"`
 	DenVerSeq   uint64 `json:"denVer"`
 	RequestExtend
+	InodeEpochCheck
 }
 
 // UnlinkInodeRequest defines the request to unlink an inode.
@@ -548,6 +589,8 @@ type LookupRequest struct {
 	Name        string `json:"name"`
 	VerSeq      uint64 `json:"seq"`
 	VerAll      bool   `json:"verAll"`
+	// the hedged read is served by the follower which has applied the index, 0 means not hedged
+	HedgeAppliedID uint64 `json:"hedgeAid,omitempty"`
 }
 
 type DetryInfo struct {
@@ -563,6 +606,8 @@ type LookupResponse struct {
 	Mode   uint32      `json:"mode"`
 	VerSeq uint64      `json:"seq"`
 	LayAll []DetryInfo `json:"layerInfo"`
+	// the applied index of the partition before the lookup
+	AppliedID uint64 `json:"aid,omitempty"`
 }
 
 // InodeGetRequest defines the request to get the inode.
@@ -572,6 +617,9 @@ type InodeGetRequest struct {
 	Inode       uint64 `json:"ino"`
 	VerSeq      uint64 `json:"seq"`
 	VerAll      bool   `json:"verAll"`
+	// the hedged read is served by the follower which has applied the index, 0 means not hedged
+	HedgeAppliedID uint64 `json:"hedgeAid,omitempty"`
+	InodeEpochCheck
 }
 
 type LayerInfo struct {
@@ -584,6 +632,8 @@ type LayerInfo struct {
 type InodeGetResponse struct {
 	Info   *InodeInfo  `json:"info"`
 	LayAll []InodeInfo `json:"layerInfo"`
+	// the applied index of the partition before the inode get
+	AppliedID uint64 `json:"aid,omitempty"`
 }
 
 // BatchInodeGetRequest defines the request to get the inode in batch.
@@ -647,10 +697,197 @@ type ReadDirLimitRequest struct {
 	Limit       uint64 `json:"limit"`
 	VerSeq      uint64 `json:"seq"`
 	VerOpt      uint8  `json:"VerOpt"`
+	// the encoding of the dentries accepted by the client, nil for the plain dentries
+	Encoding *DentryBatchEncoding `json:"enc,omitempty"`
 }
 
+// ReadDirLimitResponse carries the plain children, or the children encoded by Encoding
+// in Encoded if the request accepts the encoding.
 type ReadDirLimitResponse struct {
+	Children []Dentry             `json:"children"`
+	Encoding *DentryBatchEncoding `json:"enc,omitempty"`
+	Encoded  []byte               `json:"encoded,omitempty"`
+}
+
+// GetChildren returns the children decoded from the response.
+func (resp *ReadDirLimitResponse) GetChildren() ([]Dentry, error) {
+	if resp.Encoding == nil {
+		return resp.Children, nil
+	}
+	return resp.Encoding.DecodeDentries(resp.Encoded)
+}
+
+// ReadDirStreamRequest defines the request to read dir in stream with a continuation cursor.
+// An empty cursor starts a new readdir session strictly after the marker.
+type ReadDirStreamRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	ParentID    uint64 `json:"pino"`
+	Cursor      string `json:"cursor"`
+	Marker      string `json:"marker,omitempty"`
+	Limit       uint64 `json:"limit"`
+	VerSeq      uint64 `json:"seq"`
+}
+
+// ReadDirStreamResponse defines the response to the ReadDirStreamRequest.
+type ReadDirStreamResponse struct {
 	Children []Dentry `json:"children"`
+	Cursor   string   `json:"cursor"`
+	HasMore  bool     `json:"more"`
+}
+
+// Keys to sort the entries of the filtered readdir by, the entries of the same key are in name order.
+const (
+	DirEntrySortByName  uint8 = 0
+	DirEntrySortByMtime uint8 = 1 // the oldest first
+	DirEntrySortBySize  uint8 = 2 // the largest first
+)
+
+// DirEntryFilter selects the entries of the directory by the attributes of their inodes, the zero
+// fields match all. Times are in unix seconds and sizes are in bytes.
+type DirEntryFilter struct {
+	MtimeBefore int64  `json:"mtb,omitempty"`
+	MtimeAfter  int64  `json:"mta,omitempty"`
+	SizeAbove   uint64 `json:"sza,omitempty"`
+	SizeBelow   uint64 `json:"szb,omitempty"`
+	FilesOnly   bool   `json:"files,omitempty"`
+	// the subdirectories are returned regardless of the filter, so that the scanners can descend
+	WithDirs bool `json:"dirs,omitempty"`
+}
+
+// Match returns whether the entry of the inode with the mode, size and modify time is selected.
+func (f *DirEntryFilter) Match(mode uint32, size uint64, mtime int64) bool {
+	if IsDir(mode) {
+		if f.WithDirs {
+			return true
+		}
+		if f.FilesOnly {
+			return false
+		}
+	}
+	if f.MtimeBefore != 0 && mtime >= f.MtimeBefore {
+		return false
+	}
+	if f.MtimeAfter != 0 && mtime <= f.MtimeAfter {
+		return false
+	}
+	if f.SizeAbove != 0 && size <= f.SizeAbove {
+		return false
+	}
+	if f.SizeBelow != 0 && size >= f.SizeBelow {
+		return false
+	}
+	return true
+}
+
+// DirEntryInfo is the entry of the filtered readdir with the attributes of its inode.
+type DirEntryInfo struct {
+	Dentry
+	Size  uint64 `json:"size"`
+	Mtime int64  `json:"mt"`
+}
+
+// SortDirEntries sorts the entries by the key, the entries are expected in name order.
+func SortDirEntries(entries []DirEntryInfo, sortBy uint8) {
+	switch sortBy {
+	case DirEntrySortByMtime:
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Mtime < entries[j].Mtime })
+	case DirEntrySortBySize:
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Size > entries[j].Size })
+	}
+}
+
+// ReadDirFilteredRequest defines the request to read the entries of the directory selected by the
+// filter. At most Limit dentries after Marker are scanned, not the count of the entries returned.
+type ReadDirFilteredRequest struct {
+	VolName     string         `json:"vol"`
+	PartitionID uint64         `json:"pid"`
+	ParentID    uint64         `json:"pino"`
+	Marker      string         `json:"marker"`
+	Limit       uint64         `json:"limit"`
+	VerSeq      uint64         `json:"seq"`
+	Filter      DirEntryFilter `json:"filter"`
+	SortBy      uint8          `json:"sort"`
+}
+
+// ReadDirFilteredResponse defines the response to the ReadDirFilteredRequest. Entries are the selected
+// entries whose inodes are in the meta partition of the directory, sorted by SortBy, and Unresolved are
+// the dentries whose inodes are in other partitions, which are left to the client to filter. Next is
+// the Marker of the next request, empty if all the dentries are scanned.
+type ReadDirFilteredResponse struct {
+	Entries    []DirEntryInfo `json:"entries"`
+	Unresolved []Dentry       `json:"unresolved,omitempty"`
+	Next       string         `json:"next"`
+	Scanned    uint64         `json:"scanned"`
+}
+
+// Operations of the directory changes.
+const (
+	DirChangeCreate uint8 = 1
+	DirChangeDelete uint8 = 2
+	DirChangeUpdate uint8 = 3 // the dentry is pointed to another inode, such as renaming over it
+)
+
+// DirChangeEvent is a change of the dentry in the directory, the sequence is the raft index of
+// the change in the meta partition of the directory.
+type DirChangeEvent struct {
+	Seq      uint64 `json:"seq"`
+	Op       uint8  `json:"op"`
+	ParentID uint64 `json:"pino"`
+	Name     string `json:"name"`
+	Inode    uint64 `json:"ino"`
+	Type     uint32 `json:"type"`
+	Time     int64  `json:"time"`
+}
+
+// ReadDirChangesRequest defines the request to read the changes of the directory after From.
+type ReadDirChangesRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	ParentID    uint64 `json:"pino"`
+	From        uint64 `json:"from"`
+	Limit       uint64 `json:"limit"`
+}
+
+// ReadDirChangesResponse defines the response to the ReadDirChangesRequest. Next is the From of
+// the next request, and Truncated is set if some changes after From are unknown to the meta
+// partition, in which case the directory should be listed again to catch up.
+type ReadDirChangesResponse struct {
+	Events    []DirChangeEvent `json:"events"`
+	Next      uint64           `json:"next"`
+	Truncated bool             `json:"truncated"`
+}
+
+// ReadDirCursor is the continuation cursor of a readdir session. Dentries are returned in name order
+// and a batch starts strictly after Marker, so an entry which exists during the whole session is
+// returned exactly once regardless of the inserts and deletes of other entries. Lease is the id of the
+// lease of the session issued by the meta partition, which keeps the read version of the session.
+type ReadDirCursor struct {
+	ParentID uint64 `json:"pino"`
+	Marker   string `json:"marker"`
+	Lease    string `json:"lease"`
+}
+
+// Encode encodes the cursor to an opaque string.
+func (c *ReadDirCursor) Encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeReadDirCursor decodes the cursor returned by ReadDirStreamResponse.
+func DecodeReadDirCursor(cursor string) (c *ReadDirCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	c = &ReadDirCursor{}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return
 }
 
 // AppendExtentKeyRequest defines the request to append an extent key.
@@ -669,6 +906,7 @@ type AppendExtentKeyWithCheckRequest struct {
 	DiscardExtents []ExtentKey `json:"dek"`
 	VerSeq         uint64      `json:"seq"`
 	IsSplit        bool
+	InodeEpochCheck
 }
 
 // AppendObjExtentKeyRequest defines the request to append an obj extent key.
@@ -686,6 +924,7 @@ type GetExtentsRequest struct {
 	Inode       uint64 `json:"ino"`
 	VerSeq      uint64 `json:"seq"`
 	VerAll      bool
+	InodeEpochCheck
 }
 
 // GetObjExtentsResponse defines the response to the request of getting obj extents.
@@ -712,6 +951,7 @@ type TruncateRequest struct {
 	Inode       uint64 `json:"ino"`
 	Size        uint64 `json:"sz"`
 	RequestExtend
+	InodeEpochCheck
 }
 
 type EmptyExtentKeyRequest struct {
@@ -746,6 +986,7 @@ type SetAttrRequest struct {
 	AccessTime  int64  `json:"at"`
 	Valid       uint32 `json:"valid"`
 	VerSeq      uint64 `json:"seq"`
+	InodeEpochCheck
 }
 
 const (
@@ -786,6 +1027,7 @@ type SetXAttrRequest struct {
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	Value       string `json:"val"`
+	InodeEpochCheck
 }
 
 type BatchSetXAttrRequest struct {
@@ -793,6 +1035,7 @@ type BatchSetXAttrRequest struct {
 	PartitionId uint64            `json:"pid"`
 	Inode       uint64            `json:"ino"`
 	Attrs       map[string]string `json:"attrs"`
+	InodeEpochCheck
 }
 
 type GetAllXAttrRequest struct {
@@ -800,6 +1043,7 @@ type GetAllXAttrRequest struct {
 	PartitionId uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
 	VerSeq      uint64 `json:"seq"`
+	InodeEpochCheck
 }
 
 type GetAllXAttrResponse struct {
@@ -815,6 +1059,7 @@ type GetXAttrRequest struct {
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	VerSeq      uint64 `json:"seq"`
+	InodeEpochCheck
 }
 
 type GetXAttrResponse struct {
@@ -831,6 +1076,7 @@ type RemoveXAttrRequest struct {
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	VerSeq      uint64 `json:"seq"`
+	InodeEpochCheck
 }
 
 type ListXAttrRequest struct {
@@ -838,6 +1084,7 @@ type ListXAttrRequest struct {
 	PartitionId uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
 	VerSeq      uint64 `json:"seq"`
+	InodeEpochCheck
 }
 
 type ListXAttrResponse struct {
@@ -0,0 +1,242 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// HeartbeatReportDelta is the sequence of the partition reports in the heartbeat response. The
+// node keeps the reports sent last time, and reports only the partitions changed since then and
+// the removed partitions if the master has applied the last reports, BaseReportSeq is the
+// sequence of the last reports then. BaseReportSeq is zero for the full reports, and ReportSeq
+// is zero for the node which always sends the full reports.
+type HeartbeatReportDelta struct {
+	ReportSeq         uint64   `json:",omitempty"`
+	BaseReportSeq     uint64   `json:",omitempty"`
+	RemovedPartitions []uint64 `json:",omitempty"`
+}
+
+// the sequence of the node starts from the time, so that the sequence of the restarted node
+// never matches the reports applied before
+func nextReportSeq(seq uint64) uint64 {
+	if seq == 0 {
+		return uint64(time.Now().UnixNano())
+	}
+	return seq + 1
+}
+
+// DataPartitionReportTracker tracks the partition reports sent by the datanode.
+type DataPartitionReportTracker struct {
+	mu      sync.Mutex
+	seq     uint64
+	reports map[uint64]*DataPartitionReport
+}
+
+// Track reports the changed partitions only in the response if the request is based on the
+// last reports, and records the reports of the response as the last reports.
+func (t *DataPartitionReportTracker) Track(req *HeartBeatRequest, resp *DataNodeHeartbeatResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reports := make(map[uint64]*DataPartitionReport, len(resp.PartitionReports))
+	for _, r := range resp.PartitionReports {
+		reports[r.PartitionID] = r
+	}
+	if req.ReportSeq != 0 && req.ReportSeq == t.seq && !req.FullReport {
+		changed := make([]*DataPartitionReport, 0)
+		for _, r := range resp.PartitionReports {
+			if last, ok := t.reports[r.PartitionID]; !ok || *last != *r {
+				changed = append(changed, r)
+			}
+		}
+		for id := range t.reports {
+			if _, ok := reports[id]; !ok {
+				resp.RemovedPartitions = append(resp.RemovedPartitions, id)
+			}
+		}
+		resp.PartitionReports = changed
+		resp.BaseReportSeq = t.seq
+	}
+	t.seq = nextReportSeq(t.seq)
+	t.reports = reports
+	resp.ReportSeq = t.seq
+}
+
+// DataPartitionReportCache keeps the partition reports of the datanode applied by the master.
+type DataPartitionReportCache struct {
+	mu       sync.Mutex
+	seq      uint64
+	reports  map[uint64]*DataPartitionReport
+	lastFull time.Time
+}
+
+// Request sets the sequence of the applied reports to the heartbeat request, the full reports
+// are requested if the last full reports are older than fullInterval.
+func (c *DataPartitionReportCache) Request(req *HeartBeatRequest, fullInterval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req.ReportSeq = c.seq
+	req.FullReport = time.Since(c.lastFull) > fullInterval
+}
+
+// Apply merges the changed reports of the response into the applied reports, and replaces the
+// reports of the response by all the reports. The delta not based on the applied reports is
+// dropped and false is returned, the response gets the applied reports then and the full reports
+// are requested by the next heartbeat.
+func (c *DataPartitionReportCache) Apply(resp *DataNodeHeartbeatResponse) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case resp.ReportSeq == 0:
+		c.seq, c.reports = 0, nil
+		return true
+	case resp.BaseReportSeq == 0:
+		c.reports = make(map[uint64]*DataPartitionReport, len(resp.PartitionReports))
+		c.lastFull = time.Now()
+	case resp.BaseReportSeq != c.seq || c.reports == nil:
+		c.seq = 0
+		resp.PartitionReports = make([]*DataPartitionReport, 0, len(c.reports))
+		for _, r := range c.reports {
+			resp.PartitionReports = append(resp.PartitionReports, r)
+		}
+		return false
+	}
+
+	changed := make(map[uint64]*DataPartitionReport, len(resp.PartitionReports))
+	for _, r := range resp.PartitionReports {
+		if r == nil {
+			continue
+		}
+		changed[r.PartitionID] = r
+		c.reports[r.PartitionID] = r.withoutIoStats()
+	}
+	for _, id := range resp.RemovedPartitions {
+		delete(c.reports, id)
+	}
+	reports := make([]*DataPartitionReport, 0, len(c.reports))
+	for id, r := range c.reports {
+		if cr, ok := changed[id]; ok {
+			r = cr
+		}
+		reports = append(reports, r)
+	}
+	resp.PartitionReports = reports
+	c.seq = resp.ReportSeq
+	return true
+}
+
+// withoutIoStats returns the report without the io stats, which are counted since the last
+// report and not kept for the unchanged partitions.
+func (r *DataPartitionReport) withoutIoStats() *DataPartitionReport {
+	if r.ReadOpCnt == 0 && r.WriteOpCnt == 0 && r.IoErrCnt == 0 {
+		return r
+	}
+	cp := *r
+	cp.ReadOpCnt, cp.ReadLatencyUs, cp.WriteOpCnt, cp.WriteLatencyUs, cp.IoErrCnt = 0, 0, 0, 0, 0
+	return &cp
+}
+
+// MetaPartitionReportTracker tracks the partition reports sent by the metanode.
+type MetaPartitionReportTracker struct {
+	mu      sync.Mutex
+	seq     uint64
+	reports map[uint64]*MetaPartitionReport
+}
+
+// Track reports the changed partitions only in the response if the request is based on the
+// last reports, and records the reports of the response as the last reports.
+func (t *MetaPartitionReportTracker) Track(req *HeartBeatRequest, resp *MetaNodeHeartbeatResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reports := make(map[uint64]*MetaPartitionReport, len(resp.MetaPartitionReports))
+	for _, r := range resp.MetaPartitionReports {
+		reports[r.PartitionID] = r
+	}
+	if req.ReportSeq != 0 && req.ReportSeq == t.seq && !req.FullReport {
+		changed := make([]*MetaPartitionReport, 0)
+		for _, r := range resp.MetaPartitionReports {
+			if last, ok := t.reports[r.PartitionID]; !ok || !reflect.DeepEqual(last, r) {
+				changed = append(changed, r)
+			}
+		}
+		for id := range t.reports {
+			if _, ok := reports[id]; !ok {
+				resp.RemovedPartitions = append(resp.RemovedPartitions, id)
+			}
+		}
+		resp.MetaPartitionReports = changed
+		resp.BaseReportSeq = t.seq
+	}
+	t.seq = nextReportSeq(t.seq)
+	t.reports = reports
+	resp.ReportSeq = t.seq
+}
+
+// MetaPartitionReportCache keeps the partition reports of the metanode applied by the master.
+type MetaPartitionReportCache struct {
+	mu       sync.Mutex
+	seq      uint64
+	reports  map[uint64]*MetaPartitionReport
+	lastFull time.Time
+}
+
+// Request sets the sequence of the applied reports to the heartbeat request, the full reports
+// are requested if the last full reports are older than fullInterval.
+func (c *MetaPartitionReportCache) Request(req *HeartBeatRequest, fullInterval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req.ReportSeq = c.seq
+	req.FullReport = time.Since(c.lastFull) > fullInterval
+}
+
+// Apply merges the changed reports of the response into the applied reports, and replaces the
+// reports of the response by all the reports, see DataPartitionReportCache.Apply.
+func (c *MetaPartitionReportCache) Apply(resp *MetaNodeHeartbeatResponse) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case resp.ReportSeq == 0:
+		c.seq, c.reports = 0, nil
+		return true
+	case resp.BaseReportSeq == 0:
+		c.reports = make(map[uint64]*MetaPartitionReport, len(resp.MetaPartitionReports))
+		c.lastFull = time.Now()
+	case resp.BaseReportSeq != c.seq || c.reports == nil:
+		c.seq = 0
+		resp.MetaPartitionReports = make([]*MetaPartitionReport, 0, len(c.reports))
+		for _, r := range c.reports {
+			resp.MetaPartitionReports = append(resp.MetaPartitionReports, r)
+		}
+		return false
+	}
+
+	for _, r := range resp.MetaPartitionReports {
+		if r != nil {
+			c.reports[r.PartitionID] = r
+		}
+	}
+	for _, id := range resp.RemovedPartitions {
+		delete(c.reports, id)
+	}
+	reports := make([]*MetaPartitionReport, 0, len(c.reports))
+	for _, r := range c.reports {
+		reports = append(reports, r)
+	}
+	resp.MetaPartitionReports = reports
+	c.seq = resp.ReportSeq
+	return true
+}
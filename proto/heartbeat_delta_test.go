@@ -0,0 +1,174 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataPartitionReportDelta(t *testing.T) {
+	var (
+		tracker DataPartitionReportTracker
+		cache   DataPartitionReportCache
+	)
+	partitions := map[uint64]*DataPartitionReport{
+		1: {PartitionID: 1, Used: 10},
+		2: {PartitionID: 2, Used: 20},
+		3: {PartitionID: 3, Used: 30},
+	}
+	// heartbeat sends the reports and returns the reported count and the reports applied by the master
+	heartbeat := func(lost bool) (int, []*DataPartitionReport, bool) {
+		req := &HeartBeatRequest{}
+		cache.Request(req, time.Hour)
+		resp := &DataNodeHeartbeatResponse{}
+		for _, r := range partitions {
+			cp := *r
+			resp.PartitionReports = append(resp.PartitionReports, &cp)
+		}
+		tracker.Track(req, resp)
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		resp = &DataNodeHeartbeatResponse{}
+		require.NoError(t, json.Unmarshal(data, resp))
+		reported := len(resp.PartitionReports)
+		if lost {
+			return reported, nil, false
+		}
+		ok := cache.Apply(resp)
+		sort.Slice(resp.PartitionReports, func(i, j int) bool {
+			return resp.PartitionReports[i].PartitionID < resp.PartitionReports[j].PartitionID
+		})
+		return reported, resp.PartitionReports, ok
+	}
+	requireReports := func(reports []*DataPartitionReport) {
+		require.Equal(t, len(partitions), len(reports))
+		for _, r := range reports {
+			require.Equal(t, partitions[r.PartitionID].Used, r.Used)
+		}
+	}
+
+	reported, reports, ok := heartbeat(false)
+	require.True(t, ok)
+	require.Equal(t, 3, reported)
+	requireReports(reports)
+
+	// nothing changed
+	reported, reports, ok = heartbeat(false)
+	require.True(t, ok)
+	require.Equal(t, 0, reported)
+	requireReports(reports)
+
+	// changed, added and removed
+	partitions[2].Used = 21
+	partitions[4] = &DataPartitionReport{PartitionID: 4, Used: 40}
+	delete(partitions, 1)
+	reported, reports, ok = heartbeat(false)
+	require.True(t, ok)
+	require.Equal(t, 2, reported)
+	requireReports(reports)
+
+	// the io stats are reported once
+	partitions[3].ReadOpCnt, partitions[3].ReadLatencyUs = 100, 5
+	reported, reports, _ = heartbeat(false)
+	require.Equal(t, 1, reported)
+	require.Equal(t, uint64(100), reports[1].ReadOpCnt)
+	partitions[3].ReadOpCnt, partitions[3].ReadLatencyUs = 0, 0
+	reported, reports, _ = heartbeat(false)
+	require.Equal(t, 1, reported)
+	require.Equal(t, uint64(0), reports[1].ReadOpCnt)
+	_, reports, _ = heartbeat(false)
+	require.Equal(t, uint64(0), reports[1].ReadOpCnt)
+
+	// the lost delta resyncs by the full reports
+	partitions[4].Used = 41
+	heartbeat(true)
+	partitions[3].Used = 31
+	reported, _, _ = heartbeat(false)
+	require.Equal(t, len(partitions), reported)
+	reported, reports, ok = heartbeat(false)
+	require.True(t, ok)
+	require.Equal(t, 0, reported)
+	requireReports(reports)
+
+	// the delta not based on the applied reports is dropped
+	req := &HeartBeatRequest{}
+	cache.Request(req, time.Hour)
+	resp := &DataNodeHeartbeatResponse{}
+	resp.ReportSeq, resp.BaseReportSeq = req.ReportSeq+2, req.ReportSeq+1
+	resp.PartitionReports = []*DataPartitionReport{{PartitionID: 2, Used: 1}}
+	require.False(t, cache.Apply(resp))
+	require.Equal(t, len(partitions), len(resp.PartitionReports))
+	cache.Request(req, time.Hour)
+	require.Equal(t, uint64(0), req.ReportSeq)
+	reported, reports, ok = heartbeat(false)
+	require.True(t, ok)
+	require.Equal(t, len(partitions), reported)
+	requireReports(reports)
+
+	// the full reports are requested periodically
+	cache.Request(req, 0)
+	require.True(t, req.FullReport)
+
+	// the node without delta reports
+	require.True(t, cache.Apply(&DataNodeHeartbeatResponse{PartitionReports: []*DataPartitionReport{{PartitionID: 1}}}))
+	cache.Request(req, time.Hour)
+	require.Equal(t, uint64(0), req.ReportSeq)
+}
+
+func TestMetaPartitionReportDelta(t *testing.T) {
+	var (
+		tracker MetaPartitionReportTracker
+		cache   MetaPartitionReportCache
+	)
+	heartbeat := func(reports ...*MetaPartitionReport) *MetaNodeHeartbeatResponse {
+		req := &HeartBeatRequest{}
+		cache.Request(req, time.Hour)
+		resp := &MetaNodeHeartbeatResponse{MetaPartitionReports: reports}
+		tracker.Track(req, resp)
+		require.True(t, cache.Apply(resp))
+		return resp
+	}
+	uidInfo := func(size uint64) []*UidReportSpaceInfo {
+		return []*UidReportSpaceInfo{{Uid: 1, Size: size}}
+	}
+
+	resp := heartbeat(&MetaPartitionReport{PartitionID: 1, InodeCnt: 1, UidInfo: uidInfo(1)},
+		&MetaPartitionReport{PartitionID: 2, InodeCnt: 2})
+	require.Equal(t, uint64(0), resp.BaseReportSeq)
+	require.Len(t, resp.MetaPartitionReports, 2)
+
+	// the changed reports are compared deeply
+	resp = &MetaNodeHeartbeatResponse{MetaPartitionReports: []*MetaPartitionReport{
+		{PartitionID: 1, InodeCnt: 1, UidInfo: uidInfo(2)},
+		{PartitionID: 2, InodeCnt: 2},
+	}}
+	req := &HeartBeatRequest{}
+	cache.Request(req, time.Hour)
+	tracker.Track(req, resp)
+	require.NotEqual(t, uint64(0), resp.BaseReportSeq)
+	require.Len(t, resp.MetaPartitionReports, 1)
+	require.True(t, cache.Apply(resp))
+	require.Len(t, resp.MetaPartitionReports, 2)
+
+	resp = heartbeat(&MetaPartitionReport{PartitionID: 1, InodeCnt: 1, UidInfo: uidInfo(2)})
+	require.Len(t, resp.MetaPartitionReports, 1)
+	require.Equal(t, uint64(1), resp.MetaPartitionReports[0].PartitionID)
+	require.Equal(t, uint64(2), resp.MetaPartitionReports[0].UidInfo[0].Size)
+}
@@ -41,7 +41,12 @@ type CreateMetaPartitionRequest struct {
 	End         uint64
 	PartitionID uint64
 	Members     []Peer
+	Learners    []Peer // the members replicating the logs without voting
 	VerSeq      uint64
+	// the partition of a cloned volume forks the inodes and dentries of the local replica of
+	// the source partition visible at CloneVerSeq, the source is set only on the creation of the volume
+	CloneSrcPartitionID uint64
+	CloneVerSeq         uint64
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
@@ -46,6 +46,7 @@ type MetaNodeInfo struct {
 	PersistenceMetaPartitions []uint64
 	RdOnly                    bool
 	CpuUtil                   float64 `json:"cpuUtil"`
+	Labels                    map[string]string
 }
 
 // DataNode stores all the information about a data node
@@ -71,6 +72,8 @@ type DataNodeInfo struct {
 	MaxDpCntLimit             uint32             `json:"maxDpCntLimit"`
 	CpuUtil                   float64            `json:"cpuUtil"`
 	IoUtils                   map[string]float64 `json:"ioUtil"`
+	Labels                    map[string]string
+	SuspectRegions            []SuspectExtentRegion `json:",omitempty"`
 }
 
 // MetaPartition defines the structure of a meta partition
@@ -224,6 +227,44 @@ type NodeStatView struct {
 	Avail      uint64
 }
 
+// PlacementNodeSnapshot is the state of a node replayed by the placement simulation
+type PlacementNodeSnapshot struct {
+	ID             uint64
+	Addr           string
+	Total          uint64
+	Used           uint64
+	PartitionCount int
+	Writable       bool
+}
+
+// PlacementSnapshot is the topology of the data nodes or meta nodes of a node set, it is
+// exported from a cluster and replayed later to compare the placement policies.
+type PlacementSnapshot struct {
+	NodesetID uint64
+	NodeType  uint32
+	Nodes     []*PlacementNodeSnapshot
+}
+
+type PlacementSimulationView struct {
+	Policy          string
+	Placed          int
+	Failed          int
+	MaxUsageRatio   float64
+	MinUsageRatio   float64
+	UsageStdDev     float64
+	MaxPartitions   int
+	MinPartitions   int
+	PartitionStdDev float64
+}
+
+type PlacementSimulationReport struct {
+	Snapshot      *PlacementSnapshot
+	Partitions    int
+	ReplicaNum    int
+	PartitionSize uint64
+	Results       []*PlacementSimulationView
+}
+
 type NodeStatInfo struct {
 	TotalGB     uint64
 	UsedGB      uint64
@@ -0,0 +1,70 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MountEntry mounts the directory SubDir of the volume at Prefix of the global namespace.
+type MountEntry struct {
+	Prefix     string
+	VolName    string
+	SubDir     string
+	UpdateTime int64
+}
+
+// MountMapView is the mount map maintained by the master, which stitches several
+// volumes into one logical namespace. The version is increased on every change.
+type MountMapView struct {
+	Version uint64
+	Entries []*MountEntry
+}
+
+// CleanMountPrefix normalizes the prefix of the global namespace to an absolute path.
+func CleanMountPrefix(prefix string) (string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("mount prefix %v is not an absolute path", prefix)
+	}
+	return path.Clean(prefix), nil
+}
+
+// Resolve returns the entry with the longest prefix covering the path and the
+// path inside the volume, it returns false if no entry covers the path.
+func (v *MountMapView) Resolve(p string) (entry *MountEntry, volPath string, ok bool) {
+	p = path.Clean("/" + p)
+	for _, e := range v.Entries {
+		if !underMountPrefix(p, e.Prefix) {
+			continue
+		}
+		if entry == nil || len(e.Prefix) > len(entry.Prefix) {
+			entry = e
+		}
+	}
+	if entry == nil {
+		return nil, "", false
+	}
+	rel := strings.TrimPrefix(p, entry.Prefix)
+	return entry, path.Join("/", entry.SubDir, rel), true
+}
+
+func underMountPrefix(p, prefix string) bool {
+	if prefix == "/" || p == prefix {
+		return true
+	}
+	return strings.HasPrefix(p, prefix+"/")
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountMapResolve(t *testing.T) {
+	_, err := CleanMountPrefix("datasets")
+	require.Error(t, err)
+	prefix, err := CleanMountPrefix("/datasets/imagenet/")
+	require.NoError(t, err)
+	require.Equal(t, "/datasets/imagenet", prefix)
+
+	view := &MountMapView{}
+	_, _, ok := view.Resolve("/datasets")
+	require.False(t, ok)
+
+	view.Entries = []*MountEntry{
+		{Prefix: "/datasets", VolName: "vol1"},
+		{Prefix: "/datasets/imagenet", VolName: "vol2", SubDir: "/train"},
+		{Prefix: "/logs", VolName: "vol3", SubDir: "/"},
+	}
+	cases := []struct {
+		path    string
+		volName string
+		volPath string
+	}{
+		{"/datasets", "vol1", "/"},
+		{"/datasets/coco/a.jpg", "vol1", "/coco/a.jpg"},
+		{"/datasets/imagenet", "vol2", "/train"},
+		{"datasets/imagenet/n01/b.jpg", "vol2", "/train/n01/b.jpg"},
+		{"/datasets/imagenet2/c.jpg", "vol1", "/imagenet2/c.jpg"},
+		{"/logs/2023/", "vol3", "/2023"},
+	}
+	for _, cs := range cases {
+		entry, volPath, ok := view.Resolve(cs.path)
+		require.True(t, ok, cs.path)
+		require.Equal(t, cs.volName, entry.VolName, cs.path)
+		require.Equal(t, cs.volPath, volPath, cs.path)
+	}
+	_, _, ok = view.Resolve("/models")
+	require.False(t, ok)
+
+	view.Entries = append(view.Entries, &MountEntry{Prefix: "/", VolName: "root"})
+	entry, volPath, ok := view.Resolve("/models/resnet")
+	require.True(t, ok)
+	require.Equal(t, "root", entry.VolName)
+	require.Equal(t, "/models/resnet", volPath)
+}
@@ -75,6 +75,41 @@ const (
 	// snapshot
 	SnapshotReadVerSeq
 
+	// supervisor
+	EnableSupervisor
+	SupervisorProbeIntervalS
+	SupervisorStuckTimeoutS
+	SupervisorMaxRemounts
+
+	// timeout and retry budgets
+	SmallReadSize
+	SmallReadTimeoutS
+	SmallReadRetry
+	LargeReadTimeoutS
+	LargeReadRetry
+	WriteTimeoutS
+	WriteRetry
+	MetaRetry
+
+	// fuse queues
+	FuseQueues
+	FuseQueueWorkers
+
+	// global namespace
+	NamespacePath
+
+	ReaddirEncoding
+
+	WireCompress
+
+	EnablePlacementHint
+
+	MetaHedgeDelayMs
+
+	// negative lookup caching
+	NegativeLookupValid
+	PermDeniedValid
+
 	MaxMountOption
 )
 
@@ -169,6 +204,31 @@ func InitMountOptions(opts []MountOption) {
 	opts[FileSystemName] = MountOption{"fileSystemName", "The explicit name of the filesystem", "", ""}
 	opts[SnapshotReadVerSeq] = MountOption{"snapshotReadSeq", "Snapshot read seq", "", int64(0)} // default false
 
+	opts[EnableSupervisor] = MountOption{"enableSupervisor", "Enable supervisor to probe the mount and remount when wedged", "", false}
+	opts[SupervisorProbeIntervalS] = MountOption{"supervisorProbeIntervalS", "The interval of supervisor probe", "", int64(30)}
+	opts[SupervisorStuckTimeoutS] = MountOption{"supervisorStuckTimeoutS", "The mount is wedged if the probe is stuck longer than the timeout", "", int64(60)}
+	opts[SupervisorMaxRemounts] = MountOption{"supervisorMaxRemounts", "The maximum times of remount by supervisor, 0 means no limit", "", int64(5)}
+
+	opts[SmallReadSize] = MountOption{"smallReadSize", "The read no larger than the size uses the small read budget", "", int64(128 * 1024)}
+	opts[SmallReadTimeoutS] = MountOption{"smallReadTimeoutS", "The timeout of small read, 0 means no limit", "", int64(60)}
+	opts[SmallReadRetry] = MountOption{"smallReadRetry", "The maximum retry times of small read", "", int64(100)}
+	opts[LargeReadTimeoutS] = MountOption{"largeReadTimeoutS", "The timeout of large read, 0 means no limit", "", int64(300)}
+	opts[LargeReadRetry] = MountOption{"largeReadRetry", "The maximum retry times of large read", "", int64(200)}
+	opts[WriteTimeoutS] = MountOption{"writeTimeoutS", "The timeout of write, 0 means no limit", "", int64(300)}
+	opts[WriteRetry] = MountOption{"writeRetry", "The maximum retry times of write", "", int64(200)}
+	opts[MetaRetry] = MountOption{"metaRetry", "The maximum retry times of meta request", "", int64(200)}
+
+	opts[FuseQueues] = MountOption{"fuseQueues", "The number of fuse device queues, 0 means one queue per CPU", "", int64(1)}
+	opts[FuseQueueWorkers] = MountOption{"fuseQueueWorkers", "The number of handler goroutines of each extra fuse queue, 0 means one goroutine per request", "", int64(64)}
+
+	opts[NamespacePath] = MountOption{"namespacePath", "The path of the global namespace to mount, the volume and sub directory are resolved by the mount map of the master", "", ""}
+	opts[ReaddirEncoding] = MountOption{"readdirEncoding", "The encoding of the readdir dentries on the wire, delta and/or gzip, such as delta,gzip", "", ""}
+	opts[WireCompress] = MountOption{"wireCompress", "Compress the data on the wire to the datanodes by lz4 if the datanodes accept, for the volumes mounted over WAN", "", false}
+	opts[EnablePlacementHint] = MountOption{"enablePlacementHint", "Write the new extents of the files to the data partitions selected by the placement hint xattr of the files", "", false}
+	opts[MetaHedgeDelayMs] = MountOption{"metaHedgeDelayMs", "Hedge the lookups and inode gets to a metanode follower if the leader does not respond in the delay, 0 disables", "", int64(0)}
+	opts[NegativeLookupValid] = MountOption{"negativeLookupValid", "Seconds to cache the lookups of the names not existing, at most 60, 0 disables", "", int64(0)}
+	opts[PermDeniedValid] = MountOption{"permDeniedValid", "Seconds to cache the lookups denied for the caller, at most 10, 0 disables", "", int64(0)}
+
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
 	}
@@ -327,4 +387,25 @@ type MountOptions struct {
 	MinWriteAbleDataPartitionCnt int
 	FileSystemName               string
 	VerReadSeq                   uint64
+	EnableSupervisor             bool
+	SupervisorProbeIntervalS     int64
+	SupervisorStuckTimeoutS      int64
+	SupervisorMaxRemounts        int64
+	SmallReadSize                int64
+	SmallReadTimeoutS            int64
+	SmallReadRetry               int64
+	LargeReadTimeoutS            int64
+	LargeReadRetry               int64
+	WriteTimeoutS                int64
+	WriteRetry                   int64
+	MetaRetry                    int64
+	FuseQueues                   int64
+	FuseQueueWorkers             int64
+	NamespacePath                string
+	ReaddirEncoding              string
+	WireCompress                 bool
+	EnablePlacementHint          bool
+	MetaHedgeDelayMs             int64
+	NegativeLookupValid          int64
+	PermDeniedValid              int64
 }
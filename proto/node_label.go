@@ -0,0 +1,200 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	LabelOpEqual     = "="
+	LabelOpNotEqual  = "!="
+	LabelOpExists    = "exists"
+	LabelOpNotExists = "!exists"
+)
+
+// LabelRequirement is one requirement of the label selector.
+type LabelRequirement struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// LabelSelector selects the nodes whose labels meet all the requirements, it is written as
+// the comma separated requirements, such as "rack=gpu-rack,disk!=hdd,encryption,!deprecated":
+//   - key=value: the node has the label with the value.
+//   - key!=value: the node has not the label with the value.
+//   - key: the node has the label.
+//   - !key: the node has not the label.
+type LabelSelector []LabelRequirement
+
+func validLabelKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "=!, ")
+}
+
+// ParseNodeLabels parses the labels written as "key=value,key", the value of the key without
+// value is empty.
+func ParseNodeLabels(s string) (labels map[string]string, err error) {
+	labels = make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, value := item, ""
+		if idx := strings.Index(item, "="); idx >= 0 {
+			key, value = strings.TrimSpace(item[:idx]), strings.TrimSpace(item[idx+1:])
+		}
+		if !validLabelKey(key) || strings.ContainsAny(value, "=, ") {
+			return nil, fmt.Errorf("invalid label %v", item)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// FormatNodeLabels formats the labels in the order of the keys.
+func FormatNodeLabels(labels map[string]string) string {
+	items := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if value == "" {
+			items = append(items, key)
+			continue
+		}
+		items = append(items, key+"="+value)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// ParseLabelSelector parses the selector, the empty selector selects all the nodes.
+func ParseLabelSelector(s string) (selector LabelSelector, err error) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		var req LabelRequirement
+		switch {
+		case strings.Contains(item, LabelOpNotEqual):
+			idx := strings.Index(item, LabelOpNotEqual)
+			req = LabelRequirement{Key: item[:idx], Op: LabelOpNotEqual, Value: item[idx+len(LabelOpNotEqual):]}
+		case strings.Contains(item, LabelOpEqual):
+			idx := strings.Index(item, LabelOpEqual)
+			req = LabelRequirement{Key: item[:idx], Op: LabelOpEqual, Value: item[idx+len(LabelOpEqual):]}
+		case strings.HasPrefix(item, "!"):
+			req = LabelRequirement{Key: item[1:], Op: LabelOpNotExists}
+		default:
+			req = LabelRequirement{Key: item, Op: LabelOpExists}
+		}
+		req.Key, req.Value = strings.TrimSpace(req.Key), strings.TrimSpace(req.Value)
+		if !validLabelKey(req.Key) || strings.ContainsAny(req.Value, "=!, ") {
+			return nil, fmt.Errorf("invalid label selector %v", item)
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// Matches returns true if the labels meet all the requirements of the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.Key]
+		switch req.Op {
+		case LabelOpEqual:
+			if !ok || value != req.Value {
+				return false
+			}
+		case LabelOpNotEqual:
+			if ok && value == req.Value {
+				return false
+			}
+		case LabelOpExists:
+			if !ok {
+				return false
+			}
+		case LabelOpNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s LabelSelector) String() string {
+	items := make([]string, 0, len(s))
+	for _, req := range s {
+		switch req.Op {
+		case LabelOpEqual, LabelOpNotEqual:
+			items = append(items, req.Key+req.Op+req.Value)
+		case LabelOpExists:
+			items = append(items, req.Key)
+		case LabelOpNotExists:
+			items = append(items, "!"+req.Key)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// PlacementViolation is the replica on the node not selected by the label selector of the volume,
+// the labels of the node or the selector of the volume are changed after the partition is created.
+type PlacementViolation struct {
+	VolName       string
+	PartitionID   uint64
+	PartitionType string // data or meta
+	Addr          string
+	LabelSelector string
+	NodeLabels    string
+}
+
+// PlacementViolationsView is the replicas violating the label selectors of the volumes.
+type PlacementViolationsView struct {
+	Violations []*PlacementViolation
+}
+
+const (
+	// XAttrPlacementHint is the xattr of the file written as the label selector, the new extents of
+	// the file are written to the data partitions whose placement labels are selected, such as
+	// "media=ssd" or "zone=zone1". The partitions of the volume are used if none is selected.
+	XAttrPlacementHint = "cfs.placement"
+
+	// PlacementLabelZone is the placement label of the zone of the first replica of the data partition,
+	// which takes the writes of the clients.
+	PlacementLabelZone = "zone"
+)
+
+// PlacementLabels returns the labels shared by the nodes of all the replicas, with the zone label set
+// to the zone of the first replica.
+func PlacementLabels(nodeLabels []map[string]string, firstZone string) map[string]string {
+	labels := make(map[string]string)
+	for i, nl := range nodeLabels {
+		if i == 0 {
+			for key, value := range nl {
+				labels[key] = value
+			}
+			continue
+		}
+		for key, value := range labels {
+			if v, ok := nl[key]; !ok || v != value {
+				delete(labels, key)
+			}
+		}
+	}
+	if firstZone != "" {
+		labels[PlacementLabelZone] = firstZone
+	}
+	return labels
+}
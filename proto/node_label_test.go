@@ -0,0 +1,79 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeLabels(t *testing.T) {
+	labels, err := ParseNodeLabels(" rack=gpu-rack, encryption ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"rack": "gpu-rack", "encryption": ""}, labels)
+	require.Equal(t, "encryption,rack=gpu-rack", FormatNodeLabels(labels))
+
+	labels, err = ParseNodeLabels("")
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	for _, s := range []string{"=v", "a=b=c", "!a", "a b=c"} {
+		_, err = ParseNodeLabels(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector("rack=gpu-rack, disk!=hdd,encryption,!deprecated")
+	require.NoError(t, err)
+	require.Equal(t, 4, len(selector))
+	require.Equal(t, "rack=gpu-rack,disk!=hdd,encryption,!deprecated", selector.String())
+
+	require.True(t, selector.Matches(map[string]string{"rack": "gpu-rack", "encryption": ""}))
+	require.True(t, selector.Matches(map[string]string{"rack": "gpu-rack", "encryption": "", "disk": "ssd"}))
+	require.False(t, selector.Matches(map[string]string{"rack": "gpu-rack", "encryption": "", "disk": "hdd"}))
+	require.False(t, selector.Matches(map[string]string{"rack": "cpu-rack", "encryption": ""}))
+	require.False(t, selector.Matches(map[string]string{"rack": "gpu-rack"}))
+	require.False(t, selector.Matches(map[string]string{"rack": "gpu-rack", "encryption": "", "deprecated": ""}))
+	require.False(t, selector.Matches(nil))
+
+	selector, err = ParseLabelSelector("")
+	require.NoError(t, err)
+	require.True(t, selector.Matches(nil))
+
+	for _, s := range []string{"=v", "!", "a=b=c", "a!=!b", "!!a"} {
+		_, err = ParseLabelSelector(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestPlacementLabels(t *testing.T) {
+	labels := PlacementLabels([]map[string]string{
+		{"media": "ssd", "rack": "r1", "encryption": ""},
+		{"media": "ssd", "rack": "r2", "encryption": ""},
+		{"media": "ssd", "encryption": ""},
+	}, "zone1")
+	require.Equal(t, map[string]string{"media": "ssd", "encryption": "", PlacementLabelZone: "zone1"}, labels)
+
+	selector, err := ParseLabelSelector("media=ssd,zone=zone1")
+	require.NoError(t, err)
+	require.True(t, selector.Matches(labels))
+	selector, err = ParseLabelSelector("zone=zone2")
+	require.NoError(t, err)
+	require.False(t, selector.Matches(labels))
+
+	require.Equal(t, map[string]string{}, PlacementLabels(nil, ""))
+}
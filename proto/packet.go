@@ -69,6 +69,7 @@ const (
 	OpReadTinyDeleteRecord           uint8 = 0x14
 	OpTinyExtentRepairRead           uint8 = 0x15
 	OpGetMaxExtentIDAndPartitionSize uint8 = 0x16
+	OpSetExtentEnvelope              uint8 = 0x17
 
 	// Operations: Client -> MetaNode.
 	OpMetaCreateInode   uint8 = 0x20
@@ -102,6 +103,8 @@ const (
 	OpMetaBatchGetXAttr      uint8 = 0x39
 	OpMetaExtentAddWithCheck uint8 = 0x3A // Append extent key with discard extents check
 	OpMetaReadDirLimit       uint8 = 0x3D
+	OpMetaReadDirStream      uint8 = 0x3E
+	OpMetaReadDirChanges     uint8 = 0x3F
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -150,6 +153,7 @@ const (
 
 	OpBatchDeleteExtent   uint8 = 0x75 // SDK to MetaNode
 	OpGetExpiredMultipart uint8 = 0x76
+	OpMetaReadDirFiltered uint8 = 0x77 // SDK to MetaNode
 
 	// Operations: MetaNode Leader -> MetaNode Follower
 	OpMetaBatchDeleteInode  uint8 = 0x90
@@ -184,6 +188,9 @@ const (
 	OpSyncTryWriteAppend    uint8 = 0xB7
 	OpVersionOp             uint8 = 0xB8
 
+	// raft command of the encryption envelope of the extent
+	OpExtentEnvelope uint8 = 0xB9
+
 	// Commons
 	OpNoSpaceErr uint8 = 0xEE
 	OpDirQuota   uint8 = 0xF1
@@ -243,6 +250,9 @@ const (
 	OpVersionOperation uint8 = 0xD5
 	OpSplitMarkDelete  uint8 = 0xD6
 	OpTryOtherExtent   uint8 = 0xD7
+
+	// negotiate the wire compression of the connection between the client and the datanode
+	OpNegotiateWireCompress uint8 = 0xD8
 )
 
 const (
@@ -255,6 +265,9 @@ const (
 	DefaultClusterLoadFactor          float64 = 10
 	MultiVersionFlag                          = 0x80
 	VersionListFlag                           = 0x40
+	// set in the extent type while the data is compressed on the wire, or in the read request
+	// accepting the compressed replies, it is cleared once the packet is received
+	WireCompressFlag = 0x20
 )
 
 // multi version operation
@@ -383,6 +396,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMarkDelete"
 	case OpSplitMarkDelete:
 		m = "OpMarkDelete"
+	case OpNegotiateWireCompress:
+		m = "OpNegotiateWireCompress"
 	case OpWrite:
 		m = "OpWrite"
 	case OpTryWriteAppend:
@@ -435,6 +450,12 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaReadDir"
 	case OpMetaReadDirLimit:
 		m = "OpMetaReadDirLimit"
+	case OpMetaReadDirStream:
+		m = "OpMetaReadDirStream"
+	case OpMetaReadDirChanges:
+		m = "OpMetaReadDirChanges"
+	case OpMetaReadDirFiltered:
+		m = "OpMetaReadDirFiltered"
 	case OpMetaInodeGet:
 		m = "OpMetaInodeGet"
 	case OpMetaBatchInodeGet:
@@ -511,6 +532,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpTinyExtentRepairRead"
 	case OpGetMaxExtentIDAndPartitionSize:
 		m = "OpGetMaxExtentIDAndPartitionSize"
+	case OpSetExtentEnvelope:
+		m = "OpSetExtentEnvelope"
 	case OpBroadcastMinAppliedID:
 		m = "OpBroadcastMinAppliedID"
 	case OpRemoveDataPartitionRaftMember:
@@ -174,6 +174,22 @@ const (
 	OSSPutObjectLockConfigurationAction Action = OSSActionPrefix + "PutObjectLockConfiguration"
 	OSSGetObjectLockConfigurationAction Action = OSSActionPrefix + "GetObjectLockConfiguration"
 
+	// Soft delete actions
+	OSSPutBucketSoftDeleteAction      Action = OSSActionPrefix + "PutBucketSoftDelete"
+	OSSGetBucketSoftDeleteAction      Action = OSSActionPrefix + "GetBucketSoftDelete"
+	OSSListSoftDeletedObjectsAction   Action = OSSActionPrefix + "ListSoftDeletedObjects"
+	OSSRecoverSoftDeletedObjectAction Action = OSSActionPrefix + "RecoverSoftDeletedObject"
+
+	// Policy simulation actions
+	OSSSimulateBucketPolicyAction Action = OSSActionPrefix + "SimulateBucketPolicy"
+
+	// Verify actions
+	OSSStartBucketVerifyAction Action = OSSActionPrefix + "StartBucketVerify"
+	OSSGetBucketVerifyAction   Action = OSSActionPrefix + "GetBucketVerify"
+
+	// Archive actions
+	OSSGetBucketArchiveAction Action = OSSActionPrefix + "GetBucketArchive"
+
 	NoneAction Action = ""
 )
 
@@ -258,6 +274,18 @@ var AllActions = []Action{
 
 	OSSPutObjectLockConfigurationAction,
 	OSSGetObjectLockConfigurationAction,
+
+	OSSPutBucketSoftDeleteAction,
+	OSSGetBucketSoftDeleteAction,
+	OSSListSoftDeletedObjectsAction,
+	OSSRecoverSoftDeletedObjectAction,
+
+	OSSSimulateBucketPolicyAction,
+
+	OSSStartBucketVerifyAction,
+	OSSGetBucketVerifyAction,
+
+	OSSGetBucketArchiveAction,
 }
 
 func ParseAction(str string) Action {
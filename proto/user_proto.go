@@ -98,15 +98,16 @@ type AKUser struct {
 }
 
 type UserInfo struct {
-	UserID      string       `json:"user_id" graphql:"user_id"`
-	AccessKey   string       `json:"access_key" graphql:"access_key"`
-	SecretKey   string       `json:"secret_key" graphql:"secret_key"`
-	Policy      *UserPolicy  `json:"policy" graphql:"policy"`
-	UserType    UserType     `json:"user_type" graphql:"user_type"`
-	CreateTime  string       `json:"create_time" graphql:"create_time"`
-	Description string       `json:"description" graphql:"description"`
-	Mu          sync.RWMutex `json:"-" graphql:"-"`
-	EMPTY       bool         // graphql need ???
+	UserID      string         `json:"user_id" graphql:"user_id"`
+	AccessKey   string         `json:"access_key" graphql:"access_key"`
+	SecretKey   string         `json:"secret_key" graphql:"secret_key"`
+	Policy      *UserPolicy    `json:"policy" graphql:"policy"`
+	UserType    UserType       `json:"user_type" graphql:"user_type"`
+	CreateTime  string         `json:"create_time" graphql:"create_time"`
+	Description string         `json:"description" graphql:"description"`
+	Posix       *PosixIdentity `json:"posix,omitempty" graphql:"posix"`
+	Mu          sync.RWMutex   `json:"-" graphql:"-"`
+	EMPTY       bool           // graphql need ???
 }
 
 func (i *UserInfo) String() string {
@@ -121,6 +122,27 @@ func NewUserInfo() *UserInfo {
 	return &UserInfo{Policy: NewUserPolicy()}
 }
 
+// PosixIdentity is the POSIX identity a user is mapped to. Requests signed
+// with the user's access key act on the file system as this uid and gid, so
+// S3 and POSIX clients share a single owner model.
+type PosixIdentity struct {
+	Uid    uint32   `json:"uid" graphql:"uid"`
+	Gid    uint32   `json:"gid" graphql:"gid"`
+	Groups []uint32 `json:"groups,omitempty" graphql:"groups"`
+}
+
+func (identity *PosixIdentity) InGroup(gid uint32) bool {
+	if identity.Gid == gid {
+		return true
+	}
+	for _, group := range identity.Groups {
+		if group == gid {
+			return true
+		}
+	}
+	return false
+}
+
 type VolUser struct {
 	Vol     string       `json:"vol"`
 	UserIDs []string     `json:"user_id"`
@@ -360,6 +382,11 @@ func NewUserPermRemoveParam(userID, volmue string) *UserPermRemoveParam {
 	return &UserPermRemoveParam{UserID: userID, Volume: volmue}
 }
 
+type UserPosixIdentityParam struct {
+	UserID string        `json:"user_id"`
+	Posix  PosixIdentity `json:"posix"`
+}
+
 type UserTransferVolParam struct {
 	Volume  string `json:"volume"`
 	UserSrc string `json:"user_src"`
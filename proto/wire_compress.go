@@ -0,0 +1,120 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/pierrec/lz4"
+)
+
+const (
+	WireCompressLz4 = "lz4"
+
+	// the data smaller than the size is sent as it is
+	WireCompressMinSize = 4 * util.KB
+	// the packets skipped compressing after the incompressible data
+	wireCompressSkipPackets = 16
+	// the compressed data begins with the size of the raw data
+	wireCompressHeaderSize = 4
+)
+
+var ErrWireCompressCorrupted = errors.New("corrupted wire compressed data")
+
+// IsWireCompressed returns true if the data is compressed on the wire, or the read request
+// accepts the compressed replies.
+func (p *Packet) IsWireCompressed() bool {
+	return p.ExtentType&WireCompressFlag == WireCompressFlag
+}
+
+// CompressData compresses the data of the packet by lz4 for the wire. The data is kept if it is
+// smaller than minSize or it is incompressible, false is returned then. The packet is usually a
+// copy of the one to send, so that the raw data is kept for the retries.
+func (p *Packet) CompressData(minSize int) bool {
+	size := int(p.Size)
+	if size < minSize || size > len(p.Data) {
+		return false
+	}
+	// the compressed data saving less than 1/8 is incompressible
+	dst := make([]byte, wireCompressHeaderSize+size-size/8)
+	n, err := lz4.CompressBlock(p.Data[:size], dst[wireCompressHeaderSize:], nil)
+	if err != nil || n == 0 {
+		return false
+	}
+	binary.BigEndian.PutUint32(dst, uint32(size))
+	p.Data = dst[:wireCompressHeaderSize+n]
+	p.Size = uint32(len(p.Data))
+	p.ExtentType |= WireCompressFlag
+	return true
+}
+
+// DecompressData decompresses the data compressed on the wire into buf, a new buffer is allocated
+// if buf is nil. The data and the size of the packet are set to the raw data. The raw data of a
+// packet never exceeds util.BlockSize, a larger size in the header is rejected before allocating.
+func (p *Packet) DecompressData(buf []byte) (err error) {
+	if !p.IsWireCompressed() {
+		return
+	}
+	if p.Size < wireCompressHeaderSize || int(p.Size) > len(p.Data) {
+		return ErrWireCompressCorrupted
+	}
+	rawSize := int(binary.BigEndian.Uint32(p.Data))
+	if rawSize > util.BlockSize {
+		return ErrWireCompressCorrupted
+	}
+	if buf == nil {
+		if p.IsWriteOperation() && rawSize == util.BlockSize {
+			buf, _ = Buffers.Get(rawSize)
+		} else {
+			buf = make([]byte, rawSize)
+		}
+	}
+	if rawSize > len(buf) {
+		return ErrWireCompressCorrupted
+	}
+	n, err := lz4.UncompressBlock(p.Data[wireCompressHeaderSize:p.Size], buf[:rawSize])
+	if err != nil || n != rawSize {
+		return ErrWireCompressCorrupted
+	}
+	p.Data = buf[:rawSize]
+	p.Size = uint32(rawSize)
+	p.ExtentType &^= WireCompressFlag
+	return
+}
+
+// WireCompressor compresses the packets for the wire, and skips compressing the next packets
+// after the incompressible data, so that little cpu is wasted on the incompressible data.
+type WireCompressor struct {
+	skip int32
+}
+
+// Compress compresses the data of the packet, see Packet.CompressData.
+func (w *WireCompressor) Compress(p *Packet, minSize int) bool {
+	if int(p.Size) < minSize {
+		return false
+	}
+	if atomic.LoadInt32(&w.skip) > 0 {
+		atomic.AddInt32(&w.skip, -1)
+		return false
+	}
+	if !p.CompressData(minSize) {
+		atomic.StoreInt32(&w.skip, wireCompressSkipPackets)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWireCompress(t *testing.T) {
+	raw := bytes.Repeat([]byte("cubefs wire compression "), 1024)
+	p := NewPacket()
+	p.Opcode = OpStreamRead
+	p.ResultCode = OpOk
+	p.Data, p.Size = raw, uint32(len(raw))
+
+	wire := *p
+	require.True(t, wire.CompressData(WireCompressMinSize))
+	require.True(t, wire.IsWireCompressed())
+	require.Less(t, int(wire.Size), len(raw))
+	require.Equal(t, uint32(len(raw)), p.Size)
+
+	reply := wire
+	reply.Data = append([]byte{}, wire.Data...)
+	buf := make([]byte, len(raw))
+	require.NoError(t, reply.DecompressData(buf))
+	require.False(t, reply.IsWireCompressed())
+	require.Equal(t, raw, reply.Data)
+
+	// the corrupted data
+	wire.Data[len(wire.Data)-1] ^= 0xff
+	wire.Data[wireCompressHeaderSize] ^= 0xff
+	require.Error(t, wire.DecompressData(nil))
+
+	// the raw size exceeding the block is rejected
+	huge := &Packet{Data: make([]byte, 64), Size: 64, ExtentType: WireCompressFlag}
+	binary.BigEndian.PutUint32(huge.Data, math.MaxUint32)
+	require.ErrorIs(t, huge.DecompressData(nil), ErrWireCompressCorrupted)
+
+	// the small and the incompressible data
+	small := &Packet{Data: raw[:100], Size: 100}
+	require.False(t, small.CompressData(WireCompressMinSize))
+	random := make([]byte, 64*1024)
+	rand.Read(random)
+	var w WireCompressor
+	require.False(t, w.Compress(&Packet{Data: random, Size: uint32(len(random))}, WireCompressMinSize))
+	require.False(t, w.Compress(&Packet{Data: raw, Size: uint32(len(raw))}, WireCompressMinSize))
+	w.skip = 0
+	require.True(t, w.Compress(&Packet{Data: raw, Size: uint32(len(raw))}, WireCompressMinSize))
+}
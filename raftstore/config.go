@@ -16,6 +16,7 @@ package raftstore
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
 )
@@ -71,6 +72,9 @@ type PartitionConfig struct {
 	Peers   []PeerAddress
 	SM      PartitionFsm
 	WalPath string
+	// WalSync and WalSyncDelay are the durability of the raft log, see Partition.SetWalSync.
+	WalSync      bool
+	WalSyncDelay time.Duration
 }
 
 func (p PeerAddress) String() string {
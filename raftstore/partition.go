@@ -16,9 +16,11 @@ package raftstore
 
 import (
 	"os"
+	"time"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft"
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	"github.com/cubefs/cubefs/depends/tiglabs/raft/storage/wal"
 )
 
 // PartitionStatus is a type alias of raft.Status
@@ -68,6 +70,11 @@ type Partition interface {
 	Truncate(index uint64)
 	TryToLeader(nodeID uint64) error
 	IsOfflinePeer() bool
+
+	// SetWalSync changes whether the raft log is synced before it is acknowledged, the syncs
+	// are at least the delay apart and the log stored in the meantime is grouped into one
+	// delayed sync.
+	SetWalSync(sync bool, delay time.Duration)
 }
 
 // Default implementation of the Partition interface.
@@ -76,6 +83,7 @@ type partition struct {
 	raft    *raft.RaftServer
 	walPath string
 	config  *PartitionConfig
+	ws      *wal.Storage
 }
 
 // ChangeMember submits member change event and information to raft log.
@@ -174,6 +182,13 @@ func (p *partition) Submit(cmd []byte) (resp interface{}, err error) {
 	return
 }
 
+// SetWalSync changes the durability of the raft log.
+func (p *partition) SetWalSync(sync bool, delay time.Duration) {
+	if p.ws != nil {
+		p.ws.SetSync(sync, delay)
+	}
+}
+
 // Truncate truncates the raft log
 func (p *partition) Truncate(index uint64) {
 	if p.raft != nil {
@@ -181,11 +196,12 @@ func (p *partition) Truncate(index uint64) {
 	}
 }
 
-func newPartition(cfg *PartitionConfig, raft *raft.RaftServer, walPath string) Partition {
+func newPartition(cfg *PartitionConfig, raft *raft.RaftServer, walPath string, ws *wal.Storage) Partition {
 	return &partition{
 		id:      cfg.ID,
 		raft:    raft,
 		walPath: walPath,
 		config:  cfg,
+		ws:      ws,
 	}
 }
@@ -163,7 +163,7 @@ func (s *raftStore) CreatePartition(cfg *PartitionConfig) (p Partition, err erro
 		walPath = path.Join(cfg.WalPath, "wal_"+strconv.FormatUint(cfg.ID, 10))
 	}
 
-	wc := &wal.Config{}
+	wc := &wal.Config{Sync: cfg.WalSync, SyncDelay: cfg.WalSyncDelay}
 	ws, err := wal.NewStorage(walPath, wc)
 	if err != nil {
 		return
@@ -192,6 +192,6 @@ func (s *raftStore) CreatePartition(cfg *PartitionConfig) (p Partition, err erro
 	if err = s.raftServer.CreateRaft(rc); err != nil {
 		return
 	}
-	p = newPartition(cfg, s.raftServer, walPath)
+	p = newPartition(cfg, s.raftServer, walPath, ws)
 	return
 }
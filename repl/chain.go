@@ -0,0 +1,79 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repl
+
+import (
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// ReplChainFlag is set in RemainingFollowers of the packet forwarded to the head of a follower
+// chain, the head forwards the packet to the rest followers of the chain in the arg. The packet
+// is not a leader packet on the head.
+const ReplChainFlag uint8 = 0x80
+
+// SetReplChain forwards the packets to the followers in the same remote zone by a chain, so that
+// the data crosses the zones once to the head of the chain instead of once per follower. zoneOf
+// returns the zone of the follower, the follower of the unknown zone is sent directly.
+func (rp *ReplProtocol) SetReplChain(localZone string, zoneOf func(addr string) (zone string, ok bool)) {
+	rp.localZone = localZone
+	rp.zoneOf = zoneOf
+}
+
+// IsChainedPacket returns true if the packet is forwarded to the head of a follower chain.
+func (p *Packet) IsChainedPacket() bool {
+	return p.RemainingFollowers&ReplChainFlag == ReplChainFlag && !p.isSpecialReplicaCntPacket()
+}
+
+// planFollowerChains groups the followers in the same remote zone into one chain in the order of
+// the followers, the first follower of each chain is sent by the leader. The followers in the
+// local zone and of the unknown zones make the chains of themselves.
+func planFollowerChains(followers []string, localZone string, zoneOf func(addr string) (string, bool)) (chains [][]string) {
+	chains = make([][]string, 0, len(followers))
+	zoneChains := make(map[string]int)
+	for _, addr := range followers {
+		var (
+			zone string
+			ok   bool
+		)
+		if zoneOf != nil {
+			zone, ok = zoneOf(addr)
+		}
+		if !ok || zone == "" || zone == localZone {
+			chains = append(chains, []string{addr})
+			continue
+		}
+		if index, exist := zoneChains[zone]; exist {
+			chains[index] = append(chains[index], addr)
+			continue
+		}
+		zoneChains[zone] = len(chains)
+		chains = append(chains, []string{addr})
+	}
+	return
+}
+
+// setChainFollowers sets the rest followers of the chain to the packet forwarded to the head.
+func (p *FollowerPacket) setChainFollowers(followers []string) {
+	p.RemainingFollowers = 0
+	p.Arg, p.ArgLen = nil, 0
+	if len(followers) == 0 {
+		return
+	}
+	p.RemainingFollowers = ReplChainFlag | uint8(len(followers))
+	p.Arg = []byte(strings.Join(followers, proto.AddrSplit) + proto.AddrSplit)
+	p.ArgLen = uint32(len(p.Arg))
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repl
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanFollowerChains(t *testing.T) {
+	zones := map[string]string{"a1": "a", "b1": "b", "b2": "b", "c1": "c"}
+	zoneOf := func(addr string) (string, bool) {
+		zone, ok := zones[addr]
+		return zone, ok
+	}
+
+	require.Equal(t, [][]string{{"b1", "b2"}}, planFollowerChains([]string{"b1", "b2"}, "a", zoneOf))
+	require.Equal(t, [][]string{{"a1"}, {"b1", "b2"}, {"c1"}, {"x1"}},
+		planFollowerChains([]string{"a1", "b1", "c1", "b2", "x1"}, "a", zoneOf))
+	require.Equal(t, [][]string{{"b1"}, {"b2"}}, planFollowerChains([]string{"b1", "b2"}, "b", zoneOf))
+	require.Equal(t, [][]string{{"b1"}, {"b2"}}, planFollowerChains([]string{"b1", "b2"}, "a", nil))
+}
+
+func TestChainedPacket(t *testing.T) {
+	fp := NewFollowerPacket()
+	fp.Opcode = proto.OpWrite
+	fp.setChainFollowers([]string{"b2", "b3"})
+
+	p := NewPacket()
+	p.Opcode = fp.Opcode
+	p.RemainingFollowers = fp.RemainingFollowers
+	p.Arg, p.ArgLen = fp.Arg, fp.ArgLen
+	require.NoError(t, p.resolveFollowersAddr())
+	require.Equal(t, []string{"b2", "b3"}, p.followersAddrs)
+	require.True(t, p.IsChainedPacket())
+	require.True(t, p.IsForwardPacket())
+	require.False(t, p.IsLeaderPacket())
+
+	p.RemainingFollowers = 2
+	require.False(t, p.IsChainedPacket())
+	require.True(t, p.IsLeaderPacket())
+
+	fp.setChainFollowers(nil)
+	require.Equal(t, uint8(0), fp.RemainingFollowers)
+	require.Equal(t, uint32(0), fp.ArgLen)
+}
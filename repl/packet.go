@@ -48,6 +48,8 @@ type Packet struct {
 	// used locally
 	shallDegrade bool
 	AfterPre     bool
+
+	acceptCompressedReply bool
 }
 
 type FollowerPacket struct {
@@ -175,6 +177,29 @@ func (p *Packet) resolveFollowersAddr() (err error) {
 	return
 }
 
+// resolveWireCompression decompresses the data compressed on the wire, and records the read request
+// accepting the compressed replies. The flag is cleared, so that the packet is processed as usual.
+func (p *Packet) resolveWireCompression() (err error) {
+	if !p.IsWireCompressed() {
+		return
+	}
+	if p.IsReadOperation() {
+		p.acceptCompressedReply = true
+		p.ExtentType &^= proto.WireCompressFlag
+		return
+	}
+	if err = p.DecompressData(nil); err != nil {
+		p.ExtentType &^= proto.WireCompressFlag
+		p.PackErrorBody(ActionPreparePkt, err.Error())
+	}
+	return
+}
+
+// AcceptCompressedReply returns true if the read request accepts the replies compressed on the wire.
+func (p *Packet) AcceptCompressedReply() bool {
+	return p.acceptCompressedReply
+}
+
 func NewPacket() (p *Packet) {
 	p = new(Packet)
 	p.Magic = proto.ProtoMagic
@@ -194,6 +219,22 @@ func NewPacketToGetAllWatermarks(partitionID uint64, extentType uint8) (p *Packe
 	return
 }
 
+// NewPacketToSetExtentEnvelope returns the packet to set the encryption envelope of the extent,
+// which is sent to the raft leader of the partition and replicated to the replicas by raft.
+func NewPacketToSetExtentEnvelope(partitionID, extentID uint64, data []byte) (p *Packet) {
+	p = new(Packet)
+	p.Opcode = proto.OpSetExtentEnvelope
+	p.PartitionID = partitionID
+	p.ExtentID = extentID
+	p.Magic = proto.ProtoMagic
+	p.ReqID = proto.GenerateRequestID()
+	p.ExtentType = proto.NormalExtentType
+	p.Data = data
+	p.Size = uint32(len(data))
+
+	return
+}
+
 func NewPacketToReadTinyDeleteRecord(partitionID uint64, offset int64) (p *Packet) {
 	p = new(Packet)
 	p.Opcode = proto.OpReadTinyDeleteRecord
@@ -313,6 +354,8 @@ func (p *Packet) identificationErrorResultCode(errLog string, errMsg string) {
 		p.ResultCode = proto.OpTryOtherAddr
 	} else if strings.Contains(errMsg, raft.ErrStopped.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, storage.BlockCorruptError.Error()) {
+		p.ResultCode = proto.OpTryOtherAddr
 	} else if strings.Contains(errMsg, storage.VerNotConsistentError.Error()) {
 		p.ResultCode = proto.ErrCodeVersionOpError
 		// log.LogDebugf("action[identificationErrorResultCode] not change ver erro code, (%v)", string(debug.Stack()))
@@ -369,7 +412,7 @@ func (p *Packet) isSpecialReplicaCntPacket() bool {
 
 // A leader packet is the packet send to the leader and does not require packet forwarding.
 func (p *Packet) IsLeaderPacket() (ok bool) {
-	if (p.IsForwardPkt() || p.isSpecialReplicaCntPacket()) &&
+	if (p.IsForwardPkt() || p.isSpecialReplicaCntPacket()) && !p.IsChainedPacket() &&
 		(p.IsNormalWriteOperation() || p.IsCreateExtentOperation() || p.IsMarkDeleteExtentOperation()) {
 		ok = true
 	}
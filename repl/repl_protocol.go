@@ -59,6 +59,9 @@ type ReplProtocol struct {
 	getSmuxConn func(addr string) (c net.Conn, err error)
 	putSmuxConn func(conn net.Conn, force bool)
 
+	localZone string
+	zoneOf    func(addr string) (zone string, ok bool)
+
 	isError int32
 	replId  int64
 }
@@ -271,6 +274,10 @@ func (rp *ReplProtocol) readPkgAndPrepare() (err error) {
 	// log.LogDebugf("action[readPkgAndPrepare] packet(%v) op %v from remote(%v) conn(%v) ",
 	//	request.GetUniqueLogId(), request.Opcode, rp.sourceConn.RemoteAddr().String(), rp.sourceConn)
 
+	if err = request.resolveWireCompression(); err != nil {
+		err = rp.putResponse(request)
+		return
+	}
 	if err = request.resolveFollowersAddr(); err != nil {
 		err = rp.putResponse(request)
 		return
@@ -286,15 +293,17 @@ func (rp *ReplProtocol) readPkgAndPrepare() (err error) {
 }
 
 func (rp *ReplProtocol) sendRequestToAllFollowers(request *Packet) (index int, err error) {
-	for index = 0; index < len(request.followersAddrs); index++ {
+	chains := planFollowerChains(request.followersAddrs, rp.localZone, rp.zoneOf)
+	request.followerPackets = make([]*FollowerPacket, len(chains))
+	for index = 0; index < len(chains); index++ {
 		var transport *FollowerTransport
-		if transport, err = rp.allocateFollowersConns(request, index); err != nil {
+		if transport, err = rp.allocateFollowersConns(request, chains[index][0]); err != nil {
 			request.PackErrorBody(ActionSendToFollowers, err.Error())
 			return
 		}
 		followerRequest := NewFollowerPacket()
 		copyPacket(request, followerRequest)
-		followerRequest.RemainingFollowers = 0
+		followerRequest.setChainFollowers(chains[index][1:])
 		request.followerPackets[index] = followerRequest
 		transport.Write(followerRequest)
 	}
@@ -377,7 +386,7 @@ func (rp *ReplProtocol) checkLocalResultAndReciveAllFollowerResponse() {
 		return
 	}
 	// NOTE: wait for all followers
-	for index := 0; index < len(response.followersAddrs); index++ {
+	for index := 0; index < len(response.followerPackets); index++ {
 		followerPacket := response.followerPackets[index]
 		err := <-followerPacket.respCh
 		if err != nil {
@@ -458,13 +467,11 @@ func (d *SmuxConn) Close() error {
 
 // Allocate the connections to the followers. We use partitionId + extentId + followerAddr as the key.
 // Note that we need to ensure the order of packets sent to the datanode is consistent here.
-func (rp *ReplProtocol) allocateFollowersConns(p *Packet, index int) (transport *FollowerTransport, err error) {
+func (rp *ReplProtocol) allocateFollowersConns(p *Packet, addr string) (transport *FollowerTransport, err error) {
 	rp.lock.RLock()
-	transport = rp.followerConnects[p.followersAddrs[index]]
+	transport = rp.followerConnects[addr]
 	rp.lock.RUnlock()
 	if transport == nil {
-		addr := p.followersAddrs[index]
-
 		var conn net.Conn
 		if (p.IsMarkDeleteExtentOperation() || p.IsBatchDeleteExtents()) && rp.getSmuxConn != nil {
 			var smuxCon net.Conn
@@ -491,7 +498,7 @@ func (rp *ReplProtocol) allocateFollowersConns(p *Packet, index int) (transport
 		}
 
 		rp.lock.Lock()
-		rp.followerConnects[p.followersAddrs[index]] = transport
+		rp.followerConnects[addr] = transport
 		rp.lock.Unlock()
 	}
 
@@ -58,6 +58,12 @@ type LimitFactor struct {
 	valAllocLastApply  uint64
 	valAllocLastCommit uint64
 	isSetLimitZero     bool
+	// the unused limit of the grids is saved as the credits up to burstCredit, the alloc beyond
+	// the limit and the buffer consumes the credits instead of waiting, so the long-term usage
+	// keeps within the limit assigned by master
+	burstCredit    uint64
+	credit         uint64
+	creditConsumed uint64 // since the last report
 }
 
 func (factor *LimitFactor) getNeedByMagnify(allocCnt uint32, magnify uint32) uint64 {
@@ -116,6 +122,9 @@ func (factor *LimitFactor) alloc(allocCnt uint32) (ret uint8, future *util.Futur
 		factor.lock.RUnlock()
 		factor.lock.Lock()
 		activeState.needWait = true
+		if factor.waitList.Len() == 0 && factor.consumeCredit(grid, allocCnt) {
+			return runNow, nil
+		}
 		future = util.NewFuture()
 
 		factor.waitList.PushBack(&AllocElement{
@@ -151,6 +160,54 @@ func (factor *LimitFactor) alloc(allocCnt uint32) (ret uint8, future *util.Futur
 	return runNow, future
 }
 
+// consumeCredit takes the credits for the part of the alloc beyond the limit and the buffer of
+// the grid, the caller holds the lock.
+func (factor *LimitFactor) consumeCredit(grid *GridElement, allocCnt uint32) bool {
+	used := atomic.LoadUint64(&grid.used) + uint64(allocCnt)
+	if used <= grid.limit+grid.buffer {
+		atomic.AddUint64(&grid.used, uint64(allocCnt))
+		return true
+	}
+	need := used - grid.limit - grid.buffer
+	if need > uint64(allocCnt) {
+		need = uint64(allocCnt)
+	}
+	if need > factor.credit {
+		return false
+	}
+	factor.credit -= need
+	atomic.AddUint64(&factor.creditConsumed, need)
+	atomic.AddUint64(&grid.used, uint64(allocCnt))
+	return true
+}
+
+// saveCredit saves the unused limit of the grid as the credits, the caller holds the lock.
+func (factor *LimitFactor) saveCredit(grid *GridElement) {
+	if !factor.mgr.enable || factor.burstCredit == 0 {
+		factor.credit = 0
+		return
+	}
+	if used := atomic.LoadUint64(&grid.used); used < grid.limit {
+		factor.credit += grid.limit - used
+	}
+	if factor.credit > factor.burstCredit {
+		factor.credit = factor.burstCredit
+	}
+}
+
+func (factor *LimitFactor) SetBurstCredit(burstCredit uint64) {
+	factor.lock.Lock()
+	defer factor.lock.Unlock()
+	if factor.burstCredit != burstCredit {
+		log.QosWriteDebugf("action[SetBurstCredit] factor type [%v] burstCredit [%v] to [%v]",
+			proto.QosTypeString(factor.factorType), factor.burstCredit, burstCredit)
+	}
+	factor.burstCredit = burstCredit
+	if factor.credit > burstCredit {
+		factor.credit = burstCredit
+	}
+}
+
 func (factor *LimitFactor) SetLimit(limitVal uint64, bufferVal uint64) {
 	log.QosWriteDebugf("action[SetLimit] factor type [%v] limitVal [%v] bufferVal [%v]", proto.QosTypeString(factor.factorType), limitVal, bufferVal)
 	var grid *GridElement
@@ -252,6 +309,7 @@ func (factor *LimitFactor) CheckGrid() {
 	factor.lock.Lock()
 
 	grid := factor.gridList.Back().Value.(*GridElement)
+	factor.saveCredit(grid)
 	newGrid := &GridElement{
 		time:   time.Now(),
 		limit:  grid.limit,
@@ -423,10 +481,11 @@ func (limitManager *LimitManager) GetFlowInfo() (*proto.ClientReportLimitInfo, b
 		}
 
 		factor := &proto.ClientLimitInfo{
-			Used:       reqUsed,
-			Need:       limitManager.CalcNeedByPow(limitFactor, reqUsed),
-			UsedLimit:  limitFactor.gridList.Back().Value.(*GridElement).limit * girdCntOneSecond,
-			UsedBuffer: limitFactor.gridList.Back().Value.(*GridElement).buffer * girdCntOneSecond,
+			Used:           reqUsed,
+			Need:           limitManager.CalcNeedByPow(limitFactor, reqUsed),
+			UsedLimit:      limitFactor.gridList.Back().Value.(*GridElement).limit * girdCntOneSecond,
+			UsedBuffer:     limitFactor.gridList.Back().Value.(*GridElement).buffer * girdCntOneSecond,
+			CreditConsumed: atomic.SwapUint64(&limitFactor.creditConsumed, 0),
 		}
 
 		limitFactor.lock.RUnlock()
@@ -516,6 +575,7 @@ func (limitManager *LimitManager) SetClientLimit(limit *proto.LimitRsp2Client) {
 
 	for factorType, clientLimitInfo := range limit.FactorMap {
 		limitManager.limitMap[factorType].SetLimit(clientLimitInfo.UsedLimit, clientLimitInfo.UsedBuffer)
+		limitManager.limitMap[factorType].SetBurstCredit(clientLimitInfo.BurstCredit)
 	}
 	for factorType, magnify := range limit.Magnify {
 		if magnify > 0 && magnify != limitManager.limitMap[factorType].magnify {
@@ -0,0 +1,63 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitFactorBurstCredit(t *testing.T) {
+	mgr := &LimitManager{enable: true, HitTriggerCnt: 255}
+	factor := newLimitFactor(mgr, proto.FlowWriteType)
+	mgr.limitMap = map[uint32]*LimitFactor{proto.FlowWriteType: factor}
+	factor.SetLimit(300, 0)
+	factor.SetBurstCredit(150)
+	require.EqualValues(t, 0, factor.credit)
+
+	// the unused limit of the finished grids is saved up to the burst credit
+	factor.CheckGrid()
+	require.EqualValues(t, 100, factor.credit)
+	factor.CheckGrid()
+	require.EqualValues(t, 150, factor.credit)
+
+	// the alloc beyond the limit consumes the credits instead of waiting
+	ret, future := factor.alloc(150)
+	require.Equal(t, uint8(runNow), ret)
+	require.Nil(t, future)
+	require.EqualValues(t, 100, factor.credit)
+	ret, _ = factor.alloc(100)
+	require.Equal(t, uint8(runNow), ret)
+	require.EqualValues(t, 0, factor.credit)
+
+	// the alloc waits once the credits run out
+	ret, future = factor.alloc(1)
+	require.Equal(t, uint8(runLater), ret)
+	require.NotNil(t, future)
+
+	// the consumed credits are reported once
+	info, _ := mgr.GetFlowInfo()
+	require.EqualValues(t, 150, info.FactorMap[proto.FlowWriteType].CreditConsumed)
+	info, _ = mgr.GetFlowInfo()
+	require.EqualValues(t, 0, info.FactorMap[proto.FlowWriteType].CreditConsumed)
+
+	// the credits are capped once the burst credit is lowered
+	factor.CheckGrid()
+	factor.SetBurstCredit(0)
+	factor.CheckGrid()
+	require.EqualValues(t, 0, factor.credit)
+}
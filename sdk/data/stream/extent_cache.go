@@ -19,7 +19,6 @@ import (
 	"sync"
 
 	"github.com/cubefs/cubefs/proto"
-	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/btree"
 	"github.com/cubefs/cubefs/util/log"
 )
@@ -30,6 +29,7 @@ type ExtentRequest struct {
 	Size       int
 	Data       []byte
 	ExtentKey  *proto.ExtentKey
+	tracker    *retryTracker // budget of the operation issuing the request, may be nil
 }
 
 // String returns the string format of the extent request.
@@ -368,8 +368,9 @@ func (cache *ExtentCache) Get(offset uint64) (ret *proto.ExtentKey) {
 	return ret
 }
 
-// GetEndForAppendWrite returns the extent key whose end offset equals the given offset.
-func (cache *ExtentCache) GetEndForAppendWrite(offset uint64, verSeq uint64, needCheck bool) (ret *proto.ExtentKey) {
+// GetEndForAppendWrite returns the extent key whose end offset equals the given offset,
+// and whose extent is not full of the extentSize.
+func (cache *ExtentCache) GetEndForAppendWrite(offset uint64, verSeq uint64, needCheck bool, extentSize int) (ret *proto.ExtentKey) {
 	pivot := &proto.ExtentKey{FileOffset: offset}
 	cache.RLock()
 	defer cache.RUnlock()
@@ -386,7 +387,7 @@ func (cache *ExtentCache) GetEndForAppendWrite(offset uint64, verSeq uint64, nee
 
 		if offset == ek.FileOffset+uint64(ek.Size) {
 			if !needCheck || ek.GetSeq() == verSeq {
-				if int(ek.ExtentOffset)+int(ek.Size) >= util.ExtentSize {
+				if int(ek.ExtentOffset)+int(ek.Size) >= extentSize {
 					log.LogDebugf("action[ExtentCache.GetEndForAppendWrite] inode %v req offset %v verseq %v not found, exist ek [%v]",
 						cache.inode, offset, verSeq, ek.String())
 					ret = nil
@@ -38,14 +38,15 @@ import (
 )
 
 type (
-	SplitExtentKeyFunc  func(parentInode, inode uint64, key proto.ExtentKey) error
-	AppendExtentKeyFunc func(parentInode, inode uint64, key proto.ExtentKey, discard []proto.ExtentKey) (int, error)
-	GetExtentsFunc      func(inode uint64) (uint64, uint64, []proto.ExtentKey, error)
-	TruncateFunc        func(inode, size uint64, fullPath string) error
-	EvictIcacheFunc     func(inode uint64)
-	LoadBcacheFunc      func(key string, buf []byte, offset uint64, size uint32) (int, error)
-	CacheBcacheFunc     func(key string, buf []byte) error
-	EvictBacheFunc      func(key string) error
+	SplitExtentKeyFunc   func(parentInode, inode uint64, key proto.ExtentKey) error
+	AppendExtentKeyFunc  func(parentInode, inode uint64, key proto.ExtentKey, discard []proto.ExtentKey) (int, error)
+	GetExtentsFunc       func(inode uint64) (uint64, uint64, []proto.ExtentKey, error)
+	TruncateFunc         func(inode, size uint64, fullPath string) error
+	EvictIcacheFunc      func(inode uint64)
+	LoadBcacheFunc       func(key string, buf []byte, offset uint64, size uint32) (int, error)
+	CacheBcacheFunc      func(key string, buf []byte) error
+	EvictBacheFunc       func(key string) error
+	GetPlacementHintFunc func(inode uint64) (string, error)
 )
 
 const (
@@ -103,6 +104,7 @@ type ExtentConfig struct {
 	Masters           []string
 	FollowerRead      bool
 	NearRead          bool
+	WireCompress      bool
 	Preload           bool
 	ReadRate          int64
 	WriteRate         int64
@@ -118,9 +120,16 @@ type ExtentConfig struct {
 	OnLoadBcache      LoadBcacheFunc
 	OnCacheBcache     CacheBcacheFunc
 	OnEvictBcache     EvictBacheFunc
+	// the placement hints of the files are ignored if not set
+	OnGetPlacementHint GetPlacementHintFunc
 
 	DisableMetaCache             bool
 	MinWriteAbleDataPartitionCnt int
+
+	// the reads not larger than SmallReadSize take the small read budget
+	SmallReadSize int
+	// the default budget is used for the class if not set
+	RetryBudgets [OpClassCount]RetryBudget
 }
 
 type MultiVerMgr struct {
@@ -155,9 +164,12 @@ type ExtentClient struct {
 	loadBcache         LoadBcacheFunc
 	cacheBcache        CacheBcacheFunc
 	evictBcache        EvictBacheFunc
+	getPlacementHint   GetPlacementHintFunc // May be null, must check before using
 	inflightL1cache    sync.Map
 	inflightL1BigBlock int32
 	multiVerMgr        *MultiVerMgr
+	smallReadSize      int
+	retryBudgets       [OpClassCount]RetryBudget
 }
 
 func (client *ExtentClient) UidIsLimited(uid uint32) bool {
@@ -173,6 +185,11 @@ func (client *ExtentClient) UidIsLimited(uid uint32) bool {
 	return false
 }
 
+// FullReadOnly returns whether the volume is read-only as it is full.
+func (client *ExtentClient) FullReadOnly() bool {
+	return client.dataWrapper.FullReadOnly()
+}
+
 func (client *ExtentClient) evictStreamer() bool {
 	// remove from list
 	item := client.streamerList.Back()
@@ -263,9 +280,11 @@ retry:
 	client.evictIcache = config.OnEvictIcache
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
+	client.dataWrapper.SetWireCompress(config.WireCompress)
 	client.loadBcache = config.OnLoadBcache
 	client.cacheBcache = config.OnCacheBcache
 	client.evictBcache = config.OnEvictBcache
+	client.getPlacementHint = config.OnGetPlacementHint
 	client.volumeType = config.VolumeType
 	client.volumeName = config.Volume
 	client.bcacheEnable = config.BcacheEnable
@@ -274,6 +293,16 @@ retry:
 	client.BcacheHealth = true
 	client.preload = config.Preload
 	client.disableMetaCache = config.DisableMetaCache
+	client.smallReadSize = config.SmallReadSize
+	if client.smallReadSize <= 0 {
+		client.smallReadSize = DefaultSmallReadSize
+	}
+	for class, budget := range config.RetryBudgets {
+		if budget == (RetryBudget{}) {
+			budget = DefaultRetryBudgets[class]
+		}
+		client.retryBudgets[class] = budget
+	}
 
 	var readLimit, writeLimit rate.Limit
 	if config.ReadRate <= 0 {
@@ -508,6 +537,11 @@ func (client *ExtentClient) SetFileSize(inode uint64, size int) {
 
 // Write writes the data.
 func (client *ExtentClient) Write(inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error) {
+	return client.WriteWithContext(context.Background(), inode, offset, data, flags, checkFunc)
+}
+
+// WriteWithContext writes the data, the retries stop if the context is done.
+func (client *ExtentClient) WriteWithContext(ctx context.Context, inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error) {
 	prefix := fmt.Sprintf("Write{ino(%v)offset(%v)size(%v)}", inode, offset, len(data))
 	s := client.GetStreamer(inode)
 	if s == nil {
@@ -520,7 +554,7 @@ func (client *ExtentClient) Write(inode uint64, offset int, data []byte, flags i
 		s.GetExtents()
 	})
 
-	write, err = s.IssueWriteRequest(offset, data, flags, checkFunc)
+	write, err = s.IssueWriteRequest(ctx, offset, data, flags, checkFunc)
 	if err != nil {
 		log.LogError(errors.Stack(err))
 		exporter.Warning(err.Error())
@@ -564,6 +598,11 @@ func (client *ExtentClient) Flush(inode uint64) error {
 }
 
 func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int) (read int, err error) {
+	return client.ReadWithContext(context.Background(), inode, data, offset, size)
+}
+
+// ReadWithContext reads the data, the retries stop if the context is done.
+func (client *ExtentClient) ReadWithContext(ctx context.Context, inode uint64, data []byte, offset int, size int) (read int, err error) {
 	// log.LogErrorf("======> ExtentClient Read Enter, inode(%v), len(data)=(%v), offset(%v), size(%v).", inode, len(data), offset, size)
 	// t1 := time.Now()
 	if size == 0 {
@@ -585,7 +624,7 @@ func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int
 		return
 	}
 
-	read, err = s.read(data, offset, size)
+	read, err = s.read(ctx, data, offset, size)
 	// log.LogErrorf("======> ExtentClient Read Exit, inode(%v), time[%v us].", inode, time.Since(t1).Microseconds())
 	return
 }
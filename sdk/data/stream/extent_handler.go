@@ -77,6 +77,8 @@ type ExtentHandler struct {
 	// Will not be changed.
 	conn *net.TCPConn
 	dp   *wrapper.DataPartition
+	// true if the data written to the conn is compressed on the wire
+	wireCompress bool
 
 	// Issue a signal to this channel when *inflight* hits zero.
 	// To wake up *waitForFlush*.
@@ -142,7 +144,7 @@ func (eh *ExtentHandler) String() string {
 		eh.id, eh.inode, eh.fileOffset, eh.size, eh.storeMode, eh.status, eh.dp, eh.stream.verSeq, eh.key, eh.lastKey)
 }
 
-func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool) (ek *proto.ExtentKey, err error) {
+func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool, tracker *retryTracker) (ek *proto.ExtentKey, err error) {
 	var total, write int
 
 	status := eh.getStatus()
@@ -162,7 +164,7 @@ func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool) (ek *
 	// into the extent handler, just close it and return error.
 	// In this case, the caller should try to create a new extent handler.
 	if proto.IsHot(eh.stream.client.volumeType) {
-		if eh.fileOffset+eh.size != offset || eh.size+size > util.ExtentSize ||
+		if eh.fileOffset+eh.size != offset || eh.size+size > eh.stream.client.dataWrapper.ExtentSize() ||
 			(eh.storeMode == proto.TinyExtentType && eh.size+size > blksize) {
 
 			err = errors.New("ExtentHandler: full or incontinuous")
@@ -178,6 +180,8 @@ func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool) (ek *
 			}
 			// log.LogDebugf("ExtentHandler Write: NewPacket, eh(%v) packet(%v)", eh, eh.packet)
 		}
+		// the packet merging several writes is tracked by the latest one
+		eh.packet.tracker = tracker
 		packsize := int(eh.packet.Size)
 		write = util.Min(size-total, blksize-packsize)
 		if write > 0 {
@@ -217,7 +221,7 @@ func (eh *ExtentHandler) sender() {
 
 			// Initialize dp, conn, and extID
 			if eh.dp == nil {
-				if err = eh.allocateExtent(); err != nil {
+				if err = eh.allocateExtent(packet.tracker); err != nil {
 					eh.setClosed()
 					eh.setRecovery()
 					// if dp is not specified and yet we failed, then error out.
@@ -256,7 +260,7 @@ func (eh *ExtentHandler) sender() {
 
 			log.LogDebugf("ExtentHandler sender: extent allocated, eh(%v) dp(%v) extID(%v) packet(%v)", eh, eh.dp, eh.extID, packet.GetUniqueLogId())
 
-			if err = packet.writeToConn(eh.conn); err != nil {
+			if err = packet.writeToWireConn(eh.conn, eh.wireCompress); err != nil {
 				log.LogWarnf("sender writeTo: failed, eh(%v) err(%v) packet(%v)", eh, err, packet)
 				eh.setClosed()
 				eh.setRecovery()
@@ -306,6 +310,7 @@ func (eh *ExtentHandler) processReply(packet *Packet) {
 	reply := NewReply(packet.ReqID, packet.PartitionID, packet.ExtentID)
 	err := reply.ReadFromConnWithVer(eh.conn, proto.ReadDeadlineTime)
 	if err != nil {
+		reportDataNodeTimeout(eh.dp, eh.conn.RemoteAddr().String(), err)
 		eh.processReplyError(packet, err.Error())
 		return
 	}
@@ -539,6 +544,11 @@ func (eh *ExtentHandler) recoverPacket(packet *Packet) error {
 	if packet.errCount >= MaxPacketErrorCount || proto.IsCold(eh.stream.client.volumeType) {
 		return errors.New(fmt.Sprintf("recoverPacket failed: reach max error limit, eh(%v) packet(%v)", eh, packet))
 	}
+	if packet.tracker != nil {
+		if err := packet.tracker.consume(); err != nil {
+			return errors.Trace(err, "recoverPacket failed: retry budget exhausted, eh(%v) packet(%v)", eh, packet)
+		}
+	}
 
 	handler := eh.recoverHandler
 	if handler == nil {
@@ -562,13 +572,15 @@ func (eh *ExtentHandler) discardPacket(packet *Packet) {
 	proto.Buffers.Put(packet.Data)
 	packet.Data = nil
 	eh.setError()
+	reportPartitionEIO(eh.stream.client.dataWrapper, packet.PartitionID)
 }
 
-func (eh *ExtentHandler) allocateExtent() (err error) {
+func (eh *ExtentHandler) allocateExtent(tracker *retryTracker) (err error) {
 	var (
-		dp    *wrapper.DataPartition
-		conn  *net.TCPConn
-		extID int
+		dp           *wrapper.DataPartition
+		conn         *net.TCPConn
+		wireCompress bool
+		extID        int
 	)
 
 	log.LogDebugf("ExtentHandler allocateExtent enter: eh(%v)", eh)
@@ -576,8 +588,13 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 	exclude := make(map[string]struct{})
 
 	for i := 0; i < MaxSelectDataPartitionForWrite; i++ {
+		if i > 0 && tracker != nil {
+			if err = tracker.consume(); err != nil {
+				break
+			}
+		}
 		if eh.key == nil {
-			if dp, err = eh.stream.client.dataWrapper.GetDataPartitionForWrite(exclude); err != nil {
+			if dp, err = eh.stream.client.dataWrapper.GetDataPartitionForPlacement(exclude, eh.stream.getPlacementHint()); err != nil {
 				log.LogWarnf("allocateExtent: failed to get write data partition, eh(%v) exclude(%v), clear exclude and try again!", eh, exclude)
 				exclude = make(map[string]struct{})
 				continue
@@ -602,7 +619,7 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 			extID = int(eh.key.ExtentId)
 		}
 
-		if conn, err = StreamConnPool.GetConnect(dp.Hosts[0]); err != nil {
+		if conn, wireCompress, err = getStreamConnect(dp.Hosts[0], eh.stream.client.dataWrapper.WireCompress()); err != nil {
 			log.LogWarnf("allocateExtent: failed to create connection, eh(%v) err(%v) dp(%v) exclude(%v)",
 				eh, err, dp, exclude)
 			// If storeMode is tinyExtentType and can't create connection, we also check host status.
@@ -616,6 +633,7 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 		// success
 		eh.dp = dp
 		eh.conn = conn
+		eh.wireCompress = wireCompress
 		eh.extID = extID
 
 		// log.LogDebugf("ExtentHandler allocateExtent exit: eh(%v) dp(%v) extID(%v)", eh, dp, extID)
@@ -60,6 +60,7 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 
 	reqPacket := NewReadPacket(reader.key, offset, size, reader.inode, req.FileOffset, reader.followerRead)
 	sc := NewStreamConn(reader.dp, reader.followerRead)
+	sc.tracker = req.tracker
 
 	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
 
@@ -73,6 +74,7 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 
 			if e != nil {
 				log.LogWarnf("Extent Reader Read: failed to read from connect, ino(%v) req(%v) readBytes(%v) err(%v)", reader.inode, reqPacket, readBytes, e)
+				reportDataNodeTimeout(reader.dp, sc.currAddr, e)
 				// Upon receiving TryOtherAddrError, other hosts will be retried.
 				return TryOtherAddrError, false
 			}
@@ -100,6 +102,7 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 			log.LogWarnf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
 		} else {
 			log.LogErrorf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
+			reportPartitionEIO(reader.dp.ClientWrapper, reader.dp.PartitionID)
 		}
 	}
 
@@ -32,6 +32,7 @@ type Packet struct {
 	proto.Packet
 	inode    uint64
 	errCount int
+	tracker  *retryTracker // budget of the write issuing the packet, may be nil
 }
 
 // String returns the string format of the packet.
@@ -210,6 +211,10 @@ func (p *Packet) readFromConn(c net.Conn, deadlineTime time.Duration) (err error
 		return
 	}
 
+	if p.IsWireCompressed() {
+		return p.readWireCompressed(c)
+	}
+
 	size := int(p.Size)
 	if size > len(p.Data) {
 		size = len(p.Data)
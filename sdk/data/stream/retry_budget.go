@@ -0,0 +1,151 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/util"
+)
+
+// OpClass classifies the data operations, each class has its own timeout and retry budget.
+type OpClass int
+
+const (
+	OpClassSmallRead OpClass = iota
+	OpClassLargeRead
+	OpClassWrite
+	OpClassCount
+)
+
+const DefaultSmallReadSize = util.BlockSize
+
+// RetryBudget limits the total time and the retry times of an operation.
+type RetryBudget struct {
+	Timeout  time.Duration // no time limit if 0
+	MaxRetry int
+}
+
+var DefaultRetryBudgets = [OpClassCount]RetryBudget{
+	OpClassSmallRead: {Timeout: 60 * time.Second, MaxRetry: 100},
+	OpClassLargeRead: {Timeout: 300 * time.Second, MaxRetry: StreamSendMaxRetry},
+	OpClassWrite:     {Timeout: 300 * time.Second, MaxRetry: StreamSendMaxRetry},
+}
+
+type nonblockKey struct{}
+
+// WithNonblock marks the operation issued by a handle opened with O_NONBLOCK,
+// which fails with EAGAIN instead of retrying.
+func WithNonblock(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonblockKey{}, true)
+}
+
+func isNonblock(ctx context.Context) bool {
+	nonblock, _ := ctx.Value(nonblockKey{}).(bool)
+	return nonblock
+}
+
+// retryTracker tracks the budget consumed by an operation. The deadline of the context,
+// e.g. the interrupted fuse request, is honored if it is earlier than the budget.
+// It may be shared by the packets of the operation retried in the background.
+type retryTracker struct {
+	ctx            context.Context
+	maxRetry       int32
+	retries        int32
+	deadline       time.Time
+	budgetDeadline time.Time
+	nonblock       bool
+}
+
+func newRetryTracker(ctx context.Context, budget RetryBudget) *retryTracker {
+	t := &retryTracker{
+		ctx:      ctx,
+		maxRetry: int32(budget.MaxRetry),
+		nonblock: isNonblock(ctx),
+	}
+	if budget.Timeout > 0 {
+		t.budgetDeadline = time.Now().Add(budget.Timeout)
+	}
+	t.deadline = t.budgetDeadline
+	if d, ok := ctx.Deadline(); ok && (t.deadline.IsZero() || d.Before(t.deadline)) {
+		t.deadline = d
+	}
+	return t
+}
+
+// detach returns the tracker of the packets which may be sent after the operation returns,
+// e.g. the buffered writes, they are bounded by the budget only but not the context.
+func (t *retryTracker) detach() *retryTracker {
+	return &retryTracker{
+		ctx:            context.Background(),
+		maxRetry:       t.maxRetry,
+		retries:        atomic.LoadInt32(&t.retries),
+		deadline:       t.budgetDeadline,
+		budgetDeadline: t.budgetDeadline,
+	}
+}
+
+// check returns the error if the operation should not go on.
+func (t *retryTracker) check() error {
+	if t.ctx.Err() != nil {
+		return syscall.EINTR
+	}
+	if !t.deadline.IsZero() && time.Now().After(t.deadline) {
+		return syscall.ETIMEDOUT
+	}
+	return nil
+}
+
+// consume consumes a retry without waiting, it is used by the retries in the background.
+func (t *retryTracker) consume() error {
+	if t.nonblock {
+		return syscall.EAGAIN
+	}
+	if err := t.check(); err != nil {
+		return err
+	}
+	if atomic.AddInt32(&t.retries, 1) > t.maxRetry {
+		return syscall.ETIMEDOUT
+	}
+	return nil
+}
+
+// next consumes a retry and waits for the interval before retrying.
+func (t *retryTracker) next(interval time.Duration) error {
+	if err := t.consume(); err != nil {
+		return err
+	}
+	if !t.deadline.IsZero() && time.Now().Add(interval).After(t.deadline) {
+		return syscall.ETIMEDOUT
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-t.ctx.Done():
+		return syscall.EINTR
+	}
+}
+
+func (client *ExtentClient) readBudget(size int) RetryBudget {
+	if size <= client.smallReadSize {
+		return client.retryBudgets[OpClassSmallRead]
+	}
+	return client.retryBudgets[OpClassLargeRead]
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTrackerDeadline(t *testing.T) {
+	// no time limit
+	tracker := newRetryTracker(context.Background(), RetryBudget{MaxRetry: 1})
+	require.True(t, tracker.deadline.IsZero())
+	require.NoError(t, tracker.check())
+
+	tracker = newRetryTracker(context.Background(), RetryBudget{Timeout: 20 * time.Millisecond, MaxRetry: 100})
+	require.NoError(t, tracker.check())
+	require.NoError(t, tracker.consume())
+	// the retry waiting beyond the deadline fails at once
+	start := time.Now()
+	require.Equal(t, syscall.ETIMEDOUT, tracker.next(time.Second))
+	require.Less(t, time.Since(start), time.Second)
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, syscall.ETIMEDOUT, tracker.check())
+	require.Equal(t, syscall.ETIMEDOUT, tracker.consume())
+	require.Equal(t, syscall.ETIMEDOUT, tracker.next(time.Millisecond))
+
+	// the deadline of the context is honored if it is earlier than the budget
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	tracker = newRetryTracker(ctx, RetryBudget{Timeout: time.Minute, MaxRetry: 100})
+	deadline, _ := ctx.Deadline()
+	require.Equal(t, deadline, tracker.deadline)
+	tracker = newRetryTracker(ctx, RetryBudget{MaxRetry: 100})
+	require.Equal(t, deadline, tracker.deadline)
+}
+
+func TestRetryTrackerMaxRetry(t *testing.T) {
+	tracker := newRetryTracker(context.Background(), RetryBudget{Timeout: time.Minute, MaxRetry: 2})
+	require.NoError(t, tracker.next(time.Millisecond))
+	require.NoError(t, tracker.consume())
+	require.Equal(t, syscall.ETIMEDOUT, tracker.consume())
+	require.Equal(t, syscall.ETIMEDOUT, tracker.next(time.Millisecond))
+	// the exhausted tracker is not consumable until the operation ends
+	require.NoError(t, tracker.check())
+
+	tracker = newRetryTracker(context.Background(), RetryBudget{MaxRetry: 0})
+	require.Equal(t, syscall.ETIMEDOUT, tracker.next(time.Millisecond))
+}
+
+func TestRetryTrackerNonblock(t *testing.T) {
+	tracker := newRetryTracker(WithNonblock(context.Background()), RetryBudget{Timeout: time.Minute, MaxRetry: 100})
+	require.NoError(t, tracker.check())
+	require.Equal(t, syscall.EAGAIN, tracker.consume())
+	require.Equal(t, syscall.EAGAIN, tracker.next(time.Millisecond))
+	require.Equal(t, int32(0), tracker.retries)
+}
+
+func TestRetryTrackerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker := newRetryTracker(ctx, RetryBudget{MaxRetry: 100})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	// the waiting retry is interrupted by the canceled request
+	start := time.Now()
+	require.Equal(t, syscall.EINTR, tracker.next(time.Minute))
+	require.Less(t, time.Since(start), time.Minute)
+
+	require.Equal(t, syscall.EINTR, tracker.check())
+	require.Equal(t, syscall.EINTR, tracker.consume())
+	require.Equal(t, syscall.EINTR, tracker.next(time.Millisecond))
+}
+
+func TestRetryTrackerDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(WithNonblock(context.Background()), 10*time.Millisecond)
+	tracker := newRetryTracker(ctx, RetryBudget{Timeout: time.Minute, MaxRetry: 3})
+	tracker.retries = 1
+	detached := tracker.detach()
+	cancel()
+	require.Equal(t, syscall.EINTR, tracker.check())
+
+	// the detached tracker is bounded by the budget only
+	require.Equal(t, tracker.budgetDeadline, detached.deadline)
+	require.NoError(t, detached.check())
+	require.NoError(t, detached.next(time.Millisecond))
+	require.NoError(t, detached.consume())
+	require.Equal(t, syscall.ETIMEDOUT, detached.consume())
+	require.Equal(t, int32(1), tracker.retries)
+}
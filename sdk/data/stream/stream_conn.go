@@ -17,6 +17,7 @@ package stream
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -39,10 +40,29 @@ const (
 
 type GetReplyFunc func(conn *net.TCPConn) (err error, again bool)
 
+// reportDataNodeTimeout reports to the master if err is a timeout talking to the data node.
+func reportDataNodeTimeout(dp *wrapper.DataPartition, addr string, err error) {
+	if dp == nil || dp.ClientWrapper == nil {
+		return
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		dp.ClientWrapper.ReportClientError(proto.ClientErrDataNodeTimeout, addr)
+	}
+}
+
+// reportPartitionEIO reports to the master that an io of the partition finally fails.
+func reportPartitionEIO(w *wrapper.Wrapper, partitionID uint64) {
+	if w == nil || partitionID == 0 {
+		return
+	}
+	w.ReportClientError(proto.ClientErrPartitionEIO, strconv.FormatUint(partitionID, 10))
+}
+
 // StreamConn defines the struct of the stream connection.
 type StreamConn struct {
 	dp       *wrapper.DataPartition
 	currAddr string
+	tracker  *retryTracker // retry without budget if nil
 }
 
 var StreamConnPool = util.NewConnectPool()
@@ -104,21 +124,35 @@ func (sc *StreamConn) String() string {
 // Send send the given packet over the network through the stream connection until success
 // or the maximum number of retries is reached.
 func (sc *StreamConn) Send(retry *bool, req *Packet, getReply GetReplyFunc) (err error) {
-	for i := 0; i < StreamSendMaxRetry; i++ {
+	for i := 0; i < StreamSendMaxRetry || sc.tracker != nil; i++ {
 		err = sc.sendToDataPartition(req, retry, getReply)
 		if err == nil || err == proto.ErrCodeVersionOp || !*retry || err == TryOtherAddrError {
 			return
 		}
 		log.LogWarnf("StreamConn Send: err(%v)", err)
-		time.Sleep(StreamSendSleepInterval)
+		if e := sc.wait(); e != nil {
+			log.LogWarnf("StreamConn Send: stop retrying, sc(%v) reqPacket(%v) retries(%v) err(%v) lastErr(%v)", sc, req, i, e, err)
+			return e
+		}
 	}
 	return errors.New(fmt.Sprintf("StreamConn Send: retried %v times and still failed, sc(%v) reqPacket(%v)", StreamSendMaxRetry, sc, req))
 }
 
+// wait sleeps before the next retry, returns the error if the budget is exhausted.
+func (sc *StreamConn) wait() error {
+	if sc.tracker == nil {
+		time.Sleep(StreamSendSleepInterval)
+		return nil
+	}
+	return sc.tracker.next(StreamSendSleepInterval)
+}
+
 func (sc *StreamConn) sendToDataPartition(req *Packet, retry *bool, getReply GetReplyFunc) (err error) {
-	conn, err := StreamConnPool.GetConnect(sc.currAddr)
+	wireCompress := sc.dp.ClientWrapper.WireCompress()
+	conn, compress, err := getStreamConnect(sc.currAddr, wireCompress)
 	if err == nil {
 		log.LogDebugf("req opcode %v, conn %v", req.Opcode, conn)
+		req.setWireCompress(compress)
 		err = sc.sendToConn(conn, req, getReply)
 		if err == nil {
 			StreamConnPool.PutConnect(conn, false)
@@ -136,12 +170,18 @@ func (sc *StreamConn) sendToDataPartition(req *Packet, retry *bool, getReply Get
 	hosts := sortByStatus(sc.dp, true)
 
 	for _, addr := range hosts {
+		if sc.tracker != nil {
+			if e := sc.tracker.check(); e != nil {
+				return e
+			}
+		}
 		log.LogWarnf("sendToDataPartition: try addr(%v) reqPacket(%v)", addr, req)
-		conn, err = StreamConnPool.GetConnect(addr)
+		conn, compress, err = getStreamConnect(addr, wireCompress)
 		if err != nil {
 			log.LogWarnf("sendToDataPartition: failed to get connection to addr(%v) reqPacket(%v) err(%v)", addr, req, err)
 			continue
 		}
+		req.setWireCompress(compress)
 		sc.currAddr = addr
 		sc.dp.LeaderAddr = addr
 		err = sc.sendToConn(conn, req, getReply)
@@ -159,7 +199,7 @@ func (sc *StreamConn) sendToDataPartition(req *Packet, retry *bool, getReply Get
 }
 
 func (sc *StreamConn) sendToConn(conn *net.TCPConn, req *Packet, getReply GetReplyFunc) (err error) {
-	for i := 0; i < StreamSendMaxRetry; i++ {
+	for i := 0; i < StreamSendMaxRetry || sc.tracker != nil; i++ {
 		log.LogDebugf("sendToConn: send to addr(%v), reqPacket(%v)", sc.currAddr, req)
 		err = req.WriteToConn(conn)
 		if err != nil {
@@ -178,7 +218,10 @@ func (sc *StreamConn) sendToConn(conn *net.TCPConn, req *Packet, getReply GetRep
 		}
 
 		log.LogWarnf("sendToConn: getReply error and will RETRY, sc(%v) err(%v)", sc, err)
-		time.Sleep(StreamSendSleepInterval)
+		if e := sc.wait(); e != nil {
+			err = e
+			break
+		}
 	}
 
 	log.LogDebugf("sendToConn exit: send to addr(%v) reqPacket(%v) err(%v)", sc.currAddr, req, err)
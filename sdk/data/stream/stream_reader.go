@@ -54,6 +54,8 @@ type Streamer struct {
 	pendingCache         chan bcacheKey
 	verSeq               uint64
 	needUpdateVer        int32
+	placementOnce        sync.Once
+	placementHint        proto.LabelSelector // the placement hint of the file, loaded on the first allocation
 }
 
 type bcacheKey struct {
@@ -61,6 +63,25 @@ type bcacheKey struct {
 	extentKey *proto.ExtentKey
 }
 
+// getPlacementHint returns the placement hint of the file, which is loaded once for the streamer,
+// so that the hint set later takes effect after the streamer is evicted.
+func (s *Streamer) getPlacementHint() proto.LabelSelector {
+	if s.client.getPlacementHint == nil {
+		return nil
+	}
+	s.placementOnce.Do(func() {
+		value, err := s.client.getPlacementHint(s.inode)
+		if err != nil {
+			log.LogWarnf("getPlacementHint: ino(%v) err(%v)", s.inode, err)
+			return
+		}
+		if s.placementHint, err = proto.ParseLabelSelector(value); err != nil {
+			log.LogWarnf("getPlacementHint: ino(%v) hint(%v) err(%v)", s.inode, value, err)
+		}
+	})
+	return s.placementHint
+}
+
 // NewStreamer returns a new streamer.
 func NewStreamer(client *ExtentClient, inode uint64) *Streamer {
 	s := new(Streamer)
@@ -124,7 +145,7 @@ func (s *Streamer) GetExtentReader(ek *proto.ExtentKey) (*ExtentReader, error) {
 	return reader, nil
 }
 
-func (s *Streamer) read(data []byte, offset int, size int) (total int, err error) {
+func (s *Streamer) read(ctx context.Context, data []byte, offset int, size int) (total int, err error) {
 	var (
 		readBytes       int
 		reader          *ExtentReader
@@ -132,9 +153,9 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 		revisedRequests []*ExtentRequest
 	)
 	log.LogDebugf("action[streamer.read] offset %v size %v", offset, size)
-	ctx := context.Background()
-	s.client.readLimiter.Wait(ctx)
-	s.client.LimitManager.ReadAlloc(ctx, size)
+	s.client.readLimiter.Wait(context.Background())
+	s.client.LimitManager.ReadAlloc(context.Background(), size)
+	tracker := newRetryTracker(ctx, s.client.readBudget(size))
 	requests = s.extents.PrepareReadRequests(offset, size, data)
 	for _, req := range requests {
 		if req.ExtentKey == nil {
@@ -208,6 +229,7 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 			}
 
 			// read extent
+			req.tracker = tracker
 			reader, err = s.GetExtentReader(req.ExtentKey)
 			if err != nil {
 				log.LogErrorf("action[streamer.read] req %v err %v", req, err)
@@ -71,6 +71,7 @@ type WriteRequest struct {
 	err        error
 	done       chan struct{}
 	checkFunc  func() error
+	ctx        context.Context
 }
 
 // FlushRequest defines a flush request.
@@ -110,7 +111,7 @@ func (s *Streamer) IssueOpenRequest() error {
 	return nil
 }
 
-func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int, checkFunc func() error) (write int, err error) {
+func (s *Streamer) IssueWriteRequest(ctx context.Context, offset int, data []byte, flags int, checkFunc func() error) (write int, err error) {
 	if atomic.LoadInt32(&s.status) >= StreamerError {
 		return 0, errors.New(fmt.Sprintf("IssueWriteRequest: stream writer in error status, ino(%v)", s.inode))
 	}
@@ -123,6 +124,7 @@ func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int, checkFu
 	request.flags = flags
 	request.done = make(chan struct{}, 1)
 	request.checkFunc = checkFunc
+	request.ctx = ctx
 
 	s.request <- request
 	s.writeLock.Unlock()
@@ -130,6 +132,7 @@ func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int, checkFu
 	<-request.done
 	err = request.err
 	write = request.writeBytes
+	request.ctx = nil
 	writeRequestPool.Put(request)
 	return
 }
@@ -276,7 +279,7 @@ func (s *Streamer) handleRequest(request interface{}) {
 		s.open()
 		request.done <- struct{}{}
 	case *WriteRequest:
-		request.writeBytes, request.err = s.write(request.data, request.fileOffset, request.size, request.flags, request.checkFunc)
+		request.writeBytes, request.err = s.write(request.ctx, request.data, request.fileOffset, request.size, request.flags, request.checkFunc)
 		request.done <- struct{}{}
 	case *TruncRequest:
 		request.err = s.truncate(request.size, request.fullPath)
@@ -297,11 +300,12 @@ func (s *Streamer) handleRequest(request interface{}) {
 	}
 }
 
-func (s *Streamer) write(data []byte, offset, size, flags int, checkFunc func() error) (total int, err error) {
+func (s *Streamer) write(ctx context.Context, data []byte, offset, size, flags int, checkFunc func() error) (total int, err error) {
 	var (
 		direct     bool
 		retryTimes int8
 	)
+	tracker := newRetryTracker(ctx, s.client.retryBudgets[OpClassWrite])
 
 	if flags&proto.FlagsSyncWrite != 0 {
 		direct = true
@@ -314,8 +318,7 @@ begin:
 
 	log.LogDebugf("Streamer write enter: ino(%v) offset(%v) size(%v) flags(%v)", s.inode, offset, size, flags)
 
-	ctx := context.Background()
-	s.client.writeLimiter.Wait(ctx)
+	s.client.writeLimiter.Wait(context.Background())
 
 	requests := s.extents.PrepareWriteRequests(offset, size, data)
 	log.LogDebugf("Streamer write: ino(%v) prepared requests(%v)", s.inode, requests)
@@ -339,6 +342,7 @@ begin:
 
 	for _, req := range requests {
 		var writeSize int
+		req.tracker = tracker
 		if req.ExtentKey != nil {
 			if s.client.bcacheEnable {
 				cacheKey := util.GenerateRepVolKey(s.client.volumeName, s.inode, req.ExtentKey.PartitionId, req.ExtentKey.ExtentId, uint64(req.FileOffset))
@@ -456,6 +460,7 @@ func (s *Streamer) doDirectWriteByAppend(req *ExtentRequest, direct bool, op uin
 	sc := &StreamConn{
 		dp:       dp,
 		currAddr: addr,
+		tracker:  req.tracker,
 	}
 
 	replyPacket := new(Packet)
@@ -616,6 +621,7 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 	}
 
 	sc := NewStreamConn(dp, false)
+	sc.tracker = req.tracker
 
 	for total < size {
 		reqPacket := NewOverwritePacket(dp, req.ExtentKey.ExtentId, offset-ekFileOffset+total+ekExtOffset, s.inode, offset)
@@ -675,6 +681,11 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 				log.LogWarnf("doOverwrite: need retry.ino(%v) req(%v) reqPacket(%v) err(%v) replyPacket(%v)", s.inode, req, reqPacket, err, replyPacket)
 				return
 			}
+			if _, ok := err.(syscall.Errno); ok {
+				// the budget is exhausted, keep the errno for the caller
+				log.LogWarnf("doOverwrite: ino(%v) req(%v) reqPacket(%v) err(%v)", s.inode, req, reqPacket, err)
+				break
+			}
 			err = errors.New(fmt.Sprintf("doOverwrite: failed or reply NOK: err(%v) ino(%v) req(%v) replyPacket(%v)", err, s.inode, req, replyPacket))
 			break
 		}
@@ -692,7 +703,11 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 func (s *Streamer) tryInitExtentHandlerByLastEk(offset, size int) (isLastEkVerNotEqual bool) {
 	storeMode := s.GetStoreMod(offset, size)
 	getEndEkFunc := func() *proto.ExtentKey {
-		if ek := s.extents.GetEndForAppendWrite(uint64(offset), s.verSeq, false); ek != nil && !storage.IsTinyExtent(ek.ExtentId) {
+		if ek := s.extents.GetEndForAppendWrite(uint64(offset), s.verSeq, false, s.client.dataWrapper.ExtentSize()); ek != nil && !storage.IsTinyExtent(ek.ExtentId) {
+			// the extents shared with the clone source are never appended
+			if dp, err := s.client.dataWrapper.GetDataPartition(ek.PartitionId); err == nil && dp.CloneSrc {
+				return nil
+			}
 			return ek
 		}
 		return nil
@@ -701,7 +716,7 @@ func (s *Streamer) tryInitExtentHandlerByLastEk(offset, size int) (isLastEkVerNo
 	checkVerFunc := func(currentEK *proto.ExtentKey) {
 		if currentEK.GetSeq() != s.verSeq {
 			log.LogDebugf("tryInitExtentHandlerByLastEk. exist ek seq %v vs request seq %v", currentEK.GetSeq(), s.verSeq)
-			if int(currentEK.ExtentOffset)+int(currentEK.Size)+size > util.ExtentSize {
+			if int(currentEK.ExtentOffset)+int(currentEK.Size)+size > s.client.dataWrapper.ExtentSize() {
 				s.closeOpenHandler()
 				return
 			}
@@ -784,23 +799,28 @@ func (s *Streamer) doWriteAppend(req *ExtentRequest, direct bool) (writeSize int
 	// First, attempt sequential writes using neighboring extent keys. If the last extent has a different version,
 	// it indicates that the extent may have been fully utilized by the previous version.
 	// Next, try writing and directly checking the extent at the datanode. If the extent cannot be reused, create a new extent for writing.
-	if writeSize, err, status = s.doWriteAppendEx(req.Data, req.FileOffset, req.Size, direct, true); status == LastEKVersionNotEqual {
+	if writeSize, err, status = s.doWriteAppendEx(req.Data, req.FileOffset, req.Size, direct, true, req.tracker); status == LastEKVersionNotEqual {
 		log.LogDebugf("action[streamer.write] tryDirectAppendWrite req %v FileOffset %v size %v", req.ExtentKey, req.FileOffset, req.Size)
 		if writeSize, _, err, status = s.tryDirectAppendWrite(req, direct); status == int32(proto.OpTryOtherExtent) {
 			log.LogDebugf("action[streamer.write] doWriteAppend again req %v FileOffset %v size %v", req.ExtentKey, req.FileOffset, req.Size)
-			writeSize, err, _ = s.doWriteAppendEx(req.Data, req.FileOffset, req.Size, direct, false)
+			writeSize, err, _ = s.doWriteAppendEx(req.Data, req.FileOffset, req.Size, direct, false, req.tracker)
 		}
 	}
 	log.LogDebugf("action[streamer.write] doWriteAppend status %v err %v", status, err)
 	return
 }
 
-func (s *Streamer) doWriteAppendEx(data []byte, offset, size int, direct bool, reUseEk bool) (total int, err error, status int32) {
+func (s *Streamer) doWriteAppendEx(data []byte, offset, size int, direct bool, reUseEk bool, tracker *retryTracker) (total int, err error, status int32) {
 	var (
 		ek        *proto.ExtentKey
 		storeMode int
 	)
 
+	// the packets of the buffered writes are flushed after the write returns
+	if tracker != nil && !direct && proto.IsHot(s.client.volumeType) {
+		tracker = tracker.detach()
+	}
+
 	// Small files are usually written in a single write, so use tiny extent
 	// store only for the first write operation.
 	storeMode = s.GetStoreMod(offset, size)
@@ -826,7 +846,7 @@ func (s *Streamer) doWriteAppendEx(data []byte, offset, size int, direct bool, r
 				s.closeOpenHandler()
 				continue
 			}
-			ek, err = s.handler.write(data, offset, size, direct)
+			ek, err = s.handler.write(data, offset, size, direct, tracker)
 			if err == nil && ek != nil {
 				ek.SetSeq(s.verSeq)
 				if !s.dirty {
@@ -840,7 +860,7 @@ func (s *Streamer) doWriteAppendEx(data []byte, offset, size int, direct bool, r
 	} else {
 		s.handler = NewExtentHandler(s, offset, storeMode, 0)
 		s.dirty = false
-		ek, err = s.handler.write(data, offset, size, direct)
+		ek, err = s.handler.write(data, offset, size, direct, tracker)
 		if err == nil && ek != nil {
 			if !s.dirty {
 				s.dirtylist.Put(s.handler)
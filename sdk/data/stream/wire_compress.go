@@ -0,0 +1,163 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the negotiation of the connection is forgotten after the connection idles for the time, the
+	// idle connection is closed by the pool earlier
+	wireConnExpiration = 2 * util.ConnectIdleTime * time.Second
+	// the datanode failing the negotiation is not negotiated again in the interval
+	wireUnsupportedInterval = 10 * time.Minute
+	wireConnSweepInterval   = time.Minute
+)
+
+var (
+	streamWireConns      = &wireConns{}
+	streamWireCompressor proto.WireCompressor
+)
+
+type wireConn struct {
+	compress bool
+	used     int64
+}
+
+// wireConns keeps the wire compression negotiated on the connections to the datanodes.
+type wireConns struct {
+	conns       sync.Map // *net.TCPConn -> *wireConn
+	unsupported sync.Map // addr -> time.Time
+	lastSweep   int64
+}
+
+// negotiate returns true if the connection compresses the data on the wire, the compression is
+// negotiated once on the new connection. The error means the connection is closed by the datanode
+// not knowing the negotiation, the datanode is not negotiated again for a while then.
+func (w *wireConns) negotiate(conn *net.TCPConn) (compress bool, err error) {
+	now := time.Now()
+	w.sweep(now)
+	if v, ok := w.conns.Load(conn); ok {
+		wc := v.(*wireConn)
+		atomic.StoreInt64(&wc.used, now.UnixNano())
+		return wc.compress, nil
+	}
+	addr := conn.RemoteAddr().String()
+	if v, ok := w.unsupported.Load(addr); ok && now.Before(v.(time.Time)) {
+		return false, nil
+	}
+
+	req := proto.NewPacketReqID()
+	req.Opcode = proto.OpNegotiateWireCompress
+	req.Data = []byte(proto.WireCompressLz4)
+	req.Size = uint32(len(req.Data))
+	if err = req.WriteToConn(conn); err != nil {
+		return false, err
+	}
+	reply := proto.NewPacket()
+	if err = reply.ReadFromConnWithVer(conn, proto.ReadDeadlineTime); err != nil {
+		return false, err
+	}
+	if reply.ResultCode != proto.OpOk || reply.ReqID != req.ReqID {
+		log.LogWarnf("negotiate wire compress: not supported by datanode(%v) reply(%v)", addr, reply.GetResultMsg())
+		w.unsupported.Store(addr, now.Add(wireUnsupportedInterval))
+		return false, fmt.Errorf("negotiate wire compress: datanode(%v) reply(%v)", addr, reply.GetResultMsg())
+	}
+	compress = string(reply.Data[:reply.Size]) == proto.WireCompressLz4
+	w.conns.Store(conn, &wireConn{compress: compress, used: now.UnixNano()})
+	log.LogDebugf("negotiate wire compress: datanode(%v) local(%v) compress(%v)", addr, conn.LocalAddr(), compress)
+	return
+}
+
+func (w *wireConns) sweep(now time.Time) {
+	last := atomic.LoadInt64(&w.lastSweep)
+	if now.UnixNano()-last < int64(wireConnSweepInterval) || !atomic.CompareAndSwapInt64(&w.lastSweep, last, now.UnixNano()) {
+		return
+	}
+	w.conns.Range(func(key, value interface{}) bool {
+		if now.UnixNano()-atomic.LoadInt64(&value.(*wireConn).used) > int64(wireConnExpiration) {
+			w.conns.Delete(key)
+		}
+		return true
+	})
+	w.unsupported.Range(func(key, value interface{}) bool {
+		if now.After(value.(time.Time)) {
+			w.unsupported.Delete(key)
+		}
+		return true
+	})
+}
+
+// getStreamConnect gets the connection to the datanode, and negotiates the wire compression of the
+// connection if enabled. The connection closed by the negotiation is replaced by a new one.
+func getStreamConnect(addr string, wireCompress bool) (conn *net.TCPConn, compress bool, err error) {
+	if conn, err = StreamConnPool.GetConnect(addr); err != nil || !wireCompress {
+		return
+	}
+	if compress, err = streamWireConns.negotiate(conn); err != nil {
+		StreamConnPool.PutConnect(conn, true)
+		compress = false
+		conn, err = StreamConnPool.GetConnect(addr)
+	}
+	return
+}
+
+// writeToWireConn writes the packet with the data compressed if the connection compresses the data
+// on the wire, the packet keeps the raw data for the retries.
+func (p *Packet) writeToWireConn(conn net.Conn, compress bool) error {
+	if !compress || !p.IsWriteOperation() {
+		return p.writeToConn(conn)
+	}
+	p.CRC = crc32.ChecksumIEEE(p.Data[:p.Size])
+	wire := p.Packet
+	if !streamWireCompressor.Compress(&wire, proto.WireCompressMinSize) {
+		return p.WriteToConn(conn)
+	}
+	return wire.WriteToConn(conn)
+}
+
+// setWireCompress sets the read request to accept the replies compressed on the wire.
+func (p *Packet) setWireCompress(compress bool) {
+	if compress && p.IsReadOperation() {
+		p.ExtentType |= proto.WireCompressFlag
+		return
+	}
+	p.ExtentType &^= proto.WireCompressFlag
+}
+
+// readWireCompressed reads the data compressed on the wire, and decompresses it into the data.
+func (p *Packet) readWireCompressed(c net.Conn) (err error) {
+	compressed := p.Packet
+	compressed.Data = make([]byte, p.Size)
+	if _, err = io.ReadFull(c, compressed.Data); err != nil {
+		return
+	}
+	if err = compressed.DecompressData(p.Data); err != nil {
+		return
+	}
+	p.Data, p.Size, p.ExtentType = compressed.Data, compressed.Size, compressed.ExtentType
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wrapper
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	clientErrorReportInterval = time.Minute
+	// only the most frequent errors are reported, same as the limit of the master
+	clientErrorMaxReportItems = 64
+)
+
+type clientErrorKey struct {
+	category string
+	key      string
+}
+
+// ReportClientError counts an error observed by the client, the counts are reported
+// to the master periodically for the fleet diagnostics of the volume.
+func (w *Wrapper) ReportClientError(category, key string) {
+	w.clientErrLock.Lock()
+	defer w.clientErrLock.Unlock()
+	if w.clientErrs == nil {
+		w.clientErrs = make(map[clientErrorKey]uint64)
+	}
+	w.clientErrs[clientErrorKey{category: category, key: key}]++
+}
+
+// takeClientErrors returns the errors counted since the last call, the most frequent first.
+func (w *Wrapper) takeClientErrors() (items []*proto.ClientErrorItem) {
+	w.clientErrLock.Lock()
+	errs := w.clientErrs
+	w.clientErrs = nil
+	w.clientErrLock.Unlock()
+
+	items = make([]*proto.ClientErrorItem, 0, len(errs))
+	for key, count := range errs {
+		items = append(items, &proto.ClientErrorItem{Category: key.category, Key: key.key, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Count > items[j].Count
+	})
+	if len(items) > clientErrorMaxReportItems {
+		items = items[:clientErrorMaxReportItems]
+	}
+	return
+}
+
+func (w *Wrapper) reportClientErrors() {
+	items := w.takeClientErrors()
+	if len(items) == 0 {
+		return
+	}
+	report := &proto.ClientErrorReport{VolName: w.volName, Client: w.LocalIp, Items: items}
+	// the counts are dropped if the report fails, the errors keep being counted anyway
+	if err := w.mc.ClientAPI().ReportClientErrors(report); err != nil {
+		log.LogWarnf("reportClientErrors: vol(%v) report %v items failed, err(%v)", w.volName, len(items), err)
+	}
+}
+
+func (w *Wrapper) reportClientErrorsByTick() {
+	ticker := time.NewTicker(clientErrorReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.reportClientErrors()
+		case <-w.stopC:
+			return
+		}
+	}
+}
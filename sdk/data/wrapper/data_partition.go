@@ -35,6 +35,7 @@ type DataPartition struct {
 	NearHosts     []string
 	ClientWrapper *Wrapper
 	Metrics       *DataPartitionMetrics
+	CloneSrc      bool // the partition of the clone source is read only
 }
 
 // DataPartitionMetrics defines the wrapper of the metrics related to the data partition.
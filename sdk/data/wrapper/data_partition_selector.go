@@ -16,8 +16,10 @@ package wrapper
 
 import (
 	"errors"
+	"math/rand"
 	"strings"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -114,6 +116,9 @@ func (w *Wrapper) refreshDpSelector(partitions []*DataPartition) {
 		}
 	}
 
+	w.Lock.Lock()
+	w.rwPartitions = partitions
+	w.Lock.Unlock()
 	_ = dpSelector.Refresh(partitions)
 }
 
@@ -126,6 +131,30 @@ func (w *Wrapper) GetDataPartitionForWrite(exclude map[string]struct{}) (*DataPa
 	return dpSelector.Select(exclude)
 }
 
+// GetDataPartitionForPlacement returns an available data partition for write whose placement labels
+// are selected by the placement hint of the file, the partition is picked by the dp selector if none
+// is selected.
+func (w *Wrapper) GetDataPartitionForPlacement(exclude map[string]struct{}, hint proto.LabelSelector) (*DataPartition, error) {
+	if len(hint) == 0 {
+		return w.GetDataPartitionForWrite(exclude)
+	}
+	w.Lock.RLock()
+	partitions := w.rwPartitions
+	w.Lock.RUnlock()
+
+	candidates := make([]*DataPartition, 0)
+	for _, dp := range partitions {
+		if dp.Status == proto.ReadWrite && hint.Matches(dp.Labels) && !isExcluded(dp, exclude) {
+			candidates = append(candidates, dp)
+		}
+	}
+	if len(candidates) == 0 {
+		log.LogDebugf("GetDataPartitionForPlacement: no writable dp selected by hint(%v), use dp selector", hint)
+		return w.GetDataPartitionForWrite(exclude)
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
 func (w *Wrapper) RemoveDataPartitionForWrite(partitionID uint64) {
 	w.Lock.RLock()
 	dpSelector := w.dpSelector
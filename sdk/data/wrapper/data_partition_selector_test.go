@@ -0,0 +1,61 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataPartitionForPlacement(t *testing.T) {
+	newDp := func(id uint64, host string, labels map[string]string) *DataPartition {
+		dp := &DataPartition{}
+		dp.PartitionID = id
+		dp.Status = proto.ReadWrite
+		dp.Hosts = []string{host}
+		dp.Labels = labels
+		return dp
+	}
+	w := &Wrapper{}
+	require.NoError(t, w.initDpSelector())
+	w.refreshDpSelector([]*DataPartition{
+		newDp(1, "host1", map[string]string{"media": "hdd", proto.PlacementLabelZone: "zone1"}),
+		newDp(2, "host2", map[string]string{"media": "ssd", proto.PlacementLabelZone: "zone1"}),
+		newDp(3, "host3", map[string]string{"media": "ssd", proto.PlacementLabelZone: "zone2"}),
+	})
+
+	hint, err := proto.ParseLabelSelector("media=ssd,zone=zone2")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		dp, err := w.GetDataPartitionForPlacement(nil, hint)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), dp.PartitionID)
+	}
+
+	hint, err = proto.ParseLabelSelector("media=ssd")
+	require.NoError(t, err)
+	dp, err := w.GetDataPartitionForPlacement(map[string]struct{}{"host3": {}}, hint)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), dp.PartitionID)
+
+	// the dp selector picks the partition if none is selected by the hint
+	hint, err = proto.ParseLabelSelector("media=nvme")
+	require.NoError(t, err)
+	dp, err = w.GetDataPartitionForPlacement(nil, hint)
+	require.NoError(t, err)
+	require.NotNil(t, dp)
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wrapper
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the volume view is refreshed every minute, the state turning the volume read-only
+// is polled by a much shorter heartbeat
+const volStateHeartbeatInterval = 5 * time.Second
+
+// FullReadOnly returns whether the volume is read-only as it is full, the writes shall
+// fail fast rather than running into ENOSPC of the datanodes.
+func (w *Wrapper) FullReadOnly() bool {
+	return atomic.LoadInt32(&w.fullReadOnly) != 0
+}
+
+func (w *Wrapper) setFullReadOnly(fullReadOnly bool) {
+	var val int32
+	if fullReadOnly {
+		val = 1
+	}
+	if old := atomic.SwapInt32(&w.fullReadOnly, val); old != val {
+		log.LogWarnf("setFullReadOnly: volume(%v) full read-only from old(%v) to new(%v)", w.volName, old != 0, fullReadOnly)
+	}
+}
+
+func (w *Wrapper) heartbeatVolState() {
+	state, err := w.mc.ClientAPI().GetVolumeClientState(w.volName)
+	if err != nil {
+		// keep the last state, the writes are judged by the datanodes anyway
+		log.LogWarnf("heartbeatVolState: volume(%v) err(%v)", w.volName, err)
+		return
+	}
+	w.setFullReadOnly(state.FullReadOnly)
+}
+
+func (w *Wrapper) heartbeatVolStateByTick() {
+	ticker := time.NewTicker(volStateHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.heartbeatVolState()
+		case <-w.stopC:
+			return
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
@@ -60,12 +61,17 @@ type Wrapper struct {
 	EnablePosixAcl        bool
 	masters               []string
 	partitions            map[uint64]*DataPartition
+	rwPartitions          []*DataPartition // the writable partitions the dp selector is refreshed by
 	followerRead          bool
 	followerReadClientCfg bool
 	nearRead              bool
+	wireCompress          bool
 	dpSelectorChanged     bool
 	dpSelectorName        string
 	dpSelectorParm        string
+	extentSize            int64  // max size of the normal extents of the extent size class of the volume
+	fullReadOnly          int32  // the volume is read-only as it is full
+	cloneSrcVolName       string // the source volume shares the data with the cloned volume
 	mc                    *masterSDK.MasterClient
 	stopOnce              sync.Once
 	stopC                 chan struct{}
@@ -82,6 +88,9 @@ type Wrapper struct {
 	verConfReadSeq               uint64
 	verReadSeq                   uint64
 	SimpleClient                 SimpleClientInfo
+
+	clientErrLock sync.Mutex
+	clientErrs    map[clientErrorKey]uint64
 }
 
 func (w *Wrapper) GetMasterClient() *masterSDK.MasterClient {
@@ -147,6 +156,8 @@ func NewDataPartitionWrapper(client SimpleClientInfo, volName string, masters []
 	w.verReadSeq = verReadSeq
 	w.SimpleClient = client
 	go w.uploadFlowInfoByTick(client)
+	go w.reportClientErrorsByTick()
+	go w.heartbeatVolStateByTick()
 	go w.update(client)
 	return
 }
@@ -166,6 +177,26 @@ func (w *Wrapper) FollowerRead() bool {
 	return w.followerRead
 }
 
+// ExtentSize returns the size the normal extents of the volume are rolled at.
+func (w *Wrapper) ExtentSize() int {
+	if size := atomic.LoadInt64(&w.extentSize); size > 0 {
+		return int(size)
+	}
+	return util.ExtentSize
+}
+
+func (w *Wrapper) updateExtentSize(class string) {
+	size, ok := proto.ExtentSizeOfClass(class)
+	if !ok {
+		log.LogWarnf("updateExtentSize: volume(%v) unknown extent size class(%v)", w.volName, class)
+		return
+	}
+	if old := atomic.SwapInt64(&w.extentSize, int64(size)); old != int64(size) {
+		log.LogInfof("updateExtentSize: volume(%v) extent size class(%v) size from old(%v) to new(%v)",
+			w.volName, class, old, size)
+	}
+}
+
 func (w *Wrapper) tryGetPartition(index uint64) (partition *DataPartition, ok bool) {
 	w.Lock.RLock()
 	defer w.Lock.RUnlock()
@@ -217,6 +248,9 @@ func (w *Wrapper) GetSimpleVolView() (err error) {
 	w.dpSelectorParm = view.DpSelectorParm
 	w.volType = view.VolType
 	w.EnablePosixAcl = view.EnablePosixAcl
+	w.updateExtentSize(view.ExtentSizeClass)
+	w.setFullReadOnly(view.FullReadOnly)
+	w.updateCloneSrc(view.CloneInfo)
 	w.UpdateUidsView(view)
 
 	log.LogDebugf("GetSimpleVolView: get volume simple info: ID(%v) name(%v) owner(%v) status(%v) capacity(%v) "+
@@ -332,6 +366,9 @@ func (w *Wrapper) updateSimpleVolView() (err error) {
 	}
 
 	w.UpdateUidsView(view)
+	w.updateExtentSize(view.ExtentSizeClass)
+	w.setFullReadOnly(view.FullReadOnly)
+	w.updateCloneSrc(view.CloneInfo)
 
 	if w.followerRead != view.FollowerRead && !w.followerReadClientCfg {
 		log.LogDebugf("UpdateSimpleVolView: update followerRead from old(%v) to new(%v)",
@@ -411,7 +448,57 @@ func (w *Wrapper) updateDataPartition(isInit bool) (err error) {
 		return
 	}
 	log.LogInfof("updateDataPartition: get data partitions: volume(%v) partitions(%v)", w.volName, len(dpv.DataPartitions))
-	return w.updateDataPartitionByRsp(isInit, dpv.DataPartitions)
+	if err = w.updateDataPartitionByRsp(isInit, dpv.DataPartitions); err != nil {
+		return
+	}
+	return w.updateCloneSrcDataPartition()
+}
+
+func (w *Wrapper) updateCloneSrc(info *proto.VolCloneInfo) {
+	srcVolName := ""
+	if info != nil && info.Status == proto.VolCloneStatusShared {
+		srcVolName = info.SrcVolName
+	}
+	w.Lock.Lock()
+	if w.cloneSrcVolName != srcVolName {
+		log.LogInfof("updateCloneSrc: volume(%v) clone source from old(%v) to new(%v)", w.volName, w.cloneSrcVolName, srcVolName)
+		w.cloneSrcVolName = srcVolName
+	}
+	w.Lock.Unlock()
+}
+
+// updateCloneSrcDataPartition loads the data partitions of the clone source to read the shared
+// extents, the partitions are never selected to write.
+func (w *Wrapper) updateCloneSrcDataPartition() (err error) {
+	w.Lock.RLock()
+	srcVolName := w.cloneSrcVolName
+	w.Lock.RUnlock()
+	if srcVolName == "" {
+		return
+	}
+
+	var dpv *proto.DataPartitionsView
+	if dpv, err = w.mc.ClientAPI().EncodingGzip().GetDataPartitions(srcVolName); err != nil {
+		log.LogErrorf("updateCloneSrcDataPartition: get data partitions fail: volume(%v) source(%v) err(%v)",
+			w.volName, srcVolName, err)
+		return
+	}
+	for _, partition := range dpv.DataPartitions {
+		if partition == nil {
+			continue
+		}
+		dp := &DataPartition{
+			DataPartitionResponse: *partition,
+			ClientWrapper:         w,
+			CloneSrc:              true,
+		}
+		if w.followerRead && w.nearRead {
+			dp.NearHosts = w.sortHostsByDistance(dp.Hosts)
+		}
+		w.replaceOrInsertPartition(dp)
+	}
+	log.LogInfof("updateCloneSrcDataPartition: volume(%v) source(%v) partitions(%v)", w.volName, srcVolName, len(dpv.DataPartitions))
+	return
 }
 
 func (w *Wrapper) UpdateDataPartition() (err error) {
@@ -608,6 +695,16 @@ func (w *Wrapper) NearRead() bool {
 	return w.nearRead
 }
 
+func (w *Wrapper) SetWireCompress(wireCompress bool) {
+	w.wireCompress = wireCompress
+	log.LogInfof("SetWireCompress: set wireCompress to %v", w.wireCompress)
+}
+
+// WireCompress returns true if the data on the wire to the datanodes is compressed.
+func (w *Wrapper) WireCompress() bool {
+	return w.wireCompress
+}
+
 // Sort hosts by distance form local
 func (w *Wrapper) sortHostsByDistance(srcHosts []string) []string {
 	hosts := make([]string, len(srcHosts))
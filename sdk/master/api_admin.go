@@ -64,6 +64,58 @@ func (api *AdminAPI) GetClusterStat() (cs *proto.ClusterStatInfo, err error) {
 	return
 }
 
+func (api *AdminAPI) SetDataPartitionSlo(enable bool, latencyMs uint64, violationLimit int) (err error) {
+	request := newRequest(get, proto.AdminSetDataPartitionSlo).Header(api.h)
+	request.addParam("enable", strconv.FormatBool(enable))
+	request.addParam("latencyMs", strconv.FormatUint(latencyMs, 10))
+	request.addParam("violationLimit", strconv.Itoa(violationLimit))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) GetDataPartitionSlo() (info *proto.DataPartitionSloInfo, err error) {
+	info = &proto.DataPartitionSloInfo{}
+	err = api.mc.requestWith(info, newRequest(get, proto.AdminGetDataPartitionSlo).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) SetCapability(name string, enable bool) (err error) {
+	request := newRequest(get, proto.AdminSetCapability).Header(api.h)
+	request.addParam("name", name)
+	request.addParam("enable", strconv.FormatBool(enable))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) GetCapabilities() (views []*proto.CapabilityView, err error) {
+	views = make([]*proto.CapabilityView, 0)
+	err = api.mc.requestWith(&views, newRequest(get, proto.AdminGetCapabilities).Header(api.h))
+	return
+}
+
+func (api *AdminAPI) GetVolClientHealth(volName string) (health *proto.VolClientHealth, err error) {
+	health = &proto.VolClientHealth{}
+	err = api.mc.requestWith(health, newRequest(get, proto.AdminGetVolClientHealth).
+		Header(api.h).addParam("name", volName))
+	return
+}
+
+func (api *AdminAPI) SetMount(prefix, volName, subDir string) (err error) {
+	request := newRequest(get, proto.AdminSetMount).Header(api.h)
+	request.addParam("prefix", prefix)
+	request.addParam("name", volName)
+	request.addParam("subDir", subDir)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) RemoveMount(prefix string) (err error) {
+	request := newRequest(get, proto.AdminRemoveMount).Header(api.h)
+	request.addParam("prefix", prefix)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) ListZones() (zoneViews []*proto.ZoneView, err error) {
 	zoneViews = make([]*proto.ZoneView, 0)
 	err = api.mc.requestWith(&zoneViews, newRequest(get, proto.GetAllZones).Header(api.h))
@@ -227,6 +279,25 @@ func (api *AdminAPI) DeleteVolumeWithAuthNode(volName, authKey, clientIDKey stri
 	return
 }
 
+func (api *AdminAPI) CloneVolume(volName, cloneName, authKey, owner string, materialize bool) (err error) {
+	request := newRequest(get, proto.AdminCloneVol).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("cloneName", cloneName)
+	request.addParam("authKey", authKey)
+	request.addParam("owner", owner)
+	request.addParam("materialize", strconv.FormatBool(materialize))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) DetachVolumeClone(volName, authKey string) (err error) {
+	request := newRequest(get, proto.AdminDetachVolClone).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("authKey", authKey)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) UpdateVolume(
 	vv *proto.SimpleVolView,
 	txTimeout int64,
@@ -352,6 +423,23 @@ func (api *AdminAPI) CreateVolName(volName, owner string, capacity uint64, delet
 	return
 }
 
+// AdviseCreateVolume asks the master whether the volume could be created with the args, nothing is created.
+func (api *AdminAPI) AdviseCreateVolume(capacity uint64, replicaNum, volType int, crossZone, normalZonesFirst bool,
+	zoneName string, cacheCapacity int,
+) (advice *proto.VolCreationAdvice, err error) {
+	request := newRequest(get, proto.AdminAdviseCreateVol).Header(api.h)
+	request.addParam("capacity", strconv.FormatUint(capacity, 10))
+	request.addParam("replicaNum", strconv.Itoa(replicaNum))
+	request.addParam("volType", strconv.Itoa(volType))
+	request.addParam("crossZone", strconv.FormatBool(crossZone))
+	request.addParam("normalZonesFirst", strconv.FormatBool(normalZonesFirst))
+	request.addParam("zoneName", zoneName)
+	request.addParam("cacheCap", strconv.Itoa(cacheCapacity))
+	advice = &proto.VolCreationAdvice{}
+	err = api.mc.requestWith(advice, request)
+	return
+}
+
 func (api *AdminAPI) CreateDefaultVolume(volName, owner string) (err error) {
 	request := newRequest(get, proto.AdminCreateVol).Header(api.h)
 	request.addParam("name", volName)
@@ -383,6 +471,105 @@ func (api *AdminAPI) SetVolumeAuditLog(volName string, enable bool) (err error)
 	return
 }
 
+func (api *AdminAPI) SetVolMetaDurability(volName, durability string, groupCommitDelayMs uint32) (err error) {
+	request := newRequest(post, proto.AdminVolSetMetaDurability).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("durability", durability)
+	request.addParam("groupCommitDelayMs", strconv.FormatUint(uint64(groupCommitDelayMs), 10))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolVerifyReadCrc(volName string, enable bool) (err error) {
+	request := newRequest(post, proto.AdminVolSetVerifyReadCrc).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("enable", strconv.FormatBool(enable))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolWriteBurstCredit(volName string, burstCredit uint64) (err error) {
+	request := newRequest(post, proto.AdminVolSetWriteBurstCredit).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("burstCredit", strconv.FormatUint(burstCredit, 10))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolMetaOpLimit(volName string, metaOpLimit, metaOpBurst uint64) (err error) {
+	request := newRequest(post, proto.AdminVolSetMetaOpLimit).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("metaOpLimit", strconv.FormatUint(metaOpLimit, 10))
+	request.addParam("metaOpBurst", strconv.FormatUint(metaOpBurst, 10))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolExtentSizeClass(volName, class string) (err error) {
+	request := newRequest(post, proto.AdminVolSetExtentSizeClass).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("extentSizeClass", class)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolFullReadOnlyRatio(volName string, ratio float64) (err error) {
+	request := newRequest(post, proto.AdminVolSetFullReadOnlyRatio).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("ratio", strconv.FormatFloat(ratio, 'f', -1, 64))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetVolLabelSelector(volName, selector string) (err error) {
+	request := newRequest(post, proto.AdminVolSetLabelSelector).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("labelSelector", selector)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) SetNodeLabels(addr string, nodeType uint32, labels string) (err error) {
+	request := newRequest(post, proto.AdminSetNodeLabels).Header(api.h)
+	request.addParam("addr", addr)
+	request.addParam("nodeType", strconv.FormatUint(uint64(nodeType), 10))
+	request.addParam("labels", labels)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
+func (api *AdminAPI) GetPlacementViolations() (view *proto.PlacementViolationsView, err error) {
+	view = &proto.PlacementViolationsView{}
+	err = api.mc.requestWith(view, newRequest(get, proto.AdminGetPlacementViolations).Header(api.h))
+	return
+}
+
+// SimulatePlacement compares the placement policies on the topology of the node set, or on the
+// snapshot exported before if it is not nil.
+func (api *AdminAPI) SimulatePlacement(nodesetId uint64, nodeType uint32, snapshot *proto.PlacementSnapshot,
+	policies string, count int, replicaNum int,
+) (report *proto.PlacementSimulationReport, err error) {
+	request := newRequest(post, proto.AdminSimulatePlacement).Header(api.h)
+	if snapshot != nil {
+		request.Body(snapshot)
+	} else {
+		request.addParam("nodesetId", strconv.FormatUint(nodesetId, 10))
+		request.addParam("nodeType", strconv.FormatUint(uint64(nodeType), 10))
+	}
+	if policies != "" {
+		request.addParam("policies", policies)
+	}
+	if count > 0 {
+		request.addParam("count", strconv.Itoa(count))
+	}
+	if replicaNum > 0 {
+		request.addParam("replicaNum", strconv.Itoa(replicaNum))
+	}
+	report = &proto.PlacementSimulationReport{}
+	err = api.mc.requestWith(report, request)
+	return
+}
+
 func (api *AdminAPI) GetMonitorPushAddr() (addr string, err error) {
 	err = api.mc.requestWith(&addr, newRequest(get, proto.AdminGetMonitorPushAddr).Header(api.h))
 	return
@@ -678,6 +865,20 @@ func (api *AdminAPI) DelBucketLifecycle(volume string) (err error) {
 	return
 }
 
+func (api *AdminAPI) ListBucketEvents(epoch int64, seq uint64) (view *proto.BucketEventsView, err error) {
+	view = &proto.BucketEventsView{}
+	err = api.mc.requestWith(view, newRequest(get, proto.ListBucketEvents).Header(api.h).
+		addParam("epoch", strconv.FormatInt(epoch, 10)).addParam("seq", strconv.FormatUint(seq, 10)))
+	return
+}
+
+func (api *AdminAPI) ReportBucketEvent(volume string) (err error) {
+	request := newRequest(post, proto.ReportBucketEvent).Header(api.h)
+	request.addParam("name", volume)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) GetS3QoSInfo() (data []byte, err error) {
 	return api.mc.serveRequest(newRequest(get, proto.S3QoSGet).Header(api.h))
 }
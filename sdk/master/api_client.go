@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/cubefs/cubefs/proto"
 )
@@ -87,6 +88,14 @@ func (api *ClientAPI) GetVolumeStat(volName string) (info *proto.VolStatInfo, er
 	return
 }
 
+// GetVolumeClientState returns the state of the volume polled by the heartbeats of the clients.
+func (api *ClientAPI) GetVolumeClientState(volName string) (state *proto.VolClientState, err error) {
+	state = &proto.VolClientState{}
+	err = api.mc.requestWith(state, newRequest(get, proto.ClientVolState).
+		Header(api.h).addParam("name", volName))
+	return
+}
+
 func (api *ClientAPI) GetMetaPartition(partitionID uint64) (partition *proto.MetaPartitionInfo, err error) {
 	partition = &proto.MetaPartitionInfo{}
 	err = api.mc.requestWith(partition, newRequest(get, proto.ClientMetaPartition).
@@ -129,3 +138,26 @@ func (api *ClientAPI) GetPreLoadDataPartitions(volName string) (view *proto.Data
 		Header(api.h).addParam("name", volName))
 	return
 }
+
+// ReportClientErrors reports the errors observed by the client since the last report.
+func (api *ClientAPI) ReportClientErrors(report *proto.ClientErrorReport) (err error) {
+	_, err = api.mc.serveRequest(newRequest(post, proto.ClientReportErrors).Header(api.h).Body(report))
+	return
+}
+
+// NegotiateCapabilities returns the capabilities requested by the client at mount which are enabled.
+func (api *ClientAPI) NegotiateCapabilities(volName string, requested []string) (n *proto.CapabilityNegotiation, err error) {
+	n = &proto.CapabilityNegotiation{}
+	request := newRequest(get, proto.ClientCapabilities).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("capabilities", strings.Join(requested, ","))
+	err = api.mc.requestWith(n, request)
+	return
+}
+
+// GetMountMap returns the mount map stitching the volumes into the global namespace.
+func (api *ClientAPI) GetMountMap() (view *proto.MountMapView, err error) {
+	view = &proto.MountMapView{}
+	err = api.mc.requestWith(view, newRequest(get, proto.ClientMountMap).Header(api.h))
+	return
+}
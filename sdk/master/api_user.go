@@ -90,6 +90,26 @@ func (api *UserAPI) GetUserInfo(userID string) (userInfo *proto.UserInfo, err er
 	return
 }
 
+func (api *UserAPI) GetUidInfo(uid uint32) (userInfo *proto.UserInfo, err error) {
+	userInfo = &proto.UserInfo{}
+	err = api.mc.requestWith(userInfo, newRequest(get, proto.UserGetUidInfo).Header(api.h).addParamAny("uid", uid))
+	return
+}
+
+func (api *UserAPI) SetPosix(param *proto.UserPosixIdentityParam, clientIDKey string) (userInfo *proto.UserInfo, err error) {
+	userInfo = &proto.UserInfo{}
+	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserSetPosix).
+		Header(api.h).Body(param).addParam("clientIDKey", clientIDKey))
+	return
+}
+
+func (api *UserAPI) RemovePosix(userID, clientIDKey string) (userInfo *proto.UserInfo, err error) {
+	userInfo = &proto.UserInfo{}
+	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserRemovePosix).
+		Header(api.h).addParam("user", userID).addParam("clientIDKey", clientIDKey))
+	return
+}
+
 func (api *UserAPI) UpdatePolicy(param *proto.UserPermUpdateParam, clientIDKey string) (userInfo *proto.UserInfo, err error) {
 	userInfo = &proto.UserInfo{}
 	err = api.mc.requestWith(userInfo, newRequest(post, proto.UserUpdatePolicy).
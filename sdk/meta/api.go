@@ -46,6 +46,8 @@ const (
 	BatchGetBufLen         = 500
 	UpdateSummaryRetry     = 3
 	SummaryKey             = "DirStat"
+	DirChangesKey          = "DirChanges" // read as DirChanges.<from>
+	DirChangesLimit        = 100
 	ChannelLen             = 100
 	BatchSize              = 200
 	MaxGoroutineNum        = 5
@@ -390,19 +392,29 @@ func (mw *MetaWrapper) BatchGetExpiredMultipart(prefix string, days int) (expire
 }
 
 func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
+	return mw.inodeGet(inode, true)
+}
+
+// InodeGetLatest_ll gets the latest inode of the id, the epoch held by the open handles is not checked,
+// so that the inode taking a reused id can be got by the id looked up from the dentry.
+func (mw *MetaWrapper) InodeGetLatest_ll(inode uint64) (*proto.InodeInfo, error) {
+	return mw.inodeGet(inode, false)
+}
+
+func (mw *MetaWrapper) inodeGet(inode uint64, checkEpoch bool) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
 		log.LogErrorf("InodeGet_ll: No such partition, ino(%v)", inode)
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode, mw.VerReadSeq)
+	status, info, err := mw.igetWithEpochCheck(mp, inode, mw.VerReadSeq, checkEpoch)
 	if err != nil || status != statusOK {
 		if status == statusNoent {
 			// For NOENT error, pull the latest mp and give it another try,
 			// in case the mp view is outdated.
 			mw.triggerAndWaitForceUpdate()
-			return mw.doInodeGet(inode)
+			return mw.doInodeGet(inode, checkEpoch)
 		}
 		return nil, statusToErrno(status)
 	}
@@ -420,14 +432,14 @@ func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 }
 
 // Just like InodeGet but without retry
-func (mw *MetaWrapper) doInodeGet(inode uint64) (*proto.InodeInfo, error) {
+func (mw *MetaWrapper) doInodeGet(inode uint64, checkEpoch bool) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
 		log.LogErrorf("InodeGet_ll: No such partition, ino(%v)", inode)
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode, mw.VerReadSeq)
+	status, info, err := mw.igetWithEpochCheck(mp, inode, mw.VerReadSeq, checkEpoch)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
@@ -1336,6 +1348,90 @@ func (mw *MetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint6
 	return children, nil
 }
 
+// ReadDirStream_ll reads at most limit dentries with parentID. An empty cursor starts a new readdir session,
+// and the returned cursor continues the session without duplicated or missed dentries.
+func (mw *MetaWrapper) ReadDirStream_ll(parentID uint64, cursor string, limit uint64) (children []proto.Dentry, next string, more bool, err error) {
+	log.LogDebugf("action[ReadDirStream_ll] parentID %v cursor %v limit %v", parentID, cursor, limit)
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, "", false, syscall.ENOENT
+	}
+
+	status, resp, err := mw.readDirStream(parentMP, parentID, cursor, "", limit, mw.VerReadSeq)
+	if err == nil && status == statusInval && cursor != "" {
+		// the lease of the session is lost by the restart or the switch of the meta node,
+		// start a new session after the position of the cursor
+		if c, e := proto.DecodeReadDirCursor(cursor); e == nil && c.ParentID == parentID {
+			log.LogWarnf("ReadDirStream_ll: parentID(%v) restart session after marker(%v)", parentID, c.Marker)
+			status, resp, err = mw.readDirStream(parentMP, parentID, "", c.Marker, limit, mw.VerReadSeq)
+		}
+	}
+	if err != nil || status != statusOK {
+		return nil, "", false, statusToErrno(status)
+	}
+	return resp.Children, resp.Cursor, resp.HasMore, nil
+}
+
+// ReadDirChanges_ll reads at most limit changes of the dentries with parentID after the sequence from,
+// the returned next is the sequence to read the following changes. The changes after from may be
+// dropped by the meta partition if truncated is returned, and the directory should be listed again.
+func (mw *MetaWrapper) ReadDirChanges_ll(parentID uint64, from uint64, limit uint64) (events []proto.DirChangeEvent, next uint64, truncated bool, err error) {
+	log.LogDebugf("action[ReadDirChanges_ll] parentID %v from %v limit %v", parentID, from, limit)
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, 0, false, syscall.ENOENT
+	}
+
+	status, resp, err := mw.readDirChanges(parentMP, parentID, from, limit)
+	if err != nil || status != statusOK {
+		return nil, 0, false, statusToErrno(status)
+	}
+	return resp.Events, resp.Next, resp.Truncated, nil
+}
+
+// ReadDirFiltered_ll scans at most limit dentries with parentID after the marker, and returns the entries
+// selected by the filter sorted by sortBy. The meta partition of the parent filters the entries whose
+// inodes are in it, and the others are fetched from their partitions here. The returned next is the
+// marker to scan the following dentries, empty if all the dentries are scanned.
+func (mw *MetaWrapper) ReadDirFiltered_ll(parentID uint64, marker string, limit uint64, filter *proto.DirEntryFilter, sortBy uint8) (entries []proto.DirEntryInfo, next string, err error) {
+	log.LogDebugf("action[ReadDirFiltered_ll] parentID %v marker %v limit %v filter %v sort %v", parentID, marker, limit, *filter, sortBy)
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		return nil, "", syscall.ENOENT
+	}
+
+	status, resp, err := mw.readDirFiltered(parentMP, parentID, marker, limit, filter, sortBy)
+	if err != nil || status != statusOK {
+		return nil, "", statusToErrno(status)
+	}
+	if len(resp.Unresolved) == 0 {
+		return resp.Entries, resp.Next, nil
+	}
+
+	inodes := make([]uint64, 0, len(resp.Unresolved))
+	for _, d := range resp.Unresolved {
+		inodes = append(inodes, d.Inode)
+	}
+	infos := make(map[uint64]*proto.InodeInfo, len(inodes))
+	for _, info := range mw.BatchInodeGet(inodes) {
+		infos[info.Inode] = info
+	}
+	entries = resp.Entries
+	for _, d := range resp.Unresolved {
+		info, ok := infos[d.Inode]
+		if !ok {
+			continue
+		}
+		mtime := info.ModifyTime.Unix()
+		if filter.Match(d.Type, info.Size, mtime) {
+			entries = append(entries, proto.DirEntryInfo{Dentry: d, Size: info.Size, Mtime: mtime})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	proto.SortDirEntries(entries, sortBy)
+	return entries, resp.Next, nil
+}
+
 func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32, fullPath string) error {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
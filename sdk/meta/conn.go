@@ -57,6 +57,8 @@ func (mw *MetaWrapper) putConn(mc *MetaConn, err error) {
 }
 
 func (mw *MetaWrapper) sendToMetaPartition(mp *MetaPartition, req *proto.Packet) (*proto.Packet, error) {
+	defer mw.beginHedgeWrite(mp, req)()
+
 	var (
 		resp    *proto.Packet
 		err     error
@@ -72,7 +74,14 @@ func (mw *MetaWrapper) sendToMetaPartition(mp *MetaPartition, req *proto.Packet)
 		sendTimeLimit = int(mw.metaSendTimeout) * 1000 // ms
 	}
 
-	delta := (sendTimeLimit*2/SendRetryLimit - SendRetryInterval*2) / SendRetryLimit // ms
+	retryLimit := mw.metaRetryLimit
+	if retryLimit <= 0 {
+		retryLimit = SendRetryLimit
+	}
+	delta := (sendTimeLimit*2/retryLimit - SendRetryInterval*2) / retryLimit // ms
+	if delta < 0 {
+		delta = 0
+	}
 	log.LogDebugf("mw.metaSendTimeout: %v s, sendTimeLimit: %v ms, delta: %v ms, req %v", mw.metaSendTimeout, sendTimeLimit, delta, req)
 
 	req.ExtentType |= proto.MultiVersionFlag
@@ -116,7 +125,7 @@ sendWithList:
 	mw.putConn(mc, err)
 retry:
 	start = time.Now()
-	for i := 0; i <= SendRetryLimit; i++ {
+	for i := 0; i <= retryLimit; i++ {
 		for j, addr = range mp.Members {
 			mc, err = mw.getConn(mp.PartitionID, addr)
 			errs[j] = err
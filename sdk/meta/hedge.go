@@ -0,0 +1,213 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// the operations not changing the metadata, the others reset the applied index of the hedged reads
+var metaReadOps = map[uint8]struct{}{
+	proto.OpMetaLookup:          {},
+	proto.OpMetaReadDir:         {},
+	proto.OpMetaReadDirOnly:     {},
+	proto.OpMetaReadDirLimit:    {},
+	proto.OpMetaReadDirStream:   {},
+	proto.OpMetaReadDirChanges:  {},
+	proto.OpMetaReadDirFiltered: {},
+	proto.OpMetaInodeGet:        {},
+	proto.OpMetaBatchInodeGet:   {},
+	proto.OpMetaExtentsList:     {},
+	proto.OpMetaObjExtentsList:  {},
+	proto.OpMetaGetXAttr:        {},
+	proto.OpMetaListXAttr:       {},
+	proto.OpMetaBatchGetXAttr:   {},
+	proto.OpMetaGetInodeQuota:   {},
+	proto.OpGetMultipart:        {},
+	proto.OpListMultiparts:      {},
+	proto.OpMetaTxGet:           {},
+}
+
+// hedgeState is the applied index of a meta partition which the hedged reads require the
+// followers to have applied. It is the max applied index observed by the reads, and is reset by
+// the writes, so that the partition is read from the leader once written, whose applied index
+// covers the writes, and the client never reads older than it has read or written.
+type hedgeState struct {
+	mu        sync.Mutex
+	appliedID uint64
+	seq       uint64 // changed by the writes at the start and the end
+	writes    int    // the writes in flight
+}
+
+func (s *hedgeState) getAppliedID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writes > 0 {
+		return 0
+	}
+	return s.appliedID
+}
+
+func (s *hedgeState) beginWrite() {
+	s.mu.Lock()
+	s.writes++
+	s.seq++
+	s.appliedID = 0
+	s.mu.Unlock()
+}
+
+func (s *hedgeState) endWrite() {
+	s.mu.Lock()
+	s.writes--
+	s.seq++
+	s.mu.Unlock()
+}
+
+// beginRead returns the seq to observe the applied index of the read with, ok is false
+// if the applied index of the read may not cover the writes in flight.
+func (s *hedgeState) beginRead() (seq uint64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq, s.writes == 0
+}
+
+func (s *hedgeState) observe(seq, appliedID uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seq == seq && appliedID > s.appliedID {
+		s.appliedID = appliedID
+	}
+}
+
+func (mw *MetaWrapper) getHedgeState(partitionID uint64) *hedgeState {
+	if value, ok := mw.hedgeStates.Load(partitionID); ok {
+		return value.(*hedgeState)
+	}
+	value, _ := mw.hedgeStates.LoadOrStore(partitionID, &hedgeState{})
+	return value.(*hedgeState)
+}
+
+// beginHedgeWrite resets the applied index of the partition if the request is not a read, and
+// returns the function to be called once the request is done.
+func (mw *MetaWrapper) beginHedgeWrite(mp *MetaPartition, req *proto.Packet) (end func()) {
+	if mw.metaHedgeDelay <= 0 {
+		return func() {}
+	}
+	if _, ok := metaReadOps[req.Opcode]; ok {
+		return func() {}
+	}
+	state := mw.getHedgeState(mp.PartitionID)
+	state.beginWrite()
+	return state.endWrite
+}
+
+// beginHedgeRead returns the function to observe the applied index of the read response with.
+func (mw *MetaWrapper) beginHedgeRead(mp *MetaPartition) (observe func(appliedID uint64)) {
+	if mw.metaHedgeDelay <= 0 {
+		return func(uint64) {}
+	}
+	state := mw.getHedgeState(mp.PartitionID)
+	seq, ok := state.beginRead()
+	return func(appliedID uint64) {
+		if ok && appliedID > 0 {
+			state.observe(seq, appliedID)
+		}
+	}
+}
+
+type hedgeResult struct {
+	resp *proto.Packet
+	err  error
+}
+
+// sendHedgedToMetaPartition sends the read to the leader, and hedges it to a follower if the leader
+// does not respond in the hedge delay, the first good response is returned. newHedgeReq returns the
+// request to the follower, which requires the follower to have applied the index.
+func (mw *MetaWrapper) sendHedgedToMetaPartition(mp *MetaPartition, req *proto.Packet,
+	newHedgeReq func(appliedID uint64) (*proto.Packet, error),
+) (*proto.Packet, error) {
+	if mw.metaHedgeDelay <= 0 {
+		return mw.sendToMetaPartition(mp, req)
+	}
+	appliedID := mw.getHedgeState(mp.PartitionID).getAppliedID()
+	followers := make([]string, 0, len(mp.Members))
+	for _, addr := range mp.Members {
+		if addr != mp.LeaderAddr {
+			followers = append(followers, addr)
+		}
+	}
+	if appliedID == 0 || mp.LeaderAddr == "" || len(followers) == 0 {
+		return mw.sendToMetaPartition(mp, req)
+	}
+
+	leaderC := make(chan hedgeResult, 1)
+	go func() {
+		resp, err := mw.sendToMetaPartition(mp, req)
+		leaderC <- hedgeResult{resp: resp, err: err}
+	}()
+	timer := time.NewTimer(mw.metaHedgeDelay)
+	defer timer.Stop()
+	select {
+	case r := <-leaderC:
+		return r.resp, r.err
+	case <-timer.C:
+	}
+
+	hedgeReq, err := newHedgeReq(appliedID)
+	if err != nil {
+		r := <-leaderC
+		return r.resp, r.err
+	}
+	labels := map[string]string{exporter.Vol: mw.volname, exporter.Op: hedgeReq.GetOpMsg()}
+	exporter.NewCounter("metaHedgedRead").AddWithLabels(1, labels)
+	hedgeC := make(chan hedgeResult, 1)
+	go func() {
+		resp, err := mw.sendToFollower(mp, followers[rand.Intn(len(followers))], hedgeReq)
+		hedgeC <- hedgeResult{resp: resp, err: err}
+	}()
+	select {
+	case r := <-leaderC:
+		return r.resp, r.err
+	case r := <-hedgeC:
+		if r.err == nil && !r.resp.ShouldRetry() {
+			exporter.NewCounter("metaHedgedReadWin").AddWithLabels(1, labels)
+			return r.resp, nil
+		}
+		log.LogDebugf("sendHedgedToMetaPartition: hedged read failed, req(%v) mp(%v) err(%v) resp(%v)", hedgeReq, mp, r.err, r.resp)
+	}
+	r := <-leaderC
+	return r.resp, r.err
+}
+
+// sendToFollower sends the hedged read to the follower once.
+func (mw *MetaWrapper) sendToFollower(mp *MetaPartition, addr string, req *proto.Packet) (resp *proto.Packet, err error) {
+	mc, err := mw.getConn(mp.PartitionID, addr)
+	if err != nil {
+		return
+	}
+	var lastSeq uint64
+	if mw.Client != nil {
+		lastSeq = mw.Client.GetLatestVer()
+	}
+	resp, err = mc.send(req, lastSeq)
+	mw.putConn(mc, err)
+	return
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgeState(t *testing.T) {
+	mw := &MetaWrapper{metaHedgeDelay: time.Millisecond}
+	mp := &MetaPartition{PartitionID: 1}
+	state := mw.getHedgeState(mp.PartitionID)
+	require.Equal(t, uint64(0), state.getAppliedID())
+
+	// the max applied index observed by the reads
+	mw.beginHedgeRead(mp)(10)
+	mw.beginHedgeRead(mp)(8)
+	require.Equal(t, uint64(10), state.getAppliedID())
+
+	// the reads are not hedged while writing, and the applied index is reset by the writes
+	read := mw.beginHedgeRead(mp)
+	end := mw.beginHedgeWrite(mp, &proto.Packet{Opcode: proto.OpMetaCreateInode})
+	require.Equal(t, uint64(0), state.getAppliedID())
+	readWhileWriting := mw.beginHedgeRead(mp)
+	read(12)
+	require.Equal(t, uint64(0), state.getAppliedID())
+	end()
+	readWhileWriting(13)
+	require.Equal(t, uint64(0), state.getAppliedID())
+
+	// the reads after the writes cover the writes
+	mw.beginHedgeRead(mp)(14)
+	require.Equal(t, uint64(14), state.getAppliedID())
+
+	// the reads do not reset the applied index
+	mw.beginHedgeWrite(mp, &proto.Packet{Opcode: proto.OpMetaLookup})()
+	require.Equal(t, uint64(14), state.getAppliedID())
+	require.Equal(t, uint64(0), mw.getHedgeState(2).getAppliedID())
+}
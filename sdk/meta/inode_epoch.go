@@ -0,0 +1,62 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"github.com/cubefs/cubefs/proto"
+)
+
+type heldInodeEpoch struct {
+	epoch uint32
+	refs  int
+}
+
+// HoldInodeEpoch records the epoch of the inode opened by a handle, the requests on the inode
+// carry the epoch, so that they are rejected if the inode id is reused by a new inode on the metanode.
+func (mw *MetaWrapper) HoldInodeEpoch(ino uint64, epoch uint32) {
+	mw.inodeEpochsLock.Lock()
+	defer mw.inodeEpochsLock.Unlock()
+	if mw.inodeEpochs == nil {
+		mw.inodeEpochs = make(map[uint64]*heldInodeEpoch)
+	}
+	held, ok := mw.inodeEpochs[ino]
+	if !ok {
+		mw.inodeEpochs[ino] = &heldInodeEpoch{epoch: epoch, refs: 1}
+		return
+	}
+	held.epoch = epoch
+	held.refs++
+}
+
+// ReleaseInodeEpoch releases the epoch of the inode held by HoldInodeEpoch.
+func (mw *MetaWrapper) ReleaseInodeEpoch(ino uint64) {
+	mw.inodeEpochsLock.Lock()
+	defer mw.inodeEpochsLock.Unlock()
+	held, ok := mw.inodeEpochs[ino]
+	if !ok {
+		return
+	}
+	if held.refs--; held.refs <= 0 {
+		delete(mw.inodeEpochs, ino)
+	}
+}
+
+func (mw *MetaWrapper) setInodeEpochCheck(ino uint64, check *proto.InodeEpochCheck) {
+	mw.inodeEpochsLock.Lock()
+	defer mw.inodeEpochsLock.Unlock()
+	if held, ok := mw.inodeEpochs[ino]; ok {
+		check.SetEpoch(held.epoch)
+	}
+}
@@ -91,6 +91,9 @@ type MetaConfig struct {
 	OnAsyncTaskError AsyncTaskErrorFunc
 	EnableSummary    bool
 	MetaSendTimeout  int64
+	MetaRetryLimit   int // SendRetryLimit if 0
+	ReaddirEncoding  string
+	MetaHedgeDelay   time.Duration // the lookups and inode gets are not hedged if 0
 
 	// EnableTransaction uint8
 	// EnableTransaction bool
@@ -150,6 +153,10 @@ type MetaWrapper struct {
 	singleflight            singleflight.Group
 	EnableSummary           bool
 	metaSendTimeout         int64
+	metaRetryLimit          int
+	readDirEncoding         *proto.DentryBatchEncoding
+	metaHedgeDelay          time.Duration
+	hedgeStates             sync.Map // partition id -> *hedgeState
 	DirChildrenNumLimit     uint32
 	EnableTransaction       proto.TxOpMask
 	TxTimeout               int64
@@ -168,6 +175,10 @@ type MetaWrapper struct {
 	VerReadSeq uint64
 	LastVerSeq uint64
 	Client     wrapper.SimpleClientInfo
+
+	// epochs of the inodes held by the open handles
+	inodeEpochs     map[uint64]*heldInodeEpoch
+	inodeEpochsLock sync.Mutex
 }
 
 type uniqidRange struct {
@@ -209,6 +220,14 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.mc = masterSDK.NewMasterClient(config.Masters, false)
 	mw.onAsyncTaskError = config.OnAsyncTaskError
 	mw.metaSendTimeout = config.MetaSendTimeout
+	mw.metaRetryLimit = SendRetryLimit
+	if config.MetaRetryLimit > 0 {
+		mw.metaRetryLimit = config.MetaRetryLimit
+	}
+	mw.metaHedgeDelay = config.MetaHedgeDelay
+	if mw.readDirEncoding, err = proto.ParseDentryBatchEncoding(config.ReaddirEncoding); err != nil {
+		return nil, err
+	}
 	mw.conns = util.NewConnectPool()
 	mw.partitions = make(map[uint64]*MetaPartition)
 	mw.ranges = btree.New(32)
@@ -0,0 +1,285 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"encoding/json"
+	"io"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const namespaceReadDirLimit = 1024
+
+// namespaceMeta is the metadata operations the export and the import of the namespace use.
+type namespaceMeta interface {
+	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
+	BatchInodeGet(inodes []uint64) []*proto.InodeInfo
+	XAttrGetAll_ll(inode uint64) (*proto.XAttrInfo, error)
+	ReadDirStream_ll(parentID uint64, cursor string, limit uint64) (children []proto.Dentry, next string, more bool, err error)
+	Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error)
+	Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error)
+	Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error)
+	Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error
+	BatchSetXAttr_ll(inode uint64, attrs map[string]string) error
+}
+
+// ExportNamespace writes the metadata of the subtree at root to w as a stream of
+// proto.NamespaceRecord, the directories are walked breadth first and read by pages,
+// so the memory used is bounded by the directories pending to walk.
+func (mw *MetaWrapper) ExportNamespace(root uint64, w io.Writer) (count int, err error) {
+	return exportNamespace(mw, root, w)
+}
+
+// NamespaceImportFunc is called with each imported record and the id of its inode, existed is
+// true if the dentry exists before the import and is skipped.
+type NamespaceImportFunc func(rec *proto.NamespaceRecord, ino uint64, existed bool) error
+
+// ImportNamespace creates the subtree of the stream written by ExportNamespace under parentID,
+// the root of the stream is mapped to parentID itself. The inodes are created with new ids and
+// onImport, if not nil, is called with each record and the id of its inode, so the mover copies
+// the data of the files and keeps the mapping of the ids. The files are created empty, the hard
+// links are linked to the inode created for the first of them, and the times of the directories
+// are set after all the records are imported as the creations of the children change them.
+//
+// The dentries that already exist with the same type are skipped, except that the times of the
+// directories are still set, so an import that failed halfway is resumed by importing the same
+// stream again.
+func (mw *MetaWrapper) ImportNamespace(r io.Reader, parentID uint64, onImport NamespaceImportFunc) (created, skipped int, err error) {
+	return importNamespace(mw, r, parentID, onImport)
+}
+
+func exportNamespace(meta namespaceMeta, root uint64, w io.Writer) (count int, err error) {
+	enc := json.NewEncoder(w)
+	info, err := meta.InodeGet_ll(root)
+	if err != nil {
+		return
+	}
+	if !proto.IsDir(info.Mode) {
+		return 0, syscall.ENOTDIR
+	}
+	if err = exportNamespaceRecord(meta, enc, info, 0, ""); err != nil {
+		return
+	}
+	count++
+
+	dirs := []uint64{root}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		cursor := ""
+		for {
+			children, next, more, err := meta.ReadDirStream_ll(dir, cursor, namespaceReadDirLimit)
+			if err != nil {
+				log.LogErrorf("exportNamespace: readdir ino(%v) cursor(%v) err(%v)", dir, cursor, err)
+				return count, err
+			}
+			inodes := make([]uint64, 0, len(children))
+			for _, child := range children {
+				inodes = append(inodes, child.Inode)
+			}
+			infos := make(map[uint64]*proto.InodeInfo, len(children))
+			for _, info := range meta.BatchInodeGet(inodes) {
+				infos[info.Inode] = info
+			}
+			for _, child := range children {
+				info, ok := infos[child.Inode]
+				if !ok {
+					// the batch get skips the failed partitions, retry one by one
+					if info, err = meta.InodeGet_ll(child.Inode); err == syscall.ENOENT {
+						log.LogWarnf("exportNamespace: skip the deleted dentry parent(%v) name(%v) ino(%v)", dir, child.Name, child.Inode)
+						continue
+					} else if err != nil {
+						return count, err
+					}
+				}
+				if err = exportNamespaceRecord(meta, enc, info, dir, child.Name); err != nil {
+					return count, err
+				}
+				count++
+				if proto.IsDir(info.Mode) {
+					dirs = append(dirs, info.Inode)
+				}
+			}
+			if !more {
+				break
+			}
+			cursor = next
+		}
+	}
+	return
+}
+
+func exportNamespaceRecord(meta namespaceMeta, enc *json.Encoder, info *proto.InodeInfo, parent uint64, name string) (err error) {
+	rec := &proto.NamespaceRecord{
+		Ino:        info.Inode,
+		Parent:     parent,
+		Name:       name,
+		Mode:       info.Mode,
+		Nlink:      info.Nlink,
+		Uid:        info.Uid,
+		Gid:        info.Gid,
+		Size:       info.Size,
+		ModifyTime: info.ModifyTime.Unix(),
+		AccessTime: info.AccessTime.Unix(),
+		Target:     info.Target,
+	}
+	xattrs, err := meta.XAttrGetAll_ll(info.Inode)
+	if err != nil {
+		log.LogErrorf("exportNamespace: get xattrs ino(%v) err(%v)", info.Inode, err)
+		return
+	}
+	if len(xattrs.XAttrs) > 0 {
+		rec.XAttrs = xattrs.XAttrs
+	}
+	return enc.Encode(rec)
+}
+
+func importNamespace(meta namespaceMeta, r io.Reader, parentID uint64, onImport NamespaceImportFunc) (created, skipped int, err error) {
+	type dirTimes struct {
+		ino          uint64
+		atime, mtime int64
+	}
+	var (
+		dec = json.NewDecoder(r)
+		// ids of the directories and the hard linked files, the parents of the records
+		// and the targets of the links
+		inos     = make(map[uint64]uint64)
+		dirs     []dirTimes
+		hasRoot  bool
+		recIndex int
+	)
+	for {
+		rec := new(proto.NamespaceRecord)
+		if err = dec.Decode(rec); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			log.LogErrorf("importNamespace: decode record(%v) err(%v)", recIndex, err)
+			return
+		}
+		recIndex++
+		if !hasRoot {
+			if rec.Parent != 0 || !proto.IsDir(rec.Mode) {
+				log.LogErrorf("importNamespace: the first record ino(%v) is not the root of a subtree", rec.Ino)
+				return created, skipped, syscall.EINVAL
+			}
+			inos[rec.Ino] = parentID
+			hasRoot = true
+			continue
+		}
+		parent, ok := inos[rec.Parent]
+		if !ok || rec.Name == "" {
+			log.LogErrorf("importNamespace: record(%v) parent(%v) name(%v) not after its parent", recIndex, rec.Parent, rec.Name)
+			return created, skipped, syscall.EINVAL
+		}
+
+		var (
+			ino     uint64
+			existed bool
+		)
+		if linked, ok := inos[rec.Ino]; ok && !proto.IsDir(rec.Mode) {
+			if _, err = meta.Link(parent, rec.Name, linked, ""); err == syscall.EEXIST {
+				existed, err = true, lookupNamespaceDentry(meta, parent, rec, linked)
+			}
+			if err != nil {
+				log.LogErrorf("importNamespace: link parent(%v) name(%v) ino(%v) err(%v)", parent, rec.Name, linked, err)
+				return
+			}
+			ino = linked
+		} else {
+			var info *proto.InodeInfo
+			if info, err = meta.Create_ll(parent, rec.Name, rec.Mode, rec.Uid, rec.Gid, rec.Target, ""); err == syscall.EEXIST {
+				existed = true
+				var mode uint32
+				if ino, mode, err = meta.Lookup_ll(parent, rec.Name); err == nil && proto.IsDir(mode) != proto.IsDir(rec.Mode) {
+					err = syscall.EEXIST
+				}
+			} else if err == nil {
+				ino = info.Inode
+			}
+			if err != nil {
+				log.LogErrorf("importNamespace: create parent(%v) name(%v) err(%v)", parent, rec.Name, err)
+				return
+			}
+			if proto.IsDir(rec.Mode) || rec.Nlink > 1 {
+				inos[rec.Ino] = ino
+			}
+			if !existed {
+				if err = importNamespaceAttrs(meta, ino, rec); err != nil {
+					return
+				}
+			}
+			// the times of the existing directories are set too, the children missed by
+			// the failed import change them
+			if proto.IsDir(rec.Mode) {
+				dirs = append(dirs, dirTimes{ino: ino, atime: rec.AccessTime, mtime: rec.ModifyTime})
+			}
+		}
+		if existed {
+			log.LogDebugf("importNamespace: skip the existing dentry parent(%v) name(%v) ino(%v)", parent, rec.Name, ino)
+			skipped++
+		} else {
+			created++
+		}
+		if onImport != nil {
+			if err = onImport(rec, ino, existed); err != nil {
+				return
+			}
+		}
+	}
+	if !hasRoot {
+		return 0, 0, syscall.EINVAL
+	}
+	for _, dir := range dirs {
+		if err = meta.Setattr(dir.ino, proto.AttrAccessTime|proto.AttrModifyTime, 0, 0, 0, dir.atime, dir.mtime); err != nil {
+			log.LogErrorf("importNamespace: set times ino(%v) err(%v)", dir.ino, err)
+			return
+		}
+	}
+	return
+}
+
+// importNamespaceAttrs sets the xattrs and the times of the files, the times of the
+// directories are set after their children are imported.
+func importNamespaceAttrs(meta namespaceMeta, ino uint64, rec *proto.NamespaceRecord) (err error) {
+	if len(rec.XAttrs) > 0 {
+		if err = meta.BatchSetXAttr_ll(ino, rec.XAttrs); err != nil {
+			log.LogErrorf("importNamespace: set xattrs ino(%v) err(%v)", ino, err)
+			return
+		}
+	}
+	if proto.IsDir(rec.Mode) {
+		return
+	}
+	if err = meta.Setattr(ino, proto.AttrAccessTime|proto.AttrModifyTime, 0, 0, 0, rec.AccessTime, rec.ModifyTime); err != nil {
+		log.LogErrorf("importNamespace: set times ino(%v) err(%v)", ino, err)
+	}
+	return
+}
+
+// lookupNamespaceDentry returns EEXIST if the existing dentry of the hard link is not linked to ino.
+func lookupNamespaceDentry(meta namespaceMeta, parent uint64, rec *proto.NamespaceRecord, ino uint64) error {
+	existing, _, err := meta.Lookup_ll(parent, rec.Name)
+	if err != nil {
+		return err
+	}
+	if existing != ino {
+		return syscall.EEXIST
+	}
+	return nil
+}
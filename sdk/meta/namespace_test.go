@@ -0,0 +1,233 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// memNamespace is an in-memory namespace, the readdir is paged by the names.
+type memNamespace struct {
+	nextIno  uint64
+	inodes   map[uint64]*proto.InodeInfo
+	xattrs   map[uint64]map[string]string
+	children map[uint64]map[string]uint64
+}
+
+func newMemNamespace(root, nextIno uint64) *memNamespace {
+	ns := &memNamespace{
+		nextIno:  nextIno,
+		inodes:   make(map[uint64]*proto.InodeInfo),
+		xattrs:   make(map[uint64]map[string]string),
+		children: make(map[uint64]map[string]uint64),
+	}
+	ns.inodes[root] = &proto.InodeInfo{Inode: root, Mode: proto.Mode(os.ModeDir | 0o755), Nlink: 2}
+	ns.children[root] = make(map[string]uint64)
+	return ns
+}
+
+func (ns *memNamespace) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
+	if info, ok := ns.inodes[inode]; ok {
+		return info, nil
+	}
+	return nil, syscall.ENOENT
+}
+
+func (ns *memNamespace) BatchInodeGet(inodes []uint64) (infos []*proto.InodeInfo) {
+	for _, ino := range inodes {
+		if info, ok := ns.inodes[ino]; ok {
+			infos = append(infos, info)
+		}
+	}
+	return
+}
+
+func (ns *memNamespace) XAttrGetAll_ll(inode uint64) (*proto.XAttrInfo, error) {
+	return &proto.XAttrInfo{Inode: inode, XAttrs: ns.xattrs[inode]}, nil
+}
+
+func (ns *memNamespace) ReadDirStream_ll(parentID uint64, cursor string, limit uint64) (children []proto.Dentry, next string, more bool, err error) {
+	names := make([]string, 0)
+	for name := range ns.children[parentID] {
+		if name > cursor {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if uint64(len(names)) > limit {
+		names, more = names[:limit], true
+	}
+	for _, name := range names {
+		children = append(children, proto.Dentry{Name: name, Inode: ns.children[parentID][name]})
+		next = name
+	}
+	return
+}
+
+func (ns *memNamespace) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	if _, ok := ns.children[parentID][name]; ok {
+		return nil, syscall.EEXIST
+	}
+	ns.nextIno++
+	info := &proto.InodeInfo{Inode: ns.nextIno, Mode: mode, Nlink: 1, Uid: uid, Gid: gid, Target: target,
+		ModifyTime: time.Now(), AccessTime: time.Now()}
+	if proto.IsDir(mode) {
+		info.Nlink = 2
+		ns.children[info.Inode] = make(map[string]uint64)
+	}
+	ns.inodes[info.Inode] = info
+	ns.children[parentID][name] = info.Inode
+	ns.inodes[parentID].ModifyTime = time.Now()
+	return info, nil
+}
+
+func (ns *memNamespace) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	ino, ok := ns.children[parentID][name]
+	if !ok {
+		return 0, 0, syscall.ENOENT
+	}
+	return ino, ns.inodes[ino].Mode, nil
+}
+
+func (ns *memNamespace) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
+	if _, ok := ns.children[parentID][name]; ok {
+		return nil, syscall.EEXIST
+	}
+	ns.children[parentID][name] = ino
+	ns.inodes[ino].Nlink++
+	return ns.inodes[ino], nil
+}
+
+func (ns *memNamespace) Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
+	if valid&proto.AttrAccessTime != 0 {
+		ns.inodes[inode].AccessTime = time.Unix(atime, 0)
+	}
+	if valid&proto.AttrModifyTime != 0 {
+		ns.inodes[inode].ModifyTime = time.Unix(mtime, 0)
+	}
+	return nil
+}
+
+func (ns *memNamespace) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
+	ns.xattrs[inode] = attrs
+	return nil
+}
+
+// walk returns the paths of the subtree at ino with the inode infos
+func (ns *memNamespace) walk(ino uint64, prefix string, paths map[string]*proto.InodeInfo) {
+	for name, child := range ns.children[ino] {
+		paths[prefix+"/"+name] = ns.inodes[child]
+		ns.walk(child, prefix+"/"+name, paths)
+	}
+}
+
+func TestNamespaceExportImport(t *testing.T) {
+	src := newMemNamespace(proto.RootIno, 100)
+	mtime := time.Unix(1600000000, 0)
+	create := func(parent uint64, name string, mode os.FileMode, target []byte) uint64 {
+		info, err := src.Create_ll(parent, name, proto.Mode(mode), 1000, 1000, target, "")
+		require.NoError(t, err)
+		info.Size = uint64(len(name))
+		return info.Inode
+	}
+	dir := create(proto.RootIno, "dir", os.ModeDir|0o750, nil)
+	for i := 0; i < 2*namespaceReadDirLimit+3; i++ {
+		create(dir, fmt.Sprintf("file%05d", i), 0o644, nil)
+	}
+	sub := create(dir, "sub", os.ModeDir|0o700, nil)
+	file := create(sub, "data", 0o600, nil)
+	src.xattrs[file] = map[string]string{"user.k": "v"}
+	_, err := src.Link(proto.RootIno, "hardlink", file, "")
+	require.NoError(t, err)
+	create(proto.RootIno, "symlink", os.ModeSymlink|0o777, []byte("dir/sub/data"))
+	for _, info := range src.inodes {
+		info.ModifyTime = mtime
+	}
+
+	buf := new(bytes.Buffer)
+	count, err := exportNamespace(src, proto.RootIno, buf)
+	require.NoError(t, err)
+	// the root, the dentries and the second link
+	require.Equal(t, len(src.inodes)+1, count)
+
+	dst := newMemNamespace(proto.RootIno, 5000)
+	target, err := dst.Create_ll(proto.RootIno, "import", proto.Mode(os.ModeDir|0o755), 0, 0, nil, "")
+	require.NoError(t, err)
+	created := make(map[uint64]uint64)
+	onImport := func(rec *proto.NamespaceRecord, ino uint64, existed bool) error {
+		if !existed {
+			created[rec.Ino] = ino
+		}
+		return nil
+	}
+	// the import failed halfway is resumed by importing the stream again
+	half := bytes.SplitAfterN(buf.Bytes(), []byte("\n"), count/2+1)
+	imported, skipped, err := importNamespace(dst, bytes.NewReader(bytes.Join(half[:count/2], nil)), target.Inode, onImport)
+	require.NoError(t, err)
+	require.Equal(t, count/2-1, imported)
+	require.Equal(t, 0, skipped)
+	resumed, skipped, err := importNamespace(dst, bytes.NewReader(buf.Bytes()), target.Inode, onImport)
+	require.NoError(t, err)
+	require.Equal(t, count/2-1, skipped)
+	require.Equal(t, count-1, imported+resumed)
+	require.Len(t, created, len(src.inodes)-1)
+
+	srcPaths := make(map[string]*proto.InodeInfo)
+	src.walk(proto.RootIno, "", srcPaths)
+	dstPaths := make(map[string]*proto.InodeInfo)
+	dst.walk(target.Inode, "", dstPaths)
+	require.Equal(t, len(srcPaths), len(dstPaths))
+	for path, srcInfo := range srcPaths {
+		dstInfo, ok := dstPaths[path]
+		require.True(t, ok, path)
+		require.Equal(t, created[srcInfo.Inode], dstInfo.Inode, path)
+		require.Equal(t, srcInfo.Mode, dstInfo.Mode, path)
+		require.Equal(t, srcInfo.Uid, dstInfo.Uid, path)
+		require.Equal(t, srcInfo.Target, dstInfo.Target, path)
+		require.Equal(t, mtime.Unix(), dstInfo.ModifyTime.Unix(), path)
+	}
+	require.Equal(t, dstPaths["/hardlink"].Inode, dstPaths["/dir/sub/data"].Inode)
+	require.Equal(t, uint32(2), dstPaths["/hardlink"].Nlink)
+	require.Equal(t, "v", dst.xattrs[dstPaths["/hardlink"].Inode]["user.k"])
+
+	// all the dentries exist
+	imported, skipped, err = importNamespace(dst, bytes.NewReader(buf.Bytes()), target.Inode, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, imported)
+	require.Equal(t, count-1, skipped)
+
+	// the existing dentries of the other types or inodes are not skipped
+	_, _, err = importNamespace(dst, strings.NewReader(`{"ino":1,"mode":2147484141}
+{"ino":7,"pino":1,"name":"symlink","mode":2147484141}`), target.Inode, nil)
+	require.Equal(t, syscall.EEXIST, err)
+	_, _, err = importNamespace(dst, strings.NewReader(`{"ino":1,"mode":2147484141}
+{"ino":7,"pino":1,"name":"symlink","mode":420,"nlink":2}
+{"ino":7,"pino":1,"name":"hardlink","mode":420,"nlink":2}`), target.Inode, nil)
+	require.Equal(t, syscall.EEXIST, err)
+
+	// the records of the stream must follow their parents
+	_, _, err = importNamespace(dst, strings.NewReader(`{"ino":7,"pino":3,"name":"x","mode":420}`), target.Inode, nil)
+	require.Equal(t, syscall.EINVAL, err)
+}
@@ -346,6 +346,7 @@ func (mw *MetaWrapper) iunlink(mp *MetaPartition, inode uint64, verSeq uint64, d
 		DenVerSeq:   denVerSeq,
 	}
 	req.FullPaths = []string{fullPath}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaUnlinkInode
@@ -974,7 +975,15 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string, v
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
 	}()
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	observe := mw.beginHedgeRead(mp)
+	packet, err = mw.sendHedgedToMetaPartition(mp, packet, func(appliedID uint64) (*proto.Packet, error) {
+		hedgeReq := *req
+		hedgeReq.HedgeAppliedID = appliedID
+		hedgePacket := proto.NewPacketReqID()
+		hedgePacket.Opcode = proto.OpMetaLookup
+		hedgePacket.PartitionID = mp.PartitionID
+		return hedgePacket, hedgePacket.MarshalData(&hedgeReq)
+	})
 	if err != nil {
 		log.LogErrorf("lookup: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		errMetric := exporter.NewCounter("fileOpenFailed")
@@ -1003,11 +1012,22 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string, v
 		errMetric.AddWithLabels(1, map[string]string{exporter.Vol: mw.volname, exporter.Err: "EIO"})
 		return
 	}
+	observe(resp.AppliedID)
 	log.LogDebugf("lookup exit: packet(%v) mp(%v) req(%v) ino(%v) mode(%v)", packet, mp, *req, resp.Inode, resp.Mode)
 	return statusOK, resp.Inode, resp.Mode, nil
 }
 
 func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64, verSeq uint64) (status int, info *proto.InodeInfo, err error) {
+	return mw.igetWithEpochCheck(mp, inode, verSeq, true)
+}
+
+// igetLatest gets the inode of the id regardless of the epoch held by the handles, it is used when
+// the id is taken from a dentry, which always refers to the latest inode.
+func (mw *MetaWrapper) igetLatest(mp *MetaPartition, inode uint64, verSeq uint64) (status int, info *proto.InodeInfo, err error) {
+	return mw.igetWithEpochCheck(mp, inode, verSeq, false)
+}
+
+func (mw *MetaWrapper) igetWithEpochCheck(mp *MetaPartition, inode uint64, verSeq uint64, checkEpoch bool) (status int, info *proto.InodeInfo, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("iget", err, bgTime, 1)
@@ -1019,6 +1039,9 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64, verSeq uint64) (sta
 		Inode:       inode,
 		VerSeq:      verSeq,
 	}
+	if checkEpoch {
+		mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
+	}
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaInodeGet
@@ -1037,7 +1060,15 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64, verSeq uint64) (sta
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
 	}()
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	observe := mw.beginHedgeRead(mp)
+	packet, err = mw.sendHedgedToMetaPartition(mp, packet, func(appliedID uint64) (*proto.Packet, error) {
+		hedgeReq := *req
+		hedgeReq.HedgeAppliedID = appliedID
+		hedgePacket := proto.NewPacketReqID()
+		hedgePacket.Opcode = proto.OpMetaInodeGet
+		hedgePacket.PartitionID = mp.PartitionID
+		return hedgePacket, hedgePacket.MarshalData(&hedgeReq)
+	})
 	if err != nil {
 		log.LogErrorf("iget: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -1055,6 +1086,7 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64, verSeq uint64) (sta
 		log.LogErrorf("iget: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
 		return
 	}
+	observe(resp.AppliedID)
 	return statusOK, resp.Info, nil
 }
 
@@ -1178,6 +1210,7 @@ func (mw *MetaWrapper) readDirLimit(mp *MetaPartition, parentID uint64, from str
 		Limit:       limit,
 		VerSeq:      verSeq,
 		VerOpt:      verOpt,
+		Encoding:    mw.readDirEncoding,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -1213,8 +1246,155 @@ func (mw *MetaWrapper) readDirLimit(mp *MetaPartition, parentID uint64, from str
 		log.LogErrorf("readDirLimit: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
 		return
 	}
-	log.LogDebugf("readDirLimit: packet(%v) mp(%v) req(%v) rsp(%v)", packet, mp, *req, resp.Children)
-	return statusOK, resp.Children, nil
+	if children, err = resp.GetChildren(); err != nil {
+		log.LogErrorf("readDirLimit: packet(%v) mp(%v) encoding(%v) err(%v)", packet, mp, resp.Encoding, err)
+		return
+	}
+	log.LogDebugf("readDirLimit: packet(%v) mp(%v) req(%v) rsp(%v)", packet, mp, *req, children)
+	return statusOK, children, nil
+}
+
+// read dentries in stream, continue from the cursor returned by the last batch, or start after the marker
+func (mw *MetaWrapper) readDirStream(mp *MetaPartition, parentID uint64, cursor, marker string, limit uint64, verSeq uint64) (status int, resp *proto.ReadDirStreamResponse, err error) {
+	req := &proto.ReadDirStreamRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		ParentID:    parentID,
+		Cursor:      cursor,
+		Marker:      marker,
+		Limit:       limit,
+		VerSeq:      verSeq,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadDirStream
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readDirStream: req(%v) err(%v)", *req, err)
+		return
+	}
+	log.LogDebugf("action[readDirStream] mp [%v] parentId %v", mp.PartitionID, parentID)
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readDirStream: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("readDirStream: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadDirStreamResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readDirStream: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readDirStream: packet(%v) mp(%v) req(%v) count(%v) more(%v)", packet, mp, *req, len(resp.Children), resp.HasMore)
+	return statusOK, resp, nil
+}
+
+func (mw *MetaWrapper) readDirChanges(mp *MetaPartition, parentID uint64, from uint64, limit uint64) (status int, resp *proto.ReadDirChangesResponse, err error) {
+	req := &proto.ReadDirChangesRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		ParentID:    parentID,
+		From:        from,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadDirChanges
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readDirChanges: req(%v) err(%v)", *req, err)
+		return
+	}
+	log.LogDebugf("action[readDirChanges] mp [%v] parentId %v from %v", mp.PartitionID, parentID, from)
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readDirChanges: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("readDirChanges: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadDirChangesResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readDirChanges: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readDirChanges: packet(%v) mp(%v) req(%v) count(%v) next(%v) truncated(%v)", packet, mp, *req, len(resp.Events), resp.Next, resp.Truncated)
+	return statusOK, resp, nil
+}
+
+// read the dentries selected by the filter, at most limit dentries after the marker are scanned
+func (mw *MetaWrapper) readDirFiltered(mp *MetaPartition, parentID uint64, marker string, limit uint64, filter *proto.DirEntryFilter, sortBy uint8) (status int, resp *proto.ReadDirFilteredResponse, err error) {
+	req := &proto.ReadDirFilteredRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		ParentID:    parentID,
+		Marker:      marker,
+		Limit:       limit,
+		VerSeq:      mw.VerReadSeq,
+		Filter:      *filter,
+		SortBy:      sortBy,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaReadDirFiltered
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("readDirFiltered: req(%v) err(%v)", *req, err)
+		return
+	}
+	log.LogDebugf("action[readDirFiltered] mp [%v] parentId %v marker %v", mp.PartitionID, parentID, marker)
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("readDirFiltered: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("readDirFiltered: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.ReadDirFilteredResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("readDirFiltered: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("readDirFiltered: packet(%v) mp(%v) req(%v) scanned(%v) matched(%v) unresolved(%v) next(%v)",
+		packet, mp, *req, resp.Scanned, len(resp.Entries), len(resp.Unresolved), resp.Next)
+	return statusOK, resp, nil
 }
 
 func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent proto.ExtentKey, discard []proto.ExtentKey, isSplit bool) (status int, err error) {
@@ -1231,6 +1411,7 @@ func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent p
 		DiscardExtents: discard,
 		IsSplit:        isSplit,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaExtentAddWithCheck
@@ -1274,6 +1455,7 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (resp *proto.
 		Inode:       inode,
 		VerSeq:      mw.VerReadSeq,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaExtentsList
@@ -1408,6 +1590,7 @@ func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64, fullPath
 		Size:        size,
 	}
 	req.FullPaths = []string{fullPath}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaTruncate
@@ -1560,6 +1743,7 @@ func (mw *MetaWrapper) setattr(mp *MetaPartition, inode uint64, valid, mode, uid
 		AccessTime:  atime,
 		ModifyTime:  mtime,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaSetattr
@@ -1994,6 +2178,7 @@ func (mw *MetaWrapper) batchSetXAttr(mp *MetaPartition, inode uint64, attrs map[
 		Inode:       inode,
 		Attrs:       make(map[string]string),
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	for key, val := range attrs {
 		req.Attrs[key] = val
@@ -2045,6 +2230,7 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 		Key:         string(name),
 		Value:       string(value),
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaSetXAttr
@@ -2090,6 +2276,7 @@ func (mw *MetaWrapper) getAllXAttr(mp *MetaPartition, inode uint64) (attrs map[s
 		PartitionId: mp.PartitionID,
 		Inode:       inode,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaGetAllXAttr
@@ -2143,6 +2330,7 @@ func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (v
 		Key:         name,
 		VerSeq:      mw.VerReadSeq,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaGetXAttr
@@ -2195,6 +2383,7 @@ func (mw *MetaWrapper) removeXAttr(mp *MetaPartition, inode uint64, name string)
 		Inode:       inode,
 		Key:         name,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaRemoveXAttr
@@ -2238,6 +2427,7 @@ func (mw *MetaWrapper) listXAttr(mp *MetaPartition, inode uint64) (keys []string
 		Inode:       inode,
 		VerSeq:      mw.VerReadSeq,
 	}
+	mw.setInodeEpochCheck(inode, &req.InodeEpochCheck)
 
 	packet := proto.NewPacketReqID()
 	packet.Opcode = proto.OpMetaListXAttr
@@ -36,6 +36,8 @@ var (
 	ForbidWriteError           = errors.New("single replica decommission forbid write")
 	VerNotConsistentError      = errors.New("ver not consistent")
 	SnapshotNeedNewExtentError = errors.New("snapshot need new extent error")
+	BlockCrcChangedError       = errors.New("block crc has been changed")
+	BlockCorruptError          = errors.New("block data mismatches the block crc")
 )
 
 func newParameterError(format string, a ...interface{}) error {
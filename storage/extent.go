@@ -54,6 +54,8 @@ type ExtentInfo struct {
 	SnapshotDataOff     uint64 `json:"snapSize"`
 	SnapPreAllocDataOff uint64 `json:"snapPreAllocSize"`
 	ApplyID             uint64 `json:"applyID"`
+	// the encryption envelope of the extent, only attached to the watermarks of the repair
+	Envelope *ExtentEnvelope `json:"envelope,omitempty"`
 }
 
 func (ei *ExtentInfo) TotalSize() uint64 {
@@ -149,7 +151,16 @@ func (e *Extent) GetFile() *os.File {
 // InitToFS init extent data info filesystem. If entry file exist and overwrite is true,
 // this operation will clear all data of exist entry file and initialize extent header data.
 func (e *Extent) InitToFS() (err error) {
-	if e.file, err = os.OpenFile(e.filePath, ExtentOpenOpt, 0o666); err != nil {
+	return e.initToFS(ExtentOpenOpt)
+}
+
+// InitSpareToFS initializes the extent on the spare file linked to the extent path.
+func (e *Extent) InitSpareToFS() (err error) {
+	return e.initToFS(os.O_RDWR)
+}
+
+func (e *Extent) initToFS(flag int) (err error) {
+	if e.file, err = os.OpenFile(e.filePath, flag, 0o666); err != nil {
 		return err
 	}
 
@@ -398,6 +409,55 @@ func (e *Extent) Read(data []byte, offset, size int64, isRepairRead bool) (crc u
 	return
 }
 
+// ReadVerified reads data from a normal extent, and verifies the blocks read against the crcs
+// persisted on write. The blocks partially read are read entirely to be verified, and the
+// blocks without crc are not verified.
+func (e *Extent) ReadVerified(data []byte, offset, size int64) (crc uint32, corruptBlockNo int, err error) {
+	corruptBlockNo = -1
+	if err = e.checkReadOffsetAndSize(offset, size); err != nil {
+		log.LogErrorf("action[Extent.ReadVerified] offset %d size %d err %v", offset, size, err)
+		return
+	}
+
+	// the lock keeps the block crcs consistent with the data read
+	e.Lock()
+	defer e.Unlock()
+	var rSize int
+	if rSize, err = e.file.ReadAt(data[:size], offset); err != nil {
+		log.LogErrorf("action[Extent.ReadVerified] offset %v size %v err %v realsize %v", offset, size, err, rSize)
+		return
+	}
+	var block []byte
+	for blockNo := int(offset / util.BlockSize); int64(blockNo)*util.BlockSize < offset+size; blockNo++ {
+		blockCrc, blockSize := e.GetCrc(int64(blockNo)), e.blockDataSize(blockNo)
+		if blockCrc == 0 || blockSize == 0 {
+			continue
+		}
+		blockOffset := int64(blockNo) * util.BlockSize
+		var blockData []byte
+		if blockOffset >= offset && blockOffset+blockSize <= offset+size {
+			blockData = data[blockOffset-offset : blockOffset-offset+blockSize]
+		} else {
+			if block == nil {
+				block = make([]byte, util.BlockSize)
+			}
+			var readN int
+			if readN, err = e.file.ReadAt(block[:blockSize], blockOffset); err != nil && !(err == io.EOF && int64(readN) == blockSize) {
+				return
+			}
+			err = nil
+			blockData = block[:blockSize]
+		}
+		if crc32.ChecksumIEEE(blockData) != blockCrc {
+			corruptBlockNo = blockNo
+			err = BlockCorruptError
+			return
+		}
+	}
+	crc = crc32.ChecksumIEEE(data[:size])
+	return
+}
+
 // ReadTiny read data from a tiny extent.
 func (e *Extent) ReadTiny(data []byte, offset, size int64, isRepairRead bool) (crc uint32, err error) {
 	_, err = e.file.ReadAt(data[:size], offset)
@@ -445,6 +505,52 @@ func (e *Extent) GetCrc(blockNo int64) uint32 {
 	return binary.BigEndian.Uint32(e.header[blockNo*util.PerBlockCrcSize : (blockNo+1)*util.PerBlockCrcSize])
 }
 
+// blockDataSize returns the size of the data in the block, only the blocks in front of the snapshot data are counted.
+func (e *Extent) blockDataSize(blockNo int) int64 {
+	offset := int64(blockNo) * util.BlockSize
+	if offset >= e.dataSize {
+		return 0
+	}
+	return int64(math.Min(float64(util.BlockSize), float64(e.dataSize-offset)))
+}
+
+// VerifyBlock reads the block and checks the data against the crc persisted on write,
+// the blocks without crc are skipped and reported as healthy.
+func (e *Extent) VerifyBlock(blockNo int, data []byte) (crc uint32, size int64, corrupt bool, err error) {
+	e.Lock()
+	defer e.Unlock()
+	if crc = e.GetCrc(int64(blockNo)); crc == 0 {
+		return
+	}
+	if size = e.blockDataSize(blockNo); size == 0 {
+		return
+	}
+	var readN int
+	if readN, err = e.file.ReadAt(data[:size], int64(blockNo)*util.BlockSize); err != nil && !(err == io.EOF && int64(readN) == size) {
+		return
+	}
+	err = nil
+	corrupt = crc32.ChecksumIEEE(data[:size]) != crc
+	return
+}
+
+// RepairBlock overwrites the block in place with the data fetched from a healthy replica.
+// The repair is refused if the block has been rewritten since it was verified.
+func (e *Extent) RepairBlock(blockNo int, data []byte, crc uint32) (err error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.GetCrc(int64(blockNo)) != crc {
+		return BlockCrcChangedError
+	}
+	if int64(len(data)) != e.blockDataSize(blockNo) || crc32.ChecksumIEEE(data) != crc {
+		return CrcMismatchError
+	}
+	if _, err = e.file.WriteAt(data, int64(blockNo)*util.BlockSize); err != nil {
+		return
+	}
+	return e.file.Sync()
+}
+
 func (e *Extent) autoComputeExtentCrc(crcFunc UpdateCrcFunc) (crc uint32, err error) {
 	var blockCnt int
 	extSize := e.Size()
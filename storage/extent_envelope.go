@@ -0,0 +1,344 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ExtEnvelopeFileName = "EXTENT_ENVELOPE"
+)
+
+// ExtentEnvelope is the encryption metadata of the data stored in an extent.
+// The data is encrypted by the client or gateway, datanode only keeps the envelope
+// for tracking, key rotation and audit, and never decrypts the data.
+type ExtentEnvelope struct {
+	ExtentID   uint64 `json:"extentID"`
+	Algorithm  string `json:"algorithm"`
+	KeyID      string `json:"keyID"`
+	KeyVersion uint32 `json:"keyVersion"`
+	IV         []byte `json:"iv"`
+	CreateTime int64  `json:"createTime"`
+	UpdateTime int64  `json:"updateTime"`
+}
+
+// the envelope journal is compacted once the records outnumber the envelopes by far
+const envelopeCompactRecords = 1024
+
+const (
+	envelopeOpSet = "set"
+	envelopeOpDel = "del"
+)
+
+// envelopeRecord is a line of the envelope journal, a change of the envelopes is appended
+// to the journal instead of rewriting all the envelopes.
+type envelopeRecord struct {
+	Op       string          `json:"op"`
+	ExtentID uint64          `json:"extentID"`
+	Envelope *ExtentEnvelope `json:"envelope,omitempty"`
+}
+
+type extentEnvelopes struct {
+	sync.RWMutex
+	filePath  string
+	fp        *os.File
+	records   int
+	envelopes map[uint64]*ExtentEnvelope
+}
+
+func newExtentEnvelopes(dataPath string) (ee *extentEnvelopes, err error) {
+	ee = &extentEnvelopes{
+		filePath:  path.Join(dataPath, ExtEnvelopeFileName),
+		envelopes: make(map[uint64]*ExtentEnvelope),
+	}
+	if err = ee.load(); err != nil {
+		return nil, err
+	}
+	if ee.fp, err = os.OpenFile(ee.filePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o666); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// load replays the envelope journal, the torn record of a crash in the middle of appending is truncated.
+func (ee *extentEnvelopes) load() (err error) {
+	data, err := os.ReadFile(ee.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	var offset int
+	for offset < len(data) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		record := new(envelopeRecord)
+		if err = json.Unmarshal(data[offset:offset+end], record); err != nil {
+			return fmt.Errorf("load extent envelopes from %v at offset %v: %v", ee.filePath, offset, err)
+		}
+		ee.apply(record)
+		ee.records++
+		offset += end + 1
+	}
+	if offset < len(data) {
+		log.LogWarnf("action[loadExtentEnvelopes] truncate torn record of %v at offset %v size %v", ee.filePath, offset, len(data))
+		return os.Truncate(ee.filePath, int64(offset))
+	}
+	return
+}
+
+func (ee *extentEnvelopes) apply(record *envelopeRecord) {
+	switch record.Op {
+	case envelopeOpSet:
+		ee.envelopes[record.ExtentID] = record.Envelope
+	case envelopeOpDel:
+		delete(ee.envelopes, record.ExtentID)
+	}
+}
+
+// append persists the record to the journal before it is applied to the envelopes in memory,
+// so the envelopes are left unchanged if the disk fails.
+func (ee *extentEnvelopes) append(record *envelopeRecord) (err error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if _, err = ee.fp.Write(append(data, '\n')); err != nil {
+		return
+	}
+	if err = ee.fp.Sync(); err != nil {
+		return
+	}
+	ee.apply(record)
+	ee.records++
+	if ee.records > envelopeCompactRecords && ee.records > 2*len(ee.envelopes) {
+		if err := ee.compact(); err != nil {
+			log.LogWarnf("action[compactExtentEnvelopes] compact %v failed: %v", ee.filePath, err)
+		}
+	}
+	return
+}
+
+// compact rewrites the journal with a record of each envelope.
+func (ee *extentEnvelopes) compact() (err error) {
+	extentIDs := make([]uint64, 0, len(ee.envelopes))
+	for extentID := range ee.envelopes {
+		extentIDs = append(extentIDs, extentID)
+	}
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	buf := bytes.NewBuffer(nil)
+	for _, extentID := range extentIDs {
+		data, err := json.Marshal(&envelopeRecord{Op: envelopeOpSet, ExtentID: extentID, Envelope: ee.envelopes[extentID]})
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	tmpFile := ee.filePath + ".tmp"
+	fp, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o666)
+	if err != nil {
+		return
+	}
+	if _, err = fp.Write(buf.Bytes()); err != nil {
+		fp.Close()
+		return
+	}
+	if err = fp.Sync(); err != nil {
+		fp.Close()
+		return
+	}
+	fp.Close()
+	if err = os.Rename(tmpFile, ee.filePath); err != nil {
+		return
+	}
+	newFp, err := os.OpenFile(ee.filePath, os.O_RDWR|os.O_APPEND, 0o666)
+	if err != nil {
+		return
+	}
+	ee.fp.Close()
+	ee.fp = newFp
+	ee.records = len(extentIDs)
+	return
+}
+
+func (ee *extentEnvelopes) close() {
+	ee.Lock()
+	defer ee.Unlock()
+	ee.fp.Sync()
+	ee.fp.Close()
+}
+
+func checkExtentEnvelope(env *ExtentEnvelope) (err error) {
+	if IsTinyExtent(env.ExtentID) {
+		return fmt.Errorf("tiny extent[%v] is shared by files, envelope is not supported", env.ExtentID)
+	}
+	if env.KeyID == "" {
+		return fmt.Errorf("key id of extent[%v] is empty", env.ExtentID)
+	}
+	return
+}
+
+// CheckExtentEnvelope checks the envelope before it is replicated to the replicas of the partition.
+func (s *ExtentStore) CheckExtentEnvelope(env *ExtentEnvelope) (err error) {
+	if err = checkExtentEnvelope(env); err != nil {
+		return
+	}
+	if !s.HasExtent(env.ExtentID) {
+		return ExtentNotFoundError
+	}
+	return
+}
+
+// SetExtentEnvelope stores the encryption envelope of a normal extent, the key id and version
+// are replaced when the key of the extent is rotated. It is applied by every replica of the
+// partition, the extent is checked by the leader only, since it may be not repaired to the
+// replica yet.
+func (s *ExtentStore) SetExtentEnvelope(env *ExtentEnvelope) (err error) {
+	if err = checkExtentEnvelope(env); err != nil {
+		return
+	}
+
+	s.envelopes.Lock()
+	defer s.envelopes.Unlock()
+	newEnv := *env
+	if newEnv.UpdateTime == 0 {
+		newEnv.UpdateTime = time.Now().Unix()
+	}
+	newEnv.CreateTime = newEnv.UpdateTime
+	old, ok := s.envelopes.envelopes[env.ExtentID]
+	if ok {
+		newEnv.CreateTime = old.CreateTime
+	}
+	if err = s.envelopes.append(&envelopeRecord{Op: envelopeOpSet, ExtentID: env.ExtentID, Envelope: &newEnv}); err != nil {
+		log.LogErrorf("action[SetExtentEnvelope] partition(%v) extent(%v) persist err(%v)", s.partitionID, env.ExtentID, err)
+		return BrokenDiskError
+	}
+	if ok {
+		log.LogWarnf("action[SetExtentEnvelope] partition(%v) extent(%v) key rotated from (%v:%v) to (%v:%v)",
+			s.partitionID, env.ExtentID, old.KeyID, old.KeyVersion, newEnv.KeyID, newEnv.KeyVersion)
+	} else {
+		log.LogInfof("action[SetExtentEnvelope] partition(%v) extent(%v) key(%v:%v) algorithm(%v)",
+			s.partitionID, env.ExtentID, newEnv.KeyID, newEnv.KeyVersion, newEnv.Algorithm)
+	}
+	return
+}
+
+// GetExtentEnvelope returns the encryption envelope of the extent.
+func (s *ExtentStore) GetExtentEnvelope(extentID uint64) (env *ExtentEnvelope, err error) {
+	s.envelopes.RLock()
+	defer s.envelopes.RUnlock()
+	e, ok := s.envelopes.envelopes[extentID]
+	if !ok {
+		return nil, ExtentNotFoundError
+	}
+	env = new(ExtentEnvelope)
+	*env = *e
+	return
+}
+
+// GetAllExtentEnvelopes returns the encryption envelopes of all the extents, which are filtered by the key id if given.
+func (s *ExtentStore) GetAllExtentEnvelopes(keyID string) (envelopes []*ExtentEnvelope) {
+	s.envelopes.RLock()
+	defer s.envelopes.RUnlock()
+	envelopes = make([]*ExtentEnvelope, 0, len(s.envelopes.envelopes))
+	for _, e := range s.envelopes.envelopes {
+		if keyID != "" && e.KeyID != keyID {
+			continue
+		}
+		env := *e
+		envelopes = append(envelopes, &env)
+	}
+	sort.Slice(envelopes, func(i, j int) bool { return envelopes[i].ExtentID < envelopes[j].ExtentID })
+	return
+}
+
+// NewerThan returns true if the envelope is set after the other one of the same extent, the
+// update time is given by the raft leader, so it is the same on all the replicas.
+func (env *ExtentEnvelope) NewerThan(other *ExtentEnvelope) bool {
+	if other == nil {
+		return true
+	}
+	if env.UpdateTime != other.UpdateTime {
+		return env.UpdateTime > other.UpdateTime
+	}
+	return env.KeyVersion > other.KeyVersion
+}
+
+// AttachExtentEnvelopes returns the copies of the extent infos with their envelopes attached,
+// so the envelopes are compared among the replicas and repaired along with the extents.
+func (s *ExtentStore) AttachExtentEnvelopes(extents []*ExtentInfo) (infos []*ExtentInfo) {
+	s.envelopes.RLock()
+	defer s.envelopes.RUnlock()
+	infos = make([]*ExtentInfo, 0, len(extents))
+	for _, ei := range extents {
+		info := *ei
+		if env, ok := s.envelopes.envelopes[ei.FileID]; ok {
+			copied := *env
+			info.Envelope = &copied
+		}
+		infos = append(infos, &info)
+	}
+	return
+}
+
+// RepairExtentEnvelope stores the envelope of the extent repaired from the other replica as it is,
+// unless the local one is newer, which has been applied by raft after the repair task is built.
+func (s *ExtentStore) RepairExtentEnvelope(env *ExtentEnvelope) (err error) {
+	if err = checkExtentEnvelope(env); err != nil {
+		return
+	}
+	if !s.HasExtent(env.ExtentID) {
+		return ExtentNotFoundError
+	}
+
+	s.envelopes.Lock()
+	defer s.envelopes.Unlock()
+	old := s.envelopes.envelopes[env.ExtentID]
+	if !env.NewerThan(old) {
+		return
+	}
+	newEnv := *env
+	if err = s.envelopes.append(&envelopeRecord{Op: envelopeOpSet, ExtentID: env.ExtentID, Envelope: &newEnv}); err != nil {
+		log.LogErrorf("action[RepairExtentEnvelope] partition(%v) extent(%v) persist err(%v)", s.partitionID, env.ExtentID, err)
+		return BrokenDiskError
+	}
+	log.LogInfof("action[RepairExtentEnvelope] partition(%v) extent(%v) key(%v:%v) updateTime(%v)",
+		s.partitionID, env.ExtentID, newEnv.KeyID, newEnv.KeyVersion, newEnv.UpdateTime)
+	return
+}
+
+func (s *ExtentStore) deleteExtentEnvelope(extentID uint64) (err error) {
+	s.envelopes.Lock()
+	defer s.envelopes.Unlock()
+	if _, ok := s.envelopes.envelopes[extentID]; !ok {
+		return
+	}
+	return s.envelopes.append(&envelopeRecord{Op: envelopeOpDel, ExtentID: extentID})
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	SpareExtentPrefix = ".spare_"
+	// the spares cover the extent creations predicted in the lead time
+	spareExtentLeadSec = 30
+	// weight of the latest interval in the predicted rates
+	spareExtentRateWeight = 0.3
+	// the spares are pre-allocated in units of spareExtentAllocUnit
+	spareExtentAllocUnit = util.MB
+)
+
+type spareExtent struct {
+	path     string
+	prealloc int64
+}
+
+// spareExtents keeps the files created and pre-allocated in the background, a new
+// normal extent takes a spare file by link, so that the appends rarely pay the
+// latency of file creation and block allocation in the foreground.
+//
+// The number of spares follows the predicted creation rate of the extents, and the
+// spares are pre-allocated with the predicted bytes appended to an extent.
+type spareExtents struct {
+	dataPath string
+	maxCount int
+
+	createCnt   uint64 // normal extents created since the last refill
+	appendBytes uint64 // bytes appended to normal extents since the last refill
+
+	// updated by refill only
+	createRate     float64 // extents created per second
+	bytesPerExtent float64
+
+	mu    sync.Mutex
+	seq   uint64
+	files []*spareExtent
+}
+
+func newSpareExtents(dataPath string, maxCount int) *spareExtents {
+	return &spareExtents{dataPath: dataPath, maxCount: maxCount}
+}
+
+// EnableSpareExtents enables at most maxCount spare extents of the store, the
+// stale spares left by the last run are removed since their sizes are unknown.
+func (s *ExtentStore) EnableSpareExtents(maxCount int) {
+	if maxCount <= 0 {
+		return
+	}
+	if files, err := os.ReadDir(s.dataPath); err == nil {
+		for _, f := range files {
+			if strings.HasPrefix(f.Name(), SpareExtentPrefix) {
+				os.Remove(path.Join(s.dataPath, f.Name()))
+			}
+		}
+	}
+	s.spares = newSpareExtents(s.dataPath, maxCount)
+}
+
+// RefillSpareExtents updates the prediction by the extents created and the bytes
+// appended in the last interval, then creates or removes the spares to match it.
+func (s *ExtentStore) RefillSpareExtents(interval time.Duration) {
+	if s.spares == nil || interval <= 0 {
+		return
+	}
+	s.spares.refill(interval)
+}
+
+// SpareExtentCount returns the number of the spare extents ready to use.
+func (s *ExtentStore) SpareExtentCount() int {
+	if s.spares == nil {
+		return 0
+	}
+	return s.spares.count()
+}
+
+func (se *spareExtents) count() int {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	return len(se.files)
+}
+
+func (se *spareExtents) onCreate() {
+	atomic.AddUint64(&se.createCnt, 1)
+}
+
+func (se *spareExtents) onAppend(size int64) {
+	atomic.AddUint64(&se.appendBytes, uint64(size))
+}
+
+// take returns the path of the spare pre-allocated the most, or empty if no spare.
+func (se *spareExtents) take() string {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	if len(se.files) == 0 {
+		return ""
+	}
+	idx := 0
+	for i, f := range se.files {
+		if f.prealloc > se.files[idx].prealloc {
+			idx = i
+		}
+	}
+	spare := se.files[idx]
+	se.files = append(se.files[:idx], se.files[idx+1:]...)
+	return spare.path
+}
+
+// takeTo links a spare to the extent path, it never replaces an existing file.
+func (se *spareExtents) takeTo(name string) bool {
+	se.onCreate()
+	spare := se.take()
+	if spare == "" {
+		return false
+	}
+	defer os.Remove(spare)
+	if err := os.Link(spare, name); err != nil {
+		log.LogWarnf("[spareExtents] link spare %v to extent %v failed: %v", spare, name, err)
+		return false
+	}
+	return true
+}
+
+func ewma(old, latest float64) float64 {
+	return old*(1-spareExtentRateWeight) + latest*spareExtentRateWeight
+}
+
+// predict returns the number of the spares and the bytes pre-allocated to each.
+func (se *spareExtents) predict(created, appended uint64, interval time.Duration) (target int, prealloc int64) {
+	se.createRate = ewma(se.createRate, float64(created)/interval.Seconds())
+	if created > 0 {
+		se.bytesPerExtent = ewma(se.bytesPerExtent, float64(appended)/float64(created))
+	}
+
+	// keep no spare for the partitions rarely creating extents recently
+	if expected := se.createRate * spareExtentLeadSec; expected >= 0.5 {
+		target = int(math.Ceil(expected))
+	}
+	if target > se.maxCount {
+		target = se.maxCount
+	}
+	units := int64(math.Ceil(se.bytesPerExtent / spareExtentAllocUnit))
+	if prealloc = units * spareExtentAllocUnit; prealloc > util.ExtentSize {
+		prealloc = util.ExtentSize
+	}
+	return
+}
+
+func (se *spareExtents) refill(interval time.Duration) {
+	created := atomic.SwapUint64(&se.createCnt, 0)
+	appended := atomic.SwapUint64(&se.appendBytes, 0)
+	target, prealloc := se.predict(created, appended, interval)
+
+	se.mu.Lock()
+	count := len(se.files)
+	se.mu.Unlock()
+
+	// release the space of the redundant spares slowly, the rate may come back soon
+	if count > target {
+		if name := se.take(); name != "" {
+			os.Remove(name)
+		}
+		return
+	}
+	for ; count < target; count++ {
+		spare, err := se.create(prealloc)
+		if err != nil {
+			log.LogWarnf("[spareExtents] create spare extent of %v failed: %v", se.dataPath, err)
+			return
+		}
+		se.mu.Lock()
+		se.files = append(se.files, spare)
+		se.mu.Unlock()
+	}
+}
+
+func (se *spareExtents) create(prealloc int64) (spare *spareExtent, err error) {
+	name := path.Join(se.dataPath, fmt.Sprintf("%s%d", SpareExtentPrefix, atomic.AddUint64(&se.seq, 1)))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0o666)
+	if err != nil {
+		return
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(name)
+		}
+	}()
+	if prealloc > 0 {
+		// keep the size so that the extent still starts empty
+		if err = fallocate(int(f.Fd()), util.FallocFLKeepSize, 0, prealloc); err != nil {
+			return
+		}
+	}
+	return &spareExtent{path: name, prealloc: prealloc}, nil
+}
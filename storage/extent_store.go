@@ -139,6 +139,8 @@ type ExtentStore struct {
 	partitionType                     int
 	ApplyId                           uint64
 	ApplyIdMutex                      sync.RWMutex
+	envelopes                         *extentEnvelopes // encryption envelopes of extents
+	spares                            *spareExtents    // nil if the spare extents are disabled
 }
 
 func MkdirAll(name string) (err error) {
@@ -229,6 +231,10 @@ func NewExtentStore(dataDir string, partitionID uint64, storeSize, dpType int, i
 
 	s.extentInfoMap = make(map[uint64]*ExtentInfo)
 	s.cache = NewExtentCache(100)
+	if s.envelopes, err = newExtentEnvelopes(s.dataPath); err != nil {
+		err = fmt.Errorf("init extent envelopes: %v", err)
+		return
+	}
 	if err = s.initBaseFileID(); err != nil {
 		err = fmt.Errorf("init base field ID: %v", err)
 		return
@@ -311,7 +317,11 @@ func (s *ExtentStore) Create(extentID uint64) (err error) {
 
 	e = NewExtentInCore(name, extentID)
 	e.header = make([]byte, util.BlockHeaderSize)
-	err = e.InitToFS()
+	if s.spares != nil && !IsTinyExtent(extentID) && s.spares.takeTo(name) {
+		err = e.InitSpareToFS()
+	} else {
+		err = e.InitToFS()
+	}
 	if err != nil {
 		return err
 	}
@@ -403,6 +413,9 @@ func (s *ExtentStore) Write(extentID uint64, offset, size int64, data []byte, cr
 		log.LogInfof("action[Write] path %v err %v", e.filePath, err)
 		return status, err
 	}
+	if s.spares != nil && writeType == AppendWriteType && !IsTinyExtent(extentID) {
+		s.spares.onAppend(size)
+	}
 
 	ei.UpdateExtentInfo(e, 0)
 	return status, nil
@@ -438,27 +451,45 @@ func IsTinyExtent(extentID uint64) bool {
 // Read reads the extent based on the given id.
 func (s *ExtentStore) Read(extentID uint64, offset, size int64, nbuf []byte, isRepairRead bool) (crc uint32, err error) {
 	var e *Extent
+	if e, err = s.extentToRead(extentID); err != nil {
+		return
+	}
+
+	//if err = s.checkOffsetAndSize(extentID, offset, size); err != nil {
+	//	return
+	//}
+	crc, err = e.Read(nbuf, offset, size, isRepairRead)
+
+	return
+}
+
+// ReadVerified reads the data like Read, the blocks of the normal extents are verified against
+// the persisted block crcs, and the number of the corrupt block is returned with BlockCorruptError.
+func (s *ExtentStore) ReadVerified(extentID uint64, offset, size int64, nbuf []byte) (crc uint32, corruptBlockNo int, err error) {
+	if IsTinyExtent(extentID) || !proto.IsNormalDp(s.partitionType) {
+		crc, err = s.Read(extentID, offset, size, nbuf, false)
+		return crc, -1, err
+	}
+	var e *Extent
+	if e, err = s.extentToRead(extentID); err != nil {
+		return 0, -1, err
+	}
+	return e.ReadVerified(nbuf, offset, size)
+}
+
+func (s *ExtentStore) extentToRead(extentID uint64) (e *Extent, err error) {
 	s.eiMutex.RLock()
 	ei := s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
 
 	if ei == nil {
-		return 0, errors.Trace(ExtentHasBeenDeletedError, "[Read] extent[%d] is already been deleted", extentID)
+		return nil, errors.Trace(ExtentHasBeenDeletedError, "[Read] extent[%d] is already been deleted", extentID)
 	}
 
 	// update extent access time
 	atomic.StoreInt64(&ei.AccessTime, time.Now().Unix())
 
-	if e, err = s.extentWithHeader(ei); err != nil {
-		return
-	}
-
-	//if err = s.checkOffsetAndSize(extentID, offset, size); err != nil {
-	//	return
-	//}
-	crc, err = e.Read(nbuf, offset, size, isRepairRead)
-
-	return
+	return s.extentWithHeader(ei)
 }
 
 func (s *ExtentStore) DumpExtents() (extInfos SortedExtentInfos) {
@@ -517,6 +548,11 @@ func (s *ExtentStore) MarkDelete(extentID uint64, offset, size int64) (err error
 	extentFilePath := path.Join(s.dataPath, strconv.FormatUint(extentID, 10))
 	log.LogDebugf("action[MarkDelete] extentID %v offset %v size %v ei(size %v extentFilePath %v)",
 		extentID, offset, size, ei.Size, extentFilePath)
+	// the envelope is removed first, the extent is left intact if the disk fails
+	if err = s.deleteExtentEnvelope(extentID); err != nil {
+		err = BrokenDiskError
+		return
+	}
 	if err = os.Remove(extentFilePath); err != nil && !os.IsNotExist(err) {
 		// NOTE: if remove failed
 		// we meet a disk error
@@ -569,6 +605,7 @@ func (s *ExtentStore) Close() {
 	s.normalExtentDeleteFp.Close()
 	s.verifyExtentFp.Sync()
 	s.verifyExtentFp.Close()
+	s.envelopes.close()
 	for _, vFp := range s.verifyExtentFpAppend {
 		if vFp != nil {
 			vFp.Sync()
@@ -1076,6 +1113,34 @@ func (s *ExtentStore) ScanBlocks(extentID uint64) (bcs []*BlockCrc, err error) {
 	return
 }
 
+func (s *ExtentStore) normalExtentWithHeader(extentID uint64) (e *Extent, err error) {
+	if IsTinyExtent(extentID) || !proto.IsNormalDp(s.partitionType) {
+		return nil, ParameterMismatchError
+	}
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	return s.extentWithHeader(ei)
+}
+
+// VerifyBlock checks the data of the block of a normal extent against the persisted block crc.
+func (s *ExtentStore) VerifyBlock(extentID uint64, blockNo int, data []byte) (crc uint32, size int64, corrupt bool, err error) {
+	var e *Extent
+	if e, err = s.normalExtentWithHeader(extentID); err != nil {
+		return
+	}
+	return e.VerifyBlock(blockNo, data)
+}
+
+// RepairBlock rewrites the corrupt block of a normal extent with the healthy data.
+func (s *ExtentStore) RepairBlock(extentID uint64, blockNo int, data []byte, crc uint32) (err error) {
+	var e *Extent
+	if e, err = s.normalExtentWithHeader(extentID); err != nil {
+		return
+	}
+	return e.RepairBlock(blockNo, data, crc)
+}
+
 type ExtentInfoArr []*ExtentInfo
 
 func (arr ExtentInfoArr) Len() int           { return len(arr) }
@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/storage"
@@ -345,3 +346,250 @@ func TestExtentStores(t *testing.T) {
 		extentStoreTest(t, ty)
 	}
 }
+
+func TestExtentEnvelope(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+
+	// extent not exist, tiny extent and empty key are rejected
+	require.ErrorIs(t, s.CheckExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id + 1, KeyID: "k1"}), storage.ExtentNotFoundError)
+	require.Error(t, s.CheckExtentEnvelope(&storage.ExtentEnvelope{ExtentID: storage.TinyExtentStartID, KeyID: "k1"}))
+	require.Error(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: storage.TinyExtentStartID, KeyID: "k1"}))
+	require.Error(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id}))
+	require.NoError(t, s.CheckExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, KeyID: "k1"}))
+
+	iv := []byte("0123456789abcdef")
+	require.NoError(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, Algorithm: "AES-256-GCM", KeyID: "k1", KeyVersion: 1, IV: iv, UpdateTime: 100}))
+	// rotate the key
+	require.NoError(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, Algorithm: "AES-256-GCM", KeyID: "k2", KeyVersion: 2, IV: iv, UpdateTime: 200}))
+	require.Empty(t, s.GetAllExtentEnvelopes("k1"))
+	require.Len(t, s.GetAllExtentEnvelopes("k2"), 1)
+	// the journal is compacted after many rotations
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, Algorithm: "AES-256-GCM", KeyID: "k2", KeyVersion: 2, IV: iv, UpdateTime: 200}))
+	}
+	s.Close()
+	journal := filepath.Join(path, storage.ExtEnvelopeFileName)
+	data, err := os.ReadFile(journal)
+	require.NoError(t, err)
+	require.Less(t, bytes.Count(data, []byte("\n")), 2000)
+	// the torn record of a crash is truncated
+	fp, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND, 0o666)
+	require.NoError(t, err)
+	_, err = fp.WriteString(`{"op":"del","ext`)
+	require.NoError(t, err)
+	require.NoError(t, fp.Close())
+
+	// envelopes are loaded after reopen
+	s, err = storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, false)
+	require.NoError(t, err)
+	defer s.Close()
+	env, err := s.GetExtentEnvelope(id)
+	require.NoError(t, err)
+	require.Equal(t, "k2", env.KeyID)
+	require.EqualValues(t, 2, env.KeyVersion)
+	require.Equal(t, iv, env.IV)
+	require.EqualValues(t, 100, env.CreateTime)
+	require.EqualValues(t, 200, env.UpdateTime)
+
+	// envelope is removed with the extent
+	require.NoError(t, s.MarkDelete(id, 0, 0))
+	_, err = s.GetExtentEnvelope(id)
+	require.ErrorIs(t, err, storage.ExtentNotFoundError)
+	require.Empty(t, s.GetAllExtentEnvelopes(""))
+}
+
+func TestExtentEnvelopeRepair(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+	other, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(other))
+
+	// the envelope of the other replica is stored as it is
+	iv := []byte("0123456789abcdef")
+	require.ErrorIs(t, s.RepairExtentEnvelope(&storage.ExtentEnvelope{ExtentID: other + 1, KeyID: "k1"}), storage.ExtentNotFoundError)
+	require.NoError(t, s.RepairExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, KeyID: "k1", KeyVersion: 1, IV: iv, CreateTime: 100, UpdateTime: 200}))
+	env, err := s.GetExtentEnvelope(id)
+	require.NoError(t, err)
+	require.EqualValues(t, 100, env.CreateTime)
+	require.EqualValues(t, 200, env.UpdateTime)
+
+	// the envelope applied by raft after the repair task is built is kept
+	require.NoError(t, s.SetExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, KeyID: "k2", KeyVersion: 2, IV: iv, UpdateTime: 300}))
+	require.NoError(t, s.RepairExtentEnvelope(&storage.ExtentEnvelope{ExtentID: id, KeyID: "k1", KeyVersion: 1, IV: iv, CreateTime: 100, UpdateTime: 200}))
+	env, err = s.GetExtentEnvelope(id)
+	require.NoError(t, err)
+	require.Equal(t, "k2", env.KeyID)
+
+	// the envelopes are attached to the copies of the watermarks
+	extents, _, err := s.GetAllWatermarks(storage.NormalExtentFilter())
+	require.NoError(t, err)
+	infos := s.AttachExtentEnvelopes(extents)
+	require.Len(t, infos, len(extents))
+	for i, info := range infos {
+		require.Nil(t, extents[i].Envelope)
+		if info.FileID == id {
+			require.Equal(t, "k2", info.Envelope.KeyID)
+		} else {
+			require.Nil(t, info.Envelope)
+		}
+	}
+}
+
+func TestVerifyAndRepairBlock(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+	data := bytes.Repeat([]byte("test"), util.BlockSize/len("test"))
+	crc := crc32.ChecksumIEEE(data)
+
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+	_, err = s.Write(id, 0, int64(len(data)), data, crc, storage.AppendWriteType, true)
+	require.NoError(t, err)
+
+	buf := make([]byte, util.BlockSize)
+	blockCrc, size, corrupt, err := s.VerifyBlock(id, 0, buf)
+	require.NoError(t, err)
+	require.False(t, corrupt)
+	require.Equal(t, crc, blockCrc)
+	require.EqualValues(t, util.BlockSize, size)
+
+	// block out of data size
+	_, size, corrupt, err = s.VerifyBlock(id, 1, buf)
+	require.NoError(t, err)
+	require.False(t, corrupt)
+	require.EqualValues(t, 0, size)
+
+	// corrupt the data on disk
+	f, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%v", id)), os.O_RDWR, 0o666)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("xxxx"), 1024)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, _, corrupt, err = s.VerifyBlock(id, 0, buf)
+	require.NoError(t, err)
+	require.True(t, corrupt)
+
+	require.ErrorIs(t, s.RepairBlock(id, 0, data, crc+1), storage.BlockCrcChangedError)
+	require.ErrorIs(t, s.RepairBlock(id, 0, data[:len(data)-1], crc), storage.CrcMismatchError)
+	require.NoError(t, s.RepairBlock(id, 0, data, crc))
+	_, _, corrupt, err = s.VerifyBlock(id, 0, buf)
+	require.NoError(t, err)
+	require.False(t, corrupt)
+}
+
+func TestReadVerified(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+	data := bytes.Repeat([]byte("test"), util.BlockSize/len("test"))
+	crc := crc32.ChecksumIEEE(data)
+
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+	for i := 0; i < 2; i++ {
+		_, err = s.Write(id, int64(i*util.BlockSize), int64(len(data)), data, crc, storage.AppendWriteType, true)
+		require.NoError(t, err)
+	}
+
+	buf := make([]byte, 2*util.BlockSize)
+	readCrc, corruptBlockNo, err := s.ReadVerified(id, 0, int64(len(buf)), buf)
+	require.NoError(t, err)
+	require.Equal(t, -1, corruptBlockNo)
+	require.Equal(t, crc32.ChecksumIEEE(buf), readCrc)
+
+	// corrupt the second block, the reads covering it partially detect the corruption too
+	f, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%v", id)), os.O_RDWR, 0o666)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("xxxx"), util.BlockSize+1024)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, corruptBlockNo, err = s.ReadVerified(id, 0, util.BlockSize, buf)
+	require.NoError(t, err)
+	require.Equal(t, -1, corruptBlockNo)
+	_, corruptBlockNo, err = s.ReadVerified(id, util.BlockSize-100, 200, buf)
+	require.ErrorIs(t, err, storage.BlockCorruptError)
+	require.Equal(t, 1, corruptBlockNo)
+
+	// the plain read does not verify the data
+	_, err = s.Read(id, util.BlockSize, util.BlockSize, buf, false)
+	require.NoError(t, err)
+}
+
+func TestSpareExtents(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+
+	countSpareFiles := func() (cnt int) {
+		files, err := os.ReadDir(path)
+		require.NoError(t, err)
+		for _, f := range files {
+			if strings.HasPrefix(f.Name(), storage.SpareExtentPrefix) {
+				cnt++
+			}
+		}
+		return
+	}
+	// the stale spares are removed
+	require.NoError(t, os.WriteFile(filepath.Join(path, storage.SpareExtentPrefix+"0"), nil, 0o666))
+	s.EnableSpareExtents(4)
+	require.Equal(t, 0, countSpareFiles())
+
+	data := []byte(dataStr)
+	crc := crc32.ChecksumIEEE(data)
+	for i := 0; i < 2; i++ {
+		id, err := s.NextExtentID()
+		require.NoError(t, err)
+		require.NoError(t, s.Create(id))
+		_, err = s.Write(id, 0, int64(len(data)), data, crc, storage.AppendWriteType, true)
+		require.NoError(t, err)
+	}
+	s.RefillSpareExtents(time.Second)
+	require.Equal(t, 4, s.SpareExtentCount())
+	require.Equal(t, 4, countSpareFiles())
+
+	// a new extent takes a spare and starts empty
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+	require.Equal(t, 3, s.SpareExtentCount())
+	require.Equal(t, 3, countSpareFiles())
+	ei, err := s.Watermark(id)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, ei.Size)
+	extentStoreNormalRwTest(t, s, id)
+
+	// the spares are released after the creations stop
+	for i := 0; i < 20; i++ {
+		s.RefillSpareExtents(time.Second)
+	}
+	require.Equal(t, 0, s.SpareExtentCount())
+	require.Equal(t, 0, countSpareFiles())
+}